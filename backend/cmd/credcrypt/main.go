@@ -0,0 +1,115 @@
+// credcrypt 批量加密账号凭证中的明文敏感字段，并把旧主密钥加密的字段重新加密为当前主密钥。
+// 需先在配置中启用 security.credential_encryption；可重复执行，已是最新的行会被跳过。
+// 指定 -audit-logs 时改为处理审计日志请求/响应体（需启用 audit_log.encryption）：明文记录加密，
+// 旧主密钥版本的记录只重新包装数据密钥。
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"log"
+
+	"github.com/Wei-Shaw/sub2api/ent"
+	dbaccount "github.com/Wei-Shaw/sub2api/ent/account"
+	_ "github.com/Wei-Shaw/sub2api/ent/runtime"
+	"github.com/Wei-Shaw/sub2api/ent/schema/mixins"
+	"github.com/Wei-Shaw/sub2api/internal/config"
+	"github.com/Wei-Shaw/sub2api/internal/repository"
+	"github.com/Wei-Shaw/sub2api/internal/service"
+)
+
+func main() {
+	dryRun := flag.Bool("dry-run", false, "Report accounts that would be (re-)encrypted without writing")
+	batchSize := flag.Int("batch-size", 200, "Number of accounts to load per batch")
+	auditLogs := flag.Bool("audit-logs", false, "Encrypt/re-wrap audit log bodies instead of account credentials")
+	flag.Parse()
+
+	cfg, err := config.Load()
+	if err != nil {
+		log.Fatalf("failed to load config: %v", err)
+	}
+	if *auditLogs {
+		if !cfg.AuditLog.Enabled || !cfg.AuditLog.Encryption.Enabled {
+			log.Fatalf("audit_log.enabled and audit_log.encryption.enabled must be true")
+		}
+	} else if !cfg.Security.CredentialEncryption.Enabled {
+		log.Fatalf("security.credential_encryption.enabled must be true")
+	}
+
+	// InitEnt 同时初始化凭证加密器
+	client, sqlDB, err := repository.InitEnt(cfg)
+	if err != nil {
+		log.Fatalf("failed to init db: %v", err)
+	}
+	defer func() {
+		if err := client.Close(); err != nil {
+			log.Printf("failed to close db: %v", err)
+		}
+	}()
+
+	action := "updated"
+	if *dryRun {
+		action = "would update"
+	}
+
+	if *auditLogs {
+		auditLogService, err := service.NewAuditLogService(repository.NewAuditLogRepository(sqlDB), cfg)
+		if err != nil {
+			log.Fatalf("failed to init audit log encryption: %v", err)
+		}
+		scanned, updated, err := auditLogService.EncryptStoredBodies(context.Background(), *batchSize, *dryRun)
+		if err != nil {
+			log.Fatalf("audit log migration failed after %d records: %v", scanned, err)
+		}
+		fmt.Printf("scanned %d audit logs, %s %d\n", scanned, action, updated)
+		return
+	}
+
+	// 软删除的账号同样保存着凭证，一并处理
+	ctx := mixins.SkipSoftDelete(context.Background())
+	scanned, updated, err := migrateCredentials(ctx, client, *batchSize, *dryRun)
+	if err != nil {
+		log.Fatalf("credential migration failed after %d accounts: %v", scanned, err)
+	}
+	fmt.Printf("scanned %d accounts, %s %d\n", scanned, action, updated)
+}
+
+func migrateCredentials(ctx context.Context, client *ent.Client, batchSize int, dryRun bool) (scanned, updated int, err error) {
+	if batchSize <= 0 {
+		batchSize = 200
+	}
+	var lastID int64
+	for {
+		accounts, err := client.Account.Query().
+			Where(dbaccount.IDGT(lastID)).
+			Order(ent.Asc(dbaccount.FieldID)).
+			Limit(batchSize).
+			All(ctx)
+		if err != nil {
+			return scanned, updated, fmt.Errorf("load accounts: %w", err)
+		}
+		if len(accounts) == 0 {
+			return scanned, updated, nil
+		}
+		for _, a := range accounts {
+			lastID = a.ID
+			scanned++
+			credentials, changed, err := service.ReencryptAccountCredentials(a.Credentials)
+			if err != nil {
+				return scanned, updated, fmt.Errorf("account %d: %w", a.ID, err)
+			}
+			if !changed {
+				continue
+			}
+			updated++
+			if dryRun {
+				log.Printf("account %d (%s) needs encryption", a.ID, a.Name)
+				continue
+			}
+			if err := client.Account.UpdateOneID(a.ID).SetCredentials(credentials).Exec(ctx); err != nil {
+				return scanned, updated, fmt.Errorf("update account %d: %w", a.ID, err)
+			}
+		}
+	}
+}
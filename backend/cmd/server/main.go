@@ -7,19 +7,26 @@ import (
 	_ "embed"
 	"errors"
 	"flag"
+	"io"
 	"log"
 	"log/slog"
 	"net/http"
 	"os"
 	"os/signal"
 	"strings"
+	"sync"
+	"sync/atomic"
 	"syscall"
 	"time"
 
 	_ "github.com/Wei-Shaw/sub2api/ent/runtime"
 	"github.com/Wei-Shaw/sub2api/internal/config"
 	"github.com/Wei-Shaw/sub2api/internal/handler"
+	"github.com/Wei-Shaw/sub2api/internal/pkg/logger"
+	"github.com/Wei-Shaw/sub2api/internal/pkg/tracing"
+	"github.com/Wei-Shaw/sub2api/internal/server"
 	"github.com/Wei-Shaw/sub2api/internal/server/middleware"
+	"github.com/Wei-Shaw/sub2api/internal/service"
 	"github.com/Wei-Shaw/sub2api/internal/setup"
 	"github.com/Wei-Shaw/sub2api/internal/web"
 
@@ -45,24 +52,23 @@ func init() {
 	}
 }
 
-// initLogger configures the default slog handler based on gin.Mode().
-// In non-release mode, Debug level logs are enabled.
-func initLogger() {
-	var level slog.Level
+// initLogger configures the default slog handler writing to w. The level defaults to
+// Debug outside gin release mode; format and level can be overridden by the
+// log section of the config once it is loaded.
+func initLogger(w io.Writer, format, level string) {
+	defaultLevel := slog.LevelDebug
 	if gin.Mode() == gin.ReleaseMode {
-		level = slog.LevelInfo
-	} else {
-		level = slog.LevelDebug
+		defaultLevel = slog.LevelInfo
 	}
-	handler := slog.NewTextHandler(os.Stderr, &slog.HandlerOptions{
-		Level: level,
+	logger.Init(w, logger.Options{
+		Format: format,
+		Level:  logger.ParseLevel(level, defaultLevel),
 	})
-	slog.SetDefault(slog.New(handler))
 }
 
 func main() {
 	// Initialize slog logger based on gin mode
-	initLogger()
+	initLogger(os.Stderr, logger.FormatText, "")
 
 	// Parse command line flags
 	setupMode := flag.Bool("setup", false, "Run setup wizard in CLI mode")
@@ -113,7 +119,7 @@ func runSetupServer() {
 
 	// Serve embedded frontend if available
 	if web.HasEmbeddedFrontend() {
-		r.Use(web.ServeEmbeddedFrontend())
+		r.Use(web.ServeEmbeddedFrontend(web.FrontendOptions{}))
 	}
 
 	// Get server address from config.yaml or environment variables (SERVER_HOST, SERVER_PORT)
@@ -127,15 +133,53 @@ func runSetupServer() {
 	}
 }
 
+const (
+	// shutdownAbortGracePeriod 排空超时取消请求后，等待处理器退出的时间
+	shutdownAbortGracePeriod = 5 * time.Second
+	// shutdownUsageFlushTimeout 等待异步用量记录写入的最长时间
+	shutdownUsageFlushTimeout = 10 * time.Second
+)
+
 func runMainServer() {
 	cfg, err := config.Load()
 	if err != nil {
 		log.Fatalf("Failed to load config: %v", err)
 	}
+	redactor, err := service.InitPIIRedaction(cfg)
+	if err != nil {
+		log.Fatalf("Failed to initialize PII redaction: %v", err)
+	}
+	var logOutput io.Writer = os.Stderr
+	if cfg.Security.PIIRedaction.RedactLogs {
+		logOutput = redactor.Writer(os.Stderr)
+	}
+	initLogger(logOutput, cfg.Log.Format, cfg.Log.Level)
 	if cfg.RunMode == config.RunModeSimple {
 		log.Println("⚠️  WARNING: Running in SIMPLE mode - billing and quota checks are DISABLED")
 	}
 
+	if cfg.Tracing.Enabled {
+		shutdownTracing, err := tracing.Init(context.Background(), tracing.Options{
+			Endpoint:          cfg.Tracing.Endpoint,
+			Headers:           cfg.Tracing.Headers,
+			ServiceName:       cfg.Tracing.ServiceName,
+			ServiceVersion:    Version,
+			SampleRatio:       cfg.Tracing.SampleRatio,
+			PropagateUpstream: cfg.Tracing.PropagateUpstream,
+		})
+		if err != nil {
+			log.Fatalf("Failed to initialize tracing: %v", err)
+		}
+		defer func() {
+			ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+			defer cancel()
+			if err := shutdownTracing(ctx); err != nil {
+				log.Printf("Tracing shutdown error: %v", err)
+			}
+		}()
+		log.Printf("Tracing enabled, exporting to %s", cfg.Tracing.Endpoint)
+	}
+
 	buildInfo := handler.BuildInfo{
 		Version:   Version,
 		BuildType: BuildType,
@@ -147,15 +191,28 @@ func runMainServer() {
 	}
 	defer app.Cleanup()
 
-	// 启动服务器
+	// 启动服务器（每个监听器独立运行）
+	for _, l := range app.Servers {
+		go func(l *server.Listener) {
+			if err := l.ListenAndServe(); err != nil && !errors.Is(err, http.ErrServerClosed) {
+				log.Fatalf("Failed to start listener %s: %v", l.Name, err)
+			}
+		}(l)
+		log.Printf("Server started on %s %s (%s)", l.Network, l.Address, l.Name)
+	}
+
+	// SIGHUP 触发配置热重载（不中断进行中的请求）
+	hup := make(chan os.Signal, 1)
+	signal.Notify(hup, syscall.SIGHUP)
 	go func() {
-		if err := app.Server.ListenAndServe(); err != nil && !errors.Is(err, http.ErrServerClosed) {
-			log.Fatalf("Failed to start server: %v", err)
+		for range hup {
+			log.Println("Received SIGHUP, reloading config...")
+			if _, err := app.ConfigReloader.Reload(context.Background()); err != nil {
+				log.Printf("Config reload failed, keeping current config: %v", err)
+			}
 		}
 	}()
 
-	log.Printf("Server started on %s", app.Server.Addr)
-
 	// 等待中断信号
 	quit := make(chan os.Signal, 1)
 	signal.Notify(quit, syscall.SIGINT, syscall.SIGTERM)
@@ -163,12 +220,47 @@ func runMainServer() {
 
 	log.Println("Shutting down server...")
 
-	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
-	defer cancel()
+	// 1. 进入排空：健康检查返回 503，监听器停止接收新连接，进行中的请求（含流式）继续完成
+	app.Shutdown.BeginDrain()
+	drainTimeout := time.Duration(cfg.Server.ShutdownTimeout) * time.Second
+	if !shutdownListeners(app.Servers, drainTimeout) {
+		// 2. 排空超时：取消剩余请求，给处理器留出时间结束流并释放并发槽位
+		log.Printf("In-flight requests did not finish within %s, cancelling them", drainTimeout)
+		app.Shutdown.AbortInFlight()
+		if !shutdownListeners(app.Servers, shutdownAbortGracePeriod) {
+			for _, l := range app.Servers {
+				_ = l.Server.Close()
+			}
+		}
+	}
 
-	if err := app.Server.Shutdown(ctx); err != nil {
-		log.Fatalf("Server forced to shutdown: %v", err)
+	// 3. 等待异步用量记录写入完成（失败的记录已转入重试队列）
+	ctx, cancel := context.WithTimeout(context.Background(), shutdownUsageFlushTimeout)
+	defer cancel()
+	if !app.Shutdown.WaitTasks(ctx) {
+		log.Printf("Warning: %d usage records still pending at shutdown", app.Shutdown.PendingTasks())
 	}
 
 	log.Println("Server exited")
 }
+
+// shutdownListeners 并行关闭所有监听器并等待进行中的请求完成；全部在 timeout 内完成时返回 true
+func shutdownListeners(listeners []*server.Listener, timeout time.Duration) bool {
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+
+	var wg sync.WaitGroup
+	var forced atomic.Bool
+	for _, l := range listeners {
+		wg.Add(1)
+		go func(l *server.Listener) {
+			defer wg.Done()
+			if err := l.Server.Shutdown(ctx); err != nil {
+				forced.Store(true)
+				log.Printf("Listener %s shutdown incomplete: %v", l.Name, err)
+			}
+		}(l)
+	}
+	wg.Wait()
+	return !forced.Load()
+}
@@ -6,7 +6,6 @@ package main
 import (
 	"context"
 	"log"
-	"net/http"
 	"time"
 
 	"github.com/Wei-Shaw/sub2api/ent"
@@ -22,8 +21,10 @@ import (
 )
 
 type Application struct {
-	Server  *http.Server
-	Cleanup func()
+	Servers        []*server.Listener
+	Cleanup        func()
+	ConfigReloader *service.ConfigReloadService
+	Shutdown       *service.ShutdownCoordinator
 }
 
 func initializeApplication(buildInfo handler.BuildInfo) (*Application, error) {
@@ -47,7 +48,7 @@ func initializeApplication(buildInfo handler.BuildInfo) (*Application, error) {
 		provideCleanup,
 
 		// Application struct
-		wire.Struct(new(Application), "Server", "Cleanup"),
+		wire.Struct(new(Application), "Servers", "Cleanup", "ConfigReloader", "Shutdown"),
 	)
 	return nil, nil
 }
@@ -67,14 +68,26 @@ func provideCleanup(
 	opsAlertEvaluator *service.OpsAlertEvaluatorService,
 	opsCleanup *service.OpsCleanupService,
 	opsScheduledReport *service.OpsScheduledReportService,
+	opsEventExporter *service.OpsEventExporterGroup,
+	opsRequestPhases *service.OpsRequestPhaseService,
+	usageWebhookDispatcher *service.UsageWebhookDispatcher,
+	auditLogService *service.AuditLogService,
+	trashService *service.TrashService,
+	budgetAlertService *service.BudgetAlertService,
+	usageRetryService *service.UsageRetryService,
+	regionReplicator *repository.RegionReplicator,
 	schedulerSnapshot *service.SchedulerSnapshotService,
 	tokenRefresh *service.TokenRefreshService,
 	accountExpiry *service.AccountExpiryService,
+	stripeBilling *service.StripeBillingService,
+	accountCanary *service.AccountCanaryService,
+	modelDiscovery *service.AccountModelDiscoveryService,
 	subscriptionExpiry *service.SubscriptionExpiryService,
 	usageCleanup *service.UsageCleanupService,
 	pricing *service.PricingService,
 	emailQueue *service.EmailQueueService,
 	billingCache *service.BillingCacheService,
+	concurrency *service.ConcurrencyService,
 	oauth *service.OAuthService,
 	openaiOAuth *service.OpenAIOAuthService,
 	geminiOAuth *service.GeminiOAuthService,
@@ -139,6 +152,18 @@ func provideCleanup(
 				accountExpiry.Stop()
 				return nil
 			}},
+			{"StripeBillingService", func() error {
+				stripeBilling.Stop()
+				return nil
+			}},
+			{"AccountCanaryService", func() error {
+				accountCanary.Stop()
+				return nil
+			}},
+			{"AccountModelDiscoveryService", func() error {
+				modelDiscovery.Stop()
+				return nil
+			}},
 			{"SubscriptionExpiryService", func() error {
 				subscriptionExpiry.Stop()
 				return nil
@@ -155,6 +180,15 @@ func provideCleanup(
 				billingCache.Stop()
 				return nil
 			}},
+			{"ConcurrencyService", func() error {
+				// 释放本实例仍持有的槽位（排空超时被取消的请求可能未及时释放）
+				released, err := concurrency.ReleaseOwnedSlots(ctx)
+				if released > 0 {
+					log.Printf("[Cleanup] Released %d concurrency slots held by this instance", released)
+				}
+				concurrency.Stop()
+				return err
+			}},
 			{"OAuthService", func() error {
 				oauth.Stop()
 				return nil
@@ -171,6 +205,38 @@ func provideCleanup(
 				antigravityOAuth.Stop()
 				return nil
 			}},
+			{"OpsEventExporter", func() error {
+				opsEventExporter.Stop()
+				return nil
+			}},
+			{"OpsRequestPhaseService", func() error {
+				opsRequestPhases.Stop()
+				return nil
+			}},
+			{"UsageWebhookDispatcher", func() error {
+				usageWebhookDispatcher.Stop()
+				return nil
+			}},
+			{"AuditLogService", func() error {
+				auditLogService.Stop()
+				return nil
+			}},
+			{"TrashService", func() error {
+				trashService.Stop()
+				return nil
+			}},
+			{"BudgetAlertService", func() error {
+				budgetAlertService.Stop()
+				return nil
+			}},
+			{"UsageRetryService", func() error {
+				usageRetryService.Stop()
+				return nil
+			}},
+			{"RegionReplicator", func() error {
+				regionReplicator.Stop()
+				return nil
+			}},
 			{"Redis", func() error {
 				return rdb.Close()
 			}},
@@ -18,7 +18,6 @@ import (
 	"github.com/Wei-Shaw/sub2api/internal/service"
 	"github.com/redis/go-redis/v9"
 	"log"
-	"net/http"
 	"time"
 )
 
@@ -56,7 +55,15 @@ func initializeApplication(buildInfo handler.BuildInfo) (*Application, error) {
 	promoCodeRepository := repository.NewPromoCodeRepository(client)
 	billingCache := repository.NewBillingCache(redisClient)
 	userSubscriptionRepository := repository.NewUserSubscriptionRepository(client)
-	billingCacheService := service.NewBillingCacheService(billingCache, userRepository, userSubscriptionRepository, configConfig)
+	spendCapRepository := repository.NewSpendCapRepository(db)
+	spendCapCache := repository.NewSpendCapCache(redisClient)
+	budgetAlertCache := repository.NewBudgetAlertCache(redisClient)
+	usageWebhookSender := repository.NewUsageWebhookSender(configConfig)
+	budgetAlertService := service.ProvideBudgetAlertService(settingService, budgetAlertCache, usageWebhookSender, emailService)
+	spendCapService := service.NewSpendCapService(spendCapRepository, spendCapCache, budgetAlertService, configConfig)
+	quotaRolloverRepository := repository.NewQuotaRolloverRepository(db)
+	quotaRolloverService := service.NewQuotaRolloverService(quotaRolloverRepository, configConfig)
+	billingCacheService := service.NewBillingCacheService(billingCache, userRepository, userSubscriptionRepository, configConfig, spendCapService, quotaRolloverService)
 	apiKeyRepository := repository.NewAPIKeyRepository(client)
 	groupRepository := repository.NewGroupRepository(client, db)
 	userGroupRateRepository := repository.NewUserGroupRateRepository(db)
@@ -77,9 +84,21 @@ func initializeApplication(buildInfo handler.BuildInfo) (*Application, error) {
 	totpService := service.NewTotpService(userRepository, secretEncryptor, totpCache, settingService, emailService, emailQueueService)
 	authHandler := handler.NewAuthHandler(configConfig, authService, userService, settingService, promoService, redeemService, totpService)
 	userHandler := handler.NewUserHandler(userService)
-	apiKeyHandler := handler.NewAPIKeyHandler(apiKeyService)
-	usageLogRepository := repository.NewUsageLogRepository(client, db)
-	usageService := service.NewUsageService(usageLogRepository, userRepository, client, apiKeyAuthCacheInvalidator)
+	apiKeyHandler := handler.NewAPIKeyHandler(apiKeyService, billingCacheService)
+	opsEventWriter := repository.ProvideClickHouseOpsEventWriter(configConfig)
+	opsLogShippers := repository.ProvideOpsLogShippers(configConfig)
+	opsEventExporterGroup := service.ProvideOpsEventExporter(opsEventWriter, opsLogShippers, configConfig)
+	usageWebhookDispatcher := service.ProvideUsageWebhookDispatcher(usageWebhookSender, configConfig)
+	usageLogRepository := repository.ProvideUsageLogRepository(client, db, opsEventExporterGroup, usageWebhookDispatcher)
+	pricingRemoteClient := repository.ProvidePricingRemoteClient(configConfig)
+	pricingService, err := service.ProvidePricingService(configConfig, pricingRemoteClient)
+	if err != nil {
+		return nil, err
+	}
+	modelPriceRepository := repository.NewModelPriceRepository(db)
+	modelPriceService := service.NewModelPriceService(modelPriceRepository)
+	billingService := service.NewBillingService(configConfig, pricingService, modelPriceService)
+	usageService := service.NewUsageService(usageLogRepository, userRepository, client, apiKeyAuthCacheInvalidator, billingService)
 	usageHandler := handler.NewUsageHandler(usageService, apiKeyService)
 	redeemHandler := handler.NewRedeemHandler(redeemService)
 	subscriptionHandler := handler.NewSubscriptionHandler(subscriptionService)
@@ -104,7 +123,7 @@ func initializeApplication(buildInfo handler.BuildInfo) (*Application, error) {
 	adminService := service.NewAdminService(userRepository, groupRepository, accountRepository, proxyRepository, apiKeyRepository, redeemCodeRepository, userGroupRateRepository, billingCacheService, proxyExitInfoProber, proxyLatencyCache, apiKeyAuthCacheInvalidator)
 	concurrencyCache := repository.ProvideConcurrencyCache(redisClient, configConfig)
 	concurrencyService := service.ProvideConcurrencyService(concurrencyCache, accountRepository, configConfig)
-	adminUserHandler := admin.NewUserHandler(adminService, concurrencyService)
+	adminUserHandler := admin.NewUserHandler(adminService, concurrencyService, apiKeyService)
 	groupHandler := admin.NewGroupHandler(adminService)
 	claudeOAuthClient := repository.NewClaudeOAuthClient()
 	oAuthService := service.NewOAuthService(proxyRepository, claudeOAuthClient)
@@ -115,7 +134,8 @@ func initializeApplication(buildInfo handler.BuildInfo) (*Application, error) {
 	geminiOAuthService := service.NewGeminiOAuthService(proxyRepository, geminiOAuthClient, geminiCliCodeAssistClient, configConfig)
 	antigravityOAuthService := service.NewAntigravityOAuthService(proxyRepository)
 	geminiQuotaService := service.NewGeminiQuotaService(configConfig, settingRepository)
-	tempUnschedCache := repository.NewTempUnschedCache(redisClient)
+	regionReplicator := repository.ProvideRegionReplicator(configConfig)
+	tempUnschedCache := repository.ProvideTempUnschedCache(redisClient, regionReplicator)
 	timeoutCounterCache := repository.NewTimeoutCounterCache(redisClient)
 	geminiTokenCache := repository.NewGeminiTokenCache(redisClient)
 	compositeTokenCacheInvalidator := service.NewCompositeTokenCacheInvalidator(geminiTokenCache)
@@ -127,15 +147,20 @@ func initializeApplication(buildInfo handler.BuildInfo) (*Application, error) {
 	identityCache := repository.NewIdentityCache(redisClient)
 	accountUsageService := service.NewAccountUsageService(accountRepository, usageLogRepository, claudeUsageFetcher, geminiQuotaService, antigravityQuotaFetcher, usageCache, identityCache)
 	geminiTokenProvider := service.NewGeminiTokenProvider(accountRepository, geminiTokenCache, geminiOAuthService)
-	gatewayCache := repository.NewGatewayCache(redisClient)
+	gatewayCache := repository.ProvideGatewayCache(redisClient, regionReplicator)
 	schedulerOutboxRepository := repository.NewSchedulerOutboxRepository(db)
-	schedulerSnapshotService := service.ProvideSchedulerSnapshotService(schedulerCache, schedulerOutboxRepository, accountRepository, groupRepository, configConfig)
+	accountSessionCleanupService := service.NewAccountSessionCleanupService(gatewayCache, concurrencyService, accountRepository, configConfig)
+	schedulerSnapshotService := service.ProvideSchedulerSnapshotService(schedulerCache, schedulerOutboxRepository, accountRepository, groupRepository, accountSessionCleanupService, configConfig)
 	antigravityTokenProvider := service.NewAntigravityTokenProvider(accountRepository, geminiTokenCache, antigravityOAuthService)
-	antigravityGatewayService := service.NewAntigravityGatewayService(accountRepository, gatewayCache, schedulerSnapshotService, antigravityTokenProvider, rateLimitService, httpUpstream, settingService)
+	memoryGuard := service.NewMemoryGuard(configConfig)
+	antigravityGatewayService := service.NewAntigravityGatewayService(accountRepository, gatewayCache, schedulerSnapshotService, antigravityTokenProvider, rateLimitService, httpUpstream, settingService, memoryGuard)
 	accountTestService := service.NewAccountTestService(accountRepository, geminiTokenProvider, antigravityGatewayService, httpUpstream, configConfig)
 	crsSyncService := service.NewCRSSyncService(accountRepository, proxyRepository, oAuthService, openAIOAuthService, geminiOAuthService, configConfig)
 	sessionLimitCache := repository.ProvideSessionLimitCache(redisClient, configConfig)
-	accountHandler := admin.NewAccountHandler(adminService, oAuthService, openAIOAuthService, geminiOAuthService, antigravityOAuthService, rateLimitService, accountUsageService, accountTestService, concurrencyService, crsSyncService, sessionLimitCache, compositeTokenCacheInvalidator)
+	upstreamMetadataCache := repository.NewUpstreamMetadataCache(redisClient)
+	accountModelDiscoveryService := service.ProvideAccountModelDiscoveryService(accountRepository, httpUpstream, upstreamMetadataCache, configConfig)
+	accountCredentialRotationService := service.NewAccountCredentialRotationService(accountRepository, accountTestService, geminiTokenCache, compositeTokenCacheInvalidator)
+	accountHandler := admin.NewAccountHandler(adminService, oAuthService, openAIOAuthService, geminiOAuthService, antigravityOAuthService, rateLimitService, accountUsageService, accountTestService, concurrencyService, crsSyncService, sessionLimitCache, compositeTokenCacheInvalidator, accountModelDiscoveryService, accountCredentialRotationService)
 	adminAnnouncementHandler := admin.NewAnnouncementHandler(announcementService)
 	oAuthHandler := admin.NewOAuthHandler(oAuthService)
 	openAIOAuthHandler := admin.NewOpenAIOAuthHandler(openAIOAuthService, adminService)
@@ -144,29 +169,31 @@ func initializeApplication(buildInfo handler.BuildInfo) (*Application, error) {
 	proxyHandler := admin.NewProxyHandler(adminService)
 	adminRedeemHandler := admin.NewRedeemHandler(adminService)
 	promoHandler := admin.NewPromoHandler(promoService)
-	opsRepository := repository.NewOpsRepository(db)
-	pricingRemoteClient := repository.ProvidePricingRemoteClient(configConfig)
-	pricingService, err := service.ProvidePricingService(configConfig, pricingRemoteClient)
-	if err != nil {
-		return nil, err
-	}
-	billingService := service.NewBillingService(configConfig, pricingService)
+	opsRepository := repository.ProvideOpsRepository(db, opsEventExporterGroup)
 	identityService := service.NewIdentityService(identityCache)
 	deferredService := service.ProvideDeferredService(accountRepository, timingWheelService)
 	claudeTokenProvider := service.NewClaudeTokenProvider(accountRepository, geminiTokenCache, oAuthService)
 	digestSessionStore := service.NewDigestSessionStore()
-	gatewayService := service.NewGatewayService(accountRepository, groupRepository, usageLogRepository, userRepository, userSubscriptionRepository, userGroupRateRepository, gatewayCache, configConfig, schedulerSnapshotService, concurrencyService, billingService, rateLimitService, billingCacheService, identityService, httpUpstream, deferredService, claudeTokenProvider, sessionLimitCache, digestSessionStore)
+	usageRetryQueue := repository.NewUsageRetryQueue(redisClient, configConfig)
+	usageRetryService := service.ProvideUsageRetryService(usageRetryQueue, usageLogRepository, userRepository, userSubscriptionRepository, apiKeyService, configConfig)
+	gatewayService := service.NewGatewayService(accountRepository, groupRepository, usageLogRepository, userRepository, userSubscriptionRepository, userGroupRateRepository, gatewayCache, configConfig, schedulerSnapshotService, concurrencyService, billingService, rateLimitService, billingCacheService, identityService, httpUpstream, deferredService, claudeTokenProvider, sessionLimitCache, digestSessionStore, budgetAlertService, usageRetryService, memoryGuard)
 	openAITokenProvider := service.NewOpenAITokenProvider(accountRepository, geminiTokenCache, openAIOAuthService)
-	openAIGatewayService := service.NewOpenAIGatewayService(accountRepository, usageLogRepository, userRepository, userSubscriptionRepository, gatewayCache, configConfig, schedulerSnapshotService, concurrencyService, billingService, rateLimitService, billingCacheService, httpUpstream, deferredService, openAITokenProvider)
-	geminiMessagesCompatService := service.NewGeminiMessagesCompatService(accountRepository, groupRepository, gatewayCache, schedulerSnapshotService, geminiTokenProvider, rateLimitService, httpUpstream, antigravityGatewayService, configConfig)
-	opsService := service.NewOpsService(opsRepository, settingRepository, configConfig, accountRepository, userRepository, concurrencyService, gatewayService, openAIGatewayService, geminiMessagesCompatService, antigravityGatewayService)
+	openAIResponseContextCache := repository.NewOpenAIResponseContextCache(redisClient)
+	serviceBuildInfo := provideServiceBuildInfo(buildInfo)
+	openAIGatewayService := service.NewOpenAIGatewayService(accountRepository, usageLogRepository, userRepository, userSubscriptionRepository, gatewayCache, configConfig, schedulerSnapshotService, concurrencyService, billingService, rateLimitService, billingCacheService, httpUpstream, deferredService, openAITokenProvider, budgetAlertService, usageRetryService, memoryGuard, openAIResponseContextCache, serviceBuildInfo)
+	geminiMessagesCompatService := service.NewGeminiMessagesCompatService(accountRepository, groupRepository, gatewayCache, schedulerSnapshotService, geminiTokenProvider, rateLimitService, httpUpstream, antigravityGatewayService, upstreamMetadataCache, configConfig)
+	streamAbuseCache := repository.NewStreamAbuseCache(redisClient)
+	streamAbuseService := service.NewStreamAbuseService(configConfig, streamAbuseCache)
+	usageAnomalyCache := repository.NewUsageAnomalyCache(redisClient)
+	usageAnomalyService := service.NewUsageAnomalyService(configConfig, usageAnomalyCache, apiKeyService)
+	opsService := service.NewOpsService(opsRepository, settingRepository, configConfig, accountRepository, userRepository, concurrencyService, gatewayService, openAIGatewayService, geminiMessagesCompatService, antigravityGatewayService, streamAbuseService, usageAnomalyService)
 	settingHandler := admin.NewSettingHandler(settingService, emailService, turnstileService, opsService)
 	opsHandler := admin.NewOpsHandler(opsService)
 	updateCache := repository.NewUpdateCache(redisClient)
 	gitHubReleaseClient := repository.ProvideGitHubReleaseClient(configConfig)
-	serviceBuildInfo := provideServiceBuildInfo(buildInfo)
 	updateService := service.ProvideUpdateService(updateCache, gitHubReleaseClient, serviceBuildInfo)
-	systemHandler := handler.ProvideSystemHandler(updateService)
+	configReloadService := service.NewConfigReloadService(configConfig)
+	systemHandler := handler.ProvideSystemHandler(updateService, configReloadService)
 	adminSubscriptionHandler := admin.NewSubscriptionHandler(subscriptionService)
 	usageCleanupRepository := repository.NewUsageCleanupRepository(client, db)
 	usageCleanupService := service.ProvideUsageCleanupService(usageCleanupRepository, timingWheelService, dashboardAggregationService, configConfig)
@@ -179,29 +206,72 @@ func initializeApplication(buildInfo handler.BuildInfo) (*Application, error) {
 	errorPassthroughCache := repository.NewErrorPassthroughCache(redisClient)
 	errorPassthroughService := service.NewErrorPassthroughService(errorPassthroughRepository, errorPassthroughCache)
 	errorPassthroughHandler := admin.NewErrorPassthroughHandler(errorPassthroughService)
-	adminHandlers := handler.ProvideAdminHandlers(dashboardHandler, adminUserHandler, groupHandler, accountHandler, adminAnnouncementHandler, oAuthHandler, openAIOAuthHandler, geminiOAuthHandler, antigravityOAuthHandler, proxyHandler, adminRedeemHandler, promoHandler, settingHandler, opsHandler, systemHandler, adminSubscriptionHandler, adminUsageHandler, userAttributeHandler, errorPassthroughHandler)
-	gatewayHandler := handler.NewGatewayHandler(gatewayService, geminiMessagesCompatService, antigravityGatewayService, userService, concurrencyService, billingCacheService, usageService, apiKeyService, errorPassthroughService, configConfig)
-	openAIGatewayHandler := handler.NewOpenAIGatewayHandler(openAIGatewayService, concurrencyService, billingCacheService, apiKeyService, errorPassthroughService, configConfig)
+	modelPriceHandler := admin.NewModelPriceHandler(modelPriceService)
+	spendCapHandler := admin.NewSpendCapHandler(spendCapService)
+	auditLogRepository := repository.NewAuditLogRepository(db)
+	auditLogService, err := service.ProvideAuditLogService(auditLogRepository, configConfig)
+	if err != nil {
+		return nil, err
+	}
+	auditLogHandler := admin.NewAuditLogHandler(auditLogService)
+	trashRepository := repository.NewTrashRepository(db)
+	trashService := service.ProvideTrashService(trashRepository, apiKeyAuthCacheInvalidator, configConfig)
+	trashHandler := admin.NewTrashHandler(trashService)
+	adminActionLogRepository := repository.NewAdminActionLogRepository(db)
+	adminActionLogService := service.NewAdminActionLogService(adminActionLogRepository)
+	adminActionLogHandler := admin.NewAdminActionLogHandler(adminActionLogService, adminService, errorPassthroughService)
+	ipBanCache := repository.NewIPBanCache(redisClient)
+	ipBanService := service.NewIPBanService(configConfig, ipBanCache)
+	ipBanHandler := admin.NewIPBanHandler(ipBanService)
+	adminHandlers := handler.ProvideAdminHandlers(dashboardHandler, adminUserHandler, groupHandler, accountHandler, adminAnnouncementHandler, oAuthHandler, openAIOAuthHandler, geminiOAuthHandler, antigravityOAuthHandler, proxyHandler, adminRedeemHandler, promoHandler, settingHandler, opsHandler, systemHandler, adminSubscriptionHandler, adminUsageHandler, userAttributeHandler, errorPassthroughHandler, modelPriceHandler, spendCapHandler, auditLogHandler, trashHandler, adminActionLogHandler, ipBanHandler)
+	modelAliasService := service.NewModelAliasService(settingService)
+	virtualModelService := service.NewVirtualModelService(settingService)
+	requestStripService := service.NewRequestStripService(settingService)
+	requestSanitizeService := service.NewRequestSanitizeService(settingService)
+	responseCache := repository.NewResponseCache(redisClient)
+	responseCacheService := service.NewResponseCacheService(configConfig, responseCache, usageLogRepository)
+	tokenCounterService := service.NewTokenCounterService(configConfig)
+	upstreamErrorMappingService := service.NewUpstreamErrorMappingService(settingService)
+	shutdownCoordinator := service.NewShutdownCoordinator()
+	gatewayHandler := handler.NewGatewayHandler(gatewayService, geminiMessagesCompatService, antigravityGatewayService, userService, concurrencyService, billingCacheService, usageService, apiKeyService, errorPassthroughService, modelAliasService, virtualModelService, requestStripService, requestSanitizeService, streamAbuseService, responseCacheService, tokenCounterService, upstreamErrorMappingService, shutdownCoordinator, configConfig)
+	openAIGatewayHandler := handler.NewOpenAIGatewayHandler(openAIGatewayService, concurrencyService, billingCacheService, apiKeyService, errorPassthroughService, modelAliasService, virtualModelService, requestStripService, requestSanitizeService, streamAbuseService, responseCacheService, tokenCounterService, upstreamErrorMappingService, shutdownCoordinator, configConfig)
 	handlerSettingHandler := handler.ProvideSettingHandler(settingService, buildInfo)
 	totpHandler := handler.NewTotpHandler(totpService)
-	handlers := handler.ProvideHandlers(authHandler, userHandler, apiKeyHandler, usageHandler, redeemHandler, subscriptionHandler, announcementHandler, adminHandlers, gatewayHandler, openAIGatewayHandler, handlerSettingHandler, totpHandler)
+	scalingSignalService := service.NewScalingSignalService(accountRepository, concurrencyService)
+	scalingHandler := handler.NewScalingHandler(scalingSignalService)
+	healthHandler := handler.NewHealthHandler(shutdownCoordinator)
+	stripeRepository := repository.NewStripeRepository(db)
+	stripeClient := repository.NewStripeClient(configConfig)
+	stripeBillingService := service.ProvideStripeBillingService(configConfig, stripeRepository, stripeClient, subscriptionService, userRepository, usageLogRepository, apiKeyAuthCacheInvalidator)
+	stripeHandler := handler.NewStripeHandler(stripeBillingService)
+	openAPIHandler := handler.ProvideOpenAPIHandler(buildInfo)
+	gatewayMetricsService := service.NewGatewayMetricsService(configConfig, accountRepository, concurrencyService)
+	metricsHandler := handler.NewMetricsHandler(gatewayMetricsService)
+	handlers := handler.ProvideHandlers(authHandler, userHandler, apiKeyHandler, usageHandler, redeemHandler, subscriptionHandler, announcementHandler, adminHandlers, gatewayHandler, openAIGatewayHandler, handlerSettingHandler, totpHandler, scalingHandler, healthHandler, stripeHandler, openAPIHandler, metricsHandler, configReloadService)
 	jwtAuthMiddleware := middleware.NewJWTAuthMiddleware(authService, userService)
 	adminAuthMiddleware := middleware.NewAdminAuthMiddleware(authService, userService, settingService)
 	apiKeyAuthMiddleware := middleware.NewAPIKeyAuthMiddleware(apiKeyService, subscriptionService, configConfig)
-	engine := server.ProvideRouter(configConfig, handlers, jwtAuthMiddleware, adminAuthMiddleware, apiKeyAuthMiddleware, apiKeyService, subscriptionService, opsService, settingService, redisClient)
-	httpServer := server.ProvideHTTPServer(configConfig, engine)
+	routerFactory := server.ProvideRouterFactory(configConfig, handlers, jwtAuthMiddleware, adminAuthMiddleware, apiKeyAuthMiddleware, apiKeyService, subscriptionService, opsService, auditLogService, ipBanService, settingService, redisClient)
+	v, err := server.ProvideHTTPServers(configConfig, routerFactory, shutdownCoordinator)
+	if err != nil {
+		return nil, err
+	}
 	opsMetricsCollector := service.ProvideOpsMetricsCollector(opsRepository, settingRepository, accountRepository, concurrencyService, db, redisClient, configConfig)
 	opsAggregationService := service.ProvideOpsAggregationService(opsRepository, settingRepository, db, redisClient, configConfig)
 	opsAlertEvaluatorService := service.ProvideOpsAlertEvaluatorService(opsService, opsRepository, emailService, redisClient, configConfig)
 	opsCleanupService := service.ProvideOpsCleanupService(opsRepository, db, redisClient, configConfig)
 	opsScheduledReportService := service.ProvideOpsScheduledReportService(opsService, userService, emailService, redisClient, configConfig)
+	opsRequestPhaseService := service.ProvideOpsRequestPhaseService(opsRepository, opsService)
 	tokenRefreshService := service.ProvideTokenRefreshService(accountRepository, oAuthService, openAIOAuthService, geminiOAuthService, antigravityOAuthService, compositeTokenCacheInvalidator, schedulerCache, configConfig)
 	accountExpiryService := service.ProvideAccountExpiryService(accountRepository)
+	accountCanaryService := service.ProvideAccountCanaryService(accountRepository, usageLogRepository, opsRepository)
 	subscriptionExpiryService := service.ProvideSubscriptionExpiryService(userSubscriptionRepository)
-	v := provideCleanup(client, redisClient, opsMetricsCollector, opsAggregationService, opsAlertEvaluatorService, opsCleanupService, opsScheduledReportService, schedulerSnapshotService, tokenRefreshService, accountExpiryService, subscriptionExpiryService, usageCleanupService, pricingService, emailQueueService, billingCacheService, oAuthService, openAIOAuthService, geminiOAuthService, antigravityOAuthService)
+	v2 := provideCleanup(client, redisClient, opsMetricsCollector, opsAggregationService, opsAlertEvaluatorService, opsCleanupService, opsScheduledReportService, opsEventExporterGroup, opsRequestPhaseService, usageWebhookDispatcher, auditLogService, trashService, budgetAlertService, usageRetryService, regionReplicator, schedulerSnapshotService, tokenRefreshService, accountExpiryService, stripeBillingService, accountCanaryService, accountModelDiscoveryService, subscriptionExpiryService, usageCleanupService, pricingService, emailQueueService, billingCacheService, concurrencyService, oAuthService, openAIOAuthService, geminiOAuthService, antigravityOAuthService)
 	application := &Application{
-		Server:  httpServer,
-		Cleanup: v,
+		Servers:        v,
+		Cleanup:        v2,
+		ConfigReloader: configReloadService,
+		Shutdown:       shutdownCoordinator,
 	}
 	return application, nil
 }
@@ -209,8 +279,10 @@ func initializeApplication(buildInfo handler.BuildInfo) (*Application, error) {
 // wire.go:
 
 type Application struct {
-	Server  *http.Server
-	Cleanup func()
+	Servers        []*server.Listener
+	Cleanup        func()
+	ConfigReloader *service.ConfigReloadService
+	Shutdown       *service.ShutdownCoordinator
 }
 
 func provideServiceBuildInfo(buildInfo handler.BuildInfo) service.BuildInfo {
@@ -228,14 +300,26 @@ func provideCleanup(
 	opsAlertEvaluator *service.OpsAlertEvaluatorService,
 	opsCleanup *service.OpsCleanupService,
 	opsScheduledReport *service.OpsScheduledReportService,
+	opsEventExporter *service.OpsEventExporterGroup,
+	opsRequestPhases *service.OpsRequestPhaseService,
+	usageWebhookDispatcher *service.UsageWebhookDispatcher,
+	auditLogService *service.AuditLogService,
+	trashService *service.TrashService,
+	budgetAlertService *service.BudgetAlertService,
+	usageRetryService *service.UsageRetryService,
+	regionReplicator *repository.RegionReplicator,
 	schedulerSnapshot *service.SchedulerSnapshotService,
 	tokenRefresh *service.TokenRefreshService,
 	accountExpiry *service.AccountExpiryService,
+	stripeBilling *service.StripeBillingService,
+	accountCanary *service.AccountCanaryService,
+	modelDiscovery *service.AccountModelDiscoveryService,
 	subscriptionExpiry *service.SubscriptionExpiryService,
 	usageCleanup *service.UsageCleanupService,
 	pricing *service.PricingService,
 	emailQueue *service.EmailQueueService,
 	billingCache *service.BillingCacheService,
+	concurrency *service.ConcurrencyService,
 	oauth *service.OAuthService,
 	openaiOAuth *service.OpenAIOAuthService,
 	geminiOAuth *service.GeminiOAuthService,
@@ -299,6 +383,18 @@ func provideCleanup(
 				accountExpiry.Stop()
 				return nil
 			}},
+			{"StripeBillingService", func() error {
+				stripeBilling.Stop()
+				return nil
+			}},
+			{"AccountCanaryService", func() error {
+				accountCanary.Stop()
+				return nil
+			}},
+			{"AccountModelDiscoveryService", func() error {
+				modelDiscovery.Stop()
+				return nil
+			}},
 			{"SubscriptionExpiryService", func() error {
 				subscriptionExpiry.Stop()
 				return nil
@@ -315,6 +411,15 @@ func provideCleanup(
 				billingCache.Stop()
 				return nil
 			}},
+			{"ConcurrencyService", func() error {
+
+				released, err := concurrency.ReleaseOwnedSlots(ctx)
+				if released > 0 {
+					log.Printf("[Cleanup] Released %d concurrency slots held by this instance", released)
+				}
+				concurrency.Stop()
+				return err
+			}},
 			{"OAuthService", func() error {
 				oauth.Stop()
 				return nil
@@ -331,6 +436,38 @@ func provideCleanup(
 				antigravityOAuth.Stop()
 				return nil
 			}},
+			{"OpsEventExporter", func() error {
+				opsEventExporter.Stop()
+				return nil
+			}},
+			{"OpsRequestPhaseService", func() error {
+				opsRequestPhases.Stop()
+				return nil
+			}},
+			{"UsageWebhookDispatcher", func() error {
+				usageWebhookDispatcher.Stop()
+				return nil
+			}},
+			{"AuditLogService", func() error {
+				auditLogService.Stop()
+				return nil
+			}},
+			{"TrashService", func() error {
+				trashService.Stop()
+				return nil
+			}},
+			{"BudgetAlertService", func() error {
+				budgetAlertService.Stop()
+				return nil
+			}},
+			{"UsageRetryService", func() error {
+				usageRetryService.Stop()
+				return nil
+			}},
+			{"RegionReplicator", func() error {
+				regionReplicator.Stop()
+				return nil
+			}},
 			{"Redis", func() error {
 				return rdb.Close()
 			}},
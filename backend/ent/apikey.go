@@ -13,6 +13,7 @@ import (
 	"github.com/Wei-Shaw/sub2api/ent/apikey"
 	"github.com/Wei-Shaw/sub2api/ent/group"
 	"github.com/Wei-Shaw/sub2api/ent/user"
+	"github.com/Wei-Shaw/sub2api/internal/domain"
 )
 
 // APIKey is the model entity for the APIKey schema.
@@ -36,10 +37,30 @@ type APIKey struct {
 	GroupID *int64 `json:"group_id,omitempty"`
 	// Status holds the value of the "status" field.
 	Status string `json:"status,omitempty"`
+	// 优先级类别（interactive/batch），决定故障转移预算；为空使用全局配置
+	PriorityClass string `json:"priority_class,omitempty"`
 	// Allowed IPs/CIDRs, e.g. ["192.168.1.100", "10.0.0.0/8"]
 	IPWhitelist []string `json:"ip_whitelist,omitempty"`
+	// 允许请求的模型（支持末尾 * 通配），为空表示仅受分组策略限制
+	AllowedModels []string `json:"allowed_models,omitempty"`
+	// 权限范围：允许访问的端点（responses/chat/claude/gemini/embeddings）与功能（streaming/tools/images），为空表示不限制
+	Scopes []string `json:"scopes,omitempty"`
 	// Blocked IPs/CIDRs
 	IPBlacklist []string `json:"ip_blacklist,omitempty"`
+	// 区域策略：要求/优先使用指定区域的账号（覆盖分组配置）
+	RegionPolicy domain.RegionPolicy `json:"region_policy,omitempty"`
+	// 系统提示词注入策略（覆盖分组配置）
+	SystemPromptPolicy domain.SystemPromptPolicy `json:"system_prompt_policy,omitempty"`
+	// 用量回调：请求完成后向 Key 持有者配置的地址推送请求摘要
+	UsageWebhook domain.UsageWebhook `json:"usage_webhook,omitempty"`
+	// 请求签名：启用后请求须携带以共享密钥计算的 HMAC 签名与时间戳，防止截获的请求被重放
+	RequestSigning domain.RequestSigning `json:"request_signing,omitempty"`
+	// 每日/每月 token 与请求数硬配额（按指定时区的自然日/月重置）
+	TokenQuota domain.TokenQuota `json:"token_quota,omitempty"`
+	// 内置工具（web_search/code_interpreter/image_generation）每日调用上限
+	ToolLimits map[string]int `json:"tool_limits,omitempty"`
+	// 调试模式：在错误响应中附带脱敏后的上游错误详情
+	DebugErrors bool `json:"debug_errors,omitempty"`
 	// Quota limit in USD for this API key (0 = unlimited)
 	Quota float64 `json:"quota,omitempty"`
 	// Used quota amount in USD
@@ -101,13 +122,15 @@ func (*APIKey) scanValues(columns []string) ([]any, error) {
 	values := make([]any, len(columns))
 	for i := range columns {
 		switch columns[i] {
-		case apikey.FieldIPWhitelist, apikey.FieldIPBlacklist:
+		case apikey.FieldIPWhitelist, apikey.FieldAllowedModels, apikey.FieldScopes, apikey.FieldIPBlacklist, apikey.FieldRegionPolicy, apikey.FieldSystemPromptPolicy, apikey.FieldUsageWebhook, apikey.FieldRequestSigning, apikey.FieldTokenQuota, apikey.FieldToolLimits:
 			values[i] = new([]byte)
+		case apikey.FieldDebugErrors:
+			values[i] = new(sql.NullBool)
 		case apikey.FieldQuota, apikey.FieldQuotaUsed:
 			values[i] = new(sql.NullFloat64)
 		case apikey.FieldID, apikey.FieldUserID, apikey.FieldGroupID:
 			values[i] = new(sql.NullInt64)
-		case apikey.FieldKey, apikey.FieldName, apikey.FieldStatus:
+		case apikey.FieldKey, apikey.FieldName, apikey.FieldStatus, apikey.FieldPriorityClass:
 			values[i] = new(sql.NullString)
 		case apikey.FieldCreatedAt, apikey.FieldUpdatedAt, apikey.FieldDeletedAt, apikey.FieldExpiresAt:
 			values[i] = new(sql.NullTime)
@@ -182,6 +205,12 @@ func (_m *APIKey) assignValues(columns []string, values []any) error {
 			} else if value.Valid {
 				_m.Status = value.String
 			}
+		case apikey.FieldPriorityClass:
+			if value, ok := values[i].(*sql.NullString); !ok {
+				return fmt.Errorf("unexpected type %T for field priority_class", values[i])
+			} else if value.Valid {
+				_m.PriorityClass = value.String
+			}
 		case apikey.FieldIPWhitelist:
 			if value, ok := values[i].(*[]byte); !ok {
 				return fmt.Errorf("unexpected type %T for field ip_whitelist", values[i])
@@ -190,6 +219,22 @@ func (_m *APIKey) assignValues(columns []string, values []any) error {
 					return fmt.Errorf("unmarshal field ip_whitelist: %w", err)
 				}
 			}
+		case apikey.FieldAllowedModels:
+			if value, ok := values[i].(*[]byte); !ok {
+				return fmt.Errorf("unexpected type %T for field allowed_models", values[i])
+			} else if value != nil && len(*value) > 0 {
+				if err := json.Unmarshal(*value, &_m.AllowedModels); err != nil {
+					return fmt.Errorf("unmarshal field allowed_models: %w", err)
+				}
+			}
+		case apikey.FieldScopes:
+			if value, ok := values[i].(*[]byte); !ok {
+				return fmt.Errorf("unexpected type %T for field scopes", values[i])
+			} else if value != nil && len(*value) > 0 {
+				if err := json.Unmarshal(*value, &_m.Scopes); err != nil {
+					return fmt.Errorf("unmarshal field scopes: %w", err)
+				}
+			}
 		case apikey.FieldIPBlacklist:
 			if value, ok := values[i].(*[]byte); !ok {
 				return fmt.Errorf("unexpected type %T for field ip_blacklist", values[i])
@@ -198,6 +243,60 @@ func (_m *APIKey) assignValues(columns []string, values []any) error {
 					return fmt.Errorf("unmarshal field ip_blacklist: %w", err)
 				}
 			}
+		case apikey.FieldRegionPolicy:
+			if value, ok := values[i].(*[]byte); !ok {
+				return fmt.Errorf("unexpected type %T for field region_policy", values[i])
+			} else if value != nil && len(*value) > 0 {
+				if err := json.Unmarshal(*value, &_m.RegionPolicy); err != nil {
+					return fmt.Errorf("unmarshal field region_policy: %w", err)
+				}
+			}
+		case apikey.FieldSystemPromptPolicy:
+			if value, ok := values[i].(*[]byte); !ok {
+				return fmt.Errorf("unexpected type %T for field system_prompt_policy", values[i])
+			} else if value != nil && len(*value) > 0 {
+				if err := json.Unmarshal(*value, &_m.SystemPromptPolicy); err != nil {
+					return fmt.Errorf("unmarshal field system_prompt_policy: %w", err)
+				}
+			}
+		case apikey.FieldUsageWebhook:
+			if value, ok := values[i].(*[]byte); !ok {
+				return fmt.Errorf("unexpected type %T for field usage_webhook", values[i])
+			} else if value != nil && len(*value) > 0 {
+				if err := json.Unmarshal(*value, &_m.UsageWebhook); err != nil {
+					return fmt.Errorf("unmarshal field usage_webhook: %w", err)
+				}
+			}
+		case apikey.FieldRequestSigning:
+			if value, ok := values[i].(*[]byte); !ok {
+				return fmt.Errorf("unexpected type %T for field request_signing", values[i])
+			} else if value != nil && len(*value) > 0 {
+				if err := json.Unmarshal(*value, &_m.RequestSigning); err != nil {
+					return fmt.Errorf("unmarshal field request_signing: %w", err)
+				}
+			}
+		case apikey.FieldTokenQuota:
+			if value, ok := values[i].(*[]byte); !ok {
+				return fmt.Errorf("unexpected type %T for field token_quota", values[i])
+			} else if value != nil && len(*value) > 0 {
+				if err := json.Unmarshal(*value, &_m.TokenQuota); err != nil {
+					return fmt.Errorf("unmarshal field token_quota: %w", err)
+				}
+			}
+		case apikey.FieldToolLimits:
+			if value, ok := values[i].(*[]byte); !ok {
+				return fmt.Errorf("unexpected type %T for field tool_limits", values[i])
+			} else if value != nil && len(*value) > 0 {
+				if err := json.Unmarshal(*value, &_m.ToolLimits); err != nil {
+					return fmt.Errorf("unmarshal field tool_limits: %w", err)
+				}
+			}
+		case apikey.FieldDebugErrors:
+			if value, ok := values[i].(*sql.NullBool); !ok {
+				return fmt.Errorf("unexpected type %T for field debug_errors", values[i])
+			} else if value.Valid {
+				_m.DebugErrors = value.Bool
+			}
 		case apikey.FieldQuota:
 			if value, ok := values[i].(*sql.NullFloat64); !ok {
 				return fmt.Errorf("unexpected type %T for field quota", values[i])
@@ -296,12 +395,42 @@ func (_m *APIKey) String() string {
 	builder.WriteString("status=")
 	builder.WriteString(_m.Status)
 	builder.WriteString(", ")
+	builder.WriteString("priority_class=")
+	builder.WriteString(_m.PriorityClass)
+	builder.WriteString(", ")
 	builder.WriteString("ip_whitelist=")
 	builder.WriteString(fmt.Sprintf("%v", _m.IPWhitelist))
 	builder.WriteString(", ")
+	builder.WriteString("allowed_models=")
+	builder.WriteString(fmt.Sprintf("%v", _m.AllowedModels))
+	builder.WriteString(", ")
+	builder.WriteString("scopes=")
+	builder.WriteString(fmt.Sprintf("%v", _m.Scopes))
+	builder.WriteString(", ")
 	builder.WriteString("ip_blacklist=")
 	builder.WriteString(fmt.Sprintf("%v", _m.IPBlacklist))
 	builder.WriteString(", ")
+	builder.WriteString("region_policy=")
+	builder.WriteString(fmt.Sprintf("%v", _m.RegionPolicy))
+	builder.WriteString(", ")
+	builder.WriteString("system_prompt_policy=")
+	builder.WriteString(fmt.Sprintf("%v", _m.SystemPromptPolicy))
+	builder.WriteString(", ")
+	builder.WriteString("usage_webhook=")
+	builder.WriteString(fmt.Sprintf("%v", _m.UsageWebhook))
+	builder.WriteString(", ")
+	builder.WriteString("request_signing=")
+	builder.WriteString(fmt.Sprintf("%v", _m.RequestSigning))
+	builder.WriteString(", ")
+	builder.WriteString("token_quota=")
+	builder.WriteString(fmt.Sprintf("%v", _m.TokenQuota))
+	builder.WriteString(", ")
+	builder.WriteString("tool_limits=")
+	builder.WriteString(fmt.Sprintf("%v", _m.ToolLimits))
+	builder.WriteString(", ")
+	builder.WriteString("debug_errors=")
+	builder.WriteString(fmt.Sprintf("%v", _m.DebugErrors))
+	builder.WriteString(", ")
 	builder.WriteString("quota=")
 	builder.WriteString(fmt.Sprintf("%v", _m.Quota))
 	builder.WriteString(", ")
@@ -31,10 +31,30 @@ const (
 	FieldGroupID = "group_id"
 	// FieldStatus holds the string denoting the status field in the database.
 	FieldStatus = "status"
+	// FieldPriorityClass holds the string denoting the priority_class field in the database.
+	FieldPriorityClass = "priority_class"
 	// FieldIPWhitelist holds the string denoting the ip_whitelist field in the database.
 	FieldIPWhitelist = "ip_whitelist"
+	// FieldAllowedModels holds the string denoting the allowed_models field in the database.
+	FieldAllowedModels = "allowed_models"
+	// FieldScopes holds the string denoting the scopes field in the database.
+	FieldScopes = "scopes"
 	// FieldIPBlacklist holds the string denoting the ip_blacklist field in the database.
 	FieldIPBlacklist = "ip_blacklist"
+	// FieldRegionPolicy holds the string denoting the region_policy field in the database.
+	FieldRegionPolicy = "region_policy"
+	// FieldSystemPromptPolicy holds the string denoting the system_prompt_policy field in the database.
+	FieldSystemPromptPolicy = "system_prompt_policy"
+	// FieldUsageWebhook holds the string denoting the usage_webhook field in the database.
+	FieldUsageWebhook = "usage_webhook"
+	// FieldRequestSigning holds the string denoting the request_signing field in the database.
+	FieldRequestSigning = "request_signing"
+	// FieldTokenQuota holds the string denoting the token_quota field in the database.
+	FieldTokenQuota = "token_quota"
+	// FieldToolLimits holds the string denoting the tool_limits field in the database.
+	FieldToolLimits = "tool_limits"
+	// FieldDebugErrors holds the string denoting the debug_errors field in the database.
+	FieldDebugErrors = "debug_errors"
 	// FieldQuota holds the string denoting the quota field in the database.
 	FieldQuota = "quota"
 	// FieldQuotaUsed holds the string denoting the quota_used field in the database.
@@ -83,8 +103,18 @@ var Columns = []string{
 	FieldName,
 	FieldGroupID,
 	FieldStatus,
+	FieldPriorityClass,
 	FieldIPWhitelist,
+	FieldAllowedModels,
+	FieldScopes,
 	FieldIPBlacklist,
+	FieldRegionPolicy,
+	FieldSystemPromptPolicy,
+	FieldUsageWebhook,
+	FieldRequestSigning,
+	FieldTokenQuota,
+	FieldToolLimits,
+	FieldDebugErrors,
 	FieldQuota,
 	FieldQuotaUsed,
 	FieldExpiresAt,
@@ -122,6 +152,12 @@ var (
 	DefaultStatus string
 	// StatusValidator is a validator for the "status" field. It is called by the builders before save.
 	StatusValidator func(string) error
+	// DefaultPriorityClass holds the default value on creation for the "priority_class" field.
+	DefaultPriorityClass string
+	// PriorityClassValidator is a validator for the "priority_class" field. It is called by the builders before save.
+	PriorityClassValidator func(string) error
+	// DefaultDebugErrors holds the default value on creation for the "debug_errors" field.
+	DefaultDebugErrors bool
 	// DefaultQuota holds the default value on creation for the "quota" field.
 	DefaultQuota float64
 	// DefaultQuotaUsed holds the default value on creation for the "quota_used" field.
@@ -176,6 +212,16 @@ func ByStatus(opts ...sql.OrderTermOption) OrderOption {
 	return sql.OrderByField(FieldStatus, opts...).ToFunc()
 }
 
+// ByPriorityClass orders the results by the priority_class field.
+func ByPriorityClass(opts ...sql.OrderTermOption) OrderOption {
+	return sql.OrderByField(FieldPriorityClass, opts...).ToFunc()
+}
+
+// ByDebugErrors orders the results by the debug_errors field.
+func ByDebugErrors(opts ...sql.OrderTermOption) OrderOption {
+	return sql.OrderByField(FieldDebugErrors, opts...).ToFunc()
+}
+
 // ByQuota orders the results by the quota field.
 func ByQuota(opts ...sql.OrderTermOption) OrderOption {
 	return sql.OrderByField(FieldQuota, opts...).ToFunc()
@@ -95,6 +95,16 @@ func Status(v string) predicate.APIKey {
 	return predicate.APIKey(sql.FieldEQ(FieldStatus, v))
 }
 
+// PriorityClass applies equality check predicate on the "priority_class" field. It's identical to PriorityClassEQ.
+func PriorityClass(v string) predicate.APIKey {
+	return predicate.APIKey(sql.FieldEQ(FieldPriorityClass, v))
+}
+
+// DebugErrors applies equality check predicate on the "debug_errors" field. It's identical to DebugErrorsEQ.
+func DebugErrors(v bool) predicate.APIKey {
+	return predicate.APIKey(sql.FieldEQ(FieldDebugErrors, v))
+}
+
 // Quota applies equality check predicate on the "quota" field. It's identical to QuotaEQ.
 func Quota(v float64) predicate.APIKey {
 	return predicate.APIKey(sql.FieldEQ(FieldQuota, v))
@@ -485,6 +495,71 @@ func StatusContainsFold(v string) predicate.APIKey {
 	return predicate.APIKey(sql.FieldContainsFold(FieldStatus, v))
 }
 
+// PriorityClassEQ applies the EQ predicate on the "priority_class" field.
+func PriorityClassEQ(v string) predicate.APIKey {
+	return predicate.APIKey(sql.FieldEQ(FieldPriorityClass, v))
+}
+
+// PriorityClassNEQ applies the NEQ predicate on the "priority_class" field.
+func PriorityClassNEQ(v string) predicate.APIKey {
+	return predicate.APIKey(sql.FieldNEQ(FieldPriorityClass, v))
+}
+
+// PriorityClassIn applies the In predicate on the "priority_class" field.
+func PriorityClassIn(vs ...string) predicate.APIKey {
+	return predicate.APIKey(sql.FieldIn(FieldPriorityClass, vs...))
+}
+
+// PriorityClassNotIn applies the NotIn predicate on the "priority_class" field.
+func PriorityClassNotIn(vs ...string) predicate.APIKey {
+	return predicate.APIKey(sql.FieldNotIn(FieldPriorityClass, vs...))
+}
+
+// PriorityClassGT applies the GT predicate on the "priority_class" field.
+func PriorityClassGT(v string) predicate.APIKey {
+	return predicate.APIKey(sql.FieldGT(FieldPriorityClass, v))
+}
+
+// PriorityClassGTE applies the GTE predicate on the "priority_class" field.
+func PriorityClassGTE(v string) predicate.APIKey {
+	return predicate.APIKey(sql.FieldGTE(FieldPriorityClass, v))
+}
+
+// PriorityClassLT applies the LT predicate on the "priority_class" field.
+func PriorityClassLT(v string) predicate.APIKey {
+	return predicate.APIKey(sql.FieldLT(FieldPriorityClass, v))
+}
+
+// PriorityClassLTE applies the LTE predicate on the "priority_class" field.
+func PriorityClassLTE(v string) predicate.APIKey {
+	return predicate.APIKey(sql.FieldLTE(FieldPriorityClass, v))
+}
+
+// PriorityClassContains applies the Contains predicate on the "priority_class" field.
+func PriorityClassContains(v string) predicate.APIKey {
+	return predicate.APIKey(sql.FieldContains(FieldPriorityClass, v))
+}
+
+// PriorityClassHasPrefix applies the HasPrefix predicate on the "priority_class" field.
+func PriorityClassHasPrefix(v string) predicate.APIKey {
+	return predicate.APIKey(sql.FieldHasPrefix(FieldPriorityClass, v))
+}
+
+// PriorityClassHasSuffix applies the HasSuffix predicate on the "priority_class" field.
+func PriorityClassHasSuffix(v string) predicate.APIKey {
+	return predicate.APIKey(sql.FieldHasSuffix(FieldPriorityClass, v))
+}
+
+// PriorityClassEqualFold applies the EqualFold predicate on the "priority_class" field.
+func PriorityClassEqualFold(v string) predicate.APIKey {
+	return predicate.APIKey(sql.FieldEqualFold(FieldPriorityClass, v))
+}
+
+// PriorityClassContainsFold applies the ContainsFold predicate on the "priority_class" field.
+func PriorityClassContainsFold(v string) predicate.APIKey {
+	return predicate.APIKey(sql.FieldContainsFold(FieldPriorityClass, v))
+}
+
 // IPWhitelistIsNil applies the IsNil predicate on the "ip_whitelist" field.
 func IPWhitelistIsNil() predicate.APIKey {
 	return predicate.APIKey(sql.FieldIsNull(FieldIPWhitelist))
@@ -495,6 +570,26 @@ func IPWhitelistNotNil() predicate.APIKey {
 	return predicate.APIKey(sql.FieldNotNull(FieldIPWhitelist))
 }
 
+// AllowedModelsIsNil applies the IsNil predicate on the "allowed_models" field.
+func AllowedModelsIsNil() predicate.APIKey {
+	return predicate.APIKey(sql.FieldIsNull(FieldAllowedModels))
+}
+
+// AllowedModelsNotNil applies the NotNil predicate on the "allowed_models" field.
+func AllowedModelsNotNil() predicate.APIKey {
+	return predicate.APIKey(sql.FieldNotNull(FieldAllowedModels))
+}
+
+// ScopesIsNil applies the IsNil predicate on the "scopes" field.
+func ScopesIsNil() predicate.APIKey {
+	return predicate.APIKey(sql.FieldIsNull(FieldScopes))
+}
+
+// ScopesNotNil applies the NotNil predicate on the "scopes" field.
+func ScopesNotNil() predicate.APIKey {
+	return predicate.APIKey(sql.FieldNotNull(FieldScopes))
+}
+
 // IPBlacklistIsNil applies the IsNil predicate on the "ip_blacklist" field.
 func IPBlacklistIsNil() predicate.APIKey {
 	return predicate.APIKey(sql.FieldIsNull(FieldIPBlacklist))
@@ -505,6 +600,76 @@ func IPBlacklistNotNil() predicate.APIKey {
 	return predicate.APIKey(sql.FieldNotNull(FieldIPBlacklist))
 }
 
+// RegionPolicyIsNil applies the IsNil predicate on the "region_policy" field.
+func RegionPolicyIsNil() predicate.APIKey {
+	return predicate.APIKey(sql.FieldIsNull(FieldRegionPolicy))
+}
+
+// RegionPolicyNotNil applies the NotNil predicate on the "region_policy" field.
+func RegionPolicyNotNil() predicate.APIKey {
+	return predicate.APIKey(sql.FieldNotNull(FieldRegionPolicy))
+}
+
+// SystemPromptPolicyIsNil applies the IsNil predicate on the "system_prompt_policy" field.
+func SystemPromptPolicyIsNil() predicate.APIKey {
+	return predicate.APIKey(sql.FieldIsNull(FieldSystemPromptPolicy))
+}
+
+// SystemPromptPolicyNotNil applies the NotNil predicate on the "system_prompt_policy" field.
+func SystemPromptPolicyNotNil() predicate.APIKey {
+	return predicate.APIKey(sql.FieldNotNull(FieldSystemPromptPolicy))
+}
+
+// UsageWebhookIsNil applies the IsNil predicate on the "usage_webhook" field.
+func UsageWebhookIsNil() predicate.APIKey {
+	return predicate.APIKey(sql.FieldIsNull(FieldUsageWebhook))
+}
+
+// UsageWebhookNotNil applies the NotNil predicate on the "usage_webhook" field.
+func UsageWebhookNotNil() predicate.APIKey {
+	return predicate.APIKey(sql.FieldNotNull(FieldUsageWebhook))
+}
+
+// RequestSigningIsNil applies the IsNil predicate on the "request_signing" field.
+func RequestSigningIsNil() predicate.APIKey {
+	return predicate.APIKey(sql.FieldIsNull(FieldRequestSigning))
+}
+
+// RequestSigningNotNil applies the NotNil predicate on the "request_signing" field.
+func RequestSigningNotNil() predicate.APIKey {
+	return predicate.APIKey(sql.FieldNotNull(FieldRequestSigning))
+}
+
+// TokenQuotaIsNil applies the IsNil predicate on the "token_quota" field.
+func TokenQuotaIsNil() predicate.APIKey {
+	return predicate.APIKey(sql.FieldIsNull(FieldTokenQuota))
+}
+
+// TokenQuotaNotNil applies the NotNil predicate on the "token_quota" field.
+func TokenQuotaNotNil() predicate.APIKey {
+	return predicate.APIKey(sql.FieldNotNull(FieldTokenQuota))
+}
+
+// ToolLimitsIsNil applies the IsNil predicate on the "tool_limits" field.
+func ToolLimitsIsNil() predicate.APIKey {
+	return predicate.APIKey(sql.FieldIsNull(FieldToolLimits))
+}
+
+// ToolLimitsNotNil applies the NotNil predicate on the "tool_limits" field.
+func ToolLimitsNotNil() predicate.APIKey {
+	return predicate.APIKey(sql.FieldNotNull(FieldToolLimits))
+}
+
+// DebugErrorsEQ applies the EQ predicate on the "debug_errors" field.
+func DebugErrorsEQ(v bool) predicate.APIKey {
+	return predicate.APIKey(sql.FieldEQ(FieldDebugErrors, v))
+}
+
+// DebugErrorsNEQ applies the NEQ predicate on the "debug_errors" field.
+func DebugErrorsNEQ(v bool) predicate.APIKey {
+	return predicate.APIKey(sql.FieldNEQ(FieldDebugErrors, v))
+}
+
 // QuotaEQ applies the EQ predicate on the "quota" field.
 func QuotaEQ(v float64) predicate.APIKey {
 	return predicate.APIKey(sql.FieldEQ(FieldQuota, v))
@@ -15,6 +15,7 @@ import (
 	"github.com/Wei-Shaw/sub2api/ent/group"
 	"github.com/Wei-Shaw/sub2api/ent/usagelog"
 	"github.com/Wei-Shaw/sub2api/ent/user"
+	"github.com/Wei-Shaw/sub2api/internal/domain"
 )
 
 // APIKeyCreate is the builder for creating a APIKey entity.
@@ -113,18 +114,94 @@ func (_c *APIKeyCreate) SetNillableStatus(v *string) *APIKeyCreate {
 	return _c
 }
 
+// SetPriorityClass sets the "priority_class" field.
+func (_c *APIKeyCreate) SetPriorityClass(v string) *APIKeyCreate {
+	_c.mutation.SetPriorityClass(v)
+	return _c
+}
+
+// SetNillablePriorityClass sets the "priority_class" field if the given value is not nil.
+func (_c *APIKeyCreate) SetNillablePriorityClass(v *string) *APIKeyCreate {
+	if v != nil {
+		_c.SetPriorityClass(*v)
+	}
+	return _c
+}
+
 // SetIPWhitelist sets the "ip_whitelist" field.
 func (_c *APIKeyCreate) SetIPWhitelist(v []string) *APIKeyCreate {
 	_c.mutation.SetIPWhitelist(v)
 	return _c
 }
 
+// SetAllowedModels sets the "allowed_models" field.
+func (_c *APIKeyCreate) SetAllowedModels(v []string) *APIKeyCreate {
+	_c.mutation.SetAllowedModels(v)
+	return _c
+}
+
+// SetScopes sets the "scopes" field.
+func (_c *APIKeyCreate) SetScopes(v []string) *APIKeyCreate {
+	_c.mutation.SetScopes(v)
+	return _c
+}
+
 // SetIPBlacklist sets the "ip_blacklist" field.
 func (_c *APIKeyCreate) SetIPBlacklist(v []string) *APIKeyCreate {
 	_c.mutation.SetIPBlacklist(v)
 	return _c
 }
 
+// SetRegionPolicy sets the "region_policy" field.
+func (_c *APIKeyCreate) SetRegionPolicy(v domain.RegionPolicy) *APIKeyCreate {
+	_c.mutation.SetRegionPolicy(v)
+	return _c
+}
+
+// SetSystemPromptPolicy sets the "system_prompt_policy" field.
+func (_c *APIKeyCreate) SetSystemPromptPolicy(v domain.SystemPromptPolicy) *APIKeyCreate {
+	_c.mutation.SetSystemPromptPolicy(v)
+	return _c
+}
+
+// SetUsageWebhook sets the "usage_webhook" field.
+func (_c *APIKeyCreate) SetUsageWebhook(v domain.UsageWebhook) *APIKeyCreate {
+	_c.mutation.SetUsageWebhook(v)
+	return _c
+}
+
+// SetRequestSigning sets the "request_signing" field.
+func (_c *APIKeyCreate) SetRequestSigning(v domain.RequestSigning) *APIKeyCreate {
+	_c.mutation.SetRequestSigning(v)
+	return _c
+}
+
+// SetTokenQuota sets the "token_quota" field.
+func (_c *APIKeyCreate) SetTokenQuota(v domain.TokenQuota) *APIKeyCreate {
+	_c.mutation.SetTokenQuota(v)
+	return _c
+}
+
+// SetToolLimits sets the "tool_limits" field.
+func (_c *APIKeyCreate) SetToolLimits(v map[string]int) *APIKeyCreate {
+	_c.mutation.SetToolLimits(v)
+	return _c
+}
+
+// SetDebugErrors sets the "debug_errors" field.
+func (_c *APIKeyCreate) SetDebugErrors(v bool) *APIKeyCreate {
+	_c.mutation.SetDebugErrors(v)
+	return _c
+}
+
+// SetNillableDebugErrors sets the "debug_errors" field if the given value is not nil.
+func (_c *APIKeyCreate) SetNillableDebugErrors(v *bool) *APIKeyCreate {
+	if v != nil {
+		_c.SetDebugErrors(*v)
+	}
+	return _c
+}
+
 // SetQuota sets the "quota" field.
 func (_c *APIKeyCreate) SetQuota(v float64) *APIKeyCreate {
 	_c.mutation.SetQuota(v)
@@ -247,6 +324,14 @@ func (_c *APIKeyCreate) defaults() error {
 		v := apikey.DefaultStatus
 		_c.mutation.SetStatus(v)
 	}
+	if _, ok := _c.mutation.PriorityClass(); !ok {
+		v := apikey.DefaultPriorityClass
+		_c.mutation.SetPriorityClass(v)
+	}
+	if _, ok := _c.mutation.DebugErrors(); !ok {
+		v := apikey.DefaultDebugErrors
+		_c.mutation.SetDebugErrors(v)
+	}
 	if _, ok := _c.mutation.Quota(); !ok {
 		v := apikey.DefaultQuota
 		_c.mutation.SetQuota(v)
@@ -288,11 +373,22 @@ func (_c *APIKeyCreate) check() error {
 	if _, ok := _c.mutation.Status(); !ok {
 		return &ValidationError{Name: "status", err: errors.New(`ent: missing required field "APIKey.status"`)}
 	}
+	if _, ok := _c.mutation.PriorityClass(); !ok {
+		return &ValidationError{Name: "priority_class", err: errors.New(`ent: missing required field "APIKey.priority_class"`)}
+	}
 	if v, ok := _c.mutation.Status(); ok {
 		if err := apikey.StatusValidator(v); err != nil {
 			return &ValidationError{Name: "status", err: fmt.Errorf(`ent: validator failed for field "APIKey.status": %w`, err)}
 		}
 	}
+	if v, ok := _c.mutation.PriorityClass(); ok {
+		if err := apikey.PriorityClassValidator(v); err != nil {
+			return &ValidationError{Name: "priority_class", err: fmt.Errorf(`ent: validator failed for field "APIKey.priority_class": %w`, err)}
+		}
+	}
+	if _, ok := _c.mutation.DebugErrors(); !ok {
+		return &ValidationError{Name: "debug_errors", err: errors.New(`ent: missing required field "APIKey.debug_errors"`)}
+	}
 	if _, ok := _c.mutation.Quota(); !ok {
 		return &ValidationError{Name: "quota", err: errors.New(`ent: missing required field "APIKey.quota"`)}
 	}
@@ -353,14 +449,54 @@ func (_c *APIKeyCreate) createSpec() (*APIKey, *sqlgraph.CreateSpec) {
 		_spec.SetField(apikey.FieldStatus, field.TypeString, value)
 		_node.Status = value
 	}
+	if value, ok := _c.mutation.PriorityClass(); ok {
+		_spec.SetField(apikey.FieldPriorityClass, field.TypeString, value)
+		_node.PriorityClass = value
+	}
 	if value, ok := _c.mutation.IPWhitelist(); ok {
 		_spec.SetField(apikey.FieldIPWhitelist, field.TypeJSON, value)
 		_node.IPWhitelist = value
 	}
+	if value, ok := _c.mutation.AllowedModels(); ok {
+		_spec.SetField(apikey.FieldAllowedModels, field.TypeJSON, value)
+		_node.AllowedModels = value
+	}
+	if value, ok := _c.mutation.Scopes(); ok {
+		_spec.SetField(apikey.FieldScopes, field.TypeJSON, value)
+		_node.Scopes = value
+	}
 	if value, ok := _c.mutation.IPBlacklist(); ok {
 		_spec.SetField(apikey.FieldIPBlacklist, field.TypeJSON, value)
 		_node.IPBlacklist = value
 	}
+	if value, ok := _c.mutation.RegionPolicy(); ok {
+		_spec.SetField(apikey.FieldRegionPolicy, field.TypeJSON, value)
+		_node.RegionPolicy = value
+	}
+	if value, ok := _c.mutation.SystemPromptPolicy(); ok {
+		_spec.SetField(apikey.FieldSystemPromptPolicy, field.TypeJSON, value)
+		_node.SystemPromptPolicy = value
+	}
+	if value, ok := _c.mutation.UsageWebhook(); ok {
+		_spec.SetField(apikey.FieldUsageWebhook, field.TypeJSON, value)
+		_node.UsageWebhook = value
+	}
+	if value, ok := _c.mutation.RequestSigning(); ok {
+		_spec.SetField(apikey.FieldRequestSigning, field.TypeJSON, value)
+		_node.RequestSigning = value
+	}
+	if value, ok := _c.mutation.TokenQuota(); ok {
+		_spec.SetField(apikey.FieldTokenQuota, field.TypeJSON, value)
+		_node.TokenQuota = value
+	}
+	if value, ok := _c.mutation.ToolLimits(); ok {
+		_spec.SetField(apikey.FieldToolLimits, field.TypeJSON, value)
+		_node.ToolLimits = value
+	}
+	if value, ok := _c.mutation.DebugErrors(); ok {
+		_spec.SetField(apikey.FieldDebugErrors, field.TypeBool, value)
+		_node.DebugErrors = value
+	}
 	if value, ok := _c.mutation.Quota(); ok {
 		_spec.SetField(apikey.FieldQuota, field.TypeFloat64, value)
 		_node.Quota = value
@@ -571,6 +707,18 @@ func (u *APIKeyUpsert) UpdateStatus() *APIKeyUpsert {
 	return u
 }
 
+// SetPriorityClass sets the "priority_class" field.
+func (u *APIKeyUpsert) SetPriorityClass(v string) *APIKeyUpsert {
+	u.Set(apikey.FieldPriorityClass, v)
+	return u
+}
+
+// UpdatePriorityClass sets the "priority_class" field to the value that was provided on create.
+func (u *APIKeyUpsert) UpdatePriorityClass() *APIKeyUpsert {
+	u.SetExcluded(apikey.FieldPriorityClass)
+	return u
+}
+
 // SetIPWhitelist sets the "ip_whitelist" field.
 func (u *APIKeyUpsert) SetIPWhitelist(v []string) *APIKeyUpsert {
 	u.Set(apikey.FieldIPWhitelist, v)
@@ -589,6 +737,42 @@ func (u *APIKeyUpsert) ClearIPWhitelist() *APIKeyUpsert {
 	return u
 }
 
+// SetAllowedModels sets the "allowed_models" field.
+func (u *APIKeyUpsert) SetAllowedModels(v []string) *APIKeyUpsert {
+	u.Set(apikey.FieldAllowedModels, v)
+	return u
+}
+
+// UpdateAllowedModels sets the "allowed_models" field to the value that was provided on create.
+func (u *APIKeyUpsert) UpdateAllowedModels() *APIKeyUpsert {
+	u.SetExcluded(apikey.FieldAllowedModels)
+	return u
+}
+
+// ClearAllowedModels clears the value of the "allowed_models" field.
+func (u *APIKeyUpsert) ClearAllowedModels() *APIKeyUpsert {
+	u.SetNull(apikey.FieldAllowedModels)
+	return u
+}
+
+// SetScopes sets the "scopes" field.
+func (u *APIKeyUpsert) SetScopes(v []string) *APIKeyUpsert {
+	u.Set(apikey.FieldScopes, v)
+	return u
+}
+
+// UpdateScopes sets the "scopes" field to the value that was provided on create.
+func (u *APIKeyUpsert) UpdateScopes() *APIKeyUpsert {
+	u.SetExcluded(apikey.FieldScopes)
+	return u
+}
+
+// ClearScopes clears the value of the "scopes" field.
+func (u *APIKeyUpsert) ClearScopes() *APIKeyUpsert {
+	u.SetNull(apikey.FieldScopes)
+	return u
+}
+
 // SetIPBlacklist sets the "ip_blacklist" field.
 func (u *APIKeyUpsert) SetIPBlacklist(v []string) *APIKeyUpsert {
 	u.Set(apikey.FieldIPBlacklist, v)
@@ -607,6 +791,126 @@ func (u *APIKeyUpsert) ClearIPBlacklist() *APIKeyUpsert {
 	return u
 }
 
+// SetRegionPolicy sets the "region_policy" field.
+func (u *APIKeyUpsert) SetRegionPolicy(v domain.RegionPolicy) *APIKeyUpsert {
+	u.Set(apikey.FieldRegionPolicy, v)
+	return u
+}
+
+// UpdateRegionPolicy sets the "region_policy" field to the value that was provided on create.
+func (u *APIKeyUpsert) UpdateRegionPolicy() *APIKeyUpsert {
+	u.SetExcluded(apikey.FieldRegionPolicy)
+	return u
+}
+
+// ClearRegionPolicy clears the value of the "region_policy" field.
+func (u *APIKeyUpsert) ClearRegionPolicy() *APIKeyUpsert {
+	u.SetNull(apikey.FieldRegionPolicy)
+	return u
+}
+
+// SetSystemPromptPolicy sets the "system_prompt_policy" field.
+func (u *APIKeyUpsert) SetSystemPromptPolicy(v domain.SystemPromptPolicy) *APIKeyUpsert {
+	u.Set(apikey.FieldSystemPromptPolicy, v)
+	return u
+}
+
+// UpdateSystemPromptPolicy sets the "system_prompt_policy" field to the value that was provided on create.
+func (u *APIKeyUpsert) UpdateSystemPromptPolicy() *APIKeyUpsert {
+	u.SetExcluded(apikey.FieldSystemPromptPolicy)
+	return u
+}
+
+// ClearSystemPromptPolicy clears the value of the "system_prompt_policy" field.
+func (u *APIKeyUpsert) ClearSystemPromptPolicy() *APIKeyUpsert {
+	u.SetNull(apikey.FieldSystemPromptPolicy)
+	return u
+}
+
+// SetUsageWebhook sets the "usage_webhook" field.
+func (u *APIKeyUpsert) SetUsageWebhook(v domain.UsageWebhook) *APIKeyUpsert {
+	u.Set(apikey.FieldUsageWebhook, v)
+	return u
+}
+
+// UpdateUsageWebhook sets the "usage_webhook" field to the value that was provided on create.
+func (u *APIKeyUpsert) UpdateUsageWebhook() *APIKeyUpsert {
+	u.SetExcluded(apikey.FieldUsageWebhook)
+	return u
+}
+
+// ClearUsageWebhook clears the value of the "usage_webhook" field.
+func (u *APIKeyUpsert) ClearUsageWebhook() *APIKeyUpsert {
+	u.SetNull(apikey.FieldUsageWebhook)
+	return u
+}
+
+// SetRequestSigning sets the "request_signing" field.
+func (u *APIKeyUpsert) SetRequestSigning(v domain.RequestSigning) *APIKeyUpsert {
+	u.Set(apikey.FieldRequestSigning, v)
+	return u
+}
+
+// UpdateRequestSigning sets the "request_signing" field to the value that was provided on create.
+func (u *APIKeyUpsert) UpdateRequestSigning() *APIKeyUpsert {
+	u.SetExcluded(apikey.FieldRequestSigning)
+	return u
+}
+
+// ClearRequestSigning clears the value of the "request_signing" field.
+func (u *APIKeyUpsert) ClearRequestSigning() *APIKeyUpsert {
+	u.SetNull(apikey.FieldRequestSigning)
+	return u
+}
+
+// SetTokenQuota sets the "token_quota" field.
+func (u *APIKeyUpsert) SetTokenQuota(v domain.TokenQuota) *APIKeyUpsert {
+	u.Set(apikey.FieldTokenQuota, v)
+	return u
+}
+
+// UpdateTokenQuota sets the "token_quota" field to the value that was provided on create.
+func (u *APIKeyUpsert) UpdateTokenQuota() *APIKeyUpsert {
+	u.SetExcluded(apikey.FieldTokenQuota)
+	return u
+}
+
+// ClearTokenQuota clears the value of the "token_quota" field.
+func (u *APIKeyUpsert) ClearTokenQuota() *APIKeyUpsert {
+	u.SetNull(apikey.FieldTokenQuota)
+	return u
+}
+
+// SetToolLimits sets the "tool_limits" field.
+func (u *APIKeyUpsert) SetToolLimits(v map[string]int) *APIKeyUpsert {
+	u.Set(apikey.FieldToolLimits, v)
+	return u
+}
+
+// UpdateToolLimits sets the "tool_limits" field to the value that was provided on create.
+func (u *APIKeyUpsert) UpdateToolLimits() *APIKeyUpsert {
+	u.SetExcluded(apikey.FieldToolLimits)
+	return u
+}
+
+// ClearToolLimits clears the value of the "tool_limits" field.
+func (u *APIKeyUpsert) ClearToolLimits() *APIKeyUpsert {
+	u.SetNull(apikey.FieldToolLimits)
+	return u
+}
+
+// SetDebugErrors sets the "debug_errors" field.
+func (u *APIKeyUpsert) SetDebugErrors(v bool) *APIKeyUpsert {
+	u.Set(apikey.FieldDebugErrors, v)
+	return u
+}
+
+// UpdateDebugErrors sets the "debug_errors" field to the value that was provided on create.
+func (u *APIKeyUpsert) UpdateDebugErrors() *APIKeyUpsert {
+	u.SetExcluded(apikey.FieldDebugErrors)
+	return u
+}
+
 // SetQuota sets the "quota" field.
 func (u *APIKeyUpsert) SetQuota(v float64) *APIKeyUpsert {
 	u.Set(apikey.FieldQuota, v)
@@ -818,6 +1122,20 @@ func (u *APIKeyUpsertOne) UpdateStatus() *APIKeyUpsertOne {
 	})
 }
 
+// SetPriorityClass sets the "priority_class" field.
+func (u *APIKeyUpsertOne) SetPriorityClass(v string) *APIKeyUpsertOne {
+	return u.Update(func(s *APIKeyUpsert) {
+		s.SetPriorityClass(v)
+	})
+}
+
+// UpdatePriorityClass sets the "priority_class" field to the value that was provided on create.
+func (u *APIKeyUpsertOne) UpdatePriorityClass() *APIKeyUpsertOne {
+	return u.Update(func(s *APIKeyUpsert) {
+		s.UpdatePriorityClass()
+	})
+}
+
 // SetIPWhitelist sets the "ip_whitelist" field.
 func (u *APIKeyUpsertOne) SetIPWhitelist(v []string) *APIKeyUpsertOne {
 	return u.Update(func(s *APIKeyUpsert) {
@@ -839,6 +1157,48 @@ func (u *APIKeyUpsertOne) ClearIPWhitelist() *APIKeyUpsertOne {
 	})
 }
 
+// SetAllowedModels sets the "allowed_models" field.
+func (u *APIKeyUpsertOne) SetAllowedModels(v []string) *APIKeyUpsertOne {
+	return u.Update(func(s *APIKeyUpsert) {
+		s.SetAllowedModels(v)
+	})
+}
+
+// UpdateAllowedModels sets the "allowed_models" field to the value that was provided on create.
+func (u *APIKeyUpsertOne) UpdateAllowedModels() *APIKeyUpsertOne {
+	return u.Update(func(s *APIKeyUpsert) {
+		s.UpdateAllowedModels()
+	})
+}
+
+// ClearAllowedModels clears the value of the "allowed_models" field.
+func (u *APIKeyUpsertOne) ClearAllowedModels() *APIKeyUpsertOne {
+	return u.Update(func(s *APIKeyUpsert) {
+		s.ClearAllowedModels()
+	})
+}
+
+// SetScopes sets the "scopes" field.
+func (u *APIKeyUpsertOne) SetScopes(v []string) *APIKeyUpsertOne {
+	return u.Update(func(s *APIKeyUpsert) {
+		s.SetScopes(v)
+	})
+}
+
+// UpdateScopes sets the "scopes" field to the value that was provided on create.
+func (u *APIKeyUpsertOne) UpdateScopes() *APIKeyUpsertOne {
+	return u.Update(func(s *APIKeyUpsert) {
+		s.UpdateScopes()
+	})
+}
+
+// ClearScopes clears the value of the "scopes" field.
+func (u *APIKeyUpsertOne) ClearScopes() *APIKeyUpsertOne {
+	return u.Update(func(s *APIKeyUpsert) {
+		s.ClearScopes()
+	})
+}
+
 // SetIPBlacklist sets the "ip_blacklist" field.
 func (u *APIKeyUpsertOne) SetIPBlacklist(v []string) *APIKeyUpsertOne {
 	return u.Update(func(s *APIKeyUpsert) {
@@ -860,6 +1220,146 @@ func (u *APIKeyUpsertOne) ClearIPBlacklist() *APIKeyUpsertOne {
 	})
 }
 
+// SetRegionPolicy sets the "region_policy" field.
+func (u *APIKeyUpsertOne) SetRegionPolicy(v domain.RegionPolicy) *APIKeyUpsertOne {
+	return u.Update(func(s *APIKeyUpsert) {
+		s.SetRegionPolicy(v)
+	})
+}
+
+// UpdateRegionPolicy sets the "region_policy" field to the value that was provided on create.
+func (u *APIKeyUpsertOne) UpdateRegionPolicy() *APIKeyUpsertOne {
+	return u.Update(func(s *APIKeyUpsert) {
+		s.UpdateRegionPolicy()
+	})
+}
+
+// ClearRegionPolicy clears the value of the "region_policy" field.
+func (u *APIKeyUpsertOne) ClearRegionPolicy() *APIKeyUpsertOne {
+	return u.Update(func(s *APIKeyUpsert) {
+		s.ClearRegionPolicy()
+	})
+}
+
+// SetSystemPromptPolicy sets the "system_prompt_policy" field.
+func (u *APIKeyUpsertOne) SetSystemPromptPolicy(v domain.SystemPromptPolicy) *APIKeyUpsertOne {
+	return u.Update(func(s *APIKeyUpsert) {
+		s.SetSystemPromptPolicy(v)
+	})
+}
+
+// UpdateSystemPromptPolicy sets the "system_prompt_policy" field to the value that was provided on create.
+func (u *APIKeyUpsertOne) UpdateSystemPromptPolicy() *APIKeyUpsertOne {
+	return u.Update(func(s *APIKeyUpsert) {
+		s.UpdateSystemPromptPolicy()
+	})
+}
+
+// ClearSystemPromptPolicy clears the value of the "system_prompt_policy" field.
+func (u *APIKeyUpsertOne) ClearSystemPromptPolicy() *APIKeyUpsertOne {
+	return u.Update(func(s *APIKeyUpsert) {
+		s.ClearSystemPromptPolicy()
+	})
+}
+
+// SetUsageWebhook sets the "usage_webhook" field.
+func (u *APIKeyUpsertOne) SetUsageWebhook(v domain.UsageWebhook) *APIKeyUpsertOne {
+	return u.Update(func(s *APIKeyUpsert) {
+		s.SetUsageWebhook(v)
+	})
+}
+
+// UpdateUsageWebhook sets the "usage_webhook" field to the value that was provided on create.
+func (u *APIKeyUpsertOne) UpdateUsageWebhook() *APIKeyUpsertOne {
+	return u.Update(func(s *APIKeyUpsert) {
+		s.UpdateUsageWebhook()
+	})
+}
+
+// ClearUsageWebhook clears the value of the "usage_webhook" field.
+func (u *APIKeyUpsertOne) ClearUsageWebhook() *APIKeyUpsertOne {
+	return u.Update(func(s *APIKeyUpsert) {
+		s.ClearUsageWebhook()
+	})
+}
+
+// SetRequestSigning sets the "request_signing" field.
+func (u *APIKeyUpsertOne) SetRequestSigning(v domain.RequestSigning) *APIKeyUpsertOne {
+	return u.Update(func(s *APIKeyUpsert) {
+		s.SetRequestSigning(v)
+	})
+}
+
+// UpdateRequestSigning sets the "request_signing" field to the value that was provided on create.
+func (u *APIKeyUpsertOne) UpdateRequestSigning() *APIKeyUpsertOne {
+	return u.Update(func(s *APIKeyUpsert) {
+		s.UpdateRequestSigning()
+	})
+}
+
+// ClearRequestSigning clears the value of the "request_signing" field.
+func (u *APIKeyUpsertOne) ClearRequestSigning() *APIKeyUpsertOne {
+	return u.Update(func(s *APIKeyUpsert) {
+		s.ClearRequestSigning()
+	})
+}
+
+// SetTokenQuota sets the "token_quota" field.
+func (u *APIKeyUpsertOne) SetTokenQuota(v domain.TokenQuota) *APIKeyUpsertOne {
+	return u.Update(func(s *APIKeyUpsert) {
+		s.SetTokenQuota(v)
+	})
+}
+
+// UpdateTokenQuota sets the "token_quota" field to the value that was provided on create.
+func (u *APIKeyUpsertOne) UpdateTokenQuota() *APIKeyUpsertOne {
+	return u.Update(func(s *APIKeyUpsert) {
+		s.UpdateTokenQuota()
+	})
+}
+
+// ClearTokenQuota clears the value of the "token_quota" field.
+func (u *APIKeyUpsertOne) ClearTokenQuota() *APIKeyUpsertOne {
+	return u.Update(func(s *APIKeyUpsert) {
+		s.ClearTokenQuota()
+	})
+}
+
+// SetToolLimits sets the "tool_limits" field.
+func (u *APIKeyUpsertOne) SetToolLimits(v map[string]int) *APIKeyUpsertOne {
+	return u.Update(func(s *APIKeyUpsert) {
+		s.SetToolLimits(v)
+	})
+}
+
+// UpdateToolLimits sets the "tool_limits" field to the value that was provided on create.
+func (u *APIKeyUpsertOne) UpdateToolLimits() *APIKeyUpsertOne {
+	return u.Update(func(s *APIKeyUpsert) {
+		s.UpdateToolLimits()
+	})
+}
+
+// ClearToolLimits clears the value of the "tool_limits" field.
+func (u *APIKeyUpsertOne) ClearToolLimits() *APIKeyUpsertOne {
+	return u.Update(func(s *APIKeyUpsert) {
+		s.ClearToolLimits()
+	})
+}
+
+// SetDebugErrors sets the "debug_errors" field.
+func (u *APIKeyUpsertOne) SetDebugErrors(v bool) *APIKeyUpsertOne {
+	return u.Update(func(s *APIKeyUpsert) {
+		s.SetDebugErrors(v)
+	})
+}
+
+// UpdateDebugErrors sets the "debug_errors" field to the value that was provided on create.
+func (u *APIKeyUpsertOne) UpdateDebugErrors() *APIKeyUpsertOne {
+	return u.Update(func(s *APIKeyUpsert) {
+		s.UpdateDebugErrors()
+	})
+}
+
 // SetQuota sets the "quota" field.
 func (u *APIKeyUpsertOne) SetQuota(v float64) *APIKeyUpsertOne {
 	return u.Update(func(s *APIKeyUpsert) {
@@ -1246,6 +1746,20 @@ func (u *APIKeyUpsertBulk) UpdateStatus() *APIKeyUpsertBulk {
 	})
 }
 
+// SetPriorityClass sets the "priority_class" field.
+func (u *APIKeyUpsertBulk) SetPriorityClass(v string) *APIKeyUpsertBulk {
+	return u.Update(func(s *APIKeyUpsert) {
+		s.SetPriorityClass(v)
+	})
+}
+
+// UpdatePriorityClass sets the "priority_class" field to the value that was provided on create.
+func (u *APIKeyUpsertBulk) UpdatePriorityClass() *APIKeyUpsertBulk {
+	return u.Update(func(s *APIKeyUpsert) {
+		s.UpdatePriorityClass()
+	})
+}
+
 // SetIPWhitelist sets the "ip_whitelist" field.
 func (u *APIKeyUpsertBulk) SetIPWhitelist(v []string) *APIKeyUpsertBulk {
 	return u.Update(func(s *APIKeyUpsert) {
@@ -1267,6 +1781,48 @@ func (u *APIKeyUpsertBulk) ClearIPWhitelist() *APIKeyUpsertBulk {
 	})
 }
 
+// SetAllowedModels sets the "allowed_models" field.
+func (u *APIKeyUpsertBulk) SetAllowedModels(v []string) *APIKeyUpsertBulk {
+	return u.Update(func(s *APIKeyUpsert) {
+		s.SetAllowedModels(v)
+	})
+}
+
+// UpdateAllowedModels sets the "allowed_models" field to the value that was provided on create.
+func (u *APIKeyUpsertBulk) UpdateAllowedModels() *APIKeyUpsertBulk {
+	return u.Update(func(s *APIKeyUpsert) {
+		s.UpdateAllowedModels()
+	})
+}
+
+// ClearAllowedModels clears the value of the "allowed_models" field.
+func (u *APIKeyUpsertBulk) ClearAllowedModels() *APIKeyUpsertBulk {
+	return u.Update(func(s *APIKeyUpsert) {
+		s.ClearAllowedModels()
+	})
+}
+
+// SetScopes sets the "scopes" field.
+func (u *APIKeyUpsertBulk) SetScopes(v []string) *APIKeyUpsertBulk {
+	return u.Update(func(s *APIKeyUpsert) {
+		s.SetScopes(v)
+	})
+}
+
+// UpdateScopes sets the "scopes" field to the value that was provided on create.
+func (u *APIKeyUpsertBulk) UpdateScopes() *APIKeyUpsertBulk {
+	return u.Update(func(s *APIKeyUpsert) {
+		s.UpdateScopes()
+	})
+}
+
+// ClearScopes clears the value of the "scopes" field.
+func (u *APIKeyUpsertBulk) ClearScopes() *APIKeyUpsertBulk {
+	return u.Update(func(s *APIKeyUpsert) {
+		s.ClearScopes()
+	})
+}
+
 // SetIPBlacklist sets the "ip_blacklist" field.
 func (u *APIKeyUpsertBulk) SetIPBlacklist(v []string) *APIKeyUpsertBulk {
 	return u.Update(func(s *APIKeyUpsert) {
@@ -1288,6 +1844,146 @@ func (u *APIKeyUpsertBulk) ClearIPBlacklist() *APIKeyUpsertBulk {
 	})
 }
 
+// SetRegionPolicy sets the "region_policy" field.
+func (u *APIKeyUpsertBulk) SetRegionPolicy(v domain.RegionPolicy) *APIKeyUpsertBulk {
+	return u.Update(func(s *APIKeyUpsert) {
+		s.SetRegionPolicy(v)
+	})
+}
+
+// UpdateRegionPolicy sets the "region_policy" field to the value that was provided on create.
+func (u *APIKeyUpsertBulk) UpdateRegionPolicy() *APIKeyUpsertBulk {
+	return u.Update(func(s *APIKeyUpsert) {
+		s.UpdateRegionPolicy()
+	})
+}
+
+// ClearRegionPolicy clears the value of the "region_policy" field.
+func (u *APIKeyUpsertBulk) ClearRegionPolicy() *APIKeyUpsertBulk {
+	return u.Update(func(s *APIKeyUpsert) {
+		s.ClearRegionPolicy()
+	})
+}
+
+// SetSystemPromptPolicy sets the "system_prompt_policy" field.
+func (u *APIKeyUpsertBulk) SetSystemPromptPolicy(v domain.SystemPromptPolicy) *APIKeyUpsertBulk {
+	return u.Update(func(s *APIKeyUpsert) {
+		s.SetSystemPromptPolicy(v)
+	})
+}
+
+// UpdateSystemPromptPolicy sets the "system_prompt_policy" field to the value that was provided on create.
+func (u *APIKeyUpsertBulk) UpdateSystemPromptPolicy() *APIKeyUpsertBulk {
+	return u.Update(func(s *APIKeyUpsert) {
+		s.UpdateSystemPromptPolicy()
+	})
+}
+
+// ClearSystemPromptPolicy clears the value of the "system_prompt_policy" field.
+func (u *APIKeyUpsertBulk) ClearSystemPromptPolicy() *APIKeyUpsertBulk {
+	return u.Update(func(s *APIKeyUpsert) {
+		s.ClearSystemPromptPolicy()
+	})
+}
+
+// SetUsageWebhook sets the "usage_webhook" field.
+func (u *APIKeyUpsertBulk) SetUsageWebhook(v domain.UsageWebhook) *APIKeyUpsertBulk {
+	return u.Update(func(s *APIKeyUpsert) {
+		s.SetUsageWebhook(v)
+	})
+}
+
+// UpdateUsageWebhook sets the "usage_webhook" field to the value that was provided on create.
+func (u *APIKeyUpsertBulk) UpdateUsageWebhook() *APIKeyUpsertBulk {
+	return u.Update(func(s *APIKeyUpsert) {
+		s.UpdateUsageWebhook()
+	})
+}
+
+// ClearUsageWebhook clears the value of the "usage_webhook" field.
+func (u *APIKeyUpsertBulk) ClearUsageWebhook() *APIKeyUpsertBulk {
+	return u.Update(func(s *APIKeyUpsert) {
+		s.ClearUsageWebhook()
+	})
+}
+
+// SetRequestSigning sets the "request_signing" field.
+func (u *APIKeyUpsertBulk) SetRequestSigning(v domain.RequestSigning) *APIKeyUpsertBulk {
+	return u.Update(func(s *APIKeyUpsert) {
+		s.SetRequestSigning(v)
+	})
+}
+
+// UpdateRequestSigning sets the "request_signing" field to the value that was provided on create.
+func (u *APIKeyUpsertBulk) UpdateRequestSigning() *APIKeyUpsertBulk {
+	return u.Update(func(s *APIKeyUpsert) {
+		s.UpdateRequestSigning()
+	})
+}
+
+// ClearRequestSigning clears the value of the "request_signing" field.
+func (u *APIKeyUpsertBulk) ClearRequestSigning() *APIKeyUpsertBulk {
+	return u.Update(func(s *APIKeyUpsert) {
+		s.ClearRequestSigning()
+	})
+}
+
+// SetTokenQuota sets the "token_quota" field.
+func (u *APIKeyUpsertBulk) SetTokenQuota(v domain.TokenQuota) *APIKeyUpsertBulk {
+	return u.Update(func(s *APIKeyUpsert) {
+		s.SetTokenQuota(v)
+	})
+}
+
+// UpdateTokenQuota sets the "token_quota" field to the value that was provided on create.
+func (u *APIKeyUpsertBulk) UpdateTokenQuota() *APIKeyUpsertBulk {
+	return u.Update(func(s *APIKeyUpsert) {
+		s.UpdateTokenQuota()
+	})
+}
+
+// ClearTokenQuota clears the value of the "token_quota" field.
+func (u *APIKeyUpsertBulk) ClearTokenQuota() *APIKeyUpsertBulk {
+	return u.Update(func(s *APIKeyUpsert) {
+		s.ClearTokenQuota()
+	})
+}
+
+// SetToolLimits sets the "tool_limits" field.
+func (u *APIKeyUpsertBulk) SetToolLimits(v map[string]int) *APIKeyUpsertBulk {
+	return u.Update(func(s *APIKeyUpsert) {
+		s.SetToolLimits(v)
+	})
+}
+
+// UpdateToolLimits sets the "tool_limits" field to the value that was provided on create.
+func (u *APIKeyUpsertBulk) UpdateToolLimits() *APIKeyUpsertBulk {
+	return u.Update(func(s *APIKeyUpsert) {
+		s.UpdateToolLimits()
+	})
+}
+
+// ClearToolLimits clears the value of the "tool_limits" field.
+func (u *APIKeyUpsertBulk) ClearToolLimits() *APIKeyUpsertBulk {
+	return u.Update(func(s *APIKeyUpsert) {
+		s.ClearToolLimits()
+	})
+}
+
+// SetDebugErrors sets the "debug_errors" field.
+func (u *APIKeyUpsertBulk) SetDebugErrors(v bool) *APIKeyUpsertBulk {
+	return u.Update(func(s *APIKeyUpsert) {
+		s.SetDebugErrors(v)
+	})
+}
+
+// UpdateDebugErrors sets the "debug_errors" field to the value that was provided on create.
+func (u *APIKeyUpsertBulk) UpdateDebugErrors() *APIKeyUpsertBulk {
+	return u.Update(func(s *APIKeyUpsert) {
+		s.UpdateDebugErrors()
+	})
+}
+
 // SetQuota sets the "quota" field.
 func (u *APIKeyUpsertBulk) SetQuota(v float64) *APIKeyUpsertBulk {
 	return u.Update(func(s *APIKeyUpsert) {
@@ -17,6 +17,7 @@ import (
 	"github.com/Wei-Shaw/sub2api/ent/predicate"
 	"github.com/Wei-Shaw/sub2api/ent/usagelog"
 	"github.com/Wei-Shaw/sub2api/ent/user"
+	"github.com/Wei-Shaw/sub2api/internal/domain"
 )
 
 // APIKeyUpdate is the builder for updating APIKey entities.
@@ -134,6 +135,20 @@ func (_u *APIKeyUpdate) SetNillableStatus(v *string) *APIKeyUpdate {
 	return _u
 }
 
+// SetPriorityClass sets the "priority_class" field.
+func (_u *APIKeyUpdate) SetPriorityClass(v string) *APIKeyUpdate {
+	_u.mutation.SetPriorityClass(v)
+	return _u
+}
+
+// SetNillablePriorityClass sets the "priority_class" field if the given value is not nil.
+func (_u *APIKeyUpdate) SetNillablePriorityClass(v *string) *APIKeyUpdate {
+	if v != nil {
+		_u.SetPriorityClass(*v)
+	}
+	return _u
+}
+
 // SetIPWhitelist sets the "ip_whitelist" field.
 func (_u *APIKeyUpdate) SetIPWhitelist(v []string) *APIKeyUpdate {
 	_u.mutation.SetIPWhitelist(v)
@@ -152,6 +167,42 @@ func (_u *APIKeyUpdate) ClearIPWhitelist() *APIKeyUpdate {
 	return _u
 }
 
+// SetAllowedModels sets the "allowed_models" field.
+func (_u *APIKeyUpdate) SetAllowedModels(v []string) *APIKeyUpdate {
+	_u.mutation.SetAllowedModels(v)
+	return _u
+}
+
+// AppendAllowedModels appends value to the "allowed_models" field.
+func (_u *APIKeyUpdate) AppendAllowedModels(v []string) *APIKeyUpdate {
+	_u.mutation.AppendAllowedModels(v)
+	return _u
+}
+
+// ClearAllowedModels clears the value of the "allowed_models" field.
+func (_u *APIKeyUpdate) ClearAllowedModels() *APIKeyUpdate {
+	_u.mutation.ClearAllowedModels()
+	return _u
+}
+
+// SetScopes sets the "scopes" field.
+func (_u *APIKeyUpdate) SetScopes(v []string) *APIKeyUpdate {
+	_u.mutation.SetScopes(v)
+	return _u
+}
+
+// AppendScopes appends value to the "scopes" field.
+func (_u *APIKeyUpdate) AppendScopes(v []string) *APIKeyUpdate {
+	_u.mutation.AppendScopes(v)
+	return _u
+}
+
+// ClearScopes clears the value of the "scopes" field.
+func (_u *APIKeyUpdate) ClearScopes() *APIKeyUpdate {
+	_u.mutation.ClearScopes()
+	return _u
+}
+
 // SetIPBlacklist sets the "ip_blacklist" field.
 func (_u *APIKeyUpdate) SetIPBlacklist(v []string) *APIKeyUpdate {
 	_u.mutation.SetIPBlacklist(v)
@@ -170,6 +221,92 @@ func (_u *APIKeyUpdate) ClearIPBlacklist() *APIKeyUpdate {
 	return _u
 }
 
+// SetRegionPolicy sets the "region_policy" field.
+func (_u *APIKeyUpdate) SetRegionPolicy(v domain.RegionPolicy) *APIKeyUpdate {
+	_u.mutation.SetRegionPolicy(v)
+	return _u
+}
+
+// ClearRegionPolicy clears the value of the "region_policy" field.
+func (_u *APIKeyUpdate) ClearRegionPolicy() *APIKeyUpdate {
+	_u.mutation.ClearRegionPolicy()
+	return _u
+}
+
+// SetSystemPromptPolicy sets the "system_prompt_policy" field.
+func (_u *APIKeyUpdate) SetSystemPromptPolicy(v domain.SystemPromptPolicy) *APIKeyUpdate {
+	_u.mutation.SetSystemPromptPolicy(v)
+	return _u
+}
+
+// ClearSystemPromptPolicy clears the value of the "system_prompt_policy" field.
+func (_u *APIKeyUpdate) ClearSystemPromptPolicy() *APIKeyUpdate {
+	_u.mutation.ClearSystemPromptPolicy()
+	return _u
+}
+
+// SetUsageWebhook sets the "usage_webhook" field.
+func (_u *APIKeyUpdate) SetUsageWebhook(v domain.UsageWebhook) *APIKeyUpdate {
+	_u.mutation.SetUsageWebhook(v)
+	return _u
+}
+
+// ClearUsageWebhook clears the value of the "usage_webhook" field.
+func (_u *APIKeyUpdate) ClearUsageWebhook() *APIKeyUpdate {
+	_u.mutation.ClearUsageWebhook()
+	return _u
+}
+
+// SetRequestSigning sets the "request_signing" field.
+func (_u *APIKeyUpdate) SetRequestSigning(v domain.RequestSigning) *APIKeyUpdate {
+	_u.mutation.SetRequestSigning(v)
+	return _u
+}
+
+// ClearRequestSigning clears the value of the "request_signing" field.
+func (_u *APIKeyUpdate) ClearRequestSigning() *APIKeyUpdate {
+	_u.mutation.ClearRequestSigning()
+	return _u
+}
+
+// SetTokenQuota sets the "token_quota" field.
+func (_u *APIKeyUpdate) SetTokenQuota(v domain.TokenQuota) *APIKeyUpdate {
+	_u.mutation.SetTokenQuota(v)
+	return _u
+}
+
+// ClearTokenQuota clears the value of the "token_quota" field.
+func (_u *APIKeyUpdate) ClearTokenQuota() *APIKeyUpdate {
+	_u.mutation.ClearTokenQuota()
+	return _u
+}
+
+// SetToolLimits sets the "tool_limits" field.
+func (_u *APIKeyUpdate) SetToolLimits(v map[string]int) *APIKeyUpdate {
+	_u.mutation.SetToolLimits(v)
+	return _u
+}
+
+// ClearToolLimits clears the value of the "tool_limits" field.
+func (_u *APIKeyUpdate) ClearToolLimits() *APIKeyUpdate {
+	_u.mutation.ClearToolLimits()
+	return _u
+}
+
+// SetDebugErrors sets the "debug_errors" field.
+func (_u *APIKeyUpdate) SetDebugErrors(v bool) *APIKeyUpdate {
+	_u.mutation.SetDebugErrors(v)
+	return _u
+}
+
+// SetNillableDebugErrors sets the "debug_errors" field if the given value is not nil.
+func (_u *APIKeyUpdate) SetNillableDebugErrors(v *bool) *APIKeyUpdate {
+	if v != nil {
+		_u.SetDebugErrors(*v)
+	}
+	return _u
+}
+
 // SetQuota sets the "quota" field.
 func (_u *APIKeyUpdate) SetQuota(v float64) *APIKeyUpdate {
 	_u.mutation.ResetQuota()
@@ -354,6 +491,11 @@ func (_u *APIKeyUpdate) check() error {
 			return &ValidationError{Name: "status", err: fmt.Errorf(`ent: validator failed for field "APIKey.status": %w`, err)}
 		}
 	}
+	if v, ok := _u.mutation.PriorityClass(); ok {
+		if err := apikey.PriorityClassValidator(v); err != nil {
+			return &ValidationError{Name: "priority_class", err: fmt.Errorf(`ent: validator failed for field "APIKey.priority_class": %w`, err)}
+		}
+	}
 	if _u.mutation.UserCleared() && len(_u.mutation.UserIDs()) > 0 {
 		return errors.New(`ent: clearing a required unique edge "APIKey.user"`)
 	}
@@ -390,20 +532,63 @@ func (_u *APIKeyUpdate) sqlSave(ctx context.Context) (_node int, err error) {
 	if value, ok := _u.mutation.Status(); ok {
 		_spec.SetField(apikey.FieldStatus, field.TypeString, value)
 	}
+	if value, ok := _u.mutation.PriorityClass(); ok {
+		_spec.SetField(apikey.FieldPriorityClass, field.TypeString, value)
+	}
 	if value, ok := _u.mutation.IPWhitelist(); ok {
 		_spec.SetField(apikey.FieldIPWhitelist, field.TypeJSON, value)
 	}
+	if value, ok := _u.mutation.AllowedModels(); ok {
+		_spec.SetField(apikey.FieldAllowedModels, field.TypeJSON, value)
+	}
+	if value, ok := _u.mutation.Scopes(); ok {
+		_spec.SetField(apikey.FieldScopes, field.TypeJSON, value)
+	}
 	if value, ok := _u.mutation.AppendedIPWhitelist(); ok {
 		_spec.AddModifier(func(u *sql.UpdateBuilder) {
 			sqljson.Append(u, apikey.FieldIPWhitelist, value)
 		})
 	}
+	if value, ok := _u.mutation.AppendedAllowedModels(); ok {
+		_spec.AddModifier(func(u *sql.UpdateBuilder) {
+			sqljson.Append(u, apikey.FieldAllowedModels, value)
+		})
+	}
+	if value, ok := _u.mutation.AppendedScopes(); ok {
+		_spec.AddModifier(func(u *sql.UpdateBuilder) {
+			sqljson.Append(u, apikey.FieldScopes, value)
+		})
+	}
 	if _u.mutation.IPWhitelistCleared() {
 		_spec.ClearField(apikey.FieldIPWhitelist, field.TypeJSON)
 	}
+	if _u.mutation.AllowedModelsCleared() {
+		_spec.ClearField(apikey.FieldAllowedModels, field.TypeJSON)
+	}
+	if _u.mutation.ScopesCleared() {
+		_spec.ClearField(apikey.FieldScopes, field.TypeJSON)
+	}
 	if value, ok := _u.mutation.IPBlacklist(); ok {
 		_spec.SetField(apikey.FieldIPBlacklist, field.TypeJSON, value)
 	}
+	if value, ok := _u.mutation.RegionPolicy(); ok {
+		_spec.SetField(apikey.FieldRegionPolicy, field.TypeJSON, value)
+	}
+	if value, ok := _u.mutation.SystemPromptPolicy(); ok {
+		_spec.SetField(apikey.FieldSystemPromptPolicy, field.TypeJSON, value)
+	}
+	if value, ok := _u.mutation.UsageWebhook(); ok {
+		_spec.SetField(apikey.FieldUsageWebhook, field.TypeJSON, value)
+	}
+	if value, ok := _u.mutation.RequestSigning(); ok {
+		_spec.SetField(apikey.FieldRequestSigning, field.TypeJSON, value)
+	}
+	if value, ok := _u.mutation.TokenQuota(); ok {
+		_spec.SetField(apikey.FieldTokenQuota, field.TypeJSON, value)
+	}
+	if value, ok := _u.mutation.ToolLimits(); ok {
+		_spec.SetField(apikey.FieldToolLimits, field.TypeJSON, value)
+	}
 	if value, ok := _u.mutation.AppendedIPBlacklist(); ok {
 		_spec.AddModifier(func(u *sql.UpdateBuilder) {
 			sqljson.Append(u, apikey.FieldIPBlacklist, value)
@@ -412,6 +597,27 @@ func (_u *APIKeyUpdate) sqlSave(ctx context.Context) (_node int, err error) {
 	if _u.mutation.IPBlacklistCleared() {
 		_spec.ClearField(apikey.FieldIPBlacklist, field.TypeJSON)
 	}
+	if _u.mutation.RegionPolicyCleared() {
+		_spec.ClearField(apikey.FieldRegionPolicy, field.TypeJSON)
+	}
+	if _u.mutation.SystemPromptPolicyCleared() {
+		_spec.ClearField(apikey.FieldSystemPromptPolicy, field.TypeJSON)
+	}
+	if _u.mutation.UsageWebhookCleared() {
+		_spec.ClearField(apikey.FieldUsageWebhook, field.TypeJSON)
+	}
+	if _u.mutation.RequestSigningCleared() {
+		_spec.ClearField(apikey.FieldRequestSigning, field.TypeJSON)
+	}
+	if _u.mutation.TokenQuotaCleared() {
+		_spec.ClearField(apikey.FieldTokenQuota, field.TypeJSON)
+	}
+	if _u.mutation.ToolLimitsCleared() {
+		_spec.ClearField(apikey.FieldToolLimits, field.TypeJSON)
+	}
+	if value, ok := _u.mutation.DebugErrors(); ok {
+		_spec.SetField(apikey.FieldDebugErrors, field.TypeBool, value)
+	}
 	if value, ok := _u.mutation.Quota(); ok {
 		_spec.SetField(apikey.FieldQuota, field.TypeFloat64, value)
 	}
@@ -655,6 +861,20 @@ func (_u *APIKeyUpdateOne) SetNillableStatus(v *string) *APIKeyUpdateOne {
 	return _u
 }
 
+// SetPriorityClass sets the "priority_class" field.
+func (_u *APIKeyUpdateOne) SetPriorityClass(v string) *APIKeyUpdateOne {
+	_u.mutation.SetPriorityClass(v)
+	return _u
+}
+
+// SetNillablePriorityClass sets the "priority_class" field if the given value is not nil.
+func (_u *APIKeyUpdateOne) SetNillablePriorityClass(v *string) *APIKeyUpdateOne {
+	if v != nil {
+		_u.SetPriorityClass(*v)
+	}
+	return _u
+}
+
 // SetIPWhitelist sets the "ip_whitelist" field.
 func (_u *APIKeyUpdateOne) SetIPWhitelist(v []string) *APIKeyUpdateOne {
 	_u.mutation.SetIPWhitelist(v)
@@ -673,6 +893,42 @@ func (_u *APIKeyUpdateOne) ClearIPWhitelist() *APIKeyUpdateOne {
 	return _u
 }
 
+// SetAllowedModels sets the "allowed_models" field.
+func (_u *APIKeyUpdateOne) SetAllowedModels(v []string) *APIKeyUpdateOne {
+	_u.mutation.SetAllowedModels(v)
+	return _u
+}
+
+// AppendAllowedModels appends value to the "allowed_models" field.
+func (_u *APIKeyUpdateOne) AppendAllowedModels(v []string) *APIKeyUpdateOne {
+	_u.mutation.AppendAllowedModels(v)
+	return _u
+}
+
+// ClearAllowedModels clears the value of the "allowed_models" field.
+func (_u *APIKeyUpdateOne) ClearAllowedModels() *APIKeyUpdateOne {
+	_u.mutation.ClearAllowedModels()
+	return _u
+}
+
+// SetScopes sets the "scopes" field.
+func (_u *APIKeyUpdateOne) SetScopes(v []string) *APIKeyUpdateOne {
+	_u.mutation.SetScopes(v)
+	return _u
+}
+
+// AppendScopes appends value to the "scopes" field.
+func (_u *APIKeyUpdateOne) AppendScopes(v []string) *APIKeyUpdateOne {
+	_u.mutation.AppendScopes(v)
+	return _u
+}
+
+// ClearScopes clears the value of the "scopes" field.
+func (_u *APIKeyUpdateOne) ClearScopes() *APIKeyUpdateOne {
+	_u.mutation.ClearScopes()
+	return _u
+}
+
 // SetIPBlacklist sets the "ip_blacklist" field.
 func (_u *APIKeyUpdateOne) SetIPBlacklist(v []string) *APIKeyUpdateOne {
 	_u.mutation.SetIPBlacklist(v)
@@ -691,6 +947,92 @@ func (_u *APIKeyUpdateOne) ClearIPBlacklist() *APIKeyUpdateOne {
 	return _u
 }
 
+// SetRegionPolicy sets the "region_policy" field.
+func (_u *APIKeyUpdateOne) SetRegionPolicy(v domain.RegionPolicy) *APIKeyUpdateOne {
+	_u.mutation.SetRegionPolicy(v)
+	return _u
+}
+
+// ClearRegionPolicy clears the value of the "region_policy" field.
+func (_u *APIKeyUpdateOne) ClearRegionPolicy() *APIKeyUpdateOne {
+	_u.mutation.ClearRegionPolicy()
+	return _u
+}
+
+// SetSystemPromptPolicy sets the "system_prompt_policy" field.
+func (_u *APIKeyUpdateOne) SetSystemPromptPolicy(v domain.SystemPromptPolicy) *APIKeyUpdateOne {
+	_u.mutation.SetSystemPromptPolicy(v)
+	return _u
+}
+
+// ClearSystemPromptPolicy clears the value of the "system_prompt_policy" field.
+func (_u *APIKeyUpdateOne) ClearSystemPromptPolicy() *APIKeyUpdateOne {
+	_u.mutation.ClearSystemPromptPolicy()
+	return _u
+}
+
+// SetUsageWebhook sets the "usage_webhook" field.
+func (_u *APIKeyUpdateOne) SetUsageWebhook(v domain.UsageWebhook) *APIKeyUpdateOne {
+	_u.mutation.SetUsageWebhook(v)
+	return _u
+}
+
+// ClearUsageWebhook clears the value of the "usage_webhook" field.
+func (_u *APIKeyUpdateOne) ClearUsageWebhook() *APIKeyUpdateOne {
+	_u.mutation.ClearUsageWebhook()
+	return _u
+}
+
+// SetRequestSigning sets the "request_signing" field.
+func (_u *APIKeyUpdateOne) SetRequestSigning(v domain.RequestSigning) *APIKeyUpdateOne {
+	_u.mutation.SetRequestSigning(v)
+	return _u
+}
+
+// ClearRequestSigning clears the value of the "request_signing" field.
+func (_u *APIKeyUpdateOne) ClearRequestSigning() *APIKeyUpdateOne {
+	_u.mutation.ClearRequestSigning()
+	return _u
+}
+
+// SetTokenQuota sets the "token_quota" field.
+func (_u *APIKeyUpdateOne) SetTokenQuota(v domain.TokenQuota) *APIKeyUpdateOne {
+	_u.mutation.SetTokenQuota(v)
+	return _u
+}
+
+// ClearTokenQuota clears the value of the "token_quota" field.
+func (_u *APIKeyUpdateOne) ClearTokenQuota() *APIKeyUpdateOne {
+	_u.mutation.ClearTokenQuota()
+	return _u
+}
+
+// SetToolLimits sets the "tool_limits" field.
+func (_u *APIKeyUpdateOne) SetToolLimits(v map[string]int) *APIKeyUpdateOne {
+	_u.mutation.SetToolLimits(v)
+	return _u
+}
+
+// ClearToolLimits clears the value of the "tool_limits" field.
+func (_u *APIKeyUpdateOne) ClearToolLimits() *APIKeyUpdateOne {
+	_u.mutation.ClearToolLimits()
+	return _u
+}
+
+// SetDebugErrors sets the "debug_errors" field.
+func (_u *APIKeyUpdateOne) SetDebugErrors(v bool) *APIKeyUpdateOne {
+	_u.mutation.SetDebugErrors(v)
+	return _u
+}
+
+// SetNillableDebugErrors sets the "debug_errors" field if the given value is not nil.
+func (_u *APIKeyUpdateOne) SetNillableDebugErrors(v *bool) *APIKeyUpdateOne {
+	if v != nil {
+		_u.SetDebugErrors(*v)
+	}
+	return _u
+}
+
 // SetQuota sets the "quota" field.
 func (_u *APIKeyUpdateOne) SetQuota(v float64) *APIKeyUpdateOne {
 	_u.mutation.ResetQuota()
@@ -888,6 +1230,11 @@ func (_u *APIKeyUpdateOne) check() error {
 			return &ValidationError{Name: "status", err: fmt.Errorf(`ent: validator failed for field "APIKey.status": %w`, err)}
 		}
 	}
+	if v, ok := _u.mutation.PriorityClass(); ok {
+		if err := apikey.PriorityClassValidator(v); err != nil {
+			return &ValidationError{Name: "priority_class", err: fmt.Errorf(`ent: validator failed for field "APIKey.priority_class": %w`, err)}
+		}
+	}
 	if _u.mutation.UserCleared() && len(_u.mutation.UserIDs()) > 0 {
 		return errors.New(`ent: clearing a required unique edge "APIKey.user"`)
 	}
@@ -941,20 +1288,63 @@ func (_u *APIKeyUpdateOne) sqlSave(ctx context.Context) (_node *APIKey, err erro
 	if value, ok := _u.mutation.Status(); ok {
 		_spec.SetField(apikey.FieldStatus, field.TypeString, value)
 	}
+	if value, ok := _u.mutation.PriorityClass(); ok {
+		_spec.SetField(apikey.FieldPriorityClass, field.TypeString, value)
+	}
 	if value, ok := _u.mutation.IPWhitelist(); ok {
 		_spec.SetField(apikey.FieldIPWhitelist, field.TypeJSON, value)
 	}
+	if value, ok := _u.mutation.AllowedModels(); ok {
+		_spec.SetField(apikey.FieldAllowedModels, field.TypeJSON, value)
+	}
+	if value, ok := _u.mutation.Scopes(); ok {
+		_spec.SetField(apikey.FieldScopes, field.TypeJSON, value)
+	}
 	if value, ok := _u.mutation.AppendedIPWhitelist(); ok {
 		_spec.AddModifier(func(u *sql.UpdateBuilder) {
 			sqljson.Append(u, apikey.FieldIPWhitelist, value)
 		})
 	}
+	if value, ok := _u.mutation.AppendedAllowedModels(); ok {
+		_spec.AddModifier(func(u *sql.UpdateBuilder) {
+			sqljson.Append(u, apikey.FieldAllowedModels, value)
+		})
+	}
+	if value, ok := _u.mutation.AppendedScopes(); ok {
+		_spec.AddModifier(func(u *sql.UpdateBuilder) {
+			sqljson.Append(u, apikey.FieldScopes, value)
+		})
+	}
 	if _u.mutation.IPWhitelistCleared() {
 		_spec.ClearField(apikey.FieldIPWhitelist, field.TypeJSON)
 	}
+	if _u.mutation.AllowedModelsCleared() {
+		_spec.ClearField(apikey.FieldAllowedModels, field.TypeJSON)
+	}
+	if _u.mutation.ScopesCleared() {
+		_spec.ClearField(apikey.FieldScopes, field.TypeJSON)
+	}
 	if value, ok := _u.mutation.IPBlacklist(); ok {
 		_spec.SetField(apikey.FieldIPBlacklist, field.TypeJSON, value)
 	}
+	if value, ok := _u.mutation.RegionPolicy(); ok {
+		_spec.SetField(apikey.FieldRegionPolicy, field.TypeJSON, value)
+	}
+	if value, ok := _u.mutation.SystemPromptPolicy(); ok {
+		_spec.SetField(apikey.FieldSystemPromptPolicy, field.TypeJSON, value)
+	}
+	if value, ok := _u.mutation.UsageWebhook(); ok {
+		_spec.SetField(apikey.FieldUsageWebhook, field.TypeJSON, value)
+	}
+	if value, ok := _u.mutation.RequestSigning(); ok {
+		_spec.SetField(apikey.FieldRequestSigning, field.TypeJSON, value)
+	}
+	if value, ok := _u.mutation.TokenQuota(); ok {
+		_spec.SetField(apikey.FieldTokenQuota, field.TypeJSON, value)
+	}
+	if value, ok := _u.mutation.ToolLimits(); ok {
+		_spec.SetField(apikey.FieldToolLimits, field.TypeJSON, value)
+	}
 	if value, ok := _u.mutation.AppendedIPBlacklist(); ok {
 		_spec.AddModifier(func(u *sql.UpdateBuilder) {
 			sqljson.Append(u, apikey.FieldIPBlacklist, value)
@@ -963,6 +1353,27 @@ func (_u *APIKeyUpdateOne) sqlSave(ctx context.Context) (_node *APIKey, err erro
 	if _u.mutation.IPBlacklistCleared() {
 		_spec.ClearField(apikey.FieldIPBlacklist, field.TypeJSON)
 	}
+	if _u.mutation.RegionPolicyCleared() {
+		_spec.ClearField(apikey.FieldRegionPolicy, field.TypeJSON)
+	}
+	if _u.mutation.SystemPromptPolicyCleared() {
+		_spec.ClearField(apikey.FieldSystemPromptPolicy, field.TypeJSON)
+	}
+	if _u.mutation.UsageWebhookCleared() {
+		_spec.ClearField(apikey.FieldUsageWebhook, field.TypeJSON)
+	}
+	if _u.mutation.RequestSigningCleared() {
+		_spec.ClearField(apikey.FieldRequestSigning, field.TypeJSON)
+	}
+	if _u.mutation.TokenQuotaCleared() {
+		_spec.ClearField(apikey.FieldTokenQuota, field.TypeJSON)
+	}
+	if _u.mutation.ToolLimitsCleared() {
+		_spec.ClearField(apikey.FieldToolLimits, field.TypeJSON)
+	}
+	if value, ok := _u.mutation.DebugErrors(); ok {
+		_spec.SetField(apikey.FieldDebugErrors, field.TypeBool, value)
+	}
 	if value, ok := _u.mutation.Quota(); ok {
 		_spec.SetField(apikey.FieldQuota, field.TypeFloat64, value)
 	}
@@ -32,6 +32,10 @@ type ErrorPassthroughRule struct {
 	ErrorCodes []int `json:"error_codes,omitempty"`
 	// Keywords holds the value of the "keywords" field.
 	Keywords []string `json:"keywords,omitempty"`
+	// BodyPatterns holds the value of the "body_patterns" field.
+	BodyPatterns []string `json:"body_patterns,omitempty"`
+	// HeaderMatches holds the value of the "header_matches" field.
+	HeaderMatches map[string]string `json:"header_matches,omitempty"`
 	// MatchMode holds the value of the "match_mode" field.
 	MatchMode string `json:"match_mode,omitempty"`
 	// Platforms holds the value of the "platforms" field.
@@ -56,7 +60,7 @@ func (*ErrorPassthroughRule) scanValues(columns []string) ([]any, error) {
 	values := make([]any, len(columns))
 	for i := range columns {
 		switch columns[i] {
-		case errorpassthroughrule.FieldErrorCodes, errorpassthroughrule.FieldKeywords, errorpassthroughrule.FieldPlatforms:
+		case errorpassthroughrule.FieldErrorCodes, errorpassthroughrule.FieldKeywords, errorpassthroughrule.FieldBodyPatterns, errorpassthroughrule.FieldHeaderMatches, errorpassthroughrule.FieldPlatforms:
 			values[i] = new([]byte)
 		case errorpassthroughrule.FieldEnabled, errorpassthroughrule.FieldPassthroughCode, errorpassthroughrule.FieldPassthroughBody, errorpassthroughrule.FieldSkipMonitoring:
 			values[i] = new(sql.NullBool)
@@ -133,6 +137,22 @@ func (_m *ErrorPassthroughRule) assignValues(columns []string, values []any) err
 					return fmt.Errorf("unmarshal field keywords: %w", err)
 				}
 			}
+		case errorpassthroughrule.FieldBodyPatterns:
+			if value, ok := values[i].(*[]byte); !ok {
+				return fmt.Errorf("unexpected type %T for field body_patterns", values[i])
+			} else if value != nil && len(*value) > 0 {
+				if err := json.Unmarshal(*value, &_m.BodyPatterns); err != nil {
+					return fmt.Errorf("unmarshal field body_patterns: %w", err)
+				}
+			}
+		case errorpassthroughrule.FieldHeaderMatches:
+			if value, ok := values[i].(*[]byte); !ok {
+				return fmt.Errorf("unexpected type %T for field header_matches", values[i])
+			} else if value != nil && len(*value) > 0 {
+				if err := json.Unmarshal(*value, &_m.HeaderMatches); err != nil {
+					return fmt.Errorf("unmarshal field header_matches: %w", err)
+				}
+			}
 		case errorpassthroughrule.FieldMatchMode:
 			if value, ok := values[i].(*sql.NullString); !ok {
 				return fmt.Errorf("unexpected type %T for field match_mode", values[i])
@@ -243,6 +263,12 @@ func (_m *ErrorPassthroughRule) String() string {
 	builder.WriteString("keywords=")
 	builder.WriteString(fmt.Sprintf("%v", _m.Keywords))
 	builder.WriteString(", ")
+	builder.WriteString("body_patterns=")
+	builder.WriteString(fmt.Sprintf("%v", _m.BodyPatterns))
+	builder.WriteString(", ")
+	builder.WriteString("header_matches=")
+	builder.WriteString(fmt.Sprintf("%v", _m.HeaderMatches))
+	builder.WriteString(", ")
 	builder.WriteString("match_mode=")
 	builder.WriteString(_m.MatchMode)
 	builder.WriteString(", ")
@@ -27,6 +27,10 @@ const (
 	FieldErrorCodes = "error_codes"
 	// FieldKeywords holds the string denoting the keywords field in the database.
 	FieldKeywords = "keywords"
+	// FieldBodyPatterns holds the string denoting the body_patterns field in the database.
+	FieldBodyPatterns = "body_patterns"
+	// FieldHeaderMatches holds the string denoting the header_matches field in the database.
+	FieldHeaderMatches = "header_matches"
 	// FieldMatchMode holds the string denoting the match_mode field in the database.
 	FieldMatchMode = "match_mode"
 	// FieldPlatforms holds the string denoting the platforms field in the database.
@@ -57,6 +61,8 @@ var Columns = []string{
 	FieldPriority,
 	FieldErrorCodes,
 	FieldKeywords,
+	FieldBodyPatterns,
+	FieldHeaderMatches,
 	FieldMatchMode,
 	FieldPlatforms,
 	FieldPassthroughCode,
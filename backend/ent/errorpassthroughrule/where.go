@@ -324,11 +324,31 @@ func KeywordsIsNil() predicate.ErrorPassthroughRule {
 	return predicate.ErrorPassthroughRule(sql.FieldIsNull(FieldKeywords))
 }
 
+// BodyPatternsIsNil applies the IsNil predicate on the "body_patterns" field.
+func BodyPatternsIsNil() predicate.ErrorPassthroughRule {
+	return predicate.ErrorPassthroughRule(sql.FieldIsNull(FieldBodyPatterns))
+}
+
+// HeaderMatchesIsNil applies the IsNil predicate on the "header_matches" field.
+func HeaderMatchesIsNil() predicate.ErrorPassthroughRule {
+	return predicate.ErrorPassthroughRule(sql.FieldIsNull(FieldHeaderMatches))
+}
+
 // KeywordsNotNil applies the NotNil predicate on the "keywords" field.
 func KeywordsNotNil() predicate.ErrorPassthroughRule {
 	return predicate.ErrorPassthroughRule(sql.FieldNotNull(FieldKeywords))
 }
 
+// BodyPatternsNotNil applies the NotNil predicate on the "body_patterns" field.
+func BodyPatternsNotNil() predicate.ErrorPassthroughRule {
+	return predicate.ErrorPassthroughRule(sql.FieldNotNull(FieldBodyPatterns))
+}
+
+// HeaderMatchesNotNil applies the NotNil predicate on the "header_matches" field.
+func HeaderMatchesNotNil() predicate.ErrorPassthroughRule {
+	return predicate.ErrorPassthroughRule(sql.FieldNotNull(FieldHeaderMatches))
+}
+
 // MatchModeEQ applies the EQ predicate on the "match_mode" field.
 func MatchModeEQ(v string) predicate.ErrorPassthroughRule {
 	return predicate.ErrorPassthroughRule(sql.FieldEQ(FieldMatchMode, v))
@@ -96,6 +96,18 @@ func (_c *ErrorPassthroughRuleCreate) SetKeywords(v []string) *ErrorPassthroughR
 	return _c
 }
 
+// SetBodyPatterns sets the "body_patterns" field.
+func (_c *ErrorPassthroughRuleCreate) SetBodyPatterns(v []string) *ErrorPassthroughRuleCreate {
+	_c.mutation.SetBodyPatterns(v)
+	return _c
+}
+
+// SetHeaderMatches sets the "header_matches" field.
+func (_c *ErrorPassthroughRuleCreate) SetHeaderMatches(v map[string]string) *ErrorPassthroughRuleCreate {
+	_c.mutation.SetHeaderMatches(v)
+	return _c
+}
+
 // SetMatchMode sets the "match_mode" field.
 func (_c *ErrorPassthroughRuleCreate) SetMatchMode(v string) *ErrorPassthroughRuleCreate {
 	_c.mutation.SetMatchMode(v)
@@ -363,6 +375,14 @@ func (_c *ErrorPassthroughRuleCreate) createSpec() (*ErrorPassthroughRule, *sqlg
 		_spec.SetField(errorpassthroughrule.FieldKeywords, field.TypeJSON, value)
 		_node.Keywords = value
 	}
+	if value, ok := _c.mutation.BodyPatterns(); ok {
+		_spec.SetField(errorpassthroughrule.FieldBodyPatterns, field.TypeJSON, value)
+		_node.BodyPatterns = value
+	}
+	if value, ok := _c.mutation.HeaderMatches(); ok {
+		_spec.SetField(errorpassthroughrule.FieldHeaderMatches, field.TypeJSON, value)
+		_node.HeaderMatches = value
+	}
 	if value, ok := _c.mutation.MatchMode(); ok {
 		_spec.SetField(errorpassthroughrule.FieldMatchMode, field.TypeString, value)
 		_node.MatchMode = value
@@ -525,18 +545,54 @@ func (u *ErrorPassthroughRuleUpsert) SetKeywords(v []string) *ErrorPassthroughRu
 	return u
 }
 
+// SetBodyPatterns sets the "body_patterns" field.
+func (u *ErrorPassthroughRuleUpsert) SetBodyPatterns(v []string) *ErrorPassthroughRuleUpsert {
+	u.Set(errorpassthroughrule.FieldBodyPatterns, v)
+	return u
+}
+
+// SetHeaderMatches sets the "header_matches" field.
+func (u *ErrorPassthroughRuleUpsert) SetHeaderMatches(v map[string]string) *ErrorPassthroughRuleUpsert {
+	u.Set(errorpassthroughrule.FieldHeaderMatches, v)
+	return u
+}
+
 // UpdateKeywords sets the "keywords" field to the value that was provided on create.
 func (u *ErrorPassthroughRuleUpsert) UpdateKeywords() *ErrorPassthroughRuleUpsert {
 	u.SetExcluded(errorpassthroughrule.FieldKeywords)
 	return u
 }
 
+// UpdateBodyPatterns sets the "body_patterns" field to the value that was provided on create.
+func (u *ErrorPassthroughRuleUpsert) UpdateBodyPatterns() *ErrorPassthroughRuleUpsert {
+	u.SetExcluded(errorpassthroughrule.FieldBodyPatterns)
+	return u
+}
+
+// UpdateHeaderMatches sets the "header_matches" field to the value that was provided on create.
+func (u *ErrorPassthroughRuleUpsert) UpdateHeaderMatches() *ErrorPassthroughRuleUpsert {
+	u.SetExcluded(errorpassthroughrule.FieldHeaderMatches)
+	return u
+}
+
 // ClearKeywords clears the value of the "keywords" field.
 func (u *ErrorPassthroughRuleUpsert) ClearKeywords() *ErrorPassthroughRuleUpsert {
 	u.SetNull(errorpassthroughrule.FieldKeywords)
 	return u
 }
 
+// ClearBodyPatterns clears the value of the "body_patterns" field.
+func (u *ErrorPassthroughRuleUpsert) ClearBodyPatterns() *ErrorPassthroughRuleUpsert {
+	u.SetNull(errorpassthroughrule.FieldBodyPatterns)
+	return u
+}
+
+// ClearHeaderMatches clears the value of the "header_matches" field.
+func (u *ErrorPassthroughRuleUpsert) ClearHeaderMatches() *ErrorPassthroughRuleUpsert {
+	u.SetNull(errorpassthroughrule.FieldHeaderMatches)
+	return u
+}
+
 // SetMatchMode sets the "match_mode" field.
 func (u *ErrorPassthroughRuleUpsert) SetMatchMode(v string) *ErrorPassthroughRuleUpsert {
 	u.Set(errorpassthroughrule.FieldMatchMode, v)
@@ -799,6 +855,20 @@ func (u *ErrorPassthroughRuleUpsertOne) SetKeywords(v []string) *ErrorPassthroug
 	})
 }
 
+// SetBodyPatterns sets the "body_patterns" field.
+func (u *ErrorPassthroughRuleUpsertOne) SetBodyPatterns(v []string) *ErrorPassthroughRuleUpsertOne {
+	return u.Update(func(s *ErrorPassthroughRuleUpsert) {
+		s.SetBodyPatterns(v)
+	})
+}
+
+// SetHeaderMatches sets the "header_matches" field.
+func (u *ErrorPassthroughRuleUpsertOne) SetHeaderMatches(v map[string]string) *ErrorPassthroughRuleUpsertOne {
+	return u.Update(func(s *ErrorPassthroughRuleUpsert) {
+		s.SetHeaderMatches(v)
+	})
+}
+
 // UpdateKeywords sets the "keywords" field to the value that was provided on create.
 func (u *ErrorPassthroughRuleUpsertOne) UpdateKeywords() *ErrorPassthroughRuleUpsertOne {
 	return u.Update(func(s *ErrorPassthroughRuleUpsert) {
@@ -806,6 +876,20 @@ func (u *ErrorPassthroughRuleUpsertOne) UpdateKeywords() *ErrorPassthroughRuleUp
 	})
 }
 
+// UpdateBodyPatterns sets the "body_patterns" field to the value that was provided on create.
+func (u *ErrorPassthroughRuleUpsertOne) UpdateBodyPatterns() *ErrorPassthroughRuleUpsertOne {
+	return u.Update(func(s *ErrorPassthroughRuleUpsert) {
+		s.UpdateBodyPatterns()
+	})
+}
+
+// UpdateHeaderMatches sets the "header_matches" field to the value that was provided on create.
+func (u *ErrorPassthroughRuleUpsertOne) UpdateHeaderMatches() *ErrorPassthroughRuleUpsertOne {
+	return u.Update(func(s *ErrorPassthroughRuleUpsert) {
+		s.UpdateHeaderMatches()
+	})
+}
+
 // ClearKeywords clears the value of the "keywords" field.
 func (u *ErrorPassthroughRuleUpsertOne) ClearKeywords() *ErrorPassthroughRuleUpsertOne {
 	return u.Update(func(s *ErrorPassthroughRuleUpsert) {
@@ -813,6 +897,20 @@ func (u *ErrorPassthroughRuleUpsertOne) ClearKeywords() *ErrorPassthroughRuleUps
 	})
 }
 
+// ClearBodyPatterns clears the value of the "body_patterns" field.
+func (u *ErrorPassthroughRuleUpsertOne) ClearBodyPatterns() *ErrorPassthroughRuleUpsertOne {
+	return u.Update(func(s *ErrorPassthroughRuleUpsert) {
+		s.ClearBodyPatterns()
+	})
+}
+
+// ClearHeaderMatches clears the value of the "header_matches" field.
+func (u *ErrorPassthroughRuleUpsertOne) ClearHeaderMatches() *ErrorPassthroughRuleUpsertOne {
+	return u.Update(func(s *ErrorPassthroughRuleUpsert) {
+		s.ClearHeaderMatches()
+	})
+}
+
 // SetMatchMode sets the "match_mode" field.
 func (u *ErrorPassthroughRuleUpsertOne) SetMatchMode(v string) *ErrorPassthroughRuleUpsertOne {
 	return u.Update(func(s *ErrorPassthroughRuleUpsert) {
@@ -1262,6 +1360,20 @@ func (u *ErrorPassthroughRuleUpsertBulk) SetKeywords(v []string) *ErrorPassthrou
 	})
 }
 
+// SetBodyPatterns sets the "body_patterns" field.
+func (u *ErrorPassthroughRuleUpsertBulk) SetBodyPatterns(v []string) *ErrorPassthroughRuleUpsertBulk {
+	return u.Update(func(s *ErrorPassthroughRuleUpsert) {
+		s.SetBodyPatterns(v)
+	})
+}
+
+// SetHeaderMatches sets the "header_matches" field.
+func (u *ErrorPassthroughRuleUpsertBulk) SetHeaderMatches(v map[string]string) *ErrorPassthroughRuleUpsertBulk {
+	return u.Update(func(s *ErrorPassthroughRuleUpsert) {
+		s.SetHeaderMatches(v)
+	})
+}
+
 // UpdateKeywords sets the "keywords" field to the value that was provided on create.
 func (u *ErrorPassthroughRuleUpsertBulk) UpdateKeywords() *ErrorPassthroughRuleUpsertBulk {
 	return u.Update(func(s *ErrorPassthroughRuleUpsert) {
@@ -1269,6 +1381,20 @@ func (u *ErrorPassthroughRuleUpsertBulk) UpdateKeywords() *ErrorPassthroughRuleU
 	})
 }
 
+// UpdateBodyPatterns sets the "body_patterns" field to the value that was provided on create.
+func (u *ErrorPassthroughRuleUpsertBulk) UpdateBodyPatterns() *ErrorPassthroughRuleUpsertBulk {
+	return u.Update(func(s *ErrorPassthroughRuleUpsert) {
+		s.UpdateBodyPatterns()
+	})
+}
+
+// UpdateHeaderMatches sets the "header_matches" field to the value that was provided on create.
+func (u *ErrorPassthroughRuleUpsertBulk) UpdateHeaderMatches() *ErrorPassthroughRuleUpsertBulk {
+	return u.Update(func(s *ErrorPassthroughRuleUpsert) {
+		s.UpdateHeaderMatches()
+	})
+}
+
 // ClearKeywords clears the value of the "keywords" field.
 func (u *ErrorPassthroughRuleUpsertBulk) ClearKeywords() *ErrorPassthroughRuleUpsertBulk {
 	return u.Update(func(s *ErrorPassthroughRuleUpsert) {
@@ -1276,6 +1402,20 @@ func (u *ErrorPassthroughRuleUpsertBulk) ClearKeywords() *ErrorPassthroughRuleUp
 	})
 }
 
+// ClearBodyPatterns clears the value of the "body_patterns" field.
+func (u *ErrorPassthroughRuleUpsertBulk) ClearBodyPatterns() *ErrorPassthroughRuleUpsertBulk {
+	return u.Update(func(s *ErrorPassthroughRuleUpsert) {
+		s.ClearBodyPatterns()
+	})
+}
+
+// ClearHeaderMatches clears the value of the "header_matches" field.
+func (u *ErrorPassthroughRuleUpsertBulk) ClearHeaderMatches() *ErrorPassthroughRuleUpsertBulk {
+	return u.Update(func(s *ErrorPassthroughRuleUpsert) {
+		s.ClearHeaderMatches()
+	})
+}
+
 // SetMatchMode sets the "match_mode" field.
 func (u *ErrorPassthroughRuleUpsertBulk) SetMatchMode(v string) *ErrorPassthroughRuleUpsertBulk {
 	return u.Update(func(s *ErrorPassthroughRuleUpsert) {
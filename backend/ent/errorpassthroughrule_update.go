@@ -108,18 +108,48 @@ func (_u *ErrorPassthroughRuleUpdate) SetKeywords(v []string) *ErrorPassthroughR
 	return _u
 }
 
+// SetBodyPatterns sets the "body_patterns" field.
+func (_u *ErrorPassthroughRuleUpdate) SetBodyPatterns(v []string) *ErrorPassthroughRuleUpdate {
+	_u.mutation.SetBodyPatterns(v)
+	return _u
+}
+
+// SetHeaderMatches sets the "header_matches" field.
+func (_u *ErrorPassthroughRuleUpdate) SetHeaderMatches(v map[string]string) *ErrorPassthroughRuleUpdate {
+	_u.mutation.SetHeaderMatches(v)
+	return _u
+}
+
 // AppendKeywords appends value to the "keywords" field.
 func (_u *ErrorPassthroughRuleUpdate) AppendKeywords(v []string) *ErrorPassthroughRuleUpdate {
 	_u.mutation.AppendKeywords(v)
 	return _u
 }
 
+// AppendBodyPatterns appends value to the "body_patterns" field.
+func (_u *ErrorPassthroughRuleUpdate) AppendBodyPatterns(v []string) *ErrorPassthroughRuleUpdate {
+	_u.mutation.AppendBodyPatterns(v)
+	return _u
+}
+
 // ClearKeywords clears the value of the "keywords" field.
 func (_u *ErrorPassthroughRuleUpdate) ClearKeywords() *ErrorPassthroughRuleUpdate {
 	_u.mutation.ClearKeywords()
 	return _u
 }
 
+// ClearBodyPatterns clears the value of the "body_patterns" field.
+func (_u *ErrorPassthroughRuleUpdate) ClearBodyPatterns() *ErrorPassthroughRuleUpdate {
+	_u.mutation.ClearBodyPatterns()
+	return _u
+}
+
+// ClearHeaderMatches clears the value of the "header_matches" field.
+func (_u *ErrorPassthroughRuleUpdate) ClearHeaderMatches() *ErrorPassthroughRuleUpdate {
+	_u.mutation.ClearHeaderMatches()
+	return _u
+}
+
 // SetMatchMode sets the "match_mode" field.
 func (_u *ErrorPassthroughRuleUpdate) SetMatchMode(v string) *ErrorPassthroughRuleUpdate {
 	_u.mutation.SetMatchMode(v)
@@ -358,14 +388,31 @@ func (_u *ErrorPassthroughRuleUpdate) sqlSave(ctx context.Context) (_node int, e
 	if value, ok := _u.mutation.Keywords(); ok {
 		_spec.SetField(errorpassthroughrule.FieldKeywords, field.TypeJSON, value)
 	}
+	if value, ok := _u.mutation.BodyPatterns(); ok {
+		_spec.SetField(errorpassthroughrule.FieldBodyPatterns, field.TypeJSON, value)
+	}
+	if value, ok := _u.mutation.HeaderMatches(); ok {
+		_spec.SetField(errorpassthroughrule.FieldHeaderMatches, field.TypeJSON, value)
+	}
 	if value, ok := _u.mutation.AppendedKeywords(); ok {
 		_spec.AddModifier(func(u *sql.UpdateBuilder) {
 			sqljson.Append(u, errorpassthroughrule.FieldKeywords, value)
 		})
 	}
+	if value, ok := _u.mutation.AppendedBodyPatterns(); ok {
+		_spec.AddModifier(func(u *sql.UpdateBuilder) {
+			sqljson.Append(u, errorpassthroughrule.FieldBodyPatterns, value)
+		})
+	}
 	if _u.mutation.KeywordsCleared() {
 		_spec.ClearField(errorpassthroughrule.FieldKeywords, field.TypeJSON)
 	}
+	if _u.mutation.BodyPatternsCleared() {
+		_spec.ClearField(errorpassthroughrule.FieldBodyPatterns, field.TypeJSON)
+	}
+	if _u.mutation.HeaderMatchesCleared() {
+		_spec.ClearField(errorpassthroughrule.FieldHeaderMatches, field.TypeJSON)
+	}
 	if value, ok := _u.mutation.MatchMode(); ok {
 		_spec.SetField(errorpassthroughrule.FieldMatchMode, field.TypeString, value)
 	}
@@ -509,18 +556,48 @@ func (_u *ErrorPassthroughRuleUpdateOne) SetKeywords(v []string) *ErrorPassthrou
 	return _u
 }
 
+// SetBodyPatterns sets the "body_patterns" field.
+func (_u *ErrorPassthroughRuleUpdateOne) SetBodyPatterns(v []string) *ErrorPassthroughRuleUpdateOne {
+	_u.mutation.SetBodyPatterns(v)
+	return _u
+}
+
+// SetHeaderMatches sets the "header_matches" field.
+func (_u *ErrorPassthroughRuleUpdateOne) SetHeaderMatches(v map[string]string) *ErrorPassthroughRuleUpdateOne {
+	_u.mutation.SetHeaderMatches(v)
+	return _u
+}
+
 // AppendKeywords appends value to the "keywords" field.
 func (_u *ErrorPassthroughRuleUpdateOne) AppendKeywords(v []string) *ErrorPassthroughRuleUpdateOne {
 	_u.mutation.AppendKeywords(v)
 	return _u
 }
 
+// AppendBodyPatterns appends value to the "body_patterns" field.
+func (_u *ErrorPassthroughRuleUpdateOne) AppendBodyPatterns(v []string) *ErrorPassthroughRuleUpdateOne {
+	_u.mutation.AppendBodyPatterns(v)
+	return _u
+}
+
 // ClearKeywords clears the value of the "keywords" field.
 func (_u *ErrorPassthroughRuleUpdateOne) ClearKeywords() *ErrorPassthroughRuleUpdateOne {
 	_u.mutation.ClearKeywords()
 	return _u
 }
 
+// ClearBodyPatterns clears the value of the "body_patterns" field.
+func (_u *ErrorPassthroughRuleUpdateOne) ClearBodyPatterns() *ErrorPassthroughRuleUpdateOne {
+	_u.mutation.ClearBodyPatterns()
+	return _u
+}
+
+// ClearHeaderMatches clears the value of the "header_matches" field.
+func (_u *ErrorPassthroughRuleUpdateOne) ClearHeaderMatches() *ErrorPassthroughRuleUpdateOne {
+	_u.mutation.ClearHeaderMatches()
+	return _u
+}
+
 // SetMatchMode sets the "match_mode" field.
 func (_u *ErrorPassthroughRuleUpdateOne) SetMatchMode(v string) *ErrorPassthroughRuleUpdateOne {
 	_u.mutation.SetMatchMode(v)
@@ -789,14 +866,31 @@ func (_u *ErrorPassthroughRuleUpdateOne) sqlSave(ctx context.Context) (_node *Er
 	if value, ok := _u.mutation.Keywords(); ok {
 		_spec.SetField(errorpassthroughrule.FieldKeywords, field.TypeJSON, value)
 	}
+	if value, ok := _u.mutation.BodyPatterns(); ok {
+		_spec.SetField(errorpassthroughrule.FieldBodyPatterns, field.TypeJSON, value)
+	}
+	if value, ok := _u.mutation.HeaderMatches(); ok {
+		_spec.SetField(errorpassthroughrule.FieldHeaderMatches, field.TypeJSON, value)
+	}
 	if value, ok := _u.mutation.AppendedKeywords(); ok {
 		_spec.AddModifier(func(u *sql.UpdateBuilder) {
 			sqljson.Append(u, errorpassthroughrule.FieldKeywords, value)
 		})
 	}
+	if value, ok := _u.mutation.AppendedBodyPatterns(); ok {
+		_spec.AddModifier(func(u *sql.UpdateBuilder) {
+			sqljson.Append(u, errorpassthroughrule.FieldBodyPatterns, value)
+		})
+	}
 	if _u.mutation.KeywordsCleared() {
 		_spec.ClearField(errorpassthroughrule.FieldKeywords, field.TypeJSON)
 	}
+	if _u.mutation.BodyPatternsCleared() {
+		_spec.ClearField(errorpassthroughrule.FieldBodyPatterns, field.TypeJSON)
+	}
+	if _u.mutation.HeaderMatchesCleared() {
+		_spec.ClearField(errorpassthroughrule.FieldHeaderMatches, field.TypeJSON)
+	}
 	if value, ok := _u.mutation.MatchMode(); ok {
 		_spec.SetField(errorpassthroughrule.FieldMatchMode, field.TypeString, value)
 	}
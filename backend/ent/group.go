@@ -11,6 +11,7 @@ import (
 	"entgo.io/ent"
 	"entgo.io/ent/dialect/sql"
 	"github.com/Wei-Shaw/sub2api/ent/group"
+	"github.com/Wei-Shaw/sub2api/internal/domain"
 )
 
 // Group is the model entity for the Group schema.
@@ -60,6 +61,18 @@ type Group struct {
 	FallbackGroupIDOnInvalidRequest *int64 `json:"fallback_group_id_on_invalid_request,omitempty"`
 	// 模型路由配置：模型模式 -> 优先账号ID列表
 	ModelRouting map[string][]int64 `json:"model_routing,omitempty"`
+	// 模型参数策略：模型模式 -> 参数范围/互斥约束
+	ModelParamPolicies map[string]domain.ModelParamPolicy `json:"model_param_policies,omitempty"`
+	// 区域策略：要求/优先使用指定区域的账号
+	RegionPolicy domain.RegionPolicy `json:"region_policy,omitempty"`
+	// 系统提示词注入策略
+	SystemPromptPolicy domain.SystemPromptPolicy `json:"system_prompt_policy,omitempty"`
+	// 提示词模板：包裹用户最新消息的前缀/后缀
+	PromptTemplate domain.PromptTemplate `json:"prompt_template,omitempty"`
+	// 模型访问策略：允许/禁止请求的模型列表
+	ModelAccessPolicy domain.ModelAccessPolicy `json:"model_access_policy,omitempty"`
+	// 重试策略：可重试状态码、指数退避与总时间预算
+	RetryPolicy domain.RetryPolicy `json:"retry_policy,omitempty"`
 	// 是否启用模型路由配置
 	ModelRoutingEnabled bool `json:"model_routing_enabled,omitempty"`
 	// 是否注入 MCP XML 调用协议提示词（仅 antigravity 平台）
@@ -68,6 +81,10 @@ type Group struct {
 	SupportedModelScopes []string `json:"supported_model_scopes,omitempty"`
 	// 分组显示排序，数值越小越靠前
 	SortOrder int `json:"sort_order,omitempty"`
+	// 仅计量：记录用量与费用但不扣费、不做计费资格拦截
+	MeteringOnly bool `json:"metering_only,omitempty"`
+	// 严格字段模式：拒绝包含未知顶层字段的请求（宽松模式原样透传）
+	StrictRequestFields bool `json:"strict_request_fields,omitempty"`
 	// Edges holds the relations/edges for other nodes in the graph.
 	// The values are being populated by the GroupQuery when eager-loading is set.
 	Edges        GroupEdges `json:"edges"`
@@ -174,9 +191,9 @@ func (*Group) scanValues(columns []string) ([]any, error) {
 	values := make([]any, len(columns))
 	for i := range columns {
 		switch columns[i] {
-		case group.FieldModelRouting, group.FieldSupportedModelScopes:
+		case group.FieldModelRouting, group.FieldModelParamPolicies, group.FieldRegionPolicy, group.FieldSystemPromptPolicy, group.FieldPromptTemplate, group.FieldModelAccessPolicy, group.FieldRetryPolicy, group.FieldSupportedModelScopes:
 			values[i] = new([]byte)
-		case group.FieldIsExclusive, group.FieldClaudeCodeOnly, group.FieldModelRoutingEnabled, group.FieldMcpXMLInject:
+		case group.FieldIsExclusive, group.FieldClaudeCodeOnly, group.FieldModelRoutingEnabled, group.FieldMcpXMLInject, group.FieldMeteringOnly, group.FieldStrictRequestFields:
 			values[i] = new(sql.NullBool)
 		case group.FieldRateMultiplier, group.FieldDailyLimitUsd, group.FieldWeeklyLimitUsd, group.FieldMonthlyLimitUsd, group.FieldImagePrice1k, group.FieldImagePrice2k, group.FieldImagePrice4k:
 			values[i] = new(sql.NullFloat64)
@@ -345,6 +362,54 @@ func (_m *Group) assignValues(columns []string, values []any) error {
 					return fmt.Errorf("unmarshal field model_routing: %w", err)
 				}
 			}
+		case group.FieldModelParamPolicies:
+			if value, ok := values[i].(*[]byte); !ok {
+				return fmt.Errorf("unexpected type %T for field model_param_policies", values[i])
+			} else if value != nil && len(*value) > 0 {
+				if err := json.Unmarshal(*value, &_m.ModelParamPolicies); err != nil {
+					return fmt.Errorf("unmarshal field model_param_policies: %w", err)
+				}
+			}
+		case group.FieldRegionPolicy:
+			if value, ok := values[i].(*[]byte); !ok {
+				return fmt.Errorf("unexpected type %T for field region_policy", values[i])
+			} else if value != nil && len(*value) > 0 {
+				if err := json.Unmarshal(*value, &_m.RegionPolicy); err != nil {
+					return fmt.Errorf("unmarshal field region_policy: %w", err)
+				}
+			}
+		case group.FieldSystemPromptPolicy:
+			if value, ok := values[i].(*[]byte); !ok {
+				return fmt.Errorf("unexpected type %T for field system_prompt_policy", values[i])
+			} else if value != nil && len(*value) > 0 {
+				if err := json.Unmarshal(*value, &_m.SystemPromptPolicy); err != nil {
+					return fmt.Errorf("unmarshal field system_prompt_policy: %w", err)
+				}
+			}
+		case group.FieldPromptTemplate:
+			if value, ok := values[i].(*[]byte); !ok {
+				return fmt.Errorf("unexpected type %T for field prompt_template", values[i])
+			} else if value != nil && len(*value) > 0 {
+				if err := json.Unmarshal(*value, &_m.PromptTemplate); err != nil {
+					return fmt.Errorf("unmarshal field prompt_template: %w", err)
+				}
+			}
+		case group.FieldModelAccessPolicy:
+			if value, ok := values[i].(*[]byte); !ok {
+				return fmt.Errorf("unexpected type %T for field model_access_policy", values[i])
+			} else if value != nil && len(*value) > 0 {
+				if err := json.Unmarshal(*value, &_m.ModelAccessPolicy); err != nil {
+					return fmt.Errorf("unmarshal field model_access_policy: %w", err)
+				}
+			}
+		case group.FieldRetryPolicy:
+			if value, ok := values[i].(*[]byte); !ok {
+				return fmt.Errorf("unexpected type %T for field retry_policy", values[i])
+			} else if value != nil && len(*value) > 0 {
+				if err := json.Unmarshal(*value, &_m.RetryPolicy); err != nil {
+					return fmt.Errorf("unmarshal field retry_policy: %w", err)
+				}
+			}
 		case group.FieldModelRoutingEnabled:
 			if value, ok := values[i].(*sql.NullBool); !ok {
 				return fmt.Errorf("unexpected type %T for field model_routing_enabled", values[i])
@@ -371,6 +436,18 @@ func (_m *Group) assignValues(columns []string, values []any) error {
 			} else if value.Valid {
 				_m.SortOrder = int(value.Int64)
 			}
+		case group.FieldMeteringOnly:
+			if value, ok := values[i].(*sql.NullBool); !ok {
+				return fmt.Errorf("unexpected type %T for field metering_only", values[i])
+			} else if value.Valid {
+				_m.MeteringOnly = value.Bool
+			}
+		case group.FieldStrictRequestFields:
+			if value, ok := values[i].(*sql.NullBool); !ok {
+				return fmt.Errorf("unexpected type %T for field strict_request_fields", values[i])
+			} else if value.Valid {
+				_m.StrictRequestFields = value.Bool
+			}
 		default:
 			_m.selectValues.Set(columns[i], values[i])
 		}
@@ -530,6 +607,24 @@ func (_m *Group) String() string {
 	builder.WriteString("model_routing=")
 	builder.WriteString(fmt.Sprintf("%v", _m.ModelRouting))
 	builder.WriteString(", ")
+	builder.WriteString("model_param_policies=")
+	builder.WriteString(fmt.Sprintf("%v", _m.ModelParamPolicies))
+	builder.WriteString(", ")
+	builder.WriteString("region_policy=")
+	builder.WriteString(fmt.Sprintf("%v", _m.RegionPolicy))
+	builder.WriteString(", ")
+	builder.WriteString("system_prompt_policy=")
+	builder.WriteString(fmt.Sprintf("%v", _m.SystemPromptPolicy))
+	builder.WriteString(", ")
+	builder.WriteString("prompt_template=")
+	builder.WriteString(fmt.Sprintf("%v", _m.PromptTemplate))
+	builder.WriteString(", ")
+	builder.WriteString("model_access_policy=")
+	builder.WriteString(fmt.Sprintf("%v", _m.ModelAccessPolicy))
+	builder.WriteString(", ")
+	builder.WriteString("retry_policy=")
+	builder.WriteString(fmt.Sprintf("%v", _m.RetryPolicy))
+	builder.WriteString(", ")
 	builder.WriteString("model_routing_enabled=")
 	builder.WriteString(fmt.Sprintf("%v", _m.ModelRoutingEnabled))
 	builder.WriteString(", ")
@@ -541,6 +636,12 @@ func (_m *Group) String() string {
 	builder.WriteString(", ")
 	builder.WriteString("sort_order=")
 	builder.WriteString(fmt.Sprintf("%v", _m.SortOrder))
+	builder.WriteString(", ")
+	builder.WriteString("metering_only=")
+	builder.WriteString(fmt.Sprintf("%v", _m.MeteringOnly))
+	builder.WriteString(", ")
+	builder.WriteString("strict_request_fields=")
+	builder.WriteString(fmt.Sprintf("%v", _m.StrictRequestFields))
 	builder.WriteByte(')')
 	return builder.String()
 }
@@ -57,6 +57,18 @@ const (
 	FieldFallbackGroupIDOnInvalidRequest = "fallback_group_id_on_invalid_request"
 	// FieldModelRouting holds the string denoting the model_routing field in the database.
 	FieldModelRouting = "model_routing"
+	// FieldModelParamPolicies holds the string denoting the model_param_policies field in the database.
+	FieldModelParamPolicies = "model_param_policies"
+	// FieldRegionPolicy holds the string denoting the region_policy field in the database.
+	FieldRegionPolicy = "region_policy"
+	// FieldSystemPromptPolicy holds the string denoting the system_prompt_policy field in the database.
+	FieldSystemPromptPolicy = "system_prompt_policy"
+	// FieldPromptTemplate holds the string denoting the prompt_template field in the database.
+	FieldPromptTemplate = "prompt_template"
+	// FieldModelAccessPolicy holds the string denoting the model_access_policy field in the database.
+	FieldModelAccessPolicy = "model_access_policy"
+	// FieldRetryPolicy holds the string denoting the retry_policy field in the database.
+	FieldRetryPolicy = "retry_policy"
 	// FieldModelRoutingEnabled holds the string denoting the model_routing_enabled field in the database.
 	FieldModelRoutingEnabled = "model_routing_enabled"
 	// FieldMcpXMLInject holds the string denoting the mcp_xml_inject field in the database.
@@ -65,6 +77,10 @@ const (
 	FieldSupportedModelScopes = "supported_model_scopes"
 	// FieldSortOrder holds the string denoting the sort_order field in the database.
 	FieldSortOrder = "sort_order"
+	// FieldMeteringOnly holds the string denoting the metering_only field in the database.
+	FieldMeteringOnly = "metering_only"
+	// FieldStrictRequestFields holds the string denoting the strict_request_fields field in the database.
+	FieldStrictRequestFields = "strict_request_fields"
 	// EdgeAPIKeys holds the string denoting the api_keys edge name in mutations.
 	EdgeAPIKeys = "api_keys"
 	// EdgeRedeemCodes holds the string denoting the redeem_codes edge name in mutations.
@@ -161,10 +177,18 @@ var Columns = []string{
 	FieldFallbackGroupID,
 	FieldFallbackGroupIDOnInvalidRequest,
 	FieldModelRouting,
+	FieldModelParamPolicies,
+	FieldRegionPolicy,
+	FieldSystemPromptPolicy,
+	FieldPromptTemplate,
+	FieldModelAccessPolicy,
+	FieldRetryPolicy,
 	FieldModelRoutingEnabled,
 	FieldMcpXMLInject,
 	FieldSupportedModelScopes,
 	FieldSortOrder,
+	FieldMeteringOnly,
+	FieldStrictRequestFields,
 }
 
 var (
@@ -230,6 +254,10 @@ var (
 	DefaultSupportedModelScopes []string
 	// DefaultSortOrder holds the default value on creation for the "sort_order" field.
 	DefaultSortOrder int
+	// DefaultMeteringOnly holds the default value on creation for the "metering_only" field.
+	DefaultMeteringOnly bool
+	// DefaultStrictRequestFields holds the default value on creation for the "strict_request_fields" field.
+	DefaultStrictRequestFields bool
 )
 
 // OrderOption defines the ordering options for the Group queries.
@@ -355,6 +383,16 @@ func BySortOrder(opts ...sql.OrderTermOption) OrderOption {
 	return sql.OrderByField(FieldSortOrder, opts...).ToFunc()
 }
 
+// ByMeteringOnly orders the results by the metering_only field.
+func ByMeteringOnly(opts ...sql.OrderTermOption) OrderOption {
+	return sql.OrderByField(FieldMeteringOnly, opts...).ToFunc()
+}
+
+// ByStrictRequestFields orders the results by the strict_request_fields field.
+func ByStrictRequestFields(opts ...sql.OrderTermOption) OrderOption {
+	return sql.OrderByField(FieldStrictRequestFields, opts...).ToFunc()
+}
+
 // ByAPIKeysCount orders the results by api_keys count.
 func ByAPIKeysCount(opts ...sql.OrderTermOption) OrderOption {
 	return func(s *sql.Selector) {
@@ -165,6 +165,16 @@ func McpXMLInject(v bool) predicate.Group {
 	return predicate.Group(sql.FieldEQ(FieldMcpXMLInject, v))
 }
 
+// MeteringOnly applies equality check predicate on the "metering_only" field. It's identical to MeteringOnlyEQ.
+func MeteringOnly(v bool) predicate.Group {
+	return predicate.Group(sql.FieldEQ(FieldMeteringOnly, v))
+}
+
+// StrictRequestFields applies equality check predicate on the "strict_request_fields" field. It's identical to StrictRequestFieldsEQ.
+func StrictRequestFields(v bool) predicate.Group {
+	return predicate.Group(sql.FieldEQ(FieldStrictRequestFields, v))
+}
+
 // SortOrder applies equality check predicate on the "sort_order" field. It's identical to SortOrderEQ.
 func SortOrder(v int) predicate.Group {
 	return predicate.Group(sql.FieldEQ(FieldSortOrder, v))
@@ -1145,6 +1155,66 @@ func ModelRoutingNotNil() predicate.Group {
 	return predicate.Group(sql.FieldNotNull(FieldModelRouting))
 }
 
+// ModelParamPoliciesIsNil applies the IsNil predicate on the "model_param_policies" field.
+func ModelParamPoliciesIsNil() predicate.Group {
+	return predicate.Group(sql.FieldIsNull(FieldModelParamPolicies))
+}
+
+// ModelParamPoliciesNotNil applies the NotNil predicate on the "model_param_policies" field.
+func ModelParamPoliciesNotNil() predicate.Group {
+	return predicate.Group(sql.FieldNotNull(FieldModelParamPolicies))
+}
+
+// RegionPolicyIsNil applies the IsNil predicate on the "region_policy" field.
+func RegionPolicyIsNil() predicate.Group {
+	return predicate.Group(sql.FieldIsNull(FieldRegionPolicy))
+}
+
+// RegionPolicyNotNil applies the NotNil predicate on the "region_policy" field.
+func RegionPolicyNotNil() predicate.Group {
+	return predicate.Group(sql.FieldNotNull(FieldRegionPolicy))
+}
+
+// SystemPromptPolicyIsNil applies the IsNil predicate on the "system_prompt_policy" field.
+func SystemPromptPolicyIsNil() predicate.Group {
+	return predicate.Group(sql.FieldIsNull(FieldSystemPromptPolicy))
+}
+
+// SystemPromptPolicyNotNil applies the NotNil predicate on the "system_prompt_policy" field.
+func SystemPromptPolicyNotNil() predicate.Group {
+	return predicate.Group(sql.FieldNotNull(FieldSystemPromptPolicy))
+}
+
+// PromptTemplateIsNil applies the IsNil predicate on the "prompt_template" field.
+func PromptTemplateIsNil() predicate.Group {
+	return predicate.Group(sql.FieldIsNull(FieldPromptTemplate))
+}
+
+// PromptTemplateNotNil applies the NotNil predicate on the "prompt_template" field.
+func PromptTemplateNotNil() predicate.Group {
+	return predicate.Group(sql.FieldNotNull(FieldPromptTemplate))
+}
+
+// ModelAccessPolicyIsNil applies the IsNil predicate on the "model_access_policy" field.
+func ModelAccessPolicyIsNil() predicate.Group {
+	return predicate.Group(sql.FieldIsNull(FieldModelAccessPolicy))
+}
+
+// RetryPolicyIsNil applies the IsNil predicate on the "retry_policy" field.
+func RetryPolicyIsNil() predicate.Group {
+	return predicate.Group(sql.FieldIsNull(FieldRetryPolicy))
+}
+
+// ModelAccessPolicyNotNil applies the NotNil predicate on the "model_access_policy" field.
+func ModelAccessPolicyNotNil() predicate.Group {
+	return predicate.Group(sql.FieldNotNull(FieldModelAccessPolicy))
+}
+
+// RetryPolicyNotNil applies the NotNil predicate on the "retry_policy" field.
+func RetryPolicyNotNil() predicate.Group {
+	return predicate.Group(sql.FieldNotNull(FieldRetryPolicy))
+}
+
 // ModelRoutingEnabledEQ applies the EQ predicate on the "model_routing_enabled" field.
 func ModelRoutingEnabledEQ(v bool) predicate.Group {
 	return predicate.Group(sql.FieldEQ(FieldModelRoutingEnabled, v))
@@ -1205,6 +1275,26 @@ func SortOrderLTE(v int) predicate.Group {
 	return predicate.Group(sql.FieldLTE(FieldSortOrder, v))
 }
 
+// MeteringOnlyEQ applies the EQ predicate on the "metering_only" field.
+func MeteringOnlyEQ(v bool) predicate.Group {
+	return predicate.Group(sql.FieldEQ(FieldMeteringOnly, v))
+}
+
+// MeteringOnlyNEQ applies the NEQ predicate on the "metering_only" field.
+func MeteringOnlyNEQ(v bool) predicate.Group {
+	return predicate.Group(sql.FieldNEQ(FieldMeteringOnly, v))
+}
+
+// StrictRequestFieldsEQ applies the EQ predicate on the "strict_request_fields" field.
+func StrictRequestFieldsEQ(v bool) predicate.Group {
+	return predicate.Group(sql.FieldEQ(FieldStrictRequestFields, v))
+}
+
+// StrictRequestFieldsNEQ applies the NEQ predicate on the "strict_request_fields" field.
+func StrictRequestFieldsNEQ(v bool) predicate.Group {
+	return predicate.Group(sql.FieldNEQ(FieldStrictRequestFields, v))
+}
+
 // HasAPIKeys applies the HasEdge predicate on the "api_keys" edge.
 func HasAPIKeys() predicate.Group {
 	return predicate.Group(func(s *sql.Selector) {
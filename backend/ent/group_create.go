@@ -18,6 +18,7 @@ import (
 	"github.com/Wei-Shaw/sub2api/ent/usagelog"
 	"github.com/Wei-Shaw/sub2api/ent/user"
 	"github.com/Wei-Shaw/sub2api/ent/usersubscription"
+	"github.com/Wei-Shaw/sub2api/internal/domain"
 )
 
 // GroupCreate is the builder for creating a Group entity.
@@ -306,6 +307,42 @@ func (_c *GroupCreate) SetModelRouting(v map[string][]int64) *GroupCreate {
 	return _c
 }
 
+// SetModelParamPolicies sets the "model_param_policies" field.
+func (_c *GroupCreate) SetModelParamPolicies(v map[string]domain.ModelParamPolicy) *GroupCreate {
+	_c.mutation.SetModelParamPolicies(v)
+	return _c
+}
+
+// SetRegionPolicy sets the "region_policy" field.
+func (_c *GroupCreate) SetRegionPolicy(v domain.RegionPolicy) *GroupCreate {
+	_c.mutation.SetRegionPolicy(v)
+	return _c
+}
+
+// SetSystemPromptPolicy sets the "system_prompt_policy" field.
+func (_c *GroupCreate) SetSystemPromptPolicy(v domain.SystemPromptPolicy) *GroupCreate {
+	_c.mutation.SetSystemPromptPolicy(v)
+	return _c
+}
+
+// SetPromptTemplate sets the "prompt_template" field.
+func (_c *GroupCreate) SetPromptTemplate(v domain.PromptTemplate) *GroupCreate {
+	_c.mutation.SetPromptTemplate(v)
+	return _c
+}
+
+// SetModelAccessPolicy sets the "model_access_policy" field.
+func (_c *GroupCreate) SetModelAccessPolicy(v domain.ModelAccessPolicy) *GroupCreate {
+	_c.mutation.SetModelAccessPolicy(v)
+	return _c
+}
+
+// SetRetryPolicy sets the "retry_policy" field.
+func (_c *GroupCreate) SetRetryPolicy(v domain.RetryPolicy) *GroupCreate {
+	_c.mutation.SetRetryPolicy(v)
+	return _c
+}
+
 // SetModelRoutingEnabled sets the "model_routing_enabled" field.
 func (_c *GroupCreate) SetModelRoutingEnabled(v bool) *GroupCreate {
 	_c.mutation.SetModelRoutingEnabled(v)
@@ -354,6 +391,34 @@ func (_c *GroupCreate) SetNillableSortOrder(v *int) *GroupCreate {
 	return _c
 }
 
+// SetMeteringOnly sets the "metering_only" field.
+func (_c *GroupCreate) SetMeteringOnly(v bool) *GroupCreate {
+	_c.mutation.SetMeteringOnly(v)
+	return _c
+}
+
+// SetNillableMeteringOnly sets the "metering_only" field if the given value is not nil.
+func (_c *GroupCreate) SetNillableMeteringOnly(v *bool) *GroupCreate {
+	if v != nil {
+		_c.SetMeteringOnly(*v)
+	}
+	return _c
+}
+
+// SetStrictRequestFields sets the "strict_request_fields" field.
+func (_c *GroupCreate) SetStrictRequestFields(v bool) *GroupCreate {
+	_c.mutation.SetStrictRequestFields(v)
+	return _c
+}
+
+// SetNillableStrictRequestFields sets the "strict_request_fields" field if the given value is not nil.
+func (_c *GroupCreate) SetNillableStrictRequestFields(v *bool) *GroupCreate {
+	if v != nil {
+		_c.SetStrictRequestFields(*v)
+	}
+	return _c
+}
+
 // AddAPIKeyIDs adds the "api_keys" edge to the APIKey entity by IDs.
 func (_c *GroupCreate) AddAPIKeyIDs(ids ...int64) *GroupCreate {
 	_c.mutation.AddAPIKeyIDs(ids...)
@@ -539,6 +604,14 @@ func (_c *GroupCreate) defaults() error {
 		v := group.DefaultSortOrder
 		_c.mutation.SetSortOrder(v)
 	}
+	if _, ok := _c.mutation.MeteringOnly(); !ok {
+		v := group.DefaultMeteringOnly
+		_c.mutation.SetMeteringOnly(v)
+	}
+	if _, ok := _c.mutation.StrictRequestFields(); !ok {
+		v := group.DefaultStrictRequestFields
+		_c.mutation.SetStrictRequestFields(v)
+	}
 	return nil
 }
 
@@ -606,6 +679,12 @@ func (_c *GroupCreate) check() error {
 	if _, ok := _c.mutation.SortOrder(); !ok {
 		return &ValidationError{Name: "sort_order", err: errors.New(`ent: missing required field "Group.sort_order"`)}
 	}
+	if _, ok := _c.mutation.MeteringOnly(); !ok {
+		return &ValidationError{Name: "metering_only", err: errors.New(`ent: missing required field "Group.metering_only"`)}
+	}
+	if _, ok := _c.mutation.StrictRequestFields(); !ok {
+		return &ValidationError{Name: "strict_request_fields", err: errors.New(`ent: missing required field "Group.strict_request_fields"`)}
+	}
 	return nil
 }
 
@@ -717,6 +796,30 @@ func (_c *GroupCreate) createSpec() (*Group, *sqlgraph.CreateSpec) {
 		_spec.SetField(group.FieldModelRouting, field.TypeJSON, value)
 		_node.ModelRouting = value
 	}
+	if value, ok := _c.mutation.ModelParamPolicies(); ok {
+		_spec.SetField(group.FieldModelParamPolicies, field.TypeJSON, value)
+		_node.ModelParamPolicies = value
+	}
+	if value, ok := _c.mutation.RegionPolicy(); ok {
+		_spec.SetField(group.FieldRegionPolicy, field.TypeJSON, value)
+		_node.RegionPolicy = value
+	}
+	if value, ok := _c.mutation.SystemPromptPolicy(); ok {
+		_spec.SetField(group.FieldSystemPromptPolicy, field.TypeJSON, value)
+		_node.SystemPromptPolicy = value
+	}
+	if value, ok := _c.mutation.PromptTemplate(); ok {
+		_spec.SetField(group.FieldPromptTemplate, field.TypeJSON, value)
+		_node.PromptTemplate = value
+	}
+	if value, ok := _c.mutation.ModelAccessPolicy(); ok {
+		_spec.SetField(group.FieldModelAccessPolicy, field.TypeJSON, value)
+		_node.ModelAccessPolicy = value
+	}
+	if value, ok := _c.mutation.RetryPolicy(); ok {
+		_spec.SetField(group.FieldRetryPolicy, field.TypeJSON, value)
+		_node.RetryPolicy = value
+	}
 	if value, ok := _c.mutation.ModelRoutingEnabled(); ok {
 		_spec.SetField(group.FieldModelRoutingEnabled, field.TypeBool, value)
 		_node.ModelRoutingEnabled = value
@@ -733,6 +836,14 @@ func (_c *GroupCreate) createSpec() (*Group, *sqlgraph.CreateSpec) {
 		_spec.SetField(group.FieldSortOrder, field.TypeInt, value)
 		_node.SortOrder = value
 	}
+	if value, ok := _c.mutation.MeteringOnly(); ok {
+		_spec.SetField(group.FieldMeteringOnly, field.TypeBool, value)
+		_node.MeteringOnly = value
+	}
+	if value, ok := _c.mutation.StrictRequestFields(); ok {
+		_spec.SetField(group.FieldStrictRequestFields, field.TypeBool, value)
+		_node.StrictRequestFields = value
+	}
 	if nodes := _c.mutation.APIKeysIDs(); len(nodes) > 0 {
 		edge := &sqlgraph.EdgeSpec{
 			Rel:     sqlgraph.O2M,
@@ -1255,6 +1366,114 @@ func (u *GroupUpsert) ClearModelRouting() *GroupUpsert {
 	return u
 }
 
+// SetModelParamPolicies sets the "model_param_policies" field.
+func (u *GroupUpsert) SetModelParamPolicies(v map[string]domain.ModelParamPolicy) *GroupUpsert {
+	u.Set(group.FieldModelParamPolicies, v)
+	return u
+}
+
+// UpdateModelParamPolicies sets the "model_param_policies" field to the value that was provided on create.
+func (u *GroupUpsert) UpdateModelParamPolicies() *GroupUpsert {
+	u.SetExcluded(group.FieldModelParamPolicies)
+	return u
+}
+
+// ClearModelParamPolicies clears the value of the "model_param_policies" field.
+func (u *GroupUpsert) ClearModelParamPolicies() *GroupUpsert {
+	u.SetNull(group.FieldModelParamPolicies)
+	return u
+}
+
+// SetRegionPolicy sets the "region_policy" field.
+func (u *GroupUpsert) SetRegionPolicy(v domain.RegionPolicy) *GroupUpsert {
+	u.Set(group.FieldRegionPolicy, v)
+	return u
+}
+
+// UpdateRegionPolicy sets the "region_policy" field to the value that was provided on create.
+func (u *GroupUpsert) UpdateRegionPolicy() *GroupUpsert {
+	u.SetExcluded(group.FieldRegionPolicy)
+	return u
+}
+
+// ClearRegionPolicy clears the value of the "region_policy" field.
+func (u *GroupUpsert) ClearRegionPolicy() *GroupUpsert {
+	u.SetNull(group.FieldRegionPolicy)
+	return u
+}
+
+// SetSystemPromptPolicy sets the "system_prompt_policy" field.
+func (u *GroupUpsert) SetSystemPromptPolicy(v domain.SystemPromptPolicy) *GroupUpsert {
+	u.Set(group.FieldSystemPromptPolicy, v)
+	return u
+}
+
+// UpdateSystemPromptPolicy sets the "system_prompt_policy" field to the value that was provided on create.
+func (u *GroupUpsert) UpdateSystemPromptPolicy() *GroupUpsert {
+	u.SetExcluded(group.FieldSystemPromptPolicy)
+	return u
+}
+
+// ClearSystemPromptPolicy clears the value of the "system_prompt_policy" field.
+func (u *GroupUpsert) ClearSystemPromptPolicy() *GroupUpsert {
+	u.SetNull(group.FieldSystemPromptPolicy)
+	return u
+}
+
+// SetPromptTemplate sets the "prompt_template" field.
+func (u *GroupUpsert) SetPromptTemplate(v domain.PromptTemplate) *GroupUpsert {
+	u.Set(group.FieldPromptTemplate, v)
+	return u
+}
+
+// UpdatePromptTemplate sets the "prompt_template" field to the value that was provided on create.
+func (u *GroupUpsert) UpdatePromptTemplate() *GroupUpsert {
+	u.SetExcluded(group.FieldPromptTemplate)
+	return u
+}
+
+// ClearPromptTemplate clears the value of the "prompt_template" field.
+func (u *GroupUpsert) ClearPromptTemplate() *GroupUpsert {
+	u.SetNull(group.FieldPromptTemplate)
+	return u
+}
+
+// SetModelAccessPolicy sets the "model_access_policy" field.
+func (u *GroupUpsert) SetModelAccessPolicy(v domain.ModelAccessPolicy) *GroupUpsert {
+	u.Set(group.FieldModelAccessPolicy, v)
+	return u
+}
+
+// SetRetryPolicy sets the "retry_policy" field.
+func (u *GroupUpsert) SetRetryPolicy(v domain.RetryPolicy) *GroupUpsert {
+	u.Set(group.FieldRetryPolicy, v)
+	return u
+}
+
+// UpdateModelAccessPolicy sets the "model_access_policy" field to the value that was provided on create.
+func (u *GroupUpsert) UpdateModelAccessPolicy() *GroupUpsert {
+	u.SetExcluded(group.FieldModelAccessPolicy)
+	return u
+}
+
+// UpdateRetryPolicy sets the "retry_policy" field to the value that was provided on create.
+func (u *GroupUpsert) UpdateRetryPolicy() *GroupUpsert {
+	u.SetExcluded(group.FieldRetryPolicy)
+	return u
+}
+
+// ClearModelAccessPolicy clears the value of the "model_access_policy" field.
+func (u *GroupUpsert) ClearModelAccessPolicy() *GroupUpsert {
+	u.SetNull(group.FieldModelAccessPolicy)
+	return u
+}
+
+// ClearRetryPolicy clears the value of the "retry_policy" field.
+func (u *GroupUpsert) ClearRetryPolicy() *GroupUpsert {
+	u.SetNull(group.FieldRetryPolicy)
+	return u
+}
+
 // SetModelRoutingEnabled sets the "model_routing_enabled" field.
 func (u *GroupUpsert) SetModelRoutingEnabled(v bool) *GroupUpsert {
 	u.Set(group.FieldModelRoutingEnabled, v)
@@ -1303,6 +1522,30 @@ func (u *GroupUpsert) UpdateSortOrder() *GroupUpsert {
 	return u
 }
 
+// SetMeteringOnly sets the "metering_only" field.
+func (u *GroupUpsert) SetMeteringOnly(v bool) *GroupUpsert {
+	u.Set(group.FieldMeteringOnly, v)
+	return u
+}
+
+// UpdateMeteringOnly sets the "metering_only" field to the value that was provided on create.
+func (u *GroupUpsert) UpdateMeteringOnly() *GroupUpsert {
+	u.SetExcluded(group.FieldMeteringOnly)
+	return u
+}
+
+// SetStrictRequestFields sets the "strict_request_fields" field.
+func (u *GroupUpsert) SetStrictRequestFields(v bool) *GroupUpsert {
+	u.Set(group.FieldStrictRequestFields, v)
+	return u
+}
+
+// UpdateStrictRequestFields sets the "strict_request_fields" field to the value that was provided on create.
+func (u *GroupUpsert) UpdateStrictRequestFields() *GroupUpsert {
+	u.SetExcluded(group.FieldStrictRequestFields)
+	return u
+}
+
 // AddSortOrder adds v to the "sort_order" field.
 func (u *GroupUpsert) AddSortOrder(v int) *GroupUpsert {
 	u.Add(group.FieldSortOrder, v)
@@ -1781,6 +2024,132 @@ func (u *GroupUpsertOne) ClearModelRouting() *GroupUpsertOne {
 	})
 }
 
+// SetModelParamPolicies sets the "model_param_policies" field.
+func (u *GroupUpsertOne) SetModelParamPolicies(v map[string]domain.ModelParamPolicy) *GroupUpsertOne {
+	return u.Update(func(s *GroupUpsert) {
+		s.SetModelParamPolicies(v)
+	})
+}
+
+// UpdateModelParamPolicies sets the "model_param_policies" field to the value that was provided on create.
+func (u *GroupUpsertOne) UpdateModelParamPolicies() *GroupUpsertOne {
+	return u.Update(func(s *GroupUpsert) {
+		s.UpdateModelParamPolicies()
+	})
+}
+
+// ClearModelParamPolicies clears the value of the "model_param_policies" field.
+func (u *GroupUpsertOne) ClearModelParamPolicies() *GroupUpsertOne {
+	return u.Update(func(s *GroupUpsert) {
+		s.ClearModelParamPolicies()
+	})
+}
+
+// SetRegionPolicy sets the "region_policy" field.
+func (u *GroupUpsertOne) SetRegionPolicy(v domain.RegionPolicy) *GroupUpsertOne {
+	return u.Update(func(s *GroupUpsert) {
+		s.SetRegionPolicy(v)
+	})
+}
+
+// UpdateRegionPolicy sets the "region_policy" field to the value that was provided on create.
+func (u *GroupUpsertOne) UpdateRegionPolicy() *GroupUpsertOne {
+	return u.Update(func(s *GroupUpsert) {
+		s.UpdateRegionPolicy()
+	})
+}
+
+// ClearRegionPolicy clears the value of the "region_policy" field.
+func (u *GroupUpsertOne) ClearRegionPolicy() *GroupUpsertOne {
+	return u.Update(func(s *GroupUpsert) {
+		s.ClearRegionPolicy()
+	})
+}
+
+// SetSystemPromptPolicy sets the "system_prompt_policy" field.
+func (u *GroupUpsertOne) SetSystemPromptPolicy(v domain.SystemPromptPolicy) *GroupUpsertOne {
+	return u.Update(func(s *GroupUpsert) {
+		s.SetSystemPromptPolicy(v)
+	})
+}
+
+// UpdateSystemPromptPolicy sets the "system_prompt_policy" field to the value that was provided on create.
+func (u *GroupUpsertOne) UpdateSystemPromptPolicy() *GroupUpsertOne {
+	return u.Update(func(s *GroupUpsert) {
+		s.UpdateSystemPromptPolicy()
+	})
+}
+
+// ClearSystemPromptPolicy clears the value of the "system_prompt_policy" field.
+func (u *GroupUpsertOne) ClearSystemPromptPolicy() *GroupUpsertOne {
+	return u.Update(func(s *GroupUpsert) {
+		s.ClearSystemPromptPolicy()
+	})
+}
+
+// SetPromptTemplate sets the "prompt_template" field.
+func (u *GroupUpsertOne) SetPromptTemplate(v domain.PromptTemplate) *GroupUpsertOne {
+	return u.Update(func(s *GroupUpsert) {
+		s.SetPromptTemplate(v)
+	})
+}
+
+// UpdatePromptTemplate sets the "prompt_template" field to the value that was provided on create.
+func (u *GroupUpsertOne) UpdatePromptTemplate() *GroupUpsertOne {
+	return u.Update(func(s *GroupUpsert) {
+		s.UpdatePromptTemplate()
+	})
+}
+
+// ClearPromptTemplate clears the value of the "prompt_template" field.
+func (u *GroupUpsertOne) ClearPromptTemplate() *GroupUpsertOne {
+	return u.Update(func(s *GroupUpsert) {
+		s.ClearPromptTemplate()
+	})
+}
+
+// SetModelAccessPolicy sets the "model_access_policy" field.
+func (u *GroupUpsertOne) SetModelAccessPolicy(v domain.ModelAccessPolicy) *GroupUpsertOne {
+	return u.Update(func(s *GroupUpsert) {
+		s.SetModelAccessPolicy(v)
+	})
+}
+
+// SetRetryPolicy sets the "retry_policy" field.
+func (u *GroupUpsertOne) SetRetryPolicy(v domain.RetryPolicy) *GroupUpsertOne {
+	return u.Update(func(s *GroupUpsert) {
+		s.SetRetryPolicy(v)
+	})
+}
+
+// UpdateModelAccessPolicy sets the "model_access_policy" field to the value that was provided on create.
+func (u *GroupUpsertOne) UpdateModelAccessPolicy() *GroupUpsertOne {
+	return u.Update(func(s *GroupUpsert) {
+		s.UpdateModelAccessPolicy()
+	})
+}
+
+// UpdateRetryPolicy sets the "retry_policy" field to the value that was provided on create.
+func (u *GroupUpsertOne) UpdateRetryPolicy() *GroupUpsertOne {
+	return u.Update(func(s *GroupUpsert) {
+		s.UpdateRetryPolicy()
+	})
+}
+
+// ClearModelAccessPolicy clears the value of the "model_access_policy" field.
+func (u *GroupUpsertOne) ClearModelAccessPolicy() *GroupUpsertOne {
+	return u.Update(func(s *GroupUpsert) {
+		s.ClearModelAccessPolicy()
+	})
+}
+
+// ClearRetryPolicy clears the value of the "retry_policy" field.
+func (u *GroupUpsertOne) ClearRetryPolicy() *GroupUpsertOne {
+	return u.Update(func(s *GroupUpsert) {
+		s.ClearRetryPolicy()
+	})
+}
+
 // SetModelRoutingEnabled sets the "model_routing_enabled" field.
 func (u *GroupUpsertOne) SetModelRoutingEnabled(v bool) *GroupUpsertOne {
 	return u.Update(func(s *GroupUpsert) {
@@ -1844,6 +2213,34 @@ func (u *GroupUpsertOne) UpdateSortOrder() *GroupUpsertOne {
 	})
 }
 
+// SetMeteringOnly sets the "metering_only" field.
+func (u *GroupUpsertOne) SetMeteringOnly(v bool) *GroupUpsertOne {
+	return u.Update(func(s *GroupUpsert) {
+		s.SetMeteringOnly(v)
+	})
+}
+
+// UpdateMeteringOnly sets the "metering_only" field to the value that was provided on create.
+func (u *GroupUpsertOne) UpdateMeteringOnly() *GroupUpsertOne {
+	return u.Update(func(s *GroupUpsert) {
+		s.UpdateMeteringOnly()
+	})
+}
+
+// SetStrictRequestFields sets the "strict_request_fields" field.
+func (u *GroupUpsertOne) SetStrictRequestFields(v bool) *GroupUpsertOne {
+	return u.Update(func(s *GroupUpsert) {
+		s.SetStrictRequestFields(v)
+	})
+}
+
+// UpdateStrictRequestFields sets the "strict_request_fields" field to the value that was provided on create.
+func (u *GroupUpsertOne) UpdateStrictRequestFields() *GroupUpsertOne {
+	return u.Update(func(s *GroupUpsert) {
+		s.UpdateStrictRequestFields()
+	})
+}
+
 // Exec executes the query.
 func (u *GroupUpsertOne) Exec(ctx context.Context) error {
 	if len(u.create.conflict) == 0 {
@@ -2482,6 +2879,132 @@ func (u *GroupUpsertBulk) ClearModelRouting() *GroupUpsertBulk {
 	})
 }
 
+// SetModelParamPolicies sets the "model_param_policies" field.
+func (u *GroupUpsertBulk) SetModelParamPolicies(v map[string]domain.ModelParamPolicy) *GroupUpsertBulk {
+	return u.Update(func(s *GroupUpsert) {
+		s.SetModelParamPolicies(v)
+	})
+}
+
+// UpdateModelParamPolicies sets the "model_param_policies" field to the value that was provided on create.
+func (u *GroupUpsertBulk) UpdateModelParamPolicies() *GroupUpsertBulk {
+	return u.Update(func(s *GroupUpsert) {
+		s.UpdateModelParamPolicies()
+	})
+}
+
+// ClearModelParamPolicies clears the value of the "model_param_policies" field.
+func (u *GroupUpsertBulk) ClearModelParamPolicies() *GroupUpsertBulk {
+	return u.Update(func(s *GroupUpsert) {
+		s.ClearModelParamPolicies()
+	})
+}
+
+// SetRegionPolicy sets the "region_policy" field.
+func (u *GroupUpsertBulk) SetRegionPolicy(v domain.RegionPolicy) *GroupUpsertBulk {
+	return u.Update(func(s *GroupUpsert) {
+		s.SetRegionPolicy(v)
+	})
+}
+
+// UpdateRegionPolicy sets the "region_policy" field to the value that was provided on create.
+func (u *GroupUpsertBulk) UpdateRegionPolicy() *GroupUpsertBulk {
+	return u.Update(func(s *GroupUpsert) {
+		s.UpdateRegionPolicy()
+	})
+}
+
+// ClearRegionPolicy clears the value of the "region_policy" field.
+func (u *GroupUpsertBulk) ClearRegionPolicy() *GroupUpsertBulk {
+	return u.Update(func(s *GroupUpsert) {
+		s.ClearRegionPolicy()
+	})
+}
+
+// SetSystemPromptPolicy sets the "system_prompt_policy" field.
+func (u *GroupUpsertBulk) SetSystemPromptPolicy(v domain.SystemPromptPolicy) *GroupUpsertBulk {
+	return u.Update(func(s *GroupUpsert) {
+		s.SetSystemPromptPolicy(v)
+	})
+}
+
+// UpdateSystemPromptPolicy sets the "system_prompt_policy" field to the value that was provided on create.
+func (u *GroupUpsertBulk) UpdateSystemPromptPolicy() *GroupUpsertBulk {
+	return u.Update(func(s *GroupUpsert) {
+		s.UpdateSystemPromptPolicy()
+	})
+}
+
+// ClearSystemPromptPolicy clears the value of the "system_prompt_policy" field.
+func (u *GroupUpsertBulk) ClearSystemPromptPolicy() *GroupUpsertBulk {
+	return u.Update(func(s *GroupUpsert) {
+		s.ClearSystemPromptPolicy()
+	})
+}
+
+// SetPromptTemplate sets the "prompt_template" field.
+func (u *GroupUpsertBulk) SetPromptTemplate(v domain.PromptTemplate) *GroupUpsertBulk {
+	return u.Update(func(s *GroupUpsert) {
+		s.SetPromptTemplate(v)
+	})
+}
+
+// UpdatePromptTemplate sets the "prompt_template" field to the value that was provided on create.
+func (u *GroupUpsertBulk) UpdatePromptTemplate() *GroupUpsertBulk {
+	return u.Update(func(s *GroupUpsert) {
+		s.UpdatePromptTemplate()
+	})
+}
+
+// ClearPromptTemplate clears the value of the "prompt_template" field.
+func (u *GroupUpsertBulk) ClearPromptTemplate() *GroupUpsertBulk {
+	return u.Update(func(s *GroupUpsert) {
+		s.ClearPromptTemplate()
+	})
+}
+
+// SetModelAccessPolicy sets the "model_access_policy" field.
+func (u *GroupUpsertBulk) SetModelAccessPolicy(v domain.ModelAccessPolicy) *GroupUpsertBulk {
+	return u.Update(func(s *GroupUpsert) {
+		s.SetModelAccessPolicy(v)
+	})
+}
+
+// SetRetryPolicy sets the "retry_policy" field.
+func (u *GroupUpsertBulk) SetRetryPolicy(v domain.RetryPolicy) *GroupUpsertBulk {
+	return u.Update(func(s *GroupUpsert) {
+		s.SetRetryPolicy(v)
+	})
+}
+
+// UpdateModelAccessPolicy sets the "model_access_policy" field to the value that was provided on create.
+func (u *GroupUpsertBulk) UpdateModelAccessPolicy() *GroupUpsertBulk {
+	return u.Update(func(s *GroupUpsert) {
+		s.UpdateModelAccessPolicy()
+	})
+}
+
+// UpdateRetryPolicy sets the "retry_policy" field to the value that was provided on create.
+func (u *GroupUpsertBulk) UpdateRetryPolicy() *GroupUpsertBulk {
+	return u.Update(func(s *GroupUpsert) {
+		s.UpdateRetryPolicy()
+	})
+}
+
+// ClearModelAccessPolicy clears the value of the "model_access_policy" field.
+func (u *GroupUpsertBulk) ClearModelAccessPolicy() *GroupUpsertBulk {
+	return u.Update(func(s *GroupUpsert) {
+		s.ClearModelAccessPolicy()
+	})
+}
+
+// ClearRetryPolicy clears the value of the "retry_policy" field.
+func (u *GroupUpsertBulk) ClearRetryPolicy() *GroupUpsertBulk {
+	return u.Update(func(s *GroupUpsert) {
+		s.ClearRetryPolicy()
+	})
+}
+
 // SetModelRoutingEnabled sets the "model_routing_enabled" field.
 func (u *GroupUpsertBulk) SetModelRoutingEnabled(v bool) *GroupUpsertBulk {
 	return u.Update(func(s *GroupUpsert) {
@@ -2545,6 +3068,34 @@ func (u *GroupUpsertBulk) UpdateSortOrder() *GroupUpsertBulk {
 	})
 }
 
+// SetMeteringOnly sets the "metering_only" field.
+func (u *GroupUpsertBulk) SetMeteringOnly(v bool) *GroupUpsertBulk {
+	return u.Update(func(s *GroupUpsert) {
+		s.SetMeteringOnly(v)
+	})
+}
+
+// UpdateMeteringOnly sets the "metering_only" field to the value that was provided on create.
+func (u *GroupUpsertBulk) UpdateMeteringOnly() *GroupUpsertBulk {
+	return u.Update(func(s *GroupUpsert) {
+		s.UpdateMeteringOnly()
+	})
+}
+
+// SetStrictRequestFields sets the "strict_request_fields" field.
+func (u *GroupUpsertBulk) SetStrictRequestFields(v bool) *GroupUpsertBulk {
+	return u.Update(func(s *GroupUpsert) {
+		s.SetStrictRequestFields(v)
+	})
+}
+
+// UpdateStrictRequestFields sets the "strict_request_fields" field to the value that was provided on create.
+func (u *GroupUpsertBulk) UpdateStrictRequestFields() *GroupUpsertBulk {
+	return u.Update(func(s *GroupUpsert) {
+		s.UpdateStrictRequestFields()
+	})
+}
+
 // Exec executes the query.
 func (u *GroupUpsertBulk) Exec(ctx context.Context) error {
 	if u.create.err != nil {
@@ -20,6 +20,7 @@ import (
 	"github.com/Wei-Shaw/sub2api/ent/usagelog"
 	"github.com/Wei-Shaw/sub2api/ent/user"
 	"github.com/Wei-Shaw/sub2api/ent/usersubscription"
+	"github.com/Wei-Shaw/sub2api/internal/domain"
 )
 
 // GroupUpdate is the builder for updating Group entities.
@@ -435,6 +436,78 @@ func (_u *GroupUpdate) ClearModelRouting() *GroupUpdate {
 	return _u
 }
 
+// SetModelParamPolicies sets the "model_param_policies" field.
+func (_u *GroupUpdate) SetModelParamPolicies(v map[string]domain.ModelParamPolicy) *GroupUpdate {
+	_u.mutation.SetModelParamPolicies(v)
+	return _u
+}
+
+// ClearModelParamPolicies clears the value of the "model_param_policies" field.
+func (_u *GroupUpdate) ClearModelParamPolicies() *GroupUpdate {
+	_u.mutation.ClearModelParamPolicies()
+	return _u
+}
+
+// SetRegionPolicy sets the "region_policy" field.
+func (_u *GroupUpdate) SetRegionPolicy(v domain.RegionPolicy) *GroupUpdate {
+	_u.mutation.SetRegionPolicy(v)
+	return _u
+}
+
+// ClearRegionPolicy clears the value of the "region_policy" field.
+func (_u *GroupUpdate) ClearRegionPolicy() *GroupUpdate {
+	_u.mutation.ClearRegionPolicy()
+	return _u
+}
+
+// SetSystemPromptPolicy sets the "system_prompt_policy" field.
+func (_u *GroupUpdate) SetSystemPromptPolicy(v domain.SystemPromptPolicy) *GroupUpdate {
+	_u.mutation.SetSystemPromptPolicy(v)
+	return _u
+}
+
+// ClearSystemPromptPolicy clears the value of the "system_prompt_policy" field.
+func (_u *GroupUpdate) ClearSystemPromptPolicy() *GroupUpdate {
+	_u.mutation.ClearSystemPromptPolicy()
+	return _u
+}
+
+// SetPromptTemplate sets the "prompt_template" field.
+func (_u *GroupUpdate) SetPromptTemplate(v domain.PromptTemplate) *GroupUpdate {
+	_u.mutation.SetPromptTemplate(v)
+	return _u
+}
+
+// ClearPromptTemplate clears the value of the "prompt_template" field.
+func (_u *GroupUpdate) ClearPromptTemplate() *GroupUpdate {
+	_u.mutation.ClearPromptTemplate()
+	return _u
+}
+
+// SetModelAccessPolicy sets the "model_access_policy" field.
+func (_u *GroupUpdate) SetModelAccessPolicy(v domain.ModelAccessPolicy) *GroupUpdate {
+	_u.mutation.SetModelAccessPolicy(v)
+	return _u
+}
+
+// SetRetryPolicy sets the "retry_policy" field.
+func (_u *GroupUpdate) SetRetryPolicy(v domain.RetryPolicy) *GroupUpdate {
+	_u.mutation.SetRetryPolicy(v)
+	return _u
+}
+
+// ClearModelAccessPolicy clears the value of the "model_access_policy" field.
+func (_u *GroupUpdate) ClearModelAccessPolicy() *GroupUpdate {
+	_u.mutation.ClearModelAccessPolicy()
+	return _u
+}
+
+// ClearRetryPolicy clears the value of the "retry_policy" field.
+func (_u *GroupUpdate) ClearRetryPolicy() *GroupUpdate {
+	_u.mutation.ClearRetryPolicy()
+	return _u
+}
+
 // SetModelRoutingEnabled sets the "model_routing_enabled" field.
 func (_u *GroupUpdate) SetModelRoutingEnabled(v bool) *GroupUpdate {
 	_u.mutation.SetModelRoutingEnabled(v)
@@ -496,6 +569,34 @@ func (_u *GroupUpdate) AddSortOrder(v int) *GroupUpdate {
 	return _u
 }
 
+// SetMeteringOnly sets the "metering_only" field.
+func (_u *GroupUpdate) SetMeteringOnly(v bool) *GroupUpdate {
+	_u.mutation.SetMeteringOnly(v)
+	return _u
+}
+
+// SetNillableMeteringOnly sets the "metering_only" field if the given value is not nil.
+func (_u *GroupUpdate) SetNillableMeteringOnly(v *bool) *GroupUpdate {
+	if v != nil {
+		_u.SetMeteringOnly(*v)
+	}
+	return _u
+}
+
+// SetStrictRequestFields sets the "strict_request_fields" field.
+func (_u *GroupUpdate) SetStrictRequestFields(v bool) *GroupUpdate {
+	_u.mutation.SetStrictRequestFields(v)
+	return _u
+}
+
+// SetNillableStrictRequestFields sets the "strict_request_fields" field if the given value is not nil.
+func (_u *GroupUpdate) SetNillableStrictRequestFields(v *bool) *GroupUpdate {
+	if v != nil {
+		_u.SetStrictRequestFields(*v)
+	}
+	return _u
+}
+
 // AddAPIKeyIDs adds the "api_keys" edge to the APIKey entity by IDs.
 func (_u *GroupUpdate) AddAPIKeyIDs(ids ...int64) *GroupUpdate {
 	_u.mutation.AddAPIKeyIDs(ids...)
@@ -916,9 +1017,45 @@ func (_u *GroupUpdate) sqlSave(ctx context.Context) (_node int, err error) {
 	if value, ok := _u.mutation.ModelRouting(); ok {
 		_spec.SetField(group.FieldModelRouting, field.TypeJSON, value)
 	}
+	if value, ok := _u.mutation.ModelParamPolicies(); ok {
+		_spec.SetField(group.FieldModelParamPolicies, field.TypeJSON, value)
+	}
+	if value, ok := _u.mutation.RegionPolicy(); ok {
+		_spec.SetField(group.FieldRegionPolicy, field.TypeJSON, value)
+	}
+	if value, ok := _u.mutation.SystemPromptPolicy(); ok {
+		_spec.SetField(group.FieldSystemPromptPolicy, field.TypeJSON, value)
+	}
+	if value, ok := _u.mutation.PromptTemplate(); ok {
+		_spec.SetField(group.FieldPromptTemplate, field.TypeJSON, value)
+	}
+	if value, ok := _u.mutation.ModelAccessPolicy(); ok {
+		_spec.SetField(group.FieldModelAccessPolicy, field.TypeJSON, value)
+	}
+	if value, ok := _u.mutation.RetryPolicy(); ok {
+		_spec.SetField(group.FieldRetryPolicy, field.TypeJSON, value)
+	}
 	if _u.mutation.ModelRoutingCleared() {
 		_spec.ClearField(group.FieldModelRouting, field.TypeJSON)
 	}
+	if _u.mutation.ModelParamPoliciesCleared() {
+		_spec.ClearField(group.FieldModelParamPolicies, field.TypeJSON)
+	}
+	if _u.mutation.RegionPolicyCleared() {
+		_spec.ClearField(group.FieldRegionPolicy, field.TypeJSON)
+	}
+	if _u.mutation.SystemPromptPolicyCleared() {
+		_spec.ClearField(group.FieldSystemPromptPolicy, field.TypeJSON)
+	}
+	if _u.mutation.PromptTemplateCleared() {
+		_spec.ClearField(group.FieldPromptTemplate, field.TypeJSON)
+	}
+	if _u.mutation.ModelAccessPolicyCleared() {
+		_spec.ClearField(group.FieldModelAccessPolicy, field.TypeJSON)
+	}
+	if _u.mutation.RetryPolicyCleared() {
+		_spec.ClearField(group.FieldRetryPolicy, field.TypeJSON)
+	}
 	if value, ok := _u.mutation.ModelRoutingEnabled(); ok {
 		_spec.SetField(group.FieldModelRoutingEnabled, field.TypeBool, value)
 	}
@@ -939,6 +1076,12 @@ func (_u *GroupUpdate) sqlSave(ctx context.Context) (_node int, err error) {
 	if value, ok := _u.mutation.AddedSortOrder(); ok {
 		_spec.AddField(group.FieldSortOrder, field.TypeInt, value)
 	}
+	if value, ok := _u.mutation.MeteringOnly(); ok {
+		_spec.SetField(group.FieldMeteringOnly, field.TypeBool, value)
+	}
+	if value, ok := _u.mutation.StrictRequestFields(); ok {
+		_spec.SetField(group.FieldStrictRequestFields, field.TypeBool, value)
+	}
 	if _u.mutation.APIKeysCleared() {
 		edge := &sqlgraph.EdgeSpec{
 			Rel:     sqlgraph.O2M,
@@ -1653,6 +1796,78 @@ func (_u *GroupUpdateOne) ClearModelRouting() *GroupUpdateOne {
 	return _u
 }
 
+// SetModelParamPolicies sets the "model_param_policies" field.
+func (_u *GroupUpdateOne) SetModelParamPolicies(v map[string]domain.ModelParamPolicy) *GroupUpdateOne {
+	_u.mutation.SetModelParamPolicies(v)
+	return _u
+}
+
+// ClearModelParamPolicies clears the value of the "model_param_policies" field.
+func (_u *GroupUpdateOne) ClearModelParamPolicies() *GroupUpdateOne {
+	_u.mutation.ClearModelParamPolicies()
+	return _u
+}
+
+// SetRegionPolicy sets the "region_policy" field.
+func (_u *GroupUpdateOne) SetRegionPolicy(v domain.RegionPolicy) *GroupUpdateOne {
+	_u.mutation.SetRegionPolicy(v)
+	return _u
+}
+
+// ClearRegionPolicy clears the value of the "region_policy" field.
+func (_u *GroupUpdateOne) ClearRegionPolicy() *GroupUpdateOne {
+	_u.mutation.ClearRegionPolicy()
+	return _u
+}
+
+// SetSystemPromptPolicy sets the "system_prompt_policy" field.
+func (_u *GroupUpdateOne) SetSystemPromptPolicy(v domain.SystemPromptPolicy) *GroupUpdateOne {
+	_u.mutation.SetSystemPromptPolicy(v)
+	return _u
+}
+
+// ClearSystemPromptPolicy clears the value of the "system_prompt_policy" field.
+func (_u *GroupUpdateOne) ClearSystemPromptPolicy() *GroupUpdateOne {
+	_u.mutation.ClearSystemPromptPolicy()
+	return _u
+}
+
+// SetPromptTemplate sets the "prompt_template" field.
+func (_u *GroupUpdateOne) SetPromptTemplate(v domain.PromptTemplate) *GroupUpdateOne {
+	_u.mutation.SetPromptTemplate(v)
+	return _u
+}
+
+// ClearPromptTemplate clears the value of the "prompt_template" field.
+func (_u *GroupUpdateOne) ClearPromptTemplate() *GroupUpdateOne {
+	_u.mutation.ClearPromptTemplate()
+	return _u
+}
+
+// SetModelAccessPolicy sets the "model_access_policy" field.
+func (_u *GroupUpdateOne) SetModelAccessPolicy(v domain.ModelAccessPolicy) *GroupUpdateOne {
+	_u.mutation.SetModelAccessPolicy(v)
+	return _u
+}
+
+// SetRetryPolicy sets the "retry_policy" field.
+func (_u *GroupUpdateOne) SetRetryPolicy(v domain.RetryPolicy) *GroupUpdateOne {
+	_u.mutation.SetRetryPolicy(v)
+	return _u
+}
+
+// ClearModelAccessPolicy clears the value of the "model_access_policy" field.
+func (_u *GroupUpdateOne) ClearModelAccessPolicy() *GroupUpdateOne {
+	_u.mutation.ClearModelAccessPolicy()
+	return _u
+}
+
+// ClearRetryPolicy clears the value of the "retry_policy" field.
+func (_u *GroupUpdateOne) ClearRetryPolicy() *GroupUpdateOne {
+	_u.mutation.ClearRetryPolicy()
+	return _u
+}
+
 // SetModelRoutingEnabled sets the "model_routing_enabled" field.
 func (_u *GroupUpdateOne) SetModelRoutingEnabled(v bool) *GroupUpdateOne {
 	_u.mutation.SetModelRoutingEnabled(v)
@@ -1714,6 +1929,34 @@ func (_u *GroupUpdateOne) AddSortOrder(v int) *GroupUpdateOne {
 	return _u
 }
 
+// SetMeteringOnly sets the "metering_only" field.
+func (_u *GroupUpdateOne) SetMeteringOnly(v bool) *GroupUpdateOne {
+	_u.mutation.SetMeteringOnly(v)
+	return _u
+}
+
+// SetNillableMeteringOnly sets the "metering_only" field if the given value is not nil.
+func (_u *GroupUpdateOne) SetNillableMeteringOnly(v *bool) *GroupUpdateOne {
+	if v != nil {
+		_u.SetMeteringOnly(*v)
+	}
+	return _u
+}
+
+// SetStrictRequestFields sets the "strict_request_fields" field.
+func (_u *GroupUpdateOne) SetStrictRequestFields(v bool) *GroupUpdateOne {
+	_u.mutation.SetStrictRequestFields(v)
+	return _u
+}
+
+// SetNillableStrictRequestFields sets the "strict_request_fields" field if the given value is not nil.
+func (_u *GroupUpdateOne) SetNillableStrictRequestFields(v *bool) *GroupUpdateOne {
+	if v != nil {
+		_u.SetStrictRequestFields(*v)
+	}
+	return _u
+}
+
 // AddAPIKeyIDs adds the "api_keys" edge to the APIKey entity by IDs.
 func (_u *GroupUpdateOne) AddAPIKeyIDs(ids ...int64) *GroupUpdateOne {
 	_u.mutation.AddAPIKeyIDs(ids...)
@@ -2164,9 +2407,45 @@ func (_u *GroupUpdateOne) sqlSave(ctx context.Context) (_node *Group, err error)
 	if value, ok := _u.mutation.ModelRouting(); ok {
 		_spec.SetField(group.FieldModelRouting, field.TypeJSON, value)
 	}
+	if value, ok := _u.mutation.ModelParamPolicies(); ok {
+		_spec.SetField(group.FieldModelParamPolicies, field.TypeJSON, value)
+	}
+	if value, ok := _u.mutation.RegionPolicy(); ok {
+		_spec.SetField(group.FieldRegionPolicy, field.TypeJSON, value)
+	}
+	if value, ok := _u.mutation.SystemPromptPolicy(); ok {
+		_spec.SetField(group.FieldSystemPromptPolicy, field.TypeJSON, value)
+	}
+	if value, ok := _u.mutation.PromptTemplate(); ok {
+		_spec.SetField(group.FieldPromptTemplate, field.TypeJSON, value)
+	}
+	if value, ok := _u.mutation.ModelAccessPolicy(); ok {
+		_spec.SetField(group.FieldModelAccessPolicy, field.TypeJSON, value)
+	}
+	if value, ok := _u.mutation.RetryPolicy(); ok {
+		_spec.SetField(group.FieldRetryPolicy, field.TypeJSON, value)
+	}
 	if _u.mutation.ModelRoutingCleared() {
 		_spec.ClearField(group.FieldModelRouting, field.TypeJSON)
 	}
+	if _u.mutation.ModelParamPoliciesCleared() {
+		_spec.ClearField(group.FieldModelParamPolicies, field.TypeJSON)
+	}
+	if _u.mutation.RegionPolicyCleared() {
+		_spec.ClearField(group.FieldRegionPolicy, field.TypeJSON)
+	}
+	if _u.mutation.SystemPromptPolicyCleared() {
+		_spec.ClearField(group.FieldSystemPromptPolicy, field.TypeJSON)
+	}
+	if _u.mutation.PromptTemplateCleared() {
+		_spec.ClearField(group.FieldPromptTemplate, field.TypeJSON)
+	}
+	if _u.mutation.ModelAccessPolicyCleared() {
+		_spec.ClearField(group.FieldModelAccessPolicy, field.TypeJSON)
+	}
+	if _u.mutation.RetryPolicyCleared() {
+		_spec.ClearField(group.FieldRetryPolicy, field.TypeJSON)
+	}
 	if value, ok := _u.mutation.ModelRoutingEnabled(); ok {
 		_spec.SetField(group.FieldModelRoutingEnabled, field.TypeBool, value)
 	}
@@ -2187,6 +2466,12 @@ func (_u *GroupUpdateOne) sqlSave(ctx context.Context) (_node *Group, err error)
 	if value, ok := _u.mutation.AddedSortOrder(); ok {
 		_spec.AddField(group.FieldSortOrder, field.TypeInt, value)
 	}
+	if value, ok := _u.mutation.MeteringOnly(); ok {
+		_spec.SetField(group.FieldMeteringOnly, field.TypeBool, value)
+	}
+	if value, ok := _u.mutation.StrictRequestFields(); ok {
+		_spec.SetField(group.FieldStrictRequestFields, field.TypeBool, value)
+	}
 	if _u.mutation.APIKeysCleared() {
 		edge := &sqlgraph.EdgeSpec{
 			Rel:     sqlgraph.O2M,
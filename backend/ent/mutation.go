@@ -68,35 +68,47 @@ const (
 // APIKeyMutation represents an operation that mutates the APIKey nodes in the graph.
 type APIKeyMutation struct {
 	config
-	op                 Op
-	typ                string
-	id                 *int64
-	created_at         *time.Time
-	updated_at         *time.Time
-	deleted_at         *time.Time
-	key                *string
-	name               *string
-	status             *string
-	ip_whitelist       *[]string
-	appendip_whitelist []string
-	ip_blacklist       *[]string
-	appendip_blacklist []string
-	quota              *float64
-	addquota           *float64
-	quota_used         *float64
-	addquota_used      *float64
-	expires_at         *time.Time
-	clearedFields      map[string]struct{}
-	user               *int64
-	cleareduser        bool
-	group              *int64
-	clearedgroup       bool
-	usage_logs         map[int64]struct{}
-	removedusage_logs  map[int64]struct{}
-	clearedusage_logs  bool
-	done               bool
-	oldValue           func(context.Context) (*APIKey, error)
-	predicates         []predicate.APIKey
+	op                   Op
+	typ                  string
+	id                   *int64
+	created_at           *time.Time
+	updated_at           *time.Time
+	deleted_at           *time.Time
+	key                  *string
+	name                 *string
+	status               *string
+	priority_class       *string
+	ip_whitelist         *[]string
+	appendip_whitelist   []string
+	allowed_models       *[]string
+	appendallowed_models []string
+	scopes               *[]string
+	appendscopes         []string
+	ip_blacklist         *[]string
+	appendip_blacklist   []string
+	region_policy        *domain.RegionPolicy
+	system_prompt_policy *domain.SystemPromptPolicy
+	usage_webhook        *domain.UsageWebhook
+	request_signing      *domain.RequestSigning
+	token_quota          *domain.TokenQuota
+	tool_limits          *map[string]int
+	debug_errors         *bool
+	quota                *float64
+	addquota             *float64
+	quota_used           *float64
+	addquota_used        *float64
+	expires_at           *time.Time
+	clearedFields        map[string]struct{}
+	user                 *int64
+	cleareduser          bool
+	group                *int64
+	clearedgroup         bool
+	usage_logs           map[int64]struct{}
+	removedusage_logs    map[int64]struct{}
+	clearedusage_logs    bool
+	done                 bool
+	oldValue             func(context.Context) (*APIKey, error)
+	predicates           []predicate.APIKey
 }
 
 var _ ent.Mutation = (*APIKeyMutation)(nil)
@@ -511,6 +523,42 @@ func (m *APIKeyMutation) ResetStatus() {
 	m.status = nil
 }
 
+// SetPriorityClass sets the "priority_class" field.
+func (m *APIKeyMutation) SetPriorityClass(s string) {
+	m.priority_class = &s
+}
+
+// PriorityClass returns the value of the "priority_class" field in the mutation.
+func (m *APIKeyMutation) PriorityClass() (r string, exists bool) {
+	v := m.priority_class
+	if v == nil {
+		return
+	}
+	return *v, true
+}
+
+// OldPriorityClass returns the old "priority_class" field's value of the APIKey entity.
+// If the APIKey object wasn't provided to the builder, the object is fetched from the database.
+// An error is returned if the mutation operation is not UpdateOne, or the database query fails.
+func (m *APIKeyMutation) OldPriorityClass(ctx context.Context) (v string, err error) {
+	if !m.op.Is(OpUpdateOne) {
+		return v, errors.New("OldPriorityClass is only allowed on UpdateOne operations")
+	}
+	if m.id == nil || m.oldValue == nil {
+		return v, errors.New("OldPriorityClass requires an ID field in the mutation")
+	}
+	oldValue, err := m.oldValue(ctx)
+	if err != nil {
+		return v, fmt.Errorf("querying old value for OldPriorityClass: %w", err)
+	}
+	return oldValue.PriorityClass, nil
+}
+
+// ResetPriorityClass resets all changes to the "priority_class" field.
+func (m *APIKeyMutation) ResetPriorityClass() {
+	m.priority_class = nil
+}
+
 // SetIPWhitelist sets the "ip_whitelist" field.
 func (m *APIKeyMutation) SetIPWhitelist(s []string) {
 	m.ip_whitelist = &s
@@ -576,6 +624,136 @@ func (m *APIKeyMutation) ResetIPWhitelist() {
 	delete(m.clearedFields, apikey.FieldIPWhitelist)
 }
 
+// SetAllowedModels sets the "allowed_models" field.
+func (m *APIKeyMutation) SetAllowedModels(s []string) {
+	m.allowed_models = &s
+	m.appendallowed_models = nil
+}
+
+// AllowedModels returns the value of the "allowed_models" field in the mutation.
+func (m *APIKeyMutation) AllowedModels() (r []string, exists bool) {
+	v := m.allowed_models
+	if v == nil {
+		return
+	}
+	return *v, true
+}
+
+// OldAllowedModels returns the old "allowed_models" field's value of the APIKey entity.
+// If the APIKey object wasn't provided to the builder, the object is fetched from the database.
+// An error is returned if the mutation operation is not UpdateOne, or the database query fails.
+func (m *APIKeyMutation) OldAllowedModels(ctx context.Context) (v []string, err error) {
+	if !m.op.Is(OpUpdateOne) {
+		return v, errors.New("OldAllowedModels is only allowed on UpdateOne operations")
+	}
+	if m.id == nil || m.oldValue == nil {
+		return v, errors.New("OldAllowedModels requires an ID field in the mutation")
+	}
+	oldValue, err := m.oldValue(ctx)
+	if err != nil {
+		return v, fmt.Errorf("querying old value for OldAllowedModels: %w", err)
+	}
+	return oldValue.AllowedModels, nil
+}
+
+// AppendAllowedModels adds s to the "allowed_models" field.
+func (m *APIKeyMutation) AppendAllowedModels(s []string) {
+	m.appendallowed_models = append(m.appendallowed_models, s...)
+}
+
+// AppendedAllowedModels returns the list of values that were appended to the "allowed_models" field in this mutation.
+func (m *APIKeyMutation) AppendedAllowedModels() ([]string, bool) {
+	if len(m.appendallowed_models) == 0 {
+		return nil, false
+	}
+	return m.appendallowed_models, true
+}
+
+// ClearAllowedModels clears the value of the "allowed_models" field.
+func (m *APIKeyMutation) ClearAllowedModels() {
+	m.allowed_models = nil
+	m.appendallowed_models = nil
+	m.clearedFields[apikey.FieldAllowedModels] = struct{}{}
+}
+
+// AllowedModelsCleared returns if the "allowed_models" field was cleared in this mutation.
+func (m *APIKeyMutation) AllowedModelsCleared() bool {
+	_, ok := m.clearedFields[apikey.FieldAllowedModels]
+	return ok
+}
+
+// ResetAllowedModels resets all changes to the "allowed_models" field.
+func (m *APIKeyMutation) ResetAllowedModels() {
+	m.allowed_models = nil
+	m.appendallowed_models = nil
+	delete(m.clearedFields, apikey.FieldAllowedModels)
+}
+
+// SetScopes sets the "scopes" field.
+func (m *APIKeyMutation) SetScopes(s []string) {
+	m.scopes = &s
+	m.appendscopes = nil
+}
+
+// Scopes returns the value of the "scopes" field in the mutation.
+func (m *APIKeyMutation) Scopes() (r []string, exists bool) {
+	v := m.scopes
+	if v == nil {
+		return
+	}
+	return *v, true
+}
+
+// OldScopes returns the old "scopes" field's value of the APIKey entity.
+// If the APIKey object wasn't provided to the builder, the object is fetched from the database.
+// An error is returned if the mutation operation is not UpdateOne, or the database query fails.
+func (m *APIKeyMutation) OldScopes(ctx context.Context) (v []string, err error) {
+	if !m.op.Is(OpUpdateOne) {
+		return v, errors.New("OldScopes is only allowed on UpdateOne operations")
+	}
+	if m.id == nil || m.oldValue == nil {
+		return v, errors.New("OldScopes requires an ID field in the mutation")
+	}
+	oldValue, err := m.oldValue(ctx)
+	if err != nil {
+		return v, fmt.Errorf("querying old value for OldScopes: %w", err)
+	}
+	return oldValue.Scopes, nil
+}
+
+// AppendScopes adds s to the "scopes" field.
+func (m *APIKeyMutation) AppendScopes(s []string) {
+	m.appendscopes = append(m.appendscopes, s...)
+}
+
+// AppendedScopes returns the list of values that were appended to the "scopes" field in this mutation.
+func (m *APIKeyMutation) AppendedScopes() ([]string, bool) {
+	if len(m.appendscopes) == 0 {
+		return nil, false
+	}
+	return m.appendscopes, true
+}
+
+// ClearScopes clears the value of the "scopes" field.
+func (m *APIKeyMutation) ClearScopes() {
+	m.scopes = nil
+	m.appendscopes = nil
+	m.clearedFields[apikey.FieldScopes] = struct{}{}
+}
+
+// ScopesCleared returns if the "scopes" field was cleared in this mutation.
+func (m *APIKeyMutation) ScopesCleared() bool {
+	_, ok := m.clearedFields[apikey.FieldScopes]
+	return ok
+}
+
+// ResetScopes resets all changes to the "scopes" field.
+func (m *APIKeyMutation) ResetScopes() {
+	m.scopes = nil
+	m.appendscopes = nil
+	delete(m.clearedFields, apikey.FieldScopes)
+}
+
 // SetIPBlacklist sets the "ip_blacklist" field.
 func (m *APIKeyMutation) SetIPBlacklist(s []string) {
 	m.ip_blacklist = &s
@@ -641,6 +819,336 @@ func (m *APIKeyMutation) ResetIPBlacklist() {
 	delete(m.clearedFields, apikey.FieldIPBlacklist)
 }
 
+// SetRegionPolicy sets the "region_policy" field.
+func (m *APIKeyMutation) SetRegionPolicy(rp domain.RegionPolicy) {
+	m.region_policy = &rp
+}
+
+// RegionPolicy returns the value of the "region_policy" field in the mutation.
+func (m *APIKeyMutation) RegionPolicy() (r domain.RegionPolicy, exists bool) {
+	v := m.region_policy
+	if v == nil {
+		return
+	}
+	return *v, true
+}
+
+// OldRegionPolicy returns the old "region_policy" field's value of the APIKey entity.
+// If the APIKey object wasn't provided to the builder, the object is fetched from the database.
+// An error is returned if the mutation operation is not UpdateOne, or the database query fails.
+func (m *APIKeyMutation) OldRegionPolicy(ctx context.Context) (v domain.RegionPolicy, err error) {
+	if !m.op.Is(OpUpdateOne) {
+		return v, errors.New("OldRegionPolicy is only allowed on UpdateOne operations")
+	}
+	if m.id == nil || m.oldValue == nil {
+		return v, errors.New("OldRegionPolicy requires an ID field in the mutation")
+	}
+	oldValue, err := m.oldValue(ctx)
+	if err != nil {
+		return v, fmt.Errorf("querying old value for OldRegionPolicy: %w", err)
+	}
+	return oldValue.RegionPolicy, nil
+}
+
+// ClearRegionPolicy clears the value of the "region_policy" field.
+func (m *APIKeyMutation) ClearRegionPolicy() {
+	m.region_policy = nil
+	m.clearedFields[apikey.FieldRegionPolicy] = struct{}{}
+}
+
+// RegionPolicyCleared returns if the "region_policy" field was cleared in this mutation.
+func (m *APIKeyMutation) RegionPolicyCleared() bool {
+	_, ok := m.clearedFields[apikey.FieldRegionPolicy]
+	return ok
+}
+
+// ResetRegionPolicy resets all changes to the "region_policy" field.
+func (m *APIKeyMutation) ResetRegionPolicy() {
+	m.region_policy = nil
+	delete(m.clearedFields, apikey.FieldRegionPolicy)
+}
+
+// SetSystemPromptPolicy sets the "system_prompt_policy" field.
+func (m *APIKeyMutation) SetSystemPromptPolicy(rp domain.SystemPromptPolicy) {
+	m.system_prompt_policy = &rp
+}
+
+// SystemPromptPolicy returns the value of the "system_prompt_policy" field in the mutation.
+func (m *APIKeyMutation) SystemPromptPolicy() (r domain.SystemPromptPolicy, exists bool) {
+	v := m.system_prompt_policy
+	if v == nil {
+		return
+	}
+	return *v, true
+}
+
+// OldSystemPromptPolicy returns the old "system_prompt_policy" field's value of the APIKey entity.
+// If the APIKey object wasn't provided to the builder, the object is fetched from the database.
+// An error is returned if the mutation operation is not UpdateOne, or the database query fails.
+func (m *APIKeyMutation) OldSystemPromptPolicy(ctx context.Context) (v domain.SystemPromptPolicy, err error) {
+	if !m.op.Is(OpUpdateOne) {
+		return v, errors.New("OldSystemPromptPolicy is only allowed on UpdateOne operations")
+	}
+	if m.id == nil || m.oldValue == nil {
+		return v, errors.New("OldSystemPromptPolicy requires an ID field in the mutation")
+	}
+	oldValue, err := m.oldValue(ctx)
+	if err != nil {
+		return v, fmt.Errorf("querying old value for OldSystemPromptPolicy: %w", err)
+	}
+	return oldValue.SystemPromptPolicy, nil
+}
+
+// ClearSystemPromptPolicy clears the value of the "system_prompt_policy" field.
+func (m *APIKeyMutation) ClearSystemPromptPolicy() {
+	m.system_prompt_policy = nil
+	m.clearedFields[apikey.FieldSystemPromptPolicy] = struct{}{}
+}
+
+// SystemPromptPolicyCleared returns if the "system_prompt_policy" field was cleared in this mutation.
+func (m *APIKeyMutation) SystemPromptPolicyCleared() bool {
+	_, ok := m.clearedFields[apikey.FieldSystemPromptPolicy]
+	return ok
+}
+
+// ResetSystemPromptPolicy resets all changes to the "system_prompt_policy" field.
+func (m *APIKeyMutation) ResetSystemPromptPolicy() {
+	m.system_prompt_policy = nil
+	delete(m.clearedFields, apikey.FieldSystemPromptPolicy)
+}
+
+// SetUsageWebhook sets the "usage_webhook" field.
+func (m *APIKeyMutation) SetUsageWebhook(rp domain.UsageWebhook) {
+	m.usage_webhook = &rp
+}
+
+// UsageWebhook returns the value of the "usage_webhook" field in the mutation.
+func (m *APIKeyMutation) UsageWebhook() (r domain.UsageWebhook, exists bool) {
+	v := m.usage_webhook
+	if v == nil {
+		return
+	}
+	return *v, true
+}
+
+// OldUsageWebhook returns the old "usage_webhook" field's value of the APIKey entity.
+// If the APIKey object wasn't provided to the builder, the object is fetched from the database.
+// An error is returned if the mutation operation is not UpdateOne, or the database query fails.
+func (m *APIKeyMutation) OldUsageWebhook(ctx context.Context) (v domain.UsageWebhook, err error) {
+	if !m.op.Is(OpUpdateOne) {
+		return v, errors.New("OldUsageWebhook is only allowed on UpdateOne operations")
+	}
+	if m.id == nil || m.oldValue == nil {
+		return v, errors.New("OldUsageWebhook requires an ID field in the mutation")
+	}
+	oldValue, err := m.oldValue(ctx)
+	if err != nil {
+		return v, fmt.Errorf("querying old value for OldUsageWebhook: %w", err)
+	}
+	return oldValue.UsageWebhook, nil
+}
+
+// ClearUsageWebhook clears the value of the "usage_webhook" field.
+func (m *APIKeyMutation) ClearUsageWebhook() {
+	m.usage_webhook = nil
+	m.clearedFields[apikey.FieldUsageWebhook] = struct{}{}
+}
+
+// UsageWebhookCleared returns if the "usage_webhook" field was cleared in this mutation.
+func (m *APIKeyMutation) UsageWebhookCleared() bool {
+	_, ok := m.clearedFields[apikey.FieldUsageWebhook]
+	return ok
+}
+
+// ResetUsageWebhook resets all changes to the "usage_webhook" field.
+func (m *APIKeyMutation) ResetUsageWebhook() {
+	m.usage_webhook = nil
+	delete(m.clearedFields, apikey.FieldUsageWebhook)
+}
+
+// SetRequestSigning sets the "request_signing" field.
+func (m *APIKeyMutation) SetRequestSigning(rp domain.RequestSigning) {
+	m.request_signing = &rp
+}
+
+// RequestSigning returns the value of the "request_signing" field in the mutation.
+func (m *APIKeyMutation) RequestSigning() (r domain.RequestSigning, exists bool) {
+	v := m.request_signing
+	if v == nil {
+		return
+	}
+	return *v, true
+}
+
+// OldRequestSigning returns the old "request_signing" field's value of the APIKey entity.
+// If the APIKey object wasn't provided to the builder, the object is fetched from the database.
+// An error is returned if the mutation operation is not UpdateOne, or the database query fails.
+func (m *APIKeyMutation) OldRequestSigning(ctx context.Context) (v domain.RequestSigning, err error) {
+	if !m.op.Is(OpUpdateOne) {
+		return v, errors.New("OldRequestSigning is only allowed on UpdateOne operations")
+	}
+	if m.id == nil || m.oldValue == nil {
+		return v, errors.New("OldRequestSigning requires an ID field in the mutation")
+	}
+	oldValue, err := m.oldValue(ctx)
+	if err != nil {
+		return v, fmt.Errorf("querying old value for OldRequestSigning: %w", err)
+	}
+	return oldValue.RequestSigning, nil
+}
+
+// ClearRequestSigning clears the value of the "request_signing" field.
+func (m *APIKeyMutation) ClearRequestSigning() {
+	m.request_signing = nil
+	m.clearedFields[apikey.FieldRequestSigning] = struct{}{}
+}
+
+// RequestSigningCleared returns if the "request_signing" field was cleared in this mutation.
+func (m *APIKeyMutation) RequestSigningCleared() bool {
+	_, ok := m.clearedFields[apikey.FieldRequestSigning]
+	return ok
+}
+
+// ResetRequestSigning resets all changes to the "request_signing" field.
+func (m *APIKeyMutation) ResetRequestSigning() {
+	m.request_signing = nil
+	delete(m.clearedFields, apikey.FieldRequestSigning)
+}
+
+// SetTokenQuota sets the "token_quota" field.
+func (m *APIKeyMutation) SetTokenQuota(rp domain.TokenQuota) {
+	m.token_quota = &rp
+}
+
+// TokenQuota returns the value of the "token_quota" field in the mutation.
+func (m *APIKeyMutation) TokenQuota() (r domain.TokenQuota, exists bool) {
+	v := m.token_quota
+	if v == nil {
+		return
+	}
+	return *v, true
+}
+
+// OldTokenQuota returns the old "token_quota" field's value of the APIKey entity.
+// If the APIKey object wasn't provided to the builder, the object is fetched from the database.
+// An error is returned if the mutation operation is not UpdateOne, or the database query fails.
+func (m *APIKeyMutation) OldTokenQuota(ctx context.Context) (v domain.TokenQuota, err error) {
+	if !m.op.Is(OpUpdateOne) {
+		return v, errors.New("OldTokenQuota is only allowed on UpdateOne operations")
+	}
+	if m.id == nil || m.oldValue == nil {
+		return v, errors.New("OldTokenQuota requires an ID field in the mutation")
+	}
+	oldValue, err := m.oldValue(ctx)
+	if err != nil {
+		return v, fmt.Errorf("querying old value for OldTokenQuota: %w", err)
+	}
+	return oldValue.TokenQuota, nil
+}
+
+// ClearTokenQuota clears the value of the "token_quota" field.
+func (m *APIKeyMutation) ClearTokenQuota() {
+	m.token_quota = nil
+	m.clearedFields[apikey.FieldTokenQuota] = struct{}{}
+}
+
+// TokenQuotaCleared returns if the "token_quota" field was cleared in this mutation.
+func (m *APIKeyMutation) TokenQuotaCleared() bool {
+	_, ok := m.clearedFields[apikey.FieldTokenQuota]
+	return ok
+}
+
+// ResetTokenQuota resets all changes to the "token_quota" field.
+func (m *APIKeyMutation) ResetTokenQuota() {
+	m.token_quota = nil
+	delete(m.clearedFields, apikey.FieldTokenQuota)
+}
+
+// SetToolLimits sets the "tool_limits" field.
+func (m *APIKeyMutation) SetToolLimits(value map[string]int) {
+	m.tool_limits = &value
+}
+
+// ToolLimits returns the value of the "tool_limits" field in the mutation.
+func (m *APIKeyMutation) ToolLimits() (r map[string]int, exists bool) {
+	v := m.tool_limits
+	if v == nil {
+		return
+	}
+	return *v, true
+}
+
+// OldToolLimits returns the old "tool_limits" field's value of the APIKey entity.
+// If the APIKey object wasn't provided to the builder, the object is fetched from the database.
+// An error is returned if the mutation operation is not UpdateOne, or the database query fails.
+func (m *APIKeyMutation) OldToolLimits(ctx context.Context) (v map[string]int, err error) {
+	if !m.op.Is(OpUpdateOne) {
+		return v, errors.New("OldToolLimits is only allowed on UpdateOne operations")
+	}
+	if m.id == nil || m.oldValue == nil {
+		return v, errors.New("OldToolLimits requires an ID field in the mutation")
+	}
+	oldValue, err := m.oldValue(ctx)
+	if err != nil {
+		return v, fmt.Errorf("querying old value for OldToolLimits: %w", err)
+	}
+	return oldValue.ToolLimits, nil
+}
+
+// ClearToolLimits clears the value of the "tool_limits" field.
+func (m *APIKeyMutation) ClearToolLimits() {
+	m.tool_limits = nil
+	m.clearedFields[apikey.FieldToolLimits] = struct{}{}
+}
+
+// ToolLimitsCleared returns if the "tool_limits" field was cleared in this mutation.
+func (m *APIKeyMutation) ToolLimitsCleared() bool {
+	_, ok := m.clearedFields[apikey.FieldToolLimits]
+	return ok
+}
+
+// ResetToolLimits resets all changes to the "tool_limits" field.
+func (m *APIKeyMutation) ResetToolLimits() {
+	m.tool_limits = nil
+	delete(m.clearedFields, apikey.FieldToolLimits)
+}
+
+// SetDebugErrors sets the "debug_errors" field.
+func (m *APIKeyMutation) SetDebugErrors(b bool) {
+	m.debug_errors = &b
+}
+
+// DebugErrors returns the value of the "debug_errors" field in the mutation.
+func (m *APIKeyMutation) DebugErrors() (r bool, exists bool) {
+	v := m.debug_errors
+	if v == nil {
+		return
+	}
+	return *v, true
+}
+
+// OldDebugErrors returns the old "debug_errors" field's value of the APIKey entity.
+// If the APIKey object wasn't provided to the builder, the object is fetched from the database.
+// An error is returned if the mutation operation is not UpdateOne, or the database query fails.
+func (m *APIKeyMutation) OldDebugErrors(ctx context.Context) (v bool, err error) {
+	if !m.op.Is(OpUpdateOne) {
+		return v, errors.New("OldDebugErrors is only allowed on UpdateOne operations")
+	}
+	if m.id == nil || m.oldValue == nil {
+		return v, errors.New("OldDebugErrors requires an ID field in the mutation")
+	}
+	oldValue, err := m.oldValue(ctx)
+	if err != nil {
+		return v, fmt.Errorf("querying old value for OldDebugErrors: %w", err)
+	}
+	return oldValue.DebugErrors, nil
+}
+
+// ResetDebugErrors resets all changes to the "debug_errors" field.
+func (m *APIKeyMutation) ResetDebugErrors() {
+	m.debug_errors = nil
+}
+
 // SetQuota sets the "quota" field.
 func (m *APIKeyMutation) SetQuota(f float64) {
 	m.quota = &f
@@ -944,7 +1452,7 @@ func (m *APIKeyMutation) Type() string {
 // order to get all numeric fields that were incremented/decremented, call
 // AddedFields().
 func (m *APIKeyMutation) Fields() []string {
-	fields := make([]string, 0, 13)
+	fields := make([]string, 0, 22)
 	if m.created_at != nil {
 		fields = append(fields, apikey.FieldCreatedAt)
 	}
@@ -969,12 +1477,42 @@ func (m *APIKeyMutation) Fields() []string {
 	if m.status != nil {
 		fields = append(fields, apikey.FieldStatus)
 	}
+	if m.priority_class != nil {
+		fields = append(fields, apikey.FieldPriorityClass)
+	}
 	if m.ip_whitelist != nil {
 		fields = append(fields, apikey.FieldIPWhitelist)
 	}
+	if m.allowed_models != nil {
+		fields = append(fields, apikey.FieldAllowedModels)
+	}
+	if m.scopes != nil {
+		fields = append(fields, apikey.FieldScopes)
+	}
 	if m.ip_blacklist != nil {
 		fields = append(fields, apikey.FieldIPBlacklist)
 	}
+	if m.region_policy != nil {
+		fields = append(fields, apikey.FieldRegionPolicy)
+	}
+	if m.system_prompt_policy != nil {
+		fields = append(fields, apikey.FieldSystemPromptPolicy)
+	}
+	if m.usage_webhook != nil {
+		fields = append(fields, apikey.FieldUsageWebhook)
+	}
+	if m.request_signing != nil {
+		fields = append(fields, apikey.FieldRequestSigning)
+	}
+	if m.token_quota != nil {
+		fields = append(fields, apikey.FieldTokenQuota)
+	}
+	if m.tool_limits != nil {
+		fields = append(fields, apikey.FieldToolLimits)
+	}
+	if m.debug_errors != nil {
+		fields = append(fields, apikey.FieldDebugErrors)
+	}
 	if m.quota != nil {
 		fields = append(fields, apikey.FieldQuota)
 	}
@@ -1008,10 +1546,30 @@ func (m *APIKeyMutation) Field(name string) (ent.Value, bool) {
 		return m.GroupID()
 	case apikey.FieldStatus:
 		return m.Status()
+	case apikey.FieldPriorityClass:
+		return m.PriorityClass()
 	case apikey.FieldIPWhitelist:
 		return m.IPWhitelist()
+	case apikey.FieldAllowedModels:
+		return m.AllowedModels()
+	case apikey.FieldScopes:
+		return m.Scopes()
 	case apikey.FieldIPBlacklist:
 		return m.IPBlacklist()
+	case apikey.FieldRegionPolicy:
+		return m.RegionPolicy()
+	case apikey.FieldSystemPromptPolicy:
+		return m.SystemPromptPolicy()
+	case apikey.FieldUsageWebhook:
+		return m.UsageWebhook()
+	case apikey.FieldRequestSigning:
+		return m.RequestSigning()
+	case apikey.FieldTokenQuota:
+		return m.TokenQuota()
+	case apikey.FieldToolLimits:
+		return m.ToolLimits()
+	case apikey.FieldDebugErrors:
+		return m.DebugErrors()
 	case apikey.FieldQuota:
 		return m.Quota()
 	case apikey.FieldQuotaUsed:
@@ -1043,10 +1601,30 @@ func (m *APIKeyMutation) OldField(ctx context.Context, name string) (ent.Value,
 		return m.OldGroupID(ctx)
 	case apikey.FieldStatus:
 		return m.OldStatus(ctx)
+	case apikey.FieldPriorityClass:
+		return m.OldPriorityClass(ctx)
 	case apikey.FieldIPWhitelist:
 		return m.OldIPWhitelist(ctx)
+	case apikey.FieldAllowedModels:
+		return m.OldAllowedModels(ctx)
+	case apikey.FieldScopes:
+		return m.OldScopes(ctx)
 	case apikey.FieldIPBlacklist:
 		return m.OldIPBlacklist(ctx)
+	case apikey.FieldRegionPolicy:
+		return m.OldRegionPolicy(ctx)
+	case apikey.FieldSystemPromptPolicy:
+		return m.OldSystemPromptPolicy(ctx)
+	case apikey.FieldUsageWebhook:
+		return m.OldUsageWebhook(ctx)
+	case apikey.FieldRequestSigning:
+		return m.OldRequestSigning(ctx)
+	case apikey.FieldTokenQuota:
+		return m.OldTokenQuota(ctx)
+	case apikey.FieldToolLimits:
+		return m.OldToolLimits(ctx)
+	case apikey.FieldDebugErrors:
+		return m.OldDebugErrors(ctx)
 	case apikey.FieldQuota:
 		return m.OldQuota(ctx)
 	case apikey.FieldQuotaUsed:
@@ -1118,6 +1696,13 @@ func (m *APIKeyMutation) SetField(name string, value ent.Value) error {
 		}
 		m.SetStatus(v)
 		return nil
+	case apikey.FieldPriorityClass:
+		v, ok := value.(string)
+		if !ok {
+			return fmt.Errorf("unexpected type %T for field %s", value, name)
+		}
+		m.SetPriorityClass(v)
+		return nil
 	case apikey.FieldIPWhitelist:
 		v, ok := value.([]string)
 		if !ok {
@@ -1125,6 +1710,20 @@ func (m *APIKeyMutation) SetField(name string, value ent.Value) error {
 		}
 		m.SetIPWhitelist(v)
 		return nil
+	case apikey.FieldAllowedModels:
+		v, ok := value.([]string)
+		if !ok {
+			return fmt.Errorf("unexpected type %T for field %s", value, name)
+		}
+		m.SetAllowedModels(v)
+		return nil
+	case apikey.FieldScopes:
+		v, ok := value.([]string)
+		if !ok {
+			return fmt.Errorf("unexpected type %T for field %s", value, name)
+		}
+		m.SetScopes(v)
+		return nil
 	case apikey.FieldIPBlacklist:
 		v, ok := value.([]string)
 		if !ok {
@@ -1132,6 +1731,55 @@ func (m *APIKeyMutation) SetField(name string, value ent.Value) error {
 		}
 		m.SetIPBlacklist(v)
 		return nil
+	case apikey.FieldRegionPolicy:
+		v, ok := value.(domain.RegionPolicy)
+		if !ok {
+			return fmt.Errorf("unexpected type %T for field %s", value, name)
+		}
+		m.SetRegionPolicy(v)
+		return nil
+	case apikey.FieldSystemPromptPolicy:
+		v, ok := value.(domain.SystemPromptPolicy)
+		if !ok {
+			return fmt.Errorf("unexpected type %T for field %s", value, name)
+		}
+		m.SetSystemPromptPolicy(v)
+		return nil
+	case apikey.FieldUsageWebhook:
+		v, ok := value.(domain.UsageWebhook)
+		if !ok {
+			return fmt.Errorf("unexpected type %T for field %s", value, name)
+		}
+		m.SetUsageWebhook(v)
+		return nil
+	case apikey.FieldRequestSigning:
+		v, ok := value.(domain.RequestSigning)
+		if !ok {
+			return fmt.Errorf("unexpected type %T for field %s", value, name)
+		}
+		m.SetRequestSigning(v)
+		return nil
+	case apikey.FieldTokenQuota:
+		v, ok := value.(domain.TokenQuota)
+		if !ok {
+			return fmt.Errorf("unexpected type %T for field %s", value, name)
+		}
+		m.SetTokenQuota(v)
+		return nil
+	case apikey.FieldToolLimits:
+		v, ok := value.(map[string]int)
+		if !ok {
+			return fmt.Errorf("unexpected type %T for field %s", value, name)
+		}
+		m.SetToolLimits(v)
+		return nil
+	case apikey.FieldDebugErrors:
+		v, ok := value.(bool)
+		if !ok {
+			return fmt.Errorf("unexpected type %T for field %s", value, name)
+		}
+		m.SetDebugErrors(v)
+		return nil
 	case apikey.FieldQuota:
 		v, ok := value.(float64)
 		if !ok {
@@ -1219,9 +1867,33 @@ func (m *APIKeyMutation) ClearedFields() []string {
 	if m.FieldCleared(apikey.FieldIPWhitelist) {
 		fields = append(fields, apikey.FieldIPWhitelist)
 	}
+	if m.FieldCleared(apikey.FieldAllowedModels) {
+		fields = append(fields, apikey.FieldAllowedModels)
+	}
+	if m.FieldCleared(apikey.FieldScopes) {
+		fields = append(fields, apikey.FieldScopes)
+	}
 	if m.FieldCleared(apikey.FieldIPBlacklist) {
 		fields = append(fields, apikey.FieldIPBlacklist)
 	}
+	if m.FieldCleared(apikey.FieldRegionPolicy) {
+		fields = append(fields, apikey.FieldRegionPolicy)
+	}
+	if m.FieldCleared(apikey.FieldSystemPromptPolicy) {
+		fields = append(fields, apikey.FieldSystemPromptPolicy)
+	}
+	if m.FieldCleared(apikey.FieldUsageWebhook) {
+		fields = append(fields, apikey.FieldUsageWebhook)
+	}
+	if m.FieldCleared(apikey.FieldRequestSigning) {
+		fields = append(fields, apikey.FieldRequestSigning)
+	}
+	if m.FieldCleared(apikey.FieldTokenQuota) {
+		fields = append(fields, apikey.FieldTokenQuota)
+	}
+	if m.FieldCleared(apikey.FieldToolLimits) {
+		fields = append(fields, apikey.FieldToolLimits)
+	}
 	if m.FieldCleared(apikey.FieldExpiresAt) {
 		fields = append(fields, apikey.FieldExpiresAt)
 	}
@@ -1248,9 +1920,33 @@ func (m *APIKeyMutation) ClearField(name string) error {
 	case apikey.FieldIPWhitelist:
 		m.ClearIPWhitelist()
 		return nil
+	case apikey.FieldAllowedModels:
+		m.ClearAllowedModels()
+		return nil
+	case apikey.FieldScopes:
+		m.ClearScopes()
+		return nil
 	case apikey.FieldIPBlacklist:
 		m.ClearIPBlacklist()
 		return nil
+	case apikey.FieldRegionPolicy:
+		m.ClearRegionPolicy()
+		return nil
+	case apikey.FieldSystemPromptPolicy:
+		m.ClearSystemPromptPolicy()
+		return nil
+	case apikey.FieldUsageWebhook:
+		m.ClearUsageWebhook()
+		return nil
+	case apikey.FieldRequestSigning:
+		m.ClearRequestSigning()
+		return nil
+	case apikey.FieldTokenQuota:
+		m.ClearTokenQuota()
+		return nil
+	case apikey.FieldToolLimits:
+		m.ClearToolLimits()
+		return nil
 	case apikey.FieldExpiresAt:
 		m.ClearExpiresAt()
 		return nil
@@ -1286,12 +1982,42 @@ func (m *APIKeyMutation) ResetField(name string) error {
 	case apikey.FieldStatus:
 		m.ResetStatus()
 		return nil
+	case apikey.FieldPriorityClass:
+		m.ResetPriorityClass()
+		return nil
 	case apikey.FieldIPWhitelist:
 		m.ResetIPWhitelist()
 		return nil
+	case apikey.FieldAllowedModels:
+		m.ResetAllowedModels()
+		return nil
+	case apikey.FieldScopes:
+		m.ResetScopes()
+		return nil
 	case apikey.FieldIPBlacklist:
 		m.ResetIPBlacklist()
 		return nil
+	case apikey.FieldRegionPolicy:
+		m.ResetRegionPolicy()
+		return nil
+	case apikey.FieldSystemPromptPolicy:
+		m.ResetSystemPromptPolicy()
+		return nil
+	case apikey.FieldUsageWebhook:
+		m.ResetUsageWebhook()
+		return nil
+	case apikey.FieldRequestSigning:
+		m.ResetRequestSigning()
+		return nil
+	case apikey.FieldTokenQuota:
+		m.ResetTokenQuota()
+		return nil
+	case apikey.FieldToolLimits:
+		m.ResetToolLimits()
+		return nil
+	case apikey.FieldDebugErrors:
+		m.ResetDebugErrors()
+		return nil
 	case apikey.FieldQuota:
 		m.ResetQuota()
 		return nil
@@ -5755,33 +6481,36 @@ func (m *AnnouncementReadMutation) ResetEdge(name string) error {
 // ErrorPassthroughRuleMutation represents an operation that mutates the ErrorPassthroughRule nodes in the graph.
 type ErrorPassthroughRuleMutation struct {
 	config
-	op                Op
-	typ               string
-	id                *int64
-	created_at        *time.Time
-	updated_at        *time.Time
-	name              *string
-	enabled           *bool
-	priority          *int
-	addpriority       *int
-	error_codes       *[]int
-	appenderror_codes []int
-	keywords          *[]string
-	appendkeywords    []string
-	match_mode        *string
-	platforms         *[]string
-	appendplatforms   []string
-	passthrough_code  *bool
-	response_code     *int
-	addresponse_code  *int
-	passthrough_body  *bool
-	custom_message    *string
-	skip_monitoring   *bool
-	description       *string
-	clearedFields     map[string]struct{}
-	done              bool
-	oldValue          func(context.Context) (*ErrorPassthroughRule, error)
-	predicates        []predicate.ErrorPassthroughRule
+	op                  Op
+	typ                 string
+	id                  *int64
+	created_at          *time.Time
+	updated_at          *time.Time
+	name                *string
+	enabled             *bool
+	priority            *int
+	addpriority         *int
+	error_codes         *[]int
+	appenderror_codes   []int
+	keywords            *[]string
+	appendkeywords      []string
+	body_patterns       *[]string
+	appendbody_patterns []string
+	header_matches      *map[string]string
+	match_mode          *string
+	platforms           *[]string
+	appendplatforms     []string
+	passthrough_code    *bool
+	response_code       *int
+	addresponse_code    *int
+	passthrough_body    *bool
+	custom_message      *string
+	skip_monitoring     *bool
+	description         *string
+	clearedFields       map[string]struct{}
+	done                bool
+	oldValue            func(context.Context) (*ErrorPassthroughRule, error)
+	predicates          []predicate.ErrorPassthroughRule
 }
 
 var _ ent.Mutation = (*ErrorPassthroughRuleMutation)(nil)
@@ -6153,6 +6882,17 @@ func (m *ErrorPassthroughRuleMutation) SetKeywords(s []string) {
 	m.appendkeywords = nil
 }
 
+// SetBodyPatterns sets the "body_patterns" field.
+func (m *ErrorPassthroughRuleMutation) SetBodyPatterns(s []string) {
+	m.body_patterns = &s
+	m.appendbody_patterns = nil
+}
+
+// SetHeaderMatches sets the "header_matches" field.
+func (m *ErrorPassthroughRuleMutation) SetHeaderMatches(s map[string]string) {
+	m.header_matches = &s
+}
+
 // Keywords returns the value of the "keywords" field in the mutation.
 func (m *ErrorPassthroughRuleMutation) Keywords() (r []string, exists bool) {
 	v := m.keywords
@@ -6162,6 +6902,24 @@ func (m *ErrorPassthroughRuleMutation) Keywords() (r []string, exists bool) {
 	return *v, true
 }
 
+// BodyPatterns returns the value of the "body_patterns" field in the mutation.
+func (m *ErrorPassthroughRuleMutation) BodyPatterns() (r []string, exists bool) {
+	v := m.body_patterns
+	if v == nil {
+		return
+	}
+	return *v, true
+}
+
+// HeaderMatches returns the value of the "header_matches" field in the mutation.
+func (m *ErrorPassthroughRuleMutation) HeaderMatches() (r map[string]string, exists bool) {
+	v := m.header_matches
+	if v == nil {
+		return
+	}
+	return *v, true
+}
+
 // OldKeywords returns the old "keywords" field's value of the ErrorPassthroughRule entity.
 // If the ErrorPassthroughRule object wasn't provided to the builder, the object is fetched from the database.
 // An error is returned if the mutation operation is not UpdateOne, or the database query fails.
@@ -6179,11 +6937,50 @@ func (m *ErrorPassthroughRuleMutation) OldKeywords(ctx context.Context) (v []str
 	return oldValue.Keywords, nil
 }
 
+// OldBodyPatterns returns the old "body_patterns" field's value of the ErrorPassthroughRule entity.
+// If the ErrorPassthroughRule object wasn't provided to the builder, the object is fetched from the database.
+// An error is returned if the mutation operation is not UpdateOne, or the database query fails.
+func (m *ErrorPassthroughRuleMutation) OldBodyPatterns(ctx context.Context) (v []string, err error) {
+	if !m.op.Is(OpUpdateOne) {
+		return v, errors.New("OldBodyPatterns is only allowed on UpdateOne operations")
+	}
+	if m.id == nil || m.oldValue == nil {
+		return v, errors.New("OldBodyPatterns requires an ID field in the mutation")
+	}
+	oldValue, err := m.oldValue(ctx)
+	if err != nil {
+		return v, fmt.Errorf("querying old value for OldBodyPatterns: %w", err)
+	}
+	return oldValue.BodyPatterns, nil
+}
+
+// OldHeaderMatches returns the old "header_matches" field's value of the ErrorPassthroughRule entity.
+// If the ErrorPassthroughRule object wasn't provided to the builder, the object is fetched from the database.
+// An error is returned if the mutation operation is not UpdateOne, or the database query fails.
+func (m *ErrorPassthroughRuleMutation) OldHeaderMatches(ctx context.Context) (v map[string]string, err error) {
+	if !m.op.Is(OpUpdateOne) {
+		return v, errors.New("OldHeaderMatches is only allowed on UpdateOne operations")
+	}
+	if m.id == nil || m.oldValue == nil {
+		return v, errors.New("OldHeaderMatches requires an ID field in the mutation")
+	}
+	oldValue, err := m.oldValue(ctx)
+	if err != nil {
+		return v, fmt.Errorf("querying old value for OldHeaderMatches: %w", err)
+	}
+	return oldValue.HeaderMatches, nil
+}
+
 // AppendKeywords adds s to the "keywords" field.
 func (m *ErrorPassthroughRuleMutation) AppendKeywords(s []string) {
 	m.appendkeywords = append(m.appendkeywords, s...)
 }
 
+// AppendBodyPatterns adds s to the "body_patterns" field.
+func (m *ErrorPassthroughRuleMutation) AppendBodyPatterns(s []string) {
+	m.appendbody_patterns = append(m.appendbody_patterns, s...)
+}
+
 // AppendedKeywords returns the list of values that were appended to the "keywords" field in this mutation.
 func (m *ErrorPassthroughRuleMutation) AppendedKeywords() ([]string, bool) {
 	if len(m.appendkeywords) == 0 {
@@ -6192,6 +6989,14 @@ func (m *ErrorPassthroughRuleMutation) AppendedKeywords() ([]string, bool) {
 	return m.appendkeywords, true
 }
 
+// AppendedBodyPatterns returns the list of values that were appended to the "body_patterns" field in this mutation.
+func (m *ErrorPassthroughRuleMutation) AppendedBodyPatterns() ([]string, bool) {
+	if len(m.appendbody_patterns) == 0 {
+		return nil, false
+	}
+	return m.appendbody_patterns, true
+}
+
 // ClearKeywords clears the value of the "keywords" field.
 func (m *ErrorPassthroughRuleMutation) ClearKeywords() {
 	m.keywords = nil
@@ -6199,12 +7004,37 @@ func (m *ErrorPassthroughRuleMutation) ClearKeywords() {
 	m.clearedFields[errorpassthroughrule.FieldKeywords] = struct{}{}
 }
 
+// ClearBodyPatterns clears the value of the "body_patterns" field.
+func (m *ErrorPassthroughRuleMutation) ClearBodyPatterns() {
+	m.body_patterns = nil
+	m.appendbody_patterns = nil
+	m.clearedFields[errorpassthroughrule.FieldBodyPatterns] = struct{}{}
+}
+
+// ClearHeaderMatches clears the value of the "header_matches" field.
+func (m *ErrorPassthroughRuleMutation) ClearHeaderMatches() {
+	m.header_matches = nil
+	m.clearedFields[errorpassthroughrule.FieldHeaderMatches] = struct{}{}
+}
+
 // KeywordsCleared returns if the "keywords" field was cleared in this mutation.
 func (m *ErrorPassthroughRuleMutation) KeywordsCleared() bool {
 	_, ok := m.clearedFields[errorpassthroughrule.FieldKeywords]
 	return ok
 }
 
+// BodyPatternsCleared returns if the "body_patterns" field was cleared in this mutation.
+func (m *ErrorPassthroughRuleMutation) BodyPatternsCleared() bool {
+	_, ok := m.clearedFields[errorpassthroughrule.FieldBodyPatterns]
+	return ok
+}
+
+// HeaderMatchesCleared returns if the "header_matches" field was cleared in this mutation.
+func (m *ErrorPassthroughRuleMutation) HeaderMatchesCleared() bool {
+	_, ok := m.clearedFields[errorpassthroughrule.FieldHeaderMatches]
+	return ok
+}
+
 // ResetKeywords resets all changes to the "keywords" field.
 func (m *ErrorPassthroughRuleMutation) ResetKeywords() {
 	m.keywords = nil
@@ -6212,6 +7042,19 @@ func (m *ErrorPassthroughRuleMutation) ResetKeywords() {
 	delete(m.clearedFields, errorpassthroughrule.FieldKeywords)
 }
 
+// ResetBodyPatterns resets all changes to the "body_patterns" field.
+func (m *ErrorPassthroughRuleMutation) ResetBodyPatterns() {
+	m.body_patterns = nil
+	m.appendbody_patterns = nil
+	delete(m.clearedFields, errorpassthroughrule.FieldBodyPatterns)
+}
+
+// ResetHeaderMatches resets all changes to the "header_matches" field.
+func (m *ErrorPassthroughRuleMutation) ResetHeaderMatches() {
+	m.header_matches = nil
+	delete(m.clearedFields, errorpassthroughrule.FieldHeaderMatches)
+}
+
 // SetMatchMode sets the "match_mode" field.
 func (m *ErrorPassthroughRuleMutation) SetMatchMode(s string) {
 	m.match_mode = &s
@@ -6623,7 +7466,7 @@ func (m *ErrorPassthroughRuleMutation) Type() string {
 // order to get all numeric fields that were incremented/decremented, call
 // AddedFields().
 func (m *ErrorPassthroughRuleMutation) Fields() []string {
-	fields := make([]string, 0, 15)
+	fields := make([]string, 0, 17)
 	if m.created_at != nil {
 		fields = append(fields, errorpassthroughrule.FieldCreatedAt)
 	}
@@ -6645,6 +7488,12 @@ func (m *ErrorPassthroughRuleMutation) Fields() []string {
 	if m.keywords != nil {
 		fields = append(fields, errorpassthroughrule.FieldKeywords)
 	}
+	if m.body_patterns != nil {
+		fields = append(fields, errorpassthroughrule.FieldBodyPatterns)
+	}
+	if m.header_matches != nil {
+		fields = append(fields, errorpassthroughrule.FieldHeaderMatches)
+	}
 	if m.match_mode != nil {
 		fields = append(fields, errorpassthroughrule.FieldMatchMode)
 	}
@@ -6691,6 +7540,10 @@ func (m *ErrorPassthroughRuleMutation) Field(name string) (ent.Value, bool) {
 		return m.ErrorCodes()
 	case errorpassthroughrule.FieldKeywords:
 		return m.Keywords()
+	case errorpassthroughrule.FieldBodyPatterns:
+		return m.BodyPatterns()
+	case errorpassthroughrule.FieldHeaderMatches:
+		return m.HeaderMatches()
 	case errorpassthroughrule.FieldMatchMode:
 		return m.MatchMode()
 	case errorpassthroughrule.FieldPlatforms:
@@ -6730,6 +7583,10 @@ func (m *ErrorPassthroughRuleMutation) OldField(ctx context.Context, name string
 		return m.OldErrorCodes(ctx)
 	case errorpassthroughrule.FieldKeywords:
 		return m.OldKeywords(ctx)
+	case errorpassthroughrule.FieldBodyPatterns:
+		return m.OldBodyPatterns(ctx)
+	case errorpassthroughrule.FieldHeaderMatches:
+		return m.OldHeaderMatches(ctx)
 	case errorpassthroughrule.FieldMatchMode:
 		return m.OldMatchMode(ctx)
 	case errorpassthroughrule.FieldPlatforms:
@@ -6804,6 +7661,20 @@ func (m *ErrorPassthroughRuleMutation) SetField(name string, value ent.Value) er
 		}
 		m.SetKeywords(v)
 		return nil
+	case errorpassthroughrule.FieldBodyPatterns:
+		v, ok := value.([]string)
+		if !ok {
+			return fmt.Errorf("unexpected type %T for field %s", value, name)
+		}
+		m.SetBodyPatterns(v)
+		return nil
+	case errorpassthroughrule.FieldHeaderMatches:
+		v, ok := value.(map[string]string)
+		if !ok {
+			return fmt.Errorf("unexpected type %T for field %s", value, name)
+		}
+		m.SetHeaderMatches(v)
+		return nil
 	case errorpassthroughrule.FieldMatchMode:
 		v, ok := value.(string)
 		if !ok {
@@ -6923,6 +7794,12 @@ func (m *ErrorPassthroughRuleMutation) ClearedFields() []string {
 	if m.FieldCleared(errorpassthroughrule.FieldKeywords) {
 		fields = append(fields, errorpassthroughrule.FieldKeywords)
 	}
+	if m.FieldCleared(errorpassthroughrule.FieldBodyPatterns) {
+		fields = append(fields, errorpassthroughrule.FieldBodyPatterns)
+	}
+	if m.FieldCleared(errorpassthroughrule.FieldHeaderMatches) {
+		fields = append(fields, errorpassthroughrule.FieldHeaderMatches)
+	}
 	if m.FieldCleared(errorpassthroughrule.FieldPlatforms) {
 		fields = append(fields, errorpassthroughrule.FieldPlatforms)
 	}
@@ -6955,6 +7832,12 @@ func (m *ErrorPassthroughRuleMutation) ClearField(name string) error {
 	case errorpassthroughrule.FieldKeywords:
 		m.ClearKeywords()
 		return nil
+	case errorpassthroughrule.FieldBodyPatterns:
+		m.ClearBodyPatterns()
+		return nil
+	case errorpassthroughrule.FieldHeaderMatches:
+		m.ClearHeaderMatches()
+		return nil
 	case errorpassthroughrule.FieldPlatforms:
 		m.ClearPlatforms()
 		return nil
@@ -6996,6 +7879,12 @@ func (m *ErrorPassthroughRuleMutation) ResetField(name string) error {
 	case errorpassthroughrule.FieldKeywords:
 		m.ResetKeywords()
 		return nil
+	case errorpassthroughrule.FieldBodyPatterns:
+		m.ResetBodyPatterns()
+		return nil
+	case errorpassthroughrule.FieldHeaderMatches:
+		m.ResetHeaderMatches()
+		return nil
 	case errorpassthroughrule.FieldMatchMode:
 		m.ResetMatchMode()
 		return nil
@@ -7109,12 +7998,20 @@ type GroupMutation struct {
 	fallback_group_id_on_invalid_request    *int64
 	addfallback_group_id_on_invalid_request *int64
 	model_routing                           *map[string][]int64
+	model_param_policies                    *map[string]domain.ModelParamPolicy
+	region_policy                           *domain.RegionPolicy
+	system_prompt_policy                    *domain.SystemPromptPolicy
+	prompt_template                         *domain.PromptTemplate
+	model_access_policy                     *domain.ModelAccessPolicy
+	retry_policy                            *domain.RetryPolicy
 	model_routing_enabled                   *bool
 	mcp_xml_inject                          *bool
 	supported_model_scopes                  *[]string
 	appendsupported_model_scopes            []string
 	sort_order                              *int
 	addsort_order                           *int
+	metering_only                           *bool
+	strict_request_fields                   *bool
 	clearedFields                           map[string]struct{}
 	api_keys                                map[int64]struct{}
 	removedapi_keys                         map[int64]struct{}
@@ -8119,229 +9016,523 @@ func (m *GroupMutation) ResetImagePrice4k() {
 	delete(m.clearedFields, group.FieldImagePrice4k)
 }
 
-// SetClaudeCodeOnly sets the "claude_code_only" field.
-func (m *GroupMutation) SetClaudeCodeOnly(b bool) {
-	m.claude_code_only = &b
+// SetClaudeCodeOnly sets the "claude_code_only" field.
+func (m *GroupMutation) SetClaudeCodeOnly(b bool) {
+	m.claude_code_only = &b
+}
+
+// ClaudeCodeOnly returns the value of the "claude_code_only" field in the mutation.
+func (m *GroupMutation) ClaudeCodeOnly() (r bool, exists bool) {
+	v := m.claude_code_only
+	if v == nil {
+		return
+	}
+	return *v, true
+}
+
+// OldClaudeCodeOnly returns the old "claude_code_only" field's value of the Group entity.
+// If the Group object wasn't provided to the builder, the object is fetched from the database.
+// An error is returned if the mutation operation is not UpdateOne, or the database query fails.
+func (m *GroupMutation) OldClaudeCodeOnly(ctx context.Context) (v bool, err error) {
+	if !m.op.Is(OpUpdateOne) {
+		return v, errors.New("OldClaudeCodeOnly is only allowed on UpdateOne operations")
+	}
+	if m.id == nil || m.oldValue == nil {
+		return v, errors.New("OldClaudeCodeOnly requires an ID field in the mutation")
+	}
+	oldValue, err := m.oldValue(ctx)
+	if err != nil {
+		return v, fmt.Errorf("querying old value for OldClaudeCodeOnly: %w", err)
+	}
+	return oldValue.ClaudeCodeOnly, nil
+}
+
+// ResetClaudeCodeOnly resets all changes to the "claude_code_only" field.
+func (m *GroupMutation) ResetClaudeCodeOnly() {
+	m.claude_code_only = nil
+}
+
+// SetFallbackGroupID sets the "fallback_group_id" field.
+func (m *GroupMutation) SetFallbackGroupID(i int64) {
+	m.fallback_group_id = &i
+	m.addfallback_group_id = nil
+}
+
+// FallbackGroupID returns the value of the "fallback_group_id" field in the mutation.
+func (m *GroupMutation) FallbackGroupID() (r int64, exists bool) {
+	v := m.fallback_group_id
+	if v == nil {
+		return
+	}
+	return *v, true
+}
+
+// OldFallbackGroupID returns the old "fallback_group_id" field's value of the Group entity.
+// If the Group object wasn't provided to the builder, the object is fetched from the database.
+// An error is returned if the mutation operation is not UpdateOne, or the database query fails.
+func (m *GroupMutation) OldFallbackGroupID(ctx context.Context) (v *int64, err error) {
+	if !m.op.Is(OpUpdateOne) {
+		return v, errors.New("OldFallbackGroupID is only allowed on UpdateOne operations")
+	}
+	if m.id == nil || m.oldValue == nil {
+		return v, errors.New("OldFallbackGroupID requires an ID field in the mutation")
+	}
+	oldValue, err := m.oldValue(ctx)
+	if err != nil {
+		return v, fmt.Errorf("querying old value for OldFallbackGroupID: %w", err)
+	}
+	return oldValue.FallbackGroupID, nil
+}
+
+// AddFallbackGroupID adds i to the "fallback_group_id" field.
+func (m *GroupMutation) AddFallbackGroupID(i int64) {
+	if m.addfallback_group_id != nil {
+		*m.addfallback_group_id += i
+	} else {
+		m.addfallback_group_id = &i
+	}
+}
+
+// AddedFallbackGroupID returns the value that was added to the "fallback_group_id" field in this mutation.
+func (m *GroupMutation) AddedFallbackGroupID() (r int64, exists bool) {
+	v := m.addfallback_group_id
+	if v == nil {
+		return
+	}
+	return *v, true
+}
+
+// ClearFallbackGroupID clears the value of the "fallback_group_id" field.
+func (m *GroupMutation) ClearFallbackGroupID() {
+	m.fallback_group_id = nil
+	m.addfallback_group_id = nil
+	m.clearedFields[group.FieldFallbackGroupID] = struct{}{}
+}
+
+// FallbackGroupIDCleared returns if the "fallback_group_id" field was cleared in this mutation.
+func (m *GroupMutation) FallbackGroupIDCleared() bool {
+	_, ok := m.clearedFields[group.FieldFallbackGroupID]
+	return ok
+}
+
+// ResetFallbackGroupID resets all changes to the "fallback_group_id" field.
+func (m *GroupMutation) ResetFallbackGroupID() {
+	m.fallback_group_id = nil
+	m.addfallback_group_id = nil
+	delete(m.clearedFields, group.FieldFallbackGroupID)
+}
+
+// SetFallbackGroupIDOnInvalidRequest sets the "fallback_group_id_on_invalid_request" field.
+func (m *GroupMutation) SetFallbackGroupIDOnInvalidRequest(i int64) {
+	m.fallback_group_id_on_invalid_request = &i
+	m.addfallback_group_id_on_invalid_request = nil
+}
+
+// FallbackGroupIDOnInvalidRequest returns the value of the "fallback_group_id_on_invalid_request" field in the mutation.
+func (m *GroupMutation) FallbackGroupIDOnInvalidRequest() (r int64, exists bool) {
+	v := m.fallback_group_id_on_invalid_request
+	if v == nil {
+		return
+	}
+	return *v, true
+}
+
+// OldFallbackGroupIDOnInvalidRequest returns the old "fallback_group_id_on_invalid_request" field's value of the Group entity.
+// If the Group object wasn't provided to the builder, the object is fetched from the database.
+// An error is returned if the mutation operation is not UpdateOne, or the database query fails.
+func (m *GroupMutation) OldFallbackGroupIDOnInvalidRequest(ctx context.Context) (v *int64, err error) {
+	if !m.op.Is(OpUpdateOne) {
+		return v, errors.New("OldFallbackGroupIDOnInvalidRequest is only allowed on UpdateOne operations")
+	}
+	if m.id == nil || m.oldValue == nil {
+		return v, errors.New("OldFallbackGroupIDOnInvalidRequest requires an ID field in the mutation")
+	}
+	oldValue, err := m.oldValue(ctx)
+	if err != nil {
+		return v, fmt.Errorf("querying old value for OldFallbackGroupIDOnInvalidRequest: %w", err)
+	}
+	return oldValue.FallbackGroupIDOnInvalidRequest, nil
+}
+
+// AddFallbackGroupIDOnInvalidRequest adds i to the "fallback_group_id_on_invalid_request" field.
+func (m *GroupMutation) AddFallbackGroupIDOnInvalidRequest(i int64) {
+	if m.addfallback_group_id_on_invalid_request != nil {
+		*m.addfallback_group_id_on_invalid_request += i
+	} else {
+		m.addfallback_group_id_on_invalid_request = &i
+	}
+}
+
+// AddedFallbackGroupIDOnInvalidRequest returns the value that was added to the "fallback_group_id_on_invalid_request" field in this mutation.
+func (m *GroupMutation) AddedFallbackGroupIDOnInvalidRequest() (r int64, exists bool) {
+	v := m.addfallback_group_id_on_invalid_request
+	if v == nil {
+		return
+	}
+	return *v, true
+}
+
+// ClearFallbackGroupIDOnInvalidRequest clears the value of the "fallback_group_id_on_invalid_request" field.
+func (m *GroupMutation) ClearFallbackGroupIDOnInvalidRequest() {
+	m.fallback_group_id_on_invalid_request = nil
+	m.addfallback_group_id_on_invalid_request = nil
+	m.clearedFields[group.FieldFallbackGroupIDOnInvalidRequest] = struct{}{}
+}
+
+// FallbackGroupIDOnInvalidRequestCleared returns if the "fallback_group_id_on_invalid_request" field was cleared in this mutation.
+func (m *GroupMutation) FallbackGroupIDOnInvalidRequestCleared() bool {
+	_, ok := m.clearedFields[group.FieldFallbackGroupIDOnInvalidRequest]
+	return ok
+}
+
+// ResetFallbackGroupIDOnInvalidRequest resets all changes to the "fallback_group_id_on_invalid_request" field.
+func (m *GroupMutation) ResetFallbackGroupIDOnInvalidRequest() {
+	m.fallback_group_id_on_invalid_request = nil
+	m.addfallback_group_id_on_invalid_request = nil
+	delete(m.clearedFields, group.FieldFallbackGroupIDOnInvalidRequest)
+}
+
+// SetModelRouting sets the "model_routing" field.
+func (m *GroupMutation) SetModelRouting(value map[string][]int64) {
+	m.model_routing = &value
+}
+
+// ModelRouting returns the value of the "model_routing" field in the mutation.
+func (m *GroupMutation) ModelRouting() (r map[string][]int64, exists bool) {
+	v := m.model_routing
+	if v == nil {
+		return
+	}
+	return *v, true
+}
+
+// OldModelRouting returns the old "model_routing" field's value of the Group entity.
+// If the Group object wasn't provided to the builder, the object is fetched from the database.
+// An error is returned if the mutation operation is not UpdateOne, or the database query fails.
+func (m *GroupMutation) OldModelRouting(ctx context.Context) (v map[string][]int64, err error) {
+	if !m.op.Is(OpUpdateOne) {
+		return v, errors.New("OldModelRouting is only allowed on UpdateOne operations")
+	}
+	if m.id == nil || m.oldValue == nil {
+		return v, errors.New("OldModelRouting requires an ID field in the mutation")
+	}
+	oldValue, err := m.oldValue(ctx)
+	if err != nil {
+		return v, fmt.Errorf("querying old value for OldModelRouting: %w", err)
+	}
+	return oldValue.ModelRouting, nil
+}
+
+// ClearModelRouting clears the value of the "model_routing" field.
+func (m *GroupMutation) ClearModelRouting() {
+	m.model_routing = nil
+	m.clearedFields[group.FieldModelRouting] = struct{}{}
+}
+
+// ModelRoutingCleared returns if the "model_routing" field was cleared in this mutation.
+func (m *GroupMutation) ModelRoutingCleared() bool {
+	_, ok := m.clearedFields[group.FieldModelRouting]
+	return ok
+}
+
+// ResetModelRouting resets all changes to the "model_routing" field.
+func (m *GroupMutation) ResetModelRouting() {
+	m.model_routing = nil
+	delete(m.clearedFields, group.FieldModelRouting)
+}
+
+// SetModelParamPolicies sets the "model_param_policies" field.
+func (m *GroupMutation) SetModelParamPolicies(value map[string]domain.ModelParamPolicy) {
+	m.model_param_policies = &value
+}
+
+// ModelParamPolicies returns the value of the "model_param_policies" field in the mutation.
+func (m *GroupMutation) ModelParamPolicies() (r map[string]domain.ModelParamPolicy, exists bool) {
+	v := m.model_param_policies
+	if v == nil {
+		return
+	}
+	return *v, true
+}
+
+// OldModelParamPolicies returns the old "model_param_policies" field's value of the Group entity.
+// If the Group object wasn't provided to the builder, the object is fetched from the database.
+// An error is returned if the mutation operation is not UpdateOne, or the database query fails.
+func (m *GroupMutation) OldModelParamPolicies(ctx context.Context) (v map[string]domain.ModelParamPolicy, err error) {
+	if !m.op.Is(OpUpdateOne) {
+		return v, errors.New("OldModelParamPolicies is only allowed on UpdateOne operations")
+	}
+	if m.id == nil || m.oldValue == nil {
+		return v, errors.New("OldModelParamPolicies requires an ID field in the mutation")
+	}
+	oldValue, err := m.oldValue(ctx)
+	if err != nil {
+		return v, fmt.Errorf("querying old value for OldModelParamPolicies: %w", err)
+	}
+	return oldValue.ModelParamPolicies, nil
+}
+
+// ClearModelParamPolicies clears the value of the "model_param_policies" field.
+func (m *GroupMutation) ClearModelParamPolicies() {
+	m.model_param_policies = nil
+	m.clearedFields[group.FieldModelParamPolicies] = struct{}{}
+}
+
+// ModelParamPoliciesCleared returns if the "model_param_policies" field was cleared in this mutation.
+func (m *GroupMutation) ModelParamPoliciesCleared() bool {
+	_, ok := m.clearedFields[group.FieldModelParamPolicies]
+	return ok
+}
+
+// ResetModelParamPolicies resets all changes to the "model_param_policies" field.
+func (m *GroupMutation) ResetModelParamPolicies() {
+	m.model_param_policies = nil
+	delete(m.clearedFields, group.FieldModelParamPolicies)
+}
+
+// SetRegionPolicy sets the "region_policy" field.
+func (m *GroupMutation) SetRegionPolicy(rp domain.RegionPolicy) {
+	m.region_policy = &rp
 }
 
-// ClaudeCodeOnly returns the value of the "claude_code_only" field in the mutation.
-func (m *GroupMutation) ClaudeCodeOnly() (r bool, exists bool) {
-	v := m.claude_code_only
+// RegionPolicy returns the value of the "region_policy" field in the mutation.
+func (m *GroupMutation) RegionPolicy() (r domain.RegionPolicy, exists bool) {
+	v := m.region_policy
 	if v == nil {
 		return
 	}
 	return *v, true
 }
 
-// OldClaudeCodeOnly returns the old "claude_code_only" field's value of the Group entity.
+// OldRegionPolicy returns the old "region_policy" field's value of the Group entity.
 // If the Group object wasn't provided to the builder, the object is fetched from the database.
 // An error is returned if the mutation operation is not UpdateOne, or the database query fails.
-func (m *GroupMutation) OldClaudeCodeOnly(ctx context.Context) (v bool, err error) {
+func (m *GroupMutation) OldRegionPolicy(ctx context.Context) (v domain.RegionPolicy, err error) {
 	if !m.op.Is(OpUpdateOne) {
-		return v, errors.New("OldClaudeCodeOnly is only allowed on UpdateOne operations")
+		return v, errors.New("OldRegionPolicy is only allowed on UpdateOne operations")
 	}
 	if m.id == nil || m.oldValue == nil {
-		return v, errors.New("OldClaudeCodeOnly requires an ID field in the mutation")
+		return v, errors.New("OldRegionPolicy requires an ID field in the mutation")
 	}
 	oldValue, err := m.oldValue(ctx)
 	if err != nil {
-		return v, fmt.Errorf("querying old value for OldClaudeCodeOnly: %w", err)
+		return v, fmt.Errorf("querying old value for OldRegionPolicy: %w", err)
 	}
-	return oldValue.ClaudeCodeOnly, nil
+	return oldValue.RegionPolicy, nil
 }
 
-// ResetClaudeCodeOnly resets all changes to the "claude_code_only" field.
-func (m *GroupMutation) ResetClaudeCodeOnly() {
-	m.claude_code_only = nil
+// ClearRegionPolicy clears the value of the "region_policy" field.
+func (m *GroupMutation) ClearRegionPolicy() {
+	m.region_policy = nil
+	m.clearedFields[group.FieldRegionPolicy] = struct{}{}
 }
 
-// SetFallbackGroupID sets the "fallback_group_id" field.
-func (m *GroupMutation) SetFallbackGroupID(i int64) {
-	m.fallback_group_id = &i
-	m.addfallback_group_id = nil
+// RegionPolicyCleared returns if the "region_policy" field was cleared in this mutation.
+func (m *GroupMutation) RegionPolicyCleared() bool {
+	_, ok := m.clearedFields[group.FieldRegionPolicy]
+	return ok
 }
 
-// FallbackGroupID returns the value of the "fallback_group_id" field in the mutation.
-func (m *GroupMutation) FallbackGroupID() (r int64, exists bool) {
-	v := m.fallback_group_id
+// ResetRegionPolicy resets all changes to the "region_policy" field.
+func (m *GroupMutation) ResetRegionPolicy() {
+	m.region_policy = nil
+	delete(m.clearedFields, group.FieldRegionPolicy)
+}
+
+// SetSystemPromptPolicy sets the "system_prompt_policy" field.
+func (m *GroupMutation) SetSystemPromptPolicy(rp domain.SystemPromptPolicy) {
+	m.system_prompt_policy = &rp
+}
+
+// SystemPromptPolicy returns the value of the "system_prompt_policy" field in the mutation.
+func (m *GroupMutation) SystemPromptPolicy() (r domain.SystemPromptPolicy, exists bool) {
+	v := m.system_prompt_policy
 	if v == nil {
 		return
 	}
 	return *v, true
 }
 
-// OldFallbackGroupID returns the old "fallback_group_id" field's value of the Group entity.
+// OldSystemPromptPolicy returns the old "system_prompt_policy" field's value of the Group entity.
 // If the Group object wasn't provided to the builder, the object is fetched from the database.
 // An error is returned if the mutation operation is not UpdateOne, or the database query fails.
-func (m *GroupMutation) OldFallbackGroupID(ctx context.Context) (v *int64, err error) {
+func (m *GroupMutation) OldSystemPromptPolicy(ctx context.Context) (v domain.SystemPromptPolicy, err error) {
 	if !m.op.Is(OpUpdateOne) {
-		return v, errors.New("OldFallbackGroupID is only allowed on UpdateOne operations")
+		return v, errors.New("OldSystemPromptPolicy is only allowed on UpdateOne operations")
 	}
 	if m.id == nil || m.oldValue == nil {
-		return v, errors.New("OldFallbackGroupID requires an ID field in the mutation")
+		return v, errors.New("OldSystemPromptPolicy requires an ID field in the mutation")
 	}
 	oldValue, err := m.oldValue(ctx)
 	if err != nil {
-		return v, fmt.Errorf("querying old value for OldFallbackGroupID: %w", err)
+		return v, fmt.Errorf("querying old value for OldSystemPromptPolicy: %w", err)
 	}
-	return oldValue.FallbackGroupID, nil
-}
-
-// AddFallbackGroupID adds i to the "fallback_group_id" field.
-func (m *GroupMutation) AddFallbackGroupID(i int64) {
-	if m.addfallback_group_id != nil {
-		*m.addfallback_group_id += i
-	} else {
-		m.addfallback_group_id = &i
-	}
-}
-
-// AddedFallbackGroupID returns the value that was added to the "fallback_group_id" field in this mutation.
-func (m *GroupMutation) AddedFallbackGroupID() (r int64, exists bool) {
-	v := m.addfallback_group_id
-	if v == nil {
-		return
-	}
-	return *v, true
+	return oldValue.SystemPromptPolicy, nil
 }
 
-// ClearFallbackGroupID clears the value of the "fallback_group_id" field.
-func (m *GroupMutation) ClearFallbackGroupID() {
-	m.fallback_group_id = nil
-	m.addfallback_group_id = nil
-	m.clearedFields[group.FieldFallbackGroupID] = struct{}{}
+// ClearSystemPromptPolicy clears the value of the "system_prompt_policy" field.
+func (m *GroupMutation) ClearSystemPromptPolicy() {
+	m.system_prompt_policy = nil
+	m.clearedFields[group.FieldSystemPromptPolicy] = struct{}{}
 }
 
-// FallbackGroupIDCleared returns if the "fallback_group_id" field was cleared in this mutation.
-func (m *GroupMutation) FallbackGroupIDCleared() bool {
-	_, ok := m.clearedFields[group.FieldFallbackGroupID]
+// SystemPromptPolicyCleared returns if the "system_prompt_policy" field was cleared in this mutation.
+func (m *GroupMutation) SystemPromptPolicyCleared() bool {
+	_, ok := m.clearedFields[group.FieldSystemPromptPolicy]
 	return ok
 }
 
-// ResetFallbackGroupID resets all changes to the "fallback_group_id" field.
-func (m *GroupMutation) ResetFallbackGroupID() {
-	m.fallback_group_id = nil
-	m.addfallback_group_id = nil
-	delete(m.clearedFields, group.FieldFallbackGroupID)
+// ResetSystemPromptPolicy resets all changes to the "system_prompt_policy" field.
+func (m *GroupMutation) ResetSystemPromptPolicy() {
+	m.system_prompt_policy = nil
+	delete(m.clearedFields, group.FieldSystemPromptPolicy)
 }
 
-// SetFallbackGroupIDOnInvalidRequest sets the "fallback_group_id_on_invalid_request" field.
-func (m *GroupMutation) SetFallbackGroupIDOnInvalidRequest(i int64) {
-	m.fallback_group_id_on_invalid_request = &i
-	m.addfallback_group_id_on_invalid_request = nil
+// SetPromptTemplate sets the "prompt_template" field.
+func (m *GroupMutation) SetPromptTemplate(rp domain.PromptTemplate) {
+	m.prompt_template = &rp
 }
 
-// FallbackGroupIDOnInvalidRequest returns the value of the "fallback_group_id_on_invalid_request" field in the mutation.
-func (m *GroupMutation) FallbackGroupIDOnInvalidRequest() (r int64, exists bool) {
-	v := m.fallback_group_id_on_invalid_request
+// PromptTemplate returns the value of the "prompt_template" field in the mutation.
+func (m *GroupMutation) PromptTemplate() (r domain.PromptTemplate, exists bool) {
+	v := m.prompt_template
 	if v == nil {
 		return
 	}
 	return *v, true
 }
 
-// OldFallbackGroupIDOnInvalidRequest returns the old "fallback_group_id_on_invalid_request" field's value of the Group entity.
+// OldPromptTemplate returns the old "prompt_template" field's value of the Group entity.
 // If the Group object wasn't provided to the builder, the object is fetched from the database.
 // An error is returned if the mutation operation is not UpdateOne, or the database query fails.
-func (m *GroupMutation) OldFallbackGroupIDOnInvalidRequest(ctx context.Context) (v *int64, err error) {
+func (m *GroupMutation) OldPromptTemplate(ctx context.Context) (v domain.PromptTemplate, err error) {
 	if !m.op.Is(OpUpdateOne) {
-		return v, errors.New("OldFallbackGroupIDOnInvalidRequest is only allowed on UpdateOne operations")
+		return v, errors.New("OldPromptTemplate is only allowed on UpdateOne operations")
 	}
 	if m.id == nil || m.oldValue == nil {
-		return v, errors.New("OldFallbackGroupIDOnInvalidRequest requires an ID field in the mutation")
+		return v, errors.New("OldPromptTemplate requires an ID field in the mutation")
 	}
 	oldValue, err := m.oldValue(ctx)
 	if err != nil {
-		return v, fmt.Errorf("querying old value for OldFallbackGroupIDOnInvalidRequest: %w", err)
+		return v, fmt.Errorf("querying old value for OldPromptTemplate: %w", err)
 	}
-	return oldValue.FallbackGroupIDOnInvalidRequest, nil
+	return oldValue.PromptTemplate, nil
 }
 
-// AddFallbackGroupIDOnInvalidRequest adds i to the "fallback_group_id_on_invalid_request" field.
-func (m *GroupMutation) AddFallbackGroupIDOnInvalidRequest(i int64) {
-	if m.addfallback_group_id_on_invalid_request != nil {
-		*m.addfallback_group_id_on_invalid_request += i
-	} else {
-		m.addfallback_group_id_on_invalid_request = &i
-	}
+// ClearPromptTemplate clears the value of the "prompt_template" field.
+func (m *GroupMutation) ClearPromptTemplate() {
+	m.prompt_template = nil
+	m.clearedFields[group.FieldPromptTemplate] = struct{}{}
 }
 
-// AddedFallbackGroupIDOnInvalidRequest returns the value that was added to the "fallback_group_id_on_invalid_request" field in this mutation.
-func (m *GroupMutation) AddedFallbackGroupIDOnInvalidRequest() (r int64, exists bool) {
-	v := m.addfallback_group_id_on_invalid_request
-	if v == nil {
-		return
-	}
-	return *v, true
+// PromptTemplateCleared returns if the "prompt_template" field was cleared in this mutation.
+func (m *GroupMutation) PromptTemplateCleared() bool {
+	_, ok := m.clearedFields[group.FieldPromptTemplate]
+	return ok
 }
 
-// ClearFallbackGroupIDOnInvalidRequest clears the value of the "fallback_group_id_on_invalid_request" field.
-func (m *GroupMutation) ClearFallbackGroupIDOnInvalidRequest() {
-	m.fallback_group_id_on_invalid_request = nil
-	m.addfallback_group_id_on_invalid_request = nil
-	m.clearedFields[group.FieldFallbackGroupIDOnInvalidRequest] = struct{}{}
+// ResetPromptTemplate resets all changes to the "prompt_template" field.
+func (m *GroupMutation) ResetPromptTemplate() {
+	m.prompt_template = nil
+	delete(m.clearedFields, group.FieldPromptTemplate)
 }
 
-// FallbackGroupIDOnInvalidRequestCleared returns if the "fallback_group_id_on_invalid_request" field was cleared in this mutation.
-func (m *GroupMutation) FallbackGroupIDOnInvalidRequestCleared() bool {
-	_, ok := m.clearedFields[group.FieldFallbackGroupIDOnInvalidRequest]
-	return ok
+// SetModelAccessPolicy sets the "model_access_policy" field.
+func (m *GroupMutation) SetModelAccessPolicy(rp domain.ModelAccessPolicy) {
+	m.model_access_policy = &rp
 }
 
-// ResetFallbackGroupIDOnInvalidRequest resets all changes to the "fallback_group_id_on_invalid_request" field.
-func (m *GroupMutation) ResetFallbackGroupIDOnInvalidRequest() {
-	m.fallback_group_id_on_invalid_request = nil
-	m.addfallback_group_id_on_invalid_request = nil
-	delete(m.clearedFields, group.FieldFallbackGroupIDOnInvalidRequest)
+// SetRetryPolicy sets the "retry_policy" field.
+func (m *GroupMutation) SetRetryPolicy(rp domain.RetryPolicy) {
+	m.retry_policy = &rp
 }
 
-// SetModelRouting sets the "model_routing" field.
-func (m *GroupMutation) SetModelRouting(value map[string][]int64) {
-	m.model_routing = &value
+// ModelAccessPolicy returns the value of the "model_access_policy" field in the mutation.
+func (m *GroupMutation) ModelAccessPolicy() (r domain.ModelAccessPolicy, exists bool) {
+	v := m.model_access_policy
+	if v == nil {
+		return
+	}
+	return *v, true
 }
 
-// ModelRouting returns the value of the "model_routing" field in the mutation.
-func (m *GroupMutation) ModelRouting() (r map[string][]int64, exists bool) {
-	v := m.model_routing
+// RetryPolicy returns the value of the "retry_policy" field in the mutation.
+func (m *GroupMutation) RetryPolicy() (r domain.RetryPolicy, exists bool) {
+	v := m.retry_policy
 	if v == nil {
 		return
 	}
 	return *v, true
 }
 
-// OldModelRouting returns the old "model_routing" field's value of the Group entity.
+// OldModelAccessPolicy returns the old "model_access_policy" field's value of the Group entity.
 // If the Group object wasn't provided to the builder, the object is fetched from the database.
 // An error is returned if the mutation operation is not UpdateOne, or the database query fails.
-func (m *GroupMutation) OldModelRouting(ctx context.Context) (v map[string][]int64, err error) {
+func (m *GroupMutation) OldModelAccessPolicy(ctx context.Context) (v domain.ModelAccessPolicy, err error) {
 	if !m.op.Is(OpUpdateOne) {
-		return v, errors.New("OldModelRouting is only allowed on UpdateOne operations")
+		return v, errors.New("OldModelAccessPolicy is only allowed on UpdateOne operations")
 	}
 	if m.id == nil || m.oldValue == nil {
-		return v, errors.New("OldModelRouting requires an ID field in the mutation")
+		return v, errors.New("OldModelAccessPolicy requires an ID field in the mutation")
 	}
 	oldValue, err := m.oldValue(ctx)
 	if err != nil {
-		return v, fmt.Errorf("querying old value for OldModelRouting: %w", err)
+		return v, fmt.Errorf("querying old value for OldModelAccessPolicy: %w", err)
 	}
-	return oldValue.ModelRouting, nil
+	return oldValue.ModelAccessPolicy, nil
 }
 
-// ClearModelRouting clears the value of the "model_routing" field.
-func (m *GroupMutation) ClearModelRouting() {
-	m.model_routing = nil
-	m.clearedFields[group.FieldModelRouting] = struct{}{}
+// OldRetryPolicy returns the old "retry_policy" field's value of the Group entity.
+// If the Group object wasn't provided to the builder, the object is fetched from the database.
+// An error is returned if the mutation operation is not UpdateOne, or the database query fails.
+func (m *GroupMutation) OldRetryPolicy(ctx context.Context) (v domain.RetryPolicy, err error) {
+	if !m.op.Is(OpUpdateOne) {
+		return v, errors.New("OldRetryPolicy is only allowed on UpdateOne operations")
+	}
+	if m.id == nil || m.oldValue == nil {
+		return v, errors.New("OldRetryPolicy requires an ID field in the mutation")
+	}
+	oldValue, err := m.oldValue(ctx)
+	if err != nil {
+		return v, fmt.Errorf("querying old value for OldRetryPolicy: %w", err)
+	}
+	return oldValue.RetryPolicy, nil
 }
 
-// ModelRoutingCleared returns if the "model_routing" field was cleared in this mutation.
-func (m *GroupMutation) ModelRoutingCleared() bool {
-	_, ok := m.clearedFields[group.FieldModelRouting]
+// ClearModelAccessPolicy clears the value of the "model_access_policy" field.
+func (m *GroupMutation) ClearModelAccessPolicy() {
+	m.model_access_policy = nil
+	m.clearedFields[group.FieldModelAccessPolicy] = struct{}{}
+}
+
+// ClearRetryPolicy clears the value of the "retry_policy" field.
+func (m *GroupMutation) ClearRetryPolicy() {
+	m.retry_policy = nil
+	m.clearedFields[group.FieldRetryPolicy] = struct{}{}
+}
+
+// ModelAccessPolicyCleared returns if the "model_access_policy" field was cleared in this mutation.
+func (m *GroupMutation) ModelAccessPolicyCleared() bool {
+	_, ok := m.clearedFields[group.FieldModelAccessPolicy]
 	return ok
 }
 
-// ResetModelRouting resets all changes to the "model_routing" field.
-func (m *GroupMutation) ResetModelRouting() {
-	m.model_routing = nil
-	delete(m.clearedFields, group.FieldModelRouting)
+// RetryPolicyCleared returns if the "retry_policy" field was cleared in this mutation.
+func (m *GroupMutation) RetryPolicyCleared() bool {
+	_, ok := m.clearedFields[group.FieldRetryPolicy]
+	return ok
+}
+
+// ResetModelAccessPolicy resets all changes to the "model_access_policy" field.
+func (m *GroupMutation) ResetModelAccessPolicy() {
+	m.model_access_policy = nil
+	delete(m.clearedFields, group.FieldModelAccessPolicy)
+}
+
+// ResetRetryPolicy resets all changes to the "retry_policy" field.
+func (m *GroupMutation) ResetRetryPolicy() {
+	m.retry_policy = nil
+	delete(m.clearedFields, group.FieldRetryPolicy)
 }
 
 // SetModelRoutingEnabled sets the "model_routing_enabled" field.
@@ -8523,6 +9714,78 @@ func (m *GroupMutation) ResetSortOrder() {
 	m.addsort_order = nil
 }
 
+// SetMeteringOnly sets the "metering_only" field.
+func (m *GroupMutation) SetMeteringOnly(b bool) {
+	m.metering_only = &b
+}
+
+// MeteringOnly returns the value of the "metering_only" field in the mutation.
+func (m *GroupMutation) MeteringOnly() (r bool, exists bool) {
+	v := m.metering_only
+	if v == nil {
+		return
+	}
+	return *v, true
+}
+
+// OldMeteringOnly returns the old "metering_only" field's value of the Group entity.
+// If the Group object wasn't provided to the builder, the object is fetched from the database.
+// An error is returned if the mutation operation is not UpdateOne, or the database query fails.
+func (m *GroupMutation) OldMeteringOnly(ctx context.Context) (v bool, err error) {
+	if !m.op.Is(OpUpdateOne) {
+		return v, errors.New("OldMeteringOnly is only allowed on UpdateOne operations")
+	}
+	if m.id == nil || m.oldValue == nil {
+		return v, errors.New("OldMeteringOnly requires an ID field in the mutation")
+	}
+	oldValue, err := m.oldValue(ctx)
+	if err != nil {
+		return v, fmt.Errorf("querying old value for OldMeteringOnly: %w", err)
+	}
+	return oldValue.MeteringOnly, nil
+}
+
+// ResetMeteringOnly resets all changes to the "metering_only" field.
+func (m *GroupMutation) ResetMeteringOnly() {
+	m.metering_only = nil
+}
+
+// SetStrictRequestFields sets the "strict_request_fields" field.
+func (m *GroupMutation) SetStrictRequestFields(b bool) {
+	m.strict_request_fields = &b
+}
+
+// StrictRequestFields returns the value of the "strict_request_fields" field in the mutation.
+func (m *GroupMutation) StrictRequestFields() (r bool, exists bool) {
+	v := m.strict_request_fields
+	if v == nil {
+		return
+	}
+	return *v, true
+}
+
+// OldStrictRequestFields returns the old "strict_request_fields" field's value of the Group entity.
+// If the Group object wasn't provided to the builder, the object is fetched from the database.
+// An error is returned if the mutation operation is not UpdateOne, or the database query fails.
+func (m *GroupMutation) OldStrictRequestFields(ctx context.Context) (v bool, err error) {
+	if !m.op.Is(OpUpdateOne) {
+		return v, errors.New("OldStrictRequestFields is only allowed on UpdateOne operations")
+	}
+	if m.id == nil || m.oldValue == nil {
+		return v, errors.New("OldStrictRequestFields requires an ID field in the mutation")
+	}
+	oldValue, err := m.oldValue(ctx)
+	if err != nil {
+		return v, fmt.Errorf("querying old value for OldStrictRequestFields: %w", err)
+	}
+	return oldValue.StrictRequestFields, nil
+}
+
+// ResetStrictRequestFields resets all changes to the "strict_request_fields" field.
+func (m *GroupMutation) ResetStrictRequestFields() {
+	m.strict_request_fields = nil
+}
+
 // AddAPIKeyIDs adds the "api_keys" edge to the APIKey entity by ids.
 func (m *GroupMutation) AddAPIKeyIDs(ids ...int64) {
 	if m.api_keys == nil {
@@ -8881,7 +10144,7 @@ func (m *GroupMutation) Type() string {
 // order to get all numeric fields that were incremented/decremented, call
 // AddedFields().
 func (m *GroupMutation) Fields() []string {
-	fields := make([]string, 0, 25)
+	fields := make([]string, 0, 33)
 	if m.created_at != nil {
 		fields = append(fields, group.FieldCreatedAt)
 	}
@@ -8945,6 +10208,24 @@ func (m *GroupMutation) Fields() []string {
 	if m.model_routing != nil {
 		fields = append(fields, group.FieldModelRouting)
 	}
+	if m.model_param_policies != nil {
+		fields = append(fields, group.FieldModelParamPolicies)
+	}
+	if m.region_policy != nil {
+		fields = append(fields, group.FieldRegionPolicy)
+	}
+	if m.system_prompt_policy != nil {
+		fields = append(fields, group.FieldSystemPromptPolicy)
+	}
+	if m.prompt_template != nil {
+		fields = append(fields, group.FieldPromptTemplate)
+	}
+	if m.model_access_policy != nil {
+		fields = append(fields, group.FieldModelAccessPolicy)
+	}
+	if m.retry_policy != nil {
+		fields = append(fields, group.FieldRetryPolicy)
+	}
 	if m.model_routing_enabled != nil {
 		fields = append(fields, group.FieldModelRoutingEnabled)
 	}
@@ -8957,6 +10238,12 @@ func (m *GroupMutation) Fields() []string {
 	if m.sort_order != nil {
 		fields = append(fields, group.FieldSortOrder)
 	}
+	if m.metering_only != nil {
+		fields = append(fields, group.FieldMeteringOnly)
+	}
+	if m.strict_request_fields != nil {
+		fields = append(fields, group.FieldStrictRequestFields)
+	}
 	return fields
 }
 
@@ -9007,6 +10294,18 @@ func (m *GroupMutation) Field(name string) (ent.Value, bool) {
 		return m.FallbackGroupIDOnInvalidRequest()
 	case group.FieldModelRouting:
 		return m.ModelRouting()
+	case group.FieldModelParamPolicies:
+		return m.ModelParamPolicies()
+	case group.FieldRegionPolicy:
+		return m.RegionPolicy()
+	case group.FieldSystemPromptPolicy:
+		return m.SystemPromptPolicy()
+	case group.FieldPromptTemplate:
+		return m.PromptTemplate()
+	case group.FieldModelAccessPolicy:
+		return m.ModelAccessPolicy()
+	case group.FieldRetryPolicy:
+		return m.RetryPolicy()
 	case group.FieldModelRoutingEnabled:
 		return m.ModelRoutingEnabled()
 	case group.FieldMcpXMLInject:
@@ -9015,6 +10314,10 @@ func (m *GroupMutation) Field(name string) (ent.Value, bool) {
 		return m.SupportedModelScopes()
 	case group.FieldSortOrder:
 		return m.SortOrder()
+	case group.FieldMeteringOnly:
+		return m.MeteringOnly()
+	case group.FieldStrictRequestFields:
+		return m.StrictRequestFields()
 	}
 	return nil, false
 }
@@ -9066,6 +10369,18 @@ func (m *GroupMutation) OldField(ctx context.Context, name string) (ent.Value, e
 		return m.OldFallbackGroupIDOnInvalidRequest(ctx)
 	case group.FieldModelRouting:
 		return m.OldModelRouting(ctx)
+	case group.FieldModelParamPolicies:
+		return m.OldModelParamPolicies(ctx)
+	case group.FieldRegionPolicy:
+		return m.OldRegionPolicy(ctx)
+	case group.FieldSystemPromptPolicy:
+		return m.OldSystemPromptPolicy(ctx)
+	case group.FieldPromptTemplate:
+		return m.OldPromptTemplate(ctx)
+	case group.FieldModelAccessPolicy:
+		return m.OldModelAccessPolicy(ctx)
+	case group.FieldRetryPolicy:
+		return m.OldRetryPolicy(ctx)
 	case group.FieldModelRoutingEnabled:
 		return m.OldModelRoutingEnabled(ctx)
 	case group.FieldMcpXMLInject:
@@ -9074,6 +10389,10 @@ func (m *GroupMutation) OldField(ctx context.Context, name string) (ent.Value, e
 		return m.OldSupportedModelScopes(ctx)
 	case group.FieldSortOrder:
 		return m.OldSortOrder(ctx)
+	case group.FieldMeteringOnly:
+		return m.OldMeteringOnly(ctx)
+	case group.FieldStrictRequestFields:
+		return m.OldStrictRequestFields(ctx)
 	}
 	return nil, fmt.Errorf("unknown Group field %s", name)
 }
@@ -9230,6 +10549,48 @@ func (m *GroupMutation) SetField(name string, value ent.Value) error {
 		}
 		m.SetModelRouting(v)
 		return nil
+	case group.FieldModelParamPolicies:
+		v, ok := value.(map[string]domain.ModelParamPolicy)
+		if !ok {
+			return fmt.Errorf("unexpected type %T for field %s", value, name)
+		}
+		m.SetModelParamPolicies(v)
+		return nil
+	case group.FieldRegionPolicy:
+		v, ok := value.(domain.RegionPolicy)
+		if !ok {
+			return fmt.Errorf("unexpected type %T for field %s", value, name)
+		}
+		m.SetRegionPolicy(v)
+		return nil
+	case group.FieldSystemPromptPolicy:
+		v, ok := value.(domain.SystemPromptPolicy)
+		if !ok {
+			return fmt.Errorf("unexpected type %T for field %s", value, name)
+		}
+		m.SetSystemPromptPolicy(v)
+		return nil
+	case group.FieldPromptTemplate:
+		v, ok := value.(domain.PromptTemplate)
+		if !ok {
+			return fmt.Errorf("unexpected type %T for field %s", value, name)
+		}
+		m.SetPromptTemplate(v)
+		return nil
+	case group.FieldModelAccessPolicy:
+		v, ok := value.(domain.ModelAccessPolicy)
+		if !ok {
+			return fmt.Errorf("unexpected type %T for field %s", value, name)
+		}
+		m.SetModelAccessPolicy(v)
+		return nil
+	case group.FieldRetryPolicy:
+		v, ok := value.(domain.RetryPolicy)
+		if !ok {
+			return fmt.Errorf("unexpected type %T for field %s", value, name)
+		}
+		m.SetRetryPolicy(v)
+		return nil
 	case group.FieldModelRoutingEnabled:
 		v, ok := value.(bool)
 		if !ok {
@@ -9258,6 +10619,20 @@ func (m *GroupMutation) SetField(name string, value ent.Value) error {
 		}
 		m.SetSortOrder(v)
 		return nil
+	case group.FieldMeteringOnly:
+		v, ok := value.(bool)
+		if !ok {
+			return fmt.Errorf("unexpected type %T for field %s", value, name)
+		}
+		m.SetMeteringOnly(v)
+		return nil
+	case group.FieldStrictRequestFields:
+		v, ok := value.(bool)
+		if !ok {
+			return fmt.Errorf("unexpected type %T for field %s", value, name)
+		}
+		m.SetStrictRequestFields(v)
+		return nil
 	}
 	return fmt.Errorf("unknown Group field %s", name)
 }
@@ -9456,6 +10831,24 @@ func (m *GroupMutation) ClearedFields() []string {
 	if m.FieldCleared(group.FieldModelRouting) {
 		fields = append(fields, group.FieldModelRouting)
 	}
+	if m.FieldCleared(group.FieldModelParamPolicies) {
+		fields = append(fields, group.FieldModelParamPolicies)
+	}
+	if m.FieldCleared(group.FieldRegionPolicy) {
+		fields = append(fields, group.FieldRegionPolicy)
+	}
+	if m.FieldCleared(group.FieldSystemPromptPolicy) {
+		fields = append(fields, group.FieldSystemPromptPolicy)
+	}
+	if m.FieldCleared(group.FieldPromptTemplate) {
+		fields = append(fields, group.FieldPromptTemplate)
+	}
+	if m.FieldCleared(group.FieldModelAccessPolicy) {
+		fields = append(fields, group.FieldModelAccessPolicy)
+	}
+	if m.FieldCleared(group.FieldRetryPolicy) {
+		fields = append(fields, group.FieldRetryPolicy)
+	}
 	return fields
 }
 
@@ -9503,6 +10896,24 @@ func (m *GroupMutation) ClearField(name string) error {
 	case group.FieldModelRouting:
 		m.ClearModelRouting()
 		return nil
+	case group.FieldModelParamPolicies:
+		m.ClearModelParamPolicies()
+		return nil
+	case group.FieldRegionPolicy:
+		m.ClearRegionPolicy()
+		return nil
+	case group.FieldSystemPromptPolicy:
+		m.ClearSystemPromptPolicy()
+		return nil
+	case group.FieldPromptTemplate:
+		m.ClearPromptTemplate()
+		return nil
+	case group.FieldModelAccessPolicy:
+		m.ClearModelAccessPolicy()
+		return nil
+	case group.FieldRetryPolicy:
+		m.ClearRetryPolicy()
+		return nil
 	}
 	return fmt.Errorf("unknown Group nullable field %s", name)
 }
@@ -9574,6 +10985,24 @@ func (m *GroupMutation) ResetField(name string) error {
 	case group.FieldModelRouting:
 		m.ResetModelRouting()
 		return nil
+	case group.FieldModelParamPolicies:
+		m.ResetModelParamPolicies()
+		return nil
+	case group.FieldRegionPolicy:
+		m.ResetRegionPolicy()
+		return nil
+	case group.FieldSystemPromptPolicy:
+		m.ResetSystemPromptPolicy()
+		return nil
+	case group.FieldPromptTemplate:
+		m.ResetPromptTemplate()
+		return nil
+	case group.FieldModelAccessPolicy:
+		m.ResetModelAccessPolicy()
+		return nil
+	case group.FieldRetryPolicy:
+		m.ResetRetryPolicy()
+		return nil
 	case group.FieldModelRoutingEnabled:
 		m.ResetModelRoutingEnabled()
 		return nil
@@ -9586,6 +11015,12 @@ func (m *GroupMutation) ResetField(name string) error {
 	case group.FieldSortOrder:
 		m.ResetSortOrder()
 		return nil
+	case group.FieldMeteringOnly:
+		m.ResetMeteringOnly()
+		return nil
+	case group.FieldStrictRequestFields:
+		m.ResetStrictRequestFields()
+		return nil
 	}
 	return fmt.Errorf("unknown Group field %s", name)
 }
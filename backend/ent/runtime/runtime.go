@@ -92,12 +92,22 @@ func init() {
 	apikey.DefaultStatus = apikeyDescStatus.Default.(string)
 	// apikey.StatusValidator is a validator for the "status" field. It is called by the builders before save.
 	apikey.StatusValidator = apikeyDescStatus.Validators[0].(func(string) error)
+	// apikeyDescPriorityClass is the schema descriptor for priority_class field.
+	apikeyDescPriorityClass := apikeyFields[5].Descriptor()
+	// apikey.DefaultPriorityClass holds the default value on creation for the priority_class field.
+	apikey.DefaultPriorityClass = apikeyDescPriorityClass.Default.(string)
+	// apikey.PriorityClassValidator is a validator for the "priority_class" field. It is called by the builders before save.
+	apikey.PriorityClassValidator = apikeyDescPriorityClass.Validators[0].(func(string) error)
+	// apikeyDescDebugErrors is the schema descriptor for debug_errors field.
+	apikeyDescDebugErrors := apikeyFields[16].Descriptor()
+	// apikey.DefaultDebugErrors holds the default value on creation for the debug_errors field.
+	apikey.DefaultDebugErrors = apikeyDescDebugErrors.Default.(bool)
 	// apikeyDescQuota is the schema descriptor for quota field.
-	apikeyDescQuota := apikeyFields[7].Descriptor()
+	apikeyDescQuota := apikeyFields[17].Descriptor()
 	// apikey.DefaultQuota holds the default value on creation for the quota field.
 	apikey.DefaultQuota = apikeyDescQuota.Default.(float64)
 	// apikeyDescQuotaUsed is the schema descriptor for quota_used field.
-	apikeyDescQuotaUsed := apikeyFields[8].Descriptor()
+	apikeyDescQuotaUsed := apikeyFields[18].Descriptor()
 	// apikey.DefaultQuotaUsed holds the default value on creation for the quota_used field.
 	apikey.DefaultQuotaUsed = apikeyDescQuotaUsed.Default.(float64)
 	accountMixin := schema.Account{}.Mixin()
@@ -313,21 +323,21 @@ func init() {
 	// errorpassthroughrule.DefaultPriority holds the default value on creation for the priority field.
 	errorpassthroughrule.DefaultPriority = errorpassthroughruleDescPriority.Default.(int)
 	// errorpassthroughruleDescMatchMode is the schema descriptor for match_mode field.
-	errorpassthroughruleDescMatchMode := errorpassthroughruleFields[5].Descriptor()
+	errorpassthroughruleDescMatchMode := errorpassthroughruleFields[7].Descriptor()
 	// errorpassthroughrule.DefaultMatchMode holds the default value on creation for the match_mode field.
 	errorpassthroughrule.DefaultMatchMode = errorpassthroughruleDescMatchMode.Default.(string)
 	// errorpassthroughrule.MatchModeValidator is a validator for the "match_mode" field. It is called by the builders before save.
 	errorpassthroughrule.MatchModeValidator = errorpassthroughruleDescMatchMode.Validators[0].(func(string) error)
 	// errorpassthroughruleDescPassthroughCode is the schema descriptor for passthrough_code field.
-	errorpassthroughruleDescPassthroughCode := errorpassthroughruleFields[7].Descriptor()
+	errorpassthroughruleDescPassthroughCode := errorpassthroughruleFields[9].Descriptor()
 	// errorpassthroughrule.DefaultPassthroughCode holds the default value on creation for the passthrough_code field.
 	errorpassthroughrule.DefaultPassthroughCode = errorpassthroughruleDescPassthroughCode.Default.(bool)
 	// errorpassthroughruleDescPassthroughBody is the schema descriptor for passthrough_body field.
-	errorpassthroughruleDescPassthroughBody := errorpassthroughruleFields[9].Descriptor()
+	errorpassthroughruleDescPassthroughBody := errorpassthroughruleFields[11].Descriptor()
 	// errorpassthroughrule.DefaultPassthroughBody holds the default value on creation for the passthrough_body field.
 	errorpassthroughrule.DefaultPassthroughBody = errorpassthroughruleDescPassthroughBody.Default.(bool)
 	// errorpassthroughruleDescSkipMonitoring is the schema descriptor for skip_monitoring field.
-	errorpassthroughruleDescSkipMonitoring := errorpassthroughruleFields[11].Descriptor()
+	errorpassthroughruleDescSkipMonitoring := errorpassthroughruleFields[13].Descriptor()
 	// errorpassthroughrule.DefaultSkipMonitoring holds the default value on creation for the skip_monitoring field.
 	errorpassthroughrule.DefaultSkipMonitoring = errorpassthroughruleDescSkipMonitoring.Default.(bool)
 	groupMixin := schema.Group{}.Mixin()
@@ -402,21 +412,29 @@ func init() {
 	// group.DefaultClaudeCodeOnly holds the default value on creation for the claude_code_only field.
 	group.DefaultClaudeCodeOnly = groupDescClaudeCodeOnly.Default.(bool)
 	// groupDescModelRoutingEnabled is the schema descriptor for model_routing_enabled field.
-	groupDescModelRoutingEnabled := groupFields[18].Descriptor()
+	groupDescModelRoutingEnabled := groupFields[24].Descriptor()
 	// group.DefaultModelRoutingEnabled holds the default value on creation for the model_routing_enabled field.
 	group.DefaultModelRoutingEnabled = groupDescModelRoutingEnabled.Default.(bool)
 	// groupDescMcpXMLInject is the schema descriptor for mcp_xml_inject field.
-	groupDescMcpXMLInject := groupFields[19].Descriptor()
+	groupDescMcpXMLInject := groupFields[25].Descriptor()
 	// group.DefaultMcpXMLInject holds the default value on creation for the mcp_xml_inject field.
 	group.DefaultMcpXMLInject = groupDescMcpXMLInject.Default.(bool)
 	// groupDescSupportedModelScopes is the schema descriptor for supported_model_scopes field.
-	groupDescSupportedModelScopes := groupFields[20].Descriptor()
+	groupDescSupportedModelScopes := groupFields[26].Descriptor()
 	// group.DefaultSupportedModelScopes holds the default value on creation for the supported_model_scopes field.
 	group.DefaultSupportedModelScopes = groupDescSupportedModelScopes.Default.([]string)
 	// groupDescSortOrder is the schema descriptor for sort_order field.
-	groupDescSortOrder := groupFields[21].Descriptor()
+	groupDescSortOrder := groupFields[27].Descriptor()
 	// group.DefaultSortOrder holds the default value on creation for the sort_order field.
 	group.DefaultSortOrder = groupDescSortOrder.Default.(int)
+	// groupDescMeteringOnly is the schema descriptor for metering_only field.
+	groupDescMeteringOnly := groupFields[28].Descriptor()
+	// group.DefaultMeteringOnly holds the default value on creation for the metering_only field.
+	group.DefaultMeteringOnly = groupDescMeteringOnly.Default.(bool)
+	// groupDescStrictRequestFields is the schema descriptor for strict_request_fields field.
+	groupDescStrictRequestFields := groupFields[29].Descriptor()
+	// group.DefaultStrictRequestFields holds the default value on creation for the strict_request_fields field.
+	group.DefaultStrictRequestFields = groupDescStrictRequestFields.Default.(bool)
 	promocodeFields := schema.PromoCode{}.Fields()
 	_ = promocodeFields
 	// promocodeDescCode is the schema descriptor for code field.
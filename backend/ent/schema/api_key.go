@@ -47,12 +47,43 @@ func (APIKey) Fields() []ent.Field {
 		field.String("status").
 			MaxLen(20).
 			Default(domain.StatusActive),
+		field.String("priority_class").
+			MaxLen(20).
+			Default("").
+			Comment("优先级类别（interactive/batch），决定故障转移预算；为空使用全局配置"),
 		field.JSON("ip_whitelist", []string{}).
 			Optional().
 			Comment("Allowed IPs/CIDRs, e.g. [\"192.168.1.100\", \"10.0.0.0/8\"]"),
+		field.JSON("allowed_models", []string{}).
+			Optional().
+			Comment("允许请求的模型（支持末尾 * 通配），为空表示仅受分组策略限制"),
+		field.JSON("scopes", []string{}).
+			Optional().
+			Comment("权限范围：允许访问的端点（responses/chat/claude/gemini/embeddings）与功能（streaming/tools/images），为空表示不限制"),
 		field.JSON("ip_blacklist", []string{}).
 			Optional().
 			Comment("Blocked IPs/CIDRs"),
+		field.JSON("region_policy", domain.RegionPolicy{}).
+			Optional().
+			Comment("区域策略：要求/优先使用指定区域的账号（覆盖分组配置）"),
+		field.JSON("system_prompt_policy", domain.SystemPromptPolicy{}).
+			Optional().
+			Comment("系统提示词注入策略（覆盖分组配置）"),
+		field.JSON("usage_webhook", domain.UsageWebhook{}).
+			Optional().
+			Comment("用量回调：请求完成后向 Key 持有者配置的地址推送请求摘要"),
+		field.JSON("request_signing", domain.RequestSigning{}).
+			Optional().
+			Comment("请求签名：启用后请求须携带以共享密钥计算的 HMAC 签名与时间戳，防止截获的请求被重放"),
+		field.JSON("token_quota", domain.TokenQuota{}).
+			Optional().
+			Comment("每日/每月 token 与请求数硬配额（按指定时区的自然日/月重置）"),
+		field.JSON("tool_limits", map[string]int{}).
+			Optional().
+			Comment("内置工具（web_search/code_interpreter/image_generation）每日调用上限"),
+		field.Bool("debug_errors").
+			Default(false).
+			Comment("调试模式：在错误响应中附带脱敏后的上游错误详情"),
 
 		// ========== Quota fields ==========
 		// Quota limit in USD (0 = unlimited)
@@ -15,7 +15,7 @@ import (
 // ErrorPassthroughRule 定义全局错误透传规则的 schema。
 //
 // 错误透传规则用于控制上游错误如何返回给客户端：
-//   - 匹配条件：错误码 + 关键词组合
+//   - 匹配条件：错误码 + 关键词/正则（响应体）+ 响应头组合
 //   - 响应行为：透传原始信息 或 自定义错误信息
 //   - 响应状态码：可指定返回给客户端的状态码
 //   - 平台范围：规则适用的平台（Anthropic、OpenAI、Gemini、Antigravity）
@@ -67,16 +67,28 @@ func (ErrorPassthroughRule) Fields() []ent.Field {
 			Optional().
 			SchemaType(map[string]string{dialect.Postgres: "jsonb"}),
 
+		// body_patterns: 匹配上游响应体的正则表达式列表（OR关系，与 keywords 同属响应体条件）
+		// 例如：["(?i)prompt is too long: \\d+ tokens"]
+		field.JSON("body_patterns", []string{}).
+			Optional().
+			SchemaType(map[string]string{dialect.Postgres: "jsonb"}),
+
+		// header_matches: 匹配上游响应头（头名 → 正则，AND关系）
+		// 例如：{"x-should-retry": "^false$"}
+		field.JSON("header_matches", map[string]string{}).
+			Optional().
+			SchemaType(map[string]string{dialect.Postgres: "jsonb"}),
+
 		// match_mode: 匹配模式
-		// - "any": 错误码匹配 OR 关键词匹配（任一条件满足即可）
-		// - "all": 错误码匹配 AND 关键词匹配（所有条件都必须满足）
+		// - "any": 错误码、响应体、响应头任一条件满足即可
+		// - "all": 所有已配置的条件都必须满足
 		field.String("match_mode").
 			MaxLen(10).
 			Default("any"),
 
 		// platforms: 适用平台列表
 		// 例如：["anthropic", "openai", "gemini", "antigravity"]
-		// 空列表表示适用于所有平台
+		// 支持通配符（如 "*"、"gemini*"），空列表表示适用于所有平台
 		field.JSON("platforms", []string{}).
 			Optional().
 			SchemaType(map[string]string{dialect.Postgres: "jsonb"}),
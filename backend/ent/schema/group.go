@@ -106,6 +106,42 @@ func (Group) Fields() []ent.Field {
 			SchemaType(map[string]string{dialect.Postgres: "jsonb"}).
 			Comment("模型路由配置：模型模式 -> 优先账号ID列表"),
 
+		// 模型参数策略 (added by migration 055)
+		field.JSON("model_param_policies", map[string]domain.ModelParamPolicy{}).
+			Optional().
+			SchemaType(map[string]string{dialect.Postgres: "jsonb"}).
+			Comment("模型参数策略：模型模式 -> 参数范围/互斥约束"),
+
+		// 区域策略 (added by migration 056)
+		field.JSON("region_policy", domain.RegionPolicy{}).
+			Optional().
+			SchemaType(map[string]string{dialect.Postgres: "jsonb"}).
+			Comment("区域策略：要求/优先使用指定区域的账号"),
+
+		// 系统提示词注入策略 (added by migration 062)
+		field.JSON("system_prompt_policy", domain.SystemPromptPolicy{}).
+			Optional().
+			SchemaType(map[string]string{dialect.Postgres: "jsonb"}).
+			Comment("系统提示词注入策略"),
+
+		// 提示词前缀/后缀模板 (added by migration 063)
+		field.JSON("prompt_template", domain.PromptTemplate{}).
+			Optional().
+			SchemaType(map[string]string{dialect.Postgres: "jsonb"}).
+			Comment("提示词模板：包裹用户最新消息的前缀/后缀"),
+
+		// 模型访问策略 (added by migration 059)
+		field.JSON("model_access_policy", domain.ModelAccessPolicy{}).
+			Optional().
+			SchemaType(map[string]string{dialect.Postgres: "jsonb"}).
+			Comment("模型访问策略：允许/禁止请求的模型列表"),
+
+		// 重试策略 (added by migration 081)
+		field.JSON("retry_policy", domain.RetryPolicy{}).
+			Optional().
+			SchemaType(map[string]string{dialect.Postgres: "jsonb"}).
+			Comment("重试策略：可重试状态码、指数退避与总时间预算"),
+
 		// 模型路由开关 (added by migration 041)
 		field.Bool("model_routing_enabled").
 			Default(false).
@@ -126,6 +162,16 @@ func (Group) Fields() []ent.Field {
 		field.Int("sort_order").
 			Default(0).
 			Comment("分组显示排序，数值越小越靠前"),
+
+		// 仅计量模式 (added by migration 069)
+		field.Bool("metering_only").
+			Default(false).
+			Comment("仅计量：记录用量与费用但不扣费、不做计费资格拦截"),
+
+		// 未知顶层字段处理模式 (added by migration 072)
+		field.Bool("strict_request_fields").
+			Default(false).
+			Comment("严格字段模式：拒绝包含未知顶层字段的请求（宽松模式原样透传）"),
 	}
 }
 
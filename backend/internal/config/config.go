@@ -6,11 +6,16 @@ import (
 	"encoding/hex"
 	"fmt"
 	"log"
+	"net"
 	"net/url"
 	"os"
+	"regexp"
+	"strconv"
 	"strings"
+	"sync/atomic"
 	"time"
 
+	"github.com/Wei-Shaw/sub2api/internal/pkg/piiredact"
 	"github.com/spf13/viper"
 )
 
@@ -19,6 +24,15 @@ const (
 	RunModeSimple   = "simple"
 )
 
+// clickHouseIdentifierPattern ClickHouse 库名/表名（直接拼接到 SQL 中，仅允许安全标识符）
+var clickHouseIdentifierPattern = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_]*$`)
+
+// lokiLabelNamePattern Loki/Prometheus 标签名规则
+var lokiLabelNamePattern = regexp.MustCompile(`^[a-zA-Z_][a-zA-Z0-9_]*$`)
+
+// elasticsearchIndexPattern Elasticsearch 索引名前缀（小写，不含特殊字符，不以 -_+ 开头）
+var elasticsearchIndexPattern = regexp.MustCompile(`^[a-z0-9][a-z0-9._-]*$`)
+
 // DefaultCSPPolicy is the default Content-Security-Policy with nonce support
 // __CSP_NONCE__ will be replaced with actual nonce at request time by the SecurityHeaders middleware
 const DefaultCSPPolicy = "default-src 'self'; script-src 'self' __CSP_NONCE__ https://challenges.cloudflare.com https://static.cloudflareinsights.com; style-src 'self' 'unsafe-inline' https://fonts.googleapis.com; img-src 'self' data: https:; font-src 'self' data: https://fonts.gstatic.com; connect-src 'self' https:; frame-src https://challenges.cloudflare.com; frame-ancestors 'none'; base-uri 'self'; form-action 'self'"
@@ -54,15 +68,70 @@ type Config struct {
 	Pricing      PricingConfig              `mapstructure:"pricing"`
 	Gateway      GatewayConfig              `mapstructure:"gateway"`
 	APIKeyAuth   APIKeyAuthCacheConfig      `mapstructure:"api_key_auth_cache"`
+	APIKeyRotate APIKeyRotationConfig       `mapstructure:"api_key_rotation"`
 	Dashboard    DashboardCacheConfig       `mapstructure:"dashboard_cache"`
 	DashboardAgg DashboardAggregationConfig `mapstructure:"dashboard_aggregation"`
 	UsageCleanup UsageCleanupConfig         `mapstructure:"usage_cleanup"`
+	UsageWebhook UsageWebhookConfig         `mapstructure:"usage_webhook"`
+	UsageRetry   UsageRetryConfig           `mapstructure:"usage_retry_queue"`
+	AuditLog     AuditLogConfig             `mapstructure:"audit_log"`
+	Trash        TrashConfig                `mapstructure:"trash"`
+	Stripe       StripeConfig               `mapstructure:"stripe"`
 	Concurrency  ConcurrencyConfig          `mapstructure:"concurrency"`
 	TokenRefresh TokenRefreshConfig         `mapstructure:"token_refresh"`
 	RunMode      string                     `mapstructure:"run_mode" yaml:"run_mode"`
 	Timezone     string                     `mapstructure:"timezone"` // e.g. "Asia/Shanghai", "UTC"
 	Gemini       GeminiConfig               `mapstructure:"gemini"`
 	Update       UpdateConfig               `mapstructure:"update"`
+	Frontend     FrontendConfig             `mapstructure:"frontend"`
+	Metrics      MetricsConfig              `mapstructure:"metrics"`
+	Tracing      TracingConfig              `mapstructure:"tracing"`
+	Log          LogConfig                  `mapstructure:"log"`
+
+	// live 热重载发布的当前配置快照，由 Load 初始化（见 Live）
+	live *atomic.Pointer[Config]
+}
+
+// LogConfig 结构化日志配置
+type LogConfig struct {
+	// Format 输出格式：text（默认，便于本地阅读）或 json（便于日志平台采集）
+	Format string `mapstructure:"format"`
+	// Level 日志级别：debug/info/warn/error；为空时 release 模式为 info，其余为 debug
+	Level string `mapstructure:"level"`
+}
+
+// MetricsConfig Prometheus /metrics 端点配置
+type MetricsConfig struct {
+	// Enabled 是否开放 /metrics
+	Enabled bool `mapstructure:"enabled"`
+	// AuthToken 抓取时需携带的 Bearer Token，为空表示不校验（建议仅在内网监听器上开放）
+	AuthToken string `mapstructure:"auth_token"`
+}
+
+// TracingConfig OpenTelemetry 链路追踪配置（OTLP/HTTP 导出）
+type TracingConfig struct {
+	// Enabled 是否启用链路追踪
+	Enabled bool `mapstructure:"enabled"`
+	// Endpoint OTLP/HTTP 接收端地址，如 http://otel-collector:4318
+	Endpoint string `mapstructure:"endpoint"`
+	// Headers 导出请求附加的头（如 collector 鉴权）
+	Headers map[string]string `mapstructure:"headers"`
+	// ServiceName 上报的 service.name
+	ServiceName string `mapstructure:"service_name"`
+	// SampleRatio 根 span 采样率（0-1]；携带 traceparent 的请求沿用调用方的采样决定
+	SampleRatio float64 `mapstructure:"sample_ratio"`
+	// PropagateUpstream 是否向上游 API 请求注入 traceparent/tracestate 头。
+	// 注意：开启后上游服务商可见这些头，如需对上游完全透明请关闭。
+	PropagateUpstream bool `mapstructure:"propagate_upstream"`
+}
+
+// FrontendConfig 内嵌前端（-tags embed 构建）的服务配置
+type FrontendConfig struct {
+	// APIBasePath 注入到 index.html 的 API 基础路径，前端据此访问后端接口；
+	// 经反向代理挂载到子路径时修改（如 "/sub2api/api/v1"），也可以是完整 URL
+	APIBasePath string `mapstructure:"api_base_path"`
+	// AssetCacheMaxAge 带内容哈希的静态资源（assets/ 目录）缓存时间（秒），0 表示不缓存
+	AssetCacheMaxAge int `mapstructure:"asset_cache_max_age"`
 }
 
 type GeminiConfig struct {
@@ -141,17 +210,100 @@ type PricingConfig struct {
 	UpdateIntervalHours int `mapstructure:"update_interval_hours"`
 	// 哈希校验间隔（分钟）
 	HashCheckIntervalMinutes int `mapstructure:"hash_check_interval_minutes"`
+	// 内置工具（web_search/code_interpreter/image_generation）单价
+	ToolPrices ToolPricingConfig `mapstructure:"tool_prices"`
+}
+
+// ToolPricingConfig 上游内置工具调用的单价（USD），按次独立计费，叠加在 token 费用之上
+type ToolPricingConfig struct {
+	// 每次 web 搜索调用
+	WebSearchPerCall float64 `mapstructure:"web_search_per_call"`
+	// 每个 code interpreter 会话
+	CodeInterpreterPerSession float64 `mapstructure:"code_interpreter_per_session"`
+	// Responses 内 image_generation 工具每生成一张图片
+	ImageGenerationPerImage float64 `mapstructure:"image_generation_per_image"`
 }
 
 type ServerConfig struct {
-	Host               string    `mapstructure:"host"`
-	Port               int       `mapstructure:"port"`
-	Mode               string    `mapstructure:"mode"`                  // debug/release
-	ReadHeaderTimeout  int       `mapstructure:"read_header_timeout"`   // 读取请求头超时（秒）
-	IdleTimeout        int       `mapstructure:"idle_timeout"`          // 空闲连接超时（秒）
-	TrustedProxies     []string  `mapstructure:"trusted_proxies"`       // 可信代理列表（CIDR/IP）
-	MaxRequestBodySize int64     `mapstructure:"max_request_body_size"` // 全局最大请求体限制
-	H2C                H2CConfig `mapstructure:"h2c"`                   // HTTP/2 Cleartext 配置
+	Host               string          `mapstructure:"host"`
+	Port               int             `mapstructure:"port"`
+	Mode               string          `mapstructure:"mode"`                  // debug/release
+	ReadHeaderTimeout  int             `mapstructure:"read_header_timeout"`   // 读取请求头超时（秒）
+	IdleTimeout        int             `mapstructure:"idle_timeout"`          // 空闲连接超时（秒）
+	TrustedProxies     []string        `mapstructure:"trusted_proxies"`       // 可信代理列表（CIDR/IP）
+	MaxRequestBodySize int64           `mapstructure:"max_request_body_size"` // 全局最大请求体限制
+	ShutdownTimeout    int             `mapstructure:"shutdown_timeout"`      // 优雅关闭时等待进行中请求（含流式）完成的最长时间（秒）
+	H2C                H2CConfig       `mapstructure:"h2c"`                   // HTTP/2 Cleartext 配置
+	TLS                ServerTLSConfig `mapstructure:"tls"`                   // 监听端 TLS / mTLS 配置
+	// Listeners: 多监听器（TCP / Unix socket），每个监听器只注册指定范围的路由并拥有独立的中间件栈；
+	// 为空时沿用 host:port 单监听器并注册全部路由
+	Listeners []ListenerConfig `mapstructure:"listeners"`
+}
+
+// 监听器路由范围
+const (
+	ListenerRouteAll     = "all"     // 全部路由
+	ListenerRouteGateway = "gateway" // 网关（/v1、/v1beta、/antigravity 等 API Key 端点）
+	ListenerRouteAdmin   = "admin"   // 管理后台 API（含登录认证与前端页面）
+	ListenerRouteUser    = "user"    // 用户端 API（含登录认证与前端页面）
+)
+
+// ListenerConfig 单个监听器配置
+type ListenerConfig struct {
+	Name string `mapstructure:"name"`
+	// Network: tcp（默认）或 unix
+	Network string `mapstructure:"network"`
+	// Address: tcp 为 host:port，unix 为 socket 文件路径
+	Address string `mapstructure:"address"`
+	// SocketMode: unix socket 文件权限（八进制字符串，如 "0660"），为空时不修改
+	SocketMode string `mapstructure:"socket_mode"`
+	// Routes: 注册的路由范围（all / gateway / admin / user），为空视为 all
+	Routes []string `mapstructure:"routes"`
+	// TLS: 使用 server.tls 的证书（及客户端证书校验）提供 HTTPS
+	TLS bool `mapstructure:"tls"`
+	// TrustedProxies: 覆盖 server.trusted_proxies，为空时沿用全局配置
+	TrustedProxies []string `mapstructure:"trusted_proxies"`
+}
+
+// EffectiveListeners 返回实际生效的监听器；未配置 listeners 时返回兼容旧配置的单个 TCP 监听器
+func (s *ServerConfig) EffectiveListeners() []ListenerConfig {
+	if len(s.Listeners) > 0 {
+		return s.Listeners
+	}
+	return []ListenerConfig{{
+		Name:    "default",
+		Network: "tcp",
+		Address: s.Address(),
+		Routes:  []string{ListenerRouteAll},
+		TLS:     s.TLS.Enabled,
+	}}
+}
+
+// ServerTLSConfig 监听端 TLS 配置
+type ServerTLSConfig struct {
+	Enabled  bool   `mapstructure:"enabled"`
+	CertFile string `mapstructure:"cert_file"`
+	KeyFile  string `mapstructure:"key_file"`
+	// ClientCAFile: 校验客户端证书的 CA（PEM）；配置后客户端可出示证书（可选），是否必需由 client_auth 决定
+	ClientCAFile string               `mapstructure:"client_ca_file"`
+	ClientAuth   ClientCertAuthConfig `mapstructure:"client_auth"`
+}
+
+// ClientCertAuthConfig 网关端点的客户端证书认证（证书 Subject/SAN → API Key）
+type ClientCertAuthConfig struct {
+	Enabled bool `mapstructure:"enabled"`
+	// RequireCert: 网关端点只接受已映射的客户端证书，拒绝 Bearer / x-api-key 等令牌认证
+	RequireCert bool                `mapstructure:"require_cert"`
+	Mappings    []ClientCertMapping `mapstructure:"mappings"`
+}
+
+// ClientCertMapping 客户端证书到 API Key 的映射；同时配置 subject 与 san 时需同时匹配
+type ClientCertMapping struct {
+	// Subject: 证书 Subject DN（如 "CN=svc-a,O=Acme"）或 CN
+	Subject string `mapstructure:"subject"`
+	// SAN: 任一 DNS / Email / URI / IP SAN 与之相等即匹配
+	SAN    string `mapstructure:"san"`
+	APIKey string `mapstructure:"api_key"`
 }
 
 // H2CConfig HTTP/2 Cleartext 配置
@@ -174,6 +326,27 @@ type SecurityConfig struct {
 	ResponseHeaders ResponseHeaderConfig `mapstructure:"response_headers"`
 	CSP             CSPConfig            `mapstructure:"csp"`
 	ProxyProbe      ProxyProbeConfig     `mapstructure:"proxy_probe"`
+	RequestSigning  RequestSigningConfig `mapstructure:"request_signing"`
+	GatewayJWT      GatewayJWTConfig     `mapstructure:"gateway_jwt"`
+	// CredentialEncryption 账号凭证静态加密
+	CredentialEncryption CredentialEncryptionConfig `mapstructure:"credential_encryption"`
+	// PIIRedaction 日志、运维错误记录、审计与使用记录落盘前的个人信息脱敏
+	PIIRedaction PIIRedactionConfig `mapstructure:"pii_redaction"`
+}
+
+// PIIRedactionConfig 个人信息脱敏配置
+type PIIRedactionConfig struct {
+	Enabled bool `mapstructure:"enabled"`
+	// BuiltinPatterns 启用的内置规则：email、phone、credit_card、cn_id_card、us_ssn、ipv4
+	BuiltinPatterns []string `mapstructure:"builtin_patterns"`
+	// Patterns 自定义正则表达式（RE2 语法），匹配内容被替换
+	Patterns []string `mapstructure:"patterns"`
+	// Fields JSON 字段名（不区分大小写），命中时整个值被替换（如 metadata、user）
+	Fields []string `mapstructure:"fields"`
+	// Replacement 替换文本
+	Replacement string `mapstructure:"replacement"`
+	// RedactLogs 是否同时屏蔽进程日志输出（标准库 log 与 slog）
+	RedactLogs bool `mapstructure:"redact_logs"`
 }
 
 type URLAllowlistConfig struct {
@@ -201,8 +374,70 @@ type ProxyProbeConfig struct {
 	InsecureSkipVerify bool `mapstructure:"insecure_skip_verify"` // 已禁用：禁止跳过 TLS 证书验证
 }
 
+// RequestSigningConfig API Key 请求签名（HMAC）校验配置
+type RequestSigningConfig struct {
+	// MaxSkewSeconds 请求时间戳与服务器时间允许的最大偏差（秒），同时作为防重放签名的保留时长
+	MaxSkewSeconds int `mapstructure:"max_skew_seconds"`
+}
+
+// CredentialEncryptionConfig 账号凭证静态加密：api_key、access/refresh token、cookie 等敏感字段
+// 以信封加密方式写入数据库，网关使用时再按需解密
+type CredentialEncryptionConfig struct {
+	Enabled bool `mapstructure:"enabled"`
+	// KeyID 当前主密钥标识，写入密文以支持轮换
+	KeyID string `mapstructure:"key_id"`
+	// Key base64 编码的 32 字节主密钥
+	Key string `mapstructure:"key"`
+	// KeyCommand 启动时执行以获取主密钥（输出 base64），用于从 KMS 等外部密钥服务获取；与 key 二选一
+	KeyCommand string `mapstructure:"key_command"`
+	// PreviousKeys 轮换前的旧主密钥（key_id → base64），仅用于解密
+	PreviousKeys map[string]string `mapstructure:"previous_keys"`
+}
+
+// GatewayJWTConfig 网关 JWT 认证：接受身份提供方签发的短期 JWT 替代静态 API Key。
+// JWT 通过 api_key_id 声明映射到一个承载用户、分组与限额的 API Key，用量与计费记在该 Key 上。
+type GatewayJWTConfig struct {
+	Enabled bool `mapstructure:"enabled"`
+	// Issuer 要求的 iss 声明
+	Issuer string `mapstructure:"issuer"`
+	// Audience 要求的 aud 声明（为空时不校验）
+	Audience string `mapstructure:"audience"`
+	// HMACSecret HS256/HS384/HS512 共享密钥
+	HMACSecret string `mapstructure:"hmac_secret"`
+	// PublicKeyFile RS*/ES*/EdDSA 公钥（PEM）路径；与 hmac_secret 至少配置一项
+	PublicKeyFile string `mapstructure:"public_key_file"`
+	// MaxTTLMinutes 允许的最长有效期（exp - iat），超过则拒绝，确保只接受短期凭证
+	MaxTTLMinutes int `mapstructure:"max_ttl_minutes"`
+	// LeewaySeconds 校验 exp/nbf/iat 时允许的时钟偏差（秒）
+	LeewaySeconds int `mapstructure:"leeway_seconds"`
+}
+
 type BillingConfig struct {
 	CircuitBreaker CircuitBreakerConfig `mapstructure:"circuit_breaker"`
+	// SpendCap 用户每日/每月消费上限默认值（可按用户覆盖）
+	SpendCap SpendCapConfig `mapstructure:"spend_cap"`
+	// QuotaRollover 订阅月度额度结转
+	QuotaRollover QuotaRolloverConfig `mapstructure:"quota_rollover"`
+}
+
+// SpendCapConfig 用户消费上限（美元，按服务器时区的自然日/自然月统计实际扣费金额，0 表示不限制）。
+// 软上限只告警（响应头 + 预算告警通知），硬上限拒绝请求。
+type SpendCapConfig struct {
+	Enabled        bool    `mapstructure:"enabled"`
+	DailySoftUSD   float64 `mapstructure:"daily_soft_usd"`
+	DailyHardUSD   float64 `mapstructure:"daily_hard_usd"`
+	MonthlySoftUSD float64 `mapstructure:"monthly_soft_usd"`
+	MonthlyHardUSD float64 `mapstructure:"monthly_hard_usd"`
+}
+
+// QuotaRolloverConfig 订阅月度额度结转：月窗口重置时，上个窗口未用完的月额度结转到新窗口，
+// 结转额按分组月限额的百分比封顶，并在新窗口开始后 ExpiryDays 天过期。
+type QuotaRolloverConfig struct {
+	Enabled bool `mapstructure:"enabled"`
+	// MaxPercent 结转上限占分组月限额的百分比（100 表示最多结转一个完整月额度）
+	MaxPercent float64 `mapstructure:"max_percent"`
+	// ExpiryDays 结转额度自新窗口开始起的有效天数
+	ExpiryDays int `mapstructure:"expiry_days"`
 }
 
 type CircuitBreakerConfig struct {
@@ -215,6 +450,12 @@ type CircuitBreakerConfig struct {
 type ConcurrencyConfig struct {
 	// PingInterval: 并发等待期间的 SSE ping 间隔（秒）
 	PingInterval int `mapstructure:"ping_interval"`
+	// ReconcileOnStartup: 启动时与 Redis 对账，清理本实例上一次运行（或其他已崩溃实例）遗留的槽位，
+	// 并校验等待计数键。启用后槽位成员带实例归属，实例通过心跳键声明存活。
+	ReconcileOnStartup bool `mapstructure:"reconcile_on_startup"`
+	// InstanceID: 实例标识（用于识别上一次运行遗留的槽位），为空时使用主机名；
+	// 同一主机运行多个实例时需分别配置
+	InstanceID string `mapstructure:"instance_id"`
 }
 
 // GatewayConfig API网关相关配置
@@ -245,6 +486,20 @@ type GatewayConfig struct {
 	// 超过此时间未使用的客户端会被标记为可回收
 	// 建议值：根据用户访问频率设置，一般 10-30 分钟
 	ClientIdleTTLSeconds int `mapstructure:"client_idle_ttl_seconds"`
+	// UpstreamTransport: 上游 Transport 调优（HTTP/2、TLS 会话复用、拨号/握手超时）
+	UpstreamTransport GatewayUpstreamTransportConfig `mapstructure:"upstream_transport"`
+	// AccountWorkerPool: 账号级上游调用硬隔离（每账号独立的有界 worker 池）
+	AccountWorkerPool GatewayAccountWorkerPoolConfig `mapstructure:"account_worker_pool"`
+	// MemoryGuard: 请求体、SSE 缓冲与 Ops 捕获的内存预算，接近预算时拒绝新的流式请求
+	MemoryGuard GatewayMemoryGuardConfig `mapstructure:"memory_guard"`
+	// CostPreflight: 请求费用预检，预估最大费用超过剩余预算时拒绝
+	CostPreflight GatewayCostPreflightConfig `mapstructure:"cost_preflight"`
+	// StreamAbuse: 流式请求滥用检测（首 token 后立即断开、取消率异常等抓取特征）
+	StreamAbuse GatewayStreamAbuseConfig `mapstructure:"stream_abuse"`
+	// UsageAnomaly: 按 API Key 检测偏离历史模式的用量（请求量突增、新国家、新模型），用于发现泄露的 Key
+	UsageAnomaly GatewayUsageAnomalyConfig `mapstructure:"usage_anomaly"`
+	// IPBan: 认证前按 IP 限流，并临时封禁频繁使用无效 Key 的 IP
+	IPBan GatewayIPBanConfig `mapstructure:"ip_ban"`
 	// ConcurrencySlotTTLMinutes: 并发槽位过期时间（分钟）
 	// 应大于最长 LLM 请求时间，防止请求完成前槽位过期
 	ConcurrencySlotTTLMinutes int `mapstructure:"concurrency_slot_ttl_minutes"`
@@ -257,6 +512,14 @@ type GatewayConfig struct {
 	StreamDataIntervalTimeout int `mapstructure:"stream_data_interval_timeout"`
 	// StreamKeepaliveInterval: 流式 keepalive 间隔（秒），0表示禁用
 	StreamKeepaliveInterval int `mapstructure:"stream_keepalive_interval"`
+	// PhaseTimeouts: 上游请求分阶段超时（连接/首 token/分片间隔/总时长），各阶段超时产生不同的错误与故障转移决策
+	PhaseTimeouts GatewayPhaseTimeoutsConfig `mapstructure:"phase_timeouts"`
+	// ResponseContinuity: Responses API previous_response_id 续链在故障转移切换账号后的处理
+	ResponseContinuity GatewayResponseContinuityConfig `mapstructure:"response_continuity"`
+	// ResponseCache: 相同非流式请求的响应缓存（命中时不请求上游）
+	ResponseCache GatewayResponseCacheConfig `mapstructure:"response_cache"`
+	// TokenCounting: 本地 token 计数（count_tokens 本地应答、上下文裁剪）
+	TokenCounting GatewayTokenCountingConfig `mapstructure:"token_counting"`
 	// MaxLineSize: 上游 SSE 单行最大字节数（0使用默认值）
 	MaxLineSize int `mapstructure:"max_line_size"`
 
@@ -271,10 +534,16 @@ type GatewayConfig struct {
 	// 是否允许对部分 400 错误触发 failover（默认关闭以避免改变语义）
 	FailoverOn400 bool `mapstructure:"failover_on_400"`
 
+	// 分组处于维护状态时返回给客户端的提示信息（HTTP 503）
+	MaintenanceMessage string `mapstructure:"maintenance_message"`
+
 	// 账户切换最大次数（遇到上游错误时切换到其他账户的次数上限）
 	MaxAccountSwitches int `mapstructure:"max_account_switches"`
 	// Gemini 账户切换最大次数（Gemini 平台单独配置，因 API 限制更严格）
 	MaxAccountSwitchesGemini int `mapstructure:"max_account_switches_gemini"`
+	// FailoverClasses: 按 API Key 优先级类别（interactive/batch）配置故障转移预算，
+	// 未设置类别的 Key 使用上面的全局切换次数
+	FailoverClasses map[string]GatewayFailoverClassConfig `mapstructure:"failover_classes"`
 
 	// Antigravity 429 fallback 限流时间（分钟），解析重置时间失败时使用
 	AntigravityFallbackCooldownMinutes int `mapstructure:"antigravity_fallback_cooldown_minutes"`
@@ -282,6 +551,12 @@ type GatewayConfig struct {
 	// Scheduling: 账号调度相关配置
 	Scheduling GatewaySchedulingConfig `mapstructure:"scheduling"`
 
+	// ModelDiscovery: 账号模型能力矩阵自动发现配置
+	ModelDiscovery GatewayModelDiscoveryConfig `mapstructure:"model_discovery"`
+
+	// RegionSync: 多区域部署时与其他区域共享粘性会话绑定与账号临时冷却状态
+	RegionSync GatewayRegionSyncConfig `mapstructure:"region_sync"`
+
 	// TLSFingerprint: TLS指纹伪装配置
 	TLSFingerprint TLSFingerprintConfig `mapstructure:"tls_fingerprint"`
 }
@@ -310,6 +585,227 @@ type TLSProfileConfig struct {
 	PointFormats []uint8 `mapstructure:"point_formats"`
 }
 
+// GatewayModelDiscoveryConfig 账号模型自动发现配置
+// 定期请求 API Key 账号上游的 /models 接口，将可用模型写入账号 extra.discovered_models，调度时据此过滤
+type GatewayModelDiscoveryConfig struct {
+	// Enabled: 是否启用定期自动发现
+	Enabled bool `mapstructure:"enabled"`
+	// Interval: 刷新周期
+	Interval time.Duration `mapstructure:"interval"`
+	// CacheTTL: 上游模型列表与模型信息探测结果的 Redis 缓存有效期（0 表示不缓存）
+	CacheTTL time.Duration `mapstructure:"cache_ttl"`
+}
+
+// GatewayRegionSyncConfig 多区域粘性会话协调配置
+// 每个区域使用独立的 Redis；启用后本区域写入的粘性会话绑定与账号临时不可调度状态
+// 会异步复制到其他区域的 Redis，用户在区域间切换时仍能命中原账号。
+type GatewayRegionSyncConfig struct {
+	// Enabled: 是否启用跨区域复制
+	Enabled bool `mapstructure:"enabled"`
+	// Region: 本区域名称（仅用于日志）
+	Region string `mapstructure:"region"`
+	// Peers: 其他区域的 Redis
+	Peers []GatewayRegionPeerConfig `mapstructure:"peers"`
+	// QueueSize: 待复制写操作的内存队列容量，队列满时丢弃（不阻塞请求）
+	QueueSize int `mapstructure:"queue_size"`
+	// Timeout: 单次跨区域 Redis 操作超时
+	Timeout time.Duration `mapstructure:"timeout"`
+	// LookupPeersOnMiss: 本区域未命中粘性会话时同步查询其他区域（弥补复制延迟，会增加新会话的首次调度延迟）
+	LookupPeersOnMiss bool `mapstructure:"lookup_peers_on_miss"`
+}
+
+// GatewayRegionPeerConfig 其他区域的 Redis 连接配置
+type GatewayRegionPeerConfig struct {
+	Name      string `mapstructure:"name"`
+	Host      string `mapstructure:"host"`
+	Port      int    `mapstructure:"port"`
+	Password  string `mapstructure:"password"`
+	DB        int    `mapstructure:"db"`
+	EnableTLS bool   `mapstructure:"enable_tls"`
+}
+
+func (p *GatewayRegionPeerConfig) Address() string {
+	return fmt.Sprintf("%s:%d", p.Host, p.Port)
+}
+
+// GatewayMemoryGuardConfig 网关内存护栏配置
+// 统计请求体、上游 SSE 行缓冲与 Ops 错误日志捕获占用的内存：占用达到 RejectThreshold 比例后
+// 新的流式请求直接返回 503 + Retry-After；单个流的缓冲超过 PerStreamBudgetMB 或全局预算耗尽时中止该流。
+type GatewayMemoryGuardConfig struct {
+	Enabled bool `mapstructure:"enabled"`
+	// GlobalBudgetMB: 全局内存预算（MB）
+	GlobalBudgetMB int `mapstructure:"global_budget_mb"`
+	// PerStreamBudgetMB: 单个流式响应的缓冲预算（MB）
+	PerStreamBudgetMB int `mapstructure:"per_stream_budget_mb"`
+	// RejectThreshold: 占用达到全局预算的该比例时拒绝新的流式请求（0-1]
+	RejectThreshold float64 `mapstructure:"reject_threshold"`
+	// RetryAfterSeconds: 拒绝时返回的 Retry-After（秒）
+	RetryAfterSeconds int `mapstructure:"retry_after_seconds"`
+}
+
+// GatewayUpstreamTransportConfig 上游 HTTP Transport 调优配置
+// 作用于每个缓存客户端的 Transport；TLS 指纹客户端由 utls 自行拨号握手，只使用连接池参数。
+type GatewayUpstreamTransportConfig struct {
+	// HTTP2: 上游支持时经 ALPN 协商 HTTP/2，单连接多路复用（经代理、账号自定义 TLS 时同样生效）
+	HTTP2 bool `mapstructure:"http2"`
+	// HTTP2PingIntervalSeconds: HTTP/2 连接超过该时间未收到帧时发送 PING 探活，及早发现被中间设备静默断开的连接；0 表示不探活
+	HTTP2PingIntervalSeconds int `mapstructure:"http2_ping_interval_seconds"`
+	// TLSSessionCacheSize: 每个客户端的 TLS 会话缓存条目数，新建连接时恢复会话以跳过完整握手；0 表示关闭
+	TLSSessionCacheSize int `mapstructure:"tls_session_cache_size"`
+	// DialTimeoutSeconds: TCP 建连超时（秒）
+	DialTimeoutSeconds int `mapstructure:"dial_timeout_seconds"`
+	// TLSHandshakeTimeoutSeconds: TLS 握手超时（秒）
+	TLSHandshakeTimeoutSeconds int `mapstructure:"tls_handshake_timeout_seconds"`
+	// KeepAliveSeconds: TCP keep-alive 探测间隔（秒）
+	KeepAliveSeconds int `mapstructure:"keep_alive_seconds"`
+}
+
+// GatewayAccountWorkerPoolConfig 账号级上游 worker 池配置
+// 开启后每个账号的上游调用（从发起请求到响应体关闭）占用该账号池中的一个 worker，
+// 单个账号上游挂起时只会耗尽自己的池，不会拖垮共享的 HTTP 客户端与文件描述符。
+type GatewayAccountWorkerPoolConfig struct {
+	Enabled bool `mapstructure:"enabled"`
+	// Size: 每账号 worker 数上限，0 表示按账号并发数（账号未设置并发时使用 DefaultSize）
+	Size int `mapstructure:"size"`
+	// DefaultSize: 账号未设置并发数且 Size=0 时使用的 worker 数
+	DefaultSize int `mapstructure:"default_size"`
+	// QueueTimeout: 等待空闲 worker 的最长时间，超时视为池饱和并切换账号；0 表示不等待
+	QueueTimeout time.Duration `mapstructure:"queue_timeout"`
+}
+
+// GatewayCostPreflightConfig 请求费用预检配置
+// 开启后在占用账号前按（估算提示 token + 最大输出 token）× 单价 × 倍率估算请求最大费用，
+// 超过剩余预算（余额 / API Key 额度 / 订阅剩余限额）时直接拒绝。
+type GatewayCostPreflightConfig struct {
+	Enabled bool `mapstructure:"enabled"`
+	// DefaultMaxOutputTokens: 请求未声明最大输出 token 时按此值估算
+	DefaultMaxOutputTokens int `mapstructure:"default_max_output_tokens"`
+}
+
+// GatewayStreamAbuseConfig 流式请求滥用检测配置
+// 按 API Key 统计滑动窗口内的流式请求数、客户端取消数与首 token 后立即放弃数，
+// 比例超过阈值的 Key 会被标记，并作为运维告警指标 stream_abuse_flagged_keys 的数据来源。
+type GatewayStreamAbuseConfig struct {
+	Enabled bool `mapstructure:"enabled"`
+	// WindowMinutes: 统计窗口（分钟）
+	WindowMinutes int `mapstructure:"window_minutes"`
+	// MinRequests: 窗口内流式请求数达到该值才参与判定，避免少量样本误判
+	MinRequests int `mapstructure:"min_requests"`
+	// EarlyAbandonSeconds: 首 token 后多少秒内断开视为"立即放弃"
+	EarlyAbandonSeconds int `mapstructure:"early_abandon_seconds"`
+	// EarlyAbandonRate: 立即放弃占比阈值（0-1）
+	EarlyAbandonRate float64 `mapstructure:"early_abandon_rate"`
+	// CancelRate: 客户端取消占比阈值（0-1）
+	CancelRate float64 `mapstructure:"cancel_rate"`
+	// FlagTTLMinutes: 标记保留时间（分钟）
+	FlagTTLMinutes int `mapstructure:"flag_ttl_minutes"`
+}
+
+// 用量异常处置动作
+const (
+	UsageAnomalyActionAlert   = "alert"
+	UsageAnomalyActionSuspend = "suspend"
+)
+
+// GatewayUsageAnomalyConfig API Key 用量异常检测配置
+// 每个 Key 先经过学习期建立基线（每小时请求数、出现过的国家与模型），之后出现
+// 请求量突增、新国家或新模型时标记该 Key，并按 action 告警或自动停用。
+// 被标记的 Key 数量作为运维告警指标 usage_anomaly_flagged_keys 的数据来源。
+type GatewayUsageAnomalyConfig struct {
+	Enabled bool `mapstructure:"enabled"`
+	// BaselineHours: 计算每小时平均请求数的回看窗口（小时）
+	BaselineHours int `mapstructure:"baseline_hours"`
+	// LearningHours: Key 首次出现后的学习期（小时），学习期内只记录不判定
+	LearningHours int `mapstructure:"learning_hours"`
+	// SpikeFactor: 当前小时请求数超过基线平均值的倍数时判定为突增
+	SpikeFactor float64 `mapstructure:"spike_factor"`
+	// MinRequestsPerHour: 当前小时请求数达到该值才判定突增，避免低流量 Key 误判
+	MinRequestsPerHour int `mapstructure:"min_requests_per_hour"`
+	// CountryHeader: 反向代理/CDN 写入的客户端国家代码请求头（如 CF-IPCountry），为空不检测新国家
+	CountryHeader string `mapstructure:"country_header"`
+	// DetectNewModels: 是否将首次使用的模型视为异常
+	DetectNewModels bool `mapstructure:"detect_new_models"`
+	// Action: alert（仅标记告警）或 suspend（标记并停用 Key）
+	Action string `mapstructure:"action"`
+	// FlagTTLMinutes: 标记保留时间（分钟），期间同一 Key 不重复处置
+	FlagTTLMinutes int `mapstructure:"flag_ttl_minutes"`
+}
+
+// GatewayIPBanConfig 认证前的 IP 限流与封禁配置
+// 在 API Key 认证（数据库查询）之前按客户端 IP 计数：超过每分钟请求上限时返回 429，
+// 窗口内认证失败次数达到阈值时临时封禁该 IP。计数与封禁记录存于 Redis，多实例共享。
+type GatewayIPBanConfig struct {
+	Enabled bool `mapstructure:"enabled"`
+	// RequestsPerMinute: 单个 IP 每分钟最大请求数（认证前统计），0 表示不限流
+	RequestsPerMinute int `mapstructure:"requests_per_minute"`
+	// MaxAuthFailures: 窗口内认证失败达到该次数时封禁 IP
+	MaxAuthFailures int `mapstructure:"max_auth_failures"`
+	// FailureWindowSeconds: 认证失败计数窗口（秒）
+	FailureWindowSeconds int `mapstructure:"failure_window_seconds"`
+	// BanMinutes: 封禁时长（分钟）
+	BanMinutes int `mapstructure:"ban_minutes"`
+	// Whitelist: 不受限流与封禁影响的 IP/CIDR（如内网出口、健康检查）
+	Whitelist []string `mapstructure:"whitelist"`
+}
+
+// GatewayPhaseTimeoutsConfig 上游请求分阶段超时（秒），0 表示该阶段不限制
+type GatewayPhaseTimeoutsConfig struct {
+	// ConnectSeconds: 获取上游连接（含 DNS、代理隧道与 TLS 握手）超时，超时后切换账号
+	ConnectSeconds int `mapstructure:"connect_seconds"`
+	// FirstTokenSeconds: 首 token 超时（流式为首个 SSE 分片，非流式为响应头），超时后切换账号
+	FirstTokenSeconds int `mapstructure:"first_token_seconds"`
+	// ChunkGapSeconds: 首个分片之后相邻分片的最大间隔，超时后结束响应（已开始输出，不切换账号）
+	ChunkGapSeconds int `mapstructure:"chunk_gap_seconds"`
+	// TotalSeconds: 单次上游请求总时长（含流式传输），超时后结束响应且不切换账号
+	TotalSeconds int `mapstructure:"total_seconds"`
+}
+
+// GatewayResponseContinuityConfig Responses API 续链上下文配置
+// 上游只在创建响应的账号上保存 previous_response_id 对应的上下文，切换账号后原样转发必然失败：
+// 网关记录每个响应所属账号（可选保存完整对话历史），切换到其他账号时用历史重建上下文，
+// 无历史可用时直接返回明确的错误
+type GatewayResponseContinuityConfig struct {
+	// Enabled: 是否记录响应所属账号并检查续链请求
+	Enabled bool `mapstructure:"enabled"`
+	// TTLSeconds: 响应记录保留时长（秒）
+	TTLSeconds int `mapstructure:"ttl_seconds"`
+	// StoreHistory: 是否在 Redis 中保存对话历史（输入与输出项），用于在其他账号上重建上下文
+	StoreHistory bool `mapstructure:"store_history"`
+	// MaxHistoryBytes: 单个响应保存的对话历史上限（字节），超过时只记录所属账号
+	MaxHistoryBytes int `mapstructure:"max_history_bytes"`
+}
+
+// GatewayResponseCacheConfig 非流式请求响应缓存配置
+// 以请求体内容寻址（按 API Key 隔离），仅缓存 temperature 为 0 或客户端显式要求缓存（X-Sub2API-Cache: on）的成功响应；
+// 命中时直接返回缓存内容并带 X-Cache: HIT 响应头，不请求上游也不计费。评测场景会反复提交相同的提示词。
+type GatewayResponseCacheConfig struct {
+	Enabled bool `mapstructure:"enabled"`
+	// TTLSeconds: 缓存保留时长（秒）
+	TTLSeconds int `mapstructure:"ttl_seconds"`
+	// MaxResponseBytes: 可缓存的响应体上限（字节），更大的响应不缓存
+	MaxResponseBytes int `mapstructure:"max_response_bytes"`
+}
+
+// GatewayTokenCountingConfig 本地 token 计数配置
+// 额度预检与 /v1/token-count 接口始终使用本地估算；以下开关控制是否替代上游 count_tokens 以及是否裁剪过长的上下文
+type GatewayTokenCountingConfig struct {
+	// LocalCountTokens: /v1/messages/count_tokens 由本地估算直接应答，不再转发上游
+	LocalCountTokens bool `mapstructure:"local_count_tokens"`
+	// ContextTrimMaxTokens: 估算提示 token 超过该值时从最早的对话轮次开始裁剪（保留 system 与最新一轮），0 表示不裁剪
+	ContextTrimMaxTokens int `mapstructure:"context_trim_max_tokens"`
+}
+
+// GatewayFailoverClassConfig 单个优先级类别的故障转移预算
+type GatewayFailoverClassConfig struct {
+	// MaxAccountSwitches: 最大账号切换次数，0 表示沿用全局 max_account_switches
+	MaxAccountSwitches int `mapstructure:"max_account_switches"`
+	// AttemptTimeoutSeconds: 单次尝试等待上游响应头的超时（秒），超时后切换账号；0 表示沿用 response_header_timeout
+	AttemptTimeoutSeconds int `mapstructure:"attempt_timeout_seconds"`
+	// HedgeAfterMs: 请求对冲，首个账号在该毫秒数内未开始输出（首 token）时，并行向另一账号发送相同请求，
+	// 采用先响应的一方并取消另一方（仅按获胜账号计费）；0 表示不启用
+	HedgeAfterMs int `mapstructure:"hedge_after_ms"`
+}
+
 // GatewaySchedulingConfig accounts scheduling configuration.
 type GatewaySchedulingConfig struct {
 	// 粘性会话排队配置
@@ -326,9 +822,24 @@ type GatewaySchedulingConfig struct {
 	// 负载计算
 	LoadBatchEnabled bool `mapstructure:"load_batch_enabled"`
 
+	// 新会话初始放置使用按容量加权的一致性哈希环（同优先级内），账号池增减时仅少量会话改变首选账号
+	SessionConsistentHash bool `mapstructure:"session_consistent_hash"`
+
+	// 提示词缓存感知路由：记录会话（prompt_cache_key/会话 hash）最近成功响应的账号，即该账号持有上游预热缓存；
+	// 负载感知选择时只要其负载率与同优先级最低负载账号的差距不超过 PromptCacheMaxLoadGap（百分点），就优先选择该账号
+	PromptCacheAffinity   bool          `mapstructure:"prompt_cache_affinity"`
+	PromptCacheWarmTTL    time.Duration `mapstructure:"prompt_cache_warm_ttl"`
+	PromptCacheMaxLoadGap int           `mapstructure:"prompt_cache_max_load_gap"`
+
 	// 过期槽位清理周期（0 表示禁用）
 	SlotCleanupInterval time.Duration `mapstructure:"slot_cleanup_interval"`
 
+	// 账号被删除/禁用时，将其绑定的粘性会话预先重绑到同分组的替代账号（false 时仅删除绑定）
+	RebindSessionsOnAccountRemoval bool `mapstructure:"rebind_sessions_on_account_removal"`
+
+	// 两阶段选择的槽位预占有效期：负载感知选择写入的预占需在转发开始前确认，超时自动回收（0 表示禁用预占）
+	ReservationTTL time.Duration `mapstructure:"reservation_ttl"`
+
 	// 受控回源配置
 	DbFallbackEnabled bool `mapstructure:"db_fallback_enabled"`
 	// 受控回源超时（秒），0 表示不额外收紧超时
@@ -453,6 +964,134 @@ type OpsConfig struct {
 
 	// Pre-aggregation configuration.
 	Aggregation OpsAggregationConfig `mapstructure:"aggregation"`
+
+	// ClickHouse 请求级运维事件副本（用于长周期分析查询），默认关闭
+	ClickHouse OpsClickHouseConfig `mapstructure:"clickhouse"`
+
+	// LogShipping 将请求级运维事件推送到 Loki / Elasticsearch（不再只依赖 stdout 采集），默认关闭
+	LogShipping OpsLogShippingConfig `mapstructure:"log_shipping"`
+
+	// BodyCapture 错误日志请求体在请求上下文中的捕获上限，控制图片等大请求的内存/存储占用
+	BodyCapture OpsBodyCaptureConfig `mapstructure:"body_capture"`
+
+	// ReferenceDiff 开发者模式：将同一请求分别发往池内账号与官方参考账号并比较响应结构，默认关闭
+	ReferenceDiff OpsReferenceDiffConfig `mapstructure:"reference_diff"`
+
+	// SlowRequest 慢请求检测：超过耗时/首字阈值的请求连同账号、模型、重试次数一并记录，可配置告警
+	SlowRequest OpsSlowRequestConfig `mapstructure:"slow_request"`
+}
+
+// OpsSlowRequestConfig 慢请求检测配置。阈值为 0 表示不按该维度判定。
+// 流式请求总耗时天然较长，因此单独使用 StreamDurationThresholdMs。
+type OpsSlowRequestConfig struct {
+	Enabled bool `mapstructure:"enabled"`
+	// DurationThresholdMs 非流式请求总耗时阈值（毫秒）
+	DurationThresholdMs int `mapstructure:"duration_threshold_ms"`
+	// StreamDurationThresholdMs 流式请求总耗时阈值（毫秒）
+	StreamDurationThresholdMs int `mapstructure:"stream_duration_threshold_ms"`
+	// TTFTThresholdMs 首 token 耗时阈值（毫秒）
+	TTFTThresholdMs int `mapstructure:"ttft_threshold_ms"`
+}
+
+// OpsReferenceDiffConfig 参考上游对比（开发者模式）配置。
+// 仅允许列入 ReferenceAccountIDs 的账号作为参考端，每次对比由管理员手动触发。
+type OpsReferenceDiffConfig struct {
+	Enabled bool `mapstructure:"enabled"`
+	// ReferenceAccountIDs 允许作为参考端的第一方账号 ID
+	ReferenceAccountIDs []int64 `mapstructure:"reference_account_ids"`
+	// MaxResponseBytes 每一端捕获的响应体上限（字节）
+	MaxResponseBytes int `mapstructure:"max_response_bytes"`
+	// MinInterval 两次对比之间的最小间隔（避免误操作消耗参考账号额度）
+	MinInterval time.Duration `mapstructure:"min_interval"`
+}
+
+// OpsBodyCaptureConfig 控制网关为运维错误日志捕获的请求体大小。
+// 超过上限的请求体仅保留头部并附带截断标记；含内联图片/文件的大请求可只保留哈希。
+type OpsBodyCaptureConfig struct {
+	// MaxBytes 默认捕获上限（字节，0 表示不限制）
+	MaxBytes int `mapstructure:"max_bytes"`
+	// HashOnlyMultimodalBytes 含内联媒体（base64 图片/文件）且超过该大小的请求体只保留 SHA-256（0 表示关闭）
+	HashOnlyMultimodalBytes int `mapstructure:"hash_only_multimodal_bytes"`
+	// Routes 按路由前缀覆盖捕获上限（最长前缀优先）
+	Routes []OpsBodyCaptureRouteConfig `mapstructure:"routes"`
+}
+
+// OpsBodyCaptureRouteConfig 单个路由前缀的请求体捕获上限
+type OpsBodyCaptureRouteConfig struct {
+	// PathPrefix 请求路径前缀，如 /v1/messages/count_tokens
+	PathPrefix string `mapstructure:"path_prefix"`
+	// MaxBytes 捕获上限（字节，0 表示不限制）
+	MaxBytes int `mapstructure:"max_bytes"`
+	// HashOnly 为 true 时该路由超过上限的请求体只保留 SHA-256
+	HashOnly bool `mapstructure:"hash_only"`
+}
+
+// OpsClickHouseConfig 将请求级运维事件（成功请求的用量日志 + 错误日志，每个请求一行）
+// 异步批量写入 ClickHouse（HTTP 接口），启动后自动创建库表。
+type OpsClickHouseConfig struct {
+	Enabled bool `mapstructure:"enabled"`
+	// Endpoint ClickHouse HTTP 接口地址，如 http://clickhouse:8123
+	Endpoint string `mapstructure:"endpoint"`
+	Database string `mapstructure:"database"`
+	Table    string `mapstructure:"table"`
+	Username string `mapstructure:"username"`
+	Password string `mapstructure:"password"`
+	// BatchSize 单批写入的最大事件数
+	BatchSize int `mapstructure:"batch_size"`
+	// FlushInterval 未攒满一批时的最长写入间隔
+	FlushInterval time.Duration `mapstructure:"flush_interval"`
+	// QueueSize 内存队列容量，队列满时丢弃新事件（不阻塞请求）
+	QueueSize int `mapstructure:"queue_size"`
+	// Timeout 单次 HTTP 请求超时
+	Timeout time.Duration `mapstructure:"timeout"`
+	// TTLDays 表级数据保留天数（0 表示不过期）
+	TTLDays int `mapstructure:"ttl_days"`
+}
+
+// OpsLogShippingConfig 日志投递配置。Loki 与 Elasticsearch 各自独立排队与写入，
+// 共用批量/队列/重试参数；重试期间队列继续积压，满后丢弃新事件（不阻塞请求）。
+type OpsLogShippingConfig struct {
+	Loki          OpsLokiConfig          `mapstructure:"loki"`
+	Elasticsearch OpsElasticsearchConfig `mapstructure:"elasticsearch"`
+
+	// BatchSize 单批推送的最大事件数
+	BatchSize int `mapstructure:"batch_size"`
+	// FlushInterval 未攒满一批时的最长推送间隔
+	FlushInterval time.Duration `mapstructure:"flush_interval"`
+	// QueueSize 每个目标的内存队列容量
+	QueueSize int `mapstructure:"queue_size"`
+	// Timeout 单次 HTTP 请求超时
+	Timeout time.Duration `mapstructure:"timeout"`
+	// MaxRetries 推送失败后的重试次数（0 表示不重试）
+	MaxRetries int `mapstructure:"max_retries"`
+	// RetryBackoff 首次重试前的等待时间，之后每次翻倍
+	RetryBackoff time.Duration `mapstructure:"retry_backoff"`
+}
+
+// OpsLokiConfig Loki 推送配置（/loki/api/v1/push，JSON 格式）。
+// 每个事件一行 JSON 日志；stream 标签只包含 Labels 与 kind/platform，避免高基数。
+type OpsLokiConfig struct {
+	Enabled bool `mapstructure:"enabled"`
+	// Endpoint Loki 地址，如 http://loki:3100
+	Endpoint string `mapstructure:"endpoint"`
+	// TenantID 多租户模式下的 X-Scope-OrgID（可选）
+	TenantID string `mapstructure:"tenant_id"`
+	Username string `mapstructure:"username"`
+	Password string `mapstructure:"password"`
+	// Labels 附加的静态 stream 标签，如 {job: sub2api, env: prod}
+	Labels map[string]string `mapstructure:"labels"`
+}
+
+// OpsElasticsearchConfig Elasticsearch 推送配置（_bulk 接口），按天写入 {index_prefix}-YYYY.MM.DD 索引
+type OpsElasticsearchConfig struct {
+	Enabled bool `mapstructure:"enabled"`
+	// Endpoint Elasticsearch 地址，如 http://elasticsearch:9200
+	Endpoint    string `mapstructure:"endpoint"`
+	IndexPrefix string `mapstructure:"index_prefix"`
+	Username    string `mapstructure:"username"`
+	Password    string `mapstructure:"password"`
+	// APIKey Elasticsearch API Key（base64 编码的 id:key），设置后优先于用户名密码
+	APIKey string `mapstructure:"api_key"`
 }
 
 type OpsCleanupConfig struct {
@@ -512,7 +1151,22 @@ type DefaultConfig struct {
 }
 
 type RateLimitConfig struct {
-	OverloadCooldownMinutes int `mapstructure:"overload_cooldown_minutes"` // 529过载冷却时间(分钟)
+	OverloadCooldownMinutes int                    `mapstructure:"overload_cooldown_minutes"` // 529过载冷却时间(分钟)
+	AdaptiveCooldown        AdaptiveCooldownConfig `mapstructure:"adaptive_cooldown"`
+}
+
+// AdaptiveCooldownConfig 429/529 自适应冷却配置
+// 上游未给出明确重置时间时，以 Retry-After（缺省为默认冷却时间）为基础冷却账号，
+// 同一账号在计数窗口内连续限流/过载时冷却时间按倍数递增
+type AdaptiveCooldownConfig struct {
+	// Enabled: 是否启用连续限流时的指数递增
+	Enabled bool `mapstructure:"enabled"`
+	// Multiplier: 每次连续限流的冷却倍数
+	Multiplier float64 `mapstructure:"multiplier"`
+	// MaxSeconds: 递增后的冷却上限（秒）；上游 Retry-After 超过上限时以其为准
+	MaxSeconds int `mapstructure:"max_seconds"`
+	// ResetWindowSeconds: 距上次限流超过该时长后连续次数重新计数（秒）
+	ResetWindowSeconds int `mapstructure:"reset_window_seconds"`
 }
 
 // APIKeyAuthCacheConfig API Key 认证缓存配置
@@ -525,6 +1179,16 @@ type APIKeyAuthCacheConfig struct {
 	Singleflight       bool `mapstructure:"singleflight"`
 }
 
+// APIKeyRotationConfig API Key 轮换与过期提醒配置
+type APIKeyRotationConfig struct {
+	// DefaultGraceMinutes: 轮换后旧 Key 继续有效的默认时长（分钟），0 表示立即失效
+	DefaultGraceMinutes int `mapstructure:"default_grace_minutes"`
+	// MaxGraceMinutes: 轮换请求可指定的最长重叠时长（分钟）
+	MaxGraceMinutes int `mapstructure:"max_grace_minutes"`
+	// ExpiryWarningHours: Key 距过期不足该时长时在网关响应头中提示，0 表示不提示
+	ExpiryWarningHours int `mapstructure:"expiry_warning_hours"`
+}
+
 // DashboardCacheConfig 仪表盘统计缓存配置
 type DashboardCacheConfig struct {
 	// Enabled: 是否启用仪表盘缓存
@@ -578,6 +1242,124 @@ type UsageCleanupConfig struct {
 	TaskTimeoutSeconds int `mapstructure:"task_timeout_seconds"`
 }
 
+// UsageWebhookConfig API Key 用量回调投递配置（回调地址由 Key 持有者自行配置）
+type UsageWebhookConfig struct {
+	// Enabled: 是否允许配置并投递用量回调
+	Enabled bool `mapstructure:"enabled"`
+	// Workers: 并发投递协程数
+	Workers int `mapstructure:"workers"`
+	// QueueSize: 内存队列容量，队列满时丢弃新回调（不阻塞请求）
+	QueueSize int `mapstructure:"queue_size"`
+	// Timeout: 单次投递 HTTP 超时
+	Timeout time.Duration `mapstructure:"timeout"`
+	// MaxRetries: 网络错误或 5xx/429 时的最大重试次数
+	MaxRetries int `mapstructure:"max_retries"`
+	// AllowPrivateHosts: 是否允许回调到内网/回环地址（默认禁止，防 SSRF）
+	AllowPrivateHosts bool `mapstructure:"allow_private_hosts"`
+	// AllowInsecureHTTP: 是否允许 http 回调地址（默认只允许 https）
+	AllowInsecureHTTP bool `mapstructure:"allow_insecure_http"`
+}
+
+// UsageRetryConfig 用量记录持久化重试队列配置：写库失败的用量日志与扣费操作写入 Redis Stream，后台重放
+type UsageRetryConfig struct {
+	// Enabled: 是否启用重试队列（关闭时写库失败仅记录日志）
+	Enabled bool `mapstructure:"enabled"`
+	// MaxLength: 队列最大长度（近似裁剪），超出时丢弃最旧记录
+	MaxLength int64 `mapstructure:"max_length"`
+	// ReplayInterval: 后台重放间隔
+	ReplayInterval time.Duration `mapstructure:"replay_interval"`
+	// BatchSize: 每轮最多重放的记录数
+	BatchSize int `mapstructure:"batch_size"`
+	// MaxAttempts: 单条记录最大重放次数，超出后移入死信队列等待人工处理
+	MaxAttempts int `mapstructure:"max_attempts"`
+}
+
+// AuditLogConfig 网关请求/响应审计日志配置（合规审查与事故复盘）
+type AuditLogConfig struct {
+	// Enabled: 是否记录网关请求审计日志（默认关闭）
+	Enabled bool `mapstructure:"enabled"`
+	// CaptureBodies: 是否保存脱敏后的请求/响应体（关闭时仅保存元数据）
+	CaptureBodies bool `mapstructure:"capture_bodies"`
+	// MaxBodyBytes: 请求体/响应体各自保存的最大字节数，超出部分截断
+	MaxBodyBytes int `mapstructure:"max_body_bytes"`
+	// RedactPrompts: 是否屏蔽提示词与生成内容（messages/input/contents 等文本字段）
+	RedactPrompts bool `mapstructure:"redact_prompts"`
+	// RedactImages: 是否屏蔽内联图片/文件（base64、data URL）
+	RedactImages bool `mapstructure:"redact_images"`
+	// RedactFields: 额外需要屏蔽的 JSON 字段名（不区分大小写）；凭据类字段始终屏蔽
+	RedactFields []string `mapstructure:"redact_fields"`
+	// RetentionDays: 审计日志保留天数，过期记录由后台定时清理
+	RetentionDays int `mapstructure:"retention_days"`
+	// Workers: 异步写入协程数
+	Workers int `mapstructure:"workers"`
+	// QueueSize: 内存写入队列容量，队列满时丢弃（不阻塞请求）
+	QueueSize int `mapstructure:"queue_size"`
+	// Encryption: 请求/响应体静态加密
+	Encryption AuditLogEncryptionConfig `mapstructure:"encryption"`
+}
+
+// AuditLogEncryptionConfig 审计日志请求/响应体静态加密：每个用户使用独立的数据密钥，
+// 数据密钥由主密钥（本地或外部 KMS）包装后与记录一同保存；轮换主密钥只需重新包装数据密钥，不必重新加密内容
+type AuditLogEncryptionConfig struct {
+	Enabled bool `mapstructure:"enabled"`
+	// Provider 主密钥提供方：local（本地主密钥）或 command（通过外部命令调用 KMS）
+	Provider string `mapstructure:"provider"`
+	// KeyID 当前主密钥版本，随包装后的数据密钥保存
+	KeyID string `mapstructure:"key_id"`
+	// Key local：base64 编码的 32 字节主密钥
+	Key string `mapstructure:"key"`
+	// PreviousKeys local：轮换前的旧主密钥（key_id → base64），仅用于解包
+	PreviousKeys map[string]string `mapstructure:"previous_keys"`
+	// WrapCommand command：包装数据密钥的命令，标准输入为数据密钥，标准输出为 base64 编码的包装结果
+	WrapCommand string `mapstructure:"wrap_command"`
+	// UnwrapCommand command：解包数据密钥的命令，标准输入为包装结果，标准输出为 base64 编码的数据密钥
+	UnwrapCommand string `mapstructure:"unwrap_command"`
+	// DataKeyTTLMinutes 同一用户复用数据密钥的时长，到期后生成新的数据密钥
+	DataKeyTTLMinutes int `mapstructure:"data_key_ttl_minutes"`
+}
+
+// TrashConfig 账号与 API Key 回收站配置
+type TrashConfig struct {
+	// RetentionDays: 删除后可恢复的天数；超过后清除凭证/Key 明文（记录与用量历史保留），0 表示永久可恢复
+	RetentionDays int `mapstructure:"retention_days"`
+}
+
+// StripeConfig Stripe 订阅与按量计费集成配置
+type StripeConfig struct {
+	// Enabled: 是否启用 Stripe Webhook 与用量上报
+	Enabled bool `mapstructure:"enabled"`
+	// SecretKey: Stripe API 密钥（sk_live_... / rk_live_...），用于上报用量
+	SecretKey string `mapstructure:"secret_key"`
+	// WebhookSecret: Webhook 签名密钥（whsec_...）
+	WebhookSecret string `mapstructure:"webhook_secret"`
+	// APIBaseURL: Stripe API 地址（测试时可指向 stripe-mock）
+	APIBaseURL string `mapstructure:"api_base_url"`
+	// WebhookTolerance: Webhook 时间戳允许的最大偏差，防重放
+	WebhookTolerance time.Duration `mapstructure:"webhook_tolerance"`
+	// Plans: Stripe Price 与订阅分组的映射，订阅生效时自动开通对应分组
+	Plans []StripePlanConfig `mapstructure:"plans"`
+	// Metered: 按量计费（Meter Events）配置
+	Metered StripeMeteredConfig `mapstructure:"metered"`
+}
+
+// StripePlanConfig 单个 Stripe Price 对应的订阅分组
+type StripePlanConfig struct {
+	PriceID string `mapstructure:"price_id"`
+	GroupID int64  `mapstructure:"group_id"`
+}
+
+// StripeMeteredConfig 按量计费配置：订阅包含这些 Price 的用户会被定期上报实际消费
+type StripeMeteredConfig struct {
+	// PriceIDs: 按量计费的 Price ID 列表
+	PriceIDs []string `mapstructure:"price_ids"`
+	// MeterEventName: Stripe Meter 的 event_name
+	MeterEventName string `mapstructure:"meter_event_name"`
+	// ReportInterval: 上报周期
+	ReportInterval time.Duration `mapstructure:"report_interval"`
+	// ValueScale: 上报值 = 实际消费(USD) × ValueScale 并取整，默认 100（按美分上报）
+	ValueScale float64 `mapstructure:"value_scale"`
+}
+
 func NormalizeRunMode(value string) string {
 	normalized := strings.ToLower(strings.TrimSpace(value))
 	switch normalized {
@@ -588,31 +1370,8 @@ func NormalizeRunMode(value string) string {
 	}
 }
 
-func Load() (*Config, error) {
-	viper.SetConfigName("config")
-	viper.SetConfigType("yaml")
-
-	// Add config paths in priority order
-	// 1. DATA_DIR environment variable (highest priority)
-	if dataDir := os.Getenv("DATA_DIR"); dataDir != "" {
-		viper.AddConfigPath(dataDir)
-	}
-	// 2. Docker data directory
-	viper.AddConfigPath("/app/data")
-	// 3. Current directory
-	viper.AddConfigPath(".")
-	// 4. Config subdirectory
-	viper.AddConfigPath("./config")
-	// 5. System config directory
-	viper.AddConfigPath("/etc/sub2api")
-
-	// 环境变量支持
-	viper.AutomaticEnv()
-	viper.SetEnvKeyReplacer(strings.NewReplacer(".", "_"))
-
-	// 默认值
-	setDefaults()
-
+// readConfig 读取配置文件与环境变量并做规范化（不生成密钥、不校验），供 Load 与热重载共用
+func readConfig() (*Config, error) {
 	if err := viper.ReadInConfig(); err != nil {
 		if _, ok := err.(viper.ConfigFileNotFoundError); !ok {
 			return nil, fmt.Errorf("read config error: %w", err)
@@ -643,11 +1402,47 @@ func Load() (*Config, error) {
 	cfg.LinuxDo.UserInfoEmailPath = strings.TrimSpace(cfg.LinuxDo.UserInfoEmailPath)
 	cfg.LinuxDo.UserInfoIDPath = strings.TrimSpace(cfg.LinuxDo.UserInfoIDPath)
 	cfg.LinuxDo.UserInfoUsernamePath = strings.TrimSpace(cfg.LinuxDo.UserInfoUsernamePath)
+	cfg.Frontend.APIBasePath = strings.TrimRight(strings.TrimSpace(cfg.Frontend.APIBasePath), "/")
+	if cfg.Frontend.APIBasePath == "" {
+		cfg.Frontend.APIBasePath = "/api/v1"
+	}
 	cfg.Dashboard.KeyPrefix = strings.TrimSpace(cfg.Dashboard.KeyPrefix)
 	cfg.CORS.AllowedOrigins = normalizeStringSlice(cfg.CORS.AllowedOrigins)
 	cfg.Security.ResponseHeaders.AdditionalAllowed = normalizeStringSlice(cfg.Security.ResponseHeaders.AdditionalAllowed)
 	cfg.Security.ResponseHeaders.ForceRemove = normalizeStringSlice(cfg.Security.ResponseHeaders.ForceRemove)
 	cfg.Security.CSP.Policy = strings.TrimSpace(cfg.Security.CSP.Policy)
+	return &cfg, nil
+}
+
+func Load() (*Config, error) {
+	viper.SetConfigName("config")
+	viper.SetConfigType("yaml")
+
+	// Add config paths in priority order
+	// 1. DATA_DIR environment variable (highest priority)
+	if dataDir := os.Getenv("DATA_DIR"); dataDir != "" {
+		viper.AddConfigPath(dataDir)
+	}
+	// 2. Docker data directory
+	viper.AddConfigPath("/app/data")
+	// 3. Current directory
+	viper.AddConfigPath(".")
+	// 4. Config subdirectory
+	viper.AddConfigPath("./config")
+	// 5. System config directory
+	viper.AddConfigPath("/etc/sub2api")
+
+	// 环境变量支持
+	viper.AutomaticEnv()
+	viper.SetEnvKeyReplacer(strings.NewReplacer(".", "_"))
+
+	// 默认值
+	setDefaults()
+
+	cfg, err := readConfig()
+	if err != nil {
+		return nil, err
+	}
 
 	if cfg.JWT.Secret == "" {
 		secret, err := generateJWTSecret(64)
@@ -693,7 +1488,9 @@ func Load() (*Config, error) {
 		)
 	}
 
-	return &cfg, nil
+	cfg.live = new(atomic.Pointer[Config])
+	cfg.live.Store(cfg)
+	return cfg, nil
 }
 
 func setDefaults() {
@@ -707,7 +1504,30 @@ func setDefaults() {
 	viper.SetDefault("server.idle_timeout", 120)       // 120秒空闲超时
 	viper.SetDefault("server.trusted_proxies", []string{})
 	viper.SetDefault("server.max_request_body_size", int64(100*1024*1024))
+	viper.SetDefault("server.shutdown_timeout", 30) // 30秒排空进行中的请求
 	// H2C 默认配置
+	viper.SetDefault("server.tls.enabled", false)
+	viper.SetDefault("frontend.api_base_path", "/api/v1")
+	viper.SetDefault("frontend.asset_cache_max_age", 31536000) // 1 年，资源文件名带哈希，可长期缓存
+
+	// Metrics
+	viper.SetDefault("metrics.enabled", false)
+	viper.SetDefault("metrics.auth_token", "")
+
+	// Log
+	viper.SetDefault("log.format", "text")
+	viper.SetDefault("log.level", "")
+
+	// Tracing
+	viper.SetDefault("tracing.enabled", false)
+	viper.SetDefault("tracing.endpoint", "http://localhost:4318")
+	viper.SetDefault("tracing.headers", map[string]string{})
+	viper.SetDefault("tracing.service_name", "sub2api")
+	viper.SetDefault("tracing.sample_ratio", 1.0)
+	viper.SetDefault("tracing.propagate_upstream", true)
+
+	viper.SetDefault("server.tls.client_auth.enabled", false)
+	viper.SetDefault("server.tls.client_auth.require_cert", false)
 	viper.SetDefault("server.h2c.enabled", false)
 	viper.SetDefault("server.h2c.max_concurrent_streams", uint32(50))      // 50 个并发流
 	viper.SetDefault("server.h2c.idle_timeout", 75)                        // 75 秒
@@ -743,12 +1563,38 @@ func setDefaults() {
 	viper.SetDefault("security.csp.enabled", true)
 	viper.SetDefault("security.csp.policy", DefaultCSPPolicy)
 	viper.SetDefault("security.proxy_probe.insecure_skip_verify", false)
+	viper.SetDefault("security.request_signing.max_skew_seconds", 300)
+	viper.SetDefault("security.credential_encryption.enabled", false)
+	viper.SetDefault("security.credential_encryption.key_id", "default")
+	viper.SetDefault("security.credential_encryption.key", "")
+	viper.SetDefault("security.credential_encryption.key_command", "")
+	viper.SetDefault("security.pii_redaction.enabled", false)
+	viper.SetDefault("security.pii_redaction.builtin_patterns", []string{"email", "phone", "credit_card"})
+	viper.SetDefault("security.pii_redaction.patterns", []string{})
+	viper.SetDefault("security.pii_redaction.fields", []string{})
+	viper.SetDefault("security.pii_redaction.replacement", piiredact.DefaultReplacement)
+	viper.SetDefault("security.pii_redaction.redact_logs", true)
+	viper.SetDefault("security.gateway_jwt.enabled", false)
+	viper.SetDefault("security.gateway_jwt.issuer", "")
+	viper.SetDefault("security.gateway_jwt.audience", "")
+	viper.SetDefault("security.gateway_jwt.hmac_secret", "")
+	viper.SetDefault("security.gateway_jwt.public_key_file", "")
+	viper.SetDefault("security.gateway_jwt.max_ttl_minutes", 60)
+	viper.SetDefault("security.gateway_jwt.leeway_seconds", 30)
 
 	// Billing
 	viper.SetDefault("billing.circuit_breaker.enabled", true)
 	viper.SetDefault("billing.circuit_breaker.failure_threshold", 5)
 	viper.SetDefault("billing.circuit_breaker.reset_timeout_seconds", 30)
 	viper.SetDefault("billing.circuit_breaker.half_open_requests", 3)
+	viper.SetDefault("billing.spend_cap.enabled", false)
+	viper.SetDefault("billing.spend_cap.daily_soft_usd", 0)
+	viper.SetDefault("billing.spend_cap.daily_hard_usd", 0)
+	viper.SetDefault("billing.spend_cap.monthly_soft_usd", 0)
+	viper.SetDefault("billing.spend_cap.monthly_hard_usd", 0)
+	viper.SetDefault("billing.quota_rollover.enabled", false)
+	viper.SetDefault("billing.quota_rollover.max_percent", 100)
+	viper.SetDefault("billing.quota_rollover.expiry_days", 30)
 
 	// Turnstile
 	viper.SetDefault("turnstile.required", false)
@@ -806,6 +1652,45 @@ func setDefaults() {
 	viper.SetDefault("ops.metrics_collector_cache.enabled", true)
 	// TTL should be slightly larger than collection interval (1m) to maximize cross-replica cache hits.
 	viper.SetDefault("ops.metrics_collector_cache.ttl", 65*time.Second)
+	viper.SetDefault("ops.clickhouse.enabled", false)
+	viper.SetDefault("ops.clickhouse.endpoint", "")
+	viper.SetDefault("ops.clickhouse.database", "sub2api")
+	viper.SetDefault("ops.clickhouse.table", "ops_request_events")
+	viper.SetDefault("ops.clickhouse.username", "default")
+	viper.SetDefault("ops.clickhouse.password", "")
+	viper.SetDefault("ops.clickhouse.batch_size", 1000)
+	viper.SetDefault("ops.clickhouse.flush_interval", 5*time.Second)
+	viper.SetDefault("ops.clickhouse.queue_size", 20000)
+	viper.SetDefault("ops.clickhouse.timeout", 10*time.Second)
+	viper.SetDefault("ops.clickhouse.ttl_days", 365)
+	viper.SetDefault("ops.log_shipping.loki.enabled", false)
+	viper.SetDefault("ops.log_shipping.loki.endpoint", "")
+	viper.SetDefault("ops.log_shipping.loki.tenant_id", "")
+	viper.SetDefault("ops.log_shipping.loki.username", "")
+	viper.SetDefault("ops.log_shipping.loki.password", "")
+	viper.SetDefault("ops.log_shipping.loki.labels", map[string]string{"job": "sub2api"})
+	viper.SetDefault("ops.log_shipping.elasticsearch.enabled", false)
+	viper.SetDefault("ops.log_shipping.elasticsearch.endpoint", "")
+	viper.SetDefault("ops.log_shipping.elasticsearch.index_prefix", "sub2api-ops")
+	viper.SetDefault("ops.log_shipping.elasticsearch.username", "")
+	viper.SetDefault("ops.log_shipping.elasticsearch.password", "")
+	viper.SetDefault("ops.log_shipping.elasticsearch.api_key", "")
+	viper.SetDefault("ops.log_shipping.batch_size", 500)
+	viper.SetDefault("ops.log_shipping.flush_interval", 2*time.Second)
+	viper.SetDefault("ops.log_shipping.queue_size", 10000)
+	viper.SetDefault("ops.log_shipping.timeout", 10*time.Second)
+	viper.SetDefault("ops.log_shipping.max_retries", 3)
+	viper.SetDefault("ops.log_shipping.retry_backoff", time.Second)
+	viper.SetDefault("ops.body_capture.max_bytes", 1024*1024)
+	viper.SetDefault("ops.body_capture.hash_only_multimodal_bytes", 256*1024)
+	viper.SetDefault("ops.reference_diff.enabled", false)
+	viper.SetDefault("ops.reference_diff.reference_account_ids", []int64{})
+	viper.SetDefault("ops.reference_diff.max_response_bytes", 256*1024)
+	viper.SetDefault("ops.reference_diff.min_interval", 10*time.Second)
+	viper.SetDefault("ops.slow_request.enabled", false)
+	viper.SetDefault("ops.slow_request.duration_threshold_ms", 60000)
+	viper.SetDefault("ops.slow_request.stream_duration_threshold_ms", 0)
+	viper.SetDefault("ops.slow_request.ttft_threshold_ms", 15000)
 
 	// JWT
 	viper.SetDefault("jwt.secret", "")
@@ -829,6 +1714,10 @@ func setDefaults() {
 
 	// RateLimit
 	viper.SetDefault("rate_limit.overload_cooldown_minutes", 10)
+	viper.SetDefault("rate_limit.adaptive_cooldown.enabled", true)
+	viper.SetDefault("rate_limit.adaptive_cooldown.multiplier", 2.0)
+	viper.SetDefault("rate_limit.adaptive_cooldown.max_seconds", 3600)
+	viper.SetDefault("rate_limit.adaptive_cooldown.reset_window_seconds", 1800)
 
 	// Pricing - 从 price-mirror 分支同步，该分支维护了 sha256 哈希文件用于增量更新检查
 	viper.SetDefault("pricing.remote_url", "https://raw.githubusercontent.com/Wei-Shaw/claude-relay-service/price-mirror/model_prices_and_context_window.json")
@@ -837,6 +1726,9 @@ func setDefaults() {
 	viper.SetDefault("pricing.fallback_file", "./resources/model-pricing/model_prices_and_context_window.json")
 	viper.SetDefault("pricing.update_interval_hours", 24)
 	viper.SetDefault("pricing.hash_check_interval_minutes", 10)
+	viper.SetDefault("pricing.tool_prices.web_search_per_call", 0.01)
+	viper.SetDefault("pricing.tool_prices.code_interpreter_per_session", 0.03)
+	viper.SetDefault("pricing.tool_prices.image_generation_per_image", 0.04)
 
 	// Timezone (default to Asia/Shanghai for Chinese users)
 	viper.SetDefault("timezone", "Asia/Shanghai")
@@ -849,6 +1741,11 @@ func setDefaults() {
 	viper.SetDefault("api_key_auth_cache.jitter_percent", 10)
 	viper.SetDefault("api_key_auth_cache.singleflight", true)
 
+	// API Key rotation
+	viper.SetDefault("api_key_rotation.default_grace_minutes", 1440)
+	viper.SetDefault("api_key_rotation.max_grace_minutes", 10080)
+	viper.SetDefault("api_key_rotation.expiry_warning_hours", 72)
+
 	// Dashboard cache
 	viper.SetDefault("dashboard_cache.enabled", true)
 	viper.SetDefault("dashboard_cache.key_prefix", "sub2api:")
@@ -874,14 +1771,64 @@ func setDefaults() {
 	viper.SetDefault("usage_cleanup.worker_interval_seconds", 10)
 	viper.SetDefault("usage_cleanup.task_timeout_seconds", 1800)
 
+	// Usage webhook
+	viper.SetDefault("usage_webhook.enabled", true)
+	viper.SetDefault("usage_webhook.workers", 4)
+	viper.SetDefault("usage_webhook.queue_size", 10000)
+	viper.SetDefault("usage_webhook.timeout", 5*time.Second)
+	viper.SetDefault("usage_webhook.max_retries", 2)
+	viper.SetDefault("usage_webhook.allow_private_hosts", false)
+	viper.SetDefault("usage_webhook.allow_insecure_http", false)
+
+	// Usage retry queue
+	viper.SetDefault("usage_retry_queue.enabled", true)
+	viper.SetDefault("usage_retry_queue.max_length", 1000000)
+	viper.SetDefault("usage_retry_queue.replay_interval", 30*time.Second)
+	viper.SetDefault("usage_retry_queue.batch_size", 100)
+	viper.SetDefault("usage_retry_queue.max_attempts", 50)
+
+	// Audit log
+	viper.SetDefault("audit_log.enabled", false)
+	viper.SetDefault("audit_log.capture_bodies", false)
+	viper.SetDefault("audit_log.max_body_bytes", 64*1024)
+	viper.SetDefault("audit_log.redact_prompts", true)
+	viper.SetDefault("audit_log.redact_images", true)
+	viper.SetDefault("audit_log.redact_fields", []string{})
+	viper.SetDefault("audit_log.retention_days", 30)
+	viper.SetDefault("audit_log.workers", 2)
+	viper.SetDefault("audit_log.queue_size", 10000)
+	viper.SetDefault("audit_log.encryption.enabled", false)
+	viper.SetDefault("audit_log.encryption.provider", "local")
+	viper.SetDefault("audit_log.encryption.key_id", "default")
+	viper.SetDefault("audit_log.encryption.key", "")
+	viper.SetDefault("audit_log.encryption.wrap_command", "")
+	viper.SetDefault("audit_log.encryption.unwrap_command", "")
+	viper.SetDefault("audit_log.encryption.data_key_ttl_minutes", 1440)
+
+	// Trash
+	viper.SetDefault("trash.retention_days", 30)
+
+	// Stripe
+	viper.SetDefault("stripe.enabled", false)
+	viper.SetDefault("stripe.api_base_url", "https://api.stripe.com")
+	viper.SetDefault("stripe.webhook_tolerance", 5*time.Minute)
+	viper.SetDefault("stripe.metered.report_interval", time.Hour)
+	viper.SetDefault("stripe.metered.value_scale", 100)
+
 	// Gateway
 	viper.SetDefault("gateway.response_header_timeout", 600) // 600秒(10分钟)等待上游响应头，LLM高负载时可能排队较久
 	viper.SetDefault("gateway.log_upstream_error_body", true)
 	viper.SetDefault("gateway.log_upstream_error_body_max_bytes", 2048)
 	viper.SetDefault("gateway.inject_beta_for_apikey", false)
 	viper.SetDefault("gateway.failover_on_400", false)
+	viper.SetDefault("gateway.maintenance_message", "Service is under maintenance, please try again later")
 	viper.SetDefault("gateway.max_account_switches", 10)
 	viper.SetDefault("gateway.max_account_switches_gemini", 3)
+	viper.SetDefault("gateway.failover_classes.interactive.max_account_switches", 2)
+	viper.SetDefault("gateway.failover_classes.interactive.attempt_timeout_seconds", 30)
+	viper.SetDefault("gateway.failover_classes.interactive.hedge_after_ms", 0)
+	viper.SetDefault("gateway.failover_classes.batch.max_account_switches", 20)
+	viper.SetDefault("gateway.failover_classes.batch.attempt_timeout_seconds", 0)
 	viper.SetDefault("gateway.antigravity_fallback_cooldown_minutes", 1)
 	viper.SetDefault("gateway.max_body_size", int64(100*1024*1024))
 	viper.SetDefault("gateway.connection_pool_isolation", ConnectionPoolIsolationAccountProxy)
@@ -892,17 +1839,82 @@ func setDefaults() {
 	viper.SetDefault("gateway.idle_conn_timeout_seconds", 90) // 空闲连接超时（秒）
 	viper.SetDefault("gateway.max_upstream_clients", 5000)
 	viper.SetDefault("gateway.client_idle_ttl_seconds", 900)
+	viper.SetDefault("gateway.upstream_transport.http2", true)
+	viper.SetDefault("gateway.upstream_transport.http2_ping_interval_seconds", 30)
+	viper.SetDefault("gateway.upstream_transport.tls_session_cache_size", 64)
+	viper.SetDefault("gateway.upstream_transport.dial_timeout_seconds", 10)
+	viper.SetDefault("gateway.upstream_transport.tls_handshake_timeout_seconds", 10)
+	viper.SetDefault("gateway.upstream_transport.keep_alive_seconds", 30)
+	viper.SetDefault("gateway.account_worker_pool.enabled", false)
+	viper.SetDefault("gateway.account_worker_pool.size", 0)
+	viper.SetDefault("gateway.account_worker_pool.default_size", 32)
+	viper.SetDefault("gateway.account_worker_pool.queue_timeout", 5*time.Second)
+	viper.SetDefault("gateway.memory_guard.enabled", false)
+	viper.SetDefault("gateway.memory_guard.global_budget_mb", 1024)
+	viper.SetDefault("gateway.memory_guard.per_stream_budget_mb", 64)
+	viper.SetDefault("gateway.memory_guard.reject_threshold", 0.85)
+	viper.SetDefault("gateway.memory_guard.retry_after_seconds", 5)
+	viper.SetDefault("gateway.cost_preflight.enabled", false)
+	viper.SetDefault("gateway.cost_preflight.default_max_output_tokens", 4096)
+	viper.SetDefault("gateway.stream_abuse.enabled", false)
+	viper.SetDefault("gateway.stream_abuse.window_minutes", 10)
+	viper.SetDefault("gateway.stream_abuse.min_requests", 20)
+	viper.SetDefault("gateway.stream_abuse.early_abandon_seconds", 2)
+	viper.SetDefault("gateway.stream_abuse.early_abandon_rate", 0.6)
+	viper.SetDefault("gateway.stream_abuse.cancel_rate", 0.9)
+	viper.SetDefault("gateway.stream_abuse.flag_ttl_minutes", 60)
+	viper.SetDefault("gateway.usage_anomaly.enabled", false)
+	viper.SetDefault("gateway.usage_anomaly.baseline_hours", 168)
+	viper.SetDefault("gateway.usage_anomaly.learning_hours", 72)
+	viper.SetDefault("gateway.usage_anomaly.spike_factor", 10.0)
+	viper.SetDefault("gateway.usage_anomaly.min_requests_per_hour", 200)
+	viper.SetDefault("gateway.usage_anomaly.country_header", "CF-IPCountry")
+	viper.SetDefault("gateway.usage_anomaly.detect_new_models", false)
+	viper.SetDefault("gateway.usage_anomaly.action", UsageAnomalyActionAlert)
+	viper.SetDefault("gateway.usage_anomaly.flag_ttl_minutes", 1440)
+	viper.SetDefault("gateway.ip_ban.enabled", false)
+	viper.SetDefault("gateway.ip_ban.requests_per_minute", 600)
+	viper.SetDefault("gateway.ip_ban.max_auth_failures", 20)
+	viper.SetDefault("gateway.ip_ban.failure_window_seconds", 300)
+	viper.SetDefault("gateway.ip_ban.ban_minutes", 30)
+	viper.SetDefault("gateway.ip_ban.whitelist", []string{})
 	viper.SetDefault("gateway.concurrency_slot_ttl_minutes", 30) // 并发槽位过期时间（支持超长请求）
 	viper.SetDefault("gateway.stream_data_interval_timeout", 180)
+	viper.SetDefault("gateway.phase_timeouts.connect_seconds", 10)
+	viper.SetDefault("gateway.phase_timeouts.first_token_seconds", 0)
+	viper.SetDefault("gateway.phase_timeouts.chunk_gap_seconds", 0)
+	viper.SetDefault("gateway.phase_timeouts.total_seconds", 0)
+	viper.SetDefault("gateway.response_continuity.enabled", true)
+	viper.SetDefault("gateway.response_continuity.ttl_seconds", 86400)
+	viper.SetDefault("gateway.response_continuity.store_history", false)
+	viper.SetDefault("gateway.response_continuity.max_history_bytes", 2*1024*1024)
+	viper.SetDefault("gateway.response_cache.enabled", false)
+	viper.SetDefault("gateway.response_cache.ttl_seconds", 3600)
+	viper.SetDefault("gateway.response_cache.max_response_bytes", 1024*1024)
+	viper.SetDefault("gateway.token_counting.local_count_tokens", false)
+	viper.SetDefault("gateway.token_counting.context_trim_max_tokens", 0)
 	viper.SetDefault("gateway.stream_keepalive_interval", 10)
 	viper.SetDefault("gateway.max_line_size", 40*1024*1024)
+	viper.SetDefault("gateway.model_discovery.enabled", false)
+	viper.SetDefault("gateway.model_discovery.interval", 6*time.Hour)
+	viper.SetDefault("gateway.model_discovery.cache_ttl", 10*time.Minute)
+	viper.SetDefault("gateway.region_sync.enabled", false)
+	viper.SetDefault("gateway.region_sync.queue_size", 10000)
+	viper.SetDefault("gateway.region_sync.timeout", 2*time.Second)
+	viper.SetDefault("gateway.region_sync.lookup_peers_on_miss", false)
 	viper.SetDefault("gateway.scheduling.sticky_session_max_waiting", 3)
 	viper.SetDefault("gateway.scheduling.sticky_session_wait_timeout", 120*time.Second)
 	viper.SetDefault("gateway.scheduling.fallback_wait_timeout", 30*time.Second)
 	viper.SetDefault("gateway.scheduling.fallback_max_waiting", 100)
 	viper.SetDefault("gateway.scheduling.fallback_selection_mode", "last_used")
 	viper.SetDefault("gateway.scheduling.load_batch_enabled", true)
+	viper.SetDefault("gateway.scheduling.session_consistent_hash", true)
+	viper.SetDefault("gateway.scheduling.prompt_cache_affinity", true)
+	viper.SetDefault("gateway.scheduling.prompt_cache_warm_ttl", 10*time.Minute)
+	viper.SetDefault("gateway.scheduling.prompt_cache_max_load_gap", 40)
 	viper.SetDefault("gateway.scheduling.slot_cleanup_interval", 30*time.Second)
+	viper.SetDefault("gateway.scheduling.rebind_sessions_on_account_removal", false)
+	viper.SetDefault("gateway.scheduling.reservation_ttl", 30*time.Second)
 	viper.SetDefault("gateway.scheduling.db_fallback_enabled", true)
 	viper.SetDefault("gateway.scheduling.db_fallback_timeout_seconds", 0)
 	viper.SetDefault("gateway.scheduling.db_fallback_max_qps", 0)
@@ -915,6 +1927,8 @@ func setDefaults() {
 	// TLS指纹伪装配置（默认关闭，需要账号级别单独启用）
 	viper.SetDefault("gateway.tls_fingerprint.enabled", true)
 	viper.SetDefault("concurrency.ping_interval", 10)
+	viper.SetDefault("concurrency.reconcile_on_startup", true)
+	viper.SetDefault("concurrency.instance_id", "")
 
 	// TokenRefresh
 	viper.SetDefault("token_refresh.enabled", true)
@@ -958,9 +1972,60 @@ func (c *Config) Validate() error {
 	if c.JWT.RefreshWindowMinutes < 0 {
 		return fmt.Errorf("jwt.refresh_window_minutes must be non-negative")
 	}
+	if c.Pricing.ToolPrices.WebSearchPerCall < 0 || c.Pricing.ToolPrices.CodeInterpreterPerSession < 0 || c.Pricing.ToolPrices.ImageGenerationPerImage < 0 {
+		return fmt.Errorf("pricing.tool_prices must be non-negative")
+	}
+	if ac := c.RateLimit.AdaptiveCooldown; ac.Enabled {
+		if ac.Multiplier < 1 {
+			return fmt.Errorf("rate_limit.adaptive_cooldown.multiplier must be >= 1")
+		}
+		if ac.MaxSeconds < 0 || ac.ResetWindowSeconds < 0 {
+			return fmt.Errorf("rate_limit.adaptive_cooldown values must be non-negative")
+		}
+	}
+	for class, budget := range c.Gateway.FailoverClasses {
+		if budget.MaxAccountSwitches < 0 || budget.AttemptTimeoutSeconds < 0 || budget.HedgeAfterMs < 0 {
+			return fmt.Errorf("gateway.failover_classes.%s values must be non-negative", class)
+		}
+	}
 	if c.Security.CSP.Enabled && strings.TrimSpace(c.Security.CSP.Policy) == "" {
 		return fmt.Errorf("security.csp.policy is required when CSP is enabled")
 	}
+	if c.Security.RequestSigning.MaxSkewSeconds <= 0 || c.Security.RequestSigning.MaxSkewSeconds > 3600 {
+		return fmt.Errorf("security.request_signing.max_skew_seconds must be between 1 and 3600")
+	}
+	if c.Security.CredentialEncryption.Enabled {
+		enc := c.Security.CredentialEncryption
+		if strings.TrimSpace(enc.KeyID) == "" || strings.Contains(enc.KeyID, ":") {
+			return fmt.Errorf("security.credential_encryption.key_id must be non-empty and must not contain ':'")
+		}
+		hasKey := strings.TrimSpace(enc.Key) != ""
+		hasCommand := strings.TrimSpace(enc.KeyCommand) != ""
+		if hasKey == hasCommand {
+			return fmt.Errorf("security.credential_encryption requires exactly one of key or key_command")
+		}
+	}
+	if c.Security.PIIRedaction.Enabled {
+		pii := c.Security.PIIRedaction
+		if _, err := piiredact.New(piiredact.Options{BuiltinPatterns: pii.BuiltinPatterns, Patterns: pii.Patterns, Fields: pii.Fields}); err != nil {
+			return fmt.Errorf("security.pii_redaction: %w (builtin patterns: %s)", err, strings.Join(piiredact.BuiltinPatternNames(), ", "))
+		}
+	}
+	if c.Security.GatewayJWT.Enabled {
+		gatewayJWT := c.Security.GatewayJWT
+		if strings.TrimSpace(gatewayJWT.Issuer) == "" {
+			return fmt.Errorf("security.gateway_jwt.issuer is required when gateway JWT auth is enabled")
+		}
+		if strings.TrimSpace(gatewayJWT.HMACSecret) == "" && strings.TrimSpace(gatewayJWT.PublicKeyFile) == "" {
+			return fmt.Errorf("security.gateway_jwt requires hmac_secret or public_key_file")
+		}
+		if gatewayJWT.MaxTTLMinutes <= 0 || gatewayJWT.MaxTTLMinutes > 1440 {
+			return fmt.Errorf("security.gateway_jwt.max_ttl_minutes must be between 1 and 1440")
+		}
+		if gatewayJWT.LeewaySeconds < 0 || gatewayJWT.LeewaySeconds > 300 {
+			return fmt.Errorf("security.gateway_jwt.leeway_seconds must be between 0 and 300")
+		}
+	}
 	if c.LinuxDo.Enabled {
 		if strings.TrimSpace(c.LinuxDo.ClientID) == "" {
 			return fmt.Errorf("linuxdo_connect.client_id is required when linuxdo_connect.enabled=true")
@@ -1027,6 +2092,18 @@ func (c *Config) Validate() error {
 			return fmt.Errorf("billing.circuit_breaker.half_open_requests must be positive")
 		}
 	}
+	spendCap := c.Billing.SpendCap
+	if spendCap.DailySoftUSD < 0 || spendCap.DailyHardUSD < 0 || spendCap.MonthlySoftUSD < 0 || spendCap.MonthlyHardUSD < 0 {
+		return fmt.Errorf("billing.spend_cap limits must be non-negative")
+	}
+	if rollover := c.Billing.QuotaRollover; rollover.Enabled {
+		if rollover.MaxPercent <= 0 {
+			return fmt.Errorf("billing.quota_rollover.max_percent must be positive")
+		}
+		if rollover.ExpiryDays <= 0 {
+			return fmt.Errorf("billing.quota_rollover.expiry_days must be positive")
+		}
+	}
 	if c.Database.MaxOpenConns <= 0 {
 		return fmt.Errorf("database.max_open_conns must be positive")
 	}
@@ -1060,6 +2137,15 @@ func (c *Config) Validate() error {
 	if c.Redis.MinIdleConns > c.Redis.PoolSize {
 		return fmt.Errorf("redis.min_idle_conns cannot exceed redis.pool_size")
 	}
+	if c.APIKeyRotate.MaxGraceMinutes < 0 || c.APIKeyRotate.DefaultGraceMinutes < 0 {
+		return fmt.Errorf("api_key_rotation grace minutes must be non-negative")
+	}
+	if c.APIKeyRotate.DefaultGraceMinutes > c.APIKeyRotate.MaxGraceMinutes {
+		return fmt.Errorf("api_key_rotation.default_grace_minutes cannot exceed max_grace_minutes")
+	}
+	if c.APIKeyRotate.ExpiryWarningHours < 0 {
+		return fmt.Errorf("api_key_rotation.expiry_warning_hours must be non-negative")
+	}
 	if c.Dashboard.Enabled {
 		if c.Dashboard.StatsFreshTTLSeconds <= 0 {
 			return fmt.Errorf("dashboard_cache.stats_fresh_ttl_seconds must be positive")
@@ -1159,6 +2245,170 @@ func (c *Config) Validate() error {
 			return fmt.Errorf("usage_cleanup.task_timeout_seconds must be non-negative")
 		}
 	}
+	if c.UsageWebhook.Enabled {
+		if c.UsageWebhook.Workers <= 0 || c.UsageWebhook.QueueSize <= 0 {
+			return fmt.Errorf("usage_webhook.workers and queue_size must be positive")
+		}
+		if c.UsageWebhook.Timeout <= 0 {
+			return fmt.Errorf("usage_webhook.timeout must be positive")
+		}
+		if c.UsageWebhook.MaxRetries < 0 {
+			return fmt.Errorf("usage_webhook.max_retries must be non-negative")
+		}
+	}
+	if c.UsageRetry.Enabled {
+		if c.UsageRetry.MaxLength <= 0 || c.UsageRetry.BatchSize <= 0 {
+			return fmt.Errorf("usage_retry_queue.max_length and batch_size must be positive")
+		}
+		if c.UsageRetry.ReplayInterval <= 0 {
+			return fmt.Errorf("usage_retry_queue.replay_interval must be positive")
+		}
+		if c.UsageRetry.MaxAttempts <= 0 {
+			return fmt.Errorf("usage_retry_queue.max_attempts must be positive")
+		}
+	}
+	if c.AuditLog.Enabled {
+		if c.AuditLog.Workers <= 0 || c.AuditLog.QueueSize <= 0 {
+			return fmt.Errorf("audit_log.workers and queue_size must be positive")
+		}
+		if c.AuditLog.CaptureBodies && c.AuditLog.MaxBodyBytes <= 0 {
+			return fmt.Errorf("audit_log.max_body_bytes must be positive when capture_bodies=true")
+		}
+		if c.AuditLog.RetentionDays < 0 {
+			return fmt.Errorf("audit_log.retention_days must be non-negative")
+		}
+		if enc := c.AuditLog.Encryption; enc.Enabled {
+			if strings.TrimSpace(enc.KeyID) == "" {
+				return fmt.Errorf("audit_log.encryption.key_id is required")
+			}
+			if enc.DataKeyTTLMinutes < 0 {
+				return fmt.Errorf("audit_log.encryption.data_key_ttl_minutes must be non-negative")
+			}
+			switch enc.Provider {
+			case "local":
+				if strings.TrimSpace(enc.Key) == "" {
+					return fmt.Errorf("audit_log.encryption.key is required when provider=local")
+				}
+			case "command":
+				if strings.TrimSpace(enc.WrapCommand) == "" || strings.TrimSpace(enc.UnwrapCommand) == "" {
+					return fmt.Errorf("audit_log.encryption.wrap_command and unwrap_command are required when provider=command")
+				}
+			default:
+				return fmt.Errorf("audit_log.encryption.provider must be one of: local, command")
+			}
+		}
+	}
+	if c.Trash.RetentionDays < 0 {
+		return fmt.Errorf("trash.retention_days must be non-negative")
+	}
+	if c.Stripe.Enabled {
+		if strings.TrimSpace(c.Stripe.WebhookSecret) == "" {
+			return fmt.Errorf("stripe.webhook_secret is required when stripe.enabled=true")
+		}
+		if c.Stripe.WebhookTolerance <= 0 {
+			return fmt.Errorf("stripe.webhook_tolerance must be positive")
+		}
+		for i, plan := range c.Stripe.Plans {
+			if strings.TrimSpace(plan.PriceID) == "" || plan.GroupID <= 0 {
+				return fmt.Errorf("stripe.plans[%d] requires price_id and a positive group_id", i)
+			}
+		}
+		if len(c.Stripe.Metered.PriceIDs) > 0 {
+			if strings.TrimSpace(c.Stripe.SecretKey) == "" {
+				return fmt.Errorf("stripe.secret_key is required when stripe.metered.price_ids is set")
+			}
+			if strings.TrimSpace(c.Stripe.Metered.MeterEventName) == "" {
+				return fmt.Errorf("stripe.metered.meter_event_name is required when stripe.metered.price_ids is set")
+			}
+			if c.Stripe.Metered.ReportInterval <= 0 {
+				return fmt.Errorf("stripe.metered.report_interval must be positive")
+			}
+			if c.Stripe.Metered.ValueScale <= 0 {
+				return fmt.Errorf("stripe.metered.value_scale must be positive")
+			}
+		}
+		if _, err := url.Parse(c.Stripe.APIBaseURL); err != nil || strings.TrimSpace(c.Stripe.APIBaseURL) == "" {
+			return fmt.Errorf("stripe.api_base_url is invalid")
+		}
+	}
+	if c.Server.ShutdownTimeout < 0 {
+		return fmt.Errorf("server.shutdown_timeout must be non-negative")
+	}
+	if c.Server.TLS.Enabled {
+		if strings.TrimSpace(c.Server.TLS.CertFile) == "" || strings.TrimSpace(c.Server.TLS.KeyFile) == "" {
+			return fmt.Errorf("server.tls.cert_file and server.tls.key_file are required when server.tls.enabled=true")
+		}
+	}
+	if c.Frontend.AssetCacheMaxAge < 0 {
+		return fmt.Errorf("frontend.asset_cache_max_age must be non-negative")
+	}
+	switch strings.ToLower(strings.TrimSpace(c.Log.Format)) {
+	case "", "text", "json":
+	default:
+		return fmt.Errorf("log.format must be one of: text, json")
+	}
+	switch strings.ToLower(strings.TrimSpace(c.Log.Level)) {
+	case "", "debug", "info", "warn", "warning", "error":
+	default:
+		return fmt.Errorf("log.level must be one of: debug, info, warn, error")
+	}
+	if c.Tracing.Enabled {
+		if strings.TrimSpace(c.Tracing.Endpoint) == "" {
+			return fmt.Errorf("tracing.endpoint is required when tracing is enabled")
+		}
+		if c.Tracing.SampleRatio <= 0 || c.Tracing.SampleRatio > 1 {
+			return fmt.Errorf("tracing.sample_ratio must be within (0, 1]")
+		}
+	}
+	listenerNames := make(map[string]struct{}, len(c.Server.Listeners))
+	for i, l := range c.Server.Listeners {
+		if strings.TrimSpace(l.Name) == "" {
+			return fmt.Errorf("server.listeners[%d].name is required", i)
+		}
+		if _, dup := listenerNames[l.Name]; dup {
+			return fmt.Errorf("server.listeners[%d].name %q is duplicated", i, l.Name)
+		}
+		listenerNames[l.Name] = struct{}{}
+		switch l.Network {
+		case "", "tcp", "unix":
+		default:
+			return fmt.Errorf("server.listeners[%d].network must be tcp or unix", i)
+		}
+		if strings.TrimSpace(l.Address) == "" {
+			return fmt.Errorf("server.listeners[%d].address is required", i)
+		}
+		if l.SocketMode != "" {
+			if l.Network != "unix" {
+				return fmt.Errorf("server.listeners[%d].socket_mode is only valid for unix listeners", i)
+			}
+			if _, err := strconv.ParseUint(l.SocketMode, 8, 32); err != nil {
+				return fmt.Errorf("server.listeners[%d].socket_mode must be an octal file mode", i)
+			}
+		}
+		for _, route := range l.Routes {
+			switch route {
+			case ListenerRouteAll, ListenerRouteGateway, ListenerRouteAdmin, ListenerRouteUser:
+			default:
+				return fmt.Errorf("server.listeners[%d].routes contains unknown route %q", i, route)
+			}
+		}
+		if l.TLS && !c.Server.TLS.Enabled {
+			return fmt.Errorf("server.listeners[%d].tls requires server.tls.enabled=true", i)
+		}
+	}
+	if c.Server.TLS.ClientAuth.Enabled {
+		if !c.Server.TLS.Enabled || strings.TrimSpace(c.Server.TLS.ClientCAFile) == "" {
+			return fmt.Errorf("server.tls.client_auth requires server.tls.enabled=true and server.tls.client_ca_file")
+		}
+		for i, m := range c.Server.TLS.ClientAuth.Mappings {
+			if strings.TrimSpace(m.APIKey) == "" {
+				return fmt.Errorf("server.tls.client_auth.mappings[%d].api_key is required", i)
+			}
+			if strings.TrimSpace(m.Subject) == "" && strings.TrimSpace(m.SAN) == "" {
+				return fmt.Errorf("server.tls.client_auth.mappings[%d] must set subject or san", i)
+			}
+		}
+	}
 	if c.Gateway.MaxBodySize <= 0 {
 		return fmt.Errorf("gateway.max_body_size must be positive")
 	}
@@ -1191,6 +2441,108 @@ func (c *Config) Validate() error {
 	if c.Gateway.ClientIdleTTLSeconds <= 0 {
 		return fmt.Errorf("gateway.client_idle_ttl_seconds must be positive")
 	}
+	if c.Gateway.UpstreamTransport.HTTP2PingIntervalSeconds < 0 {
+		return fmt.Errorf("gateway.upstream_transport.http2_ping_interval_seconds must be non-negative")
+	}
+	if c.Gateway.UpstreamTransport.TLSSessionCacheSize < 0 {
+		return fmt.Errorf("gateway.upstream_transport.tls_session_cache_size must be non-negative")
+	}
+	if c.Gateway.UpstreamTransport.DialTimeoutSeconds <= 0 {
+		return fmt.Errorf("gateway.upstream_transport.dial_timeout_seconds must be positive")
+	}
+	if c.Gateway.UpstreamTransport.TLSHandshakeTimeoutSeconds <= 0 {
+		return fmt.Errorf("gateway.upstream_transport.tls_handshake_timeout_seconds must be positive")
+	}
+	if c.Gateway.UpstreamTransport.KeepAliveSeconds <= 0 {
+		return fmt.Errorf("gateway.upstream_transport.keep_alive_seconds must be positive")
+	}
+	if c.Gateway.AccountWorkerPool.Enabled {
+		if c.Gateway.AccountWorkerPool.Size < 0 {
+			return fmt.Errorf("gateway.account_worker_pool.size must be non-negative")
+		}
+		if c.Gateway.AccountWorkerPool.DefaultSize <= 0 {
+			return fmt.Errorf("gateway.account_worker_pool.default_size must be positive")
+		}
+		if c.Gateway.AccountWorkerPool.QueueTimeout < 0 {
+			return fmt.Errorf("gateway.account_worker_pool.queue_timeout must be non-negative")
+		}
+	}
+	if c.Gateway.MemoryGuard.Enabled {
+		if c.Gateway.MemoryGuard.GlobalBudgetMB <= 0 {
+			return fmt.Errorf("gateway.memory_guard.global_budget_mb must be positive")
+		}
+		if c.Gateway.MemoryGuard.PerStreamBudgetMB <= 0 || c.Gateway.MemoryGuard.PerStreamBudgetMB > c.Gateway.MemoryGuard.GlobalBudgetMB {
+			return fmt.Errorf("gateway.memory_guard.per_stream_budget_mb must be positive and <= global_budget_mb")
+		}
+		if c.Gateway.MemoryGuard.RejectThreshold <= 0 || c.Gateway.MemoryGuard.RejectThreshold > 1 {
+			return fmt.Errorf("gateway.memory_guard.reject_threshold must be within (0, 1]")
+		}
+		if c.Gateway.MemoryGuard.RetryAfterSeconds < 0 {
+			return fmt.Errorf("gateway.memory_guard.retry_after_seconds must be non-negative")
+		}
+	}
+	if c.Gateway.CostPreflight.DefaultMaxOutputTokens < 0 {
+		return fmt.Errorf("gateway.cost_preflight.default_max_output_tokens must be non-negative")
+	}
+	if c.Gateway.StreamAbuse.Enabled {
+		sa := c.Gateway.StreamAbuse
+		if sa.WindowMinutes <= 0 || sa.WindowMinutes > 60 {
+			return fmt.Errorf("gateway.stream_abuse.window_minutes must be between 1 and 60")
+		}
+		if sa.MinRequests <= 0 {
+			return fmt.Errorf("gateway.stream_abuse.min_requests must be positive")
+		}
+		if sa.EarlyAbandonSeconds <= 0 {
+			return fmt.Errorf("gateway.stream_abuse.early_abandon_seconds must be positive")
+		}
+		if sa.EarlyAbandonRate <= 0 || sa.EarlyAbandonRate > 1 || sa.CancelRate <= 0 || sa.CancelRate > 1 {
+			return fmt.Errorf("gateway.stream_abuse.early_abandon_rate and cancel_rate must be within (0, 1]")
+		}
+		if sa.FlagTTLMinutes <= 0 {
+			return fmt.Errorf("gateway.stream_abuse.flag_ttl_minutes must be positive")
+		}
+	}
+	if c.Gateway.UsageAnomaly.Enabled {
+		ua := c.Gateway.UsageAnomaly
+		if ua.BaselineHours <= 0 || ua.BaselineHours > 720 {
+			return fmt.Errorf("gateway.usage_anomaly.baseline_hours must be between 1 and 720")
+		}
+		if ua.LearningHours < 0 {
+			return fmt.Errorf("gateway.usage_anomaly.learning_hours must be non-negative")
+		}
+		if ua.SpikeFactor <= 1 {
+			return fmt.Errorf("gateway.usage_anomaly.spike_factor must be greater than 1")
+		}
+		if ua.MinRequestsPerHour <= 0 {
+			return fmt.Errorf("gateway.usage_anomaly.min_requests_per_hour must be positive")
+		}
+		if ua.Action != UsageAnomalyActionAlert && ua.Action != UsageAnomalyActionSuspend {
+			return fmt.Errorf("gateway.usage_anomaly.action must be one of: %s, %s", UsageAnomalyActionAlert, UsageAnomalyActionSuspend)
+		}
+		if ua.FlagTTLMinutes <= 0 {
+			return fmt.Errorf("gateway.usage_anomaly.flag_ttl_minutes must be positive")
+		}
+	}
+	if c.Gateway.IPBan.Enabled {
+		ib := c.Gateway.IPBan
+		if ib.RequestsPerMinute < 0 {
+			return fmt.Errorf("gateway.ip_ban.requests_per_minute must be non-negative")
+		}
+		if ib.MaxAuthFailures <= 0 {
+			return fmt.Errorf("gateway.ip_ban.max_auth_failures must be positive")
+		}
+		if ib.FailureWindowSeconds <= 0 || ib.FailureWindowSeconds > 86400 {
+			return fmt.Errorf("gateway.ip_ban.failure_window_seconds must be between 1 and 86400")
+		}
+		if ib.BanMinutes <= 0 {
+			return fmt.Errorf("gateway.ip_ban.ban_minutes must be positive")
+		}
+		for _, entry := range ib.Whitelist {
+			if !isValidIPOrCIDR(entry) {
+				return fmt.Errorf("gateway.ip_ban.whitelist contains invalid entry %q", entry)
+			}
+		}
+	}
 	if c.Gateway.ConcurrencySlotTTLMinutes <= 0 {
 		return fmt.Errorf("gateway.concurrency_slot_ttl_minutes must be positive")
 	}
@@ -1204,6 +2556,31 @@ func (c *Config) Validate() error {
 	if c.Gateway.StreamKeepaliveInterval < 0 {
 		return fmt.Errorf("gateway.stream_keepalive_interval must be non-negative")
 	}
+	if pt := c.Gateway.PhaseTimeouts; pt.ConnectSeconds < 0 || pt.FirstTokenSeconds < 0 || pt.ChunkGapSeconds < 0 || pt.TotalSeconds < 0 {
+		return fmt.Errorf("gateway.phase_timeouts values must be non-negative")
+	}
+	if pt := c.Gateway.PhaseTimeouts; pt.TotalSeconds > 0 && pt.FirstTokenSeconds > pt.TotalSeconds {
+		return fmt.Errorf("gateway.phase_timeouts.first_token_seconds must not exceed total_seconds")
+	}
+	if rc := c.Gateway.ResponseContinuity; rc.Enabled {
+		if rc.TTLSeconds <= 0 {
+			return fmt.Errorf("gateway.response_continuity.ttl_seconds must be positive")
+		}
+		if rc.MaxHistoryBytes < 0 {
+			return fmt.Errorf("gateway.response_continuity.max_history_bytes must be non-negative")
+		}
+	}
+	if rc := c.Gateway.ResponseCache; rc.Enabled {
+		if rc.TTLSeconds <= 0 {
+			return fmt.Errorf("gateway.response_cache.ttl_seconds must be positive")
+		}
+		if rc.MaxResponseBytes <= 0 {
+			return fmt.Errorf("gateway.response_cache.max_response_bytes must be positive")
+		}
+	}
+	if c.Gateway.TokenCounting.ContextTrimMaxTokens < 0 {
+		return fmt.Errorf("gateway.token_counting.context_trim_max_tokens must be non-negative")
+	}
 	if c.Gateway.StreamKeepaliveInterval != 0 &&
 		(c.Gateway.StreamKeepaliveInterval < 5 || c.Gateway.StreamKeepaliveInterval > 30) {
 		return fmt.Errorf("gateway.stream_keepalive_interval must be 0 or between 5-30 seconds")
@@ -1229,6 +2606,20 @@ func (c *Config) Validate() error {
 	if c.Gateway.Scheduling.SlotCleanupInterval < 0 {
 		return fmt.Errorf("gateway.scheduling.slot_cleanup_interval must be non-negative")
 	}
+	if c.Gateway.Scheduling.ReservationTTL < 0 {
+		return fmt.Errorf("gateway.scheduling.reservation_ttl must be non-negative")
+	}
+	if c.Gateway.Scheduling.ReservationTTL > 0 && c.Gateway.Scheduling.ReservationTTL < time.Second {
+		return fmt.Errorf("gateway.scheduling.reservation_ttl must be at least 1s when enabled")
+	}
+	if c.Gateway.Scheduling.PromptCacheAffinity {
+		if c.Gateway.Scheduling.PromptCacheWarmTTL <= 0 {
+			return fmt.Errorf("gateway.scheduling.prompt_cache_warm_ttl must be positive")
+		}
+		if c.Gateway.Scheduling.PromptCacheMaxLoadGap < 0 || c.Gateway.Scheduling.PromptCacheMaxLoadGap > 100 {
+			return fmt.Errorf("gateway.scheduling.prompt_cache_max_load_gap must be between 0-100")
+		}
+	}
 	if c.Gateway.Scheduling.DbFallbackTimeoutSeconds < 0 {
 		return fmt.Errorf("gateway.scheduling.db_fallback_timeout_seconds must be non-negative")
 	}
@@ -1273,9 +2664,103 @@ func (c *Config) Validate() error {
 	if c.Ops.Cleanup.Enabled && strings.TrimSpace(c.Ops.Cleanup.Schedule) == "" {
 		return fmt.Errorf("ops.cleanup.schedule is required when ops.cleanup.enabled=true")
 	}
+	if c.Gateway.RegionSync.Enabled {
+		if len(c.Gateway.RegionSync.Peers) == 0 {
+			return fmt.Errorf("gateway.region_sync.peers is required when gateway.region_sync.enabled=true")
+		}
+		for i, peer := range c.Gateway.RegionSync.Peers {
+			if strings.TrimSpace(peer.Host) == "" || peer.Port <= 0 {
+				return fmt.Errorf("gateway.region_sync.peers[%d] requires host and port", i)
+			}
+		}
+		if c.Gateway.RegionSync.QueueSize <= 0 || c.Gateway.RegionSync.Timeout <= 0 {
+			return fmt.Errorf("gateway.region_sync.queue_size and timeout must be positive")
+		}
+	}
+	if c.Ops.ClickHouse.Enabled {
+		if strings.TrimSpace(c.Ops.ClickHouse.Endpoint) == "" {
+			return fmt.Errorf("ops.clickhouse.endpoint is required when ops.clickhouse.enabled=true")
+		}
+		if !clickHouseIdentifierPattern.MatchString(c.Ops.ClickHouse.Database) {
+			return fmt.Errorf("ops.clickhouse.database must match %s", clickHouseIdentifierPattern.String())
+		}
+		if !clickHouseIdentifierPattern.MatchString(c.Ops.ClickHouse.Table) {
+			return fmt.Errorf("ops.clickhouse.table must match %s", clickHouseIdentifierPattern.String())
+		}
+		if c.Ops.ClickHouse.BatchSize <= 0 || c.Ops.ClickHouse.QueueSize <= 0 {
+			return fmt.Errorf("ops.clickhouse.batch_size and queue_size must be positive")
+		}
+		if c.Ops.ClickHouse.FlushInterval <= 0 || c.Ops.ClickHouse.Timeout <= 0 {
+			return fmt.Errorf("ops.clickhouse.flush_interval and timeout must be positive")
+		}
+		if c.Ops.ClickHouse.TTLDays < 0 {
+			return fmt.Errorf("ops.clickhouse.ttl_days must be non-negative")
+		}
+	}
+	if ls := c.Ops.LogShipping; ls.Loki.Enabled || ls.Elasticsearch.Enabled {
+		if ls.Loki.Enabled && strings.TrimSpace(ls.Loki.Endpoint) == "" {
+			return fmt.Errorf("ops.log_shipping.loki.endpoint is required when ops.log_shipping.loki.enabled=true")
+		}
+		for name := range ls.Loki.Labels {
+			if !lokiLabelNamePattern.MatchString(name) {
+				return fmt.Errorf("ops.log_shipping.loki.labels: invalid label name %q", name)
+			}
+		}
+		if ls.Elasticsearch.Enabled {
+			if strings.TrimSpace(ls.Elasticsearch.Endpoint) == "" {
+				return fmt.Errorf("ops.log_shipping.elasticsearch.endpoint is required when ops.log_shipping.elasticsearch.enabled=true")
+			}
+			if !elasticsearchIndexPattern.MatchString(ls.Elasticsearch.IndexPrefix) {
+				return fmt.Errorf("ops.log_shipping.elasticsearch.index_prefix must match %s", elasticsearchIndexPattern.String())
+			}
+		}
+		if ls.BatchSize <= 0 || ls.QueueSize <= 0 {
+			return fmt.Errorf("ops.log_shipping.batch_size and queue_size must be positive")
+		}
+		if ls.FlushInterval <= 0 || ls.Timeout <= 0 {
+			return fmt.Errorf("ops.log_shipping.flush_interval and timeout must be positive")
+		}
+		if ls.MaxRetries < 0 || ls.RetryBackoff < 0 {
+			return fmt.Errorf("ops.log_shipping.max_retries and retry_backoff must be non-negative")
+		}
+	}
+	if c.Ops.BodyCapture.MaxBytes < 0 || c.Ops.BodyCapture.HashOnlyMultimodalBytes < 0 {
+		return fmt.Errorf("ops.body_capture.max_bytes and hash_only_multimodal_bytes must be non-negative")
+	}
+	for i, route := range c.Ops.BodyCapture.Routes {
+		if !strings.HasPrefix(route.PathPrefix, "/") {
+			return fmt.Errorf("ops.body_capture.routes[%d].path_prefix must start with /", i)
+		}
+		if route.MaxBytes < 0 {
+			return fmt.Errorf("ops.body_capture.routes[%d].max_bytes must be non-negative", i)
+		}
+	}
+	if c.Ops.ReferenceDiff.Enabled {
+		if len(c.Ops.ReferenceDiff.ReferenceAccountIDs) == 0 {
+			return fmt.Errorf("ops.reference_diff.reference_account_ids is required when ops.reference_diff.enabled=true")
+		}
+		if c.Ops.ReferenceDiff.MaxResponseBytes <= 0 {
+			return fmt.Errorf("ops.reference_diff.max_response_bytes must be positive")
+		}
+		if c.Ops.ReferenceDiff.MinInterval < 0 {
+			return fmt.Errorf("ops.reference_diff.min_interval must be non-negative")
+		}
+	}
+	if c.Ops.SlowRequest.Enabled {
+		sr := c.Ops.SlowRequest
+		if sr.DurationThresholdMs < 0 || sr.StreamDurationThresholdMs < 0 || sr.TTFTThresholdMs < 0 {
+			return fmt.Errorf("ops.slow_request thresholds must be non-negative")
+		}
+		if sr.DurationThresholdMs == 0 && sr.StreamDurationThresholdMs == 0 && sr.TTFTThresholdMs == 0 {
+			return fmt.Errorf("ops.slow_request requires at least one positive threshold when enabled")
+		}
+	}
 	if c.Concurrency.PingInterval < 5 || c.Concurrency.PingInterval > 30 {
 		return fmt.Errorf("concurrency.ping_interval must be between 5-30 seconds")
 	}
+	if strings.Contains(c.Concurrency.InstanceID, ":") {
+		return fmt.Errorf("concurrency.instance_id must not contain ':'")
+	}
 	return nil
 }
 
@@ -1423,3 +2908,12 @@ func warnIfInsecureURL(field, raw string) {
 		log.Printf("Warning: %s uses http scheme; use https in production to avoid token leakage.", field)
 	}
 }
+
+// isValidIPOrCIDR 校验单个 IP 或 CIDR
+func isValidIPOrCIDR(s string) bool {
+	if strings.Contains(s, "/") {
+		_, _, err := net.ParseCIDR(s)
+		return err == nil
+	}
+	return net.ParseIP(s) != nil
+}
@@ -1,6 +1,7 @@
 package config
 
 import (
+	"fmt"
 	"strings"
 	"testing"
 	"time"
@@ -759,6 +760,16 @@ func TestValidateConfigErrors(t *testing.T) {
 			mutate:  func(c *Config) { c.Gateway.ClientIdleTTLSeconds = 0 },
 			wantErr: "gateway.client_idle_ttl_seconds",
 		},
+		{
+			name:    "gateway upstream transport dial timeout",
+			mutate:  func(c *Config) { c.Gateway.UpstreamTransport.DialTimeoutSeconds = 0 },
+			wantErr: "gateway.upstream_transport.dial_timeout_seconds",
+		},
+		{
+			name:    "gateway upstream transport tls session cache",
+			mutate:  func(c *Config) { c.Gateway.UpstreamTransport.TLSSessionCacheSize = -1 },
+			wantErr: "gateway.upstream_transport.tls_session_cache_size",
+		},
 		{
 			name:    "gateway concurrency slot ttl",
 			mutate:  func(c *Config) { c.Gateway.ConcurrencySlotTTLMinutes = 0 },
@@ -837,6 +848,34 @@ func TestValidateConfigErrors(t *testing.T) {
 			mutate:  func(c *Config) { c.Ops.Cleanup.MinuteMetricsRetentionDays = -1 },
 			wantErr: "ops.cleanup.minute_metrics_retention_days",
 		},
+		{
+			name: "audit log encryption provider",
+			mutate: func(c *Config) {
+				c.AuditLog.Enabled = true
+				c.AuditLog.Encryption.Enabled = true
+				c.AuditLog.Encryption.Provider = "vault"
+			},
+			wantErr: "audit_log.encryption.provider",
+		},
+		{
+			name: "audit log encryption local key",
+			mutate: func(c *Config) {
+				c.AuditLog.Enabled = true
+				c.AuditLog.Encryption.Enabled = true
+				c.AuditLog.Encryption.Key = ""
+			},
+			wantErr: "audit_log.encryption.key is required",
+		},
+		{
+			name: "audit log encryption commands",
+			mutate: func(c *Config) {
+				c.AuditLog.Enabled = true
+				c.AuditLog.Encryption.Enabled = true
+				c.AuditLog.Encryption.Provider = "command"
+				c.AuditLog.Encryption.UnwrapCommand = "kms-unwrap"
+			},
+			wantErr: "audit_log.encryption.wrap_command",
+		},
 	}
 
 	for _, tt := range cases {
@@ -850,3 +889,90 @@ func TestValidateConfigErrors(t *testing.T) {
 		})
 	}
 }
+
+func TestReloadAppliesOnlyReloadableFields(t *testing.T) {
+	viper.Reset()
+	cfg, err := Load()
+	if err != nil {
+		t.Fatalf("Load() error: %v", err)
+	}
+	jwtSecret := cfg.JWT.Secret
+	serverPort := cfg.Server.Port
+	startupSwitches := cfg.Gateway.MaxAccountSwitches
+
+	t.Setenv("GATEWAY_MAX_ACCOUNT_SWITCHES", "7")
+	t.Setenv("CONCURRENCY_PING_INTERVAL", "20")
+	t.Setenv("SERVER_PORT", "9999")
+
+	changed, err := Reload(cfg)
+	if err != nil {
+		t.Fatalf("Reload() error: %v", err)
+	}
+	if strings.Join(changed, ",") != "concurrency.ping_interval,gateway.max_account_switches" {
+		t.Fatalf("changed = %v", changed)
+	}
+	live := cfg.Live()
+	if live.Gateway.MaxAccountSwitches != 7 || live.Concurrency.PingInterval != 20 {
+		t.Fatalf("reloadable fields not applied: switches=%d ping=%d", live.Gateway.MaxAccountSwitches, live.Concurrency.PingInterval)
+	}
+	if live.Server.Port != serverPort || live.JWT.Secret != jwtSecret {
+		t.Fatalf("non-reloadable fields changed: port=%d", live.Server.Port)
+	}
+	// 已发布的配置不可变：重载只发布新快照，不修改启动时的配置
+	if cfg.Gateway.MaxAccountSwitches != startupSwitches {
+		t.Fatalf("startup config mutated: switches=%d", cfg.Gateway.MaxAccountSwitches)
+	}
+	if live.Live() != live {
+		t.Fatalf("snapshot should resolve to the latest snapshot")
+	}
+
+	// 无效配置不应发布新快照
+	t.Setenv("CONCURRENCY_PING_INTERVAL", "1")
+	if _, err := Reload(cfg); err == nil {
+		t.Fatalf("Reload() with invalid ping interval should fail")
+	}
+	if cfg.Live() != live || live.Concurrency.PingInterval != 20 {
+		t.Fatalf("PingInterval = %d after failed reload, want 20", cfg.Live().Concurrency.PingInterval)
+	}
+}
+
+func TestReloadConcurrentReaders(t *testing.T) {
+	viper.Reset()
+	cfg, err := Load()
+	if err != nil {
+		t.Fatalf("Load() error: %v", err)
+	}
+
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		for i := 0; i < 1000; i++ {
+			_ = cfg.Live().Gateway.MaintenanceMessage
+			_ = cfg.Live().Gateway.PhaseTimeouts
+		}
+	}()
+	for i := 0; i < 20; i++ {
+		t.Setenv("GATEWAY_MAINTENANCE_MESSAGE", fmt.Sprintf("maintenance %d", i))
+		if _, err := Reload(cfg); err != nil {
+			t.Fatalf("Reload() error: %v", err)
+		}
+	}
+	<-done
+	if got := cfg.Live().Gateway.MaintenanceMessage; got != "maintenance 19" {
+		t.Fatalf("MaintenanceMessage = %q", got)
+	}
+}
+
+func TestLiveWithoutLoad(t *testing.T) {
+	cfg := &Config{}
+	if cfg.Live() != cfg {
+		t.Fatalf("Live() on a config not created by Load should return itself")
+	}
+	var nilCfg *Config
+	if nilCfg.Live() != nil {
+		t.Fatalf("Live() on nil config should return nil")
+	}
+	if _, err := Reload(cfg); err == nil {
+		t.Fatalf("Reload() on a config not created by Load should fail")
+	}
+}
@@ -0,0 +1,107 @@
+package config
+
+import (
+	"fmt"
+	"reflect"
+	"sync"
+)
+
+// reloadMu 串行化配置热重载
+var reloadMu sync.Mutex
+
+// reloadableField 可热重载的配置项：apply 将 src 的值写入 dst，返回是否发生变化
+type reloadableField struct {
+	key   string
+	apply func(dst, src *Config) bool
+}
+
+func reloadable[T comparable](key string, get func(*Config) *T) reloadableField {
+	return reloadableField{key: key, apply: func(dst, src *Config) bool {
+		d, s := get(dst), get(src)
+		if *d == *s {
+			return false
+		}
+		*d = *s
+		return true
+	}}
+}
+
+// reloadableFields 支持热重载的配置项。
+// 这些配置均在每次请求时通过 Live 读取（或由订阅方在重载后刷新），修改后对新请求生效，进行中的流式请求不受影响。
+// 监听地址、数据库/Redis、连接池与请求体大小限制等在启动时绑定的配置仍需重启。
+var reloadableFields = []reloadableField{
+	reloadable("concurrency.ping_interval", func(c *Config) *int { return &c.Concurrency.PingInterval }),
+	reloadable("gateway.max_account_switches", func(c *Config) *int { return &c.Gateway.MaxAccountSwitches }),
+	reloadable("gateway.max_account_switches_gemini", func(c *Config) *int { return &c.Gateway.MaxAccountSwitchesGemini }),
+	reloadable("gateway.stream_data_interval_timeout", func(c *Config) *int { return &c.Gateway.StreamDataIntervalTimeout }),
+	reloadable("gateway.stream_keepalive_interval", func(c *Config) *int { return &c.Gateway.StreamKeepaliveInterval }),
+	reloadable("gateway.phase_timeouts", func(c *Config) *GatewayPhaseTimeoutsConfig { return &c.Gateway.PhaseTimeouts }),
+	reloadable("gateway.log_upstream_error_body", func(c *Config) *bool { return &c.Gateway.LogUpstreamErrorBody }),
+	reloadable("gateway.log_upstream_error_body_max_bytes", func(c *Config) *int { return &c.Gateway.LogUpstreamErrorBodyMaxBytes }),
+	reloadable("gateway.inject_beta_for_apikey", func(c *Config) *bool { return &c.Gateway.InjectBetaForAPIKey }),
+	reloadable("gateway.failover_on_400", func(c *Config) *bool { return &c.Gateway.FailoverOn400 }),
+	reloadable("gateway.maintenance_message", func(c *Config) *string { return &c.Gateway.MaintenanceMessage }),
+	{key: "gateway.failover_classes", apply: func(dst, src *Config) bool {
+		if reflect.DeepEqual(dst.Gateway.FailoverClasses, src.Gateway.FailoverClasses) {
+			return false
+		}
+		dst.Gateway.FailoverClasses = src.Gateway.FailoverClasses
+		return true
+	}},
+}
+
+// Live 返回当前生效的配置快照。热重载不会修改已发布的配置，而是发布一份新的快照，
+// 因此读取可热重载配置项时应通过 Live 获取，同一请求内取一次即可得到一致的值。
+// 未经 Load 创建的配置（如测试中直接构造）返回自身。
+func (c *Config) Live() *Config {
+	if c == nil || c.live == nil {
+		return c
+	}
+	if snapshot := c.live.Load(); snapshot != nil {
+		return snapshot
+	}
+	return c
+}
+
+// Reload 重新读取配置文件与环境变量，校验通过后基于当前快照生成新快照（仅替换可热重载的配置项）并发布，
+// 返回发生变化的配置键。校验失败时不发布新快照；cfg 本身始终不被修改。
+func Reload(cfg *Config) ([]string, error) {
+	reloadMu.Lock()
+	defer reloadMu.Unlock()
+
+	if cfg == nil || cfg.live == nil {
+		return nil, fmt.Errorf("config was not created by Load")
+	}
+	current := cfg.Live()
+	next, err := readConfig()
+	if err != nil {
+		return nil, err
+	}
+	// 自动生成的密钥不参与重载，沿用当前值以通过校验
+	if next.JWT.Secret == "" {
+		next.JWT.Secret = current.JWT.Secret
+	}
+	if next.Totp.EncryptionKey == "" {
+		next.Totp.EncryptionKey = current.Totp.EncryptionKey
+	}
+	if err := next.Validate(); err != nil {
+		return nil, fmt.Errorf("validate config error: %w", err)
+	}
+
+	snapshot := *current
+	changed := applyReloadable(&snapshot, next)
+	if len(changed) > 0 {
+		cfg.live.Store(&snapshot)
+	}
+	return changed, nil
+}
+
+func applyReloadable(dst, src *Config) []string {
+	changed := make([]string, 0)
+	for _, f := range reloadableFields {
+		if f.apply(dst, src) {
+			changed = append(changed, f.key)
+		}
+	}
+	return changed
+}
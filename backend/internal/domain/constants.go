@@ -5,15 +5,22 @@ const (
 	StatusActive   = "active"
 	StatusDisabled = "disabled"
 	StatusError    = "error"
-	StatusUnused   = "unused"
-	StatusUsed     = "used"
-	StatusExpired  = "expired"
+	// StatusMaintenance 维护中：账号不参与调度、分组拒绝新请求，进行中的请求不受影响
+	StatusMaintenance = "maintenance"
+	StatusUnused      = "unused"
+	StatusUsed        = "used"
+	StatusExpired     = "expired"
 )
 
 // Role constants
 const (
-	RoleAdmin = "admin"
+	RoleAdmin = "admin" // 超级管理员
 	RoleUser  = "user"
+
+	// 受限管理角色（可登录管理后台，权限见 service.AdminRolePermissions）
+	RoleOperator      = "operator"
+	RoleBillingViewer = "billing_viewer"
+	RoleSupport       = "support"
 )
 
 // Platform constants
@@ -0,0 +1,49 @@
+package domain
+
+import "strings"
+
+// ModelAccessPolicy 分组的模型访问策略：限制该分组（及其 API Key）可以请求的模型。
+// 模型模式支持末尾 * 通配（如 claude-opus-*），比较时忽略大小写。
+type ModelAccessPolicy struct {
+	// Allowed 允许请求的模型模式，为空表示不限制
+	Allowed []string `json:"allowed,omitempty"`
+	// Denied 禁止请求的模型模式，优先于 Allowed
+	Denied []string `json:"denied,omitempty"`
+}
+
+// IsEmpty 是否未配置任何模型访问限制
+func (p ModelAccessPolicy) IsEmpty() bool {
+	return len(p.Allowed) == 0 && len(p.Denied) == 0
+}
+
+// Permits 判断模型是否允许被请求：命中 Denied 拒绝；配置了 Allowed 时必须命中其中之一
+func (p ModelAccessPolicy) Permits(model string) bool {
+	model = strings.ToLower(strings.TrimSpace(model))
+	for _, pattern := range p.Denied {
+		if MatchModelPattern(pattern, model) {
+			return false
+		}
+	}
+	if len(p.Allowed) == 0 {
+		return true
+	}
+	for _, pattern := range p.Allowed {
+		if MatchModelPattern(pattern, model) {
+			return true
+		}
+	}
+	return false
+}
+
+// MatchModelPattern 模型模式匹配：精确匹配或末尾 * 前缀匹配（忽略大小写）
+func MatchModelPattern(pattern, model string) bool {
+	pattern = strings.ToLower(strings.TrimSpace(pattern))
+	model = strings.ToLower(strings.TrimSpace(model))
+	if pattern == "" {
+		return false
+	}
+	if prefix, ok := strings.CutSuffix(pattern, "*"); ok {
+		return strings.HasPrefix(model, prefix)
+	}
+	return pattern == model
+}
@@ -0,0 +1,31 @@
+package domain
+
+const (
+	// ModelParamPolicyActionClamp 将超出范围的参数收敛到边界值（默认）
+	ModelParamPolicyActionClamp = "clamp"
+	// ModelParamPolicyActionReject 参数超出范围时直接返回 400
+	ModelParamPolicyActionReject = "reject"
+)
+
+// ModelParamPolicy 分组级别的模型参数约束（采样参数、输出上限与推理强度默认值）。
+// 部分上游模型不接受 temperature>1，或不允许 temperature 与 top_p 同时出现，
+// 在网关侧提前处理可以避免上游报错并在故障转移耗尽后以 502 的形式返回给用户。
+type ModelParamPolicy struct {
+	Temperature *ParamRange `json:"temperature,omitempty"`
+	TopP        *ParamRange `json:"top_p,omitempty"`
+	// TemperatureTopPExclusive 表示 temperature 与 top_p 不可同时设置。
+	// clamp 模式下保留 temperature 并移除 top_p；reject 模式下返回 400。
+	TemperatureTopPExclusive bool `json:"temperature_top_p_exclusive,omitempty"`
+	// MaxOutputTokens 输出 token 上限：超过时收敛（reject 模式返回 400），未携带时注入该值
+	MaxOutputTokens *int `json:"max_output_tokens,omitempty"`
+	// ReasoningEffortDefault 未指定推理强度时注入的默认值（仅 OpenAI 协议，如 low/medium/high）
+	ReasoningEffortDefault string `json:"reasoning_effort_default,omitempty"`
+	// Action 超出约束时的处理方式：clamp（默认）| reject
+	Action string `json:"action,omitempty"`
+}
+
+// ParamRange 数值参数的闭区间，Min/Max 为空表示不限制。
+type ParamRange struct {
+	Min *float64 `json:"min,omitempty"`
+	Max *float64 `json:"max,omitempty"`
+}
@@ -0,0 +1,15 @@
+package domain
+
+// PromptTemplate 分组提示词模板：在转发前用前缀/后缀包裹用户最新一条消息（如合规声明、角色设定）。
+// 支持 {{date}}、{{user_id}} 等模板变量，由网关在请求时渲染。
+type PromptTemplate struct {
+	// Prefix 插入到用户最新消息之前的文本
+	Prefix string `json:"prefix,omitempty"`
+	// Suffix 追加到用户最新消息之后的文本
+	Suffix string `json:"suffix,omitempty"`
+}
+
+// IsEmpty 是否未配置
+func (t PromptTemplate) IsEmpty() bool {
+	return t.Prefix == "" && t.Suffix == ""
+}
@@ -0,0 +1,33 @@
+package domain
+
+import "strings"
+
+// RegionPolicy 账号区域约束，可配置在分组与 API Key 上（API Key 配置优先）。
+// 账号通过 extra.region 声明所在区域，比较时忽略大小写。
+type RegionPolicy struct {
+	// Required 仅允许调度到该区域的账号（数据驻留场景），无可用账号时直接失败
+	Required string `json:"required,omitempty"`
+	// Preferred 优先调度到该区域的账号，该区域无可调度账号时回退到全部账号
+	Preferred string `json:"preferred,omitempty"`
+}
+
+// IsEmpty 是否未配置任何区域约束
+func (p RegionPolicy) IsEmpty() bool {
+	return strings.TrimSpace(p.Required) == "" && strings.TrimSpace(p.Preferred) == ""
+}
+
+// Merge 以 override 中非空的字段覆盖当前策略
+func (p RegionPolicy) Merge(override RegionPolicy) RegionPolicy {
+	if strings.TrimSpace(override.Required) != "" {
+		p.Required = override.Required
+	}
+	if strings.TrimSpace(override.Preferred) != "" {
+		p.Preferred = override.Preferred
+	}
+	return p
+}
+
+// MatchRegion 判断账号区域是否与目标区域一致（忽略大小写与首尾空白）
+func MatchRegion(accountRegion, region string) bool {
+	return strings.EqualFold(strings.TrimSpace(accountRegion), strings.TrimSpace(region))
+}
@@ -0,0 +1,13 @@
+package domain
+
+// RequestSigning API Key 的请求签名配置：启用后该 Key 的每个网关请求都必须携带时间戳与
+// 以共享密钥计算的 HMAC-SHA256 签名（覆盖时间戳与请求体），在 Bearer Key 之上防止截获的请求被重放。
+type RequestSigning struct {
+	// Secret 签名密钥，为空表示未启用
+	Secret string `json:"secret,omitempty"`
+}
+
+// IsEnabled 是否启用请求签名
+func (s RequestSigning) IsEnabled() bool {
+	return s.Secret != ""
+}
@@ -0,0 +1,45 @@
+package domain
+
+// RetryPolicy 分组的上游失败重试（故障转移）策略：决定是否切换账号、哪些状态码切换账号重试、
+// 两次尝试之间的退避、整个请求的重试时间预算，以及最终错误是否原样返回上游响应。
+// 未配置的字段沿用网关全局行为。
+type RetryPolicy struct {
+	// RetryStatusCodes 触发切换账号重试的上游状态码，为空表示所有可故障转移的错误都重试
+	RetryStatusCodes []int `json:"retry_status_codes,omitempty"`
+	// MaxAttempts 最大账号切换次数，0 表示沿用全局 max_account_switches
+	MaxAttempts int `json:"max_attempts,omitempty"`
+	// InitialBackoffMs 首次重试前的退避时间（毫秒），0 表示不退避
+	InitialBackoffMs int `json:"initial_backoff_ms,omitempty"`
+	// MaxBackoffMs 单次退避上限（毫秒），0 表示不设上限
+	MaxBackoffMs int `json:"max_backoff_ms,omitempty"`
+	// BackoffMultiplier 指数退避倍数，0 表示默认 2
+	BackoffMultiplier float64 `json:"backoff_multiplier,omitempty"`
+	// Jitter 退避抖动比例（0~1），实际退避在 [d*(1-jitter), d] 之间随机
+	Jitter float64 `json:"jitter,omitempty"`
+	// TotalBudgetMs 从首次尝试开始的重试总时间预算（毫秒），超出后不再重试，0 表示不限制
+	TotalBudgetMs int `json:"total_budget_ms,omitempty"`
+	// DisableFailover 关闭故障转移：上游失败后不切换账号、不在同账号重试、不发起对冲请求，直接返回错误
+	DisableFailover bool `json:"disable_failover,omitempty"`
+	// PassthroughUpstreamError 最终返回给客户端的错误使用上游原始状态码与响应体，而不是网关映射后的错误
+	PassthroughUpstreamError bool `json:"passthrough_upstream_error,omitempty"`
+}
+
+// IsEmpty 是否未配置任何重试策略
+func (p RetryPolicy) IsEmpty() bool {
+	return len(p.RetryStatusCodes) == 0 && p.MaxAttempts == 0 && p.InitialBackoffMs == 0 &&
+		p.MaxBackoffMs == 0 && p.BackoffMultiplier == 0 && p.Jitter == 0 && p.TotalBudgetMs == 0 &&
+		!p.DisableFailover && !p.PassthroughUpstreamError
+}
+
+// RetriesStatus 判断该上游状态码是否允许重试
+func (p RetryPolicy) RetriesStatus(statusCode int) bool {
+	if len(p.RetryStatusCodes) == 0 {
+		return true
+	}
+	for _, code := range p.RetryStatusCodes {
+		if code == statusCode {
+			return true
+		}
+	}
+	return false
+}
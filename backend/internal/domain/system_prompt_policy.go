@@ -0,0 +1,35 @@
+package domain
+
+// 系统提示词注入模式
+const (
+	// SystemPromptModeOff 不注入
+	SystemPromptModeOff = "off"
+	// SystemPromptModeInjectIfEmpty 仅在请求未携带系统提示词时注入
+	SystemPromptModeInjectIfEmpty = "inject_if_empty"
+	// SystemPromptModeAlwaysPrepend 始终在请求的系统提示词之前追加
+	SystemPromptModeAlwaysPrepend = "always_prepend"
+	// SystemPromptModeAlwaysReplace 始终替换请求的系统提示词
+	SystemPromptModeAlwaysReplace = "always_replace"
+)
+
+// SystemPromptPolicy 系统提示词注入策略，可配置在分组与 API Key 上（API Key 配置优先）。
+// 注入位置随协议而定：OpenAI Responses 的 instructions、Anthropic 的 system、Gemini 的 systemInstruction。
+type SystemPromptPolicy struct {
+	// Mode 注入模式，为空表示未配置（沿用上级配置或默认行为）
+	Mode string `json:"mode,omitempty"`
+	// Prompt 注入的提示词；为空时 OpenAI 协议使用内置的 OpenCode 指令，其他协议不注入
+	Prompt string `json:"prompt,omitempty"`
+}
+
+// IsEmpty 是否未配置
+func (p SystemPromptPolicy) IsEmpty() bool {
+	return p.Mode == ""
+}
+
+// Merge 以 override 覆盖当前策略（override 配置了模式时整体生效）
+func (p SystemPromptPolicy) Merge(override SystemPromptPolicy) SystemPromptPolicy {
+	if !override.IsEmpty() {
+		return override
+	}
+	return p
+}
@@ -0,0 +1,17 @@
+package domain
+
+// TokenQuota API Key 的每日/每月硬配额（token 数与请求数），按 Timezone 的自然日/自然月重置。
+// 各项为 0 表示不限制。
+type TokenQuota struct {
+	DailyTokens     int64 `json:"daily_tokens,omitempty"`
+	MonthlyTokens   int64 `json:"monthly_tokens,omitempty"`
+	DailyRequests   int64 `json:"daily_requests,omitempty"`
+	MonthlyRequests int64 `json:"monthly_requests,omitempty"`
+	// Timezone 计算自然日/月使用的 IANA 时区（如 Asia/Shanghai），为空使用服务器时区
+	Timezone string `json:"timezone,omitempty"`
+}
+
+// IsEmpty 是否未配置任何限制
+func (q TokenQuota) IsEmpty() bool {
+	return q.DailyTokens <= 0 && q.MonthlyTokens <= 0 && q.DailyRequests <= 0 && q.MonthlyRequests <= 0
+}
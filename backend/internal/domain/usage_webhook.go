@@ -0,0 +1,15 @@
+package domain
+
+// UsageWebhook API Key 的用量回调配置：该 Key 的每个请求完成后，网关向 URL 推送请求摘要
+// （请求 ID、模型、token、费用、结束原因、耗时），供下游做成本追踪而无需轮询用量接口。
+type UsageWebhook struct {
+	// URL 回调地址，为空表示未启用
+	URL string `json:"url,omitempty"`
+	// Secret 签名密钥，回调请求携带 HMAC-SHA256 签名供接收方校验
+	Secret string `json:"secret,omitempty"`
+}
+
+// IsEmpty 是否未配置
+func (w UsageWebhook) IsEmpty() bool {
+	return w.URL == ""
+}
@@ -0,0 +1,60 @@
+package admin
+
+import (
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/Wei-Shaw/sub2api/internal/handler/dto"
+	"github.com/Wei-Shaw/sub2api/internal/pkg/response"
+	"github.com/Wei-Shaw/sub2api/internal/service"
+	"github.com/gin-gonic/gin"
+)
+
+// RotateCredentialsRequest represents a zero-downtime credential rotation request
+type RotateCredentialsRequest struct {
+	// Credentials are merged into the existing credentials (e.g. api_key, access_token, refresh_token, base_url)
+	Credentials map[string]any `json:"credentials" binding:"required"`
+	// Validate sends a probe request with the new credentials before committing
+	Validate bool   `json:"validate"`
+	ModelID  string `json:"model_id"`
+}
+
+// RotateCredentials atomically replaces an account's credentials without disabling it first.
+// In-flight requests finish with the old credentials; new requests pick up the new ones.
+// POST /api/v1/admin/accounts/:id/rotate-credentials
+func (h *AccountHandler) RotateCredentials(c *gin.Context) {
+	if h.credentialRotationService == nil {
+		response.Error(c, http.StatusServiceUnavailable, "Credential rotation not available")
+		return
+	}
+	accountID, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		response.BadRequest(c, "Invalid account ID")
+		return
+	}
+
+	var req RotateCredentialsRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		response.BadRequest(c, "Invalid request: "+err.Error())
+		return
+	}
+
+	result, err := h.credentialRotationService.RotateCredentials(c.Request.Context(), accountID, &service.RotateAccountCredentialsInput{
+		Credentials: req.Credentials,
+		Validate:    req.Validate,
+		ModelID:     strings.TrimSpace(req.ModelID),
+	})
+	if err != nil {
+		response.ErrorFrom(c, err)
+		return
+	}
+
+	response.Success(c, gin.H{
+		"account":          dto.AccountFromService(result.Account),
+		"rotated_keys":     result.RotatedKeys,
+		"validated":        result.Validated,
+		"probe_latency_ms": result.ProbeLatencyMs,
+		"token_version":    result.TokenVersion,
+	})
+}
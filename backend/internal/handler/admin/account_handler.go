@@ -47,6 +47,9 @@ type AccountHandler struct {
 	crsSyncService          *service.CRSSyncService
 	sessionLimitCache       service.SessionLimitCache
 	tokenCacheInvalidator   service.TokenCacheInvalidator
+	modelDiscoveryService   *service.AccountModelDiscoveryService
+
+	credentialRotationService *service.AccountCredentialRotationService
 }
 
 // NewAccountHandler creates a new admin account handler
@@ -63,6 +66,8 @@ func NewAccountHandler(
 	crsSyncService *service.CRSSyncService,
 	sessionLimitCache service.SessionLimitCache,
 	tokenCacheInvalidator service.TokenCacheInvalidator,
+	modelDiscoveryService *service.AccountModelDiscoveryService,
+	credentialRotationService *service.AccountCredentialRotationService,
 ) *AccountHandler {
 	return &AccountHandler{
 		adminService:            adminService,
@@ -77,6 +82,9 @@ func NewAccountHandler(
 		crsSyncService:          crsSyncService,
 		sessionLimitCache:       sessionLimitCache,
 		tokenCacheInvalidator:   tokenCacheInvalidator,
+		modelDiscoveryService:   modelDiscoveryService,
+
+		credentialRotationService: credentialRotationService,
 	}
 }
 
@@ -84,6 +92,7 @@ func NewAccountHandler(
 type CreateAccountRequest struct {
 	Name                    string         `json:"name" binding:"required"`
 	Notes                   *string        `json:"notes"`
+	Labels                  []string       `json:"labels"`
 	Platform                string         `json:"platform" binding:"required"`
 	Type                    string         `json:"type" binding:"required,oneof=oauth setup-token apikey upstream"`
 	Credentials             map[string]any `json:"credentials" binding:"required"`
@@ -103,6 +112,7 @@ type CreateAccountRequest struct {
 type UpdateAccountRequest struct {
 	Name                    string         `json:"name"`
 	Notes                   *string        `json:"notes"`
+	Labels                  *[]string      `json:"labels"`
 	Type                    string         `json:"type" binding:"omitempty,oneof=oauth setup-token apikey upstream"`
 	Credentials             map[string]any `json:"credentials"`
 	Extra                   map[string]any `json:"extra"`
@@ -118,8 +128,12 @@ type UpdateAccountRequest struct {
 }
 
 // BulkUpdateAccountsRequest represents the payload for bulk editing accounts
+// Either account_ids or label selects the target accounts.
 type BulkUpdateAccountsRequest struct {
-	AccountIDs              []int64        `json:"account_ids" binding:"required,min=1"`
+	AccountIDs              []int64        `json:"account_ids"`
+	Label                   string         `json:"label"`
+	AddLabels               []string       `json:"add_labels"`
+	RemoveLabels            []string       `json:"remove_labels"`
 	Name                    string         `json:"name"`
 	ProxyID                 *int64         `json:"proxy_id"`
 	Concurrency             *int           `json:"concurrency"`
@@ -156,12 +170,15 @@ func (h *AccountHandler) List(c *gin.Context) {
 		search = search[:100]
 	}
 
+	// label 按单个标签过滤（与账号标签同样规范化为小写）
+	label := strings.ToLower(strings.TrimSpace(c.Query("label")))
+
 	var groupID int64
 	if groupIDStr := c.Query("group"); groupIDStr != "" {
 		groupID, _ = strconv.ParseInt(groupIDStr, 10, 64)
 	}
 
-	accounts, total, err := h.adminService.ListAccounts(c.Request.Context(), page, pageSize, platform, accountType, status, search, groupID)
+	accounts, total, err := h.adminService.ListAccounts(c.Request.Context(), page, pageSize, platform, accountType, status, search, groupID, label)
 	if err != nil {
 		response.ErrorFrom(c, err)
 		return
@@ -302,6 +319,7 @@ func (h *AccountHandler) Create(c *gin.Context) {
 	account, err := h.adminService.CreateAccount(c.Request.Context(), &service.CreateAccountInput{
 		Name:                  req.Name,
 		Notes:                 req.Notes,
+		Labels:                req.Labels,
 		Platform:              req.Platform,
 		Type:                  req.Type,
 		Credentials:           req.Credentials,
@@ -366,6 +384,7 @@ func (h *AccountHandler) Update(c *gin.Context) {
 	account, err := h.adminService.UpdateAccount(c.Request.Context(), accountID, &service.UpdateAccountInput{
 		Name:                  req.Name,
 		Notes:                 req.Notes,
+		Labels:                req.Labels,
 		Type:                  req.Type,
 		Credentials:           req.Credentials,
 		Extra:                 req.Extra,
@@ -886,6 +905,10 @@ func (h *AccountHandler) BulkUpdate(c *gin.Context) {
 		response.BadRequest(c, "Invalid request: "+err.Error())
 		return
 	}
+	if len(req.AccountIDs) == 0 && strings.TrimSpace(req.Label) == "" {
+		response.BadRequest(c, "account_ids or label is required")
+		return
+	}
 	if req.RateMultiplier != nil && *req.RateMultiplier < 0 {
 		response.BadRequest(c, "rate_multiplier must be >= 0")
 		return
@@ -895,6 +918,8 @@ func (h *AccountHandler) BulkUpdate(c *gin.Context) {
 	skipCheck := req.ConfirmMixedChannelRisk != nil && *req.ConfirmMixedChannelRisk
 
 	hasUpdates := req.Name != "" ||
+		len(req.AddLabels) > 0 ||
+		len(req.RemoveLabels) > 0 ||
 		req.ProxyID != nil ||
 		req.Concurrency != nil ||
 		req.Priority != nil ||
@@ -912,6 +937,9 @@ func (h *AccountHandler) BulkUpdate(c *gin.Context) {
 
 	result, err := h.adminService.BulkUpdateAccounts(c.Request.Context(), &service.BulkUpdateAccountsInput{
 		AccountIDs:            req.AccountIDs,
+		Label:                 req.Label,
+		AddLabels:             req.AddLabels,
+		RemoveLabels:          req.RemoveLabels,
 		Name:                  req.Name,
 		ProxyID:               req.ProxyID,
 		Concurrency:           req.Concurrency,
@@ -1202,6 +1230,68 @@ func (h *AccountHandler) SetSchedulable(c *gin.Context) {
 	response.Success(c, dto.AccountFromService(account))
 }
 
+// SetMaintenanceRequest represents the request for toggling maintenance mode
+type SetMaintenanceRequest struct {
+	Enabled *bool `json:"enabled" binding:"required"`
+}
+
+// SetMaintenance puts an account into (or takes it out of) maintenance.
+// In-flight streams finish; new requests skip the account and sticky sessions migrate.
+// POST /api/v1/admin/accounts/:id/maintenance
+func (h *AccountHandler) SetMaintenance(c *gin.Context) {
+	accountID, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		response.BadRequest(c, "Invalid account ID")
+		return
+	}
+
+	var req SetMaintenanceRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		response.BadRequest(c, "Invalid request: "+err.Error())
+		return
+	}
+
+	account, err := h.adminService.SetAccountMaintenance(c.Request.Context(), accountID, *req.Enabled)
+	if err != nil {
+		response.ErrorFrom(c, err)
+		return
+	}
+
+	response.Success(c, dto.AccountFromService(account))
+}
+
+// DiscoverModels queries the upstream /models endpoint and refreshes the account's model matrix.
+// Always bypasses and clears the account's upstream metadata cache (force refresh).
+// POST /api/v1/admin/accounts/:id/models/discover
+func (h *AccountHandler) DiscoverModels(c *gin.Context) {
+	accountID, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		response.BadRequest(c, "Invalid account ID")
+		return
+	}
+
+	account, err := h.adminService.GetAccount(c.Request.Context(), accountID)
+	if err != nil {
+		response.NotFound(c, "Account not found")
+		return
+	}
+
+	models, err := h.modelDiscoveryService.DiscoverAndSave(c.Request.Context(), account, true)
+	if err != nil {
+		if errors.Is(err, service.ErrModelDiscoveryUnsupported) {
+			response.BadRequest(c, err.Error())
+			return
+		}
+		response.InternalError(c, "Model discovery failed: "+err.Error())
+		return
+	}
+
+	response.Success(c, gin.H{
+		"account_id": accountID,
+		"models":     models,
+	})
+}
+
 // GetAvailableModels handles getting available models for an account
 // GET /api/v1/admin/accounts/:id/models
 func (h *AccountHandler) GetAvailableModels(c *gin.Context) {
@@ -1434,7 +1524,7 @@ func (h *AccountHandler) BatchRefreshTier(c *gin.Context) {
 	accounts := make([]*service.Account, 0)
 
 	if len(req.AccountIDs) == 0 {
-		allAccounts, _, err := h.adminService.ListAccounts(ctx, 1, 10000, "gemini", "oauth", "", "", 0)
+		allAccounts, _, err := h.adminService.ListAccounts(ctx, 1, 10000, "gemini", "oauth", "", "", 0, "")
 		if err != nil {
 			response.ErrorFrom(c, err)
 			return
@@ -0,0 +1,328 @@
+package admin
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"io"
+	"log"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/Wei-Shaw/sub2api/internal/handler/dto"
+	"github.com/Wei-Shaw/sub2api/internal/pkg/ip"
+	"github.com/Wei-Shaw/sub2api/internal/pkg/pagination"
+	"github.com/Wei-Shaw/sub2api/internal/pkg/response"
+	middleware2 "github.com/Wei-Shaw/sub2api/internal/server/middleware"
+	"github.com/Wei-Shaw/sub2api/internal/service"
+	"github.com/gin-gonic/gin"
+)
+
+const (
+	// adminActionCaptureLimit 请求体/响应体最多捕获的字节数（超出后不再解析）
+	adminActionCaptureLimit = 64 * 1024
+	// adminActionWriteTimeout 单条操作审计写入超时
+	adminActionWriteTimeout = 5 * time.Second
+	adminRoutePrefix        = "/api/v1/admin/"
+)
+
+// adminSnapshotLoader 按资源 ID 加载变更前后的快照（与对应 GET 接口返回结构一致）
+type adminSnapshotLoader func(ctx context.Context, id int64) (any, error)
+
+// AdminActionLogHandler 处理管理后台操作审计的记录与查询
+type AdminActionLogHandler struct {
+	service *service.AdminActionLogService
+	loaders map[string]adminSnapshotLoader
+}
+
+// NewAdminActionLogHandler 创建操作审计处理器
+func NewAdminActionLogHandler(
+	actionLogService *service.AdminActionLogService,
+	adminService service.AdminService,
+	errorPassthroughService *service.ErrorPassthroughService,
+) *AdminActionLogHandler {
+	h := &AdminActionLogHandler{service: actionLogService, loaders: map[string]adminSnapshotLoader{}}
+	if adminService != nil {
+		h.loaders["accounts"] = func(ctx context.Context, id int64) (any, error) {
+			account, err := adminService.GetAccount(ctx, id)
+			if err != nil {
+				return nil, err
+			}
+			return dto.AccountFromService(account), nil
+		}
+		h.loaders["groups"] = func(ctx context.Context, id int64) (any, error) {
+			group, err := adminService.GetGroup(ctx, id)
+			if err != nil {
+				return nil, err
+			}
+			return dto.GroupFromServiceAdmin(group), nil
+		}
+		h.loaders["users"] = func(ctx context.Context, id int64) (any, error) {
+			user, err := adminService.GetUser(ctx, id)
+			if err != nil {
+				return nil, err
+			}
+			return dto.UserFromServiceAdmin(user), nil
+		}
+		h.loaders["proxies"] = func(ctx context.Context, id int64) (any, error) {
+			proxy, err := adminService.GetProxy(ctx, id)
+			if err != nil {
+				return nil, err
+			}
+			return dto.ProxyFromService(proxy), nil
+		}
+		h.loaders["redeem-codes"] = func(ctx context.Context, id int64) (any, error) {
+			code, err := adminService.GetRedeemCode(ctx, id)
+			if err != nil {
+				return nil, err
+			}
+			return dto.RedeemCodeFromServiceAdmin(code), nil
+		}
+	}
+	if errorPassthroughService != nil {
+		h.loaders["error-passthrough-rules"] = func(ctx context.Context, id int64) (any, error) {
+			rule, err := errorPassthroughService.GetByID(ctx, id)
+			if err != nil || rule == nil {
+				return nil, err
+			}
+			return rule, nil
+		}
+	}
+	return h
+}
+
+// adminActionCapture 有上限的字节缓冲，超出上限后丢弃并标记截断
+type adminActionCapture struct {
+	buf       bytes.Buffer
+	truncated bool
+}
+
+func (b *adminActionCapture) Write(p []byte) (int, error) {
+	if remaining := adminActionCaptureLimit - b.buf.Len(); remaining > 0 {
+		if len(p) > remaining {
+			b.truncated = true
+			_, _ = b.buf.Write(p[:remaining])
+		} else {
+			_, _ = b.buf.Write(p)
+		}
+	} else if len(p) > 0 {
+		b.truncated = true
+	}
+	return len(p), nil
+}
+
+func (b *adminActionCapture) bytes() []byte {
+	if b.truncated {
+		return nil
+	}
+	return b.buf.Bytes()
+}
+
+type adminActionResponseWriter struct {
+	gin.ResponseWriter
+	capture *adminActionCapture
+}
+
+func (w *adminActionResponseWriter) Write(p []byte) (int, error) {
+	n, err := w.ResponseWriter.Write(p)
+	_, _ = w.capture.Write(p[:n])
+	return n, err
+}
+
+func (w *adminActionResponseWriter) WriteString(s string) (int, error) {
+	n, err := w.ResponseWriter.WriteString(s)
+	_, _ = w.capture.Write([]byte(s[:n]))
+	return n, err
+}
+
+// Middleware records every admin mutation (non-GET request) with actor, route,
+// redacted request body and before/after snapshots of the target resource.
+// Snapshots come from the registered loaders; for resources without a loader
+// (or creates without an ID), the response data is used as the "after" state.
+func (h *AdminActionLogHandler) Middleware() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if h == nil || h.service == nil {
+			c.Next()
+			return
+		}
+		switch c.Request.Method {
+		case http.MethodGet, http.MethodHead, http.MethodOptions:
+			c.Next()
+			return
+		}
+
+		route := c.FullPath()
+		resourceType, resourceID := parseAdminResource(route, c)
+		loader := h.loaders[resourceType]
+
+		var before json.RawMessage
+		if loader != nil && resourceID != nil {
+			before = h.loadSnapshot(c.Request.Context(), loader, *resourceID)
+		}
+
+		reqCapture := &adminActionCapture{}
+		if c.Request.Body != nil {
+			c.Request.Body = auditTeeBody{Reader: io.TeeReader(c.Request.Body, reqCapture), Closer: c.Request.Body}
+		}
+		respCapture := &adminActionCapture{}
+		c.Writer = &adminActionResponseWriter{ResponseWriter: c.Writer, capture: respCapture}
+
+		c.Next()
+
+		entry := &service.AdminActionLog{
+			Method:       c.Request.Method,
+			Path:         c.Request.URL.Path,
+			Route:        c.Request.Method + " " + route,
+			ResourceType: resourceType,
+			ResourceID:   resourceID,
+			StatusCode:   c.Writer.Status(),
+			ClientIP:     strings.TrimSpace(ip.GetClientIP(c)),
+			UserAgent:    truncateUserAgent(c.GetHeader("User-Agent")),
+			RequestBody:  service.SanitizeAdminRequestBody(reqCapture.bytes()),
+			Before:       before,
+		}
+		if subject, ok := middleware2.GetAuthSubjectFromContext(c); ok && subject.UserID > 0 {
+			actorID := subject.UserID
+			entry.ActorUserID = &actorID
+		}
+		if v, ok := c.Get("auth_method"); ok {
+			entry.AuthMethod, _ = v.(string)
+		}
+		// 失败的请求不产生变更，只记录操作本身
+		if entry.StatusCode < http.StatusBadRequest {
+			ctx := c.Request.Context()
+			if loader != nil && resourceID != nil {
+				entry.After = h.loadSnapshot(ctx, loader, *resourceID)
+			} else {
+				entry.After = adminResponseData(respCapture.bytes())
+			}
+		} else {
+			entry.Before = nil
+		}
+
+		ctx, cancel := context.WithTimeout(context.WithoutCancel(c.Request.Context()), adminActionWriteTimeout)
+		defer cancel()
+		if err := h.service.Record(ctx, entry); err != nil {
+			log.Printf("[AdminActionLog] record failed: route=%s err=%v", entry.Route, err)
+		}
+	}
+}
+
+func (h *AdminActionLogHandler) loadSnapshot(ctx context.Context, loader adminSnapshotLoader, id int64) json.RawMessage {
+	v, err := loader(ctx, id)
+	if err != nil || v == nil {
+		// 资源不存在（创建前/删除后）视为空快照
+		return nil
+	}
+	return service.SanitizeAdminSnapshot(v)
+}
+
+type auditTeeBody struct {
+	io.Reader
+	io.Closer
+}
+
+// parseAdminResource 从路由模板解析资源类型与 ID，如 /api/v1/admin/accounts/:id/schedulable -> accounts, :id
+func parseAdminResource(route string, c *gin.Context) (string, *int64) {
+	rest := strings.TrimPrefix(route, adminRoutePrefix)
+	if rest == route {
+		return "", nil
+	}
+	parts := strings.Split(rest, "/")
+	resourceType := parts[0]
+	if len(parts) < 2 || !strings.HasPrefix(parts[1], ":") {
+		return resourceType, nil
+	}
+	id, err := strconv.ParseInt(c.Param(strings.TrimPrefix(parts[1], ":")), 10, 64)
+	if err != nil || id <= 0 {
+		return resourceType, nil
+	}
+	return resourceType, &id
+}
+
+// adminResponseData 提取统一响应结构中的 data 字段并脱敏
+func adminResponseData(raw []byte) json.RawMessage {
+	if len(raw) == 0 {
+		return nil
+	}
+	var resp struct {
+		Data json.RawMessage `json:"data"`
+	}
+	if err := json.Unmarshal(raw, &resp); err != nil || len(resp.Data) == 0 {
+		return nil
+	}
+	var decoded any
+	if err := json.Unmarshal(resp.Data, &decoded); err != nil {
+		return nil
+	}
+	return service.SanitizeAdminSnapshot(decoded)
+}
+
+func truncateUserAgent(ua string) string {
+	if len(ua) > 512 {
+		return ua[:512]
+	}
+	return ua
+}
+
+// List 分页查询操作审计（不含请求体与快照）
+// GET /api/v1/admin/action-logs
+func (h *AdminActionLogHandler) List(c *gin.Context) {
+	page, pageSize := response.ParsePagination(c)
+
+	var filter service.AdminActionLogFilter
+	for _, p := range []struct {
+		name string
+		dest **time.Time
+	}{{"start_time", &filter.StartTime}, {"end_time", &filter.EndTime}} {
+		if v := strings.TrimSpace(c.Query(p.name)); v != "" {
+			t, err := time.Parse(time.RFC3339, v)
+			if err != nil {
+				response.BadRequest(c, "Invalid "+p.name+", expected RFC3339")
+				return
+			}
+			*p.dest = &t
+		}
+	}
+	for _, p := range []struct {
+		name string
+		dest **int64
+	}{{"actor_user_id", &filter.ActorUserID}, {"resource_id", &filter.ResourceID}} {
+		if v := strings.TrimSpace(c.Query(p.name)); v != "" {
+			id, err := strconv.ParseInt(v, 10, 64)
+			if err != nil || id <= 0 {
+				response.BadRequest(c, "Invalid "+p.name)
+				return
+			}
+			*p.dest = &id
+		}
+	}
+	filter.ResourceType = strings.TrimSpace(c.Query("resource_type"))
+	filter.Method = strings.TrimSpace(c.Query("method"))
+	filter.Search = strings.TrimSpace(c.Query("search"))
+
+	logs, result, err := h.service.List(c.Request.Context(), pagination.PaginationParams{Page: page, PageSize: pageSize}, filter)
+	if err != nil {
+		response.ErrorFrom(c, err)
+		return
+	}
+	response.Paginated(c, logs, result.Total, page, pageSize)
+}
+
+// GetByID 获取单条操作审计（含脱敏后的请求体、变更前后快照与字段差异）
+// GET /api/v1/admin/action-logs/:id
+func (h *AdminActionLogHandler) GetByID(c *gin.Context) {
+	id, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		response.BadRequest(c, "Invalid action log ID")
+		return
+	}
+	entry, err := h.service.GetByID(c.Request.Context(), id)
+	if err != nil {
+		response.ErrorFrom(c, err)
+		return
+	}
+	response.Success(c, entry)
+}
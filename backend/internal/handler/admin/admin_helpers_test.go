@@ -116,6 +116,14 @@ func TestOpsAlertRuleValidation(t *testing.T) {
 	_, err = validateOpsAlertRulePayload(map[string]json.RawMessage{})
 	require.Error(t, err)
 
+	raw["metric_type"] = json.RawMessage(`"slo_burn_rate"`)
+	raw["threshold"] = json.RawMessage(`14.4`)
+	_, err = validateOpsAlertRulePayload(raw)
+	require.Error(t, err)
+	raw["filters"] = json.RawMessage(`{"slo_id":3}`)
+	_, err = validateOpsAlertRulePayload(raw)
+	require.NoError(t, err)
+
 	require.True(t, isPercentOrRateMetric("error_rate"))
 	require.False(t, isPercentOrRateMetric("concurrency_queue_depth"))
 }
@@ -166,7 +166,7 @@ func (s *stubAdminService) GetGroupAPIKeys(ctx context.Context, groupID int64, p
 	return s.apiKeys, int64(len(s.apiKeys)), nil
 }
 
-func (s *stubAdminService) ListAccounts(ctx context.Context, page, pageSize int, platform, accountType, status, search string, groupID int64) ([]service.Account, int64, error) {
+func (s *stubAdminService) ListAccounts(ctx context.Context, page, pageSize int, platform, accountType, status, search string, groupID int64, label string) ([]service.Account, int64, error) {
 	return s.accounts, int64(len(s.accounts)), nil
 }
 
@@ -220,6 +220,14 @@ func (s *stubAdminService) SetAccountSchedulable(ctx context.Context, id int64,
 	return &account, nil
 }
 
+func (s *stubAdminService) SetAccountMaintenance(ctx context.Context, id int64, enabled bool) (*service.Account, error) {
+	account := service.Account{ID: id, Name: "account", Status: service.StatusActive, Schedulable: true}
+	if enabled {
+		account.Status = service.StatusMaintenance
+	}
+	return &account, nil
+}
+
 func (s *stubAdminService) BulkUpdateAccounts(ctx context.Context, input *service.BulkUpdateAccountsInput) (*service.BulkUpdateAccountsResult, error) {
 	return &service.BulkUpdateAccountsResult{Success: 1, Failed: 0, SuccessIDs: []int64{1}}, nil
 }
@@ -361,5 +369,18 @@ func (s *stubAdminService) UpdateGroupSortOrders(ctx context.Context, updates []
 	return nil
 }
 
+func (s *stubAdminService) CloneGroup(ctx context.Context, sourceID int64, input *service.CloneGroupInput) (*service.CloneGroupResult, error) {
+	group := service.Group{ID: 201, Name: input.Name, Status: service.StatusActive}
+	return &service.CloneGroupResult{Group: &group}, nil
+}
+
+func (s *stubAdminService) SetGroupMaintenance(ctx context.Context, id int64, enabled bool) (*service.Group, error) {
+	group := service.Group{ID: id, Name: "group", Status: service.StatusActive}
+	if enabled {
+		group.Status = service.StatusMaintenance
+	}
+	return &group, nil
+}
+
 // Ensure stub implements interface.
 var _ service.AdminService = (*stubAdminService)(nil)
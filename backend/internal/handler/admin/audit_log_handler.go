@@ -0,0 +1,108 @@
+package admin
+
+import (
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/Wei-Shaw/sub2api/internal/pkg/pagination"
+	"github.com/Wei-Shaw/sub2api/internal/pkg/response"
+	"github.com/Wei-Shaw/sub2api/internal/service"
+	"github.com/gin-gonic/gin"
+)
+
+// AuditLogHandler 处理网关请求审计日志的查询与清理
+type AuditLogHandler struct {
+	service *service.AuditLogService
+}
+
+// NewAuditLogHandler 创建审计日志处理器
+func NewAuditLogHandler(service *service.AuditLogService) *AuditLogHandler {
+	return &AuditLogHandler{service: service}
+}
+
+// List 分页查询审计日志（不含请求/响应体）
+// GET /api/v1/admin/audit-logs
+func (h *AuditLogHandler) List(c *gin.Context) {
+	page, pageSize := response.ParsePagination(c)
+
+	var filter service.AuditLogFilter
+	for _, p := range []struct {
+		name string
+		dest **time.Time
+	}{{"start_time", &filter.StartTime}, {"end_time", &filter.EndTime}} {
+		if v := strings.TrimSpace(c.Query(p.name)); v != "" {
+			t, err := time.Parse(time.RFC3339, v)
+			if err != nil {
+				response.BadRequest(c, "Invalid "+p.name+", expected RFC3339")
+				return
+			}
+			*p.dest = &t
+		}
+	}
+	for _, p := range []struct {
+		name string
+		dest **int64
+	}{{"user_id", &filter.UserID}, {"api_key_id", &filter.APIKeyID}, {"account_id", &filter.AccountID}} {
+		if v := strings.TrimSpace(c.Query(p.name)); v != "" {
+			id, err := strconv.ParseInt(v, 10, 64)
+			if err != nil || id <= 0 {
+				response.BadRequest(c, "Invalid "+p.name)
+				return
+			}
+			*p.dest = &id
+		}
+	}
+	if v := strings.TrimSpace(c.Query("status_code")); v != "" {
+		code, err := strconv.Atoi(v)
+		if err != nil {
+			response.BadRequest(c, "Invalid status_code")
+			return
+		}
+		filter.StatusCode = &code
+	}
+	filter.Model = strings.TrimSpace(c.Query("model"))
+	filter.RequestID = strings.TrimSpace(c.Query("request_id"))
+
+	logs, result, err := h.service.List(c.Request.Context(), pagination.PaginationParams{Page: page, PageSize: pageSize}, filter)
+	if err != nil {
+		response.ErrorFrom(c, err)
+		return
+	}
+	response.Paginated(c, logs, result.Total, page, pageSize)
+}
+
+// GetByID 获取单条审计日志（含脱敏后的请求/响应体）
+// GET /api/v1/admin/audit-logs/:id
+func (h *AuditLogHandler) GetByID(c *gin.Context) {
+	id, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		response.BadRequest(c, "Invalid audit log ID")
+		return
+	}
+	entry, err := h.service.GetByID(c.Request.Context(), id)
+	if err != nil {
+		response.ErrorFrom(c, err)
+		return
+	}
+	response.Success(c, entry)
+}
+
+// Purge 删除指定时间之前的审计日志（早于自动保留期的手动清理）
+// DELETE /api/v1/admin/audit-logs?before=2026-01-01T00:00:00Z
+func (h *AuditLogHandler) Purge(c *gin.Context) {
+	before, err := time.Parse(time.RFC3339, strings.TrimSpace(c.Query("before")))
+	if err != nil {
+		response.BadRequest(c, "Invalid before, expected RFC3339")
+		return
+	}
+	deleted, err := h.service.Purge(c.Request.Context(), before)
+	if err != nil {
+		response.ErrorFrom(c, err)
+		return
+	}
+	response.Success(c, gin.H{
+		"deleted":        deleted,
+		"retention_days": h.service.RetentionDays(),
+	})
+}
@@ -1,6 +1,7 @@
 package admin
 
 import (
+	"net/http"
 	"strconv"
 
 	"github.com/Wei-Shaw/sub2api/internal/model"
@@ -21,36 +22,56 @@ func NewErrorPassthroughHandler(service *service.ErrorPassthroughService) *Error
 
 // CreateErrorPassthroughRuleRequest 创建规则请求
 type CreateErrorPassthroughRuleRequest struct {
-	Name            string   `json:"name" binding:"required"`
-	Enabled         *bool    `json:"enabled"`
-	Priority        int      `json:"priority"`
-	ErrorCodes      []int    `json:"error_codes"`
-	Keywords        []string `json:"keywords"`
-	MatchMode       string   `json:"match_mode"`
-	Platforms       []string `json:"platforms"`
-	PassthroughCode *bool    `json:"passthrough_code"`
-	ResponseCode    *int     `json:"response_code"`
-	PassthroughBody *bool    `json:"passthrough_body"`
-	CustomMessage   *string  `json:"custom_message"`
-	SkipMonitoring  *bool    `json:"skip_monitoring"`
-	Description     *string  `json:"description"`
+	Name            string            `json:"name" binding:"required"`
+	Enabled         *bool             `json:"enabled"`
+	Priority        int               `json:"priority"`
+	ErrorCodes      []int             `json:"error_codes"`
+	Keywords        []string          `json:"keywords"`
+	BodyPatterns    []string          `json:"body_patterns"`
+	HeaderMatches   map[string]string `json:"header_matches"`
+	MatchMode       string            `json:"match_mode"`
+	Platforms       []string          `json:"platforms"`
+	PassthroughCode *bool             `json:"passthrough_code"`
+	ResponseCode    *int              `json:"response_code"`
+	PassthroughBody *bool             `json:"passthrough_body"`
+	CustomMessage   *string           `json:"custom_message"`
+	SkipMonitoring  *bool             `json:"skip_monitoring"`
+	Description     *string           `json:"description"`
 }
 
 // UpdateErrorPassthroughRuleRequest 更新规则请求（部分更新，所有字段可选）
 type UpdateErrorPassthroughRuleRequest struct {
-	Name            *string  `json:"name"`
-	Enabled         *bool    `json:"enabled"`
-	Priority        *int     `json:"priority"`
-	ErrorCodes      []int    `json:"error_codes"`
-	Keywords        []string `json:"keywords"`
-	MatchMode       *string  `json:"match_mode"`
-	Platforms       []string `json:"platforms"`
-	PassthroughCode *bool    `json:"passthrough_code"`
-	ResponseCode    *int     `json:"response_code"`
-	PassthroughBody *bool    `json:"passthrough_body"`
-	CustomMessage   *string  `json:"custom_message"`
-	SkipMonitoring  *bool    `json:"skip_monitoring"`
-	Description     *string  `json:"description"`
+	Name            *string           `json:"name"`
+	Enabled         *bool             `json:"enabled"`
+	Priority        *int              `json:"priority"`
+	ErrorCodes      []int             `json:"error_codes"`
+	Keywords        []string          `json:"keywords"`
+	BodyPatterns    []string          `json:"body_patterns"`
+	HeaderMatches   map[string]string `json:"header_matches"`
+	MatchMode       *string           `json:"match_mode"`
+	Platforms       []string          `json:"platforms"`
+	PassthroughCode *bool             `json:"passthrough_code"`
+	ResponseCode    *int              `json:"response_code"`
+	PassthroughBody *bool             `json:"passthrough_body"`
+	CustomMessage   *string           `json:"custom_message"`
+	SkipMonitoring  *bool             `json:"skip_monitoring"`
+	Description     *string           `json:"description"`
+}
+
+// TestErrorPassthroughRuleRequest 规则测试请求
+// 提供 rule 时试运行该规则（无需保存）；否则按优先级匹配已保存的规则
+type TestErrorPassthroughRuleRequest struct {
+	Rule       *CreateErrorPassthroughRuleRequest `json:"rule"`
+	Platform   string                             `json:"platform" binding:"required"`
+	StatusCode int                                `json:"status_code" binding:"required"`
+	Headers    map[string]string                  `json:"headers"`
+	Body       string                             `json:"body"`
+}
+
+// TestErrorPassthroughRuleResponse 规则测试结果
+type TestErrorPassthroughRuleResponse struct {
+	Matched bool                        `json:"matched"`
+	Rule    *model.ErrorPassthroughRule `json:"rule,omitempty"`
 }
 
 // List 获取所有规则
@@ -95,52 +116,7 @@ func (h *ErrorPassthroughHandler) Create(c *gin.Context) {
 		return
 	}
 
-	rule := &model.ErrorPassthroughRule{
-		Name:       req.Name,
-		Priority:   req.Priority,
-		ErrorCodes: req.ErrorCodes,
-		Keywords:   req.Keywords,
-		Platforms:  req.Platforms,
-	}
-
-	// 设置默认值
-	if req.Enabled != nil {
-		rule.Enabled = *req.Enabled
-	} else {
-		rule.Enabled = true
-	}
-	if req.MatchMode != "" {
-		rule.MatchMode = req.MatchMode
-	} else {
-		rule.MatchMode = model.MatchModeAny
-	}
-	if req.PassthroughCode != nil {
-		rule.PassthroughCode = *req.PassthroughCode
-	} else {
-		rule.PassthroughCode = true
-	}
-	if req.PassthroughBody != nil {
-		rule.PassthroughBody = *req.PassthroughBody
-	} else {
-		rule.PassthroughBody = true
-	}
-	if req.SkipMonitoring != nil {
-		rule.SkipMonitoring = *req.SkipMonitoring
-	}
-	rule.ResponseCode = req.ResponseCode
-	rule.CustomMessage = req.CustomMessage
-	rule.Description = req.Description
-
-	// 确保切片不为 nil
-	if rule.ErrorCodes == nil {
-		rule.ErrorCodes = []int{}
-	}
-	if rule.Keywords == nil {
-		rule.Keywords = []string{}
-	}
-	if rule.Platforms == nil {
-		rule.Platforms = []string{}
-	}
+	rule := buildRuleFromCreateRequest(&req)
 
 	created, err := h.service.Create(c.Request.Context(), rule)
 	if err != nil {
@@ -189,6 +165,8 @@ func (h *ErrorPassthroughHandler) Update(c *gin.Context) {
 		Priority:        existing.Priority,
 		ErrorCodes:      existing.ErrorCodes,
 		Keywords:        existing.Keywords,
+		BodyPatterns:    existing.BodyPatterns,
+		HeaderMatches:   existing.HeaderMatches,
 		MatchMode:       existing.MatchMode,
 		Platforms:       existing.Platforms,
 		PassthroughCode: existing.PassthroughCode,
@@ -215,6 +193,12 @@ func (h *ErrorPassthroughHandler) Update(c *gin.Context) {
 	if req.Keywords != nil {
 		rule.Keywords = req.Keywords
 	}
+	if req.BodyPatterns != nil {
+		rule.BodyPatterns = req.BodyPatterns
+	}
+	if req.HeaderMatches != nil {
+		rule.HeaderMatches = req.HeaderMatches
+	}
 	if req.MatchMode != nil {
 		rule.MatchMode = *req.MatchMode
 	}
@@ -247,6 +231,12 @@ func (h *ErrorPassthroughHandler) Update(c *gin.Context) {
 	if rule.Keywords == nil {
 		rule.Keywords = []string{}
 	}
+	if rule.BodyPatterns == nil {
+		rule.BodyPatterns = []string{}
+	}
+	if rule.HeaderMatches == nil {
+		rule.HeaderMatches = map[string]string{}
+	}
 	if rule.Platforms == nil {
 		rule.Platforms = []string{}
 	}
@@ -280,3 +270,101 @@ func (h *ErrorPassthroughHandler) Delete(c *gin.Context) {
 
 	response.Success(c, gin.H{"message": "Rule deleted successfully"})
 }
+
+// Test 使用给定的上游响应测试规则匹配
+// POST /api/v1/admin/error-passthrough-rules/test
+func (h *ErrorPassthroughHandler) Test(c *gin.Context) {
+	var req TestErrorPassthroughRuleRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		response.BadRequest(c, "Invalid request: "+err.Error())
+		return
+	}
+
+	headers := make(http.Header, len(req.Headers))
+	for k, v := range req.Headers {
+		headers.Set(k, v)
+	}
+	body := []byte(req.Body)
+
+	if req.Rule != nil {
+		rule := buildRuleFromCreateRequest(req.Rule)
+		matched, err := h.service.TestRule(rule, req.Platform, req.StatusCode, headers, body)
+		if err != nil {
+			if _, ok := err.(*model.ValidationError); ok {
+				response.BadRequest(c, err.Error())
+				return
+			}
+			response.ErrorFrom(c, err)
+			return
+		}
+		result := TestErrorPassthroughRuleResponse{Matched: matched}
+		if matched {
+			result.Rule = rule
+		}
+		response.Success(c, result)
+		return
+	}
+
+	rule := h.service.MatchRuleWithHeaders(req.Platform, req.StatusCode, headers, body)
+	response.Success(c, TestErrorPassthroughRuleResponse{Matched: rule != nil, Rule: rule})
+}
+
+// buildRuleFromCreateRequest 根据创建请求构造规则并填充默认值
+func buildRuleFromCreateRequest(req *CreateErrorPassthroughRuleRequest) *model.ErrorPassthroughRule {
+	rule := &model.ErrorPassthroughRule{
+		Name:          req.Name,
+		Priority:      req.Priority,
+		ErrorCodes:    req.ErrorCodes,
+		Keywords:      req.Keywords,
+		BodyPatterns:  req.BodyPatterns,
+		HeaderMatches: req.HeaderMatches,
+		Platforms:     req.Platforms,
+	}
+
+	// 设置默认值
+	if req.Enabled != nil {
+		rule.Enabled = *req.Enabled
+	} else {
+		rule.Enabled = true
+	}
+	if req.MatchMode != "" {
+		rule.MatchMode = req.MatchMode
+	} else {
+		rule.MatchMode = model.MatchModeAny
+	}
+	if req.PassthroughCode != nil {
+		rule.PassthroughCode = *req.PassthroughCode
+	} else {
+		rule.PassthroughCode = true
+	}
+	if req.PassthroughBody != nil {
+		rule.PassthroughBody = *req.PassthroughBody
+	} else {
+		rule.PassthroughBody = true
+	}
+	if req.SkipMonitoring != nil {
+		rule.SkipMonitoring = *req.SkipMonitoring
+	}
+	rule.ResponseCode = req.ResponseCode
+	rule.CustomMessage = req.CustomMessage
+	rule.Description = req.Description
+
+	// 确保切片不为 nil
+	if rule.ErrorCodes == nil {
+		rule.ErrorCodes = []int{}
+	}
+	if rule.Keywords == nil {
+		rule.Keywords = []string{}
+	}
+	if rule.BodyPatterns == nil {
+		rule.BodyPatterns = []string{}
+	}
+	if rule.HeaderMatches == nil {
+		rule.HeaderMatches = map[string]string{}
+	}
+	if rule.Platforms == nil {
+		rule.Platforms = []string{}
+	}
+
+	return rule
+}
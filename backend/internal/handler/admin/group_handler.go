@@ -45,8 +45,24 @@ type CreateGroupRequest struct {
 	ModelRouting        map[string][]int64 `json:"model_routing"`
 	ModelRoutingEnabled bool               `json:"model_routing_enabled"`
 	MCPXMLInject        *bool              `json:"mcp_xml_inject"`
+	// 模型参数策略（temperature/top_p 范围约束）
+	ModelParamPolicies map[string]service.ModelParamPolicy `json:"model_param_policies"`
+	// 区域策略
+	RegionPolicy service.RegionPolicy `json:"region_policy"`
+	// 系统提示词注入策略
+	SystemPromptPolicy service.SystemPromptPolicy `json:"system_prompt_policy"`
+	// 提示词前缀/后缀模板（支持 {{date}}、{{user_id}} 等变量）
+	PromptTemplate service.PromptTemplate `json:"prompt_template"`
+	// 模型访问策略（允许/禁止请求的模型）
+	ModelAccessPolicy service.ModelAccessPolicy `json:"model_access_policy"`
+	// 重试策略（可重试状态码、指数退避与总时间预算）
+	RetryPolicy service.RetryPolicy `json:"retry_policy"`
 	// 支持的模型系列（仅 antigravity 平台使用）
 	SupportedModelScopes []string `json:"supported_model_scopes"`
+	// 仅计量模式：记录用量与费用但不扣费、不做计费资格拦截
+	MeteringOnly bool `json:"metering_only"`
+	// 严格字段模式：拒绝包含未知顶层字段的请求
+	StrictRequestFields bool `json:"strict_request_fields"`
 	// 从指定分组复制账号（创建后自动绑定）
 	CopyAccountsFromGroupIDs []int64 `json:"copy_accounts_from_group_ids"`
 }
@@ -74,8 +90,24 @@ type UpdateGroupRequest struct {
 	ModelRouting        map[string][]int64 `json:"model_routing"`
 	ModelRoutingEnabled *bool              `json:"model_routing_enabled"`
 	MCPXMLInject        *bool              `json:"mcp_xml_inject"`
+	// 模型参数策略（temperature/top_p 范围约束）
+	ModelParamPolicies map[string]service.ModelParamPolicy `json:"model_param_policies"`
+	// 区域策略（不传表示不修改）
+	RegionPolicy *service.RegionPolicy `json:"region_policy"`
+	// 系统提示词注入策略（不传表示不修改）
+	SystemPromptPolicy *service.SystemPromptPolicy `json:"system_prompt_policy"`
+	// 提示词前缀/后缀模板（不传表示不修改）
+	PromptTemplate *service.PromptTemplate `json:"prompt_template"`
+	// 模型访问策略（不传表示不修改）
+	ModelAccessPolicy *service.ModelAccessPolicy `json:"model_access_policy"`
+	// 重试策略（不传表示不修改）
+	RetryPolicy *service.RetryPolicy `json:"retry_policy"`
 	// 支持的模型系列（仅 antigravity 平台使用）
 	SupportedModelScopes *[]string `json:"supported_model_scopes"`
+	// 仅计量模式（不传表示不修改）
+	MeteringOnly *bool `json:"metering_only"`
+	// 严格字段模式（不传表示不修改）
+	StrictRequestFields *bool `json:"strict_request_fields"`
 	// 从指定分组复制账号（同步操作：先清空当前分组的账号绑定，再绑定源分组的账号）
 	CopyAccountsFromGroupIDs []int64 `json:"copy_accounts_from_group_ids"`
 }
@@ -184,8 +216,16 @@ func (h *GroupHandler) Create(c *gin.Context) {
 		FallbackGroupIDOnInvalidRequest: req.FallbackGroupIDOnInvalidRequest,
 		ModelRouting:                    req.ModelRouting,
 		ModelRoutingEnabled:             req.ModelRoutingEnabled,
+		ModelParamPolicies:              req.ModelParamPolicies,
+		RegionPolicy:                    req.RegionPolicy,
+		SystemPromptPolicy:              req.SystemPromptPolicy,
+		PromptTemplate:                  req.PromptTemplate,
+		ModelAccessPolicy:               req.ModelAccessPolicy,
+		RetryPolicy:                     req.RetryPolicy,
 		MCPXMLInject:                    req.MCPXMLInject,
 		SupportedModelScopes:            req.SupportedModelScopes,
+		MeteringOnly:                    req.MeteringOnly,
+		StrictRequestFields:             req.StrictRequestFields,
 		CopyAccountsFromGroupIDs:        req.CopyAccountsFromGroupIDs,
 	})
 	if err != nil {
@@ -230,8 +270,16 @@ func (h *GroupHandler) Update(c *gin.Context) {
 		FallbackGroupIDOnInvalidRequest: req.FallbackGroupIDOnInvalidRequest,
 		ModelRouting:                    req.ModelRouting,
 		ModelRoutingEnabled:             req.ModelRoutingEnabled,
+		ModelParamPolicies:              req.ModelParamPolicies,
+		RegionPolicy:                    req.RegionPolicy,
+		SystemPromptPolicy:              req.SystemPromptPolicy,
+		PromptTemplate:                  req.PromptTemplate,
+		ModelAccessPolicy:               req.ModelAccessPolicy,
+		RetryPolicy:                     req.RetryPolicy,
 		MCPXMLInject:                    req.MCPXMLInject,
 		SupportedModelScopes:            req.SupportedModelScopes,
+		MeteringOnly:                    req.MeteringOnly,
+		StrictRequestFields:             req.StrictRequestFields,
 		CopyAccountsFromGroupIDs:        req.CopyAccountsFromGroupIDs,
 	})
 	if err != nil {
@@ -335,3 +383,72 @@ func (h *GroupHandler) UpdateSortOrder(c *gin.Context) {
 
 	response.Success(c, gin.H{"message": "Sort order updated successfully"})
 }
+
+// CloneGroupRequest represents the request to clone a group as a template
+type CloneGroupRequest struct {
+	Name           string   `json:"name" binding:"required"`
+	Description    *string  `json:"description"`
+	RateMultiplier *float64 `json:"rate_multiplier"`
+	IsExclusive    *bool    `json:"is_exclusive"`
+	// AccountMode: none (default) / link (share accounts) / copy (duplicate accounts)
+	AccountMode string `json:"account_mode" binding:"omitempty,oneof=none link copy"`
+}
+
+// Clone handles cloning a group's configuration into a new group
+// POST /api/v1/admin/groups/:id/clone
+func (h *GroupHandler) Clone(c *gin.Context) {
+	groupID, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		response.BadRequest(c, "Invalid group ID")
+		return
+	}
+
+	var req CloneGroupRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		response.BadRequest(c, "Invalid request: "+err.Error())
+		return
+	}
+
+	result, err := h.adminService.CloneGroup(c.Request.Context(), groupID, &service.CloneGroupInput{
+		Name:           req.Name,
+		Description:    req.Description,
+		RateMultiplier: req.RateMultiplier,
+		IsExclusive:    req.IsExclusive,
+		AccountMode:    req.AccountMode,
+	})
+	if err != nil {
+		response.ErrorFrom(c, err)
+		return
+	}
+
+	response.Success(c, gin.H{
+		"group":                dto.GroupFromServiceAdmin(result.Group),
+		"linked_account_count": result.LinkedAccountCount,
+		"copied_account_count": result.CopiedAccountCount,
+	})
+}
+
+// SetMaintenance puts a group into (or takes it out of) maintenance.
+// New requests on the group get gateway.maintenance_message; in-flight requests finish.
+// POST /api/v1/admin/groups/:id/maintenance
+func (h *GroupHandler) SetMaintenance(c *gin.Context) {
+	groupID, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		response.BadRequest(c, "Invalid group ID")
+		return
+	}
+
+	var req SetMaintenanceRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		response.BadRequest(c, "Invalid request: "+err.Error())
+		return
+	}
+
+	group, err := h.adminService.SetGroupMaintenance(c.Request.Context(), groupID, *req.Enabled)
+	if err != nil {
+		response.ErrorFrom(c, err)
+		return
+	}
+
+	response.Success(c, dto.GroupFromServiceAdmin(group))
+}
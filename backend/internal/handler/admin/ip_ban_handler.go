@@ -0,0 +1,44 @@
+package admin
+
+import (
+	"time"
+
+	"github.com/Wei-Shaw/sub2api/internal/pkg/response"
+	"github.com/Wei-Shaw/sub2api/internal/service"
+	"github.com/gin-gonic/gin"
+)
+
+// IPBanHandler 处理认证前 IP 封禁的查询与解封
+type IPBanHandler struct {
+	service *service.IPBanService
+}
+
+// NewIPBanHandler 创建 IP 封禁处理器
+func NewIPBanHandler(service *service.IPBanService) *IPBanHandler {
+	return &IPBanHandler{service: service}
+}
+
+// List 列出当前被封禁的 IP
+// GET /api/v1/admin/ip-bans
+func (h *IPBanHandler) List(c *gin.Context) {
+	bans, err := h.service.ListBans(c.Request.Context())
+	if err != nil {
+		response.ErrorFrom(c, err)
+		return
+	}
+	response.Success(c, gin.H{
+		"enabled":   h.service.Enabled(),
+		"bans":      bans,
+		"timestamp": time.Now().UTC(),
+	})
+}
+
+// Delete 解除 IP 封禁
+// DELETE /api/v1/admin/ip-bans/:ip
+func (h *IPBanHandler) Delete(c *gin.Context) {
+	if err := h.service.Unban(c.Request.Context(), c.Param("ip")); err != nil {
+		response.ErrorFrom(c, err)
+		return
+	}
+	response.Success(c, gin.H{"message": "IP unbanned successfully"})
+}
@@ -0,0 +1,169 @@
+package admin
+
+import (
+	"strconv"
+	"time"
+
+	"github.com/Wei-Shaw/sub2api/internal/pkg/response"
+	"github.com/Wei-Shaw/sub2api/internal/service"
+	"github.com/gin-gonic/gin"
+)
+
+// ModelPriceHandler 处理模型价格表的 HTTP 请求
+type ModelPriceHandler struct {
+	service *service.ModelPriceService
+}
+
+// NewModelPriceHandler 创建模型价格表处理器
+func NewModelPriceHandler(service *service.ModelPriceService) *ModelPriceHandler {
+	return &ModelPriceHandler{service: service}
+}
+
+// CreateModelPriceRequest 新增价格版本请求（价格单位：USD / 1M tokens）
+type CreateModelPriceRequest struct {
+	Platform             string     `json:"platform"`
+	Model                string     `json:"model" binding:"required"`
+	InputPrice           float64    `json:"input_price"`
+	OutputPrice          float64    `json:"output_price"`
+	CacheCreationPrice   float64    `json:"cache_creation_price"`
+	CacheCreation1hPrice float64    `json:"cache_creation_1h_price"`
+	CacheReadPrice       float64    `json:"cache_read_price"`
+	EffectiveFrom        *time.Time `json:"effective_from"` // 为空表示立即生效
+	Notes                string     `json:"notes"`
+}
+
+// UpdateModelPriceRequest 更新尚未生效的价格版本（部分更新，所有字段可选）
+type UpdateModelPriceRequest struct {
+	Platform             *string    `json:"platform"`
+	Model                *string    `json:"model"`
+	InputPrice           *float64   `json:"input_price"`
+	OutputPrice          *float64   `json:"output_price"`
+	CacheCreationPrice   *float64   `json:"cache_creation_price"`
+	CacheCreation1hPrice *float64   `json:"cache_creation_1h_price"`
+	CacheReadPrice       *float64   `json:"cache_read_price"`
+	EffectiveFrom        *time.Time `json:"effective_from"`
+	Notes                *string    `json:"notes"`
+}
+
+// List 获取价格版本列表
+// GET /api/v1/admin/model-prices?platform=&model=
+func (h *ModelPriceHandler) List(c *gin.Context) {
+	prices, err := h.service.List(c.Request.Context(), c.Query("platform"), c.Query("model"))
+	if err != nil {
+		response.ErrorFrom(c, err)
+		return
+	}
+	response.Success(c, prices)
+}
+
+// GetByID 获取价格版本
+// GET /api/v1/admin/model-prices/:id
+func (h *ModelPriceHandler) GetByID(c *gin.Context) {
+	id, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		response.BadRequest(c, "Invalid price ID")
+		return
+	}
+	price, err := h.service.GetByID(c.Request.Context(), id)
+	if err != nil {
+		response.ErrorFrom(c, err)
+		return
+	}
+	response.Success(c, price)
+}
+
+// Create 新增价格版本
+// POST /api/v1/admin/model-prices
+func (h *ModelPriceHandler) Create(c *gin.Context) {
+	var req CreateModelPriceRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		response.BadRequest(c, "Invalid request: "+err.Error())
+		return
+	}
+
+	price := &service.ModelPrice{
+		Platform:             req.Platform,
+		Model:                req.Model,
+		InputPrice:           req.InputPrice,
+		OutputPrice:          req.OutputPrice,
+		CacheCreationPrice:   req.CacheCreationPrice,
+		CacheCreation1hPrice: req.CacheCreation1hPrice,
+		CacheReadPrice:       req.CacheReadPrice,
+		Notes:                req.Notes,
+	}
+	if req.EffectiveFrom != nil {
+		price.EffectiveFrom = *req.EffectiveFrom
+	}
+
+	created, err := h.service.Create(c.Request.Context(), price)
+	if err != nil {
+		response.ErrorFrom(c, err)
+		return
+	}
+	response.Success(c, created)
+}
+
+// Update 更新尚未生效的价格版本
+// PUT /api/v1/admin/model-prices/:id
+func (h *ModelPriceHandler) Update(c *gin.Context) {
+	id, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		response.BadRequest(c, "Invalid price ID")
+		return
+	}
+	var req UpdateModelPriceRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		response.BadRequest(c, "Invalid request: "+err.Error())
+		return
+	}
+
+	updated, err := h.service.Update(c.Request.Context(), id, func(p *service.ModelPrice) {
+		if req.Platform != nil {
+			p.Platform = *req.Platform
+		}
+		if req.Model != nil {
+			p.Model = *req.Model
+		}
+		if req.InputPrice != nil {
+			p.InputPrice = *req.InputPrice
+		}
+		if req.OutputPrice != nil {
+			p.OutputPrice = *req.OutputPrice
+		}
+		if req.CacheCreationPrice != nil {
+			p.CacheCreationPrice = *req.CacheCreationPrice
+		}
+		if req.CacheCreation1hPrice != nil {
+			p.CacheCreation1hPrice = *req.CacheCreation1hPrice
+		}
+		if req.CacheReadPrice != nil {
+			p.CacheReadPrice = *req.CacheReadPrice
+		}
+		if req.EffectiveFrom != nil {
+			p.EffectiveFrom = *req.EffectiveFrom
+		}
+		if req.Notes != nil {
+			p.Notes = *req.Notes
+		}
+	})
+	if err != nil {
+		response.ErrorFrom(c, err)
+		return
+	}
+	response.Success(c, updated)
+}
+
+// Delete 删除尚未生效的价格版本
+// DELETE /api/v1/admin/model-prices/:id
+func (h *ModelPriceHandler) Delete(c *gin.Context) {
+	id, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		response.BadRequest(c, "Invalid price ID")
+		return
+	}
+	if err := h.service.Delete(c.Request.Context(), id); err != nil {
+		response.ErrorFrom(c, err)
+		return
+	}
+	response.Success(c, gin.H{"message": "Model price deleted successfully"})
+}
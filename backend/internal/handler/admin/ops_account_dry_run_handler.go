@@ -0,0 +1,42 @@
+package admin
+
+import (
+	"net/http"
+	"strconv"
+
+	"github.com/Wei-Shaw/sub2api/internal/pkg/response"
+	"github.com/Wei-Shaw/sub2api/internal/service"
+	"github.com/gin-gonic/gin"
+)
+
+// DryRunAccount sends a canned prompt through the gateway Forward path pinned to the account,
+// bypassing scheduling and billing, and returns latency, status and the raw upstream error if any.
+// POST /api/v1/admin/accounts/:id/dry-run
+func (h *OpsHandler) DryRunAccount(c *gin.Context) {
+	if h.opsService == nil {
+		response.Error(c, http.StatusServiceUnavailable, "Ops service not available")
+		return
+	}
+
+	accountID, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil || accountID <= 0 {
+		response.BadRequest(c, "Invalid account ID")
+		return
+	}
+
+	var req service.OpsAccountDryRunRequest
+	// Body is optional: model and prompt fall back to platform defaults.
+	if c.Request.ContentLength > 0 {
+		if err := c.ShouldBindJSON(&req); err != nil {
+			response.BadRequest(c, "Invalid request: "+err.Error())
+			return
+		}
+	}
+
+	result, err := h.opsService.DryRunAccount(c.Request.Context(), accountID, &req)
+	if err != nil {
+		response.ErrorFrom(c, err)
+		return
+	}
+	response.Success(c, result)
+}
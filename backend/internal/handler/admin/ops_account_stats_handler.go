@@ -0,0 +1,32 @@
+package admin
+
+import (
+	"net/http"
+	"strconv"
+
+	"github.com/Wei-Shaw/sub2api/internal/pkg/response"
+	"github.com/gin-gonic/gin"
+)
+
+// GetAccountRealtimeStats returns 1m/5m/1h rolling RPS, tokens/s, 4xx/5xx rates and failover counts
+// for an account, served from the ops in-memory counters instead of usage-log queries.
+// GET /api/v1/admin/accounts/:id/realtime-stats
+func (h *OpsHandler) GetAccountRealtimeStats(c *gin.Context) {
+	if h.opsService == nil {
+		response.Error(c, http.StatusServiceUnavailable, "Ops service not available")
+		return
+	}
+
+	accountID, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil || accountID <= 0 {
+		response.BadRequest(c, "Invalid account ID")
+		return
+	}
+
+	stats, err := h.opsService.GetAccountRollingStats(c.Request.Context(), accountID)
+	if err != nil {
+		response.ErrorFrom(c, err)
+		return
+	}
+	response.Success(c, stats)
+}
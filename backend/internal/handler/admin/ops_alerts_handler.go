@@ -23,6 +23,10 @@ var validOpsAlertMetricTypes = []string{
 	"cpu_usage_percent",
 	"memory_usage_percent",
 	"concurrency_queue_depth",
+	"slo_burn_rate",
+	"slo_error_budget_remaining",
+	"slow_request_count",
+	"usage_anomaly_flagged_keys",
 }
 
 var validOpsAlertMetricTypeSet = func() map[string]struct{} {
@@ -82,13 +86,18 @@ func isPercentOrRateMetric(metricType string) bool {
 		"error_rate",
 		"upstream_error_rate",
 		"cpu_usage_percent",
-		"memory_usage_percent":
+		"memory_usage_percent",
+		"slo_error_budget_remaining":
 		return true
 	default:
 		return false
 	}
 }
 
+func isSLOMetric(metricType string) bool {
+	return strings.HasPrefix(metricType, "slo_")
+}
+
 func validateOpsAlertRulePayload(raw map[string]json.RawMessage) (*opsAlertRuleValidatedInput, error) {
 	if raw == nil {
 		return nil, fmt.Errorf("invalid request body")
@@ -140,6 +149,15 @@ func validateOpsAlertRulePayload(raw map[string]json.RawMessage) (*opsAlertRuleV
 		return nil, fmt.Errorf("threshold must be >= 0")
 	}
 
+	if isSLOMetric(metricType) {
+		var filters struct {
+			SLOID int64 `json:"slo_id"`
+		}
+		if v, ok := raw["filters"]; !ok || json.Unmarshal(v, &filters) != nil || filters.SLOID <= 0 {
+			return nil, fmt.Errorf("filters.slo_id is required for metric_type %s", metricType)
+		}
+	}
+
 	validated := &opsAlertRuleValidatedInput{
 		Name:       name,
 		MetricType: metricType,
@@ -0,0 +1,278 @@
+package admin
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/Wei-Shaw/sub2api/internal/pkg/response"
+	"github.com/Wei-Shaw/sub2api/internal/service"
+	"github.com/gin-gonic/gin"
+	"github.com/gorilla/websocket"
+)
+
+const (
+	liveTrafficSubscriberBuffer = 512
+	liveTrafficSSEHeartbeat     = 15 * time.Second
+	liveTrafficDroppedInterval  = 2 * time.Second
+)
+
+// liveTrafficMessage is the envelope pushed to WS/SSE clients.
+// type=request carries one finished gateway request; type=dropped reports events skipped for a slow client.
+type liveTrafficMessage struct {
+	Type    string                       `json:"type"`
+	Data    *service.OpsLiveTrafficEvent `json:"data,omitempty"`
+	Dropped int64                        `json:"dropped,omitempty"`
+}
+
+func parseLiveTrafficFilter(c *gin.Context) (service.OpsLiveTrafficFilter, bool) {
+	filter := service.OpsLiveTrafficFilter{
+		Platform: strings.TrimSpace(c.Query("platform")),
+	}
+	if v := strings.TrimSpace(c.Query("group_id")); v != "" {
+		id, err := strconv.ParseInt(v, 10, 64)
+		if err != nil || id <= 0 {
+			response.BadRequest(c, "Invalid group_id")
+			return filter, false
+		}
+		filter.GroupID = &id
+	}
+	if v := strings.TrimSpace(c.Query("account_id")); v != "" {
+		id, err := strconv.ParseInt(v, 10, 64)
+		if err != nil || id <= 0 {
+			response.BadRequest(c, "Invalid account_id")
+			return filter, false
+		}
+		filter.AccountID = id
+	}
+	if v := strings.TrimSpace(c.Query("errors_only")); v != "" {
+		b, err := strconv.ParseBool(v)
+		if err != nil {
+			response.BadRequest(c, "Invalid errors_only")
+			return filter, false
+		}
+		filter.ErrorOnly = b
+	}
+	return filter, true
+}
+
+// LiveTrafficWSHandler streams finished gateway requests (model, account, latency, tokens, status) via WebSocket.
+// Optional filters: platform, group_id, account_id, errors_only.
+// GET /api/v1/admin/ops/ws/traffic
+func (h *OpsHandler) LiveTrafficWSHandler(c *gin.Context) {
+	clientIP := requestClientIP(c.Request)
+
+	if h == nil || h.opsService == nil {
+		c.JSON(http.StatusServiceUnavailable, gin.H{"error": "ops service not initialized"})
+		return
+	}
+	filter, ok := parseLiveTrafficFilter(c)
+	if !ok {
+		return
+	}
+
+	// Same contract as the QPS socket: upgrade then close with a deterministic code when disabled.
+	if !h.opsService.IsRealtimeMonitoringEnabled(c.Request.Context()) {
+		conn, err := upgrader.Upgrade(c.Writer, c.Request, nil)
+		if err != nil {
+			c.JSON(http.StatusNotFound, gin.H{"error": "ops realtime monitoring is disabled"})
+			return
+		}
+		closeWS(conn, opsWSCloseRealtimeDisabled, "realtime_disabled")
+		return
+	}
+
+	if !tryAcquireOpsWSTotalSlot(opsWSLimits.MaxConns) {
+		log.Printf("[OpsWS] connection limit reached: %d/%d", wsConnCount.Load(), opsWSLimits.MaxConns)
+		c.JSON(http.StatusServiceUnavailable, gin.H{"error": "too many connections"})
+		return
+	}
+	defer func() {
+		if wsConnCount.Add(-1) == 0 {
+			scheduleQPSWSIdleStop()
+		}
+	}()
+
+	if opsWSLimits.MaxConnsPerIP > 0 && clientIP != "" {
+		if !tryAcquireOpsWSIPSlot(clientIP, opsWSLimits.MaxConnsPerIP) {
+			log.Printf("[OpsWS] per-ip connection limit reached: ip=%s limit=%d", clientIP, opsWSLimits.MaxConnsPerIP)
+			c.JSON(http.StatusServiceUnavailable, gin.H{"error": "too many connections"})
+			return
+		}
+		defer releaseOpsWSIPSlot(clientIP)
+	}
+
+	conn, err := upgrader.Upgrade(c.Writer, c.Request, nil)
+	if err != nil {
+		log.Printf("[OpsWS] upgrade failed: %v", err)
+		return
+	}
+	defer func() {
+		_ = conn.Close()
+	}()
+
+	sub := service.OpsLiveTraffic().Subscribe(filter, liveTrafficSubscriberBuffer)
+	defer sub.Close()
+
+	handleLiveTrafficWebSocket(c.Request.Context(), conn, sub)
+}
+
+func handleLiveTrafficWebSocket(parentCtx context.Context, conn *websocket.Conn, sub *service.OpsLiveTrafficSubscription) {
+	ctx, cancel := context.WithCancel(parentCtx)
+	defer cancel()
+
+	var wg sync.WaitGroup
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		defer cancel()
+
+		conn.SetReadLimit(qpsWSMaxReadBytes)
+		if err := conn.SetReadDeadline(time.Now().Add(qpsWSPongWait)); err != nil {
+			return
+		}
+		conn.SetPongHandler(func(string) error {
+			return conn.SetReadDeadline(time.Now().Add(qpsWSPongWait))
+		})
+		for {
+			if _, _, err := conn.ReadMessage(); err != nil {
+				if websocket.IsUnexpectedCloseError(err, websocket.CloseNormalClosure, websocket.CloseGoingAway, websocket.CloseNoStatusReceived) {
+					log.Printf("[OpsWS] traffic read failed: %v", err)
+				}
+				return
+			}
+		}
+	}()
+	defer func() {
+		_ = conn.Close()
+		wg.Wait()
+	}()
+
+	pingTicker := time.NewTicker(qpsWSPingInterval)
+	defer pingTicker.Stop()
+	droppedTicker := time.NewTicker(liveTrafficDroppedInterval)
+	defer droppedTicker.Stop()
+
+	writeJSON := func(msg liveTrafficMessage) error {
+		data, err := json.Marshal(msg)
+		if err != nil {
+			return err
+		}
+		if err := conn.SetWriteDeadline(time.Now().Add(qpsWSWriteTimeout)); err != nil {
+			return err
+		}
+		return conn.WriteMessage(websocket.TextMessage, data)
+	}
+
+	for {
+		select {
+		case ev, ok := <-sub.Events():
+			if !ok {
+				return
+			}
+			if err := writeJSON(liveTrafficMessage{Type: "request", Data: &ev}); err != nil {
+				log.Printf("[OpsWS] traffic write failed: %v", err)
+				return
+			}
+		case <-droppedTicker.C:
+			if n := sub.TakeDropped(); n > 0 {
+				if err := writeJSON(liveTrafficMessage{Type: "dropped", Dropped: n}); err != nil {
+					return
+				}
+			}
+		case <-pingTicker.C:
+			if err := conn.SetWriteDeadline(time.Now().Add(qpsWSWriteTimeout)); err != nil {
+				return
+			}
+			if err := conn.WriteMessage(websocket.PingMessage, nil); err != nil {
+				log.Printf("[OpsWS] traffic ping failed: %v", err)
+				return
+			}
+		case <-ctx.Done():
+			_ = conn.SetWriteDeadline(time.Now().Add(qpsWSWriteTimeout))
+			_ = conn.WriteMessage(websocket.CloseMessage, websocket.FormatCloseMessage(websocket.CloseNormalClosure, ""))
+			return
+		}
+	}
+}
+
+// LiveTrafficSSEHandler streams the same live request feed as Server-Sent Events
+// (for clients that can send an Authorization header but not a WebSocket subprotocol, e.g. curl).
+// GET /api/v1/admin/ops/traffic/stream
+func (h *OpsHandler) LiveTrafficSSEHandler(c *gin.Context) {
+	if h.opsService == nil {
+		response.Error(c, http.StatusServiceUnavailable, "Ops service not available")
+		return
+	}
+	if err := h.opsService.RequireMonitoringEnabled(c.Request.Context()); err != nil {
+		response.ErrorFrom(c, err)
+		return
+	}
+	if !h.opsService.IsRealtimeMonitoringEnabled(c.Request.Context()) {
+		response.Error(c, http.StatusNotFound, "ops realtime monitoring is disabled")
+		return
+	}
+	filter, ok := parseLiveTrafficFilter(c)
+	if !ok {
+		return
+	}
+	flusher, ok := c.Writer.(http.Flusher)
+	if !ok {
+		response.InternalError(c, "Streaming not supported")
+		return
+	}
+
+	sub := service.OpsLiveTraffic().Subscribe(filter, liveTrafficSubscriberBuffer)
+	defer sub.Close()
+
+	c.Header("Content-Type", "text/event-stream")
+	c.Header("Cache-Control", "no-cache")
+	c.Header("Connection", "keep-alive")
+	c.Header("X-Accel-Buffering", "no")
+	c.Status(http.StatusOK)
+	flusher.Flush()
+
+	heartbeat := time.NewTicker(liveTrafficSSEHeartbeat)
+	defer heartbeat.Stop()
+	droppedTicker := time.NewTicker(liveTrafficDroppedInterval)
+	defer droppedTicker.Stop()
+
+	writeEvent := func(msg liveTrafficMessage) bool {
+		data, err := json.Marshal(msg)
+		if err != nil {
+			return false
+		}
+		if _, err := fmt.Fprintf(c.Writer, "event: %s\ndata: %s\n\n", msg.Type, data); err != nil {
+			return false
+		}
+		flusher.Flush()
+		return true
+	}
+
+	ctx := c.Request.Context()
+	for {
+		select {
+		case ev, ok := <-sub.Events():
+			if !ok || !writeEvent(liveTrafficMessage{Type: "request", Data: &ev}) {
+				return
+			}
+		case <-droppedTicker.C:
+			if n := sub.TakeDropped(); n > 0 && !writeEvent(liveTrafficMessage{Type: "dropped", Dropped: n}) {
+				return
+			}
+		case <-heartbeat.C:
+			if _, err := fmt.Fprint(c.Writer, ": ping\n\n"); err != nil {
+				return
+			}
+			flusher.Flush()
+		case <-ctx.Done():
+			return
+		}
+	}
+}
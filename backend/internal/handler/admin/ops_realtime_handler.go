@@ -63,6 +63,104 @@ func (h *OpsHandler) GetConcurrencyStats(c *gin.Context) {
 	response.Success(c, payload)
 }
 
+// GetAccountWorkerPoolStats returns per-account upstream worker pool saturation metrics.
+// GET /api/v1/admin/ops/account-worker-pools
+func (h *OpsHandler) GetAccountWorkerPoolStats(c *gin.Context) {
+	if h.opsService == nil {
+		response.Error(c, http.StatusServiceUnavailable, "Ops service not available")
+		return
+	}
+
+	enabled, pools, err := h.opsService.GetAccountWorkerPoolStats(c.Request.Context())
+	if err != nil {
+		response.ErrorFrom(c, err)
+		return
+	}
+	response.Success(c, gin.H{
+		"enabled":   enabled,
+		"pools":     pools,
+		"timestamp": time.Now().UTC(),
+	})
+}
+
+// GetConcurrencyReconcileResult returns the leaked slot / wait-counter counts recovered by this instance's startup reconciliation.
+// GET /api/v1/admin/ops/concurrency/reconcile
+func (h *OpsHandler) GetConcurrencyReconcileResult(c *gin.Context) {
+	if h.opsService == nil {
+		response.Error(c, http.StatusServiceUnavailable, "Ops service not available")
+		return
+	}
+
+	enabled, result, err := h.opsService.GetConcurrencyReconcileResult(c.Request.Context())
+	if err != nil {
+		response.ErrorFrom(c, err)
+		return
+	}
+	response.Success(c, gin.H{
+		"enabled": enabled,
+		"result":  result,
+	})
+}
+
+// GetMemoryGuardStats returns gateway memory guard usage and rejection counters.
+// GET /api/v1/admin/ops/memory-guard
+func (h *OpsHandler) GetMemoryGuardStats(c *gin.Context) {
+	if h.opsService == nil {
+		response.Error(c, http.StatusServiceUnavailable, "Ops service not available")
+		return
+	}
+
+	stats, err := h.opsService.GetMemoryGuardStats(c.Request.Context())
+	if err != nil {
+		response.ErrorFrom(c, err)
+		return
+	}
+	response.Success(c, gin.H{
+		"stats":     stats,
+		"timestamp": time.Now().UTC(),
+	})
+}
+
+// GetStreamAbuseFlags returns API keys flagged by streaming abuse detection.
+// GET /api/v1/admin/ops/stream-abuse
+func (h *OpsHandler) GetStreamAbuseFlags(c *gin.Context) {
+	if h.opsService == nil {
+		response.Error(c, http.StatusServiceUnavailable, "Ops service not available")
+		return
+	}
+
+	enabled, flags, err := h.opsService.GetStreamAbuseFlags(c.Request.Context())
+	if err != nil {
+		response.ErrorFrom(c, err)
+		return
+	}
+	response.Success(c, gin.H{
+		"enabled":   enabled,
+		"flags":     flags,
+		"timestamp": time.Now().UTC(),
+	})
+}
+
+// GetUsageAnomalyFlags returns API keys flagged by usage anomaly detection.
+// GET /api/v1/admin/ops/usage-anomalies
+func (h *OpsHandler) GetUsageAnomalyFlags(c *gin.Context) {
+	if h.opsService == nil {
+		response.Error(c, http.StatusServiceUnavailable, "Ops service not available")
+		return
+	}
+
+	enabled, flags, err := h.opsService.GetUsageAnomalyFlags(c.Request.Context())
+	if err != nil {
+		response.ErrorFrom(c, err)
+		return
+	}
+	response.Success(c, gin.H{
+		"enabled":   enabled,
+		"flags":     flags,
+		"timestamp": time.Now().UTC(),
+	})
+}
+
 // GetUserConcurrencyStats returns real-time concurrency usage for all active users.
 // GET /api/v1/admin/ops/user-concurrency
 func (h *OpsHandler) GetUserConcurrencyStats(c *gin.Context) {
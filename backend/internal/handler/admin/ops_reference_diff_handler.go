@@ -0,0 +1,56 @@
+package admin
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"github.com/Wei-Shaw/sub2api/internal/pkg/response"
+	"github.com/Wei-Shaw/sub2api/internal/service"
+	"github.com/gin-gonic/gin"
+)
+
+type opsReferenceDiffRequest struct {
+	RequestPath        string          `json:"request_path"`
+	Body               json.RawMessage `json:"body"`
+	ErrorID            int64           `json:"error_id"`
+	Model              string          `json:"model"`
+	PooledAccountID    int64           `json:"pooled_account_id"`
+	GroupID            *int64          `json:"group_id"`
+	ReferenceAccountID int64           `json:"reference_account_id" binding:"required,gt=0"`
+}
+
+// CompareWithReference sends the same non-streaming request to a pooled account and a
+// first-party reference account and returns a structural diff of both responses.
+// The request comes from "body" or from a captured ops error log ("error_id").
+// POST /api/v1/admin/ops/reference-diff
+func (h *OpsHandler) CompareWithReference(c *gin.Context) {
+	if h.opsService == nil {
+		response.Error(c, http.StatusServiceUnavailable, "Ops service not available")
+		return
+	}
+
+	var req opsReferenceDiffRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		response.BadRequest(c, "Invalid request: "+err.Error())
+		return
+	}
+	if req.ErrorID <= 0 && len(req.Body) == 0 {
+		response.BadRequest(c, "body or error_id is required")
+		return
+	}
+
+	result, err := h.opsService.CompareWithReference(c.Request.Context(), &service.OpsReferenceDiffInput{
+		RequestPath:        req.RequestPath,
+		Body:               req.Body,
+		ErrorID:            req.ErrorID,
+		Model:              req.Model,
+		PooledAccountID:    req.PooledAccountID,
+		GroupID:            req.GroupID,
+		ReferenceAccountID: req.ReferenceAccountID,
+	})
+	if err != nil {
+		response.ErrorFrom(c, err)
+		return
+	}
+	response.Success(c, result)
+}
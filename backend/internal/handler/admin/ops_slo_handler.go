@@ -0,0 +1,130 @@
+package admin
+
+import (
+	"net/http"
+	"strconv"
+
+	"github.com/Wei-Shaw/sub2api/internal/pkg/response"
+	"github.com/Wei-Shaw/sub2api/internal/service"
+	"github.com/gin-gonic/gin"
+)
+
+func parseOpsSLOID(c *gin.Context) (int64, bool) {
+	id, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil || id <= 0 {
+		response.BadRequest(c, "Invalid SLO ID")
+		return 0, false
+	}
+	return id, true
+}
+
+// ListSLOs returns all SLO definitions.
+// GET /api/v1/admin/ops/slos
+func (h *OpsHandler) ListSLOs(c *gin.Context) {
+	if h.opsService == nil {
+		response.Error(c, http.StatusServiceUnavailable, "Ops service not available")
+		return
+	}
+	slos, err := h.opsService.ListSLOs(c.Request.Context())
+	if err != nil {
+		response.ErrorFrom(c, err)
+		return
+	}
+	response.Success(c, slos)
+}
+
+// CreateSLO creates an SLO definition (availability or TTFT objective, optionally scoped to a group/platform).
+// POST /api/v1/admin/ops/slos
+func (h *OpsHandler) CreateSLO(c *gin.Context) {
+	if h.opsService == nil {
+		response.Error(c, http.StatusServiceUnavailable, "Ops service not available")
+		return
+	}
+	var req service.OpsSLODefinition
+	if err := c.ShouldBindJSON(&req); err != nil {
+		response.BadRequest(c, "Invalid request body")
+		return
+	}
+	created, err := h.opsService.CreateSLO(c.Request.Context(), &req)
+	if err != nil {
+		response.ErrorFrom(c, err)
+		return
+	}
+	response.Success(c, created)
+}
+
+// UpdateSLO replaces an SLO definition.
+// PUT /api/v1/admin/ops/slos/:id
+func (h *OpsHandler) UpdateSLO(c *gin.Context) {
+	if h.opsService == nil {
+		response.Error(c, http.StatusServiceUnavailable, "Ops service not available")
+		return
+	}
+	id, ok := parseOpsSLOID(c)
+	if !ok {
+		return
+	}
+	var req service.OpsSLODefinition
+	if err := c.ShouldBindJSON(&req); err != nil {
+		response.BadRequest(c, "Invalid request body")
+		return
+	}
+	updated, err := h.opsService.UpdateSLO(c.Request.Context(), id, &req)
+	if err != nil {
+		response.ErrorFrom(c, err)
+		return
+	}
+	response.Success(c, updated)
+}
+
+// DeleteSLO deletes an SLO definition.
+// DELETE /api/v1/admin/ops/slos/:id
+func (h *OpsHandler) DeleteSLO(c *gin.Context) {
+	if h.opsService == nil {
+		response.Error(c, http.StatusServiceUnavailable, "Ops service not available")
+		return
+	}
+	id, ok := parseOpsSLOID(c)
+	if !ok {
+		return
+	}
+	if err := h.opsService.DeleteSLO(c.Request.Context(), id); err != nil {
+		response.ErrorFrom(c, err)
+		return
+	}
+	response.Success(c, gin.H{"deleted": true})
+}
+
+// GetSLOStatuses returns rolling compliance, remaining error budget and short-window burn rates for every SLO.
+// GET /api/v1/admin/ops/slos/status
+func (h *OpsHandler) GetSLOStatuses(c *gin.Context) {
+	if h.opsService == nil {
+		response.Error(c, http.StatusServiceUnavailable, "Ops service not available")
+		return
+	}
+	statuses, err := h.opsService.GetSLOStatuses(c.Request.Context())
+	if err != nil {
+		response.ErrorFrom(c, err)
+		return
+	}
+	response.Success(c, statuses)
+}
+
+// GetSLOStatus returns rolling compliance and error budget for a single SLO.
+// GET /api/v1/admin/ops/slos/:id/status
+func (h *OpsHandler) GetSLOStatus(c *gin.Context) {
+	if h.opsService == nil {
+		response.Error(c, http.StatusServiceUnavailable, "Ops service not available")
+		return
+	}
+	id, ok := parseOpsSLOID(c)
+	if !ok {
+		return
+	}
+	status, err := h.opsService.GetSLOStatus(c.Request.Context(), id)
+	if err != nil {
+		response.ErrorFrom(c, err)
+		return
+	}
+	response.Success(c, status)
+}
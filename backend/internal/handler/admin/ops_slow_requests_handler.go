@@ -0,0 +1,97 @@
+package admin
+
+import (
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/Wei-Shaw/sub2api/internal/pkg/response"
+	"github.com/Wei-Shaw/sub2api/internal/service"
+	"github.com/gin-gonic/gin"
+)
+
+// parseOpsSlowRequestFilter parses the shared time range / platform / group / account / model / reason filters.
+func parseOpsSlowRequestFilter(c *gin.Context) (*service.OpsSlowRequestFilter, bool) {
+	startTime, endTime, err := parseOpsTimeRange(c, "1h")
+	if err != nil {
+		response.BadRequest(c, err.Error())
+		return nil, false
+	}
+
+	filter := &service.OpsSlowRequestFilter{
+		StartTime: &startTime,
+		EndTime:   &endTime,
+		Platform:  strings.TrimSpace(c.Query("platform")),
+		Model:     strings.TrimSpace(c.Query("model")),
+	}
+
+	if v := strings.TrimSpace(c.Query("group_id")); v != "" {
+		id, err := strconv.ParseInt(v, 10, 64)
+		if err != nil || id <= 0 {
+			response.BadRequest(c, "Invalid group_id")
+			return nil, false
+		}
+		filter.GroupID = &id
+	}
+	if v := strings.TrimSpace(c.Query("account_id")); v != "" {
+		id, err := strconv.ParseInt(v, 10, 64)
+		if err != nil || id <= 0 {
+			response.BadRequest(c, "Invalid account_id")
+			return nil, false
+		}
+		filter.AccountID = &id
+	}
+	if v := strings.ToLower(strings.TrimSpace(c.Query("reason"))); v != "" {
+		if v != service.OpsSlowRequestReasonDuration && v != service.OpsSlowRequestReasonTTFT {
+			response.BadRequest(c, "Invalid reason")
+			return nil, false
+		}
+		filter.Reason = v
+	}
+	return filter, true
+}
+
+// ListSlowRequests returns requests that exceeded the configured duration/TTFT thresholds.
+// GET /api/v1/admin/ops/slow-requests
+func (h *OpsHandler) ListSlowRequests(c *gin.Context) {
+	if h.opsService == nil {
+		response.Error(c, http.StatusServiceUnavailable, "Ops service not available")
+		return
+	}
+
+	filter, ok := parseOpsSlowRequestFilter(c)
+	if !ok {
+		return
+	}
+	filter.Page, filter.PageSize = response.ParsePagination(c)
+	if filter.PageSize > 100 {
+		filter.PageSize = 100
+	}
+
+	out, err := h.opsService.ListSlowRequests(c.Request.Context(), filter)
+	if err != nil {
+		response.ErrorFrom(c, err)
+		return
+	}
+	response.Paginated(c, out.Items, out.Total, out.Page, out.PageSize)
+}
+
+// GetSlowRequestSummary aggregates slow requests by account + model to surface degrading upstreams.
+// GET /api/v1/admin/ops/slow-requests/summary
+func (h *OpsHandler) GetSlowRequestSummary(c *gin.Context) {
+	if h.opsService == nil {
+		response.Error(c, http.StatusServiceUnavailable, "Ops service not available")
+		return
+	}
+
+	filter, ok := parseOpsSlowRequestFilter(c)
+	if !ok {
+		return
+	}
+	items, err := h.opsService.GetSlowRequestSummary(c.Request.Context(), filter)
+	if err != nil {
+		response.ErrorFrom(c, err)
+		return
+	}
+	response.Success(c, items)
+}
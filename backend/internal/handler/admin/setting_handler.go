@@ -796,3 +796,434 @@ func (h *SettingHandler) UpdateStreamTimeoutSettings(c *gin.Context) {
 		ThresholdWindowMinutes: updatedSettings.ThresholdWindowMinutes,
 	})
 }
+
+// GetModelAliasSettings 获取模型别名配置
+// GET /api/v1/admin/settings/model-aliases
+func (h *SettingHandler) GetModelAliasSettings(c *gin.Context) {
+	settings, err := h.settingService.GetModelAliasSettings(c.Request.Context())
+	if err != nil {
+		response.ErrorFrom(c, err)
+		return
+	}
+
+	response.Success(c, modelAliasSettingsToDTO(settings))
+}
+
+// UpdateModelAliasSettingsRequest 更新模型别名配置请求
+type UpdateModelAliasSettingsRequest struct {
+	Enabled bool                 `json:"enabled"`
+	Rules   []dto.ModelAliasRule `json:"rules"`
+}
+
+// UpdateModelAliasSettings 更新模型别名配置
+// PUT /api/v1/admin/settings/model-aliases
+func (h *SettingHandler) UpdateModelAliasSettings(c *gin.Context) {
+	var req UpdateModelAliasSettingsRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		response.BadRequest(c, "Invalid request: "+err.Error())
+		return
+	}
+
+	settings := &service.ModelAliasSettings{
+		Enabled: req.Enabled,
+		Rules:   make([]service.ModelAliasRule, 0, len(req.Rules)),
+	}
+	for _, rule := range req.Rules {
+		settings.Rules = append(settings.Rules, service.ModelAliasRule{
+			From:               rule.From,
+			To:                 rule.To,
+			Platform:           rule.Platform,
+			Deprecated:         rule.Deprecated,
+			DeprecationMessage: rule.DeprecationMessage,
+		})
+	}
+
+	if err := h.settingService.SetModelAliasSettings(c.Request.Context(), settings); err != nil {
+		response.BadRequest(c, err.Error())
+		return
+	}
+
+	// 重新获取设置返回
+	updatedSettings, err := h.settingService.GetModelAliasSettings(c.Request.Context())
+	if err != nil {
+		response.ErrorFrom(c, err)
+		return
+	}
+
+	response.Success(c, modelAliasSettingsToDTO(updatedSettings))
+}
+
+func modelAliasSettingsToDTO(settings *service.ModelAliasSettings) dto.ModelAliasSettings {
+	out := dto.ModelAliasSettings{
+		Enabled: settings.Enabled,
+		Rules:   make([]dto.ModelAliasRule, 0, len(settings.Rules)),
+	}
+	for _, rule := range settings.Rules {
+		out.Rules = append(out.Rules, dto.ModelAliasRule{
+			From:               rule.From,
+			To:                 rule.To,
+			Platform:           rule.Platform,
+			Deprecated:         rule.Deprecated,
+			DeprecationMessage: rule.DeprecationMessage,
+		})
+	}
+	return out
+}
+
+// GetRequestStripSettings 获取请求字段剔除配置
+// GET /api/v1/admin/settings/request-strip
+func (h *SettingHandler) GetRequestStripSettings(c *gin.Context) {
+	settings, err := h.settingService.GetRequestStripSettings(c.Request.Context())
+	if err != nil {
+		response.ErrorFrom(c, err)
+		return
+	}
+
+	response.Success(c, requestStripSettingsToDTO(settings))
+}
+
+// UpdateRequestStripSettingsRequest 更新请求字段剔除配置请求
+type UpdateRequestStripSettingsRequest struct {
+	Enabled bool                   `json:"enabled"`
+	Rules   []dto.RequestStripRule `json:"rules"`
+}
+
+// UpdateRequestStripSettings 更新请求字段剔除配置
+// PUT /api/v1/admin/settings/request-strip
+func (h *SettingHandler) UpdateRequestStripSettings(c *gin.Context) {
+	var req UpdateRequestStripSettingsRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		response.BadRequest(c, "Invalid request: "+err.Error())
+		return
+	}
+
+	settings := &service.RequestStripSettings{
+		Enabled: req.Enabled,
+		Rules:   make([]service.RequestStripRule, 0, len(req.Rules)),
+	}
+	for _, rule := range req.Rules {
+		settings.Rules = append(settings.Rules, service.RequestStripRule{
+			Path:     rule.Path,
+			Protocol: rule.Protocol,
+		})
+	}
+
+	if err := h.settingService.SetRequestStripSettings(c.Request.Context(), settings); err != nil {
+		response.BadRequest(c, err.Error())
+		return
+	}
+
+	// 重新获取设置返回
+	updatedSettings, err := h.settingService.GetRequestStripSettings(c.Request.Context())
+	if err != nil {
+		response.ErrorFrom(c, err)
+		return
+	}
+
+	response.Success(c, requestStripSettingsToDTO(updatedSettings))
+}
+
+func requestStripSettingsToDTO(settings *service.RequestStripSettings) dto.RequestStripSettings {
+	out := dto.RequestStripSettings{
+		Enabled: settings.Enabled,
+		Rules:   make([]dto.RequestStripRule, 0, len(settings.Rules)),
+	}
+	for _, rule := range settings.Rules {
+		out.Rules = append(out.Rules, dto.RequestStripRule{
+			Path:     rule.Path,
+			Protocol: rule.Protocol,
+		})
+	}
+	return out
+}
+
+// GetRequestSanitizeSettings 获取请求净化配置
+// GET /api/v1/admin/settings/request-sanitize
+func (h *SettingHandler) GetRequestSanitizeSettings(c *gin.Context) {
+	settings, err := h.settingService.GetRequestSanitizeSettings(c.Request.Context())
+	if err != nil {
+		response.ErrorFrom(c, err)
+		return
+	}
+
+	response.Success(c, requestSanitizeSettingsToDTO(settings))
+}
+
+// UpdateRequestSanitizeSettingsRequest 更新请求净化配置请求
+type UpdateRequestSanitizeSettingsRequest struct {
+	Enabled bool                      `json:"enabled"`
+	Rules   []dto.RequestSanitizeRule `json:"rules"`
+}
+
+// UpdateRequestSanitizeSettings 更新请求净化配置
+// PUT /api/v1/admin/settings/request-sanitize
+func (h *SettingHandler) UpdateRequestSanitizeSettings(c *gin.Context) {
+	var req UpdateRequestSanitizeSettingsRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		response.BadRequest(c, "Invalid request: "+err.Error())
+		return
+	}
+
+	settings := &service.RequestSanitizeSettings{
+		Enabled: req.Enabled,
+		Rules:   make([]service.RequestSanitizeRule, 0, len(req.Rules)),
+	}
+	for _, rule := range req.Rules {
+		settings.Rules = append(settings.Rules, service.RequestSanitizeRule{
+			Protocol:         rule.Protocol,
+			GroupIDs:         rule.GroupIDs,
+			Path:             rule.Path,
+			Values:           rule.Values,
+			AllowedToolTypes: rule.AllowedToolTypes,
+			Action:           rule.Action,
+			Message:          rule.Message,
+		})
+	}
+
+	if err := h.settingService.SetRequestSanitizeSettings(c.Request.Context(), settings); err != nil {
+		response.BadRequest(c, err.Error())
+		return
+	}
+
+	// 重新获取设置返回
+	updatedSettings, err := h.settingService.GetRequestSanitizeSettings(c.Request.Context())
+	if err != nil {
+		response.ErrorFrom(c, err)
+		return
+	}
+
+	response.Success(c, requestSanitizeSettingsToDTO(updatedSettings))
+}
+
+func requestSanitizeSettingsToDTO(settings *service.RequestSanitizeSettings) dto.RequestSanitizeSettings {
+	out := dto.RequestSanitizeSettings{
+		Enabled: settings.Enabled,
+		Rules:   make([]dto.RequestSanitizeRule, 0, len(settings.Rules)),
+	}
+	for _, rule := range settings.Rules {
+		out.Rules = append(out.Rules, dto.RequestSanitizeRule{
+			Protocol:         rule.Protocol,
+			GroupIDs:         rule.GroupIDs,
+			Path:             rule.Path,
+			Values:           rule.Values,
+			AllowedToolTypes: rule.AllowedToolTypes,
+			Action:           rule.Action,
+			Message:          rule.Message,
+		})
+	}
+	return out
+}
+
+// GetBudgetAlertSettings 获取预算告警配置
+// GET /api/v1/admin/settings/budget-alerts
+func (h *SettingHandler) GetBudgetAlertSettings(c *gin.Context) {
+	settings, err := h.settingService.GetBudgetAlertSettings(c.Request.Context())
+	if err != nil {
+		response.ErrorFrom(c, err)
+		return
+	}
+
+	response.Success(c, budgetAlertSettingsToDTO(settings))
+}
+
+// UpdateBudgetAlertSettingsRequest 更新预算告警配置请求
+type UpdateBudgetAlertSettingsRequest struct {
+	Enabled         bool     `json:"enabled"`
+	Thresholds      []int    `json:"thresholds"`
+	Scopes          []string `json:"scopes"`
+	WebhookURL      string   `json:"webhook_url"`
+	WebhookSecret   string   `json:"webhook_secret"`
+	EmailRecipients []string `json:"email_recipients"`
+}
+
+// UpdateBudgetAlertSettings 更新预算告警配置
+// PUT /api/v1/admin/settings/budget-alerts
+func (h *SettingHandler) UpdateBudgetAlertSettings(c *gin.Context) {
+	var req UpdateBudgetAlertSettingsRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		response.BadRequest(c, "Invalid request: "+err.Error())
+		return
+	}
+
+	settings := &service.BudgetAlertSettings{
+		Enabled:         req.Enabled,
+		Thresholds:      req.Thresholds,
+		Scopes:          req.Scopes,
+		WebhookURL:      req.WebhookURL,
+		WebhookSecret:   req.WebhookSecret,
+		EmailRecipients: req.EmailRecipients,
+	}
+	if err := h.settingService.SetBudgetAlertSettings(c.Request.Context(), settings); err != nil {
+		response.BadRequest(c, err.Error())
+		return
+	}
+
+	// 重新获取设置返回
+	updatedSettings, err := h.settingService.GetBudgetAlertSettings(c.Request.Context())
+	if err != nil {
+		response.ErrorFrom(c, err)
+		return
+	}
+
+	response.Success(c, budgetAlertSettingsToDTO(updatedSettings))
+}
+
+func budgetAlertSettingsToDTO(settings *service.BudgetAlertSettings) dto.BudgetAlertSettings {
+	return dto.BudgetAlertSettings{
+		Enabled:         settings.Enabled,
+		Thresholds:      settings.Thresholds,
+		Scopes:          settings.Scopes,
+		WebhookURL:      settings.WebhookURL,
+		WebhookSecret:   settings.WebhookSecret,
+		EmailRecipients: settings.EmailRecipients,
+	}
+}
+
+// GetUpstreamErrorMappingSettings 获取上游错误映射配置（含内置映射）
+// GET /api/v1/admin/settings/upstream-error-mappings
+func (h *SettingHandler) GetUpstreamErrorMappingSettings(c *gin.Context) {
+	settings, err := h.settingService.GetUpstreamErrorMappingSettings(c.Request.Context())
+	if err != nil {
+		response.ErrorFrom(c, err)
+		return
+	}
+
+	response.Success(c, upstreamErrorMappingSettingsToDTO(settings))
+}
+
+// UpdateUpstreamErrorMappingSettingsRequest 更新上游错误映射配置请求
+type UpdateUpstreamErrorMappingSettingsRequest struct {
+	Enabled bool                           `json:"enabled"`
+	Rules   []dto.UpstreamErrorMappingRule `json:"rules"`
+}
+
+// UpdateUpstreamErrorMappingSettings 更新上游错误映射配置
+// PUT /api/v1/admin/settings/upstream-error-mappings
+func (h *SettingHandler) UpdateUpstreamErrorMappingSettings(c *gin.Context) {
+	var req UpdateUpstreamErrorMappingSettingsRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		response.BadRequest(c, "Invalid request: "+err.Error())
+		return
+	}
+
+	settings := &service.UpstreamErrorMappingSettings{
+		Enabled: req.Enabled,
+		Rules:   make([]service.UpstreamErrorMappingRule, 0, len(req.Rules)),
+	}
+	for _, rule := range req.Rules {
+		settings.Rules = append(settings.Rules, service.UpstreamErrorMappingRule(rule))
+	}
+
+	if err := h.settingService.SetUpstreamErrorMappingSettings(c.Request.Context(), settings); err != nil {
+		response.BadRequest(c, err.Error())
+		return
+	}
+
+	// 重新获取设置返回
+	updatedSettings, err := h.settingService.GetUpstreamErrorMappingSettings(c.Request.Context())
+	if err != nil {
+		response.ErrorFrom(c, err)
+		return
+	}
+
+	response.Success(c, upstreamErrorMappingSettingsToDTO(updatedSettings))
+}
+
+func upstreamErrorMappingSettingsToDTO(settings *service.UpstreamErrorMappingSettings) dto.UpstreamErrorMappingSettings {
+	builtin := service.BuiltinUpstreamErrorMappingRules()
+	out := dto.UpstreamErrorMappingSettings{
+		Enabled:      settings.Enabled,
+		Rules:        make([]dto.UpstreamErrorMappingRule, 0, len(settings.Rules)),
+		BuiltinRules: make([]dto.UpstreamErrorMappingRule, 0, len(builtin)),
+	}
+	for _, rule := range settings.Rules {
+		out.Rules = append(out.Rules, dto.UpstreamErrorMappingRule(rule))
+	}
+	for _, rule := range builtin {
+		out.BuiltinRules = append(out.BuiltinRules, dto.UpstreamErrorMappingRule(rule))
+	}
+	return out
+}
+
+// GetVirtualModelSettings 获取虚拟模型配置
+// GET /api/v1/admin/settings/virtual-models
+func (h *SettingHandler) GetVirtualModelSettings(c *gin.Context) {
+	settings, err := h.settingService.GetVirtualModelSettings(c.Request.Context())
+	if err != nil {
+		response.ErrorFrom(c, err)
+		return
+	}
+
+	response.Success(c, virtualModelSettingsToDTO(settings))
+}
+
+// UpdateVirtualModelSettingsRequest 更新虚拟模型配置请求
+type UpdateVirtualModelSettingsRequest struct {
+	Enabled bool               `json:"enabled"`
+	Models  []dto.VirtualModel `json:"models"`
+}
+
+// UpdateVirtualModelSettings 更新虚拟模型配置
+// PUT /api/v1/admin/settings/virtual-models
+func (h *SettingHandler) UpdateVirtualModelSettings(c *gin.Context) {
+	var req UpdateVirtualModelSettingsRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		response.BadRequest(c, "Invalid request: "+err.Error())
+		return
+	}
+
+	settings := &service.VirtualModelSettings{
+		Enabled: req.Enabled,
+		Models:  make([]service.VirtualModel, 0, len(req.Models)),
+	}
+	for _, vm := range req.Models {
+		targets := make([]service.VirtualModelTarget, 0, len(vm.Targets))
+		for _, target := range vm.Targets {
+			targets = append(targets, service.VirtualModelTarget{
+				Model:      target.Model,
+				AccountIDs: target.AccountIDs,
+			})
+		}
+		settings.Models = append(settings.Models, service.VirtualModel{
+			Name:     vm.Name,
+			Platform: vm.Platform,
+			Targets:  targets,
+		})
+	}
+
+	if err := h.settingService.SetVirtualModelSettings(c.Request.Context(), settings); err != nil {
+		response.BadRequest(c, err.Error())
+		return
+	}
+
+	// 重新获取设置返回
+	updatedSettings, err := h.settingService.GetVirtualModelSettings(c.Request.Context())
+	if err != nil {
+		response.ErrorFrom(c, err)
+		return
+	}
+
+	response.Success(c, virtualModelSettingsToDTO(updatedSettings))
+}
+
+func virtualModelSettingsToDTO(settings *service.VirtualModelSettings) dto.VirtualModelSettings {
+	out := dto.VirtualModelSettings{
+		Enabled: settings.Enabled,
+		Models:  make([]dto.VirtualModel, 0, len(settings.Models)),
+	}
+	for _, vm := range settings.Models {
+		targets := make([]dto.VirtualModelTarget, 0, len(vm.Targets))
+		for _, target := range vm.Targets {
+			targets = append(targets, dto.VirtualModelTarget{
+				Model:      target.Model,
+				AccountIDs: target.AccountIDs,
+			})
+		}
+		out.Models = append(out.Models, dto.VirtualModel{
+			Name:     vm.Name,
+			Platform: vm.Platform,
+			Targets:  targets,
+		})
+	}
+	return out
+}
@@ -0,0 +1,87 @@
+package admin
+
+import (
+	"strconv"
+
+	"github.com/Wei-Shaw/sub2api/internal/pkg/response"
+	"github.com/Wei-Shaw/sub2api/internal/service"
+	"github.com/gin-gonic/gin"
+)
+
+// SpendCapHandler 处理用户消费软/硬上限覆盖的 HTTP 请求
+type SpendCapHandler struct {
+	service *service.SpendCapService
+}
+
+// NewSpendCapHandler 创建用户消费上限处理器
+func NewSpendCapHandler(service *service.SpendCapService) *SpendCapHandler {
+	return &SpendCapHandler{service: service}
+}
+
+// UpdateSpendCapRequest 设置用户覆盖（单位：USD）；省略或为 null 的字段沿用全局默认值，0 表示不限制
+type UpdateSpendCapRequest struct {
+	DailySoftUSD   *float64 `json:"daily_soft_usd"`
+	DailyHardUSD   *float64 `json:"daily_hard_usd"`
+	MonthlySoftUSD *float64 `json:"monthly_soft_usd"`
+	MonthlyHardUSD *float64 `json:"monthly_hard_usd"`
+	Notes          string   `json:"notes"`
+}
+
+// Get 获取用户的默认/覆盖/生效上限与当期消费
+// GET /api/v1/admin/users/:id/spend-cap
+func (h *SpendCapHandler) Get(c *gin.Context) {
+	userID, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		response.BadRequest(c, "Invalid user ID")
+		return
+	}
+	result, err := h.service.GetUserSpendCap(c.Request.Context(), userID)
+	if err != nil {
+		response.ErrorFrom(c, err)
+		return
+	}
+	response.Success(c, result)
+}
+
+// Update 设置用户覆盖（整体替换）
+// PUT /api/v1/admin/users/:id/spend-cap
+func (h *SpendCapHandler) Update(c *gin.Context) {
+	userID, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		response.BadRequest(c, "Invalid user ID")
+		return
+	}
+	var req UpdateSpendCapRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		response.BadRequest(c, "Invalid request: "+err.Error())
+		return
+	}
+	result, err := h.service.SetOverride(c.Request.Context(), &service.SpendCapOverride{
+		UserID:         userID,
+		DailySoftUSD:   req.DailySoftUSD,
+		DailyHardUSD:   req.DailyHardUSD,
+		MonthlySoftUSD: req.MonthlySoftUSD,
+		MonthlyHardUSD: req.MonthlyHardUSD,
+		Notes:          req.Notes,
+	})
+	if err != nil {
+		response.ErrorFrom(c, err)
+		return
+	}
+	response.Success(c, result)
+}
+
+// Delete 删除用户覆盖，恢复全局默认值
+// DELETE /api/v1/admin/users/:id/spend-cap
+func (h *SpendCapHandler) Delete(c *gin.Context) {
+	userID, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		response.BadRequest(c, "Invalid user ID")
+		return
+	}
+	if err := h.service.DeleteOverride(c.Request.Context(), userID); err != nil {
+		response.ErrorFrom(c, err)
+		return
+	}
+	response.Success(c, gin.H{"message": "Spend cap override deleted"})
+}
@@ -13,16 +13,30 @@ import (
 
 // SystemHandler handles system-related operations
 type SystemHandler struct {
-	updateSvc *service.UpdateService
+	updateSvc       *service.UpdateService
+	configReloadSvc *service.ConfigReloadService
 }
 
 // NewSystemHandler creates a new SystemHandler
-func NewSystemHandler(updateSvc *service.UpdateService) *SystemHandler {
+func NewSystemHandler(updateSvc *service.UpdateService, configReloadSvc *service.ConfigReloadService) *SystemHandler {
 	return &SystemHandler{
-		updateSvc: updateSvc,
+		updateSvc:       updateSvc,
+		configReloadSvc: configReloadSvc,
 	}
 }
 
+// ReloadConfig reloads hot-reloadable settings from the config file without restarting,
+// so active streams are not interrupted. Equivalent to sending SIGHUP to the process.
+// POST /api/v1/admin/system/reload-config
+func (h *SystemHandler) ReloadConfig(c *gin.Context) {
+	result, err := h.configReloadSvc.Reload(c.Request.Context())
+	if err != nil {
+		response.ErrorFrom(c, err)
+		return
+	}
+	response.Success(c, result)
+}
+
 // GetVersion returns the current version
 // GET /api/v1/admin/system/version
 func (h *SystemHandler) GetVersion(c *gin.Context) {
@@ -0,0 +1,74 @@
+package admin
+
+import (
+	"strconv"
+
+	"github.com/Wei-Shaw/sub2api/internal/pkg/pagination"
+	"github.com/Wei-Shaw/sub2api/internal/pkg/response"
+	"github.com/Wei-Shaw/sub2api/internal/service"
+	"github.com/gin-gonic/gin"
+)
+
+// TrashHandler 处理回收站（已删除账号/API Key）的查询与恢复
+type TrashHandler struct {
+	service *service.TrashService
+}
+
+// NewTrashHandler 创建回收站处理器
+func NewTrashHandler(service *service.TrashService) *TrashHandler {
+	return &TrashHandler{service: service}
+}
+
+// ListAccounts 分页列出回收站中的账号
+// GET /api/v1/admin/trash/accounts
+func (h *TrashHandler) ListAccounts(c *gin.Context) {
+	page, pageSize := response.ParsePagination(c)
+	items, result, err := h.service.ListAccounts(c.Request.Context(), pagination.PaginationParams{Page: page, PageSize: pageSize})
+	if err != nil {
+		response.ErrorFrom(c, err)
+		return
+	}
+	response.Paginated(c, items, result.Total, page, pageSize)
+}
+
+// ListAPIKeys 分页列出回收站中的 API Key
+// GET /api/v1/admin/trash/api-keys
+func (h *TrashHandler) ListAPIKeys(c *gin.Context) {
+	page, pageSize := response.ParsePagination(c)
+	items, result, err := h.service.ListAPIKeys(c.Request.Context(), pagination.PaginationParams{Page: page, PageSize: pageSize})
+	if err != nil {
+		response.ErrorFrom(c, err)
+		return
+	}
+	response.Paginated(c, items, result.Total, page, pageSize)
+}
+
+// RestoreAccount 从回收站恢复账号（重新绑定删除前的分组）
+// POST /api/v1/admin/trash/accounts/:id/restore
+func (h *TrashHandler) RestoreAccount(c *gin.Context) {
+	id, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil || id <= 0 {
+		response.BadRequest(c, "Invalid account ID")
+		return
+	}
+	if err := h.service.RestoreAccount(c.Request.Context(), id); err != nil {
+		response.ErrorFrom(c, err)
+		return
+	}
+	response.Success(c, gin.H{"id": id, "restored": true})
+}
+
+// RestoreAPIKey 从回收站恢复 API Key
+// POST /api/v1/admin/trash/api-keys/:id/restore
+func (h *TrashHandler) RestoreAPIKey(c *gin.Context) {
+	id, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil || id <= 0 {
+		response.BadRequest(c, "Invalid API key ID")
+		return
+	}
+	if err := h.service.RestoreAPIKey(c.Request.Context(), id); err != nil {
+		response.ErrorFrom(c, err)
+		return
+	}
+	response.Success(c, gin.H{"id": id, "restored": true})
+}
@@ -1,6 +1,7 @@
 package admin
 
 import (
+	"fmt"
 	"log"
 	"net/http"
 	"strconv"
@@ -60,13 +61,64 @@ type CreateUsageCleanupTaskRequest struct {
 func (h *UsageHandler) List(c *gin.Context) {
 	page, pageSize := response.ParsePagination(c)
 
+	filters, ok := parseUsageLogFilters(c)
+	if !ok {
+		return
+	}
+
+	params := pagination.PaginationParams{Page: page, PageSize: pageSize}
+	records, result, err := h.usageService.ListWithFilters(c.Request.Context(), params, filters)
+	if err != nil {
+		response.ErrorFrom(c, err)
+		return
+	}
+
+	out := make([]dto.AdminUsageLog, 0, len(records))
+	for i := range records {
+		out = append(out, *dto.UsageLogFromServiceAdmin(&records[i]))
+	}
+	response.Paginated(c, out, result.Total, page, pageSize)
+}
+
+// Export streams usage records in the date range as CSV or Parquet
+// GET /api/v1/admin/usage/export?format=csv|parquet&start_date=&end_date=
+func (h *UsageHandler) Export(c *gin.Context) {
+	filters, ok := parseUsageLogFilters(c)
+	if !ok {
+		return
+	}
+	format, err := service.NormalizeUsageExportFormat(c.Query("format"))
+	if err != nil {
+		response.ErrorFrom(c, err)
+		return
+	}
+	if err := service.ValidateUsageExportRange(filters); err != nil {
+		response.ErrorFrom(c, err)
+		return
+	}
+
+	filename := fmt.Sprintf("usage_%s_%s.%s", c.Query("start_date"), c.Query("end_date"), format)
+	c.Header("Content-Type", service.UsageExportContentType(format))
+	c.Header("Content-Disposition", fmt.Sprintf("attachment; filename=%q", filename))
+	c.Header("Cache-Control", "no-store")
+	c.Status(http.StatusOK)
+
+	// 响应头已发送，导出中途失败只能记录日志并中断连接
+	if err := h.usageService.ExportUsage(c.Request.Context(), c.Writer, filters, service.UsageExportOptions{Format: format, IncludeAccount: true}); err != nil {
+		log.Printf("[UsageExport] 导出失败: format=%s err=%v", format, err)
+		c.Abort()
+	}
+}
+
+// parseUsageLogFilters 解析用量筛选参数（列表与导出共用），解析失败时已写入 400 响应
+func parseUsageLogFilters(c *gin.Context) (usagestats.UsageLogFilters, bool) {
 	// Parse filters
 	var userID, apiKeyID, accountID, groupID int64
 	if userIDStr := c.Query("user_id"); userIDStr != "" {
 		id, err := strconv.ParseInt(userIDStr, 10, 64)
 		if err != nil {
 			response.BadRequest(c, "Invalid user_id")
-			return
+			return usagestats.UsageLogFilters{}, false
 		}
 		userID = id
 	}
@@ -75,7 +127,7 @@ func (h *UsageHandler) List(c *gin.Context) {
 		id, err := strconv.ParseInt(apiKeyIDStr, 10, 64)
 		if err != nil {
 			response.BadRequest(c, "Invalid api_key_id")
-			return
+			return usagestats.UsageLogFilters{}, false
 		}
 		apiKeyID = id
 	}
@@ -84,7 +136,7 @@ func (h *UsageHandler) List(c *gin.Context) {
 		id, err := strconv.ParseInt(accountIDStr, 10, 64)
 		if err != nil {
 			response.BadRequest(c, "Invalid account_id")
-			return
+			return usagestats.UsageLogFilters{}, false
 		}
 		accountID = id
 	}
@@ -93,7 +145,7 @@ func (h *UsageHandler) List(c *gin.Context) {
 		id, err := strconv.ParseInt(groupIDStr, 10, 64)
 		if err != nil {
 			response.BadRequest(c, "Invalid group_id")
-			return
+			return usagestats.UsageLogFilters{}, false
 		}
 		groupID = id
 	}
@@ -105,7 +157,7 @@ func (h *UsageHandler) List(c *gin.Context) {
 		val, err := strconv.ParseBool(streamStr)
 		if err != nil {
 			response.BadRequest(c, "Invalid stream value, use true or false")
-			return
+			return usagestats.UsageLogFilters{}, false
 		}
 		stream = &val
 	}
@@ -115,7 +167,7 @@ func (h *UsageHandler) List(c *gin.Context) {
 		val, err := strconv.ParseInt(billingTypeStr, 10, 8)
 		if err != nil {
 			response.BadRequest(c, "Invalid billing_type")
-			return
+			return usagestats.UsageLogFilters{}, false
 		}
 		bt := int8(val)
 		billingType = &bt
@@ -128,7 +180,7 @@ func (h *UsageHandler) List(c *gin.Context) {
 		t, err := timezone.ParseInUserLocation("2006-01-02", startDateStr, userTZ)
 		if err != nil {
 			response.BadRequest(c, "Invalid start_date format, use YYYY-MM-DD")
-			return
+			return usagestats.UsageLogFilters{}, false
 		}
 		startTime = &t
 	}
@@ -137,15 +189,14 @@ func (h *UsageHandler) List(c *gin.Context) {
 		t, err := timezone.ParseInUserLocation("2006-01-02", endDateStr, userTZ)
 		if err != nil {
 			response.BadRequest(c, "Invalid end_date format, use YYYY-MM-DD")
-			return
+			return usagestats.UsageLogFilters{}, false
 		}
 		// Set end time to end of day
 		t = t.Add(24*time.Hour - time.Nanosecond)
 		endTime = &t
 	}
 
-	params := pagination.PaginationParams{Page: page, PageSize: pageSize}
-	filters := usagestats.UsageLogFilters{
+	return usagestats.UsageLogFilters{
 		UserID:      userID,
 		APIKeyID:    apiKeyID,
 		AccountID:   accountID,
@@ -155,19 +206,7 @@ func (h *UsageHandler) List(c *gin.Context) {
 		BillingType: billingType,
 		StartTime:   startTime,
 		EndTime:     endTime,
-	}
-
-	records, result, err := h.usageService.ListWithFilters(c.Request.Context(), params, filters)
-	if err != nil {
-		response.ErrorFrom(c, err)
-		return
-	}
-
-	out := make([]dto.AdminUsageLog, 0, len(records))
-	for i := range records {
-		out = append(out, *dto.UsageLogFromServiceAdmin(&records[i]))
-	}
-	response.Paginated(c, out, result.Total, page, pageSize)
+	}, true
 }
 
 // Stats handles getting usage statistics with filters
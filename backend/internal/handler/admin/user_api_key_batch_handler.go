@@ -0,0 +1,116 @@
+package admin
+
+import (
+	"bytes"
+	"encoding/csv"
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/Wei-Shaw/sub2api/internal/handler/dto"
+	"github.com/Wei-Shaw/sub2api/internal/pkg/response"
+	"github.com/Wei-Shaw/sub2api/internal/service"
+
+	"github.com/gin-gonic/gin"
+)
+
+// BatchCreateAPIKeysRequest represents the request to mint many API keys with shared settings
+type BatchCreateAPIKeysRequest struct {
+	Count         int      `json:"count" binding:"required,min=1,max=500"`
+	NamePrefix    string   `json:"name_prefix" binding:"max=64"`
+	GroupID       *int64   `json:"group_id"`
+	Quota         float64  `json:"quota" binding:"min=0"`
+	ExpiresInDays *int     `json:"expires_in_days" binding:"omitempty,min=1,max=36500"`
+	AllowedModels []string `json:"allowed_models"`
+	Scopes        []string `json:"scopes"`
+	IPWhitelist   []string `json:"ip_whitelist"`
+	// Format selects the response body: csv (default, downloadable) or json
+	Format string `json:"format" binding:"omitempty,oneof=csv json"`
+}
+
+// BatchCreateAPIKeys handles minting N API keys for a user at once
+// POST /api/v1/admin/users/:id/api-keys/batch
+func (h *UserHandler) BatchCreateAPIKeys(c *gin.Context) {
+	userID, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		response.BadRequest(c, "Invalid user ID")
+		return
+	}
+
+	var req BatchCreateAPIKeysRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		response.BadRequest(c, "Invalid request: "+err.Error())
+		return
+	}
+	if h.apiKeyService == nil {
+		response.Error(c, http.StatusServiceUnavailable, "API key service not available")
+		return
+	}
+
+	keys, err := h.apiKeyService.BatchCreate(c.Request.Context(), userID, service.BatchCreateAPIKeysRequest{
+		Count:      req.Count,
+		NamePrefix: req.NamePrefix,
+		Template: service.CreateAPIKeyRequest{
+			GroupID:       req.GroupID,
+			Quota:         req.Quota,
+			ExpiresInDays: req.ExpiresInDays,
+			AllowedModels: req.AllowedModels,
+			Scopes:        req.Scopes,
+			IPWhitelist:   req.IPWhitelist,
+		},
+	})
+	if err != nil {
+		response.ErrorFrom(c, err)
+		return
+	}
+
+	if req.Format == "json" {
+		out := make([]dto.APIKey, 0, len(keys))
+		for _, key := range keys {
+			out = append(out, *dto.APIKeyFromService(key))
+		}
+		response.Success(c, out)
+		return
+	}
+
+	var buf bytes.Buffer
+	writer := csv.NewWriter(&buf)
+	if err := writer.Write([]string{"id", "name", "key", "user_id", "group_id", "quota", "expires_at", "created_at"}); err != nil {
+		response.InternalError(c, "Failed to export api keys: "+err.Error())
+		return
+	}
+	for _, key := range keys {
+		groupID := ""
+		if key.GroupID != nil {
+			groupID = strconv.FormatInt(*key.GroupID, 10)
+		}
+		expiresAt := ""
+		if key.ExpiresAt != nil {
+			expiresAt = key.ExpiresAt.Format("2006-01-02 15:04:05")
+		}
+		if err := writer.Write([]string{
+			strconv.FormatInt(key.ID, 10),
+			key.Name,
+			key.Key,
+			strconv.FormatInt(key.UserID, 10),
+			groupID,
+			fmt.Sprintf("%.2f", key.Quota),
+			expiresAt,
+			key.CreatedAt.Format("2006-01-02 15:04:05"),
+		}); err != nil {
+			response.InternalError(c, "Failed to export api keys: "+err.Error())
+			return
+		}
+	}
+	writer.Flush()
+	if err := writer.Error(); err != nil {
+		response.InternalError(c, "Failed to export api keys: "+err.Error())
+		return
+	}
+
+	filename := fmt.Sprintf("api_keys_user_%d_%s.csv", userID, time.Now().Format("20060102150405"))
+	c.Header("Content-Type", "text/csv")
+	c.Header("Content-Disposition", "attachment; filename="+filename)
+	c.Data(http.StatusOK, "text/csv", buf.Bytes())
+}
@@ -16,37 +16,61 @@ import (
 
 // APIKeyHandler handles API key-related requests
 type APIKeyHandler struct {
-	apiKeyService *service.APIKeyService
+	apiKeyService       *service.APIKeyService
+	billingCacheService *service.BillingCacheService
 }
 
 // NewAPIKeyHandler creates a new APIKeyHandler
-func NewAPIKeyHandler(apiKeyService *service.APIKeyService) *APIKeyHandler {
+func NewAPIKeyHandler(apiKeyService *service.APIKeyService, billingCacheService *service.BillingCacheService) *APIKeyHandler {
 	return &APIKeyHandler{
-		apiKeyService: apiKeyService,
+		apiKeyService:       apiKeyService,
+		billingCacheService: billingCacheService,
 	}
 }
 
 // CreateAPIKeyRequest represents the create API key request payload
 type CreateAPIKeyRequest struct {
-	Name          string   `json:"name" binding:"required"`
-	GroupID       *int64   `json:"group_id"`        // nullable
-	CustomKey     *string  `json:"custom_key"`      // 可选的自定义key
-	IPWhitelist   []string `json:"ip_whitelist"`    // IP 白名单
-	IPBlacklist   []string `json:"ip_blacklist"`    // IP 黑名单
-	Quota         *float64 `json:"quota"`           // 配额限制 (USD)
-	ExpiresInDays *int     `json:"expires_in_days"` // 过期天数
+	Name               string                      `json:"name" binding:"required"`
+	GroupID            *int64                      `json:"group_id"`             // nullable
+	CustomKey          *string                     `json:"custom_key"`           // 可选的自定义key
+	IPWhitelist        []string                    `json:"ip_whitelist"`         // IP 白名单
+	IPBlacklist        []string                    `json:"ip_blacklist"`         // IP 黑名单
+	AllowedModels      []string                    `json:"allowed_models"`       // 允许请求的模型（支持末尾 * 通配）
+	Scopes             []string                    `json:"scopes"`               // 访问范围（端点与功能）
+	RegionPolicy       *service.RegionPolicy       `json:"region_policy"`        // 区域策略
+	SystemPromptPolicy *service.SystemPromptPolicy `json:"system_prompt_policy"` // 系统提示词注入策略
+	UsageWebhookURL    *string                     `json:"usage_webhook_url"`    // 用量回调地址（签名密钥自动生成）
+	RequestSigning     bool                        `json:"request_signing"`      // 启用请求签名（签名密钥自动生成）
+	ToolLimits         map[string]int              `json:"tool_limits"`          // 内置工具每日调用上限
+	TokenQuota         *service.TokenQuota         `json:"token_quota"`          // 每日/每月 token 与请求数硬配额
+	DebugErrors        bool                        `json:"debug_errors"`         // 调试模式：错误响应附带上游错误详情
+	PriorityClass      string                      `json:"priority_class"`       // 优先级类别：interactive/batch，空为默认
+	Quota              *float64                    `json:"quota"`                // 配额限制 (USD)
+	ExpiresInDays      *int                        `json:"expires_in_days"`      // 过期天数
 }
 
 // UpdateAPIKeyRequest represents the update API key request payload
 type UpdateAPIKeyRequest struct {
-	Name        string   `json:"name"`
-	GroupID     *int64   `json:"group_id"`
-	Status      string   `json:"status" binding:"omitempty,oneof=active inactive"`
-	IPWhitelist []string `json:"ip_whitelist"` // IP 白名单
-	IPBlacklist []string `json:"ip_blacklist"` // IP 黑名单
-	Quota       *float64 `json:"quota"`        // 配额限制 (USD), 0=无限制
-	ExpiresAt   *string  `json:"expires_at"`   // 过期时间 (ISO 8601)
-	ResetQuota  *bool    `json:"reset_quota"`  // 重置已用配额
+	Name                     string                      `json:"name"`
+	GroupID                  *int64                      `json:"group_id"`
+	Status                   string                      `json:"status" binding:"omitempty,oneof=active inactive"`
+	IPWhitelist              []string                    `json:"ip_whitelist"`                // IP 白名单
+	IPBlacklist              []string                    `json:"ip_blacklist"`                // IP 黑名单
+	AllowedModels            []string                    `json:"allowed_models"`              // 允许请求的模型（不传表示不修改，空数组清空）
+	Scopes                   []string                    `json:"scopes"`                      // 访问范围（不传表示不修改，空数组清空）
+	RegionPolicy             *service.RegionPolicy       `json:"region_policy"`               // 区域策略（不传表示不修改）
+	SystemPromptPolicy       *service.SystemPromptPolicy `json:"system_prompt_policy"`        // 系统提示词注入策略（不传表示不修改）
+	UsageWebhookURL          *string                     `json:"usage_webhook_url"`           // 用量回调地址（不传表示不修改，空字符串关闭）
+	RotateUsageWebhookSecret bool                        `json:"rotate_usage_webhook_secret"` // 重新生成用量回调签名密钥
+	RequestSigning           *bool                       `json:"request_signing"`             // 启用/关闭请求签名（不传表示不修改）
+	RotateSigningSecret      bool                        `json:"rotate_signing_secret"`       // 重新生成请求签名密钥
+	ToolLimits               map[string]int              `json:"tool_limits"`                 // 内置工具每日调用上限（不传表示不修改，空对象清空）
+	TokenQuota               *service.TokenQuota         `json:"token_quota"`                 // 每日/每月 token 与请求数硬配额（不传表示不修改，空对象清空）
+	DebugErrors              *bool                       `json:"debug_errors"`                // 调试模式（不传表示不修改）
+	PriorityClass            *string                     `json:"priority_class"`              // 优先级类别（不传表示不修改）
+	Quota                    *float64                    `json:"quota"`                       // 配额限制 (USD), 0=无限制
+	ExpiresAt                *string                     `json:"expires_at"`                  // 过期时间 (ISO 8601)
+	ResetQuota               *bool                       `json:"reset_quota"`                 // 重置已用配额
 }
 
 // List handles listing user's API keys with pagination
@@ -104,6 +128,51 @@ func (h *APIKeyHandler) GetByID(c *gin.Context) {
 	response.Success(c, dto.APIKeyFromService(key))
 }
 
+// APIKeyQuotaUsageResponse API Key token 配额及当前周期用量
+type APIKeyQuotaUsageResponse struct {
+	TokenQuota service.TokenQuota       `json:"token_quota"`
+	Usage      *service.TokenQuotaUsage `json:"usage"`
+}
+
+// GetQuotaUsage handles getting token quota counters of an API key
+// GET /api/v1/keys/:id/quota-usage
+func (h *APIKeyHandler) GetQuotaUsage(c *gin.Context) {
+	subject, ok := middleware2.GetAuthSubjectFromContext(c)
+	if !ok {
+		response.Unauthorized(c, "User not authenticated")
+		return
+	}
+
+	keyID, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		response.BadRequest(c, "Invalid key ID")
+		return
+	}
+
+	key, err := h.apiKeyService.GetByID(c.Request.Context(), keyID)
+	if err != nil {
+		response.ErrorFrom(c, err)
+		return
+	}
+
+	// 验证所有权
+	if key.UserID != subject.UserID {
+		response.Forbidden(c, "Not authorized to access this key")
+		return
+	}
+
+	usage := &service.TokenQuotaUsage{}
+	if h.billingCacheService != nil {
+		usage, err = h.billingCacheService.GetTokenQuotaUsage(c.Request.Context(), key)
+		if err != nil {
+			response.ErrorFrom(c, err)
+			return
+		}
+	}
+
+	response.Success(c, APIKeyQuotaUsageResponse{TokenQuota: key.TokenQuota, Usage: usage})
+}
+
 // Create handles creating a new API key
 // POST /api/v1/api-keys
 func (h *APIKeyHandler) Create(c *gin.Context) {
@@ -120,12 +189,22 @@ func (h *APIKeyHandler) Create(c *gin.Context) {
 	}
 
 	svcReq := service.CreateAPIKeyRequest{
-		Name:          req.Name,
-		GroupID:       req.GroupID,
-		CustomKey:     req.CustomKey,
-		IPWhitelist:   req.IPWhitelist,
-		IPBlacklist:   req.IPBlacklist,
-		ExpiresInDays: req.ExpiresInDays,
+		Name:               req.Name,
+		GroupID:            req.GroupID,
+		CustomKey:          req.CustomKey,
+		IPWhitelist:        req.IPWhitelist,
+		IPBlacklist:        req.IPBlacklist,
+		AllowedModels:      req.AllowedModels,
+		Scopes:             req.Scopes,
+		RegionPolicy:       req.RegionPolicy,
+		SystemPromptPolicy: req.SystemPromptPolicy,
+		UsageWebhookURL:    req.UsageWebhookURL,
+		RequestSigning:     req.RequestSigning,
+		ToolLimits:         req.ToolLimits,
+		TokenQuota:         req.TokenQuota,
+		DebugErrors:        req.DebugErrors,
+		PriorityClass:      req.PriorityClass,
+		ExpiresInDays:      req.ExpiresInDays,
 	}
 	if req.Quota != nil {
 		svcReq.Quota = *req.Quota
@@ -161,10 +240,22 @@ func (h *APIKeyHandler) Update(c *gin.Context) {
 	}
 
 	svcReq := service.UpdateAPIKeyRequest{
-		IPWhitelist: req.IPWhitelist,
-		IPBlacklist: req.IPBlacklist,
-		Quota:       req.Quota,
-		ResetQuota:  req.ResetQuota,
+		IPWhitelist:              req.IPWhitelist,
+		IPBlacklist:              req.IPBlacklist,
+		AllowedModels:            req.AllowedModels,
+		Scopes:                   req.Scopes,
+		RegionPolicy:             req.RegionPolicy,
+		SystemPromptPolicy:       req.SystemPromptPolicy,
+		UsageWebhookURL:          req.UsageWebhookURL,
+		RotateUsageWebhookSecret: req.RotateUsageWebhookSecret,
+		RequestSigning:           req.RequestSigning,
+		RotateSigningSecret:      req.RotateSigningSecret,
+		ToolLimits:               req.ToolLimits,
+		TokenQuota:               req.TokenQuota,
+		DebugErrors:              req.DebugErrors,
+		PriorityClass:            req.PriorityClass,
+		Quota:                    req.Quota,
+		ResetQuota:               req.ResetQuota,
 	}
 	if req.Name != "" {
 		svcReq.Name = &req.Name
@@ -222,6 +313,49 @@ func (h *APIKeyHandler) Delete(c *gin.Context) {
 	response.Success(c, gin.H{"message": "API key deleted successfully"})
 }
 
+// RotateAPIKeyRequest represents the rotate API key request payload
+type RotateAPIKeyRequest struct {
+	GraceMinutes *int `json:"grace_minutes"` // 旧 Key 继续有效的分钟数（不传使用默认值，0 立即失效）
+}
+
+// Rotate issues a replacement for an API key; the old key stays valid until the grace period ends
+// POST /api/v1/keys/:id/rotate
+func (h *APIKeyHandler) Rotate(c *gin.Context) {
+	subject, ok := middleware2.GetAuthSubjectFromContext(c)
+	if !ok {
+		response.Unauthorized(c, "User not authenticated")
+		return
+	}
+
+	keyID, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		response.BadRequest(c, "Invalid key ID")
+		return
+	}
+
+	var req RotateAPIKeyRequest
+	if c.Request.ContentLength > 0 {
+		if err := c.ShouldBindJSON(&req); err != nil {
+			response.BadRequest(c, "Invalid request: "+err.Error())
+			return
+		}
+	}
+
+	rotation, err := h.apiKeyService.Rotate(c.Request.Context(), keyID, subject.UserID, service.RotateAPIKeyRequest{
+		GraceMinutes: req.GraceMinutes,
+	})
+	if err != nil {
+		response.ErrorFrom(c, err)
+		return
+	}
+
+	response.Success(c, gin.H{
+		"api_key":       dto.APIKeyFromService(rotation.NewKey),
+		"previous_key":  dto.APIKeyFromService(rotation.OldKey),
+		"grace_ends_at": rotation.GraceEndsAt,
+	})
+}
+
 // GetAvailableGroups 获取用户可以绑定的分组列表
 // GET /api/v1/groups/available
 func (h *APIKeyHandler) GetAvailableGroups(c *gin.Context) {
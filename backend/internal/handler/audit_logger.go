@@ -0,0 +1,99 @@
+package handler
+
+import (
+	"io"
+	"strings"
+	"time"
+
+	"github.com/Wei-Shaw/sub2api/internal/pkg/ctxkey"
+	"github.com/Wei-Shaw/sub2api/internal/pkg/ip"
+	middleware2 "github.com/Wei-Shaw/sub2api/internal/server/middleware"
+	"github.com/Wei-Shaw/sub2api/internal/service"
+	"github.com/gin-gonic/gin"
+)
+
+// auditCaptureReader 将读取到的请求体同步写入审计捕获器
+type auditCaptureReader struct {
+	io.Reader
+	io.Closer
+}
+
+// auditCaptureWriter 将响应体同步写入审计捕获器（捕获器只保留前 max_body_bytes 字节）
+type auditCaptureWriter struct {
+	gin.ResponseWriter
+	capture *service.AuditBodyCapture
+}
+
+func (w *auditCaptureWriter) Write(b []byte) (int, error) {
+	n, err := w.ResponseWriter.Write(b)
+	_, _ = w.capture.Write(b[:n])
+	return n, err
+}
+
+func (w *auditCaptureWriter) WriteString(s string) (int, error) {
+	n, err := w.ResponseWriter.WriteString(s)
+	_, _ = w.capture.Write([]byte(s[:n]))
+	return n, err
+}
+
+// AuditLogMiddleware records authenticated gateway requests into audit_logs.
+// When audit_log.capture_bodies is enabled, request and response bodies are teed into
+// bounded captures and redacted asynchronously before being persisted.
+func AuditLogMiddleware(audit *service.AuditLogService) gin.HandlerFunc {
+	if !audit.Enabled() {
+		return func(c *gin.Context) { c.Next() }
+	}
+	return func(c *gin.Context) {
+		start := time.Now()
+		reqCapture := audit.NewBodyCapture()
+		respCapture := audit.NewBodyCapture()
+		if reqCapture != nil {
+			if c.Request.Body != nil {
+				c.Request.Body = auditCaptureReader{Reader: io.TeeReader(c.Request.Body, reqCapture), Closer: c.Request.Body}
+			}
+			c.Writer = &auditCaptureWriter{ResponseWriter: c.Writer, capture: respCapture}
+		}
+
+		c.Next()
+
+		apiKey, _ := middleware2.GetAPIKeyFromContext(c)
+		if apiKey == nil {
+			// 认证失败的请求不记录（无法归属到用户/Key）
+			return
+		}
+		userID, apiKeyID := apiKey.UserID, apiKey.ID
+		entry := &service.AuditLog{
+			UserID:        &userID,
+			APIKeyID:      &apiKeyID,
+			GroupID:       apiKey.GroupID,
+			Platform:      resolveOpsPlatform(apiKey, guessPlatformFromPath(c.Request.URL.Path)),
+			Method:        c.Request.Method,
+			Path:          c.Request.URL.Path,
+			StatusCode:    c.Writer.Status(),
+			DurationMs:    int(time.Since(start).Milliseconds()),
+			ClientIP:      strings.TrimSpace(ip.GetClientIP(c)),
+			UserAgent:     truncateString(c.GetHeader("User-Agent"), 512),
+			RequestBytes:  reqCapture.Size(),
+			ResponseBytes: c.Writer.Size(),
+		}
+		if entry.RequestBytes == 0 && c.Request.ContentLength > 0 {
+			entry.RequestBytes = int(c.Request.ContentLength)
+		}
+		if entry.ResponseBytes < 0 {
+			entry.ResponseBytes = 0
+		}
+		entry.RequestID, _ = c.Request.Context().Value(ctxkey.ClientRequestID).(string)
+		if v, ok := c.Get(opsModelKey); ok {
+			entry.Model, _ = v.(string)
+		}
+		if v, ok := c.Get(opsStreamKey); ok {
+			entry.Stream, _ = v.(bool)
+		}
+		if v, ok := c.Get(opsAccountIDKey); ok {
+			if accountID, ok := v.(int64); ok && accountID > 0 {
+				entry.AccountID = &accountID
+			}
+		}
+		audit.Record(entry, reqCapture, respCapture)
+	}
+}
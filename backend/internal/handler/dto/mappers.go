@@ -69,21 +69,31 @@ func APIKeyFromService(k *service.APIKey) *APIKey {
 		return nil
 	}
 	return &APIKey{
-		ID:          k.ID,
-		UserID:      k.UserID,
-		Key:         k.Key,
-		Name:        k.Name,
-		GroupID:     k.GroupID,
-		Status:      k.Status,
-		IPWhitelist: k.IPWhitelist,
-		IPBlacklist: k.IPBlacklist,
-		Quota:       k.Quota,
-		QuotaUsed:   k.QuotaUsed,
-		ExpiresAt:   k.ExpiresAt,
-		CreatedAt:   k.CreatedAt,
-		UpdatedAt:   k.UpdatedAt,
-		User:        UserFromServiceShallow(k.User),
-		Group:       GroupFromServiceShallow(k.Group),
+		ID:                 k.ID,
+		UserID:             k.UserID,
+		Key:                k.Key,
+		Name:               k.Name,
+		GroupID:            k.GroupID,
+		Status:             k.Status,
+		IPWhitelist:        k.IPWhitelist,
+		IPBlacklist:        k.IPBlacklist,
+		AllowedModels:      k.AllowedModels,
+		Scopes:             k.Scopes,
+		RegionPolicy:       k.RegionPolicy,
+		SystemPromptPolicy: k.SystemPromptPolicy,
+		UsageWebhook:       k.UsageWebhook,
+		RequestSigning:     k.RequestSigning,
+		ToolLimits:         k.ToolLimits,
+		TokenQuota:         k.TokenQuota,
+		DebugErrors:        k.DebugErrors,
+		PriorityClass:      k.PriorityClass,
+		Quota:              k.Quota,
+		QuotaUsed:          k.QuotaUsed,
+		ExpiresAt:          k.ExpiresAt,
+		CreatedAt:          k.CreatedAt,
+		UpdatedAt:          k.UpdatedAt,
+		User:               UserFromServiceShallow(k.User),
+		Group:              GroupFromServiceShallow(k.Group),
 	}
 }
 
@@ -112,10 +122,18 @@ func GroupFromServiceAdmin(g *service.Group) *AdminGroup {
 		Group:                groupFromServiceBase(g),
 		ModelRouting:         g.ModelRouting,
 		ModelRoutingEnabled:  g.ModelRoutingEnabled,
+		ModelParamPolicies:   g.ModelParamPolicies,
+		RegionPolicy:         g.RegionPolicy,
+		SystemPromptPolicy:   g.SystemPromptPolicy,
+		PromptTemplate:       g.PromptTemplate,
+		ModelAccessPolicy:    g.ModelAccessPolicy,
+		RetryPolicy:          g.RetryPolicy,
 		MCPXMLInject:         g.MCPXMLInject,
 		SupportedModelScopes: g.SupportedModelScopes,
 		AccountCount:         g.AccountCount,
 		SortOrder:            g.SortOrder,
+		MeteringOnly:         g.MeteringOnly,
+		StrictRequestFields:  g.StrictRequestFields,
 	}
 	if len(g.AccountGroups) > 0 {
 		out.AccountGroups = make([]AccountGroup, 0, len(g.AccountGroups))
@@ -160,6 +178,7 @@ func AccountFromServiceShallow(a *service.Account) *Account {
 		ID:                      a.ID,
 		Name:                    a.Name,
 		Notes:                   a.Notes,
+		Labels:                  a.GetLabels(),
 		Platform:                a.Platform,
 		Type:                    a.Type,
 		Credentials:             a.Credentials,
@@ -376,6 +395,7 @@ func usageLogFromServiceUser(l *service.UsageLog) UsageLog {
 		RequestID:             l.RequestID,
 		Model:                 l.Model,
 		ReasoningEffort:       l.ReasoningEffort,
+		GatewayFingerprint:    l.GatewayFingerprint,
 		GroupID:               l.GroupID,
 		SubscriptionID:        l.SubscriptionID,
 		InputTokens:           l.InputTokens,
@@ -392,11 +412,14 @@ func usageLogFromServiceUser(l *service.UsageLog) UsageLog {
 		ActualCost:            l.ActualCost,
 		RateMultiplier:        l.RateMultiplier,
 		BillingType:           l.BillingType,
+		MeteringOnly:          l.MeteringOnly,
 		Stream:                l.Stream,
 		DurationMs:            l.DurationMs,
 		FirstTokenMs:          l.FirstTokenMs,
 		ImageCount:            l.ImageCount,
 		ImageSize:             l.ImageSize,
+		ToolUsage:             l.ToolUsage,
+		ToolCost:              l.ToolCost,
 		UserAgent:             l.UserAgent,
 		CreatedAt:             l.CreatedAt,
 		User:                  UserFromServiceShallow(l.User),
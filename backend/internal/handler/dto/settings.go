@@ -82,6 +82,96 @@ type PublicSettings struct {
 	Version                     string `json:"version"`
 }
 
+// ModelAliasRule 模型别名规则 DTO
+type ModelAliasRule struct {
+	From               string `json:"from"`
+	To                 string `json:"to"`
+	Platform           string `json:"platform,omitempty"`
+	Deprecated         bool   `json:"deprecated,omitempty"`
+	DeprecationMessage string `json:"deprecation_message,omitempty"`
+}
+
+// ModelAliasSettings 模型别名配置 DTO
+type ModelAliasSettings struct {
+	Enabled bool             `json:"enabled"`
+	Rules   []ModelAliasRule `json:"rules"`
+}
+
+// RequestStripRule 请求字段剔除规则 DTO
+type RequestStripRule struct {
+	Path     string `json:"path"`
+	Protocol string `json:"protocol,omitempty"`
+}
+
+// RequestStripSettings 请求字段剔除配置 DTO
+type RequestStripSettings struct {
+	Enabled bool               `json:"enabled"`
+	Rules   []RequestStripRule `json:"rules"`
+}
+
+// RequestSanitizeRule 请求净化规则 DTO
+type RequestSanitizeRule struct {
+	Protocol         string   `json:"protocol,omitempty"`
+	GroupIDs         []int64  `json:"group_ids,omitempty"`
+	Path             string   `json:"path,omitempty"`
+	Values           []string `json:"values,omitempty"`
+	AllowedToolTypes []string `json:"allowed_tool_types,omitempty"`
+	Action           string   `json:"action"`
+	Message          string   `json:"message,omitempty"`
+}
+
+// RequestSanitizeSettings 请求净化配置 DTO
+type RequestSanitizeSettings struct {
+	Enabled bool                  `json:"enabled"`
+	Rules   []RequestSanitizeRule `json:"rules"`
+}
+
+// BudgetAlertSettings 预算阈值告警配置 DTO
+type BudgetAlertSettings struct {
+	Enabled         bool     `json:"enabled"`
+	Thresholds      []int    `json:"thresholds"`
+	Scopes          []string `json:"scopes"`
+	WebhookURL      string   `json:"webhook_url"`
+	WebhookSecret   string   `json:"webhook_secret"`
+	EmailRecipients []string `json:"email_recipients"`
+}
+
+// UpstreamErrorMappingRule 上游错误映射规则 DTO
+type UpstreamErrorMappingRule struct {
+	Platform          string `json:"platform,omitempty"`
+	UpstreamStatus    int    `json:"upstream_status,omitempty"`
+	UpstreamErrorCode string `json:"upstream_error_code,omitempty"`
+	ClientStatus      int    `json:"client_status,omitempty"`
+	ErrorType         string `json:"error_type,omitempty"`
+	Message           string `json:"message,omitempty"`
+}
+
+// UpstreamErrorMappingSettings 上游错误映射配置 DTO（BuiltinRules 为只读的内置映射）
+type UpstreamErrorMappingSettings struct {
+	Enabled      bool                       `json:"enabled"`
+	Rules        []UpstreamErrorMappingRule `json:"rules"`
+	BuiltinRules []UpstreamErrorMappingRule `json:"builtin_rules"`
+}
+
+// VirtualModelTarget 虚拟模型回退目标 DTO
+type VirtualModelTarget struct {
+	Model      string  `json:"model"`
+	AccountIDs []int64 `json:"account_ids,omitempty"`
+}
+
+// VirtualModel 虚拟模型 DTO
+type VirtualModel struct {
+	Name     string               `json:"name"`
+	Platform string               `json:"platform,omitempty"`
+	Targets  []VirtualModelTarget `json:"targets"`
+}
+
+// VirtualModelSettings 虚拟模型配置 DTO
+type VirtualModelSettings struct {
+	Enabled bool           `json:"enabled"`
+	Models  []VirtualModel `json:"models"`
+}
+
 // StreamTimeoutSettings 流超时处理配置 DTO
 type StreamTimeoutSettings struct {
 	Enabled                bool   `json:"enabled"`
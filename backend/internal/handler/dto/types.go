@@ -1,6 +1,10 @@
 package dto
 
-import "time"
+import (
+	"time"
+
+	"github.com/Wei-Shaw/sub2api/internal/service"
+)
 
 type User struct {
 	ID            int64     `json:"id"`
@@ -30,19 +34,29 @@ type AdminUser struct {
 }
 
 type APIKey struct {
-	ID          int64      `json:"id"`
-	UserID      int64      `json:"user_id"`
-	Key         string     `json:"key"`
-	Name        string     `json:"name"`
-	GroupID     *int64     `json:"group_id"`
-	Status      string     `json:"status"`
-	IPWhitelist []string   `json:"ip_whitelist"`
-	IPBlacklist []string   `json:"ip_blacklist"`
-	Quota       float64    `json:"quota"`      // Quota limit in USD (0 = unlimited)
-	QuotaUsed   float64    `json:"quota_used"` // Used quota amount in USD
-	ExpiresAt   *time.Time `json:"expires_at"` // Expiration time (nil = never expires)
-	CreatedAt   time.Time  `json:"created_at"`
-	UpdatedAt   time.Time  `json:"updated_at"`
+	ID                 int64                      `json:"id"`
+	UserID             int64                      `json:"user_id"`
+	Key                string                     `json:"key"`
+	Name               string                     `json:"name"`
+	GroupID            *int64                     `json:"group_id"`
+	Status             string                     `json:"status"`
+	IPWhitelist        []string                   `json:"ip_whitelist"`
+	IPBlacklist        []string                   `json:"ip_blacklist"`
+	AllowedModels      []string                   `json:"allowed_models,omitempty"`
+	Scopes             []string                   `json:"scopes,omitempty"`
+	RegionPolicy       service.RegionPolicy       `json:"region_policy"`
+	SystemPromptPolicy service.SystemPromptPolicy `json:"system_prompt_policy"`
+	UsageWebhook       service.UsageWebhook       `json:"usage_webhook"` // 用量回调（含签名密钥，仅对 Key 持有者与管理员可见）
+	RequestSigning     service.RequestSigning     `json:"request_signing"`
+	ToolLimits         map[string]int             `json:"tool_limits,omitempty"`
+	TokenQuota         service.TokenQuota         `json:"token_quota"`
+	DebugErrors        bool                       `json:"debug_errors"`
+	PriorityClass      string                     `json:"priority_class"`
+	Quota              float64                    `json:"quota"`      // Quota limit in USD (0 = unlimited)
+	QuotaUsed          float64                    `json:"quota_used"` // Used quota amount in USD
+	ExpiresAt          *time.Time                 `json:"expires_at"` // Expiration time (nil = never expires)
+	CreatedAt          time.Time                  `json:"created_at"`
+	UpdatedAt          time.Time                  `json:"updated_at"`
 
 	User  *User  `json:"user,omitempty"`
 	Group *Group `json:"group,omitempty"`
@@ -86,6 +100,24 @@ type AdminGroup struct {
 	ModelRouting        map[string][]int64 `json:"model_routing"`
 	ModelRoutingEnabled bool               `json:"model_routing_enabled"`
 
+	// 模型参数策略（temperature/top_p 范围约束）
+	ModelParamPolicies map[string]service.ModelParamPolicy `json:"model_param_policies"`
+
+	// 区域策略
+	RegionPolicy service.RegionPolicy `json:"region_policy"`
+
+	// 系统提示词注入策略
+	SystemPromptPolicy service.SystemPromptPolicy `json:"system_prompt_policy"`
+
+	// 提示词前缀/后缀模板
+	PromptTemplate service.PromptTemplate `json:"prompt_template"`
+
+	// 模型访问策略
+	ModelAccessPolicy service.ModelAccessPolicy `json:"model_access_policy"`
+
+	// 重试策略
+	RetryPolicy service.RetryPolicy `json:"retry_policy"`
+
 	// MCP XML 协议注入（仅 antigravity 平台使用）
 	MCPXMLInject bool `json:"mcp_xml_inject"`
 
@@ -96,12 +128,19 @@ type AdminGroup struct {
 
 	// 分组排序
 	SortOrder int `json:"sort_order"`
+
+	// 仅计量模式（记录用量但不扣费）
+	MeteringOnly bool `json:"metering_only"`
+
+	// 严格字段模式（拒绝未知顶层请求字段）
+	StrictRequestFields bool `json:"strict_request_fields"`
 }
 
 type Account struct {
 	ID                 int64          `json:"id"`
 	Name               string         `json:"name"`
 	Notes              *string        `json:"notes"`
+	Labels             []string       `json:"labels"`
 	Platform           string         `json:"platform"`
 	Type               string         `json:"type"`
 	Credentials        map[string]any `json:"credentials"`
@@ -241,6 +280,8 @@ type UsageLog struct {
 	// ReasoningEffort is the request's reasoning effort level (OpenAI Responses API).
 	// nil means not provided / not applicable.
 	ReasoningEffort *string `json:"reasoning_effort,omitempty"`
+	// GatewayFingerprint 请求携带 seed 时回显的网关指纹，用于排查可复现性（如账号切换）
+	GatewayFingerprint *string `json:"gateway_fingerprint,omitempty"`
 
 	GroupID        *int64 `json:"group_id"`
 	SubscriptionID *int64 `json:"subscription_id"`
@@ -261,7 +302,9 @@ type UsageLog struct {
 	ActualCost        float64 `json:"actual_cost"`
 	RateMultiplier    float64 `json:"rate_multiplier"`
 
-	BillingType  int8 `json:"billing_type"`
+	BillingType int8 `json:"billing_type"`
+	// MeteringOnly 仅计量记录：费用已计算但未扣除
+	MeteringOnly bool `json:"metering_only"`
 	Stream       bool `json:"stream"`
 	DurationMs   *int `json:"duration_ms"`
 	FirstTokenMs *int `json:"first_token_ms"`
@@ -270,6 +313,10 @@ type UsageLog struct {
 	ImageCount int     `json:"image_count"`
 	ImageSize  *string `json:"image_size"`
 
+	// 内置工具调用次数与费用（费用已计入 total_cost）
+	ToolUsage *service.ToolUsage `json:"tool_usage,omitempty"`
+	ToolCost  float64            `json:"tool_cost,omitempty"`
+
 	// User-Agent
 	UserAgent *string `json:"user_agent"`
 
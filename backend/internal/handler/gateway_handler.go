@@ -7,9 +7,10 @@ import (
 	"errors"
 	"fmt"
 	"io"
-	"log"
+	"log/slog"
 	"net/http"
 	"strings"
+	"sync/atomic"
 	"time"
 
 	"github.com/Wei-Shaw/sub2api/internal/config"
@@ -22,8 +23,10 @@ import (
 	"github.com/Wei-Shaw/sub2api/internal/pkg/openai"
 	middleware2 "github.com/Wei-Shaw/sub2api/internal/server/middleware"
 	"github.com/Wei-Shaw/sub2api/internal/service"
+	"github.com/Wei-Shaw/sub2api/internal/util/responseheaders"
 
 	"github.com/gin-gonic/gin"
+	"github.com/tidwall/gjson"
 )
 
 // GatewayHandler handles API gateway requests
@@ -36,9 +39,17 @@ type GatewayHandler struct {
 	usageService              *service.UsageService
 	apiKeyService             *service.APIKeyService
 	errorPassthroughService   *service.ErrorPassthroughService
+	modelAliasService         *service.ModelAliasService
+	virtualModelService       *service.VirtualModelService
+	requestStripService       *service.RequestStripService
+	requestSanitizeService    *service.RequestSanitizeService
+	streamAbuseService        *service.StreamAbuseService
+	responseCacheService      *service.ResponseCacheService
+	tokenCounter              *service.TokenCounterService
+	upstreamErrorMapping      *service.UpstreamErrorMappingService
+	shutdown                  *service.ShutdownCoordinator
 	concurrencyHelper         *ConcurrencyHelper
-	maxAccountSwitches        int
-	maxAccountSwitchesGemini  int
+	failoverLimits            atomic.Pointer[gatewayFailoverLimits]
 }
 
 // NewGatewayHandler creates a new GatewayHandler
@@ -52,21 +63,22 @@ func NewGatewayHandler(
 	usageService *service.UsageService,
 	apiKeyService *service.APIKeyService,
 	errorPassthroughService *service.ErrorPassthroughService,
+	modelAliasService *service.ModelAliasService,
+	virtualModelService *service.VirtualModelService,
+	requestStripService *service.RequestStripService,
+	requestSanitizeService *service.RequestSanitizeService,
+	streamAbuseService *service.StreamAbuseService,
+	responseCacheService *service.ResponseCacheService,
+	tokenCounter *service.TokenCounterService,
+	upstreamErrorMapping *service.UpstreamErrorMappingService,
+	shutdown *service.ShutdownCoordinator,
 	cfg *config.Config,
 ) *GatewayHandler {
 	pingInterval := time.Duration(0)
-	maxAccountSwitches := 10
-	maxAccountSwitchesGemini := 3
 	if cfg != nil {
 		pingInterval = time.Duration(cfg.Concurrency.PingInterval) * time.Second
-		if cfg.Gateway.MaxAccountSwitches > 0 {
-			maxAccountSwitches = cfg.Gateway.MaxAccountSwitches
-		}
-		if cfg.Gateway.MaxAccountSwitchesGemini > 0 {
-			maxAccountSwitchesGemini = cfg.Gateway.MaxAccountSwitchesGemini
-		}
 	}
-	return &GatewayHandler{
+	h := &GatewayHandler{
 		gatewayService:            gatewayService,
 		geminiCompatService:       geminiCompatService,
 		antigravityGatewayService: antigravityGatewayService,
@@ -75,10 +87,26 @@ func NewGatewayHandler(
 		usageService:              usageService,
 		apiKeyService:             apiKeyService,
 		errorPassthroughService:   errorPassthroughService,
+		modelAliasService:         modelAliasService,
+		virtualModelService:       virtualModelService,
+		requestStripService:       requestStripService,
+		requestSanitizeService:    requestSanitizeService,
+		streamAbuseService:        streamAbuseService,
+		responseCacheService:      responseCacheService,
+		tokenCounter:              tokenCounter,
+		upstreamErrorMapping:      upstreamErrorMapping,
+		shutdown:                  shutdown,
 		concurrencyHelper:         NewConcurrencyHelper(concurrencyService, SSEPingFormatClaude, pingInterval),
-		maxAccountSwitches:        maxAccountSwitches,
-		maxAccountSwitchesGemini:  maxAccountSwitchesGemini,
 	}
+	h.failoverLimits.Store(newGatewayFailoverLimits(cfg, 10, 3))
+	return h
+}
+
+// ApplyConfig refreshes the cached ping interval and failover limits after a config reload.
+// In-flight requests keep the values they started with.
+func (h *GatewayHandler) ApplyConfig(cfg *config.Config) {
+	h.concurrencyHelper.SetPingInterval(time.Duration(cfg.Concurrency.PingInterval) * time.Second)
+	h.failoverLimits.Store(newGatewayFailoverLimits(cfg, 10, 3))
 }
 
 // Messages handles Claude API compatible messages endpoint
@@ -113,8 +141,14 @@ func (h *GatewayHandler) Messages(c *gin.Context) {
 		return
 	}
 
+	// 剔除客户端注入的随机字段，保证粘性会话 hash 稳定
+	body = stripRequestFields(c, h.requestStripService, domain.PlatformAnthropic, body)
+
 	setOpsRequestContext(c, "", false, body)
 
+	// 解析模型名推理强度后缀（如 claude-sonnet-4-5-thinking），在解析请求前写入 thinking 参数
+	body = applyModelSuffix(c, body, domain.PlatformAnthropic)
+
 	parsedReq, err := service.ParseGatewayRequest(body, domain.PlatformAnthropic)
 	if err != nil {
 		h.errorResponse(c, http.StatusBadRequest, "invalid_request_error", "Failed to parse request body")
@@ -123,6 +157,14 @@ func (h *GatewayHandler) Messages(c *gin.Context) {
 	reqModel := parsedReq.Model
 	reqStream := parsedReq.Stream
 
+	releaseMemory, ok := admitRequestMemory(c, h.gatewayService.MemoryGuard(), len(body), reqStream)
+	if !ok {
+		h.errorResponse(c, http.StatusServiceUnavailable, "overloaded_error", "Gateway is under memory pressure, please retry later")
+		return
+	}
+	defer releaseMemory()
+	setRequestFeatures(c, body, reqStream)
+
 	// 设置 max_tokens=1 + haiku 探测请求标识到 context 中
 	// 必须在 SetClaudeCodeClientContext 之前设置，因为 ClaudeCodeValidator 需要读取此标识进行绕过判断
 	if isMaxTokensOneHaikuRequest(reqModel, parsedReq.MaxTokens, reqStream) {
@@ -145,9 +187,110 @@ func (h *GatewayHandler) Messages(c *gin.Context) {
 		return
 	}
 
+	// 按管理员配置的模型别名改写请求模型（账号选择与计费均使用改写后的模型，响应中回显原始模型）
+	if aliased, aliasedBody := applyModelAlias(c, h.modelAliasService, apiKey, reqModel, body); aliased != reqModel {
+		reqModel, body = aliased, aliasedBody
+		parsedReq.Model = aliased
+		setOpsRequestContext(c, reqModel, reqStream, body)
+	}
+
+	// 展开虚拟模型：当前目标无可用账号或故障转移耗尽时，在故障转移循环内依次尝试回退链的后续目标
+	virtualChain, virtualModel, virtualBody, err := applyVirtualModel(c, h.virtualModelService, apiKey, reqModel, body)
+	if err != nil {
+		h.errorResponse(c, http.StatusBadRequest, "invalid_request_error", err.Error())
+		return
+	}
+	if virtualChain != nil {
+		reqModel, body = virtualModel, virtualBody
+		parsedReq.Model = virtualModel
+		setOpsRequestContext(c, reqModel, reqStream, body)
+	}
+	// nextVirtualTarget 切换到回退链的下一个目标，调用方需重置自身的故障转移状态
+	nextVirtualTarget := func() bool {
+		if virtualChain == nil {
+			return false
+		}
+		nextModel, nextBody, ok := advanceVirtualModel(c, virtualChain, body)
+		if !ok {
+			return false
+		}
+		reqModel, body = nextModel, nextBody
+		parsedReq.Model, parsedReq.Body = nextModel, nextBody
+		setOpsRequestContext(c, reqModel, reqStream, body)
+		return true
+	}
+
+	// 校验分组模型访问策略（允许/禁止列表）与 API Key 的模型限制，在占用并发槽位前拒绝
+	if err := service.CheckAPIKeyModelAccess(apiKey, reqModel); err != nil {
+		h.errorResponse(c, http.StatusBadRequest, "invalid_request_error", err.Error())
+		return
+	}
+
+	// 校验客户端自带的会话标识（X-Sub2API-Session），存在时优先用于粘性路由
+	clientSessionHash, err := service.ClientSessionHash(c.GetHeader(service.ClientSessionHeader), apiKey.ID)
+	if err != nil {
+		h.errorResponse(c, http.StatusBadRequest, "invalid_request_error", err.Error())
+		return
+	}
+
+	// 按分组模型参数策略收敛/校验 temperature、top_p，避免上游拒绝后在故障转移耗尽时以 502 返回
+	if body, err = service.ApplyModelParamPolicy(apiKey.Group, reqModel, body, domain.PlatformAnthropic); err != nil {
+		var policyErr *service.ModelParamPolicyError
+		if errors.As(err, &policyErr) {
+			h.errorResponse(c, http.StatusBadRequest, "invalid_request_error", policyErr.Message)
+			return
+		}
+		h.errorResponse(c, http.StatusInternalServerError, "api_error", "Failed to process request")
+		return
+	}
+	parsedReq.Body = body
+
+	// 按分组/API Key 的系统提示词策略改写 system
+	if body, err = applyAnthropicSystemPromptPolicy(c, apiKey, parsedReq, body); err != nil {
+		h.errorResponse(c, http.StatusInternalServerError, "api_error", "Failed to process request")
+		return
+	}
+
+	// 按分组提示词模板包裹用户最新消息（仅改写转发的请求体，会话 hash 仍基于客户端原始消息）
+	if body, err = service.ApplyPromptTemplate(apiKey, body, domain.PlatformAnthropic, reqModel); err != nil {
+		h.errorResponse(c, http.StatusInternalServerError, "api_error", "Failed to process request")
+		return
+	}
+
+	// 按请求净化规则剔除或拒绝危险/不支持的字段与工具
+	body, rejectMsg := sanitizeRequest(c, h.requestSanitizeService, apiKey, domain.PlatformAnthropic, body)
+	if rejectMsg != "" {
+		h.errorResponse(c, http.StatusBadRequest, "invalid_request_error", rejectMsg)
+		return
+	}
+	// 严格字段模式下拒绝未知顶层字段（宽松模式原样透传）
+	if rejectMsg := checkUnknownRequestFields(apiKey, service.RequestSchemaAnthropicMessages, body); rejectMsg != "" {
+		h.errorResponse(c, http.StatusBadRequest, "invalid_request_error", rejectMsg)
+		return
+	}
+	// 估算提示 token 超过上下文裁剪上限时删除最早的对话轮次（会话 hash 仍基于客户端原始消息）
+	if trimmed, removed := h.tokenCounter.TrimContext(domain.PlatformAnthropic, reqModel, body); removed > 0 {
+		body = trimmed
+		slog.InfoContext(c.Request.Context(), "context trimmed by local token estimate", "model", reqModel, "removed_items", removed)
+	}
+	parsedReq.Body = body
+
+	// 检查 API Key 的内置工具（web_search 等）每日调用上限，超限时在占用并发槽位前拒绝
+	if err := h.gatewayService.CheckToolLimits(c.Request.Context(), apiKey, body); err != nil {
+		var limitErr *service.ToolLimitExceededError
+		if errors.As(err, &limitErr) {
+			h.errorResponse(c, http.StatusTooManyRequests, "rate_limit_error", limitErr.Error())
+			return
+		}
+	}
+
 	// Track if we've started streaming (for error handling)
 	streamStarted := false
 
+	// 流式滥用检测：记录客户端断开时间
+	disconnectWatch := watchClientDisconnect(c.Request.Context(), h.streamAbuseService, reqStream)
+	defer disconnectWatch.stop()
+
 	// 绑定错误透传服务，允许 service 层在非 failover 错误场景复用规则。
 	if h.errorPassthroughService != nil {
 		service.BindErrorPassthroughService(c, h.errorPassthroughService)
@@ -161,7 +304,7 @@ func (h *GatewayHandler) Messages(c *gin.Context) {
 	canWait, err := h.concurrencyHelper.IncrementWaitCount(c.Request.Context(), subject.UserID, maxWait)
 	waitCounted := false
 	if err != nil {
-		log.Printf("Increment wait count failed: %v", err)
+		slog.WarnContext(c.Request.Context(), "increment user wait count failed", "error", err)
 		// On error, allow request to proceed
 	} else if !canWait {
 		h.errorResponse(c, http.StatusTooManyRequests, "rate_limit_error", "Too many pending requests, please retry later")
@@ -180,7 +323,7 @@ func (h *GatewayHandler) Messages(c *gin.Context) {
 	// 1. 首先获取用户并发槽位
 	userReleaseFunc, err := h.concurrencyHelper.AcquireUserSlotWithWait(c, subject.UserID, subject.Concurrency, reqStream, &streamStarted)
 	if err != nil {
-		log.Printf("User concurrency acquire failed: %v", err)
+		slog.WarnContext(c.Request.Context(), "user concurrency acquire failed", "error", err)
 		h.handleConcurrencyError(c, err, "user", streamStarted)
 		return
 	}
@@ -196,8 +339,8 @@ func (h *GatewayHandler) Messages(c *gin.Context) {
 	}
 
 	// 2. 【新增】Wait后二次检查余额/订阅
-	if err := h.billingCacheService.CheckBillingEligibility(c.Request.Context(), apiKey.User, apiKey, apiKey.Group, subscription); err != nil {
-		log.Printf("Billing eligibility check failed after wait: %v", err)
+	if err := checkBillingEligibility(c, h.billingCacheService, apiKey.User, apiKey, apiKey.Group, subscription); err != nil {
+		slog.WarnContext(c.Request.Context(), "billing eligibility check failed after wait", "error", err)
 		status, code, message := billingErrorDetails(err)
 		h.handleStreamingAwareError(c, status, code, message, streamStarted)
 		return
@@ -209,7 +352,10 @@ func (h *GatewayHandler) Messages(c *gin.Context) {
 		UserAgent: c.GetHeader("User-Agent"),
 		APIKeyID:  apiKey.ID,
 	}
-	sessionHash := h.gatewayService.GenerateSessionHash(parsedReq)
+	sessionHash := clientSessionHash
+	if sessionHash == "" {
+		sessionHash = h.gatewayService.GenerateSessionHash(parsedReq)
+	}
 
 	// 获取平台：优先使用强制平台（/antigravity 路由，中间件已设置 request.Context），否则使用分组平台
 	platform := ""
@@ -218,6 +364,21 @@ func (h *GatewayHandler) Messages(c *gin.Context) {
 	} else if apiKey.Group != nil {
 		platform = apiKey.Group.Platform
 	}
+
+	// 费用预检：预估最大费用超过剩余预算时在占用账号前拒绝
+	estimatedCost := h.gatewayService.EstimateRequestMaxCost(c.Request.Context(), apiKey, platform, reqModel, body)
+	if err := h.billingCacheService.CheckEstimatedCost(c.Request.Context(), apiKey.User, apiKey, apiKey.Group, subscription, estimatedCost); err != nil {
+		status, code, message := billingErrorDetails(err)
+		h.handleStreamingAwareError(c, status, code, message, streamStarted)
+		return
+	}
+
+	// 相同非流式请求命中响应缓存时直接返回，不请求上游；放在计费资格与费用预检之后，命中同样受余额/额度约束
+	cacheHit, storeResponseCache := serveResponseCache(c, h.responseCacheService, apiKey, subscription, body, reqStream)
+	if cacheHit {
+		return
+	}
+
 	sessionKey := sessionHash
 	if platform == service.PlatformGemini && sessionHash != "" {
 		sessionKey = "gemini:" + sessionHash
@@ -232,7 +393,10 @@ func (h *GatewayHandler) Messages(c *gin.Context) {
 	hasBoundSession := sessionKey != "" && sessionBoundAccountID > 0
 
 	if platform == service.PlatformGemini {
-		maxAccountSwitches := h.maxAccountSwitchesGemini
+		// 故障转移预算按分组重试策略与 API Key 优先级类别决定（Gemini 路径仅调整切换次数）
+		limits := h.failoverLimits.Load()
+		retry, _ := service.ResolveRetryController(apiKey.Group, limits.failoverClasses, apiKey.PriorityClass, limits.maxAccountSwitchesGemini)
+		maxAccountSwitches := retry.MaxAccountSwitches()
 		switchCount := 0
 		failedAccountIDs := make(map[int64]struct{})
 		sameAccountRetryCount := make(map[int64]int) // 同账号重试计数
@@ -247,8 +411,17 @@ func (h *GatewayHandler) Messages(c *gin.Context) {
 		}
 
 		for {
+			selectStartedAt := time.Now()
 			selection, err := h.gatewayService.SelectAccountWithLoadAwareness(c.Request.Context(), apiKey.GroupID, sessionKey, reqModel, failedAccountIDs, "") // Gemini 不使用会话限制
+			addOpsPhaseDuration(c, opsSelectionKey, time.Since(selectStartedAt))
 			if err != nil {
+				if nextVirtualTarget() {
+					switchCount = 0
+					failedAccountIDs = make(map[int64]struct{})
+					sameAccountRetryCount = make(map[int64]int)
+					lastFailoverErr = nil
+					continue
+				}
 				if len(failedAccountIDs) == 0 {
 					h.handleStreamingAwareError(c, http.StatusServiceUnavailable, "api_error", "No available accounts: "+err.Error(), streamStarted)
 					return
@@ -258,7 +431,7 @@ func (h *GatewayHandler) Messages(c *gin.Context) {
 				// 谷歌上游 503 (MODEL_CAPACITY_EXHAUSTED) 通常是暂时性的，等几秒就能恢复。
 				if lastFailoverErr != nil && lastFailoverErr.StatusCode == http.StatusServiceUnavailable && switchCount <= maxAccountSwitches {
 					if sleepAntigravitySingleAccountBackoff(c.Request.Context(), switchCount) {
-						log.Printf("Antigravity single-account 503 retry: clearing failed accounts, retry %d/%d", switchCount, maxAccountSwitches)
+						slog.InfoContext(c.Request.Context(), "antigravity single-account 503 retry, clearing failed accounts", "switch_count", switchCount, "max_switches", maxAccountSwitches)
 						failedAccountIDs = make(map[int64]struct{})
 						// 设置 context 标记，让 Service 层预检查等待限流过期而非直接切换
 						ctx := context.WithValue(c.Request.Context(), ctxkey.SingleAccountRetry, true)
@@ -267,9 +440,9 @@ func (h *GatewayHandler) Messages(c *gin.Context) {
 					}
 				}
 				if lastFailoverErr != nil {
-					h.handleFailoverExhausted(c, lastFailoverErr, service.PlatformGemini, streamStarted)
+					h.handleFailoverExhausted(c, lastFailoverErr, retry, service.PlatformGemini, streamStarted)
 				} else {
-					h.handleFailoverExhaustedSimple(c, 502, streamStarted)
+					h.handleFailoverExhaustedSimple(c, service.PlatformGemini, 502, streamStarted)
 				}
 				return
 			}
@@ -294,6 +467,9 @@ func (h *GatewayHandler) Messages(c *gin.Context) {
 
 			// 3. 获取账号并发槽位
 			accountReleaseFunc := selection.ReleaseFunc
+			if !commitAccountReservation(c.Request.Context(), selection) {
+				continue
+			}
 			if !selection.Acquired {
 				if selection.WaitPlan == nil {
 					h.handleStreamingAwareError(c, http.StatusServiceUnavailable, "api_error", "No available accounts", streamStarted)
@@ -302,9 +478,9 @@ func (h *GatewayHandler) Messages(c *gin.Context) {
 				accountWaitCounted := false
 				canWait, err := h.concurrencyHelper.IncrementAccountWaitCount(c.Request.Context(), account.ID, selection.WaitPlan.MaxWaiting)
 				if err != nil {
-					log.Printf("Increment account wait count failed: %v", err)
+					slog.WarnContext(c.Request.Context(), "increment account wait count failed", "error", err)
 				} else if !canWait {
-					log.Printf("Account wait queue full: account=%d", account.ID)
+					slog.WarnContext(c.Request.Context(), "account wait queue full")
 					h.handleStreamingAwareError(c, http.StatusTooManyRequests, "rate_limit_error", "Too many pending requests, please retry later", streamStarted)
 					return
 				}
@@ -327,7 +503,7 @@ func (h *GatewayHandler) Messages(c *gin.Context) {
 					&streamStarted,
 				)
 				if err != nil {
-					log.Printf("Account concurrency acquire failed: %v", err)
+					slog.WarnContext(c.Request.Context(), "account concurrency acquire failed", "error", err)
 					h.handleConcurrencyError(c, err, "account", streamStarted)
 					return
 				}
@@ -337,7 +513,7 @@ func (h *GatewayHandler) Messages(c *gin.Context) {
 					accountWaitCounted = false
 				}
 				if err := h.gatewayService.BindStickySession(c.Request.Context(), apiKey.GroupID, sessionKey, account.ID); err != nil {
-					log.Printf("Bind sticky session failed: %v", err)
+					slog.WarnContext(c.Request.Context(), "bind sticky session failed", "error", err)
 				}
 			}
 			// 账号槽位/等待计数需要在超时或断开时安全回收
@@ -366,10 +542,10 @@ func (h *GatewayHandler) Messages(c *gin.Context) {
 					}
 
 					// 同账号重试：对 RetryableOnSameAccount 的临时性错误，先在同一账号上重试
-					if failoverErr.RetryableOnSameAccount && sameAccountRetryCount[account.ID] < maxSameAccountRetries {
+					if failoverErr.RetryableOnSameAccount && retry.FailoverEnabled() && sameAccountRetryCount[account.ID] < maxSameAccountRetries {
 						sameAccountRetryCount[account.ID]++
-						log.Printf("Account %d: retryable error %d, same-account retry %d/%d",
-							account.ID, failoverErr.StatusCode, sameAccountRetryCount[account.ID], maxSameAccountRetries)
+						slog.InfoContext(c.Request.Context(), "retryable upstream error, retrying same account",
+							"upstream_status", failoverErr.StatusCode, "retry", sameAccountRetryCount[account.ID], "max_retries", maxSameAccountRetries)
 						if !sleepSameAccountRetryDelay(c.Request.Context()) {
 							return
 						}
@@ -382,30 +558,46 @@ func (h *GatewayHandler) Messages(c *gin.Context) {
 					}
 
 					failedAccountIDs[account.ID] = struct{}{}
-					if switchCount >= maxAccountSwitches {
-						h.handleFailoverExhausted(c, failoverErr, service.PlatformGemini, streamStarted)
+					if !retry.ShouldRetry(failoverErr.StatusCode, switchCount) {
+						if nextVirtualTarget() {
+							switchCount = 0
+							failedAccountIDs = make(map[int64]struct{})
+							sameAccountRetryCount = make(map[int64]int)
+							lastFailoverErr = nil
+							continue
+						}
+						h.handleFailoverExhausted(c, failoverErr, retry, service.PlatformGemini, streamStarted)
 						return
 					}
 					switchCount++
-					log.Printf("Account %d: upstream error %d, switching account %d/%d", account.ID, failoverErr.StatusCode, switchCount, maxAccountSwitches)
-					if account.Platform == service.PlatformAntigravity {
-						if !sleepFailoverDelay(c.Request.Context(), switchCount) {
-							return
-						}
+					service.ObserveGatewayFailover(account, apiKey.GroupID)
+					slog.WarnContext(c.Request.Context(), "upstream error, switching account", "upstream_status", failoverErr.StatusCode, "switch_count", switchCount, "max_switches", maxAccountSwitches)
+					if !waitBeforeAccountSwitch(c.Request.Context(), retry, account.Platform, switchCount) {
+						return
 					}
 					continue
 				}
 				// 错误响应已在Forward中处理，这里只记录日志
-				log.Printf("Forward request failed: %v", err)
+				slog.ErrorContext(c.Request.Context(), "forward request failed", "error", err)
 				return
 			}
 
+			storeResponseCache(account.ID, result.Model)
+			h.gatewayService.MarkPromptCacheWarm(c.Request.Context(), apiKey.GroupID, sessionKey, account, result.Usage.CacheReadInputTokens)
+			recordStreamObservation(h.streamAbuseService, disconnectWatch, apiKey, result.FirstTokenMs, result.Duration)
+			setLiveTrafficResult(c, account, result.Usage.InputTokens, result.Usage.OutputTokens, result.FirstTokenMs)
+			setOpsForwardTiming(c, result.Stream, result.Duration, result.FirstTokenMs)
+
 			// 捕获请求信息（用于异步记录，避免在 goroutine 中访问 gin.Context）
 			userAgent := c.GetHeader("User-Agent")
 			clientIP := ip.GetClientIP(c)
+			reqCtx := c.Request.Context()
 
 			// 异步记录使用量（subscription已在函数开头获取）
+			// 登记为关闭前需完成的任务，优雅关闭时等待用量记录落库
+			finishTask := h.shutdown.BeginTask()
 			go func(result *service.ForwardResult, usedAccount *service.Account, ua, clientIP string, fcb bool) {
+				defer finishTask()
 				ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
 				defer cancel()
 				if err := h.gatewayService.RecordUsage(ctx, &service.RecordUsageInput{
@@ -419,7 +611,7 @@ func (h *GatewayHandler) Messages(c *gin.Context) {
 					ForceCacheBilling: fcb,
 					APIKeyService:     h.apiKeyService,
 				}); err != nil {
-					log.Printf("Record usage failed: %v", err)
+					slog.ErrorContext(reqCtx, "record usage failed", "error", err)
 				}
 			}(result, account, userAgent, clientIP, forceCacheBilling)
 			return
@@ -442,7 +634,10 @@ func (h *GatewayHandler) Messages(c *gin.Context) {
 	}
 
 	for {
-		maxAccountSwitches := h.maxAccountSwitches
+		// 故障转移预算按分组重试策略与 API Key 优先级类别决定：交互式请求切换次数更少、单次尝试超时更短
+		limits := h.failoverLimits.Load()
+		retry, failoverBudget := service.ResolveRetryController(currentAPIKey.Group, limits.failoverClasses, currentAPIKey.PriorityClass, limits.maxAccountSwitches)
+		maxAccountSwitches := retry.MaxAccountSwitches()
 		switchCount := 0
 		failedAccountIDs := make(map[int64]struct{})
 		sameAccountRetryCount := make(map[int64]int) // 同账号重试计数
@@ -452,8 +647,17 @@ func (h *GatewayHandler) Messages(c *gin.Context) {
 
 		for {
 			// 选择支持该模型的账号
+			selectStartedAt := time.Now()
 			selection, err := h.gatewayService.SelectAccountWithLoadAwareness(c.Request.Context(), currentAPIKey.GroupID, sessionKey, reqModel, failedAccountIDs, parsedReq.MetadataUserID)
+			addOpsPhaseDuration(c, opsSelectionKey, time.Since(selectStartedAt))
 			if err != nil {
+				if nextVirtualTarget() {
+					switchCount = 0
+					failedAccountIDs = make(map[int64]struct{})
+					sameAccountRetryCount = make(map[int64]int)
+					lastFailoverErr = nil
+					continue
+				}
 				if len(failedAccountIDs) == 0 {
 					h.handleStreamingAwareError(c, http.StatusServiceUnavailable, "api_error", "No available accounts: "+err.Error(), streamStarted)
 					return
@@ -463,7 +667,7 @@ func (h *GatewayHandler) Messages(c *gin.Context) {
 				// 谷歌上游 503 (MODEL_CAPACITY_EXHAUSTED) 通常是暂时性的，等几秒就能恢复。
 				if lastFailoverErr != nil && lastFailoverErr.StatusCode == http.StatusServiceUnavailable && switchCount <= maxAccountSwitches {
 					if sleepAntigravitySingleAccountBackoff(c.Request.Context(), switchCount) {
-						log.Printf("Antigravity single-account 503 retry: clearing failed accounts, retry %d/%d", switchCount, maxAccountSwitches)
+						slog.InfoContext(c.Request.Context(), "antigravity single-account 503 retry, clearing failed accounts", "switch_count", switchCount, "max_switches", maxAccountSwitches)
 						failedAccountIDs = make(map[int64]struct{})
 						// 设置 context 标记，让 Service 层预检查等待限流过期而非直接切换
 						ctx := context.WithValue(c.Request.Context(), ctxkey.SingleAccountRetry, true)
@@ -472,9 +676,9 @@ func (h *GatewayHandler) Messages(c *gin.Context) {
 					}
 				}
 				if lastFailoverErr != nil {
-					h.handleFailoverExhausted(c, lastFailoverErr, platform, streamStarted)
+					h.handleFailoverExhausted(c, lastFailoverErr, retry, platform, streamStarted)
 				} else {
-					h.handleFailoverExhaustedSimple(c, 502, streamStarted)
+					h.handleFailoverExhaustedSimple(c, platform, 502, streamStarted)
 				}
 				return
 			}
@@ -499,6 +703,9 @@ func (h *GatewayHandler) Messages(c *gin.Context) {
 
 			// 3. 获取账号并发槽位
 			accountReleaseFunc := selection.ReleaseFunc
+			if !commitAccountReservation(c.Request.Context(), selection) {
+				continue
+			}
 			if !selection.Acquired {
 				if selection.WaitPlan == nil {
 					h.handleStreamingAwareError(c, http.StatusServiceUnavailable, "api_error", "No available accounts", streamStarted)
@@ -507,9 +714,9 @@ func (h *GatewayHandler) Messages(c *gin.Context) {
 				accountWaitCounted := false
 				canWait, err := h.concurrencyHelper.IncrementAccountWaitCount(c.Request.Context(), account.ID, selection.WaitPlan.MaxWaiting)
 				if err != nil {
-					log.Printf("Increment account wait count failed: %v", err)
+					slog.WarnContext(c.Request.Context(), "increment account wait count failed", "error", err)
 				} else if !canWait {
-					log.Printf("Account wait queue full: account=%d", account.ID)
+					slog.WarnContext(c.Request.Context(), "account wait queue full")
 					h.handleStreamingAwareError(c, http.StatusTooManyRequests, "rate_limit_error", "Too many pending requests, please retry later", streamStarted)
 					return
 				}
@@ -531,7 +738,7 @@ func (h *GatewayHandler) Messages(c *gin.Context) {
 					&streamStarted,
 				)
 				if err != nil {
-					log.Printf("Account concurrency acquire failed: %v", err)
+					slog.WarnContext(c.Request.Context(), "account concurrency acquire failed", "error", err)
 					h.handleConcurrencyError(c, err, "account", streamStarted)
 					return
 				}
@@ -540,22 +747,56 @@ func (h *GatewayHandler) Messages(c *gin.Context) {
 					accountWaitCounted = false
 				}
 				if err := h.gatewayService.BindStickySession(c.Request.Context(), currentAPIKey.GroupID, sessionKey, account.ID); err != nil {
-					log.Printf("Bind sticky session failed: %v", err)
+					slog.WarnContext(c.Request.Context(), "bind sticky session failed", "error", err)
 				}
 			}
 			// 账号槽位/等待计数需要在超时或断开时安全回收
 			accountReleaseFunc = wrapReleaseOnDone(c.Request.Context(), accountReleaseFunc)
 
 			// 转发请求 - 根据账号平台分流
-			var result *service.ForwardResult
-			requestCtx := c.Request.Context()
-			if switchCount > 0 {
-				requestCtx = context.WithValue(requestCtx, ctxkey.AccountSwitchCount, switchCount)
+			forward := func(requestCtx context.Context, fc *gin.Context, acc *service.Account) (*service.ForwardResult, error) {
+				if acc.Platform == service.PlatformAntigravity && acc.Type != service.AccountTypeAPIKey {
+					return h.antigravityGatewayService.Forward(requestCtx, fc, acc, body, hasBoundSession)
+				}
+				return h.gatewayService.Forward(service.WithUpstreamAttemptTimeout(requestCtx, failoverBudget.AttemptTimeout), fc, acc, parsedReq)
 			}
-			if account.Platform == service.PlatformAntigravity && account.Type != service.AccountTypeAPIKey {
-				result, err = h.antigravityGatewayService.Forward(requestCtx, c, account, body, hasBoundSession)
+			var result *service.ForwardResult
+			if failoverBudget.HedgeAfter > 0 && switchCount == 0 {
+				// 请求对冲：首个账号迟迟未开始输出时并行尝试另一账号，只有获胜方的结果会被计费
+				primaryAccount := account
+				var hedgeAccount *service.Account
+				outcome := runHedgedForward(c, failoverBudget.HedgeAfter,
+					func(fc *gin.Context) (*service.ForwardResult, error) {
+						return forward(fc.Request.Context(), fc, primaryAccount)
+					},
+					func() (hedgeForwardFunc[*service.ForwardResult], bool) {
+						excluded := hedgeExcludedAccounts(failedAccountIDs, primaryAccount.ID)
+						hedgeSelection, err := h.gatewayService.SelectAccountWithLoadAwareness(c.Request.Context(), currentAPIKey.GroupID, "", reqModel, excluded, parsedReq.MetadataUserID)
+						release, ok := acquireHedgeSelection(c.Request.Context(), hedgeSelection, err)
+						if !ok {
+							return nil, false
+						}
+						hedgeAccount = hedgeSelection.Account
+						slog.InfoContext(c.Request.Context(), "first account slow to respond, hedging request", "account_id", primaryAccount.ID, "hedge_account_id", hedgeAccount.ID, "hedge_after_ms", failoverBudget.HedgeAfter.Milliseconds())
+						return func(fc *gin.Context) (*service.ForwardResult, error) {
+							defer release()
+							return forward(fc.Request.Context(), fc, hedgeAccount)
+						}, true
+					})
+				result, err = outcome.result, outcome.err
+				if outcome.hedged {
+					service.ObserveGatewayHedge(primaryAccount.Platform, currentAPIKey.GroupID, hedgeWinnerLabel(outcome))
+				}
+				if outcome.winner == hedgeSecondary {
+					account = hedgeAccount
+					setOpsSelectedAccount(c, account.ID)
+				}
 			} else {
-				result, err = h.gatewayService.Forward(requestCtx, c, account, parsedReq)
+				requestCtx := c.Request.Context()
+				if switchCount > 0 {
+					requestCtx = context.WithValue(requestCtx, ctxkey.AccountSwitchCount, switchCount)
+				}
+				result, err = forward(requestCtx, c, account)
 			}
 			if accountReleaseFunc != nil {
 				accountReleaseFunc()
@@ -563,23 +804,23 @@ func (h *GatewayHandler) Messages(c *gin.Context) {
 			if err != nil {
 				var promptTooLongErr *service.PromptTooLongError
 				if errors.As(err, &promptTooLongErr) {
-					log.Printf("Prompt too long from antigravity: group=%d fallback_group_id=%v fallback_used=%v", currentAPIKey.GroupID, fallbackGroupID, fallbackUsed)
+					slog.InfoContext(c.Request.Context(), "prompt too long from antigravity", "group_id", currentAPIKey.GroupID, "fallback_group_id", fallbackGroupID, "fallback_used", fallbackUsed)
 					if !fallbackUsed && fallbackGroupID != nil && *fallbackGroupID > 0 {
 						fallbackGroup, err := h.gatewayService.ResolveGroupByID(c.Request.Context(), *fallbackGroupID)
 						if err != nil {
-							log.Printf("Resolve fallback group failed: %v", err)
+							slog.WarnContext(c.Request.Context(), "resolve fallback group failed", "error", err)
 							_ = h.antigravityGatewayService.WriteMappedClaudeError(c, account, promptTooLongErr.StatusCode, promptTooLongErr.RequestID, promptTooLongErr.Body)
 							return
 						}
 						if fallbackGroup.Platform != service.PlatformAnthropic ||
 							fallbackGroup.SubscriptionType == service.SubscriptionTypeSubscription ||
 							fallbackGroup.FallbackGroupIDOnInvalidRequest != nil {
-							log.Printf("Fallback group invalid: group=%d platform=%s subscription=%s", fallbackGroup.ID, fallbackGroup.Platform, fallbackGroup.SubscriptionType)
+							slog.WarnContext(c.Request.Context(), "fallback group invalid", "group_id", fallbackGroup.ID, "platform", fallbackGroup.Platform, "subscription_type", fallbackGroup.SubscriptionType)
 							_ = h.antigravityGatewayService.WriteMappedClaudeError(c, account, promptTooLongErr.StatusCode, promptTooLongErr.RequestID, promptTooLongErr.Body)
 							return
 						}
 						fallbackAPIKey := cloneAPIKeyWithGroup(apiKey, fallbackGroup)
-						if err := h.billingCacheService.CheckBillingEligibility(c.Request.Context(), fallbackAPIKey.User, fallbackAPIKey, fallbackGroup, nil); err != nil {
+						if err := checkBillingEligibility(c, h.billingCacheService, fallbackAPIKey.User, fallbackAPIKey, fallbackGroup, nil); err != nil {
 							status, code, message := billingErrorDetails(err)
 							h.handleStreamingAwareError(c, status, code, message, streamStarted)
 							return
@@ -604,10 +845,10 @@ func (h *GatewayHandler) Messages(c *gin.Context) {
 					}
 
 					// 同账号重试：对 RetryableOnSameAccount 的临时性错误，先在同一账号上重试
-					if failoverErr.RetryableOnSameAccount && sameAccountRetryCount[account.ID] < maxSameAccountRetries {
+					if failoverErr.RetryableOnSameAccount && retry.FailoverEnabled() && sameAccountRetryCount[account.ID] < maxSameAccountRetries {
 						sameAccountRetryCount[account.ID]++
-						log.Printf("Account %d: retryable error %d, same-account retry %d/%d",
-							account.ID, failoverErr.StatusCode, sameAccountRetryCount[account.ID], maxSameAccountRetries)
+						slog.InfoContext(c.Request.Context(), "retryable upstream error, retrying same account",
+							"upstream_status", failoverErr.StatusCode, "retry", sameAccountRetryCount[account.ID], "max_retries", maxSameAccountRetries)
 						if !sleepSameAccountRetryDelay(c.Request.Context()) {
 							return
 						}
@@ -620,30 +861,46 @@ func (h *GatewayHandler) Messages(c *gin.Context) {
 					}
 
 					failedAccountIDs[account.ID] = struct{}{}
-					if switchCount >= maxAccountSwitches {
-						h.handleFailoverExhausted(c, failoverErr, account.Platform, streamStarted)
+					if !retry.ShouldRetry(failoverErr.StatusCode, switchCount) {
+						if nextVirtualTarget() {
+							switchCount = 0
+							failedAccountIDs = make(map[int64]struct{})
+							sameAccountRetryCount = make(map[int64]int)
+							lastFailoverErr = nil
+							continue
+						}
+						h.handleFailoverExhausted(c, failoverErr, retry, account.Platform, streamStarted)
 						return
 					}
 					switchCount++
-					log.Printf("Account %d: upstream error %d, switching account %d/%d", account.ID, failoverErr.StatusCode, switchCount, maxAccountSwitches)
-					if account.Platform == service.PlatformAntigravity {
-						if !sleepFailoverDelay(c.Request.Context(), switchCount) {
-							return
-						}
+					service.ObserveGatewayFailover(account, currentAPIKey.GroupID)
+					slog.WarnContext(c.Request.Context(), "upstream error, switching account", "upstream_status", failoverErr.StatusCode, "switch_count", switchCount, "max_switches", maxAccountSwitches)
+					if !waitBeforeAccountSwitch(c.Request.Context(), retry, account.Platform, switchCount) {
+						return
 					}
 					continue
 				}
 				// 错误响应已在Forward中处理，这里只记录日志
-				log.Printf("Account %d: Forward request failed: %v", account.ID, err)
+				slog.ErrorContext(c.Request.Context(), "forward request failed", "error", err)
 				return
 			}
 
+			storeResponseCache(account.ID, result.Model)
+			h.gatewayService.MarkPromptCacheWarm(c.Request.Context(), currentAPIKey.GroupID, sessionKey, account, result.Usage.CacheReadInputTokens)
+			recordStreamObservation(h.streamAbuseService, disconnectWatch, currentAPIKey, result.FirstTokenMs, result.Duration)
+			setLiveTrafficResult(c, account, result.Usage.InputTokens, result.Usage.OutputTokens, result.FirstTokenMs)
+			setOpsForwardTiming(c, result.Stream, result.Duration, result.FirstTokenMs)
+
 			// 捕获请求信息（用于异步记录，避免在 goroutine 中访问 gin.Context）
 			userAgent := c.GetHeader("User-Agent")
 			clientIP := ip.GetClientIP(c)
+			reqCtx := c.Request.Context()
 
 			// 异步记录使用量（subscription已在函数开头获取）
+			// 登记为关闭前需完成的任务，优雅关闭时等待用量记录落库
+			finishTask := h.shutdown.BeginTask()
 			go func(result *service.ForwardResult, usedAccount *service.Account, ua, clientIP string, fcb bool) {
+				defer finishTask()
 				ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
 				defer cancel()
 				if err := h.gatewayService.RecordUsage(ctx, &service.RecordUsageInput{
@@ -657,7 +914,7 @@ func (h *GatewayHandler) Messages(c *gin.Context) {
 					ForceCacheBilling: fcb,
 					APIKeyService:     h.apiKeyService,
 				}); err != nil {
-					log.Printf("Record usage failed: %v", err)
+					slog.ErrorContext(reqCtx, "record usage failed", "error", err)
 				}
 			}(result, account, userAgent, clientIP, forceCacheBilling)
 			return
@@ -690,6 +947,10 @@ func (h *GatewayHandler) Models(c *gin.Context) {
 		// Build model list from whitelist
 		models := make([]claude.Model, 0, len(availableModels))
 		for _, modelID := range availableModels {
+			// 隐藏分组模型访问策略或 API Key 模型限制不允许请求的模型
+			if apiKey != nil && service.CheckAPIKeyModelAccess(apiKey, modelID) != nil {
+				continue
+			}
 			models = append(models, claude.Model{
 				ID:          modelID,
 				Type:        "model",
@@ -795,21 +1056,30 @@ func (h *GatewayHandler) Usage(c *gin.Context) {
 			return
 		}
 
-		remaining := h.calculateSubscriptionRemaining(apiKey.Group, subscription)
+		rollover, rolloverExpiresAt := 0.0, time.Time{}
+		if h.billingCacheService != nil && apiKey.Group.HasMonthlyLimit() {
+			rollover, rolloverExpiresAt = h.billingCacheService.MonthlyRollover(c.Request.Context(), subscription.UserID, subscription.GroupID)
+		}
+		remaining := h.calculateSubscriptionRemaining(apiKey.Group, subscription, rollover)
+		subscriptionData := gin.H{
+			"daily_usage_usd":   subscription.DailyUsageUSD,
+			"weekly_usage_usd":  subscription.WeeklyUsageUSD,
+			"monthly_usage_usd": subscription.MonthlyUsageUSD,
+			"daily_limit_usd":   apiKey.Group.DailyLimitUSD,
+			"weekly_limit_usd":  apiKey.Group.WeeklyLimitUSD,
+			"monthly_limit_usd": apiKey.Group.MonthlyLimitUSD,
+			"expires_at":        subscription.ExpiresAt,
+		}
+		if rollover > 0 {
+			subscriptionData["monthly_rollover_usd"] = rollover
+			subscriptionData["monthly_rollover_expires_at"] = rolloverExpiresAt
+		}
 		resp := gin.H{
-			"isValid":   true,
-			"planName":  apiKey.Group.Name,
-			"remaining": remaining,
-			"unit":      "USD",
-			"subscription": gin.H{
-				"daily_usage_usd":   subscription.DailyUsageUSD,
-				"weekly_usage_usd":  subscription.WeeklyUsageUSD,
-				"monthly_usage_usd": subscription.MonthlyUsageUSD,
-				"daily_limit_usd":   apiKey.Group.DailyLimitUSD,
-				"weekly_limit_usd":  apiKey.Group.WeeklyLimitUSD,
-				"monthly_limit_usd": apiKey.Group.MonthlyLimitUSD,
-				"expires_at":        subscription.ExpiresAt,
-			},
+			"isValid":      true,
+			"planName":     apiKey.Group.Name,
+			"remaining":    remaining,
+			"unit":         "USD",
+			"subscription": subscriptionData,
 		}
 		if usageData != nil {
 			resp["usage"] = usageData
@@ -838,11 +1108,11 @@ func (h *GatewayHandler) Usage(c *gin.Context) {
 	c.JSON(http.StatusOK, resp)
 }
 
-// calculateSubscriptionRemaining 计算订阅剩余可用额度
+// calculateSubscriptionRemaining 计算订阅剩余可用额度（月额度包含有效的结转额度）
 // 逻辑：
 // 1. 如果日/周/月任一限额达到100%，返回0
 // 2. 否则返回所有已配置周期中剩余额度的最小值
-func (h *GatewayHandler) calculateSubscriptionRemaining(group *service.Group, sub *service.UserSubscription) float64 {
+func (h *GatewayHandler) calculateSubscriptionRemaining(group *service.Group, sub *service.UserSubscription, monthlyRollover float64) float64 {
 	var remainingValues []float64
 
 	// 检查日限额
@@ -865,7 +1135,7 @@ func (h *GatewayHandler) calculateSubscriptionRemaining(group *service.Group, su
 
 	// 检查月限额
 	if group.HasMonthlyLimit() {
-		remaining := *group.MonthlyLimitUSD - sub.MonthlyUsageUSD
+		remaining := *group.MonthlyLimitUSD + monthlyRollover - sub.MonthlyUsageUSD
 		if remaining <= 0 {
 			return 0
 		}
@@ -889,6 +1159,10 @@ func (h *GatewayHandler) calculateSubscriptionRemaining(group *service.Group, su
 
 // handleConcurrencyError handles concurrency-related errors with proper 429 response
 func (h *GatewayHandler) handleConcurrencyError(c *gin.Context, err error, slotType string, streamStarted bool) {
+	if isConcurrencyWaitCanceled(err) {
+		// 客户端已断开，无需再写响应
+		return
+	}
 	h.handleStreamingAwareError(c, http.StatusTooManyRequests, "rate_limit_error",
 		fmt.Sprintf("Concurrency limit exceeded for %s, please retry later", slotType), streamStarted)
 }
@@ -931,6 +1205,40 @@ func sleepFailoverDelay(ctx context.Context, switchCount int) bool {
 	}
 }
 
+// waitBeforeAccountSwitch 切换账号前等待：分组配置了退避时按重试策略（指数退避+抖动）等待，
+// 否则 Antigravity 沿用线性递增延时、其他平台立即切换。返回 false 表示 context 已取消。
+func waitBeforeAccountSwitch(ctx context.Context, retry *service.RetryController, platform string, switchCount int) bool {
+	if retry != nil && retry.HasBackoff() {
+		return retry.Wait(ctx, switchCount)
+	}
+	if platform == service.PlatformAntigravity {
+		return sleepFailoverDelay(ctx, switchCount)
+	}
+	return true
+}
+
+// writeUpstreamErrorVerbatim 分组故障转移策略要求原样返回上游错误时，按上游状态码、
+// 白名单内的响应头与原始响应体直接返回。流已开始输出或上游未返回响应体时返回 false，
+// 由调用方继续按透传规则与错误映射处理。
+func writeUpstreamErrorVerbatim(c *gin.Context, retry *service.RetryController, failoverErr *service.UpstreamFailoverError, streamStarted bool) bool {
+	if streamStarted || !retry.PassthroughUpstreamError() || failoverErr == nil || len(failoverErr.ResponseBody) == 0 {
+		return false
+	}
+	headers := responseheaders.FilterHeaders(failoverErr.ResponseHeaders, config.ResponseHeaderConfig{})
+	// 响应体已解压，不能沿用上游的编码声明
+	headers.Del("Content-Encoding")
+	dst := c.Writer.Header()
+	for key, values := range headers {
+		dst[key] = values
+	}
+	contentType := headers.Get("Content-Type")
+	if contentType == "" {
+		contentType = "application/json"
+	}
+	c.Data(failoverErr.StatusCode, contentType, failoverErr.ResponseBody)
+	return true
+}
+
 // sleepAntigravitySingleAccountBackoff Antigravity 平台单账号分组的 503 退避重试延时。
 // 当分组内只有一个可用账号且上游返回 503（MODEL_CAPACITY_EXHAUSTED）时使用，
 // 采用短固定延时策略。Service 层在 SingleAccountRetry 模式下已经做了充分的原地重试
@@ -942,7 +1250,7 @@ func sleepAntigravitySingleAccountBackoff(ctx context.Context, retryCount int) b
 	// Handler 层只需短暂间隔后重新进入 Service 层即可。
 	const delay = 2 * time.Second
 
-	log.Printf("Antigravity single-account 503 backoff: waiting %v before retry (attempt %d)", delay, retryCount)
+	slog.InfoContext(ctx, "antigravity single-account 503 backoff", "delay", delay, "attempt", retryCount)
 
 	select {
 	case <-ctx.Done():
@@ -952,13 +1260,19 @@ func sleepAntigravitySingleAccountBackoff(ctx context.Context, retryCount int) b
 	}
 }
 
-func (h *GatewayHandler) handleFailoverExhausted(c *gin.Context, failoverErr *service.UpstreamFailoverError, platform string, streamStarted bool) {
+func (h *GatewayHandler) handleFailoverExhausted(c *gin.Context, failoverErr *service.UpstreamFailoverError, retry *service.RetryController, platform string, streamStarted bool) {
 	statusCode := failoverErr.StatusCode
 	responseBody := failoverErr.ResponseBody
+	service.SetUpstreamErrorDebugSource(c, statusCode, responseBody)
+
+	// 分组要求原样返回上游错误
+	if writeUpstreamErrorVerbatim(c, retry, failoverErr, streamStarted) {
+		return
+	}
 
 	// 先检查透传规则
-	if h.errorPassthroughService != nil && len(responseBody) > 0 {
-		if rule := h.errorPassthroughService.MatchRule(platform, statusCode, responseBody); rule != nil {
+	if h.errorPassthroughService != nil && (len(responseBody) > 0 || len(failoverErr.ResponseHeaders) > 0) {
+		if rule := h.errorPassthroughService.MatchRuleWithHeaders(platform, statusCode, failoverErr.ResponseHeaders, responseBody); rule != nil {
 			// 确定响应状态码
 			respCode := statusCode
 			if !rule.PassthroughCode && rule.ResponseCode != nil {
@@ -980,32 +1294,22 @@ func (h *GatewayHandler) handleFailoverExhausted(c *gin.Context, failoverErr *se
 		}
 	}
 
-	// 使用默认的错误映射
-	status, errType, errMsg := h.mapUpstreamError(statusCode)
+	// 使用错误映射（管理员配置优先，其次内置映射）
+	status, errType, errMsg := h.mapUpstreamError(c, platform, statusCode, responseBody)
 	h.handleStreamingAwareError(c, status, errType, errMsg, streamStarted)
 }
 
 // handleFailoverExhaustedSimple 简化版本，用于没有响应体的情况
-func (h *GatewayHandler) handleFailoverExhaustedSimple(c *gin.Context, statusCode int, streamStarted bool) {
-	status, errType, errMsg := h.mapUpstreamError(statusCode)
+func (h *GatewayHandler) handleFailoverExhaustedSimple(c *gin.Context, platform string, statusCode int, streamStarted bool) {
+	service.SetUpstreamErrorDebugSource(c, statusCode, nil)
+	status, errType, errMsg := h.mapUpstreamError(c, platform, statusCode, nil)
 	h.handleStreamingAwareError(c, status, errType, errMsg, streamStarted)
 }
 
-func (h *GatewayHandler) mapUpstreamError(statusCode int) (int, string, string) {
-	switch statusCode {
-	case 401:
-		return http.StatusBadGateway, "upstream_error", "Upstream authentication failed, please contact administrator"
-	case 403:
-		return http.StatusBadGateway, "upstream_error", "Upstream access forbidden, please contact administrator"
-	case 429:
-		return http.StatusTooManyRequests, "rate_limit_error", "Upstream rate limit exceeded, please retry later"
-	case 529:
-		return http.StatusServiceUnavailable, "overloaded_error", "Upstream service overloaded, please retry later"
-	case 500, 502, 503, 504:
-		return http.StatusBadGateway, "upstream_error", "Upstream service temporarily unavailable"
-	default:
-		return http.StatusBadGateway, "upstream_error", "Upstream request failed"
-	}
+// mapUpstreamError 将上游错误映射为返回给客户端的状态码、错误类型与消息
+func (h *GatewayHandler) mapUpstreamError(c *gin.Context, platform string, statusCode int, responseBody []byte) (int, string, string) {
+	m := h.upstreamErrorMapping.Map(c.Request.Context(), platform, statusCode, responseBody)
+	return m.Status, m.Type, m.Message
 }
 
 // handleStreamingAwareError handles errors that may occur after streaming has started
@@ -1015,12 +1319,16 @@ func (h *GatewayHandler) handleStreamingAwareError(c *gin.Context, status int, e
 		flusher, ok := c.Writer.(http.Flusher)
 		if ok {
 			// Send error event in SSE format with proper JSON marshaling
+			errorBody := map[string]any{
+				"type":    errType,
+				"message": message,
+			}
+			if debug := service.BuildUpstreamErrorDebug(c, 0, nil); debug != nil {
+				errorBody["upstream"] = debug
+			}
 			errorData := map[string]any{
-				"type": "error",
-				"error": map[string]string{
-					"type":    errType,
-					"message": message,
-				},
+				"type":  "error",
+				"error": errorBody,
 			}
 			jsonBytes, err := json.Marshal(errorData)
 			if err != nil {
@@ -1042,12 +1350,17 @@ func (h *GatewayHandler) handleStreamingAwareError(c *gin.Context, status int, e
 
 // errorResponse 返回Claude API格式的错误响应
 func (h *GatewayHandler) errorResponse(c *gin.Context, status int, errType, message string) {
+	errorBody := gin.H{
+		"type":    errType,
+		"message": message,
+	}
+	// API Key 开启调试模式时附带脱敏后的上游错误详情
+	if debug := service.BuildUpstreamErrorDebug(c, 0, nil); debug != nil {
+		errorBody["upstream"] = debug
+	}
 	c.JSON(status, gin.H{
-		"type": "error",
-		"error": gin.H{
-			"type":    errType,
-			"message": message,
-		},
+		"type":  "error",
+		"error": errorBody,
 	})
 }
 
@@ -1084,11 +1397,17 @@ func (h *GatewayHandler) CountTokens(c *gin.Context) {
 		return
 	}
 
+	// 剔除客户端注入的随机字段，保证粘性会话 hash 稳定
+	body = stripRequestFields(c, h.requestStripService, domain.PlatformAnthropic, body)
+
 	// 检查是否为 Claude Code 客户端，设置到 context 中
 	SetClaudeCodeClientContext(c, body)
 
 	setOpsRequestContext(c, "", false, body)
 
+	// 解析模型名推理强度后缀（如 claude-sonnet-4-5-thinking），在解析请求前写入 thinking 参数
+	body = applyModelSuffix(c, body, domain.PlatformAnthropic)
+
 	parsedReq, err := service.ParseGatewayRequest(body, domain.PlatformAnthropic)
 	if err != nil {
 		h.errorResponse(c, http.StatusBadRequest, "invalid_request_error", "Failed to parse request body")
@@ -1103,6 +1422,55 @@ func (h *GatewayHandler) CountTokens(c *gin.Context) {
 		return
 	}
 
+	// 与 Messages 保持一致：按模型别名改写后再选择账号
+	if aliased, aliasedBody := applyModelAlias(c, h.modelAliasService, apiKey, parsedReq.Model, body); aliased != parsedReq.Model {
+		parsedReq.Model, parsedReq.Body, body = aliased, aliasedBody, aliasedBody
+	}
+
+	// 虚拟模型按回退链第一个目标计算 token（count_tokens 不做链式回退）
+	virtualChain, virtualModel, virtualBody, err := applyVirtualModel(c, h.virtualModelService, apiKey, parsedReq.Model, body)
+	if err != nil {
+		h.errorResponse(c, http.StatusBadRequest, "invalid_request_error", err.Error())
+		return
+	}
+	if virtualChain != nil {
+		parsedReq.Model, parsedReq.Body, body = virtualModel, virtualBody, virtualBody
+	}
+
+	// 校验分组模型访问策略（允许/禁止列表）与 API Key 的模型限制
+	if err := service.CheckAPIKeyModelAccess(apiKey, parsedReq.Model); err != nil {
+		h.errorResponse(c, http.StatusBadRequest, "invalid_request_error", err.Error())
+		return
+	}
+
+	// 校验客户端自带的会话标识（X-Sub2API-Session），存在时优先用于粘性路由
+	clientSessionHash, err := service.ClientSessionHash(c.GetHeader(service.ClientSessionHeader), apiKey.ID)
+	if err != nil {
+		h.errorResponse(c, http.StatusBadRequest, "invalid_request_error", err.Error())
+		return
+	}
+
+	// 与 Messages 保持一致：按系统提示词策略与提示词模板改写后再计算 token
+	if body, err = applyAnthropicSystemPromptPolicy(c, apiKey, parsedReq, body); err != nil {
+		h.errorResponse(c, http.StatusInternalServerError, "api_error", "Failed to process request")
+		return
+	}
+	if body, err = service.ApplyPromptTemplate(apiKey, body, domain.PlatformAnthropic, parsedReq.Model); err != nil {
+		h.errorResponse(c, http.StatusInternalServerError, "api_error", "Failed to process request")
+		return
+	}
+	body, rejectMsg := sanitizeRequest(c, h.requestSanitizeService, apiKey, domain.PlatformAnthropic, body)
+	if rejectMsg != "" {
+		h.errorResponse(c, http.StatusBadRequest, "invalid_request_error", rejectMsg)
+		return
+	}
+	// 严格字段模式下拒绝未知顶层字段（宽松模式原样透传）
+	if rejectMsg := checkUnknownRequestFields(apiKey, service.RequestSchemaAnthropicMessages, body); rejectMsg != "" {
+		h.errorResponse(c, http.StatusBadRequest, "invalid_request_error", rejectMsg)
+		return
+	}
+	parsedReq.Body = body
+
 	setOpsRequestContext(c, parsedReq.Model, parsedReq.Stream, body)
 
 	// 获取订阅信息（可能为nil）
@@ -1110,19 +1478,29 @@ func (h *GatewayHandler) CountTokens(c *gin.Context) {
 
 	// 校验 billing eligibility（订阅/余额）
 	// 【注意】不计算并发，但需要校验订阅/余额
-	if err := h.billingCacheService.CheckBillingEligibility(c.Request.Context(), apiKey.User, apiKey, apiKey.Group, subscription); err != nil {
+	if err := checkBillingEligibility(c, h.billingCacheService, apiKey.User, apiKey, apiKey.Group, subscription); err != nil {
 		status, code, message := billingErrorDetails(err)
 		h.errorResponse(c, status, code, message)
 		return
 	}
 
+	// 开启本地计数时直接按本地分词器估算应答，不选择账号、不请求上游
+	if h.tokenCounter.LocalCountTokens() {
+		count := h.tokenCounter.CountRequestTokens(domain.PlatformAnthropic, parsedReq.Model, body)
+		c.JSON(http.StatusOK, gin.H{"input_tokens": count.InputTokens})
+		return
+	}
+
 	// 计算粘性会话 hash
 	parsedReq.SessionContext = &service.SessionContext{
 		ClientIP:  ip.GetClientIP(c),
 		UserAgent: c.GetHeader("User-Agent"),
 		APIKeyID:  apiKey.ID,
 	}
-	sessionHash := h.gatewayService.GenerateSessionHash(parsedReq)
+	sessionHash := clientSessionHash
+	if sessionHash == "" {
+		sessionHash = h.gatewayService.GenerateSessionHash(parsedReq)
+	}
 
 	// 选择支持该模型的账号
 	account, err := h.gatewayService.SelectAccountForModel(c.Request.Context(), apiKey.GroupID, sessionHash, parsedReq.Model)
@@ -1134,12 +1512,53 @@ func (h *GatewayHandler) CountTokens(c *gin.Context) {
 
 	// 转发请求（不记录使用量）
 	if err := h.gatewayService.ForwardCountTokens(c.Request.Context(), c, account, parsedReq); err != nil {
-		log.Printf("Forward count_tokens request failed: %v", err)
+		slog.ErrorContext(c.Request.Context(), "forward count_tokens request failed", "error", err)
 		// 错误响应已在 ForwardCountTokens 中处理
 		return
 	}
 }
 
+// TokenCount estimates prompt tokens with the local tokenizer without contacting any upstream
+// POST /v1/token-count
+// 接受任意受支持格式（Anthropic Messages / Chat Completions / Responses / Gemini）的请求体，
+// 按请求模型与分组平台选择分词器；不校验余额、不占用并发、不记录使用量
+func (h *GatewayHandler) TokenCount(c *gin.Context) {
+	apiKey, ok := middleware2.GetAPIKeyFromContext(c)
+	if !ok {
+		h.errorResponse(c, http.StatusUnauthorized, "authentication_error", "Invalid API key")
+		return
+	}
+
+	body, err := io.ReadAll(c.Request.Body)
+	if err != nil {
+		if maxErr, ok := extractMaxBytesError(err); ok {
+			h.errorResponse(c, http.StatusRequestEntityTooLarge, "invalid_request_error", buildBodyTooLargeMessage(maxErr.Limit))
+			return
+		}
+		h.errorResponse(c, http.StatusBadRequest, "invalid_request_error", "Failed to read request body")
+		return
+	}
+	if !gjson.ValidBytes(body) {
+		h.errorResponse(c, http.StatusBadRequest, "invalid_request_error", "Failed to parse request body")
+		return
+	}
+
+	model := gjson.GetBytes(body, "model").String()
+	if model == "" {
+		h.errorResponse(c, http.StatusBadRequest, "invalid_request_error", "model is required")
+		return
+	}
+
+	platform := domain.PlatformAnthropic
+	if forced, ok := middleware2.GetForcePlatformFromContext(c); ok && forced != "" {
+		platform = forced
+	} else if apiKey.Group != nil && apiKey.Group.Platform != "" {
+		platform = apiKey.Group.Platform
+	}
+
+	c.JSON(http.StatusOK, h.tokenCounter.CountRequestTokens(platform, model, body))
+}
+
 // InterceptType 表示请求拦截类型
 type InterceptType int
 
@@ -0,0 +1,45 @@
+package handler
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/Wei-Shaw/sub2api/internal/service"
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/require"
+)
+
+func TestWriteUpstreamErrorVerbatim(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	failoverErr := &service.UpstreamFailoverError{
+		StatusCode:   http.StatusTooManyRequests,
+		ResponseBody: []byte(`{"type":"error","error":{"type":"rate_limit_error","message":"slow down"}}`),
+		ResponseHeaders: http.Header{
+			"Content-Type":     {"application/json; charset=utf-8"},
+			"Content-Encoding": {"gzip"},
+			"Retry-After":      {"12"},
+			"Set-Cookie":       {"secret=1"},
+		},
+	}
+	passthrough := service.NewRetryController(service.RetryPolicy{PassthroughUpstreamError: true}, 0)
+
+	rec := httptest.NewRecorder()
+	c, _ := gin.CreateTestContext(rec)
+	require.True(t, writeUpstreamErrorVerbatim(c, passthrough, failoverErr, false))
+	require.Equal(t, http.StatusTooManyRequests, rec.Code)
+	require.Equal(t, string(failoverErr.ResponseBody), rec.Body.String())
+	require.Equal(t, "12", rec.Header().Get("Retry-After"))
+	require.Equal(t, "application/json; charset=utf-8", rec.Header().Get("Content-Type"))
+	require.Empty(t, rec.Header().Get("Content-Encoding"))
+	require.Empty(t, rec.Header().Get("Set-Cookie"))
+
+	// 未开启、流已开始或无响应体时交由错误映射处理
+	rec = httptest.NewRecorder()
+	c, _ = gin.CreateTestContext(rec)
+	require.False(t, writeUpstreamErrorVerbatim(c, service.NewRetryController(service.RetryPolicy{}, 0), failoverErr, false))
+	require.False(t, writeUpstreamErrorVerbatim(c, passthrough, failoverErr, true))
+	require.False(t, writeUpstreamErrorVerbatim(c, passthrough, &service.UpstreamFailoverError{StatusCode: 502}, false))
+	require.False(t, writeUpstreamErrorVerbatim(c, nil, failoverErr, false))
+	require.False(t, c.Writer.Written())
+}
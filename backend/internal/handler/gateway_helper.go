@@ -3,20 +3,209 @@ package handler
 import (
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
+	"log/slog"
 	"math/rand"
 	"net/http"
+	"strconv"
+	"strings"
 	"sync"
+	"sync/atomic"
 	"time"
 
+	"github.com/Wei-Shaw/sub2api/internal/config"
+	"github.com/Wei-Shaw/sub2api/internal/pkg/tracing"
+	"github.com/Wei-Shaw/sub2api/internal/server/middleware"
 	"github.com/Wei-Shaw/sub2api/internal/service"
 
 	"github.com/gin-gonic/gin"
+	"github.com/tidwall/gjson"
+	"github.com/tidwall/sjson"
+	"go.opentelemetry.io/otel/attribute"
 )
 
 // claudeCodeValidator is a singleton validator for Claude Code client detection
 var claudeCodeValidator = service.NewClaudeCodeValidator()
 
+// stripRequestFields 在会话 hash 与请求规范化前按管理员配置剔除客户端注入的随机字段（nonce、时间戳等）
+func stripRequestFields(c *gin.Context, svc *service.RequestStripService, protocol string, body []byte) []byte {
+	newBody, stripped := svc.Apply(c.Request.Context(), protocol, body)
+	if len(stripped) > 0 {
+		slog.InfoContext(c.Request.Context(), "request fields stripped", "protocol", protocol, "fields", strings.Join(stripped, ","))
+	}
+	return newBody
+}
+
+// sanitizeRequest 按管理员配置的请求净化规则剔除或拒绝危险/不支持的字段与工具；
+// 返回非空的拒绝说明时调用方应以 400 拒绝请求
+func sanitizeRequest(c *gin.Context, svc *service.RequestSanitizeService, apiKey *service.APIKey, protocol string, body []byte) ([]byte, string) {
+	newBody, stripped, err := svc.Apply(c.Request.Context(), apiKey, protocol, body)
+	if err != nil {
+		var rejectErr *service.RequestSanitizeRejectError
+		if errors.As(err, &rejectErr) {
+			slog.WarnContext(c.Request.Context(), "request rejected by sanitize rule", "protocol", protocol, "reason", rejectErr.Message)
+			return body, rejectErr.Message
+		}
+		return body, ""
+	}
+	if len(stripped) > 0 {
+		slog.InfoContext(c.Request.Context(), "request sanitized", "protocol", protocol, "fields", strings.Join(stripped, ","))
+	}
+	return newBody, ""
+}
+
+// checkUnknownRequestFields 分组启用严格字段模式时校验顶层字段；返回非空的拒绝说明时调用方应以 400 拒绝请求
+func checkUnknownRequestFields(apiKey *service.APIKey, schema string, body []byte) string {
+	if apiKey == nil {
+		return ""
+	}
+	if err := service.CheckUnknownRequestFields(apiKey.Group, schema, body); err != nil {
+		slog.Warn("request rejected: unknown top-level fields", "schema", schema, "api_key_id", apiKey.ID, "error", err)
+		return err.Error()
+	}
+	return ""
+}
+
+// applyModelSuffix 解析请求模型名中的推理强度后缀（如 gpt-5.2:high、claude-sonnet-4-5-thinking），
+// 去除后缀并写入对应协议的推理参数；命中时在 context 中记录客户端原始模型（响应中回显）。
+func applyModelSuffix(c *gin.Context, body []byte, protocol string) []byte {
+	model, newBody, ok := service.ApplyModelSuffix(body, protocol)
+	if !ok {
+		return body
+	}
+	origin := gjson.GetBytes(body, "model").String()
+	c.Request = c.Request.WithContext(service.WithModelAliasOrigin(c.Request.Context(), origin))
+	slog.DebugContext(c.Request.Context(), "model suffix applied", "origin_model", origin, "target_model", model)
+	return newBody
+}
+
+// checkBillingEligibility 检查计费资格；用户当期消费超过软上限时放行并写入 X-Spend-Cap-Warning 响应头
+func checkBillingEligibility(c *gin.Context, svc *service.BillingCacheService, user *service.User, apiKey *service.APIKey, group *service.Group, subscription *service.UserSubscription) error {
+	ctx := service.WithSpendCapWarningRecorder(c.Request.Context())
+	if err := svc.CheckBillingEligibility(ctx, user, apiKey, group, subscription); err != nil {
+		return err
+	}
+	if warning := service.SpendCapWarningFromContext(ctx); warning != nil {
+		c.Header(service.SpendCapWarningHeader, warning.HeaderValue())
+	}
+	return nil
+}
+
+// applyModelAlias 在账号选择前按管理员配置的别名规则改写请求模型。
+// 命中时将客户端原始模型写入 request context，供 service 层在响应中回显。
+func applyModelAlias(c *gin.Context, svc *service.ModelAliasService, apiKey *service.APIKey, model string, body []byte) (string, []byte) {
+	if svc == nil || model == "" {
+		return model, body
+	}
+	target, newBody, rule := svc.Apply(c.Request.Context(), requestPlatform(c, apiKey), model, body)
+	if rule == nil {
+		return model, body
+	}
+	ctx := service.WithModelAliasOrigin(c.Request.Context(), model)
+	// 已弃用模型：按替代模型执行，同时通过响应头（及非流式响应中的 warning 字段）提示客户端迁移
+	if deprecation := service.NewModelDeprecation(model, rule); deprecation != nil {
+		ctx = service.WithModelDeprecation(ctx, deprecation)
+		c.Header(service.ModelDeprecatedHeader, deprecation.HeaderValue())
+	}
+	c.Request = c.Request.WithContext(ctx)
+	return target, newBody
+}
+
+// applyAnthropicSystemPromptPolicy 按分组/API Key 的系统提示词策略改写 Messages 请求的 system，
+// 同步更新解析结果（粘性会话 hash 与转发均基于改写后的 system）。
+func applyAnthropicSystemPromptPolicy(c *gin.Context, apiKey *service.APIKey, parsedReq *service.ParsedRequest, body []byte) ([]byte, error) {
+	newBody, err := service.ApplySystemPromptPolicy(apiKey, body, service.PlatformAnthropic, c.GetHeader("User-Agent"))
+	if err != nil {
+		return body, err
+	}
+	if system := gjson.GetBytes(newBody, "system"); system.Exists() {
+		parsedReq.System, parsedReq.HasSystem = system.Value(), true
+	}
+	parsedReq.Body = newBody
+	return newBody, nil
+}
+
+// admitRequestMemory 按内存护栏登记请求体占用；占用接近全局预算时拒绝新的流式请求。
+// 拒绝时已写入 Retry-After 头，调用方需返回 503；准入时返回的 release 需在请求结束时调用。
+func admitRequestMemory(c *gin.Context, guard *service.MemoryGuard, bodySize int, stream bool) (func(), bool) {
+	release, ok := guard.AdmitRequest(bodySize, stream)
+	if ok {
+		return release, true
+	}
+	if retryAfter := guard.RetryAfter(); retryAfter > 0 {
+		c.Header("Retry-After", strconv.Itoa(int(retryAfter/time.Second)))
+	}
+	slog.WarnContext(c.Request.Context(), "memory guard rejected request", "body_bytes", bodySize, "stream", stream)
+	return nil, false
+}
+
+// setRequestFeatures 识别请求依赖的上游能力（图片/工具/长上下文/流式）并写入 context，
+// 账号调度时排除显式声明不具备这些能力的账号
+func setRequestFeatures(c *gin.Context, body []byte, stream bool) {
+	features := service.DetectRequestFeatures(body, stream)
+	if strings.Contains(c.GetHeader("anthropic-beta"), "context-1m") {
+		features.LongContext = true
+	}
+	c.Request = c.Request.WithContext(service.WithRequestFeatures(c.Request.Context(), features))
+}
+
+// requestPlatform 返回请求的调度平台：优先使用强制平台，否则使用分组平台
+func requestPlatform(c *gin.Context, apiKey *service.APIKey) string {
+	platform, _ := middleware.GetForcePlatformFromContext(c)
+	if platform == "" && apiKey != nil && apiKey.Group != nil {
+		platform = apiKey.Group.Platform
+	}
+	return platform
+}
+
+// applyVirtualModel 在账号选择前展开管理员配置的虚拟模型。
+// 命中时将请求改写为回退链上的第一个目标，并在 context 中记录客户端原始模型（响应中回显）与目标账号范围；
+// 未命中时返回 nil 回退链。
+func applyVirtualModel(c *gin.Context, svc *service.VirtualModelService, apiKey *service.APIKey, model string, body []byte) (*service.VirtualModelChain, string, []byte, error) {
+	if svc == nil || model == "" {
+		return nil, model, body, nil
+	}
+	vm := svc.Resolve(c.Request.Context(), requestPlatform(c, apiKey), model)
+	if vm == nil {
+		return nil, model, body, nil
+	}
+	chain, err := service.NewVirtualModelChain(vm, apiKey)
+	if err != nil {
+		return nil, model, body, err
+	}
+	c.Request = c.Request.WithContext(service.WithModelAliasOrigin(c.Request.Context(), model))
+	target, newBody, ok := useVirtualModelTarget(c, chain, body)
+	if !ok {
+		return nil, model, body, fmt.Errorf("failed to apply virtual model %s", model)
+	}
+	return chain, target, newBody, nil
+}
+
+// advanceVirtualModel 当前目标无可用账号或故障转移耗尽时切换到回退链的下一个目标，链耗尽时返回 false
+func advanceVirtualModel(c *gin.Context, chain *service.VirtualModelChain, body []byte) (string, []byte, bool) {
+	for chain.Next() {
+		if target, newBody, ok := useVirtualModelTarget(c, chain, body); ok {
+			slog.InfoContext(c.Request.Context(), "virtual model fallback", "virtual_model", chain.Name(), "target_model", target)
+			return target, newBody, true
+		}
+	}
+	return "", body, false
+}
+
+func useVirtualModelTarget(c *gin.Context, chain *service.VirtualModelChain, body []byte) (string, []byte, bool) {
+	target := chain.Current()
+	if body != nil {
+		newBody, err := sjson.SetBytes(body, "model", target.Model)
+		if err != nil {
+			return "", body, false
+		}
+		body = newBody
+	}
+	c.Request = c.Request.WithContext(service.WithVirtualModelTarget(c.Request.Context(), target))
+	return target.Model, body, true
+}
+
 // SetClaudeCodeClientContext 检查请求是否来自 Claude Code 客户端，并设置到 context 中
 // 返回更新后的 context
 func SetClaudeCodeClientContext(c *gin.Context, body []byte) {
@@ -74,32 +263,76 @@ const (
 type ConcurrencyError struct {
 	SlotType  string
 	IsTimeout bool
+	// IsCanceled 表示等待期间客户端已断开（请求被放弃），调用方无需再写响应
+	IsCanceled bool
 }
 
 func (e *ConcurrencyError) Error() string {
+	if e.IsCanceled {
+		return fmt.Sprintf("request canceled while waiting for %s concurrency slot", e.SlotType)
+	}
 	if e.IsTimeout {
 		return fmt.Sprintf("timeout waiting for %s concurrency slot", e.SlotType)
 	}
 	return fmt.Sprintf("%s concurrency limit reached", e.SlotType)
 }
 
+// isConcurrencyWaitCanceled 判断并发等待是否因客户端断开而放弃
+func isConcurrencyWaitCanceled(err error) bool {
+	var concurrencyErr *ConcurrencyError
+	if errors.As(err, &concurrencyErr) && concurrencyErr.IsCanceled {
+		return true
+	}
+	return errors.Is(err, context.Canceled)
+}
+
 // ConcurrencyHelper provides common concurrency slot management for gateway handlers
 type ConcurrencyHelper struct {
 	concurrencyService *service.ConcurrencyService
 	pingFormat         SSEPingFormat
-	pingInterval       time.Duration
+	pingInterval       atomic.Int64 // time.Duration，支持配置热重载
 }
 
 // NewConcurrencyHelper creates a new ConcurrencyHelper
 func NewConcurrencyHelper(concurrencyService *service.ConcurrencyService, pingFormat SSEPingFormat, pingInterval time.Duration) *ConcurrencyHelper {
+	h := &ConcurrencyHelper{
+		concurrencyService: concurrencyService,
+		pingFormat:         pingFormat,
+	}
+	h.SetPingInterval(pingInterval)
+	return h
+}
+
+// SetPingInterval updates the SSE ping interval used by subsequent waits (<=0 restores the default).
+func (h *ConcurrencyHelper) SetPingInterval(pingInterval time.Duration) {
 	if pingInterval <= 0 {
 		pingInterval = defaultPingInterval
 	}
-	return &ConcurrencyHelper{
-		concurrencyService: concurrencyService,
-		pingFormat:         pingFormat,
-		pingInterval:       pingInterval,
+	h.pingInterval.Store(int64(pingInterval))
+}
+
+// gatewayFailoverLimits 账号切换相关配置，配置热重载时整体原子替换
+type gatewayFailoverLimits struct {
+	maxAccountSwitches       int
+	maxAccountSwitchesGemini int
+	failoverClasses          map[string]config.GatewayFailoverClassConfig
+}
+
+func newGatewayFailoverLimits(cfg *config.Config, defaultSwitches, defaultSwitchesGemini int) *gatewayFailoverLimits {
+	limits := &gatewayFailoverLimits{
+		maxAccountSwitches:       defaultSwitches,
+		maxAccountSwitchesGemini: defaultSwitchesGemini,
+	}
+	if cfg != nil {
+		limits.failoverClasses = cfg.Gateway.FailoverClasses
+		if cfg.Gateway.MaxAccountSwitches > 0 {
+			limits.maxAccountSwitches = cfg.Gateway.MaxAccountSwitches
+		}
+		if cfg.Gateway.MaxAccountSwitchesGemini > 0 {
+			limits.maxAccountSwitchesGemini = cfg.Gateway.MaxAccountSwitchesGemini
+		}
 	}
+	return limits
 }
 
 // wrapReleaseOnDone ensures release runs at most once and still triggers on context cancellation.
@@ -200,8 +433,27 @@ func (h *ConcurrencyHelper) waitForSlotWithPing(c *gin.Context, slotType string,
 }
 
 // waitForSlotWithPingTimeout waits for a concurrency slot with a custom timeout.
+// The wait is recorded as a tracing span so queueing time shows up in traces, and accumulated
+// as the queue-wait (user) or slot-wait (account) phase for the per-group phase metrics.
 func (h *ConcurrencyHelper) waitForSlotWithPingTimeout(c *gin.Context, slotType string, id int64, maxConcurrency int, timeout time.Duration, isStream bool, streamStarted *bool) (func(), error) {
-	ctx, cancel := context.WithTimeout(c.Request.Context(), timeout)
+	ctx, span := tracing.Start(c.Request.Context(), "gateway.slot_wait",
+		attribute.String("slot.type", slotType),
+		attribute.Int64("slot.id", id),
+		attribute.Int("slot.max_concurrency", maxConcurrency),
+	)
+	waitStartedAt := time.Now()
+	release, err := h.acquireSlotWithPingTimeout(c, ctx, slotType, id, maxConcurrency, timeout, isStream, streamStarted)
+	tracing.End(span, err)
+	if slotType == "user" {
+		addOpsPhaseDuration(c, opsQueueWaitKey, time.Since(waitStartedAt))
+	} else {
+		addOpsPhaseDuration(c, opsSlotWaitKey, time.Since(waitStartedAt))
+	}
+	return release, err
+}
+
+func (h *ConcurrencyHelper) acquireSlotWithPingTimeout(c *gin.Context, parent context.Context, slotType string, id int64, maxConcurrency int, timeout time.Duration, isStream bool, streamStarted *bool) (func(), error) {
+	ctx, cancel := context.WithTimeout(parent, timeout)
 	defer cancel()
 
 	// Try immediate acquire first (avoid unnecessary wait)
@@ -216,7 +468,7 @@ func (h *ConcurrencyHelper) waitForSlotWithPingTimeout(c *gin.Context, slotType
 		return nil, err
 	}
 	if result.Acquired {
-		return result.ReleaseFunc, nil
+		return releaseIfAbandoned(ctx, c, slotType, result.ReleaseFunc)
 	}
 
 	// Determine if ping is needed (streaming + ping format defined)
@@ -234,7 +486,7 @@ func (h *ConcurrencyHelper) waitForSlotWithPingTimeout(c *gin.Context, slotType
 	// Only create ping ticker if ping is needed
 	var pingCh <-chan time.Time
 	if needPing {
-		pingTicker := time.NewTicker(h.pingInterval)
+		pingTicker := time.NewTicker(time.Duration(h.pingInterval.Load()))
 		defer pingTicker.Stop()
 		pingCh = pingTicker.C
 	}
@@ -247,6 +499,13 @@ func (h *ConcurrencyHelper) waitForSlotWithPingTimeout(c *gin.Context, slotType
 	for {
 		select {
 		case <-ctx.Done():
+			// 区分客户端断开与排队超时：客户端已断开时不再视为超时
+			if c.Request.Context().Err() != nil {
+				return nil, &ConcurrencyError{
+					SlotType:   slotType,
+					IsCanceled: true,
+				}
+			}
 			return nil, &ConcurrencyError{
 				SlotType:  slotType,
 				IsTimeout: true,
@@ -282,7 +541,7 @@ func (h *ConcurrencyHelper) waitForSlotWithPingTimeout(c *gin.Context, slotType
 			}
 
 			if result.Acquired {
-				return result.ReleaseFunc, nil
+				return releaseIfAbandoned(ctx, c, slotType, result.ReleaseFunc)
 			}
 			backoff = nextBackoff(backoff, rng)
 			timer.Reset(backoff)
@@ -290,6 +549,27 @@ func (h *ConcurrencyHelper) waitForSlotWithPingTimeout(c *gin.Context, slotType
 	}
 }
 
+// releaseIfAbandoned 在槽位获取成功但请求已被放弃（客户端断开/排队超时）时立即归还槽位，
+// 避免在无人等待响应的情况下继续向上游发起请求。
+func releaseIfAbandoned(waitCtx context.Context, c *gin.Context, slotType string, releaseFunc func()) (func(), error) {
+	if waitCtx.Err() == nil {
+		return releaseFunc, nil
+	}
+	if releaseFunc != nil {
+		releaseFunc()
+	}
+	if c.Request.Context().Err() != nil {
+		return nil, &ConcurrencyError{
+			SlotType:   slotType,
+			IsCanceled: true,
+		}
+	}
+	return nil, &ConcurrencyError{
+		SlotType:  slotType,
+		IsTimeout: true,
+	}
+}
+
 // AcquireAccountSlotWithWaitTimeout acquires an account slot with a custom timeout (keeps SSE ping).
 func (h *ConcurrencyHelper) AcquireAccountSlotWithWaitTimeout(c *gin.Context, accountID int64, maxConcurrency int, timeout time.Duration, isStream bool, streamStarted *bool) (func(), error) {
 	return h.waitForSlotWithPingTimeout(c, "account", accountID, maxConcurrency, timeout, isStream, streamStarted)
@@ -321,3 +601,19 @@ func nextBackoff(current time.Duration, rng *rand.Rand) time.Duration {
 	}
 	return jittered
 }
+
+// commitAccountReservation 在转发开始前确认两阶段选择的槽位预占。
+// 预占已过期（槽位已被回收）时返回 false，调用方应重新选择账号；其他错误仅记录日志并继续转发。
+func commitAccountReservation(ctx context.Context, selection *service.AccountSelectionResult) bool {
+	if selection == nil || selection.Reservation == nil {
+		return true
+	}
+	if err := selection.Reservation.Commit(ctx); err != nil {
+		if errors.Is(err, service.ErrAccountReservationExpired) {
+			slog.WarnContext(ctx, "account reservation expired before forwarding", "reserved_account_id", selection.Reservation.AccountID)
+			return false
+		}
+		slog.WarnContext(ctx, "commit account reservation failed", "reserved_account_id", selection.Reservation.AccountID, "error", err)
+	}
+	return true
+}
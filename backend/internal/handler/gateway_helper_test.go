@@ -2,10 +2,16 @@ package handler
 
 import (
 	"context"
+	"net/http"
+	"net/http/httptest"
 	"runtime"
 	"sync/atomic"
 	"testing"
 	"time"
+
+	"github.com/Wei-Shaw/sub2api/internal/service"
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/require"
 )
 
 // TestWrapReleaseOnDone_NoGoroutineLeak 验证 wrapReleaseOnDone 修复后不会泄露 goroutine
@@ -139,3 +145,225 @@ func BenchmarkWrapReleaseOnDone(b *testing.B) {
 		release()
 	}
 }
+
+// waitTestConcurrencyCache 用于并发等待测试的 ConcurrencyCache 桩实现
+type waitTestConcurrencyCache struct {
+	acquireCalls  int32
+	releaseCalls  int32
+	accountWaits  int32
+	onAcquire     func() bool
+	acquireResult bool
+}
+
+func (c *waitTestConcurrencyCache) acquire() (bool, error) {
+	atomic.AddInt32(&c.acquireCalls, 1)
+	if c.onAcquire != nil {
+		return c.onAcquire(), nil
+	}
+	return c.acquireResult, nil
+}
+
+func (c *waitTestConcurrencyCache) AcquireAccountSlot(ctx context.Context, accountID int64, maxConcurrency int, requestID string) (bool, error) {
+	return c.acquire()
+}
+
+func (c *waitTestConcurrencyCache) ReserveAccountSlot(ctx context.Context, accountID int64, maxConcurrency, expectedConcurrency int, requestID string, ttl time.Duration) (bool, int, error) {
+	ok, err := c.acquire()
+	return ok, 0, err
+}
+
+func (c *waitTestConcurrencyCache) CommitAccountSlot(ctx context.Context, accountID int64, requestID string) (bool, error) {
+	return true, nil
+}
+
+func (c *waitTestConcurrencyCache) ReleaseAccountSlot(ctx context.Context, accountID int64, requestID string) error {
+	atomic.AddInt32(&c.releaseCalls, 1)
+	return nil
+}
+
+func (c *waitTestConcurrencyCache) GetAccountConcurrency(ctx context.Context, accountID int64) (int, error) {
+	return 0, nil
+}
+
+func (c *waitTestConcurrencyCache) IncrementAccountWaitCount(ctx context.Context, accountID int64, maxWait int) (bool, error) {
+	atomic.AddInt32(&c.accountWaits, 1)
+	return true, nil
+}
+
+func (c *waitTestConcurrencyCache) DecrementAccountWaitCount(ctx context.Context, accountID int64) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	atomic.AddInt32(&c.accountWaits, -1)
+	return nil
+}
+
+func (c *waitTestConcurrencyCache) GetAccountWaitingCount(ctx context.Context, accountID int64) (int, error) {
+	return int(atomic.LoadInt32(&c.accountWaits)), nil
+}
+
+func (c *waitTestConcurrencyCache) AcquireUserSlot(ctx context.Context, userID int64, maxConcurrency int, requestID string) (bool, error) {
+	return c.acquire()
+}
+
+func (c *waitTestConcurrencyCache) ReleaseUserSlot(ctx context.Context, userID int64, requestID string) error {
+	atomic.AddInt32(&c.releaseCalls, 1)
+	return nil
+}
+
+func (c *waitTestConcurrencyCache) GetUserConcurrency(ctx context.Context, userID int64) (int, error) {
+	return 0, nil
+}
+
+func (c *waitTestConcurrencyCache) IncrementWaitCount(ctx context.Context, userID int64, maxWait int) (bool, error) {
+	return true, nil
+}
+
+func (c *waitTestConcurrencyCache) DecrementWaitCount(ctx context.Context, userID int64) error {
+	return nil
+}
+
+func (c *waitTestConcurrencyCache) GetAccountsLoadBatch(ctx context.Context, accounts []service.AccountWithConcurrency) (map[int64]*service.AccountLoadInfo, error) {
+	return map[int64]*service.AccountLoadInfo{}, nil
+}
+
+func (c *waitTestConcurrencyCache) GetUsersLoadBatch(ctx context.Context, users []service.UserWithConcurrency) (map[int64]*service.UserLoadInfo, error) {
+	return map[int64]*service.UserLoadInfo{}, nil
+}
+
+func (c *waitTestConcurrencyCache) CleanupExpiredAccountSlots(ctx context.Context, accountID int64) error {
+	return nil
+}
+
+func newWaitTestContext(t *testing.T) (*gin.Context, context.CancelFunc) {
+	t.Helper()
+	gin.SetMode(gin.TestMode)
+	c, _ := gin.CreateTestContext(httptest.NewRecorder())
+	ctx, cancel := context.WithCancel(context.Background())
+	c.Request = httptest.NewRequest(http.MethodPost, "/v1/messages", nil).WithContext(ctx)
+	return c, cancel
+}
+
+// TestWaitForSlot_ClientCancelIsNotTimeout 验证等待期间客户端断开时返回取消错误而非超时
+func TestWaitForSlot_ClientCancelIsNotTimeout(t *testing.T) {
+	cache := &waitTestConcurrencyCache{}
+	helper := NewConcurrencyHelper(service.NewConcurrencyService(cache), SSEPingFormatNone, time.Second)
+	c, cancel := newWaitTestContext(t)
+
+	time.AfterFunc(150*time.Millisecond, cancel)
+
+	streamStarted := false
+	release, err := helper.AcquireAccountSlotWithWaitTimeout(c, 1, 1, 5*time.Second, false, &streamStarted)
+	require.Nil(t, release)
+	require.Error(t, err)
+
+	var concurrencyErr *ConcurrencyError
+	require.ErrorAs(t, err, &concurrencyErr)
+	require.True(t, concurrencyErr.IsCanceled)
+	require.False(t, concurrencyErr.IsTimeout)
+	require.True(t, isConcurrencyWaitCanceled(err))
+	require.Equal(t, int32(0), atomic.LoadInt32(&cache.releaseCalls))
+}
+
+// TestWaitForSlot_QueueTimeout 验证排队超时（客户端仍在线）返回超时错误
+func TestWaitForSlot_QueueTimeout(t *testing.T) {
+	cache := &waitTestConcurrencyCache{}
+	helper := NewConcurrencyHelper(service.NewConcurrencyService(cache), SSEPingFormatNone, time.Second)
+	c, cancel := newWaitTestContext(t)
+	defer cancel()
+
+	streamStarted := false
+	release, err := helper.AcquireAccountSlotWithWaitTimeout(c, 1, 1, 150*time.Millisecond, false, &streamStarted)
+	require.Nil(t, release)
+
+	var concurrencyErr *ConcurrencyError
+	require.ErrorAs(t, err, &concurrencyErr)
+	require.True(t, concurrencyErr.IsTimeout)
+	require.False(t, isConcurrencyWaitCanceled(err))
+}
+
+// TestWaitForSlot_AcquiredAfterAbandonIsReleased 验证请求被放弃后才拿到的槽位会被立即归还，
+// 调用方拿不到 release 函数，因此不会再向上游发起请求。
+func TestWaitForSlot_AcquiredAfterAbandonIsReleased(t *testing.T) {
+	c, cancel := newWaitTestContext(t)
+	cache := &waitTestConcurrencyCache{}
+	first := true
+	cache.onAcquire = func() bool {
+		if first {
+			first = false
+			return false
+		}
+		// 模拟 Redis 往返过程中客户端断开，但槽位已写入
+		cancel()
+		return true
+	}
+	helper := NewConcurrencyHelper(service.NewConcurrencyService(cache), SSEPingFormatNone, time.Second)
+
+	streamStarted := false
+	release, err := helper.AcquireAccountSlotWithWaitTimeout(c, 1, 1, 5*time.Second, false, &streamStarted)
+	require.Nil(t, release)
+	require.True(t, isConcurrencyWaitCanceled(err))
+	require.Equal(t, int32(1), atomic.LoadInt32(&cache.releaseCalls))
+}
+
+// TestDecrementAccountWaitCount_AfterClientCancel 验证客户端断开后等待计数仍能正确回收
+func TestDecrementAccountWaitCount_AfterClientCancel(t *testing.T) {
+	cache := &waitTestConcurrencyCache{}
+	helper := NewConcurrencyHelper(service.NewConcurrencyService(cache), SSEPingFormatNone, time.Second)
+	c, cancel := newWaitTestContext(t)
+
+	canWait, err := helper.IncrementAccountWaitCount(c.Request.Context(), 1, 10)
+	require.NoError(t, err)
+	require.True(t, canWait)
+	require.Equal(t, int32(1), atomic.LoadInt32(&cache.accountWaits))
+
+	cancel()
+	helper.DecrementAccountWaitCount(c.Request.Context(), 1)
+	require.Equal(t, int32(0), atomic.LoadInt32(&cache.accountWaits))
+}
+
+// TestUpstreamRequestCanceledWithClient 验证基于请求 context 发起的上游请求在客户端断开时被取消，
+// 同时并发槽位被回收，不会留下无人接收的上游调用。
+func TestUpstreamRequestCanceledWithClient(t *testing.T) {
+	upstreamStarted := make(chan struct{})
+	upstreamCanceled := make(chan struct{})
+	upstream := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		close(upstreamStarted)
+		select {
+		case <-r.Context().Done():
+			close(upstreamCanceled)
+		case <-time.After(5 * time.Second):
+		}
+	}))
+	defer upstream.Close()
+
+	c, cancel := newWaitTestContext(t)
+	var releaseCount int32
+	release := wrapReleaseOnDone(c.Request.Context(), func() {
+		atomic.AddInt32(&releaseCount, 1)
+	})
+	defer release()
+
+	req, err := http.NewRequestWithContext(c.Request.Context(), http.MethodPost, upstream.URL, nil)
+	require.NoError(t, err)
+
+	done := make(chan error, 1)
+	go func() {
+		resp, err := http.DefaultClient.Do(req)
+		if resp != nil {
+			_ = resp.Body.Close()
+		}
+		done <- err
+	}()
+
+	<-upstreamStarted
+	cancel()
+
+	select {
+	case <-upstreamCanceled:
+	case <-time.After(2 * time.Second):
+		t.Fatal("upstream request was not canceled after client disconnect")
+	}
+	require.ErrorIs(t, <-done, context.Canceled)
+	require.Eventually(t, func() bool { return atomic.LoadInt32(&releaseCount) == 1 }, time.Second, 10*time.Millisecond)
+}
@@ -8,7 +8,7 @@ import (
 	"encoding/json"
 	"errors"
 	"io"
-	"log"
+	"log/slog"
 	"net/http"
 	"regexp"
 	"strings"
@@ -174,6 +174,67 @@ func (h *GatewayHandler) GeminiV1BetaModels(c *gin.Context) {
 		return
 	}
 
+	releaseMemory, ok := admitRequestMemory(c, h.gatewayService.MemoryGuard(), len(body), stream)
+	if !ok {
+		googleError(c, http.StatusServiceUnavailable, "Gateway is under memory pressure, please retry later")
+		return
+	}
+	defer releaseMemory()
+	setRequestFeatures(c, body, stream)
+
+	// 剔除客户端注入的随机字段，保证粘性会话 hash 稳定
+	body = stripRequestFields(c, h.requestStripService, domain.PlatformGemini, body)
+
+	// Gemini 原生 API 的模型在路径中，别名仅改写模型名（请求体不含 model 字段）
+	modelName, _ = applyModelAlias(c, h.modelAliasService, apiKey, modelName, nil)
+
+	// 校验分组模型访问策略（允许/禁止列表）与 API Key 的模型限制，在占用并发槽位前拒绝
+	if err := service.CheckAPIKeyModelAccess(apiKey, modelName); err != nil {
+		googleError(c, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	// 校验客户端自带的会话标识（X-Sub2API-Session），存在时优先用于粘性路由
+	clientSessionHash, err := service.ClientSessionHash(c.GetHeader(service.ClientSessionHeader), apiKey.ID)
+	if err != nil {
+		googleError(c, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	// 按分组模型参数策略收敛/校验 generationConfig.temperature/topP
+	if body, err = service.ApplyModelParamPolicy(apiKey.Group, modelName, body, domain.PlatformGemini); err != nil {
+		var policyErr *service.ModelParamPolicyError
+		if errors.As(err, &policyErr) {
+			googleError(c, http.StatusBadRequest, policyErr.Message)
+			return
+		}
+		googleError(c, http.StatusInternalServerError, "Failed to process request")
+		return
+	}
+
+	// 按分组/API Key 的系统提示词策略改写 systemInstruction
+	if body, err = service.ApplySystemPromptPolicy(apiKey, body, domain.PlatformGemini, c.GetHeader("User-Agent")); err != nil {
+		googleError(c, http.StatusInternalServerError, "Failed to process request")
+		return
+	}
+
+	// 按分组提示词模板包裹用户最新一条 content
+	if body, err = service.ApplyPromptTemplate(apiKey, body, domain.PlatformGemini, modelName); err != nil {
+		googleError(c, http.StatusInternalServerError, "Failed to process request")
+		return
+	}
+
+	// 按请求净化规则剔除或拒绝危险/不支持的字段与工具
+	body, rejectMsg := sanitizeRequest(c, h.requestSanitizeService, apiKey, domain.PlatformGemini, body)
+	if rejectMsg != "" {
+		googleError(c, http.StatusBadRequest, rejectMsg)
+		return
+	}
+	if rejectMsg := checkUnknownRequestFields(apiKey, service.RequestSchemaGeminiGenerate, body); rejectMsg != "" {
+		googleError(c, http.StatusBadRequest, rejectMsg)
+		return
+	}
+
 	setOpsRequestContext(c, modelName, stream, body)
 
 	// Get subscription (may be nil)
@@ -187,7 +248,7 @@ func (h *GatewayHandler) GeminiV1BetaModels(c *gin.Context) {
 	canWait, err := geminiConcurrency.IncrementWaitCount(c.Request.Context(), authSubject.UserID, maxWait)
 	waitCounted := false
 	if err != nil {
-		log.Printf("Increment wait count failed: %v", err)
+		slog.WarnContext(c.Request.Context(), "increment user wait count failed", "error", err)
 	} else if !canWait {
 		googleError(c, http.StatusTooManyRequests, "Too many pending requests, please retry later")
 		return
@@ -203,11 +264,16 @@ func (h *GatewayHandler) GeminiV1BetaModels(c *gin.Context) {
 
 	// 1) user concurrency slot
 	streamStarted := false
+	disconnectWatch := watchClientDisconnect(c.Request.Context(), h.streamAbuseService, stream)
+	defer disconnectWatch.stop()
 	if h.errorPassthroughService != nil {
 		service.BindErrorPassthroughService(c, h.errorPassthroughService)
 	}
 	userReleaseFunc, err := geminiConcurrency.AcquireUserSlotWithWait(c, authSubject.UserID, authSubject.Concurrency, stream, &streamStarted)
 	if err != nil {
+		if isConcurrencyWaitCanceled(err) {
+			return
+		}
 		googleError(c, http.StatusTooManyRequests, err.Error())
 		return
 	}
@@ -222,15 +288,30 @@ func (h *GatewayHandler) GeminiV1BetaModels(c *gin.Context) {
 	}
 
 	// 2) billing eligibility check (after wait)
-	if err := h.billingCacheService.CheckBillingEligibility(c.Request.Context(), apiKey.User, apiKey, apiKey.Group, subscription); err != nil {
+	if err := checkBillingEligibility(c, h.billingCacheService, apiKey.User, apiKey, apiKey.Group, subscription); err != nil {
+		status, _, message := billingErrorDetails(err)
+		googleError(c, status, message)
+		return
+	}
+
+	// 费用预检：预估最大费用超过剩余预算时在占用账号前拒绝
+	costPlatform := service.PlatformGemini
+	if apiKey.Group != nil && apiKey.Group.Platform != "" {
+		costPlatform = apiKey.Group.Platform
+	}
+	estimatedCost := h.gatewayService.EstimateRequestMaxCost(c.Request.Context(), apiKey, costPlatform, modelName, body)
+	if err := h.billingCacheService.CheckEstimatedCost(c.Request.Context(), apiKey.User, apiKey, apiKey.Group, subscription, estimatedCost); err != nil {
 		status, _, message := billingErrorDetails(err)
 		googleError(c, status, message)
 		return
 	}
 
 	// 3) select account (sticky session based on request body)
-	// 优先使用 Gemini CLI 的会话标识（privileged-user-id + tmp 目录哈希）
-	sessionHash := extractGeminiCLISessionHash(c, body)
+	// 优先使用客户端自带的会话标识，其次 Gemini CLI 的会话标识（privileged-user-id + tmp 目录哈希）
+	sessionHash := clientSessionHash
+	if sessionHash == "" {
+		sessionHash = extractGeminiCLISessionHash(c, body)
+	}
 	if sessionHash == "" {
 		// Fallback: 使用通用的会话哈希生成逻辑（适用于其他客户端）
 		parsedReq, _ := service.ParseGatewayRequest(body, domain.PlatformGemini)
@@ -296,8 +377,8 @@ func (h *GatewayHandler) GeminiV1BetaModels(c *gin.Context) {
 					matchedDigestChain = foundMatchedChain
 					sessionBoundAccountID = foundAccountID
 					geminiSessionUUID = foundUUID
-					log.Printf("[Gemini] Digest fallback matched: uuid=%s, accountID=%d, chain=%s",
-						safeShortPrefix(foundUUID, 8), foundAccountID, truncateDigestChain(geminiDigestChain))
+					slog.InfoContext(c.Request.Context(), "gemini digest fallback matched",
+						"session_uuid", safeShortPrefix(foundUUID, 8), "bound_account_id", foundAccountID, "chain", truncateDigestChain(geminiDigestChain))
 
 					// 关键：如果原 sessionKey 为空，使用 prefixHash + uuid 作为 sessionKey
 					// 这样 SelectAccountWithLoadAwareness 的粘性会话逻辑会优先使用匹配到的账号
@@ -321,7 +402,9 @@ func (h *GatewayHandler) GeminiV1BetaModels(c *gin.Context) {
 	hasBoundSession := sessionKey != "" && sessionBoundAccountID > 0
 	cleanedForUnknownBinding := false
 
-	maxAccountSwitches := h.maxAccountSwitchesGemini
+	limits := h.failoverLimits.Load()
+	retry, _ := service.ResolveRetryController(apiKey.Group, limits.failoverClasses, apiKey.PriorityClass, limits.maxAccountSwitchesGemini)
+	maxAccountSwitches := retry.MaxAccountSwitches()
 	switchCount := 0
 	failedAccountIDs := make(map[int64]struct{})
 	var lastFailoverErr *service.UpstreamFailoverError
@@ -335,7 +418,9 @@ func (h *GatewayHandler) GeminiV1BetaModels(c *gin.Context) {
 	}
 
 	for {
+		selectStartedAt := time.Now()
 		selection, err := h.gatewayService.SelectAccountWithLoadAwareness(c.Request.Context(), apiKey.GroupID, sessionKey, modelName, failedAccountIDs, "") // Gemini 不使用会话限制
+		addOpsPhaseDuration(c, opsSelectionKey, time.Since(selectStartedAt))
 		if err != nil {
 			if len(failedAccountIDs) == 0 {
 				googleError(c, http.StatusServiceUnavailable, "No available Gemini accounts: "+err.Error())
@@ -346,7 +431,7 @@ func (h *GatewayHandler) GeminiV1BetaModels(c *gin.Context) {
 			// 谷歌上游 503 (MODEL_CAPACITY_EXHAUSTED) 通常是暂时性的，等几秒就能恢复。
 			if lastFailoverErr != nil && lastFailoverErr.StatusCode == http.StatusServiceUnavailable && switchCount <= maxAccountSwitches {
 				if sleepAntigravitySingleAccountBackoff(c.Request.Context(), switchCount) {
-					log.Printf("Antigravity single-account 503 retry: clearing failed accounts, retry %d/%d", switchCount, maxAccountSwitches)
+					slog.InfoContext(c.Request.Context(), "antigravity single-account 503 retry, clearing failed accounts", "switch_count", switchCount, "max_switches", maxAccountSwitches)
 					failedAccountIDs = make(map[int64]struct{})
 					// 设置 context 标记，让 Service 层预检查等待限流过期而非直接切换
 					ctx := context.WithValue(c.Request.Context(), ctxkey.SingleAccountRetry, true)
@@ -354,7 +439,7 @@ func (h *GatewayHandler) GeminiV1BetaModels(c *gin.Context) {
 					continue
 				}
 			}
-			h.handleGeminiFailoverExhausted(c, lastFailoverErr)
+			h.handleGeminiFailoverExhausted(c, lastFailoverErr, retry)
 			return
 		}
 		account := selection.Account
@@ -363,13 +448,13 @@ func (h *GatewayHandler) GeminiV1BetaModels(c *gin.Context) {
 		// 检测账号切换：如果粘性会话绑定的账号与当前选择的账号不同，清除 thoughtSignature
 		// 注意：Gemini 原生 API 的 thoughtSignature 与具体上游账号强相关；跨账号透传会导致 400。
 		if sessionBoundAccountID > 0 && sessionBoundAccountID != account.ID {
-			log.Printf("[Gemini] Sticky session account switched: %d -> %d, cleaning thoughtSignature", sessionBoundAccountID, account.ID)
+			slog.InfoContext(c.Request.Context(), "gemini sticky session account switched, cleaning thoughtSignature", "bound_account_id", sessionBoundAccountID)
 			body = service.CleanGeminiNativeThoughtSignatures(body)
 			sessionBoundAccountID = account.ID
 		} else if sessionKey != "" && sessionBoundAccountID == 0 && !cleanedForUnknownBinding && bytes.Contains(body, []byte(`"thoughtSignature"`)) {
 			// 无缓存绑定但请求里已有 thoughtSignature：常见于缓存丢失/TTL 过期后，客户端继续携带旧签名。
 			// 为避免第一次转发就 400，这里做一次确定性清理，让新账号重新生成签名链路。
-			log.Printf("[Gemini] Sticky session binding missing, cleaning thoughtSignature proactively")
+			slog.InfoContext(c.Request.Context(), "gemini sticky session binding missing, cleaning thoughtSignature proactively")
 			body = service.CleanGeminiNativeThoughtSignatures(body)
 			cleanedForUnknownBinding = true
 			sessionBoundAccountID = account.ID
@@ -380,6 +465,9 @@ func (h *GatewayHandler) GeminiV1BetaModels(c *gin.Context) {
 
 		// 4) account concurrency slot
 		accountReleaseFunc := selection.ReleaseFunc
+		if !commitAccountReservation(c.Request.Context(), selection) {
+			continue
+		}
 		if !selection.Acquired {
 			if selection.WaitPlan == nil {
 				googleError(c, http.StatusServiceUnavailable, "No available Gemini accounts")
@@ -388,9 +476,9 @@ func (h *GatewayHandler) GeminiV1BetaModels(c *gin.Context) {
 			accountWaitCounted := false
 			canWait, err := geminiConcurrency.IncrementAccountWaitCount(c.Request.Context(), account.ID, selection.WaitPlan.MaxWaiting)
 			if err != nil {
-				log.Printf("Increment account wait count failed: %v", err)
+				slog.WarnContext(c.Request.Context(), "increment account wait count failed", "error", err)
 			} else if !canWait {
-				log.Printf("Account wait queue full: account=%d", account.ID)
+				slog.WarnContext(c.Request.Context(), "account wait queue full")
 				googleError(c, http.StatusTooManyRequests, "Too many pending requests, please retry later")
 				return
 			}
@@ -412,6 +500,9 @@ func (h *GatewayHandler) GeminiV1BetaModels(c *gin.Context) {
 				&streamStarted,
 			)
 			if err != nil {
+				if isConcurrencyWaitCanceled(err) {
+					return
+				}
 				googleError(c, http.StatusTooManyRequests, err.Error())
 				return
 			}
@@ -420,7 +511,7 @@ func (h *GatewayHandler) GeminiV1BetaModels(c *gin.Context) {
 				accountWaitCounted = false
 			}
 			if err := h.gatewayService.BindStickySession(c.Request.Context(), apiKey.GroupID, sessionKey, account.ID); err != nil {
-				log.Printf("Bind sticky session failed: %v", err)
+				slog.WarnContext(c.Request.Context(), "bind sticky session failed", "error", err)
 			}
 		}
 		// 账号槽位/等待计数需要在超时或断开时安全回收
@@ -447,29 +538,29 @@ func (h *GatewayHandler) GeminiV1BetaModels(c *gin.Context) {
 				if needForceCacheBilling(hasBoundSession, failoverErr) {
 					forceCacheBilling = true
 				}
-				if switchCount >= maxAccountSwitches {
+				if !retry.ShouldRetry(failoverErr.StatusCode, switchCount) {
 					lastFailoverErr = failoverErr
-					h.handleGeminiFailoverExhausted(c, lastFailoverErr)
+					h.handleGeminiFailoverExhausted(c, lastFailoverErr, retry)
 					return
 				}
 				lastFailoverErr = failoverErr
 				switchCount++
-				log.Printf("Gemini account %d: upstream error %d, switching account %d/%d", account.ID, failoverErr.StatusCode, switchCount, maxAccountSwitches)
-				if account.Platform == service.PlatformAntigravity {
-					if !sleepFailoverDelay(c.Request.Context(), switchCount) {
-						return
-					}
+				service.ObserveGatewayFailover(account, apiKey.GroupID)
+				slog.WarnContext(c.Request.Context(), "upstream error, switching account", "upstream_status", failoverErr.StatusCode, "switch_count", switchCount, "max_switches", maxAccountSwitches)
+				if !waitBeforeAccountSwitch(c.Request.Context(), retry, account.Platform, switchCount) {
+					return
 				}
 				continue
 			}
 			// ForwardNative already wrote the response
-			log.Printf("Gemini native forward failed: %v", err)
+			slog.ErrorContext(c.Request.Context(), "gemini native forward failed", "error", err)
 			return
 		}
 
 		// 捕获请求信息（用于异步记录，避免在 goroutine 中访问 gin.Context）
 		userAgent := c.GetHeader("User-Agent")
 		clientIP := ip.GetClientIP(c)
+		reqCtx := c.Request.Context()
 
 		// 保存 Gemini 内容摘要会话（用于 Fallback 匹配）
 		if useDigestFallback && geminiDigestChain != "" && geminiPrefixHash != "" {
@@ -482,12 +573,20 @@ func (h *GatewayHandler) GeminiV1BetaModels(c *gin.Context) {
 				account.ID,
 				matchedDigestChain,
 			); err != nil {
-				log.Printf("[Gemini] Failed to save digest session: %v", err)
+				slog.WarnContext(c.Request.Context(), "gemini save digest session failed", "error", err)
 			}
 		}
 
+		h.gatewayService.MarkPromptCacheWarm(c.Request.Context(), apiKey.GroupID, sessionKey, account, result.Usage.CacheReadInputTokens)
+		recordStreamObservation(h.streamAbuseService, disconnectWatch, apiKey, result.FirstTokenMs, result.Duration)
+		setLiveTrafficResult(c, account, result.Usage.InputTokens, result.Usage.OutputTokens, result.FirstTokenMs)
+		setOpsForwardTiming(c, result.Stream, result.Duration, result.FirstTokenMs)
+
 		// 6) record usage async (Gemini 使用长上下文双倍计费)
+		// 登记为关闭前需完成的任务，优雅关闭时等待用量记录落库
+		finishTask := h.shutdown.BeginTask()
 		go func(result *service.ForwardResult, usedAccount *service.Account, ua, ip string, fcb bool) {
+			defer finishTask()
 			ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
 			defer cancel()
 
@@ -504,7 +603,7 @@ func (h *GatewayHandler) GeminiV1BetaModels(c *gin.Context) {
 				ForceCacheBilling:     fcb,
 				APIKeyService:         h.apiKeyService,
 			}); err != nil {
-				log.Printf("Record usage failed: %v", err)
+				slog.ErrorContext(reqCtx, "record usage failed", "error", err)
 			}
 		}(result, account, userAgent, clientIP, forceCacheBilling)
 		return
@@ -530,18 +629,23 @@ func parseGeminiModelAction(rest string) (model string, action string, err error
 	return "", "", &pathParseError{"invalid model action path"}
 }
 
-func (h *GatewayHandler) handleGeminiFailoverExhausted(c *gin.Context, failoverErr *service.UpstreamFailoverError) {
+func (h *GatewayHandler) handleGeminiFailoverExhausted(c *gin.Context, failoverErr *service.UpstreamFailoverError, retry *service.RetryController) {
 	if failoverErr == nil {
 		googleError(c, http.StatusBadGateway, "Upstream request failed")
 		return
 	}
 
+	// 分组要求原样返回上游错误
+	if writeUpstreamErrorVerbatim(c, retry, failoverErr, false) {
+		return
+	}
+
 	statusCode := failoverErr.StatusCode
 	responseBody := failoverErr.ResponseBody
 
 	// 先检查透传规则
-	if h.errorPassthroughService != nil && len(responseBody) > 0 {
-		if rule := h.errorPassthroughService.MatchRule(service.PlatformGemini, statusCode, responseBody); rule != nil {
+	if h.errorPassthroughService != nil && (len(responseBody) > 0 || len(failoverErr.ResponseHeaders) > 0) {
+		if rule := h.errorPassthroughService.MatchRuleWithHeaders(service.PlatformGemini, statusCode, failoverErr.ResponseHeaders, responseBody); rule != nil {
 			// 确定响应状态码
 			respCode := statusCode
 			if !rule.PassthroughCode && rule.ResponseCode != nil {
@@ -563,26 +667,9 @@ func (h *GatewayHandler) handleGeminiFailoverExhausted(c *gin.Context, failoverE
 		}
 	}
 
-	// 使用默认的错误映射
-	status, message := mapGeminiUpstreamError(statusCode)
-	googleError(c, status, message)
-}
-
-func mapGeminiUpstreamError(statusCode int) (int, string) {
-	switch statusCode {
-	case 401:
-		return http.StatusBadGateway, "Upstream authentication failed, please contact administrator"
-	case 403:
-		return http.StatusBadGateway, "Upstream access forbidden, please contact administrator"
-	case 429:
-		return http.StatusTooManyRequests, "Upstream rate limit exceeded, please retry later"
-	case 529:
-		return http.StatusServiceUnavailable, "Upstream service overloaded, please retry later"
-	case 500, 502, 503, 504:
-		return http.StatusBadGateway, "Upstream service temporarily unavailable"
-	default:
-		return http.StatusBadGateway, "Upstream request failed"
-	}
+	// 使用错误映射（管理员配置优先，其次内置映射）
+	mapped := h.upstreamErrorMapping.Map(c.Request.Context(), service.PlatformGemini, statusCode, responseBody)
+	googleError(c, mapped.Status, mapped.Message)
 }
 
 type pathParseError struct{ msg string }
@@ -25,6 +25,12 @@ type AdminHandlers struct {
 	Usage            *admin.UsageHandler
 	UserAttribute    *admin.UserAttributeHandler
 	ErrorPassthrough *admin.ErrorPassthroughHandler
+	ModelPrice       *admin.ModelPriceHandler
+	SpendCap         *admin.SpendCapHandler
+	AuditLog         *admin.AuditLogHandler
+	Trash            *admin.TrashHandler
+	ActionLog        *admin.AdminActionLogHandler
+	IPBan            *admin.IPBanHandler
 }
 
 // Handlers contains all HTTP handlers
@@ -41,6 +47,11 @@ type Handlers struct {
 	OpenAIGateway *OpenAIGatewayHandler
 	Setting       *SettingHandler
 	Totp          *TotpHandler
+	Scaling       *ScalingHandler
+	Health        *HealthHandler
+	Stripe        *StripeHandler
+	OpenAPI       *OpenAPIHandler
+	Metrics       *MetricsHandler
 }
 
 // BuildInfo contains build-time information
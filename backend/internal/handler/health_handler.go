@@ -0,0 +1,28 @@
+package handler
+
+import (
+	"net/http"
+
+	"github.com/Wei-Shaw/sub2api/internal/service"
+	"github.com/gin-gonic/gin"
+)
+
+// HealthHandler serves the liveness/readiness probe.
+type HealthHandler struct {
+	shutdown *service.ShutdownCoordinator
+}
+
+// NewHealthHandler creates a new HealthHandler
+func NewHealthHandler(shutdown *service.ShutdownCoordinator) *HealthHandler {
+	return &HealthHandler{shutdown: shutdown}
+}
+
+// Check reports ok, or 503 while the instance is draining for shutdown so load balancers stop routing to it
+// GET /health
+func (h *HealthHandler) Check(c *gin.Context) {
+	if h.shutdown.Draining() {
+		c.JSON(http.StatusServiceUnavailable, gin.H{"status": "draining"})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"status": "ok"})
+}
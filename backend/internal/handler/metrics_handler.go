@@ -0,0 +1,133 @@
+package handler
+
+import (
+	"crypto/subtle"
+	"log"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/Wei-Shaw/sub2api/internal/pkg/ctxkey"
+	"github.com/Wei-Shaw/sub2api/internal/pkg/metrics"
+	middleware2 "github.com/Wei-Shaw/sub2api/internal/server/middleware"
+	"github.com/Wei-Shaw/sub2api/internal/service"
+	"github.com/gin-gonic/gin"
+)
+
+// MetricsHandler exposes gateway metrics in Prometheus text format.
+type MetricsHandler struct {
+	metricsService *service.GatewayMetricsService
+}
+
+// NewMetricsHandler creates a new MetricsHandler
+func NewMetricsHandler(metricsService *service.GatewayMetricsService) *MetricsHandler {
+	return &MetricsHandler{metricsService: metricsService}
+}
+
+// Scrape writes all metrics; returns 404 when disabled and 401 on a bad bearer token
+// GET /metrics
+func (h *MetricsHandler) Scrape(c *gin.Context) {
+	if !h.metricsService.Enabled() {
+		c.Status(http.StatusNotFound)
+		return
+	}
+	if token := h.metricsService.AuthToken(); token != "" {
+		provided := strings.TrimPrefix(c.GetHeader("Authorization"), "Bearer ")
+		if subtle.ConstantTimeCompare([]byte(provided), []byte(token)) != 1 {
+			c.Status(http.StatusUnauthorized)
+			return
+		}
+	}
+	c.Header("Content-Type", metrics.ContentType)
+	c.Status(http.StatusOK)
+	if err := h.metricsService.WriteMetrics(c.Request.Context(), c.Writer); err != nil {
+		log.Printf("[Metrics] write metrics failed: %v", err)
+	}
+}
+
+// GatewayMetricsMiddleware records request count and latency per platform/model/account/group.
+// The model and final account come from the same context keys the ops error logger uses.
+func GatewayMetricsMiddleware() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		start := time.Now()
+		c.Next()
+
+		apiKey, _ := middleware2.GetAPIKeyFromContext(c)
+		if apiKey == nil {
+			// 认证失败的请求不计入（避免无效标签组合膨胀）
+			return
+		}
+		var modelName string
+		if v, ok := c.Get(opsModelKey); ok {
+			modelName, _ = v.(string)
+		}
+		var accountID int64
+		if v, ok := c.Get(opsAccountIDKey); ok {
+			accountID, _ = v.(int64)
+		}
+		platform := resolveOpsPlatform(apiKey, guessPlatformFromPath(c.Request.URL.Path))
+		elapsed := time.Since(start)
+		service.ObserveGatewayRequest(platform, modelName, accountID, apiKey.GroupID, c.Writer.Status(), elapsed)
+		publishLiveTraffic(c, apiKey, platform, modelName, accountID, elapsed)
+	}
+}
+
+// liveTrafficResult carries token usage from a successful forward to the live traffic publisher.
+type liveTrafficResult struct {
+	accountName  string
+	inputTokens  int
+	outputTokens int
+	firstTokenMs *int
+}
+
+// setLiveTrafficResult stores the forward result so GatewayMetricsMiddleware can include
+// tokens in the live traffic event. TTFT and the token total are always kept for slow-request
+// detection and per-account stats; the rest is skipped when no admin is watching the feed.
+func setLiveTrafficResult(c *gin.Context, account *service.Account, inputTokens, outputTokens int, firstTokenMs *int) {
+	if c == nil {
+		return
+	}
+	if firstTokenMs != nil {
+		c.Set(opsFirstTokenMsKey, *firstTokenMs)
+	}
+	c.Set(opsUsageTokensKey, int64(inputTokens+outputTokens))
+	if !service.OpsLiveTraffic().HasSubscribers() {
+		return
+	}
+	res := liveTrafficResult{inputTokens: inputTokens, outputTokens: outputTokens, firstTokenMs: firstTokenMs}
+	if account != nil {
+		res.accountName = account.Name
+	}
+	c.Set(opsLiveTrafficKey, res)
+}
+
+func publishLiveTraffic(c *gin.Context, apiKey *service.APIKey, platform, model string, accountID int64, elapsed time.Duration) {
+	hub := service.OpsLiveTraffic()
+	if !hub.HasSubscribers() {
+		return
+	}
+	ev := service.OpsLiveTrafficEvent{
+		Platform:   platform,
+		Model:      model,
+		AccountID:  accountID,
+		GroupID:    apiKey.GroupID,
+		UserID:     apiKey.UserID,
+		APIKeyID:   apiKey.ID,
+		Path:       c.Request.URL.Path,
+		StatusCode: c.Writer.Status(),
+		DurationMs: elapsed.Milliseconds(),
+	}
+	ev.RequestID, _ = c.Request.Context().Value(ctxkey.ClientRequestID).(string)
+	if v, ok := c.Get(opsStreamKey); ok {
+		ev.Stream, _ = v.(bool)
+	}
+	if v, ok := c.Get(opsLiveTrafficKey); ok {
+		if res, ok := v.(liveTrafficResult); ok {
+			ev.AccountName = res.accountName
+			ev.InputTokens = res.inputTokens
+			ev.OutputTokens = res.outputTokens
+			ev.FirstTokenMs = res.firstTokenMs
+		}
+	}
+	hub.Publish(ev)
+}
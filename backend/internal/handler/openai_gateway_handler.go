@@ -7,19 +7,20 @@ import (
 	"errors"
 	"fmt"
 	"io"
-	"log"
+	"log/slog"
 	"net/http"
 	"sort"
 	"strings"
+	"sync/atomic"
 	"time"
 
 	"github.com/Wei-Shaw/sub2api/internal/config"
 	"github.com/Wei-Shaw/sub2api/internal/pkg/ip"
-	"github.com/Wei-Shaw/sub2api/internal/pkg/openai"
 	middleware2 "github.com/Wei-Shaw/sub2api/internal/server/middleware"
 	"github.com/Wei-Shaw/sub2api/internal/service"
 
 	"github.com/gin-gonic/gin"
+	"github.com/tidwall/gjson"
 )
 
 // OpenAIGatewayHandler handles OpenAI API gateway requests
@@ -28,8 +29,17 @@ type OpenAIGatewayHandler struct {
 	billingCacheService     *service.BillingCacheService
 	apiKeyService           *service.APIKeyService
 	errorPassthroughService *service.ErrorPassthroughService
+	modelAliasService       *service.ModelAliasService
+	virtualModelService     *service.VirtualModelService
+	requestStripService     *service.RequestStripService
+	requestSanitizeService  *service.RequestSanitizeService
+	streamAbuseService      *service.StreamAbuseService
+	responseCacheService    *service.ResponseCacheService
+	tokenCounter            *service.TokenCounterService
+	upstreamErrorMapping    *service.UpstreamErrorMappingService
+	shutdown                *service.ShutdownCoordinator
 	concurrencyHelper       *ConcurrencyHelper
-	maxAccountSwitches      int
+	failoverLimits          atomic.Pointer[gatewayFailoverLimits]
 }
 
 // NewOpenAIGatewayHandler creates a new OpenAIGatewayHandler
@@ -39,24 +49,46 @@ func NewOpenAIGatewayHandler(
 	billingCacheService *service.BillingCacheService,
 	apiKeyService *service.APIKeyService,
 	errorPassthroughService *service.ErrorPassthroughService,
+	modelAliasService *service.ModelAliasService,
+	virtualModelService *service.VirtualModelService,
+	requestStripService *service.RequestStripService,
+	requestSanitizeService *service.RequestSanitizeService,
+	streamAbuseService *service.StreamAbuseService,
+	responseCacheService *service.ResponseCacheService,
+	tokenCounter *service.TokenCounterService,
+	upstreamErrorMapping *service.UpstreamErrorMappingService,
+	shutdown *service.ShutdownCoordinator,
 	cfg *config.Config,
 ) *OpenAIGatewayHandler {
 	pingInterval := time.Duration(0)
-	maxAccountSwitches := 3
 	if cfg != nil {
 		pingInterval = time.Duration(cfg.Concurrency.PingInterval) * time.Second
-		if cfg.Gateway.MaxAccountSwitches > 0 {
-			maxAccountSwitches = cfg.Gateway.MaxAccountSwitches
-		}
 	}
-	return &OpenAIGatewayHandler{
+	h := &OpenAIGatewayHandler{
 		gatewayService:          gatewayService,
 		billingCacheService:     billingCacheService,
 		apiKeyService:           apiKeyService,
 		errorPassthroughService: errorPassthroughService,
+		modelAliasService:       modelAliasService,
+		virtualModelService:     virtualModelService,
+		requestStripService:     requestStripService,
+		requestSanitizeService:  requestSanitizeService,
+		streamAbuseService:      streamAbuseService,
+		responseCacheService:    responseCacheService,
+		tokenCounter:            tokenCounter,
+		upstreamErrorMapping:    upstreamErrorMapping,
+		shutdown:                shutdown,
 		concurrencyHelper:       NewConcurrencyHelper(concurrencyService, SSEPingFormatComment, pingInterval),
-		maxAccountSwitches:      maxAccountSwitches,
 	}
+	h.failoverLimits.Store(newGatewayFailoverLimits(cfg, 3, 3))
+	return h
+}
+
+// ApplyConfig refreshes the cached ping interval and failover limits after a config reload.
+// In-flight requests keep the values they started with.
+func (h *OpenAIGatewayHandler) ApplyConfig(cfg *config.Config) {
+	h.concurrencyHelper.SetPingInterval(time.Duration(cfg.Concurrency.PingInterval) * time.Second)
+	h.failoverLimits.Store(newGatewayFailoverLimits(cfg, 3, 3))
 }
 
 // Responses handles OpenAI Responses API endpoint
@@ -91,8 +123,14 @@ func (h *OpenAIGatewayHandler) Responses(c *gin.Context) {
 		return
 	}
 
+	// 剔除客户端注入的随机字段，保证粘性会话 hash 稳定
+	body = stripRequestFields(c, h.requestStripService, service.PlatformOpenAI, body)
+
 	setOpsRequestContext(c, "", false, body)
 
+	// 解析模型名推理强度后缀（如 gpt-5.2:high），写入 reasoning.effort（Chat Completions 兼容请求同样经过此处）
+	body = applyModelSuffix(c, body, service.PlatformOpenAI)
+
 	// Parse request body to map for potential modification
 	var reqBody map[string]any
 	if err := json.Unmarshal(body, &reqBody); err != nil {
@@ -110,19 +148,96 @@ func (h *OpenAIGatewayHandler) Responses(c *gin.Context) {
 		return
 	}
 
-	userAgent := c.GetHeader("User-Agent")
-	if !openai.IsCodexCLIRequest(userAgent) {
-		existingInstructions, _ := reqBody["instructions"].(string)
-		if strings.TrimSpace(existingInstructions) == "" {
-			if instructions := strings.TrimSpace(service.GetOpenCodeInstructions()); instructions != "" {
-				reqBody["instructions"] = instructions
-				// Re-serialize body
-				body, err = json.Marshal(reqBody)
-				if err != nil {
-					h.errorResponse(c, http.StatusInternalServerError, "api_error", "Failed to process request")
-					return
-				}
-			}
+	releaseMemory, ok := admitRequestMemory(c, h.gatewayService.MemoryGuard(), len(body), reqStream)
+	if !ok {
+		h.errorResponse(c, http.StatusServiceUnavailable, "api_error", "Gateway is under memory pressure, please retry later")
+		return
+	}
+	defer releaseMemory()
+	setRequestFeatures(c, body, reqStream)
+
+	// 按分组/API Key 的系统提示词策略改写 instructions（未配置时非 Codex CLI 客户端缺省注入内置指令）
+	body, err = service.ApplySystemPromptPolicy(apiKey, body, service.PlatformOpenAI, c.GetHeader("User-Agent"))
+	if err != nil {
+		h.errorResponse(c, http.StatusInternalServerError, "api_error", "Failed to process request")
+		return
+	}
+	if instructions := gjson.GetBytes(body, "instructions"); instructions.Exists() {
+		reqBody["instructions"] = instructions.String()
+	}
+
+	// 按管理员配置的模型别名改写请求模型（账号选择与计费均使用改写后的模型，响应中回显原始模型）
+	if aliased, aliasedBody := applyModelAlias(c, h.modelAliasService, apiKey, reqModel, body); aliased != reqModel {
+		reqModel, body = aliased, aliasedBody
+		reqBody["model"] = aliased
+	}
+
+	// 展开虚拟模型：当前目标无可用账号或故障转移耗尽时，在故障转移循环内依次尝试回退链的后续目标
+	virtualChain, virtualModel, virtualBody, err := applyVirtualModel(c, h.virtualModelService, apiKey, reqModel, body)
+	if err != nil {
+		h.errorResponse(c, http.StatusBadRequest, "invalid_request_error", err.Error())
+		return
+	}
+	if virtualChain != nil {
+		reqModel, body = virtualModel, virtualBody
+		reqBody["model"] = virtualModel
+	}
+
+	// 校验分组模型访问策略（允许/禁止列表）与 API Key 的模型限制，在占用并发槽位前拒绝
+	if err := service.CheckAPIKeyModelAccess(apiKey, reqModel); err != nil {
+		h.errorResponse(c, http.StatusBadRequest, "invalid_request_error", err.Error())
+		return
+	}
+
+	// 校验客户端自带的会话标识（X-Sub2API-Session），存在时优先用于粘性路由
+	clientSessionHash, err := service.ClientSessionHash(c.GetHeader(service.ClientSessionHeader), apiKey.ID)
+	if err != nil {
+		h.errorResponse(c, http.StatusBadRequest, "invalid_request_error", err.Error())
+		return
+	}
+
+	// 按分组模型参数策略收敛/校验 temperature、top_p，避免上游拒绝后在故障转移耗尽时以 502 返回
+	if body, err = service.ApplyModelParamPolicy(apiKey.Group, reqModel, body, service.PlatformOpenAI); err != nil {
+		var policyErr *service.ModelParamPolicyError
+		if errors.As(err, &policyErr) {
+			h.errorResponse(c, http.StatusBadRequest, "invalid_request_error", policyErr.Message)
+			return
+		}
+		h.errorResponse(c, http.StatusInternalServerError, "api_error", "Failed to process request")
+		return
+	}
+
+	// 按分组提示词模板包裹用户最新一条 input 消息
+	if body, err = service.ApplyPromptTemplate(apiKey, body, service.PlatformOpenAI, reqModel); err != nil {
+		h.errorResponse(c, http.StatusInternalServerError, "api_error", "Failed to process request")
+		return
+	}
+
+	// 按请求净化规则剔除或拒绝危险/不支持的字段与工具（如禁止上游留存分组的 store=true）
+	body, rejectMsg := sanitizeRequest(c, h.requestSanitizeService, apiKey, service.PlatformOpenAI, body)
+	if rejectMsg != "" {
+		h.errorResponse(c, http.StatusBadRequest, "invalid_request_error", rejectMsg)
+		return
+	}
+	// 严格字段模式下拒绝未知顶层字段；Chat Completions 兼容请求已在转换前按 Chat Completions 字段校验
+	if !c.GetBool(service.CtxKeyOpenAIChatCompletionsCompat) {
+		if rejectMsg := checkUnknownRequestFields(apiKey, service.RequestSchemaOpenAIResponses, body); rejectMsg != "" {
+			h.errorResponse(c, http.StatusBadRequest, "invalid_request_error", rejectMsg)
+			return
+		}
+	}
+	// 估算提示 token 超过上下文裁剪上限时删除最早的对话轮次（会话 hash 仍基于客户端原始 input）
+	if trimmed, removed := h.tokenCounter.TrimContext(service.PlatformOpenAI, reqModel, body); removed > 0 {
+		body = trimmed
+		slog.InfoContext(c.Request.Context(), "context trimmed by local token estimate", "model", reqModel, "removed_items", removed)
+	}
+
+	// 检查 API Key 的内置工具（web_search/code_interpreter/image_generation）每日调用上限
+	if err := h.gatewayService.CheckToolLimits(c.Request.Context(), apiKey, body); err != nil {
+		var limitErr *service.ToolLimitExceededError
+		if errors.As(err, &limitErr) {
+			h.errorResponse(c, http.StatusTooManyRequests, "rate_limit_error", limitErr.Error())
+			return
 		}
 	}
 
@@ -135,13 +250,13 @@ func (h *OpenAIGatewayHandler) Responses(c *gin.Context) {
 		previousResponseID, _ := reqBody["previous_response_id"].(string)
 		if strings.TrimSpace(previousResponseID) == "" && !service.HasToolCallContext(reqBody) {
 			if service.HasFunctionCallOutputMissingCallID(reqBody) {
-				log.Printf("[OpenAI Handler] function_call_output 缺少 call_id: model=%s", reqModel)
+				slog.WarnContext(c.Request.Context(), "function_call_output missing call_id")
 				h.errorResponse(c, http.StatusBadRequest, "invalid_request_error", "function_call_output requires call_id or previous_response_id; if relying on history, ensure store=true and reuse previous_response_id")
 				return
 			}
 			callIDs := service.FunctionCallOutputCallIDs(reqBody)
 			if !service.HasItemReferenceForCallIDs(reqBody, callIDs) {
-				log.Printf("[OpenAI Handler] function_call_output 缺少匹配的 item_reference: model=%s", reqModel)
+				slog.WarnContext(c.Request.Context(), "function_call_output missing matching item_reference")
 				h.errorResponse(c, http.StatusBadRequest, "invalid_request_error", "function_call_output requires item_reference ids matching each call_id, or previous_response_id/tool_call context; if relying on history, ensure store=true and reuse previous_response_id")
 				return
 			}
@@ -151,6 +266,10 @@ func (h *OpenAIGatewayHandler) Responses(c *gin.Context) {
 	// Track if we've started streaming (for error handling)
 	streamStarted := false
 
+	// 流式滥用检测：记录客户端断开时间
+	disconnectWatch := watchClientDisconnect(c.Request.Context(), h.streamAbuseService, reqStream)
+	defer disconnectWatch.stop()
+
 	// 绑定错误透传服务，允许 service 层在非 failover 错误场景复用规则。
 	if h.errorPassthroughService != nil {
 		service.BindErrorPassthroughService(c, h.errorPassthroughService)
@@ -164,7 +283,7 @@ func (h *OpenAIGatewayHandler) Responses(c *gin.Context) {
 	canWait, err := h.concurrencyHelper.IncrementWaitCount(c.Request.Context(), subject.UserID, maxWait)
 	waitCounted := false
 	if err != nil {
-		log.Printf("Increment wait count failed: %v", err)
+		slog.WarnContext(c.Request.Context(), "increment user wait count failed", "error", err)
 		// On error, allow request to proceed
 	} else if !canWait {
 		h.errorResponse(c, http.StatusTooManyRequests, "rate_limit_error", "Too many pending requests, please retry later")
@@ -182,7 +301,7 @@ func (h *OpenAIGatewayHandler) Responses(c *gin.Context) {
 	// 1. First acquire user concurrency slot
 	userReleaseFunc, err := h.concurrencyHelper.AcquireUserSlotWithWait(c, subject.UserID, subject.Concurrency, reqStream, &streamStarted)
 	if err != nil {
-		log.Printf("User concurrency acquire failed: %v", err)
+		slog.WarnContext(c.Request.Context(), "user concurrency acquire failed", "error", err)
 		h.handleConcurrencyError(c, err, "user", streamStarted)
 		return
 	}
@@ -198,44 +317,76 @@ func (h *OpenAIGatewayHandler) Responses(c *gin.Context) {
 	}
 
 	// 2. Re-check billing eligibility after wait
-	if err := h.billingCacheService.CheckBillingEligibility(c.Request.Context(), apiKey.User, apiKey, apiKey.Group, subscription); err != nil {
-		log.Printf("Billing eligibility check failed after wait: %v", err)
+	if err := checkBillingEligibility(c, h.billingCacheService, apiKey.User, apiKey, apiKey.Group, subscription); err != nil {
+		slog.WarnContext(c.Request.Context(), "billing eligibility check failed after wait", "error", err)
 		status, code, message := billingErrorDetails(err)
 		h.handleStreamingAwareError(c, status, code, message, streamStarted)
 		return
 	}
 
-	// Generate session hash (header first; fallback to prompt_cache_key)
-	sessionHash := h.gatewayService.GenerateSessionHash(c, reqBody)
+	// Pre-flight cost check: reject before acquiring an account if the estimated max cost exceeds the remaining budget
+	estimatedCost := h.gatewayService.EstimateRequestMaxCost(c.Request.Context(), apiKey, reqModel, body)
+	if err := h.billingCacheService.CheckEstimatedCost(c.Request.Context(), apiKey.User, apiKey, apiKey.Group, subscription, estimatedCost); err != nil {
+		status, code, message := billingErrorDetails(err)
+		h.handleStreamingAwareError(c, status, code, message, streamStarted)
+		return
+	}
 
-	maxAccountSwitches := h.maxAccountSwitches
+	// 相同非流式请求命中响应缓存时直接返回，不请求上游；放在计费资格与费用预检之后，命中同样受余额/额度约束
+	cacheHit, storeResponseCache := serveResponseCache(c, h.responseCacheService, apiKey, subscription, body, reqStream)
+	if cacheHit {
+		return
+	}
+
+	// Generate session hash (X-Sub2API-Session first, then session headers; fallback to prompt_cache_key)
+	sessionHash := clientSessionHash
+	if sessionHash == "" {
+		sessionHash = h.gatewayService.GenerateSessionHash(c, reqBody)
+	}
+
+	// 故障转移预算按 API Key 优先级类别决定：交互式请求切换次数更少、单次尝试超时更短
+	limits := h.failoverLimits.Load()
+	retry, failoverBudget := service.ResolveRetryController(apiKey.Group, limits.failoverClasses, apiKey.PriorityClass, limits.maxAccountSwitches)
+	maxAccountSwitches := retry.MaxAccountSwitches()
 	switchCount := 0
 	failedAccountIDs := make(map[int64]struct{})
 	var lastFailoverErr *service.UpstreamFailoverError
 
 	for {
 		// Select account supporting the requested model
-		log.Printf("[OpenAI Handler] Selecting account: groupID=%v model=%s", apiKey.GroupID, reqModel)
+		slog.DebugContext(c.Request.Context(), "selecting account", "group_id", apiKey.GroupID)
+		selectStartedAt := time.Now()
 		selection, err := h.gatewayService.SelectAccountWithLoadAwareness(c.Request.Context(), apiKey.GroupID, sessionHash, reqModel, failedAccountIDs)
+		addOpsPhaseDuration(c, opsSelectionKey, time.Since(selectStartedAt))
 		if err != nil {
-			log.Printf("[OpenAI Handler] SelectAccount failed: %v", err)
+			slog.WarnContext(c.Request.Context(), "select account failed", "error", err)
+			if nextModel, nextBody, ok := h.nextVirtualTarget(c, virtualChain, body, reqStream); ok {
+				reqModel, body = nextModel, nextBody
+				switchCount = 0
+				failedAccountIDs = make(map[int64]struct{})
+				lastFailoverErr = nil
+				continue
+			}
 			if len(failedAccountIDs) == 0 {
 				h.handleStreamingAwareError(c, http.StatusServiceUnavailable, "api_error", "No available accounts: "+err.Error(), streamStarted)
 				return
 			}
 			if lastFailoverErr != nil {
-				h.handleFailoverExhausted(c, lastFailoverErr, streamStarted)
+				h.handleFailoverExhausted(c, lastFailoverErr, retry, streamStarted)
 			} else {
 				h.handleFailoverExhaustedSimple(c, 502, streamStarted)
 			}
 			return
 		}
 		account := selection.Account
-		log.Printf("[OpenAI Handler] Selected account: id=%d name=%s", account.ID, account.Name)
+		slog.DebugContext(c.Request.Context(), "selected account", "selected_account_id", account.ID, "account_name", account.Name)
 		setOpsSelectedAccount(c, account.ID)
 
 		// 3. Acquire account concurrency slot
 		accountReleaseFunc := selection.ReleaseFunc
+		if !commitAccountReservation(c.Request.Context(), selection) {
+			continue
+		}
 		if !selection.Acquired {
 			if selection.WaitPlan == nil {
 				h.handleStreamingAwareError(c, http.StatusServiceUnavailable, "api_error", "No available accounts", streamStarted)
@@ -244,9 +395,9 @@ func (h *OpenAIGatewayHandler) Responses(c *gin.Context) {
 			accountWaitCounted := false
 			canWait, err := h.concurrencyHelper.IncrementAccountWaitCount(c.Request.Context(), account.ID, selection.WaitPlan.MaxWaiting)
 			if err != nil {
-				log.Printf("Increment account wait count failed: %v", err)
+				slog.WarnContext(c.Request.Context(), "increment account wait count failed", "error", err)
 			} else if !canWait {
-				log.Printf("Account wait queue full: account=%d", account.ID)
+				slog.WarnContext(c.Request.Context(), "account wait queue full")
 				h.handleStreamingAwareError(c, http.StatusTooManyRequests, "rate_limit_error", "Too many pending requests, please retry later", streamStarted)
 				return
 			}
@@ -268,7 +419,7 @@ func (h *OpenAIGatewayHandler) Responses(c *gin.Context) {
 				&streamStarted,
 			)
 			if err != nil {
-				log.Printf("Account concurrency acquire failed: %v", err)
+				slog.WarnContext(c.Request.Context(), "account concurrency acquire failed", "error", err)
 				h.handleConcurrencyError(c, err, "account", streamStarted)
 				return
 			}
@@ -277,14 +428,63 @@ func (h *OpenAIGatewayHandler) Responses(c *gin.Context) {
 				accountWaitCounted = false
 			}
 			if err := h.gatewayService.BindStickySession(c.Request.Context(), apiKey.GroupID, sessionHash, account.ID); err != nil {
-				log.Printf("Bind sticky session failed: %v", err)
+				slog.WarnContext(c.Request.Context(), "bind sticky session failed", "error", err)
 			}
 		}
 		// 账号槽位/等待计数需要在超时或断开时安全回收
 		accountReleaseFunc = wrapReleaseOnDone(c.Request.Context(), accountReleaseFunc)
 
 		// Forward request
-		result, err := h.gatewayService.Forward(c.Request.Context(), c, account, body)
+		forward := func(fc *gin.Context, acc *service.Account) (*service.OpenAIForwardResult, error) {
+			// previous_response_id 由其他账号创建时，用网关保存的对话历史重建上下文
+			attemptBody, err := h.gatewayService.PrepareResponseContinuity(fc.Request.Context(), acc, body)
+			if err != nil {
+				var unavailableErr *service.PreviousResponseUnavailableError
+				if errors.As(err, &unavailableErr) {
+					h.errorResponse(fc, http.StatusBadRequest, "invalid_request_error", unavailableErr.Error())
+				}
+				return nil, err
+			}
+			res, err := h.gatewayService.Forward(service.WithUpstreamAttemptTimeout(fc.Request.Context(), failoverBudget.AttemptTimeout), fc, acc, attemptBody)
+			if err == nil {
+				h.gatewayService.RecordResponseContext(fc.Request.Context(), acc, attemptBody, res)
+			}
+			return res, err
+		}
+		var result *service.OpenAIForwardResult
+		if failoverBudget.HedgeAfter > 0 && switchCount == 0 {
+			// 请求对冲：首个账号迟迟未开始输出时并行尝试另一账号，只有获胜方的结果会被计费
+			primaryAccount := account
+			var hedgeAccount *service.Account
+			outcome := runHedgedForward(c, failoverBudget.HedgeAfter,
+				func(fc *gin.Context) (*service.OpenAIForwardResult, error) {
+					return forward(fc, primaryAccount)
+				},
+				func() (hedgeForwardFunc[*service.OpenAIForwardResult], bool) {
+					excluded := hedgeExcludedAccounts(failedAccountIDs, primaryAccount.ID)
+					hedgeSelection, err := h.gatewayService.SelectAccountWithLoadAwareness(c.Request.Context(), apiKey.GroupID, "", reqModel, excluded)
+					release, ok := acquireHedgeSelection(c.Request.Context(), hedgeSelection, err)
+					if !ok {
+						return nil, false
+					}
+					hedgeAccount = hedgeSelection.Account
+					slog.InfoContext(c.Request.Context(), "first account slow to respond, hedging request", "account_id", primaryAccount.ID, "hedge_account_id", hedgeAccount.ID, "hedge_after_ms", failoverBudget.HedgeAfter.Milliseconds())
+					return func(fc *gin.Context) (*service.OpenAIForwardResult, error) {
+						defer release()
+						return forward(fc, hedgeAccount)
+					}, true
+				})
+			result, err = outcome.result, outcome.err
+			if outcome.hedged {
+				service.ObserveGatewayHedge(primaryAccount.Platform, apiKey.GroupID, hedgeWinnerLabel(outcome))
+			}
+			if outcome.winner == hedgeSecondary {
+				account = hedgeAccount
+				setOpsSelectedAccount(c, account.ID)
+			}
+		} else {
+			result, err = forward(c, account)
+		}
 		if accountReleaseFunc != nil {
 			accountReleaseFunc()
 		}
@@ -293,25 +493,46 @@ func (h *OpenAIGatewayHandler) Responses(c *gin.Context) {
 			if errors.As(err, &failoverErr) {
 				failedAccountIDs[account.ID] = struct{}{}
 				lastFailoverErr = failoverErr
-				if switchCount >= maxAccountSwitches {
-					h.handleFailoverExhausted(c, failoverErr, streamStarted)
+				if !retry.ShouldRetry(failoverErr.StatusCode, switchCount) {
+					if nextModel, nextBody, ok := h.nextVirtualTarget(c, virtualChain, body, reqStream); ok {
+						reqModel, body = nextModel, nextBody
+						switchCount = 0
+						failedAccountIDs = make(map[int64]struct{})
+						lastFailoverErr = nil
+						continue
+					}
+					h.handleFailoverExhausted(c, failoverErr, retry, streamStarted)
 					return
 				}
 				switchCount++
-				log.Printf("Account %d: upstream error %d, switching account %d/%d", account.ID, failoverErr.StatusCode, switchCount, maxAccountSwitches)
+				service.ObserveGatewayFailover(account, apiKey.GroupID)
+				slog.WarnContext(c.Request.Context(), "upstream error, switching account", "upstream_status", failoverErr.StatusCode, "switch_count", switchCount, "max_switches", maxAccountSwitches)
+				if !waitBeforeAccountSwitch(c.Request.Context(), retry, account.Platform, switchCount) {
+					return
+				}
 				continue
 			}
 			// Error response already handled in Forward, just log
-			log.Printf("Account %d: Forward request failed: %v", account.ID, err)
+			slog.ErrorContext(c.Request.Context(), "forward request failed", "error", err)
 			return
 		}
 
+		storeResponseCache(account.ID, result.Model)
+		h.gatewayService.MarkPromptCacheWarm(c.Request.Context(), apiKey.GroupID, sessionHash, account, result.Usage.CacheReadInputTokens)
+		recordStreamObservation(h.streamAbuseService, disconnectWatch, apiKey, result.FirstTokenMs, result.Duration)
+		setLiveTrafficResult(c, account, result.Usage.InputTokens, result.Usage.OutputTokens, result.FirstTokenMs)
+		setOpsForwardTiming(c, result.Stream, result.Duration, result.FirstTokenMs)
+
 		// 捕获请求信息（用于异步记录，避免在 goroutine 中访问 gin.Context）
 		userAgent := c.GetHeader("User-Agent")
 		clientIP := ip.GetClientIP(c)
+		reqCtx := c.Request.Context()
 
 		// Async record usage
+		// 登记为关闭前需完成的任务，优雅关闭时等待用量记录落库
+		finishTask := h.shutdown.BeginTask()
 		go func(result *service.OpenAIForwardResult, usedAccount *service.Account, ua, ip string) {
+			defer finishTask()
 			ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
 			defer cancel()
 			if err := h.gatewayService.RecordUsage(ctx, &service.OpenAIRecordUsageInput{
@@ -324,7 +545,7 @@ func (h *OpenAIGatewayHandler) Responses(c *gin.Context) {
 				IPAddress:     ip,
 				APIKeyService: h.apiKeyService,
 			}); err != nil {
-				log.Printf("Record usage failed: %v", err)
+				slog.ErrorContext(reqCtx, "record usage failed", "error", err)
 			}
 		}(result, account, userAgent, clientIP)
 		return
@@ -337,23 +558,34 @@ func (h *OpenAIGatewayHandler) ChatCompletions(c *gin.Context) {
 	body, err := io.ReadAll(c.Request.Body)
 	if err != nil {
 		if maxErr, ok := extractMaxBytesError(err); ok {
-			log.Printf("[OpenAI ChatCompat] request body too large: path=%s limit=%d ua=%q", c.Request.URL.Path, maxErr.Limit, c.GetHeader("User-Agent"))
+			slog.WarnContext(c.Request.Context(), "chat completions request body too large", "path", c.Request.URL.Path, "limit", maxErr.Limit, "user_agent", c.GetHeader("User-Agent"))
 			h.errorResponse(c, http.StatusRequestEntityTooLarge, "invalid_request_error", buildBodyTooLargeMessage(maxErr.Limit))
 			return
 		}
-		log.Printf("[OpenAI ChatCompat] read request body failed: path=%s err=%v ua=%q", c.Request.URL.Path, err, c.GetHeader("User-Agent"))
+		slog.WarnContext(c.Request.Context(), "chat completions read request body failed", "path", c.Request.URL.Path, "error", err, "user_agent", c.GetHeader("User-Agent"))
 		h.errorResponse(c, http.StatusBadRequest, "invalid_request_error", "Failed to read request body")
 		return
 	}
 	if len(body) == 0 {
-		log.Printf("[OpenAI ChatCompat] empty request body: path=%s content_type=%q ua=%q", c.Request.URL.Path, c.GetHeader("Content-Type"), c.GetHeader("User-Agent"))
+		slog.WarnContext(c.Request.Context(), "chat completions empty request body", "path", c.Request.URL.Path, "content_type", c.GetHeader("Content-Type"), "user_agent", c.GetHeader("User-Agent"))
 		h.errorResponse(c, http.StatusBadRequest, "invalid_request_error", "Request body is empty")
 		return
 	}
 
+	// 剔除客户端注入的随机字段（在转换为 Responses 格式前按 Chat Completions 字段匹配）
+	body = stripRequestFields(c, h.requestStripService, service.PlatformOpenAI, body)
+
+	// 严格字段模式下按 Chat Completions 字段校验（转换为 Responses 格式时会复制全部顶层字段）
+	if apiKey, ok := middleware2.GetAPIKeyFromContext(c); ok {
+		if rejectMsg := checkUnknownRequestFields(apiKey, service.RequestSchemaOpenAIChatCompletion, body); rejectMsg != "" {
+			h.errorResponse(c, http.StatusBadRequest, "invalid_request_error", rejectMsg)
+			return
+		}
+	}
+
 	var reqBody map[string]any
 	if err := json.Unmarshal(body, &reqBody); err != nil {
-		log.Printf("[OpenAI ChatCompat] parse request body failed: path=%s err=%v content_type=%q ua=%q", c.Request.URL.Path, err, c.GetHeader("Content-Type"), c.GetHeader("User-Agent"))
+		slog.WarnContext(c.Request.Context(), "chat completions parse request body failed", "path", c.Request.URL.Path, "error", err, "content_type", c.GetHeader("Content-Type"), "user_agent", c.GetHeader("User-Agent"))
 		h.errorResponse(c, http.StatusBadRequest, "invalid_request_error", "Failed to parse request body")
 		return
 	}
@@ -363,13 +595,13 @@ func (h *OpenAIGatewayHandler) ChatCompletions(c *gin.Context) {
 	normalizedReq, convErr := normalizeChatCompletionsRequest(reqBody)
 	if convErr != nil {
 		if rawStats.RawImageParts > 0 || rawStats.RawInvalidImageParts > 0 || rawStats.RawUnknownParts > 0 {
-			log.Printf("[OpenAI ChatCompat] normalization failed: model=%s raw_images=%d invalid_images=%d unknown_parts=%d unknown_types=%s error=%v",
-				reqModel,
-				rawStats.RawImageParts,
-				rawStats.RawInvalidImageParts,
-				rawStats.RawUnknownParts,
-				rawStats.UnknownTypesString(),
-				convErr,
+			slog.WarnContext(c.Request.Context(), "chat completions normalization failed",
+				"model", reqModel,
+				"raw_images", rawStats.RawImageParts,
+				"invalid_images", rawStats.RawInvalidImageParts,
+				"unknown_parts", rawStats.RawUnknownParts,
+				"unknown_types", rawStats.UnknownTypesString(),
+				"error", convErr,
 			)
 		}
 		h.errorResponse(c, http.StatusBadRequest, "invalid_request_error", convErr.Error())
@@ -377,24 +609,24 @@ func (h *OpenAIGatewayHandler) ChatCompletions(c *gin.Context) {
 	}
 	normalizedStats := collectNormalizedChatInputStats(normalizedReq["input"])
 	if rawStats.RawImageParts > 0 || rawStats.RawUnknownParts > 0 || rawStats.RawInvalidImageParts > 0 {
-		log.Printf("[OpenAI ChatCompat] multimodal normalization: model=%s raw_messages=%d raw_images=%d invalid_images=%d raw_unknown_parts=%d unknown_types=%s normalized_input_items=%d normalized_images=%d normalized_text_parts=%d",
-			reqModel,
-			rawStats.RawMessages,
-			rawStats.RawImageParts,
-			rawStats.RawInvalidImageParts,
-			rawStats.RawUnknownParts,
-			rawStats.UnknownTypesString(),
-			normalizedStats.InputItems,
-			normalizedStats.InputImageParts,
-			normalizedStats.InputTextParts,
+		slog.InfoContext(c.Request.Context(), "chat completions multimodal normalization",
+			"model", reqModel,
+			"raw_messages", rawStats.RawMessages,
+			"raw_images", rawStats.RawImageParts,
+			"invalid_images", rawStats.RawInvalidImageParts,
+			"raw_unknown_parts", rawStats.RawUnknownParts,
+			"unknown_types", rawStats.UnknownTypesString(),
+			"normalized_input_items", normalizedStats.InputItems,
+			"normalized_images", normalizedStats.InputImageParts,
+			"normalized_text_parts", normalizedStats.InputTextParts,
 		)
 	}
 	if rawStats.RawImageParts > normalizedStats.InputImageParts {
-		log.Printf("[OpenAI ChatCompat] image parts dropped during normalization: model=%s raw_images=%d normalized_images=%d dropped=%d",
-			reqModel,
-			rawStats.RawImageParts,
-			normalizedStats.InputImageParts,
-			rawStats.RawImageParts-normalizedStats.InputImageParts,
+		slog.WarnContext(c.Request.Context(), "chat completions image parts dropped during normalization",
+			"model", reqModel,
+			"raw_images", rawStats.RawImageParts,
+			"normalized_images", normalizedStats.InputImageParts,
+			"dropped", rawStats.RawImageParts-normalizedStats.InputImageParts,
 		)
 	}
 
@@ -543,8 +775,8 @@ func normalizeChatCompletionsRequest(req map[string]any) (map[string]any, error)
 				// Preserve text as a normal assistant message so downstream context stays intact.
 				if hasNonEmptyMessageContent(contentParts) {
 					inputItems = append(inputItems, map[string]any{
-						"type": "message",
-						"role": role,
+						"type":    "message",
+						"role":    role,
 						"content": contentParts,
 					})
 				}
@@ -563,8 +795,8 @@ func normalizeChatCompletionsRequest(req map[string]any) (map[string]any, error)
 			continue
 		}
 		inputItems = append(inputItems, map[string]any{
-			"type": "message",
-			"role": role,
+			"type":    "message",
+			"role":    role,
 			"content": ensureNonEmptyMessageContent(contentParts, content),
 		})
 	}
@@ -845,19 +1077,42 @@ func collectNormalizedChatInputStats(input any) normalizedChatInputStats {
 	return stats
 }
 
+// nextVirtualTarget 切换到虚拟模型回退链的下一个目标，调用方需重置自身的故障转移状态
+func (h *OpenAIGatewayHandler) nextVirtualTarget(c *gin.Context, chain *service.VirtualModelChain, body []byte, reqStream bool) (string, []byte, bool) {
+	if chain == nil {
+		return "", body, false
+	}
+	nextModel, nextBody, ok := advanceVirtualModel(c, chain, body)
+	if !ok {
+		return "", body, false
+	}
+	setOpsRequestContext(c, nextModel, reqStream, nextBody)
+	return nextModel, nextBody, true
+}
+
 // handleConcurrencyError handles concurrency-related errors with proper 429 response
 func (h *OpenAIGatewayHandler) handleConcurrencyError(c *gin.Context, err error, slotType string, streamStarted bool) {
+	if isConcurrencyWaitCanceled(err) {
+		// 客户端已断开，无需再写响应
+		return
+	}
 	h.handleStreamingAwareError(c, http.StatusTooManyRequests, "rate_limit_error",
 		fmt.Sprintf("Concurrency limit exceeded for %s, please retry later", slotType), streamStarted)
 }
 
-func (h *OpenAIGatewayHandler) handleFailoverExhausted(c *gin.Context, failoverErr *service.UpstreamFailoverError, streamStarted bool) {
+func (h *OpenAIGatewayHandler) handleFailoverExhausted(c *gin.Context, failoverErr *service.UpstreamFailoverError, retry *service.RetryController, streamStarted bool) {
 	statusCode := failoverErr.StatusCode
 	responseBody := failoverErr.ResponseBody
+	service.SetUpstreamErrorDebugSource(c, statusCode, responseBody)
+
+	// 分组要求原样返回上游错误
+	if writeUpstreamErrorVerbatim(c, retry, failoverErr, streamStarted) {
+		return
+	}
 
 	// 先检查透传规则
-	if h.errorPassthroughService != nil && len(responseBody) > 0 {
-		if rule := h.errorPassthroughService.MatchRule("openai", statusCode, responseBody); rule != nil {
+	if h.errorPassthroughService != nil && (len(responseBody) > 0 || len(failoverErr.ResponseHeaders) > 0) {
+		if rule := h.errorPassthroughService.MatchRuleWithHeaders("openai", statusCode, failoverErr.ResponseHeaders, responseBody); rule != nil {
 			// 确定响应状态码
 			respCode := statusCode
 			if !rule.PassthroughCode && rule.ResponseCode != nil {
@@ -879,32 +1134,22 @@ func (h *OpenAIGatewayHandler) handleFailoverExhausted(c *gin.Context, failoverE
 		}
 	}
 
-	// 使用默认的错误映射
-	status, errType, errMsg := h.mapUpstreamError(statusCode)
+	// 使用错误映射（管理员配置优先，其次内置映射）
+	status, errType, errMsg := h.mapUpstreamError(c, statusCode, responseBody)
 	h.handleStreamingAwareError(c, status, errType, errMsg, streamStarted)
 }
 
 // handleFailoverExhaustedSimple 简化版本，用于没有响应体的情况
 func (h *OpenAIGatewayHandler) handleFailoverExhaustedSimple(c *gin.Context, statusCode int, streamStarted bool) {
-	status, errType, errMsg := h.mapUpstreamError(statusCode)
+	service.SetUpstreamErrorDebugSource(c, statusCode, nil)
+	status, errType, errMsg := h.mapUpstreamError(c, statusCode, nil)
 	h.handleStreamingAwareError(c, status, errType, errMsg, streamStarted)
 }
 
-func (h *OpenAIGatewayHandler) mapUpstreamError(statusCode int) (int, string, string) {
-	switch statusCode {
-	case 401:
-		return http.StatusBadGateway, "upstream_error", "Upstream authentication failed, please contact administrator"
-	case 403:
-		return http.StatusBadGateway, "upstream_error", "Upstream access forbidden, please contact administrator"
-	case 429:
-		return http.StatusTooManyRequests, "rate_limit_error", "Upstream rate limit exceeded, please retry later"
-	case 529:
-		return http.StatusServiceUnavailable, "upstream_error", "Upstream service overloaded, please retry later"
-	case 500, 502, 503, 504:
-		return http.StatusBadGateway, "upstream_error", "Upstream service temporarily unavailable"
-	default:
-		return http.StatusBadGateway, "upstream_error", "Upstream request failed"
-	}
+// mapUpstreamError 将上游错误映射为返回给客户端的状态码、错误类型与消息
+func (h *OpenAIGatewayHandler) mapUpstreamError(c *gin.Context, statusCode int, responseBody []byte) (int, string, string) {
+	m := h.upstreamErrorMapping.Map(c.Request.Context(), service.PlatformOpenAI, statusCode, responseBody)
+	return m.Status, m.Type, m.Message
 }
 
 // handleStreamingAwareError handles errors that may occur after streaming has started
@@ -929,10 +1174,15 @@ func (h *OpenAIGatewayHandler) handleStreamingAwareError(c *gin.Context, status
 
 // errorResponse returns OpenAI API format error response
 func (h *OpenAIGatewayHandler) errorResponse(c *gin.Context, status int, errType, message string) {
+	errorBody := gin.H{
+		"type":    errType,
+		"message": message,
+	}
+	// API Key 开启调试模式时附带脱敏后的上游错误详情
+	if debug := service.BuildUpstreamErrorDebug(c, 0, nil); debug != nil {
+		errorBody["upstream"] = debug
+	}
 	c.JSON(status, gin.H{
-		"error": gin.H{
-			"type":    errType,
-			"message": message,
-		},
+		"error": errorBody,
 	})
 }
@@ -153,7 +153,7 @@ func TestNormalizeChatCompletionsRequest_ConvertsImageURLString(t *testing.T) {
 				"role": "user",
 				"content": []any{
 					map[string]any{
-						"type": "image_url",
+						"type":      "image_url",
 						"image_url": "https://example.com/dog.png",
 					},
 				},
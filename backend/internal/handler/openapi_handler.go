@@ -0,0 +1,57 @@
+package handler
+
+import (
+	"encoding/json"
+	"net/http"
+	"sync"
+
+	"github.com/Wei-Shaw/sub2api/internal/pkg/openapi"
+	"github.com/Wei-Shaw/sub2api/internal/pkg/response"
+	"github.com/gin-gonic/gin"
+)
+
+// OpenAPIHandler 提供根据已注册路由生成的 OpenAPI 文档，供生成类型化客户端 SDK
+type OpenAPIHandler struct {
+	version string
+
+	once sync.Once
+	spec []byte
+	err  error
+}
+
+// ProvideOpenAPIHandler creates OpenAPIHandler with version from BuildInfo
+func ProvideOpenAPIHandler(buildInfo BuildInfo) *OpenAPIHandler {
+	return &OpenAPIHandler{version: buildInfo.Version}
+}
+
+// Spec 返回 OpenAPI 3.1 文档；首次请求时根据引擎上的全部路由生成并缓存
+// （路由在启动后不再变化，因此注册顺序不影响结果）
+// GET /openapi.json?download=1
+func (h *OpenAPIHandler) Spec(r *gin.Engine) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		h.once.Do(func() {
+			h.spec, h.err = h.build(r.Routes())
+		})
+		if h.err != nil {
+			response.InternalError(c, "Failed to generate OpenAPI document")
+			return
+		}
+		if c.Query("download") != "" {
+			c.Header("Content-Disposition", `attachment; filename="openapi.json"`)
+		}
+		c.Data(http.StatusOK, "application/json; charset=utf-8", h.spec)
+	}
+}
+
+func (h *OpenAPIHandler) build(infos gin.RoutesInfo) ([]byte, error) {
+	routes := make([]openapi.Route, 0, len(infos))
+	for _, info := range infos {
+		routes = append(routes, openapi.Route{Method: info.Method, Path: info.Path, Handler: info.Handler})
+	}
+	doc := openapi.Build(openapi.Options{
+		Title:       "Sub2API",
+		Version:     h.version,
+		Description: "Claude / OpenAI / Gemini compatible gateway and management API",
+	}, routes)
+	return json.MarshalIndent(doc, "", "  ")
+}
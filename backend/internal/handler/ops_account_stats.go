@@ -0,0 +1,42 @@
+package handler
+
+import (
+	"strings"
+
+	middleware2 "github.com/Wei-Shaw/sub2api/internal/server/middleware"
+	"github.com/Wei-Shaw/sub2api/internal/service"
+	"github.com/gin-gonic/gin"
+)
+
+// recordOpsAccountStats feeds the per-account rolling counters: the final account gets the request,
+// its tokens and the response status; every account failed over from in this request gets a failover.
+func recordOpsAccountStats(c *gin.Context, ops *service.OpsService) {
+	if isCountTokensRequest(c) {
+		return
+	}
+	apiKey, _ := middleware2.GetAPIKeyFromContext(c)
+	if apiKey == nil {
+		return
+	}
+
+	sample := &service.OpsAccountRequestSample{StatusCode: c.Writer.Status()}
+	if v, ok := c.Get(opsAccountIDKey); ok {
+		sample.AccountID, _ = v.(int64)
+	}
+	if v, ok := c.Get(opsUsageTokensKey); ok {
+		sample.Tokens, _ = v.(int64)
+	}
+	if v, ok := c.Get(service.OpsUpstreamErrorsKey); ok {
+		if events, ok := v.([]*service.OpsUpstreamErrorEvent); ok {
+			for _, ev := range events {
+				if ev != nil && ev.AccountID > 0 && strings.Contains(ev.Kind, "failover") {
+					sample.FailoverAccountIDs = append(sample.FailoverAccountIDs, ev.AccountID)
+				}
+			}
+		}
+	}
+	if sample.AccountID <= 0 && len(sample.FailoverAccountIDs) == 0 {
+		return
+	}
+	ops.RecordAccountRequest(sample)
+}
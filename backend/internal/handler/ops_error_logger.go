@@ -16,6 +16,7 @@ import (
 
 	"github.com/Wei-Shaw/sub2api/internal/pkg/ctxkey"
 	"github.com/Wei-Shaw/sub2api/internal/pkg/ip"
+	"github.com/Wei-Shaw/sub2api/internal/pkg/logger"
 	middleware2 "github.com/Wei-Shaw/sub2api/internal/server/middleware"
 	"github.com/Wei-Shaw/sub2api/internal/service"
 	"github.com/gin-gonic/gin"
@@ -26,6 +27,12 @@ const (
 	opsStreamKey      = "ops_stream"
 	opsRequestBodyKey = "ops_request_body"
 	opsAccountIDKey   = "ops_account_id"
+
+	opsRequestBodyBytesKey = "ops_request_body_bytes"
+	opsBodyCaptureKey      = "ops_body_capture"
+	opsLiveTrafficKey      = "ops_live_traffic"
+	opsFirstTokenMsKey     = "ops_first_token_ms"
+	opsUsageTokensKey      = "ops_usage_tokens"
 )
 
 const (
@@ -44,6 +51,8 @@ type opsErrorLogJob struct {
 	ops         *service.OpsService
 	entry       *service.OpsInsertErrorLogInput
 	requestBody []byte
+	// reservedBytes 为请求体在内存护栏中登记的字节数，写入完成或丢弃时释放
+	reservedBytes int64
 }
 
 var (
@@ -93,6 +102,7 @@ func startOpsErrorLogWorkers() {
 							log.Printf("[OpsErrorLogger] worker panic: %v\n%s", r, debug.Stack())
 						}
 					}()
+					defer job.ops.MemoryGuard().Release(job.reservedBytes)
 					ctx, cancel := context.WithTimeout(context.Background(), opsErrorLogTimeout)
 					_ = job.ops.RecordError(ctx, job.entry, job.requestBody)
 					cancel()
@@ -128,12 +138,20 @@ func enqueueOpsErrorLog(ops *service.OpsService, entry *service.OpsInsertErrorLo
 		return
 	}
 
+	// 排队中的请求体计入内存护栏预算；预算不足时仅记录错误元数据，不保留请求体
+	guard := ops.MemoryGuard()
+	reserved := int64(len(requestBody))
+	if !guard.TryReserve(reserved) {
+		requestBody, reserved = nil, 0
+	}
+
 	select {
-	case opsErrorLogQueue <- opsErrorLogJob{ops: ops, entry: entry, requestBody: requestBody}:
+	case opsErrorLogQueue <- opsErrorLogJob{ops: ops, entry: entry, requestBody: requestBody, reservedBytes: reserved}:
 		opsErrorLogQueueLen.Add(1)
 		opsErrorLogEnqueued.Add(1)
 	default:
 		// Queue is full; drop to avoid blocking request handling.
+		guard.Release(reserved)
 		opsErrorLogDropped.Add(1)
 		maybeLogOpsErrorLogDrop()
 	}
@@ -257,16 +275,50 @@ func setOpsRequestContext(c *gin.Context, model string, stream bool, requestBody
 	}
 	c.Set(opsModelKey, model)
 	c.Set(opsStreamKey, stream)
+	if c.Request != nil {
+		logger.SetModel(c.Request.Context(), model)
+	}
 	if len(requestBody) > 0 {
-		c.Set(opsRequestBodyKey, requestBody)
+		// 按路由捕获上限截断/哈希请求体，避免大请求（尤其是图片）在上下文与错误日志队列中长期占用内存
+		var capture *service.OpsBodyCapture
+		if v, ok := c.Get(opsBodyCaptureKey); ok {
+			capture, _ = v.(*service.OpsBodyCapture)
+		}
+		path := ""
+		if c.Request != nil && c.Request.URL != nil {
+			path = c.Request.URL.Path
+		}
+		c.Set(opsRequestBodyKey, capture.Capture(path, requestBody))
+		c.Set(opsRequestBodyBytesKey, len(requestBody))
 	}
 }
 
+// opsRequestBodyFromContext 取出捕获的请求体；捕获时被截断的请求体同时在 entry 中记录原始大小
+func opsRequestBodyFromContext(c *gin.Context, entry *service.OpsInsertErrorLogInput) []byte {
+	v, ok := c.Get(opsRequestBodyKey)
+	if !ok {
+		return nil
+	}
+	b, ok := v.([]byte)
+	if !ok || len(b) == 0 {
+		return nil
+	}
+	if n, ok := c.Get(opsRequestBodyBytesKey); ok {
+		if size, ok := n.(int); ok && size > len(b) {
+			entry.RequestBodyBytes = &size
+		}
+	}
+	return b
+}
+
 func setOpsSelectedAccount(c *gin.Context, accountID int64) {
 	if c == nil || accountID <= 0 {
 		return
 	}
 	c.Set(opsAccountIDKey, accountID)
+	if c.Request != nil {
+		logger.SetAccountID(c.Request.Context(), accountID)
+	}
 }
 
 type opsCaptureWriter struct {
@@ -306,8 +358,10 @@ func (w *opsCaptureWriter) WriteString(s string) (int, error) {
 // - Streaming errors after the response has started (SSE) may still need explicit logging.
 func OpsErrorLoggerMiddleware(ops *service.OpsService) gin.HandlerFunc {
 	return func(c *gin.Context) {
+		startedAt := time.Now()
 		w := &opsCaptureWriter{ResponseWriter: c.Writer, limit: 64 * 1024}
 		c.Writer = w
+		c.Set(opsBodyCaptureKey, ops.BodyCapture())
 		c.Next()
 
 		if ops == nil {
@@ -316,6 +370,9 @@ func OpsErrorLoggerMiddleware(ops *service.OpsService) gin.HandlerFunc {
 		if !ops.IsMonitoringEnabled(c.Request.Context()) {
 			return
 		}
+		recordOpsSlowRequest(c, ops, time.Since(startedAt))
+		recordOpsRequestPhases(c, ops)
+		recordOpsAccountStats(c, ops)
 
 		status := c.Writer.Status()
 		if status < 400 {
@@ -528,12 +585,7 @@ func OpsErrorLoggerMiddleware(ops *service.OpsService) gin.HandlerFunc {
 				entry.ClientIP = &clientIP
 			}
 
-			var requestBody []byte
-			if v, ok := c.Get(opsRequestBodyKey); ok {
-				if b, ok := v.([]byte); ok && len(b) > 0 {
-					requestBody = b
-				}
-			}
+			requestBody := opsRequestBodyFromContext(c, entry)
 			// Store request headers/body only when an upstream error occurred to keep overhead minimal.
 			entry.RequestHeadersJSON = extractOpsRetryRequestHeaders(c)
 
@@ -564,6 +616,11 @@ func OpsErrorLoggerMiddleware(ops *service.OpsService) gin.HandlerFunc {
 		}
 
 		apiKey, _ := middleware2.GetAPIKeyFromContext(c)
+		// IP 限制拒绝的请求未完成认证，仍归属到被调用的 Key 以便追踪泄露 Key 的滥用
+		deniedAPIKey, ipDenied := middleware2.GetDeniedAPIKeyFromContext(c)
+		if apiKey == nil && ipDenied {
+			apiKey = deniedAPIKey
+		}
 
 		clientRequestID, _ := c.Request.Context().Value(ctxkey.ClientRequestID).(string)
 
@@ -593,6 +650,9 @@ func OpsErrorLoggerMiddleware(ops *service.OpsService) gin.HandlerFunc {
 		}
 
 		phase := classifyOpsPhase(parsed.ErrorType, parsed.Message, parsed.Code)
+		if ipDenied {
+			phase = "auth"
+		}
 		isBusinessLimited := classifyOpsIsBusinessLimited(parsed.ErrorType, phase, parsed.Code, status, parsed.Message)
 
 		errorOwner := classifyOpsErrorOwner(phase, parsed.Message)
@@ -707,12 +767,7 @@ func OpsErrorLoggerMiddleware(ops *service.OpsService) gin.HandlerFunc {
 			entry.ClientIP = &clientIP
 		}
 
-		var requestBody []byte
-		if v, ok := c.Get(opsRequestBodyKey); ok {
-			if b, ok := v.([]byte); ok && len(b) > 0 {
-				requestBody = b
-			}
-		}
+		requestBody := opsRequestBodyFromContext(c, entry)
 		// Persist only a minimal, whitelisted set of request headers to improve retry fidelity.
 		// Do NOT store Authorization/Cookie/etc.
 		entry.RequestHeadersJSON = extractOpsRetryRequestHeaders(c)
@@ -0,0 +1,111 @@
+package handler
+
+import (
+	"time"
+
+	middleware2 "github.com/Wei-Shaw/sub2api/internal/server/middleware"
+	"github.com/Wei-Shaw/sub2api/internal/service"
+	"github.com/gin-gonic/gin"
+)
+
+// Per-request phase timings accumulated on the gin context (retries/failovers add up).
+const (
+	opsQueueWaitKey     = "ops_phase_queue_wait"
+	opsSlotWaitKey      = "ops_phase_slot_wait"
+	opsSelectionKey     = "ops_phase_selection"
+	opsForwardTimingKey = "ops_phase_forward"
+)
+
+type opsForwardTiming struct {
+	stream       bool
+	duration     time.Duration
+	firstTokenMs *int
+}
+
+// addOpsPhaseDuration adds d to the phase accumulated under key.
+func addOpsPhaseDuration(c *gin.Context, key string, d time.Duration) {
+	if c == nil || d <= 0 {
+		return
+	}
+	if v, ok := c.Get(key); ok {
+		if prev, ok := v.(time.Duration); ok {
+			d += prev
+		}
+	}
+	c.Set(key, d)
+}
+
+func getOpsPhaseDuration(c *gin.Context, key string) time.Duration {
+	if v, ok := c.Get(key); ok {
+		if d, ok := v.(time.Duration); ok {
+			return d
+		}
+	}
+	return 0
+}
+
+// setOpsForwardTiming stores the successful upstream forward timing (used for TTFB / stream duration).
+func setOpsForwardTiming(c *gin.Context, stream bool, duration time.Duration, firstTokenMs *int) {
+	if c == nil {
+		return
+	}
+	c.Set(opsForwardTimingKey, opsForwardTiming{stream: stream, duration: duration, firstTokenMs: firstTokenMs})
+}
+
+// buildOpsRequestPhaseSample converts the accumulated timings into a phase sample. TTFB is the
+// upstream first token for streams and the whole upstream call otherwise; stream duration is
+// the part of a streaming forward after the first token.
+func buildOpsRequestPhaseSample(queueWait, slotWait, selection time.Duration, forward *opsForwardTiming) *service.OpsRequestPhaseSample {
+	sample := &service.OpsRequestPhaseSample{
+		QueueWaitMs: queueWait.Milliseconds(),
+		SlotWaitMs:  slotWait.Milliseconds(),
+		SelectionMs: selection.Milliseconds(),
+	}
+	if forward == nil {
+		return sample
+	}
+	durationMs := forward.duration.Milliseconds()
+	switch {
+	case forward.stream && forward.firstTokenMs != nil:
+		ttfb := int64(*forward.firstTokenMs)
+		streamMs := max(durationMs-ttfb, 0)
+		sample.TTFBMs = &ttfb
+		sample.StreamMs = &streamMs
+	case !forward.stream && durationMs > 0:
+		sample.TTFBMs = &durationMs
+	}
+	return sample
+}
+
+// recordOpsRequestPhases records the phase timings of a successful gateway request into the per-group aggregation.
+func recordOpsRequestPhases(c *gin.Context, ops *service.OpsService) {
+	if c.Writer.Status() >= 400 || isCountTokensRequest(c) {
+		return
+	}
+	apiKey, _ := middleware2.GetAPIKeyFromContext(c)
+	if apiKey == nil {
+		return
+	}
+
+	var forward *opsForwardTiming
+	if v, ok := c.Get(opsForwardTimingKey); ok {
+		if t, ok := v.(opsForwardTiming); ok {
+			forward = &t
+		}
+	}
+	if forward == nil {
+		return
+	}
+
+	sample := buildOpsRequestPhaseSample(
+		getOpsPhaseDuration(c, opsQueueWaitKey),
+		getOpsPhaseDuration(c, opsSlotWaitKey),
+		getOpsPhaseDuration(c, opsSelectionKey),
+		forward,
+	)
+	if apiKey.GroupID != nil {
+		sample.GroupID = *apiKey.GroupID
+	}
+	sample.Platform = resolveOpsPlatform(apiKey, guessPlatformFromPath(c.Request.URL.Path))
+	ops.RecordRequestPhases(sample)
+}
@@ -0,0 +1,63 @@
+package handler
+
+import (
+	"time"
+
+	"github.com/Wei-Shaw/sub2api/internal/pkg/ctxkey"
+	middleware2 "github.com/Wei-Shaw/sub2api/internal/server/middleware"
+	"github.com/Wei-Shaw/sub2api/internal/service"
+	"github.com/gin-gonic/gin"
+)
+
+// recordOpsSlowRequest flags requests over the ops.slow_request duration/TTFT thresholds and records
+// them with the final account, model and the number of upstream retries/failovers seen in this request.
+func recordOpsSlowRequest(c *gin.Context, ops *service.OpsService, elapsed time.Duration) {
+	apiKey, _ := middleware2.GetAPIKeyFromContext(c)
+	if apiKey == nil || isCountTokensRequest(c) {
+		return
+	}
+
+	var stream bool
+	if v, ok := c.Get(opsStreamKey); ok {
+		stream, _ = v.(bool)
+	}
+	var firstTokenMs *int
+	if v, ok := c.Get(opsFirstTokenMsKey); ok {
+		if ms, ok := v.(int); ok {
+			firstTokenMs = &ms
+		}
+	}
+	reasons := ops.DetectSlowRequest(stream, elapsed.Milliseconds(), firstTokenMs)
+	if len(reasons) == 0 {
+		return
+	}
+
+	entry := &service.OpsInsertSlowRequestInput{
+		UserID:       &apiKey.UserID,
+		APIKeyID:     &apiKey.ID,
+		GroupID:      apiKey.GroupID,
+		Platform:     resolveOpsPlatform(apiKey, guessPlatformFromPath(c.Request.URL.Path)),
+		RequestPath:  c.Request.URL.Path,
+		Stream:       stream,
+		StatusCode:   c.Writer.Status(),
+		DurationMs:   int(elapsed.Milliseconds()),
+		FirstTokenMs: firstTokenMs,
+		Reasons:      reasons,
+		CreatedAt:    time.Now().UTC(),
+	}
+	entry.RequestID, _ = c.Request.Context().Value(ctxkey.ClientRequestID).(string)
+	if v, ok := c.Get(opsModelKey); ok {
+		entry.Model, _ = v.(string)
+	}
+	if v, ok := c.Get(opsAccountIDKey); ok {
+		if id, ok := v.(int64); ok && id > 0 {
+			entry.AccountID = &id
+		}
+	}
+	if v, ok := c.Get(service.OpsUpstreamErrorsKey); ok {
+		if events, ok := v.([]*service.OpsUpstreamErrorEvent); ok {
+			entry.RetryCount = len(events)
+		}
+	}
+	ops.RecordSlowRequest(entry)
+}
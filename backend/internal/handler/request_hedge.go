@@ -0,0 +1,316 @@
+package handler
+
+import (
+	"bytes"
+	"context"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/Wei-Shaw/sub2api/internal/service"
+	"github.com/gin-gonic/gin"
+)
+
+// 对冲尝试序号
+const (
+	hedgePrimary = iota
+	hedgeSecondary
+)
+
+// hedgeForwardFunc 在独立的 gin.Context 副本上执行一次转发
+type hedgeForwardFunc[R any] func(c *gin.Context) (R, error)
+
+// hedgeLauncher 在主尝试超过对冲延迟仍未输出时调用，选择第二个账号并返回其转发函数；
+// ok=false 表示没有可立即使用的账号，放弃对冲
+type hedgeLauncher[R any] func() (forward hedgeForwardFunc[R], ok bool)
+
+// hedgeOutcome 对冲转发结果：有获胜尝试时为获胜方的结果，否则为主尝试的结果
+type hedgeOutcome[R any] struct {
+	result R
+	err    error
+	// winner 结果来源（hedgePrimary/hedgeSecondary）
+	winner int
+	// hedged 是否实际发起了对冲尝试
+	hedged bool
+	// decided 是否有尝试以非错误状态开始输出
+	decided bool
+}
+
+// runHedgedForward 执行主尝试；若其在 delay 内未开始输出，则调用 launch 向另一账号并行发送相同请求。
+// 第一个以非错误状态写出响应体的尝试获胜，其余尝试立即取消且输出被丢弃：
+// 客户端只收到一份响应，调用方只按获胜尝试的结果记录用量，避免重复计费。
+// 没有获胜方时（全部失败）返回主尝试的结果，并输出其已缓冲的错误响应。
+func runHedgedForward[R any](c *gin.Context, delay time.Duration, primary hedgeForwardFunc[R], launch hedgeLauncher[R]) hedgeOutcome[R] {
+	type attemptDone struct {
+		index  int
+		result R
+		err    error
+	}
+
+	race := newHedgeRace(c.Writer)
+	defer race.cancelAll()
+
+	results := make(chan attemptDone, 2)
+	forks := make([]*gin.Context, 0, 2)
+	outcomes := make([]attemptDone, 0, 2)
+	start := func(forward hedgeForwardFunc[R]) {
+		index := len(forks)
+		fork := race.fork(c, index)
+		forks = append(forks, fork)
+		outcomes = append(outcomes, attemptDone{index: index})
+		go func() {
+			result, err := forward(fork)
+			results <- attemptDone{index: index, result: result, err: err}
+		}()
+	}
+
+	start(primary)
+	timer := time.NewTimer(delay)
+	defer timer.Stop()
+
+	hedged := false
+	for running := 1; running > 0; {
+		select {
+		case done := <-results:
+			outcomes[done.index] = done
+			running--
+		case <-timer.C:
+			if race.decided() {
+				continue
+			}
+			if forward, ok := launch(); ok {
+				hedged = true
+				start(forward)
+				running++
+			}
+		}
+	}
+
+	winner, decided := race.winnerIndex()
+	if !decided {
+		winner = hedgePrimary
+		race.flush(hedgePrimary)
+	}
+	// 同步获胜尝试在 gin.Context 上设置的值（运维计时、错误上下文等）
+	for k, v := range forks[winner].Keys {
+		c.Set(k, v)
+	}
+	done := outcomes[winner]
+	return hedgeOutcome[R]{result: done.result, err: done.err, winner: winner, hedged: hedged, decided: decided}
+}
+
+// hedgeExcludedAccounts 对冲账号选择时排除已失败账号与主尝试账号
+func hedgeExcludedAccounts(failed map[int64]struct{}, primaryID int64) map[int64]struct{} {
+	excluded := make(map[int64]struct{}, len(failed)+1)
+	for id := range failed {
+		excluded[id] = struct{}{}
+	}
+	excluded[primaryID] = struct{}{}
+	return excluded
+}
+
+// acquireHedgeSelection 确认对冲账号的槽位。对冲只使用可立即获取槽位的账号，
+// 不排队等待（等待期间的 ping 会写入客户端）；返回转发结束后需调用的释放函数
+func acquireHedgeSelection(ctx context.Context, selection *service.AccountSelectionResult, err error) (func(), bool) {
+	if err != nil || selection == nil || selection.Account == nil || !selection.Acquired {
+		return nil, false
+	}
+	if !commitAccountReservation(ctx, selection) {
+		return nil, false
+	}
+	release := selection.ReleaseFunc
+	if release == nil {
+		release = func() {}
+	}
+	return release, true
+}
+
+// hedgeWinnerLabel 对冲指标中的获胜方标签
+func hedgeWinnerLabel[R any](outcome hedgeOutcome[R]) string {
+	switch {
+	case !outcome.decided:
+		return "none"
+	case outcome.winner == hedgeSecondary:
+		return "hedge"
+	default:
+		return "primary"
+	}
+}
+
+// hedgeRace 对冲尝试之间的竞速状态
+type hedgeRace struct {
+	mu      sync.Mutex
+	target  gin.ResponseWriter
+	winner  int
+	writers []*hedgeWriter
+	cancels []context.CancelFunc
+}
+
+func newHedgeRace(target gin.ResponseWriter) *hedgeRace {
+	return &hedgeRace{target: target, winner: -1}
+}
+
+// fork 为一次尝试创建独立的 gin.Context：请求 context 可单独取消，响应写入竞速 writer
+func (r *hedgeRace) fork(c *gin.Context, index int) *gin.Context {
+	ctx, cancel := context.WithCancel(c.Request.Context())
+	w := &hedgeWriter{ResponseWriter: r.target, race: r, index: index, header: http.Header{}, size: -1}
+
+	r.mu.Lock()
+	r.writers = append(r.writers, w)
+	r.cancels = append(r.cancels, cancel)
+	r.mu.Unlock()
+
+	fork := c.Copy()
+	fork.Request = c.Request.WithContext(ctx)
+	fork.Writer = w
+	return fork
+}
+
+func (r *hedgeRace) decided() bool {
+	_, ok := r.winnerIndex()
+	return ok
+}
+
+func (r *hedgeRace) winnerIndex() (int, bool) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.winner, r.winner >= 0
+}
+
+// claim 尝试成为获胜方：输出已缓冲的响应头与响应体，并取消其余尝试
+func (r *hedgeRace) claim(w *hedgeWriter) bool {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.winner >= 0 {
+		return r.winner == w.index
+	}
+	r.winner = w.index
+	for i, cancel := range r.cancels {
+		if i != w.index {
+			cancel()
+		}
+	}
+	w.commit()
+	return true
+}
+
+// flush 无获胜方时输出指定尝试已缓冲的响应（通常为错误响应）
+func (r *hedgeRace) flush(index int) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if index < len(r.writers) && (r.writers[index].size >= 0 || r.writers[index].status > 0) {
+		r.writers[index].commit()
+	}
+}
+
+func (r *hedgeRace) cancelAll() {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for _, cancel := range r.cancels {
+		cancel()
+	}
+}
+
+// hedgeWriter 对冲尝试的响应 writer：获胜前缓冲响应头与响应体，
+// 首次以非错误状态写出响应体时参与竞速，获胜后直接写入客户端，落败后丢弃所有输出
+type hedgeWriter struct {
+	gin.ResponseWriter
+
+	race      *hedgeRace
+	index     int
+	header    http.Header
+	status    int
+	body      bytes.Buffer
+	size      int
+	committed bool
+}
+
+// commit 将缓冲内容写入客户端，此后所有写入直接透传（调用方持有 race.mu）
+func (w *hedgeWriter) commit() {
+	dst := w.ResponseWriter.Header()
+	for k, v := range w.header {
+		dst[k] = v
+	}
+	if w.status > 0 {
+		w.ResponseWriter.WriteHeader(w.status)
+	}
+	if w.body.Len() > 0 {
+		_, _ = w.ResponseWriter.Write(w.body.Bytes())
+		w.ResponseWriter.Flush()
+	}
+	w.body.Reset()
+	w.committed = true
+}
+
+func (w *hedgeWriter) Header() http.Header {
+	if w.committed {
+		return w.ResponseWriter.Header()
+	}
+	return w.header
+}
+
+func (w *hedgeWriter) WriteHeader(code int) {
+	if w.committed {
+		w.ResponseWriter.WriteHeader(code)
+		return
+	}
+	if code > 0 && w.size < 0 {
+		w.status = code
+	}
+}
+
+func (w *hedgeWriter) WriteHeaderNow() {
+	if w.committed {
+		w.ResponseWriter.WriteHeaderNow()
+	}
+}
+
+func (w *hedgeWriter) Write(b []byte) (int, error) {
+	if w.committed {
+		return w.ResponseWriter.Write(b)
+	}
+	if w.Status() < http.StatusBadRequest && w.race.claim(w) {
+		return w.ResponseWriter.Write(b)
+	}
+	if _, lost := w.race.winnerIndex(); lost {
+		// 落败尝试：丢弃输出，其 context 已被取消
+		return len(b), nil
+	}
+	// 错误响应先缓冲，所有尝试都失败时由主尝试输出
+	if w.size < 0 {
+		w.size = 0
+	}
+	w.size += len(b)
+	return w.body.Write(b)
+}
+
+func (w *hedgeWriter) WriteString(s string) (int, error) {
+	return w.Write([]byte(s))
+}
+
+func (w *hedgeWriter) Flush() {
+	if w.committed {
+		w.ResponseWriter.Flush()
+	}
+}
+
+func (w *hedgeWriter) Status() int {
+	if w.committed {
+		return w.ResponseWriter.Status()
+	}
+	if w.status > 0 {
+		return w.status
+	}
+	return http.StatusOK
+}
+
+func (w *hedgeWriter) Size() int {
+	if w.committed {
+		return w.ResponseWriter.Size()
+	}
+	return w.size
+}
+
+func (w *hedgeWriter) Written() bool {
+	return w.Size() != -1
+}
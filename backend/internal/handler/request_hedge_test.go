@@ -0,0 +1,121 @@
+package handler
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/require"
+)
+
+func newHedgeTestContext() (*gin.Context, *httptest.ResponseRecorder) {
+	gin.SetMode(gin.TestMode)
+	rec := httptest.NewRecorder()
+	c, _ := gin.CreateTestContext(rec)
+	c.Request = httptest.NewRequest(http.MethodPost, "/v1/messages", nil)
+	return c, rec
+}
+
+func TestRunHedgedForward_PrimaryFastSkipsHedge(t *testing.T) {
+	c, rec := newHedgeTestContext()
+	launched := false
+
+	outcome := runHedgedForward(c, 50*time.Millisecond,
+		func(fc *gin.Context) (string, error) {
+			fc.JSON(http.StatusOK, gin.H{"from": "primary"})
+			return "primary", nil
+		},
+		func() (hedgeForwardFunc[string], bool) {
+			launched = true
+			return nil, false
+		})
+
+	require.NoError(t, outcome.err)
+	require.Equal(t, "primary", outcome.result)
+	require.Equal(t, hedgePrimary, outcome.winner)
+	require.False(t, outcome.hedged)
+	require.False(t, launched)
+	require.Equal(t, http.StatusOK, rec.Code)
+	require.JSONEq(t, `{"from":"primary"}`, rec.Body.String())
+}
+
+func TestRunHedgedForward_HedgeWinsAndPrimaryCancelled(t *testing.T) {
+	c, rec := newHedgeTestContext()
+	primaryCancelled := make(chan struct{})
+
+	outcome := runHedgedForward(c, 10*time.Millisecond,
+		func(fc *gin.Context) (string, error) {
+			<-fc.Request.Context().Done()
+			close(primaryCancelled)
+			// 落败后的输出不能到达客户端
+			fc.String(http.StatusOK, "late primary")
+			return "primary", fc.Request.Context().Err()
+		},
+		func() (hedgeForwardFunc[string], bool) {
+			return func(fc *gin.Context) (string, error) {
+				fc.Header("X-Hedge", "1")
+				fc.String(http.StatusOK, "hedge")
+				return "hedge", nil
+			}, true
+		})
+
+	<-primaryCancelled
+	require.NoError(t, outcome.err)
+	require.Equal(t, "hedge", outcome.result)
+	require.Equal(t, hedgeSecondary, outcome.winner)
+	require.True(t, outcome.hedged)
+	require.Equal(t, "hedge", hedgeWinnerLabel(outcome))
+	require.Equal(t, "hedge", rec.Body.String())
+	require.Equal(t, "1", rec.Header().Get("X-Hedge"))
+}
+
+func TestRunHedgedForward_ErrorResponseDoesNotWin(t *testing.T) {
+	c, rec := newHedgeTestContext()
+	primaryErr := errors.New("upstream 500")
+	release := make(chan struct{})
+
+	outcome := runHedgedForward(c, 10*time.Millisecond,
+		func(fc *gin.Context) (string, error) {
+			<-release
+			fc.JSON(http.StatusBadGateway, gin.H{"error": "primary failed"})
+			return "", primaryErr
+		},
+		func() (hedgeForwardFunc[string], bool) {
+			return func(fc *gin.Context) (string, error) {
+				defer close(release)
+				fc.JSON(http.StatusTooManyRequests, gin.H{"error": "hedge failed"})
+				return "", errors.New("upstream 429")
+			}, true
+		})
+
+	// 全部失败时返回主尝试的错误，并输出其缓冲的错误响应
+	require.ErrorIs(t, outcome.err, primaryErr)
+	require.Equal(t, hedgePrimary, outcome.winner)
+	require.True(t, outcome.hedged)
+	require.Equal(t, "none", hedgeWinnerLabel(outcome))
+	require.Equal(t, http.StatusBadGateway, rec.Code)
+	require.JSONEq(t, `{"error":"primary failed"}`, rec.Body.String())
+}
+
+func TestRunHedgedForward_NoHedgeAccountAvailable(t *testing.T) {
+	c, rec := newHedgeTestContext()
+
+	outcome := runHedgedForward(c, 5*time.Millisecond,
+		func(fc *gin.Context) (string, error) {
+			time.Sleep(30 * time.Millisecond)
+			fc.Set("forwarded", true)
+			fc.String(http.StatusOK, "slow primary")
+			return "primary", nil
+		},
+		func() (hedgeForwardFunc[string], bool) {
+			return nil, false
+		})
+
+	require.NoError(t, outcome.err)
+	require.False(t, outcome.hedged)
+	require.Equal(t, "slow primary", rec.Body.String())
+	require.True(t, c.GetBool("forwarded"), "winner context values are copied back")
+}
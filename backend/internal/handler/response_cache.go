@@ -0,0 +1,81 @@
+package handler
+
+import (
+	"bytes"
+
+	"github.com/Wei-Shaw/sub2api/internal/pkg/ip"
+	"github.com/Wei-Shaw/sub2api/internal/service"
+	"github.com/gin-gonic/gin"
+)
+
+// responseCacheWriter 捕获非流式响应体用于写入响应缓存（超过上限后停止捕获）
+type responseCacheWriter struct {
+	gin.ResponseWriter
+	limit    int
+	buf      bytes.Buffer
+	overflow bool
+}
+
+func (w *responseCacheWriter) capture(b []byte) {
+	if w.overflow {
+		return
+	}
+	if w.buf.Len()+len(b) > w.limit {
+		w.overflow = true
+		w.buf.Reset()
+		return
+	}
+	_, _ = w.buf.Write(b)
+}
+
+func (w *responseCacheWriter) Write(b []byte) (int, error) {
+	n, err := w.ResponseWriter.Write(b)
+	w.capture(b[:n])
+	return n, err
+}
+
+func (w *responseCacheWriter) WriteString(s string) (int, error) {
+	n, err := w.ResponseWriter.WriteString(s)
+	w.capture([]byte(s[:n]))
+	return n, err
+}
+
+// serveResponseCache 处理可缓存的非流式请求：命中时直接写回缓存的响应、记录零费用用量并返回 true；
+// 未命中时包装 Writer 捕获响应体，返回的 store 需在转发成功后以实际使用的账号与模型调用以写入缓存。
+// 调用方须在计费资格与费用预检之后调用，避免余额不足或已超额的 Key 通过缓存获得响应。
+// body 应为最终转发前的请求体；请求不可缓存时 store 为空操作。
+// Chat Completions 兼容请求经 Responses 处理时同样走缓存：key 包含路由模板，缓存的是已转换为
+// Chat Completions 格式的响应体，因此与 /v1/responses 的相同请求互不命中。
+func serveResponseCache(c *gin.Context, svc *service.ResponseCacheService, apiKey *service.APIKey, subscription *service.UserSubscription, body []byte, stream bool) (bool, func(accountID int64, model string)) {
+	noop := func(int64, string) {}
+	if !svc.Enabled() || apiKey == nil {
+		return false, noop
+	}
+	key := svc.CacheKey(apiKey.ID, c.FullPath(), body, stream, c.GetHeader(service.ResponseCacheHeader))
+	if key == "" {
+		return false, noop
+	}
+	if cached := svc.Lookup(c.Request.Context(), key); cached != nil {
+		c.Header(service.ResponseCacheStatusHeader, "HIT")
+		c.Data(cached.StatusCode, cached.ContentType, cached.Body)
+		svc.RecordHit(c.Request.Context(), apiKey, subscription, cached, c.GetHeader("User-Agent"), ip.GetClientIP(c))
+		return true, noop
+	}
+
+	c.Header(service.ResponseCacheStatusHeader, "MISS")
+	w := &responseCacheWriter{ResponseWriter: c.Writer, limit: svc.MaxResponseBytes()}
+	c.Writer = w
+	return false, func(accountID int64, model string) {
+		// 已压缩或被截断的响应不缓存
+		if w.overflow || w.Header().Get("Content-Encoding") != "" {
+			return
+		}
+		svc.Store(c.Request.Context(), key, &service.CachedResponse{
+			StatusCode:  w.Status(),
+			ContentType: w.Header().Get("Content-Type"),
+			Body:        bytes.Clone(w.buf.Bytes()),
+			AccountID:   accountID,
+			Model:       model,
+		})
+	}
+}
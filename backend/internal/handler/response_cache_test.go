@@ -0,0 +1,102 @@
+package handler
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/Wei-Shaw/sub2api/internal/config"
+	"github.com/Wei-Shaw/sub2api/internal/service"
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/require"
+)
+
+type responseCacheHandlerStub struct {
+	items map[string]*service.CachedResponse
+}
+
+func (c *responseCacheHandlerStub) GetCachedResponse(ctx context.Context, key string) (*service.CachedResponse, error) {
+	return c.items[key], nil
+}
+
+func (c *responseCacheHandlerStub) SetCachedResponse(ctx context.Context, key string, resp *service.CachedResponse, ttl time.Duration) error {
+	c.items[key] = resp
+	return nil
+}
+
+type responseCacheUsageRepoStub struct {
+	service.UsageLogRepository
+	logs []*service.UsageLog
+}
+
+func (r *responseCacheUsageRepoStub) Create(ctx context.Context, log *service.UsageLog) (bool, error) {
+	r.logs = append(r.logs, log)
+	return true, nil
+}
+
+func TestServeResponseCache_MissThenHit(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	cfg := &config.Config{}
+	cfg.Gateway.ResponseCache = config.GatewayResponseCacheConfig{Enabled: true, TTLSeconds: 60, MaxResponseBytes: 1024}
+	cache := &responseCacheHandlerStub{items: map[string]*service.CachedResponse{}}
+	usage := &responseCacheUsageRepoStub{}
+	svc := service.NewResponseCacheService(cfg, cache, usage)
+	groupID := int64(3)
+	apiKey := &service.APIKey{ID: 1, UserID: 2, GroupID: &groupID}
+	body := []byte(`{"model":"m","temperature":0,"input":"hi"}`)
+	upstreamCalls := 0
+
+	router := gin.New()
+	handle := func(c *gin.Context) {
+		hit, store := serveResponseCache(c, svc, apiKey, nil, body, false)
+		if hit {
+			return
+		}
+		upstreamCalls++
+		c.JSON(http.StatusOK, gin.H{"output": "hello", "route": c.FullPath()})
+		store(42, "m")
+	}
+	router.POST("/v1/responses", handle)
+	router.POST("/v1/chat/completions", handle)
+
+	first := httptest.NewRecorder()
+	router.ServeHTTP(first, httptest.NewRequest(http.MethodPost, "/v1/responses", nil))
+	require.Equal(t, "MISS", first.Header().Get(service.ResponseCacheStatusHeader))
+	require.Len(t, cache.items, 1)
+
+	second := httptest.NewRecorder()
+	router.ServeHTTP(second, httptest.NewRequest(http.MethodPost, "/v1/responses", nil))
+	require.Equal(t, http.StatusOK, second.Code)
+	require.Equal(t, "HIT", second.Header().Get(service.ResponseCacheStatusHeader))
+	require.Equal(t, first.Body.String(), second.Body.String())
+	require.Contains(t, second.Header().Get("Content-Type"), "application/json")
+	require.Equal(t, 1, upstreamCalls)
+
+	// 命中不请求上游，但记录一条零费用用量（账号与模型取自写入缓存时的上游请求）
+	require.Len(t, usage.logs, 1)
+	hitLog := usage.logs[0]
+	require.Equal(t, int64(2), hitLog.UserID)
+	require.Equal(t, int64(1), hitLog.APIKeyID)
+	require.Equal(t, int64(42), hitLog.AccountID)
+	require.Equal(t, "m", hitLog.Model)
+	require.Equal(t, &groupID, hitLog.GroupID)
+	require.Zero(t, hitLog.ActualCost)
+
+	// Chat Completions 兼容路由按路由隔离缓存，不会命中 /v1/responses 的条目
+	chat := httptest.NewRecorder()
+	router.ServeHTTP(chat, httptest.NewRequest(http.MethodPost, "/v1/chat/completions", nil))
+	require.Equal(t, "MISS", chat.Header().Get(service.ResponseCacheStatusHeader))
+	require.Contains(t, chat.Body.String(), "/v1/chat/completions")
+	require.Equal(t, 2, upstreamCalls)
+
+	// 客户端显式跳过缓存
+	bypass := httptest.NewRecorder()
+	req := httptest.NewRequest(http.MethodPost, "/v1/responses", nil)
+	req.Header.Set(service.ResponseCacheHeader, "off")
+	router.ServeHTTP(bypass, req)
+	require.Empty(t, bypass.Header().Get(service.ResponseCacheStatusHeader))
+	require.Equal(t, 3, upstreamCalls)
+	require.Len(t, usage.logs, 1)
+}
@@ -0,0 +1,30 @@
+package handler
+
+import (
+	"net/http"
+
+	"github.com/Wei-Shaw/sub2api/internal/pkg/response"
+	"github.com/Wei-Shaw/sub2api/internal/service"
+	"github.com/gin-gonic/gin"
+)
+
+// ScalingHandler exposes the autoscaling signal for HPA/KEDA external scalers.
+type ScalingHandler struct {
+	scalingService *service.ScalingSignalService
+}
+
+// NewScalingHandler creates a new ScalingHandler
+func NewScalingHandler(scalingService *service.ScalingSignalService) *ScalingHandler {
+	return &ScalingHandler{scalingService: scalingService}
+}
+
+// Signal returns the current scaling signal as flat JSON
+// GET /health/scaling
+func (h *ScalingHandler) Signal(c *gin.Context) {
+	signal, err := h.scalingService.GetSignal(c.Request.Context())
+	if err != nil {
+		response.ErrorFrom(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, signal)
+}
@@ -0,0 +1,68 @@
+package handler
+
+import (
+	"context"
+	"log"
+	"sync/atomic"
+	"time"
+
+	"github.com/Wei-Shaw/sub2api/internal/service"
+)
+
+// clientDisconnectWatch 监听请求处理期间客户端断开（请求 context 被取消）的时间，
+// 用于流式滥用检测判断"首 token 后立即放弃"。nil 表示未启用，所有方法均为空操作。
+type clientDisconnectWatch struct {
+	disconnectedAt atomic.Int64
+	stopFn         func() bool
+}
+
+// watchClientDisconnect 仅在流式请求且启用检测时开始监听
+func watchClientDisconnect(ctx context.Context, svc *service.StreamAbuseService, stream bool) *clientDisconnectWatch {
+	if !stream || !svc.Enabled() {
+		return nil
+	}
+	w := &clientDisconnectWatch{}
+	w.stopFn = context.AfterFunc(ctx, func() {
+		w.disconnectedAt.CompareAndSwap(0, time.Now().UnixNano())
+	})
+	return w
+}
+
+// stop 停止监听，返回断开时间（零值表示未断开）
+func (w *clientDisconnectWatch) stop() time.Time {
+	if w == nil {
+		return time.Time{}
+	}
+	if !w.stopFn() {
+		// 回调已触发但可能尚未写入时间，以当前时间兜底
+		w.disconnectedAt.CompareAndSwap(0, time.Now().UnixNano())
+	}
+	if ns := w.disconnectedAt.Load(); ns > 0 {
+		return time.Unix(0, ns)
+	}
+	return time.Time{}
+}
+
+// recordStreamObservation 在 Forward 成功返回后调用：根据转发耗时与首 token 耗时推算首 token 时间，
+// 异步写入流式行为计数
+func recordStreamObservation(svc *service.StreamAbuseService, w *clientDisconnectWatch, apiKey *service.APIKey, firstTokenMs *int, duration time.Duration) {
+	if w == nil || apiKey == nil {
+		return
+	}
+	now := time.Now()
+	obs := service.StreamObservation{
+		APIKeyID:       apiKey.ID,
+		UserID:         apiKey.UserID,
+		DisconnectedAt: w.stop(),
+	}
+	if firstTokenMs != nil {
+		obs.FirstTokenAt = now.Add(-duration).Add(time.Duration(*firstTokenMs) * time.Millisecond)
+	}
+	go func() {
+		ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+		defer cancel()
+		if _, err := svc.Observe(ctx, obs); err != nil {
+			log.Printf("[StreamAbuse] Record stream observation failed: key=%d err=%v", obs.APIKeyID, err)
+		}
+	}()
+}
@@ -0,0 +1,42 @@
+package handler
+
+import (
+	"io"
+	"net/http"
+
+	"github.com/Wei-Shaw/sub2api/internal/pkg/response"
+	"github.com/Wei-Shaw/sub2api/internal/service"
+	"github.com/gin-gonic/gin"
+)
+
+// stripeWebhookMaxBodyBytes Stripe 事件体上限
+const stripeWebhookMaxBodyBytes = 1 << 20
+
+// StripeHandler 接收 Stripe Webhook
+type StripeHandler struct {
+	stripeService *service.StripeBillingService
+}
+
+// NewStripeHandler creates a new StripeHandler
+func NewStripeHandler(stripeService *service.StripeBillingService) *StripeHandler {
+	return &StripeHandler{stripeService: stripeService}
+}
+
+// Webhook 处理 Stripe 事件（签名校验需要原始请求体）
+// POST /api/v1/stripe/webhook
+func (h *StripeHandler) Webhook(c *gin.Context) {
+	if !h.stripeService.Enabled() {
+		response.ErrorFrom(c, service.ErrStripeDisabled)
+		return
+	}
+	payload, err := io.ReadAll(http.MaxBytesReader(c.Writer, c.Request.Body, stripeWebhookMaxBodyBytes))
+	if err != nil {
+		response.BadRequest(c, "Failed to read request body")
+		return
+	}
+	if err := h.stripeService.HandleWebhook(c.Request.Context(), payload, c.GetHeader("Stripe-Signature")); err != nil {
+		response.ErrorFrom(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"received": true})
+}
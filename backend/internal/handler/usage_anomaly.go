@@ -0,0 +1,44 @@
+package handler
+
+import (
+	"context"
+	"log"
+	"time"
+
+	middleware2 "github.com/Wei-Shaw/sub2api/internal/server/middleware"
+	"github.com/Wei-Shaw/sub2api/internal/service"
+	"github.com/gin-gonic/gin"
+)
+
+const usageAnomalyObserveTimeout = 3 * time.Second
+
+// UsageAnomalyMiddleware feeds authenticated gateway requests into per-key usage anomaly
+// detection. Observation runs asynchronously so Redis latency never delays the response.
+func UsageAnomalyMiddleware(svc *service.UsageAnomalyService) gin.HandlerFunc {
+	if !svc.Enabled() {
+		return func(c *gin.Context) { c.Next() }
+	}
+	countryHeader := svc.CountryHeader()
+	return func(c *gin.Context) {
+		c.Next()
+
+		apiKey, _ := middleware2.GetAPIKeyFromContext(c)
+		if apiKey == nil {
+			return
+		}
+		obs := service.UsageObservation{APIKeyID: apiKey.ID, UserID: apiKey.UserID}
+		if countryHeader != "" {
+			obs.Country = c.GetHeader(countryHeader)
+		}
+		if v, ok := c.Get(opsModelKey); ok {
+			obs.Model, _ = v.(string)
+		}
+		go func() {
+			ctx, cancel := context.WithTimeout(context.Background(), usageAnomalyObserveTimeout)
+			defer cancel()
+			if _, err := svc.Observe(ctx, obs); err != nil {
+				log.Printf("[UsageAnomaly] observe api key %d failed: %v", obs.APIKeyID, err)
+			}
+		}()
+	}
+}
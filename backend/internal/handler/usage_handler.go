@@ -1,6 +1,9 @@
 package handler
 
 import (
+	"fmt"
+	"log"
+	"net/http"
 	"strconv"
 	"time"
 
@@ -40,23 +43,44 @@ func (h *UsageHandler) List(c *gin.Context) {
 
 	page, pageSize := response.ParsePagination(c)
 
+	filters, ok := h.parseUserUsageFilters(c, subject.UserID)
+	if !ok {
+		return
+	}
+
+	params := pagination.PaginationParams{Page: page, PageSize: pageSize}
+	records, result, err := h.usageService.ListWithFilters(c.Request.Context(), params, filters)
+	if err != nil {
+		response.ErrorFrom(c, err)
+		return
+	}
+
+	out := make([]dto.UsageLog, 0, len(records))
+	for i := range records {
+		out = append(out, *dto.UsageLogFromService(&records[i]))
+	}
+	response.Paginated(c, out, result.Total, page, pageSize)
+}
+
+// parseUserUsageFilters 解析当前用户的用量筛选参数（列表与导出共用），解析失败时已写入错误响应
+func (h *UsageHandler) parseUserUsageFilters(c *gin.Context, userID int64) (usagestats.UsageLogFilters, bool) {
 	var apiKeyID int64
 	if apiKeyIDStr := c.Query("api_key_id"); apiKeyIDStr != "" {
 		id, err := strconv.ParseInt(apiKeyIDStr, 10, 64)
 		if err != nil {
 			response.BadRequest(c, "Invalid api_key_id")
-			return
+			return usagestats.UsageLogFilters{}, false
 		}
 
 		// [Security Fix] Verify API Key ownership to prevent horizontal privilege escalation
 		apiKey, err := h.apiKeyService.GetByID(c.Request.Context(), id)
 		if err != nil {
 			response.ErrorFrom(c, err)
-			return
+			return usagestats.UsageLogFilters{}, false
 		}
-		if apiKey.UserID != subject.UserID {
+		if apiKey.UserID != userID {
 			response.Forbidden(c, "Not authorized to access this API key's usage records")
-			return
+			return usagestats.UsageLogFilters{}, false
 		}
 
 		apiKeyID = id
@@ -70,7 +94,7 @@ func (h *UsageHandler) List(c *gin.Context) {
 		val, err := strconv.ParseBool(streamStr)
 		if err != nil {
 			response.BadRequest(c, "Invalid stream value, use true or false")
-			return
+			return usagestats.UsageLogFilters{}, false
 		}
 		stream = &val
 	}
@@ -80,7 +104,7 @@ func (h *UsageHandler) List(c *gin.Context) {
 		val, err := strconv.ParseInt(billingTypeStr, 10, 8)
 		if err != nil {
 			response.BadRequest(c, "Invalid billing_type")
-			return
+			return usagestats.UsageLogFilters{}, false
 		}
 		bt := int8(val)
 		billingType = &bt
@@ -93,7 +117,7 @@ func (h *UsageHandler) List(c *gin.Context) {
 		t, err := timezone.ParseInUserLocation("2006-01-02", startDateStr, userTZ)
 		if err != nil {
 			response.BadRequest(c, "Invalid start_date format, use YYYY-MM-DD")
-			return
+			return usagestats.UsageLogFilters{}, false
 		}
 		startTime = &t
 	}
@@ -102,35 +126,58 @@ func (h *UsageHandler) List(c *gin.Context) {
 		t, err := timezone.ParseInUserLocation("2006-01-02", endDateStr, userTZ)
 		if err != nil {
 			response.BadRequest(c, "Invalid end_date format, use YYYY-MM-DD")
-			return
+			return usagestats.UsageLogFilters{}, false
 		}
 		// Set end time to end of day
 		t = t.Add(24*time.Hour - time.Nanosecond)
 		endTime = &t
 	}
 
-	params := pagination.PaginationParams{Page: page, PageSize: pageSize}
-	filters := usagestats.UsageLogFilters{
-		UserID:      subject.UserID, // Always filter by current user for security
+	return usagestats.UsageLogFilters{
+		UserID:      userID, // Always filter by current user for security
 		APIKeyID:    apiKeyID,
 		Model:       model,
 		Stream:      stream,
 		BillingType: billingType,
 		StartTime:   startTime,
 		EndTime:     endTime,
+	}, true
+}
+
+// Export streams the current user's usage records in the date range as CSV or Parquet
+// GET /api/v1/usage/export?format=csv|parquet&start_date=&end_date=
+func (h *UsageHandler) Export(c *gin.Context) {
+	subject, ok := middleware2.GetAuthSubjectFromContext(c)
+	if !ok {
+		response.Unauthorized(c, "User not authenticated")
+		return
 	}
 
-	records, result, err := h.usageService.ListWithFilters(c.Request.Context(), params, filters)
+	filters, ok := h.parseUserUsageFilters(c, subject.UserID)
+	if !ok {
+		return
+	}
+	format, err := service.NormalizeUsageExportFormat(c.Query("format"))
 	if err != nil {
 		response.ErrorFrom(c, err)
 		return
 	}
+	if err := service.ValidateUsageExportRange(filters); err != nil {
+		response.ErrorFrom(c, err)
+		return
+	}
 
-	out := make([]dto.UsageLog, 0, len(records))
-	for i := range records {
-		out = append(out, *dto.UsageLogFromService(&records[i]))
+	filename := fmt.Sprintf("usage_%s_%s.%s", c.Query("start_date"), c.Query("end_date"), format)
+	c.Header("Content-Type", service.UsageExportContentType(format))
+	c.Header("Content-Disposition", fmt.Sprintf("attachment; filename=%q", filename))
+	c.Header("Cache-Control", "no-store")
+	c.Status(http.StatusOK)
+
+	// 响应头已发送，导出中途失败只能记录日志并中断连接
+	if err := h.usageService.ExportUsage(c.Request.Context(), c.Writer, filters, service.UsageExportOptions{Format: format}); err != nil {
+		log.Printf("[UsageExport] 导出失败: user=%d format=%s err=%v", subject.UserID, format, err)
+		c.Abort()
 	}
-	response.Paginated(c, out, result.Total, page, pageSize)
 }
 
 // GetByID handles getting a single usage record
@@ -28,6 +28,12 @@ func ProvideAdminHandlers(
 	usageHandler *admin.UsageHandler,
 	userAttributeHandler *admin.UserAttributeHandler,
 	errorPassthroughHandler *admin.ErrorPassthroughHandler,
+	modelPriceHandler *admin.ModelPriceHandler,
+	spendCapHandler *admin.SpendCapHandler,
+	auditLogHandler *admin.AuditLogHandler,
+	trashHandler *admin.TrashHandler,
+	actionLogHandler *admin.AdminActionLogHandler,
+	ipBanHandler *admin.IPBanHandler,
 ) *AdminHandlers {
 	return &AdminHandlers{
 		Dashboard:        dashboardHandler,
@@ -49,12 +55,18 @@ func ProvideAdminHandlers(
 		Usage:            usageHandler,
 		UserAttribute:    userAttributeHandler,
 		ErrorPassthrough: errorPassthroughHandler,
+		ModelPrice:       modelPriceHandler,
+		SpendCap:         spendCapHandler,
+		AuditLog:         auditLogHandler,
+		Trash:            trashHandler,
+		ActionLog:        actionLogHandler,
+		IPBan:            ipBanHandler,
 	}
 }
 
-// ProvideSystemHandler creates admin.SystemHandler with UpdateService
-func ProvideSystemHandler(updateService *service.UpdateService) *admin.SystemHandler {
-	return admin.NewSystemHandler(updateService)
+// ProvideSystemHandler creates admin.SystemHandler with UpdateService and ConfigReloadService
+func ProvideSystemHandler(updateService *service.UpdateService, configReloadService *service.ConfigReloadService) *admin.SystemHandler {
+	return admin.NewSystemHandler(updateService, configReloadService)
 }
 
 // ProvideSettingHandler creates SettingHandler with version from BuildInfo
@@ -76,7 +88,16 @@ func ProvideHandlers(
 	openaiGatewayHandler *OpenAIGatewayHandler,
 	settingHandler *SettingHandler,
 	totpHandler *TotpHandler,
+	scalingHandler *ScalingHandler,
+	healthHandler *HealthHandler,
+	stripeHandler *StripeHandler,
+	openAPIHandler *OpenAPIHandler,
+	metricsHandler *MetricsHandler,
+	configReloadService *service.ConfigReloadService,
 ) *Handlers {
+	// 网关处理器在构造时缓存了部分配置，热重载后需刷新
+	configReloadService.Subscribe(gatewayHandler.ApplyConfig)
+	configReloadService.Subscribe(openaiGatewayHandler.ApplyConfig)
 	return &Handlers{
 		Auth:          authHandler,
 		User:          userHandler,
@@ -90,6 +111,11 @@ func ProvideHandlers(
 		OpenAIGateway: openaiGatewayHandler,
 		Setting:       settingHandler,
 		Totp:          totpHandler,
+		Scaling:       scalingHandler,
+		Health:        healthHandler,
+		Stripe:        stripeHandler,
+		OpenAPI:       openAPIHandler,
+		Metrics:       metricsHandler,
 	}
 }
 
@@ -106,7 +132,12 @@ var ProviderSet = wire.NewSet(
 	NewGatewayHandler,
 	NewOpenAIGatewayHandler,
 	NewTotpHandler,
+	NewScalingHandler,
+	NewHealthHandler,
+	NewStripeHandler,
+	NewMetricsHandler,
 	ProvideSettingHandler,
+	ProvideOpenAPIHandler,
 
 	// Admin handlers
 	admin.NewDashboardHandler,
@@ -128,6 +159,12 @@ var ProviderSet = wire.NewSet(
 	admin.NewUsageHandler,
 	admin.NewUserAttributeHandler,
 	admin.NewErrorPassthroughHandler,
+	admin.NewModelPriceHandler,
+	admin.NewSpendCapHandler,
+	admin.NewAuditLogHandler,
+	admin.NewTrashHandler,
+	admin.NewAdminActionLogHandler,
+	admin.NewIPBanHandler,
 
 	// AdminHandlers and Handlers constructors
 	ProvideAdminHandlers,
@@ -0,0 +1,183 @@
+// Package credcrypt 提供账号凭证字段的信封加密。
+//
+// 每个值使用随机生成的数据密钥（DEK）以 AES-256-GCM 加密，DEK 再由主密钥（KEK）加密后与密文一起保存：
+//
+//	enc:v1:<key_id>:<base64(nonce|wrapped_dek)>:<base64(nonce|ciphertext)>
+//
+// 密文中记录主密钥标识，轮换主密钥后旧密文仍可用旧密钥解密。
+package credcrypt
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// Prefix 加密值的前缀
+const Prefix = "enc:v1:"
+
+// KeySize 主密钥长度（AES-256）
+const KeySize = 32
+
+var (
+	ErrUnknownKey = errors.New("credcrypt: unknown key id")
+	ErrMalformed  = errors.New("credcrypt: malformed ciphertext")
+)
+
+// Cipher 使用当前主密钥加密，并可用当前或旧主密钥解密
+type Cipher struct {
+	keyID string
+	keks  map[string]cipher.AEAD
+}
+
+// New 创建 Cipher。keyID 为当前主密钥标识，previous 为轮换前的旧主密钥（仅用于解密）
+func New(keyID string, key []byte, previous map[string][]byte) (*Cipher, error) {
+	keyID = strings.TrimSpace(keyID)
+	if keyID == "" || strings.Contains(keyID, ":") {
+		return nil, fmt.Errorf("credcrypt: key id must be non-empty and must not contain ':'")
+	}
+	c := &Cipher{keyID: keyID, keks: make(map[string]cipher.AEAD, len(previous)+1)}
+	for id, k := range previous {
+		aead, err := newAEAD(k)
+		if err != nil {
+			return nil, fmt.Errorf("credcrypt: previous key %q: %w", id, err)
+		}
+		c.keks[id] = aead
+	}
+	aead, err := newAEAD(key)
+	if err != nil {
+		return nil, fmt.Errorf("credcrypt: key %q: %w", keyID, err)
+	}
+	c.keks[keyID] = aead
+	return c, nil
+}
+
+// DecodeKey 解析 base64 编码的主密钥
+func DecodeKey(encoded string) ([]byte, error) {
+	key, err := base64.StdEncoding.DecodeString(strings.TrimSpace(encoded))
+	if err != nil {
+		return nil, fmt.Errorf("credcrypt: decode key: %w", err)
+	}
+	if len(key) != KeySize {
+		return nil, fmt.Errorf("credcrypt: key must be %d bytes, got %d", KeySize, len(key))
+	}
+	return key, nil
+}
+
+func newAEAD(key []byte) (cipher.AEAD, error) {
+	if len(key) != KeySize {
+		return nil, fmt.Errorf("key must be %d bytes, got %d", KeySize, len(key))
+	}
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	return cipher.NewGCM(block)
+}
+
+// KeyID 返回当前主密钥标识
+func (c *Cipher) KeyID() string {
+	return c.keyID
+}
+
+// IsEncrypted 判断值是否为本包生成的密文
+func IsEncrypted(value string) bool {
+	return strings.HasPrefix(value, Prefix)
+}
+
+// Encrypt 以当前主密钥信封加密明文
+func (c *Cipher) Encrypt(plaintext string) (string, error) {
+	dek := make([]byte, KeySize)
+	if _, err := rand.Read(dek); err != nil {
+		return "", fmt.Errorf("credcrypt: generate data key: %w", err)
+	}
+	wrapped, err := seal(c.keks[c.keyID], dek, []byte(c.keyID))
+	if err != nil {
+		return "", err
+	}
+	dataAEAD, err := newAEAD(dek)
+	if err != nil {
+		return "", err
+	}
+	ciphertext, err := seal(dataAEAD, []byte(plaintext), nil)
+	if err != nil {
+		return "", err
+	}
+	return Prefix + c.keyID + ":" +
+		base64.RawStdEncoding.EncodeToString(wrapped) + ":" +
+		base64.RawStdEncoding.EncodeToString(ciphertext), nil
+}
+
+// Decrypt 解密密文；非密文原样返回，兼容尚未迁移的明文数据
+func (c *Cipher) Decrypt(value string) (string, error) {
+	if !IsEncrypted(value) {
+		return value, nil
+	}
+	keyID, wrapped, ciphertext, err := parse(value)
+	if err != nil {
+		return "", err
+	}
+	kek, ok := c.keks[keyID]
+	if !ok {
+		return "", fmt.Errorf("%w: %s", ErrUnknownKey, keyID)
+	}
+	dek, err := open(kek, wrapped, []byte(keyID))
+	if err != nil {
+		return "", err
+	}
+	dataAEAD, err := newAEAD(dek)
+	if err != nil {
+		return "", ErrMalformed
+	}
+	plaintext, err := open(dataAEAD, ciphertext, nil)
+	if err != nil {
+		return "", err
+	}
+	return string(plaintext), nil
+}
+
+// NeedsReencrypt 判断值是否需要（重新）加密：明文，或由非当前主密钥加密
+func (c *Cipher) NeedsReencrypt(value string) bool {
+	if !IsEncrypted(value) {
+		return true
+	}
+	keyID, _, _, err := parse(value)
+	return err == nil && keyID != c.keyID
+}
+
+func parse(value string) (keyID string, wrapped, ciphertext []byte, err error) {
+	parts := strings.Split(strings.TrimPrefix(value, Prefix), ":")
+	if len(parts) != 3 || parts[0] == "" {
+		return "", nil, nil, ErrMalformed
+	}
+	if wrapped, err = base64.RawStdEncoding.DecodeString(parts[1]); err != nil {
+		return "", nil, nil, ErrMalformed
+	}
+	if ciphertext, err = base64.RawStdEncoding.DecodeString(parts[2]); err != nil {
+		return "", nil, nil, ErrMalformed
+	}
+	return parts[0], wrapped, ciphertext, nil
+}
+
+func seal(aead cipher.AEAD, plaintext, additionalData []byte) ([]byte, error) {
+	nonce := make([]byte, aead.NonceSize(), aead.NonceSize()+len(plaintext)+aead.Overhead())
+	if _, err := rand.Read(nonce); err != nil {
+		return nil, fmt.Errorf("credcrypt: generate nonce: %w", err)
+	}
+	return aead.Seal(nonce, nonce, plaintext, additionalData), nil
+}
+
+func open(aead cipher.AEAD, data, additionalData []byte) ([]byte, error) {
+	if len(data) < aead.NonceSize() {
+		return nil, ErrMalformed
+	}
+	plaintext, err := aead.Open(nil, data[:aead.NonceSize()], data[aead.NonceSize():], additionalData)
+	if err != nil {
+		return nil, fmt.Errorf("credcrypt: decrypt: %w", err)
+	}
+	return plaintext, nil
+}
@@ -0,0 +1,98 @@
+//go:build unit
+
+package credcrypt
+
+import (
+	"bytes"
+	"encoding/base64"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func testKey(b byte) []byte {
+	return bytes.Repeat([]byte{b}, KeySize)
+}
+
+func TestCipher_RoundTrip(t *testing.T) {
+	c, err := New("k1", testKey(1), nil)
+	require.NoError(t, err)
+
+	enc, err := c.Encrypt("sk-ant-secret")
+	require.NoError(t, err)
+	require.True(t, IsEncrypted(enc))
+	require.NotContains(t, enc, "sk-ant-secret")
+
+	// 每次加密使用新的数据密钥与 nonce
+	enc2, err := c.Encrypt("sk-ant-secret")
+	require.NoError(t, err)
+	require.NotEqual(t, enc, enc2)
+
+	plain, err := c.Decrypt(enc)
+	require.NoError(t, err)
+	require.Equal(t, "sk-ant-secret", plain)
+
+	// 明文原样返回
+	plain, err = c.Decrypt("plain-token")
+	require.NoError(t, err)
+	require.Equal(t, "plain-token", plain)
+}
+
+func TestCipher_Rotation(t *testing.T) {
+	old, err := New("k1", testKey(1), nil)
+	require.NoError(t, err)
+	enc, err := old.Encrypt("refresh-token")
+	require.NoError(t, err)
+
+	rotated, err := New("k2", testKey(2), map[string][]byte{"k1": testKey(1)})
+	require.NoError(t, err)
+	plain, err := rotated.Decrypt(enc)
+	require.NoError(t, err)
+	require.Equal(t, "refresh-token", plain)
+	require.True(t, rotated.NeedsReencrypt(enc))
+	require.True(t, rotated.NeedsReencrypt("plain"))
+
+	reenc, err := rotated.Encrypt(plain)
+	require.NoError(t, err)
+	require.False(t, rotated.NeedsReencrypt(reenc))
+
+	// 未保留旧密钥时无法解密
+	withoutOld, err := New("k2", testKey(2), nil)
+	require.NoError(t, err)
+	_, err = withoutOld.Decrypt(enc)
+	require.ErrorIs(t, err, ErrUnknownKey)
+}
+
+func TestCipher_Tampered(t *testing.T) {
+	c, err := New("k1", testKey(1), nil)
+	require.NoError(t, err)
+	enc, err := c.Encrypt("secret")
+	require.NoError(t, err)
+
+	other, err := New("k1", testKey(9), nil)
+	require.NoError(t, err)
+	_, err = other.Decrypt(enc)
+	require.Error(t, err)
+
+	_, err = c.Decrypt(Prefix + "k1:not-base64!:x")
+	require.ErrorIs(t, err, ErrMalformed)
+	_, err = c.Decrypt(enc[:len(enc)-4])
+	require.Error(t, err)
+}
+
+func TestNewAndDecodeKey(t *testing.T) {
+	_, err := New("", testKey(1), nil)
+	require.Error(t, err)
+	_, err = New("a:b", testKey(1), nil)
+	require.Error(t, err)
+	_, err = New("k1", []byte("short"), nil)
+	require.Error(t, err)
+
+	key, err := DecodeKey(base64.StdEncoding.EncodeToString(testKey(3)))
+	require.NoError(t, err)
+	require.Equal(t, testKey(3), key)
+	_, err = DecodeKey(base64.StdEncoding.EncodeToString([]byte("short")))
+	require.Error(t, err)
+	_, err = DecodeKey("%%%")
+	require.Error(t, err)
+}
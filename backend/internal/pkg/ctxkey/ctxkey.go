@@ -11,6 +11,9 @@ const (
 	// ClientRequestID 客户端请求的唯一标识，用于追踪请求全生命周期（用于 Ops 监控与排障）。
 	ClientRequestID Key = "ctx_client_request_id"
 
+	// LogFields 请求级结构化日志关联字段（*logger.Fields），由请求日志中间件设置
+	LogFields Key = "ctx_log_fields"
+
 	// RetryCount 表示当前请求在网关层的重试次数（用于 Ops 记录与排障）。
 	RetryCount Key = "ctx_retry_count"
 
@@ -25,6 +28,9 @@ const (
 	// Group 认证后的分组信息，由 API Key 认证中间件设置
 	Group Key = "ctx_group"
 
+	// RegionPolicy 请求生效的账号区域策略（API Key 覆盖分组），由 API Key 认证中间件设置
+	RegionPolicy Key = "ctx_region_policy"
+
 	// IsMaxTokensOneHaikuRequest 标识当前请求是否为 max_tokens=1 + haiku 模型的探测请求
 	// 用于 ClaudeCodeOnly 验证绕过（绕过 system prompt 检查，但仍需验证 User-Agent）
 	IsMaxTokensOneHaikuRequest Key = "ctx_is_max_tokens_one_haiku"
@@ -32,4 +38,26 @@ const (
 	// SingleAccountRetry 标识当前请求处于单账号 503 退避重试模式。
 	// 在此模式下，Service 层的模型限流预检查将等待限流过期而非直接切换账号。
 	SingleAccountRetry Key = "ctx_single_account_retry"
+
+	// ModelAliasOrigin 命中模型别名规则时客户端原始请求的模型名，用于在响应中回显
+	ModelAliasOrigin Key = "ctx_model_alias_origin"
+
+	// ModelDeprecation 命中已弃用模型的别名规则时的弃用信息（*service.ModelDeprecation），用于响应告警
+	ModelDeprecation Key = "ctx_model_deprecation"
+
+	// VirtualModelAccountIDs 虚拟模型当前目标限定的账号 ID 列表，为空表示不限制
+	VirtualModelAccountIDs Key = "ctx_virtual_model_account_ids"
+
+	// UpstreamAttemptTimeout 单次上游尝试等待响应头的超时（time.Duration），按 API Key 优先级类别设置
+	UpstreamAttemptTimeout Key = "ctx_upstream_attempt_timeout"
+	// UpstreamTimeoutBudget 上游请求分阶段超时预算（service.UpstreamTimeoutBudget）
+	UpstreamTimeoutBudget Key = "ctx_upstream_timeout_budget"
+	// UpstreamClientTLS 账号级上游 TLS 设置（*service.UpstreamClientTLS），用于 mTLS 与自定义 CA
+	UpstreamClientTLS Key = "ctx_upstream_client_tls"
+
+	// RequestFeatures 请求依赖的上游能力（service.RequestFeatures），用于调度时排除不具备能力的账号
+	RequestFeatures Key = "ctx_request_features"
+
+	// SpendCapWarning 计费资格检查命中消费软上限时的告警记录器，由 handler 读取并写入响应头
+	SpendCapWarning Key = "ctx_spend_cap_warning"
 )
@@ -0,0 +1,219 @@
+// Package envelope 提供按租户划分数据密钥的信封加密。
+//
+// 每个租户使用独立的数据密钥（DEK）以 AES-256-GCM 加密内容，DEK 由 KeyWrapper（外部 KMS 或本地主密钥）
+// 包装后与密文一同保存（包装后的 DEK + 主密钥版本）。轮换主密钥时只需解包并重新包装 DEK，内容密文保持不变。
+package envelope
+
+import (
+	"context"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+)
+
+// KeySize 数据密钥与本地主密钥长度（AES-256）
+const KeySize = 32
+
+// maxUnwrapCache 已解包 DEK 缓存的最大条目数，超出后整体清空
+const maxUnwrapCache = 4096
+
+var (
+	ErrUnknownKey = errors.New("envelope: unknown key id")
+	ErrMalformed  = errors.New("envelope: malformed ciphertext")
+)
+
+// KeyWrapper 包装/解包数据密钥的主密钥服务（KMS 抽象）
+type KeyWrapper interface {
+	// KeyID 当前用于包装的主密钥版本，随包装结果一同保存
+	KeyID() string
+	// Wrap 以当前主密钥包装 DEK
+	Wrap(ctx context.Context, dek []byte) ([]byte, error)
+	// Unwrap 以 keyID 版本的主密钥解包 DEK
+	Unwrap(ctx context.Context, keyID string, wrapped []byte) ([]byte, error)
+}
+
+// TenantKey 租户当前使用的数据密钥及其包装结果
+type TenantKey struct {
+	// KeyID 包装 DEK 的主密钥版本
+	KeyID string
+	// Wrapped 包装后的 DEK，与密文一同保存
+	Wrapped []byte
+
+	dek       []byte
+	createdAt time.Time
+}
+
+// KeyRing 管理各租户的数据密钥：同一租户在有效期内复用同一个 DEK（只调用一次 KMS 包装），
+// 解包结果按包装值缓存，避免每次读取都访问 KMS
+type KeyRing struct {
+	wrapper KeyWrapper
+	maxAge  time.Duration
+	nowFunc func() time.Time
+
+	mu        sync.Mutex
+	tenants   map[string]*TenantKey
+	unwrapped map[string][]byte
+}
+
+// NewKeyRing 创建密钥环；maxAge 为租户 DEK 的最长使用时间，<=0 表示不过期（仍会随主密钥版本变化而更换）
+func NewKeyRing(wrapper KeyWrapper, maxAge time.Duration) *KeyRing {
+	return &KeyRing{
+		wrapper:   wrapper,
+		maxAge:    maxAge,
+		nowFunc:   time.Now,
+		tenants:   make(map[string]*TenantKey),
+		unwrapped: make(map[string][]byte),
+	}
+}
+
+// KeyID 返回当前主密钥版本
+func (r *KeyRing) KeyID() string {
+	return r.wrapper.KeyID()
+}
+
+// TenantKey 返回租户当前的数据密钥；不存在、已过期或主密钥版本已变化时生成新的 DEK 并包装
+func (r *KeyRing) TenantKey(ctx context.Context, tenant string) (*TenantKey, error) {
+	keyID := r.wrapper.KeyID()
+	now := r.nowFunc()
+
+	r.mu.Lock()
+	current := r.tenants[tenant]
+	r.mu.Unlock()
+	if current != nil && current.KeyID == keyID && (r.maxAge <= 0 || now.Sub(current.createdAt) < r.maxAge) {
+		return current, nil
+	}
+
+	dek := make([]byte, KeySize)
+	if _, err := rand.Read(dek); err != nil {
+		return nil, fmt.Errorf("envelope: generate data key: %w", err)
+	}
+	wrapped, err := r.wrapper.Wrap(ctx, dek)
+	if err != nil {
+		return nil, fmt.Errorf("envelope: wrap data key: %w", err)
+	}
+	key := &TenantKey{KeyID: keyID, Wrapped: wrapped, dek: dek, createdAt: now}
+
+	r.mu.Lock()
+	r.tenants[tenant] = key
+	r.mu.Unlock()
+	return key, nil
+}
+
+// Encrypt 以租户数据密钥加密明文，tenant 作为附加认证数据绑定到密文上
+func (k *TenantKey) Encrypt(plaintext, tenant string) (string, error) {
+	return seal(k.dek, plaintext, tenant)
+}
+
+// Decrypt 解包 keyID/wrapped 对应的 DEK 并解密由 TenantKey.Encrypt 生成的密文；tenant 必须与加密时一致
+func (r *KeyRing) Decrypt(ctx context.Context, keyID string, wrapped []byte, ciphertext, tenant string) (string, error) {
+	dek, err := r.unwrap(ctx, keyID, wrapped)
+	if err != nil {
+		return "", err
+	}
+	return open(dek, ciphertext, tenant)
+}
+
+// Rewrap 用当前主密钥重新包装由旧版本主密钥包装的 DEK；DEK 本身不变，因此已有密文无需重新加密。
+// changed 为 false 表示已是当前版本
+func (r *KeyRing) Rewrap(ctx context.Context, keyID string, wrapped []byte) (newKeyID string, newWrapped []byte, changed bool, err error) {
+	current := r.wrapper.KeyID()
+	if keyID == current {
+		return keyID, wrapped, false, nil
+	}
+	dek, err := r.unwrap(ctx, keyID, wrapped)
+	if err != nil {
+		return "", nil, false, err
+	}
+	newWrapped, err = r.wrapper.Wrap(ctx, dek)
+	if err != nil {
+		return "", nil, false, fmt.Errorf("envelope: wrap data key: %w", err)
+	}
+	return current, newWrapped, true, nil
+}
+
+func (r *KeyRing) unwrap(ctx context.Context, keyID string, wrapped []byte) ([]byte, error) {
+	cacheKey := keyID + ":" + string(wrapped)
+	r.mu.Lock()
+	dek, ok := r.unwrapped[cacheKey]
+	r.mu.Unlock()
+	if ok {
+		return dek, nil
+	}
+	dek, err := r.wrapper.Unwrap(ctx, keyID, wrapped)
+	if err != nil {
+		return nil, err
+	}
+	if len(dek) != KeySize {
+		return nil, fmt.Errorf("envelope: unwrapped data key must be %d bytes, got %d", KeySize, len(dek))
+	}
+	r.mu.Lock()
+	if len(r.unwrapped) >= maxUnwrapCache {
+		r.unwrapped = make(map[string][]byte)
+	}
+	r.unwrapped[cacheKey] = dek
+	r.mu.Unlock()
+	return dek, nil
+}
+
+func newAEAD(key []byte) (cipher.AEAD, error) {
+	if len(key) != KeySize {
+		return nil, fmt.Errorf("envelope: key must be %d bytes, got %d", KeySize, len(key))
+	}
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	return cipher.NewGCM(block)
+}
+
+func sealBytes(aead cipher.AEAD, plaintext, additionalData []byte) ([]byte, error) {
+	nonce := make([]byte, aead.NonceSize(), aead.NonceSize()+len(plaintext)+aead.Overhead())
+	if _, err := rand.Read(nonce); err != nil {
+		return nil, fmt.Errorf("envelope: generate nonce: %w", err)
+	}
+	return aead.Seal(nonce, nonce, plaintext, additionalData), nil
+}
+
+func openBytes(aead cipher.AEAD, data, additionalData []byte) ([]byte, error) {
+	if len(data) < aead.NonceSize() {
+		return nil, ErrMalformed
+	}
+	plaintext, err := aead.Open(nil, data[:aead.NonceSize()], data[aead.NonceSize():], additionalData)
+	if err != nil {
+		return nil, fmt.Errorf("envelope: decrypt: %w", err)
+	}
+	return plaintext, nil
+}
+
+func seal(dek []byte, plaintext, tenant string) (string, error) {
+	aead, err := newAEAD(dek)
+	if err != nil {
+		return "", err
+	}
+	data, err := sealBytes(aead, []byte(plaintext), []byte(tenant))
+	if err != nil {
+		return "", err
+	}
+	return base64.RawStdEncoding.EncodeToString(data), nil
+}
+
+func open(dek []byte, ciphertext, tenant string) (string, error) {
+	data, err := base64.RawStdEncoding.DecodeString(ciphertext)
+	if err != nil {
+		return "", ErrMalformed
+	}
+	aead, err := newAEAD(dek)
+	if err != nil {
+		return "", err
+	}
+	plaintext, err := openBytes(aead, data, []byte(tenant))
+	if err != nil {
+		return "", err
+	}
+	return string(plaintext), nil
+}
@@ -0,0 +1,133 @@
+//go:build unit
+
+package envelope
+
+import (
+	"bytes"
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func testKey(b byte) []byte {
+	return bytes.Repeat([]byte{b}, KeySize)
+}
+
+type countingWrapper struct {
+	KeyWrapper
+	wraps, unwraps int
+}
+
+func (w *countingWrapper) Wrap(ctx context.Context, dek []byte) ([]byte, error) {
+	w.wraps++
+	return w.KeyWrapper.Wrap(ctx, dek)
+}
+
+func (w *countingWrapper) Unwrap(ctx context.Context, keyID string, wrapped []byte) ([]byte, error) {
+	w.unwraps++
+	return w.KeyWrapper.Unwrap(ctx, keyID, wrapped)
+}
+
+func TestKeyRing_PerTenantKeys(t *testing.T) {
+	local, err := NewLocalKeyWrapper("k1", map[string][]byte{"k1": testKey(1)})
+	require.NoError(t, err)
+	wrapper := &countingWrapper{KeyWrapper: local}
+	ring := NewKeyRing(wrapper, time.Hour)
+	ctx := context.Background()
+
+	alice, err := ring.TenantKey(ctx, "user:1")
+	require.NoError(t, err)
+	again, err := ring.TenantKey(ctx, "user:1")
+	require.NoError(t, err)
+	require.Same(t, alice, again)
+	bob, err := ring.TenantKey(ctx, "user:2")
+	require.NoError(t, err)
+	require.NotEqual(t, alice.Wrapped, bob.Wrapped)
+	require.Equal(t, 2, wrapper.wraps)
+
+	ciphertext, err := alice.Encrypt("prompt body", "user:1")
+	require.NoError(t, err)
+	require.NotContains(t, ciphertext, "prompt body")
+
+	plain, err := ring.Decrypt(ctx, alice.KeyID, alice.Wrapped, ciphertext, "user:1")
+	require.NoError(t, err)
+	require.Equal(t, "prompt body", plain)
+
+	// 租户不一致或使用其他租户的 DEK 时无法解密
+	_, err = ring.Decrypt(ctx, alice.KeyID, alice.Wrapped, ciphertext, "user:2")
+	require.Error(t, err)
+	_, err = ring.Decrypt(ctx, bob.KeyID, bob.Wrapped, ciphertext, "user:1")
+	require.Error(t, err)
+}
+
+func TestKeyRing_TenantKeyExpires(t *testing.T) {
+	local, err := NewLocalKeyWrapper("k1", map[string][]byte{"k1": testKey(1)})
+	require.NoError(t, err)
+	ring := NewKeyRing(local, time.Hour)
+	now := time.Now()
+	ring.nowFunc = func() time.Time { return now }
+
+	first, err := ring.TenantKey(context.Background(), "user:1")
+	require.NoError(t, err)
+	now = now.Add(2 * time.Hour)
+	second, err := ring.TenantKey(context.Background(), "user:1")
+	require.NoError(t, err)
+	require.NotEqual(t, first.Wrapped, second.Wrapped)
+}
+
+func TestKeyRing_RewrapKeepsCiphertext(t *testing.T) {
+	ctx := context.Background()
+	v1, err := NewLocalKeyWrapper("k1", map[string][]byte{"k1": testKey(1)})
+	require.NoError(t, err)
+	key, err := NewKeyRing(v1, 0).TenantKey(ctx, "user:1")
+	require.NoError(t, err)
+	ciphertext, err := key.Encrypt("secret", "user:1")
+	require.NoError(t, err)
+
+	// 轮换：k2 为当前版本，k1 仅用于解包
+	v2, err := NewLocalKeyWrapper("k2", map[string][]byte{"k1": testKey(1), "k2": testKey(2)})
+	require.NoError(t, err)
+	keyID, wrapped, changed, err := NewKeyRing(v2, 0).Rewrap(ctx, key.KeyID, key.Wrapped)
+	require.NoError(t, err)
+	require.True(t, changed)
+	require.Equal(t, "k2", keyID)
+
+	// 移除旧主密钥后，重新包装的 DEK 仍能解密原密文
+	v2Only, err := NewLocalKeyWrapper("k2", map[string][]byte{"k2": testKey(2)})
+	require.NoError(t, err)
+	ring := NewKeyRing(v2Only, 0)
+	plain, err := ring.Decrypt(ctx, keyID, wrapped, ciphertext, "user:1")
+	require.NoError(t, err)
+	require.Equal(t, "secret", plain)
+	_, err = ring.Decrypt(ctx, key.KeyID, key.Wrapped, ciphertext, "user:1")
+	require.ErrorIs(t, err, ErrUnknownKey)
+
+	_, _, changed, err = ring.Rewrap(ctx, keyID, wrapped)
+	require.NoError(t, err)
+	require.False(t, changed)
+}
+
+func TestCommandKeyWrapper(t *testing.T) {
+	// 用 base64 模拟 KMS：wrap 原样回显，unwrap 校验主密钥版本
+	w, err := NewCommandKeyWrapper("kms-v1", "base64", `[ "$SUB2API_KEY_ID" = kms-v1 ] && base64`)
+	require.NoError(t, err)
+	ring := NewKeyRing(w, 0)
+	ctx := context.Background()
+
+	key, err := ring.TenantKey(ctx, "user:1")
+	require.NoError(t, err)
+	require.Len(t, key.Wrapped, KeySize)
+	ciphertext, err := key.Encrypt("hello", "user:1")
+	require.NoError(t, err)
+	plain, err := ring.Decrypt(ctx, "kms-v1", key.Wrapped, ciphertext, "user:1")
+	require.NoError(t, err)
+	require.Equal(t, "hello", plain)
+
+	_, err = NewKeyRing(w, 0).Decrypt(ctx, "kms-v0", key.Wrapped, ciphertext, "user:1")
+	require.Error(t, err)
+
+	_, err = NewCommandKeyWrapper("kms-v1", "", "cat")
+	require.Error(t, err)
+}
@@ -0,0 +1,115 @@
+package envelope
+
+import (
+	"bytes"
+	"context"
+	"crypto/cipher"
+	"encoding/base64"
+	"fmt"
+	"os"
+	"os/exec"
+	"strings"
+	"time"
+)
+
+// commandTimeout 单次执行 KMS 命令的超时
+const commandTimeout = 30 * time.Second
+
+// LocalKeyWrapper 以本地主密钥（AES-256-GCM）包装 DEK，适用于未接入外部 KMS 的部署。
+// 轮换时新增主密钥版本，旧版本保留用于解包
+type LocalKeyWrapper struct {
+	keyID string
+	keks  map[string]cipher.AEAD
+}
+
+// NewLocalKeyWrapper 创建本地包装器；keys 为主密钥版本 → 32 字节密钥，须包含 keyID
+func NewLocalKeyWrapper(keyID string, keys map[string][]byte) (*LocalKeyWrapper, error) {
+	keyID = strings.TrimSpace(keyID)
+	if keyID == "" {
+		return nil, fmt.Errorf("envelope: key id is required")
+	}
+	if _, ok := keys[keyID]; !ok {
+		return nil, fmt.Errorf("envelope: key %q is not configured", keyID)
+	}
+	w := &LocalKeyWrapper{keyID: keyID, keks: make(map[string]cipher.AEAD, len(keys))}
+	for id, key := range keys {
+		aead, err := newAEAD(key)
+		if err != nil {
+			return nil, fmt.Errorf("envelope: key %q: %w", id, err)
+		}
+		w.keks[id] = aead
+	}
+	return w, nil
+}
+
+// KeyID 当前主密钥版本
+func (w *LocalKeyWrapper) KeyID() string {
+	return w.keyID
+}
+
+// Wrap 以当前主密钥包装 DEK
+func (w *LocalKeyWrapper) Wrap(_ context.Context, dek []byte) ([]byte, error) {
+	return sealBytes(w.keks[w.keyID], dek, []byte(w.keyID))
+}
+
+// Unwrap 以 keyID 版本的主密钥解包 DEK
+func (w *LocalKeyWrapper) Unwrap(_ context.Context, keyID string, wrapped []byte) ([]byte, error) {
+	kek, ok := w.keks[keyID]
+	if !ok {
+		return nil, fmt.Errorf("%w: %s", ErrUnknownKey, keyID)
+	}
+	return openBytes(kek, wrapped, []byte(keyID))
+}
+
+// CommandKeyWrapper 通过外部命令调用 KMS 包装/解包 DEK（AWS KMS、GCP KMS、age 等）。
+// 命令从标准输入读取原始字节，向标准输出写出 base64 编码的结果；
+// 环境变量 SUB2API_KEY_ID 为本次使用的主密钥版本（解包时为包装时记录的版本）
+type CommandKeyWrapper struct {
+	keyID         string
+	wrapCommand   string
+	unwrapCommand string
+}
+
+// NewCommandKeyWrapper 创建命令包装器；keyID 为当前主密钥版本（如 KMS 密钥别名或轮换批次）
+func NewCommandKeyWrapper(keyID, wrapCommand, unwrapCommand string) (*CommandKeyWrapper, error) {
+	keyID = strings.TrimSpace(keyID)
+	if keyID == "" {
+		return nil, fmt.Errorf("envelope: key id is required")
+	}
+	if strings.TrimSpace(wrapCommand) == "" || strings.TrimSpace(unwrapCommand) == "" {
+		return nil, fmt.Errorf("envelope: wrap and unwrap commands are required")
+	}
+	return &CommandKeyWrapper{keyID: keyID, wrapCommand: wrapCommand, unwrapCommand: unwrapCommand}, nil
+}
+
+// KeyID 当前主密钥版本
+func (w *CommandKeyWrapper) KeyID() string {
+	return w.keyID
+}
+
+// Wrap 执行 wrap 命令包装 DEK
+func (w *CommandKeyWrapper) Wrap(ctx context.Context, dek []byte) ([]byte, error) {
+	return runKeyCommand(ctx, w.wrapCommand, w.keyID, dek)
+}
+
+// Unwrap 执行 unwrap 命令解包 DEK
+func (w *CommandKeyWrapper) Unwrap(ctx context.Context, keyID string, wrapped []byte) ([]byte, error) {
+	return runKeyCommand(ctx, w.unwrapCommand, keyID, wrapped)
+}
+
+func runKeyCommand(ctx context.Context, command, keyID string, input []byte) ([]byte, error) {
+	ctx, cancel := context.WithTimeout(ctx, commandTimeout)
+	defer cancel()
+	cmd := exec.CommandContext(ctx, "sh", "-c", command)
+	cmd.Stdin = bytes.NewReader(input)
+	cmd.Env = append(os.Environ(), "SUB2API_KEY_ID="+keyID)
+	output, err := cmd.Output()
+	if err != nil {
+		return nil, fmt.Errorf("envelope: key command failed: %w", err)
+	}
+	decoded, err := base64.StdEncoding.DecodeString(strings.TrimSpace(string(output)))
+	if err != nil {
+		return nil, fmt.Errorf("envelope: key command output is not base64: %w", err)
+	}
+	return decoded, nil
+}
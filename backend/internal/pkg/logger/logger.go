@@ -0,0 +1,153 @@
+// Package logger 基于 log/slog 的结构化日志：支持 JSON/文本输出，并自动为带请求上下文的日志
+// 附加 request_id、api_key_id、account_id、model 等关联字段。
+//
+// slog.SetDefault 之后标准库 log.Printf 的输出也会经由同一 handler 输出（Info 级别），
+// 因此旧日志在 JSON 模式下同样是合法的 JSON 行，只是不带请求关联字段。
+package logger
+
+import (
+	"context"
+	"io"
+	"log/slog"
+	"strings"
+	"sync"
+
+	"github.com/Wei-Shaw/sub2api/internal/pkg/ctxkey"
+)
+
+// 输出格式
+const (
+	FormatText = "text"
+	FormatJSON = "json"
+)
+
+// Options 日志初始化参数
+type Options struct {
+	// Format 输出格式：text（默认）或 json
+	Format string
+	// Level 日志级别：debug/info/warn/error
+	Level slog.Level
+}
+
+// ParseLevel 解析日志级别，无法识别时返回 fallback
+func ParseLevel(s string, fallback slog.Level) slog.Level {
+	switch strings.ToLower(strings.TrimSpace(s)) {
+	case "debug":
+		return slog.LevelDebug
+	case "info":
+		return slog.LevelInfo
+	case "warn", "warning":
+		return slog.LevelWarn
+	case "error":
+		return slog.LevelError
+	default:
+		return fallback
+	}
+}
+
+// Init 构建 handler 并设置为 slog 默认 logger（同时接管标准库 log 的输出）
+func Init(w io.Writer, opts Options) {
+	slog.SetDefault(slog.New(NewHandler(w, opts)))
+}
+
+// NewHandler 按格式创建 handler，并包装为会注入请求关联字段的 handler
+func NewHandler(w io.Writer, opts Options) slog.Handler {
+	handlerOpts := &slog.HandlerOptions{Level: opts.Level}
+	var inner slog.Handler
+	if strings.EqualFold(opts.Format, FormatJSON) {
+		inner = slog.NewJSONHandler(w, handlerOpts)
+	} else {
+		inner = slog.NewTextHandler(w, handlerOpts)
+	}
+	return &contextHandler{Handler: inner}
+}
+
+// contextHandler 在输出前从 context 中读取请求关联字段
+type contextHandler struct {
+	slog.Handler
+}
+
+func (h *contextHandler) Handle(ctx context.Context, r slog.Record) error {
+	if f := FieldsFromContext(ctx); f != nil {
+		r.AddAttrs(f.attrs()...)
+	}
+	return h.Handler.Handle(ctx, r)
+}
+
+func (h *contextHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	return &contextHandler{Handler: h.Handler.WithAttrs(attrs)}
+}
+
+func (h *contextHandler) WithGroup(name string) slog.Handler {
+	return &contextHandler{Handler: h.Handler.WithGroup(name)}
+}
+
+// Fields 单个请求的日志关联字段。请求开始时挂到 context 上，之后在认证、选号等阶段逐步填充；
+// 由于保存的是指针，后续派生的 context 也能看到更新后的值。
+type Fields struct {
+	mu        sync.RWMutex
+	requestID string
+	apiKeyID  int64
+	accountID int64
+	model     string
+}
+
+func (f *Fields) attrs() []slog.Attr {
+	f.mu.RLock()
+	defer f.mu.RUnlock()
+	out := make([]slog.Attr, 0, 4)
+	if f.requestID != "" {
+		out = append(out, slog.String("request_id", f.requestID))
+	}
+	if f.apiKeyID > 0 {
+		out = append(out, slog.Int64("api_key_id", f.apiKeyID))
+	}
+	if f.accountID > 0 {
+		out = append(out, slog.Int64("account_id", f.accountID))
+	}
+	if f.model != "" {
+		out = append(out, slog.String("model", f.model))
+	}
+	return out
+}
+
+// WithRequestID 为请求创建关联字段并挂到 context 上
+func WithRequestID(ctx context.Context, requestID string) context.Context {
+	return context.WithValue(ctx, ctxkey.LogFields, &Fields{requestID: requestID})
+}
+
+// FieldsFromContext 返回 context 上的关联字段，未挂载时为 nil
+func FieldsFromContext(ctx context.Context) *Fields {
+	if ctx == nil {
+		return nil
+	}
+	f, _ := ctx.Value(ctxkey.LogFields).(*Fields)
+	return f
+}
+
+// SetAPIKeyID 记录请求使用的 API Key
+func SetAPIKeyID(ctx context.Context, id int64) {
+	if f := FieldsFromContext(ctx); f != nil {
+		f.mu.Lock()
+		f.apiKeyID = id
+		f.mu.Unlock()
+	}
+}
+
+// SetAccountID 记录当前转发使用的上游账号（failover 切换账号时覆盖）
+func SetAccountID(ctx context.Context, id int64) {
+	if f := FieldsFromContext(ctx); f != nil {
+		f.mu.Lock()
+		f.accountID = id
+		f.mu.Unlock()
+	}
+}
+
+// SetModel 记录请求模型
+func SetModel(ctx context.Context, model string) {
+	if f := FieldsFromContext(ctx); f != nil {
+		f.mu.Lock()
+		f.model = model
+		f.mu.Unlock()
+	}
+}
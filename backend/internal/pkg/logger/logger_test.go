@@ -0,0 +1,53 @@
+package logger
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"log/slog"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestContextHandler_JSONIncludesRequestFields(t *testing.T) {
+	var buf bytes.Buffer
+	log := slog.New(NewHandler(&buf, Options{Format: FormatJSON, Level: slog.LevelInfo}))
+
+	ctx := WithRequestID(context.Background(), "req-1")
+	SetAPIKeyID(ctx, 7)
+	SetAccountID(ctx, 42)
+	SetModel(ctx, "claude-sonnet-4-5")
+	log.InfoContext(ctx, "forward failed", "status", 502)
+
+	var rec map[string]any
+	require.NoError(t, json.Unmarshal(buf.Bytes(), &rec))
+	require.Equal(t, "forward failed", rec["msg"])
+	require.Equal(t, "req-1", rec["request_id"])
+	require.EqualValues(t, 7, rec["api_key_id"])
+	require.EqualValues(t, 42, rec["account_id"])
+	require.Equal(t, "claude-sonnet-4-5", rec["model"])
+	require.EqualValues(t, 502, rec["status"])
+}
+
+func TestContextHandler_TextWithoutFields(t *testing.T) {
+	var buf bytes.Buffer
+	log := slog.New(NewHandler(&buf, Options{Format: FormatText, Level: slog.LevelWarn}))
+
+	log.Info("dropped")
+	require.Empty(t, buf.String())
+
+	log.With("component", "scheduler").WarnContext(context.Background(), "queue full")
+	require.Contains(t, buf.String(), `msg="queue full"`)
+	require.Contains(t, buf.String(), "component=scheduler")
+	require.NotContains(t, buf.String(), "request_id")
+
+	// 未挂载关联字段时 setter 为 no-op
+	SetAccountID(context.Background(), 1)
+}
+
+func TestParseLevel(t *testing.T) {
+	require.Equal(t, slog.LevelDebug, ParseLevel("DEBUG", slog.LevelInfo))
+	require.Equal(t, slog.LevelWarn, ParseLevel("warning", slog.LevelInfo))
+	require.Equal(t, slog.LevelInfo, ParseLevel("", slog.LevelInfo))
+}
@@ -0,0 +1,291 @@
+// Package metrics 最小的 Prometheus 文本格式指标注册表（带标签的 Counter / Gauge / Histogram），
+// 仅实现 /metrics 端点用到的子集，避免引入完整的 client_golang 依赖。
+package metrics
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"math"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// DefaultLatencyBuckets 网关请求耗时分桶（秒），覆盖短请求到长时间流式响应
+var DefaultLatencyBuckets = []float64{0.1, 0.25, 0.5, 1, 2.5, 5, 10, 30, 60, 120, 300, 600}
+
+type collector interface {
+	name() string
+	write(w *bufio.Writer)
+}
+
+// Registry 指标注册表，按注册顺序输出
+type Registry struct {
+	mu         sync.RWMutex
+	collectors []collector
+	names      map[string]struct{}
+}
+
+// NewRegistry 创建空注册表
+func NewRegistry() *Registry {
+	return &Registry{names: make(map[string]struct{})}
+}
+
+func (r *Registry) register(c collector) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if _, ok := r.names[c.name()]; ok {
+		panic("metrics: duplicate metric " + c.name())
+	}
+	r.names[c.name()] = struct{}{}
+	r.collectors = append(r.collectors, c)
+}
+
+// WriteText 以 Prometheus text exposition format (0.0.4) 输出全部指标
+func (r *Registry) WriteText(w io.Writer) error {
+	r.mu.RLock()
+	collectors := append([]collector(nil), r.collectors...)
+	r.mu.RUnlock()
+
+	bw := bufio.NewWriter(w)
+	for _, c := range collectors {
+		c.write(bw)
+	}
+	return bw.Flush()
+}
+
+// ContentType /metrics 响应的 Content-Type
+const ContentType = "text/plain; version=0.0.4; charset=utf-8"
+
+type desc struct {
+	metricName string
+	help       string
+	labels     []string
+}
+
+func (d *desc) name() string { return d.metricName }
+
+func (d *desc) writeHeader(w *bufio.Writer, typ string) {
+	fmt.Fprintf(w, "# HELP %s %s\n# TYPE %s %s\n", d.metricName, escapeHelp(d.help), d.metricName, typ)
+}
+
+func (d *desc) key(values []string) string {
+	if len(values) != len(d.labels) {
+		panic(fmt.Sprintf("metrics: %s expects %d label values, got %d", d.metricName, len(d.labels), len(values)))
+	}
+	return strings.Join(values, "\xff")
+}
+
+// labelPairs 渲染 {a="x",b="y"}；extra 追加在末尾（用于直方图的 le）
+func (d *desc) labelPairs(values []string, extra ...string) string {
+	if len(d.labels) == 0 && len(extra) == 0 {
+		return ""
+	}
+	var sb strings.Builder
+	sb.WriteByte('{')
+	for i, l := range d.labels {
+		if i > 0 {
+			sb.WriteByte(',')
+		}
+		sb.WriteString(l)
+		sb.WriteString(`="`)
+		sb.WriteString(escapeLabelValue(values[i]))
+		sb.WriteByte('"')
+	}
+	for i := 0; i+1 < len(extra); i += 2 {
+		if sb.Len() > 1 {
+			sb.WriteByte(',')
+		}
+		sb.WriteString(extra[i])
+		sb.WriteString(`="`)
+		sb.WriteString(extra[i+1])
+		sb.WriteByte('"')
+	}
+	sb.WriteByte('}')
+	return sb.String()
+}
+
+type series struct {
+	values []string
+	value  float64
+}
+
+// CounterVec 带标签的单调递增计数器
+type CounterVec struct {
+	desc
+	mu     sync.Mutex
+	series map[string]*series
+}
+
+// NewCounterVec 创建并注册计数器
+func (r *Registry) NewCounterVec(name, help string, labels ...string) *CounterVec {
+	c := &CounterVec{desc: desc{metricName: name, help: help, labels: labels}, series: make(map[string]*series)}
+	r.register(c)
+	return c
+}
+
+// Add 累加 delta（负值忽略）
+func (c *CounterVec) Add(delta float64, values ...string) {
+	if delta < 0 {
+		return
+	}
+	key := c.key(values)
+	c.mu.Lock()
+	s, ok := c.series[key]
+	if !ok {
+		s = &series{values: append([]string(nil), values...)}
+		c.series[key] = s
+	}
+	s.value += delta
+	c.mu.Unlock()
+}
+
+// Inc 计数加一
+func (c *CounterVec) Inc(values ...string) { c.Add(1, values...) }
+
+func (c *CounterVec) write(w *bufio.Writer) {
+	c.writeHeader(w, "counter")
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	for _, key := range sortedKeys(c.series) {
+		s := c.series[key]
+		fmt.Fprintf(w, "%s%s %s\n", c.metricName, c.labelPairs(s.values), formatFloat(s.value))
+	}
+}
+
+// GaugeVec 带标签的瞬时值；Reset 后重新 Set 可用于采集时整体刷新
+type GaugeVec struct {
+	desc
+	mu     sync.Mutex
+	series map[string]*series
+}
+
+// NewGaugeVec 创建并注册 Gauge
+func (r *Registry) NewGaugeVec(name, help string, labels ...string) *GaugeVec {
+	g := &GaugeVec{desc: desc{metricName: name, help: help, labels: labels}, series: make(map[string]*series)}
+	r.register(g)
+	return g
+}
+
+// Set 设置瞬时值
+func (g *GaugeVec) Set(v float64, values ...string) {
+	key := g.key(values)
+	g.mu.Lock()
+	s, ok := g.series[key]
+	if !ok {
+		s = &series{values: append([]string(nil), values...)}
+		g.series[key] = s
+	}
+	s.value = v
+	g.mu.Unlock()
+}
+
+// Reset 清空所有标签组合（被删除的账号等不再输出）
+func (g *GaugeVec) Reset() {
+	g.mu.Lock()
+	g.series = make(map[string]*series)
+	g.mu.Unlock()
+}
+
+func (g *GaugeVec) write(w *bufio.Writer) {
+	g.writeHeader(w, "gauge")
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	for _, key := range sortedKeys(g.series) {
+		s := g.series[key]
+		fmt.Fprintf(w, "%s%s %s\n", g.metricName, g.labelPairs(s.values), formatFloat(s.value))
+	}
+}
+
+type histogramSeries struct {
+	values []string
+	counts []uint64 // 与 buckets 一一对应（非累计）
+	count  uint64
+	sum    float64
+}
+
+// HistogramVec 带标签的直方图
+type HistogramVec struct {
+	desc
+	buckets []float64
+	mu      sync.Mutex
+	series  map[string]*histogramSeries
+}
+
+// NewHistogramVec 创建并注册直方图；buckets 需升序
+func (r *Registry) NewHistogramVec(name, help string, buckets []float64, labels ...string) *HistogramVec {
+	h := &HistogramVec{
+		desc:    desc{metricName: name, help: help, labels: labels},
+		buckets: append([]float64(nil), buckets...),
+		series:  make(map[string]*histogramSeries),
+	}
+	sort.Float64s(h.buckets)
+	r.register(h)
+	return h
+}
+
+// Observe 记录一次观测值
+func (h *HistogramVec) Observe(v float64, values ...string) {
+	key := h.key(values)
+	idx := sort.SearchFloat64s(h.buckets, v)
+	h.mu.Lock()
+	s, ok := h.series[key]
+	if !ok {
+		s = &histogramSeries{values: append([]string(nil), values...), counts: make([]uint64, len(h.buckets))}
+		h.series[key] = s
+	}
+	if idx < len(h.buckets) {
+		s.counts[idx]++
+	}
+	s.count++
+	s.sum += v
+	h.mu.Unlock()
+}
+
+func (h *HistogramVec) write(w *bufio.Writer) {
+	h.writeHeader(w, "histogram")
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	for _, key := range sortedKeys(h.series) {
+		s := h.series[key]
+		var cumulative uint64
+		for i, upper := range h.buckets {
+			cumulative += s.counts[i]
+			fmt.Fprintf(w, "%s_bucket%s %d\n", h.metricName, h.labelPairs(s.values, "le", formatFloat(upper)), cumulative)
+		}
+		fmt.Fprintf(w, "%s_bucket%s %d\n", h.metricName, h.labelPairs(s.values, "le", "+Inf"), s.count)
+		fmt.Fprintf(w, "%s_sum%s %s\n", h.metricName, h.labelPairs(s.values), formatFloat(s.sum))
+		fmt.Fprintf(w, "%s_count%s %d\n", h.metricName, h.labelPairs(s.values), s.count)
+	}
+}
+
+func sortedKeys[V any](m map[string]V) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+func formatFloat(v float64) string {
+	switch {
+	case math.IsInf(v, 1):
+		return "+Inf"
+	case math.IsInf(v, -1):
+		return "-Inf"
+	case math.IsNaN(v):
+		return "NaN"
+	}
+	return strconv.FormatFloat(v, 'g', -1, 64)
+}
+
+var (
+	helpReplacer  = strings.NewReplacer(`\`, `\\`, "\n", `\n`)
+	labelReplacer = strings.NewReplacer(`\`, `\\`, "\n", `\n`, `"`, `\"`)
+)
+
+func escapeHelp(s string) string       { return helpReplacer.Replace(s) }
+func escapeLabelValue(s string) string { return labelReplacer.Replace(s) }
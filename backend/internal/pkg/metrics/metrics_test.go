@@ -0,0 +1,52 @@
+package metrics
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestRegistryWriteText(t *testing.T) {
+	r := NewRegistry()
+	requests := r.NewCounterVec("test_requests_total", "Total requests.", "platform", "status")
+	slots := r.NewGaugeVec("test_slots", "Slots in use.", "account")
+	latency := r.NewHistogramVec("test_latency_seconds", "Latency.", []float64{1, 5}, "platform")
+
+	requests.Inc("openai", "200")
+	requests.Add(2, "openai", "200")
+	requests.Inc("anthropic", "500")
+	slots.Set(3, `a"b`)
+	latency.Observe(0.5, "openai")
+	latency.Observe(3, "openai")
+	latency.Observe(10, "openai")
+
+	var buf bytes.Buffer
+	require.NoError(t, r.WriteText(&buf))
+	out := buf.String()
+
+	require.Contains(t, out, "# TYPE test_requests_total counter\n")
+	require.Contains(t, out, `test_requests_total{platform="openai",status="200"} 3`+"\n")
+	require.Contains(t, out, `test_requests_total{platform="anthropic",status="500"} 1`+"\n")
+	require.Contains(t, out, `test_slots{account="a\"b"} 3`+"\n")
+	require.Contains(t, out, `test_latency_seconds_bucket{platform="openai",le="1"} 1`+"\n")
+	require.Contains(t, out, `test_latency_seconds_bucket{platform="openai",le="5"} 2`+"\n")
+	require.Contains(t, out, `test_latency_seconds_bucket{platform="openai",le="+Inf"} 3`+"\n")
+	require.Contains(t, out, `test_latency_seconds_sum{platform="openai"} 13.5`+"\n")
+	require.Contains(t, out, `test_latency_seconds_count{platform="openai"} 3`+"\n")
+
+	// 注册顺序输出
+	require.Less(t, strings.Index(out, "test_requests_total"), strings.Index(out, "test_slots"))
+
+	slots.Reset()
+	buf.Reset()
+	require.NoError(t, r.WriteText(&buf))
+	require.NotContains(t, buf.String(), `test_slots{`)
+}
+
+func TestRegistryDuplicatePanics(t *testing.T) {
+	r := NewRegistry()
+	r.NewCounterVec("dup_total", "dup")
+	require.Panics(t, func() { r.NewGaugeVec("dup_total", "dup") })
+}
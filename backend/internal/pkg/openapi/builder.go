@@ -0,0 +1,283 @@
+package openapi
+
+import (
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"unicode"
+)
+
+// Route 已注册的路由
+type Route struct {
+	Method string
+	// Path gin 风格路径（:param / *wildcard）
+	Path string
+	// Handler 处理函数全名（gin RouteInfo.Handler），用于匹配兼容层接口结构与生成摘要
+	Handler string
+}
+
+// Options 文档元信息
+type Options struct {
+	Title       string
+	Version     string
+	Description string
+}
+
+// 接口分组
+const (
+	TagGateway = "gateway"
+	TagGemini  = "gemini"
+	TagAuth    = "auth"
+	TagUser    = "user"
+	TagAdmin   = "admin"
+	TagBilling = "billing"
+	TagSystem  = "system"
+)
+
+// 认证方式名称
+const (
+	SecurityBearerAuth   = "bearerAuth"
+	SecurityAdminAPIKey  = "adminApiKey"
+	SecurityAPIKeyHeader = "apiKeyHeader"
+	SecurityAPIKeyBearer = "apiKeyBearer"
+	SecurityGoogAPIKey   = "googApiKey"
+	SecurityAPIKeyQuery  = "apiKeyQuery"
+)
+
+var tagDescriptions = []Tag{
+	{Name: TagGateway, Description: "Claude / OpenAI compatible gateway endpoints (API key)"},
+	{Name: TagGemini, Description: "Gemini native compatible endpoints (API key)"},
+	{Name: TagAuth, Description: "Login, registration and session endpoints"},
+	{Name: TagUser, Description: "User console endpoints (JWT)"},
+	{Name: TagAdmin, Description: "Admin endpoints (admin JWT or admin API key)"},
+	{Name: TagBilling, Description: "Billing provider webhooks"},
+	{Name: TagSystem, Description: "Health, status and metadata endpoints"},
+}
+
+func securitySchemes() map[string]*SecurityScheme {
+	return map[string]*SecurityScheme{
+		SecurityBearerAuth:   {Type: "http", Scheme: "bearer", BearerFormat: "JWT", Description: "Access token returned by /api/v1/auth/login"},
+		SecurityAdminAPIKey:  {Type: "apiKey", In: "header", Name: "x-api-key", Description: "Admin API key"},
+		SecurityAPIKeyHeader: {Type: "apiKey", In: "header", Name: "x-api-key", Description: "Gateway API key"},
+		SecurityAPIKeyBearer: {Type: "http", Scheme: "bearer", Description: "Gateway API key sent as a bearer token"},
+		SecurityGoogAPIKey:   {Type: "apiKey", In: "header", Name: "x-goog-api-key", Description: "Gateway API key (Gemini SDK style)"},
+		SecurityAPIKeyQuery:  {Type: "apiKey", In: "query", Name: "key", Description: "Gateway API key as query parameter (Gemini SDK style)"},
+	}
+}
+
+func requirements(names ...string) []map[string][]string {
+	out := make([]map[string][]string, 0, len(names))
+	for _, name := range names {
+		out = append(out, map[string][]string{name: {}})
+	}
+	return out
+}
+
+// routeCategory 路由所属分组、认证方式与响应格式
+type routeCategory struct {
+	tag      string
+	security []map[string][]string
+	// envelope 是否使用 {code, message, data} 统一响应格式
+	envelope bool
+}
+
+func classify(path string) routeCategory {
+	switch {
+	case strings.HasPrefix(path, "/api/v1/admin"):
+		return routeCategory{tag: TagAdmin, security: requirements(SecurityBearerAuth, SecurityAdminAPIKey), envelope: true}
+	case strings.HasPrefix(path, "/api/v1/auth"):
+		return routeCategory{tag: TagAuth, envelope: true}
+	case strings.HasPrefix(path, "/api/v1/stripe"):
+		return routeCategory{tag: TagBilling}
+	case strings.HasPrefix(path, "/api/v1/"):
+		return routeCategory{tag: TagUser, security: requirements(SecurityBearerAuth), envelope: true}
+	case strings.HasPrefix(path, "/v1beta/"), strings.HasPrefix(path, "/antigravity/v1beta/"):
+		return routeCategory{tag: TagGemini, security: requirements(SecurityGoogAPIKey, SecurityAPIKeyQuery, SecurityAPIKeyBearer)}
+	case strings.HasPrefix(path, "/v1/"), strings.HasPrefix(path, "/antigravity/"),
+		path == "/responses", path == "/chat/completions":
+		return routeCategory{tag: TagGateway, security: requirements(SecurityAPIKeyHeader, SecurityAPIKeyBearer)}
+	default:
+		return routeCategory{tag: TagSystem}
+	}
+}
+
+// Build 根据路由生成 OpenAPI 文档；同一路由表多次生成结果一致
+func Build(opts Options, routes []Route) *Document {
+	doc := &Document{
+		OpenAPI: Version,
+		Info:    Info{Title: opts.Title, Version: opts.Version, Description: opts.Description},
+		Paths:   make(map[string]*PathItem),
+		Components: Components{
+			Schemas:         componentSchemas(),
+			SecuritySchemes: securitySchemes(),
+		},
+	}
+
+	sorted := append([]Route(nil), routes...)
+	sort.Slice(sorted, func(i, j int) bool {
+		if sorted[i].Path != sorted[j].Path {
+			return sorted[i].Path < sorted[j].Path
+		}
+		return sorted[i].Method < sorted[j].Method
+	})
+
+	usedTags := make(map[string]bool)
+	usedIDs := make(map[string]int)
+	for _, route := range sorted {
+		path, params := convertPath(route.Path)
+		item := doc.Paths[path]
+		if item == nil {
+			item = &PathItem{}
+		}
+		if !item.set(route.Method, buildOperation(route, path, params, usedIDs)) {
+			continue
+		}
+		doc.Paths[path] = item
+		usedTags[classify(route.Path).tag] = true
+	}
+	for _, tag := range tagDescriptions {
+		if usedTags[tag.Name] {
+			doc.Tags = append(doc.Tags, tag)
+		}
+	}
+	return doc
+}
+
+func buildOperation(route Route, path string, params []Parameter, usedIDs map[string]int) *Operation {
+	category := classify(route.Path)
+	handlerName := shortHandlerName(route.Handler)
+	op := &Operation{
+		OperationID: uniqueOperationID(operationID(route.Method, path), usedIDs),
+		Summary:     handlerName,
+		Tags:        []string{category.tag},
+		Parameters:  params,
+		Security:    category.security,
+		Responses:   make(map[string]*Response),
+	}
+
+	if spec, ok := compatOperations[handlerName]; ok {
+		op.Summary = spec.summary
+		if spec.request != "" {
+			op.RequestBody = &RequestBody{Required: true, Content: jsonContent(Ref(spec.request))}
+		}
+		ok := &Response{Description: "Successful response", Content: jsonContent(Ref(spec.response))}
+		if spec.stream {
+			ok.Content["text/event-stream"] = &MediaType{Schema: &Schema{Type: "string", Description: "Server-sent events, returned when the request sets stream=true"}}
+		}
+		op.Responses["200"] = ok
+		op.Responses["default"] = &Response{Description: "Error response", Content: jsonContent(Ref(spec.errorSchema))}
+		return op
+	}
+
+	if category.envelope {
+		if hasBody(route.Method) {
+			op.RequestBody = &RequestBody{Content: jsonContent(&Schema{Type: "object"})}
+		}
+		op.Responses["200"] = &Response{Description: "Successful response", Content: jsonContent(Ref(SchemaAPIResponse))}
+		op.Responses["default"] = &Response{Description: "Error response", Content: jsonContent(Ref(SchemaAPIResponse))}
+		return op
+	}
+	op.Responses["200"] = &Response{Description: "Successful response"}
+	return op
+}
+
+func jsonContent(schema *Schema) map[string]*MediaType {
+	return map[string]*MediaType{"application/json": {Schema: schema}}
+}
+
+func hasBody(method string) bool {
+	return method == http.MethodPost || method == http.MethodPut || method == http.MethodPatch
+}
+
+// set 设置方法对应的操作；不支持的方法返回 false
+func (p *PathItem) set(method string, op *Operation) bool {
+	switch method {
+	case http.MethodGet:
+		p.Get = op
+	case http.MethodPut:
+		p.Put = op
+	case http.MethodPost:
+		p.Post = op
+	case http.MethodDelete:
+		p.Delete = op
+	case http.MethodPatch:
+		p.Patch = op
+	case http.MethodHead:
+		p.Head = op
+	default:
+		return false
+	}
+	return true
+}
+
+// convertPath 将 gin 路径（/users/:id、/models/*modelAction）转换为 OpenAPI 路径模板并提取路径参数
+func convertPath(path string) (string, []Parameter) {
+	segments := strings.Split(path, "/")
+	var params []Parameter
+	for i, seg := range segments {
+		if len(seg) < 2 || (seg[0] != ':' && seg[0] != '*') {
+			continue
+		}
+		name := seg[1:]
+		param := Parameter{Name: name, In: "path", Required: true, Schema: &Schema{Type: "string"}}
+		if seg[0] == '*' {
+			param.Description = "Remaining path, may contain '/' and ':' (e.g. gemini-2.5-pro:generateContent)"
+		}
+		params = append(params, param)
+		segments[i] = "{" + name + "}"
+	}
+	return strings.Join(segments, "/"), params
+}
+
+// operationID 由方法与路径生成驼峰形式的 operationId，如 GET /api/v1/admin/users/{id} -> getApiV1AdminUsersById
+func operationID(method, path string) string {
+	var b strings.Builder
+	b.WriteString(strings.ToLower(method))
+	for _, seg := range strings.Split(path, "/") {
+		if seg == "" {
+			continue
+		}
+		if strings.HasPrefix(seg, "{") && strings.HasSuffix(seg, "}") {
+			b.WriteString("By")
+			seg = strings.Trim(seg, "{}")
+		}
+		upper := true
+		for _, r := range seg {
+			if !unicode.IsLetter(r) && !unicode.IsDigit(r) {
+				upper = true
+				continue
+			}
+			if upper {
+				r = unicode.ToUpper(r)
+				upper = false
+			}
+			b.WriteRune(r)
+		}
+	}
+	return b.String()
+}
+
+func uniqueOperationID(id string, used map[string]int) string {
+	used[id]++
+	if n := used[id]; n > 1 {
+		return id + "_" + strconv.Itoa(n)
+	}
+	return id
+}
+
+// shortHandlerName 提取处理函数的“类型.方法”名，如
+// github.com/Wei-Shaw/sub2api/internal/handler.(*GatewayHandler).Messages-fm -> GatewayHandler.Messages；
+// 匿名函数返回空字符串
+func shortHandlerName(full string) string {
+	name := full[strings.LastIndex(full, "/")+1:]
+	name = strings.TrimSuffix(name, "-fm")
+	if !strings.Contains(name, "(*") {
+		return ""
+	}
+	name = strings.NewReplacer("(*", "", ")", "").Replace(name)
+	if i := strings.Index(name, "."); i >= 0 {
+		name = name[i+1:]
+	}
+	return name
+}
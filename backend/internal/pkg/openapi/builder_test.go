@@ -0,0 +1,118 @@
+package openapi
+
+import (
+	"encoding/json"
+	"net/http"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+const handlerPkg = "github.com/Wei-Shaw/sub2api/internal/handler"
+
+func testRoutes() []Route {
+	return []Route{
+		{Method: http.MethodPost, Path: "/v1/messages", Handler: handlerPkg + ".(*GatewayHandler).Messages-fm"},
+		{Method: http.MethodPost, Path: "/antigravity/v1/messages", Handler: handlerPkg + ".(*GatewayHandler).Messages-fm"},
+		{Method: http.MethodPost, Path: "/v1beta/models/*modelAction", Handler: handlerPkg + ".(*GatewayHandler).GeminiV1BetaModels-fm"},
+		{Method: http.MethodGet, Path: "/api/v1/admin/users/:id", Handler: handlerPkg + "/admin.(*UserHandler).GetByID-fm"},
+		{Method: http.MethodPut, Path: "/api/v1/admin/users/:id", Handler: handlerPkg + "/admin.(*UserHandler).Update-fm"},
+		{Method: http.MethodGet, Path: "/health", Handler: "github.com/Wei-Shaw/sub2api/internal/server/routes.RegisterCommonRoutes.func1"},
+		{Method: http.MethodOptions, Path: "/v1/messages", Handler: "cors"},
+	}
+}
+
+func TestConvertPath(t *testing.T) {
+	path, params := convertPath("/api/v1/admin/groups/:id/accounts/:accountId")
+	require.Equal(t, "/api/v1/admin/groups/{id}/accounts/{accountId}", path)
+	require.Len(t, params, 2)
+	require.Equal(t, "id", params[0].Name)
+	require.Equal(t, "path", params[0].In)
+	require.True(t, params[0].Required)
+
+	path, params = convertPath("/v1beta/models/*modelAction")
+	require.Equal(t, "/v1beta/models/{modelAction}", path)
+	require.Len(t, params, 1)
+	require.NotEmpty(t, params[0].Description)
+
+	path, params = convertPath("/health")
+	require.Equal(t, "/health", path)
+	require.Empty(t, params)
+}
+
+func TestShortHandlerName(t *testing.T) {
+	require.Equal(t, "GatewayHandler.Messages", shortHandlerName(handlerPkg+".(*GatewayHandler).Messages-fm"))
+	require.Equal(t, "UserHandler.GetByID", shortHandlerName(handlerPkg+"/admin.(*UserHandler).GetByID-fm"))
+	require.Equal(t, "", shortHandlerName("github.com/Wei-Shaw/sub2api/internal/server/routes.RegisterCommonRoutes.func1"))
+}
+
+func TestOperationID(t *testing.T) {
+	require.Equal(t, "getApiV1AdminUsersById", operationID(http.MethodGet, "/api/v1/admin/users/{id}"))
+	require.Equal(t, "postV1ChatCompletions", operationID(http.MethodPost, "/v1/chat/completions"))
+
+	used := map[string]int{}
+	require.Equal(t, "getHealth", uniqueOperationID("getHealth", used))
+	require.Equal(t, "getHealth_2", uniqueOperationID("getHealth", used))
+}
+
+func TestClassify(t *testing.T) {
+	admin := classify("/api/v1/admin/users")
+	require.Equal(t, TagAdmin, admin.tag)
+	require.True(t, admin.envelope)
+	require.Len(t, admin.security, 2)
+
+	require.Equal(t, TagAuth, classify("/api/v1/auth/login").tag)
+	require.Empty(t, classify("/api/v1/auth/login").security)
+	require.Equal(t, TagUser, classify("/api/v1/keys").tag)
+	require.Equal(t, TagGemini, classify("/v1beta/models").tag)
+	require.Equal(t, TagGateway, classify("/v1/messages").tag)
+	require.Equal(t, TagGateway, classify("/responses").tag)
+	require.Equal(t, TagSystem, classify("/health").tag)
+}
+
+func TestBuild(t *testing.T) {
+	doc := Build(Options{Title: "Sub2API", Version: "1.2.3"}, testRoutes())
+	require.Equal(t, Version, doc.OpenAPI)
+	require.Equal(t, "1.2.3", doc.Info.Version)
+
+	messages := doc.Paths["/v1/messages"]
+	require.NotNil(t, messages)
+	require.NotNil(t, messages.Post)
+	require.Equal(t, TagGateway, messages.Post.Tags[0])
+	require.Equal(t, "#/components/schemas/"+SchemaAnthropicMessagesRequest, messages.Post.RequestBody.Content["application/json"].Schema.Ref)
+	require.Contains(t, messages.Post.Responses["200"].Content, "text/event-stream")
+	require.Equal(t, "#/components/schemas/"+SchemaAnthropicError, messages.Post.Responses["default"].Content["application/json"].Schema.Ref)
+	// OPTIONS 等不支持的方法不出现在文档中
+	require.Nil(t, messages.Get)
+
+	gemini := doc.Paths["/v1beta/models/{modelAction}"].Post
+	require.Equal(t, "#/components/schemas/"+SchemaGeminiGenerateContentRequest, gemini.RequestBody.Content["application/json"].Schema.Ref)
+
+	user := doc.Paths["/api/v1/admin/users/{id}"]
+	require.Equal(t, "#/components/schemas/"+SchemaAPIResponse, user.Get.Responses["200"].Content["application/json"].Schema.Ref)
+	require.Nil(t, user.Get.RequestBody)
+	require.NotNil(t, user.Put.RequestBody)
+	require.NotEqual(t, user.Get.OperationID, user.Put.OperationID)
+
+	// 所有 $ref 都能在 components 中找到
+	for name, op := range compatOperations {
+		for _, ref := range []string{op.request, op.response, op.errorSchema} {
+			if ref != "" {
+				require.Contains(t, doc.Components.Schemas, ref, name)
+			}
+		}
+	}
+
+	tags := make([]string, 0, len(doc.Tags))
+	for _, tag := range doc.Tags {
+		tags = append(tags, tag.Name)
+	}
+	require.Equal(t, []string{TagGateway, TagGemini, TagAdmin, TagSystem}, tags)
+
+	raw, err := json.Marshal(doc)
+	require.NoError(t, err)
+	again, err := json.Marshal(Build(Options{Title: "Sub2API", Version: "1.2.3"}, testRoutes()))
+	require.NoError(t, err)
+	require.JSONEq(t, string(raw), string(again))
+	require.NotContains(t, string(raw), `"security":null`)
+}
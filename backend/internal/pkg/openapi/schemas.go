@@ -0,0 +1,338 @@
+package openapi
+
+// 组件结构名称
+const (
+	SchemaAPIResponse = "APIResponse"
+
+	SchemaAnthropicMessagesRequest     = "AnthropicMessagesRequest"
+	SchemaAnthropicMessagesResponse    = "AnthropicMessagesResponse"
+	SchemaAnthropicCountTokensRequest  = "AnthropicCountTokensRequest"
+	SchemaAnthropicCountTokensResponse = "AnthropicCountTokensResponse"
+	SchemaAnthropicError               = "AnthropicError"
+	SchemaModelList                    = "ModelList"
+	SchemaGatewayUsage                 = "GatewayUsage"
+
+	SchemaOpenAIChatCompletionRequest  = "OpenAIChatCompletionRequest"
+	SchemaOpenAIChatCompletionResponse = "OpenAIChatCompletionResponse"
+	SchemaOpenAIResponsesRequest       = "OpenAIResponsesRequest"
+	SchemaOpenAIResponsesResponse      = "OpenAIResponsesResponse"
+	SchemaOpenAIError                  = "OpenAIError"
+
+	SchemaGeminiGenerateContentRequest  = "GeminiGenerateContentRequest"
+	SchemaGeminiGenerateContentResponse = "GeminiGenerateContentResponse"
+	SchemaGeminiModel                   = "GeminiModel"
+	SchemaGeminiModelList               = "GeminiModelList"
+	SchemaGeminiError                   = "GeminiError"
+)
+
+// compatOperation 兼容层接口的请求/响应结构
+type compatOperation struct {
+	summary     string
+	request     string
+	response    string
+	errorSchema string
+	// stream 是否支持 SSE 流式响应
+	stream bool
+}
+
+// compatOperations 以处理函数“类型.方法”名索引，同一处理函数挂在多个路径（如 /v1 与 /antigravity/v1）时共用结构
+var compatOperations = map[string]compatOperation{
+	"GatewayHandler.Messages": {
+		summary: "Create a message (Anthropic Messages API)", request: SchemaAnthropicMessagesRequest,
+		response: SchemaAnthropicMessagesResponse, errorSchema: SchemaAnthropicError, stream: true,
+	},
+	"GatewayHandler.CountTokens": {
+		summary: "Count input tokens (Anthropic Messages API)", request: SchemaAnthropicCountTokensRequest,
+		response: SchemaAnthropicCountTokensResponse, errorSchema: SchemaAnthropicError,
+	},
+	"GatewayHandler.Models": {
+		summary: "List models available to the API key", response: SchemaModelList, errorSchema: SchemaAnthropicError,
+	},
+	"GatewayHandler.AntigravityModels": {
+		summary: "List Antigravity models", response: SchemaModelList, errorSchema: SchemaAnthropicError,
+	},
+	"GatewayHandler.Usage": {
+		summary: "Get remaining balance/subscription quota and usage of the API key", response: SchemaGatewayUsage, errorSchema: SchemaAnthropicError,
+	},
+	"OpenAIGatewayHandler.ChatCompletions": {
+		summary: "Create a chat completion (OpenAI Chat Completions API)", request: SchemaOpenAIChatCompletionRequest,
+		response: SchemaOpenAIChatCompletionResponse, errorSchema: SchemaOpenAIError, stream: true,
+	},
+	"OpenAIGatewayHandler.Responses": {
+		summary: "Create a model response (OpenAI Responses API)", request: SchemaOpenAIResponsesRequest,
+		response: SchemaOpenAIResponsesResponse, errorSchema: SchemaOpenAIError, stream: true,
+	},
+	"GatewayHandler.GeminiV1BetaListModels": {
+		summary: "List models (Gemini API)", response: SchemaGeminiModelList, errorSchema: SchemaGeminiError,
+	},
+	"GatewayHandler.GeminiV1BetaGetModel": {
+		summary: "Get a model (Gemini API)", response: SchemaGeminiModel, errorSchema: SchemaGeminiError,
+	},
+	"GatewayHandler.GeminiV1BetaModels": {
+		summary:  "Generate content (Gemini API, {model}:generateContent / {model}:streamGenerateContent / {model}:countTokens)",
+		request:  SchemaGeminiGenerateContentRequest,
+		response: SchemaGeminiGenerateContentResponse, errorSchema: SchemaGeminiError, stream: true,
+	},
+}
+
+func str(description string) *Schema {
+	return &Schema{Type: "string", Description: description}
+}
+
+func integer(description string) *Schema {
+	return &Schema{Type: "integer", Description: description}
+}
+
+func number(description string) *Schema {
+	return &Schema{Type: "number", Description: description}
+}
+
+func boolean(description string) *Schema {
+	return &Schema{Type: "boolean", Description: description}
+}
+
+func array(items *Schema, description string) *Schema {
+	return &Schema{Type: "array", Items: items, Description: description}
+}
+
+// object 开放对象：兼容层透传上游字段，未列出的字段同样允许
+func object(description string, properties map[string]*Schema, required ...string) *Schema {
+	return &Schema{Type: "object", Description: description, Properties: properties, Required: required}
+}
+
+func enum(description string, values ...any) *Schema {
+	return &Schema{Type: "string", Description: description, Enum: values}
+}
+
+// componentSchemas 兼容层请求/响应结构（只列出常用字段，其余字段按上游协议透传）
+func componentSchemas() map[string]*Schema {
+	anthropicUsage := object("Token usage", map[string]*Schema{
+		"input_tokens":                integer(""),
+		"output_tokens":               integer(""),
+		"cache_creation_input_tokens": integer(""),
+		"cache_read_input_tokens":     integer(""),
+	})
+	anthropicMessage := object("Conversation turn", map[string]*Schema{
+		"role":    enum("", "user", "assistant"),
+		"content": {OneOf: []*Schema{str("Plain text content"), array(object("Content block (text, image, tool_use, tool_result, thinking, ...)", map[string]*Schema{"type": str("")}, "type"), "")}},
+	}, "role", "content")
+	anthropicTool := object("Tool definition", map[string]*Schema{
+		"name":         str(""),
+		"description":  str(""),
+		"input_schema": object("JSON Schema of the tool input", nil),
+	}, "name")
+	chatMessage := object("Chat message", map[string]*Schema{
+		"role":         enum("", "system", "developer", "user", "assistant", "tool"),
+		"content":      {OneOf: []*Schema{str(""), array(object("Content part", map[string]*Schema{"type": str("")}), "")}},
+		"name":         str(""),
+		"tool_calls":   array(object("Tool call", nil), ""),
+		"tool_call_id": str(""),
+	}, "role")
+	openAIUsage := object("Token usage", map[string]*Schema{
+		"prompt_tokens":     integer(""),
+		"completion_tokens": integer(""),
+		"total_tokens":      integer(""),
+		"input_tokens":      integer(""),
+		"output_tokens":     integer(""),
+	})
+	geminiContent := object("Content", map[string]*Schema{
+		"role":  enum("", "user", "model"),
+		"parts": array(object("Part (text, inlineData, functionCall, functionResponse, ...)", map[string]*Schema{"text": str("")}), ""),
+	}, "parts")
+	model := object("Model", map[string]*Schema{
+		"id":           str(""),
+		"object":       str(""),
+		"type":         str(""),
+		"display_name": str(""),
+		"created_at":   str(""),
+	}, "id")
+	geminiModel := object("Gemini model", map[string]*Schema{
+		"name":                       str("e.g. models/gemini-2.5-pro"),
+		"displayName":                str(""),
+		"supportedGenerationMethods": array(str(""), ""),
+	}, "name")
+	usageStats := object("Usage statistics", map[string]*Schema{
+		"requests":              integer(""),
+		"input_tokens":          integer(""),
+		"output_tokens":         integer(""),
+		"cache_creation_tokens": integer(""),
+		"cache_read_tokens":     integer(""),
+		"total_tokens":          integer(""),
+		"cost":                  number("Standard cost in USD"),
+		"actual_cost":           number("Charged cost in USD"),
+	})
+
+	return map[string]*Schema{
+		SchemaAPIResponse: object("Standard response envelope of /api/v1 endpoints", map[string]*Schema{
+			"code":     integer("0 on success, HTTP status code on error"),
+			"message":  str(""),
+			"reason":   str("Machine readable error reason"),
+			"metadata": {Type: "object", AdditionalProperties: str("")},
+			"data":     {Description: "Response payload"},
+		}, "code", "message"),
+
+		SchemaAnthropicMessagesRequest: object("Anthropic Messages API request", map[string]*Schema{
+			"model":          str(""),
+			"messages":       array(anthropicMessage, ""),
+			"max_tokens":     integer(""),
+			"system":         {OneOf: []*Schema{str(""), array(object("System text block", nil), "")}},
+			"stream":         boolean(""),
+			"temperature":    number(""),
+			"top_p":          number(""),
+			"top_k":          integer(""),
+			"stop_sequences": array(str(""), ""),
+			"tools":          array(anthropicTool, ""),
+			"tool_choice":    object("", nil),
+			"thinking":       object("Extended thinking configuration", map[string]*Schema{"type": str(""), "budget_tokens": integer("")}),
+			"metadata":       object("", map[string]*Schema{"user_id": str("")}),
+		}, "model", "messages", "max_tokens"),
+		SchemaAnthropicMessagesResponse: object("Anthropic Messages API response", map[string]*Schema{
+			"id":            str(""),
+			"type":          enum("", "message"),
+			"role":          enum("", "assistant"),
+			"model":         str(""),
+			"content":       array(object("Content block", map[string]*Schema{"type": str(""), "text": str("")}, "type"), ""),
+			"stop_reason":   str(""),
+			"stop_sequence": str(""),
+			"usage":         anthropicUsage,
+		}, "id", "type", "role", "content", "model"),
+		SchemaAnthropicCountTokensRequest: object("Anthropic count_tokens request", map[string]*Schema{
+			"model":    str(""),
+			"messages": array(anthropicMessage, ""),
+			"system":   {OneOf: []*Schema{str(""), array(object("System text block", nil), "")}},
+			"tools":    array(anthropicTool, ""),
+		}, "model", "messages"),
+		SchemaAnthropicCountTokensResponse: object("Anthropic count_tokens response", map[string]*Schema{
+			"input_tokens": integer(""),
+		}, "input_tokens"),
+		SchemaAnthropicError: object("Anthropic style error", map[string]*Schema{
+			"type": enum("", "error"),
+			"error": object("", map[string]*Schema{
+				"type":    str("e.g. authentication_error, rate_limit_error, api_error"),
+				"message": str(""),
+			}, "type", "message"),
+		}, "type", "error"),
+		SchemaModelList: object("Model list", map[string]*Schema{
+			"object": enum("", "list"),
+			"data":   array(model, ""),
+		}, "object", "data"),
+		SchemaGatewayUsage: object("API key quota and usage", map[string]*Schema{
+			"isValid":      boolean(""),
+			"planName":     str(""),
+			"remaining":    number("Remaining balance or subscription quota in USD"),
+			"unit":         str(""),
+			"balance":      number("Wallet balance (balance mode only)"),
+			"subscription": object("Subscription limits and usage (subscription mode only)", nil),
+			"usage": object("", map[string]*Schema{
+				"today":               usageStats,
+				"total":               usageStats,
+				"average_duration_ms": number(""),
+				"rpm":                 integer(""),
+				"tpm":                 integer(""),
+			}),
+		}, "isValid", "remaining", "unit"),
+
+		SchemaOpenAIChatCompletionRequest: object("OpenAI Chat Completions request", map[string]*Schema{
+			"model":                 str(""),
+			"messages":              array(chatMessage, ""),
+			"stream":                boolean(""),
+			"stream_options":        object("", map[string]*Schema{"include_usage": boolean("")}),
+			"max_tokens":            integer(""),
+			"max_completion_tokens": integer(""),
+			"temperature":           number(""),
+			"top_p":                 number(""),
+			"stop":                  {OneOf: []*Schema{str(""), array(str(""), "")}},
+			"tools":                 array(object("Tool definition", nil), ""),
+			"tool_choice":           {OneOf: []*Schema{str(""), object("", nil)}},
+			"response_format":       object("", nil),
+			"reasoning_effort":      str(""),
+			"seed":                  integer(""),
+			"user":                  str(""),
+		}, "model", "messages"),
+		SchemaOpenAIChatCompletionResponse: object("OpenAI Chat Completions response", map[string]*Schema{
+			"id":      str(""),
+			"object":  enum("", "chat.completion"),
+			"created": integer(""),
+			"model":   str(""),
+			"choices": array(object("Choice", map[string]*Schema{
+				"index":         integer(""),
+				"message":       chatMessage,
+				"finish_reason": str(""),
+			}), ""),
+			"usage": openAIUsage,
+		}, "id", "object", "model", "choices"),
+		SchemaOpenAIResponsesRequest: object("OpenAI Responses API request", map[string]*Schema{
+			"model":                str(""),
+			"input":                {OneOf: []*Schema{str(""), array(object("Input item", nil), "")}},
+			"instructions":         str(""),
+			"stream":               boolean(""),
+			"max_output_tokens":    integer(""),
+			"temperature":          number(""),
+			"tools":                array(object("Tool definition", nil), ""),
+			"reasoning":            object("", map[string]*Schema{"effort": str(""), "summary": str("")}),
+			"previous_response_id": str(""),
+			"store":                boolean(""),
+			"metadata":             {Type: "object", AdditionalProperties: str("")},
+		}, "model"),
+		SchemaOpenAIResponsesResponse: object("OpenAI Responses API response", map[string]*Schema{
+			"id":         str(""),
+			"object":     enum("", "response"),
+			"created_at": integer(""),
+			"status":     str(""),
+			"model":      str(""),
+			"output":     array(object("Output item", map[string]*Schema{"type": str("")}), ""),
+			"usage":      openAIUsage,
+		}, "id", "object", "status", "model", "output"),
+		SchemaOpenAIError: object("OpenAI style error", map[string]*Schema{
+			"error": object("", map[string]*Schema{
+				"message": str(""),
+				"type":    str(""),
+				"code":    str(""),
+				"param":   str(""),
+			}, "message", "type"),
+		}, "error"),
+
+		SchemaGeminiGenerateContentRequest: object("Gemini generateContent request", map[string]*Schema{
+			"contents":          array(geminiContent, ""),
+			"systemInstruction": geminiContent,
+			"generationConfig": object("", map[string]*Schema{
+				"temperature":     number(""),
+				"topP":            number(""),
+				"topK":            integer(""),
+				"maxOutputTokens": integer(""),
+				"stopSequences":   array(str(""), ""),
+				"thinkingConfig":  object("", nil),
+			}),
+			"tools":          array(object("Tool", nil), ""),
+			"toolConfig":     object("", nil),
+			"safetySettings": array(object("Safety setting", nil), ""),
+		}, "contents"),
+		SchemaGeminiGenerateContentResponse: object("Gemini generateContent response", map[string]*Schema{
+			"candidates": array(object("Candidate", map[string]*Schema{
+				"content":      geminiContent,
+				"finishReason": str(""),
+				"index":        integer(""),
+			}), ""),
+			"usageMetadata": object("", map[string]*Schema{
+				"promptTokenCount":        integer(""),
+				"candidatesTokenCount":    integer(""),
+				"totalTokenCount":         integer(""),
+				"cachedContentTokenCount": integer(""),
+				"thoughtsTokenCount":      integer(""),
+			}),
+			"modelVersion": str(""),
+			"totalTokens":  integer("countTokens response only"),
+		}),
+		SchemaGeminiModel: geminiModel,
+		SchemaGeminiModelList: object("Gemini model list", map[string]*Schema{
+			"models": array(geminiModel, ""),
+		}, "models"),
+		SchemaGeminiError: object("Google API style error", map[string]*Schema{
+			"error": object("", map[string]*Schema{
+				"code":    integer(""),
+				"message": str(""),
+				"status":  str(""),
+			}, "code", "message"),
+		}, "error"),
+	}
+}
@@ -0,0 +1,94 @@
+package parquet
+
+import "encoding/binary"
+
+// Thrift compact protocol 类型编码
+const (
+	compactI32    = 5
+	compactI64    = 6
+	compactBinary = 8
+	compactList   = 9
+	compactStruct = 12
+)
+
+// compactWriter 最小的 Thrift compact protocol 编码器，仅支持 Parquet 元数据用到的类型
+type compactWriter struct {
+	buf []byte
+	// lastField 每层 struct 上一个字段 ID，用于字段 ID 差值编码
+	lastField []int16
+}
+
+func newCompactWriter() *compactWriter {
+	return &compactWriter{lastField: []int16{0}}
+}
+
+func (t *compactWriter) uvarint(v uint64) {
+	t.buf = binary.AppendUvarint(t.buf, v)
+}
+
+func (t *compactWriter) fieldHeader(typ byte, id int16) {
+	top := len(t.lastField) - 1
+	delta := id - t.lastField[top]
+	if delta > 0 && delta <= 15 {
+		t.buf = append(t.buf, byte(delta)<<4|typ)
+	} else {
+		t.buf = append(t.buf, typ)
+		t.uvarint(uint64(uint16((id << 1) ^ (id >> 15))))
+	}
+	t.lastField[top] = id
+}
+
+func (t *compactWriter) i32(id int16, v int32) {
+	t.fieldHeader(compactI32, id)
+	t.elemI32(v)
+}
+
+func (t *compactWriter) i64(id int16, v int64) {
+	t.fieldHeader(compactI64, id)
+	t.uvarint(uint64((v << 1) ^ (v >> 63)))
+}
+
+func (t *compactWriter) binary(id int16, s string) {
+	t.fieldHeader(compactBinary, id)
+	t.elemBinary(s)
+}
+
+func (t *compactWriter) structBegin(id int16) {
+	t.fieldHeader(compactStruct, id)
+	t.lastField = append(t.lastField, 0)
+}
+
+// elemStructBegin 列表中的 struct 元素（无字段头）
+func (t *compactWriter) elemStructBegin() {
+	t.lastField = append(t.lastField, 0)
+}
+
+func (t *compactWriter) structEnd() {
+	t.buf = append(t.buf, 0)
+	t.lastField = t.lastField[:len(t.lastField)-1]
+}
+
+func (t *compactWriter) listBegin(id int16, elemType byte, size int) {
+	t.fieldHeader(compactList, id)
+	if size < 15 {
+		t.buf = append(t.buf, byte(size)<<4|elemType)
+		return
+	}
+	t.buf = append(t.buf, 0xF0|elemType)
+	t.uvarint(uint64(size))
+}
+
+func (t *compactWriter) elemI32(v int32) {
+	t.uvarint(uint64(uint32((v << 1) ^ (v >> 31))))
+}
+
+func (t *compactWriter) elemBinary(s string) {
+	t.uvarint(uint64(len(s)))
+	t.buf = append(t.buf, s...)
+}
+
+// finish 结束顶层 struct 并返回编码结果
+func (t *compactWriter) finish() []byte {
+	t.buf = append(t.buf, 0)
+	return t.buf
+}
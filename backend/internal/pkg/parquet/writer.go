@@ -0,0 +1,312 @@
+// Package parquet 提供一个无第三方依赖的最小 Parquet 写入器。
+//
+// 只覆盖导出报表所需的子集：扁平 schema、全部列为 REQUIRED、PLAIN 编码、不压缩，
+// 每个行组每列一个数据页。行组写出后即可释放内存，适合边查询边流式输出大批量数据。
+package parquet
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"time"
+)
+
+// ColumnType 列类型
+type ColumnType int
+
+const (
+	Int64 ColumnType = iota
+	Double
+	String
+	Boolean
+	// TimestampMillis 以 INT64 毫秒存储（converted_type=TIMESTAMP_MILLIS），写入值为 time.Time
+	TimestampMillis
+)
+
+// Column 列定义
+type Column struct {
+	Name string
+	Type ColumnType
+}
+
+// Parquet 物理类型 / converted type / 编码等枚举（见 parquet-format parquet.thrift）
+const (
+	physicalBoolean   = 0
+	physicalInt64     = 2
+	physicalDouble    = 5
+	physicalByteArray = 6
+
+	convertedUTF8            = 0
+	convertedTimestampMillis = 9
+
+	repetitionRequired = 0
+	encodingPlain      = 0
+	encodingRLE        = 3
+	codecUncompressed  = 0
+	pageTypeData       = 0
+)
+
+var magic = []byte("PAR1")
+
+// ErrWriterClosed 写入器已关闭
+var ErrWriterClosed = errors.New("parquet: writer closed")
+
+type columnChunkMeta struct {
+	offset    int64
+	size      int64
+	numValues int64
+}
+
+type rowGroupMeta struct {
+	numRows int64
+	size    int64
+	columns []columnChunkMeta
+}
+
+// Writer 按行组流式写出 Parquet 文件，Close 时写入文件尾元数据
+type Writer struct {
+	w         io.Writer
+	offset    int64
+	columns   []Column
+	rowGroups []rowGroupMeta
+	numRows   int64
+	createdBy string
+	started   bool
+	closed    bool
+}
+
+// NewWriter 创建写入器；createdBy 写入文件元数据（可为空）
+func NewWriter(w io.Writer, columns []Column, createdBy string) *Writer {
+	return &Writer{w: w, columns: columns, createdBy: createdBy}
+}
+
+func (pw *Writer) write(p []byte) error {
+	n, err := pw.w.Write(p)
+	pw.offset += int64(n)
+	return err
+}
+
+func (pw *Writer) writeMagic() error {
+	if pw.started {
+		return nil
+	}
+	pw.started = true
+	return pw.write(magic)
+}
+
+// WriteRowGroup 写出一个行组；每行的值按列顺序排列，类型需与列定义一致：
+// Int64 → int64/int，Double → float64，String → string，Boolean → bool，TimestampMillis → time.Time
+func (pw *Writer) WriteRowGroup(rows [][]any) error {
+	if pw.closed {
+		return ErrWriterClosed
+	}
+	if len(rows) == 0 {
+		return nil
+	}
+	for i, row := range rows {
+		if len(row) != len(pw.columns) {
+			return fmt.Errorf("parquet: row %d has %d values, want %d", i, len(row), len(pw.columns))
+		}
+	}
+	if err := pw.writeMagic(); err != nil {
+		return err
+	}
+
+	group := rowGroupMeta{numRows: int64(len(rows)), columns: make([]columnChunkMeta, 0, len(pw.columns))}
+	for ci, col := range pw.columns {
+		data, err := encodePlain(col, rows, ci)
+		if err != nil {
+			return err
+		}
+		header := encodeDataPageHeader(len(rows), len(data))
+
+		chunk := columnChunkMeta{offset: pw.offset, size: int64(len(header) + len(data)), numValues: int64(len(rows))}
+		if err := pw.write(header); err != nil {
+			return err
+		}
+		if err := pw.write(data); err != nil {
+			return err
+		}
+		group.size += chunk.size
+		group.columns = append(group.columns, chunk)
+	}
+	pw.rowGroups = append(pw.rowGroups, group)
+	pw.numRows += group.numRows
+	return nil
+}
+
+// Close 写出文件尾（FileMetaData + 长度 + 魔数），不关闭底层 io.Writer
+func (pw *Writer) Close() error {
+	if pw.closed {
+		return nil
+	}
+	pw.closed = true
+	if err := pw.writeMagic(); err != nil {
+		return err
+	}
+	footer := pw.encodeFileMetaData()
+	if err := pw.write(footer); err != nil {
+		return err
+	}
+	var length [4]byte
+	binary.LittleEndian.PutUint32(length[:], uint32(len(footer)))
+	if err := pw.write(length[:]); err != nil {
+		return err
+	}
+	return pw.write(magic)
+}
+
+func encodePlain(col Column, rows [][]any, ci int) ([]byte, error) {
+	var buf []byte
+	switch col.Type {
+	case Boolean:
+		buf = make([]byte, (len(rows)+7)/8)
+		for i, row := range rows {
+			v, ok := row[ci].(bool)
+			if !ok {
+				return nil, typeError(col, row[ci])
+			}
+			if v {
+				buf[i/8] |= 1 << (uint(i) % 8)
+			}
+		}
+	case Int64:
+		buf = make([]byte, 0, 8*len(rows))
+		for _, row := range rows {
+			var v int64
+			switch n := row[ci].(type) {
+			case int64:
+				v = n
+			case int:
+				v = int64(n)
+			default:
+				return nil, typeError(col, row[ci])
+			}
+			buf = binary.LittleEndian.AppendUint64(buf, uint64(v))
+		}
+	case TimestampMillis:
+		buf = make([]byte, 0, 8*len(rows))
+		for _, row := range rows {
+			v, ok := row[ci].(time.Time)
+			if !ok {
+				return nil, typeError(col, row[ci])
+			}
+			buf = binary.LittleEndian.AppendUint64(buf, uint64(v.UnixMilli()))
+		}
+	case Double:
+		buf = make([]byte, 0, 8*len(rows))
+		for _, row := range rows {
+			v, ok := row[ci].(float64)
+			if !ok {
+				return nil, typeError(col, row[ci])
+			}
+			buf = binary.LittleEndian.AppendUint64(buf, math.Float64bits(v))
+		}
+	case String:
+		for _, row := range rows {
+			v, ok := row[ci].(string)
+			if !ok {
+				return nil, typeError(col, row[ci])
+			}
+			buf = binary.LittleEndian.AppendUint32(buf, uint32(len(v)))
+			buf = append(buf, v...)
+		}
+	default:
+		return nil, fmt.Errorf("parquet: column %s has unsupported type %d", col.Name, col.Type)
+	}
+	return buf, nil
+}
+
+func typeError(col Column, v any) error {
+	return fmt.Errorf("parquet: column %s got unexpected value type %T", col.Name, v)
+}
+
+func physicalType(t ColumnType) int32 {
+	switch t {
+	case Boolean:
+		return physicalBoolean
+	case Double:
+		return physicalDouble
+	case String:
+		return physicalByteArray
+	default:
+		return physicalInt64
+	}
+}
+
+// encodeDataPageHeader PageHeader{type, uncompressed_page_size, compressed_page_size, data_page_header}
+func encodeDataPageHeader(numValues, dataLen int) []byte {
+	t := newCompactWriter()
+	t.i32(1, pageTypeData)
+	t.i32(2, int32(dataLen))
+	t.i32(3, int32(dataLen))
+	t.structBegin(5)
+	t.i32(1, int32(numValues))
+	t.i32(2, encodingPlain)
+	t.i32(3, encodingRLE)
+	t.i32(4, encodingRLE)
+	t.structEnd()
+	return t.finish()
+}
+
+// encodeFileMetaData FileMetaData{version, schema, num_rows, row_groups, created_by}
+func (pw *Writer) encodeFileMetaData() []byte {
+	t := newCompactWriter()
+	t.i32(1, 1)
+
+	t.listBegin(2, compactStruct, len(pw.columns)+1)
+	t.elemStructBegin()
+	t.binary(4, "schema")
+	t.i32(5, int32(len(pw.columns)))
+	t.structEnd()
+	for _, col := range pw.columns {
+		t.elemStructBegin()
+		t.i32(1, physicalType(col.Type))
+		t.i32(3, repetitionRequired)
+		t.binary(4, col.Name)
+		switch col.Type {
+		case String:
+			t.i32(6, convertedUTF8)
+		case TimestampMillis:
+			t.i32(6, convertedTimestampMillis)
+		}
+		t.structEnd()
+	}
+
+	t.i64(3, pw.numRows)
+
+	t.listBegin(4, compactStruct, len(pw.rowGroups))
+	for _, group := range pw.rowGroups {
+		t.elemStructBegin()
+		t.listBegin(1, compactStruct, len(group.columns))
+		for ci, chunk := range group.columns {
+			col := pw.columns[ci]
+			t.elemStructBegin()
+			t.i64(2, chunk.offset)
+			t.structBegin(3)
+			t.i32(1, physicalType(col.Type))
+			t.listBegin(2, compactI32, 2)
+			t.elemI32(encodingPlain)
+			t.elemI32(encodingRLE)
+			t.listBegin(3, compactBinary, 1)
+			t.elemBinary(col.Name)
+			t.i32(4, codecUncompressed)
+			t.i64(5, chunk.numValues)
+			t.i64(6, chunk.size)
+			t.i64(7, chunk.size)
+			t.i64(9, chunk.offset)
+			t.structEnd()
+			t.structEnd()
+		}
+		t.i64(2, group.size)
+		t.i64(3, group.numRows)
+		t.structEnd()
+	}
+
+	if pw.createdBy != "" {
+		t.binary(6, pw.createdBy)
+	}
+	return t.finish()
+}
@@ -0,0 +1,93 @@
+package parquet
+
+import (
+	"bytes"
+	"encoding/binary"
+	"math"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestEncodeDataPageHeader(t *testing.T) {
+	// PageHeader{type=DATA_PAGE, uncompressed=24, compressed=24, data_page_header{num_values=3, PLAIN, RLE, RLE}}
+	want := []byte{0x15, 0x00, 0x15, 0x30, 0x15, 0x30, 0x2C, 0x15, 0x06, 0x15, 0x00, 0x15, 0x06, 0x15, 0x06, 0x00, 0x00}
+	require.Equal(t, want, encodeDataPageHeader(3, 24))
+}
+
+func TestCompactWriter_LongFormAndLists(t *testing.T) {
+	cw := newCompactWriter()
+	cw.i64(20, -1)
+	cw.listBegin(21, compactI32, 16)
+	require.Equal(t, []byte{
+		0x06, 0x28, 0x01, // 字段 ID 差值超过 15 时使用长格式：type + zigzag(20) + zigzag(-1)
+		0x19, 0xF5, 0x10, // 列表长度 >= 15 时单独编码长度
+	}, cw.buf)
+}
+
+func TestWriter_FileLayout(t *testing.T) {
+	var buf bytes.Buffer
+	w := NewWriter(&buf, []Column{
+		{Name: "id", Type: Int64},
+		{Name: "cost", Type: Double},
+		{Name: "model", Type: String},
+		{Name: "stream", Type: Boolean},
+		{Name: "created_at", Type: TimestampMillis},
+	}, "sub2api")
+
+	ts := time.UnixMilli(1700000000000)
+	require.NoError(t, w.WriteRowGroup([][]any{
+		{int64(1), 0.5, "claude", true, ts},
+		{2, 1.25, "gpt", false, ts},
+	}))
+	require.NoError(t, w.WriteRowGroup([][]any{{int64(3), 0.0, "", true, ts}}))
+	require.NoError(t, w.Close())
+	require.ErrorIs(t, w.WriteRowGroup([][]any{{int64(4), 0.0, "", true, ts}}), ErrWriterClosed)
+
+	out := buf.Bytes()
+	require.Equal(t, []byte("PAR1"), out[:4])
+	require.Equal(t, []byte("PAR1"), out[len(out)-4:])
+	footerLen := int(binary.LittleEndian.Uint32(out[len(out)-8 : len(out)-4]))
+	require.Greater(t, footerLen, 0)
+	require.Less(t, footerLen, len(out)-12)
+
+	require.Equal(t, int64(3), w.numRows)
+	require.Len(t, w.rowGroups, 2)
+
+	// 第一个行组的 id 列：页头之后紧跟 PLAIN 编码的两个 int64
+	first := w.rowGroups[0].columns[0]
+	require.Equal(t, int64(4), first.offset)
+	chunk := out[first.offset : first.offset+first.size]
+	header := encodeDataPageHeader(2, 16)
+	require.Equal(t, header, chunk[:len(header)])
+	require.Equal(t, uint64(1), binary.LittleEndian.Uint64(chunk[len(header):]))
+	require.Equal(t, uint64(2), binary.LittleEndian.Uint64(chunk[len(header)+8:]))
+
+	// cost 列紧跟 id 列
+	cost := w.rowGroups[0].columns[1]
+	require.Equal(t, first.offset+first.size, cost.offset)
+	costData := out[cost.offset+int64(len(encodeDataPageHeader(2, 16))):]
+	require.Equal(t, 0.5, math.Float64frombits(binary.LittleEndian.Uint64(costData)))
+
+	// stream 列按位打包：true,false → 0b01
+	stream := w.rowGroups[0].columns[3]
+	streamHeader := encodeDataPageHeader(2, 1)
+	require.Equal(t, int64(len(streamHeader)+1), stream.size)
+	require.Equal(t, byte(0x01), out[stream.offset+int64(len(streamHeader))])
+}
+
+func TestWriter_RejectsMismatchedRows(t *testing.T) {
+	w := NewWriter(&bytes.Buffer{}, []Column{{Name: "id", Type: Int64}}, "")
+	require.Error(t, w.WriteRowGroup([][]any{{"not-an-int"}}))
+	require.Error(t, w.WriteRowGroup([][]any{{int64(1), int64(2)}}))
+}
+
+func TestWriter_EmptyFile(t *testing.T) {
+	var buf bytes.Buffer
+	w := NewWriter(&buf, []Column{{Name: "id", Type: Int64}}, "")
+	require.NoError(t, w.Close())
+	out := buf.Bytes()
+	require.Equal(t, []byte("PAR1"), out[:4])
+	require.Equal(t, []byte("PAR1"), out[len(out)-4:])
+}
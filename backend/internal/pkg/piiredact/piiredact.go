@@ -0,0 +1,230 @@
+// Package piiredact 在持久化与日志输出之前屏蔽提示词中的个人信息（邮箱、手机号、卡号等）
+// 以及配置指定的字段。
+package piiredact
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+// DefaultReplacement 未配置替换文本时使用的占位符
+const DefaultReplacement = "[REDACTED]"
+
+// maxRedactDepth 限制递归深度以防止栈溢出
+const maxRedactDepth = 32
+
+// builtinPattern 内置规则；valid 非空时仅屏蔽通过校验的匹配（降低误报）
+type builtinPattern struct {
+	expr  string
+	valid func(match string) bool
+}
+
+// builtinPatterns 内置的常见个人信息规则
+var builtinPatterns = map[string]builtinPattern{
+	"email":       {expr: `[A-Za-z0-9._%+\-]+@[A-Za-z0-9.\-]+\.[A-Za-z]{2,}`},
+	"phone":       {expr: `(?:\+?\d{1,3}[\s\-]?)?\(?\d{3}\)?[\s\-]\d{3}[\s\-]\d{4}\b|\b1[3-9]\d{9}\b`},
+	"credit_card": {expr: `\b(?:\d[ \-]?){12,18}\d\b`, valid: luhnValid},
+	"cn_id_card":  {expr: `\b\d{17}[\dXx]\b`},
+	"us_ssn":      {expr: `\b\d{3}-\d{2}-\d{4}\b`},
+	"ipv4":        {expr: `\b(?:(?:25[0-5]|2[0-4]\d|1?\d?\d)\.){3}(?:25[0-5]|2[0-4]\d|1?\d?\d)\b`},
+}
+
+// BuiltinPatternNames 返回全部内置规则名称（已排序）
+func BuiltinPatternNames() []string {
+	names := make([]string, 0, len(builtinPatterns))
+	for name := range builtinPatterns {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// IsBuiltinPattern 判断是否为内置规则名称
+func IsBuiltinPattern(name string) bool {
+	_, ok := builtinPatterns[strings.ToLower(strings.TrimSpace(name))]
+	return ok
+}
+
+// Options 脱敏规则
+type Options struct {
+	// BuiltinPatterns 启用的内置规则名称（见 BuiltinPatternNames）
+	BuiltinPatterns []string
+	// Patterns 自定义正则表达式
+	Patterns []string
+	// Fields JSON 字段名（不区分大小写），命中时整个值被替换
+	Fields []string
+	// Replacement 替换文本，为空时使用 DefaultReplacement
+	Replacement string
+}
+
+type compiledPattern struct {
+	re    *regexp.Regexp
+	valid func(match string) bool
+}
+
+// Redactor 按正则与字段列表屏蔽文本/JSON 中的个人信息，可并发使用
+type Redactor struct {
+	patterns    []compiledPattern
+	fields      map[string]struct{}
+	replacement string
+}
+
+// New 编译脱敏规则
+func New(opts Options) (*Redactor, error) {
+	r := &Redactor{
+		fields:      make(map[string]struct{}, len(opts.Fields)),
+		replacement: opts.Replacement,
+	}
+	if r.replacement == "" {
+		r.replacement = DefaultReplacement
+	}
+	for _, name := range opts.BuiltinPatterns {
+		p, ok := builtinPatterns[strings.ToLower(strings.TrimSpace(name))]
+		if !ok {
+			return nil, fmt.Errorf("unknown builtin pattern %q", name)
+		}
+		r.patterns = append(r.patterns, compiledPattern{re: regexp.MustCompile(p.expr), valid: p.valid})
+	}
+	for _, expr := range opts.Patterns {
+		if strings.TrimSpace(expr) == "" {
+			continue
+		}
+		re, err := regexp.Compile(expr)
+		if err != nil {
+			return nil, fmt.Errorf("invalid pattern %q: %w", expr, err)
+		}
+		r.patterns = append(r.patterns, compiledPattern{re: re})
+	}
+	for _, field := range opts.Fields {
+		if f := normalizeKey(field); f != "" {
+			r.fields[f] = struct{}{}
+		}
+	}
+	return r, nil
+}
+
+// String 屏蔽文本中匹配任一规则的内容
+func (r *Redactor) String(s string) string {
+	if r == nil || s == "" {
+		return s
+	}
+	for _, p := range r.patterns {
+		if p.valid == nil {
+			s = p.re.ReplaceAllLiteralString(s, r.replacement)
+			continue
+		}
+		s = p.re.ReplaceAllStringFunc(s, func(match string) string {
+			if p.valid(match) {
+				return r.replacement
+			}
+			return match
+		})
+	}
+	return s
+}
+
+// Value 递归处理已解码的 JSON 值：命中字段列表的值整体替换，其余字符串按正则屏蔽
+func (r *Redactor) Value(v any) any {
+	if r == nil {
+		return v
+	}
+	return r.value(v, 0)
+}
+
+func (r *Redactor) value(v any, depth int) any {
+	if depth > maxRedactDepth {
+		return r.replacement
+	}
+	switch t := v.(type) {
+	case map[string]any:
+		out := make(map[string]any, len(t))
+		for k, vv := range t {
+			if _, ok := r.fields[normalizeKey(k)]; ok {
+				out[k] = r.replacement
+				continue
+			}
+			out[k] = r.value(vv, depth+1)
+		}
+		return out
+	case []any:
+		out := make([]any, 0, len(t))
+		for _, vv := range t {
+			out = append(out, r.value(vv, depth+1))
+		}
+		return out
+	case string:
+		return r.String(t)
+	default:
+		return v
+	}
+}
+
+// JSON 处理 JSON 文本；无法解析时按纯文本屏蔽
+func (r *Redactor) JSON(raw []byte) []byte {
+	if r == nil || len(raw) == 0 {
+		return raw
+	}
+	var decoded any
+	if err := json.Unmarshal(raw, &decoded); err != nil {
+		return []byte(r.String(string(raw)))
+	}
+	encoded, err := json.Marshal(r.value(decoded, 0))
+	if err != nil {
+		return []byte(r.String(string(raw)))
+	}
+	return encoded
+}
+
+// Writer 包装日志输出：每次写入的内容按正则屏蔽后再写出。
+// 标准库 log 与 slog 每条记录只调用一次 Write，因此不会把一个匹配拆到两次写入中。
+func (r *Redactor) Writer(w io.Writer) io.Writer {
+	if r == nil || len(r.patterns) == 0 {
+		return w
+	}
+	return &redactWriter{r: r, w: w}
+}
+
+type redactWriter struct {
+	r *Redactor
+	w io.Writer
+}
+
+func (w *redactWriter) Write(p []byte) (int, error) {
+	out := w.r.String(string(p))
+	if out == string(p) {
+		return w.w.Write(p)
+	}
+	if _, err := io.WriteString(w.w, out); err != nil {
+		return 0, err
+	}
+	return len(p), nil
+}
+
+func normalizeKey(key string) string {
+	return strings.ToLower(strings.TrimSpace(key))
+}
+
+// luhnValid 校验卡号（忽略空格与连字符），过滤时间戳等普通长数字
+func luhnValid(match string) bool {
+	sum, n := 0, 0
+	for i := len(match) - 1; i >= 0; i-- {
+		c := match[i]
+		if c < '0' || c > '9' {
+			continue
+		}
+		d := int(c - '0')
+		if n%2 == 1 {
+			d *= 2
+			if d > 9 {
+				d -= 9
+			}
+		}
+		sum += d
+		n++
+	}
+	return n >= 13 && sum%10 == 0
+}
@@ -0,0 +1,64 @@
+//go:build unit
+
+package piiredact
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestRedactor_String(t *testing.T) {
+	r, err := New(Options{BuiltinPatterns: []string{"email", "phone", "credit_card", "us_ssn"}, Patterns: []string{`EMP-\d{6}`}})
+	require.NoError(t, err)
+
+	require.Equal(t,
+		"mail [REDACTED], call [REDACTED] or [REDACTED], card [REDACTED], ssn [REDACTED], id [REDACTED]",
+		r.String("mail alice.smith@example.com, call +1 415-555-0100 or 13812345678, card 4111 1111 1111 1111, ssn 123-45-6789, id EMP-004211"))
+
+	// 非 Luhn 卡号的长数字（如毫秒时间戳）保留
+	require.Equal(t, "ts=1700000000000", r.String("ts=1700000000000"))
+}
+
+func TestRedactor_Value(t *testing.T) {
+	r, err := New(Options{BuiltinPatterns: []string{"email"}, Fields: []string{"Metadata"}, Replacement: "***"})
+	require.NoError(t, err)
+
+	out := r.Value(map[string]any{
+		"model":    "claude-sonnet",
+		"metadata": map[string]any{"user_id": "u-1"},
+		"messages": []any{map[string]any{"role": "user", "content": "reach me at bob@example.org"}},
+	})
+	require.Equal(t, map[string]any{
+		"model":    "claude-sonnet",
+		"metadata": "***",
+		"messages": []any{map[string]any{"role": "user", "content": "reach me at ***"}},
+	}, out)
+
+	require.JSONEq(t, `{"text":"***"}`, string(r.JSON([]byte(`{"text":"bob@example.org"}`))))
+	require.Equal(t, "plain ***", string(r.JSON([]byte("plain bob@example.org"))))
+}
+
+func TestRedactor_Writer(t *testing.T) {
+	r, err := New(Options{BuiltinPatterns: []string{"email"}})
+	require.NoError(t, err)
+
+	var buf bytes.Buffer
+	line := "upstream body: user=bob@example.org\n"
+	n, err := r.Writer(&buf).Write([]byte(line))
+	require.NoError(t, err)
+	require.Equal(t, len(line), n)
+	require.Equal(t, "upstream body: user=[REDACTED]\n", buf.String())
+
+	var disabled *Redactor
+	require.Same(t, &buf, disabled.Writer(&buf))
+	require.Equal(t, "bob@example.org", disabled.String("bob@example.org"))
+}
+
+func TestNew_Invalid(t *testing.T) {
+	_, err := New(Options{BuiltinPatterns: []string{"passport"}})
+	require.Error(t, err)
+	_, err = New(Options{Patterns: []string{"("}})
+	require.Error(t, err)
+}
@@ -0,0 +1,223 @@
+// Package tokenizer 在本地估算提示词 token 数，用于额度预检、上下文裁剪与 token 计数接口，
+// 避免为计数往返上游 count_tokens。
+//
+// 本包不包含任何模型的真实词表，全部为启发式估算：
+//   - openai: 用参照 cl100k 预分词正则改写的规则切分文本，再按片段长度与字符类别经验地估算每片的 token 数
+//   - claude: 在 openai 估算基础上按经验膨胀系数放大
+//   - gemini: SentencePiece 经验值（英文约 4 字符/token，CJK 约 1 字/token）
+//
+// 结果与上游实际 token 数存在偏差（长尾文本、代码与少见语言偏差更大），不可用于计费。
+package tokenizer
+
+import (
+	"encoding/json"
+	"math"
+	"regexp"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// Family 分词器家族
+type Family string
+
+const (
+	FamilyOpenAI Family = "openai"
+	FamilyClaude Family = "claude"
+	FamilyGemini Family = "gemini"
+)
+
+const (
+	// messageOverheadTokens 每条消息的角色/分隔符开销
+	messageOverheadTokens = 3
+	// claudeInflation Claude 相对 openai 估算的经验膨胀系数
+	claudeInflation = 1.15
+	// maxWalkDepth 限制递归深度以防止栈溢出
+	maxWalkDepth = 32
+)
+
+// imageTokens 单张图片的估算 token（按各家文档中常见尺寸的计费值）
+var imageTokens = map[Family]int{
+	FamilyOpenAI: 765,
+	FamilyClaude: 1600,
+	FamilyGemini: 258,
+}
+
+// preTokenizePattern 参照 cl100k 预分词正则改写（去掉 Go 不支持的负向前瞻），切分结果与其并不完全一致
+var preTokenizePattern = regexp.MustCompile(`(?i:'s|'t|'re|'ve|'m|'ll|'d)|[^\r\n\p{L}\p{N}]?\p{L}+|\p{N}{1,3}| ?[^\s\p{L}\p{N}]+[\r\n]*|\s*[\r\n]+|\s+`)
+
+// ForModel 根据模型名（优先）与平台推断分词器家族，无法判断时按 openai 估算
+func ForModel(platform, model string) Family {
+	m := strings.ToLower(strings.TrimSpace(model))
+	switch {
+	case strings.HasPrefix(m, "claude"):
+		return FamilyClaude
+	case strings.HasPrefix(m, "gemini") || strings.HasPrefix(m, "gemma"):
+		return FamilyGemini
+	case strings.HasPrefix(m, "gpt") || strings.HasPrefix(m, "o1") || strings.HasPrefix(m, "o3") ||
+		strings.HasPrefix(m, "o4") || strings.Contains(m, "codex"):
+		return FamilyOpenAI
+	}
+	switch strings.ToLower(platform) {
+	case "anthropic":
+		return FamilyClaude
+	case "gemini", "antigravity":
+		return FamilyGemini
+	}
+	return FamilyOpenAI
+}
+
+// CountText 估算一段文本的 token 数
+func CountText(family Family, text string) int {
+	if text == "" {
+		return 0
+	}
+	switch family {
+	case FamilyGemini:
+		return countSentencePiece(text)
+	case FamilyClaude:
+		return int(math.Ceil(float64(countPreTokenized(text)) * claudeInflation))
+	default:
+		return countPreTokenized(text)
+	}
+}
+
+// CountJSON 估算请求体（或其中一段 JSON，如单条消息）的提示 token 数：
+// 累计文本字段与消息开销，图片等二进制内容按固定值计入，工具定义按其 JSON 文本计入
+func CountJSON(family Family, raw []byte) int {
+	var v any
+	if err := json.Unmarshal(raw, &v); err != nil {
+		return 0
+	}
+	return CountValue(family, v)
+}
+
+// CountValue 同 CountJSON，作用于已解析的 JSON
+func CountValue(family Family, v any) int {
+	return countValue(family, "", v, 0)
+}
+
+func countValue(family Family, key string, v any, depth int) int {
+	if depth > maxWalkDepth {
+		return 0
+	}
+	switch val := v.(type) {
+	case map[string]any:
+		if isImageObject(val) {
+			return imageTokens[family]
+		}
+		total := 0
+		if _, hasRole := val["role"]; hasRole {
+			total += messageOverheadTokens
+		}
+		for k, child := range val {
+			switch k {
+			case "tools", "functionDeclarations", "function_declarations":
+				if b, err := json.Marshal(child); err == nil {
+					total += CountText(family, string(b))
+				}
+				continue
+			}
+			total += countValue(family, k, child, depth+1)
+		}
+		return total
+	case []any:
+		total := 0
+		for _, child := range val {
+			total += countValue(family, key, child, depth+1)
+		}
+		return total
+	case string:
+		if skipStringField(key) || strings.HasPrefix(val, "data:") {
+			return 0
+		}
+		return CountText(family, val)
+	}
+	return 0
+}
+
+// skipStringField 不计入提示词的元数据/二进制字段
+func skipStringField(key string) bool {
+	switch key {
+	case "model", "type", "role", "id", "tool_use_id", "call_id", "media_type", "mime_type", "mimeType",
+		"data", "image_url", "url", "file_data", "file_id", "signature", "encrypted_content",
+		"previous_response_id", "prompt_cache_key", "user", "safety_identifier", "reasoning_effort", "service_tier":
+		return true
+	}
+	return false
+}
+
+// isImageObject 识别各协议中的图片内容块
+func isImageObject(obj map[string]any) bool {
+	switch obj["type"] {
+	case "image", "image_url", "input_image":
+		return true
+	}
+	if inline, ok := obj["inlineData"].(map[string]any); ok {
+		if mime, _ := inline["mimeType"].(string); strings.HasPrefix(mime, "image/") {
+			return true
+		}
+	}
+	return false
+}
+
+// countPreTokenized 按预分词规则切分后逐片估算
+func countPreTokenized(text string) int {
+	total := 0
+	for _, piece := range preTokenizePattern.FindAllString(text, -1) {
+		total += pieceTokens(piece)
+	}
+	return total
+}
+
+// pieceTokens 按经验估算单个预分词片段的 token 数：
+// 常见英文单词（含前导空格）通常为 1 个 token，长词约每 4 字符 1 个 token；
+// CJK 字符约 1 字 1 token；其他非拉丁文字约 2 字符 1 token
+func pieceTokens(piece string) int {
+	if strings.TrimSpace(piece) == "" {
+		return 1
+	}
+	runes := utf8.RuneCountInString(piece)
+	ascii, cjk := 0, 0
+	for _, r := range piece {
+		switch {
+		case r <= unicode.MaxASCII:
+			ascii++
+		case isCJK(r):
+			cjk++
+		}
+	}
+	other := runes - ascii - cjk
+	tokens := cjk + (other+1)/2
+	if ascii > 0 {
+		if ascii <= 7 {
+			tokens++
+		} else {
+			tokens += 1 + (ascii-7+3)/4
+		}
+	}
+	return tokens
+}
+
+// countSentencePiece Gemini 分词经验值
+func countSentencePiece(text string) int {
+	runes := utf8.RuneCountInString(text)
+	if runes == 0 {
+		return 0
+	}
+	ascii, cjk := 0, 0
+	for _, r := range text {
+		switch {
+		case r <= unicode.MaxASCII:
+			ascii++
+		case isCJK(r):
+			cjk++
+		}
+	}
+	other := runes - ascii - cjk
+	return (ascii+3)/4 + cjk + (other+2)/3
+}
+
+func isCJK(r rune) bool {
+	return unicode.Is(unicode.Han, r) || unicode.Is(unicode.Hiragana, r) || unicode.Is(unicode.Katakana, r) || unicode.Is(unicode.Hangul, r)
+}
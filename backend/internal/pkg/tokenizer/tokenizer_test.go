@@ -0,0 +1,47 @@
+//go:build unit
+
+package tokenizer
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestForModel(t *testing.T) {
+	require.Equal(t, FamilyClaude, ForModel("openai", "claude-sonnet-4-5"))
+	require.Equal(t, FamilyOpenAI, ForModel("anthropic", "gpt-5"))
+	require.Equal(t, FamilyGemini, ForModel("antigravity", "gemini-2.5-pro"))
+	require.Equal(t, FamilyClaude, ForModel("anthropic", "custom-model"))
+	require.Equal(t, FamilyOpenAI, ForModel("", ""))
+}
+
+func TestCountText_OpenAI(t *testing.T) {
+	// 常见英文单词（含前导空格）各 1 个 token
+	require.Equal(t, 9, CountText(FamilyOpenAI, "The quick brown fox jumps over the lazy dog"))
+	// 数字按 3 位一组
+	require.Equal(t, 3, CountText(FamilyOpenAI, "1234567"))
+	// CJK 约 1 字 1 token
+	require.Equal(t, 4, CountText(FamilyOpenAI, "你好世界"))
+	require.Zero(t, CountText(FamilyOpenAI, ""))
+}
+
+func TestCountText_FamiliesDiffer(t *testing.T) {
+	text := "Tokenization approximations should stay within a reasonable range of the upstream counters."
+	openai := CountText(FamilyOpenAI, text)
+	require.Greater(t, CountText(FamilyClaude, text), openai)
+	require.InDelta(t, len(text)/4, CountText(FamilyGemini, text), 1)
+}
+
+func TestCountJSON_MessagesImagesAndTools(t *testing.T) {
+	text := CountJSON(FamilyClaude, []byte(`{"model":"claude-sonnet-4","messages":[{"role":"user","content":"hello"}]}`))
+	require.Equal(t, messageOverheadTokens+CountText(FamilyClaude, "hello"), text)
+
+	withImage := CountJSON(FamilyClaude, []byte(`{"messages":[{"role":"user","content":[{"type":"text","text":"hello"},{"type":"image","source":{"type":"base64","media_type":"image/png","data":"QUJDREVGR0hJSktMTU5PUFFSU1RVVldYWVo="}}]}]}`))
+	require.Equal(t, text+imageTokens[FamilyClaude], withImage)
+
+	withTools := CountJSON(FamilyOpenAI, []byte(`{"input":"hi","tools":[{"type":"function","name":"get_weather","parameters":{"type":"object","properties":{"city":{"type":"string"}}}}]}`))
+	require.Greater(t, withTools, CountText(FamilyOpenAI, "hi")+10)
+
+	require.Zero(t, CountJSON(FamilyOpenAI, []byte(`not json`)))
+}
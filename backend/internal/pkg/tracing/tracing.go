@@ -0,0 +1,140 @@
+// Package tracing 基于 OpenTelemetry 的链路追踪：初始化 OTLP/HTTP 导出器，
+// 提供 span 创建与上游请求的 W3C trace context 传播。未初始化时使用全局 noop provider，调用开销可忽略。
+package tracing
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync/atomic"
+	"time"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/exporters/otlp/otlptrace/otlptracehttp"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/sdk/resource"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/trace"
+)
+
+const instrumentationName = "github.com/Wei-Shaw/sub2api"
+
+// Options 链路追踪初始化参数
+type Options struct {
+	// Endpoint OTLP/HTTP 接收端地址，如 http://otel-collector:4318（路径默认 /v1/traces）
+	Endpoint string
+	// Headers 导出请求附加的头（如鉴权 token）
+	Headers map[string]string
+	// ServiceName / ServiceVersion 写入 resource 属性
+	ServiceName    string
+	ServiceVersion string
+	// SampleRatio 根 span 采样率（0-1），上游传入的采样决定优先
+	SampleRatio float64
+	// PropagateUpstream 是否向上游 API 请求注入 traceparent/tracestate 头
+	PropagateUpstream bool
+}
+
+var propagateUpstream atomic.Bool
+
+// Init 初始化全局 TracerProvider 与 W3C 传播器，返回用于优雅关闭（刷新未导出 span）的函数
+func Init(ctx context.Context, opts Options) (func(context.Context) error, error) {
+	exporterOpts, err := exporterOptions(opts)
+	if err != nil {
+		return nil, err
+	}
+	exporter, err := otlptracehttp.New(ctx, exporterOpts...)
+	if err != nil {
+		return nil, fmt.Errorf("create otlp trace exporter: %w", err)
+	}
+
+	serviceName := opts.ServiceName
+	if serviceName == "" {
+		serviceName = "sub2api"
+	}
+	res := resource.NewSchemaless(
+		attribute.String("service.name", serviceName),
+		attribute.String("service.version", opts.ServiceVersion),
+	)
+
+	ratio := opts.SampleRatio
+	if ratio <= 0 || ratio > 1 {
+		ratio = 1
+	}
+	provider := sdktrace.NewTracerProvider(
+		sdktrace.WithBatcher(exporter, sdktrace.WithBatchTimeout(5*time.Second)),
+		sdktrace.WithResource(res),
+		sdktrace.WithSampler(sdktrace.ParentBased(sdktrace.TraceIDRatioBased(ratio))),
+	)
+	otel.SetTracerProvider(provider)
+	otel.SetTextMapPropagator(propagation.NewCompositeTextMapPropagator(propagation.TraceContext{}, propagation.Baggage{}))
+	propagateUpstream.Store(opts.PropagateUpstream)
+
+	return provider.Shutdown, nil
+}
+
+// exporterOptions 将 endpoint URL 拆分为 otlptracehttp 的 host/path/TLS 选项
+func exporterOptions(opts Options) ([]otlptracehttp.Option, error) {
+	endpoint := strings.TrimSpace(opts.Endpoint)
+	if endpoint == "" {
+		return nil, fmt.Errorf("tracing endpoint is required")
+	}
+	if !strings.Contains(endpoint, "://") {
+		endpoint = "http://" + endpoint
+	}
+	u, err := url.Parse(endpoint)
+	if err != nil || u.Host == "" {
+		return nil, fmt.Errorf("invalid tracing endpoint %q", opts.Endpoint)
+	}
+
+	out := []otlptracehttp.Option{otlptracehttp.WithEndpoint(u.Host)}
+	switch u.Scheme {
+	case "http":
+		out = append(out, otlptracehttp.WithInsecure())
+	case "https":
+	default:
+		return nil, fmt.Errorf("invalid tracing endpoint scheme %q", u.Scheme)
+	}
+	if path := strings.TrimRight(u.Path, "/"); path != "" {
+		out = append(out, otlptracehttp.WithURLPath(path))
+	}
+	if len(opts.Headers) > 0 {
+		out = append(out, otlptracehttp.WithHeaders(opts.Headers))
+	}
+	return out, nil
+}
+
+// Tracer 返回网关使用的 tracer
+func Tracer() trace.Tracer {
+	return otel.Tracer(instrumentationName)
+}
+
+// Start 创建内部 span
+func Start(ctx context.Context, name string, attrs ...attribute.KeyValue) (context.Context, trace.Span) {
+	return Tracer().Start(ctx, name, trace.WithAttributes(attrs...))
+}
+
+// End 结束 span，err 非空时标记为错误
+func End(span trace.Span, err error) {
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+	}
+	span.End()
+}
+
+// Extract 从入站请求头中提取上游调用方的 trace context
+func Extract(ctx context.Context, header http.Header) context.Context {
+	return otel.GetTextMapPropagator().Extract(ctx, propagation.HeaderCarrier(header))
+}
+
+// InjectUpstream 向上游请求注入 trace context（未启用传播或当前无有效 span 时不修改请求头）
+func InjectUpstream(ctx context.Context, header http.Header) {
+	if !propagateUpstream.Load() || !trace.SpanContextFromContext(ctx).IsValid() {
+		return
+	}
+	otel.GetTextMapPropagator().Inject(ctx, propagation.HeaderCarrier(header))
+}
@@ -0,0 +1,22 @@
+package tracing
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestExporterOptions(t *testing.T) {
+	opts, err := exporterOptions(Options{Endpoint: "otel-collector:4318"})
+	require.NoError(t, err)
+	require.Len(t, opts, 2) // endpoint + insecure
+
+	opts, err = exporterOptions(Options{Endpoint: "https://otlp.example.com/custom/v1/traces", Headers: map[string]string{"x-api-key": "k"}})
+	require.NoError(t, err)
+	require.Len(t, opts, 3) // endpoint + url path + headers
+
+	_, err = exporterOptions(Options{Endpoint: ""})
+	require.Error(t, err)
+	_, err = exporterOptions(Options{Endpoint: "ftp://collector:4318"})
+	require.Error(t, err)
+}
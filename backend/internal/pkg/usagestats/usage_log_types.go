@@ -145,6 +145,15 @@ type UsageLogFilters struct {
 	EndTime     *time.Time
 }
 
+// ModelCacheUsage represents prompt cache token/cost totals for a single model
+type ModelCacheUsage struct {
+	Model               string  `json:"model"`
+	CacheCreationTokens int64   `json:"cache_creation_tokens"`
+	CacheReadTokens     int64   `json:"cache_read_tokens"`
+	CacheCreationCost   float64 `json:"cache_creation_cost"`
+	CacheReadCost       float64 `json:"cache_read_cost"`
+}
+
 // UsageStats represents usage statistics
 type UsageStats struct {
 	TotalRequests     int64    `json:"total_requests"`
@@ -0,0 +1,149 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"strings"
+
+	"github.com/Wei-Shaw/sub2api/internal/pkg/pagination"
+	"github.com/Wei-Shaw/sub2api/internal/service"
+)
+
+type adminActionLogRepository struct {
+	sql sqlExecutor
+}
+
+// NewAdminActionLogRepository 创建管理后台操作审计仓储
+func NewAdminActionLogRepository(sqlDB *sql.DB) service.AdminActionLogRepository {
+	return &adminActionLogRepository{sql: sqlDB}
+}
+
+const adminActionLogListColumns = `
+	l.id, l.actor_user_id, COALESCE(u.email, ''), l.auth_method, l.method, l.path, l.route,
+	l.resource_type, l.resource_id, l.status_code, l.client_ip, l.user_agent, l.created_at`
+
+const adminActionLogFrom = " FROM admin_action_logs l LEFT JOIN users u ON u.id = l.actor_user_id"
+
+func (r *adminActionLogRepository) Create(ctx context.Context, entry *service.AdminActionLog) error {
+	var changes []byte
+	if len(entry.Changes) > 0 {
+		raw, err := json.Marshal(entry.Changes)
+		if err != nil {
+			return err
+		}
+		changes = raw
+	}
+	query := `
+		INSERT INTO admin_action_logs (
+			actor_user_id, auth_method, method, path, route, resource_type, resource_id,
+			status_code, client_ip, user_agent, request_body, before_state, after_state, changes
+		) VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14)
+		RETURNING id, created_at`
+	return scanSingleRow(ctx, r.sql, query, []any{
+		entry.ActorUserID, entry.AuthMethod, entry.Method, entry.Path, entry.Route, entry.ResourceType, entry.ResourceID,
+		entry.StatusCode, entry.ClientIP, entry.UserAgent, entry.RequestBody,
+		nullableJSON(entry.Before), nullableJSON(entry.After), nullableJSON(changes),
+	}, &entry.ID, &entry.CreatedAt)
+}
+
+func (r *adminActionLogRepository) List(ctx context.Context, params pagination.PaginationParams, filter service.AdminActionLogFilter) ([]service.AdminActionLog, *pagination.PaginationResult, error) {
+	where, args := buildAdminActionLogWhere(filter)
+
+	var total int64
+	if err := scanSingleRow(ctx, r.sql, "SELECT COUNT(*)"+adminActionLogFrom+where, args, &total); err != nil {
+		return nil, nil, err
+	}
+
+	query := "SELECT" + adminActionLogListColumns + adminActionLogFrom + where +
+		" ORDER BY l.created_at DESC, l.id DESC LIMIT $" + itoa(len(args)+1) + " OFFSET $" + itoa(len(args)+2)
+	rows, err := r.sql.QueryContext(ctx, query, append(args, params.Limit(), params.Offset())...)
+	if err != nil {
+		return nil, nil, err
+	}
+	defer func() { _ = rows.Close() }()
+
+	logs := make([]service.AdminActionLog, 0, params.Limit())
+	for rows.Next() {
+		var entry service.AdminActionLog
+		if err := rows.Scan(adminActionLogListDest(&entry)...); err != nil {
+			return nil, nil, err
+		}
+		logs = append(logs, entry)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, nil, err
+	}
+	return logs, paginationResultFromTotal(total, params), nil
+}
+
+func (r *adminActionLogRepository) GetByID(ctx context.Context, id int64) (*service.AdminActionLog, error) {
+	var entry service.AdminActionLog
+	var before, after, changes []byte
+	dest := append(adminActionLogListDest(&entry), &entry.RequestBody, &before, &after, &changes)
+	err := scanSingleRow(ctx, r.sql,
+		"SELECT"+adminActionLogListColumns+", l.request_body, l.before_state, l.after_state, l.changes"+
+			adminActionLogFrom+" WHERE l.id = $1",
+		[]any{id}, dest...)
+	if err != nil {
+		return nil, translatePersistenceError(err, service.ErrAdminActionLogNotFound, nil)
+	}
+	entry.Before = before
+	entry.After = after
+	if len(changes) > 0 {
+		if err := json.Unmarshal(changes, &entry.Changes); err != nil {
+			entry.Changes = nil
+		}
+	}
+	return &entry, nil
+}
+
+func adminActionLogListDest(entry *service.AdminActionLog) []any {
+	return []any{
+		&entry.ID, &entry.ActorUserID, &entry.ActorEmail, &entry.AuthMethod, &entry.Method, &entry.Path,
+		&entry.Route, &entry.ResourceType, &entry.ResourceID, &entry.StatusCode, &entry.ClientIP,
+		&entry.UserAgent, &entry.CreatedAt,
+	}
+}
+
+func buildAdminActionLogWhere(filter service.AdminActionLogFilter) (string, []any) {
+	var clauses []string
+	var args []any
+	add := func(clause string, arg any) {
+		args = append(args, arg)
+		clauses = append(clauses, strings.ReplaceAll(clause, "?", "$"+itoa(len(args))))
+	}
+	if filter.StartTime != nil {
+		add("l.created_at >= ?", *filter.StartTime)
+	}
+	if filter.EndTime != nil {
+		add("l.created_at < ?", *filter.EndTime)
+	}
+	if filter.ActorUserID != nil {
+		add("l.actor_user_id = ?", *filter.ActorUserID)
+	}
+	if filter.ResourceType != "" {
+		add("l.resource_type = ?", filter.ResourceType)
+	}
+	if filter.ResourceID != nil {
+		add("l.resource_id = ?", *filter.ResourceID)
+	}
+	if filter.Method != "" {
+		add("l.method = ?", strings.ToUpper(filter.Method))
+	}
+	if filter.Search != "" {
+		add("(l.path ILIKE ? OR u.email ILIKE ?)", "%"+filter.Search+"%")
+	}
+	if len(clauses) == 0 {
+		return "", nil
+	}
+	return " WHERE " + strings.Join(clauses, " AND "), args
+}
+
+// nullableJSON 空 JSON 写入 NULL
+func nullableJSON(raw []byte) any {
+	if len(raw) == 0 {
+		return nil
+	}
+	return raw
+}
@@ -0,0 +1,84 @@
+package repository
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/Wei-Shaw/sub2api/internal/pkg/pagination"
+	"github.com/Wei-Shaw/sub2api/internal/service"
+	"github.com/stretchr/testify/require"
+)
+
+func TestAdminActionLogRepositoryCreate(t *testing.T) {
+	db, mock := newSQLMock(t)
+	repo := &adminActionLogRepository{sql: db}
+
+	actor := int64(1)
+	resourceID := int64(42)
+	entry := &service.AdminActionLog{
+		ActorUserID:  &actor,
+		AuthMethod:   "jwt",
+		Method:       "PUT",
+		Path:         "/api/v1/admin/accounts/42",
+		Route:        "PUT /api/v1/admin/accounts/:id",
+		ResourceType: "accounts",
+		ResourceID:   &resourceID,
+		StatusCode:   200,
+		Before:       json.RawMessage(`{"name":"a"}`),
+		After:        json.RawMessage(`{"name":"b"}`),
+		Changes:      []service.AdminActionFieldChange{{Field: "name", Before: "a", After: "b"}},
+	}
+	createdAt := time.Date(2026, 1, 2, 3, 4, 5, 0, time.UTC)
+	mock.ExpectQuery("INSERT INTO admin_action_logs").
+		WithArgs(&actor, "jwt", "PUT", entry.Path, entry.Route, "accounts", &resourceID,
+			200, "", "", nil, []byte(`{"name":"a"}`), []byte(`{"name":"b"}`),
+			[]byte(`[{"field":"name","before":"a","after":"b"}]`)).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "created_at"}).AddRow(int64(9), createdAt))
+
+	require.NoError(t, repo.Create(context.Background(), entry))
+	require.Equal(t, int64(9), entry.ID)
+	require.Equal(t, createdAt, entry.CreatedAt)
+	require.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestAdminActionLogRepositoryListFilters(t *testing.T) {
+	db, mock := newSQLMock(t)
+	repo := &adminActionLogRepository{sql: db}
+
+	actor := int64(3)
+	filter := service.AdminActionLogFilter{ActorUserID: &actor, ResourceType: "groups", Method: "delete", Search: "alice"}
+
+	mock.ExpectQuery(`SELECT COUNT\(\*\) FROM admin_action_logs l LEFT JOIN users u ON u.id = l.actor_user_id WHERE l.actor_user_id = \$1 AND l.resource_type = \$2 AND l.method = \$3 AND \(l.path ILIKE \$4 OR u.email ILIKE \$4\)`).
+		WithArgs(actor, "groups", "DELETE", "%alice%").
+		WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(int64(1)))
+	mock.ExpectQuery(`ORDER BY l.created_at DESC, l.id DESC LIMIT \$5 OFFSET \$6`).
+		WithArgs(actor, "groups", "DELETE", "%alice%", 20, 0).
+		WillReturnRows(sqlmock.NewRows([]string{
+			"id", "actor_user_id", "email", "auth_method", "method", "path", "route",
+			"resource_type", "resource_id", "status_code", "client_ip", "user_agent", "created_at",
+		}).AddRow(int64(5), actor, "alice@example.com", "jwt", "DELETE", "/api/v1/admin/groups/2",
+			"DELETE /api/v1/admin/groups/:id", "groups", int64(2), 200, "127.0.0.1", "curl", time.Now()))
+
+	logs, result, err := repo.List(context.Background(), pagination.PaginationParams{Page: 1, PageSize: 20}, filter)
+	require.NoError(t, err)
+	require.Len(t, logs, 1)
+	require.Equal(t, "alice@example.com", logs[0].ActorEmail)
+	require.Equal(t, int64(1), result.Total)
+	require.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestAdminActionLogRepositoryGetByIDNotFound(t *testing.T) {
+	db, mock := newSQLMock(t)
+	repo := &adminActionLogRepository{sql: db}
+
+	mock.ExpectQuery("FROM admin_action_logs l").
+		WithArgs(int64(404)).
+		WillReturnRows(sqlmock.NewRows([]string{"id"}))
+
+	_, err := repo.GetByID(context.Background(), 404)
+	require.ErrorIs(t, err, service.ErrAdminActionLogNotFound)
+	require.NoError(t, mock.ExpectationsWereMet())
+}
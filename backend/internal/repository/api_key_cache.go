@@ -17,6 +17,8 @@ const (
 	apiKeyRateLimitDuration    = 24 * time.Hour
 	apiKeyAuthCachePrefix      = "apikey:auth:"
 	authCacheInvalidateChannel = "auth:cache:invalidate"
+	// apiKeyRequestSignaturePrefix 已使用的请求签名（防重放）
+	apiKeyRequestSignaturePrefix = "apikey:sig:"
 )
 
 // apiKeyRateLimitKey generates the Redis key for API key creation rate limiting.
@@ -135,3 +137,8 @@ func (c *apiKeyCache) SubscribeAuthCacheInvalidation(ctx context.Context, handle
 
 	return nil
 }
+
+// MarkRequestSignatureSeen 以 SETNX 记录请求签名，在 ttl（签名时间窗口）内同一签名只能使用一次
+func (c *apiKeyCache) MarkRequestSignatureSeen(ctx context.Context, apiKeyID int64, signature string, ttl time.Duration) (bool, error) {
+	return c.rdb.SetNX(ctx, fmt.Sprintf("%s%d:%s", apiKeyRequestSignaturePrefix, apiKeyID, signature), 1, ttl).Result()
+}
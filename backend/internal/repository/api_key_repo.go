@@ -44,6 +44,36 @@ func (r *apiKeyRepository) Create(ctx context.Context, key *service.APIKey) erro
 	if len(key.IPBlacklist) > 0 {
 		builder.SetIPBlacklist(key.IPBlacklist)
 	}
+	if len(key.AllowedModels) > 0 {
+		builder.SetAllowedModels(key.AllowedModels)
+	}
+	if len(key.Scopes) > 0 {
+		builder.SetScopes(key.Scopes)
+	}
+	if !key.RegionPolicy.IsEmpty() {
+		builder.SetRegionPolicy(key.RegionPolicy)
+	}
+	if !key.SystemPromptPolicy.IsEmpty() {
+		builder.SetSystemPromptPolicy(key.SystemPromptPolicy)
+	}
+	if !key.UsageWebhook.IsEmpty() {
+		builder.SetUsageWebhook(key.UsageWebhook)
+	}
+	if key.RequestSigning.IsEnabled() {
+		builder.SetRequestSigning(key.RequestSigning)
+	}
+	if len(key.ToolLimits) > 0 {
+		builder.SetToolLimits(key.ToolLimits)
+	}
+	if !key.TokenQuota.IsEmpty() {
+		builder.SetTokenQuota(key.TokenQuota)
+	}
+	if key.DebugErrors {
+		builder.SetDebugErrors(true)
+	}
+	if key.PriorityClass != "" {
+		builder.SetPriorityClass(key.PriorityClass)
+	}
 
 	created, err := builder.Save(ctx)
 	if err == nil {
@@ -113,6 +143,16 @@ func (r *apiKeyRepository) GetByKeyForAuth(ctx context.Context, key string) (*se
 			apikey.FieldStatus,
 			apikey.FieldIPWhitelist,
 			apikey.FieldIPBlacklist,
+			apikey.FieldAllowedModels,
+			apikey.FieldScopes,
+			apikey.FieldRegionPolicy,
+			apikey.FieldSystemPromptPolicy,
+			apikey.FieldUsageWebhook,
+			apikey.FieldRequestSigning,
+			apikey.FieldToolLimits,
+			apikey.FieldTokenQuota,
+			apikey.FieldDebugErrors,
+			apikey.FieldPriorityClass,
 			apikey.FieldQuota,
 			apikey.FieldQuotaUsed,
 			apikey.FieldExpiresAt,
@@ -145,8 +185,16 @@ func (r *apiKeyRepository) GetByKeyForAuth(ctx context.Context, key string) (*se
 				group.FieldFallbackGroupIDOnInvalidRequest,
 				group.FieldModelRoutingEnabled,
 				group.FieldModelRouting,
+				group.FieldModelParamPolicies,
+				group.FieldRegionPolicy,
+				group.FieldSystemPromptPolicy,
+				group.FieldPromptTemplate,
+				group.FieldModelAccessPolicy,
+				group.FieldRetryPolicy,
 				group.FieldMcpXMLInject,
 				group.FieldSupportedModelScopes,
+				group.FieldMeteringOnly,
+				group.FieldStrictRequestFields,
 			)
 		}).
 		Only(ctx)
@@ -197,6 +245,48 @@ func (r *apiKeyRepository) Update(ctx context.Context, key *service.APIKey) erro
 	} else {
 		builder.ClearIPBlacklist()
 	}
+	if len(key.AllowedModels) > 0 {
+		builder.SetAllowedModels(key.AllowedModels)
+	} else {
+		builder.ClearAllowedModels()
+	}
+	if len(key.Scopes) > 0 {
+		builder.SetScopes(key.Scopes)
+	} else {
+		builder.ClearScopes()
+	}
+	if !key.RegionPolicy.IsEmpty() {
+		builder.SetRegionPolicy(key.RegionPolicy)
+	} else {
+		builder.ClearRegionPolicy()
+	}
+	if !key.SystemPromptPolicy.IsEmpty() {
+		builder.SetSystemPromptPolicy(key.SystemPromptPolicy)
+	} else {
+		builder.ClearSystemPromptPolicy()
+	}
+	if !key.UsageWebhook.IsEmpty() {
+		builder.SetUsageWebhook(key.UsageWebhook)
+	} else {
+		builder.ClearUsageWebhook()
+	}
+	if key.RequestSigning.IsEnabled() {
+		builder.SetRequestSigning(key.RequestSigning)
+	} else {
+		builder.ClearRequestSigning()
+	}
+	if len(key.ToolLimits) > 0 {
+		builder.SetToolLimits(key.ToolLimits)
+	} else {
+		builder.ClearToolLimits()
+	}
+	if !key.TokenQuota.IsEmpty() {
+		builder.SetTokenQuota(key.TokenQuota)
+	} else {
+		builder.ClearTokenQuota()
+	}
+	builder.SetDebugErrors(key.DebugErrors)
+	builder.SetPriorityClass(key.PriorityClass)
 
 	affected, err := builder.Save(ctx)
 	if err != nil {
@@ -412,19 +502,29 @@ func apiKeyEntityToService(m *dbent.APIKey) *service.APIKey {
 		return nil
 	}
 	out := &service.APIKey{
-		ID:          m.ID,
-		UserID:      m.UserID,
-		Key:         m.Key,
-		Name:        m.Name,
-		Status:      m.Status,
-		IPWhitelist: m.IPWhitelist,
-		IPBlacklist: m.IPBlacklist,
-		CreatedAt:   m.CreatedAt,
-		UpdatedAt:   m.UpdatedAt,
-		GroupID:     m.GroupID,
-		Quota:       m.Quota,
-		QuotaUsed:   m.QuotaUsed,
-		ExpiresAt:   m.ExpiresAt,
+		ID:                 m.ID,
+		UserID:             m.UserID,
+		Key:                m.Key,
+		Name:               m.Name,
+		Status:             m.Status,
+		IPWhitelist:        m.IPWhitelist,
+		IPBlacklist:        m.IPBlacklist,
+		AllowedModels:      m.AllowedModels,
+		Scopes:             m.Scopes,
+		RegionPolicy:       m.RegionPolicy,
+		SystemPromptPolicy: m.SystemPromptPolicy,
+		UsageWebhook:       m.UsageWebhook,
+		RequestSigning:     m.RequestSigning,
+		ToolLimits:         m.ToolLimits,
+		TokenQuota:         m.TokenQuota,
+		DebugErrors:        m.DebugErrors,
+		PriorityClass:      m.PriorityClass,
+		CreatedAt:          m.CreatedAt,
+		UpdatedAt:          m.UpdatedAt,
+		GroupID:            m.GroupID,
+		Quota:              m.Quota,
+		QuotaUsed:          m.QuotaUsed,
+		ExpiresAt:          m.ExpiresAt,
 	}
 	if m.Edges.User != nil {
 		out.User = userEntityToService(m.Edges.User)
@@ -483,9 +583,17 @@ func groupEntityToService(g *dbent.Group) *service.Group {
 		FallbackGroupIDOnInvalidRequest: g.FallbackGroupIDOnInvalidRequest,
 		ModelRouting:                    g.ModelRouting,
 		ModelRoutingEnabled:             g.ModelRoutingEnabled,
+		ModelParamPolicies:              g.ModelParamPolicies,
+		RegionPolicy:                    g.RegionPolicy,
+		SystemPromptPolicy:              g.SystemPromptPolicy,
+		PromptTemplate:                  g.PromptTemplate,
+		ModelAccessPolicy:               g.ModelAccessPolicy,
+		RetryPolicy:                     g.RetryPolicy,
 		MCPXMLInject:                    g.McpXMLInject,
 		SupportedModelScopes:            g.SupportedModelScopes,
 		SortOrder:                       g.SortOrder,
+		MeteringOnly:                    g.MeteringOnly,
+		StrictRequestFields:             g.StrictRequestFields,
 		CreatedAt:                       g.CreatedAt,
 		UpdatedAt:                       g.UpdatedAt,
 	}
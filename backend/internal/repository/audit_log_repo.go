@@ -0,0 +1,179 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"strings"
+	"time"
+
+	"github.com/Wei-Shaw/sub2api/internal/pkg/pagination"
+	"github.com/Wei-Shaw/sub2api/internal/service"
+)
+
+type auditLogRepository struct {
+	sql sqlExecutor
+}
+
+// NewAuditLogRepository 创建网关请求审计日志仓储
+func NewAuditLogRepository(sqlDB *sql.DB) service.AuditLogRepository {
+	return &auditLogRepository{sql: sqlDB}
+}
+
+const auditLogListColumns = `
+	id, request_id, user_id, api_key_id, account_id, group_id, platform, model,
+	method, path, status_code, stream, duration_ms, client_ip, user_agent,
+	request_bytes, response_bytes, body_truncated, created_at`
+
+func (r *auditLogRepository) Create(ctx context.Context, entry *service.AuditLog) error {
+	query := `
+		INSERT INTO audit_logs (
+			request_id, user_id, api_key_id, account_id, group_id, platform, model,
+			method, path, status_code, stream, duration_ms, client_ip, user_agent,
+			request_bytes, response_bytes, request_body, response_body, body_truncated,
+			body_key_id, body_wrapped_key
+		) VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14, $15, $16, $17, $18, $19, $20, $21)
+		RETURNING id, created_at`
+	return scanSingleRow(ctx, r.sql, query, []any{
+		entry.RequestID, entry.UserID, entry.APIKeyID, entry.AccountID, entry.GroupID, entry.Platform, entry.Model,
+		entry.Method, entry.Path, entry.StatusCode, entry.Stream, entry.DurationMs, entry.ClientIP, entry.UserAgent,
+		entry.RequestBytes, entry.ResponseBytes, entry.RequestBody, entry.ResponseBody, entry.BodyTruncated,
+		entry.BodyKeyID, entry.BodyWrappedKey,
+	}, &entry.ID, &entry.CreatedAt)
+}
+
+func (r *auditLogRepository) List(ctx context.Context, params pagination.PaginationParams, filter service.AuditLogFilter) ([]service.AuditLog, *pagination.PaginationResult, error) {
+	where, args := buildAuditLogWhere(filter)
+
+	var total int64
+	if err := scanSingleRow(ctx, r.sql, "SELECT COUNT(*) FROM audit_logs"+where, args, &total); err != nil {
+		return nil, nil, err
+	}
+
+	query := "SELECT" + auditLogListColumns + " FROM audit_logs" + where +
+		" ORDER BY created_at DESC, id DESC LIMIT $" + itoa(len(args)+1) + " OFFSET $" + itoa(len(args)+2)
+	rows, err := r.sql.QueryContext(ctx, query, append(args, params.Limit(), params.Offset())...)
+	if err != nil {
+		return nil, nil, err
+	}
+	defer func() { _ = rows.Close() }()
+
+	logs := make([]service.AuditLog, 0, params.Limit())
+	for rows.Next() {
+		var entry service.AuditLog
+		if err := rows.Scan(auditLogListDest(&entry)...); err != nil {
+			return nil, nil, err
+		}
+		logs = append(logs, entry)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, nil, err
+	}
+	return logs, paginationResultFromTotal(total, params), nil
+}
+
+func (r *auditLogRepository) GetByID(ctx context.Context, id int64) (*service.AuditLog, error) {
+	var entry service.AuditLog
+	dest := append(auditLogListDest(&entry), &entry.RequestBody, &entry.ResponseBody, &entry.BodyKeyID, &entry.BodyWrappedKey)
+	err := scanSingleRow(ctx, r.sql,
+		"SELECT"+auditLogListColumns+", request_body, response_body, body_key_id, body_wrapped_key FROM audit_logs WHERE id = $1",
+		[]any{id}, dest...)
+	if err != nil {
+		return nil, translatePersistenceError(err, service.ErrAuditLogNotFound, nil)
+	}
+	return &entry, nil
+}
+
+func (r *auditLogRepository) DeleteBefore(ctx context.Context, before time.Time) (int64, error) {
+	res, err := r.sql.ExecContext(ctx, "DELETE FROM audit_logs WHERE created_at < $1", before)
+	if err != nil {
+		return 0, err
+	}
+	return res.RowsAffected()
+}
+
+func (r *auditLogRepository) ListBodyKeysAfter(ctx context.Context, afterID int64, limit int) ([]service.AuditLog, error) {
+	// 已加密的记录只需要密钥字段，不读取请求/响应体
+	rows, err := r.sql.QueryContext(ctx, `
+		SELECT id, user_id, body_key_id, body_wrapped_key,
+			CASE WHEN body_key_id = '' THEN request_body END,
+			CASE WHEN body_key_id = '' THEN response_body END
+		FROM audit_logs
+		WHERE id > $1 AND (request_body IS NOT NULL OR response_body IS NOT NULL)
+		ORDER BY id
+		LIMIT $2`, afterID, limit)
+	if err != nil {
+		return nil, err
+	}
+	defer func() { _ = rows.Close() }()
+
+	logs := make([]service.AuditLog, 0, limit)
+	for rows.Next() {
+		var entry service.AuditLog
+		if err := rows.Scan(&entry.ID, &entry.UserID, &entry.BodyKeyID, &entry.BodyWrappedKey, &entry.RequestBody, &entry.ResponseBody); err != nil {
+			return nil, err
+		}
+		logs = append(logs, entry)
+	}
+	return logs, rows.Err()
+}
+
+func (r *auditLogRepository) UpdateBodies(ctx context.Context, id int64, requestBody, responseBody *string, keyID string, wrappedKey string) error {
+	_, err := r.sql.ExecContext(ctx, `
+		UPDATE audit_logs
+		SET request_body = $2, response_body = $3, body_key_id = $4, body_wrapped_key = $5
+		WHERE id = $1`, id, requestBody, responseBody, keyID, wrappedKey)
+	return err
+}
+
+func (r *auditLogRepository) UpdateBodyKey(ctx context.Context, id int64, keyID string, wrappedKey string) error {
+	_, err := r.sql.ExecContext(ctx,
+		"UPDATE audit_logs SET body_key_id = $2, body_wrapped_key = $3 WHERE id = $1",
+		id, keyID, wrappedKey)
+	return err
+}
+
+func auditLogListDest(entry *service.AuditLog) []any {
+	return []any{
+		&entry.ID, &entry.RequestID, &entry.UserID, &entry.APIKeyID, &entry.AccountID, &entry.GroupID,
+		&entry.Platform, &entry.Model, &entry.Method, &entry.Path, &entry.StatusCode, &entry.Stream,
+		&entry.DurationMs, &entry.ClientIP, &entry.UserAgent, &entry.RequestBytes, &entry.ResponseBytes,
+		&entry.BodyTruncated, &entry.CreatedAt,
+	}
+}
+
+func buildAuditLogWhere(filter service.AuditLogFilter) (string, []any) {
+	var clauses []string
+	var args []any
+	add := func(clause string, arg any) {
+		args = append(args, arg)
+		clauses = append(clauses, strings.ReplaceAll(clause, "?", "$"+itoa(len(args))))
+	}
+	if filter.StartTime != nil {
+		add("created_at >= ?", *filter.StartTime)
+	}
+	if filter.EndTime != nil {
+		add("created_at < ?", *filter.EndTime)
+	}
+	if filter.UserID != nil {
+		add("user_id = ?", *filter.UserID)
+	}
+	if filter.APIKeyID != nil {
+		add("api_key_id = ?", *filter.APIKeyID)
+	}
+	if filter.AccountID != nil {
+		add("account_id = ?", *filter.AccountID)
+	}
+	if filter.Model != "" {
+		add("model = ?", filter.Model)
+	}
+	if filter.RequestID != "" {
+		add("request_id = ?", filter.RequestID)
+	}
+	if filter.StatusCode != nil {
+		add("status_code = ?", *filter.StatusCode)
+	}
+	if len(clauses) == 0 {
+		return "", nil
+	}
+	return " WHERE " + strings.Join(clauses, " AND "), args
+}
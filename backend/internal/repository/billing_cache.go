@@ -4,7 +4,6 @@ import (
 	"context"
 	"errors"
 	"fmt"
-	"log"
 	"strconv"
 	"time"
 
@@ -16,6 +15,11 @@ const (
 	billingBalanceKeyPrefix = "billing:balance:"
 	billingSubKeyPrefix     = "billing:sub:"
 	billingCacheTTL         = 5 * time.Minute
+
+	billingKeyQuotaKeyPrefix = "billing:key_quota:"
+	// 计数键过期时间覆盖完整周期并留出时区差余量，周期结束后自然淘汰
+	billingKeyQuotaDayTTL   = 49 * time.Hour
+	billingKeyQuotaMonthTTL = 33 * 24 * time.Hour
 )
 
 // billingBalanceKey generates the Redis key for user balance cache.
@@ -28,12 +32,29 @@ func billingSubKey(userID, groupID int64) string {
 	return fmt.Sprintf("%s%d:%d", billingSubKeyPrefix, userID, groupID)
 }
 
+// billingKeyQuotaDayKey generates the Redis key for API key daily quota counters.
+func billingKeyQuotaDayKey(apiKeyID int64, day string) string {
+	return fmt.Sprintf("%s%d:d:%s", billingKeyQuotaKeyPrefix, apiKeyID, day)
+}
+
+// billingKeyQuotaMonthKey generates the Redis key for API key monthly quota counters.
+func billingKeyQuotaMonthKey(apiKeyID int64, month string) string {
+	return fmt.Sprintf("%s%d:m:%s", billingKeyQuotaKeyPrefix, apiKeyID, month)
+}
+
+const (
+	keyQuotaFieldTokens   = "tokens"
+	keyQuotaFieldRequests = "requests"
+)
+
 const (
 	subFieldStatus       = "status"
 	subFieldExpiresAt    = "expires_at"
 	subFieldDailyUsage   = "daily_usage"
 	subFieldWeeklyUsage  = "weekly_usage"
 	subFieldMonthlyUsage = "monthly_usage"
+	subFieldRolloverUSD  = "rollover_usd"
+	subFieldRolloverExp  = "rollover_expires_at"
 	subFieldVersion      = "version"
 )
 
@@ -89,7 +110,7 @@ func (c *billingCache) DeductUserBalance(ctx context.Context, userID int64, amou
 	key := billingBalanceKey(userID)
 	_, err := deductBalanceScript.Run(ctx, c.rdb, []string{key}, amount, int(billingCacheTTL.Seconds())).Result()
 	if err != nil && !errors.Is(err, redis.Nil) {
-		log.Printf("Warning: deduct balance cache failed for user %d: %v", userID, err)
+		return err
 	}
 	return nil
 }
@@ -138,6 +159,17 @@ func (c *billingCache) parseSubscriptionCache(data map[string]string) (*service.
 		result.MonthlyUsage, _ = strconv.ParseFloat(monthlyStr, 64)
 	}
 
+	if rolloverStr, ok := data[subFieldRolloverUSD]; ok {
+		result.RolloverUSD, _ = strconv.ParseFloat(rolloverStr, 64)
+	}
+
+	if rolloverExpStr, ok := data[subFieldRolloverExp]; ok {
+		rolloverExp, err := strconv.ParseInt(rolloverExpStr, 10, 64)
+		if err == nil && rolloverExp > 0 {
+			result.RolloverExpiresAt = time.Unix(rolloverExp, 0)
+		}
+	}
+
 	if versionStr, ok := data[subFieldVersion]; ok {
 		result.Version, _ = strconv.ParseInt(versionStr, 10, 64)
 	}
@@ -160,6 +192,10 @@ func (c *billingCache) SetSubscriptionCache(ctx context.Context, userID, groupID
 		subFieldMonthlyUsage: data.MonthlyUsage,
 		subFieldVersion:      data.Version,
 	}
+	if data.RolloverUSD > 0 {
+		fields[subFieldRolloverUSD] = data.RolloverUSD
+		fields[subFieldRolloverExp] = data.RolloverExpiresAt.Unix()
+	}
 
 	pipe := c.rdb.Pipeline()
 	pipe.HSet(ctx, key, fields)
@@ -172,7 +208,7 @@ func (c *billingCache) UpdateSubscriptionUsage(ctx context.Context, userID, grou
 	key := billingSubKey(userID, groupID)
 	_, err := updateSubUsageScript.Run(ctx, c.rdb, []string{key}, cost, int(billingCacheTTL.Seconds())).Result()
 	if err != nil && !errors.Is(err, redis.Nil) {
-		log.Printf("Warning: update subscription usage cache failed for user %d group %d: %v", userID, groupID, err)
+		return err
 	}
 	return nil
 }
@@ -181,3 +217,50 @@ func (c *billingCache) InvalidateSubscriptionCache(ctx context.Context, userID,
 	key := billingSubKey(userID, groupID)
 	return c.rdb.Del(ctx, key).Err()
 }
+
+func (c *billingCache) GetTokenQuotaUsage(ctx context.Context, apiKeyID int64, day, month string) (*service.TokenQuotaUsage, error) {
+	pipe := c.rdb.Pipeline()
+	dayCmd := pipe.HMGet(ctx, billingKeyQuotaDayKey(apiKeyID, day), keyQuotaFieldTokens, keyQuotaFieldRequests)
+	monthCmd := pipe.HMGet(ctx, billingKeyQuotaMonthKey(apiKeyID, month), keyQuotaFieldTokens, keyQuotaFieldRequests)
+	if _, err := pipe.Exec(ctx); err != nil && !errors.Is(err, redis.Nil) {
+		return nil, err
+	}
+
+	usage := &service.TokenQuotaUsage{}
+	dayVals := dayCmd.Val()
+	monthVals := monthCmd.Val()
+	if len(dayVals) == 2 {
+		usage.DailyTokens = parseQuotaCounter(dayVals[0])
+		usage.DailyRequests = parseQuotaCounter(dayVals[1])
+	}
+	if len(monthVals) == 2 {
+		usage.MonthlyTokens = parseQuotaCounter(monthVals[0])
+		usage.MonthlyRequests = parseQuotaCounter(monthVals[1])
+	}
+	return usage, nil
+}
+
+func (c *billingCache) IncrementTokenQuotaUsage(ctx context.Context, apiKeyID int64, day, month string, tokens int64) error {
+	dayKey := billingKeyQuotaDayKey(apiKeyID, day)
+	monthKey := billingKeyQuotaMonthKey(apiKeyID, month)
+
+	pipe := c.rdb.TxPipeline()
+	pipe.HIncrBy(ctx, dayKey, keyQuotaFieldTokens, tokens)
+	pipe.HIncrBy(ctx, dayKey, keyQuotaFieldRequests, 1)
+	pipe.Expire(ctx, dayKey, billingKeyQuotaDayTTL)
+	pipe.HIncrBy(ctx, monthKey, keyQuotaFieldTokens, tokens)
+	pipe.HIncrBy(ctx, monthKey, keyQuotaFieldRequests, 1)
+	pipe.Expire(ctx, monthKey, billingKeyQuotaMonthTTL)
+	_, err := pipe.Exec(ctx)
+	return err
+}
+
+// parseQuotaCounter 解析 HMGET 返回的计数值，缺失字段视为 0
+func parseQuotaCounter(v any) int64 {
+	str, ok := v.(string)
+	if !ok {
+		return 0
+	}
+	n, _ := strconv.ParseInt(str, 10, 64)
+	return n
+}
@@ -278,6 +278,38 @@ func (s *BillingCacheSuite) TestSubscriptionCache() {
 	}
 }
 
+func (s *BillingCacheSuite) TestTokenQuotaUsage() {
+	rdb := testRedis(s.T())
+	cache := NewBillingCache(rdb)
+	ctx := context.Background()
+	apiKeyID := int64(7)
+
+	usage, err := cache.GetTokenQuotaUsage(ctx, apiKeyID, "20260131", "202601")
+	require.NoError(s.T(), err, "GetTokenQuotaUsage on missing keys")
+	require.Equal(s.T(), &service.TokenQuotaUsage{}, usage)
+
+	require.NoError(s.T(), cache.IncrementTokenQuotaUsage(ctx, apiKeyID, "20260131", "202601", 120))
+	require.NoError(s.T(), cache.IncrementTokenQuotaUsage(ctx, apiKeyID, "20260131", "202601", 30))
+	// 跨日后日计数重新开始，月计数继续累加
+	require.NoError(s.T(), cache.IncrementTokenQuotaUsage(ctx, apiKeyID, "20260201", "202601", 5))
+
+	usage, err = cache.GetTokenQuotaUsage(ctx, apiKeyID, "20260131", "202601")
+	require.NoError(s.T(), err)
+	require.Equal(s.T(), &service.TokenQuotaUsage{DailyTokens: 150, DailyRequests: 2, MonthlyTokens: 155, MonthlyRequests: 3}, usage)
+
+	usage, err = cache.GetTokenQuotaUsage(ctx, apiKeyID, "20260201", "202601")
+	require.NoError(s.T(), err)
+	require.Equal(s.T(), int64(5), usage.DailyTokens)
+	require.Equal(s.T(), int64(1), usage.DailyRequests)
+
+	ttl, err := rdb.TTL(ctx, billingKeyQuotaDayKey(apiKeyID, "20260131")).Result()
+	require.NoError(s.T(), err, "TTL")
+	s.AssertTTLWithin(ttl, 1*time.Second, billingKeyQuotaDayTTL)
+	ttl, err = rdb.TTL(ctx, billingKeyQuotaMonthKey(apiKeyID, "202601")).Result()
+	require.NoError(s.T(), err, "TTL")
+	s.AssertTTLWithin(ttl, 1*time.Second, billingKeyQuotaMonthTTL)
+}
+
 func TestBillingCacheSuite(t *testing.T) {
 	suite.Run(t, new(BillingCacheSuite))
 }
@@ -0,0 +1,28 @@
+package repository
+
+import (
+	"context"
+	"time"
+
+	"github.com/Wei-Shaw/sub2api/internal/service"
+	"github.com/redis/go-redis/v9"
+)
+
+const budgetAlertKeyPrefix = "budget_alert:"
+
+// budgetAlertKey generates the Redis key for budget alert deduplication.
+func budgetAlertKey(key string) string {
+	return budgetAlertKeyPrefix + key
+}
+
+type budgetAlertCache struct {
+	rdb *redis.Client
+}
+
+func NewBudgetAlertCache(rdb *redis.Client) service.BudgetAlertCache {
+	return &budgetAlertCache{rdb: rdb}
+}
+
+func (c *budgetAlertCache) MarkBudgetAlertSent(ctx context.Context, key string, ttl time.Duration) (bool, error) {
+	return c.rdb.SetNX(ctx, budgetAlertKey(key), time.Now().Unix(), ttl).Result()
+}
@@ -5,6 +5,7 @@ import (
 	"errors"
 	"fmt"
 	"strconv"
+	"time"
 
 	"github.com/Wei-Shaw/sub2api/internal/service"
 	"github.com/redis/go-redis/v9"
@@ -76,6 +77,58 @@ var (
 		return 0
 	`)
 
+	// reserveScript 两阶段选择的槽位预占：仅当当前并发数不超过调用方观察到的快照值且未达上限时写入，
+	// 预占成员的分数取 now - slotTTL + reserveTTL，未确认时会在 reserveTTL 后被常规清理移除
+	// KEYS[1] = 有序集合键
+	// ARGV[1] = maxConcurrency
+	// ARGV[2] = expectedConcurrency（负载快照中的并发数）
+	// ARGV[3] = 槽位 TTL（秒）
+	// ARGV[4] = 预占 TTL（秒）
+	// ARGV[5] = requestID
+	// 返回 {是否预占成功, 当前并发数}
+	reserveScript = redis.NewScript(`
+		local key = KEYS[1]
+		local maxConcurrency = tonumber(ARGV[1])
+		local expected = tonumber(ARGV[2])
+		local ttl = tonumber(ARGV[3])
+		local reserveTTL = tonumber(ARGV[4])
+		local requestID = ARGV[5]
+
+		local timeResult = redis.call('TIME')
+		local now = tonumber(timeResult[1])
+		redis.call('ZREMRANGEBYSCORE', key, '-inf', now - ttl)
+
+		local count = redis.call('ZCARD', key)
+		if count >= maxConcurrency or count > expected then
+			return {0, count}
+		end
+
+		redis.call('ZADD', key, now - ttl + reserveTTL, requestID)
+		redis.call('EXPIRE', key, ttl)
+		return {1, count + 1}
+	`)
+
+	// commitScript 将预占转为正式槽位（刷新分数为当前时间）；预占已过期时返回 0
+	// KEYS[1] = 有序集合键
+	// ARGV[1] = 槽位 TTL（秒）
+	// ARGV[2] = requestID
+	commitScript = redis.NewScript(`
+		local key = KEYS[1]
+		local ttl = tonumber(ARGV[1])
+		local requestID = ARGV[2]
+
+		local timeResult = redis.call('TIME')
+		local now = tonumber(timeResult[1])
+		redis.call('ZREMRANGEBYSCORE', key, '-inf', now - ttl)
+
+		if redis.call('ZSCORE', key, requestID) == false then
+			return 0
+		end
+		redis.call('ZADD', key, now, requestID)
+		redis.call('EXPIRE', key, ttl)
+		return 1
+	`)
+
 	// getCountScript 统计有序集合中的槽位数量并清理过期条目
 	// 使用 Redis TIME 命令获取服务器时间
 	// KEYS[1] = 有序集合键
@@ -294,6 +347,11 @@ func accountWaitKey(accountID int64) string {
 	return fmt.Sprintf("%s%d", accountWaitKeyPrefix, accountID)
 }
 
+// ResetAccountSlots 删除账号的并发槽位与等待计数（账号删除/禁用后清理）
+func (c *concurrencyCache) ResetAccountSlots(ctx context.Context, accountID int64) error {
+	return c.rdb.Del(ctx, accountSlotKey(accountID), accountWaitKey(accountID)).Err()
+}
+
 // Account slot operations
 
 func (c *concurrencyCache) AcquireAccountSlot(ctx context.Context, accountID int64, maxConcurrency int, requestID string) (bool, error) {
@@ -306,6 +364,34 @@ func (c *concurrencyCache) AcquireAccountSlot(ctx context.Context, accountID int
 	return result == 1, nil
 }
 
+func (c *concurrencyCache) ReserveAccountSlot(ctx context.Context, accountID int64, maxConcurrency, expectedConcurrency int, requestID string, ttl time.Duration) (bool, int, error) {
+	key := accountSlotKey(accountID)
+	reserveTTLSeconds := int(ttl / time.Second)
+	if reserveTTLSeconds <= 0 {
+		reserveTTLSeconds = 1
+	}
+	if reserveTTLSeconds > c.slotTTLSeconds {
+		reserveTTLSeconds = c.slotTTLSeconds
+	}
+	result, err := reserveScript.Run(ctx, c.rdb, []string{key}, maxConcurrency, expectedConcurrency, c.slotTTLSeconds, reserveTTLSeconds, requestID).Int64Slice()
+	if err != nil {
+		return false, 0, err
+	}
+	if len(result) != 2 {
+		return false, 0, fmt.Errorf("unexpected reserve result length: %d", len(result))
+	}
+	return result[0] == 1, int(result[1]), nil
+}
+
+func (c *concurrencyCache) CommitAccountSlot(ctx context.Context, accountID int64, requestID string) (bool, error) {
+	key := accountSlotKey(accountID)
+	result, err := commitScript.Run(ctx, c.rdb, []string{key}, c.slotTTLSeconds, requestID).Int()
+	if err != nil {
+		return false, err
+	}
+	return result == 1, nil
+}
+
 func (c *concurrencyCache) ReleaseAccountSlot(ctx context.Context, accountID int64, requestID string) error {
 	key := accountSlotKey(accountID)
 	return c.rdb.ZRem(ctx, key, requestID).Err()
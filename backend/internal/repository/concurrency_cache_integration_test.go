@@ -57,6 +57,44 @@ func (s *ConcurrencyCacheSuite) TestAccountSlot_AcquireAndRelease() {
 	require.Equal(s.T(), 1, cur, "expected 1 after release")
 }
 
+func (s *ConcurrencyCacheSuite) TestAccountSlot_ReserveAndCommit() {
+	accountID := int64(13)
+	slotKey := fmt.Sprintf("%s%d", accountSlotKeyPrefix, accountID)
+
+	ok, err := s.cache.AcquireAccountSlot(s.ctx, accountID, 3, "existing")
+	require.NoError(s.T(), err)
+	require.True(s.T(), ok)
+
+	// 快照并发数落后于实际值时预占失败，并返回最新并发数
+	ok, cur, err := s.cache.ReserveAccountSlot(s.ctx, accountID, 3, 0, "stale", 30*time.Second)
+	require.NoError(s.T(), err, "ReserveAccountSlot stale")
+	require.False(s.T(), ok)
+	require.Equal(s.T(), 1, cur)
+
+	ok, cur, err = s.cache.ReserveAccountSlot(s.ctx, accountID, 3, 1, "reserved", 30*time.Second)
+	require.NoError(s.T(), err, "ReserveAccountSlot")
+	require.True(s.T(), ok)
+	require.Equal(s.T(), 2, cur)
+
+	// 预占计入并发数，分数早于当前时间以便未确认时提前过期
+	reservedScore, err := s.rdb.ZScore(s.ctx, slotKey, "reserved").Result()
+	require.NoError(s.T(), err)
+	existingScore, err := s.rdb.ZScore(s.ctx, slotKey, "existing").Result()
+	require.NoError(s.T(), err)
+	require.Less(s.T(), reservedScore, existingScore)
+
+	committed, err := s.cache.CommitAccountSlot(s.ctx, accountID, "reserved")
+	require.NoError(s.T(), err, "CommitAccountSlot")
+	require.True(s.T(), committed)
+	committedScore, err := s.rdb.ZScore(s.ctx, slotKey, "reserved").Result()
+	require.NoError(s.T(), err)
+	require.GreaterOrEqual(s.T(), committedScore, existingScore)
+
+	committed, err = s.cache.CommitAccountSlot(s.ctx, accountID, "missing")
+	require.NoError(s.T(), err, "CommitAccountSlot missing")
+	require.False(s.T(), committed)
+}
+
 func (s *ConcurrencyCacheSuite) TestAccountSlot_TTL() {
 	accountID := int64(11)
 	reqID := "req_ttl_test"
@@ -407,6 +445,70 @@ func (s *ConcurrencyCacheSuite) TestCleanupExpiredAccountSlots_NoExpired() {
 	require.Equal(s.T(), 2, cur)
 }
 
+func (s *ConcurrencyCacheSuite) TestReconcile_SlotOwnersAndPrune() {
+	reconciler, ok := s.cache.(service.ConcurrencyStateReconciler)
+	require.True(s.T(), ok, "cache should support reconciliation")
+
+	require.NoError(s.T(), reconciler.HeartbeatSlotOwner(s.ctx, "pod-a", "boot2", time.Minute))
+	owners, err := reconciler.ListSlotOwners(s.ctx)
+	require.NoError(s.T(), err)
+	require.Equal(s.T(), map[string]string{"pod-a": "boot2"}, owners)
+
+	for _, member := range []string{"pod-a:boot1:r1", "pod-a:boot2:r2", "legacy"} {
+		ok, err := s.cache.AcquireAccountSlot(s.ctx, 300, 10, member)
+		require.NoError(s.T(), err)
+		require.True(s.T(), ok)
+	}
+	ok, err = s.cache.AcquireUserSlot(s.ctx, 301, 10, "pod-c:boot1:r3")
+	require.NoError(s.T(), err)
+	require.True(s.T(), ok)
+	expiredTime := time.Now().Unix() - int64(testSlotTTL.Seconds()) - 10
+	require.NoError(s.T(), s.rdb.ZAdd(s.ctx, accountSlotKey(300), redis.Z{Score: float64(expiredTime), Member: "expired"}).Err())
+
+	keys, expired, leaked, err := reconciler.PruneSlots(s.ctx, func(member string) bool {
+		return member == "pod-a:boot1:r1" || member == "pod-c:boot1:r3"
+	})
+	require.NoError(s.T(), err)
+	require.Equal(s.T(), 2, keys)
+	require.Equal(s.T(), 1, expired)
+	require.Equal(s.T(), 2, leaked)
+
+	members, err := s.rdb.ZRange(s.ctx, accountSlotKey(300), 0, -1).Result()
+	require.NoError(s.T(), err)
+	require.ElementsMatch(s.T(), []string{"pod-a:boot2:r2", "legacy"}, members)
+	cur, err := s.cache.GetUserConcurrency(s.ctx, 301)
+	require.NoError(s.T(), err)
+	require.Equal(s.T(), 0, cur)
+}
+
+func (s *ConcurrencyCacheSuite) TestReconcile_ValidateWaitCounters() {
+	reconciler, ok := s.cache.(service.ConcurrencyStateReconciler)
+	require.True(s.T(), ok)
+
+	require.NoError(s.T(), s.rdb.Set(s.ctx, waitQueueKey(400), "3", time.Minute).Err())
+	require.NoError(s.T(), s.rdb.Set(s.ctx, waitQueueKey(401), "-2", time.Minute).Err())
+	require.NoError(s.T(), s.rdb.Set(s.ctx, accountWaitKey(402), "abc", 0).Err())
+	require.NoError(s.T(), s.rdb.Set(s.ctx, accountWaitKey(403), "5", 0).Err())
+	require.NoError(s.T(), s.rdb.ZAdd(s.ctx, accountWaitKey(404), redis.Z{Score: 1, Member: "x"}).Err())
+
+	keys, repaired, err := reconciler.ValidateWaitCounters(s.ctx)
+	require.NoError(s.T(), err)
+	require.Equal(s.T(), 5, keys)
+	require.Equal(s.T(), 4, repaired)
+
+	val, err := s.rdb.Get(s.ctx, waitQueueKey(400)).Result()
+	require.NoError(s.T(), err)
+	require.Equal(s.T(), "3", val)
+	for _, key := range []string{waitQueueKey(401), accountWaitKey(402), accountWaitKey(404)} {
+		exists, err := s.rdb.Exists(s.ctx, key).Result()
+		require.NoError(s.T(), err)
+		require.Zero(s.T(), exists, key)
+	}
+	ttl, err := s.rdb.TTL(s.ctx, accountWaitKey(403)).Result()
+	require.NoError(s.T(), err)
+	require.Greater(s.T(), ttl, time.Duration(0))
+}
+
 func TestConcurrencyCacheSuite(t *testing.T) {
 	suite.Run(t, new(ConcurrencyCacheSuite))
 }
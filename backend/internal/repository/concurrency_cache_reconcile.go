@@ -0,0 +1,158 @@
+package repository
+
+import (
+	"context"
+	"strings"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+)
+
+const (
+	// 实例心跳键格式: concurrency:instance:{instanceID}，值为 bootID
+	slotOwnerKeyPrefix = "concurrency:instance:"
+
+	reconcileScanCount = 500
+)
+
+// validateWaitCounterScript 校验等待计数键
+// KEYS[1] = 等待计数键
+// ARGV[1] = TTL（秒）
+// 返回 0=正常，1=已删除（类型错误/非数字/负数），2=已补设 TTL
+var validateWaitCounterScript = redis.NewScript(`
+	local value = redis.pcall('GET', KEYS[1])
+	if type(value) == 'table' and value.err then
+		redis.call('DEL', KEYS[1])
+		return 1
+	end
+	if value == false then
+		return 0
+	end
+	local n = tonumber(value)
+	if n == nil or n < 0 or n ~= math.floor(n) then
+		redis.call('DEL', KEYS[1])
+		return 1
+	end
+	if redis.call('TTL', KEYS[1]) == -1 then
+		redis.call('EXPIRE', KEYS[1], tonumber(ARGV[1]))
+		return 2
+	end
+	return 0
+`)
+
+func slotOwnerKey(instanceID string) string {
+	return slotOwnerKeyPrefix + instanceID
+}
+
+// scanKeys 按模式遍历键（SCAN，不阻塞 Redis）
+func (c *concurrencyCache) scanKeys(ctx context.Context, pattern string, fn func(key string) error) error {
+	var cursor uint64
+	for {
+		keys, next, err := c.rdb.Scan(ctx, cursor, pattern, reconcileScanCount).Result()
+		if err != nil {
+			return err
+		}
+		for _, key := range keys {
+			if err := fn(key); err != nil {
+				return err
+			}
+		}
+		if next == 0 {
+			return nil
+		}
+		cursor = next
+	}
+}
+
+// HeartbeatSlotOwner 写入/续期实例心跳
+func (c *concurrencyCache) HeartbeatSlotOwner(ctx context.Context, instanceID, bootID string, ttl time.Duration) error {
+	return c.rdb.Set(ctx, slotOwnerKey(instanceID), bootID, ttl).Err()
+}
+
+// ListSlotOwners 返回心跳仍有效的实例：instanceID -> bootID
+func (c *concurrencyCache) ListSlotOwners(ctx context.Context) (map[string]string, error) {
+	var keys []string
+	if err := c.scanKeys(ctx, slotOwnerKeyPrefix+"*", func(key string) error {
+		keys = append(keys, key)
+		return nil
+	}); err != nil {
+		return nil, err
+	}
+	owners := make(map[string]string, len(keys))
+	if len(keys) == 0 {
+		return owners, nil
+	}
+	values, err := c.rdb.MGet(ctx, keys...).Result()
+	if err != nil {
+		return nil, err
+	}
+	for i, v := range values {
+		bootID, ok := v.(string)
+		if !ok || bootID == "" {
+			continue
+		}
+		if _, instanceID, found := strings.Cut(keys[i], slotOwnerKeyPrefix); found && instanceID != "" {
+			owners[instanceID] = bootID
+		}
+	}
+	return owners, nil
+}
+
+// PruneSlots 遍历账号/用户槽位有序集合：先清理过期成员，再删除 isLeaked 判定为遗留的成员
+func (c *concurrencyCache) PruneSlots(ctx context.Context, isLeaked func(member string) bool) (keys, expired, leaked int, err error) {
+	for _, prefix := range []string{accountSlotKeyPrefix, userSlotKeyPrefix} {
+		err = c.scanKeys(ctx, prefix+"*", func(key string) error {
+			keys++
+			removed, err := cleanupExpiredSlotsScript.Run(ctx, c.rdb, []string{key}, c.slotTTLSeconds).Int()
+			if err != nil {
+				return err
+			}
+			expired += removed
+
+			members, err := c.rdb.ZRange(ctx, key, 0, -1).Result()
+			if err != nil {
+				return err
+			}
+			var stale []any
+			for _, member := range members {
+				if isLeaked(member) {
+					stale = append(stale, member)
+				}
+			}
+			if len(stale) == 0 {
+				return nil
+			}
+			n, err := c.rdb.ZRem(ctx, key, stale...).Result()
+			if err != nil {
+				return err
+			}
+			leaked += int(n)
+			return nil
+		})
+		if err != nil {
+			return keys, expired, leaked, err
+		}
+	}
+	return keys, expired, leaked, nil
+}
+
+// ValidateWaitCounters 校验用户/账号等待计数键
+func (c *concurrencyCache) ValidateWaitCounters(ctx context.Context) (keys, repaired int, err error) {
+	for _, prefix := range []string{waitQueueKeyPrefix, accountWaitKeyPrefix} {
+		err = c.scanKeys(ctx, prefix+"*", func(key string) error {
+			keys++
+			status, err := validateWaitCounterScript.Run(ctx, c.rdb, []string{key}, c.waitQueueTTLSeconds).Int()
+			if err != nil {
+				return err
+			}
+			if status != 0 {
+				repaired++
+			}
+			return nil
+		})
+		if err != nil {
+			return keys, repaired, err
+		}
+	}
+	return keys, repaired, nil
+}
@@ -5,11 +5,13 @@ package repository
 import (
 	"context"
 	"database/sql"
+	"fmt"
 	"time"
 
 	"github.com/Wei-Shaw/sub2api/ent"
 	"github.com/Wei-Shaw/sub2api/internal/config"
 	"github.com/Wei-Shaw/sub2api/internal/pkg/timezone"
+	"github.com/Wei-Shaw/sub2api/internal/service"
 	"github.com/Wei-Shaw/sub2api/migrations"
 
 	"entgo.io/ent/dialect"
@@ -20,7 +22,7 @@ import (
 // InitEnt 初始化 Ent ORM 客户端并返回客户端实例和底层的 *sql.DB。
 //
 // 该函数执行以下操作：
-//  1. 初始化全局时区设置，确保时间处理一致性
+//  1. 初始化全局时区设置与账号凭证静态加密
 //  2. 建立 PostgreSQL 数据库连接
 //  3. 自动执行数据库迁移，确保 schema 与代码同步
 //  4. 创建并返回 Ent 客户端实例
@@ -41,6 +43,11 @@ func InitEnt(cfg *config.Config) (*ent.Client, *sql.DB, error) {
 		return nil, nil, err
 	}
 
+	// 账号凭证静态加密需在任何账号读写之前就绪（主密钥可能来自 KMS 命令）。
+	if err := service.InitCredentialEncryption(context.Background(), cfg); err != nil {
+		return nil, nil, fmt.Errorf("init credential encryption: %w", err)
+	}
+
 	// 构建包含时区信息的数据库连接字符串 (DSN)。
 	// 时区信息会传递给 PostgreSQL，确保数据库层面的时间处理正确。
 	dsn := cfg.Database.DSNWithTimezone(cfg.Timezone)
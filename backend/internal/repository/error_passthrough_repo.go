@@ -63,6 +63,12 @@ func (r *errorPassthroughRepository) Create(ctx context.Context, rule *model.Err
 	if len(rule.Keywords) > 0 {
 		builder.SetKeywords(rule.Keywords)
 	}
+	if len(rule.BodyPatterns) > 0 {
+		builder.SetBodyPatterns(rule.BodyPatterns)
+	}
+	if len(rule.HeaderMatches) > 0 {
+		builder.SetHeaderMatches(rule.HeaderMatches)
+	}
 	if len(rule.Platforms) > 0 {
 		builder.SetPlatforms(rule.Platforms)
 	}
@@ -105,6 +111,16 @@ func (r *errorPassthroughRepository) Update(ctx context.Context, rule *model.Err
 	} else {
 		builder.ClearKeywords()
 	}
+	if len(rule.BodyPatterns) > 0 {
+		builder.SetBodyPatterns(rule.BodyPatterns)
+	} else {
+		builder.ClearBodyPatterns()
+	}
+	if len(rule.HeaderMatches) > 0 {
+		builder.SetHeaderMatches(rule.HeaderMatches)
+	} else {
+		builder.ClearHeaderMatches()
+	}
 	if len(rule.Platforms) > 0 {
 		builder.SetPlatforms(rule.Platforms)
 	} else {
@@ -147,6 +163,8 @@ func (r *errorPassthroughRepository) toModel(e *ent.ErrorPassthroughRule) *model
 		Priority:        e.Priority,
 		ErrorCodes:      e.ErrorCodes,
 		Keywords:        e.Keywords,
+		BodyPatterns:    e.BodyPatterns,
+		HeaderMatches:   e.HeaderMatches,
 		MatchMode:       e.MatchMode,
 		Platforms:       e.Platforms,
 		PassthroughCode: e.PassthroughCode,
@@ -173,6 +191,12 @@ func (r *errorPassthroughRepository) toModel(e *ent.ErrorPassthroughRule) *model
 	if rule.Keywords == nil {
 		rule.Keywords = []string{}
 	}
+	if rule.BodyPatterns == nil {
+		rule.BodyPatterns = []string{}
+	}
+	if rule.HeaderMatches == nil {
+		rule.HeaderMatches = map[string]string{}
+	}
 	if rule.Platforms == nil {
 		rule.Platforms = []string{}
 	}
@@ -2,7 +2,10 @@ package repository
 
 import (
 	"context"
+	"errors"
 	"fmt"
+	"strconv"
+	"strings"
 	"time"
 
 	"github.com/Wei-Shaw/sub2api/internal/service"
@@ -11,6 +14,22 @@ import (
 
 const stickySessionPrefix = "sticky_session:"
 
+// stickySessionAccountPrefix 按账号的会话反向索引（集合，成员为 {groupID}:{sessionHash}）
+const stickySessionAccountPrefix = "sticky_session_account:"
+
+// refreshGatewaySessionScript 刷新会话 TTL，并同步续期该会话所属账号的反向索引
+var refreshGatewaySessionScript = redis.NewScript(`
+local v = redis.call('GET', KEYS[1])
+if not v then
+	return 0
+end
+redis.call('EXPIRE', KEYS[1], ARGV[1])
+local idx = ARGV[2] .. v
+redis.call('SADD', idx, ARGV[3])
+redis.call('EXPIRE', idx, ARGV[1])
+return 1
+`)
+
 type gatewayCache struct {
 	rdb *redis.Client
 }
@@ -25,6 +44,14 @@ func buildSessionKey(groupID int64, sessionHash string) string {
 	return fmt.Sprintf("%s%d:%s", stickySessionPrefix, groupID, sessionHash)
 }
 
+func buildAccountSessionIndexKey(accountID int64) string {
+	return fmt.Sprintf("%s%d", stickySessionAccountPrefix, accountID)
+}
+
+func buildSessionIndexMember(groupID int64, sessionHash string) string {
+	return strconv.FormatInt(groupID, 10) + ":" + sessionHash
+}
+
 func (c *gatewayCache) GetSessionAccountID(ctx context.Context, groupID int64, sessionHash string) (int64, error) {
 	key := buildSessionKey(groupID, sessionHash)
 	return c.rdb.Get(ctx, key).Int64()
@@ -32,12 +59,23 @@ func (c *gatewayCache) GetSessionAccountID(ctx context.Context, groupID int64, s
 
 func (c *gatewayCache) SetSessionAccountID(ctx context.Context, groupID int64, sessionHash string, accountID int64, ttl time.Duration) error {
 	key := buildSessionKey(groupID, sessionHash)
-	return c.rdb.Set(ctx, key, accountID, ttl).Err()
+	idx := buildAccountSessionIndexKey(accountID)
+	_, err := c.rdb.TxPipelined(ctx, func(pipe redis.Pipeliner) error {
+		pipe.Set(ctx, key, accountID, ttl)
+		pipe.SAdd(ctx, idx, buildSessionIndexMember(groupID, sessionHash))
+		pipe.Expire(ctx, idx, ttl)
+		return nil
+	})
+	return err
 }
 
 func (c *gatewayCache) RefreshSessionTTL(ctx context.Context, groupID int64, sessionHash string, ttl time.Duration) error {
 	key := buildSessionKey(groupID, sessionHash)
-	return c.rdb.Expire(ctx, key, ttl).Err()
+	seconds := int64(ttl / time.Second)
+	if seconds <= 0 {
+		seconds = 1
+	}
+	return refreshGatewaySessionScript.Run(ctx, c.rdb, []string{key}, seconds, stickySessionAccountPrefix, buildSessionIndexMember(groupID, sessionHash)).Err()
 }
 
 // DeleteSessionAccountID 删除粘性会话与账号的绑定关系。
@@ -51,3 +89,59 @@ func (c *gatewayCache) DeleteSessionAccountID(ctx context.Context, groupID int64
 	key := buildSessionKey(groupID, sessionHash)
 	return c.rdb.Del(ctx, key).Err()
 }
+
+// ListAccountSessions 返回仍绑定到该账号的粘性会话。
+// 索引成员对应的会话已过期或已改绑到其他账号时，从索引中移除。
+func (c *gatewayCache) ListAccountSessions(ctx context.Context, accountID int64) ([]service.StickySessionBinding, error) {
+	idx := buildAccountSessionIndexKey(accountID)
+	members, err := c.rdb.SMembers(ctx, idx).Result()
+	if err != nil || len(members) == 0 {
+		return nil, err
+	}
+
+	type parsedMember struct {
+		raw     string
+		binding service.StickySessionBinding
+		cmd     *redis.StringCmd
+	}
+	parsed := make([]parsedMember, 0, len(members))
+	stale := make([]any, 0)
+	pipe := c.rdb.Pipeline()
+	for _, m := range members {
+		groupPart, hash, ok := strings.Cut(m, ":")
+		groupID, convErr := strconv.ParseInt(groupPart, 10, 64)
+		if !ok || convErr != nil || hash == "" {
+			stale = append(stale, m)
+			continue
+		}
+		parsed = append(parsed, parsedMember{
+			raw:     m,
+			binding: service.StickySessionBinding{GroupID: groupID, SessionHash: hash},
+			cmd:     pipe.Get(ctx, buildSessionKey(groupID, hash)),
+		})
+	}
+	if len(parsed) > 0 {
+		if _, err := pipe.Exec(ctx); err != nil && !errors.Is(err, redis.Nil) {
+			return nil, err
+		}
+	}
+
+	bindings := make([]service.StickySessionBinding, 0, len(parsed))
+	for _, p := range parsed {
+		bound, err := p.cmd.Int64()
+		if err != nil || bound != accountID {
+			stale = append(stale, p.raw)
+			continue
+		}
+		bindings = append(bindings, p.binding)
+	}
+	if len(stale) > 0 {
+		_ = c.rdb.SRem(ctx, idx, stale...).Err()
+	}
+	return bindings, nil
+}
+
+// ClearAccountSessionIndex 删除账号的会话反向索引
+func (c *gatewayCache) ClearAccountSessionIndex(ctx context.Context, accountID int64) error {
+	return c.rdb.Del(ctx, buildAccountSessionIndexKey(accountID)).Err()
+}
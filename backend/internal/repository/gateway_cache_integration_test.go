@@ -104,6 +104,33 @@ func (s *GatewayCacheSuite) TestGetSessionAccountID_CorruptedValue() {
 	require.False(s.T(), errors.Is(err, redis.Nil), "expected parsing error, not redis.Nil")
 }
 
+func (s *GatewayCacheSuite) TestListAccountSessions() {
+	index, ok := s.cache.(service.AccountSessionIndex)
+	require.True(s.T(), ok, "gateway cache should implement AccountSessionIndex")
+
+	accountID := int64(200)
+	require.NoError(s.T(), s.cache.SetSessionAccountID(s.ctx, 1, "openai:a", accountID, time.Minute))
+	require.NoError(s.T(), s.cache.SetSessionAccountID(s.ctx, 2, "b", accountID, time.Minute))
+	require.NoError(s.T(), s.cache.SetSessionAccountID(s.ctx, 3, "moved", accountID, time.Minute))
+	// 会话已改绑到其他账号，应从索引中剔除
+	require.NoError(s.T(), s.cache.SetSessionAccountID(s.ctx, 3, "moved", 201, time.Minute))
+
+	bindings, err := index.ListAccountSessions(s.ctx, accountID)
+	require.NoError(s.T(), err)
+	require.ElementsMatch(s.T(), []service.StickySessionBinding{
+		{GroupID: 1, SessionHash: "openai:a"},
+		{GroupID: 2, SessionHash: "b"},
+	}, bindings)
+
+	members, err := s.rdb.SMembers(s.ctx, buildAccountSessionIndexKey(accountID)).Result()
+	require.NoError(s.T(), err)
+	require.Len(s.T(), members, 2)
+
+	require.NoError(s.T(), index.ClearAccountSessionIndex(s.ctx, accountID))
+	bindings, err = index.ListAccountSessions(s.ctx, accountID)
+	require.NoError(s.T(), err)
+	require.Empty(s.T(), bindings)
+}
 
 func TestGatewayCacheSuite(t *testing.T) {
 	suite.Run(t, new(GatewayCacheSuite))
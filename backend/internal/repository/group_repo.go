@@ -52,13 +52,45 @@ func (r *groupRepository) Create(ctx context.Context, groupIn *service.Group) er
 		SetNillableFallbackGroupID(groupIn.FallbackGroupID).
 		SetNillableFallbackGroupIDOnInvalidRequest(groupIn.FallbackGroupIDOnInvalidRequest).
 		SetModelRoutingEnabled(groupIn.ModelRoutingEnabled).
-		SetMcpXMLInject(groupIn.MCPXMLInject)
+		SetMcpXMLInject(groupIn.MCPXMLInject).
+		SetMeteringOnly(groupIn.MeteringOnly).
+		SetStrictRequestFields(groupIn.StrictRequestFields)
 
 	// 设置模型路由配置
 	if groupIn.ModelRouting != nil {
 		builder = builder.SetModelRouting(groupIn.ModelRouting)
 	}
 
+	// 设置模型参数策略
+	if groupIn.ModelParamPolicies != nil {
+		builder = builder.SetModelParamPolicies(groupIn.ModelParamPolicies)
+	}
+
+	// 设置区域策略
+	if !groupIn.RegionPolicy.IsEmpty() {
+		builder = builder.SetRegionPolicy(groupIn.RegionPolicy)
+	}
+
+	// 设置系统提示词注入策略
+	if !groupIn.SystemPromptPolicy.IsEmpty() {
+		builder = builder.SetSystemPromptPolicy(groupIn.SystemPromptPolicy)
+	}
+
+	// 设置提示词模板
+	if !groupIn.PromptTemplate.IsEmpty() {
+		builder = builder.SetPromptTemplate(groupIn.PromptTemplate)
+	}
+
+	// 设置模型访问策略
+	if !groupIn.ModelAccessPolicy.IsEmpty() {
+		builder = builder.SetModelAccessPolicy(groupIn.ModelAccessPolicy)
+	}
+
+	// 设置重试策略
+	if !groupIn.RetryPolicy.IsEmpty() {
+		builder = builder.SetRetryPolicy(groupIn.RetryPolicy)
+	}
+
 	// 设置支持的模型系列（始终设置，空数组表示不限制）
 	builder = builder.SetSupportedModelScopes(groupIn.SupportedModelScopes)
 
@@ -113,7 +145,9 @@ func (r *groupRepository) Update(ctx context.Context, groupIn *service.Group) er
 		SetDefaultValidityDays(groupIn.DefaultValidityDays).
 		SetClaudeCodeOnly(groupIn.ClaudeCodeOnly).
 		SetModelRoutingEnabled(groupIn.ModelRoutingEnabled).
-		SetMcpXMLInject(groupIn.MCPXMLInject)
+		SetMcpXMLInject(groupIn.MCPXMLInject).
+		SetMeteringOnly(groupIn.MeteringOnly).
+		SetStrictRequestFields(groupIn.StrictRequestFields)
 
 	// 处理 FallbackGroupID：nil 时清除，否则设置
 	if groupIn.FallbackGroupID != nil {
@@ -135,6 +169,48 @@ func (r *groupRepository) Update(ctx context.Context, groupIn *service.Group) er
 		builder = builder.ClearModelRouting()
 	}
 
+	// 处理 ModelParamPolicies：nil 时清除，否则设置
+	if groupIn.ModelParamPolicies != nil {
+		builder = builder.SetModelParamPolicies(groupIn.ModelParamPolicies)
+	} else {
+		builder = builder.ClearModelParamPolicies()
+	}
+
+	// 处理 RegionPolicy：未配置时清除
+	if !groupIn.RegionPolicy.IsEmpty() {
+		builder = builder.SetRegionPolicy(groupIn.RegionPolicy)
+	} else {
+		builder = builder.ClearRegionPolicy()
+	}
+
+	// 处理 SystemPromptPolicy：未配置时清除
+	if !groupIn.SystemPromptPolicy.IsEmpty() {
+		builder = builder.SetSystemPromptPolicy(groupIn.SystemPromptPolicy)
+	} else {
+		builder = builder.ClearSystemPromptPolicy()
+	}
+
+	// 处理 PromptTemplate：未配置时清除
+	if !groupIn.PromptTemplate.IsEmpty() {
+		builder = builder.SetPromptTemplate(groupIn.PromptTemplate)
+	} else {
+		builder = builder.ClearPromptTemplate()
+	}
+
+	// 处理 ModelAccessPolicy：未配置时清除
+	if !groupIn.ModelAccessPolicy.IsEmpty() {
+		builder = builder.SetModelAccessPolicy(groupIn.ModelAccessPolicy)
+	} else {
+		builder = builder.ClearModelAccessPolicy()
+	}
+
+	// 处理 RetryPolicy：未配置时清除
+	if !groupIn.RetryPolicy.IsEmpty() {
+		builder = builder.SetRetryPolicy(groupIn.RetryPolicy)
+	} else {
+		builder = builder.ClearRetryPolicy()
+	}
+
 	// 处理 SupportedModelScopes（始终设置，空数组表示不限制）
 	builder = builder.SetSupportedModelScopes(groupIn.SupportedModelScopes)
 
@@ -1,6 +1,7 @@
 package repository
 
 import (
+	"crypto/tls"
 	"errors"
 	"fmt"
 	"io"
@@ -16,8 +17,11 @@ import (
 	"github.com/Wei-Shaw/sub2api/internal/config"
 	"github.com/Wei-Shaw/sub2api/internal/pkg/proxyutil"
 	"github.com/Wei-Shaw/sub2api/internal/pkg/tlsfingerprint"
+	"github.com/Wei-Shaw/sub2api/internal/pkg/tracing"
 	"github.com/Wei-Shaw/sub2api/internal/service"
 	"github.com/Wei-Shaw/sub2api/internal/util/urlvalidator"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
 )
 
 // 默认配置常量
@@ -44,6 +48,16 @@ const (
 	defaultMaxUpstreamClients = 5000
 	// defaultClientIdleTTLSeconds: 默认客户端空闲回收阈值（15分钟）
 	defaultClientIdleTTLSeconds = 900
+	// defaultDialTimeout: 默认 TCP 建连超时
+	defaultDialTimeout = 10 * time.Second
+	// defaultTLSHandshakeTimeout: 默认 TLS 握手超时
+	defaultTLSHandshakeTimeout = 10 * time.Second
+	// defaultKeepAlive: 默认 TCP keep-alive 探测间隔
+	defaultKeepAlive = 30 * time.Second
+	// defaultHTTP2PingInterval: 默认 HTTP/2 连接静默探活间隔
+	defaultHTTP2PingInterval = 30 * time.Second
+	// defaultTLSSessionCacheSize: 默认每客户端 TLS 会话缓存条目数
+	defaultTLSSessionCacheSize = 64
 )
 
 var errUpstreamClientLimitReached = errors.New("upstream client cache limit reached")
@@ -56,6 +70,12 @@ type poolSettings struct {
 	maxConnsPerHost       int           // 每主机最大连接数（含活跃）
 	idleConnTimeout       time.Duration // 空闲连接超时时间
 	responseHeaderTimeout time.Duration // 等待响应头超时时间
+	http2                 bool          // 是否协商 HTTP/2
+	http2PingInterval     time.Duration // HTTP/2 静默探活间隔（0 表示不探活）
+	tlsSessionCacheSize   int           // TLS 会话缓存条目数（0 表示关闭）
+	dialTimeout           time.Duration // TCP 建连超时
+	tlsHandshakeTimeout   time.Duration // TLS 握手超时
+	keepAlive             time.Duration // TCP keep-alive 探测间隔
 }
 
 // upstreamClientEntry 上游客户端缓存条目
@@ -89,6 +109,8 @@ type httpUpstreamService struct {
 	cfg     *config.Config                  // 全局配置
 	mu      sync.RWMutex                    // 保护 clients map 的读写锁
 	clients map[string]*upstreamClientEntry // 客户端缓存池，key 由隔离策略决定
+
+	workerPools *accountWorkerPools // 账号级 worker 池（gateway.account_worker_pool 启用时生效）
 }
 
 // NewHTTPUpstream 创建通用 HTTP 上游服务
@@ -103,6 +125,8 @@ func NewHTTPUpstream(cfg *config.Config) service.HTTPUpstream {
 	return &httpUpstreamService{
 		cfg:     cfg,
 		clients: make(map[string]*upstreamClientEntry),
+
+		workerPools: newAccountWorkerPools(),
 	}
 }
 
@@ -127,18 +151,26 @@ func (s *httpUpstreamService) Do(req *http.Request, proxyURL string, accountID i
 		return nil, err
 	}
 
-	// 获取或创建对应的客户端，并标记请求占用
-	entry, err := s.acquireClient(proxyURL, accountID, accountConcurrency)
+	// 账号级硬隔离：占用账号 worker，直到响应体关闭才归还
+	releaseWorker, err := s.acquireAccountWorker(req, accountID, accountConcurrency)
+	if err != nil {
+		return nil, err
+	}
+
+	// 获取或创建对应的客户端，并标记请求占用；账号配置了 mTLS / 自定义 CA 时使用独立客户端
+	entry, err := s.acquireClientWithClientTLS(proxyURL, accountID, accountConcurrency, service.UpstreamClientTLSFromContext(req.Context()))
 	if err != nil {
+		releaseWorker()
 		return nil, err
 	}
 
 	// 执行请求
-	resp, err := entry.client.Do(req)
+	resp, err := doWithAttemptTimeout(entry.client, req)
 	if err != nil {
 		// 请求失败，立即减少计数
 		atomic.AddInt64(&entry.inFlight, -1)
 		atomic.StoreInt64(&entry.lastUsed, time.Now().UnixNano())
+		releaseWorker()
 		return nil, err
 	}
 
@@ -147,6 +179,7 @@ func (s *httpUpstreamService) Do(req *http.Request, proxyURL string, accountID i
 	resp.Body = wrapTrackedBody(resp.Body, func() {
 		atomic.AddInt64(&entry.inFlight, -1)
 		atomic.StoreInt64(&entry.lastUsed, time.Now().UnixNano())
+		releaseWorker()
 	})
 
 	return resp, nil
@@ -187,6 +220,12 @@ func (s *httpUpstreamService) DoWithTLS(req *http.Request, proxyURL string, acco
 		return nil, err
 	}
 
+	// 账号配置了 mTLS 客户端证书 / 自定义 CA：utls 握手不携带这些设置，回退到标准 TLS
+	if service.UpstreamClientTLSFromContext(req.Context()) != nil {
+		slog.Debug("tls_fingerprint_skipped_for_client_tls", "account_id", accountID)
+		return s.Do(req, proxyURL, accountID, accountConcurrency)
+	}
+
 	// 获取 TLS 指纹 Profile
 	registry := tlsfingerprint.GlobalRegistry()
 	profile := registry.GetProfileByAccountID(accountID)
@@ -198,19 +237,26 @@ func (s *httpUpstreamService) DoWithTLS(req *http.Request, proxyURL string, acco
 
 	slog.Debug("tls_fingerprint_using_profile", "account_id", accountID, "profile", profile.Name, "grease", profile.EnableGREASE)
 
+	releaseWorker, err := s.acquireAccountWorker(req, accountID, accountConcurrency)
+	if err != nil {
+		return nil, err
+	}
+
 	// 获取或创建带 TLS 指纹的客户端
 	entry, err := s.acquireClientWithTLS(proxyURL, accountID, accountConcurrency, profile)
 	if err != nil {
+		releaseWorker()
 		slog.Debug("tls_fingerprint_acquire_client_failed", "account_id", accountID, "error", err)
 		return nil, err
 	}
 
 	// 执行请求
-	resp, err := entry.client.Do(req)
+	resp, err := doWithAttemptTimeout(entry.client, req)
 	if err != nil {
 		// 请求失败，立即减少计数
 		atomic.AddInt64(&entry.inFlight, -1)
 		atomic.StoreInt64(&entry.lastUsed, time.Now().UnixNano())
+		releaseWorker()
 		slog.Debug("tls_fingerprint_request_failed", "account_id", accountID, "error", err)
 		return nil, err
 	}
@@ -221,6 +267,7 @@ func (s *httpUpstreamService) DoWithTLS(req *http.Request, proxyURL string, acco
 	resp.Body = wrapTrackedBody(resp.Body, func() {
 		atomic.AddInt64(&entry.inFlight, -1)
 		atomic.StoreInt64(&entry.lastUsed, time.Now().UnixNano())
+		releaseWorker()
 	})
 
 	return resp, nil
@@ -314,6 +361,7 @@ func (s *httpUpstreamService) getClientEntryWithTLS(proxyURL string, accountID i
 
 	s.evictIdleLocked(now)
 	s.evictOverLimitLocked()
+	service.SetUpstreamClientPools(len(s.clients))
 	s.mu.Unlock()
 	return entry, nil
 }
@@ -358,6 +406,11 @@ func (s *httpUpstreamService) acquireClient(proxyURL string, accountID int64, ac
 	return s.getClientEntry(proxyURL, accountID, accountConcurrency, true, true)
 }
 
+// acquireClientWithClientTLS 获取或创建客户端并标记进行中请求；clientTLS 非空时使用带账号 TLS 设置的客户端
+func (s *httpUpstreamService) acquireClientWithClientTLS(proxyURL string, accountID int64, accountConcurrency int, clientTLS *service.UpstreamClientTLS) (*upstreamClientEntry, error) {
+	return s.getClientEntryWithClientTLS(proxyURL, accountID, accountConcurrency, clientTLS, true, true)
+}
+
 // getOrCreateClient 获取或创建客户端
 // 根据隔离策略和参数决定缓存键，处理代理变更和配置变更
 //
@@ -382,12 +435,22 @@ func (s *httpUpstreamService) getOrCreateClient(proxyURL string, accountID int64
 // markInFlight=true 时会标记进行中请求，用于请求路径防止被淘汰
 // enforceLimit=true 时会限制客户端数量，超限且无法淘汰时返回错误
 func (s *httpUpstreamService) getClientEntry(proxyURL string, accountID int64, accountConcurrency int, markInFlight bool, enforceLimit bool) (*upstreamClientEntry, error) {
+	return s.getClientEntryWithClientTLS(proxyURL, accountID, accountConcurrency, nil, markInFlight, enforceLimit)
+}
+
+// getClientEntryWithClientTLS 获取或创建客户端条目
+// clientTLS 非空时（账号配置了 mTLS 客户端证书 / 自定义 CA），缓存键加 "mtls:{摘要}" 前缀，
+// 与普通客户端隔离，TLS 材料变化时自然使用新的连接池
+func (s *httpUpstreamService) getClientEntryWithClientTLS(proxyURL string, accountID int64, accountConcurrency int, clientTLS *service.UpstreamClientTLS, markInFlight bool, enforceLimit bool) (*upstreamClientEntry, error) {
 	// 获取隔离模式
 	isolation := s.getIsolationMode()
 	// 标准化代理 URL 并解析
 	proxyKey, parsedProxy := normalizeProxyURL(proxyURL)
 	// 构建缓存键（根据隔离策略不同）
 	cacheKey := buildCacheKey(isolation, proxyKey, accountID)
+	if clientTLS != nil {
+		cacheKey = "mtls:" + clientTLS.CacheKey() + ":" + cacheKey
+	}
 	// 构建连接池配置键（用于检测配置变更）
 	poolKey := s.buildPoolKey(isolation, accountConcurrency)
 
@@ -438,6 +501,17 @@ func (s *httpUpstreamService) getClientEntry(proxyURL string, accountID int64, a
 		s.mu.Unlock()
 		return nil, fmt.Errorf("build transport: %w", err)
 	}
+	if clientTLS != nil {
+		tlsConfig, err := clientTLS.TLSConfig()
+		if err != nil {
+			s.mu.Unlock()
+			return nil, fmt.Errorf("build upstream client TLS: %w", err)
+		}
+		if transport.TLSClientConfig != nil {
+			tlsConfig.ClientSessionCache = transport.TLSClientConfig.ClientSessionCache
+		}
+		transport.TLSClientConfig = tlsConfig
+	}
 	client := &http.Client{Transport: transport}
 	if s.shouldValidateResolvedIP() {
 		client.CheckRedirect = s.redirectChecker
@@ -456,6 +530,7 @@ func (s *httpUpstreamService) getClientEntry(proxyURL string, accountID int64, a
 	// 执行淘汰策略：先淘汰空闲超时的，再淘汰超出数量限制的
 	s.evictIdleLocked(now)
 	s.evictOverLimitLocked()
+	service.SetUpstreamClientPools(len(s.clients))
 	s.mu.Unlock()
 	return entry, nil
 }
@@ -483,6 +558,7 @@ func (s *httpUpstreamService) shouldReuseEntry(entry *upstreamClientEntry, isola
 //   - entry: 客户端条目
 func (s *httpUpstreamService) removeClientLocked(key string, entry *upstreamClientEntry) {
 	delete(s.clients, key)
+	service.SetUpstreamClientPools(len(s.clients))
 	if entry != nil && entry.client != nil {
 		// 关闭空闲连接，释放系统资源
 		// 注意：这不会中断活跃连接
@@ -618,12 +694,13 @@ func (s *httpUpstreamService) clientIdleTTL() time.Duration {
 // 说明:
 //   - 账户隔离模式下，连接池大小与账户并发数对应
 //   - 这确保了单账户不会占用过多连接资源
+//   - 每主机空闲连接数不超过 max_idle_conns_per_host 配置
 func (s *httpUpstreamService) resolvePoolSettings(isolation string, accountConcurrency int) poolSettings {
 	settings := defaultPoolSettings(s.cfg)
 	// 账户隔离模式下，根据账户并发数调整连接池大小
 	if (isolation == config.ConnectionPoolIsolationAccount || isolation == config.ConnectionPoolIsolationAccountProxy) && accountConcurrency > 0 {
 		settings.maxIdleConns = accountConcurrency
-		settings.maxIdleConnsPerHost = accountConcurrency
+		settings.maxIdleConnsPerHost = min(accountConcurrency, settings.maxIdleConnsPerHost)
 		settings.maxConnsPerHost = accountConcurrency
 	}
 	return settings
@@ -727,6 +804,12 @@ func defaultPoolSettings(cfg *config.Config) poolSettings {
 	maxConnsPerHost := defaultMaxConnsPerHost
 	idleConnTimeout := defaultIdleConnTimeout
 	responseHeaderTimeout := defaultResponseHeaderTimeout
+	http2 := true
+	http2PingInterval := defaultHTTP2PingInterval
+	tlsSessionCacheSize := defaultTLSSessionCacheSize
+	dialTimeout := defaultDialTimeout
+	tlsHandshakeTimeout := defaultTLSHandshakeTimeout
+	keepAlive := defaultKeepAlive
 
 	if cfg != nil {
 		if cfg.Gateway.MaxIdleConns > 0 {
@@ -744,6 +827,19 @@ func defaultPoolSettings(cfg *config.Config) poolSettings {
 		if cfg.Gateway.ResponseHeaderTimeout > 0 {
 			responseHeaderTimeout = time.Duration(cfg.Gateway.ResponseHeaderTimeout) * time.Second
 		}
+		transportCfg := cfg.Gateway.UpstreamTransport
+		http2 = transportCfg.HTTP2
+		http2PingInterval = time.Duration(transportCfg.HTTP2PingIntervalSeconds) * time.Second
+		tlsSessionCacheSize = transportCfg.TLSSessionCacheSize
+		if transportCfg.DialTimeoutSeconds > 0 {
+			dialTimeout = time.Duration(transportCfg.DialTimeoutSeconds) * time.Second
+		}
+		if transportCfg.TLSHandshakeTimeoutSeconds > 0 {
+			tlsHandshakeTimeout = time.Duration(transportCfg.TLSHandshakeTimeoutSeconds) * time.Second
+		}
+		if transportCfg.KeepAliveSeconds > 0 {
+			keepAlive = time.Duration(transportCfg.KeepAliveSeconds) * time.Second
+		}
 	}
 
 	return poolSettings{
@@ -752,6 +848,12 @@ func defaultPoolSettings(cfg *config.Config) poolSettings {
 		maxConnsPerHost:       maxConnsPerHost,
 		idleConnTimeout:       idleConnTimeout,
 		responseHeaderTimeout: responseHeaderTimeout,
+		http2:                 http2,
+		http2PingInterval:     http2PingInterval,
+		tlsSessionCacheSize:   tlsSessionCacheSize,
+		dialTimeout:           dialTimeout,
+		tlsHandshakeTimeout:   tlsHandshakeTimeout,
+		keepAlive:             keepAlive,
 	}
 }
 
@@ -772,20 +874,44 @@ func defaultPoolSettings(cfg *config.Config) poolSettings {
 //   - MaxConnsPerHost: 每主机最大连接数（达到后新请求等待）
 //   - IdleConnTimeout: 空闲连接超时（超时后关闭）
 //   - ResponseHeaderTimeout: 等待响应头超时（不影响流式传输）
+//   - TLSClientConfig.ClientSessionCache: TLS 会话缓存，新建连接时恢复会话，省去完整握手
+//   - ForceAttemptHTTP2: 自定义拨号/TLS 配置会关闭默认的 HTTP/2 协商，需显式开启
 func buildUpstreamTransport(settings poolSettings, proxyURL *url.URL) (*http.Transport, error) {
+	dialer := &net.Dialer{Timeout: settings.dialTimeout, KeepAlive: settings.keepAlive}
 	transport := &http.Transport{
+		DialContext:           dialer.DialContext,
 		MaxIdleConns:          settings.maxIdleConns,
 		MaxIdleConnsPerHost:   settings.maxIdleConnsPerHost,
 		MaxConnsPerHost:       settings.maxConnsPerHost,
 		IdleConnTimeout:       settings.idleConnTimeout,
 		ResponseHeaderTimeout: settings.responseHeaderTimeout,
+		TLSHandshakeTimeout:   settings.tlsHandshakeTimeout,
 	}
+	if settings.tlsSessionCacheSize > 0 {
+		transport.TLSClientConfig = &tls.Config{ClientSessionCache: tls.NewLRUClientSessionCache(settings.tlsSessionCacheSize)}
+	}
+	configureUpstreamHTTP2(transport, settings)
 	if err := proxyutil.ConfigureTransportProxy(transport, proxyURL); err != nil {
 		return nil, err
 	}
 	return transport, nil
 }
 
+// configureUpstreamHTTP2 按配置开启或关闭上游 HTTP/2
+// 开启时显式 ForceAttemptHTTP2，并按探活间隔配置 HTTP/2 PING；
+// 关闭时置空 TLSNextProto，确保 ALPN 只协商 HTTP/1.1
+func configureUpstreamHTTP2(transport *http.Transport, settings poolSettings) {
+	if !settings.http2 {
+		transport.ForceAttemptHTTP2 = false
+		transport.TLSNextProto = map[string]func(string, *tls.Conn) http.RoundTripper{}
+		return
+	}
+	transport.ForceAttemptHTTP2 = true
+	if settings.http2PingInterval > 0 {
+		transport.HTTP2 = &http.HTTP2Config{SendPingTimeout: settings.http2PingInterval}
+	}
+}
+
 // buildUpstreamTransportWithTLSFingerprint 构建带 TLS 指纹伪装的 Transport
 // 使用 utls 库模拟 Claude CLI 的 TLS 指纹
 //
@@ -844,6 +970,40 @@ func buildUpstreamTransportWithTLSFingerprint(settings poolSettings, proxyURL *u
 	return transport, nil
 }
 
+// doWithAttemptTimeout 执行请求，并按 context 中的单次尝试超时（service.WithUpstreamAttemptTimeout）等待响应头。
+// 超时未收到响应头时取消请求并返回 service.ErrUpstreamAttemptTimeout，由网关切换账号；
+// 响应头到达后不再受该超时约束，不影响流式传输。
+// context 中带有分阶段超时预算（service.WithUpstreamTimeoutBudget）时同时执行各阶段超时。
+// 每次调用记录一个 client span（截止到响应头到达），并按配置向上游注入 trace context。
+func doWithAttemptTimeout(client *http.Client, req *http.Request) (*http.Response, error) {
+	ctx, span := tracing.Tracer().Start(req.Context(), "upstream "+req.Method,
+		trace.WithSpanKind(trace.SpanKindClient),
+		trace.WithAttributes(
+			attribute.String("http.request.method", req.Method),
+			attribute.String("server.address", req.URL.Hostname()),
+			attribute.String("url.path", req.URL.Path),
+		),
+	)
+	req = req.WithContext(withUpstreamConnMetrics(ctx, req.URL.Hostname()))
+	tracing.InjectUpstream(ctx, req.Header)
+
+	resp, err := doUpstreamAttempt(client, req)
+	if resp != nil {
+		span.SetAttributes(attribute.Int("http.response.status_code", resp.StatusCode))
+	}
+	tracing.End(span, err)
+	return resp, err
+}
+
+func doUpstreamAttempt(client *http.Client, req *http.Request) (*http.Response, error) {
+	attemptTimeout := service.UpstreamAttemptTimeoutFromContext(req.Context())
+	budget := service.UpstreamTimeoutBudgetFromContext(req.Context())
+	if attemptTimeout <= 0 && budget.IsZero() {
+		return client.Do(req)
+	}
+	return doWithPhaseTimeouts(client, req, budget, attemptTimeout)
+}
+
 // trackedBody 带跟踪功能的响应体包装器
 // 在 Close 时执行回调，用于更新请求计数
 type trackedBody struct {
@@ -0,0 +1,35 @@
+package repository
+
+import (
+	"context"
+	"crypto/tls"
+	"net/http/httptrace"
+	"time"
+
+	"github.com/Wei-Shaw/sub2api/internal/service"
+)
+
+// withUpstreamConnMetrics 挂载连接获取追踪：记录每次请求拿到的连接是否复用、协商的协议与等待耗时，
+// 用于观察连接池命中率（新建连接需经历 DNS/TCP/TLS，是高负载下首包延迟的主要来源）
+func withUpstreamConnMetrics(ctx context.Context, host string) context.Context {
+	var start time.Time
+	return httptrace.WithClientTrace(ctx, &httptrace.ClientTrace{
+		GetConn: func(string) { start = time.Now() },
+		GotConn: func(info httptrace.GotConnInfo) {
+			if start.IsZero() {
+				return
+			}
+			service.ObserveUpstreamConnection(host, negotiatedProtocol(info), info.Reused, time.Since(start))
+		},
+	})
+}
+
+// negotiatedProtocol 返回连接协商的应用层协议；未经 ALPN 协商（明文、utls 指纹连接）时视为 HTTP/1.1
+func negotiatedProtocol(info httptrace.GotConnInfo) string {
+	if conn, ok := info.Conn.(interface{ ConnectionState() tls.ConnectionState }); ok {
+		if proto := conn.ConnectionState().NegotiatedProtocol; proto != "" {
+			return proto
+		}
+	}
+	return "http/1.1"
+}
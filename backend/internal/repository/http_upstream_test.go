@@ -1,13 +1,24 @@
 package repository
 
 import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
 	"io"
+	"math/big"
 	"net/http"
+	"net/http/httptest"
 	"sync/atomic"
 	"testing"
 	"time"
 
 	"github.com/Wei-Shaw/sub2api/internal/config"
+	"github.com/Wei-Shaw/sub2api/internal/service"
 	"github.com/stretchr/testify/require"
 	"github.com/stretchr/testify/suite"
 )
@@ -116,6 +127,94 @@ func (s *HTTPUpstreamSuite) TestDo_WithoutProxy_GoesDirect() {
 
 // TestDo_WithHTTPProxy_UsesProxy 测试 HTTP 代理功能
 // 验证请求通过代理服务器转发，使用绝对 URI 格式
+// TestDo_AttemptTimeout 验证单次尝试超时：响应头未按时到达时返回 ErrUpstreamAttemptTimeout，到达后不影响读取响应体
+func (s *HTTPUpstreamSuite) TestDo_AttemptTimeout() {
+	upstream := newLocalTestServer(s.T(), http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/slow" {
+			select {
+			case <-r.Context().Done():
+			case <-time.After(2 * time.Second):
+			}
+			return
+		}
+		w.WriteHeader(http.StatusOK)
+		w.(http.Flusher).Flush()
+		time.Sleep(100 * time.Millisecond)
+		_, _ = io.WriteString(w, "streamed")
+	}))
+	s.T().Cleanup(upstream.Close)
+
+	up := NewHTTPUpstream(s.cfg)
+	ctx := service.WithUpstreamAttemptTimeout(context.Background(), 50*time.Millisecond)
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, upstream.URL+"/slow", nil)
+	require.NoError(s.T(), err, "NewRequest")
+	_, err = up.Do(req, "", 1, 1)
+	require.ErrorIs(s.T(), err, service.ErrUpstreamAttemptTimeout)
+
+	req, err = http.NewRequestWithContext(ctx, http.MethodGet, upstream.URL+"/fast", nil)
+	require.NoError(s.T(), err, "NewRequest")
+	resp, err := up.Do(req, "", 1, 1)
+	require.NoError(s.T(), err, "Do")
+	defer func() { _ = resp.Body.Close() }()
+	b, err := io.ReadAll(resp.Body)
+	require.NoError(s.T(), err, "ReadAll")
+	require.Equal(s.T(), "streamed", string(b))
+}
+
+// TestDo_PhaseTimeouts 验证分阶段超时：首 token、分片间隔与总时长超时分别返回不同的错误
+func (s *HTTPUpstreamSuite) TestDo_PhaseTimeouts() {
+	upstream := newLocalTestServer(s.T(), http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/event-stream")
+		w.WriteHeader(http.StatusOK)
+		w.(http.Flusher).Flush()
+		if r.URL.Path == "/slow-start" {
+			<-r.Context().Done()
+			return
+		}
+		for i := 0; ; i++ {
+			_, _ = io.WriteString(w, "data: {}\n\n")
+			w.(http.Flusher).Flush()
+			pause := 20 * time.Millisecond
+			if r.URL.Path == "/stall" && i > 0 {
+				pause = 2 * time.Second
+			}
+			select {
+			case <-r.Context().Done():
+				return
+			case <-time.After(pause):
+			}
+		}
+	}))
+	s.T().Cleanup(upstream.Close)
+
+	up := NewHTTPUpstream(s.cfg)
+	do := func(path string, budget service.UpstreamTimeoutBudget) (*http.Response, error) {
+		ctx := service.WithUpstreamTimeoutBudget(context.Background(), budget)
+		req, err := http.NewRequestWithContext(ctx, http.MethodGet, upstream.URL+path, nil)
+		require.NoError(s.T(), err, "NewRequest")
+		return up.Do(req, "", 1, 1)
+	}
+
+	// 首 token 超时：返回响应前检测，可切换账号
+	_, err := do("/slow-start", service.UpstreamTimeoutBudget{FirstToken: 50 * time.Millisecond})
+	require.ErrorIs(s.T(), err, service.ErrUpstreamFirstTokenTimeout)
+
+	// 分片间隔超时：首个分片已返回，读取响应体时报错
+	resp, err := do("/stall", service.UpstreamTimeoutBudget{FirstToken: time.Second, ChunkGap: 100 * time.Millisecond})
+	require.NoError(s.T(), err, "Do")
+	_, err = io.ReadAll(resp.Body)
+	_ = resp.Body.Close()
+	require.ErrorIs(s.T(), err, service.ErrUpstreamChunkGapTimeout)
+
+	// 总时长超时：分片持续到达但总时长超出预算
+	resp, err = do("/steady", service.UpstreamTimeoutBudget{ChunkGap: time.Second, Total: 150 * time.Millisecond})
+	require.NoError(s.T(), err, "Do")
+	_, err = io.ReadAll(resp.Body)
+	_ = resp.Body.Close()
+	require.ErrorIs(s.T(), err, service.ErrUpstreamTotalTimeout)
+}
+
 func (s *HTTPUpstreamSuite) TestDo_WithHTTPProxy_UsesProxy() {
 	// 用于接收代理请求的通道
 	seen := make(chan string, 1)
@@ -274,6 +373,130 @@ func (s *HTTPUpstreamSuite) TestIdleTTLDoesNotEvictActive() {
 	require.True(s.T(), hasEntry(svc, entry1), "有活跃请求时不应回收")
 }
 
+// TestDo_AccountClientTLS 验证账号级 mTLS：context 中的客户端证书与自定义 CA 用于握手，且使用独立的客户端缓存
+func (s *HTTPUpstreamSuite) TestDo_AccountClientTLS() {
+	certPEM, keyPEM, clientCert := newTestClientCert(s.T())
+	clientCAs := x509.NewCertPool()
+	clientCAs.AddCert(clientCert)
+
+	upstream := httptest.NewUnstartedServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = io.WriteString(w, r.TLS.PeerCertificates[0].Subject.CommonName)
+	}))
+	upstream.TLS = &tls.Config{ClientAuth: tls.RequireAndVerifyClientCert, ClientCAs: clientCAs}
+	upstream.StartTLS()
+	s.T().Cleanup(upstream.Close)
+	caPEM := string(pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: upstream.Certificate().Raw}))
+
+	s.cfg.Gateway = config.GatewayConfig{ConnectionPoolIsolation: config.ConnectionPoolIsolationAccount}
+	svc := s.newService()
+
+	// 未携带客户端证书时握手失败
+	req, err := http.NewRequest(http.MethodGet, upstream.URL, nil)
+	require.NoError(s.T(), err)
+	_, err = svc.Do(req, "", 1, 1)
+	require.Error(s.T(), err)
+
+	account := &service.Account{ID: 1, Credentials: map[string]any{
+		service.CredentialTLSClientCert: certPEM,
+		service.CredentialTLSClientKey:  keyPEM,
+		service.CredentialTLSCACert:     caPEM,
+	}}
+	req, err = http.NewRequestWithContext(service.WithAccountUpstreamTLS(context.Background(), account), http.MethodGet, upstream.URL, nil)
+	require.NoError(s.T(), err)
+	resp, err := svc.Do(req, "", 1, 1)
+	require.NoError(s.T(), err)
+	b, _ := io.ReadAll(resp.Body)
+	_ = resp.Body.Close()
+	require.Equal(s.T(), "sub2api-test-client", string(b))
+
+	require.Contains(s.T(), svc.clients, "account:1")
+	require.Contains(s.T(), svc.clients, "mtls:"+account.UpstreamClientTLS().CacheKey()+":account:1")
+}
+
+// TestUpstreamTransportTuning 验证 Transport 调优配置：HTTP/2 探活、TLS 会话缓存与拨号/握手超时
+func (s *HTTPUpstreamSuite) TestUpstreamTransportTuning() {
+	s.cfg.Gateway = config.GatewayConfig{UpstreamTransport: config.GatewayUpstreamTransportConfig{
+		HTTP2:                      true,
+		HTTP2PingIntervalSeconds:   15,
+		TLSSessionCacheSize:        8,
+		TLSHandshakeTimeoutSeconds: 3,
+	}}
+	svc := s.newService()
+	entry := svc.getOrCreateClient("", 1, 1)
+	transport, ok := entry.client.Transport.(*http.Transport)
+	require.True(s.T(), ok, "expected *http.Transport")
+	require.True(s.T(), transport.ForceAttemptHTTP2)
+	require.NotNil(s.T(), transport.HTTP2)
+	require.Equal(s.T(), 15*time.Second, transport.HTTP2.SendPingTimeout)
+	require.NotNil(s.T(), transport.TLSClientConfig)
+	require.NotNil(s.T(), transport.TLSClientConfig.ClientSessionCache)
+	require.Equal(s.T(), 3*time.Second, transport.TLSHandshakeTimeout)
+	require.NotNil(s.T(), transport.DialContext)
+}
+
+// TestUpstreamTransportHTTP2Disabled 验证关闭 HTTP/2 时只协商 HTTP/1.1
+func (s *HTTPUpstreamSuite) TestUpstreamTransportHTTP2Disabled() {
+	svc := s.newService()
+	entry := svc.getOrCreateClient("", 1, 1)
+	transport, ok := entry.client.Transport.(*http.Transport)
+	require.True(s.T(), ok, "expected *http.Transport")
+	require.False(s.T(), transport.ForceAttemptHTTP2)
+	require.NotNil(s.T(), transport.TLSNextProto)
+	require.Empty(s.T(), transport.TLSNextProto)
+	require.Nil(s.T(), transport.TLSClientConfig, "TLS 会话缓存未配置时不设置 TLSClientConfig")
+}
+
+// TestAccountConcurrencyRespectsIdleConnsPerHost 验证账户隔离模式下每主机空闲连接数不超过配置值
+func (s *HTTPUpstreamSuite) TestAccountConcurrencyRespectsIdleConnsPerHost() {
+	s.cfg.Gateway = config.GatewayConfig{
+		ConnectionPoolIsolation: config.ConnectionPoolIsolationAccount,
+		MaxIdleConnsPerHost:     4,
+	}
+	svc := s.newService()
+	entry := svc.getOrCreateClient("", 1, 12)
+	transport, ok := entry.client.Transport.(*http.Transport)
+	require.True(s.T(), ok, "expected *http.Transport")
+	require.Equal(s.T(), 12, transport.MaxConnsPerHost)
+	require.Equal(s.T(), 4, transport.MaxIdleConnsPerHost)
+}
+
+// TestDo_HTTP2MultiplexesOverOneConnection 验证上游支持 HTTP/2 时协商 h2，并复用同一连接与 TLS 会话缓存
+func (s *HTTPUpstreamSuite) TestDo_HTTP2MultiplexesOverOneConnection() {
+	upstream := httptest.NewUnstartedServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = io.WriteString(w, r.Proto+" "+r.RemoteAddr)
+	}))
+	upstream.EnableHTTP2 = true
+	upstream.StartTLS()
+	s.T().Cleanup(upstream.Close)
+	caPEM := string(pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: upstream.Certificate().Raw}))
+
+	s.cfg.Gateway = config.GatewayConfig{
+		ConnectionPoolIsolation: config.ConnectionPoolIsolationAccount,
+		UpstreamTransport:       config.GatewayUpstreamTransportConfig{HTTP2: true, TLSSessionCacheSize: 8},
+	}
+	svc := s.newService()
+	account := &service.Account{ID: 1, Credentials: map[string]any{service.CredentialTLSCACert: caPEM}}
+
+	var bodies []string
+	for i := 0; i < 2; i++ {
+		req, err := http.NewRequestWithContext(service.WithAccountUpstreamTLS(context.Background(), account), http.MethodGet, upstream.URL, nil)
+		require.NoError(s.T(), err)
+		resp, err := svc.Do(req, "", 1, 4)
+		require.NoError(s.T(), err)
+		require.Equal(s.T(), 2, resp.ProtoMajor)
+		b, _ := io.ReadAll(resp.Body)
+		_ = resp.Body.Close()
+		bodies = append(bodies, string(b))
+	}
+	require.Equal(s.T(), bodies[0], bodies[1], "两次请求应复用同一 HTTP/2 连接")
+
+	entry := svc.clients["mtls:"+account.UpstreamClientTLS().CacheKey()+":account:1"]
+	require.NotNil(s.T(), entry)
+	transport, ok := entry.client.Transport.(*http.Transport)
+	require.True(s.T(), ok, "expected *http.Transport")
+	require.NotNil(s.T(), transport.TLSClientConfig.ClientSessionCache, "账号自定义 TLS 时保留 TLS 会话缓存")
+}
+
 // TestHTTPUpstreamSuite 运行测试套件
 func TestHTTPUpstreamSuite(t *testing.T) {
 	suite.Run(t, new(HTTPUpstreamSuite))
@@ -289,3 +512,29 @@ func hasEntry(svc *httpUpstreamService, target *upstreamClientEntry) bool {
 	}
 	return false
 }
+
+// newTestClientCert 生成自签名客户端证书（PEM）
+func newTestClientCert(t *testing.T) (certPEM, keyPEM string, cert *x509.Certificate) {
+	t.Helper()
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+	tmpl := &x509.Certificate{
+		SerialNumber:          big.NewInt(1),
+		Subject:               pkix.Name{CommonName: "sub2api-test-client"},
+		NotBefore:             time.Now().Add(-time.Hour),
+		NotAfter:              time.Now().Add(time.Hour),
+		KeyUsage:              x509.KeyUsageDigitalSignature | x509.KeyUsageCertSign,
+		ExtKeyUsage:           []x509.ExtKeyUsage{x509.ExtKeyUsageClientAuth},
+		BasicConstraintsValid: true,
+		IsCA:                  true,
+	}
+	der, err := x509.CreateCertificate(rand.Reader, tmpl, tmpl, &key.PublicKey, key)
+	require.NoError(t, err)
+	cert, err = x509.ParseCertificate(der)
+	require.NoError(t, err)
+	keyDER, err := x509.MarshalECPrivateKey(key)
+	require.NoError(t, err)
+	certPEM = string(pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der}))
+	keyPEM = string(pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: keyDER}))
+	return certPEM, keyPEM, cert
+}
@@ -0,0 +1,165 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"sort"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/Wei-Shaw/sub2api/internal/service"
+)
+
+// accountWorkerPool 单个账号的有界上游 worker 池
+// slots 的缓冲长度即容量，占用的 worker 数 = len(slots)
+type accountWorkerPool struct {
+	slots     chan struct{}
+	capacity  int
+	waiting   int64 // 当前排队数
+	acquired  int64 // 累计获得 worker 次数
+	saturated int64 // 累计排队超时次数
+	lastUsed  int64 // 最后使用时间戳（纳秒），用于空闲回收
+}
+
+// accountWorkerPools 按账号管理 worker 池
+// 账号并发数变化导致容量变化时替换为新池，旧池中的 worker 在各自响应体关闭时归还旧池
+type accountWorkerPools struct {
+	mu    sync.Mutex
+	pools map[int64]*accountWorkerPool
+}
+
+func newAccountWorkerPools() *accountWorkerPools {
+	return &accountWorkerPools{pools: make(map[int64]*accountWorkerPool)}
+}
+
+// acquire 为账号获取一个 worker，返回的 release 必须且只需调用一次
+// 池满时最多等待 queueTimeout，超时返回 service.ErrAccountWorkerPoolSaturated；queueTimeout=0 表示不等待
+func (p *accountWorkerPools) acquire(ctx context.Context, accountID int64, capacity int, queueTimeout, idleTTL time.Duration) (func(), error) {
+	pool := p.getPool(accountID, capacity, idleTTL)
+	release := func() {
+		<-pool.slots
+		atomic.StoreInt64(&pool.lastUsed, time.Now().UnixNano())
+	}
+
+	select {
+	case pool.slots <- struct{}{}:
+		atomic.AddInt64(&pool.acquired, 1)
+		return release, nil
+	default:
+	}
+
+	if queueTimeout > 0 {
+		atomic.AddInt64(&pool.waiting, 1)
+		timer := time.NewTimer(queueTimeout)
+		defer func() {
+			timer.Stop()
+			atomic.AddInt64(&pool.waiting, -1)
+		}()
+		select {
+		case pool.slots <- struct{}{}:
+			atomic.AddInt64(&pool.acquired, 1)
+			return release, nil
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		case <-timer.C:
+		}
+	}
+
+	atomic.AddInt64(&pool.saturated, 1)
+	return nil, fmt.Errorf("%w (account %d, %d workers)", service.ErrAccountWorkerPoolSaturated, accountID, capacity)
+}
+
+// getPool 获取或创建账号 worker 池；创建新池时顺带回收空闲池
+func (p *accountWorkerPools) getPool(accountID int64, capacity int, idleTTL time.Duration) *accountWorkerPool {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if pool, ok := p.pools[accountID]; ok && pool.capacity == capacity {
+		atomic.StoreInt64(&pool.lastUsed, time.Now().UnixNano())
+		return pool
+	}
+
+	now := time.Now()
+	p.evictIdleLocked(now, idleTTL)
+	pool := &accountWorkerPool{
+		slots:    make(chan struct{}, capacity),
+		capacity: capacity,
+		lastUsed: now.UnixNano(),
+	}
+	p.pools[accountID] = pool
+	return pool
+}
+
+// evictIdleLocked 回收无占用、无排队且超过空闲阈值的池
+// 调用方必须持有 p.mu
+func (p *accountWorkerPools) evictIdleLocked(now time.Time, idleTTL time.Duration) {
+	if idleTTL <= 0 {
+		return
+	}
+	cutoff := now.Add(-idleTTL).UnixNano()
+	for id, pool := range p.pools {
+		if len(pool.slots) != 0 || atomic.LoadInt64(&pool.waiting) != 0 {
+			continue
+		}
+		if atomic.LoadInt64(&pool.lastUsed) <= cutoff {
+			delete(p.pools, id)
+		}
+	}
+}
+
+// stats 返回所有账号池的指标快照，按占用率降序
+func (p *accountWorkerPools) stats() []service.AccountWorkerPoolStats {
+	p.mu.Lock()
+	out := make([]service.AccountWorkerPoolStats, 0, len(p.pools))
+	for id, pool := range p.pools {
+		inFlight := int64(len(pool.slots))
+		out = append(out, service.AccountWorkerPoolStats{
+			AccountID:   id,
+			Capacity:    pool.capacity,
+			InFlight:    inFlight,
+			Waiting:     atomic.LoadInt64(&pool.waiting),
+			Acquired:    atomic.LoadInt64(&pool.acquired),
+			Saturated:   atomic.LoadInt64(&pool.saturated),
+			Utilization: float64(inFlight) / float64(pool.capacity),
+		})
+	}
+	p.mu.Unlock()
+
+	sort.Slice(out, func(i, j int) bool {
+		if out[i].Utilization != out[j].Utilization {
+			return out[i].Utilization > out[j].Utilization
+		}
+		return out[i].AccountID < out[j].AccountID
+	})
+	return out
+}
+
+// acquireAccountWorker 在启用账号 worker 池时为本次上游调用占用一个 worker
+// 未启用时返回空操作的 release
+func (s *httpUpstreamService) acquireAccountWorker(req *http.Request, accountID int64, accountConcurrency int) (func(), error) {
+	if s.workerPools == nil || s.cfg == nil || !s.cfg.Gateway.AccountWorkerPool.Enabled {
+		return func() {}, nil
+	}
+	cfg := s.cfg.Gateway.AccountWorkerPool
+	capacity := cfg.Size
+	if capacity <= 0 {
+		capacity = accountConcurrency
+	}
+	if capacity <= 0 {
+		capacity = cfg.DefaultSize
+	}
+	if capacity <= 0 {
+		return func() {}, nil
+	}
+	return s.workerPools.acquire(req.Context(), accountID, capacity, cfg.QueueTimeout, s.clientIdleTTL())
+}
+
+// AccountWorkerPoolStats 返回账号 worker 池饱和度指标（实现 service.AccountWorkerPoolStatsProvider）
+func (s *httpUpstreamService) AccountWorkerPoolStats() []service.AccountWorkerPoolStats {
+	if s.workerPools == nil {
+		return []service.AccountWorkerPoolStats{}
+	}
+	return s.workerPools.stats()
+}
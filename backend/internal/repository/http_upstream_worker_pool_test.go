@@ -0,0 +1,97 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/Wei-Shaw/sub2api/internal/config"
+	"github.com/Wei-Shaw/sub2api/internal/service"
+	"github.com/stretchr/testify/require"
+)
+
+func TestAccountWorkerPools_SaturatesPerAccount(t *testing.T) {
+	pools := newAccountWorkerPools()
+	ctx := context.Background()
+
+	release1, err := pools.acquire(ctx, 1, 1, 20*time.Millisecond, time.Minute)
+	require.NoError(t, err)
+
+	// 账号 1 已占满，排队超时后返回饱和错误
+	_, err = pools.acquire(ctx, 1, 1, 20*time.Millisecond, time.Minute)
+	require.True(t, errors.Is(err, service.ErrAccountWorkerPoolSaturated))
+
+	// 其他账号不受影响
+	release2, err := pools.acquire(ctx, 2, 1, 0, time.Minute)
+	require.NoError(t, err)
+	release2()
+
+	stats := pools.stats()
+	require.Len(t, stats, 2)
+	require.Equal(t, int64(1), stats[0].AccountID)
+	require.Equal(t, int64(1), stats[0].InFlight)
+	require.Equal(t, int64(1), stats[0].Saturated)
+	require.Equal(t, 1.0, stats[0].Utilization)
+
+	release1()
+	release3, err := pools.acquire(ctx, 1, 1, 0, time.Minute)
+	require.NoError(t, err)
+	release3()
+}
+
+func TestAccountWorkerPools_WaiterGetsReleasedWorker(t *testing.T) {
+	pools := newAccountWorkerPools()
+	ctx := context.Background()
+
+	release, err := pools.acquire(ctx, 1, 1, 0, time.Minute)
+	require.NoError(t, err)
+	time.AfterFunc(20*time.Millisecond, release)
+
+	release2, err := pools.acquire(ctx, 1, 1, time.Second, time.Minute)
+	require.NoError(t, err)
+	release2()
+	require.Equal(t, int64(0), pools.stats()[0].Waiting)
+}
+
+func TestHTTPUpstream_AccountWorkerHeldUntilBodyClosed(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = io.WriteString(w, "ok")
+	}))
+	t.Cleanup(server.Close)
+
+	cfg := &config.Config{
+		Security: config.SecurityConfig{URLAllowlist: config.URLAllowlistConfig{AllowPrivateHosts: true}},
+		Gateway: config.GatewayConfig{
+			AccountWorkerPool: config.GatewayAccountWorkerPoolConfig{Enabled: true, Size: 1},
+		},
+	}
+	up := NewHTTPUpstream(cfg)
+
+	req, err := http.NewRequest(http.MethodGet, server.URL, nil)
+	require.NoError(t, err)
+	resp, err := up.Do(req, "", 7, 0)
+	require.NoError(t, err)
+
+	// 响应体未关闭前 worker 仍被占用
+	req2, err := http.NewRequest(http.MethodGet, server.URL, nil)
+	require.NoError(t, err)
+	_, err = up.Do(req2, "", 7, 0)
+	require.True(t, errors.Is(err, service.ErrAccountWorkerPoolSaturated))
+
+	require.NoError(t, resp.Body.Close())
+	resp2, err := up.Do(req2, "", 7, 0)
+	require.NoError(t, err)
+	require.NoError(t, resp2.Body.Close())
+
+	provider, ok := up.(service.AccountWorkerPoolStatsProvider)
+	require.True(t, ok)
+	stats := provider.AccountWorkerPoolStats()
+	require.Len(t, stats, 1)
+	require.Equal(t, int64(2), stats[0].Acquired)
+	require.Equal(t, int64(1), stats[0].Saturated)
+	require.Equal(t, int64(0), stats[0].InFlight)
+}
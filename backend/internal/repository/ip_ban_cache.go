@@ -0,0 +1,140 @@
+package repository
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/Wei-Shaw/sub2api/internal/service"
+	"github.com/redis/go-redis/v9"
+)
+
+const (
+	ipBanRequestPrefix = "ip_ban:requests:"
+	ipBanFailurePrefix = "ip_ban:failures:"
+	ipBanBanPrefix     = "ip_ban:ban:"
+	// ipBanBannedSetKey 有序集合：member 为 IP，score 为解封时间（Unix 秒）
+	ipBanBannedSetKey = "ip_ban:banned"
+)
+
+// ipBanIncrScript 计数并在首次计数时设置过期（固定窗口）
+var ipBanIncrScript = redis.NewScript(`
+local current = redis.call('INCR', KEYS[1])
+if current == 1 or redis.call('PTTL', KEYS[1]) == -1 then
+  redis.call('PEXPIRE', KEYS[1], ARGV[1])
+end
+return current
+`)
+
+type ipBanCache struct {
+	rdb *redis.Client
+}
+
+// NewIPBanCache 创建 IP 封禁缓存
+func NewIPBanCache(rdb *redis.Client) service.IPBanCache {
+	return &ipBanCache{rdb: rdb}
+}
+
+func (c *ipBanCache) incr(ctx context.Context, key string, window time.Duration) (int64, error) {
+	windowMillis := window.Milliseconds()
+	if windowMillis < 1 {
+		windowMillis = 1
+	}
+	return ipBanIncrScript.Run(ctx, c.rdb, []string{key}, windowMillis).Int64()
+}
+
+func (c *ipBanCache) IncrIPRequests(ctx context.Context, ip string, window time.Duration) (int64, error) {
+	count, err := c.incr(ctx, ipBanRequestPrefix+ip, window)
+	if err != nil {
+		return 0, fmt.Errorf("incr ip requests: %w", err)
+	}
+	return count, nil
+}
+
+func (c *ipBanCache) IncrIPAuthFailures(ctx context.Context, ip string, window time.Duration) (int64, error) {
+	count, err := c.incr(ctx, ipBanFailurePrefix+ip, window)
+	if err != nil {
+		return 0, fmt.Errorf("incr ip auth failures: %w", err)
+	}
+	return count, nil
+}
+
+func (c *ipBanCache) SetIPBan(ctx context.Context, ban *service.IPBan, ttl time.Duration) error {
+	payload, err := json.Marshal(ban)
+	if err != nil {
+		return err
+	}
+	pipe := c.rdb.TxPipeline()
+	pipe.Set(ctx, ipBanBanPrefix+ban.IP, payload, ttl)
+	pipe.ZAdd(ctx, ipBanBannedSetKey, redis.Z{Score: float64(ban.ExpiresAt.Unix()), Member: ban.IP})
+	// 封禁期间不再累计失败次数，解封后重新计数
+	pipe.Del(ctx, ipBanFailurePrefix+ban.IP)
+	if _, err := pipe.Exec(ctx); err != nil {
+		return fmt.Errorf("set ip ban: %w", err)
+	}
+	return nil
+}
+
+func (c *ipBanCache) GetIPBan(ctx context.Context, ip string) (*service.IPBan, error) {
+	raw, err := c.rdb.Get(ctx, ipBanBanPrefix+ip).Bytes()
+	if errors.Is(err, redis.Nil) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("get ip ban: %w", err)
+	}
+	var ban service.IPBan
+	if err := json.Unmarshal(raw, &ban); err != nil {
+		return nil, fmt.Errorf("decode ip ban: %w", err)
+	}
+	return &ban, nil
+}
+
+func (c *ipBanCache) ListIPBans(ctx context.Context, now time.Time) ([]service.IPBan, error) {
+	nowScore := strconv.FormatInt(now.Unix(), 10)
+	// 顺带清理已过期的索引
+	_ = c.rdb.ZRemRangeByScore(ctx, ipBanBannedSetKey, "-inf", "("+nowScore).Err()
+
+	members, err := c.rdb.ZRangeByScore(ctx, ipBanBannedSetKey, &redis.ZRangeBy{Min: nowScore, Max: "+inf"}).Result()
+	if err != nil {
+		return nil, fmt.Errorf("list ip bans: %w", err)
+	}
+	bans := make([]service.IPBan, 0, len(members))
+	if len(members) == 0 {
+		return bans, nil
+	}
+
+	keys := make([]string, 0, len(members))
+	for _, m := range members {
+		keys = append(keys, ipBanBanPrefix+m)
+	}
+	values, err := c.rdb.MGet(ctx, keys...).Result()
+	if err != nil {
+		return nil, fmt.Errorf("get ip bans: %w", err)
+	}
+	for _, v := range values {
+		raw, ok := v.(string)
+		if !ok {
+			continue // 已解封
+		}
+		var ban service.IPBan
+		if err := json.Unmarshal([]byte(raw), &ban); err != nil {
+			continue
+		}
+		bans = append(bans, ban)
+	}
+	return bans, nil
+}
+
+func (c *ipBanCache) DeleteIPBan(ctx context.Context, ip string) error {
+	pipe := c.rdb.TxPipeline()
+	pipe.Del(ctx, ipBanBanPrefix+ip, ipBanFailurePrefix+ip)
+	pipe.ZRem(ctx, ipBanBannedSetKey, ip)
+	if _, err := pipe.Exec(ctx); err != nil {
+		return fmt.Errorf("delete ip ban: %w", err)
+	}
+	return nil
+}
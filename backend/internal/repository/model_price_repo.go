@@ -0,0 +1,127 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"strconv"
+	"strings"
+
+	"github.com/Wei-Shaw/sub2api/internal/service"
+)
+
+type modelPriceRepository struct {
+	sql sqlExecutor
+}
+
+// NewModelPriceRepository 创建模型价格表仓储
+func NewModelPriceRepository(sqlDB *sql.DB) service.ModelPriceRepository {
+	return &modelPriceRepository{sql: sqlDB}
+}
+
+const modelPriceColumns = `id, platform, model, input_price, output_price, cache_creation_price,
+	cache_creation_1h_price, cache_read_price, effective_from, notes, created_at, updated_at`
+
+// List 按可选的平台/模型过滤价格版本
+func (r *modelPriceRepository) List(ctx context.Context, platform, model string) ([]service.ModelPrice, error) {
+	var (
+		conds []string
+		args  []any
+	)
+	if platform != "" {
+		args = append(args, platform)
+		conds = append(conds, "platform = $"+strconv.Itoa(len(args)))
+	}
+	if model != "" {
+		args = append(args, model)
+		conds = append(conds, "model = $"+strconv.Itoa(len(args)))
+	}
+	query := `SELECT ` + modelPriceColumns + ` FROM model_prices`
+	if len(conds) > 0 {
+		query += ` WHERE ` + strings.Join(conds, " AND ")
+	}
+	query += ` ORDER BY platform, model, effective_from DESC`
+
+	rows, err := r.sql.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer func() { _ = rows.Close() }()
+
+	out := make([]service.ModelPrice, 0)
+	for rows.Next() {
+		var p service.ModelPrice
+		if err := scanModelPrice(rows, &p); err != nil {
+			return nil, err
+		}
+		out = append(out, p)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+// GetByID 获取价格版本
+func (r *modelPriceRepository) GetByID(ctx context.Context, id int64) (*service.ModelPrice, error) {
+	var p service.ModelPrice
+	err := scanSingleRow(ctx, r.sql, `SELECT `+modelPriceColumns+` FROM model_prices WHERE id = $1`, []any{id},
+		&p.ID, &p.Platform, &p.Model, &p.InputPrice, &p.OutputPrice, &p.CacheCreationPrice,
+		&p.CacheCreation1hPrice, &p.CacheReadPrice, &p.EffectiveFrom, &p.Notes, &p.CreatedAt, &p.UpdatedAt,
+	)
+	if err != nil {
+		return nil, translatePersistenceError(err, service.ErrModelPriceNotFound, nil)
+	}
+	return &p, nil
+}
+
+// Create 新增价格版本
+func (r *modelPriceRepository) Create(ctx context.Context, price *service.ModelPrice) error {
+	query := `
+		INSERT INTO model_prices (platform, model, input_price, output_price, cache_creation_price,
+			cache_creation_1h_price, cache_read_price, effective_from, notes, created_at, updated_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, NOW(), NOW())
+		RETURNING id, created_at, updated_at`
+	err := scanSingleRow(ctx, r.sql, query, []any{
+		price.Platform, price.Model, price.InputPrice, price.OutputPrice, price.CacheCreationPrice,
+		price.CacheCreation1hPrice, price.CacheReadPrice, price.EffectiveFrom, price.Notes,
+	}, &price.ID, &price.CreatedAt, &price.UpdatedAt)
+	return translatePersistenceError(err, nil, service.ErrModelPriceExists)
+}
+
+// Update 更新价格版本
+func (r *modelPriceRepository) Update(ctx context.Context, price *service.ModelPrice) error {
+	query := `
+		UPDATE model_prices
+		SET platform = $2, model = $3, input_price = $4, output_price = $5, cache_creation_price = $6,
+			cache_creation_1h_price = $7, cache_read_price = $8, effective_from = $9, notes = $10, updated_at = NOW()
+		WHERE id = $1
+		RETURNING updated_at`
+	err := scanSingleRow(ctx, r.sql, query, []any{
+		price.ID, price.Platform, price.Model, price.InputPrice, price.OutputPrice, price.CacheCreationPrice,
+		price.CacheCreation1hPrice, price.CacheReadPrice, price.EffectiveFrom, price.Notes,
+	}, &price.UpdatedAt)
+	return translatePersistenceError(err, service.ErrModelPriceNotFound, service.ErrModelPriceExists)
+}
+
+// Delete 删除价格版本
+func (r *modelPriceRepository) Delete(ctx context.Context, id int64) error {
+	res, err := r.sql.ExecContext(ctx, `DELETE FROM model_prices WHERE id = $1`, id)
+	if err != nil {
+		return err
+	}
+	affected, err := res.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if affected == 0 {
+		return service.ErrModelPriceNotFound
+	}
+	return nil
+}
+
+func scanModelPrice(rows *sql.Rows, p *service.ModelPrice) error {
+	return rows.Scan(
+		&p.ID, &p.Platform, &p.Model, &p.InputPrice, &p.OutputPrice, &p.CacheCreationPrice,
+		&p.CacheCreation1hPrice, &p.CacheReadPrice, &p.EffectiveFrom, &p.Notes, &p.CreatedAt, &p.UpdatedAt,
+	)
+}
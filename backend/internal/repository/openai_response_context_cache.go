@@ -0,0 +1,50 @@
+package repository
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"time"
+
+	"github.com/Wei-Shaw/sub2api/internal/service"
+	"github.com/redis/go-redis/v9"
+)
+
+const openAIResponseContextKeyPrefix = "openai:resp_ctx:"
+
+// openAIResponseContextKey generates the Redis key for a Responses API response context.
+func openAIResponseContextKey(responseID string) string {
+	return openAIResponseContextKeyPrefix + responseID
+}
+
+type openAIResponseContextCache struct {
+	rdb *redis.Client
+}
+
+// NewOpenAIResponseContextCache 创建 Responses 续链信息缓存
+func NewOpenAIResponseContextCache(rdb *redis.Client) service.OpenAIResponseContextCache {
+	return &openAIResponseContextCache{rdb: rdb}
+}
+
+func (c *openAIResponseContextCache) GetResponseContext(ctx context.Context, responseID string) (*service.OpenAIResponseContext, error) {
+	val, err := c.rdb.Get(ctx, openAIResponseContextKey(responseID)).Bytes()
+	if err != nil {
+		if errors.Is(err, redis.Nil) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	var rc service.OpenAIResponseContext
+	if err := json.Unmarshal(val, &rc); err != nil {
+		return nil, err
+	}
+	return &rc, nil
+}
+
+func (c *openAIResponseContextCache) SetResponseContext(ctx context.Context, responseID string, rc *service.OpenAIResponseContext, ttl time.Duration) error {
+	val, err := json.Marshal(rc)
+	if err != nil {
+		return err
+	}
+	return c.rdb.Set(ctx, openAIResponseContextKey(responseID), val, ttl).Err()
+}
@@ -0,0 +1,154 @@
+package repository
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/Wei-Shaw/sub2api/internal/config"
+	"github.com/Wei-Shaw/sub2api/internal/service"
+)
+
+// clickHouseErrorBodyMaxLen 错误响应体的最大保留长度
+const clickHouseErrorBodyMaxLen = 512
+
+// clickHouseOpsEventWriter 通过 ClickHouse HTTP 接口写入请求级运维事件（JSONEachRow 格式）
+type clickHouseOpsEventWriter struct {
+	endpoint   string
+	database   string
+	table      string
+	username   string
+	password   string
+	ttlDays    int
+	httpClient *http.Client
+}
+
+// ProvideClickHouseOpsEventWriter 创建 ClickHouse 事件写入器；未启用时返回 nil
+func ProvideClickHouseOpsEventWriter(cfg *config.Config) service.OpsEventWriter {
+	if cfg == nil || !cfg.Ops.ClickHouse.Enabled {
+		return nil
+	}
+	return NewClickHouseOpsEventWriter(cfg.Ops.ClickHouse)
+}
+
+func NewClickHouseOpsEventWriter(cfg config.OpsClickHouseConfig) service.OpsEventWriter {
+	return &clickHouseOpsEventWriter{
+		endpoint:   strings.TrimRight(cfg.Endpoint, "/"),
+		database:   cfg.Database,
+		table:      cfg.Table,
+		username:   cfg.Username,
+		password:   cfg.Password,
+		ttlDays:    cfg.TTLDays,
+		httpClient: &http.Client{Timeout: cfg.Timeout},
+	}
+}
+
+func (w *clickHouseOpsEventWriter) qualifiedTable() string {
+	return fmt.Sprintf("`%s`.`%s`", w.database, w.table)
+}
+
+func (w *clickHouseOpsEventWriter) EnsureOpsEventSchema(ctx context.Context) error {
+	if err := w.exec(ctx, fmt.Sprintf("CREATE DATABASE IF NOT EXISTS `%s`", w.database), nil); err != nil {
+		return fmt.Errorf("create database: %w", err)
+	}
+	if err := w.exec(ctx, w.createTableSQL(), nil); err != nil {
+		return fmt.Errorf("create table: %w", err)
+	}
+	return nil
+}
+
+func (w *clickHouseOpsEventWriter) createTableSQL() string {
+	ttl := ""
+	if w.ttlDays > 0 {
+		ttl = fmt.Sprintf("\nTTL toDateTime(created_at) + INTERVAL %d DAY", w.ttlDays)
+	}
+	return fmt.Sprintf(`CREATE TABLE IF NOT EXISTS %s (
+    created_at DateTime64(3),
+    kind LowCardinality(String),
+    request_id String,
+    client_request_id String,
+    platform LowCardinality(String),
+    model LowCardinality(String),
+    request_path String,
+    stream Bool,
+    user_id Nullable(Int64),
+    api_key_id Nullable(Int64),
+    account_id Nullable(Int64),
+    group_id Nullable(Int64),
+    status_code Int32,
+    upstream_status_code Nullable(Int32),
+    error_phase LowCardinality(String),
+    error_type LowCardinality(String),
+    severity LowCardinality(String),
+    error_message String,
+    upstream_errors String,
+    retry_count Int32,
+    duration_ms Nullable(Int32),
+    first_token_ms Nullable(Int64),
+    input_tokens Int64,
+    output_tokens Int64,
+    cache_creation_tokens Int64,
+    cache_read_tokens Int64,
+    reasoning_effort LowCardinality(String),
+    total_cost Float64,
+    actual_cost Float64
+) ENGINE = MergeTree
+PARTITION BY toYYYYMM(created_at)
+ORDER BY (created_at, request_id)%s`, w.qualifiedTable(), ttl)
+}
+
+func (w *clickHouseOpsEventWriter) WriteOpsEvents(ctx context.Context, events []service.OpsRequestEvent) error {
+	if len(events) == 0 {
+		return nil
+	}
+	var body bytes.Buffer
+	enc := json.NewEncoder(&body)
+	for i := range events {
+		if err := enc.Encode(&events[i]); err != nil {
+			return fmt.Errorf("encode event: %w", err)
+		}
+	}
+	query := fmt.Sprintf("INSERT INTO %s FORMAT JSONEachRow", w.qualifiedTable())
+	return w.exec(ctx, query, &body)
+}
+
+// exec 执行一条语句；INSERT 的数据放在请求体中，语句放在 query 参数中
+func (w *clickHouseOpsEventWriter) exec(ctx context.Context, query string, data io.Reader) error {
+	params := url.Values{}
+	params.Set("query", query)
+	// RFC3339 时间（time.Time 的 JSON 格式）需 best_effort 解析；忽略表中不存在的字段便于滚动升级
+	params.Set("date_time_input_format", "best_effort")
+	params.Set("input_format_skip_unknown_fields", "1")
+
+	if data == nil {
+		data = http.NoBody
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, w.endpoint+"/?"+params.Encode(), data)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("X-ClickHouse-User", w.username)
+	if w.password != "" {
+		req.Header.Set("X-ClickHouse-Key", w.password)
+	}
+
+	start := time.Now()
+	resp, err := w.httpClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer func() { _ = resp.Body.Close() }()
+
+	if resp.StatusCode != http.StatusOK {
+		respBody, _ := io.ReadAll(io.LimitReader(resp.Body, clickHouseErrorBodyMaxLen))
+		return fmt.Errorf("clickhouse status %d after %s: %s", resp.StatusCode, time.Since(start).Round(time.Millisecond), strings.TrimSpace(string(respBody)))
+	}
+	_, _ = io.Copy(io.Discard, resp.Body)
+	return nil
+}
@@ -0,0 +1,58 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+
+	dbent "github.com/Wei-Shaw/sub2api/ent"
+	"github.com/Wei-Shaw/sub2api/internal/service"
+)
+
+// ProvideUsageLogRepository 创建用量日志仓储；启用运维事件导出时，成功写入的用量日志同时投递到导出队列；
+// 启用用量回调时，配置了回调的 API Key 的用量日志写入后同时提交回调
+func ProvideUsageLogRepository(client *dbent.Client, sqlDB *sql.DB, exporter *service.OpsEventExporterGroup, webhooks *service.UsageWebhookDispatcher) service.UsageLogRepository {
+	var repo service.UsageLogRepository = NewUsageLogRepository(client, sqlDB)
+	if exporter != nil {
+		repo = &exportingUsageLogRepository{UsageLogRepository: repo, exporter: exporter}
+	}
+	if webhooks != nil {
+		repo = &webhookUsageLogRepository{UsageLogRepository: repo, webhooks: webhooks}
+	}
+	return repo
+}
+
+// ProvideOpsRepository 创建运维仓储；启用运维事件导出时，成功写入的错误日志同时投递到导出队列
+func ProvideOpsRepository(db *sql.DB, exporter *service.OpsEventExporterGroup) service.OpsRepository {
+	repo := NewOpsRepository(db)
+	if exporter == nil {
+		return repo
+	}
+	return &exportingOpsRepository{OpsRepository: repo, exporter: exporter}
+}
+
+type exportingUsageLogRepository struct {
+	service.UsageLogRepository
+	exporter *service.OpsEventExporterGroup
+}
+
+func (r *exportingUsageLogRepository) Create(ctx context.Context, log *service.UsageLog) (bool, error) {
+	inserted, err := r.UsageLogRepository.Create(ctx, log)
+	// 幂等重试跳过的插入不重复导出
+	if err == nil && inserted {
+		r.exporter.Enqueue(service.OpsRequestEventFromUsageLog(log))
+	}
+	return inserted, err
+}
+
+type exportingOpsRepository struct {
+	service.OpsRepository
+	exporter *service.OpsEventExporterGroup
+}
+
+func (r *exportingOpsRepository) InsertErrorLog(ctx context.Context, input *service.OpsInsertErrorLogInput) (int64, error) {
+	id, err := r.OpsRepository.InsertErrorLog(ctx, input)
+	if err == nil {
+		r.exporter.Enqueue(service.OpsRequestEventFromErrorLog(input))
+	}
+	return id, err
+}
@@ -0,0 +1,269 @@
+package repository
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"maps"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/Wei-Shaw/sub2api/internal/config"
+	"github.com/Wei-Shaw/sub2api/internal/service"
+)
+
+// logShippingErrorBodyMaxLen 推送失败时错误响应体的最大保留长度
+const logShippingErrorBodyMaxLen = 512
+
+// ProvideOpsLogShippers 创建已启用的日志投递写入器（Loki / Elasticsearch）
+func ProvideOpsLogShippers(cfg *config.Config) service.OpsLogShippers {
+	if cfg == nil {
+		return nil
+	}
+	ls := cfg.Ops.LogShipping
+	var shippers service.OpsLogShippers
+	if ls.Loki.Enabled {
+		shippers = append(shippers, service.OpsLogShipper{Name: "loki", Writer: NewLokiOpsEventWriter(ls.Loki, ls.Timeout)})
+	}
+	if ls.Elasticsearch.Enabled {
+		shippers = append(shippers, service.OpsLogShipper{Name: "elasticsearch", Writer: NewElasticsearchOpsEventWriter(ls.Elasticsearch, ls.Timeout)})
+	}
+	return shippers
+}
+
+// lokiOpsEventWriter 通过 Loki push API 写入请求级运维事件，每个事件一行 JSON 日志
+type lokiOpsEventWriter struct {
+	endpoint   string
+	tenantID   string
+	username   string
+	password   string
+	labels     map[string]string
+	httpClient *http.Client
+}
+
+func NewLokiOpsEventWriter(cfg config.OpsLokiConfig, timeout time.Duration) service.OpsEventWriter {
+	return &lokiOpsEventWriter{
+		endpoint:   strings.TrimRight(cfg.Endpoint, "/"),
+		tenantID:   cfg.TenantID,
+		username:   cfg.Username,
+		password:   cfg.Password,
+		labels:     cfg.Labels,
+		httpClient: &http.Client{Timeout: timeout},
+	}
+}
+
+// EnsureOpsEventSchema Loki 无需建表
+func (w *lokiOpsEventWriter) EnsureOpsEventSchema(ctx context.Context) error {
+	return nil
+}
+
+type lokiPushRequest struct {
+	Streams []*lokiStream `json:"streams"`
+}
+
+type lokiStream struct {
+	Stream map[string]string `json:"stream"`
+	Values [][2]string       `json:"values"`
+}
+
+// buildPushRequest 按 kind/platform 拆分 stream（静态标签 + 低基数动态标签），其余字段留在日志行中
+func (w *lokiOpsEventWriter) buildPushRequest(events []service.OpsRequestEvent) (*lokiPushRequest, error) {
+	streams := make(map[string]*lokiStream)
+	req := &lokiPushRequest{}
+	for i := range events {
+		ev := &events[i]
+		line, err := json.Marshal(ev)
+		if err != nil {
+			return nil, fmt.Errorf("encode event: %w", err)
+		}
+		platform := ev.Platform
+		if platform == "" {
+			platform = "unknown"
+		}
+		key := string(ev.Kind) + "\x00" + platform
+		stream := streams[key]
+		if stream == nil {
+			labels := make(map[string]string, len(w.labels)+2)
+			maps.Copy(labels, w.labels)
+			labels["kind"] = string(ev.Kind)
+			labels["platform"] = platform
+			stream = &lokiStream{Stream: labels}
+			streams[key] = stream
+			req.Streams = append(req.Streams, stream)
+		}
+		ts := ev.CreatedAt
+		if ts.IsZero() {
+			ts = time.Now()
+		}
+		stream.Values = append(stream.Values, [2]string{strconv.FormatInt(ts.UnixNano(), 10), string(line)})
+	}
+	return req, nil
+}
+
+func (w *lokiOpsEventWriter) WriteOpsEvents(ctx context.Context, events []service.OpsRequestEvent) error {
+	if len(events) == 0 {
+		return nil
+	}
+	payload, err := w.buildPushRequest(events)
+	if err != nil {
+		return err
+	}
+	body, err := json.Marshal(payload)
+	if err != nil {
+		return err
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, w.endpoint+"/loki/api/v1/push", bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	if w.tenantID != "" {
+		req.Header.Set("X-Scope-OrgID", w.tenantID)
+	}
+	if w.username != "" {
+		req.SetBasicAuth(w.username, w.password)
+	}
+	return doLogShippingRequest(w.httpClient, req, "loki", nil)
+}
+
+// elasticsearchOpsEventWriter 通过 _bulk 接口写入请求级运维事件，按事件时间写入每日索引
+type elasticsearchOpsEventWriter struct {
+	endpoint    string
+	indexPrefix string
+	username    string
+	password    string
+	apiKey      string
+	httpClient  *http.Client
+}
+
+func NewElasticsearchOpsEventWriter(cfg config.OpsElasticsearchConfig, timeout time.Duration) service.OpsEventWriter {
+	return &elasticsearchOpsEventWriter{
+		endpoint:    strings.TrimRight(cfg.Endpoint, "/"),
+		indexPrefix: cfg.IndexPrefix,
+		username:    cfg.Username,
+		password:    cfg.Password,
+		apiKey:      cfg.APIKey,
+		httpClient:  &http.Client{Timeout: timeout},
+	}
+}
+
+// EnsureOpsEventSchema 索引由 _bulk 写入时自动创建（映射可通过 index template 自行定制）
+func (w *elasticsearchOpsEventWriter) EnsureOpsEventSchema(ctx context.Context) error {
+	return nil
+}
+
+type elasticsearchBulkAction struct {
+	Index struct {
+		Index string `json:"_index"`
+	} `json:"index"`
+}
+
+// elasticsearchOpsEventDoc 在事件字段之外补充 Kibana 默认使用的 @timestamp
+type elasticsearchOpsEventDoc struct {
+	Timestamp time.Time `json:"@timestamp"`
+	*service.OpsRequestEvent
+}
+
+func (w *elasticsearchOpsEventWriter) buildBulkBody(events []service.OpsRequestEvent) ([]byte, error) {
+	var body bytes.Buffer
+	enc := json.NewEncoder(&body)
+	for i := range events {
+		ev := &events[i]
+		ts := ev.CreatedAt
+		if ts.IsZero() {
+			ts = time.Now()
+		}
+		var action elasticsearchBulkAction
+		action.Index.Index = w.indexPrefix + "-" + ts.UTC().Format("2006.01.02")
+		if err := enc.Encode(&action); err != nil {
+			return nil, fmt.Errorf("encode action: %w", err)
+		}
+		if err := enc.Encode(&elasticsearchOpsEventDoc{Timestamp: ts, OpsRequestEvent: ev}); err != nil {
+			return nil, fmt.Errorf("encode event: %w", err)
+		}
+	}
+	return body.Bytes(), nil
+}
+
+// elasticsearchBulkResponse _bulk 响应中判断部分失败所需的字段
+type elasticsearchBulkResponse struct {
+	Errors bool `json:"errors"`
+	Items  []map[string]struct {
+		Status int `json:"status"`
+		Error  *struct {
+			Type   string `json:"type"`
+			Reason string `json:"reason"`
+		} `json:"error"`
+	} `json:"items"`
+}
+
+func (w *elasticsearchOpsEventWriter) WriteOpsEvents(ctx context.Context, events []service.OpsRequestEvent) error {
+	if len(events) == 0 {
+		return nil
+	}
+	body, err := w.buildBulkBody(events)
+	if err != nil {
+		return err
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, w.endpoint+"/_bulk", bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/x-ndjson")
+	switch {
+	case w.apiKey != "":
+		req.Header.Set("Authorization", "ApiKey "+w.apiKey)
+	case w.username != "":
+		req.SetBasicAuth(w.username, w.password)
+	}
+	return doLogShippingRequest(w.httpClient, req, "elasticsearch", checkElasticsearchBulkResponse)
+}
+
+// checkElasticsearchBulkResponse _bulk 在 HTTP 200 下仍可能部分失败，汇总失败条数与首个原因
+func checkElasticsearchBulkResponse(body io.Reader) error {
+	var resp elasticsearchBulkResponse
+	if err := json.NewDecoder(body).Decode(&resp); err != nil {
+		return fmt.Errorf("decode bulk response: %w", err)
+	}
+	if !resp.Errors {
+		return nil
+	}
+	failed := 0
+	reason := ""
+	for _, item := range resp.Items {
+		for _, result := range item {
+			if result.Error == nil {
+				continue
+			}
+			failed++
+			if reason == "" {
+				reason = result.Error.Type + ": " + result.Error.Reason
+			}
+		}
+	}
+	return fmt.Errorf("elasticsearch bulk: %d of %d items failed: %s", failed, len(resp.Items), reason)
+}
+
+// doLogShippingRequest 发送请求并检查状态码（2xx 视为成功），可选地检查响应体
+func doLogShippingRequest(client *http.Client, req *http.Request, target string, check func(io.Reader) error) error {
+	start := time.Now()
+	resp, err := client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer func() { _ = resp.Body.Close() }()
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		respBody, _ := io.ReadAll(io.LimitReader(resp.Body, logShippingErrorBodyMaxLen))
+		return fmt.Errorf("%s status %d after %s: %s", target, resp.StatusCode, time.Since(start).Round(time.Millisecond), strings.TrimSpace(string(respBody)))
+	}
+	if check != nil {
+		return check(resp.Body)
+	}
+	_, _ = io.Copy(io.Discard, resp.Body)
+	return nil
+}
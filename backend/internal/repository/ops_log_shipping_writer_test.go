@@ -0,0 +1,128 @@
+package repository
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/Wei-Shaw/sub2api/internal/config"
+	"github.com/Wei-Shaw/sub2api/internal/service"
+	"github.com/stretchr/testify/require"
+)
+
+func testOpsLogShippingEvents() []service.OpsRequestEvent {
+	ts := time.Date(2026, 3, 1, 23, 59, 59, 0, time.UTC)
+	return []service.OpsRequestEvent{
+		{CreatedAt: ts, Kind: service.OpsRequestKindSuccess, RequestID: "r1", Platform: service.PlatformAnthropic},
+		{CreatedAt: ts.Add(time.Second), Kind: service.OpsRequestKindError, RequestID: "r2", Platform: service.PlatformAnthropic, StatusCode: 502},
+		{CreatedAt: ts.Add(2 * time.Second), Kind: service.OpsRequestKindSuccess, RequestID: "r3", Platform: service.PlatformAnthropic},
+	}
+}
+
+func TestLokiOpsEventWriter_PushGroupsStreams(t *testing.T) {
+	var got lokiPushRequest
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		require.Equal(t, "/loki/api/v1/push", r.URL.Path)
+		require.Equal(t, "tenant-a", r.Header.Get("X-Scope-OrgID"))
+		user, pass, ok := r.BasicAuth()
+		require.True(t, ok)
+		require.Equal(t, "u", user)
+		require.Equal(t, "p", pass)
+		require.NoError(t, json.NewDecoder(r.Body).Decode(&got))
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	defer srv.Close()
+
+	writer := NewLokiOpsEventWriter(config.OpsLokiConfig{
+		Endpoint: srv.URL + "/",
+		TenantID: "tenant-a",
+		Username: "u",
+		Password: "p",
+		Labels:   map[string]string{"job": "sub2api"},
+	}, time.Second)
+	require.NoError(t, writer.WriteOpsEvents(context.Background(), testOpsLogShippingEvents()))
+
+	require.Len(t, got.Streams, 2)
+	success := got.Streams[0]
+	require.Equal(t, map[string]string{"job": "sub2api", "kind": "success", "platform": "anthropic"}, success.Stream)
+	require.Len(t, success.Values, 2)
+	require.Equal(t, "1772409599000000000", success.Values[0][0])
+	require.Contains(t, success.Values[0][1], `"request_id":"r1"`)
+	require.Equal(t, "error", got.Streams[1].Stream["kind"])
+}
+
+func TestLokiOpsEventWriter_Non2xxIsError(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		http.Error(w, "entry too far behind", http.StatusBadRequest)
+	}))
+	defer srv.Close()
+
+	writer := NewLokiOpsEventWriter(config.OpsLokiConfig{Endpoint: srv.URL}, time.Second)
+	err := writer.WriteOpsEvents(context.Background(), testOpsLogShippingEvents())
+	require.ErrorContains(t, err, "loki status 400")
+	require.ErrorContains(t, err, "entry too far behind")
+}
+
+func TestElasticsearchOpsEventWriter_BulkDailyIndex(t *testing.T) {
+	var lines []string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		require.Equal(t, "/_bulk", r.URL.Path)
+		require.Equal(t, "application/x-ndjson", r.Header.Get("Content-Type"))
+		require.Equal(t, "ApiKey abc", r.Header.Get("Authorization"))
+		scanner := bufio.NewScanner(r.Body)
+		for scanner.Scan() {
+			lines = append(lines, scanner.Text())
+		}
+		_, _ = io.WriteString(w, `{"errors":false,"items":[]}`)
+	}))
+	defer srv.Close()
+
+	writer := NewElasticsearchOpsEventWriter(config.OpsElasticsearchConfig{
+		Endpoint:    srv.URL,
+		IndexPrefix: "sub2api-ops",
+		Username:    "ignored",
+		APIKey:      "abc",
+	}, time.Second)
+	require.NoError(t, writer.WriteOpsEvents(context.Background(), testOpsLogShippingEvents()))
+
+	require.Len(t, lines, 6)
+	require.Equal(t, `{"index":{"_index":"sub2api-ops-2026.03.01"}}`, lines[0])
+	require.Equal(t, `{"index":{"_index":"sub2api-ops-2026.03.02"}}`, lines[2])
+	var doc map[string]any
+	require.NoError(t, json.Unmarshal([]byte(lines[1]), &doc))
+	require.Equal(t, "2026-03-01T23:59:59Z", doc["@timestamp"])
+	require.Equal(t, "r1", doc["request_id"])
+}
+
+func TestElasticsearchOpsEventWriter_PartialBulkFailure(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = io.WriteString(w, `{"errors":true,"items":[
+			{"index":{"status":201}},
+			{"index":{"status":400,"error":{"type":"mapper_parsing_exception","reason":"failed to parse field [status_code]"}}}
+		]}`)
+	}))
+	defer srv.Close()
+
+	writer := NewElasticsearchOpsEventWriter(config.OpsElasticsearchConfig{Endpoint: srv.URL, IndexPrefix: "ops"}, time.Second)
+	err := writer.WriteOpsEvents(context.Background(), testOpsLogShippingEvents()[:2])
+	require.Error(t, err)
+	require.True(t, strings.Contains(err.Error(), "1 of 2 items failed: mapper_parsing_exception"), err.Error())
+}
+
+func TestProvideOpsLogShippers(t *testing.T) {
+	cfg := &config.Config{}
+	require.Empty(t, ProvideOpsLogShippers(cfg))
+
+	cfg.Ops.LogShipping.Loki.Enabled = true
+	cfg.Ops.LogShipping.Elasticsearch.Enabled = true
+	shippers := ProvideOpsLogShippers(cfg)
+	require.Len(t, shippers, 2)
+	require.Equal(t, "loki", shippers[0].Name)
+	require.Equal(t, "elasticsearch", shippers[1].Name)
+}
@@ -0,0 +1,217 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/Wei-Shaw/sub2api/internal/service"
+)
+
+const (
+	// opsGroupPhaseColumns 每行写入的列数
+	opsGroupPhaseColumns = 18
+	// opsGroupPhaseUpsertBatch 单条 INSERT 的行数上限（避免超出 PostgreSQL 参数个数限制）
+	opsGroupPhaseUpsertBatch = 1000
+)
+
+// opsGroupPhaseAggSelect 聚合列（sum 相加，max 取最大），顺序与 opsGroupPhaseAggDest 一致
+const opsGroupPhaseAggSelect = `
+  COALESCE(SUM(m.request_count), 0),
+  COALESCE(SUM(m.queued_count), 0),
+  COALESCE(SUM(m.queue_wait_sum_ms), 0),
+  COALESCE(MAX(m.queue_wait_max_ms), 0),
+  COALESCE(SUM(m.slot_waited_count), 0),
+  COALESCE(SUM(m.slot_wait_sum_ms), 0),
+  COALESCE(MAX(m.slot_wait_max_ms), 0),
+  COALESCE(SUM(m.selection_sum_ms), 0),
+  COALESCE(MAX(m.selection_max_ms), 0),
+  COALESCE(SUM(m.ttfb_count), 0),
+  COALESCE(SUM(m.ttfb_sum_ms), 0),
+  COALESCE(MAX(m.ttfb_max_ms), 0),
+  COALESCE(SUM(m.stream_count), 0),
+  COALESCE(SUM(m.stream_sum_ms), 0),
+  COALESCE(MAX(m.stream_max_ms), 0)`
+
+func opsGroupPhaseAggDest(b *service.OpsGroupPhaseBucket) []any {
+	return []any{
+		&b.RequestCount,
+		&b.QueuedCount, &b.QueueWaitSumMs, &b.QueueWaitMaxMs,
+		&b.SlotWaitedCount, &b.SlotWaitSumMs, &b.SlotWaitMaxMs,
+		&b.SelectionSumMs, &b.SelectionMaxMs,
+		&b.TTFBCount, &b.TTFBSumMs, &b.TTFBMaxMs,
+		&b.StreamCount, &b.StreamSumMs, &b.StreamMaxMs,
+	}
+}
+
+func (r *opsRepository) UpsertGroupPhaseBuckets(ctx context.Context, buckets []*service.OpsGroupPhaseBucket) error {
+	if r == nil || r.db == nil {
+		return fmt.Errorf("nil ops repository")
+	}
+	for len(buckets) > opsGroupPhaseUpsertBatch {
+		if err := r.upsertGroupPhaseBatch(ctx, buckets[:opsGroupPhaseUpsertBatch]); err != nil {
+			return err
+		}
+		buckets = buckets[opsGroupPhaseUpsertBatch:]
+	}
+	return r.upsertGroupPhaseBatch(ctx, buckets)
+}
+
+func (r *opsRepository) upsertGroupPhaseBatch(ctx context.Context, buckets []*service.OpsGroupPhaseBucket) error {
+	values := make([]string, 0, len(buckets))
+	args := make([]any, 0, len(buckets)*opsGroupPhaseColumns)
+	for _, b := range buckets {
+		if b == nil {
+			continue
+		}
+		placeholders := make([]string, opsGroupPhaseColumns)
+		for i := range placeholders {
+			placeholders[i] = fmt.Sprintf("$%d", len(args)+i+1)
+		}
+		values = append(values, "("+strings.Join(placeholders, ",")+")")
+		args = append(args,
+			b.BucketStart.UTC(), b.GroupID, b.Platform,
+			b.RequestCount,
+			b.QueuedCount, b.QueueWaitSumMs, b.QueueWaitMaxMs,
+			b.SlotWaitedCount, b.SlotWaitSumMs, b.SlotWaitMaxMs,
+			b.SelectionSumMs, b.SelectionMaxMs,
+			b.TTFBCount, b.TTFBSumMs, b.TTFBMaxMs,
+			b.StreamCount, b.StreamSumMs, b.StreamMaxMs,
+		)
+	}
+	if len(values) == 0 {
+		return nil
+	}
+
+	q := `
+INSERT INTO ops_group_phase_metrics AS m (
+  bucket_start, group_id, platform,
+  request_count,
+  queued_count, queue_wait_sum_ms, queue_wait_max_ms,
+  slot_waited_count, slot_wait_sum_ms, slot_wait_max_ms,
+  selection_sum_ms, selection_max_ms,
+  ttfb_count, ttfb_sum_ms, ttfb_max_ms,
+  stream_count, stream_sum_ms, stream_max_ms
+) VALUES ` + strings.Join(values, ",") + `
+ON CONFLICT (bucket_start, group_id, platform) DO UPDATE SET
+  request_count = m.request_count + EXCLUDED.request_count,
+  queued_count = m.queued_count + EXCLUDED.queued_count,
+  queue_wait_sum_ms = m.queue_wait_sum_ms + EXCLUDED.queue_wait_sum_ms,
+  queue_wait_max_ms = GREATEST(m.queue_wait_max_ms, EXCLUDED.queue_wait_max_ms),
+  slot_waited_count = m.slot_waited_count + EXCLUDED.slot_waited_count,
+  slot_wait_sum_ms = m.slot_wait_sum_ms + EXCLUDED.slot_wait_sum_ms,
+  slot_wait_max_ms = GREATEST(m.slot_wait_max_ms, EXCLUDED.slot_wait_max_ms),
+  selection_sum_ms = m.selection_sum_ms + EXCLUDED.selection_sum_ms,
+  selection_max_ms = GREATEST(m.selection_max_ms, EXCLUDED.selection_max_ms),
+  ttfb_count = m.ttfb_count + EXCLUDED.ttfb_count,
+  ttfb_sum_ms = m.ttfb_sum_ms + EXCLUDED.ttfb_sum_ms,
+  ttfb_max_ms = GREATEST(m.ttfb_max_ms, EXCLUDED.ttfb_max_ms),
+  stream_count = m.stream_count + EXCLUDED.stream_count,
+  stream_sum_ms = m.stream_sum_ms + EXCLUDED.stream_sum_ms,
+  stream_max_ms = GREATEST(m.stream_max_ms, EXCLUDED.stream_max_ms),
+  updated_at = NOW()`
+
+	_, err := r.db.ExecContext(ctx, q, args...)
+	return err
+}
+
+// buildGroupPhaseWhere 构造阶段耗时查询条件（列名带 m. 前缀）
+func buildGroupPhaseWhere(filter *service.OpsDashboardFilter) (string, []any) {
+	args := []any{filter.StartTime.UTC(), filter.EndTime.UTC()}
+	clauses := []string{"m.bucket_start >= $1", "m.bucket_start < $2"}
+	if filter.GroupID != nil && *filter.GroupID > 0 {
+		args = append(args, *filter.GroupID)
+		clauses = append(clauses, fmt.Sprintf("m.group_id = $%d", len(args)))
+	}
+	if platform := strings.TrimSpace(strings.ToLower(filter.Platform)); platform != "" {
+		args = append(args, platform)
+		clauses = append(clauses, fmt.Sprintf("m.platform = $%d", len(args)))
+	}
+	return "WHERE " + strings.Join(clauses, " AND "), args
+}
+
+func (r *opsRepository) GetGroupPhaseTrend(ctx context.Context, filter *service.OpsDashboardFilter, bucketSeconds int) ([]*service.OpsGroupPhaseBucket, error) {
+	if r == nil || r.db == nil {
+		return nil, fmt.Errorf("nil ops repository")
+	}
+	if filter == nil {
+		return nil, fmt.Errorf("nil filter")
+	}
+
+	bucketExpr := "m.bucket_start"
+	switch bucketSeconds {
+	case 3600:
+		bucketExpr = "date_trunc('hour', m.bucket_start)"
+	case 300:
+		bucketExpr = "to_timestamp(floor(extract(epoch from m.bucket_start) / 300) * 300)"
+	}
+
+	where, args := buildGroupPhaseWhere(filter)
+	q := `
+SELECT ` + bucketExpr + ` AS bucket,` + opsGroupPhaseAggSelect + `
+FROM ops_group_phase_metrics m
+` + where + `
+GROUP BY 1
+ORDER BY 1`
+
+	rows, err := r.db.QueryContext(ctx, q, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer func() { _ = rows.Close() }()
+
+	out := make([]*service.OpsGroupPhaseBucket, 0, 64)
+	for rows.Next() {
+		var (
+			b      service.OpsGroupPhaseBucket
+			bucket time.Time
+		)
+		if err := rows.Scan(append([]any{&bucket}, opsGroupPhaseAggDest(&b)...)...); err != nil {
+			return nil, err
+		}
+		b.BucketStart = bucket.UTC()
+		out = append(out, &b)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (r *opsRepository) GetGroupPhaseSummary(ctx context.Context, filter *service.OpsDashboardFilter) ([]*service.OpsGroupPhaseBucket, error) {
+	if r == nil || r.db == nil {
+		return nil, fmt.Errorf("nil ops repository")
+	}
+	if filter == nil {
+		return nil, fmt.Errorf("nil filter")
+	}
+
+	where, args := buildGroupPhaseWhere(filter)
+	q := `
+SELECT m.group_id, COALESCE(MAX(g.name), ''),` + opsGroupPhaseAggSelect + `
+FROM ops_group_phase_metrics m
+LEFT JOIN groups g ON g.id = m.group_id
+` + where + `
+GROUP BY m.group_id
+ORDER BY m.group_id`
+
+	rows, err := r.db.QueryContext(ctx, q, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer func() { _ = rows.Close() }()
+
+	out := make([]*service.OpsGroupPhaseBucket, 0, 16)
+	for rows.Next() {
+		var b service.OpsGroupPhaseBucket
+		if err := rows.Scan(append([]any{&b.GroupID, &b.GroupName}, opsGroupPhaseAggDest(&b)...)...); err != nil {
+			return nil, err
+		}
+		out = append(out, &b)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return out, nil
+}
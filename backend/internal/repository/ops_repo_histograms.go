@@ -2,6 +2,7 @@ package repository
 
 import (
 	"context"
+	"database/sql"
 	"fmt"
 	"strings"
 
@@ -77,3 +78,140 @@ ORDER BY 3 ASC`
 		Buckets:       buckets,
 	}, nil
 }
+
+// accountLatencyMetricExprs 生成单个延迟列的聚合表达式：数量、分位数、均值、最大值与各桶计数
+func accountLatencyMetricExprs(column string) []string {
+	exprs := []string{
+		"COUNT(" + column + ")",
+		"percentile_cont(0.50) WITHIN GROUP (ORDER BY " + column + ")",
+		"percentile_cont(0.90) WITHIN GROUP (ORDER BY " + column + ")",
+		"percentile_cont(0.95) WITHIN GROUP (ORDER BY " + column + ")",
+		"percentile_cont(0.99) WITHIN GROUP (ORDER BY " + column + ")",
+		"AVG(" + column + ")",
+		"MAX(" + column + ")",
+	}
+	lower := 0
+	for _, upper := range service.OpsAccountLatencyBucketBoundsMs {
+		exprs = append(exprs, fmt.Sprintf("COUNT(*) FILTER (WHERE %s >= %d AND %s < %d)", column, lower, column, upper))
+		lower = upper
+	}
+	return append(exprs, fmt.Sprintf("COUNT(*) FILTER (WHERE %s >= %d)", column, lower))
+}
+
+type accountLatencyMetricScan struct {
+	count              int64
+	p50, p90, p95, p99 sql.NullFloat64
+	avg                sql.NullFloat64
+	max                sql.NullInt64
+	buckets            []int64
+}
+
+func (m *accountLatencyMetricScan) dest() []any {
+	m.buckets = make([]int64, len(service.OpsAccountLatencyBucketBoundsMs)+1)
+	out := []any{&m.count, &m.p50, &m.p90, &m.p95, &m.p99, &m.avg, &m.max}
+	for i := range m.buckets {
+		out = append(out, &m.buckets[i])
+	}
+	return out
+}
+
+func (m *accountLatencyMetricScan) distribution(labels []string) service.OpsLatencyDistribution {
+	dist := service.OpsLatencyDistribution{
+		Count: m.count,
+		Percentiles: service.OpsPercentiles{
+			P50: floatToIntPtr(m.p50),
+			P90: floatToIntPtr(m.p90),
+			P95: floatToIntPtr(m.p95),
+			P99: floatToIntPtr(m.p99),
+			Avg: floatToIntPtr(m.avg),
+		},
+		Buckets: make([]*service.OpsLatencyHistogramBucket, 0, len(labels)),
+	}
+	if m.max.Valid {
+		v := int(m.max.Int64)
+		dist.Percentiles.Max = &v
+	}
+	for i, label := range labels {
+		dist.Buckets = append(dist.Buckets, &service.OpsLatencyHistogramBucket{Range: label, Count: m.buckets[i]})
+	}
+	return dist
+}
+
+func (r *opsRepository) GetAccountLatencyHistograms(ctx context.Context, filter *service.OpsAccountLatencyFilter) (*service.OpsAccountLatencyHistogramResponse, error) {
+	if r == nil || r.db == nil {
+		return nil, fmt.Errorf("nil ops repository")
+	}
+	if filter == nil {
+		return nil, fmt.Errorf("nil filter")
+	}
+	if filter.StartTime.IsZero() || filter.EndTime.IsZero() {
+		return nil, fmt.Errorf("start_time/end_time required")
+	}
+
+	start := filter.StartTime.UTC()
+	end := filter.EndTime.UTC()
+
+	join, where, args, idx := buildUsageWhere(&service.OpsDashboardFilter{Platform: filter.Platform, GroupID: filter.GroupID}, start, end, 1)
+	if join == "" {
+		join = "LEFT JOIN accounts a ON a.id = ul.account_id"
+	}
+	if filter.AccountID != nil && *filter.AccountID > 0 {
+		args = append(args, *filter.AccountID)
+		where += fmt.Sprintf(" AND ul.account_id = $%d", idx)
+		idx++
+	}
+	if filter.Model != "" {
+		args = append(args, filter.Model)
+		where += fmt.Sprintf(" AND ul.model = $%d", idx)
+		idx++
+	}
+	args = append(args, filter.Limit)
+
+	selects := []string{"ul.account_id", "COALESCE(MAX(a.name), '')", "COALESCE(MAX(a.platform), '')", "ul.model"}
+	selects = append(selects, accountLatencyMetricExprs("ul.duration_ms")...)
+	selects = append(selects, accountLatencyMetricExprs("ul.first_token_ms")...)
+
+	q := `
+SELECT
+  ` + strings.Join(selects, ",\n  ") + `
+FROM usage_logs ul
+` + join + `
+` + where + `
+AND ul.duration_ms IS NOT NULL
+GROUP BY ul.account_id, ul.model
+ORDER BY COUNT(*) DESC, ul.account_id ASC, ul.model ASC
+LIMIT $` + fmt.Sprint(idx)
+
+	rows, err := r.db.QueryContext(ctx, q, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer func() { _ = rows.Close() }()
+
+	labels := service.OpsAccountLatencyBucketLabels()
+	items := make([]*service.OpsAccountLatencyHistogram, 0)
+	for rows.Next() {
+		item := &service.OpsAccountLatencyHistogram{}
+		var duration, ttft accountLatencyMetricScan
+		dest := []any{&item.AccountID, &item.AccountName, &item.Platform, &item.Model}
+		dest = append(dest, duration.dest()...)
+		dest = append(dest, ttft.dest()...)
+		if err := rows.Scan(dest...); err != nil {
+			return nil, err
+		}
+		item.Duration = duration.distribution(labels)
+		item.TTFT = ttft.distribution(labels)
+		items = append(items, item)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return &service.OpsAccountLatencyHistogramResponse{
+		StartTime: start,
+		EndTime:   end,
+		Platform:  strings.TrimSpace(filter.Platform),
+		GroupID:   filter.GroupID,
+		Items:     items,
+	}, nil
+}
@@ -3,6 +3,7 @@ package repository
 import (
 	"testing"
 
+	"github.com/Wei-Shaw/sub2api/internal/service"
 	"github.com/stretchr/testify/require"
 )
 
@@ -12,3 +13,16 @@ func TestLatencyHistogramBuckets_AreConsistent(t *testing.T) {
 		require.Equal(t, b.label, latencyHistogramOrderedRanges[i])
 	}
 }
+
+func TestAccountLatencyMetricExprs_MatchBucketLabels(t *testing.T) {
+	labels := service.OpsAccountLatencyBucketLabels()
+	require.Len(t, labels, len(service.OpsAccountLatencyBucketBoundsMs)+1)
+	require.Equal(t, "0-250ms", labels[0])
+	require.Equal(t, "120000ms+", labels[len(labels)-1])
+
+	// 7 个汇总列（数量、4 个分位数、均值、最大值）+ 每个桶一列，需与扫描目标数量一致
+	exprs := accountLatencyMetricExprs("ul.duration_ms")
+	var scan accountLatencyMetricScan
+	require.Len(t, exprs, len(scan.dest()))
+	require.Equal(t, "COUNT(*) FILTER (WHERE ul.duration_ms >= 120000)", exprs[len(exprs)-1])
+}
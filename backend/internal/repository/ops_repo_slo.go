@@ -0,0 +1,46 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/Wei-Shaw/sub2api/internal/service"
+)
+
+func (r *opsRepository) GetSLOCounts(ctx context.Context, filter *service.OpsDashboardFilter, ttftThresholdMs int) (*service.OpsSLOCounts, error) {
+	if r == nil || r.db == nil {
+		return nil, fmt.Errorf("nil ops repository")
+	}
+	if filter == nil {
+		return nil, fmt.Errorf("nil filter")
+	}
+	if filter.StartTime.IsZero() || filter.EndTime.IsZero() {
+		return nil, fmt.Errorf("start_time/end_time required")
+	}
+
+	start := filter.StartTime.UTC()
+	end := filter.EndTime.UTC()
+	out := &service.OpsSLOCounts{}
+
+	join, where, args, next := buildUsageWhere(filter, start, end, 1)
+	args = append(args, ttftThresholdMs)
+	withinExpr := fmt.Sprintf("COUNT(*) FILTER (WHERE ul.first_token_ms IS NOT NULL AND ul.first_token_ms <= $%d)", next)
+	q := `
+SELECT
+  COALESCE(COUNT(*), 0) AS success_count,
+  COALESCE(COUNT(*) FILTER (WHERE ul.first_token_ms IS NOT NULL), 0) AS ttft_count,
+  COALESCE(` + withinExpr + `, 0) AS ttft_within
+FROM usage_logs ul
+` + join + `
+` + where
+	if err := r.db.QueryRowContext(ctx, q, args...).Scan(&out.SuccessCount, &out.TTFTCount, &out.TTFTWithinThreshold); err != nil {
+		return nil, err
+	}
+
+	_, _, errorCountSLA, _, _, _, err := r.queryErrorCounts(ctx, filter, start, end)
+	if err != nil {
+		return nil, err
+	}
+	out.ErrorCountSLA = errorCountSLA
+	return out, nil
+}
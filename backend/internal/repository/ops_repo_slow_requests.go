@@ -0,0 +1,305 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"math"
+	"strings"
+
+	"github.com/Wei-Shaw/sub2api/internal/service"
+)
+
+func (r *opsRepository) InsertSlowRequest(ctx context.Context, input *service.OpsInsertSlowRequestInput) error {
+	if r == nil || r.db == nil {
+		return fmt.Errorf("nil ops repository")
+	}
+	if input == nil {
+		return fmt.Errorf("nil input")
+	}
+
+	q := `
+INSERT INTO ops_slow_requests (
+  request_id,
+  user_id,
+  api_key_id,
+  account_id,
+  group_id,
+  platform,
+  model,
+  request_path,
+  stream,
+  status_code,
+  duration_ms,
+  first_token_ms,
+  retry_count,
+  reasons,
+  created_at
+) VALUES ($1,$2,$3,$4,$5,$6,$7,$8,$9,$10,$11,$12,$13,$14,$15)`
+
+	_, err := r.db.ExecContext(ctx, q,
+		input.RequestID,
+		opsNullInt64(input.UserID),
+		opsNullInt64(input.APIKeyID),
+		opsNullInt64(input.AccountID),
+		opsNullInt64(input.GroupID),
+		strings.ToLower(strings.TrimSpace(input.Platform)),
+		input.Model,
+		input.RequestPath,
+		input.Stream,
+		input.StatusCode,
+		input.DurationMs,
+		opsNullInt(input.FirstTokenMs),
+		input.RetryCount,
+		strings.Join(input.Reasons, ","),
+		input.CreatedAt,
+	)
+	return err
+}
+
+// buildSlowRequestWhere 构造慢请求查询条件（列名带 s. 前缀）
+func buildSlowRequestWhere(filter *service.OpsSlowRequestFilter) (string, []any) {
+	clauses := make([]string, 0, 8)
+	args := make([]any, 0, 8)
+	add := func(clause string, value any) {
+		args = append(args, value)
+		clauses = append(clauses, fmt.Sprintf(clause, len(args)))
+	}
+
+	if filter.StartTime != nil {
+		add("s.created_at >= $%d", filter.StartTime.UTC())
+	}
+	if filter.EndTime != nil {
+		add("s.created_at < $%d", filter.EndTime.UTC())
+	}
+	if platform := strings.TrimSpace(strings.ToLower(filter.Platform)); platform != "" {
+		add("s.platform = $%d", platform)
+	}
+	if filter.GroupID != nil && *filter.GroupID > 0 {
+		add("s.group_id = $%d", *filter.GroupID)
+	}
+	if filter.AccountID != nil && *filter.AccountID > 0 {
+		add("s.account_id = $%d", *filter.AccountID)
+	}
+	if model := strings.TrimSpace(filter.Model); model != "" {
+		add("s.model = $%d", model)
+	}
+	if reason := strings.TrimSpace(strings.ToLower(filter.Reason)); reason != "" {
+		add("$%d = ANY(string_to_array(s.reasons, ','))", reason)
+	}
+
+	if len(clauses) == 0 {
+		return "", args
+	}
+	return "WHERE " + strings.Join(clauses, " AND "), args
+}
+
+func (r *opsRepository) ListSlowRequests(ctx context.Context, filter *service.OpsSlowRequestFilter) ([]*service.OpsSlowRequest, int64, error) {
+	if r == nil || r.db == nil {
+		return nil, 0, fmt.Errorf("nil ops repository")
+	}
+	if filter == nil {
+		filter = &service.OpsSlowRequestFilter{}
+	}
+
+	page, pageSize, _, _ := filter.Normalize()
+	where, args := buildSlowRequestWhere(filter)
+
+	var total int64
+	if err := r.db.QueryRowContext(ctx, `SELECT COUNT(*) FROM ops_slow_requests s `+where, args...).Scan(&total); err != nil {
+		return nil, 0, err
+	}
+
+	q := fmt.Sprintf(`
+SELECT
+  s.id,
+  s.created_at,
+  s.request_id,
+  s.user_id,
+  s.api_key_id,
+  s.account_id,
+  COALESCE(a.name, ''),
+  s.group_id,
+  s.platform,
+  s.model,
+  s.request_path,
+  s.stream,
+  s.status_code,
+  s.duration_ms,
+  s.first_token_ms,
+  s.retry_count,
+  s.reasons
+FROM ops_slow_requests s
+LEFT JOIN accounts a ON a.id = s.account_id
+%s
+ORDER BY s.created_at DESC, s.id DESC
+LIMIT $%d OFFSET $%d`, where, len(args)+1, len(args)+2)
+	args = append(args, pageSize, (page-1)*pageSize)
+
+	rows, err := r.db.QueryContext(ctx, q, args...)
+	if err != nil {
+		return nil, 0, err
+	}
+	defer func() { _ = rows.Close() }()
+
+	out := make([]*service.OpsSlowRequest, 0, pageSize)
+	for rows.Next() {
+		var (
+			item                                 service.OpsSlowRequest
+			userID, apiKeyID, accountID, groupID sql.NullInt64
+			firstTokenMs                         sql.NullInt64
+			reasons                              string
+		)
+		if err := rows.Scan(
+			&item.ID,
+			&item.CreatedAt,
+			&item.RequestID,
+			&userID,
+			&apiKeyID,
+			&accountID,
+			&item.AccountName,
+			&groupID,
+			&item.Platform,
+			&item.Model,
+			&item.RequestPath,
+			&item.Stream,
+			&item.StatusCode,
+			&item.DurationMs,
+			&firstTokenMs,
+			&item.RetryCount,
+			&reasons,
+		); err != nil {
+			return nil, 0, err
+		}
+		item.UserID = slowRequestInt64Ptr(userID)
+		item.APIKeyID = slowRequestInt64Ptr(apiKeyID)
+		item.AccountID = slowRequestInt64Ptr(accountID)
+		item.GroupID = slowRequestInt64Ptr(groupID)
+		if firstTokenMs.Valid {
+			v := int(firstTokenMs.Int64)
+			item.FirstTokenMs = &v
+		}
+		item.Reasons = splitSlowRequestReasons(reasons)
+		out = append(out, &item)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, 0, err
+	}
+	return out, total, nil
+}
+
+func (r *opsRepository) GetSlowRequestSummary(ctx context.Context, filter *service.OpsSlowRequestFilter, limit int) ([]*service.OpsSlowRequestSummary, error) {
+	if r == nil || r.db == nil {
+		return nil, fmt.Errorf("nil ops repository")
+	}
+	if filter == nil {
+		filter = &service.OpsSlowRequestFilter{}
+	}
+	if limit <= 0 {
+		limit = 50
+	}
+
+	where, args := buildSlowRequestWhere(filter)
+	q := fmt.Sprintf(`
+SELECT
+  s.account_id,
+  COALESCE(MAX(a.name), ''),
+  s.platform,
+  s.model,
+  COUNT(*),
+  COUNT(*) FILTER (WHERE 'ttft' = ANY(string_to_array(s.reasons, ','))),
+  AVG(s.duration_ms),
+  MAX(s.duration_ms),
+  AVG(s.first_token_ms),
+  AVG(s.retry_count),
+  MAX(s.created_at)
+FROM ops_slow_requests s
+LEFT JOIN accounts a ON a.id = s.account_id
+%s
+GROUP BY s.account_id, s.platform, s.model
+ORDER BY COUNT(*) DESC, MAX(s.created_at) DESC
+LIMIT $%d`, where, len(args)+1)
+	args = append(args, limit)
+
+	rows, err := r.db.QueryContext(ctx, q, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer func() { _ = rows.Close() }()
+
+	out := make([]*service.OpsSlowRequestSummary, 0, limit)
+	for rows.Next() {
+		var (
+			item          service.OpsSlowRequestSummary
+			accountID     sql.NullInt64
+			avgDuration   sql.NullFloat64
+			maxDuration   sql.NullInt64
+			avgFirstToken sql.NullFloat64
+			avgRetry      sql.NullFloat64
+		)
+		if err := rows.Scan(
+			&accountID,
+			&item.AccountName,
+			&item.Platform,
+			&item.Model,
+			&item.Count,
+			&item.TTFTCount,
+			&avgDuration,
+			&maxDuration,
+			&avgFirstToken,
+			&avgRetry,
+			&item.LastSeenAt,
+		); err != nil {
+			return nil, err
+		}
+		item.AccountID = slowRequestInt64Ptr(accountID)
+		if avgDuration.Valid {
+			item.AvgDurationMs = int(math.Round(avgDuration.Float64))
+		}
+		if maxDuration.Valid {
+			item.MaxDurationMs = int(maxDuration.Int64)
+		}
+		item.AvgFirstTokenMs = floatToIntPtr(avgFirstToken)
+		if avgRetry.Valid {
+			item.AvgRetryCount = roundTo1DP(avgRetry.Float64)
+		}
+		out = append(out, &item)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (r *opsRepository) CountSlowRequests(ctx context.Context, filter *service.OpsSlowRequestFilter) (int64, error) {
+	if r == nil || r.db == nil {
+		return 0, fmt.Errorf("nil ops repository")
+	}
+	if filter == nil {
+		filter = &service.OpsSlowRequestFilter{}
+	}
+	where, args := buildSlowRequestWhere(filter)
+	var count int64
+	if err := r.db.QueryRowContext(ctx, `SELECT COUNT(*) FROM ops_slow_requests s `+where, args...).Scan(&count); err != nil {
+		return 0, err
+	}
+	return count, nil
+}
+
+func splitSlowRequestReasons(raw string) []string {
+	out := []string{}
+	for _, part := range strings.Split(raw, ",") {
+		if part = strings.TrimSpace(part); part != "" {
+			out = append(out, part)
+		}
+	}
+	return out
+}
+
+func slowRequestInt64Ptr(v sql.NullInt64) *int64 {
+	if !v.Valid {
+		return nil
+	}
+	n := v.Int64
+	return &n
+}
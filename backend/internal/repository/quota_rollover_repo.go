@@ -0,0 +1,48 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+
+	"github.com/Wei-Shaw/sub2api/internal/service"
+)
+
+type quotaRolloverRepository struct {
+	sql sqlExecutor
+}
+
+// NewQuotaRolloverRepository 创建订阅月额度结转仓储
+func NewQuotaRolloverRepository(sqlDB *sql.DB) service.QuotaRolloverRepository {
+	return &quotaRolloverRepository{sql: sqlDB}
+}
+
+// GetBySubscriptionID 获取订阅最近一次结转记录
+func (r *quotaRolloverRepository) GetBySubscriptionID(ctx context.Context, subscriptionID int64) (*service.QuotaRollover, error) {
+	var rollover service.QuotaRollover
+	err := scanSingleRow(ctx, r.sql, `
+		SELECT subscription_id, amount_usd, window_start, expires_at, updated_at
+		FROM subscription_quota_rollovers WHERE subscription_id = $1`, []any{subscriptionID},
+		&rollover.SubscriptionID, &rollover.AmountUSD, &rollover.WindowStart, &rollover.ExpiresAt, &rollover.UpdatedAt,
+	)
+	if err != nil {
+		return nil, translatePersistenceError(err, service.ErrQuotaRolloverNotFound, nil)
+	}
+	return &rollover, nil
+}
+
+// Upsert 写入结转记录（覆盖上一次结转）
+func (r *quotaRolloverRepository) Upsert(ctx context.Context, rollover *service.QuotaRollover) error {
+	query := `
+		INSERT INTO subscription_quota_rollovers (subscription_id, amount_usd, window_start, expires_at)
+		VALUES ($1, $2, $3, $4)
+		ON CONFLICT (subscription_id) DO UPDATE SET
+			amount_usd = EXCLUDED.amount_usd,
+			window_start = EXCLUDED.window_start,
+			expires_at = EXCLUDED.expires_at,
+			updated_at = NOW()
+		RETURNING updated_at`
+	err := scanSingleRow(ctx, r.sql, query, []any{
+		rollover.SubscriptionID, rollover.AmountUSD, rollover.WindowStart, rollover.ExpiresAt,
+	}, &rollover.UpdatedAt)
+	return translatePersistenceError(err, service.ErrSubscriptionNotFound, nil)
+}
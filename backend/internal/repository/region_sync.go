@@ -0,0 +1,314 @@
+package repository
+
+import (
+	"context"
+	"crypto/tls"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/Wei-Shaw/sub2api/internal/config"
+	"github.com/Wei-Shaw/sub2api/internal/service"
+	"github.com/redis/go-redis/v9"
+)
+
+// regionPeerPoolSize 每个对端区域 Redis 的连接池大小（仅承载复制写入与未命中查询）
+const regionPeerPoolSize = 16
+
+// regionSyncDropLogInterval 队列满丢弃时的日志输出间隔
+const regionSyncDropLogInterval = time.Minute
+
+// regionSyncOp 在单个对端 Redis 上执行的复制操作
+type regionSyncOp func(ctx context.Context, rdb *redis.Client) error
+
+type regionPeer struct {
+	name string
+	rdb  *redis.Client
+}
+
+// RegionReplicator 将本区域的粘性会话绑定与账号临时不可调度状态异步复制到其他区域的 Redis。
+// 复制直接写入对端 Redis 而不经过对端网关，因此不会产生回环复制。
+type RegionReplicator struct {
+	region      string
+	peers       []regionPeer
+	queue       chan regionSyncOp
+	timeout     time.Duration
+	lookupPeers bool
+
+	dropped     atomic.Int64
+	lastDropLog atomic.Int64
+
+	stopCh   chan struct{}
+	stopOnce sync.Once
+	wg       sync.WaitGroup
+}
+
+// ProvideRegionReplicator 创建并启动跨区域复制器；未启用时返回 nil（nil 复制器的方法均为空操作）
+func ProvideRegionReplicator(cfg *config.Config) *RegionReplicator {
+	if cfg == nil || !cfg.Gateway.RegionSync.Enabled {
+		return nil
+	}
+	syncCfg := cfg.Gateway.RegionSync
+	r := &RegionReplicator{
+		region:      syncCfg.Region,
+		queue:       make(chan regionSyncOp, syncCfg.QueueSize),
+		timeout:     syncCfg.Timeout,
+		lookupPeers: syncCfg.LookupPeersOnMiss,
+		stopCh:      make(chan struct{}),
+	}
+	for i := range syncCfg.Peers {
+		peer := syncCfg.Peers[i]
+		name := peer.Name
+		if name == "" {
+			name = peer.Address()
+		}
+		r.peers = append(r.peers, regionPeer{name: name, rdb: redis.NewClient(buildRegionPeerOptions(cfg, &peer))})
+	}
+	r.Start()
+	log.Printf("[RegionSync] Enabled for region %q with %d peer(s)", r.region, len(r.peers))
+	return r
+}
+
+// buildRegionPeerOptions 构建对端 Redis 连接选项，超时沿用本区域 Redis 配置
+func buildRegionPeerOptions(cfg *config.Config, peer *config.GatewayRegionPeerConfig) *redis.Options {
+	opts := &redis.Options{
+		Addr:         peer.Address(),
+		Password:     peer.Password,
+		DB:           peer.DB,
+		DialTimeout:  time.Duration(cfg.Redis.DialTimeoutSeconds) * time.Second,
+		ReadTimeout:  time.Duration(cfg.Redis.ReadTimeoutSeconds) * time.Second,
+		WriteTimeout: time.Duration(cfg.Redis.WriteTimeoutSeconds) * time.Second,
+		PoolSize:     regionPeerPoolSize,
+	}
+	if peer.EnableTLS {
+		opts.TLSConfig = &tls.Config{
+			MinVersion: tls.VersionTLS12,
+			ServerName: peer.Host,
+		}
+	}
+	return opts
+}
+
+// Start 启动后台复制
+func (r *RegionReplicator) Start() {
+	if r == nil {
+		return
+	}
+	r.wg.Add(1)
+	go func() {
+		defer r.wg.Done()
+		for {
+			select {
+			case op := <-r.queue:
+				r.apply(op)
+			case <-r.stopCh:
+				// 尽力写出剩余操作
+				for {
+					select {
+					case op := <-r.queue:
+						r.apply(op)
+					default:
+						return
+					}
+				}
+			}
+		}
+	}()
+}
+
+// Stop 停止复制并关闭对端连接
+func (r *RegionReplicator) Stop() {
+	if r == nil {
+		return
+	}
+	r.stopOnce.Do(func() {
+		close(r.stopCh)
+		r.wg.Wait()
+		for _, peer := range r.peers {
+			_ = peer.rdb.Close()
+		}
+	})
+}
+
+// enqueue 非阻塞地提交复制操作，队列已满时丢弃
+func (r *RegionReplicator) enqueue(op regionSyncOp) {
+	if r == nil {
+		return
+	}
+	select {
+	case r.queue <- op:
+	default:
+		dropped := r.dropped.Add(1)
+		now := time.Now().UnixNano()
+		last := r.lastDropLog.Load()
+		if now-last >= int64(regionSyncDropLogInterval) && r.lastDropLog.CompareAndSwap(last, now) {
+			log.Printf("[RegionSync] Queue full, dropped %d replication ops so far", dropped)
+		}
+	}
+}
+
+func (r *RegionReplicator) apply(op regionSyncOp) {
+	for _, peer := range r.peers {
+		ctx, cancel := context.WithTimeout(context.Background(), r.timeout)
+		if err := op(ctx, peer.rdb); err != nil {
+			log.Printf("[RegionSync] Replicate to %s failed: %v", peer.name, err)
+		}
+		cancel()
+	}
+}
+
+// lookupSession 按顺序查询对端区域的粘性会话绑定，返回账号 ID 与剩余 TTL；均未命中时返回 redis.Nil
+func (r *RegionReplicator) lookupSession(ctx context.Context, key string) (int64, time.Duration, error) {
+	for _, peer := range r.peers {
+		lookupCtx, cancel := context.WithTimeout(ctx, r.timeout)
+		pipe := peer.rdb.Pipeline()
+		getCmd := pipe.Get(lookupCtx, key)
+		ttlCmd := pipe.PTTL(lookupCtx, key)
+		_, _ = pipe.Exec(lookupCtx)
+		cancel()
+
+		accountID, err := getCmd.Int64()
+		if err != nil {
+			if !errors.Is(err, redis.Nil) {
+				log.Printf("[RegionSync] Lookup sticky session on %s failed: %v", peer.name, err)
+			}
+			continue
+		}
+		ttl, err := ttlCmd.Result()
+		if err != nil || ttl <= 0 {
+			continue
+		}
+		return accountID, ttl, nil
+	}
+	return 0, 0, redis.Nil
+}
+
+// ProvideGatewayCache 创建粘性会话缓存；启用跨区域复制时同步写入其他区域
+func ProvideGatewayCache(rdb *redis.Client, replicator *RegionReplicator) service.GatewayCache {
+	cache := NewGatewayCache(rdb)
+	if replicator == nil {
+		return cache
+	}
+	return &regionSyncGatewayCache{GatewayCache: cache, replicator: replicator}
+}
+
+// ProvideTempUnschedCache 创建账号临时不可调度缓存；启用跨区域复制时同步写入其他区域
+func ProvideTempUnschedCache(rdb *redis.Client, replicator *RegionReplicator) service.TempUnschedCache {
+	cache := NewTempUnschedCache(rdb)
+	if replicator == nil {
+		return cache
+	}
+	return &regionSyncTempUnschedCache{TempUnschedCache: cache, replicator: replicator}
+}
+
+type regionSyncGatewayCache struct {
+	service.GatewayCache
+	replicator *RegionReplicator
+}
+
+func (c *regionSyncGatewayCache) GetSessionAccountID(ctx context.Context, groupID int64, sessionHash string) (int64, error) {
+	accountID, err := c.GatewayCache.GetSessionAccountID(ctx, groupID, sessionHash)
+	if !errors.Is(err, redis.Nil) || !c.replicator.lookupPeers {
+		return accountID, err
+	}
+	// 本区域未命中：可能是复制尚未到达，查询其他区域并回填本区域（不再复制回去）
+	key := buildSessionKey(groupID, sessionHash)
+	accountID, ttl, peerErr := c.replicator.lookupSession(ctx, key)
+	if peerErr != nil {
+		return 0, err
+	}
+	_ = c.GatewayCache.SetSessionAccountID(ctx, groupID, sessionHash, accountID, ttl)
+	return accountID, nil
+}
+
+func (c *regionSyncGatewayCache) SetSessionAccountID(ctx context.Context, groupID int64, sessionHash string, accountID int64, ttl time.Duration) error {
+	if err := c.GatewayCache.SetSessionAccountID(ctx, groupID, sessionHash, accountID, ttl); err != nil {
+		return err
+	}
+	key := buildSessionKey(groupID, sessionHash)
+	c.replicator.enqueue(func(ctx context.Context, rdb *redis.Client) error {
+		return rdb.Set(ctx, key, accountID, ttl).Err()
+	})
+	return nil
+}
+
+func (c *regionSyncGatewayCache) RefreshSessionTTL(ctx context.Context, groupID int64, sessionHash string, ttl time.Duration) error {
+	if err := c.GatewayCache.RefreshSessionTTL(ctx, groupID, sessionHash, ttl); err != nil {
+		return err
+	}
+	key := buildSessionKey(groupID, sessionHash)
+	c.replicator.enqueue(func(ctx context.Context, rdb *redis.Client) error {
+		return rdb.Expire(ctx, key, ttl).Err()
+	})
+	return nil
+}
+
+// ListAccountSessions 透传到本区域缓存的账号会话反向索引
+func (c *regionSyncGatewayCache) ListAccountSessions(ctx context.Context, accountID int64) ([]service.StickySessionBinding, error) {
+	index, ok := c.GatewayCache.(service.AccountSessionIndex)
+	if !ok {
+		return nil, nil
+	}
+	return index.ListAccountSessions(ctx, accountID)
+}
+
+// ClearAccountSessionIndex 透传到本区域缓存的账号会话反向索引
+func (c *regionSyncGatewayCache) ClearAccountSessionIndex(ctx context.Context, accountID int64) error {
+	index, ok := c.GatewayCache.(service.AccountSessionIndex)
+	if !ok {
+		return nil
+	}
+	return index.ClearAccountSessionIndex(ctx, accountID)
+}
+
+func (c *regionSyncGatewayCache) DeleteSessionAccountID(ctx context.Context, groupID int64, sessionHash string) error {
+	if err := c.GatewayCache.DeleteSessionAccountID(ctx, groupID, sessionHash); err != nil {
+		return err
+	}
+	key := buildSessionKey(groupID, sessionHash)
+	c.replicator.enqueue(func(ctx context.Context, rdb *redis.Client) error {
+		return rdb.Del(ctx, key).Err()
+	})
+	return nil
+}
+
+type regionSyncTempUnschedCache struct {
+	service.TempUnschedCache
+	replicator *RegionReplicator
+}
+
+func (c *regionSyncTempUnschedCache) SetTempUnsched(ctx context.Context, accountID int64, state *service.TempUnschedState) error {
+	if err := c.TempUnschedCache.SetTempUnsched(ctx, accountID, state); err != nil {
+		return err
+	}
+	stateJSON, err := json.Marshal(state)
+	if err != nil {
+		return nil
+	}
+	key := fmt.Sprintf("%s%d", tempUnschedPrefix, accountID)
+	untilUnix := state.UntilUnix
+	c.replicator.enqueue(func(ctx context.Context, rdb *redis.Client) error {
+		ttlSeconds := int(time.Until(time.Unix(untilUnix, 0)).Seconds())
+		if ttlSeconds < 1 {
+			return nil
+		}
+		// 与本地写入一致：只延长不缩短，避免覆盖对端更长的冷却
+		return tempUnschedSetScript.Run(ctx, rdb, []string{key}, untilUnix, string(stateJSON), ttlSeconds).Err()
+	})
+	return nil
+}
+
+func (c *regionSyncTempUnschedCache) DeleteTempUnsched(ctx context.Context, accountID int64) error {
+	if err := c.TempUnschedCache.DeleteTempUnsched(ctx, accountID); err != nil {
+		return err
+	}
+	key := fmt.Sprintf("%s%d", tempUnschedPrefix, accountID)
+	c.replicator.enqueue(func(ctx context.Context, rdb *redis.Client) error {
+		return rdb.Del(ctx, key).Err()
+	})
+	return nil
+}
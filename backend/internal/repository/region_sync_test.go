@@ -0,0 +1,58 @@
+package repository
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/Wei-Shaw/sub2api/internal/config"
+	"github.com/redis/go-redis/v9"
+	"github.com/stretchr/testify/require"
+)
+
+func TestBuildRegionPeerOptions(t *testing.T) {
+	cfg := &config.Config{
+		Redis: config.RedisConfig{
+			DialTimeoutSeconds:  5,
+			ReadTimeoutSeconds:  3,
+			WriteTimeoutSeconds: 4,
+		},
+	}
+	peer := &config.GatewayRegionPeerConfig{Name: "eu-west", Host: "redis.eu", Port: 6380, Password: "secret", DB: 1, EnableTLS: true}
+
+	opts := buildRegionPeerOptions(cfg, peer)
+	require.Equal(t, "redis.eu:6380", opts.Addr)
+	require.Equal(t, "secret", opts.Password)
+	require.Equal(t, 1, opts.DB)
+	require.Equal(t, 5*time.Second, opts.DialTimeout)
+	require.Equal(t, regionPeerPoolSize, opts.PoolSize)
+	require.NotNil(t, opts.TLSConfig)
+	require.Equal(t, "redis.eu", opts.TLSConfig.ServerName)
+}
+
+func TestProvideRegionReplicator_Disabled(t *testing.T) {
+	replicator := ProvideRegionReplicator(&config.Config{})
+	require.Nil(t, replicator)
+
+	// nil 复制器的方法均为空操作，缓存不做包装
+	replicator.Stop()
+	rdb := redis.NewClient(&redis.Options{Addr: "127.0.0.1:0"})
+	defer func() { _ = rdb.Close() }()
+	require.IsType(t, &gatewayCache{}, ProvideGatewayCache(rdb, replicator))
+	require.IsType(t, &tempUnschedCache{}, ProvideTempUnschedCache(rdb, replicator))
+}
+
+func TestRegionReplicator_DropsWhenQueueFull(t *testing.T) {
+	replicator := &RegionReplicator{queue: make(chan regionSyncOp, 1), stopCh: make(chan struct{})}
+	noop := func(ctx context.Context, rdb *redis.Client) error { return nil }
+
+	// 未启动时队列不会被消费
+	replicator.enqueue(noop)
+	replicator.enqueue(noop)
+	require.Equal(t, int64(1), replicator.dropped.Load())
+
+	// 无对端时停止会清空队列
+	replicator.Start()
+	replicator.Stop()
+	require.Empty(t, replicator.queue)
+}
@@ -0,0 +1,50 @@
+package repository
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"time"
+
+	"github.com/Wei-Shaw/sub2api/internal/service"
+	"github.com/redis/go-redis/v9"
+)
+
+const responseCacheKeyPrefix = "response_cache:"
+
+// responseCacheKey generates the Redis key for a cached gateway response.
+func responseCacheKey(key string) string {
+	return responseCacheKeyPrefix + key
+}
+
+type responseCache struct {
+	rdb *redis.Client
+}
+
+// NewResponseCache 创建网关响应缓存
+func NewResponseCache(rdb *redis.Client) service.ResponseCache {
+	return &responseCache{rdb: rdb}
+}
+
+func (c *responseCache) GetCachedResponse(ctx context.Context, key string) (*service.CachedResponse, error) {
+	val, err := c.rdb.Get(ctx, responseCacheKey(key)).Bytes()
+	if err != nil {
+		if errors.Is(err, redis.Nil) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	var resp service.CachedResponse
+	if err := json.Unmarshal(val, &resp); err != nil {
+		return nil, err
+	}
+	return &resp, nil
+}
+
+func (c *responseCache) SetCachedResponse(ctx context.Context, key string, resp *service.CachedResponse, ttl time.Duration) error {
+	val, err := json.Marshal(resp)
+	if err != nil {
+		return err
+	}
+	return c.rdb.Set(ctx, responseCacheKey(key), val, ttl).Err()
+}
@@ -0,0 +1,75 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/Wei-Shaw/sub2api/internal/service"
+	"github.com/redis/go-redis/v9"
+)
+
+const (
+	spendCapKeyPrefix = "spend_cap:user:"
+	spendCapDayTTL    = 49 * time.Hour
+	spendCapMonthTTL  = 33 * 24 * time.Hour
+)
+
+// spendCapDayKey generates the Redis key for a user's daily spend counter.
+func spendCapDayKey(userID int64, day string) string {
+	return fmt.Sprintf("%s%d:d:%s", spendCapKeyPrefix, userID, day)
+}
+
+// spendCapMonthKey generates the Redis key for a user's monthly spend counter.
+func spendCapMonthKey(userID int64, month string) string {
+	return fmt.Sprintf("%s%d:m:%s", spendCapKeyPrefix, userID, month)
+}
+
+type spendCapCache struct {
+	rdb *redis.Client
+}
+
+// NewSpendCapCache 创建用户消费计数缓存
+func NewSpendCapCache(rdb *redis.Client) service.SpendCapCache {
+	return &spendCapCache{rdb: rdb}
+}
+
+func (c *spendCapCache) GetUserSpend(ctx context.Context, userID int64, day, month string) (*service.SpendCapUsage, error) {
+	vals, err := c.rdb.MGet(ctx, spendCapDayKey(userID, day), spendCapMonthKey(userID, month)).Result()
+	if err != nil && !errors.Is(err, redis.Nil) {
+		return nil, err
+	}
+	usage := &service.SpendCapUsage{}
+	if len(vals) == 2 {
+		usage.DailyUSD = parseSpendCounter(vals[0])
+		usage.MonthlyUSD = parseSpendCounter(vals[1])
+	}
+	return usage, nil
+}
+
+func (c *spendCapCache) IncrUserSpend(ctx context.Context, userID int64, day, month string, amount float64) (*service.SpendCapUsage, error) {
+	dayKey := spendCapDayKey(userID, day)
+	monthKey := spendCapMonthKey(userID, month)
+
+	pipe := c.rdb.TxPipeline()
+	dayCmd := pipe.IncrByFloat(ctx, dayKey, amount)
+	pipe.Expire(ctx, dayKey, spendCapDayTTL)
+	monthCmd := pipe.IncrByFloat(ctx, monthKey, amount)
+	pipe.Expire(ctx, monthKey, spendCapMonthTTL)
+	if _, err := pipe.Exec(ctx); err != nil {
+		return nil, err
+	}
+	return &service.SpendCapUsage{DailyUSD: dayCmd.Val(), MonthlyUSD: monthCmd.Val()}, nil
+}
+
+// parseSpendCounter 解析 MGET 返回的金额，缺失视为 0
+func parseSpendCounter(v any) float64 {
+	str, ok := v.(string)
+	if !ok {
+		return 0
+	}
+	f, _ := strconv.ParseFloat(str, 64)
+	return f
+}
@@ -0,0 +1,74 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+
+	"github.com/Wei-Shaw/sub2api/internal/service"
+)
+
+type spendCapRepository struct {
+	sql sqlExecutor
+}
+
+// NewSpendCapRepository 创建用户消费上限覆盖仓储
+func NewSpendCapRepository(sqlDB *sql.DB) service.SpendCapRepository {
+	return &spendCapRepository{sql: sqlDB}
+}
+
+// GetOverride 获取用户覆盖
+func (r *spendCapRepository) GetOverride(ctx context.Context, userID int64) (*service.SpendCapOverride, error) {
+	var (
+		o                                          service.SpendCapOverride
+		dailySoft, dailyHard, monthSoft, monthHard sql.NullFloat64
+	)
+	err := scanSingleRow(ctx, r.sql, `
+		SELECT user_id, daily_soft_usd, daily_hard_usd, monthly_soft_usd, monthly_hard_usd, notes, created_at, updated_at
+		FROM user_spend_caps WHERE user_id = $1`, []any{userID},
+		&o.UserID, &dailySoft, &dailyHard, &monthSoft, &monthHard, &o.Notes, &o.CreatedAt, &o.UpdatedAt,
+	)
+	if err != nil {
+		return nil, translatePersistenceError(err, service.ErrSpendCapOverrideNotFound, nil)
+	}
+	o.DailySoftUSD = nullFloat64Ptr(dailySoft)
+	o.DailyHardUSD = nullFloat64Ptr(dailyHard)
+	o.MonthlySoftUSD = nullFloat64Ptr(monthSoft)
+	o.MonthlyHardUSD = nullFloat64Ptr(monthHard)
+	return &o, nil
+}
+
+// UpsertOverride 新增或整体替换用户覆盖；用户不存在（或已删除）时返回 ErrUserNotFound
+func (r *spendCapRepository) UpsertOverride(ctx context.Context, o *service.SpendCapOverride) error {
+	query := `
+		INSERT INTO user_spend_caps (user_id, daily_soft_usd, daily_hard_usd, monthly_soft_usd, monthly_hard_usd, notes)
+		SELECT $1, $2, $3, $4, $5, $6
+		WHERE EXISTS (SELECT 1 FROM users WHERE id = $1 AND deleted_at IS NULL)
+		ON CONFLICT (user_id) DO UPDATE SET
+			daily_soft_usd = EXCLUDED.daily_soft_usd,
+			daily_hard_usd = EXCLUDED.daily_hard_usd,
+			monthly_soft_usd = EXCLUDED.monthly_soft_usd,
+			monthly_hard_usd = EXCLUDED.monthly_hard_usd,
+			notes = EXCLUDED.notes,
+			updated_at = NOW()
+		RETURNING created_at, updated_at`
+	err := scanSingleRow(ctx, r.sql, query, []any{
+		o.UserID, o.DailySoftUSD, o.DailyHardUSD, o.MonthlySoftUSD, o.MonthlyHardUSD, o.Notes,
+	}, &o.CreatedAt, &o.UpdatedAt)
+	return translatePersistenceError(err, service.ErrUserNotFound, nil)
+}
+
+// DeleteOverride 删除用户覆盖
+func (r *spendCapRepository) DeleteOverride(ctx context.Context, userID int64) error {
+	res, err := r.sql.ExecContext(ctx, `DELETE FROM user_spend_caps WHERE user_id = $1`, userID)
+	if err != nil {
+		return err
+	}
+	affected, err := res.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if affected == 0 {
+		return service.ErrSpendCapOverrideNotFound
+	}
+	return nil
+}
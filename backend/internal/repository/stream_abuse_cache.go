@@ -0,0 +1,155 @@
+package repository
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/Wei-Shaw/sub2api/internal/service"
+	"github.com/redis/go-redis/v9"
+)
+
+const (
+	streamAbuseCounterPrefix = "stream_abuse:counter:"
+	streamAbuseFlagPrefix    = "stream_abuse:flag:"
+	// streamAbuseFlaggedSetKey 有序集合：member 为 API Key ID，score 为标记时间（Unix 秒）
+	streamAbuseFlaggedSetKey = "stream_abuse:flagged"
+
+	streamAbuseFieldStreams   = "streams"
+	streamAbuseFieldCanceled  = "canceled"
+	streamAbuseFieldAbandoned = "abandoned"
+)
+
+// streamAbuseCounterKey 按分钟分桶的计数 key
+func streamAbuseCounterKey(apiKeyID int64, minute int64) string {
+	return fmt.Sprintf("%s%d:%d", streamAbuseCounterPrefix, apiKeyID, minute)
+}
+
+func streamAbuseFlagKey(apiKeyID int64) string {
+	return fmt.Sprintf("%s%d", streamAbuseFlagPrefix, apiKeyID)
+}
+
+type streamAbuseCache struct {
+	rdb *redis.Client
+}
+
+// NewStreamAbuseCache 创建流式滥用检测缓存
+func NewStreamAbuseCache(rdb *redis.Client) service.StreamAbuseCache {
+	return &streamAbuseCache{rdb: rdb}
+}
+
+func (c *streamAbuseCache) IncrStreamCounters(ctx context.Context, apiKeyID int64, at time.Time, canceled, abandoned bool, ttl time.Duration) error {
+	key := streamAbuseCounterKey(apiKeyID, at.Unix()/60)
+	pipe := c.rdb.Pipeline()
+	pipe.HIncrBy(ctx, key, streamAbuseFieldStreams, 1)
+	if canceled {
+		pipe.HIncrBy(ctx, key, streamAbuseFieldCanceled, 1)
+	}
+	if abandoned {
+		pipe.HIncrBy(ctx, key, streamAbuseFieldAbandoned, 1)
+	}
+	pipe.Expire(ctx, key, ttl)
+	if _, err := pipe.Exec(ctx); err != nil {
+		return fmt.Errorf("incr stream counters: %w", err)
+	}
+	return nil
+}
+
+func (c *streamAbuseCache) GetStreamCounters(ctx context.Context, apiKeyID int64, at time.Time, window time.Duration) (service.StreamAbuseCounters, error) {
+	var counters service.StreamAbuseCounters
+	current := at.Unix() / 60
+	buckets := int64(window / time.Minute)
+	if buckets <= 0 {
+		buckets = 1
+	}
+
+	pipe := c.rdb.Pipeline()
+	cmds := make([]*redis.SliceCmd, 0, buckets)
+	for m := current - buckets + 1; m <= current; m++ {
+		cmds = append(cmds, pipe.HMGet(ctx, streamAbuseCounterKey(apiKeyID, m), streamAbuseFieldStreams, streamAbuseFieldCanceled, streamAbuseFieldAbandoned))
+	}
+	if _, err := pipe.Exec(ctx); err != nil && !errors.Is(err, redis.Nil) {
+		return counters, fmt.Errorf("get stream counters: %w", err)
+	}
+	for _, cmd := range cmds {
+		vals := cmd.Val()
+		if len(vals) != 3 {
+			continue
+		}
+		counters.Streams += parseRedisInt64(vals[0])
+		counters.Canceled += parseRedisInt64(vals[1])
+		counters.Abandoned += parseRedisInt64(vals[2])
+	}
+	return counters, nil
+}
+
+func (c *streamAbuseCache) SetStreamAbuseFlag(ctx context.Context, flag *service.StreamAbuseFlag, ttl time.Duration) (bool, error) {
+	payload, err := json.Marshal(flag)
+	if err != nil {
+		return false, err
+	}
+	created, err := c.rdb.SetNX(ctx, streamAbuseFlagKey(flag.APIKeyID), payload, ttl).Result()
+	if err != nil || !created {
+		return false, err
+	}
+	member := strconv.FormatInt(flag.APIKeyID, 10)
+	if err := c.rdb.ZAdd(ctx, streamAbuseFlaggedSetKey, redis.Z{Score: float64(flag.FlaggedAt.Unix()), Member: member}).Err(); err != nil {
+		return true, fmt.Errorf("index stream abuse flag: %w", err)
+	}
+	return true, nil
+}
+
+func (c *streamAbuseCache) ListStreamAbuseFlags(ctx context.Context, since time.Time) ([]service.StreamAbuseFlag, error) {
+	sinceScore := strconv.FormatInt(since.Unix(), 10)
+	// 顺带清理过期索引
+	_ = c.rdb.ZRemRangeByScore(ctx, streamAbuseFlaggedSetKey, "-inf", "("+sinceScore).Err()
+
+	members, err := c.rdb.ZRevRangeByScore(ctx, streamAbuseFlaggedSetKey, &redis.ZRangeBy{Min: sinceScore, Max: "+inf"}).Result()
+	if err != nil {
+		return nil, fmt.Errorf("list stream abuse flags: %w", err)
+	}
+	flags := make([]service.StreamAbuseFlag, 0, len(members))
+	if len(members) == 0 {
+		return flags, nil
+	}
+
+	keys := make([]string, 0, len(members))
+	for _, m := range members {
+		id, err := strconv.ParseInt(m, 10, 64)
+		if err != nil {
+			continue
+		}
+		keys = append(keys, streamAbuseFlagKey(id))
+	}
+	values, err := c.rdb.MGet(ctx, keys...).Result()
+	if err != nil {
+		return nil, fmt.Errorf("get stream abuse flags: %w", err)
+	}
+	for _, v := range values {
+		raw, ok := v.(string)
+		if !ok {
+			continue // 标记已过期
+		}
+		var flag service.StreamAbuseFlag
+		if err := json.Unmarshal([]byte(raw), &flag); err != nil {
+			continue
+		}
+		flags = append(flags, flag)
+	}
+	return flags, nil
+}
+
+func parseRedisInt64(v any) int64 {
+	s, ok := v.(string)
+	if !ok {
+		return 0
+	}
+	n, err := strconv.ParseInt(s, 10, 64)
+	if err != nil {
+		return 0
+	}
+	return n
+}
@@ -0,0 +1,74 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/Wei-Shaw/sub2api/internal/config"
+	"github.com/Wei-Shaw/sub2api/internal/pkg/httpclient"
+	"github.com/Wei-Shaw/sub2api/internal/service"
+)
+
+const (
+	stripeClientTimeout = 30 * time.Second
+	// stripeMaxErrorBodyBytes 错误响应体读取上限（仅用于日志）
+	stripeMaxErrorBodyBytes = 4 * 1024
+)
+
+type stripeClient struct {
+	httpClient *http.Client
+	baseURL    string
+	secretKey  string
+}
+
+// NewStripeClient 创建 Stripe API 客户端（未配置密钥时返回 nil，用量上报不启动）
+func NewStripeClient(cfg *config.Config) service.StripeClient {
+	if strings.TrimSpace(cfg.Stripe.SecretKey) == "" {
+		return nil
+	}
+	client, err := httpclient.GetClient(httpclient.Options{Timeout: stripeClientTimeout})
+	if err != nil {
+		client = &http.Client{Timeout: stripeClientTimeout}
+	}
+	return &stripeClient{
+		httpClient: client,
+		baseURL:    strings.TrimRight(cfg.Stripe.APIBaseURL, "/"),
+		secretKey:  cfg.Stripe.SecretKey,
+	}
+}
+
+// ReportMeterEvent 调用 POST /v1/billing/meter_events 上报用量
+func (c *stripeClient) ReportMeterEvent(ctx context.Context, event service.StripeMeterEvent) error {
+	form := url.Values{}
+	form.Set("event_name", event.EventName)
+	form.Set("payload[stripe_customer_id]", event.CustomerID)
+	form.Set("payload[value]", strconv.FormatInt(event.Value, 10))
+	form.Set("identifier", event.Identifier)
+	if !event.Timestamp.IsZero() {
+		form.Set("timestamp", strconv.FormatInt(event.Timestamp.Unix(), 10))
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, c.baseURL+"/v1/billing/meter_events", strings.NewReader(form.Encode()))
+	if err != nil {
+		return fmt.Errorf("create request: %w", err)
+	}
+	req.Header.Set("Authorization", "Bearer "+c.secretKey)
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return fmt.Errorf("send request: %w", err)
+	}
+	defer func() { _ = resp.Body.Close() }()
+	body, _ := io.ReadAll(io.LimitReader(resp.Body, stripeMaxErrorBodyBytes))
+	if resp.StatusCode >= http.StatusMultipleChoices {
+		return fmt.Errorf("stripe meter event failed: status=%d body=%s", resp.StatusCode, strings.TrimSpace(string(body)))
+	}
+	return nil
+}
@@ -0,0 +1,126 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"time"
+
+	"github.com/Wei-Shaw/sub2api/internal/service"
+)
+
+type stripeRepository struct {
+	sql sqlExecutor
+}
+
+// NewStripeRepository 创建 Stripe 关联与 Webhook 幂等记录仓储
+func NewStripeRepository(sqlDB *sql.DB) service.StripeRepository {
+	return &stripeRepository{sql: sqlDB}
+}
+
+const stripeCustomerColumns = `user_id, customer_id, subscription_id, subscription_status, metered, user_suspended,
+	last_event_at, usage_reported_until, usage_carry, created_at, updated_at`
+
+func scanStripeCustomer(scan func(dest ...any) error, c *service.StripeCustomer) error {
+	var lastEventAt, reportedUntil sql.NullTime
+	if err := scan(
+		&c.UserID, &c.CustomerID, &c.SubscriptionID, &c.SubscriptionStatus, &c.Metered, &c.UserSuspended,
+		&lastEventAt, &reportedUntil, &c.UsageCarry, &c.CreatedAt, &c.UpdatedAt,
+	); err != nil {
+		return err
+	}
+	if lastEventAt.Valid {
+		c.LastEventAt = &lastEventAt.Time
+	}
+	if reportedUntil.Valid {
+		c.UsageReportedUntil = &reportedUntil.Time
+	}
+	return nil
+}
+
+// GetCustomerByCustomerID 按 Stripe Customer ID 查找关联
+func (r *stripeRepository) GetCustomerByCustomerID(ctx context.Context, customerID string) (*service.StripeCustomer, error) {
+	var c service.StripeCustomer
+	err := scanStripeCustomer(func(dest ...any) error {
+		return scanSingleRow(ctx, r.sql, `SELECT `+stripeCustomerColumns+` FROM stripe_customers WHERE customer_id = $1`, []any{customerID}, dest...)
+	}, &c)
+	if err != nil {
+		return nil, translatePersistenceError(err, service.ErrStripeCustomerNotFound, nil)
+	}
+	return &c, nil
+}
+
+// LinkCustomer 关联用户与 Stripe Customer（用户更换 Customer 时覆盖）
+func (r *stripeRepository) LinkCustomer(ctx context.Context, userID int64, customerID, subscriptionID string) error {
+	_, err := r.sql.ExecContext(ctx, `
+		INSERT INTO stripe_customers (user_id, customer_id, subscription_id)
+		VALUES ($1, $2, $3)
+		ON CONFLICT (user_id) DO UPDATE SET
+			customer_id = EXCLUDED.customer_id,
+			subscription_id = COALESCE(NULLIF(EXCLUDED.subscription_id, ''), stripe_customers.subscription_id),
+			updated_at = NOW()
+	`, userID, customerID, subscriptionID)
+	return translatePersistenceError(err, nil, service.ErrStripeCustomerLinked)
+}
+
+// UpdateSubscriptionState 记录 Webhook 同步后的订阅状态；首次成为按量计费客户时初始化上报水位
+func (r *stripeRepository) UpdateSubscriptionState(ctx context.Context, userID int64, state service.StripeSubscriptionState) error {
+	_, err := r.sql.ExecContext(ctx, `
+		UPDATE stripe_customers SET
+			subscription_id = $2,
+			subscription_status = $3,
+			metered = $4,
+			user_suspended = $5,
+			last_event_at = $6,
+			usage_reported_until = CASE WHEN $4 THEN COALESCE(usage_reported_until, $7) ELSE usage_reported_until END,
+			updated_at = NOW()
+		WHERE user_id = $1
+	`, userID, state.SubscriptionID, state.Status, state.Metered, state.UserSuspended, state.EventAt, state.ReportFrom)
+	return err
+}
+
+// ListMeteredCustomers 列出需要上报用量的按量计费客户
+func (r *stripeRepository) ListMeteredCustomers(ctx context.Context) ([]service.StripeCustomer, error) {
+	rows, err := r.sql.QueryContext(ctx, `SELECT `+stripeCustomerColumns+` FROM stripe_customers WHERE metered = TRUE ORDER BY user_id`)
+	if err != nil {
+		return nil, err
+	}
+	defer func() { _ = rows.Close() }()
+
+	out := make([]service.StripeCustomer, 0)
+	for rows.Next() {
+		var c service.StripeCustomer
+		if err := scanStripeCustomer(rows.Scan, &c); err != nil {
+			return nil, err
+		}
+		out = append(out, c)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+// UpdateUsageReport 推进用量上报水位
+func (r *stripeRepository) UpdateUsageReport(ctx context.Context, userID int64, reportedUntil time.Time, carry float64) error {
+	_, err := r.sql.ExecContext(ctx, `
+		UPDATE stripe_customers SET usage_reported_until = $2, usage_carry = $3, updated_at = NOW()
+		WHERE user_id = $1
+	`, userID, reportedUntil, carry)
+	return err
+}
+
+// IsEventProcessed 事件是否已处理
+func (r *stripeRepository) IsEventProcessed(ctx context.Context, eventID string) (bool, error) {
+	var exists bool
+	err := scanSingleRow(ctx, r.sql, `SELECT EXISTS(SELECT 1 FROM stripe_webhook_events WHERE event_id = $1)`, []any{eventID}, &exists)
+	return exists, err
+}
+
+// MarkEventProcessed 记录已处理事件
+func (r *stripeRepository) MarkEventProcessed(ctx context.Context, eventID, eventType string) error {
+	_, err := r.sql.ExecContext(ctx, `
+		INSERT INTO stripe_webhook_events (event_id, event_type) VALUES ($1, $2)
+		ON CONFLICT (event_id) DO NOTHING
+	`, eventID, eventType)
+	return err
+}
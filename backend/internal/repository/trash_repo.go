@@ -0,0 +1,192 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"log"
+	"time"
+
+	"github.com/Wei-Shaw/sub2api/internal/pkg/pagination"
+	"github.com/Wei-Shaw/sub2api/internal/service"
+)
+
+type trashRepository struct {
+	db *sql.DB
+}
+
+// NewTrashRepository 创建回收站仓储（软删除账号/API Key 的查询、恢复与过期清理）
+func NewTrashRepository(sqlDB *sql.DB) service.TrashRepository {
+	return &trashRepository{db: sqlDB}
+}
+
+const trashedAccountsWhere = " WHERE deleted_at IS NOT NULL AND purged_at IS NULL"
+
+func (r *trashRepository) ListTrashedAccounts(ctx context.Context, params pagination.PaginationParams) ([]service.TrashedAccount, *pagination.PaginationResult, error) {
+	var total int64
+	if err := scanSingleRow(ctx, r.db, "SELECT COUNT(*) FROM accounts"+trashedAccountsWhere, nil, &total); err != nil {
+		return nil, nil, err
+	}
+
+	rows, err := r.db.QueryContext(ctx, `
+		SELECT id, name, platform, type, COALESCE(trashed_group_ids, '[]'::jsonb), deleted_at
+		FROM accounts`+trashedAccountsWhere+`
+		ORDER BY deleted_at DESC, id DESC
+		LIMIT $1 OFFSET $2`, params.Limit(), params.Offset())
+	if err != nil {
+		return nil, nil, err
+	}
+	defer func() { _ = rows.Close() }()
+
+	items := make([]service.TrashedAccount, 0, params.Limit())
+	for rows.Next() {
+		var item service.TrashedAccount
+		var groupIDs []byte
+		if err := rows.Scan(&item.ID, &item.Name, &item.Platform, &item.Type, &groupIDs, &item.DeletedAt); err != nil {
+			return nil, nil, err
+		}
+		if err := json.Unmarshal(groupIDs, &item.GroupIDs); err != nil {
+			item.GroupIDs = nil
+		}
+		items = append(items, item)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, nil, err
+	}
+	return items, paginationResultFromTotal(total, params), nil
+}
+
+func (r *trashRepository) ListTrashedAPIKeys(ctx context.Context, params pagination.PaginationParams) ([]service.TrashedAPIKey, *pagination.PaginationResult, error) {
+	var total int64
+	if err := scanSingleRow(ctx, r.db,
+		"SELECT COUNT(*) FROM api_keys WHERE deleted_at IS NOT NULL AND purged_at IS NULL", nil, &total); err != nil {
+		return nil, nil, err
+	}
+
+	rows, err := r.db.QueryContext(ctx, `
+		SELECT k.id, k.name, k.user_id, COALESCE(u.email, ''), k.group_id, k.deleted_at
+		FROM api_keys k
+		LEFT JOIN users u ON u.id = k.user_id
+		WHERE k.deleted_at IS NOT NULL AND k.purged_at IS NULL
+		ORDER BY k.deleted_at DESC, k.id DESC
+		LIMIT $1 OFFSET $2`, params.Limit(), params.Offset())
+	if err != nil {
+		return nil, nil, err
+	}
+	defer func() { _ = rows.Close() }()
+
+	items := make([]service.TrashedAPIKey, 0, params.Limit())
+	for rows.Next() {
+		var item service.TrashedAPIKey
+		var groupID sql.NullInt64
+		if err := rows.Scan(&item.ID, &item.Name, &item.UserID, &item.UserEmail, &groupID, &item.DeletedAt); err != nil {
+			return nil, nil, err
+		}
+		if groupID.Valid {
+			item.GroupID = &groupID.Int64
+		}
+		items = append(items, item)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, nil, err
+	}
+	return items, paginationResultFromTotal(total, params), nil
+}
+
+func (r *trashRepository) RestoreAccount(ctx context.Context, id int64) error {
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer func() { _ = tx.Rollback() }()
+
+	var raw []byte
+	err = scanSingleRow(ctx, tx, `
+		UPDATE accounts
+		SET deleted_at = NULL, updated_at = NOW()
+		WHERE id = $1 AND deleted_at IS NOT NULL AND purged_at IS NULL
+		RETURNING COALESCE(trashed_group_ids, '[]'::jsonb)`, []any{id}, &raw)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return service.ErrTrashItemNotFound
+		}
+		return err
+	}
+
+	var groupIDs []int64
+	if err := json.Unmarshal(raw, &groupIDs); err != nil {
+		groupIDs = nil
+	}
+	if len(groupIDs) > 0 {
+		// 按删除前的顺序重新绑定，已删除的分组跳过
+		rawIDs, err := json.Marshal(groupIDs)
+		if err != nil {
+			return err
+		}
+		if _, err := tx.ExecContext(ctx, `
+			INSERT INTO account_groups (account_id, group_id, priority, created_at)
+			SELECT $1, g.id, ids.ord, NOW()
+			FROM jsonb_array_elements_text($2::jsonb) WITH ORDINALITY AS ids(group_id, ord)
+			JOIN groups g ON g.id = ids.group_id::bigint AND g.deleted_at IS NULL
+			ON CONFLICT (account_id, group_id) DO NOTHING`, id, rawIDs); err != nil {
+			return err
+		}
+	}
+	if _, err := tx.ExecContext(ctx, "UPDATE accounts SET trashed_group_ids = NULL WHERE id = $1", id); err != nil {
+		return err
+	}
+	if err := tx.Commit(); err != nil {
+		return err
+	}
+
+	if err := enqueueSchedulerOutbox(ctx, r.db, service.SchedulerOutboxEventAccountChanged, &id, nil, buildSchedulerGroupPayload(groupIDs)); err != nil {
+		log.Printf("[SchedulerOutbox] enqueue account restore failed: account=%d err=%v", id, err)
+	}
+	return nil
+}
+
+func (r *trashRepository) RestoreAPIKey(ctx context.Context, id int64) (string, error) {
+	var key string
+	err := scanSingleRow(ctx, r.db, `
+		UPDATE api_keys k
+		SET deleted_at = NULL, updated_at = NOW()
+		WHERE k.id = $1 AND k.deleted_at IS NOT NULL AND k.purged_at IS NULL
+		  AND EXISTS (SELECT 1 FROM users u WHERE u.id = k.user_id AND u.deleted_at IS NULL)
+		RETURNING k.key`, []any{id}, &key)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return "", service.ErrTrashItemNotFound
+		}
+		return "", err
+	}
+	return key, nil
+}
+
+func (r *trashRepository) PurgeDeletedBefore(ctx context.Context, before time.Time) (int64, int64, error) {
+	res, err := r.db.ExecContext(ctx, `
+		UPDATE accounts
+		SET credentials = '{}'::jsonb, trashed_group_ids = NULL, purged_at = NOW()
+		WHERE deleted_at IS NOT NULL AND deleted_at < $1 AND purged_at IS NULL`, before)
+	if err != nil {
+		return 0, 0, err
+	}
+	accounts, err := res.RowsAffected()
+	if err != nil {
+		return 0, 0, err
+	}
+
+	// Key 明文替换为不可用的占位值（保持唯一），记录与 usage_logs 关联保留
+	res, err = r.db.ExecContext(ctx, `
+		UPDATE api_keys
+		SET key = 'purged-' || id::text || '-' || md5(key), purged_at = NOW()
+		WHERE deleted_at IS NOT NULL AND deleted_at < $1 AND purged_at IS NULL`, before)
+	if err != nil {
+		return accounts, 0, err
+	}
+	apiKeys, err := res.RowsAffected()
+	if err != nil {
+		return accounts, 0, err
+	}
+	return accounts, apiKeys, nil
+}
@@ -0,0 +1,108 @@
+package repository
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/Wei-Shaw/sub2api/internal/pkg/pagination"
+	"github.com/Wei-Shaw/sub2api/internal/service"
+	"github.com/stretchr/testify/require"
+)
+
+func TestTrashRepositoryRestoreAccountRebindsGroups(t *testing.T) {
+	db, mock := newSQLMock(t)
+	repo := &trashRepository{db: db}
+
+	mock.ExpectBegin()
+	mock.ExpectQuery("UPDATE accounts\\s+SET deleted_at = NULL").
+		WithArgs(int64(7)).
+		WillReturnRows(sqlmock.NewRows([]string{"trashed_group_ids"}).AddRow([]byte("[3,5]")))
+	mock.ExpectExec("INSERT INTO account_groups").
+		WithArgs(int64(7), []byte("[3,5]")).
+		WillReturnResult(sqlmock.NewResult(0, 2))
+	mock.ExpectExec("UPDATE accounts SET trashed_group_ids = NULL").
+		WithArgs(int64(7)).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectCommit()
+	mock.ExpectExec("INSERT INTO scheduler_outbox").
+		WillReturnResult(sqlmock.NewResult(1, 1))
+
+	require.NoError(t, repo.RestoreAccount(context.Background(), 7))
+	require.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestTrashRepositoryRestoreAccountNotInTrash(t *testing.T) {
+	db, mock := newSQLMock(t)
+	repo := &trashRepository{db: db}
+
+	mock.ExpectBegin()
+	mock.ExpectQuery("UPDATE accounts\\s+SET deleted_at = NULL").
+		WithArgs(int64(8)).
+		WillReturnRows(sqlmock.NewRows([]string{"trashed_group_ids"}))
+	mock.ExpectRollback()
+
+	err := repo.RestoreAccount(context.Background(), 8)
+	require.ErrorIs(t, err, service.ErrTrashItemNotFound)
+	require.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestTrashRepositoryRestoreAPIKey(t *testing.T) {
+	db, mock := newSQLMock(t)
+	repo := &trashRepository{db: db}
+
+	mock.ExpectQuery("UPDATE api_keys k\\s+SET deleted_at = NULL").
+		WithArgs(int64(9)).
+		WillReturnRows(sqlmock.NewRows([]string{"key"}).AddRow("sk-restored"))
+	key, err := repo.RestoreAPIKey(context.Background(), 9)
+	require.NoError(t, err)
+	require.Equal(t, "sk-restored", key)
+
+	mock.ExpectQuery("UPDATE api_keys k\\s+SET deleted_at = NULL").
+		WithArgs(int64(10)).
+		WillReturnRows(sqlmock.NewRows([]string{"key"}))
+	_, err = repo.RestoreAPIKey(context.Background(), 10)
+	require.ErrorIs(t, err, service.ErrTrashItemNotFound)
+	require.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestTrashRepositoryPurgeDeletedBefore(t *testing.T) {
+	db, mock := newSQLMock(t)
+	repo := &trashRepository{db: db}
+	before := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+
+	mock.ExpectExec("UPDATE accounts\\s+SET credentials = '\\{\\}'::jsonb").
+		WithArgs(before).
+		WillReturnResult(sqlmock.NewResult(0, 2))
+	mock.ExpectExec("UPDATE api_keys\\s+SET key = 'purged-'").
+		WithArgs(before).
+		WillReturnResult(sqlmock.NewResult(0, 3))
+
+	accounts, apiKeys, err := repo.PurgeDeletedBefore(context.Background(), before)
+	require.NoError(t, err)
+	require.Equal(t, int64(2), accounts)
+	require.Equal(t, int64(3), apiKeys)
+	require.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestTrashRepositoryListTrashedAccounts(t *testing.T) {
+	db, mock := newSQLMock(t)
+	repo := &trashRepository{db: db}
+	deletedAt := time.Date(2026, 3, 1, 12, 0, 0, 0, time.UTC)
+
+	mock.ExpectQuery("SELECT COUNT\\(\\*\\) FROM accounts WHERE deleted_at IS NOT NULL").
+		WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(int64(1)))
+	mock.ExpectQuery("SELECT id, name, platform, type").
+		WithArgs(20, 0).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "name", "platform", "type", "trashed_group_ids", "deleted_at"}).
+			AddRow(int64(7), "acc", "anthropic", "oauth", []byte("[3]"), deletedAt))
+
+	items, result, err := repo.ListTrashedAccounts(context.Background(), pagination.PaginationParams{Page: 1, PageSize: 20})
+	require.NoError(t, err)
+	require.Len(t, items, 1)
+	require.Equal(t, []int64{3}, items[0].GroupIDs)
+	require.Equal(t, deletedAt, items[0].DeletedAt)
+	require.Equal(t, int64(1), result.Total)
+	require.NoError(t, mock.ExpectationsWereMet())
+}
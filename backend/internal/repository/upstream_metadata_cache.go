@@ -0,0 +1,75 @@
+package repository
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/Wei-Shaw/sub2api/internal/service"
+	"github.com/redis/go-redis/v9"
+)
+
+const upstreamMetadataKeyPrefix = "upstream_meta:account:"
+
+// upstreamMetadataKey 每个账号一个 Hash，便于强制刷新时整体删除
+func upstreamMetadataKey(accountID int64) string {
+	return fmt.Sprintf("%s%d", upstreamMetadataKeyPrefix, accountID)
+}
+
+// upstreamMetadataEntry Hash 字段值：Redis Hash 不支持字段级过期，过期时间随值一起存储
+type upstreamMetadataEntry struct {
+	ExpiresAt int64  `json:"expires_at"`
+	Value     []byte `json:"value"`
+}
+
+type upstreamMetadataCache struct {
+	rdb *redis.Client
+}
+
+func NewUpstreamMetadataCache(rdb *redis.Client) service.UpstreamMetadataCache {
+	return &upstreamMetadataCache{rdb: rdb}
+}
+
+func (c *upstreamMetadataCache) GetUpstreamMetadata(ctx context.Context, accountID int64, key string) ([]byte, error) {
+	raw, err := c.rdb.HGet(ctx, upstreamMetadataKey(accountID), key).Bytes()
+	if err != nil {
+		if errors.Is(err, redis.Nil) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	var entry upstreamMetadataEntry
+	if err := json.Unmarshal(raw, &entry); err != nil {
+		return nil, nil
+	}
+	if time.Now().Unix() >= entry.ExpiresAt {
+		return nil, nil
+	}
+	return entry.Value, nil
+}
+
+func (c *upstreamMetadataCache) SetUpstreamMetadata(ctx context.Context, accountID int64, key string, value []byte, ttl time.Duration) error {
+	if ttl <= 0 {
+		return nil
+	}
+	payload, err := json.Marshal(upstreamMetadataEntry{
+		ExpiresAt: time.Now().Add(ttl).Unix(),
+		Value:     value,
+	})
+	if err != nil {
+		return err
+	}
+	hashKey := upstreamMetadataKey(accountID)
+	pipe := c.rdb.TxPipeline()
+	pipe.HSet(ctx, hashKey, key, payload)
+	// Hash 整体过期时间随最近一次写入顺延，过期字段在读取时忽略
+	pipe.Expire(ctx, hashKey, ttl)
+	_, err = pipe.Exec(ctx)
+	return err
+}
+
+func (c *upstreamMetadataCache) DeleteUpstreamMetadata(ctx context.Context, accountID int64) error {
+	return c.rdb.Del(ctx, upstreamMetadataKey(accountID)).Err()
+}
@@ -0,0 +1,212 @@
+package repository
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptrace"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/Wei-Shaw/sub2api/internal/service"
+)
+
+// phaseWatchdog 按阶段超时监控一次上游请求：每个阶段一个定时器，超时后取消请求并记录对应的错误，
+// 使调用方能区分连接超时、等待响应头超时、首 token 超时、分片间隔超时与总时长超时
+type phaseWatchdog struct {
+	cancel context.CancelFunc
+
+	mu     sync.Mutex
+	err    error
+	timers map[string]*time.Timer
+	gap    time.Duration
+}
+
+const (
+	phaseConnect    = "connect"
+	phaseHeaders    = "headers"
+	phaseFirstToken = "first_token"
+	phaseChunkGap   = "chunk_gap"
+	phaseTotal      = "total"
+)
+
+func newPhaseWatchdog(budget service.UpstreamTimeoutBudget, attemptTimeout time.Duration, cancel context.CancelFunc) *phaseWatchdog {
+	w := &phaseWatchdog{cancel: cancel, timers: make(map[string]*time.Timer, 4), gap: budget.ChunkGap}
+	w.arm(phaseConnect, budget.Connect, service.ErrUpstreamConnectTimeout)
+	w.arm(phaseHeaders, attemptTimeout, service.ErrUpstreamAttemptTimeout)
+	w.arm(phaseFirstToken, budget.FirstToken, service.ErrUpstreamFirstTokenTimeout)
+	w.arm(phaseTotal, budget.Total, service.ErrUpstreamTotalTimeout)
+	return w
+}
+
+// arm 启动（或重置）阶段定时器
+func (w *phaseWatchdog) arm(phase string, timeout time.Duration, cause error) {
+	if timeout <= 0 {
+		return
+	}
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if w.err != nil {
+		return
+	}
+	if t, ok := w.timers[phase]; ok {
+		t.Reset(timeout)
+		return
+	}
+	w.timers[phase] = time.AfterFunc(timeout, func() {
+		w.fire(fmt.Errorf("%w (%s)", cause, timeout))
+	})
+}
+
+// done 阶段完成，停止其定时器
+func (w *phaseWatchdog) done(phase string) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if t, ok := w.timers[phase]; ok {
+		t.Stop()
+		delete(w.timers, phase)
+	}
+}
+
+func (w *phaseWatchdog) fire(err error) {
+	w.mu.Lock()
+	if w.err != nil {
+		w.mu.Unlock()
+		return
+	}
+	w.err = err
+	for _, t := range w.timers {
+		t.Stop()
+	}
+	w.mu.Unlock()
+	w.cancel()
+}
+
+// timedOut 返回已触发的阶段超时错误
+func (w *phaseWatchdog) timedOut() error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	return w.err
+}
+
+// stop 请求结束：停止所有定时器并释放 context
+func (w *phaseWatchdog) stop() {
+	w.mu.Lock()
+	for _, t := range w.timers {
+		t.Stop()
+	}
+	w.timers = map[string]*time.Timer{}
+	w.mu.Unlock()
+	w.cancel()
+}
+
+func (w *phaseWatchdog) trace(ctx context.Context) context.Context {
+	return httptrace.WithClientTrace(ctx, &httptrace.ClientTrace{
+		GotConn: func(httptrace.GotConnInfo) { w.done(phaseConnect) },
+	})
+}
+
+// doWithPhaseTimeouts 执行请求并按阶段超时预算监控：
+//   - 连接、响应头（单次尝试超时）与首 token 超时在返回响应前检测，返回对应错误，由网关切换账号；
+//   - 流式响应在返回前等待首个分片到达，之后的分片间隔与总时长超时体现为读取响应体时的错误。
+func doWithPhaseTimeouts(client *http.Client, req *http.Request, budget service.UpstreamTimeoutBudget, attemptTimeout time.Duration) (*http.Response, error) {
+	ctx, cancel := context.WithCancel(req.Context())
+	w := newPhaseWatchdog(budget, attemptTimeout, cancel)
+
+	resp, err := client.Do(req.WithContext(w.trace(ctx)))
+	w.done(phaseConnect)
+	w.done(phaseHeaders)
+	if timeoutErr := w.timedOut(); timeoutErr != nil {
+		// 定时器已触发：无论请求是否恰好返回，响应体都已随 context 取消而不可用
+		closeResponse(resp)
+		w.stop()
+		return nil, timeoutErr
+	}
+	if err != nil {
+		w.stop()
+		return nil, err
+	}
+
+	body := &phaseBody{ReadCloser: resp.Body, watchdog: w}
+	resp.Body = body
+	if !isEventStream(resp) || resp.StatusCode >= http.StatusBadRequest {
+		// 非流式响应以响应头作为首 token
+		w.done(phaseFirstToken)
+		body.started = true
+		return resp, nil
+	}
+
+	// 流式响应：返回前等待首个分片，首 token 超时仍可切换账号（此时尚未向客户端输出）
+	if budget.FirstToken > 0 {
+		reader := bufio.NewReader(body.ReadCloser)
+		_, peekErr := reader.Peek(1)
+		if timeoutErr := w.timedOut(); timeoutErr != nil {
+			closeResponse(resp)
+			w.stop()
+			return nil, timeoutErr
+		}
+		body.reader = reader
+		if peekErr == nil {
+			body.markChunk()
+		}
+	}
+	return resp, nil
+}
+
+func closeResponse(resp *http.Response) {
+	if resp != nil && resp.Body != nil {
+		_ = resp.Body.Close()
+	}
+}
+
+func isEventStream(resp *http.Response) bool {
+	return strings.HasPrefix(strings.ToLower(resp.Header.Get("Content-Type")), "text/event-stream")
+}
+
+// phaseBody 响应体包装：读取到数据时推进首 token/分片间隔阶段，超时后将读取错误替换为对应的阶段错误
+type phaseBody struct {
+	io.ReadCloser
+	reader   io.Reader
+	watchdog *phaseWatchdog
+	started  bool
+	once     sync.Once
+}
+
+func (b *phaseBody) Read(p []byte) (int, error) {
+	var (
+		n   int
+		err error
+	)
+	if b.reader != nil {
+		n, err = b.reader.Read(p)
+	} else {
+		n, err = b.ReadCloser.Read(p)
+	}
+	if n > 0 {
+		b.markChunk()
+	}
+	if err != nil && err != io.EOF {
+		if timeoutErr := b.watchdog.timedOut(); timeoutErr != nil {
+			err = timeoutErr
+		}
+	}
+	return n, err
+}
+
+// markChunk 收到数据：首个分片结束首 token 阶段，之后每次重置分片间隔定时器
+func (b *phaseBody) markChunk() {
+	if !b.started {
+		b.started = true
+		b.watchdog.done(phaseFirstToken)
+	}
+	b.watchdog.arm(phaseChunkGap, b.watchdog.gap, service.ErrUpstreamChunkGapTimeout)
+}
+
+func (b *phaseBody) Close() error {
+	err := b.ReadCloser.Close()
+	b.once.Do(b.watchdog.stop)
+	return err
+}
@@ -0,0 +1,152 @@
+package repository
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/Wei-Shaw/sub2api/internal/service"
+	"github.com/redis/go-redis/v9"
+)
+
+const (
+	usageAnomalySincePrefix   = "usage_anomaly:since:"
+	usageAnomalyHourPrefix    = "usage_anomaly:hour:"
+	usageAnomalySeenPrefix    = "usage_anomaly:seen:"
+	usageAnomalyFlagPrefix    = "usage_anomaly:flag:"
+	usageAnomalyFlaggedSetKey = "usage_anomaly:flagged"
+)
+
+func usageAnomalyHourKey(apiKeyID, hour int64) string {
+	return fmt.Sprintf("%s%d:%d", usageAnomalyHourPrefix, apiKeyID, hour)
+}
+
+func usageAnomalyFlagKey(apiKeyID int64) string {
+	return fmt.Sprintf("%s%d", usageAnomalyFlagPrefix, apiKeyID)
+}
+
+type usageAnomalyCache struct {
+	rdb *redis.Client
+}
+
+// NewUsageAnomalyCache 创建用量异常检测缓存
+func NewUsageAnomalyCache(rdb *redis.Client) service.UsageAnomalyCache {
+	return &usageAnomalyCache{rdb: rdb}
+}
+
+func (c *usageAnomalyCache) TrackingSince(ctx context.Context, apiKeyID int64, now time.Time, ttl time.Duration) (time.Time, error) {
+	key := fmt.Sprintf("%s%d", usageAnomalySincePrefix, apiKeyID)
+	pipe := c.rdb.Pipeline()
+	pipe.SetNX(ctx, key, now.Unix(), ttl)
+	// 持续有流量的 Key 保留跟踪起点
+	pipe.Expire(ctx, key, ttl)
+	get := pipe.Get(ctx, key)
+	if _, err := pipe.Exec(ctx); err != nil {
+		return time.Time{}, fmt.Errorf("usage anomaly tracking since: %w", err)
+	}
+	unix, err := get.Int64()
+	if err != nil {
+		return time.Time{}, fmt.Errorf("usage anomaly tracking since: %w", err)
+	}
+	return time.Unix(unix, 0), nil
+}
+
+func (c *usageAnomalyCache) IncrHourlyRequests(ctx context.Context, apiKeyID int64, hour int64, ttl time.Duration) (int64, error) {
+	key := usageAnomalyHourKey(apiKeyID, hour)
+	pipe := c.rdb.Pipeline()
+	incr := pipe.Incr(ctx, key)
+	pipe.Expire(ctx, key, ttl)
+	if _, err := pipe.Exec(ctx); err != nil {
+		return 0, fmt.Errorf("incr usage anomaly hourly requests: %w", err)
+	}
+	return incr.Val(), nil
+}
+
+func (c *usageAnomalyCache) SumHourlyRequests(ctx context.Context, apiKeyID int64, fromHour, toHour int64) (int64, error) {
+	if toHour < fromHour {
+		return 0, nil
+	}
+	keys := make([]string, 0, toHour-fromHour+1)
+	for h := fromHour; h <= toHour; h++ {
+		keys = append(keys, usageAnomalyHourKey(apiKeyID, h))
+	}
+	values, err := c.rdb.MGet(ctx, keys...).Result()
+	if err != nil && !errors.Is(err, redis.Nil) {
+		return 0, fmt.Errorf("sum usage anomaly hourly requests: %w", err)
+	}
+	var total int64
+	for _, v := range values {
+		total += parseRedisInt64(v)
+	}
+	return total, nil
+}
+
+func (c *usageAnomalyCache) AddSeenAttribute(ctx context.Context, apiKeyID int64, attr, value string, ttl time.Duration) (bool, error) {
+	key := fmt.Sprintf("%s%d:%s", usageAnomalySeenPrefix, apiKeyID, attr)
+	pipe := c.rdb.Pipeline()
+	added := pipe.SAdd(ctx, key, value)
+	pipe.Expire(ctx, key, ttl)
+	if _, err := pipe.Exec(ctx); err != nil {
+		return false, fmt.Errorf("add usage anomaly seen attribute: %w", err)
+	}
+	return added.Val() > 0, nil
+}
+
+func (c *usageAnomalyCache) SetUsageAnomalyFlag(ctx context.Context, flag *service.UsageAnomalyFlag, ttl time.Duration) (bool, error) {
+	payload, err := json.Marshal(flag)
+	if err != nil {
+		return false, err
+	}
+	created, err := c.rdb.SetNX(ctx, usageAnomalyFlagKey(flag.APIKeyID), payload, ttl).Result()
+	if err != nil || !created {
+		return false, err
+	}
+	member := strconv.FormatInt(flag.APIKeyID, 10)
+	if err := c.rdb.ZAdd(ctx, usageAnomalyFlaggedSetKey, redis.Z{Score: float64(flag.FlaggedAt.Unix()), Member: member}).Err(); err != nil {
+		return true, fmt.Errorf("index usage anomaly flag: %w", err)
+	}
+	return true, nil
+}
+
+func (c *usageAnomalyCache) ListUsageAnomalyFlags(ctx context.Context, since time.Time) ([]service.UsageAnomalyFlag, error) {
+	sinceScore := strconv.FormatInt(since.Unix(), 10)
+	// 顺带清理过期索引
+	_ = c.rdb.ZRemRangeByScore(ctx, usageAnomalyFlaggedSetKey, "-inf", "("+sinceScore).Err()
+
+	members, err := c.rdb.ZRevRangeByScore(ctx, usageAnomalyFlaggedSetKey, &redis.ZRangeBy{Min: sinceScore, Max: "+inf"}).Result()
+	if err != nil {
+		return nil, fmt.Errorf("list usage anomaly flags: %w", err)
+	}
+	flags := make([]service.UsageAnomalyFlag, 0, len(members))
+	if len(members) == 0 {
+		return flags, nil
+	}
+
+	keys := make([]string, 0, len(members))
+	for _, m := range members {
+		id, err := strconv.ParseInt(m, 10, 64)
+		if err != nil {
+			continue
+		}
+		keys = append(keys, usageAnomalyFlagKey(id))
+	}
+	values, err := c.rdb.MGet(ctx, keys...).Result()
+	if err != nil {
+		return nil, fmt.Errorf("get usage anomaly flags: %w", err)
+	}
+	for _, v := range values {
+		raw, ok := v.(string)
+		if !ok {
+			continue // 标记已过期
+		}
+		var flag service.UsageAnomalyFlag
+		if err := json.Unmarshal([]byte(raw), &flag); err != nil {
+			continue
+		}
+		flags = append(flags, flag)
+	}
+	return flags, nil
+}
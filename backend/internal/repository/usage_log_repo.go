@@ -3,6 +3,7 @@ package repository
 import (
 	"context"
 	"database/sql"
+	"encoding/json"
 	"errors"
 	"fmt"
 	"os"
@@ -22,7 +23,7 @@ import (
 	"github.com/lib/pq"
 )
 
-const usageLogSelectColumns = "id, user_id, api_key_id, account_id, request_id, model, group_id, subscription_id, input_tokens, output_tokens, cache_creation_tokens, cache_read_tokens, cache_creation_5m_tokens, cache_creation_1h_tokens, input_cost, output_cost, cache_creation_cost, cache_read_cost, total_cost, actual_cost, rate_multiplier, account_rate_multiplier, billing_type, stream, duration_ms, first_token_ms, user_agent, ip_address, image_count, image_size, reasoning_effort, created_at"
+const usageLogSelectColumns = "id, user_id, api_key_id, account_id, request_id, model, group_id, subscription_id, input_tokens, output_tokens, cache_creation_tokens, cache_read_tokens, cache_creation_5m_tokens, cache_creation_1h_tokens, input_cost, output_cost, cache_creation_cost, cache_read_cost, total_cost, actual_cost, rate_multiplier, account_rate_multiplier, billing_type, stream, duration_ms, first_token_ms, user_agent, ip_address, image_count, image_size, reasoning_effort, tool_usage, tool_cost, metering_only, gateway_fingerprint, created_at"
 
 type usageLogRepository struct {
 	client *dbent.Client
@@ -115,6 +116,10 @@ func (r *usageLogRepository) Create(ctx context.Context, log *service.UsageLog)
 				image_count,
 				image_size,
 				reasoning_effort,
+				tool_usage,
+				tool_cost,
+				metering_only,
+				gateway_fingerprint,
 				created_at
 			) VALUES (
 				$1, $2, $3, $4, $5,
@@ -122,7 +127,7 @@ func (r *usageLogRepository) Create(ctx context.Context, log *service.UsageLog)
 				$8, $9, $10, $11,
 				$12, $13,
 				$14, $15, $16, $17, $18, $19,
-				$20, $21, $22, $23, $24, $25, $26, $27, $28, $29, $30, $31
+				$20, $21, $22, $23, $24, $25, $26, $27, $28, $29, $30, $31, $32, $33, $34, $35
 			)
 			ON CONFLICT (request_id, api_key_id) DO NOTHING
 			RETURNING id, created_at
@@ -136,6 +141,11 @@ func (r *usageLogRepository) Create(ctx context.Context, log *service.UsageLog)
 	ipAddress := nullString(log.IPAddress)
 	imageSize := nullString(log.ImageSize)
 	reasoningEffort := nullString(log.ReasoningEffort)
+	gatewayFingerprint := nullString(log.GatewayFingerprint)
+	toolUsage, err := marshalToolUsage(log.ToolUsage)
+	if err != nil {
+		return false, err
+	}
 
 	var requestIDArg any
 	if requestID != "" {
@@ -173,6 +183,10 @@ func (r *usageLogRepository) Create(ctx context.Context, log *service.UsageLog)
 		log.ImageCount,
 		imageSize,
 		reasoningEffort,
+		toolUsage,
+		log.ToolCost,
+		log.MeteringOnly,
+		gatewayFingerprint,
 		createdAt,
 	}
 	if err := scanSingleRow(ctx, sqlq, query, args, &log.ID, &log.CreatedAt); err != nil {
@@ -569,6 +583,20 @@ func (r *usageLogRepository) ListByUserAndTimeRange(ctx context.Context, userID
 	return logs, nil, err
 }
 
+// GetUserBillableCost returns the user's actual cost in the range, excluding metering-only rows
+func (r *usageLogRepository) GetUserBillableCost(ctx context.Context, userID int64, startTime, endTime time.Time) (float64, error) {
+	query := `
+		SELECT COALESCE(SUM(actual_cost), 0)
+		FROM usage_logs
+		WHERE user_id = $1 AND created_at >= $2 AND created_at < $3 AND metering_only = FALSE
+	`
+	var cost float64
+	if err := scanSingleRow(ctx, r.sql, query, []any{userID, startTime, endTime}, &cost); err != nil {
+		return 0, err
+	}
+	return cost, nil
+}
+
 // GetUserStatsAggregated returns aggregated usage statistics for a user using database-level aggregation
 func (r *usageLogRepository) GetUserStatsAggregated(ctx context.Context, userID int64, startTime, endTime time.Time) (*usagestats.UsageStats, error) {
 	query := `
@@ -639,6 +667,79 @@ func (r *usageLogRepository) GetAPIKeyStatsAggregated(ctx context.Context, apiKe
 	return &stats, nil
 }
 
+// GetAPIKeyCacheUsageByModel 按模型聚合 API Key 的缓存 token 与缓存费用，用于计算缓存节省
+func (r *usageLogRepository) GetAPIKeyCacheUsageByModel(ctx context.Context, apiKeyID int64, startTime, endTime time.Time) (results []usagestats.ModelCacheUsage, err error) {
+	query := `
+		SELECT
+			model,
+			COALESCE(SUM(cache_creation_tokens), 0) as cache_creation_tokens,
+			COALESCE(SUM(cache_read_tokens), 0) as cache_read_tokens,
+			COALESCE(SUM(cache_creation_cost), 0) as cache_creation_cost,
+			COALESCE(SUM(cache_read_cost), 0) as cache_read_cost
+		FROM usage_logs
+		WHERE api_key_id = $1 AND created_at >= $2 AND created_at < $3
+			AND (cache_creation_tokens > 0 OR cache_read_tokens > 0)
+		GROUP BY model
+	`
+
+	rows, err := r.sql.QueryContext(ctx, query, apiKeyID, startTime, endTime)
+	if err != nil {
+		return nil, err
+	}
+	defer func() {
+		if closeErr := rows.Close(); closeErr != nil && err == nil {
+			err = closeErr
+			results = nil
+		}
+	}()
+
+	results = make([]usagestats.ModelCacheUsage, 0)
+	for rows.Next() {
+		var row usagestats.ModelCacheUsage
+		if err = rows.Scan(
+			&row.Model,
+			&row.CacheCreationTokens,
+			&row.CacheReadTokens,
+			&row.CacheCreationCost,
+			&row.CacheReadCost,
+		); err != nil {
+			return nil, err
+		}
+		results = append(results, row)
+	}
+	if err = rows.Err(); err != nil {
+		return nil, err
+	}
+	return results, nil
+}
+
+// GetAPIKeyToolUsage 汇总 API Key 在时间范围内的内置工具调用次数，用于每日工具上限检查
+func (r *usageLogRepository) GetAPIKeyToolUsage(ctx context.Context, apiKeyID int64, startTime, endTime time.Time) (*service.ToolUsage, error) {
+	query := `
+		SELECT
+			COALESCE(SUM((tool_usage->>'web_search_calls')::int), 0) as web_search_calls,
+			COALESCE(SUM((tool_usage->>'code_interpreter_sessions')::int), 0) as code_interpreter_sessions,
+			COALESCE(SUM((tool_usage->>'image_generations')::int), 0) as image_generations
+		FROM usage_logs
+		WHERE api_key_id = $1 AND created_at >= $2 AND created_at < $3
+			AND tool_usage IS NOT NULL
+	`
+
+	var usage service.ToolUsage
+	if err := scanSingleRow(
+		ctx,
+		r.sql,
+		query,
+		[]any{apiKeyID, startTime, endTime},
+		&usage.WebSearchCalls,
+		&usage.CodeInterpreterSessions,
+		&usage.ImageGenerations,
+	); err != nil {
+		return nil, err
+	}
+	return &usage, nil
+}
+
 // GetAccountStatsAggregated 使用 SQL 聚合统计账号使用数据
 //
 // 性能优化说明：
@@ -1311,6 +1412,21 @@ type UsageLogFilters = usagestats.UsageLogFilters
 
 // ListWithFilters lists usage logs with optional filters (for admin)
 func (r *usageLogRepository) ListWithFilters(ctx context.Context, params pagination.PaginationParams, filters UsageLogFilters) ([]service.UsageLog, *pagination.PaginationResult, error) {
+	conditions, args := buildUsageLogFilterConditions(filters)
+	whereClause := buildWhere(conditions)
+	logs, page, err := r.listUsageLogsWithPagination(ctx, whereClause, args, params)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	if err := r.hydrateUsageLogAssociations(ctx, logs); err != nil {
+		return nil, nil, err
+	}
+	return logs, page, nil
+}
+
+// buildUsageLogFilterConditions 构建用量筛选条件（列表与导出共用）
+func buildUsageLogFilterConditions(filters UsageLogFilters) ([]string, []any) {
 	conditions := make([]string, 0, 8)
 	args := make([]any, 0, 8)
 
@@ -1351,16 +1467,41 @@ func (r *usageLogRepository) ListWithFilters(ctx context.Context, params paginat
 		args = append(args, *filters.EndTime)
 	}
 
-	whereClause := buildWhere(conditions)
-	logs, page, err := r.listUsageLogsWithPagination(ctx, whereClause, args, params)
-	if err != nil {
-		return nil, nil, err
+	return conditions, args
+}
+
+// ExportWithFilters 按 id 升序分批读取筛选范围内的用量记录（keyset 分页，不长时间占用连接），
+// 每批加载关联数据后交给 fn 处理；fn 返回错误时停止。
+func (r *usageLogRepository) ExportWithFilters(ctx context.Context, filters UsageLogFilters, batchSize int, fn func([]service.UsageLog) error) error {
+	if batchSize <= 0 {
+		batchSize = 1000
 	}
+	baseConditions, baseArgs := buildUsageLogFilterConditions(filters)
 
-	if err := r.hydrateUsageLogAssociations(ctx, logs); err != nil {
-		return nil, nil, err
+	var lastID int64
+	for {
+		conditions := append(append([]string(nil), baseConditions...), fmt.Sprintf("id > $%d", len(baseArgs)+1))
+		args := append(append([]any(nil), baseArgs...), lastID, batchSize)
+		query := fmt.Sprintf("SELECT %s FROM usage_logs %s ORDER BY id ASC LIMIT $%d", usageLogSelectColumns, buildWhere(conditions), len(args))
+
+		logs, err := r.queryUsageLogs(ctx, query, args...)
+		if err != nil {
+			return err
+		}
+		if len(logs) == 0 {
+			return nil
+		}
+		if err := r.hydrateUsageLogAssociations(ctx, logs); err != nil {
+			return err
+		}
+		if err := fn(logs); err != nil {
+			return err
+		}
+		if len(logs) < batchSize {
+			return nil
+		}
+		lastID = logs[len(logs)-1].ID
 	}
-	return logs, page, nil
 }
 
 // UsageStats represents usage statistics
@@ -2195,6 +2336,10 @@ func scanUsageLog(scanner interface{ Scan(...any) error }) (*service.UsageLog, e
 		imageCount            int
 		imageSize             sql.NullString
 		reasoningEffort       sql.NullString
+		toolUsage             []byte
+		toolCost              float64
+		meteringOnly          bool
+		gatewayFingerprint    sql.NullString
 		createdAt             time.Time
 	)
 
@@ -2230,6 +2375,10 @@ func scanUsageLog(scanner interface{ Scan(...any) error }) (*service.UsageLog, e
 		&imageCount,
 		&imageSize,
 		&reasoningEffort,
+		&toolUsage,
+		&toolCost,
+		&meteringOnly,
+		&gatewayFingerprint,
 		&createdAt,
 	); err != nil {
 		return nil, err
@@ -2251,6 +2400,7 @@ func scanUsageLog(scanner interface{ Scan(...any) error }) (*service.UsageLog, e
 		OutputCost:            outputCost,
 		CacheCreationCost:     cacheCreationCost,
 		CacheReadCost:         cacheReadCost,
+		ToolCost:              toolCost,
 		TotalCost:             totalCost,
 		ActualCost:            actualCost,
 		RateMultiplier:        rateMultiplier,
@@ -2258,6 +2408,7 @@ func scanUsageLog(scanner interface{ Scan(...any) error }) (*service.UsageLog, e
 		BillingType:           int8(billingType),
 		Stream:                stream,
 		ImageCount:            imageCount,
+		MeteringOnly:          meteringOnly,
 		CreatedAt:             createdAt,
 	}
 
@@ -2292,10 +2443,30 @@ func scanUsageLog(scanner interface{ Scan(...any) error }) (*service.UsageLog, e
 	if reasoningEffort.Valid {
 		log.ReasoningEffort = &reasoningEffort.String
 	}
+	if gatewayFingerprint.Valid {
+		log.GatewayFingerprint = &gatewayFingerprint.String
+	}
+	if len(toolUsage) > 0 {
+		var usage service.ToolUsage
+		if err := json.Unmarshal(toolUsage, &usage); err == nil && !usage.IsZero() {
+			log.ToolUsage = &usage
+		}
+	}
 
 	return log, nil
 }
 
+func marshalToolUsage(usage *service.ToolUsage) (any, error) {
+	if usage == nil || usage.IsZero() {
+		return nil, nil
+	}
+	data, err := json.Marshal(usage)
+	if err != nil {
+		return nil, err
+	}
+	return string(data), nil
+}
+
 func scanTrendRows(rows *sql.Rows) ([]TrendDataPoint, error) {
 	results := make([]TrendDataPoint, 0)
 	for rows.Next() {
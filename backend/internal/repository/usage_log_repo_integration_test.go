@@ -213,6 +213,39 @@ func (s *UsageLogRepoSuite) TestGetUserStats() {
 	s.Require().Equal(int64(45), stats.OutputTokens)
 }
 
+func (s *UsageLogRepoSuite) TestGetUserBillableCost_ExcludesMeteringOnly() {
+	user := mustCreateUser(s.T(), s.client, &service.User{Email: "billable@test.com"})
+	apiKey := mustCreateApiKey(s.T(), s.client, &service.APIKey{UserID: user.ID, Key: "sk-billable", Name: "k"})
+	account := mustCreateAccount(s.T(), s.client, &service.Account{Name: "acc-billable"})
+
+	base := time.Date(2025, 1, 15, 12, 0, 0, 0, time.UTC)
+	s.createUsageLog(user, apiKey, account, 10, 20, 0.5, base)
+	s.createUsageLog(user, apiKey, account, 10, 20, 0.25, base.Add(time.Minute))
+	metered := &service.UsageLog{
+		UserID:       user.ID,
+		APIKeyID:     apiKey.ID,
+		AccountID:    account.ID,
+		RequestID:    uuid.New().String(),
+		Model:        "claude-3",
+		InputTokens:  10,
+		OutputTokens: 20,
+		TotalCost:    3,
+		ActualCost:   3,
+		MeteringOnly: true,
+		CreatedAt:    base.Add(2 * time.Minute),
+	}
+	_, err := s.repo.Create(s.ctx, metered)
+	s.Require().NoError(err)
+
+	cost, err := s.repo.GetUserBillableCost(s.ctx, user.ID, base.Add(-time.Hour), base.Add(time.Hour))
+	s.Require().NoError(err, "GetUserBillableCost")
+	s.Require().InDelta(0.75, cost, 1e-9)
+
+	stats, err := s.repo.GetUserStatsAggregated(s.ctx, user.ID, base.Add(-time.Hour), base.Add(time.Hour))
+	s.Require().NoError(err, "GetUserStatsAggregated")
+	s.Require().InDelta(3.75, stats.TotalActualCost, 1e-9)
+}
+
 // --- ListWithFilters ---
 
 func (s *UsageLogRepoSuite) TestListWithFilters() {
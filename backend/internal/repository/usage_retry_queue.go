@@ -0,0 +1,140 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"time"
+
+	"github.com/Wei-Shaw/sub2api/internal/config"
+	"github.com/Wei-Shaw/sub2api/internal/service"
+	"github.com/redis/go-redis/v9"
+)
+
+const (
+	usageRetryStreamKey     = "usage_retry:stream"
+	usageRetryDeadLetterKey = "usage_retry:dead"
+	usageRetryGroup         = "usage_retry_replayers"
+	usageRetryPayloadField  = "payload"
+)
+
+type usageRetryQueue struct {
+	rdb       *redis.Client
+	maxLength int64
+}
+
+// NewUsageRetryQueue 创建基于 Redis Stream 的用量重试队列
+func NewUsageRetryQueue(rdb *redis.Client, cfg *config.Config) service.UsageRetryQueue {
+	maxLength := int64(0)
+	if cfg != nil {
+		maxLength = cfg.UsageRetry.MaxLength
+	}
+	return &usageRetryQueue{rdb: rdb, maxLength: maxLength}
+}
+
+func (q *usageRetryQueue) Enqueue(ctx context.Context, payload []byte) error {
+	return q.add(ctx, usageRetryStreamKey, payload)
+}
+
+func (q *usageRetryQueue) DeadLetter(ctx context.Context, payload []byte) error {
+	return q.add(ctx, usageRetryDeadLetterKey, payload)
+}
+
+func (q *usageRetryQueue) add(ctx context.Context, key string, payload []byte) error {
+	args := &redis.XAddArgs{
+		Stream: key,
+		Values: map[string]any{usageRetryPayloadField: payload},
+	}
+	if q.maxLength > 0 {
+		args.MaxLen = q.maxLength
+		args.Approx = true
+	}
+	return q.rdb.XAdd(ctx, args).Err()
+}
+
+func (q *usageRetryQueue) Claim(ctx context.Context, consumer string, count int, minIdle time.Duration) ([]service.UsageRetryMessage, error) {
+	if err := q.ensureGroup(ctx); err != nil {
+		return nil, err
+	}
+
+	// 1. 本消费者已领取但未确认的记录（上一轮重放失败后中断的剩余记录）
+	messages, err := q.readGroup(ctx, consumer, count, "0")
+	if err != nil {
+		return nil, err
+	}
+
+	// 2. 接管其他消费者（已崩溃的实例）超过 minIdle 未确认的记录
+	if remaining := count - len(messages); remaining > 0 {
+		claimed, _, err := q.rdb.XAutoClaim(ctx, &redis.XAutoClaimArgs{
+			Stream:   usageRetryStreamKey,
+			Group:    usageRetryGroup,
+			Consumer: consumer,
+			MinIdle:  minIdle,
+			Start:    "0-0",
+			Count:    int64(remaining),
+		}).Result()
+		if err != nil {
+			return nil, err
+		}
+		messages = append(messages, toUsageRetryMessages(claimed)...)
+	}
+
+	// 3. 新记录
+	if remaining := count - len(messages); remaining > 0 {
+		fresh, err := q.readGroup(ctx, consumer, remaining, ">")
+		if err != nil {
+			return nil, err
+		}
+		messages = append(messages, fresh...)
+	}
+	return messages, nil
+}
+
+func (q *usageRetryQueue) Ack(ctx context.Context, ids ...string) error {
+	if len(ids) == 0 {
+		return nil
+	}
+	pipe := q.rdb.TxPipeline()
+	pipe.XAck(ctx, usageRetryStreamKey, usageRetryGroup, ids...)
+	pipe.XDel(ctx, usageRetryStreamKey, ids...)
+	_, err := pipe.Exec(ctx)
+	return err
+}
+
+func (q *usageRetryQueue) ensureGroup(ctx context.Context) error {
+	err := q.rdb.XGroupCreateMkStream(ctx, usageRetryStreamKey, usageRetryGroup, "0").Err()
+	if err != nil && !strings.Contains(err.Error(), "BUSYGROUP") {
+		return err
+	}
+	return nil
+}
+
+func (q *usageRetryQueue) readGroup(ctx context.Context, consumer string, count int, id string) ([]service.UsageRetryMessage, error) {
+	streams, err := q.rdb.XReadGroup(ctx, &redis.XReadGroupArgs{
+		Group:    usageRetryGroup,
+		Consumer: consumer,
+		Streams:  []string{usageRetryStreamKey, id},
+		Count:    int64(count),
+		Block:    -1, // 不阻塞
+	}).Result()
+	if err != nil {
+		if errors.Is(err, redis.Nil) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	var messages []service.UsageRetryMessage
+	for _, stream := range streams {
+		messages = append(messages, toUsageRetryMessages(stream.Messages)...)
+	}
+	return messages, nil
+}
+
+func toUsageRetryMessages(in []redis.XMessage) []service.UsageRetryMessage {
+	out := make([]service.UsageRetryMessage, 0, len(in))
+	for _, msg := range in {
+		payload, _ := msg.Values[usageRetryPayloadField].(string)
+		out = append(out, service.UsageRetryMessage{ID: msg.ID, Payload: []byte(payload)})
+	}
+	return out
+}
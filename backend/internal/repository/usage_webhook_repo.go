@@ -0,0 +1,68 @@
+package repository
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+
+	"github.com/Wei-Shaw/sub2api/internal/config"
+	"github.com/Wei-Shaw/sub2api/internal/pkg/httpclient"
+	"github.com/Wei-Shaw/sub2api/internal/service"
+)
+
+// usageWebhookMaxResponseBytes 读取回调响应体的上限（仅为复用连接而丢弃）
+const usageWebhookMaxResponseBytes = 64 * 1024
+
+type usageWebhookSender struct {
+	httpClient *http.Client
+}
+
+// NewUsageWebhookSender 创建用量回调 HTTP 投递端口：投递时校验解析后的 IP（防 DNS Rebinding），不跟随重定向
+func NewUsageWebhookSender(cfg *config.Config) service.UsageWebhookSender {
+	timeout := cfg.UsageWebhook.Timeout
+	sharedClient, err := httpclient.GetClient(httpclient.Options{
+		Timeout:            timeout,
+		ValidateResolvedIP: true,
+		AllowPrivateHosts:  cfg.UsageWebhook.AllowPrivateHosts,
+	})
+	if err != nil {
+		sharedClient = &http.Client{Timeout: timeout}
+	}
+	// 共享客户端不可修改，复制后关闭重定向，避免回调被重定向到内网地址
+	client := *sharedClient
+	client.CheckRedirect = func(*http.Request, []*http.Request) error {
+		return http.ErrUseLastResponse
+	}
+	return &usageWebhookSender{httpClient: &client}
+}
+
+func (s *usageWebhookSender) Send(ctx context.Context, url string, header http.Header, body []byte) (int, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, url, bytes.NewReader(body))
+	if err != nil {
+		return 0, fmt.Errorf("create request: %w", err)
+	}
+	req.Header = header
+	resp, err := s.httpClient.Do(req)
+	if err != nil {
+		return 0, fmt.Errorf("send request: %w", err)
+	}
+	defer func() { _ = resp.Body.Close() }()
+	_, _ = io.Copy(io.Discard, io.LimitReader(resp.Body, usageWebhookMaxResponseBytes))
+	return resp.StatusCode, nil
+}
+
+type webhookUsageLogRepository struct {
+	service.UsageLogRepository
+	webhooks *service.UsageWebhookDispatcher
+}
+
+func (r *webhookUsageLogRepository) Create(ctx context.Context, log *service.UsageLog) (bool, error) {
+	inserted, err := r.UsageLogRepository.Create(ctx, log)
+	// 幂等重试跳过的插入不重复回调
+	if err == nil && inserted {
+		r.webhooks.Enqueue(log)
+	}
+	return inserted, err
+}
@@ -58,22 +58,32 @@ var ProviderSet = wire.NewSet(
 	NewPromoCodeRepository,
 	NewAnnouncementRepository,
 	NewAnnouncementReadRepository,
-	NewUsageLogRepository,
+	ProvideUsageLogRepository,
 	NewUsageCleanupRepository,
 	NewDashboardAggregationRepository,
 	NewSettingRepository,
-	NewOpsRepository,
+	ProvideOpsRepository,
+	ProvideClickHouseOpsEventWriter,
+	ProvideOpsLogShippers,
 	NewUserSubscriptionRepository,
 	NewUserAttributeDefinitionRepository,
 	NewUserAttributeValueRepository,
 	NewUserGroupRateRepository,
+	NewModelPriceRepository,
+	NewStripeRepository,
+	NewSpendCapRepository,
+	NewQuotaRolloverRepository,
+	NewAuditLogRepository,
+	NewTrashRepository,
+	NewAdminActionLogRepository,
 	NewErrorPassthroughRepository,
 
 	// Cache implementations
-	NewGatewayCache,
+	ProvideRegionReplicator,
+	ProvideGatewayCache,
 	NewBillingCache,
 	NewAPIKeyCache,
-	NewTempUnschedCache,
+	ProvideTempUnschedCache,
 	NewTimeoutCounterCache,
 	ProvideConcurrencyCache,
 	ProvideSessionLimitCache,
@@ -89,12 +99,23 @@ var ProviderSet = wire.NewSet(
 	NewTotpCache,
 	NewRefreshTokenCache,
 	NewErrorPassthroughCache,
+	NewUpstreamMetadataCache,
+	NewBudgetAlertCache,
+	NewUsageRetryQueue,
+	NewStreamAbuseCache,
+	NewIPBanCache,
+	NewUsageAnomalyCache,
+	NewSpendCapCache,
+	NewOpenAIResponseContextCache,
+	NewResponseCache,
 
 	// Encryptors
 	NewAESEncryptor,
 
 	// HTTP service ports (DI Strategy A: return interface directly)
 	NewTurnstileVerifier,
+	NewUsageWebhookSender,
+	NewStripeClient,
 	ProvidePricingRemoteClient,
 	ProvideGitHubReleaseClient,
 	NewProxyExitInfoProber,
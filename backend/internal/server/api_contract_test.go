@@ -83,6 +83,13 @@ func TestAPIContracts(t *testing.T) {
 					"status": "active",
 					"ip_whitelist": null,
 					"ip_blacklist": null,
+					"region_policy": {},
+					"system_prompt_policy": {},
+					"usage_webhook": {},
+					"request_signing": {},
+					"token_quota": {},
+					"debug_errors": false,
+					"priority_class": "",
 					"quota": 0,
 					"quota_used": 0,
 					"expires_at": null,
@@ -122,6 +129,13 @@ func TestAPIContracts(t *testing.T) {
 							"status": "active",
 							"ip_whitelist": null,
 							"ip_blacklist": null,
+							"region_policy": {},
+							"system_prompt_policy": {},
+							"usage_webhook": {},
+							"request_signing": {},
+							"token_quota": {},
+							"debug_errors": false,
+							"priority_class": "",
 							"quota": 0,
 							"quota_used": 0,
 							"expires_at": null,
@@ -399,6 +413,7 @@ func TestAPIContracts(t *testing.T) {
 							"stream": true,
 							"duration_ms": 100,
 							"first_token_ms": 50,
+							"metering_only": false,
 							"image_count": 0,
 							"image_size": null,
 							"created_at": "2025-01-02T03:04:05Z",
@@ -596,7 +611,7 @@ func newContractDeps(t *testing.T) *contractDeps {
 	apiKeyService := service.NewAPIKeyService(apiKeyRepo, userRepo, groupRepo, userSubRepo, nil, apiKeyCache, cfg)
 
 	usageRepo := newStubUsageLogRepo()
-	usageService := service.NewUsageService(usageRepo, userRepo, nil, nil)
+	usageService := service.NewUsageService(usageRepo, userRepo, nil, nil, nil)
 
 	subscriptionService := service.NewSubscriptionService(groupRepo, userSubRepo, nil)
 	subscriptionHandler := handler.NewSubscriptionHandler(subscriptionService)
@@ -609,10 +624,10 @@ func newContractDeps(t *testing.T) *contractDeps {
 
 	adminService := service.NewAdminService(userRepo, groupRepo, &accountRepo, proxyRepo, apiKeyRepo, redeemRepo, nil, nil, nil, nil, nil)
 	authHandler := handler.NewAuthHandler(cfg, nil, userService, settingService, nil, redeemService, nil)
-	apiKeyHandler := handler.NewAPIKeyHandler(apiKeyService)
+	apiKeyHandler := handler.NewAPIKeyHandler(apiKeyService, nil)
 	usageHandler := handler.NewUsageHandler(usageService, apiKeyService)
 	adminSettingHandler := adminhandler.NewSettingHandler(settingService, nil, nil, nil)
-	adminAccountHandler := adminhandler.NewAccountHandler(adminService, nil, nil, nil, nil, nil, nil, nil, nil, nil, nil, nil)
+	adminAccountHandler := adminhandler.NewAccountHandler(adminService, nil, nil, nil, nil, nil, nil, nil, nil, nil, nil, nil, nil, nil)
 
 	jwtAuth := func(c *gin.Context) {
 		c.Set(string(middleware.ContextKeyUser), middleware.AuthSubject{
@@ -821,6 +836,10 @@ func (stubApiKeyCache) SubscribeAuthCacheInvalidation(ctx context.Context, handl
 	return nil
 }
 
+func (stubApiKeyCache) MarkRequestSignatureSeen(ctx context.Context, apiKeyID int64, signature string, ttl time.Duration) (bool, error) {
+	return true, nil
+}
+
 type stubGroupRepo struct {
 	active []service.Group
 }
@@ -936,7 +955,7 @@ func (s *stubAccountRepo) List(ctx context.Context, params pagination.Pagination
 	return nil, nil, errors.New("not implemented")
 }
 
-func (s *stubAccountRepo) ListWithFilters(ctx context.Context, params pagination.PaginationParams, platform, accountType, status, search string, groupID int64) ([]service.Account, *pagination.PaginationResult, error) {
+func (s *stubAccountRepo) ListWithFilters(ctx context.Context, params pagination.PaginationParams, platform, accountType, status, search string, groupID int64, label string) ([]service.Account, *pagination.PaginationResult, error) {
 	return nil, nil, errors.New("not implemented")
 }
 
@@ -1545,6 +1564,16 @@ func (r *stubUsageLogRepo) GetUserUsageTrend(ctx context.Context, startTime, end
 	return nil, errors.New("not implemented")
 }
 
+func (r *stubUsageLogRepo) GetUserBillableCost(ctx context.Context, userID int64, startTime, endTime time.Time) (float64, error) {
+	var cost float64
+	for _, log := range r.userLogs[userID] {
+		if !log.MeteringOnly {
+			cost += log.ActualCost
+		}
+	}
+	return cost, nil
+}
+
 func (r *stubUsageLogRepo) GetUserStatsAggregated(ctx context.Context, userID int64, startTime, endTime time.Time) (*usagestats.UsageStats, error) {
 	logs := r.userLogs[userID]
 	if len(logs) == 0 {
@@ -1594,6 +1623,14 @@ func (r *stubUsageLogRepo) GetAPIKeyStatsAggregated(ctx context.Context, apiKeyI
 	return nil, errors.New("not implemented")
 }
 
+func (r *stubUsageLogRepo) GetAPIKeyCacheUsageByModel(ctx context.Context, apiKeyID int64, startTime, endTime time.Time) ([]usagestats.ModelCacheUsage, error) {
+	return nil, errors.New("not implemented")
+}
+
+func (r *stubUsageLogRepo) GetAPIKeyToolUsage(ctx context.Context, apiKeyID int64, startTime, endTime time.Time) (*service.ToolUsage, error) {
+	return nil, errors.New("not implemented")
+}
+
 func (r *stubUsageLogRepo) GetAccountStatsAggregated(ctx context.Context, accountID int64, startTime, endTime time.Time) (*usagestats.UsageStats, error) {
 	return nil, errors.New("not implemented")
 }
@@ -1630,6 +1667,10 @@ func (r *stubUsageLogRepo) GetUserModelStats(ctx context.Context, userID int64,
 	return nil, errors.New("not implemented")
 }
 
+func (r *stubUsageLogRepo) ExportWithFilters(ctx context.Context, filters usagestats.UsageLogFilters, batchSize int, fn func([]service.UsageLog) error) error {
+	return errors.New("not implemented")
+}
+
 func (r *stubUsageLogRepo) ListWithFilters(ctx context.Context, params pagination.PaginationParams, filters usagestats.UsageLogFilters) ([]service.UsageLog, *pagination.PaginationResult, error) {
 	logs := r.userLogs[filters.UserID]
 
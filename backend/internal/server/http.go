@@ -2,8 +2,14 @@
 package server
 
 import (
+	"crypto/tls"
+	"crypto/x509"
+	"fmt"
 	"log"
+	"net"
 	"net/http"
+	"os"
+	"strconv"
 	"time"
 
 	"github.com/Wei-Shaw/sub2api/internal/config"
@@ -20,12 +26,15 @@ import (
 
 // ProviderSet 提供服务器层的依赖
 var ProviderSet = wire.NewSet(
-	ProvideRouter,
-	ProvideHTTPServer,
+	ProvideRouterFactory,
+	ProvideHTTPServers,
 )
 
-// ProvideRouter 提供路由器
-func ProvideRouter(
+// RouterFactory 按监听器配置构建独立的路由器（中间件栈互不共享）
+type RouterFactory func(listener config.ListenerConfig) *gin.Engine
+
+// ProvideRouterFactory 提供路由器工厂
+func ProvideRouterFactory(
 	cfg *config.Config,
 	handlers *handler.Handlers,
 	jwtAuth middleware2.JWTAuthMiddleware,
@@ -34,30 +43,130 @@ func ProvideRouter(
 	apiKeyService *service.APIKeyService,
 	subscriptionService *service.SubscriptionService,
 	opsService *service.OpsService,
+	auditLogService *service.AuditLogService,
+	ipBanService *service.IPBanService,
 	settingService *service.SettingService,
 	redisClient *redis.Client,
-) *gin.Engine {
+) RouterFactory {
 	if cfg.Server.Mode == "release" {
 		gin.SetMode(gin.ReleaseMode)
 	}
+	frontend := newFrontendMiddleware(cfg, settingService)
 
-	r := gin.New()
-	r.Use(middleware2.Recovery())
-	if len(cfg.Server.TrustedProxies) > 0 {
-		if err := r.SetTrustedProxies(cfg.Server.TrustedProxies); err != nil {
-			log.Printf("Failed to set trusted proxies: %v", err)
+	return func(listener config.ListenerConfig) *gin.Engine {
+		r := gin.New()
+		r.Use(middleware2.Recovery())
+		trustedProxies := cfg.Server.TrustedProxies
+		if len(listener.TrustedProxies) > 0 {
+			trustedProxies = listener.TrustedProxies
 		}
-	} else {
-		if err := r.SetTrustedProxies(nil); err != nil {
-			log.Printf("Failed to disable trusted proxies: %v", err)
+		if len(trustedProxies) > 0 {
+			if err := r.SetTrustedProxies(trustedProxies); err != nil {
+				log.Printf("Failed to set trusted proxies: %v", err)
+			}
+		} else {
+			if err := r.SetTrustedProxies(nil); err != nil {
+				log.Printf("Failed to disable trusted proxies: %v", err)
+			}
+		}
+
+		return SetupRouter(r, handlers, jwtAuth, adminAuth, apiKeyAuth, apiKeyService, subscriptionService, opsService, auditLogService, ipBanService, frontend, cfg, redisClient, ParseRouteScopes(listener.Routes))
+	}
+}
+
+// Listener 单个监听器：TCP 端口或 Unix socket 上的 HTTP 服务器
+type Listener struct {
+	Name       string
+	Network    string
+	Address    string
+	SocketMode os.FileMode
+	Server     *http.Server
+}
+
+// ListenAndServe 开始监听并阻塞服务，正常关闭时返回 http.ErrServerClosed
+func (l *Listener) ListenAndServe() error {
+	ln, err := l.listen()
+	if err != nil {
+		return err
+	}
+	if l.Server.TLSConfig != nil {
+		// 证书已加载到 TLSConfig
+		return l.Server.ServeTLS(ln, "", "")
+	}
+	return l.Server.Serve(ln)
+}
+
+func (l *Listener) listen() (net.Listener, error) {
+	if l.Network != "unix" {
+		return net.Listen("tcp", l.Address)
+	}
+	// 清理上次异常退出残留的 socket 文件（仅删除 socket，避免误删普通文件）
+	if info, err := os.Lstat(l.Address); err == nil {
+		if info.Mode()&os.ModeSocket == 0 {
+			return nil, fmt.Errorf("listener %s: %s exists and is not a unix socket", l.Name, l.Address)
+		}
+		if err := os.Remove(l.Address); err != nil {
+			return nil, fmt.Errorf("listener %s: remove stale socket: %w", l.Name, err)
+		}
+	}
+	ln, err := net.Listen("unix", l.Address)
+	if err != nil {
+		return nil, err
+	}
+	if l.SocketMode != 0 {
+		if err := os.Chmod(l.Address, l.SocketMode); err != nil {
+			_ = ln.Close()
+			return nil, fmt.Errorf("listener %s: chmod socket: %w", l.Name, err)
 		}
 	}
+	return ln, nil
+}
+
+// ProvideHTTPServers 按 server.listeners 为每个监听器创建独立的 HTTP 服务器
+func ProvideHTTPServers(cfg *config.Config, routerFactory RouterFactory, shutdown *service.ShutdownCoordinator) ([]*Listener, error) {
+	tlsConfig, err := buildServerTLSConfig(cfg.Server.TLS)
+	if err != nil {
+		return nil, err
+	}
 
-	return SetupRouter(r, handlers, jwtAuth, adminAuth, apiKeyAuth, apiKeyService, subscriptionService, opsService, settingService, cfg, redisClient)
+	listenerConfigs := cfg.Server.EffectiveListeners()
+	listeners := make([]*Listener, 0, len(listenerConfigs))
+	for _, lc := range listenerConfigs {
+		network := lc.Network
+		if network == "" {
+			network = "tcp"
+		}
+		var socketMode os.FileMode
+		if lc.SocketMode != "" {
+			mode, err := strconv.ParseUint(lc.SocketMode, 8, 32)
+			if err != nil {
+				return nil, fmt.Errorf("listener %s: invalid socket_mode: %w", lc.Name, err)
+			}
+			socketMode = os.FileMode(mode)
+		}
+		var listenerTLS *tls.Config
+		if lc.TLS {
+			listenerTLS = tlsConfig
+		}
+
+		srv := newHTTPServer(cfg, routerFactory(lc), listenerTLS)
+		srv.Addr = lc.Address
+		// 请求 context 派生自关闭协调器，排空超时后可统一取消仍在进行的请求
+		srv.BaseContext = shutdown.BaseContext
+		listeners = append(listeners, &Listener{
+			Name:       lc.Name,
+			Network:    network,
+			Address:    lc.Address,
+			SocketMode: socketMode,
+			Server:     srv,
+		})
+		log.Printf("Listener %s: %s %s routes=%v tls=%v", lc.Name, network, lc.Address, lc.Routes, lc.TLS)
+	}
+	return listeners, nil
 }
 
-// ProvideHTTPServer 提供 HTTP 服务器
-func ProvideHTTPServer(cfg *config.Config, router *gin.Engine) *http.Server {
+// newHTTPServer 创建 HTTP 服务器（请求体限制、H2C 与超时设置对所有监听器一致）
+func newHTTPServer(cfg *config.Config, router *gin.Engine, tlsConfig *tls.Config) *http.Server {
 	httpHandler := http.Handler(router)
 
 	globalMaxSize := cfg.Server.MaxRequestBodySize
@@ -89,8 +198,8 @@ func ProvideHTTPServer(cfg *config.Config, router *gin.Engine) *http.Server {
 	}
 
 	return &http.Server{
-		Addr:    cfg.Server.Address(),
-		Handler: httpHandler,
+		TLSConfig: tlsConfig,
+		Handler:   httpHandler,
 		// ReadHeaderTimeout: 读取请求头的超时时间，防止慢速请求头攻击
 		ReadHeaderTimeout: time.Duration(cfg.Server.ReadHeaderTimeout) * time.Second,
 		// IdleTimeout: 空闲连接超时时间，释放不活跃的连接资源
@@ -99,3 +208,37 @@ func ProvideHTTPServer(cfg *config.Config, router *gin.Engine) *http.Server {
 		// 不设置 ReadTimeout，因为大请求体可能需要较长时间读取
 	}
 }
+
+// buildServerTLSConfig 构建监听端 TLS 配置，未启用时返回 nil（明文监听）
+// 配置 client_ca_file 后按需校验客户端证书：管理后台等端点不出示证书也可访问，
+// 网关端点是否必须使用证书由 API Key 认证中间件按 client_auth 配置判断。
+func buildServerTLSConfig(cfg config.ServerTLSConfig) (*tls.Config, error) {
+	if !cfg.Enabled {
+		return nil, nil
+	}
+	cert, err := tls.LoadX509KeyPair(cfg.CertFile, cfg.KeyFile)
+	if err != nil {
+		return nil, fmt.Errorf("load server tls certificate: %w", err)
+	}
+	tlsConfig := &tls.Config{
+		Certificates: []tls.Certificate{cert},
+		MinVersion:   tls.VersionTLS12,
+	}
+	if cfg.ClientCAFile != "" {
+		pem, err := os.ReadFile(cfg.ClientCAFile)
+		if err != nil {
+			return nil, fmt.Errorf("read client ca file: %w", err)
+		}
+		pool := x509.NewCertPool()
+		if !pool.AppendCertsFromPEM(pem) {
+			return nil, fmt.Errorf("client ca file contains no valid PEM certificates")
+		}
+		tlsConfig.ClientCAs = pool
+		tlsConfig.ClientAuth = tls.VerifyClientCertIfGiven
+		log.Printf("TLS enabled with client certificate verification (client_auth.enabled=%v, require_cert=%v, mappings=%d)",
+			cfg.ClientAuth.Enabled, cfg.ClientAuth.RequireCert, len(cfg.ClientAuth.Mappings))
+	} else {
+		log.Printf("TLS enabled")
+	}
+	return tlsConfig, nil
+}
@@ -0,0 +1,79 @@
+//go:build unit
+
+package server
+
+import (
+	"context"
+	"errors"
+	"net"
+	"net/http"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/Wei-Shaw/sub2api/internal/config"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseRouteScopes(t *testing.T) {
+	require.Equal(t, AllRoutes, ParseRouteScopes(nil))
+	require.Equal(t, AllRoutes, ParseRouteScopes([]string{config.ListenerRouteGateway, config.ListenerRouteAll}))
+	require.Equal(t, RouteScopes{Gateway: true, User: true}, ParseRouteScopes([]string{config.ListenerRouteGateway, config.ListenerRouteUser}))
+
+	admin := ParseRouteScopes([]string{config.ListenerRouteAdmin})
+	require.Equal(t, RouteScopes{Admin: true}, admin)
+	require.True(t, admin.serveWeb())
+	require.False(t, ParseRouteScopes([]string{config.ListenerRouteGateway}).serveWeb())
+}
+
+func TestListener_UnixSocket(t *testing.T) {
+	socketPath := filepath.Join(t.TempDir(), "admin.sock")
+
+	// 残留的 socket 文件会在启动时被清理
+	stale, err := net.Listen("unix", socketPath)
+	require.NoError(t, err)
+	stale.(*net.UnixListener).SetUnlinkOnClose(false)
+	require.NoError(t, stale.Close())
+
+	l := &Listener{
+		Name:       "internal",
+		Network:    "unix",
+		Address:    socketPath,
+		SocketMode: 0o600,
+		Server: &http.Server{Handler: http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.WriteHeader(http.StatusNoContent)
+		})},
+	}
+	done := make(chan error, 1)
+	go func() { done <- l.ListenAndServe() }()
+
+	client := &http.Client{Transport: &http.Transport{
+		DialContext: func(ctx context.Context, _, _ string) (net.Conn, error) {
+			return (&net.Dialer{}).DialContext(ctx, "unix", socketPath)
+		},
+	}}
+	require.Eventually(t, func() bool {
+		resp, err := client.Get("http://unix/health")
+		if err != nil {
+			return false
+		}
+		_ = resp.Body.Close()
+		return resp.StatusCode == http.StatusNoContent
+	}, 2*time.Second, 10*time.Millisecond)
+
+	info, err := os.Stat(socketPath)
+	require.NoError(t, err)
+	require.Equal(t, os.FileMode(0o600), info.Mode().Perm())
+
+	require.NoError(t, l.Server.Shutdown(context.Background()))
+	require.True(t, errors.Is(<-done, http.ErrServerClosed))
+}
+
+func TestListener_RefusesToReplaceRegularFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "not-a-socket")
+	require.NoError(t, os.WriteFile(path, []byte("x"), 0o600))
+
+	l := &Listener{Name: "internal", Network: "unix", Address: path, Server: &http.Server{}}
+	require.Error(t, l.ListenAndServe())
+}
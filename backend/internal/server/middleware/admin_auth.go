@@ -36,7 +36,7 @@ func adminAuth(
 		//   Sec-WebSocket-Protocol: sub2api-admin, jwt.<token>
 		if isWebSocketUpgradeRequest(c) {
 			if token := extractJWTFromWebSocketSubprotocol(c); token != "" {
-				if !validateJWTForAdmin(c, token, authService, userService) {
+				if !validateJWTForAdmin(c, token, authService, userService) || !authorizeAdminRoute(c) {
 					return
 				}
 				c.Next()
@@ -47,7 +47,7 @@ func adminAuth(
 		// 检查 x-api-key header（Admin API Key 认证）
 		apiKey := c.GetHeader("x-api-key")
 		if apiKey != "" {
-			if !validateAdminAPIKey(c, apiKey, settingService, userService) {
+			if !validateAdminAPIKey(c, apiKey, settingService, userService) || !authorizeAdminRoute(c) {
 				return
 			}
 			c.Next()
@@ -59,7 +59,7 @@ func adminAuth(
 		if authHeader != "" {
 			parts := strings.SplitN(authHeader, " ", 2)
 			if len(parts) == 2 && parts[0] == "Bearer" {
-				if !validateJWTForAdmin(c, parts[1], authService, userService) {
+				if !validateJWTForAdmin(c, parts[1], authService, userService) || !authorizeAdminRoute(c) {
 					return
 				}
 				c.Next()
@@ -145,6 +145,17 @@ func validateAdminAPIKey(
 	return true
 }
 
+// authorizeAdminRoute 按当前角色校验对所访问管理接口的权限
+func authorizeAdminRoute(c *gin.Context) bool {
+	role, _ := GetUserRoleFromContext(c)
+	perm := service.AdminRoutePermission(c.Request.Method, c.FullPath())
+	if !service.AdminRoleHasPermission(role, perm) {
+		AbortWithError(c, 403, "ADMIN_PERMISSION_DENIED", "Permission denied: "+string(perm)+" required")
+		return false
+	}
+	return true
+}
+
 // validateJWTForAdmin 验证 JWT 并检查管理员权限
 func validateJWTForAdmin(
 	c *gin.Context,
@@ -176,8 +187,8 @@ func validateJWTForAdmin(
 		return false
 	}
 
-	// 检查管理员权限
-	if !user.IsAdmin() {
+	// 检查管理后台访问权限（超级管理员或受限管理角色，具体接口权限由 authorizeAdminRoute 校验）
+	if !user.HasAdminAccess() {
 		AbortWithError(c, 403, "FORBIDDEN", "Admin access required")
 		return false
 	}
@@ -0,0 +1,41 @@
+//go:build unit
+
+package middleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/Wei-Shaw/sub2api/internal/service"
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/require"
+)
+
+func TestAuthorizeAdminRoute_GroupAPIKeysRequireUsersWrite(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	router.Use(func(c *gin.Context) {
+		c.Set(string(ContextKeyUserRole), c.GetHeader("X-Test-Role"))
+		if !authorizeAdminRoute(c) {
+			return
+		}
+		c.Next()
+	})
+	router.GET("/api/v1/admin/groups/:id", func(c *gin.Context) { c.Status(http.StatusOK) })
+	router.GET("/api/v1/admin/groups/:id/api-keys", func(c *gin.Context) { c.Status(http.StatusOK) })
+
+	do := func(role, path string) int {
+		req := httptest.NewRequest(http.MethodGet, path, nil)
+		req.Header.Set("X-Test-Role", role)
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		return w.Code
+	}
+
+	// 分组详情对客服可见，但分组下的 API Key 列表返回完整密钥
+	require.Equal(t, http.StatusOK, do(service.RoleSupport, "/api/v1/admin/groups/1"))
+	require.Equal(t, http.StatusForbidden, do(service.RoleSupport, "/api/v1/admin/groups/1/api-keys"))
+	require.Equal(t, http.StatusForbidden, do(service.RoleOperator, "/api/v1/admin/groups/1/api-keys"))
+	require.Equal(t, http.StatusOK, do(service.RoleAdmin, "/api/v1/admin/groups/1/api-keys"))
+}
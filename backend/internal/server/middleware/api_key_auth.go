@@ -8,7 +8,9 @@ import (
 
 	"github.com/Wei-Shaw/sub2api/internal/config"
 	"github.com/Wei-Shaw/sub2api/internal/pkg/ctxkey"
+	infraerrors "github.com/Wei-Shaw/sub2api/internal/pkg/errors"
 	"github.com/Wei-Shaw/sub2api/internal/pkg/ip"
+	"github.com/Wei-Shaw/sub2api/internal/pkg/logger"
 	"github.com/Wei-Shaw/sub2api/internal/service"
 
 	"github.com/gin-gonic/gin"
@@ -29,11 +31,16 @@ func apiKeyAuthWithSubscription(apiKeyService *service.APIKeyService, subscripti
 			return
 		}
 
+		// mTLS 客户端证书映射优先于令牌认证
+		apiKeyString, certErr := clientCertAPIKey(c, cfg)
+		if certErr != nil {
+			abortClientCertError(c, certErr)
+			return
+		}
+
 		// 尝试从Authorization header中提取API key (Bearer scheme)
 		authHeader := c.GetHeader("Authorization")
-		var apiKeyString string
-
-		if authHeader != "" {
+		if apiKeyString == "" && authHeader != "" {
 			// 验证Bearer scheme
 			parts := strings.SplitN(authHeader, " ", 2)
 			if len(parts) == 2 && parts[0] == "Bearer" {
@@ -57,13 +64,17 @@ func apiKeyAuthWithSubscription(apiKeyService *service.APIKeyService, subscripti
 			return
 		}
 
-		// 从数据库验证API key
-		apiKey, err := apiKeyService.GetByKey(c.Request.Context(), apiKeyString)
+		// 从数据库验证API key（或校验网关 JWT 并映射到其 API Key）
+		apiKey, err := authenticateGatewayCredential(c, apiKeyService, apiKeyString)
 		if err != nil {
 			if errors.Is(err, service.ErrAPIKeyNotFound) {
 				AbortWithError(c, 401, "INVALID_API_KEY", "Invalid API key")
 				return
 			}
+			if status := infraerrors.Code(err); isGatewayCredentialRejected(status) {
+				AbortWithError(c, status, infraerrors.Reason(err), infraerrors.Message(err))
+				return
+			}
 			AbortWithError(c, 500, "INTERNAL_ERROR", "Failed to validate API key")
 			return
 		}
@@ -87,6 +98,7 @@ func apiKeyAuthWithSubscription(apiKeyService *service.APIKeyService, subscripti
 			AbortWithError(c, 403, "API_KEY_EXPIRED", "API key 已过期")
 			return
 		}
+		setAPIKeyExpiryHeaders(c, apiKeyService, apiKey)
 
 		// 检查API Key配额是否耗尽
 		if apiKey.IsQuotaExhausted() {
@@ -96,13 +108,15 @@ func apiKeyAuthWithSubscription(apiKeyService *service.APIKeyService, subscripti
 
 		// 检查 IP 限制（白名单/黑名单）
 		// 注意：错误信息故意模糊，避免暴露具体的 IP 限制机制
-		if len(apiKey.IPWhitelist) > 0 || len(apiKey.IPBlacklist) > 0 {
-			clientIP := ip.GetClientIP(c)
-			allowed, _ := ip.CheckIPRestriction(clientIP, apiKey.IPWhitelist, apiKey.IPBlacklist)
-			if !allowed {
-				AbortWithError(c, 403, "ACCESS_DENIED", "Access denied")
-				return
-			}
+		if !checkAPIKeyIPRestriction(c, apiKey) {
+			AbortWithError(c, 403, "ACCESS_DENIED", "Access denied")
+			return
+		}
+
+		// 启用请求签名的 Key：校验 HMAC 签名与时间戳，防止截获的请求被重放
+		if err := verifyAPIKeyRequestSignature(c, apiKeyService, apiKey); err != nil {
+			AbortWithError(c, infraerrors.Code(err), infraerrors.Reason(err), infraerrors.Message(err))
+			return
 		}
 
 		// 检查关联的用户
@@ -117,15 +131,30 @@ func apiKeyAuthWithSubscription(apiKeyService *service.APIKeyService, subscripti
 			return
 		}
 
+		// 分组维护中：拒绝新请求（进行中的请求不受影响）
+		if apiKey.Group != nil && apiKey.Group.IsInMaintenance() {
+			AbortWithError(c, 503, "GROUP_MAINTENANCE", cfg.Live().Gateway.MaintenanceMessage)
+			return
+		}
+
+		// 检查 API Key 访问范围（端点与功能），在处理器解析请求体之前拒绝
+		if err := checkAPIKeyScopes(c, apiKey); err != nil {
+			AbortWithError(c, 403, "API_KEY_SCOPE_DENIED", err.Error())
+			return
+		}
+
 		if cfg.RunMode == config.RunModeSimple {
 			// 简易模式：跳过余额和订阅检查，但仍需设置必要的上下文
 			c.Set(string(ContextKeyAPIKey), apiKey)
+			logger.SetAPIKeyID(c.Request.Context(), apiKey.ID)
 			c.Set(string(ContextKeyUser), AuthSubject{
 				UserID:      apiKey.User.ID,
 				Concurrency: apiKey.User.Concurrency,
 			})
 			c.Set(string(ContextKeyUserRole), apiKey.User.Role)
 			setGroupContext(c, apiKey.Group)
+			setRegionPolicyContext(c, apiKey)
+			setErrorDebugContext(c, apiKey)
 			c.Next()
 			return
 		}
@@ -161,17 +190,19 @@ func apiKeyAuthWithSubscription(apiKeyService *service.APIKeyService, subscripti
 				log.Printf("Failed to reset subscription windows: %v", err)
 			}
 
-			// 预检查用量限制（使用0作为额外费用进行预检查）
-			if err := subscriptionService.CheckUsageLimits(c.Request.Context(), subscription, apiKey.Group, 0); err != nil {
-				AbortWithError(c, 429, "USAGE_LIMIT_EXCEEDED", err.Error())
-				return
+			// 预检查用量限制（使用0作为额外费用进行预检查；仅计量分组不做限额拦截）
+			if !apiKey.Group.IsMeteringOnly() {
+				if err := subscriptionService.CheckUsageLimits(c.Request.Context(), subscription, apiKey.Group, 0); err != nil {
+					AbortWithError(c, 429, "USAGE_LIMIT_EXCEEDED", err.Error())
+					return
+				}
 			}
 
 			// 将订阅信息存入上下文
 			c.Set(string(ContextKeySubscription), subscription)
 		} else {
-			// 余额模式：检查用户余额
-			if apiKey.User.Balance <= 0 {
+			// 余额模式：检查用户余额（仅计量分组不扣费，跳过检查）
+			if apiKey.User.Balance <= 0 && !apiKey.Group.IsMeteringOnly() {
 				AbortWithError(c, 403, "INSUFFICIENT_BALANCE", "Insufficient account balance")
 				return
 			}
@@ -179,12 +210,15 @@ func apiKeyAuthWithSubscription(apiKeyService *service.APIKeyService, subscripti
 
 		// 将API key和用户信息存入上下文
 		c.Set(string(ContextKeyAPIKey), apiKey)
+		logger.SetAPIKeyID(c.Request.Context(), apiKey.ID)
 		c.Set(string(ContextKeyUser), AuthSubject{
 			UserID:      apiKey.User.ID,
 			Concurrency: apiKey.User.Concurrency,
 		})
 		c.Set(string(ContextKeyUserRole), apiKey.User.Role)
 		setGroupContext(c, apiKey.Group)
+		setRegionPolicyContext(c, apiKey)
+		setErrorDebugContext(c, apiKey)
 
 		c.Next()
 	}
@@ -200,6 +234,16 @@ func GetAPIKeyFromContext(c *gin.Context) (*service.APIKey, bool) {
 	return apiKey, ok
 }
 
+// GetDeniedAPIKeyFromContext 从上下文中获取因 IP 限制被拒绝的 API key
+func GetDeniedAPIKeyFromContext(c *gin.Context) (*service.APIKey, bool) {
+	value, exists := c.Get(string(ContextKeyDeniedAPIKey))
+	if !exists {
+		return nil, false
+	}
+	apiKey, ok := value.(*service.APIKey)
+	return apiKey, ok
+}
+
 // GetSubscriptionFromContext 从上下文中获取订阅信息
 func GetSubscriptionFromContext(c *gin.Context) (*service.UserSubscription, bool) {
 	value, exists := c.Get(string(ContextKeySubscription))
@@ -210,6 +254,21 @@ func GetSubscriptionFromContext(c *gin.Context) (*service.UserSubscription, bool
 	return subscription, ok
 }
 
+// checkAPIKeyIPRestriction 校验客户端 IP 是否在 API Key 的白名单/黑名单（IP 或 CIDR）允许范围内。
+// 拒绝时记录被拒绝的 Key 供运维错误日志归属，并输出告警日志（泄露的 Key 被异地调用是主要滥用来源）。
+func checkAPIKeyIPRestriction(c *gin.Context, apiKey *service.APIKey) bool {
+	if len(apiKey.IPWhitelist) == 0 && len(apiKey.IPBlacklist) == 0 {
+		return true
+	}
+	clientIP := ip.GetClientIP(c)
+	if allowed, _ := ip.CheckIPRestriction(clientIP, apiKey.IPWhitelist, apiKey.IPBlacklist); allowed {
+		return true
+	}
+	c.Set(string(ContextKeyDeniedAPIKey), apiKey)
+	log.Printf("[APIKeyAuth] IP restriction denied: api_key_id=%d client_ip=%s path=%s", apiKey.ID, clientIP, c.Request.URL.Path)
+	return false
+}
+
 func setGroupContext(c *gin.Context, group *service.Group) {
 	if !service.IsGroupContextValid(group) {
 		return
@@ -220,3 +279,17 @@ func setGroupContext(c *gin.Context, group *service.Group) {
 	ctx := context.WithValue(c.Request.Context(), ctxkey.Group, group)
 	c.Request = c.Request.WithContext(ctx)
 }
+
+func setRegionPolicyContext(c *gin.Context, apiKey *service.APIKey) {
+	policy := service.EffectiveRegionPolicy(apiKey)
+	if policy.IsEmpty() {
+		return
+	}
+	c.Request = c.Request.WithContext(service.WithRegionPolicy(c.Request.Context(), policy))
+}
+
+func setErrorDebugContext(c *gin.Context, apiKey *service.APIKey) {
+	if apiKey.DebugErrors {
+		service.EnableUpstreamErrorDebug(c)
+	}
+}
@@ -5,7 +5,9 @@ import (
 	"strings"
 
 	"github.com/Wei-Shaw/sub2api/internal/config"
+	infraerrors "github.com/Wei-Shaw/sub2api/internal/pkg/errors"
 	"github.com/Wei-Shaw/sub2api/internal/pkg/googleapi"
+	"github.com/Wei-Shaw/sub2api/internal/pkg/logger"
 	"github.com/Wei-Shaw/sub2api/internal/service"
 
 	"github.com/gin-gonic/gin"
@@ -26,18 +28,29 @@ func APIKeyAuthWithSubscriptionGoogle(apiKeyService *service.APIKeyService, subs
 			abortWithGoogleError(c, 400, "Query parameter api_key is deprecated. Use Authorization header or key instead.")
 			return
 		}
-		apiKeyString := extractAPIKeyForGoogle(c)
+		apiKeyString, certErr := clientCertAPIKey(c, cfg)
+		if certErr != nil {
+			abortWithGoogleError(c, 401, certErr.Error())
+			return
+		}
+		if apiKeyString == "" {
+			apiKeyString = extractAPIKeyForGoogle(c)
+		}
 		if apiKeyString == "" {
 			abortWithGoogleError(c, 401, "API key is required")
 			return
 		}
 
-		apiKey, err := apiKeyService.GetByKey(c.Request.Context(), apiKeyString)
+		apiKey, err := authenticateGatewayCredential(c, apiKeyService, apiKeyString)
 		if err != nil {
 			if errors.Is(err, service.ErrAPIKeyNotFound) {
 				abortWithGoogleError(c, 401, "Invalid API key")
 				return
 			}
+			if status := infraerrors.Code(err); isGatewayCredentialRejected(status) {
+				abortWithGoogleError(c, status, infraerrors.Message(err))
+				return
+			}
 			abortWithGoogleError(c, 500, "Failed to validate API key")
 			return
 		}
@@ -46,6 +59,19 @@ func APIKeyAuthWithSubscriptionGoogle(apiKeyService *service.APIKeyService, subs
 			abortWithGoogleError(c, 401, "API key is disabled")
 			return
 		}
+		if apiKey.IsExpired() {
+			abortWithGoogleError(c, 403, "API key has expired")
+			return
+		}
+		setAPIKeyExpiryHeaders(c, apiKeyService, apiKey)
+		if !checkAPIKeyIPRestriction(c, apiKey) {
+			abortWithGoogleError(c, 403, "Access denied")
+			return
+		}
+		if err := verifyAPIKeyRequestSignature(c, apiKeyService, apiKey); err != nil {
+			abortWithGoogleError(c, infraerrors.Code(err), infraerrors.Message(err))
+			return
+		}
 		if apiKey.User == nil {
 			abortWithGoogleError(c, 401, "User associated with API key not found")
 			return
@@ -54,16 +80,27 @@ func APIKeyAuthWithSubscriptionGoogle(apiKeyService *service.APIKeyService, subs
 			abortWithGoogleError(c, 401, "User account is not active")
 			return
 		}
+		if apiKey.Group != nil && apiKey.Group.IsInMaintenance() {
+			abortWithGoogleError(c, 503, cfg.Live().Gateway.MaintenanceMessage)
+			return
+		}
+		if err := checkAPIKeyScopes(c, apiKey); err != nil {
+			abortWithGoogleError(c, 403, err.Error())
+			return
+		}
 
 		// 简易模式：跳过余额和订阅检查
 		if cfg.RunMode == config.RunModeSimple {
 			c.Set(string(ContextKeyAPIKey), apiKey)
+			logger.SetAPIKeyID(c.Request.Context(), apiKey.ID)
 			c.Set(string(ContextKeyUser), AuthSubject{
 				UserID:      apiKey.User.ID,
 				Concurrency: apiKey.User.Concurrency,
 			})
 			c.Set(string(ContextKeyUserRole), apiKey.User.Role)
 			setGroupContext(c, apiKey.Group)
+			setRegionPolicyContext(c, apiKey)
+			setErrorDebugContext(c, apiKey)
 			c.Next()
 			return
 		}
@@ -85,25 +122,30 @@ func APIKeyAuthWithSubscriptionGoogle(apiKeyService *service.APIKeyService, subs
 			}
 			_ = subscriptionService.CheckAndActivateWindow(c.Request.Context(), subscription)
 			_ = subscriptionService.CheckAndResetWindows(c.Request.Context(), subscription)
-			if err := subscriptionService.CheckUsageLimits(c.Request.Context(), subscription, apiKey.Group, 0); err != nil {
-				abortWithGoogleError(c, 429, err.Error())
-				return
+			if !apiKey.Group.IsMeteringOnly() {
+				if err := subscriptionService.CheckUsageLimits(c.Request.Context(), subscription, apiKey.Group, 0); err != nil {
+					abortWithGoogleError(c, 429, err.Error())
+					return
+				}
 			}
 			c.Set(string(ContextKeySubscription), subscription)
 		} else {
-			if apiKey.User.Balance <= 0 {
+			if apiKey.User.Balance <= 0 && !apiKey.Group.IsMeteringOnly() {
 				abortWithGoogleError(c, 403, "Insufficient account balance")
 				return
 			}
 		}
 
 		c.Set(string(ContextKeyAPIKey), apiKey)
+		logger.SetAPIKeyID(c.Request.Context(), apiKey.ID)
 		c.Set(string(ContextKeyUser), AuthSubject{
 			UserID:      apiKey.User.ID,
 			Concurrency: apiKey.User.Concurrency,
 		})
 		c.Set(string(ContextKeyUserRole), apiKey.User.Role)
 		setGroupContext(c, apiKey.Group)
+		setRegionPolicyContext(c, apiKey)
+		setErrorDebugContext(c, apiKey)
 		c.Next()
 	}
 }
@@ -323,6 +323,56 @@ func TestApiKeyAuthWithSubscriptionGoogle_DisabledKey(t *testing.T) {
 	require.Equal(t, "UNAUTHENTICATED", resp.Error.Status)
 }
 
+func TestApiKeyAuthWithSubscriptionGoogle_IPRestrictionDenied(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	r := gin.New()
+	apiKeyService := newTestAPIKeyService(fakeAPIKeyRepo{
+		getByKey: func(ctx context.Context, key string) (*service.APIKey, error) {
+			return &service.APIKey{
+				ID:          7,
+				Key:         key,
+				Status:      service.StatusActive,
+				IPWhitelist: []string{"203.0.113.0/24"},
+				User: &service.User{
+					ID:      123,
+					Status:  service.StatusActive,
+					Balance: 10,
+				},
+			}, nil
+		},
+	})
+	var deniedKeyID int64
+	r.Use(func(c *gin.Context) {
+		c.Next()
+		if key, ok := GetDeniedAPIKeyFromContext(c); ok {
+			deniedKeyID = key.ID
+		}
+	})
+	r.Use(APIKeyAuthWithSubscriptionGoogle(apiKeyService, nil, &config.Config{}))
+	r.GET("/v1beta/test", func(c *gin.Context) { c.JSON(200, gin.H{"ok": true}) })
+
+	req := httptest.NewRequest(http.MethodGet, "/v1beta/test", nil)
+	req.Header.Set("Authorization", "Bearer leaked")
+	req.Header.Set("X-Real-IP", "198.51.100.9")
+	rec := httptest.NewRecorder()
+	r.ServeHTTP(rec, req)
+
+	require.Equal(t, http.StatusForbidden, rec.Code)
+	var resp googleErrorResponse
+	require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &resp))
+	require.Equal(t, "Access denied", resp.Error.Message)
+	require.Equal(t, "PERMISSION_DENIED", resp.Error.Status)
+	require.Equal(t, int64(7), deniedKeyID)
+
+	req = httptest.NewRequest(http.MethodGet, "/v1beta/test", nil)
+	req.Header.Set("Authorization", "Bearer leaked")
+	req.Header.Set("X-Real-IP", "203.0.113.20")
+	rec = httptest.NewRecorder()
+	r.ServeHTTP(rec, req)
+	require.Equal(t, http.StatusOK, rec.Code)
+}
+
 func TestApiKeyAuthWithSubscriptionGoogle_InsufficientBalance(t *testing.T) {
 	gin.SetMode(gin.TestMode)
 
@@ -0,0 +1,20 @@
+package middleware
+
+import (
+	"strconv"
+	"time"
+
+	"github.com/Wei-Shaw/sub2api/internal/service"
+
+	"github.com/gin-gonic/gin"
+)
+
+// setAPIKeyExpiryHeaders Key 即将过期（包括轮换后处于重叠窗口的旧 Key）时，在响应头中提示客户端及时更换
+func setAPIKeyExpiryHeaders(c *gin.Context, apiKeyService *service.APIKeyService, apiKey *service.APIKey) {
+	remaining, ok := apiKeyService.ExpiryWarning(apiKey, time.Now())
+	if !ok {
+		return
+	}
+	c.Header("X-API-Key-Expires-At", apiKey.ExpiresAt.UTC().Format(time.RFC3339))
+	c.Header("X-API-Key-Expires-In", strconv.FormatInt(int64(remaining/time.Second), 10))
+}
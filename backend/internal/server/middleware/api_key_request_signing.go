@@ -0,0 +1,26 @@
+package middleware
+
+import (
+	"github.com/Wei-Shaw/sub2api/internal/service"
+
+	"github.com/gin-gonic/gin"
+)
+
+// verifyAPIKeyRequestSignature 校验启用了请求签名的 API Key 的 HMAC 签名（覆盖时间戳与原始请求体）。
+// 请求体读取失败时不在此拒绝，由处理器读取同一错误后按原逻辑返回。
+func verifyAPIKeyRequestSignature(c *gin.Context, apiKeyService *service.APIKeyService, apiKey *service.APIKey) error {
+	if apiKey == nil || !apiKey.RequestSigning.IsEnabled() {
+		return nil
+	}
+	body, err := peekRequestBody(c)
+	if err != nil {
+		return nil
+	}
+	return apiKeyService.VerifyRequestSignature(
+		c.Request.Context(),
+		apiKey,
+		c.GetHeader(service.RequestSigningHeaderTimestamp),
+		c.GetHeader(service.RequestSigningHeaderSignature),
+		body,
+	)
+}
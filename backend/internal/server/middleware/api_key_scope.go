@@ -0,0 +1,63 @@
+package middleware
+
+import (
+	"bytes"
+	"io"
+	"net/http"
+
+	"github.com/Wei-Shaw/sub2api/internal/service"
+
+	"github.com/gin-gonic/gin"
+)
+
+// checkAPIKeyScopes 校验 API Key 的访问范围（端点与功能），在处理器解析请求体之前执行。
+// 端点由路径确定；仅当 Key 限制了功能时才探测原始请求体，读取后原样放回供后续处理器使用。
+func checkAPIKeyScopes(c *gin.Context, apiKey *service.APIKey) error {
+	if apiKey == nil || len(apiKey.Scopes) == 0 {
+		return nil
+	}
+	endpoint := service.APIKeyScopeEndpointForPath(c.Request.URL.Path)
+	if endpoint == "" {
+		return nil
+	}
+	if err := service.CheckAPIKeyEndpointScope(apiKey, endpoint); err != nil {
+		return err
+	}
+	if !apiKey.RestrictsScopeFeatures() {
+		return nil
+	}
+	features := service.APIKeyScopeFeaturesFromURL(c.Request.URL.Path, c.Request.URL.Query())
+	if c.Request.Method == http.MethodPost {
+		if body, err := peekRequestBody(c); err == nil {
+			features = append(features, service.DetectAPIKeyScopeFeatures(body)...)
+		}
+	}
+	return service.CheckAPIKeyFeatureScopes(apiKey, features)
+}
+
+// peekRequestBody 读取完整请求体并原样放回，供后续处理器再次读取。
+// 读取失败（如超出请求体上限）时回放已读内容与原错误，由处理器按原逻辑返回。
+func peekRequestBody(c *gin.Context) ([]byte, error) {
+	if c.Request.Body == nil {
+		return nil, nil
+	}
+	body, err := io.ReadAll(c.Request.Body)
+	c.Request.Body = replayBody{Reader: io.MultiReader(bytes.NewReader(body), errReader{err: err}), Closer: c.Request.Body}
+	return body, err
+}
+
+type replayBody struct {
+	io.Reader
+	io.Closer
+}
+
+type errReader struct {
+	err error
+}
+
+func (r errReader) Read([]byte) (int, error) {
+	if r.err != nil {
+		return 0, r.err
+	}
+	return 0, io.EOF
+}
@@ -0,0 +1,98 @@
+package middleware
+
+import (
+	"crypto/x509"
+	"errors"
+	"strings"
+
+	"github.com/Wei-Shaw/sub2api/internal/config"
+	"github.com/gin-gonic/gin"
+)
+
+var (
+	errClientCertRequired  = errors.New("client certificate required")
+	errClientCertNotMapped = errors.New("client certificate is not mapped to an API key")
+)
+
+// clientCertAPIKey 从已校验的 mTLS 客户端证书解析映射的 API Key。
+//
+// 返回值：
+//   - 未启用客户端证书认证，或未出示证书且不要求证书：("", nil)，调用方继续走令牌认证
+//   - 证书命中映射：(apiKey, nil)
+//   - 要求证书但未出示：errClientCertRequired
+//   - 出示了证书但没有对应映射：errClientCertNotMapped（要求证书时）或 ("", nil)
+func clientCertAPIKey(c *gin.Context, cfg *config.Config) (string, error) {
+	if cfg == nil || !cfg.Server.TLS.ClientAuth.Enabled {
+		return "", nil
+	}
+	auth := cfg.Server.TLS.ClientAuth
+
+	// 仅信任服务端已按 client_ca_file 校验通过的证书链
+	tlsState := c.Request.TLS
+	if tlsState == nil || len(tlsState.VerifiedChains) == 0 || len(tlsState.PeerCertificates) == 0 {
+		if auth.RequireCert {
+			return "", errClientCertRequired
+		}
+		return "", nil
+	}
+
+	cert := tlsState.PeerCertificates[0]
+	for _, m := range auth.Mappings {
+		if clientCertMatches(cert, m) {
+			return strings.TrimSpace(m.APIKey), nil
+		}
+	}
+	if auth.RequireCert {
+		return "", errClientCertNotMapped
+	}
+	return "", nil
+}
+
+// clientCertMatches 判断证书是否命中映射；subject 与 san 同时配置时需同时匹配
+func clientCertMatches(cert *x509.Certificate, m config.ClientCertMapping) bool {
+	subject := strings.TrimSpace(m.Subject)
+	san := strings.TrimSpace(m.SAN)
+	if subject == "" && san == "" {
+		return false
+	}
+	if subject != "" && subject != cert.Subject.String() && subject != cert.Subject.CommonName {
+		return false
+	}
+	if san != "" && !certHasSAN(cert, san) {
+		return false
+	}
+	return true
+}
+
+func certHasSAN(cert *x509.Certificate, san string) bool {
+	for _, name := range cert.DNSNames {
+		if strings.EqualFold(name, san) {
+			return true
+		}
+	}
+	for _, email := range cert.EmailAddresses {
+		if strings.EqualFold(email, san) {
+			return true
+		}
+	}
+	for _, uri := range cert.URIs {
+		if uri.String() == san {
+			return true
+		}
+	}
+	for _, ipAddr := range cert.IPAddresses {
+		if ipAddr.String() == san {
+			return true
+		}
+	}
+	return false
+}
+
+// abortClientCertError 客户端证书认证失败的统一响应
+func abortClientCertError(c *gin.Context, err error) {
+	if errors.Is(err, errClientCertNotMapped) {
+		AbortWithError(c, 401, "CLIENT_CERT_NOT_MAPPED", "Client certificate is not mapped to an API key")
+		return
+	}
+	AbortWithError(c, 401, "CLIENT_CERT_REQUIRED", "A valid client certificate is required for this endpoint")
+}
@@ -0,0 +1,105 @@
+//go:build unit
+
+package middleware
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+
+	"github.com/Wei-Shaw/sub2api/internal/config"
+	"github.com/Wei-Shaw/sub2api/internal/service"
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/require"
+)
+
+func TestClientCertMatches(t *testing.T) {
+	spiffe, _ := url.Parse("spiffe://acme/ns/prod/sa/reporting")
+	cert := &x509.Certificate{
+		Subject:  pkix.Name{CommonName: "billing-worker", Organization: []string{"Acme"}},
+		DNSNames: []string{"worker.internal"},
+		URIs:     []*url.URL{spiffe},
+	}
+
+	require.True(t, clientCertMatches(cert, config.ClientCertMapping{Subject: "billing-worker"}))
+	require.True(t, clientCertMatches(cert, config.ClientCertMapping{Subject: "CN=billing-worker,O=Acme"}))
+	require.True(t, clientCertMatches(cert, config.ClientCertMapping{SAN: "WORKER.internal"}))
+	require.True(t, clientCertMatches(cert, config.ClientCertMapping{SAN: "spiffe://acme/ns/prod/sa/reporting"}))
+	require.True(t, clientCertMatches(cert, config.ClientCertMapping{Subject: "billing-worker", SAN: "worker.internal"}))
+	require.False(t, clientCertMatches(cert, config.ClientCertMapping{Subject: "billing-worker", SAN: "other.internal"}))
+	require.False(t, clientCertMatches(cert, config.ClientCertMapping{Subject: "other"}))
+	require.False(t, clientCertMatches(cert, config.ClientCertMapping{}))
+}
+
+func TestAPIKeyAuthWithClientCertificate(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	user := &service.User{ID: 7, Role: service.RoleUser, Status: service.StatusActive, Concurrency: 3}
+	apiKey := &service.APIKey{ID: 100, UserID: user.ID, Key: "cert-mapped-key", Status: service.StatusActive, User: user}
+	apiKeyRepo := &stubApiKeyRepo{
+		getByKey: func(ctx context.Context, key string) (*service.APIKey, error) {
+			if key != apiKey.Key {
+				return nil, service.ErrAPIKeyNotFound
+			}
+			clone := *apiKey
+			return &clone, nil
+		},
+	}
+
+	newRouter := func(requireCert bool) *gin.Engine {
+		cfg := &config.Config{RunMode: config.RunModeSimple}
+		cfg.Server.TLS.ClientAuth = config.ClientCertAuthConfig{
+			Enabled:     true,
+			RequireCert: requireCert,
+			Mappings:    []config.ClientCertMapping{{Subject: "billing-worker", APIKey: apiKey.Key}},
+		}
+		apiKeyService := service.NewAPIKeyService(apiKeyRepo, nil, nil, nil, nil, nil, cfg)
+		return newAuthTestRouter(apiKeyService, nil, cfg)
+	}
+	withCert := func(req *http.Request, cn string) {
+		cert := &x509.Certificate{Subject: pkix.Name{CommonName: cn}}
+		req.TLS = &tls.ConnectionState{
+			PeerCertificates: []*x509.Certificate{cert},
+			VerifiedChains:   [][]*x509.Certificate{{cert}},
+		}
+	}
+
+	t.Run("mapped_certificate_authenticates", func(t *testing.T) {
+		w := httptest.NewRecorder()
+		req := httptest.NewRequest(http.MethodGet, "/t", nil)
+		withCert(req, "billing-worker")
+		newRouter(true).ServeHTTP(w, req)
+		require.Equal(t, http.StatusOK, w.Code)
+	})
+
+	t.Run("require_cert_rejects_bearer", func(t *testing.T) {
+		w := httptest.NewRecorder()
+		req := httptest.NewRequest(http.MethodGet, "/t", nil)
+		req.Header.Set("Authorization", "Bearer "+apiKey.Key)
+		newRouter(true).ServeHTTP(w, req)
+		require.Equal(t, http.StatusUnauthorized, w.Code)
+		require.Contains(t, w.Body.String(), "CLIENT_CERT_REQUIRED")
+	})
+
+	t.Run("require_cert_rejects_unmapped_certificate", func(t *testing.T) {
+		w := httptest.NewRecorder()
+		req := httptest.NewRequest(http.MethodGet, "/t", nil)
+		withCert(req, "stranger")
+		newRouter(true).ServeHTTP(w, req)
+		require.Equal(t, http.StatusUnauthorized, w.Code)
+		require.Contains(t, w.Body.String(), "CLIENT_CERT_NOT_MAPPED")
+	})
+
+	t.Run("optional_cert_falls_back_to_bearer", func(t *testing.T) {
+		w := httptest.NewRecorder()
+		req := httptest.NewRequest(http.MethodGet, "/t", nil)
+		req.Header.Set("Authorization", "Bearer "+apiKey.Key)
+		newRouter(false).ServeHTTP(w, req)
+		require.Equal(t, http.StatusOK, w.Code)
+	})
+}
@@ -0,0 +1,22 @@
+package middleware
+
+import (
+	"github.com/Wei-Shaw/sub2api/internal/service"
+
+	"github.com/gin-gonic/gin"
+)
+
+// authenticateGatewayCredential 根据凭证解析 API Key：
+// 启用网关 JWT 认证且凭证为 JWT 时，校验签名后映射到 api_key_id 声明的 API Key（已按声明收窄限制）；
+// 否则按静态 API Key 查询。两种方式返回的 Key 都继续走相同的状态、额度、IP 与订阅检查。
+func authenticateGatewayCredential(c *gin.Context, apiKeyService *service.APIKeyService, credential string) (*service.APIKey, error) {
+	if apiKeyService.IsGatewayJWT(credential) {
+		return apiKeyService.AuthenticateGatewayJWT(c.Request.Context(), credential)
+	}
+	return apiKeyService.GetByKey(c.Request.Context(), credential)
+}
+
+// isGatewayCredentialRejected 判断认证错误是否为凭证本身被拒绝（401/403），需原样返回给客户端
+func isGatewayCredentialRejected(status int) bool {
+	return status == 401 || status == 403
+}
@@ -0,0 +1,78 @@
+package middleware
+
+import (
+	"context"
+	"log"
+	"net"
+	"net/http"
+	"strconv"
+	"time"
+
+	infraerrors "github.com/Wei-Shaw/sub2api/internal/pkg/errors"
+	"github.com/Wei-Shaw/sub2api/internal/service"
+
+	"github.com/gin-gonic/gin"
+)
+
+// ipBanRecordTimeout 记录认证失败的超时（客户端断开后仍需完成计数）
+const ipBanRecordTimeout = 2 * time.Second
+
+// IPBanGuard 认证前的 IP 准入中间件，需挂在 API Key 认证之前：
+// 已封禁或超过每分钟请求上限的 IP 直接拒绝，不再查询数据库；
+// 请求以 401 结束且未通过认证时，计为该 IP 的一次认证失败。
+func IPBanGuard(ipBanService *service.IPBanService) gin.HandlerFunc {
+	return ipBanGuard(ipBanService, func(c *gin.Context, err error) {
+		AbortWithError(c, infraerrors.Code(err), infraerrors.Reason(err), infraerrors.Message(err))
+	})
+}
+
+// IPBanGuardGoogle 与 IPBanGuard 相同，错误以 Google API 格式返回（/v1beta 路由）
+func IPBanGuardGoogle(ipBanService *service.IPBanService) gin.HandlerFunc {
+	return ipBanGuard(ipBanService, func(c *gin.Context, err error) {
+		abortWithGoogleError(c, infraerrors.Code(err), infraerrors.Message(err))
+	})
+}
+
+func ipBanGuard(ipBanService *service.IPBanService, abort func(c *gin.Context, err error)) gin.HandlerFunc {
+	if !ipBanService.Enabled() {
+		return func(c *gin.Context) { c.Next() }
+	}
+	return func(c *gin.Context) {
+		// 使用 gin 的 ClientIP（仅信任受信代理的转发头），避免伪造请求头绕过封禁或嫁祸他人
+		clientIP := canonicalIP(c.ClientIP())
+		ban, err := ipBanService.Admit(c.Request.Context(), clientIP)
+		if err != nil {
+			retryAfter := time.Minute
+			if ban != nil {
+				retryAfter = time.Until(ban.ExpiresAt)
+			}
+			if seconds := int(retryAfter.Seconds()); seconds > 0 {
+				c.Header("Retry-After", strconv.Itoa(seconds))
+			}
+			abort(c, err)
+			return
+		}
+
+		c.Next()
+
+		if c.Writer.Status() != http.StatusUnauthorized {
+			return
+		}
+		if _, authenticated := c.Get(string(ContextKeyAPIKey)); authenticated {
+			return
+		}
+		ctx, cancel := context.WithTimeout(context.WithoutCancel(c.Request.Context()), ipBanRecordTimeout)
+		defer cancel()
+		if _, err := ipBanService.RecordAuthFailure(ctx, clientIP); err != nil {
+			log.Printf("[IPBan] record auth failure failed: ip=%s err=%v", clientIP, err)
+		}
+	}
+}
+
+// canonicalIP 将 IP 统一为标准文本形式，保证计数与封禁 key 一致
+func canonicalIP(s string) string {
+	if parsed := net.ParseIP(s); parsed != nil {
+		return parsed.String()
+	}
+	return s
+}
@@ -1,56 +1,46 @@
 package middleware
 
 import (
-	"log"
+	"context"
+	"log/slog"
 	"time"
 
+	"github.com/Wei-Shaw/sub2api/internal/pkg/ctxkey"
+	"github.com/Wei-Shaw/sub2api/internal/pkg/logger"
 	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
 )
 
-// Logger 请求日志中间件
+// Logger 请求日志中间件：为每个请求分配 request_id 并挂载结构化日志关联字段，
+// 请求结束后输出一条访问日志（携带认证、选号阶段填充的 api_key_id / account_id / model）
 func Logger() gin.HandlerFunc {
 	return func(c *gin.Context) {
-		// 开始时间
 		startTime := time.Now()
 
-		// 处理请求
-		c.Next()
-
-		// 结束时间
-		endTime := time.Now()
-
-		// 执行时间
-		latency := endTime.Sub(startTime)
-
-		// 请求方法
-		method := c.Request.Method
-
-		// 请求路径
-		path := c.Request.URL.Path
-
-		// 状态码
-		statusCode := c.Writer.Status()
-
-		// 客户端IP
-		clientIP := c.ClientIP()
-
-		// 协议版本
-		protocol := c.Request.Proto
+		// request_id 与 Ops 监控使用的 client_request_id 保持一致，便于交叉检索
+		ctx := c.Request.Context()
+		requestID, _ := ctx.Value(ctxkey.ClientRequestID).(string)
+		if requestID == "" {
+			requestID = uuid.New().String()
+			ctx = context.WithValue(ctx, ctxkey.ClientRequestID, requestID)
+		}
+		ctx = logger.WithRequestID(ctx, requestID)
+		c.Request = c.Request.WithContext(ctx)
 
-		// 日志格式: [时间] 状态码 | 延迟 | IP | 协议 | 方法 路径
-		log.Printf("[GIN] %v | %3d | %13v | %15s | %-6s | %-7s %s",
-			endTime.Format("2006/01/02 - 15:04:05"),
-			statusCode,
-			latency,
-			clientIP,
-			protocol,
-			method,
-			path,
-		)
+		c.Next()
 
+		attrs := []slog.Attr{
+			slog.Int("status", c.Writer.Status()),
+			slog.String("method", c.Request.Method),
+			slog.String("path", c.Request.URL.Path),
+			slog.Duration("latency", time.Since(startTime)),
+			slog.String("client_ip", c.ClientIP()),
+			slog.String("protocol", c.Request.Proto),
+		}
 		// 如果有错误，额外记录错误信息
 		if len(c.Errors) > 0 {
-			log.Printf("[GIN] Errors: %v", c.Errors.String())
+			attrs = append(attrs, slog.String("errors", c.Errors.String()))
 		}
+		slog.LogAttrs(ctx, slog.LevelInfo, "http request", attrs...)
 	}
 }
@@ -0,0 +1,50 @@
+package middleware
+
+import (
+	"bytes"
+	"encoding/json"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/Wei-Shaw/sub2api/internal/pkg/ctxkey"
+	"github.com/Wei-Shaw/sub2api/internal/pkg/logger"
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/require"
+)
+
+func TestLogger_AccessLogCarriesRequestFields(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	var buf bytes.Buffer
+	prev := slog.Default()
+	logger.Init(&buf, logger.Options{Format: logger.FormatJSON, Level: slog.LevelInfo})
+	t.Cleanup(func() { slog.SetDefault(prev) })
+
+	var requestID string
+	r := gin.New()
+	r.Use(Logger())
+	r.GET("/v1/messages", func(c *gin.Context) {
+		requestID, _ = c.Request.Context().Value(ctxkey.ClientRequestID).(string)
+		logger.SetAPIKeyID(c.Request.Context(), 11)
+		logger.SetAccountID(c.Request.Context(), 22)
+		logger.SetModel(c.Request.Context(), "gpt-5")
+		c.Status(http.StatusAccepted)
+	})
+
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/v1/messages", nil))
+	require.Equal(t, http.StatusAccepted, w.Code)
+	require.NotEmpty(t, requestID)
+
+	var rec map[string]any
+	require.NoError(t, json.Unmarshal(buf.Bytes(), &rec))
+	require.Equal(t, "http request", rec["msg"])
+	require.Equal(t, requestID, rec["request_id"])
+	require.EqualValues(t, 11, rec["api_key_id"])
+	require.EqualValues(t, 22, rec["account_id"])
+	require.Equal(t, "gpt-5", rec["model"])
+	require.EqualValues(t, http.StatusAccepted, rec["status"])
+	require.Equal(t, "/v1/messages", rec["path"])
+}
@@ -21,6 +21,8 @@ const (
 	ContextKeySubscription ContextKey = "subscription"
 	// ContextKeyForcePlatform 强制平台（用于 /antigravity 路由）
 	ContextKeyForcePlatform ContextKey = "force_platform"
+	// ContextKeyDeniedAPIKey 因 IP 限制被拒绝的 API Key（供运维错误日志归属，不代表认证通过）
+	ContextKeyDeniedAPIKey ContextKey = "denied_api_key"
 )
 
 // ForcePlatform 返回设置强制平台的中间件
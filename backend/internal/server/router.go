@@ -14,6 +14,61 @@ import (
 	"github.com/redis/go-redis/v9"
 )
 
+// RouteScopes 单个引擎注册的路由范围
+type RouteScopes struct {
+	Gateway bool
+	Admin   bool
+	User    bool
+}
+
+// AllRoutes 注册全部路由
+var AllRoutes = RouteScopes{Gateway: true, Admin: true, User: true}
+
+// ParseRouteScopes 解析监听器配置的路由范围，为空视为全部路由
+func ParseRouteScopes(routes []string) RouteScopes {
+	if len(routes) == 0 {
+		return AllRoutes
+	}
+	var scopes RouteScopes
+	for _, route := range routes {
+		switch route {
+		case config.ListenerRouteAll:
+			return AllRoutes
+		case config.ListenerRouteGateway:
+			scopes.Gateway = true
+		case config.ListenerRouteAdmin:
+			scopes.Admin = true
+		case config.ListenerRouteUser:
+			scopes.User = true
+		}
+	}
+	return scopes
+}
+
+// serveWeb 是否提供前端页面及登录认证接口（管理后台与用户端共用）
+func (s RouteScopes) serveWeb() bool {
+	return s.Admin || s.User
+}
+
+// newFrontendMiddleware 创建嵌入式前端中间件；多个监听器共享同一实例，保证设置变更时缓存统一失效
+func newFrontendMiddleware(cfg *config.Config, settingService *service.SettingService) gin.HandlerFunc {
+	if !web.HasEmbeddedFrontend() {
+		return nil
+	}
+	opts := web.FrontendOptions{
+		APIBasePath:      cfg.Frontend.APIBasePath,
+		AssetCacheMaxAge: cfg.Frontend.AssetCacheMaxAge,
+	}
+	frontendServer, err := web.NewFrontendServer(settingService, opts)
+	if err != nil {
+		log.Printf("Warning: Failed to create frontend server with settings injection: %v, using legacy mode", err)
+		return web.ServeEmbeddedFrontend(opts)
+	}
+	// Register cache invalidation callback
+	settingService.SetOnUpdateCallback(frontendServer.InvalidateCache)
+	return frontendServer.Middleware()
+}
+
 // SetupRouter 配置路由器中间件和路由
 func SetupRouter(
 	r *gin.Engine,
@@ -24,35 +79,33 @@ func SetupRouter(
 	apiKeyService *service.APIKeyService,
 	subscriptionService *service.SubscriptionService,
 	opsService *service.OpsService,
-	settingService *service.SettingService,
+	auditLogService *service.AuditLogService,
+	ipBanService *service.IPBanService,
+	frontend gin.HandlerFunc,
 	cfg *config.Config,
 	redisClient *redis.Client,
+	scopes RouteScopes,
 ) *gin.Engine {
 	// 应用中间件
 	r.Use(middleware2.Logger())
+	if cfg.Tracing.Enabled {
+		r.Use(middleware2.Tracing())
+	}
 	r.Use(middleware2.CORS(cfg.CORS))
 	r.Use(middleware2.SecurityHeaders(cfg.Security.CSP))
 
 	// Serve embedded frontend with settings injection if available
-	if web.HasEmbeddedFrontend() {
-		frontendServer, err := web.NewFrontendServer(settingService)
-		if err != nil {
-			log.Printf("Warning: Failed to create frontend server with settings injection: %v, using legacy mode", err)
-			r.Use(web.ServeEmbeddedFrontend())
-		} else {
-			// Register cache invalidation callback
-			settingService.SetOnUpdateCallback(frontendServer.InvalidateCache)
-			r.Use(frontendServer.Middleware())
-		}
+	if frontend != nil && scopes.serveWeb() {
+		r.Use(frontend)
 	}
 
 	// 注册路由
-	registerRoutes(r, handlers, jwtAuth, adminAuth, apiKeyAuth, apiKeyService, subscriptionService, opsService, cfg, redisClient)
+	registerRoutes(r, handlers, jwtAuth, adminAuth, apiKeyAuth, apiKeyService, subscriptionService, opsService, auditLogService, ipBanService, cfg, redisClient, scopes)
 
 	return r
 }
 
-// registerRoutes 注册所有 HTTP 路由
+// registerRoutes 注册 scopes 范围内的 HTTP 路由
 func registerRoutes(
 	r *gin.Engine,
 	h *handler.Handlers,
@@ -62,18 +115,30 @@ func registerRoutes(
 	apiKeyService *service.APIKeyService,
 	subscriptionService *service.SubscriptionService,
 	opsService *service.OpsService,
+	auditLogService *service.AuditLogService,
+	ipBanService *service.IPBanService,
 	cfg *config.Config,
 	redisClient *redis.Client,
+	scopes RouteScopes,
 ) {
 	// 通用路由（健康检查、状态等）
-	routes.RegisterCommonRoutes(r)
+	routes.RegisterCommonRoutes(r, h)
 
 	// API v1
 	v1 := r.Group("/api/v1")
 
 	// 注册各模块路由
-	routes.RegisterAuthRoutes(v1, h, jwtAuth, redisClient)
-	routes.RegisterUserRoutes(v1, h, jwtAuth)
-	routes.RegisterAdminRoutes(v1, h, adminAuth)
-	routes.RegisterGatewayRoutes(r, h, apiKeyAuth, apiKeyService, subscriptionService, opsService, cfg)
+	if scopes.serveWeb() {
+		routes.RegisterAuthRoutes(v1, h, jwtAuth, redisClient)
+	}
+	if scopes.User {
+		routes.RegisterUserRoutes(v1, h, jwtAuth)
+		routes.RegisterBillingRoutes(v1, h)
+	}
+	if scopes.Admin {
+		routes.RegisterAdminRoutes(v1, h, adminAuth)
+	}
+	if scopes.Gateway {
+		routes.RegisterGatewayRoutes(r, h, apiKeyAuth, apiKeyService, subscriptionService, opsService, auditLogService, ipBanService, cfg)
+	}
 }
@@ -15,7 +15,8 @@ func RegisterAdminRoutes(
 	adminAuth middleware.AdminAuthMiddleware,
 ) {
 	admin := v1.Group("/admin")
-	admin.Use(gin.HandlerFunc(adminAuth))
+	// 操作审计需在鉴权之后执行，以便记录操作人
+	admin.Use(gin.HandlerFunc(adminAuth), h.Admin.ActionLog.Middleware())
 	{
 		// 仪表盘
 		registerDashboardRoutes(admin, h)
@@ -70,6 +71,21 @@ func RegisterAdminRoutes(
 
 		// 错误透传规则管理
 		registerErrorPassthroughRoutes(admin, h)
+
+		// 模型价格表管理
+		registerModelPriceRoutes(admin, h)
+
+		// 请求审计日志
+		registerAuditLogRoutes(admin, h)
+
+		// 回收站（已删除账号/API Key 的恢复）
+		registerTrashRoutes(admin, h)
+
+		// 管理后台操作审计
+		registerAdminActionLogRoutes(admin, h)
+
+		// 认证前 IP 封禁
+		registerIPBanRoutes(admin, h)
 	}
 }
 
@@ -78,8 +94,13 @@ func registerOpsRoutes(admin *gin.RouterGroup, h *handler.Handlers) {
 	{
 		// Realtime ops signals
 		ops.GET("/concurrency", h.Admin.Ops.GetConcurrencyStats)
+		ops.GET("/concurrency/reconcile", h.Admin.Ops.GetConcurrencyReconcileResult)
 		ops.GET("/user-concurrency", h.Admin.Ops.GetUserConcurrencyStats)
 		ops.GET("/account-availability", h.Admin.Ops.GetAccountAvailability)
+		ops.GET("/account-worker-pools", h.Admin.Ops.GetAccountWorkerPoolStats)
+		ops.GET("/memory-guard", h.Admin.Ops.GetMemoryGuardStats)
+		ops.GET("/stream-abuse", h.Admin.Ops.GetStreamAbuseFlags)
+		ops.GET("/usage-anomalies", h.Admin.Ops.GetUsageAnomalyFlags)
 		ops.GET("/realtime-traffic", h.Admin.Ops.GetRealtimeTrafficSummary)
 
 		// Alerts (rules + events)
@@ -92,6 +113,18 @@ func registerOpsRoutes(admin *gin.RouterGroup, h *handler.Handlers) {
 		ops.PUT("/alert-events/:id/status", h.Admin.Ops.UpdateAlertEventStatus)
 		ops.POST("/alert-silences", h.Admin.Ops.CreateAlertSilence)
 
+		// SLOs / error budgets (DB-backed definitions)
+		ops.GET("/slos", h.Admin.Ops.ListSLOs)
+		ops.POST("/slos", h.Admin.Ops.CreateSLO)
+		ops.GET("/slos/status", h.Admin.Ops.GetSLOStatuses)
+		ops.PUT("/slos/:id", h.Admin.Ops.UpdateSLO)
+		ops.DELETE("/slos/:id", h.Admin.Ops.DeleteSLO)
+		ops.GET("/slos/:id/status", h.Admin.Ops.GetSLOStatus)
+
+		// Slow requests (ops.slow_request thresholds)
+		ops.GET("/slow-requests", h.Admin.Ops.ListSlowRequests)
+		ops.GET("/slow-requests/summary", h.Admin.Ops.GetSlowRequestSummary)
+
 		// Email notification config (DB-backed)
 		ops.GET("/email-notification/config", h.Admin.Ops.GetEmailNotificationConfig)
 		ops.PUT("/email-notification/config", h.Admin.Ops.UpdateEmailNotificationConfig)
@@ -118,8 +151,12 @@ func registerOpsRoutes(admin *gin.RouterGroup, h *handler.Handlers) {
 		ws := ops.Group("/ws")
 		{
 			ws.GET("/qps", h.Admin.Ops.QPSWSHandler)
+			ws.GET("/traffic", h.Admin.Ops.LiveTrafficWSHandler)
 		}
 
+		// Live request feed (SSE)
+		ops.GET("/traffic/stream", h.Admin.Ops.LiveTrafficSSEHandler)
+
 		// Error logs (legacy)
 		ops.GET("/errors", h.Admin.Ops.GetErrorLogs)
 		ops.GET("/errors/:id", h.Admin.Ops.GetErrorLogByID)
@@ -144,12 +181,18 @@ func registerOpsRoutes(admin *gin.RouterGroup, h *handler.Handlers) {
 		// Request drilldown (success + error)
 		ops.GET("/requests", h.Admin.Ops.ListRequestDetails)
 
+		// Developer mode: diff a pooled account against a reference upstream account
+		ops.POST("/reference-diff", h.Admin.Ops.CompareWithReference)
+
 		// Dashboard (vNext - raw path for MVP)
 		ops.GET("/dashboard/overview", h.Admin.Ops.GetDashboardOverview)
 		ops.GET("/dashboard/throughput-trend", h.Admin.Ops.GetDashboardThroughputTrend)
 		ops.GET("/dashboard/latency-histogram", h.Admin.Ops.GetDashboardLatencyHistogram)
+		ops.GET("/dashboard/account-latency", h.Admin.Ops.GetAccountLatencyHistograms)
 		ops.GET("/dashboard/error-trend", h.Admin.Ops.GetDashboardErrorTrend)
 		ops.GET("/dashboard/error-distribution", h.Admin.Ops.GetDashboardErrorDistribution)
+		ops.GET("/dashboard/group-phases", h.Admin.Ops.GetGroupPhaseSummary)
+		ops.GET("/dashboard/group-phase-trend", h.Admin.Ops.GetGroupPhaseTrend)
 	}
 }
 
@@ -178,12 +221,18 @@ func registerUserManagementRoutes(admin *gin.RouterGroup, h *handler.Handlers) {
 		users.DELETE("/:id", h.Admin.User.Delete)
 		users.POST("/:id/balance", h.Admin.User.UpdateBalance)
 		users.GET("/:id/api-keys", h.Admin.User.GetUserAPIKeys)
+		users.POST("/:id/api-keys/batch", h.Admin.User.BatchCreateAPIKeys)
 		users.GET("/:id/usage", h.Admin.User.GetUserUsage)
 		users.GET("/:id/balance-history", h.Admin.User.GetBalanceHistory)
 
 		// User attribute values
 		users.GET("/:id/attributes", h.Admin.UserAttribute.GetUserAttributes)
 		users.PUT("/:id/attributes", h.Admin.UserAttribute.UpdateUserAttributes)
+
+		// 用户消费软/硬上限覆盖
+		users.GET("/:id/spend-cap", h.Admin.SpendCap.Get)
+		users.PUT("/:id/spend-cap", h.Admin.SpendCap.Update)
+		users.DELETE("/:id/spend-cap", h.Admin.SpendCap.Delete)
 	}
 }
 
@@ -197,6 +246,8 @@ func registerGroupRoutes(admin *gin.RouterGroup, h *handler.Handlers) {
 		groups.POST("", h.Admin.Group.Create)
 		groups.PUT("/:id", h.Admin.Group.Update)
 		groups.DELETE("/:id", h.Admin.Group.Delete)
+		groups.POST("/:id/clone", h.Admin.Group.Clone)
+		groups.POST("/:id/maintenance", h.Admin.Group.SetMaintenance)
 		groups.GET("/:id/stats", h.Admin.Group.GetStats)
 		groups.GET("/:id/api-keys", h.Admin.Group.GetGroupAPIKeys)
 	}
@@ -214,16 +265,21 @@ func registerAccountRoutes(admin *gin.RouterGroup, h *handler.Handlers) {
 		accounts.DELETE("/:id", h.Admin.Account.Delete)
 		accounts.POST("/:id/test", h.Admin.Account.Test)
 		accounts.POST("/:id/refresh", h.Admin.Account.Refresh)
+		accounts.POST("/:id/rotate-credentials", h.Admin.Account.RotateCredentials)
 		accounts.POST("/:id/refresh-tier", h.Admin.Account.RefreshTier)
 		accounts.GET("/:id/stats", h.Admin.Account.GetStats)
 		accounts.POST("/:id/clear-error", h.Admin.Account.ClearError)
 		accounts.GET("/:id/usage", h.Admin.Account.GetUsage)
 		accounts.GET("/:id/today-stats", h.Admin.Account.GetTodayStats)
+		accounts.GET("/:id/realtime-stats", h.Admin.Ops.GetAccountRealtimeStats)
+		accounts.POST("/:id/dry-run", h.Admin.Ops.DryRunAccount)
 		accounts.POST("/:id/clear-rate-limit", h.Admin.Account.ClearRateLimit)
 		accounts.GET("/:id/temp-unschedulable", h.Admin.Account.GetTempUnschedulable)
 		accounts.DELETE("/:id/temp-unschedulable", h.Admin.Account.ClearTempUnschedulable)
 		accounts.POST("/:id/schedulable", h.Admin.Account.SetSchedulable)
+		accounts.POST("/:id/maintenance", h.Admin.Account.SetMaintenance)
 		accounts.GET("/:id/models", h.Admin.Account.GetAvailableModels)
+		accounts.POST("/:id/models/discover", h.Admin.Account.DiscoverModels)
 		accounts.POST("/batch", h.Admin.Account.BatchCreate)
 		accounts.GET("/data", h.Admin.Account.ExportData)
 		accounts.POST("/data", h.Admin.Account.ImportData)
@@ -344,6 +400,24 @@ func registerSettingsRoutes(admin *gin.RouterGroup, h *handler.Handlers) {
 		// 流超时处理配置
 		adminSettings.GET("/stream-timeout", h.Admin.Setting.GetStreamTimeoutSettings)
 		adminSettings.PUT("/stream-timeout", h.Admin.Setting.UpdateStreamTimeoutSettings)
+		// 模型别名/改写规则
+		adminSettings.GET("/model-aliases", h.Admin.Setting.GetModelAliasSettings)
+		adminSettings.PUT("/model-aliases", h.Admin.Setting.UpdateModelAliasSettings)
+		// 虚拟模型（回退链）配置
+		adminSettings.GET("/virtual-models", h.Admin.Setting.GetVirtualModelSettings)
+		adminSettings.PUT("/virtual-models", h.Admin.Setting.UpdateVirtualModelSettings)
+		// 请求预处理：剔除客户端随机字段
+		adminSettings.GET("/request-strip", h.Admin.Setting.GetRequestStripSettings)
+		adminSettings.PUT("/request-strip", h.Admin.Setting.UpdateRequestStripSettings)
+		// 请求净化：转发前剔除或拒绝危险/不支持的字段与工具
+		adminSettings.GET("/request-sanitize", h.Admin.Setting.GetRequestSanitizeSettings)
+		adminSettings.PUT("/request-sanitize", h.Admin.Setting.UpdateRequestSanitizeSettings)
+		// 预算阈值告警
+		adminSettings.GET("/budget-alerts", h.Admin.Setting.GetBudgetAlertSettings)
+		adminSettings.PUT("/budget-alerts", h.Admin.Setting.UpdateBudgetAlertSettings)
+		// 上游错误映射（故障转移耗尽后返回给客户端的错误）
+		adminSettings.GET("/upstream-error-mappings", h.Admin.Setting.GetUpstreamErrorMappingSettings)
+		adminSettings.PUT("/upstream-error-mappings", h.Admin.Setting.UpdateUpstreamErrorMappingSettings)
 	}
 }
 
@@ -355,6 +429,7 @@ func registerSystemRoutes(admin *gin.RouterGroup, h *handler.Handlers) {
 		system.POST("/update", h.Admin.System.PerformUpdate)
 		system.POST("/rollback", h.Admin.System.Rollback)
 		system.POST("/restart", h.Admin.System.RestartService)
+		system.POST("/reload-config", h.Admin.System.ReloadConfig)
 	}
 }
 
@@ -382,6 +457,7 @@ func registerUsageRoutes(admin *gin.RouterGroup, h *handler.Handlers) {
 	{
 		usage.GET("", h.Admin.Usage.List)
 		usage.GET("/stats", h.Admin.Usage.Stats)
+		usage.GET("/export", h.Admin.Usage.Export)
 		usage.GET("/search-users", h.Admin.Usage.SearchUsers)
 		usage.GET("/search-api-keys", h.Admin.Usage.SearchAPIKeys)
 		usage.GET("/cleanup-tasks", h.Admin.Usage.ListCleanupTasks)
@@ -408,7 +484,54 @@ func registerErrorPassthroughRoutes(admin *gin.RouterGroup, h *handler.Handlers)
 		rules.GET("", h.Admin.ErrorPassthrough.List)
 		rules.GET("/:id", h.Admin.ErrorPassthrough.GetByID)
 		rules.POST("", h.Admin.ErrorPassthrough.Create)
+		rules.POST("/test", h.Admin.ErrorPassthrough.Test)
 		rules.PUT("/:id", h.Admin.ErrorPassthrough.Update)
 		rules.DELETE("/:id", h.Admin.ErrorPassthrough.Delete)
 	}
 }
+
+func registerModelPriceRoutes(admin *gin.RouterGroup, h *handler.Handlers) {
+	prices := admin.Group("/model-prices")
+	{
+		prices.GET("", h.Admin.ModelPrice.List)
+		prices.GET("/:id", h.Admin.ModelPrice.GetByID)
+		prices.POST("", h.Admin.ModelPrice.Create)
+		prices.PUT("/:id", h.Admin.ModelPrice.Update)
+		prices.DELETE("/:id", h.Admin.ModelPrice.Delete)
+	}
+}
+
+func registerAuditLogRoutes(admin *gin.RouterGroup, h *handler.Handlers) {
+	logs := admin.Group("/audit-logs")
+	{
+		logs.GET("", h.Admin.AuditLog.List)
+		logs.GET("/:id", h.Admin.AuditLog.GetByID)
+		logs.DELETE("", h.Admin.AuditLog.Purge)
+	}
+}
+
+func registerTrashRoutes(admin *gin.RouterGroup, h *handler.Handlers) {
+	trash := admin.Group("/trash")
+	{
+		trash.GET("/accounts", h.Admin.Trash.ListAccounts)
+		trash.POST("/accounts/:id/restore", h.Admin.Trash.RestoreAccount)
+		trash.GET("/api-keys", h.Admin.Trash.ListAPIKeys)
+		trash.POST("/api-keys/:id/restore", h.Admin.Trash.RestoreAPIKey)
+	}
+}
+
+func registerAdminActionLogRoutes(admin *gin.RouterGroup, h *handler.Handlers) {
+	logs := admin.Group("/action-logs")
+	{
+		logs.GET("", h.Admin.ActionLog.List)
+		logs.GET("/:id", h.Admin.ActionLog.GetByID)
+	}
+}
+
+func registerIPBanRoutes(admin *gin.RouterGroup, h *handler.Handlers) {
+	bans := admin.Group("/ip-bans")
+	{
+		bans.GET("", h.Admin.IPBan.List)
+		bans.DELETE("/:ip", h.Admin.IPBan.Delete)
+	}
+}
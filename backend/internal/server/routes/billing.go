@@ -0,0 +1,12 @@
+package routes
+
+import (
+	"github.com/Wei-Shaw/sub2api/internal/handler"
+
+	"github.com/gin-gonic/gin"
+)
+
+// RegisterBillingRoutes 注册外部计费系统回调路由（无需登录，由签名校验保证来源）
+func RegisterBillingRoutes(v1 *gin.RouterGroup, h *handler.Handlers) {
+	v1.POST("/stripe/webhook", h.Stripe.Webhook)
+}
@@ -3,15 +3,23 @@ package routes
 import (
 	"net/http"
 
+	"github.com/Wei-Shaw/sub2api/internal/handler"
 	"github.com/gin-gonic/gin"
 )
 
 // RegisterCommonRoutes 注册通用路由（健康检查、状态等）
-func RegisterCommonRoutes(r *gin.Engine) {
-	// 健康检查
-	r.GET("/health", func(c *gin.Context) {
-		c.JSON(http.StatusOK, gin.H{"status": "ok"})
-	})
+func RegisterCommonRoutes(r *gin.Engine, h *handler.Handlers) {
+	// 健康检查（优雅关闭排空期间返回 503）
+	r.GET("/health", h.Health.Check)
+
+	// 扩缩容信号（等待队列深度、槽位饱和度、排队拒绝率），供 HPA/KEDA 外部指标使用
+	r.GET("/health/scaling", h.Scaling.Signal)
+
+	// Prometheus 指标（metrics.enabled 关闭时返回 404）
+	r.GET("/metrics", h.Metrics.Scrape)
+
+	// OpenAPI 文档（?download=1 以附件形式下载），用于生成类型化客户端 SDK
+	r.GET("/openapi.json", h.OpenAPI.Spec(r))
 
 	// Claude Code 遥测日志（忽略，直接返回200）
 	r.POST("/api/event_logging/batch", func(c *gin.Context) {
@@ -17,21 +17,34 @@ func RegisterGatewayRoutes(
 	apiKeyService *service.APIKeyService,
 	subscriptionService *service.SubscriptionService,
 	opsService *service.OpsService,
+	auditLogService *service.AuditLogService,
+	ipBanService *service.IPBanService,
 	cfg *config.Config,
 ) {
 	bodyLimit := middleware.RequestBodyLimit(cfg.Gateway.MaxBodySize)
 	clientRequestID := middleware.ClientRequestID()
 	opsErrorLogger := handler.OpsErrorLoggerMiddleware(opsService)
+	gatewayMetrics := handler.GatewayMetricsMiddleware()
+	auditLogger := handler.AuditLogMiddleware(auditLogService)
+	usageAnomaly := handler.UsageAnomalyMiddleware(opsService.UsageAnomaly())
+	// 认证前 IP 准入：封禁/限流的 IP 在查询 API Key 之前即被拒绝
+	ipGuard := middleware.IPBanGuard(ipBanService)
+	ipGuardGoogle := middleware.IPBanGuardGoogle(ipBanService)
 
 	// API网关（Claude API兼容）
 	gateway := r.Group("/v1")
+	gateway.Use(ipGuard)
 	gateway.Use(bodyLimit)
 	gateway.Use(clientRequestID)
 	gateway.Use(opsErrorLogger)
+	gateway.Use(gatewayMetrics)
+	gateway.Use(auditLogger)
+	gateway.Use(usageAnomaly)
 	gateway.Use(gin.HandlerFunc(apiKeyAuth))
 	{
 		gateway.POST("/messages", h.Gateway.Messages)
 		gateway.POST("/messages/count_tokens", h.Gateway.CountTokens)
+		gateway.POST("/token-count", h.Gateway.TokenCount)
 		gateway.GET("/models", h.Gateway.Models)
 		gateway.GET("/usage", h.Gateway.Usage)
 		// OpenAI 兼容 API
@@ -41,9 +54,13 @@ func RegisterGatewayRoutes(
 
 	// Gemini 原生 API 兼容层（Gemini SDK/CLI 直连）
 	gemini := r.Group("/v1beta")
+	gemini.Use(ipGuardGoogle)
 	gemini.Use(bodyLimit)
 	gemini.Use(clientRequestID)
 	gemini.Use(opsErrorLogger)
+	gemini.Use(gatewayMetrics)
+	gemini.Use(auditLogger)
+	gemini.Use(usageAnomaly)
 	gemini.Use(middleware.APIKeyAuthWithSubscriptionGoogle(apiKeyService, subscriptionService, cfg))
 	{
 		gemini.GET("/models", h.Gateway.GeminiV1BetaListModels)
@@ -53,17 +70,21 @@ func RegisterGatewayRoutes(
 	}
 
 	// OpenAI 兼容 API（不带 v1 前缀的别名）
-	r.POST("/responses", bodyLimit, clientRequestID, opsErrorLogger, gin.HandlerFunc(apiKeyAuth), h.OpenAIGateway.Responses)
-	r.POST("/chat/completions", bodyLimit, clientRequestID, opsErrorLogger, gin.HandlerFunc(apiKeyAuth), h.OpenAIGateway.ChatCompletions)
+	r.POST("/responses", ipGuard, bodyLimit, clientRequestID, opsErrorLogger, gatewayMetrics, auditLogger, usageAnomaly, gin.HandlerFunc(apiKeyAuth), h.OpenAIGateway.Responses)
+	r.POST("/chat/completions", ipGuard, bodyLimit, clientRequestID, opsErrorLogger, gatewayMetrics, auditLogger, usageAnomaly, gin.HandlerFunc(apiKeyAuth), h.OpenAIGateway.ChatCompletions)
 
 	// Antigravity 模型列表
-	r.GET("/antigravity/models", gin.HandlerFunc(apiKeyAuth), h.Gateway.AntigravityModels)
+	r.GET("/antigravity/models", ipGuard, gin.HandlerFunc(apiKeyAuth), h.Gateway.AntigravityModels)
 
 	// Antigravity 专用路由（仅使用 antigravity 账户，不混合调度）
 	antigravityV1 := r.Group("/antigravity/v1")
+	antigravityV1.Use(ipGuard)
 	antigravityV1.Use(bodyLimit)
 	antigravityV1.Use(clientRequestID)
 	antigravityV1.Use(opsErrorLogger)
+	antigravityV1.Use(gatewayMetrics)
+	antigravityV1.Use(auditLogger)
+	antigravityV1.Use(usageAnomaly)
 	antigravityV1.Use(middleware.ForcePlatform(service.PlatformAntigravity))
 	antigravityV1.Use(gin.HandlerFunc(apiKeyAuth))
 	{
@@ -74,9 +95,13 @@ func RegisterGatewayRoutes(
 	}
 
 	antigravityV1Beta := r.Group("/antigravity/v1beta")
+	antigravityV1Beta.Use(ipGuardGoogle)
 	antigravityV1Beta.Use(bodyLimit)
 	antigravityV1Beta.Use(clientRequestID)
 	antigravityV1Beta.Use(opsErrorLogger)
+	antigravityV1Beta.Use(gatewayMetrics)
+	antigravityV1Beta.Use(auditLogger)
+	antigravityV1Beta.Use(usageAnomaly)
 	antigravityV1Beta.Use(middleware.ForcePlatform(service.PlatformAntigravity))
 	antigravityV1Beta.Use(middleware.APIKeyAuthWithSubscriptionGoogle(apiKeyService, subscriptionService, cfg))
 	{
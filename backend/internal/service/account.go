@@ -162,7 +162,8 @@ func (a *Account) GetCredential(key string) string {
 	// 支持多种类型（兼容历史数据中 expires_at 等字段可能是数字或字符串）
 	switch val := v.(type) {
 	case string:
-		return val
+		// 启用静态加密后敏感字段以密文存储，在使用时解密
+		return decryptCredentialValue(key, val)
 	case json.Number:
 		// GORM datatypes.JSONMap 使用 UseNumber() 解析，数字类型为 json.Number
 		return val.String()
@@ -384,7 +385,16 @@ func (a *Account) GetModelMapping() map[string]string {
 
 // IsModelSupported 检查模型是否在 model_mapping 中（支持通配符）
 // 如果未配置 mapping，返回 true（允许所有模型）
+// 若账号已自动发现上游模型列表，映射后的模型还必须在该列表中
 func (a *Account) IsModelSupported(requestedModel string) bool {
+	if !a.isModelInMapping(requestedModel) {
+		return false
+	}
+	return a.IsModelDiscovered(a.GetMappedModel(requestedModel))
+}
+
+// isModelInMapping 检查模型是否命中 model_mapping（精确或通配符），未配置 mapping 时返回 true
+func (a *Account) isModelInMapping(requestedModel string) bool {
 	mapping := a.GetModelMapping()
 	if len(mapping) == 0 {
 		return true // 无映射 = 允许所有
@@ -456,6 +466,19 @@ func (a *Account) GetExtraString(key string) string {
 	return ""
 }
 
+// GetRegion 返回账号声明的区域（extra.region），未配置时为空
+func (a *Account) GetRegion() string {
+	return strings.TrimSpace(a.GetExtraString("region"))
+}
+
+// GetTier 返回账号在分组内的调度层级（extra.tier），未配置时视为 primary
+func (a *Account) GetTier() int {
+	if a.Extra == nil {
+		return AccountTierPrimary
+	}
+	return parseAccountTier(a.Extra["tier"])
+}
+
 func (a *Account) GetClaudeUserID() string {
 	if v := strings.TrimSpace(a.GetExtraString("claude_user_id")); v != "" {
 		return v
@@ -0,0 +1,95 @@
+package service
+
+import (
+	mathrand "math/rand"
+	"time"
+)
+
+// 金丝雀（灰度）放量：新增或重新启用的账号可配置 extra.canary_percent（1-99），
+// 调度时仅有对应比例的请求会考虑该账号；AccountCanaryService 在错误率确认健康后自动转正。
+const (
+	accountCanaryPercentKey      = "canary_percent"
+	accountCanaryStartedAtKey    = "canary_started_at"
+	accountCanaryMinRequestsKey  = "canary_min_requests"
+	accountCanaryMaxErrorRateKey = "canary_max_error_rate"
+	accountCanaryPromotedAtKey   = "canary_promoted_at"
+
+	defaultCanaryMinRequests  = 50
+	defaultCanaryMaxErrorRate = 0.05
+)
+
+// GetCanaryPercent 返回账号的灰度放量比例（1-99），0 表示未处于灰度阶段
+func (a *Account) GetCanaryPercent() int {
+	if a.Extra == nil {
+		return 0
+	}
+	percent := parseExtraInt(a.Extra[accountCanaryPercentKey])
+	if percent <= 0 || percent >= 100 {
+		return 0
+	}
+	return percent
+}
+
+// IsCanary 判断账号是否处于灰度放量阶段
+func (a *Account) IsCanary() bool {
+	return a.GetCanaryPercent() > 0
+}
+
+// GetCanaryStartedAt 返回灰度开始时间，未配置或格式错误时返回 nil
+func (a *Account) GetCanaryStartedAt() *time.Time {
+	raw := a.GetExtraString(accountCanaryStartedAtKey)
+	if raw == "" {
+		return nil
+	}
+	t, err := time.Parse(time.RFC3339, raw)
+	if err != nil {
+		return nil
+	}
+	return &t
+}
+
+// GetCanaryMinRequests 返回自动转正所需的最少请求数
+func (a *Account) GetCanaryMinRequests() int64 {
+	if a.Extra != nil {
+		if v := parseExtraInt(a.Extra[accountCanaryMinRequestsKey]); v > 0 {
+			return int64(v)
+		}
+	}
+	return defaultCanaryMinRequests
+}
+
+// GetCanaryMaxErrorRate 返回自动转正允许的最大错误率（0-1）
+func (a *Account) GetCanaryMaxErrorRate() float64 {
+	if a.Extra != nil {
+		if v := parseExtraFloat64(a.Extra[accountCanaryMaxErrorRateKey]); v > 0 && v <= 1 {
+			return v
+		}
+	}
+	return defaultCanaryMaxErrorRate
+}
+
+// filterCanaryAccounts 按灰度比例对候选账号抽样：灰度账号仅以 canary_percent% 的概率参与本次调度。
+// 若抽样后无可用账号，则回退为原列表，避免灰度配置导致请求无账号可用。
+func filterCanaryAccounts(accounts []Account) []Account {
+	hasCanary := false
+	for i := range accounts {
+		if accounts[i].IsCanary() {
+			hasCanary = true
+			break
+		}
+	}
+	if !hasCanary {
+		return accounts
+	}
+	result := make([]Account, 0, len(accounts))
+	for i := range accounts {
+		if percent := accounts[i].GetCanaryPercent(); percent > 0 && mathrand.Intn(100) >= percent {
+			continue
+		}
+		result = append(result, accounts[i])
+	}
+	if len(result) == 0 {
+		return accounts
+	}
+	return result
+}
@@ -0,0 +1,146 @@
+package service
+
+import (
+	"context"
+	"log"
+	"sync"
+	"time"
+)
+
+// AccountCanaryService periodically evaluates canary accounts and promotes them
+// to full traffic once enough requests have succeeded with a healthy error rate.
+type AccountCanaryService struct {
+	accountRepo  AccountRepository
+	usageLogRepo UsageLogRepository
+	opsRepo      OpsRepository
+	interval     time.Duration
+	stopCh       chan struct{}
+	stopOnce     sync.Once
+	wg           sync.WaitGroup
+}
+
+func NewAccountCanaryService(accountRepo AccountRepository, usageLogRepo UsageLogRepository, opsRepo OpsRepository, interval time.Duration) *AccountCanaryService {
+	return &AccountCanaryService{
+		accountRepo:  accountRepo,
+		usageLogRepo: usageLogRepo,
+		opsRepo:      opsRepo,
+		interval:     interval,
+		stopCh:       make(chan struct{}),
+	}
+}
+
+func (s *AccountCanaryService) Start() {
+	if s == nil || s.accountRepo == nil || s.usageLogRepo == nil || s.interval <= 0 {
+		return
+	}
+	s.wg.Add(1)
+	go func() {
+		defer s.wg.Done()
+		ticker := time.NewTicker(s.interval)
+		defer ticker.Stop()
+
+		s.runOnce()
+		for {
+			select {
+			case <-ticker.C:
+				s.runOnce()
+			case <-s.stopCh:
+				return
+			}
+		}
+	}()
+}
+
+func (s *AccountCanaryService) Stop() {
+	if s == nil {
+		return
+	}
+	s.stopOnce.Do(func() {
+		close(s.stopCh)
+	})
+	s.wg.Wait()
+}
+
+func (s *AccountCanaryService) runOnce() {
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+
+	accounts, err := s.accountRepo.ListActive(ctx)
+	if err != nil {
+		log.Printf("[AccountCanary] List active accounts failed: %v", err)
+		return
+	}
+	now := time.Now()
+	for i := range accounts {
+		account := &accounts[i]
+		if !account.IsCanary() {
+			continue
+		}
+		if err := s.evaluate(ctx, account, now); err != nil {
+			log.Printf("[AccountCanary] Evaluate account %d failed: %v", account.ID, err)
+		}
+	}
+}
+
+// evaluate 统计灰度开始以来的请求数与错误数，满足阈值时将账号转正（canary_percent 置 0）
+func (s *AccountCanaryService) evaluate(ctx context.Context, account *Account, now time.Time) error {
+	startedAt := account.GetCanaryStartedAt()
+	if startedAt == nil {
+		// 首次发现灰度账号时记录开始时间，从此刻开始统计
+		return s.accountRepo.UpdateExtra(ctx, account.ID, map[string]any{
+			accountCanaryStartedAtKey: now.UTC().Format(time.RFC3339),
+		})
+	}
+
+	stats, err := s.usageLogRepo.GetAccountWindowStats(ctx, account.ID, *startedAt)
+	if err != nil {
+		return err
+	}
+	var successes int64
+	if stats != nil {
+		successes = stats.Requests
+	}
+	errorCount, err := s.countErrors(ctx, account.ID, *startedAt)
+	if err != nil {
+		return err
+	}
+
+	total := successes + errorCount
+	if total < account.GetCanaryMinRequests() {
+		return nil
+	}
+	errorRate := float64(errorCount) / float64(total)
+	if errorRate > account.GetCanaryMaxErrorRate() {
+		log.Printf("[AccountCanary] Account %d kept in canary: error_rate=%.4f requests=%d", account.ID, errorRate, total)
+		return nil
+	}
+
+	if err := s.accountRepo.UpdateExtra(ctx, account.ID, map[string]any{
+		accountCanaryPercentKey:    0,
+		accountCanaryPromotedAtKey: now.UTC().Format(time.RFC3339),
+	}); err != nil {
+		return err
+	}
+	log.Printf("[AccountCanary] Account %d promoted to full traffic: error_rate=%.4f requests=%d", account.ID, errorRate, total)
+	return nil
+}
+
+func (s *AccountCanaryService) countErrors(ctx context.Context, accountID int64, since time.Time) (int64, error) {
+	if s.opsRepo == nil {
+		return 0, nil
+	}
+	list, err := s.opsRepo.ListErrorLogs(ctx, &OpsErrorLogFilter{
+		StartTime: &since,
+		AccountID: &accountID,
+		View:      "errors",
+		Page:      1,
+		PageSize:  1,
+	})
+	if err != nil {
+		return 0, err
+	}
+	if list == nil {
+		return 0, nil
+	}
+	return int64(list.Total), nil
+}
@@ -0,0 +1,46 @@
+//go:build unit
+
+package service
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestAccount_GetCanaryPercent(t *testing.T) {
+	require.Equal(t, 0, (&Account{}).GetCanaryPercent())
+	require.Equal(t, 10, (&Account{Extra: map[string]any{"canary_percent": float64(10)}}).GetCanaryPercent())
+	require.Equal(t, 0, (&Account{Extra: map[string]any{"canary_percent": 100}}).GetCanaryPercent())
+	require.False(t, (&Account{Extra: map[string]any{"canary_percent": 0}}).IsCanary())
+}
+
+func TestFilterCanaryAccounts(t *testing.T) {
+	t.Run("no canary keeps list", func(t *testing.T) {
+		accounts := []Account{{ID: 1}, {ID: 2}}
+		require.Equal(t, accounts, filterCanaryAccounts(accounts))
+	})
+
+	t.Run("canary sampled out most of the time", func(t *testing.T) {
+		accounts := []Account{
+			{ID: 1},
+			{ID: 2, Extra: map[string]any{"canary_percent": 1}},
+		}
+		hits := 0
+		for i := 0; i < 1000; i++ {
+			for _, acc := range filterCanaryAccounts(accounts) {
+				if acc.ID == 2 {
+					hits++
+				}
+			}
+		}
+		require.Less(t, hits, 100)
+	})
+
+	t.Run("falls back when only canary accounts exist", func(t *testing.T) {
+		accounts := []Account{{ID: 1, Extra: map[string]any{"canary_percent": 1}}}
+		for i := 0; i < 50; i++ {
+			require.Len(t, filterCanaryAccounts(accounts), 1)
+		}
+	})
+}
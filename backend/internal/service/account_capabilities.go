@@ -0,0 +1,151 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"strings"
+
+	"github.com/Wei-Shaw/sub2api/internal/pkg/ctxkey"
+	"github.com/Wei-Shaw/sub2api/internal/pkg/tokenizer"
+)
+
+// AccountCapabilitiesExtraKey 账号能力矩阵在 extra 中的键，例如
+// {"capabilities": {"vision": false, "tools": true, "long_context": false, "streaming": true}}。
+// 未声明的能力视为支持，仅显式声明为 false 的能力参与调度过滤。
+const AccountCapabilitiesExtraKey = "capabilities"
+
+// 账号能力名称
+const (
+	CapabilityVision      = "vision"
+	CapabilityTools       = "tools"
+	CapabilityLongContext = "long_context"
+	CapabilityStreaming   = "streaming"
+)
+
+// longContextPromptTokens 估算提示 token 超过该值时视为需要长上下文（超出常规 200K 窗口）
+const longContextPromptTokens = 200_000
+
+// RequestFeatures 请求依赖的上游能力，由 handler 解析请求后写入 context，供账号调度过滤
+type RequestFeatures struct {
+	Vision      bool
+	Tools       bool
+	LongContext bool
+	Stream      bool
+}
+
+// Required 返回请求依赖的能力名称列表
+func (f RequestFeatures) Required() []string {
+	var out []string
+	if f.Vision {
+		out = append(out, CapabilityVision)
+	}
+	if f.Tools {
+		out = append(out, CapabilityTools)
+	}
+	if f.LongContext {
+		out = append(out, CapabilityLongContext)
+	}
+	if f.Stream {
+		out = append(out, CapabilityStreaming)
+	}
+	return out
+}
+
+// WithRequestFeatures 将请求能力需求写入 context
+func WithRequestFeatures(ctx context.Context, features RequestFeatures) context.Context {
+	return context.WithValue(ctx, ctxkey.RequestFeatures, features)
+}
+
+// RequestFeaturesFromContext 读取请求能力需求，未设置时返回零值（不做过滤）
+func RequestFeaturesFromContext(ctx context.Context) RequestFeatures {
+	if ctx == nil {
+		return RequestFeatures{}
+	}
+	features, _ := ctx.Value(ctxkey.RequestFeatures).(RequestFeatures)
+	return features
+}
+
+// DetectRequestFeatures 从请求体识别所需能力（兼容 Anthropic / OpenAI Chat / Responses / Gemini）：
+// 图片输入、工具定义、超长提示（按模型家族本地估算 token）与流式输出。
+func DetectRequestFeatures(body []byte, stream bool) RequestFeatures {
+	features := RequestFeatures{Stream: stream}
+	var obj any
+	if err := json.Unmarshal(body, &obj); err != nil {
+		return features
+	}
+	model := ""
+	if root, ok := obj.(map[string]any); ok {
+		model, _ = root["model"].(string)
+		for _, key := range []string{"tools", "functions"} {
+			if tools, ok := root[key].([]any); ok && len(tools) > 0 {
+				features.Tools = true
+			}
+		}
+	}
+	var walk func(v any)
+	walk = func(v any) {
+		switch val := v.(type) {
+		case map[string]any:
+			if t, _ := val["type"].(string); t == "image" || t == "input_image" || t == "image_url" {
+				features.Vision = true
+			}
+			for _, k := range []string{"inlineData", "inline_data", "fileData", "file_data"} {
+				if media, ok := val[k].(map[string]any); ok && isImageMimeType(media) {
+					features.Vision = true
+				}
+			}
+			for _, child := range val {
+				walk(child)
+			}
+		case []any:
+			for _, child := range val {
+				walk(child)
+			}
+		}
+	}
+	walk(obj)
+	features.LongContext = tokenizer.CountValue(tokenizer.ForModel("", model), obj) > longContextPromptTokens
+	return features
+}
+
+func isImageMimeType(media map[string]any) bool {
+	for _, k := range []string{"mimeType", "mime_type"} {
+		if mime, _ := media[k].(string); strings.HasPrefix(mime, "image/") {
+			return true
+		}
+	}
+	return false
+}
+
+// GetCapabilities 返回账号显式声明的能力（extra.capabilities），未声明的能力不在结果中
+func (a *Account) GetCapabilities() map[string]bool {
+	if a == nil || a.Extra == nil {
+		return nil
+	}
+	raw, ok := a.Extra[AccountCapabilitiesExtraKey].(map[string]any)
+	if !ok {
+		return nil
+	}
+	out := make(map[string]bool, len(raw))
+	for name, v := range raw {
+		if b, ok := v.(bool); ok {
+			out[name] = b
+		}
+	}
+	return out
+}
+
+// SupportsRequestFeatures 判断账号能否承接请求：请求依赖的能力中任一被账号显式声明为不支持时返回 false
+func (a *Account) SupportsRequestFeatures(features RequestFeatures) bool {
+	required := features.Required()
+	if len(required) == 0 {
+		return true
+	}
+	caps := a.GetCapabilities()
+	for _, name := range required {
+		if supported, declared := caps[name]; declared && !supported {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,39 @@
+package service
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestDetectRequestFeatures(t *testing.T) {
+	anthropic := `{"model":"claude","tools":[{"name":"t"}],"messages":[{"role":"user","content":[{"type":"image","source":{"type":"base64","data":"AAAA"}}]}]}`
+	f := DetectRequestFeatures([]byte(anthropic), true)
+	require.Equal(t, RequestFeatures{Vision: true, Tools: true, Stream: true}, f)
+
+	responses := `{"model":"gpt-5","input":[{"role":"user","content":[{"type":"input_text","text":"hi"}]}],"tools":[]}`
+	require.Equal(t, RequestFeatures{}, DetectRequestFeatures([]byte(responses), false))
+
+	gemini := `{"contents":[{"parts":[{"inlineData":{"mimeType":"image/png","data":"AAAA"}}]}]}`
+	require.True(t, DetectRequestFeatures([]byte(gemini), false).Vision)
+
+	long := `{"messages":[{"role":"user","content":"` + strings.Repeat("word ", 250_000) + `"}]}`
+	require.True(t, DetectRequestFeatures([]byte(long), false).LongContext)
+}
+
+func TestAccountSupportsRequestFeatures(t *testing.T) {
+	undeclared := &Account{}
+	require.True(t, undeclared.SupportsRequestFeatures(RequestFeatures{Vision: true, Tools: true, LongContext: true, Stream: true}))
+
+	textOnly := &Account{Extra: map[string]any{
+		AccountCapabilitiesExtraKey: map[string]any{"vision": false, "tools": true},
+	}}
+	require.False(t, textOnly.SupportsRequestFeatures(RequestFeatures{Vision: true}))
+	require.True(t, textOnly.SupportsRequestFeatures(RequestFeatures{Tools: true, Stream: true}))
+
+	ctx := WithRequestFeatures(context.Background(), RequestFeatures{Vision: true})
+	require.False(t, isOpenAIAccountUsableForRequest(ctx, textOnly, ""))
+	require.True(t, isOpenAIAccountUsableForRequest(context.Background(), textOnly, ""))
+}
@@ -0,0 +1,145 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"os/exec"
+	"strings"
+	"sync/atomic"
+	"time"
+
+	"github.com/Wei-Shaw/sub2api/internal/config"
+	"github.com/Wei-Shaw/sub2api/internal/pkg/credcrypt"
+)
+
+// sensitiveCredentialKeys 启用静态加密后需要加密存储的账号凭证字段
+var sensitiveCredentialKeys = map[string]struct{}{
+	"api_key":        {},
+	"access_token":   {},
+	"refresh_token":  {},
+	"id_token":       {},
+	"session_key":    {},
+	"cookie":         {},
+	"cookies":        {},
+	"tls_client_key": {},
+}
+
+// credentialKeyCommandTimeout 执行 key_command 获取主密钥的超时
+const credentialKeyCommandTimeout = 30 * time.Second
+
+// credentialCipher 进程级凭证加密器，未启用静态加密时为 nil
+var credentialCipher atomic.Pointer[credcrypt.Cipher]
+
+// IsSensitiveCredentialKey 判断凭证字段是否属于静态加密范围
+func IsSensitiveCredentialKey(key string) bool {
+	_, ok := sensitiveCredentialKeys[key]
+	return ok
+}
+
+// InitCredentialEncryption 根据配置初始化账号凭证静态加密，需在访问数据库之前调用。
+// 主密钥来自 key，或执行 key_command 获取（如从 KMS 解密）；未启用时凭证以明文写入。
+func InitCredentialEncryption(ctx context.Context, cfg *config.Config) error {
+	if cfg == nil || !cfg.Security.CredentialEncryption.Enabled {
+		credentialCipher.Store(nil)
+		return nil
+	}
+	enc := cfg.Security.CredentialEncryption
+	key, err := loadCredentialEncryptionKey(ctx, enc)
+	if err != nil {
+		return err
+	}
+	previous := make(map[string][]byte, len(enc.PreviousKeys))
+	for keyID, encoded := range enc.PreviousKeys {
+		prevKey, err := credcrypt.DecodeKey(encoded)
+		if err != nil {
+			return fmt.Errorf("security.credential_encryption.previous_keys.%s: %w", keyID, err)
+		}
+		previous[keyID] = prevKey
+	}
+	cipher, err := credcrypt.New(enc.KeyID, key, previous)
+	if err != nil {
+		return err
+	}
+	credentialCipher.Store(cipher)
+	return nil
+}
+
+func loadCredentialEncryptionKey(ctx context.Context, enc config.CredentialEncryptionConfig) ([]byte, error) {
+	command := strings.TrimSpace(enc.KeyCommand)
+	if command == "" {
+		return credcrypt.DecodeKey(enc.Key)
+	}
+	ctx, cancel := context.WithTimeout(ctx, credentialKeyCommandTimeout)
+	defer cancel()
+	output, err := exec.CommandContext(ctx, "sh", "-c", command).Output()
+	if err != nil {
+		return nil, fmt.Errorf("security.credential_encryption.key_command failed: %w", err)
+	}
+	return credcrypt.DecodeKey(string(output))
+}
+
+// EncryptAccountCredentials 返回敏感字段已加密的凭证副本，写入数据库前调用。
+// 已加密的值原样保留；未启用静态加密时直接返回原凭证。
+func EncryptAccountCredentials(credentials map[string]any) (map[string]any, error) {
+	cipher := credentialCipher.Load()
+	if cipher == nil || len(credentials) == 0 {
+		return credentials, nil
+	}
+	out, _, err := encryptCredentialValues(cipher, credentials, false)
+	return out, err
+}
+
+// ReencryptAccountCredentials 加密凭证中的明文敏感字段，并用当前主密钥重新加密由旧主密钥加密的字段。
+// 用于批量迁移已有数据，changed 表示凭证是否有变化。
+func ReencryptAccountCredentials(credentials map[string]any) (map[string]any, bool, error) {
+	cipher := credentialCipher.Load()
+	if cipher == nil {
+		return credentials, false, fmt.Errorf("credential encryption is not enabled")
+	}
+	return encryptCredentialValues(cipher, credentials, true)
+}
+
+func encryptCredentialValues(cipher *credcrypt.Cipher, credentials map[string]any, rotate bool) (map[string]any, bool, error) {
+	out := make(map[string]any, len(credentials))
+	changed := false
+	for k, v := range credentials {
+		out[k] = v
+		s, ok := v.(string)
+		if !ok || s == "" || !IsSensitiveCredentialKey(k) {
+			continue
+		}
+		if credcrypt.IsEncrypted(s) && !(rotate && cipher.NeedsReencrypt(s)) {
+			continue
+		}
+		plaintext, err := cipher.Decrypt(s)
+		if err != nil {
+			return nil, false, fmt.Errorf("decrypt credential %s: %w", k, err)
+		}
+		encrypted, err := cipher.Encrypt(plaintext)
+		if err != nil {
+			return nil, false, fmt.Errorf("encrypt credential %s: %w", k, err)
+		}
+		out[k] = encrypted
+		changed = true
+	}
+	return out, changed, nil
+}
+
+// decryptCredentialValue 解密凭证值；非密文原样返回。解密失败时返回空字符串，避免把密文当作凭证发往上游
+func decryptCredentialValue(key, value string) string {
+	if !credcrypt.IsEncrypted(value) {
+		return value
+	}
+	cipher := credentialCipher.Load()
+	if cipher == nil {
+		slog.Warn("credential_encrypted_but_encryption_disabled", "field", key)
+		return ""
+	}
+	plaintext, err := cipher.Decrypt(value)
+	if err != nil {
+		slog.Warn("credential_decrypt_failed", "field", key, "error", err)
+		return ""
+	}
+	return plaintext
+}
@@ -0,0 +1,103 @@
+//go:build unit
+
+package service
+
+import (
+	"bytes"
+	"context"
+	"encoding/base64"
+	"testing"
+
+	"github.com/Wei-Shaw/sub2api/internal/config"
+	"github.com/Wei-Shaw/sub2api/internal/pkg/credcrypt"
+	"github.com/stretchr/testify/require"
+)
+
+func credentialEncryptionTestConfig(keyID string, key byte) *config.Config {
+	cfg := &config.Config{}
+	cfg.Security.CredentialEncryption = config.CredentialEncryptionConfig{
+		Enabled: true,
+		KeyID:   keyID,
+		Key:     base64.StdEncoding.EncodeToString(bytes.Repeat([]byte{key}, credcrypt.KeySize)),
+	}
+	return cfg
+}
+
+func TestAccountCredentialEncryption(t *testing.T) {
+	t.Cleanup(func() { credentialCipher.Store(nil) })
+
+	plain := map[string]any{
+		"api_key":    "sk-ant-secret",
+		"base_url":   "https://proxy.example.com",
+		"expires_at": float64(1735689600),
+	}
+
+	// 未启用时原样写入
+	out, err := EncryptAccountCredentials(plain)
+	require.NoError(t, err)
+	require.Equal(t, plain, out)
+
+	require.NoError(t, InitCredentialEncryption(context.Background(), credentialEncryptionTestConfig("k1", 1)))
+	encrypted, err := EncryptAccountCredentials(plain)
+	require.NoError(t, err)
+	require.True(t, credcrypt.IsEncrypted(encrypted["api_key"].(string)))
+	require.Equal(t, "https://proxy.example.com", encrypted["base_url"])
+	require.Equal(t, float64(1735689600), encrypted["expires_at"])
+	// 不修改调用方的 map
+	require.Equal(t, "sk-ant-secret", plain["api_key"])
+
+	// 已加密的值保持不变
+	again, err := EncryptAccountCredentials(encrypted)
+	require.NoError(t, err)
+	require.Equal(t, encrypted["api_key"], again["api_key"])
+
+	account := &Account{Credentials: encrypted}
+	require.Equal(t, "sk-ant-secret", account.GetCredential("api_key"))
+	require.Equal(t, "https://proxy.example.com", account.GetCredential("base_url"))
+
+	// 密文损坏时不返回密文
+	account.Credentials = map[string]any{"api_key": credcrypt.Prefix + "k1:AAAA:AAAA"}
+	require.Equal(t, "", account.GetCredential("api_key"))
+}
+
+func TestReencryptAccountCredentials(t *testing.T) {
+	t.Cleanup(func() { credentialCipher.Store(nil) })
+
+	_, _, err := ReencryptAccountCredentials(map[string]any{"api_key": "x"})
+	require.Error(t, err)
+
+	require.NoError(t, InitCredentialEncryption(context.Background(), credentialEncryptionTestConfig("k1", 1)))
+	v1, changed, err := ReencryptAccountCredentials(map[string]any{"refresh_token": "rt", "project_id": "p"})
+	require.NoError(t, err)
+	require.True(t, changed)
+	_, changed, err = ReencryptAccountCredentials(v1)
+	require.NoError(t, err)
+	require.False(t, changed)
+
+	// 轮换主密钥：旧密文可解密，迁移后改用新密钥
+	cfg := credentialEncryptionTestConfig("k2", 2)
+	cfg.Security.CredentialEncryption.PreviousKeys = map[string]string{
+		"k1": base64.StdEncoding.EncodeToString(bytes.Repeat([]byte{1}, credcrypt.KeySize)),
+	}
+	require.NoError(t, InitCredentialEncryption(context.Background(), cfg))
+	require.Equal(t, "rt", (&Account{Credentials: v1}).GetCredential("refresh_token"))
+	v2, changed, err := ReencryptAccountCredentials(v1)
+	require.NoError(t, err)
+	require.True(t, changed)
+	require.Contains(t, v2["refresh_token"], credcrypt.Prefix+"k2:")
+	require.Equal(t, "p", v2["project_id"])
+	require.Equal(t, "rt", (&Account{Credentials: v2}).GetCredential("refresh_token"))
+}
+
+func TestInitCredentialEncryption_KeyCommand(t *testing.T) {
+	t.Cleanup(func() { credentialCipher.Store(nil) })
+
+	cfg := credentialEncryptionTestConfig("kms", 7)
+	cfg.Security.CredentialEncryption.KeyCommand = "echo " + cfg.Security.CredentialEncryption.Key
+	cfg.Security.CredentialEncryption.Key = ""
+	require.NoError(t, InitCredentialEncryption(context.Background(), cfg))
+	require.Equal(t, "kms", credentialCipher.Load().KeyID())
+
+	cfg.Security.CredentialEncryption.KeyCommand = "exit 1"
+	require.Error(t, InitCredentialEncryption(context.Background(), cfg))
+}
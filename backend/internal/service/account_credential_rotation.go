@@ -0,0 +1,231 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"sort"
+	"strings"
+	"time"
+
+	infraerrors "github.com/Wei-Shaw/sub2api/internal/pkg/errors"
+)
+
+const (
+	// credentialRotationLockTTL 轮换期间持有的刷新锁 TTL（与 token provider 刷新锁一致）
+	credentialRotationLockTTL = 30 * time.Second
+	// credentialRotationLockWait 等待进行中的 token 刷新释放锁的最长时间
+	credentialRotationLockWait = 5 * time.Second
+	credentialRotationLockPoll = 200 * time.Millisecond
+)
+
+var (
+	ErrCredentialRotationEmpty = infraerrors.BadRequest("CREDENTIAL_ROTATION_EMPTY", "credentials must not be empty")
+	// ErrCredentialRotationBusy 账号的 OAuth token 正在被刷新（稍后重试即可）
+	ErrCredentialRotationBusy = infraerrors.Conflict("CREDENTIAL_ROTATION_BUSY", "account token refresh in progress, retry later")
+)
+
+// AccountCredentialProber 使用候选凭证发送一次探测请求（不落库），成功返回 nil
+type AccountCredentialProber interface {
+	ProbeAccount(ctx context.Context, account *Account, modelID string) error
+}
+
+// RotateAccountCredentialsInput 凭证轮换参数
+type RotateAccountCredentialsInput struct {
+	// Credentials 需要替换的凭证字段（与现有凭证合并，未提供的字段保持不变）
+	Credentials map[string]any
+	// Validate 为 true 时先用新凭证发送探测请求，失败则不提交
+	Validate bool
+	// ModelID 探测使用的模型（为空时使用平台默认测试模型）
+	ModelID string
+}
+
+// RotateAccountCredentialsResult 凭证轮换结果
+type RotateAccountCredentialsResult struct {
+	Account        *Account
+	RotatedKeys    []string
+	Validated      bool
+	ProbeLatencyMs *int64
+	// TokenVersion 写入凭证的 _token_version
+	TokenVersion int64
+}
+
+// AccountCredentialRotationService 零停机凭证轮换：
+//   - 进行中的请求持有各自的账号快照，继续使用旧凭证直到结束；
+//   - 新凭证以 JSONB 合并的单条 UPDATE 提交，并递增 _token_version，使持有旧快照的 token 刷新不会回写缓存；
+//   - OAuth 账号在轮换期间持有 token 刷新锁，避免后台刷新用旧 refresh_token 覆盖新凭证；
+//   - 提交后删除 access_token 缓存，后续请求立即使用新凭证。
+type AccountCredentialRotationService struct {
+	accountRepo AccountRepository
+	prober      AccountCredentialProber
+	tokenCache  GeminiTokenCache
+	invalidator TokenCacheInvalidator
+}
+
+// NewAccountCredentialRotationService 创建凭证轮换服务
+func NewAccountCredentialRotationService(
+	accountRepo AccountRepository,
+	accountTestService *AccountTestService,
+	tokenCache GeminiTokenCache,
+	invalidator TokenCacheInvalidator,
+) *AccountCredentialRotationService {
+	s := &AccountCredentialRotationService{
+		accountRepo: accountRepo,
+		tokenCache:  tokenCache,
+		invalidator: invalidator,
+	}
+	if accountTestService != nil {
+		s.prober = accountTestService
+	}
+	return s
+}
+
+// RotateCredentials 原子地替换账号凭证（无需先停用账号）
+func (s *AccountCredentialRotationService) RotateCredentials(ctx context.Context, accountID int64, input *RotateAccountCredentialsInput) (*RotateAccountCredentialsResult, error) {
+	if input == nil || len(input.Credentials) == 0 {
+		return nil, ErrCredentialRotationEmpty
+	}
+	keys := make([]string, 0, len(input.Credentials))
+	for key := range input.Credentials {
+		if strings.TrimSpace(key) == "" || strings.HasPrefix(key, "_") {
+			return nil, infraerrors.BadRequest("CREDENTIAL_ROTATION_INVALID_KEY", fmt.Sprintf("credential key %q is not allowed", key))
+		}
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	account, err := s.accountRepo.GetByID(ctx, accountID)
+	if err != nil {
+		return nil, err
+	}
+	candidate := buildRotationCandidate(account, input.Credentials)
+	if err := validateRotationCandidate(candidate); err != nil {
+		return nil, err
+	}
+
+	result := &RotateAccountCredentialsResult{RotatedKeys: keys}
+	if input.Validate {
+		if s.prober == nil {
+			return nil, infraerrors.ServiceUnavailable("CREDENTIAL_PROBE_UNAVAILABLE", "credential validation probe not available")
+		}
+		started := time.Now()
+		if err := s.prober.ProbeAccount(ctx, candidate, input.ModelID); err != nil {
+			return nil, infraerrors.BadRequest("CREDENTIAL_VALIDATION_FAILED", "validation probe failed: "+err.Error())
+		}
+		latency := time.Since(started).Milliseconds()
+		result.Validated = true
+		result.ProbeLatencyMs = &latency
+	}
+
+	release, err := s.acquireRefreshLock(ctx, candidate)
+	if err != nil {
+		return nil, err
+	}
+	defer release()
+
+	result.TokenVersion = time.Now().UnixMilli()
+	delta := make(map[string]any, len(input.Credentials)+1)
+	for k, v := range input.Credentials {
+		delta[k] = v
+	}
+	delta["_token_version"] = result.TokenVersion
+
+	affected, err := s.accountRepo.BulkUpdate(ctx, []int64{accountID}, AccountBulkUpdate{Credentials: delta})
+	if err != nil {
+		return nil, err
+	}
+	if affected == 0 {
+		return nil, ErrAccountNotFound
+	}
+
+	if s.invalidator != nil {
+		if err := s.invalidator.InvalidateToken(ctx, candidate); err != nil {
+			slog.Warn("credential_rotation_invalidate_cache_failed", "account_id", accountID, "error", err)
+		}
+	}
+	slog.Info("account_credentials_rotated", "account_id", accountID, "keys", keys, "validated", result.Validated)
+
+	updated, err := s.accountRepo.GetByID(ctx, accountID)
+	if err != nil {
+		return nil, err
+	}
+	result.Account = updated
+	return result, nil
+}
+
+// buildRotationCandidate 复制账号并合并新凭证（不修改原账号对象）
+func buildRotationCandidate(account *Account, credentials map[string]any) *Account {
+	candidate := *account
+	merged := make(map[string]any, len(account.Credentials)+len(credentials))
+	for k, v := range account.Credentials {
+		merged[k] = v
+	}
+	for k, v := range credentials {
+		merged[k] = v
+	}
+	candidate.Credentials = merged
+	return &candidate
+}
+
+// validateRotationCandidate 合并后仍需保留账号类型必需的凭证
+func validateRotationCandidate(account *Account) error {
+	switch account.Type {
+	case AccountTypeAPIKey:
+		if strings.TrimSpace(account.GetCredential("api_key")) == "" {
+			return infraerrors.BadRequest("CREDENTIAL_ROTATION_INCOMPLETE", "api_key must not be empty")
+		}
+	case AccountTypeOAuth, AccountTypeSetupToken:
+		if strings.TrimSpace(account.GetCredential("access_token")) == "" && strings.TrimSpace(account.GetCredential("refresh_token")) == "" {
+			return infraerrors.BadRequest("CREDENTIAL_ROTATION_INCOMPLETE", "access_token or refresh_token is required")
+		}
+	}
+	return nil
+}
+
+// acquireRefreshLock 对 OAuth 账号持有 token 刷新锁直到提交完成；缓存不可用时不加锁
+func (s *AccountCredentialRotationService) acquireRefreshLock(ctx context.Context, account *Account) (func(), error) {
+	noop := func() {}
+	if s.tokenCache == nil || account.Type != AccountTypeOAuth {
+		return noop, nil
+	}
+	cacheKey := rotationTokenCacheKey(account)
+	if cacheKey == "" {
+		return noop, nil
+	}
+
+	deadline := time.Now().Add(credentialRotationLockWait)
+	for {
+		locked, err := s.tokenCache.AcquireRefreshLock(ctx, cacheKey, credentialRotationLockTTL)
+		if err != nil {
+			slog.Warn("credential_rotation_lock_failed", "account_id", account.ID, "error", err)
+			return noop, nil
+		}
+		if locked {
+			return func() { _ = s.tokenCache.ReleaseRefreshLock(context.Background(), cacheKey) }, nil
+		}
+		if time.Now().After(deadline) {
+			return nil, ErrCredentialRotationBusy
+		}
+		select {
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		case <-time.After(credentialRotationLockPoll):
+		}
+	}
+}
+
+// rotationTokenCacheKey 与各平台 token provider 使用的刷新锁键一致
+func rotationTokenCacheKey(account *Account) string {
+	switch account.Platform {
+	case PlatformAnthropic:
+		return ClaudeTokenCacheKey(account)
+	case PlatformOpenAI:
+		return OpenAITokenCacheKey(account)
+	case PlatformGemini:
+		return GeminiTokenCacheKey(account)
+	case PlatformAntigravity:
+		return AntigravityTokenCacheKey(account)
+	default:
+		return ""
+	}
+}
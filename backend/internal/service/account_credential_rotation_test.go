@@ -0,0 +1,164 @@
+//go:build unit
+
+package service
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+type rotationStubAccountRepo struct {
+	AccountRepository
+	account *Account
+	deltas  []map[string]any
+}
+
+func (r *rotationStubAccountRepo) GetByID(_ context.Context, id int64) (*Account, error) {
+	if r.account == nil || r.account.ID != id {
+		return nil, ErrAccountNotFound
+	}
+	clone := *r.account
+	return &clone, nil
+}
+
+func (r *rotationStubAccountRepo) BulkUpdate(_ context.Context, ids []int64, updates AccountBulkUpdate) (int64, error) {
+	r.deltas = append(r.deltas, updates.Credentials)
+	merged := make(map[string]any, len(r.account.Credentials)+len(updates.Credentials))
+	for k, v := range r.account.Credentials {
+		merged[k] = v
+	}
+	for k, v := range updates.Credentials {
+		merged[k] = v
+	}
+	r.account.Credentials = merged
+	return int64(len(ids)), nil
+}
+
+type rotationStubProber struct {
+	err  error
+	seen *Account
+}
+
+func (p *rotationStubProber) ProbeAccount(_ context.Context, account *Account, _ string) error {
+	p.seen = account
+	return p.err
+}
+
+type rotationStubTokenCache struct {
+	GeminiTokenCache
+	locked   bool
+	released int
+}
+
+func (c *rotationStubTokenCache) AcquireRefreshLock(context.Context, string, time.Duration) (bool, error) {
+	if c.locked {
+		return false, nil
+	}
+	c.locked = true
+	return true, nil
+}
+
+func (c *rotationStubTokenCache) ReleaseRefreshLock(context.Context, string) error {
+	c.locked = false
+	c.released++
+	return nil
+}
+
+type rotationStubInvalidator struct {
+	calls int
+}
+
+func (i *rotationStubInvalidator) InvalidateToken(context.Context, *Account) error {
+	i.calls++
+	return nil
+}
+
+func TestAccountCredentialRotationService_RotateCredentials(t *testing.T) {
+	newAccount := func() *Account {
+		return &Account{
+			ID:       7,
+			Platform: PlatformAnthropic,
+			Type:     AccountTypeOAuth,
+			Credentials: map[string]any{
+				"access_token":  "old-access",
+				"refresh_token": "old-refresh",
+				"scope":         "user:inference",
+			},
+		}
+	}
+
+	t.Run("merges credentials and bumps token version", func(t *testing.T) {
+		repo := &rotationStubAccountRepo{account: newAccount()}
+		cache := &rotationStubTokenCache{}
+		invalidator := &rotationStubInvalidator{}
+		prober := &rotationStubProber{}
+		svc := &AccountCredentialRotationService{accountRepo: repo, prober: prober, tokenCache: cache, invalidator: invalidator}
+
+		result, err := svc.RotateCredentials(context.Background(), 7, &RotateAccountCredentialsInput{
+			Credentials: map[string]any{"access_token": "new-access", "refresh_token": "new-refresh"},
+			Validate:    true,
+		})
+		require.NoError(t, err)
+		require.True(t, result.Validated)
+		require.NotNil(t, result.ProbeLatencyMs)
+		require.Equal(t, []string{"access_token", "refresh_token"}, result.RotatedKeys)
+		require.Equal(t, "new-access", prober.seen.GetCredential("access_token"))
+
+		require.Len(t, repo.deltas, 1)
+		require.Equal(t, result.TokenVersion, repo.deltas[0]["_token_version"])
+		require.NotContains(t, repo.deltas[0], "scope")
+		require.Equal(t, "user:inference", result.Account.GetCredential("scope"))
+		require.Equal(t, "new-refresh", result.Account.GetCredential("refresh_token"))
+
+		require.Equal(t, 1, invalidator.calls)
+		require.Equal(t, 1, cache.released)
+		require.False(t, cache.locked)
+	})
+
+	t.Run("failed probe does not commit", func(t *testing.T) {
+		repo := &rotationStubAccountRepo{account: newAccount()}
+		svc := &AccountCredentialRotationService{accountRepo: repo, prober: &rotationStubProber{err: errors.New("401 unauthorized")}}
+
+		_, err := svc.RotateCredentials(context.Background(), 7, &RotateAccountCredentialsInput{
+			Credentials: map[string]any{"access_token": "bad"},
+			Validate:    true,
+		})
+		require.Error(t, err)
+		require.Empty(t, repo.deltas)
+		require.Equal(t, "old-access", repo.account.GetCredential("access_token"))
+	})
+
+	t.Run("rejects empty and internal keys", func(t *testing.T) {
+		repo := &rotationStubAccountRepo{account: newAccount()}
+		svc := &AccountCredentialRotationService{accountRepo: repo}
+
+		_, err := svc.RotateCredentials(context.Background(), 7, &RotateAccountCredentialsInput{})
+		require.ErrorIs(t, err, ErrCredentialRotationEmpty)
+
+		_, err = svc.RotateCredentials(context.Background(), 7, &RotateAccountCredentialsInput{
+			Credentials: map[string]any{"_token_version": 1},
+		})
+		require.Error(t, err)
+		require.Empty(t, repo.deltas)
+	})
+
+	t.Run("apikey account requires api_key", func(t *testing.T) {
+		repo := &rotationStubAccountRepo{account: &Account{
+			ID:          8,
+			Platform:    PlatformOpenAI,
+			Type:        AccountTypeAPIKey,
+			Credentials: map[string]any{"api_key": "sk-old"},
+		}}
+		svc := &AccountCredentialRotationService{accountRepo: repo}
+
+		_, err := svc.RotateCredentials(context.Background(), 8, &RotateAccountCredentialsInput{
+			Credentials: map[string]any{"api_key": " "},
+		})
+		require.Error(t, err)
+		require.Empty(t, repo.deltas)
+	})
+}
@@ -0,0 +1,109 @@
+package service
+
+import (
+	"net/http"
+	"regexp"
+	"sort"
+	"strings"
+
+	infraerrors "github.com/Wei-Shaw/sub2api/internal/pkg/errors"
+)
+
+// AccountLabelsExtraKey 账号标签在 extra 中的键（字符串数组）
+const AccountLabelsExtraKey = "labels"
+
+const (
+	maxAccountLabels      = 20
+	maxAccountLabelLength = 32
+	// maxLabelBulkAccounts 按标签批量操作时单次最多匹配的账号数
+	maxLabelBulkAccounts = 1000
+)
+
+var accountLabelPattern = regexp.MustCompile(`^[a-z0-9][a-z0-9._:/-]*$`)
+
+// GetLabels 返回账号标签（extra.labels），未设置时为空
+func (a *Account) GetLabels() []string {
+	if a == nil || a.Extra == nil {
+		return nil
+	}
+	var out []string
+	switch v := a.Extra[AccountLabelsExtraKey].(type) {
+	case []string:
+		out = append(out, v...)
+	case []any:
+		for _, item := range v {
+			if s, ok := item.(string); ok {
+				out = append(out, s)
+			}
+		}
+	}
+	return out
+}
+
+// HasLabel 判断账号是否带有指定标签（大小写不敏感）
+func (a *Account) HasLabel(label string) bool {
+	label = strings.ToLower(strings.TrimSpace(label))
+	for _, l := range a.GetLabels() {
+		if l == label {
+			return true
+		}
+	}
+	return false
+}
+
+// NormalizeAccountLabels 规范化标签：去空白、转小写、去重并排序；
+// 标签仅允许小写字母、数字与 . _ : / -，单个不超过 32 字符，每个账号最多 20 个。
+func NormalizeAccountLabels(labels []string) ([]string, error) {
+	seen := make(map[string]struct{}, len(labels))
+	out := make([]string, 0, len(labels))
+	for _, raw := range labels {
+		label := strings.ToLower(strings.TrimSpace(raw))
+		if label == "" {
+			continue
+		}
+		if len(label) > maxAccountLabelLength || !accountLabelPattern.MatchString(label) {
+			return nil, infraerrors.Newf(http.StatusBadRequest, "ACCOUNT_LABEL_INVALID",
+				"invalid account label %q: use lowercase letters, digits and . _ : / - (max %d chars)", raw, maxAccountLabelLength)
+		}
+		if _, ok := seen[label]; ok {
+			continue
+		}
+		seen[label] = struct{}{}
+		out = append(out, label)
+	}
+	if len(out) > maxAccountLabels {
+		return nil, infraerrors.Newf(http.StatusBadRequest, "ACCOUNT_LABEL_LIMIT", "an account can have at most %d labels", maxAccountLabels)
+	}
+	sort.Strings(out)
+	return out, nil
+}
+
+// applyAccountLabels 将规范化后的标签写入 extra（空标签时移除该键），返回新的 extra
+func applyAccountLabels(extra map[string]any, labels []string) map[string]any {
+	out := make(map[string]any, len(extra)+1)
+	for k, v := range extra {
+		out[k] = v
+	}
+	if len(labels) == 0 {
+		delete(out, AccountLabelsExtraKey)
+	} else {
+		out[AccountLabelsExtraKey] = labels
+	}
+	return out
+}
+
+// mergeAccountLabels 在现有标签上添加/移除标签，结果经过规范化
+func mergeAccountLabels(current, add, remove []string) ([]string, error) {
+	removeSet := make(map[string]struct{}, len(remove))
+	for _, l := range remove {
+		removeSet[strings.ToLower(strings.TrimSpace(l))] = struct{}{}
+	}
+	merged := make([]string, 0, len(current)+len(add))
+	for _, l := range append(append([]string{}, current...), add...) {
+		if _, ok := removeSet[strings.ToLower(strings.TrimSpace(l))]; ok {
+			continue
+		}
+		merged = append(merged, l)
+	}
+	return NormalizeAccountLabels(merged)
+}
@@ -0,0 +1,53 @@
+package service
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestNormalizeAccountLabels(t *testing.T) {
+	labels, err := NormalizeAccountLabels([]string{" Vendor:Acme ", "tier-1", "vendor:acme", "", "team/ops"})
+	require.NoError(t, err)
+	require.Equal(t, []string{"team/ops", "tier-1", "vendor:acme"}, labels)
+
+	_, err = NormalizeAccountLabels([]string{"has space"})
+	require.Error(t, err)
+	_, err = NormalizeAccountLabels([]string{"-leading"})
+	require.Error(t, err)
+
+	tooMany := make([]string, 0, maxAccountLabels+1)
+	for i := 0; i <= maxAccountLabels; i++ {
+		tooMany = append(tooMany, string(rune('a'+i)))
+	}
+	_, err = NormalizeAccountLabels(tooMany)
+	require.Error(t, err)
+}
+
+func TestAccountLabels_ExtraRoundTrip(t *testing.T) {
+	account := &Account{Extra: map[string]any{"region": "us"}}
+	require.Empty(t, account.GetLabels())
+
+	account.Extra = applyAccountLabels(account.Extra, []string{"prod"})
+	require.Equal(t, []string{"prod"}, account.GetLabels())
+	require.Equal(t, "us", account.Extra["region"])
+	require.True(t, account.HasLabel(" PROD "))
+
+	// JSON 反序列化后的 extra 为 []any
+	account.Extra = map[string]any{AccountLabelsExtraKey: []any{"a", "b", 1}}
+	require.Equal(t, []string{"a", "b"}, account.GetLabels())
+
+	account.Extra = applyAccountLabels(account.Extra, nil)
+	require.NotContains(t, account.Extra, AccountLabelsExtraKey)
+}
+
+func TestMergeAccountLabels(t *testing.T) {
+	labels, err := mergeAccountLabels([]string{"prod", "vendor:a"}, []string{"Backup", "prod"}, []string{"VENDOR:A"})
+	require.NoError(t, err)
+	require.Equal(t, []string{"backup", "prod"}, labels)
+
+	labels, err = mergeAccountLabels([]string{"prod"}, nil, []string{"prod"})
+	require.NoError(t, err)
+	require.NotNil(t, labels)
+	require.Empty(t, labels)
+}
@@ -0,0 +1,369 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"net/url"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/Wei-Shaw/sub2api/internal/config"
+	"github.com/Wei-Shaw/sub2api/internal/pkg/geminicli"
+	"github.com/Wei-Shaw/sub2api/internal/util/urlvalidator"
+)
+
+const (
+	anthropicModelsURL = "https://api.anthropic.com/v1/models"
+	openaiModelsURL    = "https://api.openai.com/v1/models"
+
+	modelDiscoveryMaxPages     = 10
+	modelDiscoveryMaxBodyBytes = 4 << 20
+)
+
+// ErrModelDiscoveryUnsupported 账号类型不支持自动发现模型（仅支持 Anthropic/OpenAI/Gemini API Key 账号）
+var ErrModelDiscoveryUnsupported = errors.New("model discovery is only supported for anthropic/openai/gemini api key accounts")
+
+// AccountModelDiscoveryService periodically queries upstream /models endpoints for API key
+// accounts and persists the discovered model list into account extra.
+type AccountModelDiscoveryService struct {
+	accountRepo   AccountRepository
+	httpUpstream  HTTPUpstream
+	metadataCache UpstreamMetadataCache
+	cfg           *config.Config
+	interval      time.Duration
+	stopCh        chan struct{}
+	stopOnce      sync.Once
+	wg            sync.WaitGroup
+}
+
+func NewAccountModelDiscoveryService(accountRepo AccountRepository, httpUpstream HTTPUpstream, metadataCache UpstreamMetadataCache, cfg *config.Config) *AccountModelDiscoveryService {
+	var interval time.Duration
+	if cfg != nil && cfg.Gateway.ModelDiscovery.Enabled {
+		interval = cfg.Gateway.ModelDiscovery.Interval
+	}
+	return &AccountModelDiscoveryService{
+		accountRepo:   accountRepo,
+		httpUpstream:  httpUpstream,
+		metadataCache: metadataCache,
+		cfg:           cfg,
+		interval:      interval,
+		stopCh:        make(chan struct{}),
+	}
+}
+
+// Start 启动定期发现任务（未启用或周期 <= 0 时不启动，手动触发仍可用）
+func (s *AccountModelDiscoveryService) Start() {
+	if s == nil || s.accountRepo == nil || s.httpUpstream == nil || s.interval <= 0 {
+		return
+	}
+	s.wg.Add(1)
+	go func() {
+		defer s.wg.Done()
+		ticker := time.NewTicker(s.interval)
+		defer ticker.Stop()
+
+		s.runOnce()
+		for {
+			select {
+			case <-ticker.C:
+				s.runOnce()
+			case <-s.stopCh:
+				return
+			}
+		}
+	}()
+}
+
+func (s *AccountModelDiscoveryService) Stop() {
+	if s == nil {
+		return
+	}
+	s.stopOnce.Do(func() {
+		close(s.stopCh)
+	})
+	s.wg.Wait()
+}
+
+func (s *AccountModelDiscoveryService) runOnce() {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Minute)
+	defer cancel()
+
+	accounts, err := s.accountRepo.ListActive(ctx)
+	if err != nil {
+		log.Printf("[ModelDiscovery] List active accounts failed: %v", err)
+		return
+	}
+	refreshed := 0
+	for i := range accounts {
+		account := &accounts[i]
+		if !supportsModelDiscovery(account) {
+			continue
+		}
+		if _, err := s.DiscoverAndSave(ctx, account, false); err != nil {
+			log.Printf("[ModelDiscovery] Account %d discovery failed: %v", account.ID, err)
+			continue
+		}
+		refreshed++
+	}
+	if refreshed > 0 {
+		log.Printf("[ModelDiscovery] Refreshed model matrix for %d accounts", refreshed)
+	}
+}
+
+// DiscoverAndSave 查询账号上游可用模型并写入 extra.discovered_models。
+// 缓存有效期内直接返回缓存结果（多实例部署时由最先刷新的实例写入 extra）；
+// force 为 true 时清除账号的全部上游元数据缓存后重新查询。
+func (s *AccountModelDiscoveryService) DiscoverAndSave(ctx context.Context, account *Account, force bool) ([]string, error) {
+	if !supportsModelDiscovery(account) {
+		return nil, ErrModelDiscoveryUnsupported
+	}
+	if force {
+		if s.metadataCache != nil {
+			if err := s.metadataCache.DeleteUpstreamMetadata(ctx, account.ID); err != nil {
+				log.Printf("[ModelDiscovery] Clear metadata cache for account %d failed: %v", account.ID, err)
+			}
+		}
+	} else if cached := getCachedDiscoveredModels(ctx, s.metadataCache, account.ID); len(cached) > 0 {
+		return cached, nil
+	}
+
+	models, err := s.Discover(ctx, account)
+	if err != nil {
+		return nil, err
+	}
+	// 上游返回空列表时不覆盖，避免异常响应导致账号被全部过滤
+	if len(models) == 0 {
+		return models, nil
+	}
+	setCachedDiscoveredModels(ctx, s.metadataCache, account.ID, models, upstreamMetadataCacheTTL(s.cfg))
+	if err := s.accountRepo.UpdateExtra(ctx, account.ID, map[string]any{
+		accountDiscoveredModelsKey:   models,
+		accountModelsDiscoveredAtKey: time.Now().UTC().Format(time.RFC3339),
+	}); err != nil {
+		return nil, err
+	}
+	return models, nil
+}
+
+// Discover 查询账号上游可用模型列表（去重、排序）
+func (s *AccountModelDiscoveryService) Discover(ctx context.Context, account *Account) ([]string, error) {
+	if !supportsModelDiscovery(account) {
+		return nil, ErrModelDiscoveryUnsupported
+	}
+	apiKey := strings.TrimSpace(account.GetCredential("api_key"))
+	if apiKey == "" {
+		return nil, errors.New("api_key not found in credentials")
+	}
+
+	var (
+		models []string
+		err    error
+	)
+	switch account.Platform {
+	case PlatformAnthropic:
+		models, err = s.discoverAnthropic(ctx, account, apiKey)
+	case PlatformOpenAI:
+		models, err = s.discoverOpenAI(ctx, account, apiKey)
+	case PlatformGemini:
+		models, err = s.discoverGemini(ctx, account, apiKey)
+	}
+	if err != nil {
+		return nil, err
+	}
+	return normalizeDiscoveredModels(models), nil
+}
+
+func supportsModelDiscovery(account *Account) bool {
+	if account == nil || account.Type != AccountTypeAPIKey {
+		return false
+	}
+	switch account.Platform {
+	case PlatformAnthropic, PlatformOpenAI, PlatformGemini:
+		return true
+	}
+	return false
+}
+
+func (s *AccountModelDiscoveryService) discoverAnthropic(ctx context.Context, account *Account, apiKey string) ([]string, error) {
+	endpoint := anthropicModelsURL
+	if baseURL := strings.TrimSpace(account.GetCredential("base_url")); baseURL != "" {
+		validated, err := s.validateUpstreamBaseURL(baseURL)
+		if err != nil {
+			return nil, err
+		}
+		endpoint = strings.TrimRight(validated, "/") + "/v1/models"
+	}
+
+	var models []string
+	afterID := ""
+	for page := 0; page < modelDiscoveryMaxPages; page++ {
+		query := url.Values{"limit": {"1000"}}
+		if afterID != "" {
+			query.Set("after_id", afterID)
+		}
+		var resp struct {
+			Data []struct {
+				ID string `json:"id"`
+			} `json:"data"`
+			HasMore bool   `json:"has_more"`
+			LastID  string `json:"last_id"`
+		}
+		err := s.getJSON(ctx, account, endpoint+"?"+query.Encode(), map[string]string{
+			"x-api-key":         apiKey,
+			"anthropic-version": "2023-06-01",
+		}, &resp)
+		if err != nil {
+			return nil, err
+		}
+		for _, item := range resp.Data {
+			models = append(models, item.ID)
+		}
+		if !resp.HasMore || resp.LastID == "" {
+			break
+		}
+		afterID = resp.LastID
+	}
+	return models, nil
+}
+
+func (s *AccountModelDiscoveryService) discoverOpenAI(ctx context.Context, account *Account, apiKey string) ([]string, error) {
+	endpoint := openaiModelsURL
+	if baseURL := strings.TrimSpace(account.GetCredential("base_url")); baseURL != "" {
+		validated, err := s.validateUpstreamBaseURL(baseURL)
+		if err != nil {
+			return nil, err
+		}
+		// 与 /responses 转发路径保持一致：自定义 base_url 已包含版本前缀
+		endpoint = strings.TrimRight(validated, "/") + "/models"
+	}
+
+	var resp struct {
+		Data []struct {
+			ID string `json:"id"`
+		} `json:"data"`
+	}
+	if err := s.getJSON(ctx, account, endpoint, map[string]string{
+		"Authorization": "Bearer " + apiKey,
+	}, &resp); err != nil {
+		return nil, err
+	}
+	models := make([]string, 0, len(resp.Data))
+	for _, item := range resp.Data {
+		models = append(models, item.ID)
+	}
+	return models, nil
+}
+
+func (s *AccountModelDiscoveryService) discoverGemini(ctx context.Context, account *Account, apiKey string) ([]string, error) {
+	baseURL := strings.TrimSpace(account.GetCredential("base_url"))
+	if baseURL == "" {
+		baseURL = geminicli.AIStudioBaseURL
+	}
+	validated, err := s.validateUpstreamBaseURL(baseURL)
+	if err != nil {
+		return nil, err
+	}
+	endpoint := strings.TrimRight(validated, "/") + "/v1beta/models"
+
+	var models []string
+	pageToken := ""
+	for page := 0; page < modelDiscoveryMaxPages; page++ {
+		query := url.Values{"pageSize": {"1000"}}
+		if pageToken != "" {
+			query.Set("pageToken", pageToken)
+		}
+		var resp struct {
+			Models []struct {
+				Name string `json:"name"`
+			} `json:"models"`
+			NextPageToken string `json:"nextPageToken"`
+		}
+		if err := s.getJSON(ctx, account, endpoint+"?"+query.Encode(), map[string]string{
+			"x-goog-api-key": apiKey,
+		}, &resp); err != nil {
+			return nil, err
+		}
+		for _, item := range resp.Models {
+			models = append(models, strings.TrimPrefix(item.Name, "models/"))
+		}
+		if resp.NextPageToken == "" {
+			break
+		}
+		pageToken = resp.NextPageToken
+	}
+	return models, nil
+}
+
+func (s *AccountModelDiscoveryService) getJSON(ctx context.Context, account *Account, endpoint string, headers map[string]string, out any) error {
+	// 账号配置了 mTLS 客户端证书 / 自定义 CA 时，由 HTTP 上游据此建立连接
+	ctx = WithAccountUpstreamTLS(ctx, account)
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, endpoint, nil)
+	if err != nil {
+		return err
+	}
+	for k, v := range headers {
+		req.Header.Set(k, v)
+	}
+
+	proxyURL := ""
+	if account.ProxyID != nil && account.Proxy != nil {
+		proxyURL = account.Proxy.URL()
+	}
+	resp, err := s.httpUpstream.Do(req, proxyURL, account.ID, account.Concurrency)
+	if err != nil {
+		return fmt.Errorf("request upstream models: %w", err)
+	}
+	defer func() { _ = resp.Body.Close() }()
+
+	body, err := io.ReadAll(io.LimitReader(resp.Body, modelDiscoveryMaxBodyBytes))
+	if err != nil {
+		return fmt.Errorf("read upstream models: %w", err)
+	}
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("upstream models returned status %d", resp.StatusCode)
+	}
+	if err := json.Unmarshal(body, out); err != nil {
+		return fmt.Errorf("parse upstream models: %w", err)
+	}
+	return nil
+}
+
+func (s *AccountModelDiscoveryService) validateUpstreamBaseURL(raw string) (string, error) {
+	if s.cfg == nil {
+		return "", errors.New("config is not available")
+	}
+	if !s.cfg.Security.URLAllowlist.Enabled {
+		return urlvalidator.ValidateURLFormat(raw, s.cfg.Security.URLAllowlist.AllowInsecureHTTP)
+	}
+	return urlvalidator.ValidateHTTPSURL(raw, urlvalidator.ValidationOptions{
+		AllowedHosts:     s.cfg.Security.URLAllowlist.UpstreamHosts,
+		RequireAllowlist: true,
+		AllowPrivate:     s.cfg.Security.URLAllowlist.AllowPrivateHosts,
+	})
+}
+
+// normalizeDiscoveredModels 去除空值与重复项并排序，便于比较与展示
+func normalizeDiscoveredModels(models []string) []string {
+	seen := make(map[string]struct{}, len(models))
+	result := make([]string, 0, len(models))
+	for _, model := range models {
+		model = strings.TrimSpace(model)
+		if model == "" {
+			continue
+		}
+		if _, ok := seen[model]; ok {
+			continue
+		}
+		seen[model] = struct{}{}
+		result = append(result, model)
+	}
+	sort.Strings(result)
+	return result
+}
@@ -0,0 +1,68 @@
+package service
+
+import (
+	"strings"
+	"time"
+)
+
+// 账号模型能力矩阵：由 AccountModelDiscoveryService 从上游 /models 自动发现并写入 extra，
+// 调度时作为 model_mapping 之外的第二道过滤，避免将模型路由到实际不支持的账号。
+const (
+	accountDiscoveredModelsKey   = "discovered_models"
+	accountModelsDiscoveredAtKey = "models_discovered_at"
+)
+
+// GetDiscoveredModels 返回自动发现的上游模型列表，未发现时返回 nil
+func (a *Account) GetDiscoveredModels() []string {
+	if a.Extra == nil {
+		return nil
+	}
+	switch raw := a.Extra[accountDiscoveredModelsKey].(type) {
+	case []string:
+		return raw
+	case []any:
+		models := make([]string, 0, len(raw))
+		for _, item := range raw {
+			if s, ok := item.(string); ok && strings.TrimSpace(s) != "" {
+				models = append(models, s)
+			}
+		}
+		return models
+	}
+	return nil
+}
+
+// GetModelsDiscoveredAt 返回最近一次模型发现的时间
+func (a *Account) GetModelsDiscoveredAt() *time.Time {
+	raw := a.GetExtraString(accountModelsDiscoveredAtKey)
+	if raw == "" {
+		return nil
+	}
+	t, err := time.Parse(time.RFC3339, raw)
+	if err != nil {
+		return nil
+	}
+	return &t
+}
+
+// IsModelDiscovered 检查上游模型是否在自动发现的模型列表中。
+// 未发现过模型列表时返回 true（保持静态配置行为）；
+// 支持别名匹配：claude-sonnet-4-5 可匹配 claude-sonnet-4-5-20250929，xxx-latest 按前缀匹配。
+func (a *Account) IsModelDiscovered(model string) bool {
+	discovered := a.GetDiscoveredModels()
+	if len(discovered) == 0 {
+		return true
+	}
+	model = strings.ToLower(strings.TrimSpace(model))
+	if model == "" {
+		return true
+	}
+	alias := strings.TrimSuffix(model, "-latest")
+	for _, item := range discovered {
+		item = strings.ToLower(item)
+		if item == model || item == alias || strings.HasPrefix(item, alias+"-") {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,45 @@
+//go:build unit
+
+package service
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestAccount_IsModelDiscovered(t *testing.T) {
+	account := &Account{Extra: map[string]any{
+		"discovered_models": []any{"claude-sonnet-4-5-20250929", "claude-opus-4-1-20250805"},
+	}}
+
+	require.True(t, account.IsModelDiscovered("claude-sonnet-4-5-20250929"))
+	require.True(t, account.IsModelDiscovered("claude-sonnet-4-5"))
+	require.True(t, account.IsModelDiscovered("claude-opus-4-1-latest"))
+	require.False(t, account.IsModelDiscovered("claude-3-haiku-20240307"))
+	require.True(t, (&Account{}).IsModelDiscovered("anything"))
+}
+
+func TestAccount_IsModelSupported_UsesDiscoveredMatrix(t *testing.T) {
+	account := &Account{
+		Credentials: map[string]any{
+			"model_mapping": map[string]any{"gpt-4o": "gpt-5.2", "gpt-4.1": "gpt-4.1"},
+		},
+		Extra: map[string]any{"discovered_models": []any{"gpt-5.2"}},
+	}
+
+	require.True(t, account.IsModelSupported("gpt-4o"))
+	require.False(t, account.IsModelSupported("gpt-4.1"))
+	require.False(t, account.IsModelSupported("o3"))
+}
+
+func TestNormalizeDiscoveredModels(t *testing.T) {
+	require.Equal(t, []string{"a", "b"}, normalizeDiscoveredModels([]string{"b", " a ", "", "b"}))
+}
+
+func TestSupportsModelDiscovery(t *testing.T) {
+	require.True(t, supportsModelDiscovery(&Account{Platform: PlatformOpenAI, Type: AccountTypeAPIKey}))
+	require.False(t, supportsModelDiscovery(&Account{Platform: PlatformOpenAI, Type: AccountTypeOAuth}))
+	require.False(t, supportsModelDiscovery(&Account{Platform: PlatformAntigravity, Type: AccountTypeAPIKey}))
+	require.False(t, supportsModelDiscovery(nil))
+}
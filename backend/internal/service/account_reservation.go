@@ -0,0 +1,86 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"log"
+	"time"
+)
+
+// ErrAccountReservationExpired 预占在确认前已过期（槽位已被回收），调用方需要重新选择账号
+var ErrAccountReservationExpired = errors.New("account reservation expired")
+
+// accountReservationMaxStaleRetries 单次选择中因负载快照过期而重新排序的最大次数，超过后按槽位已满处理
+const accountReservationMaxStaleRetries = 8
+
+// AccountReservation 两阶段账号选择的槽位预占。
+// 选择阶段写入短期预占并计入账号负载，转发开始前调用 Commit 转为正式槽位；
+// 未确认的预占在过期后由槽位清理自动回收，不会因请求在转发前中断而长期占用并发。
+type AccountReservation struct {
+	AccountID int64
+	ExpiresAt time.Time
+
+	requestID string
+	cache     ConcurrencyCache
+}
+
+// Commit 将预占确认为正式槽位；预占已过期时返回 ErrAccountReservationExpired
+func (r *AccountReservation) Commit(ctx context.Context) error {
+	if r == nil || r.cache == nil {
+		return nil
+	}
+	committed, err := r.cache.CommitAccountSlot(ctx, r.AccountID, r.requestID)
+	if err != nil {
+		return err
+	}
+	if !committed {
+		return ErrAccountReservationExpired
+	}
+	return nil
+}
+
+// Release 释放预占或已确认的槽位
+func (r *AccountReservation) Release() {
+	if r == nil || r.cache == nil {
+		return
+	}
+	bgCtx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	if err := r.cache.ReleaseAccountSlot(bgCtx, r.AccountID, r.requestID); err != nil {
+		log.Printf("Warning: failed to release account reservation for %d (req=%s): %v", r.AccountID, r.requestID, err)
+	}
+}
+
+// reserveAccountWithLoad 对按负载排序选中的账号执行预占。
+// 预占成功返回选择结果；负载快照已过期（其他请求抢先占用）时刷新 item.loadInfo 并返回 stale=true，
+// 调用方应按最新负载重新排序而不是继续挤占该账号。未启用预占（ttl<=0）时退化为直接获取槽位。
+// 仅在负载感知路径调用，concurrencyService 不为 nil。
+func reserveAccountWithLoad(ctx context.Context, concurrencyService *ConcurrencyService, ttl time.Duration, item accountWithLoad) (*AccountSelectionResult, bool) {
+	account := item.account
+	if ttl <= 0 {
+		result, err := concurrencyService.AcquireAccountSlot(ctx, account.ID, account.Concurrency)
+		if err != nil || !result.Acquired {
+			return nil, false
+		}
+		return &AccountSelectionResult{Account: account, Acquired: true, ReleaseFunc: result.ReleaseFunc}, false
+	}
+
+	reservation, current, err := concurrencyService.ReserveAccountSlot(ctx, account.ID, account.Concurrency, item.loadInfo.CurrentConcurrency, ttl)
+	if err != nil {
+		return nil, false
+	}
+	if reservation == nil {
+		if current >= account.Concurrency {
+			return nil, false
+		}
+		item.loadInfo.CurrentConcurrency = current
+		item.loadInfo.LoadRate = (current + item.loadInfo.WaitingCount) * 100 / account.Concurrency
+		return nil, true
+	}
+	return &AccountSelectionResult{
+		Account:     account,
+		Acquired:    true,
+		ReleaseFunc: reservation.Release,
+		Reservation: reservation,
+	}, false
+}
@@ -0,0 +1,173 @@
+package service
+
+import (
+	"context"
+	"log"
+
+	"github.com/Wei-Shaw/sub2api/internal/config"
+)
+
+// StickySessionBinding 一条粘性会话绑定（分组 + 会话哈希）
+type StickySessionBinding struct {
+	GroupID     int64
+	SessionHash string
+}
+
+// AccountSessionIndex 粘性会话按账号的反向索引（GatewayCache 的可选能力）。
+// 账号被删除/禁用时据此找到仍绑定在该账号上的会话。
+type AccountSessionIndex interface {
+	// ListAccountSessions 返回当前仍绑定到该账号的会话，并顺带清理索引中已失效的条目
+	ListAccountSessions(ctx context.Context, accountID int64) ([]StickySessionBinding, error)
+	// ClearAccountSessionIndex 删除账号的反向索引
+	ClearAccountSessionIndex(ctx context.Context, accountID int64) error
+}
+
+// AccountSlotResetter 清空账号的并发槽位与等待计数（ConcurrencyCache 的可选能力）
+type AccountSlotResetter interface {
+	ResetAccountSlots(ctx context.Context, accountID int64) error
+}
+
+// AccountSessionCleanupResult 单个账号的会话清理结果
+type AccountSessionCleanupResult struct {
+	AccountID int64 `json:"account_id"`
+	Sessions  int   `json:"sessions"`
+	Rebound   int   `json:"rebound"`
+	Cleared   int   `json:"cleared"`
+}
+
+// AccountSessionCleanupService 账号被删除或禁用后清理其粘性会话绑定与并发计数，
+// 并可按配置将受影响的会话预先绑定到同分组的替代账号，避免会话在下一次请求时才失败重选。
+type AccountSessionCleanupService struct {
+	gatewayCache       GatewayCache
+	concurrencyService *ConcurrencyService
+	accountRepo        AccountRepository
+	rebind             bool
+}
+
+// NewAccountSessionCleanupService 创建账号会话清理服务
+func NewAccountSessionCleanupService(gatewayCache GatewayCache, concurrencyService *ConcurrencyService, accountRepo AccountRepository, cfg *config.Config) *AccountSessionCleanupService {
+	svc := &AccountSessionCleanupService{
+		gatewayCache:       gatewayCache,
+		concurrencyService: concurrencyService,
+		accountRepo:        accountRepo,
+	}
+	if cfg != nil {
+		svc.rebind = cfg.Gateway.Scheduling.RebindSessionsOnAccountRemoval
+	}
+	return svc
+}
+
+// needsSessionCleanup 账号已被管理员禁用、处于错误状态或被手动设为不可调度时需要清理
+func needsSessionCleanup(account *Account) bool {
+	return account.Status != StatusActive || !account.Schedulable
+}
+
+// CleanupAccount 清理账号的会话绑定与槽位。platform 为空时（账号已删除且无快照）只删除绑定、不做重绑。
+func (s *AccountSessionCleanupService) CleanupAccount(ctx context.Context, accountID int64, platform string) (*AccountSessionCleanupResult, error) {
+	result := &AccountSessionCleanupResult{AccountID: accountID}
+	if s == nil || accountID <= 0 {
+		return result, nil
+	}
+
+	if s.concurrencyService != nil {
+		if err := s.concurrencyService.ResetAccountSlots(ctx, accountID); err != nil {
+			log.Printf("[SessionCleanup] reset slots failed: account=%d err=%v", accountID, err)
+		}
+	}
+
+	index, ok := s.gatewayCache.(AccountSessionIndex)
+	if !ok {
+		return result, nil
+	}
+	bindings, err := index.ListAccountSessions(ctx, accountID)
+	if err != nil {
+		return result, err
+	}
+	result.Sessions = len(bindings)
+
+	candidatesByGroup := make(map[int64][]Account)
+	for _, binding := range bindings {
+		var replacement *Account
+		if s.rebind && platform != "" {
+			candidates, loaded := candidatesByGroup[binding.GroupID]
+			if !loaded {
+				candidates = s.loadReplacementCandidates(ctx, binding.GroupID, platform)
+				candidatesByGroup[binding.GroupID] = candidates
+			}
+			replacement = pickReplacementAccount(candidates, accountID, binding.SessionHash)
+		}
+		if replacement != nil {
+			if err := s.gatewayCache.SetSessionAccountID(ctx, binding.GroupID, binding.SessionHash, replacement.ID, stickySessionTTL); err == nil {
+				result.Rebound++
+				continue
+			}
+		}
+		if err := s.gatewayCache.DeleteSessionAccountID(ctx, binding.GroupID, binding.SessionHash); err != nil {
+			log.Printf("[SessionCleanup] delete binding failed: account=%d group=%d err=%v", accountID, binding.GroupID, err)
+			continue
+		}
+		result.Cleared++
+	}
+	if err := index.ClearAccountSessionIndex(ctx, accountID); err != nil {
+		log.Printf("[SessionCleanup] clear index failed: account=%d err=%v", accountID, err)
+	}
+	if result.Sessions > 0 {
+		log.Printf("[SessionCleanup] account=%d sessions=%d rebound=%d cleared=%d", accountID, result.Sessions, result.Rebound, result.Cleared)
+	}
+	return result, nil
+}
+
+func (s *AccountSessionCleanupService) loadReplacementCandidates(ctx context.Context, groupID int64, platform string) []Account {
+	if s.accountRepo == nil {
+		return nil
+	}
+	var (
+		accounts []Account
+		err      error
+	)
+	if groupID > 0 {
+		accounts, err = s.accountRepo.ListSchedulableByGroupIDAndPlatform(ctx, groupID, platform)
+	} else {
+		accounts, err = s.accountRepo.ListSchedulableByPlatform(ctx, platform)
+	}
+	if err != nil {
+		log.Printf("[SessionCleanup] list candidates failed: group=%d platform=%s err=%v", groupID, platform, err)
+		return nil
+	}
+	return accounts
+}
+
+// pickReplacementAccount 在最高优先级层内沿一致性哈希环选择替代账号，
+// 与该会话下一次新放置时的首选账号保持一致
+func pickReplacementAccount(candidates []Account, removedID int64, sessionHash string) *Account {
+	var best []*Account
+	for i := range candidates {
+		acc := &candidates[i]
+		if acc.ID == removedID || !acc.IsSchedulable() {
+			continue
+		}
+		if len(best) > 0 {
+			c := compareAccountPriority(acc, best[0])
+			if c > 0 {
+				continue
+			}
+			if c < 0 {
+				best = best[:0]
+			}
+		}
+		best = append(best, acc)
+	}
+	if len(best) == 0 {
+		return nil
+	}
+	byID := make(map[int64]*Account, len(best))
+	for _, acc := range best {
+		byID[acc.ID] = acc
+	}
+	var picked *Account
+	getSessionHashRing(best).walk(sessionHash, func(accountID int64) bool {
+		picked = byID[accountID]
+		return picked == nil
+	})
+	return picked
+}
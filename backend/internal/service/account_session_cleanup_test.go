@@ -0,0 +1,39 @@
+package service
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestNeedsSessionCleanup(t *testing.T) {
+	require.False(t, needsSessionCleanup(&Account{Status: StatusActive, Schedulable: true}))
+	require.True(t, needsSessionCleanup(&Account{Status: StatusDisabled, Schedulable: true}))
+	require.True(t, needsSessionCleanup(&Account{Status: StatusError, Schedulable: true}))
+	require.True(t, needsSessionCleanup(&Account{Status: StatusActive, Schedulable: false}))
+}
+
+func TestPickReplacementAccount(t *testing.T) {
+	candidates := []Account{
+		{ID: 1, Status: StatusActive, Schedulable: true, Priority: 1, Concurrency: 5},
+		{ID: 2, Status: StatusActive, Schedulable: true, Priority: 1, Concurrency: 5},
+		{ID: 3, Status: StatusActive, Schedulable: true, Priority: 9, Concurrency: 5},
+		{ID: 4, Status: StatusActive, Schedulable: false, Priority: 0, Concurrency: 5},
+	}
+
+	// 只在最高优先级层内选择，且跳过被移除账号与不可调度账号
+	for _, hash := range []string{"a", "b", "c", "d", "e"} {
+		picked := pickReplacementAccount(candidates, 1, hash)
+		require.NotNil(t, picked)
+		require.Equal(t, int64(2), picked.ID)
+	}
+
+	// 同一会话多次选择结果稳定
+	first := pickReplacementAccount(candidates, 99, "session-x")
+	require.NotNil(t, first)
+	require.Equal(t, first.ID, pickReplacementAccount(candidates, 99, "session-x").ID)
+	require.Contains(t, []int64{1, 2}, first.ID)
+
+	require.Nil(t, pickReplacementAccount(candidates[3:], 1, "a"))
+	require.Nil(t, pickReplacementAccount(nil, 1, "a"))
+}
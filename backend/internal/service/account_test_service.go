@@ -150,7 +150,30 @@ func (s *AccountTestService) TestAccountConnection(c *gin.Context, accountID int
 		return s.sendErrorAndEnd(c, "Account not found")
 	}
 
-	// Route to platform-specific test method
+	return s.testAccount(c, account, modelID)
+}
+
+// probeResponseCaptureLimit caps the captured SSE test output of a probe
+const probeResponseCaptureLimit = 64 * 1024
+
+// ProbeAccount sends one test request with the given account, which may carry candidate
+// credentials that are not persisted yet. The SSE output is discarded; nil means success.
+func (s *AccountTestService) ProbeAccount(ctx context.Context, account *Account, modelID string) error {
+	if account == nil {
+		return errors.New("account is nil")
+	}
+	w := newLimitedResponseWriter(probeResponseCaptureLimit)
+	c, _ := gin.CreateTestContext(w)
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, "http://localhost/", nil)
+	if err != nil {
+		return err
+	}
+	c.Request = req
+	return s.testAccount(c, account, modelID)
+}
+
+// testAccount routes to the platform-specific test method
+func (s *AccountTestService) testAccount(c *gin.Context, account *Account, modelID string) error {
 	if account.IsOpenAI() {
 		return s.testOpenAIAccountConnection(c, account, modelID)
 	}
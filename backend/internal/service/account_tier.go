@@ -0,0 +1,41 @@
+package service
+
+import "strings"
+
+// 账号调度层级：同一分组内先使用 primary 层，当该层账号并发占满、额度耗尽或不可调度时溢出到下一层。
+const (
+	AccountTierPrimary   = 1
+	AccountTierSecondary = 2
+	AccountTierBackup    = 3
+)
+
+// parseAccountTier 解析层级配置，支持数字（1/2/3）与名称（primary/secondary/backup）
+func parseAccountTier(value any) int {
+	if s, ok := value.(string); ok {
+		switch strings.ToLower(strings.TrimSpace(s)) {
+		case "primary":
+			return AccountTierPrimary
+		case "secondary":
+			return AccountTierSecondary
+		case "backup":
+			return AccountTierBackup
+		}
+	}
+	tier := parseExtraInt(value)
+	if tier < AccountTierPrimary {
+		return AccountTierPrimary
+	}
+	if tier > AccountTierBackup {
+		return AccountTierBackup
+	}
+	return tier
+}
+
+// compareAccountPriority 按调度顺序比较两个账号：先比较层级，再比较优先级（数值越小越优先）。
+// 返回值 <0 表示 a 优先，>0 表示 b 优先，0 表示同层同优先级。
+func compareAccountPriority(a, b *Account) int {
+	if ta, tb := a.GetTier(), b.GetTier(); ta != tb {
+		return ta - tb
+	}
+	return a.Priority - b.Priority
+}
@@ -0,0 +1,39 @@
+//go:build unit
+
+package service
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestAccount_GetTier(t *testing.T) {
+	cases := []struct {
+		name  string
+		extra map[string]any
+		want  int
+	}{
+		{name: "unset", extra: nil, want: AccountTierPrimary},
+		{name: "name", extra: map[string]any{"tier": "Secondary"}, want: AccountTierSecondary},
+		{name: "number", extra: map[string]any{"tier": float64(3)}, want: AccountTierBackup},
+		{name: "numeric string", extra: map[string]any{"tier": "2"}, want: AccountTierSecondary},
+		{name: "out of range", extra: map[string]any{"tier": 9}, want: AccountTierBackup},
+		{name: "invalid", extra: map[string]any{"tier": "gold"}, want: AccountTierPrimary},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			require.Equal(t, tc.want, (&Account{Extra: tc.extra}).GetTier())
+		})
+	}
+}
+
+func TestSortAccountsByPriorityAndLastUsed_TierFirst(t *testing.T) {
+	accounts := []*Account{
+		{ID: 1, Priority: 1, Extra: map[string]any{"tier": "backup"}},
+		{ID: 2, Priority: 10, Extra: map[string]any{"tier": "secondary"}},
+		{ID: 3, Priority: 50},
+	}
+	sortAccountsByPriorityAndLastUsed(accounts, false)
+	require.Equal(t, []int64{3, 2, 1}, []int64{accounts[0].ID, accounts[1].ID, accounts[2].ID})
+}
@@ -47,6 +47,8 @@ type UsageLogRepository interface {
 
 	// Admin usage listing/stats
 	ListWithFilters(ctx context.Context, params pagination.PaginationParams, filters usagestats.UsageLogFilters) ([]UsageLog, *pagination.PaginationResult, error)
+	// ExportWithFilters 按 id 升序分批遍历筛选范围内的全部记录（用于导出），fn 返回错误时停止
+	ExportWithFilters(ctx context.Context, filters usagestats.UsageLogFilters, batchSize int, fn func([]UsageLog) error) error
 	GetGlobalStats(ctx context.Context, startTime, endTime time.Time) (*usagestats.UsageStats, error)
 	GetStatsWithFilters(ctx context.Context, filters usagestats.UsageLogFilters) (*usagestats.UsageStats, error)
 
@@ -55,7 +57,11 @@ type UsageLogRepository interface {
 
 	// Aggregated stats (optimized)
 	GetUserStatsAggregated(ctx context.Context, userID int64, startTime, endTime time.Time) (*usagestats.UsageStats, error)
+	// GetUserBillableCost 用户在时间范围内应计费的实际费用（不含仅计量分组的记录）
+	GetUserBillableCost(ctx context.Context, userID int64, startTime, endTime time.Time) (float64, error)
 	GetAPIKeyStatsAggregated(ctx context.Context, apiKeyID int64, startTime, endTime time.Time) (*usagestats.UsageStats, error)
+	GetAPIKeyCacheUsageByModel(ctx context.Context, apiKeyID int64, startTime, endTime time.Time) ([]usagestats.ModelCacheUsage, error)
+	GetAPIKeyToolUsage(ctx context.Context, apiKeyID int64, startTime, endTime time.Time) (*ToolUsage, error)
 	GetAccountStatsAggregated(ctx context.Context, accountID int64, startTime, endTime time.Time) (*usagestats.UsageStats, error)
 	GetModelStatsAggregated(ctx context.Context, modelName string, startTime, endTime time.Time) (*usagestats.UsageStats, error)
 	GetDailyStatsAggregated(ctx context.Context, userID int64, startTime, endTime time.Time) ([]map[string]any, error)
@@ -0,0 +1,166 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"reflect"
+	"sort"
+	"time"
+
+	infraerrors "github.com/Wei-Shaw/sub2api/internal/pkg/errors"
+	"github.com/Wei-Shaw/sub2api/internal/pkg/pagination"
+)
+
+var ErrAdminActionLogNotFound = infraerrors.NotFound("ADMIN_ACTION_LOG_NOT_FOUND", "admin action log not found")
+
+// adminActionLogMaxBodyBytes 请求体脱敏后保存的最大字节数
+const adminActionLogMaxBodyBytes = 16 * 1024
+
+// AdminActionFieldChange 单个顶层字段的变更
+type AdminActionFieldChange struct {
+	Field  string `json:"field"`
+	Before any    `json:"before"`
+	After  any    `json:"after"`
+}
+
+// AdminActionLog 管理后台变更操作审计记录
+type AdminActionLog struct {
+	ID           int64  `json:"id"`
+	ActorUserID  *int64 `json:"actor_user_id"`
+	ActorEmail   string `json:"actor_email"`
+	AuthMethod   string `json:"auth_method"` // jwt / admin_api_key
+	Method       string `json:"method"`
+	Path         string `json:"path"`
+	Route        string `json:"route"`
+	ResourceType string `json:"resource_type"`
+	ResourceID   *int64 `json:"resource_id"`
+	StatusCode   int    `json:"status_code"`
+	ClientIP     string `json:"client_ip"`
+	UserAgent    string `json:"user_agent"`
+	// 以下字段仅在详情中返回
+	RequestBody *string                  `json:"request_body,omitempty"`
+	Before      json.RawMessage          `json:"before,omitempty"`
+	After       json.RawMessage          `json:"after,omitempty"`
+	Changes     []AdminActionFieldChange `json:"changes,omitempty"`
+	CreatedAt   time.Time                `json:"created_at"`
+}
+
+// AdminActionLogFilter 操作审计查询条件
+type AdminActionLogFilter struct {
+	StartTime    *time.Time
+	EndTime      *time.Time
+	ActorUserID  *int64
+	ResourceType string
+	ResourceID   *int64
+	Method       string
+	// Search 按请求路径或操作人邮箱模糊匹配
+	Search string
+}
+
+// AdminActionLogRepository 操作审计持久化端口
+type AdminActionLogRepository interface {
+	Create(ctx context.Context, entry *AdminActionLog) error
+	// List 按创建时间倒序分页查询（列表不返回请求体与快照）
+	List(ctx context.Context, params pagination.PaginationParams, filter AdminActionLogFilter) ([]AdminActionLog, *pagination.PaginationResult, error)
+	GetByID(ctx context.Context, id int64) (*AdminActionLog, error)
+}
+
+// AdminActionLogService 管理后台操作审计：记录每次变更操作的操作人、变更前后快照与字段差异
+type AdminActionLogService struct {
+	repo AdminActionLogRepository
+}
+
+// NewAdminActionLogService 创建操作审计服务
+func NewAdminActionLogService(repo AdminActionLogRepository) *AdminActionLogService {
+	return &AdminActionLogService{repo: repo}
+}
+
+// Record 写入一条操作审计记录；Before/After 非空时自动计算字段差异
+func (s *AdminActionLogService) Record(ctx context.Context, entry *AdminActionLog) error {
+	if s == nil || s.repo == nil || entry == nil {
+		return nil
+	}
+	if entry.Changes == nil && (len(entry.Before) > 0 || len(entry.After) > 0) {
+		entry.Changes = DiffAdminSnapshots(entry.Before, entry.After)
+	}
+	return s.repo.Create(ctx, entry)
+}
+
+// List 分页查询操作审计
+func (s *AdminActionLogService) List(ctx context.Context, params pagination.PaginationParams, filter AdminActionLogFilter) ([]AdminActionLog, *pagination.PaginationResult, error) {
+	return s.repo.List(ctx, params, filter)
+}
+
+// GetByID 获取单条操作审计（含请求体与快照）
+func (s *AdminActionLogService) GetByID(ctx context.Context, id int64) (*AdminActionLog, error) {
+	return s.repo.GetByID(ctx, id)
+}
+
+// SanitizeAdminSnapshot 将资源快照序列化为 JSON 并脱敏凭证/密钥类字段；无法序列化时返回 nil
+func SanitizeAdminSnapshot(v any) json.RawMessage {
+	if v == nil {
+		return nil
+	}
+	raw, err := json.Marshal(v)
+	if err != nil {
+		return nil
+	}
+	var decoded any
+	if err := json.Unmarshal(raw, &decoded); err != nil || decoded == nil {
+		return nil
+	}
+	out, err := json.Marshal(redactSensitiveJSON(decoded))
+	if err != nil {
+		return nil
+	}
+	return out
+}
+
+// SanitizeAdminRequestBody 脱敏并截断请求体；非 JSON 或为空时返回 nil
+func SanitizeAdminRequestBody(raw []byte) *string {
+	body, _, _ := sanitizeAndTrimRequestBody(raw, adminActionLogMaxBodyBytes)
+	if body == "" {
+		return nil
+	}
+	return &body
+}
+
+// DiffAdminSnapshots 比较两个 JSON 对象快照的顶层字段，按字段名排序返回差异。
+// 任一侧不是对象时整体视为一个字段变更（创建/删除时另一侧为 nil）。
+func DiffAdminSnapshots(before, after json.RawMessage) []AdminActionFieldChange {
+	var b, a map[string]any
+	beforeIsObject := len(before) == 0 || json.Unmarshal(before, &b) == nil
+	afterIsObject := len(after) == 0 || json.Unmarshal(after, &a) == nil
+	if !beforeIsObject || !afterIsObject {
+		var bv, av any
+		_ = json.Unmarshal(before, &bv)
+		_ = json.Unmarshal(after, &av)
+		if reflect.DeepEqual(bv, av) {
+			return nil
+		}
+		return []AdminActionFieldChange{{Field: "", Before: bv, After: av}}
+	}
+
+	fields := make(map[string]struct{}, len(b)+len(a))
+	for k := range b {
+		fields[k] = struct{}{}
+	}
+	for k := range a {
+		fields[k] = struct{}{}
+	}
+	names := make([]string, 0, len(fields))
+	for k := range fields {
+		names = append(names, k)
+	}
+	sort.Strings(names)
+
+	var changes []AdminActionFieldChange
+	for _, k := range names {
+		bv, av := b[k], a[k]
+		if reflect.DeepEqual(bv, av) {
+			continue
+		}
+		changes = append(changes, AdminActionFieldChange{Field: k, Before: bv, After: av})
+	}
+	return changes
+}
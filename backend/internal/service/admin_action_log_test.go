@@ -0,0 +1,49 @@
+//go:build unit
+
+package service
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestDiffAdminSnapshots(t *testing.T) {
+	changes := DiffAdminSnapshots(
+		json.RawMessage(`{"name":"a","priority":1,"extra":{"x":1},"gone":true}`),
+		json.RawMessage(`{"name":"b","priority":1,"extra":{"x":2},"added":"y"}`),
+	)
+	require.Equal(t, []AdminActionFieldChange{
+		{Field: "added", Before: nil, After: "y"},
+		{Field: "extra", Before: map[string]any{"x": float64(1)}, After: map[string]any{"x": float64(2)}},
+		{Field: "gone", Before: true, After: nil},
+		{Field: "name", Before: "a", After: "b"},
+	}, changes)
+
+	// 创建：变更前为空
+	changes = DiffAdminSnapshots(nil, json.RawMessage(`{"id":1}`))
+	require.Equal(t, []AdminActionFieldChange{{Field: "id", Before: nil, After: float64(1)}}, changes)
+
+	// 无变化
+	require.Empty(t, DiffAdminSnapshots(json.RawMessage(`{"a":1}`), json.RawMessage(`{"a":1}`)))
+
+	// 非对象快照整体比较
+	changes = DiffAdminSnapshots(json.RawMessage(`[1]`), json.RawMessage(`[2]`))
+	require.Len(t, changes, 1)
+	require.Equal(t, "", changes[0].Field)
+}
+
+func TestSanitizeAdminSnapshotRedactsCredentials(t *testing.T) {
+	raw := SanitizeAdminSnapshot(map[string]any{
+		"name":        "acc",
+		"credentials": map[string]any{"api_key": "sk-secret"},
+		"password":    "hunter2",
+	})
+	require.NotNil(t, raw)
+	require.NotContains(t, string(raw), "sk-secret")
+	require.NotContains(t, string(raw), "hunter2")
+	require.Contains(t, string(raw), `"name":"acc"`)
+
+	require.Nil(t, SanitizeAdminSnapshot(nil))
+}
@@ -0,0 +1,136 @@
+package service
+
+import (
+	"net/http"
+	"strings"
+
+	infraerrors "github.com/Wei-Shaw/sub2api/internal/pkg/errors"
+)
+
+var (
+	ErrAdminRoleImmutable = infraerrors.Forbidden("ADMIN_ROLE_IMMUTABLE", "super admin role cannot be granted or revoked")
+	ErrInvalidUserRole    = infraerrors.BadRequest("INVALID_USER_ROLE", "invalid user role")
+)
+
+// AdminPermission 管理后台权限点，按资源域划分读/写
+type AdminPermission string
+
+const (
+	AdminPermDashboardRead AdminPermission = "dashboard:read"
+	AdminPermUsageRead     AdminPermission = "usage:read"
+	AdminPermUsageWrite    AdminPermission = "usage:write"
+	AdminPermUsersRead     AdminPermission = "users:read"
+	AdminPermUsersWrite    AdminPermission = "users:write"
+	// AdminPermAccountsRead 账号详情包含上游凭证，仅授予需要管理账号的角色
+	AdminPermAccountsRead  AdminPermission = "accounts:read"
+	AdminPermAccountsWrite AdminPermission = "accounts:write"
+	AdminPermGroupsRead    AdminPermission = "groups:read"
+	AdminPermGroupsWrite   AdminPermission = "groups:write"
+	AdminPermProxiesRead   AdminPermission = "proxies:read"
+	AdminPermProxiesWrite  AdminPermission = "proxies:write"
+	AdminPermOpsRead       AdminPermission = "ops:read"
+	AdminPermOpsWrite      AdminPermission = "ops:write"
+	AdminPermBillingRead   AdminPermission = "billing:read"
+	AdminPermBillingWrite  AdminPermission = "billing:write"
+	// AdminPermSystem 系统设置、公告、审计与回收站等，仅超级管理员
+	AdminPermSystem AdminPermission = "system"
+)
+
+// AdminRolePermissions 各受限管理角色拥有的权限；超级管理员（admin）拥有全部权限，不在此列出
+var AdminRolePermissions = map[string][]AdminPermission{
+	RoleOperator: {
+		AdminPermDashboardRead,
+		AdminPermUsageRead,
+		AdminPermUsersRead,
+		AdminPermAccountsRead, AdminPermAccountsWrite,
+		AdminPermGroupsRead, AdminPermGroupsWrite,
+		AdminPermProxiesRead, AdminPermProxiesWrite,
+		AdminPermOpsRead, AdminPermOpsWrite,
+		AdminPermBillingRead,
+	},
+	RoleBillingViewer: {
+		AdminPermDashboardRead,
+		AdminPermUsageRead,
+		AdminPermUsersRead,
+		AdminPermBillingRead,
+	},
+	RoleSupport: {
+		AdminPermDashboardRead,
+		AdminPermUsageRead,
+		AdminPermUsersRead,
+		AdminPermGroupsRead,
+		AdminPermOpsRead,
+	},
+}
+
+// adminResourcePermissions 管理路由首段 -> 读/写权限；未列出的路由仅超级管理员可访问
+var adminResourcePermissions = map[string][2]AdminPermission{
+	"dashboard":               {AdminPermDashboardRead, AdminPermSystem},
+	"usage":                   {AdminPermUsageRead, AdminPermUsageWrite},
+	"users":                   {AdminPermUsersRead, AdminPermUsersWrite},
+	"user-attributes":         {AdminPermUsersRead, AdminPermUsersWrite},
+	"accounts":                {AdminPermAccountsRead, AdminPermAccountsWrite},
+	"openai":                  {AdminPermAccountsWrite, AdminPermAccountsWrite},
+	"gemini":                  {AdminPermAccountsWrite, AdminPermAccountsWrite},
+	"antigravity":             {AdminPermAccountsWrite, AdminPermAccountsWrite},
+	"groups":                  {AdminPermGroupsRead, AdminPermGroupsWrite},
+	"proxies":                 {AdminPermProxiesRead, AdminPermProxiesWrite},
+	"ops":                     {AdminPermOpsRead, AdminPermOpsWrite},
+	"error-passthrough-rules": {AdminPermOpsRead, AdminPermOpsWrite},
+	"redeem-codes":            {AdminPermBillingRead, AdminPermBillingWrite},
+	"promo-codes":             {AdminPermBillingRead, AdminPermBillingWrite},
+	"subscriptions":           {AdminPermBillingRead, AdminPermBillingWrite},
+	"model-prices":            {AdminPermBillingRead, AdminPermBillingWrite},
+}
+
+// adminRouteOverrides 按 "METHOD 路由模板" 覆盖默认的读/写划分：
+// 以 POST 承载的批量查询按读权限处理；返回完整密钥的只读接口按写权限处理
+var adminRouteOverrides = map[string]AdminPermission{
+	"POST /api/v1/admin/dashboard/users-usage":    AdminPermDashboardRead,
+	"POST /api/v1/admin/dashboard/api-keys-usage": AdminPermDashboardRead,
+	"GET /api/v1/admin/users/:id/api-keys":        AdminPermUsersWrite,
+	"GET /api/v1/admin/groups/:id/api-keys":       AdminPermUsersWrite,
+}
+
+// IsAdminRole 角色是否可登录管理后台
+func IsAdminRole(role string) bool {
+	if role == RoleAdmin {
+		return true
+	}
+	_, ok := AdminRolePermissions[role]
+	return ok
+}
+
+// AdminRoleHasPermission 判断角色是否拥有指定权限
+func AdminRoleHasPermission(role string, perm AdminPermission) bool {
+	if role == RoleAdmin {
+		return true
+	}
+	for _, p := range AdminRolePermissions[role] {
+		if p == perm {
+			return true
+		}
+	}
+	return false
+}
+
+// AdminRoutePermission 返回访问指定管理路由（gin 路由模板，如 /api/v1/admin/accounts/:id）所需的权限
+func AdminRoutePermission(method, route string) AdminPermission {
+	rest := strings.TrimPrefix(route, "/api/v1/admin/")
+	if rest == route {
+		return AdminPermSystem
+	}
+	resource, _, _ := strings.Cut(rest, "/")
+	if perm, ok := adminRouteOverrides[method+" "+route]; ok {
+		return perm
+	}
+	perms, ok := adminResourcePermissions[resource]
+	if !ok {
+		return AdminPermSystem
+	}
+	switch method {
+	case http.MethodGet, http.MethodHead, http.MethodOptions:
+		return perms[0]
+	}
+	return perms[1]
+}
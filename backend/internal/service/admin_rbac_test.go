@@ -0,0 +1,61 @@
+//go:build unit
+
+package service
+
+import (
+	"net/http"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestAdminRoutePermission(t *testing.T) {
+	cases := []struct {
+		method string
+		route  string
+		want   AdminPermission
+	}{
+		{http.MethodGet, "/api/v1/admin/usage", AdminPermUsageRead},
+		{http.MethodPost, "/api/v1/admin/usage/cleanup-tasks", AdminPermUsageWrite},
+		{http.MethodGet, "/api/v1/admin/accounts/:id", AdminPermAccountsRead},
+		{http.MethodPut, "/api/v1/admin/accounts/:id", AdminPermAccountsWrite},
+		{http.MethodGet, "/api/v1/admin/openai/oauth/auth-url", AdminPermAccountsWrite},
+		{http.MethodPost, "/api/v1/admin/dashboard/users-usage", AdminPermDashboardRead},
+		{http.MethodPost, "/api/v1/admin/dashboard/aggregation/backfill", AdminPermSystem},
+		{http.MethodGet, "/api/v1/admin/users/:id/api-keys", AdminPermUsersWrite},
+		{http.MethodGet, "/api/v1/admin/groups/:id/api-keys", AdminPermUsersWrite},
+		{http.MethodGet, "/api/v1/admin/groups/:id", AdminPermGroupsRead},
+		{http.MethodGet, "/api/v1/admin/settings", AdminPermSystem},
+		{http.MethodGet, "/api/v1/admin/action-logs", AdminPermSystem},
+		{http.MethodGet, "", AdminPermSystem},
+	}
+	for _, tc := range cases {
+		require.Equal(t, tc.want, AdminRoutePermission(tc.method, tc.route), "%s %s", tc.method, tc.route)
+	}
+}
+
+func TestAdminRoleHasPermission(t *testing.T) {
+	require.True(t, AdminRoleHasPermission(RoleAdmin, AdminPermSystem))
+
+	// 客服可查看用量，但不能读取账号（含上游凭证）
+	require.True(t, AdminRoleHasPermission(RoleSupport, AdminPermUsageRead))
+	require.False(t, AdminRoleHasPermission(RoleSupport, AdminPermAccountsRead))
+	require.False(t, AdminRoleHasPermission(RoleSupport, AdminPermUsersWrite))
+
+	require.True(t, AdminRoleHasPermission(RoleBillingViewer, AdminPermBillingRead))
+	require.False(t, AdminRoleHasPermission(RoleBillingViewer, AdminPermBillingWrite))
+
+	require.True(t, AdminRoleHasPermission(RoleOperator, AdminPermAccountsWrite))
+	require.False(t, AdminRoleHasPermission(RoleOperator, AdminPermSystem))
+
+	require.False(t, AdminRoleHasPermission(RoleUser, AdminPermDashboardRead))
+	require.False(t, AdminRoleHasPermission("", AdminPermDashboardRead))
+}
+
+func TestIsAdminRole(t *testing.T) {
+	for _, role := range []string{RoleAdmin, RoleOperator, RoleBillingViewer, RoleSupport} {
+		require.True(t, IsAdminRole(role), role)
+	}
+	require.False(t, IsAdminRole(RoleUser))
+	require.False(t, IsAdminRole("root"))
+}
@@ -5,9 +5,11 @@ import (
 	"errors"
 	"fmt"
 	"log"
+	"net/http"
 	"strings"
 	"time"
 
+	infraerrors "github.com/Wei-Shaw/sub2api/internal/pkg/errors"
 	"github.com/Wei-Shaw/sub2api/internal/pkg/pagination"
 )
 
@@ -37,9 +39,13 @@ type AdminService interface {
 	DeleteGroup(ctx context.Context, id int64) error
 	GetGroupAPIKeys(ctx context.Context, groupID int64, page, pageSize int) ([]APIKey, int64, error)
 	UpdateGroupSortOrders(ctx context.Context, updates []GroupSortOrderUpdate) error
+	// CloneGroup 以现有分组为模板创建新分组（复制模型路由/策略/限额等配置，可选关联或复制账号）
+	CloneGroup(ctx context.Context, sourceID int64, input *CloneGroupInput) (*CloneGroupResult, error)
+	// SetGroupMaintenance 切换分组维护状态：维护中的分组对新请求返回维护提示
+	SetGroupMaintenance(ctx context.Context, id int64, enabled bool) (*Group, error)
 
 	// Account management
-	ListAccounts(ctx context.Context, page, pageSize int, platform, accountType, status, search string, groupID int64) ([]Account, int64, error)
+	ListAccounts(ctx context.Context, page, pageSize int, platform, accountType, status, search string, groupID int64, label string) ([]Account, int64, error)
 	GetAccount(ctx context.Context, id int64) (*Account, error)
 	GetAccountsByIDs(ctx context.Context, ids []int64) ([]*Account, error)
 	CreateAccount(ctx context.Context, input *CreateAccountInput) (*Account, error)
@@ -49,6 +55,8 @@ type AdminService interface {
 	ClearAccountError(ctx context.Context, id int64) (*Account, error)
 	SetAccountError(ctx context.Context, id int64, errorMsg string) error
 	SetAccountSchedulable(ctx context.Context, id int64, schedulable bool) (*Account, error)
+	// SetAccountMaintenance 切换账号维护状态：维护中的账号不参与调度，粘性会话在下次命中时迁移
+	SetAccountMaintenance(ctx context.Context, id int64, enabled bool) (*Account, error)
 	BulkUpdateAccounts(ctx context.Context, input *BulkUpdateAccountsInput) (*BulkUpdateAccountsResult, error)
 
 	// Proxy management
@@ -94,6 +102,7 @@ type UpdateUserInput struct {
 	Balance       *float64 // 使用指针区分"未提供"和"设置为0"
 	Concurrency   *int     // 使用指针区分"未提供"和"设置为0"
 	Status        string
+	Role          string   // user/operator/billing_viewer/support，空表示不修改
 	AllowedGroups *[]int64 // 使用指针区分"未提供"和"设置为空数组"
 	// GroupRates 用户专属分组倍率配置
 	// map[groupID]*rate，nil 表示删除该分组的专属倍率
@@ -121,9 +130,25 @@ type CreateGroupInput struct {
 	// 模型路由配置（仅 anthropic 平台使用）
 	ModelRouting        map[string][]int64
 	ModelRoutingEnabled bool // 是否启用模型路由
-	MCPXMLInject        *bool
+	// 模型参数策略（temperature/top_p 范围约束）
+	ModelParamPolicies map[string]ModelParamPolicy
+	// 区域策略
+	RegionPolicy RegionPolicy
+	// 系统提示词注入策略
+	SystemPromptPolicy SystemPromptPolicy
+	// 提示词前缀/后缀模板
+	PromptTemplate PromptTemplate
+	// 模型访问策略（允许/禁止请求的模型）
+	ModelAccessPolicy ModelAccessPolicy
+	// 重试策略（可重试状态码、退避与总时间预算）
+	RetryPolicy  RetryPolicy
+	MCPXMLInject *bool
 	// 支持的模型系列（仅 antigravity 平台使用）
 	SupportedModelScopes []string
+	// 仅计量模式（记录用量但不扣费）
+	MeteringOnly bool
+	// 严格字段模式（拒绝未知顶层字段）
+	StrictRequestFields bool
 	// 从指定分组复制账号（创建分组后在同一事务内绑定）
 	CopyAccountsFromGroupIDs []int64
 }
@@ -150,16 +175,55 @@ type UpdateGroupInput struct {
 	// 模型路由配置（仅 anthropic 平台使用）
 	ModelRouting        map[string][]int64
 	ModelRoutingEnabled *bool // 是否启用模型路由
-	MCPXMLInject        *bool
+	// 模型参数策略（temperature/top_p 范围约束）
+	ModelParamPolicies map[string]ModelParamPolicy
+	// 区域策略（nil 表示不修改）
+	RegionPolicy *RegionPolicy
+	// 系统提示词注入策略（nil 表示不修改）
+	SystemPromptPolicy *SystemPromptPolicy
+	// 提示词前缀/后缀模板（nil 表示不修改）
+	PromptTemplate *PromptTemplate
+	// 模型访问策略（nil 表示不修改）
+	ModelAccessPolicy *ModelAccessPolicy
+	// 重试策略（nil 表示不修改）
+	RetryPolicy  *RetryPolicy
+	MCPXMLInject *bool
 	// 支持的模型系列（仅 antigravity 平台使用）
 	SupportedModelScopes *[]string
+	// 仅计量模式（nil 表示不修改）
+	MeteringOnly *bool
+	// 严格字段模式（nil 表示不修改）
+	StrictRequestFields *bool
 	// 从指定分组复制账号（同步操作：先清空当前分组的账号绑定，再绑定源分组的账号）
 	CopyAccountsFromGroupIDs []int64
 }
 
+// 分组克隆时的账号处理方式
+const (
+	GroupCloneAccountsNone = "none" // 不带账号
+	GroupCloneAccountsLink = "link" // 新分组与源分组共享同一批账号
+	GroupCloneAccountsCopy = "copy" // 复制账号记录（OAuth/Setup Token 账号的 refresh_token 不能共用，改为关联）
+)
+
+type CloneGroupInput struct {
+	Name           string
+	Description    *string  // nil 表示沿用源分组描述
+	RateMultiplier *float64 // nil 表示沿用源分组倍率
+	IsExclusive    *bool    // nil 表示沿用源分组设置
+	// AccountMode 账号处理方式：none（默认）/link/copy
+	AccountMode string
+}
+
+type CloneGroupResult struct {
+	Group              *Group
+	LinkedAccountCount int
+	CopiedAccountCount int
+}
+
 type CreateAccountInput struct {
 	Name               string
 	Notes              *string
+	Labels             []string // 账号标签，写入 extra.labels
 	Platform           string
 	Type               string
 	Credentials        map[string]any
@@ -181,7 +245,8 @@ type CreateAccountInput struct {
 type UpdateAccountInput struct {
 	Name                  string
 	Notes                 *string
-	Type                  string // Account type: oauth, setup-token, apikey
+	Labels                *[]string // nil 表示不修改，空数组表示清除全部标签
+	Type                  string    // Account type: oauth, setup-token, apikey
 	Credentials           map[string]any
 	Extra                 map[string]any
 	ProxyID               *int64
@@ -208,6 +273,11 @@ type BulkUpdateAccountsInput struct {
 	GroupIDs       *[]int64
 	Credentials    map[string]any
 	Extra          map[string]any
+	// Label selects accounts carrying this label when AccountIDs is empty.
+	Label string
+	// AddLabels / RemoveLabels edit each selected account's labels.
+	AddLabels    []string
+	RemoveLabels []string
 	// SkipMixedChannelCheck skips the mixed channel risk check when binding groups.
 	// This should only be set when the caller has explicitly confirmed the risk.
 	SkipMixedChannelCheck bool
@@ -429,6 +499,17 @@ func (s *adminServiceImpl) UpdateUser(ctx context.Context, id int64, input *Upda
 		user.Status = input.Status
 	}
 
+	if input.Role != "" && input.Role != user.Role {
+		// 超级管理员角色不可通过接口授予或撤销
+		if user.Role == RoleAdmin || input.Role == RoleAdmin {
+			return nil, ErrAdminRoleImmutable
+		}
+		if input.Role != RoleUser && !IsAdminRole(input.Role) {
+			return nil, ErrInvalidUserRole
+		}
+		user.Role = input.Role
+	}
+
 	if input.Concurrency != nil {
 		user.Concurrency = *input.Concurrency
 	}
@@ -640,6 +721,26 @@ func (s *adminServiceImpl) CreateGroup(ctx context.Context, input *CreateGroupIn
 	imagePrice2K := normalizePrice(input.ImagePrice2K)
 	imagePrice4K := normalizePrice(input.ImagePrice4K)
 
+	if err := ValidateModelParamPolicies(input.ModelParamPolicies); err != nil {
+		return nil, err
+	}
+	modelAccessPolicy, err := NormalizeModelAccessPolicy(input.ModelAccessPolicy)
+	if err != nil {
+		return nil, err
+	}
+	retryPolicy, err := NormalizeRetryPolicy(input.RetryPolicy)
+	if err != nil {
+		return nil, err
+	}
+	systemPromptPolicy, err := NormalizeSystemPromptPolicy(input.SystemPromptPolicy)
+	if err != nil {
+		return nil, err
+	}
+	promptTemplate, err := NormalizePromptTemplate(input.PromptTemplate)
+	if err != nil {
+		return nil, err
+	}
+
 	// 校验降级分组
 	if input.FallbackGroupID != nil {
 		if err := s.validateFallbackGroup(ctx, 0, *input.FallbackGroupID); err != nil {
@@ -713,8 +814,16 @@ func (s *adminServiceImpl) CreateGroup(ctx context.Context, input *CreateGroupIn
 		FallbackGroupID:                 input.FallbackGroupID,
 		FallbackGroupIDOnInvalidRequest: fallbackOnInvalidRequest,
 		ModelRouting:                    input.ModelRouting,
+		ModelParamPolicies:              input.ModelParamPolicies,
+		RegionPolicy:                    input.RegionPolicy,
+		SystemPromptPolicy:              systemPromptPolicy,
+		PromptTemplate:                  promptTemplate,
+		ModelAccessPolicy:               modelAccessPolicy,
+		RetryPolicy:                     retryPolicy,
 		MCPXMLInject:                    mcpXMLInject,
 		SupportedModelScopes:            input.SupportedModelScopes,
+		MeteringOnly:                    input.MeteringOnly,
+		StrictRequestFields:             input.StrictRequestFields,
 	}
 	if err := s.groupRepo.Create(ctx, group); err != nil {
 		return nil, err
@@ -904,6 +1013,44 @@ func (s *adminServiceImpl) UpdateGroup(ctx context.Context, id int64, input *Upd
 	if input.ModelRoutingEnabled != nil {
 		group.ModelRoutingEnabled = *input.ModelRoutingEnabled
 	}
+	// 模型参数策略
+	if input.ModelParamPolicies != nil {
+		if err := ValidateModelParamPolicies(input.ModelParamPolicies); err != nil {
+			return nil, err
+		}
+		group.ModelParamPolicies = input.ModelParamPolicies
+	}
+	if input.RegionPolicy != nil {
+		group.RegionPolicy = *input.RegionPolicy
+	}
+	if input.SystemPromptPolicy != nil {
+		policy, err := NormalizeSystemPromptPolicy(*input.SystemPromptPolicy)
+		if err != nil {
+			return nil, err
+		}
+		group.SystemPromptPolicy = policy
+	}
+	if input.PromptTemplate != nil {
+		tpl, err := NormalizePromptTemplate(*input.PromptTemplate)
+		if err != nil {
+			return nil, err
+		}
+		group.PromptTemplate = tpl
+	}
+	if input.ModelAccessPolicy != nil {
+		policy, err := NormalizeModelAccessPolicy(*input.ModelAccessPolicy)
+		if err != nil {
+			return nil, err
+		}
+		group.ModelAccessPolicy = policy
+	}
+	if input.RetryPolicy != nil {
+		policy, err := NormalizeRetryPolicy(*input.RetryPolicy)
+		if err != nil {
+			return nil, err
+		}
+		group.RetryPolicy = policy
+	}
 	if input.MCPXMLInject != nil {
 		group.MCPXMLInject = *input.MCPXMLInject
 	}
@@ -912,6 +1059,12 @@ func (s *adminServiceImpl) UpdateGroup(ctx context.Context, id int64, input *Upd
 	if input.SupportedModelScopes != nil {
 		group.SupportedModelScopes = *input.SupportedModelScopes
 	}
+	if input.MeteringOnly != nil {
+		group.MeteringOnly = *input.MeteringOnly
+	}
+	if input.StrictRequestFields != nil {
+		group.StrictRequestFields = *input.StrictRequestFields
+	}
 
 	if err := s.groupRepo.Update(ctx, group); err != nil {
 		return nil, err
@@ -970,6 +1123,44 @@ func (s *adminServiceImpl) UpdateGroup(ctx context.Context, id int64, input *Upd
 	return group, nil
 }
 
+// SetGroupMaintenance 进入维护时该分组的新请求返回 gateway.maintenance_message（进行中的请求不受影响），
+// 退出维护恢复为 active。仅 active 分组可进入维护。
+func (s *adminServiceImpl) SetGroupMaintenance(ctx context.Context, id int64, enabled bool) (*Group, error) {
+	group, err := s.groupRepo.GetByID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	next, err := nextMaintenanceStatus(group.Status, enabled)
+	if err != nil {
+		return nil, err
+	}
+	if next == group.Status {
+		return group, nil
+	}
+	group.Status = next
+	if err := s.groupRepo.Update(ctx, group); err != nil {
+		return nil, err
+	}
+	if s.authCacheInvalidator != nil {
+		s.authCacheInvalidator.InvalidateAuthCacheByGroupID(ctx, id)
+	}
+	return group, nil
+}
+
+// nextMaintenanceStatus 计算切换维护状态后的目标状态（重复切换保持不变）
+func nextMaintenanceStatus(current string, enabled bool) (string, error) {
+	switch {
+	case enabled && current == StatusMaintenance, !enabled && current != StatusMaintenance:
+		return current, nil
+	case enabled && current != StatusActive:
+		return "", infraerrors.Conflict("MAINTENANCE_REQUIRES_ACTIVE", "only active items can enter maintenance")
+	case enabled:
+		return StatusMaintenance, nil
+	default:
+		return StatusActive, nil
+	}
+}
+
 func (s *adminServiceImpl) DeleteGroup(ctx context.Context, id int64) error {
 	var groupKeys []string
 	if s.authCacheInvalidator != nil {
@@ -1020,16 +1211,207 @@ func (s *adminServiceImpl) UpdateGroupSortOrders(ctx context.Context, updates []
 	return s.groupRepo.UpdateSortOrders(ctx, updates)
 }
 
+// CloneGroup 复制分组配置（限额、模型路由、参数/区域/提示词/访问策略等）创建新分组。
+// 错误透传规则按平台全局生效，新分组与源分组同平台，无需单独复制。
+func (s *adminServiceImpl) CloneGroup(ctx context.Context, sourceID int64, input *CloneGroupInput) (*CloneGroupResult, error) {
+	name := strings.TrimSpace(input.Name)
+	if name == "" {
+		return nil, infraerrors.BadRequest("GROUP_CLONE_NAME_REQUIRED", "name is required")
+	}
+	mode := strings.TrimSpace(input.AccountMode)
+	if mode == "" {
+		mode = GroupCloneAccountsNone
+	}
+	if mode != GroupCloneAccountsNone && mode != GroupCloneAccountsLink && mode != GroupCloneAccountsCopy {
+		return nil, infraerrors.BadRequest("GROUP_CLONE_INVALID_ACCOUNT_MODE", "account_mode must be one of none, link, copy")
+	}
+	if input.RateMultiplier != nil && *input.RateMultiplier < 0 {
+		return nil, infraerrors.BadRequest("GROUP_CLONE_INVALID_RATE", "rate_multiplier must be >= 0")
+	}
+
+	source, err := s.groupRepo.GetByID(ctx, sourceID)
+	if err != nil {
+		return nil, err
+	}
+	exists, err := s.groupRepo.ExistsByName(ctx, name)
+	if err != nil {
+		return nil, err
+	}
+	if exists {
+		return nil, ErrGroupExists
+	}
+
+	var sourceAccounts []Account
+	if mode != GroupCloneAccountsNone {
+		sourceAccounts, err = s.accountRepo.ListByGroup(ctx, sourceID)
+		if err != nil {
+			return nil, fmt.Errorf("failed to list accounts of source group: %w", err)
+		}
+	}
+
+	group := cloneGroupTemplate(source)
+	group.Name = name
+	if input.Description != nil {
+		group.Description = *input.Description
+	}
+	if input.RateMultiplier != nil {
+		group.RateMultiplier = *input.RateMultiplier
+	}
+	if input.IsExclusive != nil {
+		group.IsExclusive = *input.IsExclusive
+	}
+	if err := s.groupRepo.Create(ctx, group); err != nil {
+		return nil, err
+	}
+
+	result := &CloneGroupResult{Group: group}
+	if len(sourceAccounts) == 0 {
+		return result, nil
+	}
+
+	linkIDs := make([]int64, 0, len(sourceAccounts))
+	copiedIDs := make(map[int64]int64, len(sourceAccounts)) // 源账号 ID -> 新账号 ID
+	for i := range sourceAccounts {
+		src := &sourceAccounts[i]
+		if mode == GroupCloneAccountsLink || src.IsOAuth() {
+			linkIDs = append(linkIDs, src.ID)
+			continue
+		}
+		copied := cloneAccountTemplate(src, fmt.Sprintf("%s (%s)", src.Name, name))
+		if err := s.accountRepo.Create(ctx, copied); err != nil {
+			return nil, fmt.Errorf("failed to copy account %d: %w", src.ID, err)
+		}
+		if err := s.accountRepo.BindGroups(ctx, copied.ID, []int64{group.ID}); err != nil {
+			return nil, fmt.Errorf("failed to bind copied account %d: %w", copied.ID, err)
+		}
+		copiedIDs[src.ID] = copied.ID
+	}
+	if len(linkIDs) > 0 {
+		if err := s.groupRepo.BindAccountsToGroup(ctx, group.ID, linkIDs); err != nil {
+			return nil, fmt.Errorf("failed to bind accounts to new group: %w", err)
+		}
+	}
+
+	// 模型路由中的账号指向复制后的新账号
+	if len(copiedIDs) > 0 && len(group.ModelRouting) > 0 {
+		for _, ids := range group.ModelRouting {
+			for i, id := range ids {
+				if newID, ok := copiedIDs[id]; ok {
+					ids[i] = newID
+				}
+			}
+		}
+		if err := s.groupRepo.Update(ctx, group); err != nil {
+			return nil, fmt.Errorf("failed to remap model routing: %w", err)
+		}
+	}
+
+	result.LinkedAccountCount = len(linkIDs)
+	result.CopiedAccountCount = len(copiedIDs)
+	group.AccountCount = int64(len(linkIDs) + len(copiedIDs))
+	return result, nil
+}
+
+// cloneGroupTemplate 复制分组的配置字段（不含 ID、状态、排序与账号绑定）
+func cloneGroupTemplate(source *Group) *Group {
+	group := &Group{
+		Description:                     source.Description,
+		Platform:                        source.Platform,
+		RateMultiplier:                  source.RateMultiplier,
+		IsExclusive:                     source.IsExclusive,
+		Status:                          StatusActive,
+		SubscriptionType:                source.SubscriptionType,
+		DailyLimitUSD:                   source.DailyLimitUSD,
+		WeeklyLimitUSD:                  source.WeeklyLimitUSD,
+		MonthlyLimitUSD:                 source.MonthlyLimitUSD,
+		DefaultValidityDays:             source.DefaultValidityDays,
+		ImagePrice1K:                    source.ImagePrice1K,
+		ImagePrice2K:                    source.ImagePrice2K,
+		ImagePrice4K:                    source.ImagePrice4K,
+		ClaudeCodeOnly:                  source.ClaudeCodeOnly,
+		FallbackGroupID:                 source.FallbackGroupID,
+		FallbackGroupIDOnInvalidRequest: source.FallbackGroupIDOnInvalidRequest,
+		ModelRoutingEnabled:             source.ModelRoutingEnabled,
+		ModelParamPolicies:              source.ModelParamPolicies,
+		RegionPolicy:                    source.RegionPolicy,
+		SystemPromptPolicy:              source.SystemPromptPolicy,
+		PromptTemplate:                  source.PromptTemplate,
+		ModelAccessPolicy:               source.ModelAccessPolicy,
+		RetryPolicy:                     source.RetryPolicy,
+		MCPXMLInject:                    source.MCPXMLInject,
+		SupportedModelScopes:            append([]string(nil), source.SupportedModelScopes...),
+		MeteringOnly:                    source.MeteringOnly,
+		StrictRequestFields:             source.StrictRequestFields,
+	}
+	if source.ModelRouting != nil {
+		group.ModelRouting = make(map[string][]int64, len(source.ModelRouting))
+		for pattern, ids := range source.ModelRouting {
+			group.ModelRouting[pattern] = append([]int64(nil), ids...)
+		}
+	}
+	return group
+}
+
+// cloneAccountTemplate 复制账号的配置与凭证（不含运行时状态：限流、过载、会话窗口等）
+func cloneAccountTemplate(source *Account, name string) *Account {
+	credentials := make(map[string]any, len(source.Credentials))
+	for k, v := range source.Credentials {
+		credentials[k] = v
+	}
+	var extra map[string]any
+	if source.Extra != nil {
+		extra = make(map[string]any, len(source.Extra))
+		for k, v := range source.Extra {
+			extra[k] = v
+		}
+	}
+	return &Account{
+		Name:               name,
+		Notes:              source.Notes,
+		Platform:           source.Platform,
+		Type:               source.Type,
+		Credentials:        credentials,
+		Extra:              extra,
+		ProxyID:            source.ProxyID,
+		Concurrency:        source.Concurrency,
+		Priority:           source.Priority,
+		RateMultiplier:     source.RateMultiplier,
+		Status:             StatusActive,
+		Schedulable:        true,
+		ExpiresAt:          source.ExpiresAt,
+		AutoPauseOnExpired: source.AutoPauseOnExpired,
+	}
+}
+
 // Account management implementations
-func (s *adminServiceImpl) ListAccounts(ctx context.Context, page, pageSize int, platform, accountType, status, search string, groupID int64) ([]Account, int64, error) {
+func (s *adminServiceImpl) ListAccounts(ctx context.Context, page, pageSize int, platform, accountType, status, search string, groupID int64, label string) ([]Account, int64, error) {
 	params := pagination.PaginationParams{Page: page, PageSize: pageSize}
-	accounts, result, err := s.accountRepo.ListWithFilters(ctx, params, platform, accountType, status, search, groupID)
+	accounts, result, err := s.accountRepo.ListWithFilters(ctx, params, platform, accountType, status, search, groupID, label)
 	if err != nil {
 		return nil, 0, err
 	}
 	return accounts, result.Total, nil
 }
 
+// accountIDsByLabel 返回带有指定标签的账号 ID（最多 maxLabelBulkAccounts 个）
+func (s *adminServiceImpl) accountIDsByLabel(ctx context.Context, label string) ([]int64, error) {
+	label = strings.ToLower(strings.TrimSpace(label))
+	params := pagination.PaginationParams{Page: 1, PageSize: maxLabelBulkAccounts}
+	accounts, result, err := s.accountRepo.ListWithFilters(ctx, params, "", "", "", "", 0, label)
+	if err != nil {
+		return nil, err
+	}
+	if result != nil && result.Total > maxLabelBulkAccounts {
+		return nil, infraerrors.Newf(http.StatusBadRequest, "ACCOUNT_LABEL_TOO_MANY",
+			"label %q matches %d accounts, bulk operations are limited to %d", label, result.Total, maxLabelBulkAccounts)
+	}
+	ids := make([]int64, 0, len(accounts))
+	for i := range accounts {
+		ids = append(ids, accounts[i].ID)
+	}
+	return ids, nil
+}
+
 func (s *adminServiceImpl) GetAccount(ctx context.Context, id int64) (*Account, error) {
 	return s.accountRepo.GetByID(ctx, id)
 }
@@ -1071,6 +1453,17 @@ func (s *adminServiceImpl) CreateAccount(ctx context.Context, input *CreateAccou
 		}
 	}
 
+	if len(input.Labels) > 0 {
+		labels, err := NormalizeAccountLabels(input.Labels)
+		if err != nil {
+			return nil, err
+		}
+		input.Extra = applyAccountLabels(input.Extra, labels)
+	}
+	if err := ValidateUpstreamClientTLSCredentials(input.Credentials); err != nil {
+		return nil, err
+	}
+
 	account := &Account{
 		Name:        input.Name,
 		Notes:       normalizeAccountNotes(input.Notes),
@@ -1129,11 +1522,21 @@ func (s *adminServiceImpl) UpdateAccount(ctx context.Context, id int64, input *U
 		account.Notes = normalizeAccountNotes(input.Notes)
 	}
 	if len(input.Credentials) > 0 {
+		if err := ValidateUpstreamClientTLSCredentials(input.Credentials); err != nil {
+			return nil, err
+		}
 		account.Credentials = input.Credentials
 	}
 	if len(input.Extra) > 0 {
 		account.Extra = input.Extra
 	}
+	if input.Labels != nil {
+		labels, err := NormalizeAccountLabels(*input.Labels)
+		if err != nil {
+			return nil, err
+		}
+		account.Extra = applyAccountLabels(account.Extra, labels)
+	}
 	if input.ProxyID != nil {
 		// 0 表示清除代理（前端发送 0 而不是 null 来表达清除意图）
 		if *input.ProxyID == 0 {
@@ -1206,6 +1609,14 @@ func (s *adminServiceImpl) UpdateAccount(ctx context.Context, id int64, input *U
 // BulkUpdateAccounts updates multiple accounts in one request.
 // It merges credentials/extra keys instead of overwriting the whole object.
 func (s *adminServiceImpl) BulkUpdateAccounts(ctx context.Context, input *BulkUpdateAccountsInput) (*BulkUpdateAccountsResult, error) {
+	if len(input.AccountIDs) == 0 && input.Label != "" {
+		ids, err := s.accountIDsByLabel(ctx, input.Label)
+		if err != nil {
+			return nil, err
+		}
+		input.AccountIDs = ids
+	}
+
 	result := &BulkUpdateAccountsResult{
 		SuccessIDs: make([]int64, 0, len(input.AccountIDs)),
 		FailedIDs:  make([]int64, 0, len(input.AccountIDs)),
@@ -1268,10 +1679,40 @@ func (s *adminServiceImpl) BulkUpdateAccounts(ctx context.Context, input *BulkUp
 		return nil, err
 	}
 
-	// Handle group bindings per account (requires individual operations).
+	// Label edits need each account's current labels.
+	labelsByID := map[int64][]string{}
+	editLabels := len(input.AddLabels) > 0 || len(input.RemoveLabels) > 0
+	if editLabels {
+		accounts, err := s.accountRepo.GetByIDs(ctx, input.AccountIDs)
+		if err != nil {
+			return nil, err
+		}
+		for _, account := range accounts {
+			if account != nil {
+				labelsByID[account.ID] = account.GetLabels()
+			}
+		}
+	}
+
+	// Handle group bindings and label edits per account (requires individual operations).
 	for _, accountID := range input.AccountIDs {
 		entry := BulkUpdateAccountResult{AccountID: accountID}
 
+		if editLabels {
+			labels, err := mergeAccountLabels(labelsByID[accountID], input.AddLabels, input.RemoveLabels)
+			if err == nil {
+				err = s.accountRepo.UpdateExtra(ctx, accountID, map[string]any{AccountLabelsExtraKey: labels})
+			}
+			if err != nil {
+				entry.Success = false
+				entry.Error = err.Error()
+				result.Failed++
+				result.FailedIDs = append(result.FailedIDs, accountID)
+				result.Results = append(result.Results, entry)
+				continue
+			}
+		}
+
 		if input.GroupIDs != nil {
 			// 检查混合渠道风险（除非用户已确认）
 			if !input.SkipMixedChannelCheck {
@@ -1354,6 +1795,28 @@ func (s *adminServiceImpl) SetAccountSchedulable(ctx context.Context, id int64,
 	return s.accountRepo.GetByID(ctx, id)
 }
 
+// SetAccountMaintenance 进入维护时账号不再被调度（已在进行的流式请求正常结束），
+// 命中该账号的粘性会话会被清除并迁移到其他账号；退出维护恢复为 active。
+// 仅 active 账号可进入维护，避免退出维护时覆盖 error/disabled 状态。
+func (s *adminServiceImpl) SetAccountMaintenance(ctx context.Context, id int64, enabled bool) (*Account, error) {
+	account, err := s.accountRepo.GetByID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	next, err := nextMaintenanceStatus(account.Status, enabled)
+	if err != nil {
+		return nil, err
+	}
+	if next == account.Status {
+		return account, nil
+	}
+	account.Status = next
+	if err := s.accountRepo.Update(ctx, account); err != nil {
+		return nil, err
+	}
+	return s.accountRepo.GetByID(ctx, id)
+}
+
 // Proxy management implementations
 func (s *adminServiceImpl) ListProxies(ctx context.Context, page, pageSize int, protocol, status, search string) ([]Proxy, int64, error) {
 	params := pagination.PaginationParams{Page: page, PageSize: pageSize}
@@ -344,6 +344,14 @@ func (s *billingCacheStub) InvalidateSubscriptionCache(ctx context.Context, user
 	return nil
 }
 
+func (s *billingCacheStub) GetTokenQuotaUsage(ctx context.Context, apiKeyID int64, day, month string) (*TokenQuotaUsage, error) {
+	panic("unexpected GetTokenQuotaUsage call")
+}
+
+func (s *billingCacheStub) IncrementTokenQuotaUsage(ctx context.Context, apiKeyID int64, day, month string, tokens int64) error {
+	panic("unexpected IncrementTokenQuotaUsage call")
+}
+
 func waitForInvalidations(t *testing.T, ch <-chan subscriptionInvalidateCall, expected int) []subscriptionInvalidateCall {
 	t.Helper()
 	calls := make([]subscriptionInvalidateCall, 0, expected)
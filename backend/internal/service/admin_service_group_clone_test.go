@@ -0,0 +1,186 @@
+//go:build unit
+
+package service
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+type groupRepoStubForClone struct {
+	GroupRepository
+
+	source  *Group
+	exists  bool
+	created *Group
+	updated *Group
+	bound   []int64
+}
+
+func (s *groupRepoStubForClone) GetByID(_ context.Context, id int64) (*Group, error) {
+	if s.source == nil || s.source.ID != id {
+		return nil, ErrGroupNotFound
+	}
+	return s.source, nil
+}
+
+func (s *groupRepoStubForClone) ExistsByName(_ context.Context, _ string) (bool, error) {
+	return s.exists, nil
+}
+
+func (s *groupRepoStubForClone) Create(_ context.Context, g *Group) error {
+	g.ID = 100
+	s.created = g
+	return nil
+}
+
+func (s *groupRepoStubForClone) Update(_ context.Context, g *Group) error {
+	s.updated = g
+	return nil
+}
+
+func (s *groupRepoStubForClone) BindAccountsToGroup(_ context.Context, _ int64, accountIDs []int64) error {
+	s.bound = append(s.bound, accountIDs...)
+	return nil
+}
+
+type accountRepoStubForGroupClone struct {
+	accountRepoStub
+
+	accounts []Account
+	created  []*Account
+	bindings map[int64][]int64
+}
+
+func (s *accountRepoStubForGroupClone) ListByGroup(_ context.Context, _ int64) ([]Account, error) {
+	return s.accounts, nil
+}
+
+func (s *accountRepoStubForGroupClone) Create(_ context.Context, account *Account) error {
+	account.ID = int64(1000 + len(s.created))
+	s.created = append(s.created, account)
+	return nil
+}
+
+func (s *accountRepoStubForGroupClone) BindGroups(_ context.Context, accountID int64, groupIDs []int64) error {
+	if s.bindings == nil {
+		s.bindings = make(map[int64][]int64)
+	}
+	s.bindings[accountID] = groupIDs
+	return nil
+}
+
+func newCloneSourceGroup() *Group {
+	daily := 50.0
+	return &Group{
+		ID:                  1,
+		Name:                "tier-gold",
+		Description:         "gold customers",
+		Platform:            PlatformAnthropic,
+		RateMultiplier:      1.5,
+		Status:              StatusDisabled,
+		SubscriptionType:    SubscriptionTypeStandard,
+		DailyLimitUSD:       &daily,
+		ModelRouting:        map[string][]int64{"claude-opus-*": {11, 12}},
+		ModelRoutingEnabled: true,
+		StrictRequestFields: true,
+		SortOrder:           9,
+		AccountCount:        2,
+	}
+}
+
+func TestAdminService_CloneGroup_CopiesConfiguration(t *testing.T) {
+	groupRepo := &groupRepoStubForClone{source: newCloneSourceGroup()}
+	svc := &adminServiceImpl{groupRepo: groupRepo, accountRepo: &accountRepoStubForGroupClone{}}
+
+	rate := 1.2
+	result, err := svc.CloneGroup(context.Background(), 1, &CloneGroupInput{Name: " customer-a ", RateMultiplier: &rate})
+	require.NoError(t, err)
+
+	created := groupRepo.created
+	require.NotNil(t, created)
+	require.Equal(t, "customer-a", created.Name)
+	require.Equal(t, "gold customers", created.Description)
+	require.Equal(t, 1.2, created.RateMultiplier)
+	require.Equal(t, StatusActive, created.Status)
+	require.Equal(t, 0, created.SortOrder)
+	require.Equal(t, 50.0, *created.DailyLimitUSD)
+	require.True(t, created.ModelRoutingEnabled)
+	require.True(t, created.StrictRequestFields)
+	require.Equal(t, []int64{11, 12}, created.ModelRouting["claude-opus-*"])
+
+	// 修改新分组的路由不影响源分组
+	created.ModelRouting["claude-opus-*"][0] = 99
+	require.Equal(t, int64(11), groupRepo.source.ModelRouting["claude-opus-*"][0])
+
+	require.Zero(t, result.LinkedAccountCount)
+	require.Zero(t, result.CopiedAccountCount)
+	require.Nil(t, groupRepo.updated)
+}
+
+func TestAdminService_CloneGroup_LinkAccounts(t *testing.T) {
+	groupRepo := &groupRepoStubForClone{source: newCloneSourceGroup()}
+	accountRepo := &accountRepoStubForGroupClone{accounts: []Account{
+		{ID: 11, Type: AccountTypeAPIKey},
+		{ID: 12, Type: AccountTypeOAuth},
+	}}
+	svc := &adminServiceImpl{groupRepo: groupRepo, accountRepo: accountRepo}
+
+	result, err := svc.CloneGroup(context.Background(), 1, &CloneGroupInput{Name: "customer-b", AccountMode: GroupCloneAccountsLink})
+	require.NoError(t, err)
+	require.Equal(t, []int64{11, 12}, groupRepo.bound)
+	require.Empty(t, accountRepo.created)
+	require.Equal(t, 2, result.LinkedAccountCount)
+	require.Equal(t, int64(2), result.Group.AccountCount)
+}
+
+func TestAdminService_CloneGroup_CopyAccountsRemapsRouting(t *testing.T) {
+	groupRepo := &groupRepoStubForClone{source: newCloneSourceGroup()}
+	accountRepo := &accountRepoStubForGroupClone{accounts: []Account{
+		{ID: 11, Name: "key-1", Platform: PlatformAnthropic, Type: AccountTypeAPIKey, Credentials: map[string]any{"api_key": "sk-1"}, Concurrency: 3, Status: StatusError, ErrorMessage: "boom"},
+		{ID: 12, Name: "oauth-1", Platform: PlatformAnthropic, Type: AccountTypeOAuth},
+	}}
+	svc := &adminServiceImpl{groupRepo: groupRepo, accountRepo: accountRepo}
+
+	result, err := svc.CloneGroup(context.Background(), 1, &CloneGroupInput{Name: "customer-c", AccountMode: GroupCloneAccountsCopy})
+	require.NoError(t, err)
+
+	require.Len(t, accountRepo.created, 1)
+	copied := accountRepo.created[0]
+	require.Equal(t, "key-1 (customer-c)", copied.Name)
+	require.Equal(t, "sk-1", copied.GetCredential("api_key"))
+	require.Equal(t, 3, copied.Concurrency)
+	require.Equal(t, StatusActive, copied.Status)
+	require.Empty(t, copied.ErrorMessage)
+	require.Equal(t, []int64{100}, accountRepo.bindings[copied.ID])
+
+	// OAuth 账号不复制，只关联
+	require.Equal(t, []int64{12}, groupRepo.bound)
+	require.Equal(t, 1, result.CopiedAccountCount)
+	require.Equal(t, 1, result.LinkedAccountCount)
+
+	require.NotNil(t, groupRepo.updated)
+	require.Equal(t, []int64{copied.ID, 12}, groupRepo.updated.ModelRouting["claude-opus-*"])
+	require.Equal(t, []int64{11, 12}, groupRepo.source.ModelRouting["claude-opus-*"])
+}
+
+func TestAdminService_CloneGroup_Validation(t *testing.T) {
+	groupRepo := &groupRepoStubForClone{source: newCloneSourceGroup()}
+	svc := &adminServiceImpl{groupRepo: groupRepo, accountRepo: &accountRepoStubForGroupClone{}}
+
+	_, err := svc.CloneGroup(context.Background(), 1, &CloneGroupInput{Name: "  "})
+	require.Error(t, err)
+
+	_, err = svc.CloneGroup(context.Background(), 1, &CloneGroupInput{Name: "x", AccountMode: "move"})
+	require.Error(t, err)
+
+	_, err = svc.CloneGroup(context.Background(), 2, &CloneGroupInput{Name: "x"})
+	require.ErrorIs(t, err, ErrGroupNotFound)
+
+	groupRepo.exists = true
+	_, err = svc.CloneGroup(context.Background(), 1, &CloneGroupInput{Name: "tier-gold"})
+	require.ErrorIs(t, err, ErrGroupExists)
+	require.Nil(t, groupRepo.created)
+}
@@ -0,0 +1,37 @@
+//go:build unit
+
+package service
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestNextMaintenanceStatus(t *testing.T) {
+	cases := []struct {
+		name    string
+		current string
+		enabled bool
+		want    string
+		wantErr bool
+	}{
+		{name: "enter from active", current: StatusActive, enabled: true, want: StatusMaintenance},
+		{name: "enter again is no-op", current: StatusMaintenance, enabled: true, want: StatusMaintenance},
+		{name: "exit to active", current: StatusMaintenance, enabled: false, want: StatusActive},
+		{name: "exit keeps error status", current: StatusError, enabled: false, want: StatusError},
+		{name: "enter from error rejected", current: StatusError, enabled: true, wantErr: true},
+		{name: "enter from disabled rejected", current: StatusDisabled, enabled: true, wantErr: true},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			got, err := nextMaintenanceStatus(tc.current, tc.enabled)
+			if tc.wantErr {
+				require.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+			require.Equal(t, tc.want, got)
+		})
+	}
+}
@@ -562,10 +562,10 @@ func (s *AntigravityGatewayService) antigravityRetryLoop(p antigravityRetryLoopP
 
 	var resp *http.Response
 	var usedBaseURL string
-	logBody := p.settingService != nil && p.settingService.cfg != nil && p.settingService.cfg.Gateway.LogUpstreamErrorBody
+	logBody := p.settingService != nil && p.settingService.cfg != nil && p.settingService.cfg.Live().Gateway.LogUpstreamErrorBody
 	maxBytes := 2048
-	if p.settingService != nil && p.settingService.cfg != nil && p.settingService.cfg.Gateway.LogUpstreamErrorBodyMaxBytes > 0 {
-		maxBytes = p.settingService.cfg.Gateway.LogUpstreamErrorBodyMaxBytes
+	if p.settingService != nil && p.settingService.cfg != nil && p.settingService.cfg.Live().Gateway.LogUpstreamErrorBodyMaxBytes > 0 {
+		maxBytes = p.settingService.cfg.Live().Gateway.LogUpstreamErrorBodyMaxBytes
 	}
 	getUpstreamDetail := func(body []byte) string {
 		if !logBody {
@@ -813,6 +813,7 @@ type AntigravityGatewayService struct {
 	settingService    *SettingService
 	cache             GatewayCache // 用于模型级限流时清除粘性会话绑定
 	schedulerSnapshot *SchedulerSnapshotService
+	memoryGuard       *MemoryGuard
 }
 
 func NewAntigravityGatewayService(
@@ -823,6 +824,7 @@ func NewAntigravityGatewayService(
 	rateLimitService *RateLimitService,
 	httpUpstream HTTPUpstream,
 	settingService *SettingService,
+	memoryGuard *MemoryGuard,
 ) *AntigravityGatewayService {
 	return &AntigravityGatewayService{
 		accountRepo:       accountRepo,
@@ -832,6 +834,7 @@ func NewAntigravityGatewayService(
 		settingService:    settingService,
 		cache:             cache,
 		schedulerSnapshot: schedulerSnapshot,
+		memoryGuard:       memoryGuard,
 	}
 }
 
@@ -847,7 +850,7 @@ func (s *AntigravityGatewayService) getLogConfig() (logBody bool, maxBytes int)
 	if s.settingService == nil || s.settingService.cfg == nil {
 		return false, maxBytes
 	}
-	cfg := s.settingService.cfg.Gateway
+	cfg := s.settingService.cfg.Live().Gateway
 	if cfg.LogUpstreamErrorBodyMaxBytes > 0 {
 		maxBytes = cfg.LogUpstreamErrorBodyMaxBytes
 	}
@@ -1563,7 +1566,7 @@ func (s *AntigravityGatewayService) Forward(ctx context.Context, c *gin.Context,
 						Message:            upstreamMsg,
 						Detail:             upstreamDetail,
 					})
-					return nil, &UpstreamFailoverError{StatusCode: resp.StatusCode, ResponseBody: respBody, RetryableOnSameAccount: true}
+					return nil, &UpstreamFailoverError{StatusCode: resp.StatusCode, ResponseBody: respBody, ResponseHeaders: resp.Header, RetryableOnSameAccount: true}
 				}
 			}
 
@@ -1581,7 +1584,7 @@ func (s *AntigravityGatewayService) Forward(ctx context.Context, c *gin.Context,
 					Message:            upstreamMsg,
 					Detail:             upstreamDetail,
 				})
-				return nil, &UpstreamFailoverError{StatusCode: resp.StatusCode, ResponseBody: respBody}
+				return nil, &UpstreamFailoverError{StatusCode: resp.StatusCode, ResponseBody: respBody, ResponseHeaders: resp.Header}
 			}
 
 			return nil, s.writeMappedClaudeError(c, account, resp.StatusCode, resp.Header.Get("x-request-id"), respBody)
@@ -2120,7 +2123,7 @@ func (s *AntigravityGatewayService) ForwardGemini(ctx context.Context, c *gin.Co
 				Message:            upstreamMsg,
 				Detail:             upstreamDetail,
 			})
-			return nil, &UpstreamFailoverError{StatusCode: resp.StatusCode, ResponseBody: unwrappedForOps, RetryableOnSameAccount: true}
+			return nil, &UpstreamFailoverError{StatusCode: resp.StatusCode, ResponseBody: unwrappedForOps, ResponseHeaders: resp.Header, RetryableOnSameAccount: true}
 		}
 
 		if s.shouldFailoverUpstreamError(resp.StatusCode) {
@@ -2134,7 +2137,7 @@ func (s *AntigravityGatewayService) ForwardGemini(ctx context.Context, c *gin.Co
 				Message:            upstreamMsg,
 				Detail:             upstreamDetail,
 			})
-			return nil, &UpstreamFailoverError{StatusCode: resp.StatusCode, ResponseBody: unwrappedForOps}
+			return nil, &UpstreamFailoverError{StatusCode: resp.StatusCode, ResponseBody: unwrappedForOps, ResponseHeaders: resp.Header}
 		}
 		if contentType == "" {
 			contentType = "application/json"
@@ -2787,6 +2790,9 @@ func (s *AntigravityGatewayService) handleGeminiStreamingResponse(c *gin.Context
 		maxLineSize = s.settingService.cfg.Gateway.MaxLineSize
 	}
 	scanner.Buffer(make([]byte, 64*1024), maxLineSize)
+	streamBudget := s.memoryGuard.NewStreamBudget()
+	defer streamBudget.Close()
+	scanner.Split(streamBudget.SplitFunc(bufio.ScanLines))
 	usage := &ClaudeUsage{}
 	var firstTokenMs *int
 
@@ -2823,8 +2829,8 @@ func (s *AntigravityGatewayService) handleGeminiStreamingResponse(c *gin.Context
 
 	// 上游数据间隔超时保护（防止上游挂起长期占用连接）
 	streamInterval := time.Duration(0)
-	if s.settingService.cfg != nil && s.settingService.cfg.Gateway.StreamDataIntervalTimeout > 0 {
-		streamInterval = time.Duration(s.settingService.cfg.Gateway.StreamDataIntervalTimeout) * time.Second
+	if s.settingService.cfg != nil && s.settingService.cfg.Live().Gateway.StreamDataIntervalTimeout > 0 {
+		streamInterval = time.Duration(s.settingService.cfg.Live().Gateway.StreamDataIntervalTimeout) * time.Second
 	}
 	var intervalTicker *time.Ticker
 	if streamInterval > 0 {
@@ -2940,6 +2946,9 @@ func (s *AntigravityGatewayService) handleGeminiStreamToNonStreaming(c *gin.Cont
 		maxLineSize = s.settingService.cfg.Gateway.MaxLineSize
 	}
 	scanner.Buffer(make([]byte, 64*1024), maxLineSize)
+	streamBudget := s.memoryGuard.NewStreamBudget()
+	defer streamBudget.Close()
+	scanner.Split(streamBudget.SplitFunc(bufio.ScanLines))
 
 	usage := &ClaudeUsage{}
 	var firstTokenMs *int
@@ -2983,8 +2992,8 @@ func (s *AntigravityGatewayService) handleGeminiStreamToNonStreaming(c *gin.Cont
 
 	// 上游数据间隔超时保护（防止上游挂起长期占用连接）
 	streamInterval := time.Duration(0)
-	if s.settingService.cfg != nil && s.settingService.cfg.Gateway.StreamDataIntervalTimeout > 0 {
-		streamInterval = time.Duration(s.settingService.cfg.Gateway.StreamDataIntervalTimeout) * time.Second
+	if s.settingService.cfg != nil && s.settingService.cfg.Live().Gateway.StreamDataIntervalTimeout > 0 {
+		streamInterval = time.Duration(s.settingService.cfg.Live().Gateway.StreamDataIntervalTimeout) * time.Second
 	}
 	var intervalTicker *time.Ticker
 	if streamInterval > 0 {
@@ -3113,7 +3122,7 @@ returnResponse:
 	if err != nil {
 		return nil, fmt.Errorf("failed to marshal response: %w", err)
 	}
-	c.Data(http.StatusOK, "application/json", respBody)
+	c.Data(http.StatusOK, "application/json", withModelDeprecationWarning(c.Request.Context(), respBody))
 
 	return &antigravityStreamResult{usage: usage, firstTokenMs: firstTokenMs}, nil
 }
@@ -3316,7 +3325,7 @@ func (s *AntigravityGatewayService) writeMappedClaudeError(c *gin.Context, accou
 
 	// 检查错误透传规则
 	if ptStatus, ptErrType, ptErrMsg, matched := applyErrorPassthroughRule(
-		c, account.Platform, upstreamStatus, body,
+		c, account.Platform, upstreamStatus, nil, body,
 		0, "", "",
 	); matched {
 		c.JSON(ptStatus, gin.H{
@@ -3403,6 +3412,9 @@ func (s *AntigravityGatewayService) handleClaudeStreamToNonStreaming(c *gin.Cont
 		maxLineSize = s.settingService.cfg.Gateway.MaxLineSize
 	}
 	scanner.Buffer(make([]byte, 64*1024), maxLineSize)
+	streamBudget := s.memoryGuard.NewStreamBudget()
+	defer streamBudget.Close()
+	scanner.Split(streamBudget.SplitFunc(bufio.ScanLines))
 
 	var firstTokenMs *int
 	var last map[string]any
@@ -3444,8 +3456,8 @@ func (s *AntigravityGatewayService) handleClaudeStreamToNonStreaming(c *gin.Cont
 
 	// 上游数据间隔超时保护（防止上游挂起长期占用连接）
 	streamInterval := time.Duration(0)
-	if s.settingService.cfg != nil && s.settingService.cfg.Gateway.StreamDataIntervalTimeout > 0 {
-		streamInterval = time.Duration(s.settingService.cfg.Gateway.StreamDataIntervalTimeout) * time.Second
+	if s.settingService.cfg != nil && s.settingService.cfg.Live().Gateway.StreamDataIntervalTimeout > 0 {
+		streamInterval = time.Duration(s.settingService.cfg.Live().Gateway.StreamDataIntervalTimeout) * time.Second
 	}
 	var intervalTicker *time.Ticker
 	if streamInterval > 0 {
@@ -3552,7 +3564,7 @@ returnResponse:
 		return nil, s.writeClaudeError(c, http.StatusBadGateway, "upstream_error", "Failed to parse upstream response")
 	}
 
-	c.Data(http.StatusOK, "application/json", claudeResp)
+	c.Data(http.StatusOK, "application/json", withModelDeprecationWarning(c.Request.Context(), claudeResp))
 
 	// 转换为 service.ClaudeUsage
 	usage := &ClaudeUsage{
@@ -3587,6 +3599,9 @@ func (s *AntigravityGatewayService) handleClaudeStreamingResponse(c *gin.Context
 		maxLineSize = s.settingService.cfg.Gateway.MaxLineSize
 	}
 	scanner.Buffer(make([]byte, 64*1024), maxLineSize)
+	streamBudget := s.memoryGuard.NewStreamBudget()
+	defer streamBudget.Close()
+	scanner.Split(streamBudget.SplitFunc(bufio.ScanLines))
 
 	// 辅助函数：转换 antigravity.ClaudeUsage 到 service.ClaudeUsage
 	convertUsage := func(agUsage *antigravity.ClaudeUsage) *ClaudeUsage {
@@ -3633,8 +3648,8 @@ func (s *AntigravityGatewayService) handleClaudeStreamingResponse(c *gin.Context
 	defer close(done)
 
 	streamInterval := time.Duration(0)
-	if s.settingService.cfg != nil && s.settingService.cfg.Gateway.StreamDataIntervalTimeout > 0 {
-		streamInterval = time.Duration(s.settingService.cfg.Gateway.StreamDataIntervalTimeout) * time.Second
+	if s.settingService.cfg != nil && s.settingService.cfg.Live().Gateway.StreamDataIntervalTimeout > 0 {
+		streamInterval = time.Duration(s.settingService.cfg.Live().Gateway.StreamDataIntervalTimeout) * time.Second
 	}
 	var intervalTicker *time.Ticker
 	if streamInterval > 0 {
@@ -3994,6 +4009,9 @@ func (s *AntigravityGatewayService) streamUpstreamResponse(c *gin.Context, resp
 		maxLineSize = s.settingService.cfg.Gateway.MaxLineSize
 	}
 	scanner.Buffer(make([]byte, 64*1024), maxLineSize)
+	streamBudget := s.memoryGuard.NewStreamBudget()
+	defer streamBudget.Close()
+	scanner.Split(streamBudget.SplitFunc(bufio.ScanLines))
 
 	type scanEvent struct {
 		line string
@@ -4026,8 +4044,8 @@ func (s *AntigravityGatewayService) streamUpstreamResponse(c *gin.Context, resp
 	defer close(done)
 
 	streamInterval := time.Duration(0)
-	if s.settingService.cfg != nil && s.settingService.cfg.Gateway.StreamDataIntervalTimeout > 0 {
-		streamInterval = time.Duration(s.settingService.cfg.Gateway.StreamDataIntervalTimeout) * time.Second
+	if s.settingService.cfg != nil && s.settingService.cfg.Live().Gateway.StreamDataIntervalTimeout > 0 {
+		streamInterval = time.Duration(s.settingService.cfg.Live().Gateway.StreamDataIntervalTimeout) * time.Second
 	}
 	var intervalTicker *time.Ticker
 	if streamInterval > 0 {
@@ -19,10 +19,31 @@ type APIKey struct {
 	Status      string
 	IPWhitelist []string
 	IPBlacklist []string
-	CreatedAt   time.Time
-	UpdatedAt   time.Time
-	User        *User
-	Group       *Group
+	// 允许请求的模型（支持末尾 * 通配），在分组模型访问策略之上进一步限制；为空表示不限制
+	AllowedModels []string
+	// 访问范围（端点：responses/chat/claude/gemini/embeddings；功能：streaming/tools/images）。
+	// 端点与功能分别限制：某一类未列出任何项时该类不限制；整体为空表示不限制
+	Scopes []string
+	// 区域策略，覆盖分组上的配置
+	RegionPolicy RegionPolicy
+	// 系统提示词注入策略，覆盖分组上的配置
+	SystemPromptPolicy SystemPromptPolicy
+	// 用量回调：请求完成后向该地址推送请求摘要
+	UsageWebhook UsageWebhook
+	// 请求签名：启用后请求须携带 HMAC 签名与时间戳（防重放）
+	RequestSigning RequestSigning
+	// 内置工具每日调用上限（key 为 web_search/code_interpreter/image_generation，UTC 自然日）
+	ToolLimits map[string]int
+	// 每日/每月 token 与请求数硬配额（按配额时区的自然日/月重置）
+	TokenQuota TokenQuota
+	// 调试模式：错误响应中附带脱敏后的上游错误详情
+	DebugErrors bool
+	// 优先级类别（interactive/batch），决定故障转移预算；为空使用全局配置
+	PriorityClass string
+	CreatedAt     time.Time
+	UpdatedAt     time.Time
+	User          *User
+	Group         *Group
 
 	// Quota fields
 	Quota     float64    // Quota limit in USD (0 = unlimited)
@@ -4,14 +4,24 @@ import "time"
 
 // APIKeyAuthSnapshot API Key 认证缓存快照（仅包含认证所需字段）
 type APIKeyAuthSnapshot struct {
-	APIKeyID    int64                    `json:"api_key_id"`
-	UserID      int64                    `json:"user_id"`
-	GroupID     *int64                   `json:"group_id,omitempty"`
-	Status      string                   `json:"status"`
-	IPWhitelist []string                 `json:"ip_whitelist,omitempty"`
-	IPBlacklist []string                 `json:"ip_blacklist,omitempty"`
-	User        APIKeyAuthUserSnapshot   `json:"user"`
-	Group       *APIKeyAuthGroupSnapshot `json:"group,omitempty"`
+	APIKeyID           int64                    `json:"api_key_id"`
+	UserID             int64                    `json:"user_id"`
+	GroupID            *int64                   `json:"group_id,omitempty"`
+	Status             string                   `json:"status"`
+	IPWhitelist        []string                 `json:"ip_whitelist,omitempty"`
+	IPBlacklist        []string                 `json:"ip_blacklist,omitempty"`
+	AllowedModels      []string                 `json:"allowed_models,omitempty"`
+	Scopes             []string                 `json:"scopes,omitempty"`
+	RegionPolicy       RegionPolicy             `json:"region_policy,omitempty"`
+	SystemPromptPolicy SystemPromptPolicy       `json:"system_prompt_policy,omitempty"`
+	UsageWebhook       UsageWebhook             `json:"usage_webhook,omitempty"`
+	RequestSigning     RequestSigning           `json:"request_signing,omitempty"`
+	ToolLimits         map[string]int           `json:"tool_limits,omitempty"`
+	TokenQuota         TokenQuota               `json:"token_quota,omitempty"`
+	DebugErrors        bool                     `json:"debug_errors,omitempty"`
+	PriorityClass      string                   `json:"priority_class,omitempty"`
+	User               APIKeyAuthUserSnapshot   `json:"user"`
+	Group              *APIKeyAuthGroupSnapshot `json:"group,omitempty"`
 
 	// Quota fields for API Key independent quota feature
 	Quota     float64 `json:"quota"`      // Quota limit in USD (0 = unlimited)
@@ -54,8 +64,32 @@ type APIKeyAuthGroupSnapshot struct {
 	ModelRoutingEnabled bool               `json:"model_routing_enabled"`
 	MCPXMLInject        bool               `json:"mcp_xml_inject"`
 
+	// 模型参数策略在网关入口处应用，同样需要进入快照
+	ModelParamPolicies map[string]ModelParamPolicy `json:"model_param_policies,omitempty"`
+
+	// 区域策略参与账号调度
+	RegionPolicy RegionPolicy `json:"region_policy,omitempty"`
+
+	// 系统提示词注入策略在网关入口处应用
+	SystemPromptPolicy SystemPromptPolicy `json:"system_prompt_policy,omitempty"`
+
+	// 提示词模板在网关入口处应用
+	PromptTemplate PromptTemplate `json:"prompt_template,omitempty"`
+
+	// 模型访问策略在网关入口处校验
+	ModelAccessPolicy ModelAccessPolicy `json:"model_access_policy,omitempty"`
+
+	// 重试策略在网关故障转移时使用
+	RetryPolicy RetryPolicy `json:"retry_policy,omitempty"`
+
 	// 支持的模型系列（仅 antigravity 平台使用）
 	SupportedModelScopes []string `json:"supported_model_scopes,omitempty"`
+
+	// 仅计量模式决定是否跳过计费资格检查与扣费
+	MeteringOnly bool `json:"metering_only,omitempty"`
+
+	// 严格字段模式决定是否拒绝未知顶层请求字段
+	StrictRequestFields bool `json:"strict_request_fields,omitempty"`
 }
 
 // APIKeyAuthCacheEntry 缓存条目，支持负缓存
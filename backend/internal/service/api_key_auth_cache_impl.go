@@ -207,15 +207,25 @@ func (s *APIKeyService) snapshotFromAPIKey(apiKey *APIKey) *APIKeyAuthSnapshot {
 		return nil
 	}
 	snapshot := &APIKeyAuthSnapshot{
-		APIKeyID:    apiKey.ID,
-		UserID:      apiKey.UserID,
-		GroupID:     apiKey.GroupID,
-		Status:      apiKey.Status,
-		IPWhitelist: apiKey.IPWhitelist,
-		IPBlacklist: apiKey.IPBlacklist,
-		Quota:       apiKey.Quota,
-		QuotaUsed:   apiKey.QuotaUsed,
-		ExpiresAt:   apiKey.ExpiresAt,
+		APIKeyID:           apiKey.ID,
+		UserID:             apiKey.UserID,
+		GroupID:            apiKey.GroupID,
+		Status:             apiKey.Status,
+		IPWhitelist:        apiKey.IPWhitelist,
+		IPBlacklist:        apiKey.IPBlacklist,
+		AllowedModels:      apiKey.AllowedModels,
+		Scopes:             apiKey.Scopes,
+		RegionPolicy:       apiKey.RegionPolicy,
+		SystemPromptPolicy: apiKey.SystemPromptPolicy,
+		UsageWebhook:       apiKey.UsageWebhook,
+		RequestSigning:     apiKey.RequestSigning,
+		ToolLimits:         apiKey.ToolLimits,
+		TokenQuota:         apiKey.TokenQuota,
+		DebugErrors:        apiKey.DebugErrors,
+		PriorityClass:      apiKey.PriorityClass,
+		Quota:              apiKey.Quota,
+		QuotaUsed:          apiKey.QuotaUsed,
+		ExpiresAt:          apiKey.ExpiresAt,
 		User: APIKeyAuthUserSnapshot{
 			ID:          apiKey.User.ID,
 			Status:      apiKey.User.Status,
@@ -243,8 +253,16 @@ func (s *APIKeyService) snapshotFromAPIKey(apiKey *APIKey) *APIKeyAuthSnapshot {
 			FallbackGroupIDOnInvalidRequest: apiKey.Group.FallbackGroupIDOnInvalidRequest,
 			ModelRouting:                    apiKey.Group.ModelRouting,
 			ModelRoutingEnabled:             apiKey.Group.ModelRoutingEnabled,
+			ModelParamPolicies:              apiKey.Group.ModelParamPolicies,
+			RegionPolicy:                    apiKey.Group.RegionPolicy,
+			SystemPromptPolicy:              apiKey.Group.SystemPromptPolicy,
+			PromptTemplate:                  apiKey.Group.PromptTemplate,
+			ModelAccessPolicy:               apiKey.Group.ModelAccessPolicy,
+			RetryPolicy:                     apiKey.Group.RetryPolicy,
 			MCPXMLInject:                    apiKey.Group.MCPXMLInject,
 			SupportedModelScopes:            apiKey.Group.SupportedModelScopes,
+			MeteringOnly:                    apiKey.Group.MeteringOnly,
+			StrictRequestFields:             apiKey.Group.StrictRequestFields,
 		}
 	}
 	return snapshot
@@ -255,16 +273,26 @@ func (s *APIKeyService) snapshotToAPIKey(key string, snapshot *APIKeyAuthSnapsho
 		return nil
 	}
 	apiKey := &APIKey{
-		ID:          snapshot.APIKeyID,
-		UserID:      snapshot.UserID,
-		GroupID:     snapshot.GroupID,
-		Key:         key,
-		Status:      snapshot.Status,
-		IPWhitelist: snapshot.IPWhitelist,
-		IPBlacklist: snapshot.IPBlacklist,
-		Quota:       snapshot.Quota,
-		QuotaUsed:   snapshot.QuotaUsed,
-		ExpiresAt:   snapshot.ExpiresAt,
+		ID:                 snapshot.APIKeyID,
+		UserID:             snapshot.UserID,
+		GroupID:            snapshot.GroupID,
+		Key:                key,
+		Status:             snapshot.Status,
+		IPWhitelist:        snapshot.IPWhitelist,
+		IPBlacklist:        snapshot.IPBlacklist,
+		AllowedModels:      snapshot.AllowedModels,
+		Scopes:             snapshot.Scopes,
+		RegionPolicy:       snapshot.RegionPolicy,
+		SystemPromptPolicy: snapshot.SystemPromptPolicy,
+		UsageWebhook:       snapshot.UsageWebhook,
+		RequestSigning:     snapshot.RequestSigning,
+		ToolLimits:         snapshot.ToolLimits,
+		TokenQuota:         snapshot.TokenQuota,
+		DebugErrors:        snapshot.DebugErrors,
+		PriorityClass:      snapshot.PriorityClass,
+		Quota:              snapshot.Quota,
+		QuotaUsed:          snapshot.QuotaUsed,
+		ExpiresAt:          snapshot.ExpiresAt,
 		User: &User{
 			ID:          snapshot.User.ID,
 			Status:      snapshot.User.Status,
@@ -293,8 +321,16 @@ func (s *APIKeyService) snapshotToAPIKey(key string, snapshot *APIKeyAuthSnapsho
 			FallbackGroupIDOnInvalidRequest: snapshot.Group.FallbackGroupIDOnInvalidRequest,
 			ModelRouting:                    snapshot.Group.ModelRouting,
 			ModelRoutingEnabled:             snapshot.Group.ModelRoutingEnabled,
+			ModelParamPolicies:              snapshot.Group.ModelParamPolicies,
+			RegionPolicy:                    snapshot.Group.RegionPolicy,
+			SystemPromptPolicy:              snapshot.Group.SystemPromptPolicy,
+			PromptTemplate:                  snapshot.Group.PromptTemplate,
+			ModelAccessPolicy:               snapshot.Group.ModelAccessPolicy,
+			RetryPolicy:                     snapshot.Group.RetryPolicy,
 			MCPXMLInject:                    snapshot.Group.MCPXMLInject,
 			SupportedModelScopes:            snapshot.Group.SupportedModelScopes,
+			MeteringOnly:                    snapshot.Group.MeteringOnly,
+			StrictRequestFields:             snapshot.Group.StrictRequestFields,
 		}
 	}
 	return apiKey
@@ -0,0 +1,64 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"strconv"
+	"strings"
+
+	infraerrors "github.com/Wei-Shaw/sub2api/internal/pkg/errors"
+)
+
+// MaxAPIKeyBatchSize 单次批量创建 API Key 的数量上限
+const MaxAPIKeyBatchSize = 500
+
+// BatchCreateAPIKeysRequest 批量创建 API Key 请求（管理员为班级/团队统一发放）
+type BatchCreateAPIKeysRequest struct {
+	Count int
+	// NamePrefix 名称前缀，生成 "<prefix>-001" 形式的名称（为空时使用 "key"）
+	NamePrefix string
+	// Template 所有 Key 共享的配置（分组、额度、有效期等）；CustomKey 不可用
+	Template CreateAPIKeyRequest
+}
+
+// BatchCreate 为同一用户批量创建 API Key。
+// 任一 Key 创建失败时删除本批已创建的 Key，保证要么全部成功要么全部不生效。
+func (s *APIKeyService) BatchCreate(ctx context.Context, userID int64, req BatchCreateAPIKeysRequest) ([]*APIKey, error) {
+	if req.Count <= 0 || req.Count > MaxAPIKeyBatchSize {
+		return nil, infraerrors.BadRequest("API_KEY_BATCH_INVALID_COUNT", fmt.Sprintf("count must be between 1 and %d", MaxAPIKeyBatchSize))
+	}
+	if req.Template.CustomKey != nil && *req.Template.CustomKey != "" {
+		return nil, infraerrors.BadRequest("API_KEY_BATCH_CUSTOM_KEY", "custom_key is not supported for batch creation")
+	}
+	prefix := strings.TrimSpace(req.NamePrefix)
+	if prefix == "" {
+		prefix = "key"
+	}
+	width := max(len(strconv.Itoa(req.Count)), 3)
+
+	created := make([]*APIKey, 0, req.Count)
+	for i := 0; i < req.Count; i++ {
+		item := req.Template
+		item.CustomKey = nil
+		item.Name = fmt.Sprintf("%s-%0*d", prefix, width, i+1)
+		apiKey, err := s.Create(ctx, userID, item)
+		if err != nil {
+			s.rollbackBatchCreate(created)
+			return nil, err
+		}
+		created = append(created, apiKey)
+	}
+	return created, nil
+}
+
+// rollbackBatchCreate 删除本批已创建的 Key（尽力而为，失败仅记录日志）
+func (s *APIKeyService) rollbackBatchCreate(created []*APIKey) {
+	ctx := context.Background()
+	for _, apiKey := range created {
+		s.InvalidateAuthCacheByKey(ctx, apiKey.Key)
+		if err := s.apiKeyRepo.Delete(ctx, apiKey.ID); err != nil {
+			slog.Warn("api_key_batch_rollback_failed", "api_key_id", apiKey.ID, "error", err)
+		}
+	}
+}
@@ -0,0 +1,86 @@
+//go:build unit
+
+package service
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/Wei-Shaw/sub2api/internal/config"
+	"github.com/stretchr/testify/require"
+)
+
+type apiKeyRepoStubForBatch struct {
+	apiKeyRepoStub
+
+	created []*APIKey
+	failAt  int // 第 N 次 Create 返回错误（0 表示不失败）
+}
+
+func (s *apiKeyRepoStubForBatch) Create(_ context.Context, key *APIKey) error {
+	if s.failAt > 0 && len(s.created)+1 == s.failAt {
+		return errors.New("db down")
+	}
+	key.ID = int64(len(s.created) + 1)
+	s.created = append(s.created, key)
+	return nil
+}
+
+func TestAPIKeyService_BatchCreate(t *testing.T) {
+	newService := func(repo *apiKeyRepoStubForBatch) *APIKeyService {
+		return &APIKeyService{
+			apiKeyRepo: repo,
+			userRepo:   &userRepoStub{user: &User{ID: 3}},
+			cfg:        &config.Config{},
+		}
+	}
+
+	t.Run("creates keys with shared settings", func(t *testing.T) {
+		repo := &apiKeyRepoStubForBatch{}
+		days := 30
+		keys, err := newService(repo).BatchCreate(context.Background(), 3, BatchCreateAPIKeysRequest{
+			Count:      3,
+			NamePrefix: "class-a",
+			Template:   CreateAPIKeyRequest{Quota: 5, ExpiresInDays: &days},
+		})
+		require.NoError(t, err)
+		require.Len(t, keys, 3)
+		require.Equal(t, "class-a-001", keys[0].Name)
+		require.Equal(t, "class-a-003", keys[2].Name)
+
+		seen := map[string]struct{}{}
+		for _, key := range keys {
+			require.Equal(t, int64(3), key.UserID)
+			require.Equal(t, 5.0, key.Quota)
+			require.NotNil(t, key.ExpiresAt)
+			seen[key.Key] = struct{}{}
+		}
+		require.Len(t, seen, 3)
+	})
+
+	t.Run("rolls back on failure", func(t *testing.T) {
+		repo := &apiKeyRepoStubForBatch{failAt: 3}
+		_, err := newService(repo).BatchCreate(context.Background(), 3, BatchCreateAPIKeysRequest{Count: 5})
+		require.Error(t, err)
+		require.Equal(t, []int64{1, 2}, repo.deletedIDs)
+	})
+
+	t.Run("rejects invalid requests", func(t *testing.T) {
+		repo := &apiKeyRepoStubForBatch{}
+		svc := newService(repo)
+
+		_, err := svc.BatchCreate(context.Background(), 3, BatchCreateAPIKeysRequest{Count: 0})
+		require.Error(t, err)
+		_, err = svc.BatchCreate(context.Background(), 3, BatchCreateAPIKeysRequest{Count: MaxAPIKeyBatchSize + 1})
+		require.Error(t, err)
+
+		custom := "sk-custom-key-0001"
+		_, err = svc.BatchCreate(context.Background(), 3, BatchCreateAPIKeysRequest{
+			Count:    2,
+			Template: CreateAPIKeyRequest{CustomKey: &custom},
+		})
+		require.Error(t, err)
+		require.Empty(t, repo.created)
+	})
+}
@@ -0,0 +1,244 @@
+package service
+
+import (
+	"context"
+	"crypto"
+	"errors"
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	infraerrors "github.com/Wei-Shaw/sub2api/internal/pkg/errors"
+	"github.com/golang-jwt/jwt/v5"
+)
+
+var (
+	ErrGatewayJWTInvalid       = infraerrors.Unauthorized("GATEWAY_JWT_INVALID", "invalid or expired gateway token")
+	ErrGatewayJWTTTLExceeded   = infraerrors.Unauthorized("GATEWAY_JWT_TTL_EXCEEDED", "gateway token lifetime exceeds the allowed maximum")
+	ErrGatewayJWTKeyMismatch   = infraerrors.Unauthorized("GATEWAY_JWT_KEY_MISMATCH", "gateway token subject does not own the referenced API key")
+	ErrGatewayJWTGroupMismatch = infraerrors.Forbidden("GATEWAY_JWT_GROUP_MISMATCH", "gateway token group does not match the referenced API key")
+	ErrGatewayJWTClaimsInvalid = infraerrors.Unauthorized("GATEWAY_JWT_CLAIMS_INVALID", "gateway token claims are invalid")
+)
+
+// GatewayJWTClaims 网关 JWT 声明
+//
+//   - sub：用户 ID
+//   - api_key_id：承载分组、额度与限制的 API Key，须属于 sub 用户
+//   - group_id：可选，声明时须与 API Key 的分组一致
+//   - models / scopes：可选，只能在 API Key 原有限制基础上进一步收窄
+type GatewayJWTClaims struct {
+	APIKeyID int64    `json:"api_key_id"`
+	GroupID  *int64   `json:"group_id,omitempty"`
+	Models   []string `json:"models,omitempty"`
+	Scopes   []string `json:"scopes,omitempty"`
+	jwt.RegisteredClaims
+}
+
+var gatewayJWTHMACMethods = []string{
+	jwt.SigningMethodHS256.Name,
+	jwt.SigningMethodHS384.Name,
+	jwt.SigningMethodHS512.Name,
+}
+
+var gatewayJWTPublicKeyMethods = []string{
+	jwt.SigningMethodRS256.Name, jwt.SigningMethodRS384.Name, jwt.SigningMethodRS512.Name,
+	jwt.SigningMethodPS256.Name, jwt.SigningMethodPS384.Name, jwt.SigningMethodPS512.Name,
+	jwt.SigningMethodES256.Name, jwt.SigningMethodES384.Name, jwt.SigningMethodES512.Name,
+	jwt.SigningMethodEdDSA.Alg(),
+}
+
+// gatewayJWTKeyCache 缓存已解析的 JWT 验签公钥，配置的文件路径变化时重新加载
+type gatewayJWTKeyCache struct {
+	mu   sync.Mutex
+	path string
+	key  crypto.PublicKey
+}
+
+func (c *gatewayJWTKeyCache) load(path string) (crypto.PublicKey, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.key != nil && c.path == path {
+		return c.key, nil
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("read gateway jwt public key: %w", err)
+	}
+	key, err := parseGatewayJWTPublicKey(data)
+	if err != nil {
+		return nil, err
+	}
+	c.path = path
+	c.key = key
+	return key, nil
+}
+
+// parseGatewayJWTPublicKey 解析 PEM 公钥，支持 RSA、ECDSA 与 Ed25519
+func parseGatewayJWTPublicKey(data []byte) (crypto.PublicKey, error) {
+	if key, err := jwt.ParseRSAPublicKeyFromPEM(data); err == nil {
+		return key, nil
+	}
+	if key, err := jwt.ParseECPublicKeyFromPEM(data); err == nil {
+		return key, nil
+	}
+	if key, err := jwt.ParseEdPublicKeyFromPEM(data); err == nil {
+		return key, nil
+	}
+	return nil, errors.New("gateway jwt public key must be a PEM encoded RSA, ECDSA or Ed25519 public key")
+}
+
+// IsGatewayJWT 判断凭证是否应按网关 JWT 处理：需启用 JWT 认证且凭证形如 JWS 紧凑格式。
+// 静态 API Key 不含 "."，两种凭证不会混淆。
+func (s *APIKeyService) IsGatewayJWT(credential string) bool {
+	if s.cfg == nil || !s.cfg.Security.GatewayJWT.Enabled {
+		return false
+	}
+	return strings.HasPrefix(credential, "eyJ") && strings.Count(credential, ".") == 2
+}
+
+// AuthenticateGatewayJWT 校验网关 JWT 并返回其映射的 API Key。
+// 返回的 API Key 是副本，JWT 中的 models / scopes 声明已叠加到副本的限制上，不影响缓存中的原始数据。
+func (s *APIKeyService) AuthenticateGatewayJWT(ctx context.Context, tokenString string) (*APIKey, error) {
+	claims, err := s.parseGatewayJWT(tokenString)
+	if err != nil {
+		return nil, err
+	}
+	userID, err := strconv.ParseInt(strings.TrimSpace(claims.Subject), 10, 64)
+	if err != nil || userID <= 0 || claims.APIKeyID <= 0 {
+		return nil, ErrGatewayJWTClaimsInvalid
+	}
+
+	key, ownerID, err := s.apiKeyRepo.GetKeyAndOwnerID(ctx, claims.APIKeyID)
+	if err != nil {
+		if errors.Is(err, ErrAPIKeyNotFound) {
+			return nil, ErrGatewayJWTKeyMismatch
+		}
+		return nil, fmt.Errorf("get gateway jwt api key: %w", err)
+	}
+	if ownerID != userID {
+		return nil, ErrGatewayJWTKeyMismatch
+	}
+	apiKey, err := s.GetByKey(ctx, key)
+	if err != nil {
+		if errors.Is(err, ErrAPIKeyNotFound) {
+			return nil, ErrGatewayJWTKeyMismatch
+		}
+		return nil, err
+	}
+	if claims.GroupID != nil && (apiKey.GroupID == nil || *apiKey.GroupID != *claims.GroupID) {
+		return nil, ErrGatewayJWTGroupMismatch
+	}
+	return narrowAPIKeyForGatewayJWT(apiKey, claims)
+}
+
+// parseGatewayJWT 验签并校验 iss/aud/exp/nbf/iat 与最长有效期
+func (s *APIKeyService) parseGatewayJWT(tokenString string) (*GatewayJWTClaims, error) {
+	cfg := s.cfg.Security.GatewayJWT
+	secret := strings.TrimSpace(cfg.HMACSecret)
+	publicKeyFile := strings.TrimSpace(cfg.PublicKeyFile)
+
+	var methods []string
+	if secret != "" {
+		methods = append(methods, gatewayJWTHMACMethods...)
+	}
+	if publicKeyFile != "" {
+		methods = append(methods, gatewayJWTPublicKeyMethods...)
+	}
+	opts := []jwt.ParserOption{
+		jwt.WithValidMethods(methods),
+		jwt.WithIssuer(cfg.Issuer),
+		jwt.WithExpirationRequired(),
+		jwt.WithIssuedAt(),
+		jwt.WithLeeway(time.Duration(cfg.LeewaySeconds) * time.Second),
+	}
+	if audience := strings.TrimSpace(cfg.Audience); audience != "" {
+		opts = append(opts, jwt.WithAudience(audience))
+	}
+
+	claims := &GatewayJWTClaims{}
+	_, err := jwt.NewParser(opts...).ParseWithClaims(tokenString, claims, func(token *jwt.Token) (any, error) {
+		if _, ok := token.Method.(*jwt.SigningMethodHMAC); ok {
+			return []byte(secret), nil
+		}
+		return s.gatewayJWTKeys.load(publicKeyFile)
+	})
+	if err != nil {
+		return nil, ErrGatewayJWTInvalid
+	}
+	if claims.IssuedAt == nil {
+		return nil, ErrGatewayJWTClaimsInvalid
+	}
+	maxTTL := time.Duration(cfg.MaxTTLMinutes) * time.Minute
+	if claims.ExpiresAt.Sub(claims.IssuedAt.Time) > maxTTL {
+		return nil, ErrGatewayJWTTTLExceeded
+	}
+	return claims, nil
+}
+
+// narrowAPIKeyForGatewayJWT 将 JWT 中的 models / scopes 声明叠加到 API Key 副本上，只收窄不放宽
+func narrowAPIKeyForGatewayJWT(apiKey *APIKey, claims *GatewayJWTClaims) (*APIKey, error) {
+	narrowed := *apiKey
+
+	if len(claims.Models) > 0 {
+		models, err := NormalizeAPIKeyAllowedModels(claims.Models)
+		if err != nil {
+			return nil, ErrGatewayJWTClaimsInvalid
+		}
+		if len(apiKey.AllowedModels) > 0 {
+			keyPolicy := ModelAccessPolicy{Allowed: apiKey.AllowedModels}
+			kept := make([]string, 0, len(models))
+			for _, model := range models {
+				if keyPolicy.Permits(model) {
+					kept = append(kept, model)
+				}
+			}
+			if len(kept) == 0 {
+				return nil, ErrGatewayJWTClaimsInvalid
+			}
+			models = kept
+		}
+		narrowed.AllowedModels = models
+	}
+
+	if len(claims.Scopes) > 0 {
+		scopes, err := NormalizeAPIKeyScopes(claims.Scopes)
+		if err != nil {
+			return nil, ErrGatewayJWTClaimsInvalid
+		}
+		narrowedScopes, ok := intersectAPIKeyScopes(apiKey, scopes)
+		if !ok {
+			return nil, ErrGatewayJWTClaimsInvalid
+		}
+		narrowed.Scopes = narrowedScopes
+	}
+	return &narrowed, nil
+}
+
+// intersectAPIKeyScopes 按类别（端点/功能）求交集：
+// 声明涉及的类别取声明与 Key 的交集（Key 未限制该类别时直接采用声明），交集为空时返回 false；
+// 声明未涉及的类别保留 Key 原有限制
+func intersectAPIKeyScopes(apiKey *APIKey, claimed []string) ([]string, bool) {
+	claimKey := &APIKey{Scopes: claimed}
+	var result []string
+	for _, kind := range []func(string) bool{isAPIKeyEndpointScope, isAPIKeyFeatureScope} {
+		source := apiKey.Scopes
+		if claimKey.restrictsScopes(kind) {
+			source = claimed
+		}
+		keyRestricts := apiKey.restrictsScopes(kind)
+		matched := 0
+		for _, scope := range source {
+			if kind(scope) && (!keyRestricts || apiKey.HasScope(scope)) {
+				result = append(result, scope)
+				matched++
+			}
+		}
+		if claimKey.restrictsScopes(kind) && matched == 0 {
+			return nil, false
+		}
+	}
+	return result, true
+}
@@ -0,0 +1,176 @@
+//go:build unit
+
+package service
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/Wei-Shaw/sub2api/internal/config"
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/stretchr/testify/require"
+)
+
+type gatewayJWTRepoStub struct {
+	APIKeyRepository
+	keys map[int64]*APIKey
+}
+
+func (s *gatewayJWTRepoStub) GetKeyAndOwnerID(ctx context.Context, id int64) (string, int64, error) {
+	key, ok := s.keys[id]
+	if !ok {
+		return "", 0, ErrAPIKeyNotFound
+	}
+	return key.Key, key.UserID, nil
+}
+
+func (s *gatewayJWTRepoStub) GetByKeyForAuth(ctx context.Context, key string) (*APIKey, error) {
+	for _, k := range s.keys {
+		if k.Key == key {
+			clone := *k
+			return &clone, nil
+		}
+	}
+	return nil, ErrAPIKeyNotFound
+}
+
+func newGatewayJWTTestService(keys ...*APIKey) *APIKeyService {
+	cfg := &config.Config{}
+	cfg.Security.GatewayJWT = config.GatewayJWTConfig{
+		Enabled:       true,
+		Issuer:        "https://idp.example.com",
+		Audience:      "sub2api",
+		HMACSecret:    "gateway-jwt-test-secret",
+		MaxTTLMinutes: 60,
+		LeewaySeconds: 5,
+	}
+	repo := &gatewayJWTRepoStub{keys: map[int64]*APIKey{}}
+	for _, k := range keys {
+		repo.keys[k.ID] = k
+	}
+	return NewAPIKeyService(repo, nil, nil, nil, nil, nil, cfg)
+}
+
+func gatewayJWTTestUser(id int64) *User {
+	return &User{ID: id, Status: StatusActive, Role: RoleUser, Balance: 10, Concurrency: 3}
+}
+
+func signGatewayJWT(t *testing.T, claims GatewayJWTClaims) string {
+	t.Helper()
+	token, err := jwt.NewWithClaims(jwt.SigningMethodHS256, claims).SignedString([]byte("gateway-jwt-test-secret"))
+	require.NoError(t, err)
+	return token
+}
+
+func gatewayJWTClaims(userID string, apiKeyID int64, ttl time.Duration) GatewayJWTClaims {
+	now := time.Now()
+	return GatewayJWTClaims{
+		APIKeyID: apiKeyID,
+		RegisteredClaims: jwt.RegisteredClaims{
+			Issuer:    "https://idp.example.com",
+			Audience:  jwt.ClaimStrings{"sub2api"},
+			Subject:   userID,
+			IssuedAt:  jwt.NewNumericDate(now),
+			ExpiresAt: jwt.NewNumericDate(now.Add(ttl)),
+		},
+	}
+}
+
+func TestAPIKeyService_IsGatewayJWT(t *testing.T) {
+	svc := newGatewayJWTTestService()
+	token := signGatewayJWT(t, gatewayJWTClaims("1", 1, time.Minute))
+	require.True(t, svc.IsGatewayJWT(token))
+	require.False(t, svc.IsGatewayJWT("sk-0123456789abcdef"))
+
+	svc.cfg.Security.GatewayJWT.Enabled = false
+	require.False(t, svc.IsGatewayJWT(token))
+}
+
+func TestAPIKeyService_AuthenticateGatewayJWT(t *testing.T) {
+	groupID := int64(7)
+	backing := &APIKey{
+		ID:      11,
+		UserID:  3,
+		Key:     "sk-backing",
+		GroupID: &groupID,
+		Status:  StatusActive,
+		Scopes:  []string{APIKeyScopeClaude, APIKeyScopeChat},
+		User:    gatewayJWTTestUser(3),
+	}
+	svc := newGatewayJWTTestService(backing)
+	ctx := context.Background()
+
+	apiKey, err := svc.AuthenticateGatewayJWT(ctx, signGatewayJWT(t, gatewayJWTClaims("3", 11, 15*time.Minute)))
+	require.NoError(t, err)
+	require.Equal(t, int64(11), apiKey.ID)
+	require.Equal(t, []string{APIKeyScopeClaude, APIKeyScopeChat}, apiKey.Scopes)
+
+	// 主体与 Key 所有者不一致、Key 不存在
+	_, err = svc.AuthenticateGatewayJWT(ctx, signGatewayJWT(t, gatewayJWTClaims("4", 11, time.Minute)))
+	require.ErrorIs(t, err, ErrGatewayJWTKeyMismatch)
+	_, err = svc.AuthenticateGatewayJWT(ctx, signGatewayJWT(t, gatewayJWTClaims("3", 99, time.Minute)))
+	require.ErrorIs(t, err, ErrGatewayJWTKeyMismatch)
+	_, err = svc.AuthenticateGatewayJWT(ctx, signGatewayJWT(t, gatewayJWTClaims("not-a-user", 11, time.Minute)))
+	require.ErrorIs(t, err, ErrGatewayJWTClaimsInvalid)
+
+	// 分组声明须与 Key 一致
+	claims := gatewayJWTClaims("3", 11, time.Minute)
+	otherGroup := int64(8)
+	claims.GroupID = &otherGroup
+	_, err = svc.AuthenticateGatewayJWT(ctx, signGatewayJWT(t, claims))
+	require.ErrorIs(t, err, ErrGatewayJWTGroupMismatch)
+
+	// 有效期过长、已过期、签发方不符、签名错误
+	_, err = svc.AuthenticateGatewayJWT(ctx, signGatewayJWT(t, gatewayJWTClaims("3", 11, 2*time.Hour)))
+	require.ErrorIs(t, err, ErrGatewayJWTTTLExceeded)
+	expired := gatewayJWTClaims("3", 11, time.Minute)
+	expired.IssuedAt = jwt.NewNumericDate(time.Now().Add(-10 * time.Minute))
+	expired.ExpiresAt = jwt.NewNumericDate(time.Now().Add(-5 * time.Minute))
+	_, err = svc.AuthenticateGatewayJWT(ctx, signGatewayJWT(t, expired))
+	require.ErrorIs(t, err, ErrGatewayJWTInvalid)
+	wrongIssuer := gatewayJWTClaims("3", 11, time.Minute)
+	wrongIssuer.Issuer = "https://evil.example.com"
+	_, err = svc.AuthenticateGatewayJWT(ctx, signGatewayJWT(t, wrongIssuer))
+	require.ErrorIs(t, err, ErrGatewayJWTInvalid)
+	forged, err := jwt.NewWithClaims(jwt.SigningMethodHS256, gatewayJWTClaims("3", 11, time.Minute)).SignedString([]byte("other-secret"))
+	require.NoError(t, err)
+	_, err = svc.AuthenticateGatewayJWT(ctx, forged)
+	require.ErrorIs(t, err, ErrGatewayJWTInvalid)
+}
+
+func TestAPIKeyService_AuthenticateGatewayJWT_NarrowsLimits(t *testing.T) {
+	backing := &APIKey{
+		ID:            11,
+		UserID:        3,
+		Key:           "sk-backing",
+		Status:        StatusActive,
+		AllowedModels: []string{"claude-*"},
+		Scopes:        []string{APIKeyScopeClaude, APIKeyScopeChat},
+		User:          gatewayJWTTestUser(3),
+	}
+	svc := newGatewayJWTTestService(backing)
+	ctx := context.Background()
+
+	claims := gatewayJWTClaims("3", 11, time.Minute)
+	claims.Models = []string{"claude-sonnet-*", "gpt-5"}
+	claims.Scopes = []string{APIKeyScopeChat, APIKeyScopeGemini, APIKeyScopeStreaming}
+	apiKey, err := svc.AuthenticateGatewayJWT(ctx, signGatewayJWT(t, claims))
+	require.NoError(t, err)
+	require.Equal(t, []string{"claude-sonnet-*"}, apiKey.AllowedModels)
+	require.Equal(t, []string{APIKeyScopeChat, APIKeyScopeStreaming}, apiKey.Scopes)
+
+	// 原始 Key 不受影响
+	cached, err := svc.GetByKey(ctx, "sk-backing")
+	require.NoError(t, err)
+	require.Equal(t, []string{"claude-*"}, cached.AllowedModels)
+
+	// 声明与 Key 限制没有交集时拒绝，而不是放宽为不限制
+	claims.Models = []string{"gpt-5"}
+	_, err = svc.AuthenticateGatewayJWT(ctx, signGatewayJWT(t, claims))
+	require.ErrorIs(t, err, ErrGatewayJWTClaimsInvalid)
+	claims.Models = nil
+	claims.Scopes = []string{APIKeyScopeGemini}
+	_, err = svc.AuthenticateGatewayJWT(ctx, signGatewayJWT(t, claims))
+	require.ErrorIs(t, err, ErrGatewayJWTClaimsInvalid)
+}
@@ -0,0 +1,116 @@
+package service
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"log"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/Wei-Shaw/sub2api/internal/domain"
+	infraerrors "github.com/Wei-Shaw/sub2api/internal/pkg/errors"
+)
+
+type RequestSigning = domain.RequestSigning
+
+// 签名请求头：与用量回调使用相同的头名称与签名格式
+const (
+	// RequestSigningHeaderTimestamp Unix 时间戳（秒）
+	RequestSigningHeaderTimestamp = "X-Sub2API-Timestamp"
+	// RequestSigningHeaderSignature 值为 sha256=<hex>，对 "<timestamp>.<body>" 以密钥做 HMAC-SHA256
+	RequestSigningHeaderSignature = "X-Sub2API-Signature"
+)
+
+// requestSigningSecretPrefix 自动生成的请求签名密钥前缀
+const requestSigningSecretPrefix = "sigsec_"
+
+// defaultRequestSigningMaxSkew 未配置时允许的时间戳偏差
+const defaultRequestSigningMaxSkew = 5 * time.Minute
+
+var (
+	ErrRequestSignatureRequired = infraerrors.Unauthorized("REQUEST_SIGNATURE_REQUIRED", "this API key requires signed requests (X-Sub2API-Timestamp and X-Sub2API-Signature headers)")
+	ErrRequestSignatureExpired  = infraerrors.Unauthorized("REQUEST_SIGNATURE_EXPIRED", "request timestamp is outside the allowed window")
+	ErrRequestSignatureInvalid  = infraerrors.Unauthorized("REQUEST_SIGNATURE_INVALID", "invalid request signature")
+	ErrRequestSignatureReplayed = infraerrors.Unauthorized("REQUEST_SIGNATURE_REPLAYED", "request signature has already been used")
+	// ErrRequestSignatureUnavailable 防重放存储不可用时拒绝请求，避免签名在此期间被重放
+	ErrRequestSignatureUnavailable = infraerrors.ServiceUnavailable("REQUEST_SIGNATURE_UNAVAILABLE", "request signature verification is temporarily unavailable, please retry later")
+)
+
+// SignAPIKeyRequest 计算请求签名：sha256=hex(HMAC-SHA256(secret, "<timestamp>.<body>"))
+func SignAPIKeyRequest(secret, timestamp string, body []byte) string {
+	return SignUsageWebhook(secret, timestamp, body)
+}
+
+// generateRequestSigningSecret 生成随机请求签名密钥
+func generateRequestSigningSecret() (string, error) {
+	buf := make([]byte, 24)
+	if _, err := rand.Read(buf); err != nil {
+		return "", fmt.Errorf("generate request signing secret: %w", err)
+	}
+	return requestSigningSecretPrefix + hex.EncodeToString(buf), nil
+}
+
+// buildRequestSigning 返回新的请求签名配置：关闭时清空密钥；首次启用或 rotate 时生成新密钥，否则沿用现有密钥
+func buildRequestSigning(current RequestSigning, enabled, rotate bool) (RequestSigning, error) {
+	if !enabled {
+		return RequestSigning{}, nil
+	}
+	if current.IsEnabled() && !rotate {
+		return current, nil
+	}
+	secret, err := generateRequestSigningSecret()
+	if err != nil {
+		return current, err
+	}
+	return RequestSigning{Secret: secret}, nil
+}
+
+// requestSigningMaxSkew 时间戳允许的最大偏差
+func (s *APIKeyService) requestSigningMaxSkew() time.Duration {
+	if s.cfg == nil || s.cfg.Security.RequestSigning.MaxSkewSeconds <= 0 {
+		return defaultRequestSigningMaxSkew
+	}
+	return time.Duration(s.cfg.Security.RequestSigning.MaxSkewSeconds) * time.Second
+}
+
+// VerifyRequestSignature 校验启用了请求签名的 API Key 的请求：时间戳须在允许偏差内，签名须匹配，
+// 且同一签名在时间窗口内只能使用一次（防重放存储不可用时拒绝请求）。未启用签名的 Key 直接放行。
+func (s *APIKeyService) VerifyRequestSignature(ctx context.Context, apiKey *APIKey, timestamp, signature string, body []byte) error {
+	if apiKey == nil || !apiKey.RequestSigning.IsEnabled() {
+		return nil
+	}
+	timestamp = strings.TrimSpace(timestamp)
+	signature = strings.TrimSpace(signature)
+	if timestamp == "" || signature == "" {
+		return ErrRequestSignatureRequired
+	}
+	ts, err := strconv.ParseInt(timestamp, 10, 64)
+	if err != nil {
+		return ErrRequestSignatureExpired
+	}
+	maxSkew := s.requestSigningMaxSkew()
+	if skew := time.Since(time.Unix(ts, 0)); skew > maxSkew || skew < -maxSkew {
+		return ErrRequestSignatureExpired
+	}
+	expected := SignAPIKeyRequest(apiKey.RequestSigning.Secret, timestamp, body)
+	if !hmac.Equal([]byte(expected), []byte(signature)) {
+		return ErrRequestSignatureInvalid
+	}
+	if s.cache == nil {
+		return nil
+	}
+	// 时间戳在 [now-maxSkew, now+maxSkew] 内均有效，签名需保留两倍偏差时长才能覆盖整个有效期
+	first, err := s.cache.MarkRequestSignatureSeen(ctx, apiKey.ID, signature, 2*maxSkew)
+	if err != nil {
+		log.Printf("[RequestSigning] replay check failed (rejecting request): api_key_id=%d err=%v", apiKey.ID, err)
+		return ErrRequestSignatureUnavailable
+	}
+	if !first {
+		return ErrRequestSignatureReplayed
+	}
+	return nil
+}
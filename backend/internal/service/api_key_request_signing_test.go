@@ -0,0 +1,92 @@
+//go:build unit
+
+package service
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/Wei-Shaw/sub2api/internal/config"
+	infraerrors "github.com/Wei-Shaw/sub2api/internal/pkg/errors"
+	"github.com/stretchr/testify/require"
+)
+
+type signatureCacheStub struct {
+	APIKeyCache
+	seen map[string]bool
+	err  error
+	ttl  time.Duration
+}
+
+func (s *signatureCacheStub) MarkRequestSignatureSeen(ctx context.Context, apiKeyID int64, signature string, ttl time.Duration) (bool, error) {
+	if s.err != nil {
+		return false, s.err
+	}
+	s.ttl = ttl
+	key := strconv.FormatInt(apiKeyID, 10) + ":" + signature
+	if s.seen[key] {
+		return false, nil
+	}
+	s.seen[key] = true
+	return true, nil
+}
+
+func TestBuildRequestSigning(t *testing.T) {
+	disabled, err := buildRequestSigning(RequestSigning{Secret: "sigsec_old"}, false, true)
+	require.NoError(t, err)
+	require.False(t, disabled.IsEnabled())
+
+	enabled, err := buildRequestSigning(RequestSigning{}, true, false)
+	require.NoError(t, err)
+	require.True(t, enabled.IsEnabled())
+	require.Contains(t, enabled.Secret, requestSigningSecretPrefix)
+
+	kept, err := buildRequestSigning(enabled, true, false)
+	require.NoError(t, err)
+	require.Equal(t, enabled.Secret, kept.Secret)
+
+	rotated, err := buildRequestSigning(enabled, true, true)
+	require.NoError(t, err)
+	require.NotEqual(t, enabled.Secret, rotated.Secret)
+}
+
+func TestAPIKeyService_VerifyRequestSignature(t *testing.T) {
+	cfg := &config.Config{}
+	cfg.Security.RequestSigning.MaxSkewSeconds = 300
+	cache := &signatureCacheStub{seen: map[string]bool{}}
+	svc := &APIKeyService{cache: cache, cfg: cfg}
+	ctx := context.Background()
+
+	apiKey := &APIKey{ID: 9, RequestSigning: RequestSigning{Secret: "sigsec_test"}}
+	body := []byte(`{"model":"claude-sonnet-4-5"}`)
+	ts := strconv.FormatInt(time.Now().Unix(), 10)
+	sig := SignAPIKeyRequest("sigsec_test", ts, body)
+
+	// 未启用签名的 Key 直接放行
+	require.NoError(t, svc.VerifyRequestSignature(ctx, &APIKey{ID: 1}, "", "", body))
+
+	require.ErrorIs(t, svc.VerifyRequestSignature(ctx, apiKey, "", sig, body), ErrRequestSignatureRequired)
+	require.ErrorIs(t, svc.VerifyRequestSignature(ctx, apiKey, ts, "", body), ErrRequestSignatureRequired)
+	require.ErrorIs(t, svc.VerifyRequestSignature(ctx, apiKey, ts, sig, []byte(`{"model":"claude-opus-4-1"}`)), ErrRequestSignatureInvalid)
+	require.ErrorIs(t, svc.VerifyRequestSignature(ctx, apiKey, ts, SignAPIKeyRequest("other", ts, body), body), ErrRequestSignatureInvalid)
+
+	stale := strconv.FormatInt(time.Now().Add(-10*time.Minute).Unix(), 10)
+	require.ErrorIs(t, svc.VerifyRequestSignature(ctx, apiKey, stale, SignAPIKeyRequest("sigsec_test", stale, body), body), ErrRequestSignatureExpired)
+	require.ErrorIs(t, svc.VerifyRequestSignature(ctx, apiKey, "not-a-number", sig, body), ErrRequestSignatureExpired)
+
+	require.NoError(t, svc.VerifyRequestSignature(ctx, apiKey, ts, sig, body))
+	require.Equal(t, 10*time.Minute, cache.ttl)
+	// 同一签名不可重放
+	require.ErrorIs(t, svc.VerifyRequestSignature(ctx, apiKey, ts, sig, body), ErrRequestSignatureReplayed)
+
+	// 防重放存储不可用时拒绝（503），不能在无法确认签名未被使用时放行
+	cache.err = errors.New("redis down")
+	ts2 := strconv.FormatInt(time.Now().Unix()+1, 10)
+	err := svc.VerifyRequestSignature(ctx, apiKey, ts2, SignAPIKeyRequest("sigsec_test", ts2, body), body)
+	require.ErrorIs(t, err, ErrRequestSignatureUnavailable)
+	require.Equal(t, http.StatusServiceUnavailable, infraerrors.Code(err))
+}
@@ -0,0 +1,110 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	infraerrors "github.com/Wei-Shaw/sub2api/internal/pkg/errors"
+)
+
+var (
+	ErrAPIKeyNotRotatable       = infraerrors.BadRequest("API_KEY_NOT_ROTATABLE", "only active, unexpired api keys can be rotated")
+	ErrAPIKeyRotationGraceRange = infraerrors.BadRequest("API_KEY_ROTATION_GRACE_INVALID", "grace_minutes is out of range")
+)
+
+// RotateAPIKeyRequest 轮换 API Key 请求
+type RotateAPIKeyRequest struct {
+	// GraceMinutes 旧 Key 继续有效的分钟数（nil 使用默认值，0 表示立即失效）
+	GraceMinutes *int `json:"grace_minutes"`
+}
+
+// APIKeyRotation 轮换结果
+type APIKeyRotation struct {
+	NewKey *APIKey
+	OldKey *APIKey
+	// GraceEndsAt 旧 Key 失效时间
+	GraceEndsAt time.Time
+}
+
+func (s *APIKeyService) rotationGrace(minutes *int) (time.Duration, error) {
+	if s.cfg == nil {
+		if minutes == nil {
+			return 0, nil
+		}
+		return time.Duration(*minutes) * time.Minute, nil
+	}
+	rc := s.cfg.APIKeyRotate
+	if minutes == nil {
+		return time.Duration(rc.DefaultGraceMinutes) * time.Minute, nil
+	}
+	if *minutes < 0 || *minutes > rc.MaxGraceMinutes {
+		return 0, ErrAPIKeyRotationGraceRange.WithMetadata(map[string]string{"max_grace_minutes": fmt.Sprint(rc.MaxGraceMinutes)})
+	}
+	return time.Duration(*minutes) * time.Minute, nil
+}
+
+// Rotate 为 API Key 签发替代 Key，并让旧 Key 在重叠窗口内继续有效。
+// 新 Key 沿用旧 Key 的分组、限制、策略、签名/回调密钥、额度与过期时间（轮换不延长有效期）；
+// 旧 Key 的过期时间缩短为 min(原过期时间, 现在 + 重叠窗口)，期间两把 Key 均可使用。
+func (s *APIKeyService) Rotate(ctx context.Context, id int64, userID int64, req RotateAPIKeyRequest) (*APIKeyRotation, error) {
+	old, err := s.apiKeyRepo.GetByID(ctx, id)
+	if err != nil {
+		return nil, fmt.Errorf("get api key: %w", err)
+	}
+	if old.UserID != userID {
+		return nil, ErrInsufficientPerms
+	}
+	if !old.IsActive() || old.IsExpired() {
+		return nil, ErrAPIKeyNotRotatable
+	}
+	grace, err := s.rotationGrace(req.GraceMinutes)
+	if err != nil {
+		return nil, err
+	}
+
+	key, err := s.GenerateKey()
+	if err != nil {
+		return nil, fmt.Errorf("generate key: %w", err)
+	}
+	replacement := *old
+	replacement.ID = 0
+	replacement.Key = key
+	replacement.User = nil
+	replacement.Group = nil
+	replacement.CreatedAt = time.Time{}
+	replacement.UpdatedAt = time.Time{}
+	if err := s.apiKeyRepo.Create(ctx, &replacement); err != nil {
+		return nil, fmt.Errorf("create replacement api key: %w", err)
+	}
+
+	graceEndsAt := time.Now().Add(grace)
+	if old.ExpiresAt != nil && old.ExpiresAt.Before(graceEndsAt) {
+		graceEndsAt = *old.ExpiresAt
+	}
+	old.ExpiresAt = &graceEndsAt
+	if err := s.apiKeyRepo.Update(ctx, old); err != nil {
+		// 旧 Key 未能设置过期时间，撤销新 Key，避免出现两把长期有效的 Key
+		if delErr := s.apiKeyRepo.Delete(ctx, replacement.ID); delErr != nil {
+			return nil, fmt.Errorf("update rotated api key: %w (rollback failed: %v)", err, delErr)
+		}
+		return nil, fmt.Errorf("update rotated api key: %w", err)
+	}
+
+	s.InvalidateAuthCacheByKey(ctx, old.Key)
+	s.InvalidateAuthCacheByKey(ctx, replacement.Key)
+
+	return &APIKeyRotation{NewKey: &replacement, OldKey: old, GraceEndsAt: graceEndsAt}, nil
+}
+
+// ExpiryWarning 返回 Key 距过期的剩余时长；未设置过期时间或尚未进入提醒窗口时 ok 为 false
+func (s *APIKeyService) ExpiryWarning(apiKey *APIKey, now time.Time) (remaining time.Duration, ok bool) {
+	if s.cfg == nil || s.cfg.APIKeyRotate.ExpiryWarningHours <= 0 || apiKey == nil || apiKey.ExpiresAt == nil {
+		return 0, false
+	}
+	remaining = apiKey.ExpiresAt.Sub(now)
+	if remaining < 0 || remaining > time.Duration(s.cfg.APIKeyRotate.ExpiryWarningHours)*time.Hour {
+		return 0, false
+	}
+	return remaining, true
+}
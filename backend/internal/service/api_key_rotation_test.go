@@ -0,0 +1,132 @@
+//go:build unit
+
+package service
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/Wei-Shaw/sub2api/internal/config"
+	"github.com/stretchr/testify/require"
+)
+
+type apiKeyRotationRepoStub struct {
+	APIKeyRepository
+	keys      map[int64]*APIKey
+	nextID    int64
+	updateErr error
+	deleted   []int64
+}
+
+func (s *apiKeyRotationRepoStub) GetByID(ctx context.Context, id int64) (*APIKey, error) {
+	key, ok := s.keys[id]
+	if !ok {
+		return nil, ErrAPIKeyNotFound
+	}
+	clone := *key
+	return &clone, nil
+}
+
+func (s *apiKeyRotationRepoStub) Create(ctx context.Context, key *APIKey) error {
+	s.nextID++
+	key.ID = s.nextID
+	clone := *key
+	s.keys[key.ID] = &clone
+	return nil
+}
+
+func (s *apiKeyRotationRepoStub) Update(ctx context.Context, key *APIKey) error {
+	if s.updateErr != nil {
+		return s.updateErr
+	}
+	clone := *key
+	s.keys[key.ID] = &clone
+	return nil
+}
+
+func (s *apiKeyRotationRepoStub) Delete(ctx context.Context, id int64) error {
+	s.deleted = append(s.deleted, id)
+	delete(s.keys, id)
+	return nil
+}
+
+func newAPIKeyRotationTestService(keys ...*APIKey) (*APIKeyService, *apiKeyRotationRepoStub) {
+	cfg := &config.Config{}
+	cfg.APIKeyRotate = config.APIKeyRotationConfig{DefaultGraceMinutes: 60, MaxGraceMinutes: 1440, ExpiryWarningHours: 24}
+	repo := &apiKeyRotationRepoStub{keys: map[int64]*APIKey{}, nextID: 100}
+	for _, k := range keys {
+		repo.keys[k.ID] = k
+	}
+	return NewAPIKeyService(repo, nil, nil, nil, nil, nil, cfg), repo
+}
+
+func TestAPIKeyService_Rotate(t *testing.T) {
+	groupID := int64(3)
+	svc, repo := newAPIKeyRotationTestService(&APIKey{
+		ID: 1, UserID: 7, Key: "sk-old", Name: "ci", GroupID: &groupID, Status: StatusActive,
+		AllowedModels: []string{"claude-*"}, Quota: 10, QuotaUsed: 4,
+	})
+
+	before := time.Now()
+	rotation, err := svc.Rotate(context.Background(), 1, 7, RotateAPIKeyRequest{})
+	require.NoError(t, err)
+
+	newKey := rotation.NewKey
+	require.Equal(t, int64(101), newKey.ID)
+	require.NotEqual(t, "sk-old", newKey.Key)
+	require.Equal(t, "ci", newKey.Name)
+	require.Equal(t, &groupID, newKey.GroupID)
+	require.Equal(t, []string{"claude-*"}, newKey.AllowedModels)
+	require.Equal(t, 4.0, newKey.QuotaUsed)
+	require.Nil(t, newKey.ExpiresAt)
+
+	// 旧 Key 在默认重叠窗口内仍然有效
+	old := repo.keys[1]
+	require.NotNil(t, old.ExpiresAt)
+	require.False(t, old.IsExpired())
+	require.WithinDuration(t, before.Add(time.Hour), *old.ExpiresAt, 5*time.Second)
+	require.Equal(t, *old.ExpiresAt, rotation.GraceEndsAt)
+
+	// 已处于重叠窗口内的 Key 提示即将过期
+	remaining, ok := svc.ExpiryWarning(old, time.Now())
+	require.True(t, ok)
+	require.InDelta(t, time.Hour.Seconds(), remaining.Seconds(), 5)
+	_, ok = svc.ExpiryWarning(newKey, time.Now())
+	require.False(t, ok)
+}
+
+func TestAPIKeyService_Rotate_KeepsEarlierExpiry(t *testing.T) {
+	expiresAt := time.Now().Add(10 * time.Minute)
+	svc, repo := newAPIKeyRotationTestService(&APIKey{ID: 1, UserID: 7, Key: "sk-old", Status: StatusActive, ExpiresAt: &expiresAt})
+
+	rotation, err := svc.Rotate(context.Background(), 1, 7, RotateAPIKeyRequest{})
+	require.NoError(t, err)
+	require.True(t, repo.keys[1].ExpiresAt.Equal(expiresAt))
+	require.True(t, rotation.NewKey.ExpiresAt.Equal(expiresAt))
+}
+
+func TestAPIKeyService_Rotate_Errors(t *testing.T) {
+	expired := time.Now().Add(-time.Minute)
+	svc, repo := newAPIKeyRotationTestService(
+		&APIKey{ID: 1, UserID: 7, Key: "sk-a", Status: StatusActive},
+		&APIKey{ID: 2, UserID: 7, Key: "sk-b", Status: StatusActive, ExpiresAt: &expired},
+	)
+	ctx := context.Background()
+
+	_, err := svc.Rotate(ctx, 1, 8, RotateAPIKeyRequest{})
+	require.ErrorIs(t, err, ErrInsufficientPerms)
+	_, err = svc.Rotate(ctx, 2, 7, RotateAPIKeyRequest{})
+	require.ErrorIs(t, err, ErrAPIKeyNotRotatable)
+	tooLong := 1441
+	_, err = svc.Rotate(ctx, 1, 7, RotateAPIKeyRequest{GraceMinutes: &tooLong})
+	require.ErrorIs(t, err, ErrAPIKeyRotationGraceRange)
+
+	// 旧 Key 更新失败时撤销新 Key
+	repo.updateErr = errors.New("db down")
+	_, err = svc.Rotate(ctx, 1, 7, RotateAPIKeyRequest{})
+	require.Error(t, err)
+	require.Equal(t, []int64{101}, repo.deleted)
+	require.Nil(t, repo.keys[1].ExpiresAt)
+}
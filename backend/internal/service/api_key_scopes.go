@@ -0,0 +1,252 @@
+package service
+
+import (
+	"fmt"
+	"net/url"
+	"strings"
+
+	infraerrors "github.com/Wei-Shaw/sub2api/internal/pkg/errors"
+	"github.com/tidwall/gjson"
+)
+
+// API Key 访问范围：端点
+const (
+	APIKeyScopeResponses  = "responses"
+	APIKeyScopeChat       = "chat"
+	APIKeyScopeClaude     = "claude"
+	APIKeyScopeGemini     = "gemini"
+	APIKeyScopeEmbeddings = "embeddings"
+)
+
+// API Key 访问范围：功能
+const (
+	APIKeyScopeStreaming = "streaming"
+	APIKeyScopeTools     = "tools"
+	APIKeyScopeImages    = "images"
+)
+
+var apiKeyEndpointScopes = []string{APIKeyScopeResponses, APIKeyScopeChat, APIKeyScopeClaude, APIKeyScopeGemini, APIKeyScopeEmbeddings}
+
+var apiKeyFeatureScopes = []string{APIKeyScopeStreaming, APIKeyScopeTools, APIKeyScopeImages}
+
+var ErrInvalidAPIKeyScope = infraerrors.BadRequest("INVALID_API_KEY_SCOPE", "scopes only supports responses, chat, claude, gemini, embeddings, streaming, tools and images")
+
+// APIKeyScopeDeniedError 请求的端点或功能不在 API Key 的访问范围内
+type APIKeyScopeDeniedError struct {
+	Scope string
+}
+
+func (e *APIKeyScopeDeniedError) Error() string {
+	return fmt.Sprintf("This API key is not allowed to use %s", e.Scope)
+}
+
+func isAPIKeyEndpointScope(scope string) bool {
+	for _, s := range apiKeyEndpointScopes {
+		if s == scope {
+			return true
+		}
+	}
+	return false
+}
+
+func isAPIKeyFeatureScope(scope string) bool {
+	for _, s := range apiKeyFeatureScopes {
+		if s == scope {
+			return true
+		}
+	}
+	return false
+}
+
+// NormalizeAPIKeyScopes 清理并校验 API Key 的访问范围：转小写、去除空白与重复项，空列表返回 nil（不限制）
+func NormalizeAPIKeyScopes(scopes []string) ([]string, error) {
+	if len(scopes) == 0 {
+		return nil, nil
+	}
+	seen := make(map[string]struct{}, len(scopes))
+	normalized := make([]string, 0, len(scopes))
+	for _, raw := range scopes {
+		scope := strings.ToLower(strings.TrimSpace(raw))
+		if scope == "" {
+			continue
+		}
+		if !isAPIKeyEndpointScope(scope) && !isAPIKeyFeatureScope(scope) {
+			return nil, fmt.Errorf("%w: %q", ErrInvalidAPIKeyScope, raw)
+		}
+		if _, ok := seen[scope]; ok {
+			continue
+		}
+		seen[scope] = struct{}{}
+		normalized = append(normalized, scope)
+	}
+	if len(normalized) == 0 {
+		return nil, nil
+	}
+	return normalized, nil
+}
+
+// HasScope 判断 API Key 是否显式授予了该访问范围
+func (k *APIKey) HasScope(scope string) bool {
+	for _, s := range k.Scopes {
+		if s == scope {
+			return true
+		}
+	}
+	return false
+}
+
+// restrictsScopes 判断 API Key 是否在某一类访问范围上有限制（该类至少列出一项）
+func (k *APIKey) restrictsScopes(isKind func(string) bool) bool {
+	if k == nil {
+		return false
+	}
+	for _, s := range k.Scopes {
+		if isKind(s) {
+			return true
+		}
+	}
+	return false
+}
+
+// RestrictsScopeFeatures 判断 API Key 是否限制了功能（streaming/tools/images）
+func (k *APIKey) RestrictsScopeFeatures() bool {
+	return k.restrictsScopes(isAPIKeyFeatureScope)
+}
+
+// CheckAPIKeyEndpointScope 校验 API Key 是否允许调用该端点；endpoint 为空（模型列表、用量查询等）或未限制端点时放行
+func CheckAPIKeyEndpointScope(apiKey *APIKey, endpoint string) error {
+	if endpoint == "" || !apiKey.restrictsScopes(isAPIKeyEndpointScope) {
+		return nil
+	}
+	if !apiKey.HasScope(endpoint) {
+		return &APIKeyScopeDeniedError{Scope: endpoint}
+	}
+	return nil
+}
+
+// CheckAPIKeyFeatureScopes 校验 API Key 是否允许使用请求中的功能；未限制功能时放行
+func CheckAPIKeyFeatureScopes(apiKey *APIKey, features []string) error {
+	if !apiKey.RestrictsScopeFeatures() {
+		return nil
+	}
+	for _, feature := range features {
+		if !apiKey.HasScope(feature) {
+			return &APIKeyScopeDeniedError{Scope: feature}
+		}
+	}
+	return nil
+}
+
+// APIKeyScopeEndpointForPath 根据请求路径识别端点访问范围，不属于任何端点范围时返回空字符串
+func APIKeyScopeEndpointForPath(path string) string {
+	path = strings.TrimSuffix(path, "/")
+	switch {
+	case strings.HasSuffix(path, "/chat/completions"):
+		return APIKeyScopeChat
+	case strings.HasSuffix(path, "/responses") || strings.Contains(path, "/responses/"):
+		return APIKeyScopeResponses
+	case strings.HasSuffix(path, "/messages") || strings.HasSuffix(path, "/messages/count_tokens"):
+		return APIKeyScopeClaude
+	}
+	if _, action, ok := geminiModelAction(path); ok {
+		switch action {
+		case "embedContent", "batchEmbedContents":
+			return APIKeyScopeEmbeddings
+		default:
+			return APIKeyScopeGemini
+		}
+	}
+	return ""
+}
+
+// APIKeyScopeFeaturesFromURL 识别无需读取请求体即可确定的功能（Gemini 流式由 URL 决定）
+func APIKeyScopeFeaturesFromURL(path string, query url.Values) []string {
+	if _, action, ok := geminiModelAction(strings.TrimSuffix(path, "/")); ok {
+		if action == "streamGenerateContent" || query.Get("alt") == "sse" {
+			return []string{APIKeyScopeStreaming}
+		}
+	}
+	return nil
+}
+
+// geminiModelAction 解析 Gemini 原生路径 /v1beta/models/{model}:{action}
+func geminiModelAction(path string) (model, action string, ok bool) {
+	idx := strings.Index(path, "/v1beta/models/")
+	if idx < 0 {
+		return "", "", false
+	}
+	rest := path[idx+len("/v1beta/models/"):]
+	colon := strings.LastIndex(rest, ":")
+	if colon <= 0 || colon == len(rest)-1 {
+		return "", "", false
+	}
+	return rest[:colon], rest[colon+1:], true
+}
+
+// DetectAPIKeyScopeFeatures 从原始请求体中识别使用的功能（流式、工具、图片输入），
+// 兼容 Claude Messages、OpenAI Chat Completions/Responses 与 Gemini 格式。仅做字段探测，不反序列化请求体。
+func DetectAPIKeyScopeFeatures(body []byte) []string {
+	if len(body) == 0 || !gjson.ValidBytes(body) {
+		return nil
+	}
+	root := gjson.ParseBytes(body)
+	var features []string
+	if root.Get("stream").Bool() {
+		features = append(features, APIKeyScopeStreaming)
+	}
+	if hasNonEmptyArray(root, "tools") || hasNonEmptyArray(root, "functions") {
+		features = append(features, APIKeyScopeTools)
+	}
+	if requestHasImageInput(root) {
+		features = append(features, APIKeyScopeImages)
+	}
+	return features
+}
+
+func hasNonEmptyArray(root gjson.Result, path string) bool {
+	v := root.Get(path)
+	return v.IsArray() && len(v.Array()) > 0
+}
+
+// requestHasImageInput 检测消息内容中的图片块：
+// Claude {"type":"image"}、OpenAI {"type":"image_url"}/{"type":"input_image"}、Gemini inlineData/fileData 图片
+func requestHasImageInput(root gjson.Result) bool {
+	isImagePart := func(part gjson.Result) bool {
+		switch part.Get("type").String() {
+		case "image", "image_url", "input_image":
+			return true
+		}
+		for _, key := range []string{"inlineData", "inline_data", "fileData", "file_data"} {
+			mime := part.Get(key + ".mimeType").String()
+			if mime == "" {
+				mime = part.Get(key + ".mime_type").String()
+			}
+			if strings.HasPrefix(mime, "image/") {
+				return true
+			}
+		}
+		return false
+	}
+	found := false
+	scan := func(items gjson.Result, partsKey string) {
+		items.ForEach(func(_, item gjson.Result) bool {
+			if isImagePart(item) {
+				found = true
+				return false
+			}
+			item.Get(partsKey).ForEach(func(_, part gjson.Result) bool {
+				found = isImagePart(part)
+				return !found
+			})
+			return !found
+		})
+	}
+	scan(root.Get("messages"), "content")
+	if !found {
+		scan(root.Get("input"), "content")
+	}
+	if !found {
+		scan(root.Get("contents"), "parts")
+	}
+	return found
+}
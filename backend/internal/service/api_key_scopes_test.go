@@ -0,0 +1,103 @@
+//go:build unit
+
+package service
+
+import (
+	"errors"
+	"net/url"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestNormalizeAPIKeyScopes(t *testing.T) {
+	scopes, err := NormalizeAPIKeyScopes([]string{" Chat ", "streaming", "chat", ""})
+	require.NoError(t, err)
+	require.Equal(t, []string{"chat", "streaming"}, scopes)
+
+	scopes, err = NormalizeAPIKeyScopes([]string{" "})
+	require.NoError(t, err)
+	require.Nil(t, scopes)
+
+	_, err = NormalizeAPIKeyScopes([]string{"chat", "admin"})
+	require.ErrorIs(t, err, ErrInvalidAPIKeyScope)
+}
+
+func TestAPIKeyScopeEndpointForPath(t *testing.T) {
+	cases := map[string]string{
+		"/v1/messages":              APIKeyScopeClaude,
+		"/antigravity/v1/messages":  APIKeyScopeClaude,
+		"/v1/messages/count_tokens": APIKeyScopeClaude,
+		"/v1/responses":             APIKeyScopeResponses,
+		"/responses":                APIKeyScopeResponses,
+		"/v1/chat/completions":      APIKeyScopeChat,
+		"/chat/completions":         APIKeyScopeChat,
+		"/v1beta/models/gemini-2.5-pro:generateContent":             APIKeyScopeGemini,
+		"/antigravity/v1beta/models/gemini-3:streamGenerateContent": APIKeyScopeGemini,
+		"/v1beta/models/text-embedding-004:embedContent":            APIKeyScopeEmbeddings,
+		"/v1beta/models/text-embedding-004:batchEmbedContents":      APIKeyScopeEmbeddings,
+		"/v1/models":                    "",
+		"/v1/usage":                     "",
+		"/v1beta/models/gemini-2.5-pro": "",
+	}
+	for path, want := range cases {
+		require.Equal(t, want, APIKeyScopeEndpointForPath(path), path)
+	}
+}
+
+func TestAPIKeyScopeFeaturesFromURL(t *testing.T) {
+	require.Equal(t, []string{APIKeyScopeStreaming}, APIKeyScopeFeaturesFromURL("/v1beta/models/gemini-2.5-pro:streamGenerateContent", url.Values{}))
+	require.Equal(t, []string{APIKeyScopeStreaming}, APIKeyScopeFeaturesFromURL("/v1beta/models/gemini-2.5-pro:generateContent", url.Values{"alt": {"sse"}}))
+	require.Empty(t, APIKeyScopeFeaturesFromURL("/v1beta/models/gemini-2.5-pro:generateContent", url.Values{}))
+	require.Empty(t, APIKeyScopeFeaturesFromURL("/v1/messages", url.Values{"alt": {"sse"}}))
+}
+
+func TestDetectAPIKeyScopeFeatures(t *testing.T) {
+	require.Empty(t, DetectAPIKeyScopeFeatures([]byte(`{"model":"claude-sonnet-4-5","messages":[{"role":"user","content":"hi"}]}`)))
+	require.Empty(t, DetectAPIKeyScopeFeatures([]byte(`not json`)))
+	require.Empty(t, DetectAPIKeyScopeFeatures([]byte(`{"stream":false,"tools":[]}`)))
+
+	require.Equal(t, []string{APIKeyScopeStreaming, APIKeyScopeTools},
+		DetectAPIKeyScopeFeatures([]byte(`{"stream":true,"tools":[{"name":"get_weather"}],"messages":[]}`)))
+
+	// Claude 图片块
+	require.Equal(t, []string{APIKeyScopeImages},
+		DetectAPIKeyScopeFeatures([]byte(`{"messages":[{"role":"user","content":[{"type":"text","text":"?"},{"type":"image","source":{"type":"base64"}}]}]}`)))
+	// OpenAI Chat image_url
+	require.Equal(t, []string{APIKeyScopeImages},
+		DetectAPIKeyScopeFeatures([]byte(`{"messages":[{"role":"user","content":[{"type":"image_url","image_url":{"url":"https://x"}}]}]}`)))
+	// OpenAI Responses input_image
+	require.Equal(t, []string{APIKeyScopeImages},
+		DetectAPIKeyScopeFeatures([]byte(`{"input":[{"role":"user","content":[{"type":"input_image","image_url":"https://x"}]}]}`)))
+	// Gemini inlineData
+	require.Equal(t, []string{APIKeyScopeTools, APIKeyScopeImages},
+		DetectAPIKeyScopeFeatures([]byte(`{"tools":[{"functionDeclarations":[]}],"contents":[{"parts":[{"inlineData":{"mimeType":"image/png","data":"AA=="}}]}]}`)))
+	// 非图片附件不计入
+	require.Empty(t, DetectAPIKeyScopeFeatures([]byte(`{"contents":[{"parts":[{"inlineData":{"mimeType":"application/pdf","data":"AA=="}}]}]}`)))
+}
+
+func TestCheckAPIKeyScopes(t *testing.T) {
+	unrestricted := &APIKey{}
+	require.NoError(t, CheckAPIKeyEndpointScope(unrestricted, APIKeyScopeClaude))
+	require.NoError(t, CheckAPIKeyFeatureScopes(unrestricted, []string{APIKeyScopeStreaming, APIKeyScopeTools}))
+
+	// 仅限制端点：功能不限制
+	chatOnly := &APIKey{Scopes: []string{APIKeyScopeChat}}
+	require.NoError(t, CheckAPIKeyEndpointScope(chatOnly, APIKeyScopeChat))
+	require.NoError(t, CheckAPIKeyEndpointScope(chatOnly, ""))
+	require.False(t, chatOnly.RestrictsScopeFeatures())
+	require.NoError(t, CheckAPIKeyFeatureScopes(chatOnly, []string{APIKeyScopeImages}))
+	err := CheckAPIKeyEndpointScope(chatOnly, APIKeyScopeClaude)
+	var denied *APIKeyScopeDeniedError
+	require.True(t, errors.As(err, &denied))
+	require.Equal(t, APIKeyScopeClaude, denied.Scope)
+
+	// 仅限制功能：端点不限制
+	streamingOnly := &APIKey{Scopes: []string{APIKeyScopeStreaming}}
+	require.NoError(t, CheckAPIKeyEndpointScope(streamingOnly, APIKeyScopeGemini))
+	require.NoError(t, CheckAPIKeyFeatureScopes(streamingOnly, []string{APIKeyScopeStreaming}))
+	require.NoError(t, CheckAPIKeyFeatureScopes(streamingOnly, nil))
+	err = CheckAPIKeyFeatureScopes(streamingOnly, []string{APIKeyScopeStreaming, APIKeyScopeTools})
+	require.True(t, errors.As(err, &denied))
+	require.Equal(t, APIKeyScopeTools, denied.Scope)
+}
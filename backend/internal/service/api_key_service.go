@@ -76,6 +76,9 @@ type APIKeyCache interface {
 	// Pub/Sub for L1 cache invalidation across instances
 	PublishAuthCacheInvalidation(ctx context.Context, cacheKey string) error
 	SubscribeAuthCacheInvalidation(ctx context.Context, handler func(cacheKey string)) error
+
+	// MarkRequestSignatureSeen 记录已使用的请求签名（防重放），签名首次出现时返回 true
+	MarkRequestSignatureSeen(ctx context.Context, apiKeyID int64, signature string, ttl time.Duration) (bool, error)
 }
 
 // APIKeyAuthCacheInvalidator 提供认证缓存失效能力
@@ -92,6 +95,26 @@ type CreateAPIKeyRequest struct {
 	CustomKey   *string  `json:"custom_key"`   // 可选的自定义key
 	IPWhitelist []string `json:"ip_whitelist"` // IP 白名单
 	IPBlacklist []string `json:"ip_blacklist"` // IP 黑名单
+	// 允许请求的模型（支持末尾 * 通配，为空不限制）
+	AllowedModels []string `json:"allowed_models"`
+	// 访问范围（端点与功能，为空不限制）
+	Scopes []string `json:"scopes"`
+	// 区域策略（覆盖分组配置）
+	RegionPolicy *RegionPolicy `json:"region_policy"`
+	// 系统提示词注入策略（覆盖分组配置）
+	SystemPromptPolicy *SystemPromptPolicy `json:"system_prompt_policy"`
+	// 用量回调地址（为空不启用，签名密钥自动生成）
+	UsageWebhookURL *string `json:"usage_webhook_url"`
+	// 启用请求签名（签名密钥自动生成）
+	RequestSigning bool `json:"request_signing"`
+	// 内置工具每日调用上限（web_search/code_interpreter/image_generation）
+	ToolLimits map[string]int `json:"tool_limits"`
+	// 每日/每月 token 与请求数硬配额
+	TokenQuota *TokenQuota `json:"token_quota"`
+	// 调试模式：错误响应中附带脱敏后的上游错误详情
+	DebugErrors bool `json:"debug_errors"`
+	// 优先级类别（interactive/batch，空为默认）
+	PriorityClass string `json:"priority_class"`
 
 	// Quota fields
 	Quota         float64 `json:"quota"`           // Quota limit in USD (0 = unlimited)
@@ -105,6 +128,30 @@ type UpdateAPIKeyRequest struct {
 	Status      *string  `json:"status"`
 	IPWhitelist []string `json:"ip_whitelist"` // IP 白名单（空数组清空）
 	IPBlacklist []string `json:"ip_blacklist"` // IP 黑名单（空数组清空）
+	// 允许请求的模型（nil 表示不修改，空数组清空）
+	AllowedModels []string `json:"allowed_models"`
+	// 访问范围（nil 表示不修改，空数组清空）
+	Scopes []string `json:"scopes"`
+	// 区域策略（nil 表示不修改）
+	RegionPolicy *RegionPolicy `json:"region_policy"`
+	// 系统提示词注入策略（nil 表示不修改）
+	SystemPromptPolicy *SystemPromptPolicy `json:"system_prompt_policy"`
+	// 用量回调地址（nil 表示不修改，空字符串关闭）
+	UsageWebhookURL *string `json:"usage_webhook_url"`
+	// 重新生成用量回调签名密钥
+	RotateUsageWebhookSecret bool `json:"rotate_usage_webhook_secret"`
+	// 启用/关闭请求签名（nil 表示不修改）
+	RequestSigning *bool `json:"request_signing"`
+	// 重新生成请求签名密钥
+	RotateSigningSecret bool `json:"rotate_signing_secret"`
+	// 内置工具每日调用上限（nil 表示不修改，空 map 清空）
+	ToolLimits map[string]int `json:"tool_limits"`
+	// 每日/每月 token 与请求数硬配额（nil 表示不修改，空对象清空）
+	TokenQuota *TokenQuota `json:"token_quota"`
+	// 调试模式（nil 表示不修改）
+	DebugErrors *bool `json:"debug_errors"`
+	// 优先级类别（nil 表示不修改，空字符串恢复默认）
+	PriorityClass *string `json:"priority_class"`
 
 	// Quota fields
 	Quota           *float64   `json:"quota"`       // Quota limit in USD (nil = no change, 0 = unlimited)
@@ -125,6 +172,7 @@ type APIKeyService struct {
 	authCacheL1       *ristretto.Cache
 	authCfg           apiKeyAuthCacheConfig
 	authGroup         singleflight.Group
+	gatewayJWTKeys    gatewayJWTKeyCache
 }
 
 // NewAPIKeyService 创建API Key服务实例
@@ -252,6 +300,45 @@ func (s *APIKeyService) Create(ctx context.Context, userID int64, req CreateAPIK
 		}
 	}
 
+	toolLimits, err := NormalizeToolLimits(req.ToolLimits)
+	if err != nil {
+		return nil, err
+	}
+	var tokenQuota TokenQuota
+	if req.TokenQuota != nil {
+		if tokenQuota, err = NormalizeTokenQuota(*req.TokenQuota); err != nil {
+			return nil, err
+		}
+	}
+	allowedModels, err := NormalizeAPIKeyAllowedModels(req.AllowedModels)
+	if err != nil {
+		return nil, err
+	}
+	scopes, err := NormalizeAPIKeyScopes(req.Scopes)
+	if err != nil {
+		return nil, err
+	}
+	priorityClass, err := NormalizePriorityClass(req.PriorityClass)
+	if err != nil {
+		return nil, err
+	}
+	var systemPromptPolicy SystemPromptPolicy
+	if req.SystemPromptPolicy != nil {
+		if systemPromptPolicy, err = NormalizeSystemPromptPolicy(*req.SystemPromptPolicy); err != nil {
+			return nil, err
+		}
+	}
+	var usageWebhook UsageWebhook
+	if req.UsageWebhookURL != nil {
+		if usageWebhook, err = s.buildUsageWebhook(UsageWebhook{}, *req.UsageWebhookURL, false); err != nil {
+			return nil, err
+		}
+	}
+	requestSigning, err := buildRequestSigning(RequestSigning{}, req.RequestSigning, false)
+	if err != nil {
+		return nil, err
+	}
+
 	// 验证分组权限（如果指定了分组）
 	if req.GroupID != nil {
 		group, err := s.groupRepo.GetByID(ctx, *req.GroupID)
@@ -312,6 +399,18 @@ func (s *APIKeyService) Create(ctx context.Context, userID int64, req CreateAPIK
 		Quota:       req.Quota,
 		QuotaUsed:   0,
 	}
+	if req.RegionPolicy != nil {
+		apiKey.RegionPolicy = *req.RegionPolicy
+	}
+	apiKey.SystemPromptPolicy = systemPromptPolicy
+	apiKey.UsageWebhook = usageWebhook
+	apiKey.RequestSigning = requestSigning
+	apiKey.ToolLimits = toolLimits
+	apiKey.TokenQuota = tokenQuota
+	apiKey.AllowedModels = allowedModels
+	apiKey.Scopes = scopes
+	apiKey.DebugErrors = req.DebugErrors
+	apiKey.PriorityClass = priorityClass
 
 	// Set expiration time if specified
 	if req.ExpiresInDays != nil && *req.ExpiresInDays > 0 {
@@ -497,6 +596,77 @@ func (s *APIKeyService) Update(ctx context.Context, id int64, userID int64, req
 	apiKey.IPWhitelist = req.IPWhitelist
 	apiKey.IPBlacklist = req.IPBlacklist
 
+	if req.RegionPolicy != nil {
+		apiKey.RegionPolicy = *req.RegionPolicy
+	}
+	if req.SystemPromptPolicy != nil {
+		policy, err := NormalizeSystemPromptPolicy(*req.SystemPromptPolicy)
+		if err != nil {
+			return nil, err
+		}
+		apiKey.SystemPromptPolicy = policy
+	}
+	if req.UsageWebhookURL != nil || req.RotateUsageWebhookSecret {
+		rawURL := apiKey.UsageWebhook.URL
+		if req.UsageWebhookURL != nil {
+			rawURL = *req.UsageWebhookURL
+		}
+		webhook, err := s.buildUsageWebhook(apiKey.UsageWebhook, rawURL, req.RotateUsageWebhookSecret)
+		if err != nil {
+			return nil, err
+		}
+		apiKey.UsageWebhook = webhook
+	}
+	if req.RequestSigning != nil || req.RotateSigningSecret {
+		enabled := apiKey.RequestSigning.IsEnabled()
+		if req.RequestSigning != nil {
+			enabled = *req.RequestSigning
+		}
+		requestSigning, err := buildRequestSigning(apiKey.RequestSigning, enabled, req.RotateSigningSecret)
+		if err != nil {
+			return nil, err
+		}
+		apiKey.RequestSigning = requestSigning
+	}
+	if req.ToolLimits != nil {
+		toolLimits, err := NormalizeToolLimits(req.ToolLimits)
+		if err != nil {
+			return nil, err
+		}
+		apiKey.ToolLimits = toolLimits
+	}
+	if req.TokenQuota != nil {
+		tokenQuota, err := NormalizeTokenQuota(*req.TokenQuota)
+		if err != nil {
+			return nil, err
+		}
+		apiKey.TokenQuota = tokenQuota
+	}
+	if req.AllowedModels != nil {
+		allowedModels, err := NormalizeAPIKeyAllowedModels(req.AllowedModels)
+		if err != nil {
+			return nil, err
+		}
+		apiKey.AllowedModels = allowedModels
+	}
+	if req.Scopes != nil {
+		scopes, err := NormalizeAPIKeyScopes(req.Scopes)
+		if err != nil {
+			return nil, err
+		}
+		apiKey.Scopes = scopes
+	}
+	if req.DebugErrors != nil {
+		apiKey.DebugErrors = *req.DebugErrors
+	}
+	if req.PriorityClass != nil {
+		priorityClass, err := NormalizePriorityClass(*req.PriorityClass)
+		if err != nil {
+			return nil, err
+		}
+		apiKey.PriorityClass = priorityClass
+	}
+
 	if err := s.apiKeyRepo.Update(ctx, apiKey); err != nil {
 		return nil, fmt.Errorf("update api key: %w", err)
 	}
@@ -531,6 +701,23 @@ func (s *APIKeyService) Delete(ctx context.Context, id int64, userID int64) erro
 	return nil
 }
 
+// Suspend 停用 API Key（用量异常等系统处置），用户可在确认后重新启用或轮换
+func (s *APIKeyService) Suspend(ctx context.Context, id int64) error {
+	apiKey, err := s.apiKeyRepo.GetByID(ctx, id)
+	if err != nil {
+		return fmt.Errorf("get api key: %w", err)
+	}
+	if apiKey.Status == StatusAPIKeyDisabled {
+		return nil
+	}
+	apiKey.Status = StatusAPIKeyDisabled
+	if err := s.apiKeyRepo.Update(ctx, apiKey); err != nil {
+		return fmt.Errorf("suspend api key: %w", err)
+	}
+	s.InvalidateAuthCacheByKey(ctx, apiKey.Key)
+	return nil
+}
+
 // ValidateKey 验证API Key是否有效（用于认证中间件）
 func (s *APIKeyService) ValidateKey(ctx context.Context, key string) (*APIKey, *User, error) {
 	// 获取API Key
@@ -154,6 +154,10 @@ func (s *authCacheStub) SubscribeAuthCacheInvalidation(ctx context.Context, hand
 	return nil
 }
 
+func (s *authCacheStub) MarkRequestSignatureSeen(ctx context.Context, apiKeyID int64, signature string, ttl time.Duration) (bool, error) {
+	return true, nil
+}
+
 func TestAPIKeyService_GetByKey_UsesL2Cache(t *testing.T) {
 	cache := &authCacheStub{}
 	repo := &authRepoStub{
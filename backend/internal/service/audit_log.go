@@ -0,0 +1,306 @@
+package service
+
+import (
+	"context"
+	"log"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/Wei-Shaw/sub2api/internal/config"
+	"github.com/Wei-Shaw/sub2api/internal/pkg/envelope"
+	infraerrors "github.com/Wei-Shaw/sub2api/internal/pkg/errors"
+	"github.com/Wei-Shaw/sub2api/internal/pkg/pagination"
+)
+
+var (
+	ErrAuditLogNotFound = infraerrors.NotFound("AUDIT_LOG_NOT_FOUND", "audit log not found")
+	ErrAuditLogDisabled = infraerrors.BadRequest("AUDIT_LOG_DISABLED", "audit log is disabled")
+)
+
+// auditLogWriteTimeout 单条审计日志写入超时
+const auditLogWriteTimeout = 5 * time.Second
+
+// auditLogRetentionInterval 过期审计日志清理间隔
+const auditLogRetentionInterval = 24 * time.Hour
+
+// auditLogDropLogInterval 队列满丢弃时的日志输出间隔
+const auditLogDropLogInterval = time.Minute
+
+// AuditLog 网关请求审计记录
+type AuditLog struct {
+	ID            int64     `json:"id"`
+	RequestID     string    `json:"request_id"`
+	UserID        *int64    `json:"user_id"`
+	APIKeyID      *int64    `json:"api_key_id"`
+	AccountID     *int64    `json:"account_id"`
+	GroupID       *int64    `json:"group_id"`
+	Platform      string    `json:"platform"`
+	Model         string    `json:"model"`
+	Method        string    `json:"method"`
+	Path          string    `json:"path"`
+	StatusCode    int       `json:"status_code"`
+	Stream        bool      `json:"stream"`
+	DurationMs    int       `json:"duration_ms"`
+	ClientIP      string    `json:"client_ip"`
+	UserAgent     string    `json:"user_agent"`
+	RequestBytes  int       `json:"request_bytes"`
+	ResponseBytes int       `json:"response_bytes"`
+	RequestBody   *string   `json:"request_body,omitempty"`
+	ResponseBody  *string   `json:"response_body,omitempty"`
+	BodyTruncated bool      `json:"body_truncated"`
+	CreatedAt     time.Time `json:"created_at"`
+
+	// BodyKeyID 包装数据密钥的主密钥版本（空表示请求/响应体为明文）
+	BodyKeyID string `json:"-"`
+	// BodyWrappedKey 包装后的数据密钥（base64）
+	BodyWrappedKey *string `json:"-"`
+}
+
+// AuditLogFilter 审计日志查询条件
+type AuditLogFilter struct {
+	StartTime  *time.Time
+	EndTime    *time.Time
+	UserID     *int64
+	APIKeyID   *int64
+	AccountID  *int64
+	Model      string
+	RequestID  string
+	StatusCode *int
+}
+
+// AuditLogRepository 审计日志持久化端口
+type AuditLogRepository interface {
+	Create(ctx context.Context, entry *AuditLog) error
+	// List 按创建时间倒序分页查询（列表不返回请求/响应体）
+	List(ctx context.Context, params pagination.PaginationParams, filter AuditLogFilter) ([]AuditLog, *pagination.PaginationResult, error)
+	GetByID(ctx context.Context, id int64) (*AuditLog, error)
+	// DeleteBefore 删除 before 之前创建的记录，返回删除条数
+	DeleteBefore(ctx context.Context, before time.Time) (int64, error)
+	// ListBodyKeysAfter 按 ID 升序返回 afterID 之后带请求/响应体的记录（仅 ID、用户与密钥字段；明文记录同时返回请求/响应体）
+	ListBodyKeysAfter(ctx context.Context, afterID int64, limit int) ([]AuditLog, error)
+	// UpdateBodies 写入加密后的请求/响应体及其数据密钥
+	UpdateBodies(ctx context.Context, id int64, requestBody, responseBody *string, keyID string, wrappedKey string) error
+	// UpdateBodyKey 仅替换包装后的数据密钥（主密钥轮换），请求/响应体不变
+	UpdateBodyKey(ctx context.Context, id int64, keyID string, wrappedKey string) error
+}
+
+type auditLogJob struct {
+	entry    *AuditLog
+	request  *AuditBodyCapture
+	response *AuditBodyCapture
+}
+
+// AuditLogService 网关请求审计日志：请求路径只做非阻塞入队，由后台协程脱敏请求/响应体后写入；
+// 按 retention_days 每日清理过期记录。未启用时为 nil（nil 服务的方法均为空操作）。
+type AuditLogService struct {
+	repo      AuditLogRepository
+	cfg       config.AuditLogConfig
+	redactor  *AuditRedactor
+	queue     chan auditLogJob
+	dropped   atomic.Int64
+	lastDrop  atomic.Int64
+	stopCh    chan struct{}
+	stopOnce  sync.Once
+	wg        sync.WaitGroup
+	nowFunc   func() time.Time
+	retention time.Duration
+	// keyRing 非空时请求/响应体以用户数据密钥加密后保存
+	keyRing *envelope.KeyRing
+}
+
+// NewAuditLogService 创建审计日志服务；audit_log.enabled=false 时返回 nil
+func NewAuditLogService(repo AuditLogRepository, cfg *config.Config) (*AuditLogService, error) {
+	if repo == nil || cfg == nil || !cfg.AuditLog.Enabled {
+		return nil, nil
+	}
+	keyRing, err := newAuditLogKeyRing(cfg.AuditLog.Encryption)
+	if err != nil {
+		return nil, err
+	}
+	return &AuditLogService{
+		repo:      repo,
+		cfg:       cfg.AuditLog,
+		redactor:  NewAuditRedactor(cfg.AuditLog),
+		queue:     make(chan auditLogJob, cfg.AuditLog.QueueSize),
+		stopCh:    make(chan struct{}),
+		nowFunc:   time.Now,
+		retention: time.Duration(cfg.AuditLog.RetentionDays) * 24 * time.Hour,
+		keyRing:   keyRing,
+	}, nil
+}
+
+// Enabled 是否启用审计日志
+func (s *AuditLogService) Enabled() bool {
+	return s != nil
+}
+
+// NewBodyCapture 为一次请求创建请求体/响应体捕获器；未开启 capture_bodies 时返回 nil
+func (s *AuditLogService) NewBodyCapture() *AuditBodyCapture {
+	if s == nil || !s.cfg.CaptureBodies {
+		return nil
+	}
+	return NewAuditBodyCapture(s.cfg.MaxBodyBytes)
+}
+
+// Record 非阻塞地提交审计记录，脱敏与写库在后台协程完成；队列已满时丢弃
+func (s *AuditLogService) Record(entry *AuditLog, request, response *AuditBodyCapture) {
+	if s == nil || entry == nil {
+		return
+	}
+	select {
+	case s.queue <- auditLogJob{entry: entry, request: request, response: response}:
+	default:
+		dropped := s.dropped.Add(1)
+		now := time.Now().UnixNano()
+		last := s.lastDrop.Load()
+		if now-last >= int64(auditLogDropLogInterval) && s.lastDrop.CompareAndSwap(last, now) {
+			log.Printf("[AuditLog] Queue full, dropped %d records so far", dropped)
+		}
+	}
+}
+
+// Start 启动写入协程与过期清理
+func (s *AuditLogService) Start() {
+	if s == nil {
+		return
+	}
+	for i := 0; i < s.cfg.Workers; i++ {
+		s.wg.Add(1)
+		go func() {
+			defer s.wg.Done()
+			s.runWriter()
+		}()
+	}
+	if s.retention > 0 {
+		s.wg.Add(1)
+		go func() {
+			defer s.wg.Done()
+			s.runRetention()
+		}()
+	}
+}
+
+// Stop 停止后台协程；队列中剩余记录写入后退出
+func (s *AuditLogService) Stop() {
+	if s == nil {
+		return
+	}
+	s.stopOnce.Do(func() {
+		close(s.stopCh)
+	})
+	s.wg.Wait()
+}
+
+func (s *AuditLogService) runWriter() {
+	for {
+		select {
+		case job := <-s.queue:
+			s.write(job)
+		case <-s.stopCh:
+			for {
+				select {
+				case job := <-s.queue:
+					s.write(job)
+				default:
+					return
+				}
+			}
+		}
+	}
+}
+
+func (s *AuditLogService) write(job auditLogJob) {
+	entry := job.entry
+	entry.UserAgent = redactPII(entry.UserAgent)
+	if job.request != nil {
+		body, truncated := s.redactor.RedactCapture(job.request)
+		entry.RequestBody = &body
+		entry.BodyTruncated = entry.BodyTruncated || truncated
+	}
+	if job.response != nil {
+		body, truncated := s.redactor.RedactCapture(job.response)
+		entry.ResponseBody = &body
+		entry.BodyTruncated = entry.BodyTruncated || truncated
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), auditLogWriteTimeout)
+	defer cancel()
+	if err := s.sealBodies(ctx, entry); err != nil {
+		// 加密失败时不落明文，仅保留元数据
+		log.Printf("[AuditLog] Body encryption failed, dropping bodies: request_id=%s err=%v", entry.RequestID, err)
+		entry.RequestBody, entry.ResponseBody = nil, nil
+		entry.BodyKeyID, entry.BodyWrappedKey = "", nil
+	}
+	if err := s.repo.Create(ctx, entry); err != nil {
+		log.Printf("[AuditLog] Write failed: request_id=%s err=%v", entry.RequestID, err)
+	}
+}
+
+func (s *AuditLogService) runRetention() {
+	s.purgeExpired()
+	ticker := time.NewTicker(auditLogRetentionInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ticker.C:
+			s.purgeExpired()
+		case <-s.stopCh:
+			return
+		}
+	}
+}
+
+func (s *AuditLogService) purgeExpired() {
+	ctx, cancel := context.WithTimeout(context.Background(), time.Minute)
+	defer cancel()
+	deleted, err := s.repo.DeleteBefore(ctx, s.nowFunc().Add(-s.retention))
+	if err != nil {
+		log.Printf("[AuditLog] Retention cleanup failed: %v", err)
+		return
+	}
+	if deleted > 0 {
+		log.Printf("[AuditLog] Retention cleanup removed %d records", deleted)
+	}
+}
+
+// List 分页查询审计日志（不含请求/响应体）
+func (s *AuditLogService) List(ctx context.Context, params pagination.PaginationParams, filter AuditLogFilter) ([]AuditLog, *pagination.PaginationResult, error) {
+	if s == nil {
+		return nil, nil, ErrAuditLogDisabled
+	}
+	return s.repo.List(ctx, params, filter)
+}
+
+// GetByID 获取单条审计日志（含脱敏后的请求/响应体）
+func (s *AuditLogService) GetByID(ctx context.Context, id int64) (*AuditLog, error) {
+	if s == nil {
+		return nil, ErrAuditLogDisabled
+	}
+	entry, err := s.repo.GetByID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.openBodies(ctx, entry); err != nil {
+		return nil, err
+	}
+	return entry, nil
+}
+
+// Purge 手动删除 before 之前的审计日志，返回删除条数
+func (s *AuditLogService) Purge(ctx context.Context, before time.Time) (int64, error) {
+	if s == nil {
+		return 0, ErrAuditLogDisabled
+	}
+	if before.IsZero() {
+		return 0, infraerrors.BadRequest("INVALID_AUDIT_PURGE_BEFORE", "before is required")
+	}
+	return s.repo.DeleteBefore(ctx, before)
+}
+
+// RetentionDays 返回配置的保留天数（0 表示永久保留）
+func (s *AuditLogService) RetentionDays() int {
+	if s == nil {
+		return 0
+	}
+	return s.cfg.RetentionDays
+}
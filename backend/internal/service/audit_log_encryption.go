@@ -0,0 +1,202 @@
+package service
+
+import (
+	"context"
+	"encoding/base64"
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/Wei-Shaw/sub2api/internal/config"
+	"github.com/Wei-Shaw/sub2api/internal/pkg/credcrypt"
+	"github.com/Wei-Shaw/sub2api/internal/pkg/envelope"
+	infraerrors "github.com/Wei-Shaw/sub2api/internal/pkg/errors"
+)
+
+// ErrAuditLogBodyKeyUnavailable 记录的请求/响应体已加密，但当前未配置可解包其数据密钥的主密钥
+var ErrAuditLogBodyKeyUnavailable = infraerrors.ServiceUnavailable("AUDIT_LOG_BODY_KEY_UNAVAILABLE", "audit log body is encrypted and its key is unavailable")
+
+// newAuditLogKeyRing 按 audit_log.encryption 创建数据密钥环；未启用时返回 nil
+func newAuditLogKeyRing(enc config.AuditLogEncryptionConfig) (*envelope.KeyRing, error) {
+	if !enc.Enabled {
+		return nil, nil
+	}
+	var wrapper envelope.KeyWrapper
+	switch enc.Provider {
+	case "command":
+		w, err := envelope.NewCommandKeyWrapper(enc.KeyID, enc.WrapCommand, enc.UnwrapCommand)
+		if err != nil {
+			return nil, err
+		}
+		wrapper = w
+	default:
+		key, err := credcrypt.DecodeKey(enc.Key)
+		if err != nil {
+			return nil, fmt.Errorf("audit_log.encryption.key: %w", err)
+		}
+		keys := map[string][]byte{enc.KeyID: key}
+		for keyID, encoded := range enc.PreviousKeys {
+			prevKey, err := credcrypt.DecodeKey(encoded)
+			if err != nil {
+				return nil, fmt.Errorf("audit_log.encryption.previous_keys.%s: %w", keyID, err)
+			}
+			if _, ok := keys[keyID]; !ok {
+				keys[keyID] = prevKey
+			}
+		}
+		w, err := envelope.NewLocalKeyWrapper(enc.KeyID, keys)
+		if err != nil {
+			return nil, err
+		}
+		wrapper = w
+	}
+	return envelope.NewKeyRing(wrapper, time.Duration(enc.DataKeyTTLMinutes)*time.Minute), nil
+}
+
+// auditLogTenant 数据密钥按用户划分；租户标识同时作为附加认证数据，密文无法被挪用到其他用户的记录
+func auditLogTenant(userID *int64) string {
+	if userID == nil {
+		return "audit_log:anonymous"
+	}
+	return "audit_log:user:" + strconv.FormatInt(*userID, 10)
+}
+
+// sealBodies 以用户数据密钥加密请求/响应体，并在记录上附带包装后的数据密钥；未启用加密或无请求体时不做处理
+func (s *AuditLogService) sealBodies(ctx context.Context, entry *AuditLog) error {
+	if s.keyRing == nil || (entry.RequestBody == nil && entry.ResponseBody == nil) {
+		return nil
+	}
+	tenant := auditLogTenant(entry.UserID)
+	key, err := s.keyRing.TenantKey(ctx, tenant)
+	if err != nil {
+		return err
+	}
+	requestBody, err := sealAuditBody(key, entry.RequestBody, tenant)
+	if err != nil {
+		return err
+	}
+	responseBody, err := sealAuditBody(key, entry.ResponseBody, tenant)
+	if err != nil {
+		return err
+	}
+	wrapped := base64.StdEncoding.EncodeToString(key.Wrapped)
+	entry.RequestBody, entry.ResponseBody = requestBody, responseBody
+	entry.BodyKeyID, entry.BodyWrappedKey = key.KeyID, &wrapped
+	return nil
+}
+
+func sealAuditBody(key *envelope.TenantKey, body *string, tenant string) (*string, error) {
+	if body == nil {
+		return nil, nil
+	}
+	sealed, err := key.Encrypt(*body, tenant)
+	if err != nil {
+		return nil, err
+	}
+	return &sealed, nil
+}
+
+// openBodies 解密记录中的请求/响应体；明文记录原样返回
+func (s *AuditLogService) openBodies(ctx context.Context, entry *AuditLog) error {
+	if entry.BodyKeyID == "" {
+		return nil
+	}
+	if s.keyRing == nil || entry.BodyWrappedKey == nil {
+		return ErrAuditLogBodyKeyUnavailable
+	}
+	wrapped, err := base64.StdEncoding.DecodeString(*entry.BodyWrappedKey)
+	if err != nil {
+		return fmt.Errorf("audit log %d: decode wrapped key: %w", entry.ID, err)
+	}
+	tenant := auditLogTenant(entry.UserID)
+	for _, body := range []*string{entry.RequestBody, entry.ResponseBody} {
+		if body == nil {
+			continue
+		}
+		plaintext, err := s.keyRing.Decrypt(ctx, entry.BodyKeyID, wrapped, *body, tenant)
+		if err != nil {
+			return fmt.Errorf("audit log %d: %w", entry.ID, err)
+		}
+		*body = plaintext
+	}
+	entry.BodyKeyID, entry.BodyWrappedKey = "", nil
+	return nil
+}
+
+// EncryptStoredBodies 批量处理已保存的请求/响应体：明文记录加密后写回；由旧版本主密钥包装数据密钥的记录
+// 仅重新包装数据密钥，请求/响应体密文保持不变。可重复执行，已是当前主密钥版本的记录会被跳过。
+func (s *AuditLogService) EncryptStoredBodies(ctx context.Context, batchSize int, dryRun bool) (scanned, updated int, err error) {
+	if s == nil {
+		return 0, 0, ErrAuditLogDisabled
+	}
+	if s.keyRing == nil {
+		return 0, 0, fmt.Errorf("audit_log.encryption.enabled must be true")
+	}
+	if batchSize <= 0 {
+		batchSize = 200
+	}
+	type rewrapped struct {
+		keyID   string
+		wrapped string
+	}
+	// 同一数据密钥被大量记录共享，重新包装结果按原包装值缓存，每个数据密钥只调用一次 KMS
+	rewrapCache := make(map[string]rewrapped)
+	currentKeyID := s.keyRing.KeyID()
+
+	var lastID int64
+	for {
+		entries, err := s.repo.ListBodyKeysAfter(ctx, lastID, batchSize)
+		if err != nil {
+			return scanned, updated, fmt.Errorf("load audit logs: %w", err)
+		}
+		if len(entries) == 0 {
+			return scanned, updated, nil
+		}
+		for i := range entries {
+			entry := &entries[i]
+			lastID = entry.ID
+			scanned++
+			if entry.BodyKeyID == currentKeyID {
+				continue
+			}
+			updated++
+			if dryRun {
+				continue
+			}
+
+			if entry.BodyKeyID == "" {
+				if err := s.sealBodies(ctx, entry); err != nil {
+					return scanned, updated, fmt.Errorf("audit log %d: %w", entry.ID, err)
+				}
+				if entry.BodyWrappedKey == nil {
+					continue
+				}
+				if err := s.repo.UpdateBodies(ctx, entry.ID, entry.RequestBody, entry.ResponseBody, entry.BodyKeyID, *entry.BodyWrappedKey); err != nil {
+					return scanned, updated, fmt.Errorf("update audit log %d: %w", entry.ID, err)
+				}
+				continue
+			}
+
+			if entry.BodyWrappedKey == nil {
+				return scanned, updated, fmt.Errorf("audit log %d: missing wrapped key", entry.ID)
+			}
+			cacheKey := entry.BodyKeyID + ":" + *entry.BodyWrappedKey
+			next, ok := rewrapCache[cacheKey]
+			if !ok {
+				wrapped, err := base64.StdEncoding.DecodeString(*entry.BodyWrappedKey)
+				if err != nil {
+					return scanned, updated, fmt.Errorf("audit log %d: decode wrapped key: %w", entry.ID, err)
+				}
+				keyID, newWrapped, _, err := s.keyRing.Rewrap(ctx, entry.BodyKeyID, wrapped)
+				if err != nil {
+					return scanned, updated, fmt.Errorf("audit log %d: %w", entry.ID, err)
+				}
+				next = rewrapped{keyID: keyID, wrapped: base64.StdEncoding.EncodeToString(newWrapped)}
+				rewrapCache[cacheKey] = next
+			}
+			if err := s.repo.UpdateBodyKey(ctx, entry.ID, next.keyID, next.wrapped); err != nil {
+				return scanned, updated, fmt.Errorf("update audit log %d: %w", entry.ID, err)
+			}
+		}
+	}
+}
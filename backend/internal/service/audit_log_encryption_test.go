@@ -0,0 +1,201 @@
+package service
+
+import (
+	"context"
+	"encoding/base64"
+	"sort"
+	"testing"
+	"time"
+
+	"github.com/Wei-Shaw/sub2api/internal/config"
+	"github.com/Wei-Shaw/sub2api/internal/pkg/pagination"
+	"github.com/stretchr/testify/require"
+)
+
+type auditLogRepoStub struct {
+	rows   map[int64]*AuditLog
+	nextID int64
+}
+
+func newAuditLogRepoStub() *auditLogRepoStub {
+	return &auditLogRepoStub{rows: map[int64]*AuditLog{}}
+}
+
+func (r *auditLogRepoStub) Create(ctx context.Context, entry *AuditLog) error {
+	r.nextID++
+	entry.ID = r.nextID
+	stored := *entry
+	r.rows[entry.ID] = &stored
+	return nil
+}
+
+func (r *auditLogRepoStub) List(ctx context.Context, params pagination.PaginationParams, filter AuditLogFilter) ([]AuditLog, *pagination.PaginationResult, error) {
+	return nil, nil, nil
+}
+
+func (r *auditLogRepoStub) GetByID(ctx context.Context, id int64) (*AuditLog, error) {
+	row, ok := r.rows[id]
+	if !ok {
+		return nil, ErrAuditLogNotFound
+	}
+	entry := *row
+	entry.RequestBody = cloneStringPtr(row.RequestBody)
+	entry.ResponseBody = cloneStringPtr(row.ResponseBody)
+	entry.BodyWrappedKey = cloneStringPtr(row.BodyWrappedKey)
+	return &entry, nil
+}
+
+func (r *auditLogRepoStub) DeleteBefore(ctx context.Context, before time.Time) (int64, error) {
+	return 0, nil
+}
+
+func (r *auditLogRepoStub) ListBodyKeysAfter(ctx context.Context, afterID int64, limit int) ([]AuditLog, error) {
+	ids := make([]int64, 0, len(r.rows))
+	for id, row := range r.rows {
+		if id > afterID && (row.RequestBody != nil || row.ResponseBody != nil) {
+			ids = append(ids, id)
+		}
+	}
+	sort.Slice(ids, func(i, j int) bool { return ids[i] < ids[j] })
+	var out []AuditLog
+	for _, id := range ids {
+		if len(out) == limit {
+			break
+		}
+		entry, _ := r.GetByID(ctx, id)
+		out = append(out, *entry)
+	}
+	return out, nil
+}
+
+func (r *auditLogRepoStub) UpdateBodies(ctx context.Context, id int64, requestBody, responseBody *string, keyID string, wrappedKey string) error {
+	row := r.rows[id]
+	row.RequestBody, row.ResponseBody = cloneStringPtr(requestBody), cloneStringPtr(responseBody)
+	row.BodyKeyID, row.BodyWrappedKey = keyID, &wrappedKey
+	return nil
+}
+
+func (r *auditLogRepoStub) UpdateBodyKey(ctx context.Context, id int64, keyID string, wrappedKey string) error {
+	row := r.rows[id]
+	row.BodyKeyID, row.BodyWrappedKey = keyID, &wrappedKey
+	return nil
+}
+
+func cloneStringPtr(s *string) *string {
+	if s == nil {
+		return nil
+	}
+	v := *s
+	return &v
+}
+
+func auditEncryptionConfig(keyID string, key byte, previous map[string]byte) *config.Config {
+	encode := func(b byte) string {
+		raw := make([]byte, 32)
+		for i := range raw {
+			raw[i] = b
+		}
+		return base64.StdEncoding.EncodeToString(raw)
+	}
+	cfg := &config.Config{}
+	cfg.AuditLog = config.AuditLogConfig{Enabled: true, Workers: 1, QueueSize: 10, CaptureBodies: true, MaxBodyBytes: 1024}
+	cfg.AuditLog.Encryption = config.AuditLogEncryptionConfig{
+		Enabled:      true,
+		Provider:     "local",
+		KeyID:        keyID,
+		Key:          encode(key),
+		PreviousKeys: map[string]string{},
+	}
+	for id, b := range previous {
+		cfg.AuditLog.Encryption.PreviousKeys[id] = encode(b)
+	}
+	return cfg
+}
+
+func TestAuditLogEncryption_SealsBodiesPerUser(t *testing.T) {
+	repo := newAuditLogRepoStub()
+	svc, err := NewAuditLogService(repo, auditEncryptionConfig("v1", 1, nil))
+	require.NoError(t, err)
+
+	userID := int64(7)
+	req, resp := `{"model":"m"}`, `{"id":"r"}`
+	svc.write(auditLogJob{entry: &AuditLog{UserID: &userID, RequestBody: &req, ResponseBody: &resp}})
+
+	stored := repo.rows[1]
+	require.Equal(t, "v1", stored.BodyKeyID)
+	require.NotNil(t, stored.BodyWrappedKey)
+	require.NotEqual(t, req, *stored.RequestBody)
+	require.NotEqual(t, resp, *stored.ResponseBody)
+
+	got, err := svc.GetByID(context.Background(), 1)
+	require.NoError(t, err)
+	require.Equal(t, req, *got.RequestBody)
+	require.Equal(t, resp, *got.ResponseBody)
+
+	// 租户绑定在密文上，挪到其他用户名下的记录无法解密
+	otherUser := int64(8)
+	stored.UserID = &otherUser
+	_, err = svc.GetByID(context.Background(), 1)
+	require.Error(t, err)
+
+	// 未配置加密的服务无法读取已加密记录
+	plain, err := NewAuditLogService(repo, &config.Config{AuditLog: config.AuditLogConfig{Enabled: true, Workers: 1, QueueSize: 10}})
+	require.NoError(t, err)
+	stored.UserID = &userID
+	_, err = plain.GetByID(context.Background(), 1)
+	require.ErrorIs(t, err, ErrAuditLogBodyKeyUnavailable)
+}
+
+func TestAuditLogEncryption_RotationRewrapsKeysWithoutReencryptingBodies(t *testing.T) {
+	ctx := context.Background()
+	repo := newAuditLogRepoStub()
+	v1, err := NewAuditLogService(repo, auditEncryptionConfig("v1", 1, nil))
+	require.NoError(t, err)
+
+	userID := int64(7)
+	req, resp := `{"model":"m"}`, `{"id":"r"}`
+	v1.write(auditLogJob{entry: &AuditLog{UserID: &userID, RequestBody: &req, ResponseBody: &resp}})
+	v1.write(auditLogJob{entry: &AuditLog{UserID: &userID, RequestBody: &req}})
+	// 启用加密前写入的明文记录
+	legacy := `{"legacy":true}`
+	require.NoError(t, repo.Create(ctx, &AuditLog{RequestBody: &legacy}))
+
+	sealedReq := *repo.rows[1].RequestBody
+	sealedResp := *repo.rows[1].ResponseBody
+	oldWrapped := *repo.rows[1].BodyWrappedKey
+	// 同一用户在有效期内复用数据密钥
+	require.Equal(t, oldWrapped, *repo.rows[2].BodyWrappedKey)
+
+	v2, err := NewAuditLogService(repo, auditEncryptionConfig("v2", 2, map[string]byte{"v1": 1}))
+	require.NoError(t, err)
+	scanned, updated, err := v2.EncryptStoredBodies(ctx, 2, false)
+	require.NoError(t, err)
+	require.Equal(t, 3, scanned)
+	require.Equal(t, 3, updated)
+
+	// 数据密钥已用新主密钥重新包装，请求/响应体密文逐字节不变
+	rotated := repo.rows[1]
+	require.Equal(t, "v2", rotated.BodyKeyID)
+	require.NotEqual(t, oldWrapped, *rotated.BodyWrappedKey)
+	require.Equal(t, sealedReq, *rotated.RequestBody)
+	require.Equal(t, sealedResp, *rotated.ResponseBody)
+	require.Equal(t, *rotated.BodyWrappedKey, *repo.rows[2].BodyWrappedKey)
+
+	require.Equal(t, "v2", repo.rows[3].BodyKeyID)
+	require.NotEqual(t, legacy, *repo.rows[3].RequestBody)
+
+	// 旧主密钥下线后仍可解密全部记录
+	v2Only, err := NewAuditLogService(repo, auditEncryptionConfig("v2", 2, nil))
+	require.NoError(t, err)
+	got, err := v2Only.GetByID(ctx, 1)
+	require.NoError(t, err)
+	require.Equal(t, req, *got.RequestBody)
+	require.Equal(t, resp, *got.ResponseBody)
+	got, err = v2Only.GetByID(ctx, 3)
+	require.NoError(t, err)
+	require.Equal(t, legacy, *got.RequestBody)
+
+	_, updated, err = v2Only.EncryptStoredBodies(ctx, 2, false)
+	require.NoError(t, err)
+	require.Zero(t, updated)
+}
@@ -0,0 +1,227 @@
+package service
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"hash"
+	"strings"
+	"sync"
+
+	"github.com/Wei-Shaw/sub2api/internal/config"
+)
+
+// auditPromptKeys 承载提示词/生成内容的 JSON 字段（值为字符串时屏蔽，值为对象/数组时递归处理）
+var auditPromptKeys = map[string]struct{}{
+	"content":      {},
+	"text":         {},
+	"prompt":       {},
+	"input":        {},
+	"instructions": {},
+	"system":       {},
+	"thinking":     {},
+	"arguments":    {},
+	"output":       {},
+	"delta":        {},
+	"partial_json": {},
+	"refusal":      {},
+}
+
+// auditImageKeys 承载内联图片/文件数据的 JSON 字段
+var auditImageKeys = map[string]struct{}{
+	"data":      {},
+	"b64_json":  {},
+	"file_data": {},
+	"image_url": {},
+}
+
+// AuditBodyCapture 请求体/响应体捕获器：保留前 limit 字节用于脱敏存储，
+// 同时对完整内容计算 SHA-256 与总字节数（超出上限时仅保存摘要）。可作为 io.Writer 使用。
+type AuditBodyCapture struct {
+	mu    sync.Mutex
+	limit int
+	buf   bytes.Buffer
+	total int
+	hash  hash.Hash
+}
+
+// NewAuditBodyCapture 创建保留前 limit 字节的捕获器
+func NewAuditBodyCapture(limit int) *AuditBodyCapture {
+	return &AuditBodyCapture{limit: limit, hash: sha256.New()}
+}
+
+// Write 实现 io.Writer，始终返回 len(p)
+func (c *AuditBodyCapture) Write(p []byte) (int, error) {
+	if c == nil {
+		return len(p), nil
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	n := len(p)
+	c.total += n
+	_, _ = c.hash.Write(p)
+	if remaining := c.limit - c.buf.Len(); remaining > 0 {
+		if n > remaining {
+			p = p[:remaining]
+		}
+		_, _ = c.buf.Write(p)
+	}
+	return n, nil
+}
+
+// Size 返回已写入的总字节数
+func (c *AuditBodyCapture) Size() int {
+	if c == nil {
+		return 0
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.total
+}
+
+// snapshot 返回已保留内容、是否截断与完整内容摘要
+func (c *AuditBodyCapture) snapshot() ([]byte, bool, int, string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.buf.Bytes(), c.total > c.buf.Len(), c.total, hex.EncodeToString(c.hash.Sum(nil))
+}
+
+// AuditRedactor 按审计配置屏蔽请求/响应体中的凭据、提示词与内联媒体
+type AuditRedactor struct {
+	redactPrompts bool
+	redactImages  bool
+	extraFields   map[string]struct{}
+}
+
+// NewAuditRedactor 根据审计日志配置创建脱敏器
+func NewAuditRedactor(cfg config.AuditLogConfig) *AuditRedactor {
+	extra := make(map[string]struct{}, len(cfg.RedactFields))
+	for _, field := range cfg.RedactFields {
+		if f := strings.ToLower(strings.TrimSpace(field)); f != "" {
+			extra[f] = struct{}{}
+		}
+	}
+	return &AuditRedactor{
+		redactPrompts: cfg.RedactPrompts,
+		redactImages:  cfg.RedactImages,
+		extraFields:   extra,
+	}
+}
+
+// RedactCapture 返回捕获内容的脱敏结果与是否截断。
+// JSON 整体脱敏；SSE 按 data 行逐条脱敏（截断时丢弃最后不完整的一行）；
+// 超出上限的 JSON 与无法解析的内容只保存大小与 SHA-256，避免落盘未脱敏数据。
+func (r *AuditRedactor) RedactCapture(capture *AuditBodyCapture) (string, bool) {
+	raw, truncated, total, sum := capture.snapshot()
+	if total == 0 {
+		return "", false
+	}
+	trimmed := bytes.TrimSpace(raw)
+	if bytes.HasPrefix(trimmed, []byte("event:")) || bytes.HasPrefix(trimmed, []byte("data:")) {
+		return r.redactSSE(raw, truncated), truncated
+	}
+	if !truncated {
+		if out, ok := r.redactJSON(raw); ok {
+			return out, false
+		}
+	}
+	return auditBodyDigest(total, sum, truncated), truncated
+}
+
+func (r *AuditRedactor) redactJSON(raw []byte) (string, bool) {
+	var decoded any
+	if err := json.Unmarshal(raw, &decoded); err != nil {
+		return "", false
+	}
+	encoded, err := json.Marshal(r.redactValue("", decoded))
+	if err != nil {
+		return "", false
+	}
+	return string(encoded), true
+}
+
+func (r *AuditRedactor) redactSSE(raw []byte, truncated bool) string {
+	lines := strings.Split(string(raw), "\n")
+	if truncated && len(lines) > 0 {
+		lines = lines[:len(lines)-1]
+	}
+	for i, line := range lines {
+		payload, ok := strings.CutPrefix(line, "data:")
+		if !ok {
+			continue
+		}
+		payload = strings.TrimSpace(payload)
+		if payload == "" || payload == "[DONE]" {
+			continue
+		}
+		if out, ok := r.redactJSON([]byte(payload)); ok {
+			lines[i] = "data: " + out
+		} else {
+			lines[i] = "data: [REDACTED]"
+		}
+	}
+	return strings.Join(lines, "\n")
+}
+
+func (r *AuditRedactor) redactValue(key string, v any) any {
+	switch t := v.(type) {
+	case map[string]any:
+		out := make(map[string]any, len(t))
+		for k, vv := range t {
+			if r.isMaskedField(k) {
+				out[k] = "[REDACTED]"
+				continue
+			}
+			out[k] = r.redactValue(strings.ToLower(k), vv)
+		}
+		return out
+	case []any:
+		out := make([]any, 0, len(t))
+		for _, vv := range t {
+			out = append(out, r.redactValue(key, vv))
+		}
+		return out
+	case string:
+		if r.redactImages && isAuditInlineMedia(key, t) {
+			return fmt.Sprintf("[REDACTED media %d bytes]", len(t))
+		}
+		if r.redactPrompts {
+			if _, ok := auditPromptKeys[key]; ok {
+				return fmt.Sprintf("[REDACTED %d chars]", len([]rune(t)))
+			}
+		}
+		return redactPII(t)
+	default:
+		return v
+	}
+}
+
+func (r *AuditRedactor) isMaskedField(key string) bool {
+	if isSensitiveKey(key) {
+		return true
+	}
+	_, ok := r.extraFields[strings.ToLower(strings.TrimSpace(key))]
+	return ok
+}
+
+func isAuditInlineMedia(key, value string) bool {
+	if strings.HasPrefix(value, "data:") {
+		return true
+	}
+	if _, ok := auditImageKeys[key]; ok {
+		return len(value) > 256 && !strings.HasPrefix(value, "http://") && !strings.HasPrefix(value, "https://")
+	}
+	return false
+}
+
+func auditBodyDigest(total int, sum string, truncated bool) string {
+	encoded, _ := json.Marshal(map[string]any{
+		"body_omitted": true,
+		"body_bytes":   total,
+		"body_sha256":  sum,
+		"truncated":    truncated,
+	})
+	return string(encoded)
+}
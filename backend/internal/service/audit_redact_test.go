@@ -0,0 +1,69 @@
+package service
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/Wei-Shaw/sub2api/internal/config"
+	"github.com/stretchr/testify/require"
+	"github.com/tidwall/gjson"
+)
+
+func auditCapture(limit int, body string) *AuditBodyCapture {
+	capture := NewAuditBodyCapture(limit)
+	_, _ = capture.Write([]byte(body))
+	return capture
+}
+
+func TestAuditRedactor_MasksCredentialsPromptsAndImages(t *testing.T) {
+	r := NewAuditRedactor(config.AuditLogConfig{
+		RedactPrompts: true,
+		RedactImages:  true,
+		RedactFields:  []string{"Metadata"},
+	})
+	body := `{"model":"claude-sonnet-4-5","max_tokens":1024,"api_key":"sk-123","metadata":{"user_id":"u1"},` +
+		`"messages":[{"role":"user","content":[{"type":"text","text":"hello"},` +
+		`{"type":"image","source":{"type":"base64","media_type":"image/png","data":"` + strings.Repeat("A", 300) + `"}}]}]}`
+
+	out, truncated := r.RedactCapture(auditCapture(64*1024, body))
+	require.False(t, truncated)
+	require.Equal(t, "claude-sonnet-4-5", gjson.Get(out, "model").String())
+	require.EqualValues(t, 1024, gjson.Get(out, "max_tokens").Int())
+	require.Equal(t, "[REDACTED]", gjson.Get(out, "api_key").String())
+	require.Equal(t, "[REDACTED]", gjson.Get(out, "metadata").String())
+	require.Equal(t, "user", gjson.Get(out, "messages.0.role").String())
+	require.Equal(t, "[REDACTED 5 chars]", gjson.Get(out, "messages.0.content.0.text").String())
+	require.Equal(t, "[REDACTED media 300 bytes]", gjson.Get(out, "messages.0.content.1.source.data").String())
+	require.Equal(t, "image/png", gjson.Get(out, "messages.0.content.1.source.media_type").String())
+}
+
+func TestAuditRedactor_KeepsPromptsWhenDisabled(t *testing.T) {
+	r := NewAuditRedactor(config.AuditLogConfig{})
+	out, _ := r.RedactCapture(auditCapture(1024, `{"input":"hi","authorization":"Bearer x","image_url":"data:image/png;base64,AAAA"}`))
+	require.Equal(t, "hi", gjson.Get(out, "input").String())
+	require.Equal(t, "[REDACTED]", gjson.Get(out, "authorization").String())
+	require.Equal(t, "data:image/png;base64,AAAA", gjson.Get(out, "image_url").String())
+}
+
+func TestAuditRedactor_SSEAndOversizedBodies(t *testing.T) {
+	r := NewAuditRedactor(config.AuditLogConfig{RedactPrompts: true})
+
+	sse := "event: content_block_delta\ndata: {\"type\":\"content_block_delta\",\"delta\":{\"type\":\"text_delta\",\"text\":\"secret words\"}}\n\ndata: [DONE]\n"
+	out, truncated := r.RedactCapture(auditCapture(1024, sse))
+	require.False(t, truncated)
+	require.Contains(t, out, "event: content_block_delta")
+	require.Contains(t, out, `"text":"[REDACTED 12 chars]"`)
+	require.NotContains(t, out, "secret words")
+	require.Contains(t, out, "data: [DONE]")
+
+	// 超出上限的 JSON 只保存大小与摘要
+	big := `{"input":"` + strings.Repeat("x", 200) + `"}`
+	capture := auditCapture(64, big)
+	require.Equal(t, len(big), capture.Size())
+	out, truncated = r.RedactCapture(capture)
+	require.True(t, truncated)
+	require.True(t, gjson.Get(out, "body_omitted").Bool())
+	require.EqualValues(t, len(big), gjson.Get(out, "body_bytes").Int())
+	require.Len(t, gjson.Get(out, "body_sha256").String(), 64)
+	require.NotContains(t, out, "xxxx")
+}
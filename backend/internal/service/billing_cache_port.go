@@ -6,10 +6,12 @@ import (
 
 // SubscriptionCacheData represents cached subscription data
 type SubscriptionCacheData struct {
-	Status       string
-	ExpiresAt    time.Time
-	DailyUsage   float64
-	WeeklyUsage  float64
-	MonthlyUsage float64
-	Version      int64
+	Status            string
+	ExpiresAt         time.Time
+	DailyUsage        float64
+	WeeklyUsage       float64
+	MonthlyUsage      float64
+	RolloverUSD       float64
+	RolloverExpiresAt time.Time
+	Version           int64
 }
@@ -28,6 +28,17 @@ type subscriptionCacheData struct {
 	WeeklyUsage  float64
 	MonthlyUsage float64
 	Version      int64
+	// 月额度结转（过期后不再计入）
+	RolloverUSD       float64
+	RolloverExpiresAt time.Time
+}
+
+// monthlyRollover 返回 now 时刻仍有效的月额度结转
+func (d *subscriptionCacheData) monthlyRollover(now time.Time) float64 {
+	if d == nil || d.RolloverUSD <= 0 || !now.Before(d.RolloverExpiresAt) {
+		return 0
+	}
+	return d.RolloverUSD
 }
 
 // 缓存写入任务类型
@@ -38,6 +49,7 @@ const (
 	cacheWriteSetSubscription
 	cacheWriteUpdateSubscriptionUsage
 	cacheWriteDeductBalance
+	cacheWriteIncrementTokenQuota
 )
 
 // 异步缓存写入工作池配置
@@ -68,6 +80,11 @@ type cacheWriteTask struct {
 	balance          float64
 	amount           float64
 	subscriptionData *subscriptionCacheData
+	// token 配额计数
+	apiKeyID int64
+	day      string
+	month    string
+	tokens   int64
 }
 
 // BillingCacheService 计费缓存服务
@@ -78,6 +95,10 @@ type BillingCacheService struct {
 	subRepo        UserSubscriptionRepository
 	cfg            *config.Config
 	circuitBreaker *billingCircuitBreaker
+	spendCap       *SpendCapService
+	quotaRollover  *QuotaRolloverService
+	// Redis 写入重试仍失败时的本地增量缓冲，Redis 恢复后补写
+	fallback *cacheDeltaBuffer
 
 	cacheWriteChan     chan cacheWriteTask
 	cacheWriteWg       sync.WaitGroup
@@ -90,14 +111,19 @@ type BillingCacheService struct {
 }
 
 // NewBillingCacheService 创建计费缓存服务
-func NewBillingCacheService(cache BillingCache, userRepo UserRepository, subRepo UserSubscriptionRepository, cfg *config.Config) *BillingCacheService {
+func NewBillingCacheService(cache BillingCache, userRepo UserRepository, subRepo UserSubscriptionRepository, cfg *config.Config, spendCapService *SpendCapService, quotaRolloverService *QuotaRolloverService) *BillingCacheService {
 	svc := &BillingCacheService{
-		cache:    cache,
-		userRepo: userRepo,
-		subRepo:  subRepo,
-		cfg:      cfg,
+		cache:         cache,
+		userRepo:      userRepo,
+		subRepo:       subRepo,
+		cfg:           cfg,
+		spendCap:      spendCapService,
+		quotaRollover: quotaRolloverService,
 	}
 	svc.circuitBreaker = newBillingCircuitBreaker(cfg.Billing.CircuitBreaker)
+	if cache != nil {
+		svc.fallback = newCacheDeltaBuffer("billing", cacheFallbackFlushInterval)
+	}
 	svc.startCacheWriteWorkers()
 	return svc
 }
@@ -111,6 +137,7 @@ func (s *BillingCacheService) Stop() {
 		close(s.cacheWriteChan)
 		s.cacheWriteWg.Wait()
 		s.cacheWriteChan = nil
+		s.fallback.Stop()
 	})
 }
 
@@ -155,15 +182,15 @@ func (s *BillingCacheService) cacheWriteWorker() {
 			s.setSubscriptionCache(ctx, task.userID, task.groupID, task.subscriptionData)
 		case cacheWriteUpdateSubscriptionUsage:
 			if s.cache != nil {
-				if err := s.cache.UpdateSubscriptionUsage(ctx, task.userID, task.groupID, task.amount); err != nil {
-					log.Printf("Warning: update subscription cache failed for user %d group %d: %v", task.userID, task.groupID, err)
-				}
+				s.updateSubscriptionUsageReliable(ctx, task.userID, task.groupID, task.amount)
 			}
 		case cacheWriteDeductBalance:
 			if s.cache != nil {
-				if err := s.cache.DeductUserBalance(ctx, task.userID, task.amount); err != nil {
-					log.Printf("Warning: deduct balance cache failed for user %d: %v", task.userID, err)
-				}
+				s.deductBalanceReliable(ctx, task.userID, task.amount)
+			}
+		case cacheWriteIncrementTokenQuota:
+			if s.cache != nil {
+				s.incrementTokenQuotaReliable(ctx, task.apiKeyID, task.day, task.month, task.tokens)
 			}
 		}
 		cancel()
@@ -181,6 +208,8 @@ func cacheWriteKindName(kind cacheWriteKind) string {
 		return "update_subscription_usage"
 	case cacheWriteDeductBalance:
 		return "deduct_balance"
+	case cacheWriteIncrementTokenQuota:
+		return "increment_token_quota"
 	default:
 		return "unknown"
 	}
@@ -301,9 +330,23 @@ func (s *BillingCacheService) QueueDeductBalance(userID int64, amount float64) {
 	}
 	ctx, cancel := context.WithTimeout(context.Background(), cacheWriteTimeout)
 	defer cancel()
-	if err := s.DeductBalanceCache(ctx, userID, amount); err != nil {
-		log.Printf("Warning: deduct balance cache fallback failed for user %d: %v", userID, err)
+	s.deductBalanceReliable(ctx, userID, amount)
+}
+
+// deductBalanceReliable 扣减余额缓存，重试仍失败时登记到本地缓冲。
+// 余额以数据库为准，Redis 恢复后直接失效该用户的余额缓存（下次读取从数据库重建），
+// 而不是重放扣减：缓存可能已在故障期间过期并按最新余额重建，重放会造成重复扣减。
+func (s *BillingCacheService) deductBalanceReliable(ctx context.Context, userID int64, amount float64) {
+	err := retryCacheOp(ctx, func(ctx context.Context) error {
+		return s.cache.DeductUserBalance(ctx, userID, amount)
+	})
+	if err == nil {
+		return
 	}
+	log.Printf("Warning: deduct balance cache failed for user %d, buffered until redis recovers: %v", userID, err)
+	s.fallback.Add(fmt.Sprintf("balance:%d", userID), amount, func(ctx context.Context, _ *cacheDeltaEntry) error {
+		return s.cache.InvalidateUserBalance(ctx, userID)
+	})
 }
 
 // InvalidateUserBalance 失效用户余额缓存
@@ -353,23 +396,27 @@ func (s *BillingCacheService) GetSubscriptionStatus(ctx context.Context, userID,
 
 func (s *BillingCacheService) convertFromPortsData(data *SubscriptionCacheData) *subscriptionCacheData {
 	return &subscriptionCacheData{
-		Status:       data.Status,
-		ExpiresAt:    data.ExpiresAt,
-		DailyUsage:   data.DailyUsage,
-		WeeklyUsage:  data.WeeklyUsage,
-		MonthlyUsage: data.MonthlyUsage,
-		Version:      data.Version,
+		Status:            data.Status,
+		ExpiresAt:         data.ExpiresAt,
+		DailyUsage:        data.DailyUsage,
+		WeeklyUsage:       data.WeeklyUsage,
+		MonthlyUsage:      data.MonthlyUsage,
+		RolloverUSD:       data.RolloverUSD,
+		RolloverExpiresAt: data.RolloverExpiresAt,
+		Version:           data.Version,
 	}
 }
 
 func (s *BillingCacheService) convertToPortsData(data *subscriptionCacheData) *SubscriptionCacheData {
 	return &SubscriptionCacheData{
-		Status:       data.Status,
-		ExpiresAt:    data.ExpiresAt,
-		DailyUsage:   data.DailyUsage,
-		WeeklyUsage:  data.WeeklyUsage,
-		MonthlyUsage: data.MonthlyUsage,
-		Version:      data.Version,
+		Status:            data.Status,
+		ExpiresAt:         data.ExpiresAt,
+		DailyUsage:        data.DailyUsage,
+		WeeklyUsage:       data.WeeklyUsage,
+		MonthlyUsage:      data.MonthlyUsage,
+		RolloverUSD:       data.RolloverUSD,
+		RolloverExpiresAt: data.RolloverExpiresAt,
+		Version:           data.Version,
 	}
 }
 
@@ -380,14 +427,42 @@ func (s *BillingCacheService) getSubscriptionFromDB(ctx context.Context, userID,
 		return nil, fmt.Errorf("get subscription: %w", err)
 	}
 
-	return &subscriptionCacheData{
+	data := &subscriptionCacheData{
 		Status:       sub.Status,
 		ExpiresAt:    sub.ExpiresAt,
 		DailyUsage:   sub.DailyUsageUSD,
 		WeeklyUsage:  sub.WeeklyUsageUSD,
 		MonthlyUsage: sub.MonthlyUsageUSD,
 		Version:      sub.UpdatedAt.Unix(),
-	}, nil
+	}
+
+	// 结转额度读取失败时按无结转处理（限额只会更严格，不影响计费正确性）
+	rollover, err := s.quotaRollover.Get(ctx, sub.ID)
+	if err != nil {
+		log.Printf("Warning: get quota rollover failed for subscription %d: %v", sub.ID, err)
+	} else if rollover != nil && sub.MonthlyWindowStart != nil && rollover.WindowStart.Equal(*sub.MonthlyWindowStart) {
+		data.RolloverUSD = rollover.AmountUSD
+		data.RolloverExpiresAt = rollover.ExpiresAt
+	}
+	return data, nil
+}
+
+// CarryOverMonthlyQuota 月窗口重置前结转未用完的月额度（未启用时为空操作）
+func (s *BillingCacheService) CarryOverMonthlyQuota(ctx context.Context, sub *UserSubscription, group *Group, newWindowStart time.Time) error {
+	_, err := s.quotaRollover.CarryOver(ctx, sub, group, newWindowStart)
+	return err
+}
+
+// MonthlyRollover 返回订阅当前有效的月额度结转及其过期时间（优先从缓存读取）
+func (s *BillingCacheService) MonthlyRollover(ctx context.Context, userID, groupID int64) (float64, time.Time) {
+	if !s.quotaRollover.Enabled() {
+		return 0, time.Time{}
+	}
+	data, err := s.GetSubscriptionStatus(ctx, userID, groupID)
+	if err != nil {
+		return 0, time.Time{}
+	}
+	return data.monthlyRollover(time.Now()), data.RolloverExpiresAt
 }
 
 // setSubscriptionCache 设置订阅缓存
@@ -424,9 +499,22 @@ func (s *BillingCacheService) QueueUpdateSubscriptionUsage(userID, groupID int64
 	}
 	ctx, cancel := context.WithTimeout(context.Background(), cacheWriteTimeout)
 	defer cancel()
-	if err := s.UpdateSubscriptionUsage(ctx, userID, groupID, costUSD); err != nil {
-		log.Printf("Warning: update subscription cache fallback failed for user %d group %d: %v", userID, groupID, err)
+	s.updateSubscriptionUsageReliable(ctx, userID, groupID, costUSD)
+}
+
+// updateSubscriptionUsageReliable 累加订阅用量缓存，重试仍失败时登记到本地缓冲。
+// 与余额相同，订阅用量以数据库为准，Redis 恢复后失效缓存而非重放增量。
+func (s *BillingCacheService) updateSubscriptionUsageReliable(ctx context.Context, userID, groupID int64, costUSD float64) {
+	err := retryCacheOp(ctx, func(ctx context.Context) error {
+		return s.cache.UpdateSubscriptionUsage(ctx, userID, groupID, costUSD)
+	})
+	if err == nil {
+		return
 	}
+	log.Printf("Warning: update subscription cache failed for user %d group %d, buffered until redis recovers: %v", userID, groupID, err)
+	s.fallback.Add(fmt.Sprintf("subscription:%d:%d", userID, groupID), costUSD, func(ctx context.Context, _ *cacheDeltaEntry) error {
+		return s.cache.InvalidateSubscriptionCache(ctx, userID, groupID)
+	})
 }
 
 // InvalidateSubscription 失效指定订阅缓存
@@ -448,6 +536,7 @@ func (s *BillingCacheService) InvalidateSubscription(ctx context.Context, userID
 // CheckBillingEligibility 检查用户是否有资格发起请求
 // 余额模式：检查缓存余额 > 0
 // 订阅模式：检查缓存用量未超过限额（Group限额从参数传入）
+// 用户消费硬上限：超过时拒绝；软上限：记录到 context（见 WithSpendCapWarningRecorder），由 handler 写入响应头
 func (s *BillingCacheService) CheckBillingEligibility(ctx context.Context, user *User, apiKey *APIKey, group *Group, subscription *UserSubscription) error {
 	// 简易模式：跳过所有计费检查
 	if s.cfg.RunMode == config.RunModeSimple {
@@ -457,6 +546,21 @@ func (s *BillingCacheService) CheckBillingEligibility(ctx context.Context, user
 		return ErrBillingServiceUnavailable
 	}
 
+	// API Key 级 token/请求数硬配额（与计费模式无关）
+	if err := s.checkTokenQuota(ctx, apiKey); err != nil {
+		return err
+	}
+
+	// 仅计量分组：照常记录用量，不做余额/订阅/消费上限拦截
+	if group.IsMeteringOnly() {
+		return nil
+	}
+
+	// 用户每日/每月消费软/硬上限（与计费模式无关）
+	if err := s.checkSpendCap(ctx, user); err != nil {
+		return err
+	}
+
 	// 判断计费模式
 	isSubscriptionMode := group != nil && group.IsSubscriptionType() && subscription != nil
 
@@ -467,6 +571,35 @@ func (s *BillingCacheService) CheckBillingEligibility(ctx context.Context, user
 	return s.checkBalanceEligibility(ctx, user.ID)
 }
 
+// checkSpendCap 检查用户当期消费是否超过硬上限；超过软上限时仅记录告警
+func (s *BillingCacheService) checkSpendCap(ctx context.Context, user *User) error {
+	if user == nil || !s.spendCap.Enabled() {
+		return nil
+	}
+	caps, usage, err := s.spendCap.Current(ctx, user.ID)
+	if err != nil {
+		if s.circuitBreaker != nil {
+			s.circuitBreaker.OnFailure(err)
+		}
+		log.Printf("ALERT: spend cap check failed for user %d: %v", user.ID, err)
+		return ErrBillingServiceUnavailable.WithCause(err)
+	}
+	warning, err := caps.Evaluate(usage)
+	if err != nil {
+		return err
+	}
+	recordSpendCapWarning(ctx, warning)
+	return nil
+}
+
+// RecordUserSpend 请求计费后累加用户当期消费（未启用消费上限时为空操作）
+func (s *BillingCacheService) RecordUserSpend(ctx context.Context, user *User, amount float64) {
+	if s == nil {
+		return
+	}
+	s.spendCap.RecordSpend(ctx, user, amount)
+}
+
 // checkBalanceEligibility 检查余额模式资格
 func (s *BillingCacheService) checkBalanceEligibility(ctx context.Context, userID int64) error {
 	balance, err := s.GetUserBalance(ctx, userID)
@@ -522,7 +655,7 @@ func (s *BillingCacheService) checkSubscriptionEligibility(ctx context.Context,
 		return ErrWeeklyLimitExceeded
 	}
 
-	if group.HasMonthlyLimit() && subData.MonthlyUsage >= *group.MonthlyLimitUSD {
+	if group.HasMonthlyLimit() && subData.MonthlyUsage >= *group.MonthlyLimitUSD+subData.monthlyRollover(time.Now()) {
 		return ErrMonthlyLimitExceeded
 	}
 
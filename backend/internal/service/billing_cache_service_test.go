@@ -14,9 +14,16 @@ import (
 type billingCacheWorkerStub struct {
 	balanceUpdates      int64
 	subscriptionUpdates int64
+	quotaIncrements     int64
+	quotaUsage          *TokenQuotaUsage
+	quotaErr            error
+	balance             *float64
 }
 
 func (b *billingCacheWorkerStub) GetUserBalance(ctx context.Context, userID int64) (float64, error) {
+	if b.balance != nil {
+		return *b.balance, nil
+	}
 	return 0, errors.New("not implemented")
 }
 
@@ -52,9 +59,25 @@ func (b *billingCacheWorkerStub) InvalidateSubscriptionCache(ctx context.Context
 	return nil
 }
 
+func (b *billingCacheWorkerStub) GetTokenQuotaUsage(ctx context.Context, apiKeyID int64, day, month string) (*TokenQuotaUsage, error) {
+	if b.quotaErr != nil {
+		return nil, b.quotaErr
+	}
+	if b.quotaUsage == nil {
+		return &TokenQuotaUsage{}, nil
+	}
+	usage := *b.quotaUsage
+	return &usage, nil
+}
+
+func (b *billingCacheWorkerStub) IncrementTokenQuotaUsage(ctx context.Context, apiKeyID int64, day, month string, tokens int64) error {
+	atomic.AddInt64(&b.quotaIncrements, 1)
+	return nil
+}
+
 func TestBillingCacheServiceQueueHighLoad(t *testing.T) {
 	cache := &billingCacheWorkerStub{}
-	svc := NewBillingCacheService(cache, nil, nil, &config.Config{})
+	svc := NewBillingCacheService(cache, nil, nil, &config.Config{}, nil, nil)
 	t.Cleanup(svc.Stop)
 
 	start := time.Now()
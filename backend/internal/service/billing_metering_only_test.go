@@ -0,0 +1,41 @@
+//go:build unit
+
+package service
+
+import (
+	"context"
+	"testing"
+
+	"github.com/Wei-Shaw/sub2api/internal/config"
+	"github.com/stretchr/testify/require"
+)
+
+func TestBillingCacheService_MeteringOnlyGroupSkipsEligibility(t *testing.T) {
+	balance := 0.0
+	cache := &billingCacheWorkerStub{balance: &balance}
+	svc := NewBillingCacheService(cache, nil, nil, &config.Config{}, nil, nil)
+	t.Cleanup(svc.Stop)
+	ctx := context.Background()
+	user := &User{ID: 7}
+	apiKey := &APIKey{ID: 1}
+
+	group := &Group{ID: 2, SubscriptionType: SubscriptionTypeStandard}
+	require.ErrorIs(t, svc.CheckBillingEligibility(ctx, user, apiKey, group, nil), ErrInsufficientBalance)
+
+	// 仅计量分组：余额不足也放行，预估费用预检同样跳过
+	group.MeteringOnly = true
+	require.NoError(t, svc.CheckBillingEligibility(ctx, user, apiKey, group, nil))
+	require.NoError(t, svc.CheckEstimatedCost(ctx, user, apiKey, group, nil, 100))
+
+	// API Key token/请求数配额与计费模式无关，仍然生效
+	cache.quotaUsage = &TokenQuotaUsage{DailyRequests: 5}
+	limited := &APIKey{ID: 3, TokenQuota: TokenQuota{DailyRequests: 5}}
+	require.ErrorIs(t, svc.CheckBillingEligibility(ctx, user, limited, group, nil), ErrAPIKeyDailyRequestQuotaExceeded)
+}
+
+func TestGroup_IsMeteringOnly(t *testing.T) {
+	var nilGroup *Group
+	require.False(t, nilGroup.IsMeteringOnly())
+	require.False(t, (&Group{}).IsMeteringOnly())
+	require.True(t, (&Group{MeteringOnly: true}).IsMeteringOnly())
+}
@@ -6,8 +6,10 @@ import (
 
 	"log"
 	"strings"
+	"time"
 
 	"github.com/Wei-Shaw/sub2api/internal/config"
+	"github.com/Wei-Shaw/sub2api/internal/pkg/usagestats"
 )
 
 // BillingCache defines cache operations for billing service
@@ -23,6 +25,10 @@ type BillingCache interface {
 	SetSubscriptionCache(ctx context.Context, userID, groupID int64, data *SubscriptionCacheData) error
 	UpdateSubscriptionUsage(ctx context.Context, userID, groupID int64, cost float64) error
 	InvalidateSubscriptionCache(ctx context.Context, userID, groupID int64) error
+
+	// API Key token quota operations (day: 20060102, month: 200601)
+	GetTokenQuotaUsage(ctx context.Context, apiKeyID int64, day, month string) (*TokenQuotaUsage, error)
+	IncrementTokenQuotaUsage(ctx context.Context, apiKeyID int64, day, month string, tokens int64) error
 }
 
 // ModelPricing 模型价格配置（per-token价格，与LiteLLM格式一致）
@@ -46,29 +52,42 @@ type UsageTokens struct {
 	CacheCreation1hTokens int
 }
 
+// CacheSavings 缓存计费节省：缓存 token 按折扣价计费相对于按普通输入价格计费节省的费用（标准费用，未应用倍率）
+type CacheSavings struct {
+	CacheReadTokens     int64   `json:"cache_read_tokens"`
+	CacheCreationTokens int64   `json:"cache_creation_tokens"`
+	CacheReadCost       float64 `json:"cache_read_cost"`
+	CacheCreationCost   float64 `json:"cache_creation_cost"`
+	FlatInputCost       float64 `json:"flat_input_cost"` // 若全部按普通输入价格计费的费用
+	Savings             float64 `json:"savings"`         // FlatInputCost - (CacheReadCost + CacheCreationCost)
+}
+
 // CostBreakdown 费用明细
 type CostBreakdown struct {
 	InputCost         float64
 	OutputCost        float64
 	CacheCreationCost float64
 	CacheReadCost     float64
+	ToolCost          float64 // 内置工具（web_search 等）费用，已计入 TotalCost
 	TotalCost         float64
 	ActualCost        float64 // 应用倍率后的实际费用
 }
 
 // BillingService 计费服务
 type BillingService struct {
-	cfg            *config.Config
-	pricingService *PricingService
-	fallbackPrices map[string]*ModelPricing // 硬编码回退价格
+	cfg               *config.Config
+	pricingService    *PricingService
+	modelPriceService *ModelPriceService       // 管理员维护的价格表（最高优先级）
+	fallbackPrices    map[string]*ModelPricing // 硬编码回退价格
 }
 
 // NewBillingService 创建计费服务实例
-func NewBillingService(cfg *config.Config, pricingService *PricingService) *BillingService {
+func NewBillingService(cfg *config.Config, pricingService *PricingService, modelPriceService *ModelPriceService) *BillingService {
 	s := &BillingService{
-		cfg:            cfg,
-		pricingService: pricingService,
-		fallbackPrices: make(map[string]*ModelPricing),
+		cfg:               cfg,
+		pricingService:    pricingService,
+		modelPriceService: modelPriceService,
+		fallbackPrices:    make(map[string]*ModelPricing),
 	}
 
 	// 初始化硬编码回退价格（当动态价格不可用时使用）
@@ -163,12 +182,22 @@ func (s *BillingService) getFallbackPricing(model string) *ModelPricing {
 	return s.fallbackPrices["claude-sonnet-4"]
 }
 
-// GetModelPricing 获取模型价格配置
+// GetModelPricing 获取模型价格配置（不区分平台）
 func (s *BillingService) GetModelPricing(model string) (*ModelPricing, error) {
+	return s.GetModelPricingForPlatform("", model)
+}
+
+// GetModelPricingForPlatform 获取指定平台下当前生效的模型价格配置
+func (s *BillingService) GetModelPricingForPlatform(platform, model string) (*ModelPricing, error) {
 	// 标准化模型名称（转小写）
 	model = strings.ToLower(model)
 
-	// 1. 优先从动态价格服务获取
+	// 1. 管理员价格表优先（按请求时间选择已生效的版本）
+	if pricing := s.modelPriceService.Resolve(platform, model, time.Now()); pricing != nil {
+		return pricing, nil
+	}
+
+	// 2. 从动态价格服务获取
 	if s.pricingService != nil {
 		litellmPricing := s.pricingService.GetModelPricing(model)
 		if litellmPricing != nil {
@@ -178,11 +207,20 @@ func (s *BillingService) GetModelPricing(model string) (*ModelPricing, error) {
 			price5m := litellmPricing.CacheCreationInputTokenCost
 			price1h := litellmPricing.CacheCreationInputTokenCostAbove1hr
 			enableBreakdown := price1h > 0 && price1h > price5m
+			// LiteLLM 未提供缓存价格时按普通输入价格计费，避免缓存 token 被免费放行
+			cacheCreationPrice := litellmPricing.CacheCreationInputTokenCost
+			if cacheCreationPrice <= 0 {
+				cacheCreationPrice = litellmPricing.InputCostPerToken
+			}
+			cacheReadPrice := litellmPricing.CacheReadInputTokenCost
+			if cacheReadPrice <= 0 {
+				cacheReadPrice = litellmPricing.InputCostPerToken
+			}
 			return &ModelPricing{
 				InputPricePerToken:         litellmPricing.InputCostPerToken,
 				OutputPricePerToken:        litellmPricing.OutputCostPerToken,
-				CacheCreationPricePerToken: litellmPricing.CacheCreationInputTokenCost,
-				CacheReadPricePerToken:     litellmPricing.CacheReadInputTokenCost,
+				CacheCreationPricePerToken: cacheCreationPrice,
+				CacheReadPricePerToken:     cacheReadPrice,
 				CacheCreation5mPrice:       price5m,
 				CacheCreation1hPrice:       price1h,
 				SupportsCacheBreakdown:     enableBreakdown,
@@ -190,7 +228,7 @@ func (s *BillingService) GetModelPricing(model string) (*ModelPricing, error) {
 		}
 	}
 
-	// 2. 使用硬编码回退价格
+	// 3. 使用硬编码回退价格
 	fallback := s.getFallbackPricing(model)
 	if fallback != nil {
 		log.Printf("[Billing] Using fallback pricing for model: %s", model)
@@ -200,9 +238,14 @@ func (s *BillingService) GetModelPricing(model string) (*ModelPricing, error) {
 	return nil, fmt.Errorf("pricing not found for model: %s", model)
 }
 
-// CalculateCost 计算使用费用
+// CalculateCost 计算使用费用（不区分平台）
 func (s *BillingService) CalculateCost(model string, tokens UsageTokens, rateMultiplier float64) (*CostBreakdown, error) {
-	pricing, err := s.GetModelPricing(model)
+	return s.CalculateCostForPlatform("", model, tokens, rateMultiplier)
+}
+
+// CalculateCostForPlatform 按平台价格计算使用费用
+func (s *BillingService) CalculateCostForPlatform(platform, model string, tokens UsageTokens, rateMultiplier float64) (*CostBreakdown, error) {
+	pricing, err := s.GetModelPricingForPlatform(platform, model)
 	if err != nil {
 		return nil, err
 	}
@@ -245,6 +288,26 @@ func (s *BillingService) CalculateCost(model string, tokens UsageTokens, rateMul
 	return breakdown, nil
 }
 
+// CalculateCacheSavings 按模型汇总缓存计费节省，找不到价格的模型不计入 FlatInputCost/Savings
+func (s *BillingService) CalculateCacheSavings(usages []usagestats.ModelCacheUsage) *CacheSavings {
+	savings := &CacheSavings{}
+	for _, usage := range usages {
+		savings.CacheReadTokens += usage.CacheReadTokens
+		savings.CacheCreationTokens += usage.CacheCreationTokens
+		savings.CacheReadCost += usage.CacheReadCost
+		savings.CacheCreationCost += usage.CacheCreationCost
+
+		pricing, err := s.GetModelPricing(usage.Model)
+		if err != nil {
+			continue
+		}
+		flat := float64(usage.CacheReadTokens+usage.CacheCreationTokens) * pricing.InputPricePerToken
+		savings.FlatInputCost += flat
+		savings.Savings += flat - usage.CacheReadCost - usage.CacheCreationCost
+	}
+	return savings
+}
+
 // CalculateCostWithConfig 使用配置中的默认倍率计算费用
 func (s *BillingService) CalculateCostWithConfig(model string, tokens UsageTokens) (*CostBreakdown, error) {
 	multiplier := s.cfg.Default.RateMultiplier
@@ -262,15 +325,20 @@ func (s *BillingService) CalculateCostWithConfig(model string, tokens UsageToken
 // 拆分为：范围内 (200k, 0) + 范围外 (10k, 10k)
 // 范围内正常计费，范围外 × 2 计费
 func (s *BillingService) CalculateCostWithLongContext(model string, tokens UsageTokens, rateMultiplier float64, threshold int, extraMultiplier float64) (*CostBreakdown, error) {
+	return s.CalculateCostWithLongContextForPlatform("", model, tokens, rateMultiplier, threshold, extraMultiplier)
+}
+
+// CalculateCostWithLongContextForPlatform 同 CalculateCostWithLongContext，按平台价格计费
+func (s *BillingService) CalculateCostWithLongContextForPlatform(platform, model string, tokens UsageTokens, rateMultiplier float64, threshold int, extraMultiplier float64) (*CostBreakdown, error) {
 	// 未启用长上下文计费，直接走正常计费
 	if threshold <= 0 || extraMultiplier <= 1 {
-		return s.CalculateCost(model, tokens, rateMultiplier)
+		return s.CalculateCostForPlatform(platform, model, tokens, rateMultiplier)
 	}
 
 	// 计算总输入 token（缓存读取 + 新输入）
 	total := tokens.CacheReadTokens + tokens.InputTokens
 	if total <= threshold {
-		return s.CalculateCost(model, tokens, rateMultiplier)
+		return s.CalculateCostForPlatform(platform, model, tokens, rateMultiplier)
 	}
 
 	// 拆分成范围内和范围外
@@ -300,7 +368,7 @@ func (s *BillingService) CalculateCostWithLongContext(model string, tokens Usage
 		CacheCreation5mTokens: tokens.CacheCreation5mTokens,
 		CacheCreation1hTokens: tokens.CacheCreation1hTokens,
 	}
-	inRangeCost, err := s.CalculateCost(model, inRangeTokens, rateMultiplier)
+	inRangeCost, err := s.CalculateCostForPlatform(platform, model, inRangeTokens, rateMultiplier)
 	if err != nil {
 		return nil, err
 	}
@@ -310,7 +378,7 @@ func (s *BillingService) CalculateCostWithLongContext(model string, tokens Usage
 		InputTokens:     outRangeInputTokens,
 		CacheReadTokens: outRangeCacheTokens,
 	}
-	outRangeCost, err := s.CalculateCost(model, outRangeTokens, rateMultiplier*extraMultiplier)
+	outRangeCost, err := s.CalculateCostForPlatform(platform, model, outRangeTokens, rateMultiplier*extraMultiplier)
 	if err != nil {
 		return inRangeCost, nil // 出错时返回范围内成本
 	}
@@ -0,0 +1,38 @@
+//go:build unit
+
+package service
+
+import (
+	"testing"
+
+	"github.com/Wei-Shaw/sub2api/internal/config"
+	"github.com/Wei-Shaw/sub2api/internal/pkg/usagestats"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCalculateCacheSavings_FallbackPricing(t *testing.T) {
+	svc := NewBillingService(&config.Config{}, nil, nil)
+
+	savings := svc.CalculateCacheSavings([]usagestats.ModelCacheUsage{
+		{
+			Model:               "claude-sonnet-4",
+			CacheReadTokens:     1_000_000,
+			CacheCreationTokens: 100_000,
+			CacheReadCost:       0.3,
+			CacheCreationCost:   0.375,
+		},
+	})
+
+	require.Equal(t, int64(1_000_000), savings.CacheReadTokens)
+	require.Equal(t, int64(100_000), savings.CacheCreationTokens)
+	require.InDelta(t, 3.3, savings.FlatInputCost, 1e-9)
+	require.InDelta(t, 2.625, savings.Savings, 1e-9)
+}
+
+func TestCalculateCacheSavings_Empty(t *testing.T) {
+	svc := NewBillingService(&config.Config{}, nil, nil)
+
+	savings := svc.CalculateCacheSavings(nil)
+	require.NotNil(t, savings)
+	require.Zero(t, savings.Savings)
+}
@@ -0,0 +1,680 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"net/mail"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// 预算告警对象类型
+const (
+	BudgetAlertScopeUser    = "user"
+	BudgetAlertScopeAPIKey  = "api_key"
+	BudgetAlertScopeAccount = "account"
+)
+
+// 预算告警指标
+const (
+	// BudgetAlertMetricAPIKeyQuota API Key 美元配额
+	BudgetAlertMetricAPIKeyQuota = "api_key_quota"
+	// BudgetAlertMetricSubscriptionDaily/Weekly/Monthly 订阅分组的日/周/月费用上限
+	BudgetAlertMetricSubscriptionDaily   = "subscription_daily"
+	BudgetAlertMetricSubscriptionWeekly  = "subscription_weekly"
+	BudgetAlertMetricSubscriptionMonthly = "subscription_monthly"
+	// BudgetAlertMetricAccountWindowCost Anthropic OAuth 账号 5h 窗口费用阈值
+	BudgetAlertMetricAccountWindowCost = "account_window_cost"
+	// BudgetAlertMetricCodex5h/7d OpenAI OAuth 账号上游 5h/7d 用量百分比
+	BudgetAlertMetricCodex5h = "codex_5h"
+	BudgetAlertMetricCodex7d = "codex_7d"
+	// BudgetAlertMetricSpendDaily/Monthly Soft/Hard 用户每日/每月消费软/硬上限
+	BudgetAlertMetricSpendDailySoft   = "spend_daily_soft"
+	BudgetAlertMetricSpendDailyHard   = "spend_daily_hard"
+	BudgetAlertMetricSpendMonthlySoft = "spend_monthly_soft"
+	BudgetAlertMetricSpendMonthlyHard = "spend_monthly_hard"
+)
+
+// BudgetAlertEventThresholdCrossed 预算告警回调事件类型
+const BudgetAlertEventThresholdCrossed = "budget.threshold_crossed"
+
+const (
+	// budgetAlertCacheTTL 告警配置本地缓存有效期
+	budgetAlertCacheTTL = 15 * time.Second
+	// budgetAlertQueueSize 告警投递队列容量，队列满时丢弃（同一阈值后续请求会再次触发）
+	budgetAlertQueueSize = 256
+	// budgetAlertMaxThresholds 阈值数量上限
+	budgetAlertMaxThresholds = 10
+	// budgetAlertMaxRecipients 邮件收件人数量上限
+	budgetAlertMaxRecipients = 20
+	// budgetAlertLocalDedupMax 本地去重表条目上限，超过后清理过期条目
+	budgetAlertLocalDedupMax = 10000
+	// budgetAlertWebhookRetries 回调失败重试次数
+	budgetAlertWebhookRetries  = 2
+	budgetAlertRetryBackoff    = 2 * time.Second
+	budgetAlertSendTimeout     = 10 * time.Second
+	budgetAlertDropLogInterval = time.Minute
+)
+
+// defaultBudgetAlertThresholds 默认告警阈值（百分比）
+var defaultBudgetAlertThresholds = []int{50, 80, 100}
+
+// BudgetAlertSettings 预算阈值告警配置
+type BudgetAlertSettings struct {
+	// Enabled 是否启用预算告警
+	Enabled bool `json:"enabled"`
+	// Thresholds 告警阈值（用量占上限的百分比，升序），每个周期内每个阈值只告警一次
+	Thresholds []int `json:"thresholds"`
+	// Scopes 告警对象类型（user/api_key/account），为空表示全部
+	Scopes []string `json:"scopes"`
+	// WebhookURL 告警回调地址，为空不发送回调
+	WebhookURL string `json:"webhook_url"`
+	// WebhookSecret 回调签名密钥，为空时不携带签名头
+	WebhookSecret string `json:"webhook_secret"`
+	// EmailRecipients 告警邮件收件人，为空不发送邮件
+	EmailRecipients []string `json:"email_recipients"`
+}
+
+// DefaultBudgetAlertSettings 返回默认预算告警配置（关闭，阈值 50/80/100）
+func DefaultBudgetAlertSettings() *BudgetAlertSettings {
+	return &BudgetAlertSettings{
+		Thresholds:      append([]int(nil), defaultBudgetAlertThresholds...),
+		Scopes:          []string{},
+		EmailRecipients: []string{},
+	}
+}
+
+// normalizeBudgetAlertSettings 清理并校验配置：阈值去重排序、对象类型小写、校验回调地址与邮箱
+func normalizeBudgetAlertSettings(settings *BudgetAlertSettings) error {
+	if len(settings.Thresholds) == 0 {
+		settings.Thresholds = append([]int(nil), defaultBudgetAlertThresholds...)
+	}
+	if len(settings.Thresholds) > budgetAlertMaxThresholds {
+		return fmt.Errorf("too many thresholds (max %d)", budgetAlertMaxThresholds)
+	}
+	seen := make(map[int]struct{}, len(settings.Thresholds))
+	thresholds := make([]int, 0, len(settings.Thresholds))
+	for _, t := range settings.Thresholds {
+		if t <= 0 || t > 1000 {
+			return fmt.Errorf("invalid threshold %d (must be between 1 and 1000)", t)
+		}
+		if _, ok := seen[t]; ok {
+			continue
+		}
+		seen[t] = struct{}{}
+		thresholds = append(thresholds, t)
+	}
+	sort.Ints(thresholds)
+	settings.Thresholds = thresholds
+
+	scopes := make([]string, 0, len(settings.Scopes))
+	for _, scope := range settings.Scopes {
+		scope = strings.ToLower(strings.TrimSpace(scope))
+		switch scope {
+		case "":
+			continue
+		case BudgetAlertScopeUser, BudgetAlertScopeAPIKey, BudgetAlertScopeAccount:
+		default:
+			return fmt.Errorf("unsupported scope %q", scope)
+		}
+		if !containsString(scopes, scope) {
+			scopes = append(scopes, scope)
+		}
+	}
+	settings.Scopes = scopes
+
+	settings.WebhookURL = strings.TrimSpace(settings.WebhookURL)
+	if settings.WebhookURL != "" {
+		parsed, err := url.Parse(settings.WebhookURL)
+		if err != nil || (parsed.Scheme != "http" && parsed.Scheme != "https") || parsed.Host == "" {
+			return fmt.Errorf("invalid webhook_url")
+		}
+	}
+	settings.WebhookSecret = strings.TrimSpace(settings.WebhookSecret)
+
+	if len(settings.EmailRecipients) > budgetAlertMaxRecipients {
+		return fmt.Errorf("too many email recipients (max %d)", budgetAlertMaxRecipients)
+	}
+	recipients := make([]string, 0, len(settings.EmailRecipients))
+	for _, addr := range settings.EmailRecipients {
+		addr = strings.TrimSpace(addr)
+		if addr == "" {
+			continue
+		}
+		if _, err := mail.ParseAddress(addr); err != nil {
+			return fmt.Errorf("invalid email recipient %q", addr)
+		}
+		if !containsString(recipients, addr) {
+			recipients = append(recipients, addr)
+		}
+	}
+	settings.EmailRecipients = recipients
+	return nil
+}
+
+func containsString(values []string, target string) bool {
+	for _, v := range values {
+		if v == target {
+			return true
+		}
+	}
+	return false
+}
+
+// GetBudgetAlertSettings 获取预算告警配置
+func (s *SettingService) GetBudgetAlertSettings(ctx context.Context) (*BudgetAlertSettings, error) {
+	value, err := s.settingRepo.GetValue(ctx, SettingKeyBudgetAlertSettings)
+	if err != nil {
+		if errors.Is(err, ErrSettingNotFound) {
+			return DefaultBudgetAlertSettings(), nil
+		}
+		return nil, fmt.Errorf("get budget alert settings: %w", err)
+	}
+	if value == "" {
+		return DefaultBudgetAlertSettings(), nil
+	}
+
+	var settings BudgetAlertSettings
+	if err := json.Unmarshal([]byte(value), &settings); err != nil {
+		return DefaultBudgetAlertSettings(), nil
+	}
+	if len(settings.Thresholds) == 0 {
+		settings.Thresholds = append([]int(nil), defaultBudgetAlertThresholds...)
+	}
+	if settings.Scopes == nil {
+		settings.Scopes = []string{}
+	}
+	if settings.EmailRecipients == nil {
+		settings.EmailRecipients = []string{}
+	}
+	return &settings, nil
+}
+
+// SetBudgetAlertSettings 设置预算告警配置
+func (s *SettingService) SetBudgetAlertSettings(ctx context.Context, settings *BudgetAlertSettings) error {
+	if settings == nil {
+		return fmt.Errorf("settings cannot be nil")
+	}
+	if err := normalizeBudgetAlertSettings(settings); err != nil {
+		return err
+	}
+
+	data, err := json.Marshal(settings)
+	if err != nil {
+		return fmt.Errorf("marshal budget alert settings: %w", err)
+	}
+	return s.settingRepo.Set(ctx, SettingKeyBudgetAlertSettings, string(data))
+}
+
+// BudgetAlertCache 预算告警去重存储（多实例共享）
+type BudgetAlertCache interface {
+	// MarkBudgetAlertSent 标记告警已发送；已被标记过时返回 false
+	MarkBudgetAlertSent(ctx context.Context, key string, ttl time.Duration) (bool, error)
+}
+
+// BudgetUsage 某个对象在当前周期内对某项上限的用量
+type BudgetUsage struct {
+	Scope   string
+	ScopeID int64
+	// Name 对象名称（用于告警内容展示）
+	Name   string
+	Metric string
+	// Period 周期标识，同一周期内每个阈值只告警一次
+	Period string
+	Used   float64
+	Limit  float64
+	// TTL 去重记录有效期，应覆盖整个周期
+	TTL time.Duration
+}
+
+// BudgetAlertEvent 预算告警回调内容
+type BudgetAlertEvent struct {
+	Event     string  `json:"event"`
+	Scope     string  `json:"scope"`
+	ScopeID   int64   `json:"scope_id"`
+	Name      string  `json:"name,omitempty"`
+	Metric    string  `json:"metric"`
+	Period    string  `json:"period,omitempty"`
+	Threshold int     `json:"threshold"`
+	Percent   float64 `json:"percent"`
+	Used      float64 `json:"used"`
+	Limit     float64 `json:"limit"`
+	Timestamp string  `json:"timestamp"`
+}
+
+type budgetAlertJob struct {
+	usage     BudgetUsage
+	threshold int
+	settings  *BudgetAlertSettings
+}
+
+// BudgetAlertService 用户/API Key/账号用量越过配置的阈值（如 50%/80%/100%）时发送回调与邮件告警。
+// 请求路径只计算阈值并非阻塞入队；去重与投递在后台协程中完成。
+type BudgetAlertService struct {
+	settingService *SettingService
+	cache          BudgetAlertCache
+	sender         UsageWebhookSender
+	emailService   *EmailService
+
+	queue    chan budgetAlertJob
+	stopCh   chan struct{}
+	stopOnce sync.Once
+	wg       sync.WaitGroup
+
+	mu        sync.RWMutex
+	cached    *BudgetAlertSettings
+	expiresAt time.Time
+
+	// localSent 本实例已处理的告警键，避免越过阈值后每个请求都访问 Redis
+	localMu   sync.Mutex
+	localSent map[string]time.Time
+
+	dropped     atomic.Int64
+	lastDropLog atomic.Int64
+}
+
+// NewBudgetAlertService 创建预算告警服务
+func NewBudgetAlertService(settingService *SettingService, cache BudgetAlertCache, sender UsageWebhookSender, emailService *EmailService) *BudgetAlertService {
+	return &BudgetAlertService{
+		settingService: settingService,
+		cache:          cache,
+		sender:         sender,
+		emailService:   emailService,
+		queue:          make(chan budgetAlertJob, budgetAlertQueueSize),
+		stopCh:         make(chan struct{}),
+		localSent:      make(map[string]time.Time),
+	}
+}
+
+// Start 启动投递协程
+func (s *BudgetAlertService) Start() {
+	if s == nil {
+		return
+	}
+	s.wg.Add(1)
+	go func() {
+		defer s.wg.Done()
+		s.run()
+	}()
+}
+
+// Stop 停止投递；队列中剩余的告警各尝试投递一次
+func (s *BudgetAlertService) Stop() {
+	if s == nil {
+		return
+	}
+	s.stopOnce.Do(func() {
+		close(s.stopCh)
+	})
+	s.wg.Wait()
+}
+
+// Invalidate 清除配置本地缓存
+func (s *BudgetAlertService) Invalidate() {
+	if s == nil {
+		return
+	}
+	s.mu.Lock()
+	s.cached = nil
+	s.expiresAt = time.Time{}
+	s.mu.Unlock()
+}
+
+// Observe 检查用量是否越过告警阈值，越过时提交告警（非阻塞）
+func (s *BudgetAlertService) Observe(ctx context.Context, usages ...BudgetUsage) {
+	if s == nil || len(usages) == 0 {
+		return
+	}
+	settings := s.load(ctx)
+	if settings == nil || !settings.Enabled || (settings.WebhookURL == "" && len(settings.EmailRecipients) == 0) {
+		return
+	}
+	now := time.Now()
+	for _, usage := range usages {
+		if usage.Limit <= 0 {
+			continue
+		}
+		if len(settings.Scopes) > 0 && !containsString(settings.Scopes, usage.Scope) {
+			continue
+		}
+		threshold := crossedBudgetThreshold(settings.Thresholds, usage.Used, usage.Limit)
+		if threshold == 0 || !s.markLocal(budgetAlertKey(usage, threshold), usage.TTL, now) {
+			continue
+		}
+		s.enqueue(budgetAlertJob{usage: usage, threshold: threshold, settings: settings})
+	}
+}
+
+// crossedBudgetThreshold 返回用量已越过的最高阈值，未越过任何阈值时返回 0
+func crossedBudgetThreshold(thresholds []int, used, limit float64) int {
+	if limit <= 0 {
+		return 0
+	}
+	percent := used / limit * 100
+	crossed := 0
+	for _, t := range thresholds {
+		if percent >= float64(t) && t > crossed {
+			crossed = t
+		}
+	}
+	return crossed
+}
+
+func budgetAlertKey(usage BudgetUsage, threshold int) string {
+	return fmt.Sprintf("%s:%d:%s:%s:%d", usage.Scope, usage.ScopeID, usage.Metric, usage.Period, threshold)
+}
+
+// markLocal 本地去重；返回 false 表示本实例已处理过该告警
+func (s *BudgetAlertService) markLocal(key string, ttl time.Duration, now time.Time) bool {
+	s.localMu.Lock()
+	defer s.localMu.Unlock()
+	if expiresAt, ok := s.localSent[key]; ok && now.Before(expiresAt) {
+		return false
+	}
+	if len(s.localSent) >= budgetAlertLocalDedupMax {
+		for k, expiresAt := range s.localSent {
+			if !now.Before(expiresAt) {
+				delete(s.localSent, k)
+			}
+		}
+		if len(s.localSent) >= budgetAlertLocalDedupMax {
+			s.localSent = make(map[string]time.Time)
+		}
+	}
+	s.localSent[key] = now.Add(ttl)
+	return true
+}
+
+func (s *BudgetAlertService) enqueue(job budgetAlertJob) {
+	select {
+	case s.queue <- job:
+	default:
+		// 丢弃后清除本地去重标记，后续请求可再次触发
+		s.localMu.Lock()
+		delete(s.localSent, budgetAlertKey(job.usage, job.threshold))
+		s.localMu.Unlock()
+		dropped := s.dropped.Add(1)
+		now := time.Now().UnixNano()
+		last := s.lastDropLog.Load()
+		if now-last >= int64(budgetAlertDropLogInterval) && s.lastDropLog.CompareAndSwap(last, now) {
+			log.Printf("[BudgetAlert] Queue full, dropped %d alerts so far", dropped)
+		}
+	}
+}
+
+func (s *BudgetAlertService) run() {
+	for {
+		select {
+		case job := <-s.queue:
+			s.process(job, budgetAlertWebhookRetries)
+		case <-s.stopCh:
+			for {
+				select {
+				case job := <-s.queue:
+					s.process(job, 0)
+				default:
+					return
+				}
+			}
+		}
+	}
+}
+
+func (s *BudgetAlertService) process(job budgetAlertJob, maxRetries int) {
+	key := budgetAlertKey(job.usage, job.threshold)
+	if s.cache != nil {
+		ctx, cancel := context.WithTimeout(context.Background(), budgetAlertSendTimeout)
+		first, err := s.cache.MarkBudgetAlertSent(ctx, key, job.usage.TTL)
+		cancel()
+		if err != nil {
+			// 去重存储不可用时仍然告警（本地已去重），宁可多发不可漏发
+			log.Printf("[BudgetAlert] Mark alert %s failed: %v", key, err)
+		} else if !first {
+			return
+		}
+	}
+
+	event := budgetAlertEventFromUsage(job.usage, job.threshold, time.Now())
+	log.Printf("[BudgetAlert] %s %d %s crossed %d%% (%.4f/%.4f)", event.Scope, event.ScopeID, event.Metric, event.Threshold, event.Used, event.Limit)
+	if job.settings.WebhookURL != "" {
+		s.sendWebhook(job.settings, event, maxRetries)
+	}
+	if len(job.settings.EmailRecipients) > 0 {
+		s.sendEmails(job.settings.EmailRecipients, event)
+	}
+}
+
+func budgetAlertEventFromUsage(usage BudgetUsage, threshold int, now time.Time) *BudgetAlertEvent {
+	return &BudgetAlertEvent{
+		Event:     BudgetAlertEventThresholdCrossed,
+		Scope:     usage.Scope,
+		ScopeID:   usage.ScopeID,
+		Name:      usage.Name,
+		Metric:    usage.Metric,
+		Period:    usage.Period,
+		Threshold: threshold,
+		Percent:   usage.Used / usage.Limit * 100,
+		Used:      usage.Used,
+		Limit:     usage.Limit,
+		Timestamp: now.UTC().Format(time.RFC3339),
+	}
+}
+
+// sendWebhook 投递告警回调；网络错误或 5xx/429 时按线性退避重试
+func (s *BudgetAlertService) sendWebhook(settings *BudgetAlertSettings, event *BudgetAlertEvent, maxRetries int) {
+	if s.sender == nil {
+		return
+	}
+	payload, err := json.Marshal(event)
+	if err != nil {
+		log.Printf("[BudgetAlert] Marshal event failed: %v", err)
+		return
+	}
+	var lastErr error
+	for attempt := 0; attempt <= maxRetries; attempt++ {
+		if attempt > 0 {
+			select {
+			case <-time.After(time.Duration(attempt) * budgetAlertRetryBackoff):
+			case <-s.stopCh:
+				maxRetries = attempt
+			}
+		}
+		ctx, cancel := context.WithTimeout(context.Background(), budgetAlertSendTimeout)
+		timestamp := strconv.FormatInt(time.Now().Unix(), 10)
+		header := http.Header{}
+		header.Set("Content-Type", "application/json")
+		header.Set(UsageWebhookHeaderEvent, BudgetAlertEventThresholdCrossed)
+		header.Set(UsageWebhookHeaderTimestamp, timestamp)
+		if settings.WebhookSecret != "" {
+			header.Set(UsageWebhookHeaderSignature, SignUsageWebhook(settings.WebhookSecret, timestamp, payload))
+		}
+		status, err := s.sender.Send(ctx, settings.WebhookURL, header, payload)
+		cancel()
+		if err == nil && status >= 200 && status < 300 {
+			return
+		}
+		if err == nil {
+			err = fmt.Errorf("unexpected status %d", status)
+			if status != http.StatusTooManyRequests && status < 500 {
+				lastErr = err
+				break
+			}
+		}
+		lastErr = err
+	}
+	log.Printf("[BudgetAlert] Deliver webhook failed: %v", lastErr)
+}
+
+func (s *BudgetAlertService) sendEmails(recipients []string, event *BudgetAlertEvent) {
+	if s.emailService == nil {
+		return
+	}
+	subject := fmt.Sprintf("[Budget Alert] %s #%d %s reached %d%%", event.Scope, event.ScopeID, event.Metric, event.Threshold)
+	body := buildBudgetAlertEmailBody(event)
+	for _, addr := range recipients {
+		ctx, cancel := context.WithTimeout(context.Background(), budgetAlertSendTimeout)
+		if err := s.emailService.SendEmail(ctx, addr, subject, body); err != nil {
+			log.Printf("[BudgetAlert] Send email to %s failed: %v", addr, err)
+		}
+		cancel()
+	}
+}
+
+func buildBudgetAlertEmailBody(event *BudgetAlertEvent) string {
+	name := event.Name
+	if name == "" {
+		name = "-"
+	}
+	period := event.Period
+	if period == "" {
+		period = "-"
+	}
+	return fmt.Sprintf(`<h2>Budget threshold crossed</h2>
+<p><b>Scope</b>: %s #%d (%s)</p>
+<p><b>Metric</b>: %s</p>
+<p><b>Period</b>: %s</p>
+<p><b>Threshold</b>: %d%%</p>
+<p><b>Usage</b>: %.4f / %.4f (%.1f%%)</p>
+<p><b>Time</b>: %s</p>`,
+		htmlEscape(event.Scope), event.ScopeID, htmlEscape(name),
+		htmlEscape(event.Metric),
+		htmlEscape(period),
+		event.Threshold,
+		event.Used, event.Limit, event.Percent,
+		htmlEscape(event.Timestamp),
+	)
+}
+
+func (s *BudgetAlertService) load(ctx context.Context) *BudgetAlertSettings {
+	now := time.Now()
+	s.mu.RLock()
+	if s.cached != nil && now.Before(s.expiresAt) {
+		cached := s.cached
+		s.mu.RUnlock()
+		return cached
+	}
+	stale := s.cached
+	s.mu.RUnlock()
+
+	if s.settingService == nil {
+		return nil
+	}
+	settings, err := s.settingService.GetBudgetAlertSettings(ctx)
+	if err != nil {
+		log.Printf("[BudgetAlert] Failed to load settings: %v", err)
+		return stale
+	}
+
+	s.mu.Lock()
+	s.cached = settings
+	s.expiresAt = now.Add(budgetAlertCacheTTL)
+	s.mu.Unlock()
+	return settings
+}
+
+// budgetUsagesForRecordedUsage 根据刚计费的请求构造 API Key 配额与订阅上限的用量（包含本次请求费用）
+func budgetUsagesForRecordedUsage(apiKey *APIKey, subscription *UserSubscription, totalCost, actualCost float64) []BudgetUsage {
+	var usages []BudgetUsage
+	if apiKey != nil && apiKey.Quota > 0 {
+		usages = append(usages, BudgetUsage{
+			Scope:   BudgetAlertScopeAPIKey,
+			ScopeID: apiKey.ID,
+			Name:    apiKey.Name,
+			Metric:  BudgetAlertMetricAPIKeyQuota,
+			// 配额不按周期重置，以上限值作为周期标识：调整配额后重新告警
+			Period: strconv.FormatFloat(apiKey.Quota, 'f', -1, 64),
+			Used:   apiKey.QuotaUsed + actualCost,
+			Limit:  apiKey.Quota,
+			TTL:    30 * 24 * time.Hour,
+		})
+	}
+	if apiKey == nil || subscription == nil || apiKey.Group == nil || !apiKey.Group.IsSubscriptionType() {
+		return usages
+	}
+	group := apiKey.Group
+	name := ""
+	if apiKey.User != nil {
+		name = apiKey.User.Email
+	}
+	addSubscription := func(metric string, windowStart *time.Time, used float64, limit *float64, ttl time.Duration) {
+		if limit == nil || *limit <= 0 {
+			return
+		}
+		period := ""
+		if windowStart != nil {
+			period = strconv.FormatInt(windowStart.Unix(), 10)
+		}
+		usages = append(usages, BudgetUsage{
+			Scope:   BudgetAlertScopeUser,
+			ScopeID: subscription.UserID,
+			Name:    name,
+			Metric:  metric,
+			Period:  fmt.Sprintf("%d:%s", group.ID, period),
+			Used:    used + totalCost,
+			Limit:   *limit,
+			TTL:     ttl,
+		})
+	}
+	addSubscription(BudgetAlertMetricSubscriptionDaily, subscription.DailyWindowStart, subscription.DailyUsageUSD, group.DailyLimitUSD, 25*time.Hour)
+	addSubscription(BudgetAlertMetricSubscriptionWeekly, subscription.WeeklyWindowStart, subscription.WeeklyUsageUSD, group.WeeklyLimitUSD, 8*24*time.Hour)
+	addSubscription(BudgetAlertMetricSubscriptionMonthly, subscription.MonthlyWindowStart, subscription.MonthlyUsageUSD, group.MonthlyLimitUSD, 31*24*time.Hour)
+	return usages
+}
+
+// accountWindowCostBudgetUsage 构造 Anthropic OAuth 账号 5h 窗口费用用量
+func accountWindowCostBudgetUsage(account *Account, windowCost float64) BudgetUsage {
+	return BudgetUsage{
+		Scope:   BudgetAlertScopeAccount,
+		ScopeID: account.ID,
+		Name:    account.Name,
+		Metric:  BudgetAlertMetricAccountWindowCost,
+		Period:  strconv.FormatInt(account.GetCurrentWindowStartTime().Unix(), 10),
+		Used:    windowCost,
+		Limit:   account.GetWindowCostLimit(),
+		TTL:     6 * time.Hour,
+	}
+}
+
+// codexBudgetUsages 根据上游返回的 Codex 5h/7d 用量百分比构造账号用量
+func codexBudgetUsages(account *Account, limits *NormalizedCodexLimits, now time.Time) []BudgetUsage {
+	if account == nil || limits == nil {
+		return nil
+	}
+	var usages []BudgetUsage
+	add := func(metric string, used *float64, resetSeconds *int, windowMinutes *int, defaultWindow time.Duration) {
+		if used == nil {
+			return
+		}
+		window := defaultWindow
+		if windowMinutes != nil && *windowMinutes > 0 {
+			window = time.Duration(*windowMinutes) * time.Minute
+		}
+		// 以窗口重置时间（取整到小时）作为周期标识
+		period := ""
+		if resetSeconds != nil {
+			period = strconv.FormatInt(now.Add(time.Duration(*resetSeconds)*time.Second).Truncate(time.Hour).Unix(), 10)
+		}
+		usages = append(usages, BudgetUsage{
+			Scope:   BudgetAlertScopeAccount,
+			ScopeID: account.ID,
+			Name:    account.Name,
+			Metric:  metric,
+			Period:  period,
+			Used:    *used,
+			Limit:   100,
+			TTL:     window + time.Hour,
+		})
+	}
+	add(BudgetAlertMetricCodex5h, limits.Used5hPercent, limits.Reset5hSeconds, limits.Window5hMinutes, 5*time.Hour)
+	add(BudgetAlertMetricCodex7d, limits.Used7dPercent, limits.Reset7dSeconds, limits.Window7dMinutes, 7*24*time.Hour)
+	return usages
+}
@@ -0,0 +1,136 @@
+//go:build unit
+
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+type budgetAlertCacheStub struct {
+	mu   sync.Mutex
+	keys map[string]struct{}
+}
+
+func (c *budgetAlertCacheStub) MarkBudgetAlertSent(ctx context.Context, key string, ttl time.Duration) (bool, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if _, ok := c.keys[key]; ok {
+		return false, nil
+	}
+	c.keys[key] = struct{}{}
+	return true, nil
+}
+
+func TestNormalizeBudgetAlertSettings(t *testing.T) {
+	settings := &BudgetAlertSettings{
+		Thresholds:      []int{100, 50, 80, 50},
+		Scopes:          []string{" API_KEY ", "", "account"},
+		WebhookURL:      " https://hooks.example.com/budget ",
+		EmailRecipients: []string{" ops@example.com ", "ops@example.com", ""},
+	}
+	require.NoError(t, normalizeBudgetAlertSettings(settings))
+	require.Equal(t, []int{50, 80, 100}, settings.Thresholds)
+	require.Equal(t, []string{BudgetAlertScopeAPIKey, BudgetAlertScopeAccount}, settings.Scopes)
+	require.Equal(t, "https://hooks.example.com/budget", settings.WebhookURL)
+	require.Equal(t, []string{"ops@example.com"}, settings.EmailRecipients)
+
+	empty := &BudgetAlertSettings{}
+	require.NoError(t, normalizeBudgetAlertSettings(empty))
+	require.Equal(t, []int{50, 80, 100}, empty.Thresholds)
+
+	invalid := []*BudgetAlertSettings{
+		{Thresholds: []int{0}},
+		{Scopes: []string{"group"}},
+		{WebhookURL: "ftp://example.com"},
+		{EmailRecipients: []string{"not-an-email"}},
+	}
+	for _, s := range invalid {
+		require.Error(t, normalizeBudgetAlertSettings(s), "%+v", s)
+	}
+}
+
+func TestCrossedBudgetThreshold(t *testing.T) {
+	thresholds := []int{50, 80, 100}
+	require.Equal(t, 0, crossedBudgetThreshold(thresholds, 4.9, 10))
+	require.Equal(t, 50, crossedBudgetThreshold(thresholds, 5, 10))
+	require.Equal(t, 80, crossedBudgetThreshold(thresholds, 9.5, 10))
+	require.Equal(t, 100, crossedBudgetThreshold(thresholds, 12, 10))
+	require.Equal(t, 0, crossedBudgetThreshold(thresholds, 12, 0))
+}
+
+func TestBudgetUsagesForRecordedUsage(t *testing.T) {
+	dailyLimit := 10.0
+	windowStart := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+	apiKey := &APIKey{
+		ID:        3,
+		Name:      "ci",
+		Quota:     20,
+		QuotaUsed: 15,
+		Group:     &Group{ID: 9, SubscriptionType: SubscriptionTypeSubscription, DailyLimitUSD: &dailyLimit},
+		User:      &User{ID: 7, Email: "u@example.com"},
+	}
+	subscription := &UserSubscription{UserID: 7, DailyUsageUSD: 7, DailyWindowStart: &windowStart}
+
+	usages := budgetUsagesForRecordedUsage(apiKey, subscription, 1, 1.5)
+	require.Len(t, usages, 2)
+	require.Equal(t, BudgetAlertScopeAPIKey, usages[0].Scope)
+	require.Equal(t, 16.5, usages[0].Used)
+	require.Equal(t, 20.0, usages[0].Limit)
+	require.Equal(t, BudgetAlertScopeUser, usages[1].Scope)
+	require.Equal(t, int64(7), usages[1].ScopeID)
+	require.Equal(t, BudgetAlertMetricSubscriptionDaily, usages[1].Metric)
+	require.Equal(t, 8.0, usages[1].Used)
+
+	// 余额模式不产生订阅用量
+	require.Len(t, budgetUsagesForRecordedUsage(&APIKey{ID: 1}, nil, 1, 1), 0)
+}
+
+func TestBudgetAlertService_DeduplicatesPerThreshold(t *testing.T) {
+	repo := &settingRepoStub{values: map[string]string{
+		SettingKeyBudgetAlertSettings: `{"enabled":true,"thresholds":[50,80,100],"webhook_url":"https://hooks.example.com/budget","webhook_secret":"s3cret"}`,
+	}}
+	sender := &usageWebhookSenderStub{}
+	svc := NewBudgetAlertService(NewSettingService(repo, nil), &budgetAlertCacheStub{keys: map[string]struct{}{}}, sender, nil)
+	svc.Start()
+
+	usage := func(used float64) BudgetUsage {
+		return BudgetUsage{Scope: BudgetAlertScopeAPIKey, ScopeID: 1, Metric: BudgetAlertMetricAPIKeyQuota, Period: "10", Used: used, Limit: 10, TTL: time.Hour}
+	}
+	ctx := context.Background()
+	svc.Observe(ctx, usage(4))
+	svc.Observe(ctx, usage(5.5))
+	svc.Observe(ctx, usage(6))
+	svc.Observe(ctx, usage(8.1))
+	svc.Stop()
+
+	deliveries := sender.snapshot()
+	require.Len(t, deliveries, 2)
+	var first, second BudgetAlertEvent
+	require.NoError(t, json.Unmarshal(deliveries[0].body, &first))
+	require.NoError(t, json.Unmarshal(deliveries[1].body, &second))
+	require.Equal(t, 50, first.Threshold)
+	require.Equal(t, 80, second.Threshold)
+	require.Equal(t, BudgetAlertEventThresholdCrossed, deliveries[0].header.Get(UsageWebhookHeaderEvent))
+	require.NotEmpty(t, deliveries[0].header.Get(UsageWebhookHeaderSignature))
+}
+
+func TestBudgetAlertService_DisabledOrScopeFiltered(t *testing.T) {
+	repo := &settingRepoStub{values: map[string]string{
+		SettingKeyBudgetAlertSettings: `{"enabled":true,"scopes":["account"],"webhook_url":"https://hooks.example.com/budget"}`,
+	}}
+	sender := &usageWebhookSenderStub{}
+	svc := NewBudgetAlertService(NewSettingService(repo, nil), nil, sender, nil)
+	svc.Start()
+	svc.Observe(context.Background(), BudgetUsage{Scope: BudgetAlertScopeAPIKey, ScopeID: 1, Metric: BudgetAlertMetricAPIKeyQuota, Used: 10, Limit: 10, TTL: time.Hour})
+	svc.Stop()
+	require.Empty(t, sender.snapshot())
+
+	var nilSvc *BudgetAlertService
+	nilSvc.Observe(context.Background(), BudgetUsage{Used: 10, Limit: 10})
+}
@@ -0,0 +1,183 @@
+package service
+
+import (
+	"context"
+	"log"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// 关键 Redis 计数操作的重试与本地暂存
+//
+// 计费缓存扣减/累加、API Key token 配额计数、并发槽位释放与等待计数递减都只写一次：
+// Redis 短暂抖动时直接丢弃会让缓存中的消费计数永久偏差，或让等待计数卡住直到 TTL 过期。
+// 这里先做有限次数的退避重试，仍失败时把增量合并暂存到本地缓冲区，由后台协程在 Redis 恢复后补写。
+const (
+	cacheRetryAttempts         = 3                     // 单次操作最多尝试次数
+	cacheRetryBaseBackoff      = 20 * time.Millisecond // 首次重试退避，之后翻倍
+	cacheFallbackFlushInterval = 5 * time.Second       // 本地缓冲补写间隔
+	cacheFallbackFlushTimeout  = 2 * time.Second       // 单条补写超时
+	cacheFallbackMaxEntries    = 10000                 // 本地缓冲条目上限，超过后丢弃并告警
+)
+
+// retryCacheOp 对关键 Redis 写操作做有限次数重试（指数退避），ctx 结束时提前返回最后一次错误
+func retryCacheOp(ctx context.Context, op func(ctx context.Context) error) error {
+	backoff := cacheRetryBaseBackoff
+	for attempt := 1; ; attempt++ {
+		err := op(ctx)
+		if err == nil || attempt >= cacheRetryAttempts {
+			return err
+		}
+		timer := time.NewTimer(backoff)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return err
+		case <-timer.C:
+		}
+		backoff *= 2
+	}
+}
+
+// cacheDeltaEntry 同一 key 上合并后的待补写增量
+// apply 可以在部分成功时就地扣减 delta/count，失败后剩余部分留待下一轮
+type cacheDeltaEntry struct {
+	delta float64 // 合并后的累计增量
+	count int64   // 合并的操作次数
+	apply func(ctx context.Context, e *cacheDeltaEntry) error
+}
+
+// cacheDeltaBuffer 关键 Redis 操作失败后的本地增量缓冲
+type cacheDeltaBuffer struct {
+	name string
+
+	mu      sync.Mutex
+	entries map[string]*cacheDeltaEntry
+
+	dropped  atomic.Uint64
+	stopCh   chan struct{}
+	stopOnce sync.Once
+	wg       sync.WaitGroup
+}
+
+// newCacheDeltaBuffer 创建本地增量缓冲；interval > 0 时启动后台补写协程
+func newCacheDeltaBuffer(name string, interval time.Duration) *cacheDeltaBuffer {
+	b := &cacheDeltaBuffer{
+		name:    name,
+		entries: make(map[string]*cacheDeltaEntry),
+		stopCh:  make(chan struct{}),
+	}
+	if interval > 0 {
+		b.wg.Add(1)
+		go b.run(interval)
+	}
+	return b
+}
+
+// Add 暂存一次失败的操作；同一 key 的增量合并，apply 以首次登记的为准
+func (b *cacheDeltaBuffer) Add(key string, delta float64, apply func(ctx context.Context, e *cacheDeltaEntry) error) {
+	if b == nil {
+		return
+	}
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if e, ok := b.entries[key]; ok {
+		e.delta += delta
+		e.count++
+		return
+	}
+	if len(b.entries) >= cacheFallbackMaxEntries {
+		if b.dropped.Add(1)%100 == 1 {
+			log.Printf("ALERT: cache fallback %s buffer full, dropped %d entries", b.name, b.dropped.Load())
+		}
+		return
+	}
+	b.entries[key] = &cacheDeltaEntry{delta: delta, count: 1, apply: apply}
+}
+
+// Len 返回待补写条目数
+func (b *cacheDeltaBuffer) Len() int {
+	if b == nil {
+		return 0
+	}
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return len(b.entries)
+}
+
+// Flush 尝试补写全部暂存增量；遇到首个失败即认为 Redis 仍不可用，剩余条目留待下一轮
+func (b *cacheDeltaBuffer) Flush(ctx context.Context) {
+	if b == nil {
+		return
+	}
+	b.mu.Lock()
+	pending := b.entries
+	b.entries = make(map[string]*cacheDeltaEntry, len(pending))
+	b.mu.Unlock()
+	if len(pending) == 0 {
+		return
+	}
+
+	var (
+		flushed int
+		lastErr error
+	)
+	for key, e := range pending {
+		if lastErr == nil {
+			opCtx, cancel := context.WithTimeout(ctx, cacheFallbackFlushTimeout)
+			lastErr = e.apply(opCtx, e)
+			cancel()
+			if lastErr == nil {
+				flushed++
+				continue
+			}
+		}
+		b.restore(key, e)
+	}
+
+	if flushed > 0 {
+		log.Printf("cache fallback %s: flushed %d pending entries", b.name, flushed)
+	}
+	if lastErr != nil {
+		log.Printf("Warning: cache fallback %s flush failed, %d entries pending: %v", b.name, len(pending)-flushed, lastErr)
+	}
+}
+
+// restore 把补写失败的条目放回缓冲区，与期间新登记的同 key 增量合并
+func (b *cacheDeltaBuffer) restore(key string, e *cacheDeltaEntry) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if cur, ok := b.entries[key]; ok {
+		cur.delta += e.delta
+		cur.count += e.count
+		return
+	}
+	b.entries[key] = e
+}
+
+func (b *cacheDeltaBuffer) run(interval time.Duration) {
+	defer b.wg.Done()
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-b.stopCh:
+			return
+		case <-ticker.C:
+			b.Flush(context.Background())
+		}
+	}
+}
+
+// Stop 停止后台补写并做最后一次尽力补写
+func (b *cacheDeltaBuffer) Stop() {
+	if b == nil {
+		return
+	}
+	b.stopOnce.Do(func() {
+		close(b.stopCh)
+		b.wg.Wait()
+		b.Flush(context.Background())
+	})
+}
@@ -0,0 +1,108 @@
+//go:build unit
+
+package service
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/Wei-Shaw/sub2api/internal/config"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRetryCacheOp(t *testing.T) {
+	calls := 0
+	err := retryCacheOp(context.Background(), func(ctx context.Context) error {
+		calls++
+		if calls < cacheRetryAttempts {
+			return errors.New("redis down")
+		}
+		return nil
+	})
+	require.NoError(t, err)
+	require.Equal(t, cacheRetryAttempts, calls)
+
+	calls = 0
+	err = retryCacheOp(context.Background(), func(ctx context.Context) error {
+		calls++
+		return errors.New("redis down")
+	})
+	require.Error(t, err)
+	require.Equal(t, cacheRetryAttempts, calls)
+
+	// ctx 已取消时不再等待重试
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	calls = 0
+	require.Error(t, retryCacheOp(ctx, func(ctx context.Context) error {
+		calls++
+		return errors.New("redis down")
+	}))
+	require.Equal(t, 1, calls)
+}
+
+func TestCacheDeltaBuffer_MergeAndFlush(t *testing.T) {
+	buf := newCacheDeltaBuffer("test", 0)
+	t.Cleanup(buf.Stop)
+
+	var applied []float64
+	failuresLeft := 1
+	apply := func(ctx context.Context, e *cacheDeltaEntry) error {
+		if failuresLeft > 0 {
+			failuresLeft--
+			return errors.New("redis down")
+		}
+		applied = append(applied, e.delta)
+		return nil
+	}
+	buf.Add("k", 1.5, apply)
+	buf.Add("k", 2.5, apply)
+	require.Equal(t, 1, buf.Len())
+
+	// 首轮补写失败，条目保留并与期间的新增量合并
+	buf.Flush(context.Background())
+	require.Equal(t, 1, buf.Len())
+	buf.Add("k", 1, apply)
+
+	buf.Flush(context.Background())
+	require.Zero(t, buf.Len())
+	require.Equal(t, []float64{5}, applied)
+
+	var nilBuf *cacheDeltaBuffer
+	nilBuf.Add("k", 1, apply)
+	require.Zero(t, nilBuf.Len())
+}
+
+type flakyTokenQuotaCache struct {
+	*billingCacheWorkerStub
+	down     bool
+	tokens   int64
+	requests int64
+}
+
+func (c *flakyTokenQuotaCache) IncrementTokenQuotaUsage(ctx context.Context, apiKeyID int64, day, month string, tokens int64) error {
+	if c.down {
+		return errors.New("redis down")
+	}
+	c.tokens += tokens
+	c.requests++
+	return nil
+}
+
+func TestBillingCacheService_TokenQuotaReplayAfterRedisRecovers(t *testing.T) {
+	cache := &flakyTokenQuotaCache{billingCacheWorkerStub: &billingCacheWorkerStub{}, down: true}
+	svc := NewBillingCacheService(cache, nil, nil, &config.Config{}, nil, nil)
+	t.Cleanup(svc.Stop)
+	ctx := context.Background()
+
+	svc.incrementTokenQuotaReliable(ctx, 1, "2026-01-01", "2026-01", 100)
+	svc.incrementTokenQuotaReliable(ctx, 1, "2026-01-01", "2026-01", 50)
+	require.Equal(t, 1, svc.fallback.Len())
+
+	cache.down = false
+	svc.fallback.Flush(ctx)
+	require.Zero(t, svc.fallback.Len())
+	require.Equal(t, int64(150), cache.tokens)
+	require.Equal(t, int64(2), cache.requests)
+}
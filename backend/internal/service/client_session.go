@@ -0,0 +1,34 @@
+package service
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// ClientSessionHeader 客户端自带会话标识的请求头。
+// Agent 框架通常自行管理会话 ID，通过该头可获得确定性的粘性路由，优先级高于基于请求内容的推断。
+const ClientSessionHeader = "X-Sub2API-Session"
+
+// clientSessionIDRegex 会话标识仅允许常见 ID 字符，长度 1-128
+var clientSessionIDRegex = regexp.MustCompile(`^[A-Za-z0-9._:@/+=-]{1,128}$`)
+
+// ErrInvalidClientSession 客户端会话标识格式不合法
+var ErrInvalidClientSession = errors.New(ClientSessionHeader + " must be 1-128 characters of [A-Za-z0-9._:@/+=-]")
+
+// ClientSessionHash 校验客户端提供的会话标识并返回服务端 hash。
+// hash 混入 API Key ID，不同 Key 使用相同会话标识不会共享粘性绑定；未提供时返回空字符串。
+func ClientSessionHash(sessionID string, apiKeyID int64) (string, error) {
+	sessionID = strings.TrimSpace(sessionID)
+	if sessionID == "" {
+		return "", nil
+	}
+	if !clientSessionIDRegex.MatchString(sessionID) {
+		return "", ErrInvalidClientSession
+	}
+	hash := sha256.Sum256([]byte("client-session:" + strconv.FormatInt(apiKeyID, 10) + ":" + sessionID))
+	return hex.EncodeToString(hash[:]), nil
+}
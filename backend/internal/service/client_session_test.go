@@ -0,0 +1,33 @@
+//go:build unit
+
+package service
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestClientSessionHash(t *testing.T) {
+	hash, err := ClientSessionHash("", 1)
+	require.NoError(t, err)
+	require.Empty(t, hash)
+
+	hash, err = ClientSessionHash(" conv_01HZX:agent-7 ", 1)
+	require.NoError(t, err)
+	require.Len(t, hash, 64)
+
+	again, err := ClientSessionHash("conv_01HZX:agent-7", 1)
+	require.NoError(t, err)
+	require.Equal(t, hash, again, "同一 Key 同一会话标识应得到稳定 hash")
+
+	otherKey, err := ClientSessionHash("conv_01HZX:agent-7", 2)
+	require.NoError(t, err)
+	require.NotEqual(t, hash, otherKey, "不同 Key 的会话绑定互相隔离")
+
+	for _, invalid := range []string{"has space", "中文会话", strings.Repeat("a", 129), "line\nbreak"} {
+		_, err := ClientSessionHash(invalid, 1)
+		require.ErrorIs(t, err, ErrInvalidClientSession, invalid)
+	}
+}
@@ -0,0 +1,211 @@
+package service
+
+import (
+	"context"
+	"log"
+	"os"
+	"strings"
+	"time"
+)
+
+const (
+	// slotOwnerHeartbeatTTL 实例心跳键的过期时间；心跳缺失的实例视为已崩溃
+	slotOwnerHeartbeatTTL       = 60 * time.Second
+	slotOwnerHeartbeatInterval  = 20 * time.Second
+	concurrencyReconcileTimeout = 2 * time.Minute
+)
+
+// ConcurrencyStateReconciler 启动对账所需的缓存能力（ConcurrencyCache 的可选能力）
+type ConcurrencyStateReconciler interface {
+	// HeartbeatSlotOwner 写入/续期实例心跳，值为本次启动的 bootID
+	HeartbeatSlotOwner(ctx context.Context, instanceID, bootID string, ttl time.Duration) error
+	// ListSlotOwners 返回所有心跳仍有效的实例：instanceID -> bootID
+	ListSlotOwners(ctx context.Context) (map[string]string, error)
+	// PruneSlots 遍历账号/用户槽位键，清理过期槽位并删除 isLeaked 判定为遗留的成员
+	PruneSlots(ctx context.Context, isLeaked func(member string) bool) (keys, expired, leaked int, err error)
+	// ValidateWaitCounters 校验等待计数键：删除非数字/负数/类型错误的键，为缺少过期时间的键补设 TTL
+	ValidateWaitCounters(ctx context.Context) (keys, repaired int, err error)
+}
+
+// ConcurrencyReconcileResult 启动对账结果
+type ConcurrencyReconcileResult struct {
+	InstanceID string `json:"instance_id"`
+	BootID     string `json:"boot_id"`
+	LiveOwners int    `json:"live_owners"`
+
+	SlotKeys     int `json:"slot_keys"`
+	ExpiredSlots int `json:"expired_slots"`
+	// PreviousRunSlots 本实例上一次运行遗留的槽位
+	PreviousRunSlots int `json:"previous_run_slots"`
+	// PeerLeakedSlots 其他已崩溃实例（心跳已失效）遗留的槽位
+	PeerLeakedSlots int `json:"peer_leaked_slots"`
+
+	WaitKeys         int `json:"wait_keys"`
+	RepairedWaitKeys int `json:"repaired_wait_keys"`
+
+	Error       string    `json:"error,omitempty"`
+	DurationMs  int64     `json:"duration_ms"`
+	CompletedAt time.Time `json:"completed_at"`
+}
+
+// RecoveredSlots 对账释放的并发容量（遗留槽位数）
+func (r *ConcurrencyReconcileResult) RecoveredSlots() int {
+	return r.PreviousRunSlots + r.PeerLeakedSlots
+}
+
+// resolveSlotOwnerInstanceID 配置为空时使用主机名；去除会破坏成员格式的冒号
+func resolveSlotOwnerInstanceID(configured string) string {
+	id := strings.TrimSpace(configured)
+	if id == "" {
+		id, _ = os.Hostname()
+	}
+	id = strings.ReplaceAll(strings.TrimSpace(id), ":", "_")
+	if id == "" {
+		id = generateRequestID()
+	}
+	return id
+}
+
+// parseSlotMemberOwner 解析槽位成员 {instanceID}:{bootID}:{random}；旧格式（无归属）返回 false
+func parseSlotMemberOwner(member string) (instanceID, bootID string, ok bool) {
+	parts := strings.SplitN(member, ":", 3)
+	if len(parts) != 3 || parts[0] == "" || parts[1] == "" {
+		return "", "", false
+	}
+	return parts[0], parts[1], true
+}
+
+// EnableSlotOwnership 为槽位成员附加实例归属并开始心跳。需在开始处理请求前调用；
+// 缓存不支持对账时为 no-op。
+func (s *ConcurrencyService) EnableSlotOwnership(instanceID string) {
+	if s == nil || s.cache == nil {
+		return
+	}
+	reconciler, ok := s.cache.(ConcurrencyStateReconciler)
+	if !ok {
+		return
+	}
+	s.instanceID = resolveSlotOwnerInstanceID(instanceID)
+	s.bootID = generateRequestID()
+
+	heartbeat := func() {
+		ctx, cancel := context.WithTimeout(context.Background(), 2*time.Second)
+		defer cancel()
+		if err := reconciler.HeartbeatSlotOwner(ctx, s.instanceID, s.bootID, slotOwnerHeartbeatTTL); err != nil {
+			log.Printf("Warning: concurrency slot owner heartbeat failed: instance=%s err=%v", s.instanceID, err)
+		}
+	}
+	// 先同步写入心跳，避免其他实例对账时把本实例新建的槽位当作遗留
+	heartbeat()
+	go func() {
+		ticker := time.NewTicker(slotOwnerHeartbeatInterval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ticker.C:
+				heartbeat()
+			case <-s.stopCh:
+				return
+			}
+		}
+	}()
+}
+
+// newSlotMember 生成槽位成员；启用实例归属时带 {instanceID}:{bootID}: 前缀
+func (s *ConcurrencyService) newSlotMember() string {
+	if s.instanceID == "" {
+		return generateRequestID()
+	}
+	return s.instanceID + ":" + s.bootID + ":" + generateRequestID()
+}
+
+// isLeakedSlotMember 判断槽位是否属于已不存在的运行实例：
+// 本实例的其他 bootID（上一次运行），或心跳已失效/已重启的其他实例。无归属的旧格式成员不处理（依赖 TTL 过期）。
+func (s *ConcurrencyService) isLeakedSlotMember(member string, liveOwners map[string]string, result *ConcurrencyReconcileResult) bool {
+	instanceID, bootID, ok := parseSlotMemberOwner(member)
+	if !ok {
+		return false
+	}
+	if instanceID == s.instanceID {
+		if bootID == s.bootID {
+			return false
+		}
+		result.PreviousRunSlots++
+		return true
+	}
+	if liveOwners[instanceID] == bootID {
+		return false
+	}
+	result.PeerLeakedSlots++
+	return true
+}
+
+// ReconcileStartupState 启动对账：清理遗留槽位、校验等待计数键，记录并输出恢复数量。
+// 需在 EnableSlotOwnership 之后调用；未启用实例归属时直接返回 nil。
+func (s *ConcurrencyService) ReconcileStartupState(ctx context.Context) *ConcurrencyReconcileResult {
+	if s == nil || s.cache == nil || s.instanceID == "" {
+		return nil
+	}
+	reconciler, ok := s.cache.(ConcurrencyStateReconciler)
+	if !ok {
+		return nil
+	}
+	ctx, cancel := context.WithTimeout(ctx, concurrencyReconcileTimeout)
+	defer cancel()
+
+	started := time.Now()
+	result := &ConcurrencyReconcileResult{InstanceID: s.instanceID, BootID: s.bootID}
+	finish := func(err error) *ConcurrencyReconcileResult {
+		if err != nil {
+			result.Error = err.Error()
+		}
+		result.DurationMs = time.Since(started).Milliseconds()
+		result.CompletedAt = time.Now().UTC()
+		s.reconcileResult.Store(result)
+		log.Printf("[ConcurrencyReconcile] instance=%s slot_keys=%d expired=%d previous_run=%d peer_leaked=%d wait_keys=%d wait_repaired=%d duration_ms=%d err=%v",
+			result.InstanceID, result.SlotKeys, result.ExpiredSlots, result.PreviousRunSlots, result.PeerLeakedSlots,
+			result.WaitKeys, result.RepairedWaitKeys, result.DurationMs, err)
+		return result
+	}
+
+	liveOwners, err := reconciler.ListSlotOwners(ctx)
+	if err != nil {
+		return finish(err)
+	}
+	result.LiveOwners = len(liveOwners)
+
+	result.SlotKeys, result.ExpiredSlots, _, err = reconciler.PruneSlots(ctx, func(member string) bool {
+		return s.isLeakedSlotMember(member, liveOwners, result)
+	})
+	if err != nil {
+		return finish(err)
+	}
+
+	result.WaitKeys, result.RepairedWaitKeys, err = reconciler.ValidateWaitCounters(ctx)
+	return finish(err)
+}
+
+// ReleaseOwnedSlots 优雅关闭时释放本次运行持有的全部槽位（排空超时后仍未释放的流式请求等），
+// 避免其他实例在槽位 TTL 过期前容量被占用。未启用实例归属时不处理，返回释放数量。
+func (s *ConcurrencyService) ReleaseOwnedSlots(ctx context.Context) (int, error) {
+	if s == nil || s.cache == nil || s.instanceID == "" {
+		return 0, nil
+	}
+	reconciler, ok := s.cache.(ConcurrencyStateReconciler)
+	if !ok {
+		return 0, nil
+	}
+	_, _, released, err := reconciler.PruneSlots(ctx, func(member string) bool {
+		instanceID, bootID, ok := parseSlotMemberOwner(member)
+		return ok && instanceID == s.instanceID && bootID == s.bootID
+	})
+	return released, err
+}
+
+// LastReconcileResult 返回最近一次启动对账结果（未执行时为 nil）
+func (s *ConcurrencyService) LastReconcileResult() *ConcurrencyReconcileResult {
+	if s == nil {
+		return nil
+	}
+	return s.reconcileResult.Load()
+}
@@ -0,0 +1,119 @@
+package service
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+type reconcilerCacheStub struct {
+	ConcurrencyCache
+
+	owners  map[string]string
+	members []string
+	removed []string
+}
+
+func (c *reconcilerCacheStub) HeartbeatSlotOwner(_ context.Context, instanceID, bootID string, _ time.Duration) error {
+	c.owners[instanceID] = bootID
+	return nil
+}
+
+func (c *reconcilerCacheStub) ListSlotOwners(context.Context) (map[string]string, error) {
+	out := make(map[string]string, len(c.owners))
+	for k, v := range c.owners {
+		out[k] = v
+	}
+	return out, nil
+}
+
+func (c *reconcilerCacheStub) PruneSlots(_ context.Context, isLeaked func(member string) bool) (int, int, int, error) {
+	for _, member := range c.members {
+		if isLeaked(member) {
+			c.removed = append(c.removed, member)
+		}
+	}
+	return 1, 0, len(c.removed), nil
+}
+
+func (c *reconcilerCacheStub) ValidateWaitCounters(context.Context) (int, int, error) {
+	return 3, 1, nil
+}
+
+func TestParseSlotMemberOwner(t *testing.T) {
+	instanceID, bootID, ok := parseSlotMemberOwner("pod-a:boot1:abcd")
+	require.True(t, ok)
+	require.Equal(t, "pod-a", instanceID)
+	require.Equal(t, "boot1", bootID)
+
+	_, _, ok = parseSlotMemberOwner("0123456789abcdef")
+	require.False(t, ok)
+	_, _, ok = parseSlotMemberOwner(":boot:abcd")
+	require.False(t, ok)
+}
+
+func TestConcurrencyService_ReconcileStartupState(t *testing.T) {
+	cache := &reconcilerCacheStub{owners: map[string]string{"pod-b": "live"}}
+	svc := NewConcurrencyService(cache)
+	defer svc.Stop()
+
+	svc.EnableSlotOwnership("pod-a")
+	require.Equal(t, svc.bootID, cache.owners["pod-a"])
+	require.Regexp(t, "^pod-a:"+svc.bootID+":[0-9a-f]+$", svc.newSlotMember())
+
+	current := svc.newSlotMember()
+	cache.members = []string{
+		current,
+		"pod-a:oldboot:1111",   // 本实例上一次运行
+		"pod-b:live:2222",      // 存活的其他实例
+		"pod-b:crashed:3333",   // 其他实例已重启
+		"pod-c:gone:4444",      // 心跳已失效
+		"legacy-random-member", // 旧格式，不处理
+	}
+
+	result := svc.ReconcileStartupState(context.Background())
+	require.NotNil(t, result)
+	require.Empty(t, result.Error)
+	require.Equal(t, 1, result.PreviousRunSlots)
+	require.Equal(t, 2, result.PeerLeakedSlots)
+	require.Equal(t, 3, result.RecoveredSlots())
+	require.Equal(t, 2, result.LiveOwners)
+	require.Equal(t, 3, result.WaitKeys)
+	require.Equal(t, 1, result.RepairedWaitKeys)
+	require.ElementsMatch(t, []string{"pod-a:oldboot:1111", "pod-b:crashed:3333", "pod-c:gone:4444"}, cache.removed)
+	require.Same(t, result, svc.LastReconcileResult())
+}
+
+func TestConcurrencyService_ReconcileRequiresOwnership(t *testing.T) {
+	svc := NewConcurrencyService(&reconcilerCacheStub{owners: map[string]string{}})
+	defer svc.Stop()
+
+	require.Nil(t, svc.ReconcileStartupState(context.Background()))
+	require.NotContains(t, svc.newSlotMember(), ":")
+}
+
+func TestConcurrencyService_ReleaseOwnedSlots(t *testing.T) {
+	cache := &reconcilerCacheStub{owners: map[string]string{}}
+	svc := NewConcurrencyService(cache)
+	defer svc.Stop()
+
+	released, err := svc.ReleaseOwnedSlots(context.Background())
+	require.NoError(t, err)
+	require.Zero(t, released, "slot ownership disabled")
+
+	svc.EnableSlotOwnership("pod-a")
+	current := svc.newSlotMember()
+	cache.members = []string{
+		current,
+		"pod-a:oldboot:1111",
+		"pod-b:live:2222",
+		"legacy-random-member",
+	}
+
+	released, err = svc.ReleaseOwnedSlots(context.Background())
+	require.NoError(t, err)
+	require.Equal(t, 1, released)
+	require.Equal(t, []string{current}, cache.removed)
+}
@@ -6,6 +6,8 @@ import (
 	"encoding/hex"
 	"fmt"
 	"log"
+	"sync"
+	"sync/atomic"
 	"time"
 )
 
@@ -17,6 +19,10 @@ type ConcurrencyCache interface {
 	AcquireAccountSlot(ctx context.Context, accountID int64, maxConcurrency int, requestID string) (bool, error)
 	ReleaseAccountSlot(ctx context.Context, accountID int64, requestID string) error
 	GetAccountConcurrency(ctx context.Context, accountID int64) (int, error)
+	// 两阶段选择：仅当并发数不超过负载快照值时写入短期预占（ttl 后自动过期），返回是否成功及当前并发数
+	ReserveAccountSlot(ctx context.Context, accountID int64, maxConcurrency, expectedConcurrency int, requestID string, ttl time.Duration) (bool, int, error)
+	// 将预占确认为正式槽位；预占已过期时返回 false
+	CommitAccountSlot(ctx context.Context, accountID int64, requestID string) (bool, error)
 
 	// 账号等待队列（账号级）
 	IncrementAccountWaitCount(ctx context.Context, accountID int64, maxWait int) (bool, error)
@@ -60,11 +66,39 @@ const (
 // ConcurrencyService manages concurrent request limiting for accounts and users
 type ConcurrencyService struct {
 	cache ConcurrencyCache
+
+	// 本实例的等待队列深度与排队拒绝（shed）计数，用于对外暴露扩缩容信号
+	localWaiting atomic.Int64
+	shed         shedCounter
+
+	// 槽位释放/等待计数递减在重试仍失败时暂存于本地，Redis 恢复后补写，避免计数卡住直到 TTL 过期
+	fallback *cacheDeltaBuffer
+
+	// 槽位实例归属（启动对账用），见 EnableSlotOwnership；为空时槽位成员为纯随机 ID
+	instanceID      string
+	bootID          string
+	reconcileResult atomic.Pointer[ConcurrencyReconcileResult]
+
+	stopCh   chan struct{}
+	stopOnce sync.Once
 }
 
 // NewConcurrencyService creates a new ConcurrencyService
 func NewConcurrencyService(cache ConcurrencyCache) *ConcurrencyService {
-	return &ConcurrencyService{cache: cache}
+	svc := &ConcurrencyService{cache: cache, stopCh: make(chan struct{})}
+	if cache != nil {
+		svc.fallback = newCacheDeltaBuffer("concurrency", cacheFallbackFlushInterval)
+	}
+	return svc
+}
+
+// Stop stops the slot owner heartbeat and the fallback flusher, then makes a final best-effort flush.
+func (s *ConcurrencyService) Stop() {
+	if s == nil {
+		return
+	}
+	s.stopOnce.Do(func() { close(s.stopCh) })
+	s.fallback.Stop()
 }
 
 // AcquireResult represents the result of acquiring a concurrency slot
@@ -110,7 +144,7 @@ func (s *ConcurrencyService) AcquireAccountSlot(ctx context.Context, accountID i
 	}
 
 	// Generate unique request ID for this slot
-	requestID := generateRequestID()
+	requestID := s.newSlotMember()
 
 	acquired, err := s.cache.AcquireAccountSlot(ctx, accountID, maxConcurrency, requestID)
 	if err != nil {
@@ -123,9 +157,7 @@ func (s *ConcurrencyService) AcquireAccountSlot(ctx context.Context, accountID i
 			ReleaseFunc: func() {
 				bgCtx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
 				defer cancel()
-				if err := s.cache.ReleaseAccountSlot(bgCtx, accountID, requestID); err != nil {
-					log.Printf("Warning: failed to release account slot for %d (req=%s): %v", accountID, requestID, err)
-				}
+				s.releaseAccountSlotReliable(bgCtx, accountID, requestID)
 			},
 		}, nil
 	}
@@ -136,6 +168,30 @@ func (s *ConcurrencyService) AcquireAccountSlot(ctx context.Context, accountID i
 	}, nil
 }
 
+// ReserveAccountSlot 两阶段账号选择的第一阶段：基于负载快照预占账号槽位。
+// 当前并发数仍不超过 expectedConcurrency 时写入预占并返回 reservation；
+// 否则返回 nil 与最新并发数，调用方据此刷新负载并重新排序，避免大量请求同时挤占同一个"最空闲"账号。
+func (s *ConcurrencyService) ReserveAccountSlot(ctx context.Context, accountID int64, maxConcurrency, expectedConcurrency int, ttl time.Duration) (*AccountReservation, int, error) {
+	if maxConcurrency <= 0 {
+		return &AccountReservation{AccountID: accountID}, 0, nil
+	}
+
+	requestID := s.newSlotMember()
+	reserved, current, err := s.cache.ReserveAccountSlot(ctx, accountID, maxConcurrency, expectedConcurrency, requestID, ttl)
+	if err != nil {
+		return nil, 0, err
+	}
+	if !reserved {
+		return nil, current, nil
+	}
+	return &AccountReservation{
+		AccountID: accountID,
+		ExpiresAt: time.Now().Add(ttl),
+		requestID: requestID,
+		cache:     s.cache,
+	}, current, nil
+}
+
 // AcquireUserSlot attempts to acquire a concurrency slot for a user.
 // If the user is at max concurrency, it waits until a slot is available or timeout.
 // Returns a release function that MUST be called when the request completes.
@@ -149,7 +205,7 @@ func (s *ConcurrencyService) AcquireUserSlot(ctx context.Context, userID int64,
 	}
 
 	// Generate unique request ID for this slot
-	requestID := generateRequestID()
+	requestID := s.newSlotMember()
 
 	acquired, err := s.cache.AcquireUserSlot(ctx, userID, maxConcurrency, requestID)
 	if err != nil {
@@ -162,9 +218,7 @@ func (s *ConcurrencyService) AcquireUserSlot(ctx context.Context, userID int64,
 			ReleaseFunc: func() {
 				bgCtx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
 				defer cancel()
-				if err := s.cache.ReleaseUserSlot(bgCtx, userID, requestID); err != nil {
-					log.Printf("Warning: failed to release user slot for %d (req=%s): %v", userID, requestID, err)
-				}
+				s.releaseUserSlotReliable(bgCtx, userID, requestID)
 			},
 		}, nil
 	}
@@ -185,6 +239,7 @@ func (s *ConcurrencyService) AcquireUserSlot(ctx context.Context, userID int64,
 func (s *ConcurrencyService) IncrementWaitCount(ctx context.Context, userID int64, maxWait int) (bool, error) {
 	if s.cache == nil {
 		// Redis not available, allow request
+		s.localWaiting.Add(1)
 		return true, nil
 	}
 
@@ -192,14 +247,17 @@ func (s *ConcurrencyService) IncrementWaitCount(ctx context.Context, userID int6
 	if err != nil {
 		// On error, allow the request to proceed (fail open)
 		log.Printf("Warning: increment wait count failed for user %d: %v", userID, err)
+		s.localWaiting.Add(1)
 		return true, nil
 	}
+	s.recordWaitResult(result)
 	return result, nil
 }
 
 // DecrementWaitCount decrements the wait queue counter for a user.
 // Should be called when a request completes or exits the wait queue.
 func (s *ConcurrencyService) DecrementWaitCount(ctx context.Context, userID int64) {
+	s.localWaiting.Add(-1)
 	if s.cache == nil {
 		return
 	}
@@ -208,27 +266,37 @@ func (s *ConcurrencyService) DecrementWaitCount(ctx context.Context, userID int6
 	bgCtx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
 	defer cancel()
 
-	if err := s.cache.DecrementWaitCount(bgCtx, userID); err != nil {
-		log.Printf("Warning: decrement wait count failed for user %d: %v", userID, err)
+	err := retryCacheOp(bgCtx, func(ctx context.Context) error {
+		return s.cache.DecrementWaitCount(ctx, userID)
+	})
+	if err != nil {
+		log.Printf("Warning: decrement wait count failed for user %d, buffered until redis recovers: %v", userID, err)
+		s.fallback.Add(fmt.Sprintf("wait:user:%d", userID), -1, func(ctx context.Context, e *cacheDeltaEntry) error {
+			return replayDecrements(e, func() error { return s.cache.DecrementWaitCount(ctx, userID) })
+		})
 	}
 }
 
 // IncrementAccountWaitCount increments the wait queue counter for an account.
 func (s *ConcurrencyService) IncrementAccountWaitCount(ctx context.Context, accountID int64, maxWait int) (bool, error) {
 	if s.cache == nil {
+		s.localWaiting.Add(1)
 		return true, nil
 	}
 
 	result, err := s.cache.IncrementAccountWaitCount(ctx, accountID, maxWait)
 	if err != nil {
 		log.Printf("Warning: increment wait count failed for account %d: %v", accountID, err)
+		s.localWaiting.Add(1)
 		return true, nil
 	}
+	s.recordWaitResult(result)
 	return result, nil
 }
 
 // DecrementAccountWaitCount decrements the wait queue counter for an account.
 func (s *ConcurrencyService) DecrementAccountWaitCount(ctx context.Context, accountID int64) {
+	s.localWaiting.Add(-1)
 	if s.cache == nil {
 		return
 	}
@@ -236,9 +304,74 @@ func (s *ConcurrencyService) DecrementAccountWaitCount(ctx context.Context, acco
 	bgCtx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
 	defer cancel()
 
-	if err := s.cache.DecrementAccountWaitCount(bgCtx, accountID); err != nil {
-		log.Printf("Warning: decrement wait count failed for account %d: %v", accountID, err)
+	err := retryCacheOp(bgCtx, func(ctx context.Context) error {
+		return s.cache.DecrementAccountWaitCount(ctx, accountID)
+	})
+	if err != nil {
+		log.Printf("Warning: decrement wait count failed for account %d, buffered until redis recovers: %v", accountID, err)
+		s.fallback.Add(fmt.Sprintf("wait:account:%d", accountID), -1, func(ctx context.Context, e *cacheDeltaEntry) error {
+			return replayDecrements(e, func() error { return s.cache.DecrementAccountWaitCount(ctx, accountID) })
+		})
+	}
+}
+
+// replayDecrements 按合并的次数重放递减，部分成功时扣减剩余次数
+func replayDecrements(e *cacheDeltaEntry, decrement func() error) error {
+	for e.count > 0 {
+		if err := decrement(); err != nil {
+			return err
+		}
+		e.count--
+		e.delta++
+	}
+	return nil
+}
+
+func (s *ConcurrencyService) releaseAccountSlotReliable(ctx context.Context, accountID int64, requestID string) {
+	err := retryCacheOp(ctx, func(ctx context.Context) error {
+		return s.cache.ReleaseAccountSlot(ctx, accountID, requestID)
+	})
+	if err != nil {
+		log.Printf("Warning: failed to release account slot for %d (req=%s), buffered until redis recovers: %v", accountID, requestID, err)
+		s.fallback.Add(fmt.Sprintf("slot:account:%d:%s", accountID, requestID), 1, func(ctx context.Context, _ *cacheDeltaEntry) error {
+			return s.cache.ReleaseAccountSlot(ctx, accountID, requestID)
+		})
+	}
+}
+
+func (s *ConcurrencyService) releaseUserSlotReliable(ctx context.Context, userID int64, requestID string) {
+	err := retryCacheOp(ctx, func(ctx context.Context) error {
+		return s.cache.ReleaseUserSlot(ctx, userID, requestID)
+	})
+	if err != nil {
+		log.Printf("Warning: failed to release user slot for %d (req=%s), buffered until redis recovers: %v", userID, requestID, err)
+		s.fallback.Add(fmt.Sprintf("slot:user:%d:%s", userID, requestID), 1, func(ctx context.Context, _ *cacheDeltaEntry) error {
+			return s.cache.ReleaseUserSlot(ctx, userID, requestID)
+		})
+	}
+}
+
+// recordWaitResult 记录入队结果：成功入队计入本实例队列深度，队列已满则计为一次 shed
+func (s *ConcurrencyService) recordWaitResult(queued bool) {
+	if queued {
+		s.localWaiting.Add(1)
+		return
 	}
+	s.shed.Add(time.Now())
+}
+
+// LocalWaitingCount returns the number of requests currently queued on this instance.
+func (s *ConcurrencyService) LocalWaitingCount() int64 {
+	if v := s.localWaiting.Load(); v > 0 {
+		return v
+	}
+	return 0
+}
+
+// ShedCounts returns the total number of requests rejected because the wait queue was full,
+// and the number rejected within the last minute.
+func (s *ConcurrencyService) ShedCounts() (total int64, lastMinute int64) {
+	return s.shed.Counts(time.Now())
 }
 
 // GetAccountWaitingCount gets current wait queue count for an account.
@@ -282,6 +415,19 @@ func (s *ConcurrencyService) CleanupExpiredAccountSlots(ctx context.Context, acc
 	return s.cache.CleanupExpiredAccountSlots(ctx, accountID)
 }
 
+// ResetAccountSlots clears all slots and the wait counter of an account (used after delete/disable).
+// It is a no-op when the cache does not support resetting.
+func (s *ConcurrencyService) ResetAccountSlots(ctx context.Context, accountID int64) error {
+	if s == nil || s.cache == nil {
+		return nil
+	}
+	resetter, ok := s.cache.(AccountSlotResetter)
+	if !ok {
+		return nil
+	}
+	return resetter.ResetAccountSlots(ctx, accountID)
+}
+
 // StartSlotCleanupWorker starts a background cleanup worker for expired account slots.
 func (s *ConcurrencyService) StartSlotCleanupWorker(accountRepo AccountRepository, interval time.Duration) {
 	if s == nil || s.cache == nil || accountRepo == nil || interval <= 0 {
@@ -0,0 +1,61 @@
+package service
+
+import (
+	"context"
+	"log"
+	"sync"
+	"time"
+
+	"github.com/Wei-Shaw/sub2api/internal/config"
+	infraerrors "github.com/Wei-Shaw/sub2api/internal/pkg/errors"
+)
+
+// ConfigReloadResult 配置热重载结果
+type ConfigReloadResult struct {
+	Changed    []string  `json:"changed"`
+	ReloadedAt time.Time `json:"reloaded_at"`
+}
+
+// ConfigReloadService 配置热重载：重新读取配置文件并发布新的配置快照（读取方通过 cfg.Live() 获取），
+// 随后以新快照通知在启动时缓存了配置值的组件刷新。由 SIGHUP 与管理端接口触发。
+type ConfigReloadService struct {
+	cfg    *config.Config
+	reload func(cfg *config.Config) ([]string, error)
+
+	mu          sync.Mutex
+	subscribers []func(cfg *config.Config)
+}
+
+// NewConfigReloadService 创建配置热重载服务
+func NewConfigReloadService(cfg *config.Config) *ConfigReloadService {
+	return &ConfigReloadService{cfg: cfg, reload: config.Reload}
+}
+
+// Subscribe 注册重载回调（仅在有配置项变化时调用，参数为新发布的配置快照）
+func (s *ConfigReloadService) Subscribe(fn func(cfg *config.Config)) {
+	if s == nil || fn == nil {
+		return
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.subscribers = append(s.subscribers, fn)
+}
+
+// Reload 重新加载配置；配置无效时返回错误且不做任何修改
+func (s *ConfigReloadService) Reload(ctx context.Context) (*ConfigReloadResult, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	changed, err := s.reload(s.cfg)
+	if err != nil {
+		log.Printf("[ConfigReload] reload failed: %v", err)
+		return nil, infraerrors.BadRequest("CONFIG_RELOAD_FAILED", err.Error())
+	}
+	if len(changed) > 0 {
+		for _, fn := range s.subscribers {
+			fn(s.cfg.Live())
+		}
+	}
+	log.Printf("[ConfigReload] reloaded, changed=%v", changed)
+	return &ConfigReloadResult{Changed: changed, ReloadedAt: time.Now()}, nil
+}
@@ -0,0 +1,133 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"log"
+	"math"
+	"time"
+
+	"github.com/Wei-Shaw/sub2api/internal/config"
+	infraerrors "github.com/Wei-Shaw/sub2api/internal/pkg/errors"
+	"github.com/Wei-Shaw/sub2api/internal/pkg/tokenizer"
+)
+
+// ErrEstimatedCostExceedsBudget 请求的预估最大费用超过剩余预算（余额 / API Key 额度 / 订阅限额）
+var ErrEstimatedCostExceedsBudget = infraerrors.BadRequest(
+	"ESTIMATED_COST_EXCEEDS_BUDGET",
+	"estimated maximum cost of this request exceeds the remaining budget; lower max_tokens or top up",
+)
+
+// maxOutputTokenFields 各协议中表示最大输出 token 的字段（按优先级）
+var maxOutputTokenFields = []string{"max_tokens", "max_completion_tokens", "max_output_tokens"}
+
+// extractMaxOutputTokens 读取请求声明的最大输出 token（兼容 Anthropic / OpenAI Chat / Responses / Gemini）
+func extractMaxOutputTokens(body []byte) int {
+	var req map[string]any
+	if err := json.Unmarshal(body, &req); err != nil {
+		return 0
+	}
+	for _, field := range maxOutputTokenFields {
+		if n, ok := parseIntegralNumber(req[field]); ok && n > 0 {
+			return n
+		}
+	}
+	if gc, ok := req["generationConfig"].(map[string]any); ok {
+		if n, ok := parseIntegralNumber(gc["maxOutputTokens"]); ok && n > 0 {
+			return n
+		}
+	}
+	return 0
+}
+
+// estimateRequestMaxCost 估算请求的最大费用（本地分词估算的提示 token × 输入单价 + 最大输出 token × 输出单价）× 倍率。
+// 未启用预检或无法估算时返回 0。
+func estimateRequestMaxCost(ctx context.Context, cfg *config.Config, billing *BillingService, rateRepo UserGroupRateRepository, apiKey *APIKey, platform, model string, body []byte) float64 {
+	if cfg == nil || !cfg.Gateway.CostPreflight.Enabled || billing == nil || apiKey == nil || model == "" {
+		return 0
+	}
+	maxOutput := extractMaxOutputTokens(body)
+	if maxOutput <= 0 {
+		maxOutput = cfg.Gateway.CostPreflight.DefaultMaxOutputTokens
+	}
+	tokens := UsageTokens{
+		InputTokens:  tokenizer.CountJSON(tokenizer.ForModel(platform, model), body),
+		OutputTokens: maxOutput,
+	}
+
+	multiplier := cfg.Default.RateMultiplier
+	if apiKey.GroupID != nil && apiKey.Group != nil {
+		multiplier = apiKey.Group.RateMultiplier
+		if rateRepo != nil && apiKey.User != nil {
+			if userRate, err := rateRepo.GetByUserAndGroup(ctx, apiKey.User.ID, *apiKey.GroupID); err == nil && userRate != nil {
+				multiplier = *userRate
+			}
+		}
+	}
+
+	cost, err := billing.CalculateCostForPlatform(platform, model, tokens, multiplier)
+	if err != nil {
+		return 0
+	}
+	return cost.ActualCost
+}
+
+// EstimateRequestMaxCost 估算网关请求的最大费用，供占用账号前的预算预检使用
+func (s *GatewayService) EstimateRequestMaxCost(ctx context.Context, apiKey *APIKey, platform, model string, body []byte) float64 {
+	return estimateRequestMaxCost(ctx, s.cfg, s.billingService, s.userGroupRateRepo, apiKey, platform, model, body)
+}
+
+// EstimateRequestMaxCost 估算 OpenAI 网关请求的最大费用，供占用账号前的预算预检使用
+func (s *OpenAIGatewayService) EstimateRequestMaxCost(ctx context.Context, apiKey *APIKey, model string, body []byte) float64 {
+	return estimateRequestMaxCost(ctx, s.cfg, s.billingService, nil, apiKey, PlatformOpenAI, model, body)
+}
+
+// CheckEstimatedCost 预检：预估最大费用超过剩余预算（API Key 额度、余额或订阅剩余限额中最小者）时拒绝请求，
+// 避免单个超大请求把所剩无几的余额扣成大额负数。estimatedCost <= 0 表示未估算，直接放行。
+func (s *BillingCacheService) CheckEstimatedCost(ctx context.Context, user *User, apiKey *APIKey, group *Group, subscription *UserSubscription, estimatedCost float64) error {
+	// 仅计量分组不扣费，无需预检
+	if estimatedCost <= 0 || s.cfg.RunMode == config.RunModeSimple || group.IsMeteringOnly() {
+		return nil
+	}
+
+	remaining := math.Inf(1)
+	if apiKey != nil && apiKey.Quota > 0 {
+		remaining = math.Min(remaining, apiKey.Quota-apiKey.QuotaUsed)
+	}
+
+	if group != nil && group.IsSubscriptionType() && subscription != nil {
+		subData, err := s.GetSubscriptionStatus(ctx, user.ID, group.ID)
+		if err != nil {
+			// 预检只是保护性措施，读取失败时交由常规资格检查处理
+			return nil
+		}
+		if group.HasDailyLimit() {
+			remaining = math.Min(remaining, *group.DailyLimitUSD-subData.DailyUsage)
+		}
+		if group.HasWeeklyLimit() {
+			remaining = math.Min(remaining, *group.WeeklyLimitUSD-subData.WeeklyUsage)
+		}
+		if group.HasMonthlyLimit() {
+			remaining = math.Min(remaining, *group.MonthlyLimitUSD+subData.monthlyRollover(time.Now())-subData.MonthlyUsage)
+		}
+	} else if user != nil {
+		balance, err := s.GetUserBalance(ctx, user.ID)
+		if err != nil {
+			return nil
+		}
+		remaining = math.Min(remaining, balance)
+	}
+
+	if estimatedCost > remaining {
+		log.Printf("Cost preflight rejected: api_key=%d estimated=%.6f remaining=%.6f", apiKeyIDOrZero(apiKey), estimatedCost, remaining)
+		return ErrEstimatedCostExceedsBudget
+	}
+	return nil
+}
+
+func apiKeyIDOrZero(apiKey *APIKey) int64 {
+	if apiKey == nil {
+		return 0
+	}
+	return apiKey.ID
+}
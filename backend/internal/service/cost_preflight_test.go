@@ -0,0 +1,67 @@
+//go:build unit
+
+package service
+
+import (
+	"context"
+	"testing"
+
+	"github.com/Wei-Shaw/sub2api/internal/config"
+	"github.com/stretchr/testify/require"
+)
+
+func TestExtractMaxOutputTokens(t *testing.T) {
+	require.Equal(t, 1024, extractMaxOutputTokens([]byte(`{"max_tokens":1024}`)))
+	require.Equal(t, 2048, extractMaxOutputTokens([]byte(`{"max_completion_tokens":2048}`)))
+	require.Equal(t, 512, extractMaxOutputTokens([]byte(`{"max_output_tokens":512}`)))
+	require.Equal(t, 256, extractMaxOutputTokens([]byte(`{"generationConfig":{"maxOutputTokens":256}}`)))
+	require.Equal(t, 0, extractMaxOutputTokens([]byte(`{"max_tokens":1.5}`)))
+	require.Equal(t, 0, extractMaxOutputTokens([]byte(`not json`)))
+}
+
+func TestEstimateRequestMaxCost(t *testing.T) {
+	cfg := &config.Config{}
+	cfg.Default.RateMultiplier = 1
+	billing := NewBillingService(cfg, nil, nil)
+	body := []byte(`{"model":"claude-sonnet-4","max_tokens":1000,"messages":[{"role":"user","content":"hi"}]}`)
+	apiKey := &APIKey{ID: 1}
+
+	// 未启用时不估算
+	require.Zero(t, estimateRequestMaxCost(context.Background(), cfg, billing, nil, apiKey, PlatformAnthropic, "claude-sonnet-4", body))
+
+	cfg.Gateway.CostPreflight.Enabled = true
+	cost := estimateRequestMaxCost(context.Background(), cfg, billing, nil, apiKey, PlatformAnthropic, "claude-sonnet-4", body)
+	require.Greater(t, cost, 0.0)
+
+	// 分组倍率参与估算
+	groupID := int64(2)
+	doubled := estimateRequestMaxCost(context.Background(), cfg, billing, nil, &APIKey{ID: 1, GroupID: &groupID, Group: &Group{ID: groupID, RateMultiplier: 2}}, PlatformAnthropic, "claude-sonnet-4", body)
+	require.InDelta(t, cost*2, doubled, 1e-12)
+
+	// 未声明 max_tokens 时使用默认值
+	cfg.Gateway.CostPreflight.DefaultMaxOutputTokens = 1000
+	require.InDelta(t, cost, estimateRequestMaxCost(context.Background(), cfg, billing, nil, apiKey, PlatformAnthropic, "claude-sonnet-4",
+		[]byte(`{"model":"claude-sonnet-4","messages":[{"role":"user","content":"hi"}]}`)), 1e-12)
+}
+
+func TestBillingCacheService_CheckEstimatedCost(t *testing.T) {
+	balance := 1.0
+	cache := &billingCacheWorkerStub{balance: &balance}
+	svc := NewBillingCacheService(cache, nil, nil, &config.Config{}, nil, nil)
+	t.Cleanup(svc.Stop)
+	ctx := context.Background()
+	user := &User{ID: 7}
+
+	require.NoError(t, svc.CheckEstimatedCost(ctx, user, &APIKey{ID: 1}, nil, nil, 0))
+	require.NoError(t, svc.CheckEstimatedCost(ctx, user, &APIKey{ID: 1}, nil, nil, 0.9))
+	require.ErrorIs(t, svc.CheckEstimatedCost(ctx, user, &APIKey{ID: 1}, nil, nil, 1.5), ErrEstimatedCostExceedsBudget)
+
+	// API Key 剩余额度低于余额时以额度为准
+	limited := &APIKey{ID: 2, Quota: 10, QuotaUsed: 9.5}
+	require.ErrorIs(t, svc.CheckEstimatedCost(ctx, user, limited, nil, nil, 0.9), ErrEstimatedCostExceedsBudget)
+
+	// simple 模式不做预检
+	simple := NewBillingCacheService(cache, nil, nil, &config.Config{RunMode: config.RunModeSimple}, nil, nil)
+	t.Cleanup(simple.Stop)
+	require.NoError(t, simple.CheckEstimatedCost(ctx, user, &APIKey{ID: 1}, nil, nil, 100))
+}
@@ -4,18 +4,22 @@ import "github.com/Wei-Shaw/sub2api/internal/domain"
 
 // Status constants
 const (
-	StatusActive   = domain.StatusActive
-	StatusDisabled = domain.StatusDisabled
-	StatusError    = domain.StatusError
-	StatusUnused   = domain.StatusUnused
-	StatusUsed     = domain.StatusUsed
-	StatusExpired  = domain.StatusExpired
+	StatusActive      = domain.StatusActive
+	StatusDisabled    = domain.StatusDisabled
+	StatusError       = domain.StatusError
+	StatusMaintenance = domain.StatusMaintenance
+	StatusUnused      = domain.StatusUnused
+	StatusUsed        = domain.StatusUsed
+	StatusExpired     = domain.StatusExpired
 )
 
 // Role constants
 const (
-	RoleAdmin = domain.RoleAdmin
-	RoleUser  = domain.RoleUser
+	RoleAdmin         = domain.RoleAdmin
+	RoleUser          = domain.RoleUser
+	RoleOperator      = domain.RoleOperator
+	RoleBillingViewer = domain.RoleBillingViewer
+	RoleSupport       = domain.RoleSupport
 )
 
 // Platform constants
@@ -160,12 +164,53 @@ const (
 	// SettingKeyOpsAdvancedSettings stores JSON config for ops advanced settings (data retention, aggregation).
 	SettingKeyOpsAdvancedSettings = "ops_advanced_settings"
 
+	// SettingKeyOpsSLOSettings stores JSON SLO definitions (per-group availability/TTFT objectives).
+	SettingKeyOpsSLOSettings = "ops_slo_settings"
+
 	// =========================
 	// Stream Timeout Handling
 	// =========================
 
 	// SettingKeyStreamTimeoutSettings stores JSON config for stream timeout handling.
 	SettingKeyStreamTimeoutSettings = "stream_timeout_settings"
+
+	// =========================
+	// Model Alias
+	// =========================
+
+	// SettingKeyModelAliasSettings stores JSON config for model alias/rewrite rules.
+	SettingKeyModelAliasSettings = "model_alias_settings"
+
+	// =========================
+	// Virtual Models
+	// =========================
+
+	// SettingKeyVirtualModelSettings stores JSON config for virtual models with fallback chains.
+	SettingKeyVirtualModelSettings = "virtual_model_settings"
+
+	// =========================
+	// Request Preprocessing
+	// =========================
+
+	// SettingKeyRequestStripSettings stores JSON config for stripping client-side cache-busting fields.
+	SettingKeyRequestStripSettings = "request_strip_settings"
+
+	// SettingKeyRequestSanitizeSettings stores JSON config for stripping/rejecting dangerous or unsupported request fields.
+	SettingKeyRequestSanitizeSettings = "request_sanitize_settings"
+
+	// =========================
+	// Budget Alerts
+	// =========================
+
+	// SettingKeyBudgetAlertSettings stores JSON config for spend/usage threshold alerts (webhook/email).
+	SettingKeyBudgetAlertSettings = "budget_alert_settings"
+
+	// =========================
+	// Upstream Error Mapping
+	// =========================
+
+	// SettingKeyUpstreamErrorMappingSettings stores JSON config mapping upstream errors to client-facing status/type/message.
+	SettingKeyUpstreamErrorMappingSettings = "upstream_error_mapping_settings"
 )
 
 // AdminAPIKeyPrefix is the prefix for admin API keys (distinct from user "sk-" keys).
@@ -1,6 +1,10 @@
 package service
 
-import "github.com/gin-gonic/gin"
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
 
 const errorPassthroughServiceContextKey = "error_passthrough_service"
 
@@ -32,6 +36,7 @@ func applyErrorPassthroughRule(
 	c *gin.Context,
 	platform string,
 	upstreamStatus int,
+	responseHeaders http.Header,
 	responseBody []byte,
 	defaultStatus int,
 	defaultErrType string,
@@ -46,7 +51,7 @@ func applyErrorPassthroughRule(
 		return status, errType, errMsg, false
 	}
 
-	rule := svc.MatchRule(platform, upstreamStatus, responseBody)
+	rule := svc.MatchRuleWithHeaders(platform, upstreamStatus, responseHeaders, responseBody)
 	if rule == nil {
 		return status, errType, errMsg, false
 	}
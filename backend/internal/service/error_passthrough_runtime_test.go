@@ -24,6 +24,7 @@ func TestApplyErrorPassthroughRule_NoBoundService(t *testing.T) {
 		c,
 		PlatformAnthropic,
 		http.StatusUnprocessableEntity,
+		nil,
 		[]byte(`{"error":{"message":"invalid schema"}}`),
 		http.StatusBadGateway,
 		"upstream_error",
@@ -210,6 +211,7 @@ func TestApplyErrorPassthroughRule_SkipMonitoringSetsContextKey(t *testing.T) {
 		c,
 		PlatformAnthropic,
 		http.StatusBadRequest,
+		nil,
 		[]byte(`{"error":{"message":"prompt is too long"}}`),
 		http.StatusBadGateway,
 		"upstream_error",
@@ -240,6 +242,7 @@ func TestApplyErrorPassthroughRule_NoSkipMonitoringDoesNotSetContextKey(t *testi
 		c,
 		PlatformAnthropic,
 		http.StatusBadRequest,
+		nil,
 		[]byte(`{"error":{"message":"prompt is too long"}}`),
 		http.StatusBadGateway,
 		"upstream_error",
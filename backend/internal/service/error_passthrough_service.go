@@ -3,6 +3,9 @@ package service
 import (
 	"context"
 	"log"
+	"net/http"
+	"path"
+	"regexp"
 	"sort"
 	"strings"
 	"sync"
@@ -52,9 +55,17 @@ type ErrorPassthroughService struct {
 // cachedPassthroughRule 预计算的规则缓存，避免运行时重复 ToLower
 type cachedPassthroughRule struct {
 	*model.ErrorPassthroughRule
-	lowerKeywords  []string         // 预计算的小写关键词
-	lowerPlatforms []string         // 预计算的小写平台
-	errorCodeSet   map[int]struct{} // 预计算的 error code set
+	lowerKeywords  []string              // 预计算的小写关键词
+	lowerPlatforms []string              // 预计算的小写平台
+	errorCodeSet   map[int]struct{}      // 预计算的 error code set
+	bodyPatterns   []*regexp.Regexp      // 预编译的响应体正则
+	headerPatterns []compiledHeaderMatch // 预编译的响应头正则
+}
+
+// compiledHeaderMatch 预编译的响应头匹配条件
+type compiledHeaderMatch struct {
+	name string
+	re   *regexp.Regexp
 }
 
 const maxBodyMatchLen = 8 << 10 // 8KB，错误信息不会在 8KB 之后才出现
@@ -155,6 +166,12 @@ func (s *ErrorPassthroughService) Delete(ctx context.Context, id int64) error {
 // MatchRule 匹配透传规则
 // 返回第一个匹配的规则，如果没有匹配则返回 nil
 func (s *ErrorPassthroughService) MatchRule(platform string, statusCode int, body []byte) *model.ErrorPassthroughRule {
+	return s.MatchRuleWithHeaders(platform, statusCode, nil, body)
+}
+
+// MatchRuleWithHeaders 匹配透传规则，同时检查上游响应头
+// headers 为 nil 时配置了响应头条件的部分视为不满足
+func (s *ErrorPassthroughService) MatchRuleWithHeaders(platform string, statusCode int, headers http.Header, body []byte) *model.ErrorPassthroughRule {
 	rules := s.getCachedRules()
 	if len(rules) == 0 {
 		return nil
@@ -171,7 +188,7 @@ func (s *ErrorPassthroughService) MatchRule(platform string, statusCode int, bod
 		if !s.platformMatchesCached(rule, lowerPlatform) {
 			continue
 		}
-		if s.ruleMatchesOptimized(rule, statusCode, body, &bodyLower, &bodyLowerDone) {
+		if s.ruleMatchesOptimized(rule, statusCode, headers, body, &bodyLower, &bodyLowerDone) {
 			return rule.ErrorPassthroughRule
 		}
 	}
@@ -179,6 +196,20 @@ func (s *ErrorPassthroughService) MatchRule(platform string, statusCode int, bod
 	return nil
 }
 
+// TestRule 使用给定的上游响应试运行单条规则（不要求规则已保存或启用），用于管理端调试
+func (s *ErrorPassthroughService) TestRule(rule *model.ErrorPassthroughRule, platform string, statusCode int, headers http.Header, body []byte) (bool, error) {
+	if err := rule.Validate(); err != nil {
+		return false, err
+	}
+	cr := newCachedPassthroughRule(rule)
+	if !s.platformMatchesCached(cr, strings.ToLower(platform)) {
+		return false, nil
+	}
+	var bodyLower string
+	var bodyLowerDone bool
+	return s.ruleMatchesOptimized(cr, statusCode, headers, body, &bodyLower, &bodyLowerDone), nil
+}
+
 // getCachedRules 获取缓存的规则列表（按优先级排序）
 func (s *ErrorPassthroughService) getCachedRules() []*cachedPassthroughRule {
 	s.localCacheMu.RLock()
@@ -239,26 +270,7 @@ func (s *ErrorPassthroughService) reloadRulesFromDB(ctx context.Context) error {
 func (s *ErrorPassthroughService) setLocalCache(rules []*model.ErrorPassthroughRule) {
 	cached := make([]*cachedPassthroughRule, len(rules))
 	for i, r := range rules {
-		cr := &cachedPassthroughRule{ErrorPassthroughRule: r}
-		if len(r.Keywords) > 0 {
-			cr.lowerKeywords = make([]string, len(r.Keywords))
-			for j, kw := range r.Keywords {
-				cr.lowerKeywords[j] = strings.ToLower(kw)
-			}
-		}
-		if len(r.Platforms) > 0 {
-			cr.lowerPlatforms = make([]string, len(r.Platforms))
-			for j, p := range r.Platforms {
-				cr.lowerPlatforms[j] = strings.ToLower(p)
-			}
-		}
-		if len(r.ErrorCodes) > 0 {
-			cr.errorCodeSet = make(map[int]struct{}, len(r.ErrorCodes))
-			for _, code := range r.ErrorCodes {
-				cr.errorCodeSet[code] = struct{}{}
-			}
-		}
-		cached[i] = cr
+		cached[i] = newCachedPassthroughRule(r)
 	}
 
 	// 按优先级排序
@@ -271,6 +283,47 @@ func (s *ErrorPassthroughService) setLocalCache(rules []*model.ErrorPassthroughR
 	s.localCacheMu.Unlock()
 }
 
+// newCachedPassthroughRule 预计算单条规则的小写值、set 与正则
+func newCachedPassthroughRule(r *model.ErrorPassthroughRule) *cachedPassthroughRule {
+	cr := &cachedPassthroughRule{ErrorPassthroughRule: r}
+	if len(r.Keywords) > 0 {
+		cr.lowerKeywords = make([]string, len(r.Keywords))
+		for j, kw := range r.Keywords {
+			cr.lowerKeywords[j] = strings.ToLower(kw)
+		}
+	}
+	if len(r.Platforms) > 0 {
+		cr.lowerPlatforms = make([]string, len(r.Platforms))
+		for j, p := range r.Platforms {
+			cr.lowerPlatforms[j] = strings.ToLower(p)
+		}
+	}
+	if len(r.ErrorCodes) > 0 {
+		cr.errorCodeSet = make(map[int]struct{}, len(r.ErrorCodes))
+		for _, code := range r.ErrorCodes {
+			cr.errorCodeSet[code] = struct{}{}
+		}
+	}
+	// 写入时已校验正则；这里仍跳过无法编译的条目，避免历史脏数据导致匹配异常
+	for _, expr := range r.BodyPatterns {
+		re, err := regexp.Compile(expr)
+		if err != nil {
+			log.Printf("[ErrorPassthroughService] Rule %d has invalid body pattern %q: %v", r.ID, expr, err)
+			continue
+		}
+		cr.bodyPatterns = append(cr.bodyPatterns, re)
+	}
+	for name, expr := range r.HeaderMatches {
+		re, err := regexp.Compile(expr)
+		if err != nil {
+			log.Printf("[ErrorPassthroughService] Rule %d has invalid header pattern %s=%q: %v", r.ID, name, expr, err)
+			continue
+		}
+		cr.headerPatterns = append(cr.headerPatterns, compiledHeaderMatch{name: http.CanonicalHeaderKey(name), re: re})
+	}
+	return cr
+}
+
 // clearLocalCache 清空本地缓存，避免刷新失败时继续命中陈旧规则。
 func (s *ErrorPassthroughService) clearLocalCache() {
 	s.localCacheMu.Lock()
@@ -321,7 +374,7 @@ func ensureBodyLower(body []byte, bodyLower *string, done *bool) string {
 	return *bodyLower
 }
 
-// platformMatchesCached 使用预计算的小写平台检查是否匹配
+// platformMatchesCached 使用预计算的小写平台检查是否匹配，支持通配符（如 "*"、"gemini*"）
 func (s *ErrorPassthroughService) platformMatchesCached(rule *cachedPassthroughRule, lowerPlatform string) bool {
 	if len(rule.lowerPlatforms) == 0 {
 		return true
@@ -330,44 +383,94 @@ func (s *ErrorPassthroughService) platformMatchesCached(rule *cachedPassthroughR
 		if p == lowerPlatform {
 			return true
 		}
+		if strings.ContainsAny(p, "*?[") {
+			if ok, _ := path.Match(p, lowerPlatform); ok {
+				return true
+			}
+		}
 	}
 	return false
 }
 
-// ruleMatchesOptimized 优化的规则匹配，支持短路和延迟 body 转换
-func (s *ErrorPassthroughService) ruleMatchesOptimized(rule *cachedPassthroughRule, statusCode int, body []byte, bodyLower *string, bodyLowerDone *bool) bool {
+// ruleMatchesOptimized 优化的规则匹配，支持短路和延迟 body 转换。
+// 条件分为错误码、响应头、响应体（关键词或正则）三类，按开销从低到高依次评估：
+// "any" 模式下任一已配置条件满足即命中，"all" 模式下所有已配置条件都必须满足。
+func (s *ErrorPassthroughService) ruleMatchesOptimized(rule *cachedPassthroughRule, statusCode int, headers http.Header, body []byte, bodyLower *string, bodyLowerDone *bool) bool {
 	hasErrorCodes := len(rule.errorCodeSet) > 0
-	hasKeywords := len(rule.lowerKeywords) > 0
+	hasHeaders := len(rule.headerPatterns) > 0
+	hasBody := len(rule.lowerKeywords) > 0 || len(rule.bodyPatterns) > 0
 
-	if !hasErrorCodes && !hasKeywords {
+	if !hasErrorCodes && !hasHeaders && !hasBody {
 		return false
 	}
 
-	codeMatch := !hasErrorCodes || s.containsIntSet(rule.errorCodeSet, statusCode)
-
-	if rule.MatchMode == model.MatchModeAll {
-		// "all" 模式：所有配置的条件都必须满足，短路
-		if hasErrorCodes && !codeMatch {
+	matchAll := rule.MatchMode == model.MatchModeAll
+	if hasErrorCodes {
+		matched := s.containsIntSet(rule.errorCodeSet, statusCode)
+		if matched && !matchAll {
+			return true
+		}
+		if !matched && matchAll {
 			return false
 		}
-		if hasKeywords {
-			return s.containsAnyKeywordCached(ensureBodyLower(body, bodyLower, bodyLowerDone), rule.lowerKeywords)
+	}
+	if hasHeaders {
+		matched := s.headersMatchCached(headers, rule.headerPatterns)
+		if matched && !matchAll {
+			return true
+		}
+		if !matched && matchAll {
+			return false
 		}
-		return codeMatch
 	}
-
-	// "any" 模式：任一条件满足即可，短路
-	if hasErrorCodes && hasKeywords {
-		if codeMatch {
+	if hasBody {
+		matched := (len(rule.lowerKeywords) > 0 && s.containsAnyKeywordCached(ensureBodyLower(body, bodyLower, bodyLowerDone), rule.lowerKeywords)) ||
+			s.matchesAnyBodyPattern(body, rule.bodyPatterns)
+		if matched && !matchAll {
 			return true
 		}
-		return s.containsAnyKeywordCached(ensureBodyLower(body, bodyLower, bodyLowerDone), rule.lowerKeywords)
+		if !matched && matchAll {
+			return false
+		}
 	}
-	// 只配置了一种条件
-	if hasKeywords {
-		return s.containsAnyKeywordCached(ensureBodyLower(body, bodyLower, bodyLowerDone), rule.lowerKeywords)
+	// "all" 模式走到这里说明全部满足；"any" 模式说明全部不满足
+	return matchAll
+}
+
+// headersMatchCached 检查所有响应头条件是否满足（同名多值时任一值匹配即可）
+func (s *ErrorPassthroughService) headersMatchCached(headers http.Header, patterns []compiledHeaderMatch) bool {
+	if headers == nil {
+		return false
 	}
-	return codeMatch
+	for _, hp := range patterns {
+		matched := false
+		for _, v := range headers[hp.name] {
+			if hp.re.MatchString(v) {
+				matched = true
+				break
+			}
+		}
+		if !matched {
+			return false
+		}
+	}
+	return true
+}
+
+// matchesAnyBodyPattern 使用预编译正则匹配响应体（限制 8KB，区分大小写，可在正则中使用 (?i)）
+func (s *ErrorPassthroughService) matchesAnyBodyPattern(body []byte, patterns []*regexp.Regexp) bool {
+	if len(patterns) == 0 {
+		return false
+	}
+	if len(body) > maxBodyMatchLen {
+		body = body[:maxBodyMatchLen]
+	}
+	for _, re := range patterns {
+		if re.Match(body) {
+			return true
+		}
+	}
+	return false
 }
 
 // containsIntSet 使用 map 查找替代线性扫描
@@ -5,6 +5,7 @@ package service
 import (
 	"context"
 	"errors"
+	"net/http"
 	"strings"
 	"testing"
 
@@ -147,26 +148,7 @@ func newTestService(rules []*model.ErrorPassthroughRule) *ErrorPassthroughServic
 
 // newCachedRuleForTest 从 model.ErrorPassthroughRule 创建 cachedPassthroughRule（测试用）
 func newCachedRuleForTest(rule *model.ErrorPassthroughRule) *cachedPassthroughRule {
-	cr := &cachedPassthroughRule{ErrorPassthroughRule: rule}
-	if len(rule.Keywords) > 0 {
-		cr.lowerKeywords = make([]string, len(rule.Keywords))
-		for j, kw := range rule.Keywords {
-			cr.lowerKeywords[j] = strings.ToLower(kw)
-		}
-	}
-	if len(rule.Platforms) > 0 {
-		cr.lowerPlatforms = make([]string, len(rule.Platforms))
-		for j, p := range rule.Platforms {
-			cr.lowerPlatforms[j] = strings.ToLower(p)
-		}
-	}
-	if len(rule.ErrorCodes) > 0 {
-		cr.errorCodeSet = make(map[int]struct{}, len(rule.ErrorCodes))
-		for _, code := range rule.ErrorCodes {
-			cr.errorCodeSet[code] = struct{}{}
-		}
-	}
-	return cr
+	return newCachedPassthroughRule(rule)
 }
 
 // =============================================================================
@@ -185,7 +167,7 @@ func TestRuleMatches_NoConditions(t *testing.T) {
 
 	var bodyLower string
 	var bodyLowerDone bool
-	assert.False(t, svc.ruleMatchesOptimized(rule, 422, []byte("some error message"), &bodyLower, &bodyLowerDone),
+	assert.False(t, svc.ruleMatchesOptimized(rule, 422, nil, []byte("some error message"), &bodyLower, &bodyLowerDone),
 		"没有配置条件时不应该匹配")
 }
 
@@ -214,7 +196,7 @@ func TestRuleMatches_OnlyErrorCodes_AnyMode(t *testing.T) {
 		t.Run(tt.name, func(t *testing.T) {
 			var bodyLower string
 			var bodyLowerDone bool
-			result := svc.ruleMatchesOptimized(rule, tt.statusCode, []byte(tt.body), &bodyLower, &bodyLowerDone)
+			result := svc.ruleMatchesOptimized(rule, tt.statusCode, nil, []byte(tt.body), &bodyLower, &bodyLowerDone)
 			assert.Equal(t, tt.expected, result)
 		})
 	}
@@ -245,7 +227,7 @@ func TestRuleMatches_OnlyKeywords_AnyMode(t *testing.T) {
 		t.Run(tt.name, func(t *testing.T) {
 			var bodyLower string
 			var bodyLowerDone bool
-			result := svc.ruleMatchesOptimized(rule, tt.statusCode, []byte(tt.body), &bodyLower, &bodyLowerDone)
+			result := svc.ruleMatchesOptimized(rule, tt.statusCode, nil, []byte(tt.body), &bodyLower, &bodyLowerDone)
 			assert.Equal(t, tt.expected, result)
 		})
 	}
@@ -302,7 +284,7 @@ func TestRuleMatches_BothConditions_AnyMode(t *testing.T) {
 		t.Run(tt.name, func(t *testing.T) {
 			var bodyLower string
 			var bodyLowerDone bool
-			result := svc.ruleMatchesOptimized(rule, tt.statusCode, []byte(tt.body), &bodyLower, &bodyLowerDone)
+			result := svc.ruleMatchesOptimized(rule, tt.statusCode, nil, []byte(tt.body), &bodyLower, &bodyLowerDone)
 			assert.Equal(t, tt.expected, result, tt.reason)
 		})
 	}
@@ -359,7 +341,7 @@ func TestRuleMatches_BothConditions_AllMode(t *testing.T) {
 		t.Run(tt.name, func(t *testing.T) {
 			var bodyLower string
 			var bodyLowerDone bool
-			result := svc.ruleMatchesOptimized(rule, tt.statusCode, []byte(tt.body), &bodyLower, &bodyLowerDone)
+			result := svc.ruleMatchesOptimized(rule, tt.statusCode, nil, []byte(tt.body), &bodyLower, &bodyLowerDone)
 			assert.Equal(t, tt.expected, result, tt.reason)
 		})
 	}
@@ -1009,6 +991,110 @@ func newPassthroughRuleForWritePathTest(id int64, keyword, customMsg string) *mo
 	return rule
 }
 
+// =============================================================================
+// 测试响应体正则、响应头与平台通配符匹配
+// =============================================================================
+
+func TestRuleMatches_BodyPatterns(t *testing.T) {
+	svc := newTestService(nil)
+	rule := newCachedRuleForTest(&model.ErrorPassthroughRule{
+		Enabled:      true,
+		Keywords:     []string{"context_length_exceeded"},
+		BodyPatterns: []string{`(?i)prompt is too long: \d+ tokens`},
+		MatchMode:    model.MatchModeAny,
+	})
+
+	tests := []struct {
+		name     string
+		body     string
+		expected bool
+	}{
+		{"正则匹配", `{"message":"Prompt is too long: 210000 tokens > 200000"}`, true},
+		{"关键词匹配", `{"code":"context_length_exceeded"}`, true},
+		{"都不匹配", `{"message":"prompt is too long"}`, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var bodyLower string
+			var bodyLowerDone bool
+			assert.Equal(t, tt.expected, svc.ruleMatchesOptimized(rule, 400, nil, []byte(tt.body), &bodyLower, &bodyLowerDone))
+		})
+	}
+}
+
+func TestRuleMatches_HeaderMatches_AllMode(t *testing.T) {
+	svc := newTestService(nil)
+	rule := newCachedRuleForTest(&model.ErrorPassthroughRule{
+		Enabled:       true,
+		ErrorCodes:    []int{429},
+		HeaderMatches: map[string]string{"x-ratelimit-scope": "^(org|project)$", "Retry-After": `^\d+$`},
+		MatchMode:     model.MatchModeAll,
+	})
+
+	headers := http.Header{}
+	headers.Set("X-RateLimit-Scope", "org")
+	headers.Set("Retry-After", "30")
+
+	var bodyLower string
+	var bodyLowerDone bool
+	assert.True(t, svc.ruleMatchesOptimized(rule, 429, headers, nil, &bodyLower, &bodyLowerDone))
+	assert.False(t, svc.ruleMatchesOptimized(rule, 500, headers, nil, &bodyLower, &bodyLowerDone), "状态码不满足")
+	assert.False(t, svc.ruleMatchesOptimized(rule, 429, nil, nil, &bodyLower, &bodyLowerDone), "无响应头时不满足")
+
+	headers.Set("Retry-After", "soon")
+	assert.False(t, svc.ruleMatchesOptimized(rule, 429, headers, nil, &bodyLower, &bodyLowerDone), "任一响应头不匹配即不满足")
+}
+
+func TestMatchRule_PlatformWildcard(t *testing.T) {
+	svc := newTestService([]*model.ErrorPassthroughRule{
+		{ID: 1, Name: "gemini-family", Enabled: true, Priority: 1, ErrorCodes: []int{400}, MatchMode: model.MatchModeAny, Platforms: []string{"gemini*"}},
+		{ID: 2, Name: "all", Enabled: true, Priority: 2, ErrorCodes: []int{503}, MatchMode: model.MatchModeAny, Platforms: []string{"*"}},
+	})
+
+	require.NotNil(t, svc.MatchRule("gemini", 400, nil))
+	assert.Equal(t, int64(1), svc.MatchRule("Gemini", 400, nil).ID)
+	assert.Nil(t, svc.MatchRule("openai", 400, nil))
+	require.NotNil(t, svc.MatchRule("antigravity", 503, nil))
+	assert.Equal(t, int64(2), svc.MatchRule("antigravity", 503, nil).ID)
+}
+
+func TestMatchRuleWithHeaders(t *testing.T) {
+	svc := newTestService([]*model.ErrorPassthroughRule{
+		{ID: 1, Name: "overloaded", Enabled: true, Priority: 1, HeaderMatches: map[string]string{"X-Should-Retry": "false"}, MatchMode: model.MatchModeAny},
+	})
+
+	headers := http.Header{}
+	headers.Set("x-should-retry", "false")
+	require.NotNil(t, svc.MatchRuleWithHeaders("anthropic", 529, headers, nil))
+	assert.Nil(t, svc.MatchRule("anthropic", 529, nil), "MatchRule 不携带响应头")
+}
+
+func TestTestRule(t *testing.T) {
+	svc := newTestService(nil)
+	rule := &model.ErrorPassthroughRule{
+		Name:            "draft",
+		BodyPatterns:    []string{`quota .* exceeded`},
+		MatchMode:       model.MatchModeAny,
+		Platforms:       []string{"openai"},
+		PassthroughCode: true,
+		PassthroughBody: true,
+	}
+
+	matched, err := svc.TestRule(rule, "openai", 429, nil, []byte("daily quota for model exceeded"))
+	require.NoError(t, err)
+	assert.True(t, matched, "未启用的草稿规则也可以试运行")
+
+	matched, err = svc.TestRule(rule, "anthropic", 429, nil, []byte("daily quota for model exceeded"))
+	require.NoError(t, err)
+	assert.False(t, matched)
+
+	rule.BodyPatterns = []string{"("}
+	_, err = svc.TestRule(rule, "openai", 429, nil, nil)
+	var validationErr *model.ValidationError
+	require.ErrorAs(t, err, &validationErr)
+	assert.Equal(t, "body_patterns", validationErr.Field)
+}
+
 // Helper functions
 func testIntPtr(i int) *int       { return &i }
 func testStrPtr(s string) *string { return &s }
@@ -0,0 +1,85 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"time"
+
+	"github.com/Wei-Shaw/sub2api/internal/config"
+	"github.com/Wei-Shaw/sub2api/internal/pkg/ctxkey"
+	infraerrors "github.com/Wei-Shaw/sub2api/internal/pkg/errors"
+)
+
+// API Key 优先级类别（决定故障转移预算）
+const (
+	// PriorityClassInteractive 交互式请求：更少的账号切换次数与更短的单次尝试超时
+	PriorityClassInteractive = "interactive"
+	// PriorityClassBatch 批处理/后台请求：允许尝试更多账号与更长的单次尝试超时
+	PriorityClassBatch = "batch"
+)
+
+var ErrInvalidPriorityClass = infraerrors.BadRequest("INVALID_PRIORITY_CLASS", "priority_class must be empty, interactive or batch")
+
+// ErrUpstreamAttemptTimeout 单次上游尝试在超时时间内未收到响应头（由 HTTP 上游返回，网关据此切换账号）
+var ErrUpstreamAttemptTimeout = errors.New("upstream attempt timed out waiting for response headers")
+
+// NormalizePriorityClass 校验并规范化优先级类别，空字符串表示使用全局故障转移配置
+func NormalizePriorityClass(class string) (string, error) {
+	class = strings.ToLower(strings.TrimSpace(class))
+	switch class {
+	case "", PriorityClassInteractive, PriorityClassBatch:
+		return class, nil
+	}
+	return "", ErrInvalidPriorityClass
+}
+
+// FailoverBudget 单次请求的故障转移预算
+type FailoverBudget struct {
+	// MaxAccountSwitches 最大账号切换次数
+	MaxAccountSwitches int
+	// AttemptTimeout 单次尝试等待上游响应头的超时，0 表示使用上游默认超时
+	AttemptTimeout time.Duration
+	// HedgeAfter 首次尝试在该时间内未开始输出时向另一账号发起对冲请求，0 表示不对冲
+	HedgeAfter time.Duration
+}
+
+// ResolveFailoverBudget 按 API Key 优先级类别计算故障转移预算；
+// 未设置类别或类别未配置时使用 defaultSwitches（全局 max_account_switches）。
+func ResolveFailoverBudget(classes map[string]config.GatewayFailoverClassConfig, priorityClass string, defaultSwitches int) FailoverBudget {
+	budget := FailoverBudget{MaxAccountSwitches: defaultSwitches}
+	if priorityClass == "" {
+		return budget
+	}
+	classCfg, ok := classes[priorityClass]
+	if !ok {
+		return budget
+	}
+	if classCfg.MaxAccountSwitches > 0 {
+		budget.MaxAccountSwitches = classCfg.MaxAccountSwitches
+	}
+	if classCfg.AttemptTimeoutSeconds > 0 {
+		budget.AttemptTimeout = time.Duration(classCfg.AttemptTimeoutSeconds) * time.Second
+	}
+	if classCfg.HedgeAfterMs > 0 {
+		budget.HedgeAfter = time.Duration(classCfg.HedgeAfterMs) * time.Millisecond
+	}
+	return budget
+}
+
+// WithUpstreamAttemptTimeout 将单次尝试超时写入 context，由 HTTP 上游在等待响应头时使用
+func WithUpstreamAttemptTimeout(ctx context.Context, timeout time.Duration) context.Context {
+	if timeout <= 0 {
+		return ctx
+	}
+	return context.WithValue(ctx, ctxkey.UpstreamAttemptTimeout, timeout)
+}
+
+// UpstreamAttemptTimeoutFromContext 返回 context 中的单次尝试超时，未设置时返回 0
+func UpstreamAttemptTimeoutFromContext(ctx context.Context) time.Duration {
+	if ctx == nil {
+		return 0
+	}
+	timeout, _ := ctx.Value(ctxkey.UpstreamAttemptTimeout).(time.Duration)
+	return timeout
+}
@@ -0,0 +1,47 @@
+//go:build unit
+
+package service
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/Wei-Shaw/sub2api/internal/config"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNormalizePriorityClass(t *testing.T) {
+	class, err := NormalizePriorityClass(" Interactive ")
+	require.NoError(t, err)
+	require.Equal(t, PriorityClassInteractive, class)
+
+	class, err = NormalizePriorityClass("")
+	require.NoError(t, err)
+	require.Empty(t, class)
+
+	_, err = NormalizePriorityClass("realtime")
+	require.ErrorIs(t, err, ErrInvalidPriorityClass)
+}
+
+func TestResolveFailoverBudget(t *testing.T) {
+	classes := map[string]config.GatewayFailoverClassConfig{
+		PriorityClassInteractive: {MaxAccountSwitches: 2, AttemptTimeoutSeconds: 30, HedgeAfterMs: 800},
+		PriorityClassBatch:       {MaxAccountSwitches: 20},
+	}
+
+	require.Equal(t, FailoverBudget{MaxAccountSwitches: 10}, ResolveFailoverBudget(classes, "", 10))
+	require.Equal(t, FailoverBudget{MaxAccountSwitches: 2, AttemptTimeout: 30 * time.Second, HedgeAfter: 800 * time.Millisecond}, ResolveFailoverBudget(classes, PriorityClassInteractive, 10))
+	require.Equal(t, FailoverBudget{MaxAccountSwitches: 20}, ResolveFailoverBudget(classes, PriorityClassBatch, 10))
+	// 类别未配置时回退到全局切换次数
+	require.Equal(t, FailoverBudget{MaxAccountSwitches: 3}, ResolveFailoverBudget(nil, PriorityClassBatch, 3))
+}
+
+func TestUpstreamAttemptTimeoutContext(t *testing.T) {
+	ctx := context.Background()
+	require.Zero(t, UpstreamAttemptTimeoutFromContext(ctx))
+	require.Equal(t, ctx, WithUpstreamAttemptTimeout(ctx, 0))
+
+	ctx = WithUpstreamAttemptTimeout(ctx, 5*time.Second)
+	require.Equal(t, 5*time.Second, UpstreamAttemptTimeoutFromContext(ctx))
+}
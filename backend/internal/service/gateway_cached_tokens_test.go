@@ -88,30 +88,15 @@ func TestStreamingReconcile_MessageStart(t *testing.T) {
 		}
 	}`
 
-	var event map[string]any
-	require.NoError(t, json.Unmarshal([]byte(eventJSON), &event))
-
-	eventType, _ := event["type"].(string)
-	require.Equal(t, "message_start", eventType)
+	require.Equal(t, "message_start", gjson.Get(eventJSON, "type").String())
 
 	// 模拟 processSSEEvent 中的 reconcile 逻辑
-	if msg, ok := event["message"].(map[string]any); ok {
-		if u, ok := msg["usage"].(map[string]any); ok {
-			reconcileCachedTokens(u)
-		}
-	}
+	data, changed := reconcileCachedTokensJSON(eventJSON, "message.usage")
+	require.True(t, changed)
 
-	// 验证 cache_read_input_tokens 已被填充
-	msg, ok := event["message"].(map[string]any)
-	require.True(t, ok)
-	usage, ok := msg["usage"].(map[string]any)
-	require.True(t, ok)
-	assert.Equal(t, float64(23), usage["cache_read_input_tokens"])
-
-	// 验证重新序列化后 JSON 也包含正确值
-	data, err := json.Marshal(event)
-	require.NoError(t, err)
-	assert.Equal(t, int64(23), gjson.GetBytes(data, "message.usage.cache_read_input_tokens").Int())
+	// 验证透传给客户端的 JSON 包含正确值，其余字段保持不变
+	assert.Equal(t, int64(23), gjson.Get(data, "message.usage.cache_read_input_tokens").Int())
+	assert.Equal(t, "kimi", gjson.Get(data, "message.model").String())
 }
 
 func TestStreamingReconcile_MessageStart_NativeClaude(t *testing.T) {
@@ -127,20 +112,9 @@ func TestStreamingReconcile_MessageStart_NativeClaude(t *testing.T) {
 		}
 	}`
 
-	var event map[string]any
-	require.NoError(t, json.Unmarshal([]byte(eventJSON), &event))
-
-	if msg, ok := event["message"].(map[string]any); ok {
-		if u, ok := msg["usage"].(map[string]any); ok {
-			reconcileCachedTokens(u)
-		}
-	}
-
-	msg, ok := event["message"].(map[string]any)
-	require.True(t, ok)
-	usage, ok := msg["usage"].(map[string]any)
-	require.True(t, ok)
-	assert.Equal(t, float64(30), usage["cache_read_input_tokens"])
+	data, changed := reconcileCachedTokensJSON(eventJSON, "message.usage")
+	assert.False(t, changed)
+	assert.Equal(t, eventJSON, data)
 }
 
 // ---------- 流式 message_delta 事件 reconcile 测试 ----------
@@ -156,17 +130,12 @@ func TestStreamingReconcile_MessageDelta(t *testing.T) {
 		}
 	}`
 
-	var event map[string]any
-	require.NoError(t, json.Unmarshal([]byte(eventJSON), &event))
-
-	eventType, _ := event["type"].(string)
-	require.Equal(t, "message_delta", eventType)
+	require.Equal(t, "message_delta", gjson.Get(eventJSON, "type").String())
 
 	// 模拟 processSSEEvent 中的 reconcile 逻辑
-	usage, ok := event["usage"].(map[string]any)
-	require.True(t, ok)
-	reconcileCachedTokens(usage)
-	assert.Equal(t, float64(15), usage["cache_read_input_tokens"])
+	data, changed := reconcileCachedTokensJSON(eventJSON, "usage")
+	require.True(t, changed)
+	assert.Equal(t, int64(15), gjson.Get(data, "usage.cache_read_input_tokens").Int())
 }
 
 func TestStreamingReconcile_MessageDelta_NativeClaude(t *testing.T) {
@@ -178,14 +147,9 @@ func TestStreamingReconcile_MessageDelta_NativeClaude(t *testing.T) {
 		}
 	}`
 
-	var event map[string]any
-	require.NoError(t, json.Unmarshal([]byte(eventJSON), &event))
-
-	usage, ok := event["usage"].(map[string]any)
-	require.True(t, ok)
-	reconcileCachedTokens(usage)
-	_, hasCacheRead := usage["cache_read_input_tokens"]
-	assert.False(t, hasCacheRead, "不应为原生 Claude 响应注入 cache_read_input_tokens")
+	data, changed := reconcileCachedTokensJSON(eventJSON, "usage")
+	assert.False(t, changed)
+	assert.False(t, gjson.Get(data, "usage.cache_read_input_tokens").Exists(), "不应为原生 Claude 响应注入 cache_read_input_tokens")
 }
 
 // ---------- 非流式响应 reconcile 测试 ----------
@@ -0,0 +1,243 @@
+package service
+
+import (
+	"context"
+	"io"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/Wei-Shaw/sub2api/internal/config"
+	"github.com/Wei-Shaw/sub2api/internal/pkg/metrics"
+)
+
+// gatewayMetricsGaugeTTL 槽位/等待队列 Gauge 的刷新间隔，避免高频抓取压垮 Redis/DB
+const gatewayMetricsGaugeTTL = 5 * time.Second
+
+// upstreamConnectBuckets 上游取连接耗时分桶：复用连接在毫秒级，新建连接（含 TLS 握手）通常在 50ms-1s
+var upstreamConnectBuckets = []float64{0.001, 0.005, 0.01, 0.025, 0.05, 0.1, 0.25, 0.5, 1, 2.5, 5}
+
+// 网关 Prometheus 指标（进程内累计，重启清零，由 Prometheus 负责 rate/increase 计算）
+var (
+	gatewayMetricsRegistry = metrics.NewRegistry()
+
+	gatewayRequestsTotal = gatewayMetricsRegistry.NewCounterVec(
+		"sub2api_gateway_requests_total",
+		"Gateway requests by platform, model, final account, group and HTTP status.",
+		"platform", "model", "account", "group", "status",
+	)
+	gatewayRequestDuration = gatewayMetricsRegistry.NewHistogramVec(
+		"sub2api_gateway_request_duration_seconds",
+		"End-to-end gateway request latency in seconds (including streaming).",
+		metrics.DefaultLatencyBuckets,
+		"platform", "model", "account", "group",
+	)
+	gatewayTokensTotal = gatewayMetricsRegistry.NewCounterVec(
+		"sub2api_gateway_tokens_total",
+		"Tokens recorded in usage logs by type (input, output, cache_creation, cache_read).",
+		"platform", "model", "account", "group", "type",
+	)
+	gatewayFailoversTotal = gatewayMetricsRegistry.NewCounterVec(
+		"sub2api_gateway_failovers_total",
+		"Account switches caused by upstream failover errors.",
+		"platform", "account", "group",
+	)
+	gatewayHedgesTotal = gatewayMetricsRegistry.NewCounterVec(
+		"sub2api_gateway_hedges_total",
+		"Hedged requests sent to a second account, by which attempt won (primary, hedge, none).",
+		"platform", "group", "winner",
+	)
+	gatewayPromptCacheRoutingTotal = gatewayMetricsRegistry.NewCounterVec(
+		"sub2api_prompt_cache_routing_total",
+		"Load-aware selections for sessions, by whether the account holding the warm prompt cache was chosen (warm, overloaded, cold).",
+		"platform", "group", "decision",
+	)
+	gatewayPromptCacheReadTokensTotal = gatewayMetricsRegistry.NewCounterVec(
+		"sub2api_prompt_cache_read_tokens_total",
+		"Cache-read input tokens on session requests, by whether the request landed on the previously warm account (warm, cold).",
+		"platform", "group", "routing",
+	)
+	upstreamConnectionsTotal = gatewayMetricsRegistry.NewCounterVec(
+		"sub2api_upstream_connections_total",
+		"Upstream connections obtained for requests by host, negotiated protocol (h2, http/1.1) and whether a pooled connection was reused.",
+		"host", "protocol", "reused",
+	)
+	upstreamConnectDuration = gatewayMetricsRegistry.NewHistogramVec(
+		"sub2api_upstream_connect_duration_seconds",
+		"Time spent obtaining an upstream connection in seconds (DNS, TCP and TLS setup when not reused).",
+		upstreamConnectBuckets,
+		"host", "reused",
+	)
+	upstreamClientPools = gatewayMetricsRegistry.NewGaugeVec(
+		"sub2api_upstream_client_pools",
+		"Upstream HTTP client connection pools cached on this instance.",
+	)
+	accountSlotsInUse = gatewayMetricsRegistry.NewGaugeVec(
+		"sub2api_account_concurrency_slots_in_use",
+		"Concurrency slots currently held per schedulable account.",
+		"platform", "account",
+	)
+	accountSlotCapacity = gatewayMetricsRegistry.NewGaugeVec(
+		"sub2api_account_concurrency_slots_capacity",
+		"Configured concurrency limit per schedulable account.",
+		"platform", "account",
+	)
+	accountWaitQueueDepth = gatewayMetricsRegistry.NewGaugeVec(
+		"sub2api_account_wait_queue_depth",
+		"Requests waiting for a concurrency slot per schedulable account.",
+		"platform", "account",
+	)
+	localWaitQueueDepth = gatewayMetricsRegistry.NewGaugeVec(
+		"sub2api_local_wait_queue_depth",
+		"Requests waiting for a concurrency slot on this instance.",
+	)
+)
+
+// ObserveGatewayRequest 记录一次网关请求的最终状态与耗时
+func ObserveGatewayRequest(platform, model string, accountID int64, groupID *int64, status int, elapsed time.Duration) {
+	account := metricsIDLabel(accountID)
+	group := metricsGroupLabel(groupID)
+	gatewayRequestsTotal.Inc(platform, model, account, group, strconv.Itoa(status))
+	gatewayRequestDuration.Observe(elapsed.Seconds(), platform, model, account, group)
+}
+
+// ObserveGatewayFailover 记录一次因上游错误触发的账号切换
+func ObserveGatewayFailover(account *Account, groupID *int64) {
+	if account == nil {
+		return
+	}
+	gatewayFailoversTotal.Inc(account.Platform, metricsIDLabel(account.ID), metricsGroupLabel(groupID))
+}
+
+// ObserveGatewayHedge 记录一次已发起的对冲请求及获胜方
+func ObserveGatewayHedge(platform string, groupID *int64, winner string) {
+	gatewayHedgesTotal.Inc(platform, metricsGroupLabel(groupID), winner)
+}
+
+// observePromptCacheRouting 记录一次会话请求的提示词缓存路由决策
+func observePromptCacheRouting(platform string, groupID *int64, decision string) {
+	gatewayPromptCacheRoutingTotal.Inc(platform, metricsGroupLabel(groupID), decision)
+}
+
+// ObserveUpstreamConnection 记录一次上游取连接：协商协议、是否复用连接池中的连接及耗时
+func ObserveUpstreamConnection(host, protocol string, reused bool, elapsed time.Duration) {
+	reusedLabel := strconv.FormatBool(reused)
+	upstreamConnectionsTotal.Inc(host, protocol, reusedLabel)
+	upstreamConnectDuration.Observe(elapsed.Seconds(), host, reusedLabel)
+}
+
+// SetUpstreamClientPools 更新当前缓存的上游客户端连接池数量
+func SetUpstreamClientPools(count int) {
+	upstreamClientPools.Set(float64(count))
+}
+
+// observeUsageTokens 按用量记录累计 token 数
+func observeUsageTokens(platform string, usageLog *UsageLog) {
+	if usageLog == nil {
+		return
+	}
+	account := metricsIDLabel(usageLog.AccountID)
+	group := metricsGroupLabel(usageLog.GroupID)
+	gatewayTokensTotal.Add(float64(usageLog.InputTokens), platform, usageLog.Model, account, group, "input")
+	gatewayTokensTotal.Add(float64(usageLog.OutputTokens), platform, usageLog.Model, account, group, "output")
+	gatewayTokensTotal.Add(float64(usageLog.CacheCreationTokens), platform, usageLog.Model, account, group, "cache_creation")
+	gatewayTokensTotal.Add(float64(usageLog.CacheReadTokens), platform, usageLog.Model, account, group, "cache_read")
+}
+
+func metricsIDLabel(id int64) string {
+	if id <= 0 {
+		return ""
+	}
+	return strconv.FormatInt(id, 10)
+}
+
+func metricsGroupLabel(groupID *int64) string {
+	if groupID == nil {
+		return ""
+	}
+	return metricsIDLabel(*groupID)
+}
+
+// GatewayMetricsService 输出 Prometheus 指标；抓取时刷新槽位占用与等待队列 Gauge
+type GatewayMetricsService struct {
+	cfg                *config.Config
+	accountRepo        AccountRepository
+	concurrencyService *ConcurrencyService
+
+	mu          sync.Mutex
+	refreshedAt time.Time
+}
+
+func NewGatewayMetricsService(cfg *config.Config, accountRepo AccountRepository, concurrencyService *ConcurrencyService) *GatewayMetricsService {
+	return &GatewayMetricsService{
+		cfg:                cfg,
+		accountRepo:        accountRepo,
+		concurrencyService: concurrencyService,
+	}
+}
+
+// Enabled 是否开放 /metrics
+func (s *GatewayMetricsService) Enabled() bool {
+	return s.cfg != nil && s.cfg.Metrics.Enabled
+}
+
+// AuthToken 抓取所需的 Bearer Token，为空表示不校验
+func (s *GatewayMetricsService) AuthToken() string {
+	if s.cfg == nil {
+		return ""
+	}
+	return s.cfg.Metrics.AuthToken
+}
+
+// WriteMetrics 刷新 Gauge 并以文本格式输出全部指标；Gauge 刷新失败时沿用上次的值
+func (s *GatewayMetricsService) WriteMetrics(ctx context.Context, w io.Writer) error {
+	s.refreshGauges(ctx)
+	return gatewayMetricsRegistry.WriteText(w)
+}
+
+func (s *GatewayMetricsService) refreshGauges(ctx context.Context) {
+	now := time.Now()
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.concurrencyService != nil {
+		localWaitQueueDepth.Set(float64(s.concurrencyService.LocalWaitingCount()))
+	}
+	if !s.refreshedAt.IsZero() && now.Sub(s.refreshedAt) < gatewayMetricsGaugeTTL {
+		return
+	}
+	if s.accountRepo == nil || s.concurrencyService == nil {
+		return
+	}
+
+	accounts, err := s.accountRepo.ListSchedulable(ctx)
+	if err != nil {
+		return
+	}
+	batch := make([]AccountWithConcurrency, 0, len(accounts))
+	for i := range accounts {
+		batch = append(batch, AccountWithConcurrency{ID: accounts[i].ID, MaxConcurrency: accounts[i].Concurrency})
+	}
+	loadMap, err := s.concurrencyService.GetAccountsLoadBatch(ctx, batch)
+	if err != nil {
+		return
+	}
+
+	accountSlotsInUse.Reset()
+	accountSlotCapacity.Reset()
+	accountWaitQueueDepth.Reset()
+	for i := range accounts {
+		account := metricsIDLabel(accounts[i].ID)
+		platform := accounts[i].Platform
+		accountSlotCapacity.Set(float64(accounts[i].Concurrency), platform, account)
+		var inUse, waiting int
+		if info := loadMap[accounts[i].ID]; info != nil {
+			inUse = info.CurrentConcurrency
+			waiting = info.WaitingCount
+		}
+		accountSlotsInUse.Set(float64(inUse), platform, account)
+		accountWaitQueueDepth.Set(float64(waiting), platform, account)
+	}
+	s.refreshedAt = now
+}
@@ -87,7 +87,7 @@ func (m *mockAccountRepoForPlatform) Delete(ctx context.Context, id int64) error
 func (m *mockAccountRepoForPlatform) List(ctx context.Context, params pagination.PaginationParams) ([]Account, *pagination.PaginationResult, error) {
 	return nil, nil, nil
 }
-func (m *mockAccountRepoForPlatform) ListWithFilters(ctx context.Context, params pagination.PaginationParams, platform, accountType, status, search string, groupID int64) ([]Account, *pagination.PaginationResult, error) {
+func (m *mockAccountRepoForPlatform) ListWithFilters(ctx context.Context, params pagination.PaginationParams, platform, accountType, status, search string, groupID int64, label string) ([]Account, *pagination.PaginationResult, error) {
 	return nil, nil, nil
 }
 func (m *mockAccountRepoForPlatform) ListByGroup(ctx context.Context, groupID int64) ([]Account, error) {
@@ -1782,12 +1782,15 @@ func (m *mockConcurrencyService) GetAccountWaitingCount(ctx context.Context, acc
 
 type mockConcurrencyCache struct {
 	acquireAccountCalls int
+	reserveAccountCalls int
 	loadBatchCalls      int
 	acquireResults      map[int64]bool
 	loadBatchErr        error
 	loadMap             map[int64]*AccountLoadInfo
 	waitCounts          map[int64]int
 	skipDefaultLoad     bool
+	// reserveCounts 模拟预占时观察到的最新并发数（大于快照值时预占失败）
+	reserveCounts map[int64]int
 }
 
 func (m *mockConcurrencyCache) AcquireAccountSlot(ctx context.Context, accountID int64, maxConcurrency int, requestID string) (bool, error) {
@@ -1800,6 +1803,21 @@ func (m *mockConcurrencyCache) AcquireAccountSlot(ctx context.Context, accountID
 	return true, nil
 }
 
+func (m *mockConcurrencyCache) ReserveAccountSlot(ctx context.Context, accountID int64, maxConcurrency, expectedConcurrency int, requestID string, ttl time.Duration) (bool, int, error) {
+	m.reserveAccountCalls++
+	if m.reserveCounts != nil {
+		if current, ok := m.reserveCounts[accountID]; ok && current > expectedConcurrency {
+			return false, current, nil
+		}
+	}
+	ok, err := m.AcquireAccountSlot(ctx, accountID, maxConcurrency, requestID)
+	return ok, expectedConcurrency + 1, err
+}
+
+func (m *mockConcurrencyCache) CommitAccountSlot(ctx context.Context, accountID int64, requestID string) (bool, error) {
+	return true, nil
+}
+
 func (m *mockConcurrencyCache) ReleaseAccountSlot(ctx context.Context, accountID int64, requestID string) error {
 	return nil
 }
@@ -2993,6 +3011,49 @@ func TestGatewayService_SelectAccountWithLoadAwareness(t *testing.T) {
 		require.NotNil(t, result.Account)
 		require.Equal(t, int64(2), result.Account.ID)
 	})
+
+	t.Run("两阶段预占-快照过期按最新负载重新排序", func(t *testing.T) {
+		repo := &mockAccountRepoForPlatform{
+			accounts: []Account{
+				{ID: 1, Platform: PlatformAnthropic, Priority: 1, Status: StatusActive, Schedulable: true, Concurrency: 10},
+				{ID: 2, Platform: PlatformAnthropic, Priority: 1, Status: StatusActive, Schedulable: true, Concurrency: 10},
+			},
+			accountsByID: map[int64]*Account{},
+		}
+		for i := range repo.accounts {
+			repo.accountsByID[repo.accounts[i].ID] = &repo.accounts[i]
+		}
+
+		cfg := testConfig()
+		cfg.Gateway.Scheduling.LoadBatchEnabled = true
+		cfg.Gateway.Scheduling.ReservationTTL = 30 * time.Second
+
+		// 快照中账号 1 最空闲，但预占时已被其他请求占到 5 个并发，应改选账号 2
+		concurrencyCache := &mockConcurrencyCache{
+			loadMap: map[int64]*AccountLoadInfo{
+				1: {AccountID: 1, CurrentConcurrency: 1, LoadRate: 10},
+				2: {AccountID: 2, CurrentConcurrency: 3, LoadRate: 30},
+			},
+			skipDefaultLoad: true,
+			reserveCounts:   map[int64]int{1: 5},
+		}
+
+		svc := &GatewayService{
+			accountRepo:        repo,
+			cache:              &mockGatewayCacheForPlatform{},
+			cfg:                cfg,
+			concurrencyService: NewConcurrencyService(concurrencyCache),
+		}
+
+		result, err := svc.SelectAccountWithLoadAwareness(ctx, nil, "", "claude-3-5-sonnet-20241022", nil, "")
+		require.NoError(t, err)
+		require.NotNil(t, result)
+		require.Equal(t, int64(2), result.Account.ID)
+		require.True(t, result.Acquired)
+		require.NotNil(t, result.Reservation)
+		require.NoError(t, result.Reservation.Commit(ctx))
+		require.Equal(t, 2, concurrencyCache.reserveAccountCalls)
+	})
 }
 
 func TestGatewayService_GroupResolution_ReusesContextGroup(t *testing.T) {
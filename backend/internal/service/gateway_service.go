@@ -225,10 +225,7 @@ func isClaudeCodeCredentialScopeError(msg string) bool {
 		strings.Contains(m, "cannot be used for other api requests")
 }
 
-// sseDataRe matches SSE data lines with optional whitespace after colon.
-// Some upstream APIs return non-standard "data:" without space (should be "data: ").
 var (
-	sseDataRe            = regexp.MustCompile(`^data:\s*`)
 	sessionIDRegex       = regexp.MustCompile(`session_([a-f0-9-]{36})`)
 	claudeCliUserAgentRe = regexp.MustCompile(`^claude-cli/\d+\.\d+\.\d+`)
 
@@ -303,20 +300,20 @@ func derefGroupID(groupID *int64) int64 {
 }
 
 // shouldClearStickySession 检查账号是否处于不可调度状态，需要清理粘性会话绑定。
-// 当账号状态为错误、禁用、不可调度、处于临时不可调度期间，
+// 当账号状态为错误、禁用、维护中、不可调度、处于临时不可调度期间，
 // 或请求的模型处于限流状态时，返回 true。
 // 这确保后续请求不会继续使用不可用的账号。
 //
 // shouldClearStickySession checks if an account is in an unschedulable state
 // and the sticky session binding should be cleared.
-// Returns true when account status is error/disabled, schedulable is false,
+// Returns true when account status is error/disabled/maintenance, schedulable is false,
 // within temporary unschedulable period, or the requested model is rate-limited.
 // This ensures subsequent requests won't continue using unavailable accounts.
 func shouldClearStickySession(account *Account, requestedModel string) bool {
 	if account == nil {
 		return false
 	}
-	if account.Status == StatusError || account.Status == StatusDisabled || !account.Schedulable {
+	if account.Status == StatusError || account.Status == StatusDisabled || account.Status == StatusMaintenance || !account.Schedulable {
 		return true
 	}
 	if account.TempUnschedulableUntil != nil && time.Now().Before(*account.TempUnschedulableUntil) {
@@ -341,6 +338,8 @@ type AccountSelectionResult struct {
 	Acquired    bool
 	ReleaseFunc func()
 	WaitPlan    *AccountWaitPlan // nil means no wait allowed
+	// Reservation 负载感知选择得到的短期预占，转发前需 Commit；nil 表示槽位已直接获取或需等待
+	Reservation *AccountReservation
 }
 
 // ClaudeUsage 表示Claude API返回的usage信息
@@ -351,6 +350,14 @@ type ClaudeUsage struct {
 	CacheReadInputTokens     int `json:"cache_read_input_tokens"`
 	CacheCreation5mTokens    int // 5分钟缓存创建token（来自嵌套 cache_creation 对象）
 	CacheCreation1hTokens    int // 1小时缓存创建token（来自嵌套 cache_creation 对象）
+	WebSearchRequests        int // 服务端 web 搜索调用次数（来自嵌套 server_tool_use 对象）
+	// StopReason 结束原因（来自响应的 stop_reason，不参与 usage JSON 序列化）
+	StopReason string `json:"-"`
+}
+
+// ToolUsage 返回 usage 中的内置工具调用次数
+func (u ClaudeUsage) ToolUsage() ToolUsage {
+	return ToolUsage{WebSearchCalls: u.WebSearchRequests}
 }
 
 // ForwardResult 转发结果
@@ -371,9 +378,10 @@ type ForwardResult struct {
 // UpstreamFailoverError indicates an upstream error that should trigger account failover.
 type UpstreamFailoverError struct {
 	StatusCode             int
-	ResponseBody           []byte // 上游响应体，用于错误透传规则匹配
-	ForceCacheBilling      bool   // Antigravity 粘性会话切换时设为 true
-	RetryableOnSameAccount bool   // 临时性错误（如 Google 间歇性 400、空响应），应在同一账号上重试 N 次再切换
+	ResponseBody           []byte      // 上游响应体，用于错误透传规则匹配
+	ResponseHeaders        http.Header // 上游响应头，用于错误透传规则的响应头匹配
+	ForceCacheBilling      bool        // Antigravity 粘性会话切换时设为 true
+	RetryableOnSameAccount bool        // 临时性错误（如 Google 间歇性 400、空响应），应在同一账号上重试 N 次再切换
 }
 
 func (e *UpstreamFailoverError) Error() string {
@@ -416,6 +424,9 @@ type GatewayService struct {
 	concurrencyService  *ConcurrencyService
 	claudeTokenProvider *ClaudeTokenProvider
 	sessionLimitCache   SessionLimitCache // 会话数量限制缓存（仅 Anthropic OAuth/SetupToken）
+	budgetAlertService  *BudgetAlertService
+	usageRetryService   *UsageRetryService
+	memoryGuard         *MemoryGuard
 }
 
 // NewGatewayService creates a new GatewayService
@@ -439,6 +450,9 @@ func NewGatewayService(
 	claudeTokenProvider *ClaudeTokenProvider,
 	sessionLimitCache SessionLimitCache,
 	digestStore *DigestSessionStore,
+	budgetAlertService *BudgetAlertService,
+	usageRetryService *UsageRetryService,
+	memoryGuard *MemoryGuard,
 ) *GatewayService {
 	return &GatewayService{
 		accountRepo:         accountRepo,
@@ -460,9 +474,17 @@ func NewGatewayService(
 		deferredService:     deferredService,
 		claudeTokenProvider: claudeTokenProvider,
 		sessionLimitCache:   sessionLimitCache,
+		budgetAlertService:  budgetAlertService,
+		usageRetryService:   usageRetryService,
+		memoryGuard:         memoryGuard,
 	}
 }
 
+// MemoryGuard 返回网关内存护栏（未启用时为 nil）
+func (s *GatewayService) MemoryGuard() *MemoryGuard {
+	return s.memoryGuard
+}
+
 // GenerateSessionHash 从预解析请求计算粘性会话 hash
 func (s *GatewayService) GenerateSessionHash(parsed *ParsedRequest) string {
 	if parsed == nil {
@@ -918,6 +940,13 @@ func (s *GatewayService) SelectAccountForModelWithExclusions(ctx context.Context
 // SelectAccountWithLoadAwareness selects account with load-awareness and wait plan.
 // metadataUserID: 已废弃参数，会话限制现在统一使用 sessionHash
 func (s *GatewayService) SelectAccountWithLoadAwareness(ctx context.Context, groupID *int64, sessionHash string, requestedModel string, excludedIDs map[int64]struct{}, metadataUserID string) (*AccountSelectionResult, error) {
+	ctx, span := startAccountSelectionSpan(ctx, "", groupID, requestedModel, excludedIDs)
+	result, err := s.selectAccountWithLoadAwareness(ctx, groupID, sessionHash, requestedModel, excludedIDs, metadataUserID)
+	endAccountSelectionSpan(span, result, err)
+	return result, err
+}
+
+func (s *GatewayService) selectAccountWithLoadAwareness(ctx context.Context, groupID *int64, sessionHash string, requestedModel string, excludedIDs map[int64]struct{}, metadataUserID string) (*AccountSelectionResult, error) {
 	// 调试日志：记录调度入口参数
 	excludedIDsList := make([]int64, 0, len(excludedIDs))
 	for id := range excludedIDs {
@@ -1079,7 +1108,7 @@ func (s *GatewayService) SelectAccountWithLoadAwareness(ctx context.Context, gro
 				continue
 			}
 			account, ok := accountByID[routingAccountID]
-			if !ok || !account.IsSchedulable() {
+			if !ok || !account.IsSchedulable() || s.rateLimitService.IsAccountCoolingDown(routingAccountID) {
 				if !ok {
 					filteredMissing++
 				} else {
@@ -1122,7 +1151,7 @@ func (s *GatewayService) SelectAccountWithLoadAwareness(ctx context.Context, gro
 			// 1.5. 在路由账号范围内检查粘性会话
 			if sessionHash != "" && s.cache != nil {
 				stickyAccountID, err := s.cache.GetSessionAccountID(ctx, derefGroupID(groupID), sessionHash)
-				if err == nil && stickyAccountID > 0 && containsInt64(routingAccountIDs, stickyAccountID) && !isExcluded(stickyAccountID) {
+				if err == nil && stickyAccountID > 0 && containsInt64(routingAccountIDs, stickyAccountID) && !isExcluded(stickyAccountID) && !s.rateLimitService.IsAccountCoolingDown(stickyAccountID) {
 					// 粘性账号在路由列表中，优先使用
 					if stickyAccount, ok := accountByID[stickyAccountID]; ok {
 						if stickyAccount.IsSchedulable() &&
@@ -1196,11 +1225,11 @@ func (s *GatewayService) SelectAccountWithLoadAwareness(ctx context.Context, gro
 			}
 
 			if len(routingAvailable) > 0 {
-				// 排序：优先级 > 负载率 > 最后使用时间
+				// 排序：层级 > 优先级 > 负载率 > 最后使用时间
 				sort.SliceStable(routingAvailable, func(i, j int) bool {
 					a, b := routingAvailable[i], routingAvailable[j]
-					if a.account.Priority != b.account.Priority {
-						return a.account.Priority < b.account.Priority
+					if c := compareAccountPriority(a.account, b.account); c != 0 {
+						return c < 0
 					}
 					if a.loadInfo.LoadRate != b.loadInfo.LoadRate {
 						return a.loadInfo.LoadRate < b.loadInfo.LoadRate
@@ -1270,7 +1299,7 @@ func (s *GatewayService) SelectAccountWithLoadAwareness(ctx context.Context, gro
 	// ============ Layer 1.5: 粘性会话（仅在无模型路由配置时生效） ============
 	if len(routingAccountIDs) == 0 && sessionHash != "" && s.cache != nil {
 		accountID, err := s.cache.GetSessionAccountID(ctx, derefGroupID(groupID), sessionHash)
-		if err == nil && accountID > 0 && !isExcluded(accountID) {
+		if err == nil && accountID > 0 && !isExcluded(accountID) && !s.rateLimitService.IsAccountCoolingDown(accountID) {
 			account, ok := accountByID[accountID]
 			if ok {
 				// 检查账户是否需要清理粘性会话绑定
@@ -1332,8 +1361,9 @@ func (s *GatewayService) SelectAccountWithLoadAwareness(ctx context.Context, gro
 		}
 		// Scheduler snapshots can be temporarily stale (bucket rebuild is throttled);
 		// re-check schedulability here so recently rate-limited/overloaded accounts
-		// are not selected again before the bucket is rebuilt.
-		if !acc.IsSchedulable() {
+		// are not selected again before the bucket is rebuilt. The in-process cooldown
+		// covers the window before the rate-limit state has even reached the database.
+		if !acc.IsSchedulable() || s.rateLimitService.IsAccountCoolingDown(acc.ID) {
 			continue
 		}
 		if !s.isAccountAllowedForPlatform(acc, platform, useMixed) {
@@ -1384,20 +1414,49 @@ func (s *GatewayService) SelectAccountWithLoadAwareness(ctx context.Context, gro
 			}
 		}
 
-		// 分层过滤选择：优先级 → 负载率 → LRU
+		// 分层过滤选择：层级/优先级 → 负载率 → LRU（或一致性哈希）
+		// 负载已满（LoadRate >= 100）的账号不在 available 中，高层级占满后自然溢出到下一层级
+		// 新会话启用一致性哈希时，同一优先级内按哈希环放置（不再按负载/LRU），账号池增减只重映射少量会话
+		var ring *sessionHashRing
+		if sessionHash != "" && cfg.SessionConsistentHash {
+			ring = getSessionHashRing(candidates)
+		}
+		// 持有会话预热缓存的账号在负载差距可接受时优先尝试（仅首轮）
+		var warm *accountWithLoad
+		if sessionHash != "" && cfg.PromptCacheAffinity {
+			warmAccountID := lookupWarmCacheAccount(ctx, s.cache, cfg, groupID, sessionHash)
+			var decision string
+			warm, decision = pickWarmCacheAccount(available, warmAccountID, cfg.PromptCacheMaxLoadGap)
+			observePromptCacheRouting(platform, groupID, decision)
+		}
+		staleRetries := 0
 		for len(available) > 0 {
-			// 1. 取优先级最小的集合
+			// 1. 取层级与优先级最小的集合
 			candidates := filterByMinPriority(available)
-			// 2. 取负载率最低的集合
-			candidates = filterByMinLoadRate(candidates)
-			// 3. LRU 选择最久未用的账号
-			selected := selectByLRU(candidates, preferOAuth)
+			var selected *accountWithLoad
+			if warm != nil {
+				selected, warm = warm, nil
+			} else if ring != nil {
+				selected = ring.pick(sessionHash, candidates)
+			} else {
+				// 2. 取负载率最低的集合
+				candidates = filterByMinLoadRate(candidates)
+				// 3. LRU 选择最久未用的账号
+				selected = selectByLRU(candidates, preferOAuth)
+			}
 			if selected == nil {
 				break
 			}
 
-			result, err := s.tryAcquireAccountSlot(ctx, selected.account.ID, selected.account.Concurrency)
-			if err == nil && result.Acquired {
+			// 两阶段选择：按快照预占槽位，快照已过期时按最新负载重新排序
+			result, stale := reserveAccountWithLoad(ctx, s.concurrencyService, cfg.ReservationTTL, *selected)
+			if stale && staleRetries < accountReservationMaxStaleRetries {
+				staleRetries++
+				if selected.loadInfo.LoadRate < 100 {
+					continue
+				}
+			}
+			if result != nil {
 				// 会话数量限制检查
 				if !s.checkAndRegisterSession(ctx, selected.account, sessionHash) {
 					result.ReleaseFunc() // 释放槽位，继续尝试下一个账号
@@ -1405,11 +1464,7 @@ func (s *GatewayService) SelectAccountWithLoadAwareness(ctx context.Context, gro
 					if sessionHash != "" && s.cache != nil {
 						_ = s.cache.SetSessionAccountID(ctx, derefGroupID(groupID), sessionHash, selected.account.ID, stickySessionTTL)
 					}
-					return &AccountSelectionResult{
-						Account:     selected.account,
-						Acquired:    true,
-						ReleaseFunc: result.ReleaseFunc,
-					}, nil
+					return result, nil
 				}
 			}
 
@@ -1631,7 +1686,7 @@ func (s *GatewayService) listSchedulableAccounts(ctx context.Context, groupID *i
 					"tls_fingerprint", acc.IsTLSFingerprintEnabled())
 			}
 		}
-		return accounts, useMixed, err
+		return filterCanaryAccounts(filterAccountsByVirtualModel(ctx, filterAccountsByRegionPolicy(ctx, accounts))), useMixed, err
 	}
 	useMixed := (platform == PlatformAnthropic || platform == PlatformGemini) && !hasForcePlatform
 	if useMixed {
@@ -1671,7 +1726,7 @@ func (s *GatewayService) listSchedulableAccounts(ctx context.Context, groupID *i
 				"status", acc.Status,
 				"tls_fingerprint", acc.IsTLSFingerprintEnabled())
 		}
-		return filtered, useMixed, nil
+		return filterCanaryAccounts(filterAccountsByVirtualModel(ctx, filterAccountsByRegionPolicy(ctx, filtered))), useMixed, nil
 	}
 
 	var accounts []Account
@@ -1704,7 +1759,7 @@ func (s *GatewayService) listSchedulableAccounts(ctx context.Context, groupID *i
 			"status", acc.Status,
 			"tls_fingerprint", acc.IsTLSFingerprintEnabled())
 	}
-	return accounts, useMixed, nil
+	return filterCanaryAccounts(filterAccountsByVirtualModel(ctx, filterAccountsByRegionPolicy(ctx, accounts))), useMixed, nil
 }
 
 // IsSingleAntigravityAccountGroup 检查指定分组是否只有一个 antigravity 平台的可调度账号。
@@ -1842,26 +1897,42 @@ func (s *GatewayService) checkAndRegisterSession(ctx context.Context, account *A
 }
 
 func (s *GatewayService) getSchedulableAccount(ctx context.Context, accountID int64) (*Account, error) {
+	var (
+		account *Account
+		err     error
+	)
 	if s.schedulerSnapshot != nil {
-		return s.schedulerSnapshot.GetAccount(ctx, accountID)
+		account, err = s.schedulerSnapshot.GetAccount(ctx, accountID)
+	} else {
+		account, err = s.accountRepo.GetByID(ctx, accountID)
+	}
+	if err != nil {
+		return nil, err
+	}
+	// 粘性会话绑定的账号同样需要满足区域约束与虚拟模型账号范围
+	if !accountAllowedByRegionPolicy(ctx, account) {
+		return nil, ErrAccountRegionNotAllowed
 	}
-	return s.accountRepo.GetByID(ctx, accountID)
+	if !accountAllowedByVirtualModel(ctx, account) {
+		return nil, ErrAccountNotInVirtualModelTarget
+	}
+	return account, nil
 }
 
-// filterByMinPriority 过滤出优先级最小的账号集合
+// filterByMinPriority 过滤出层级与优先级最小的账号集合
 func filterByMinPriority(accounts []accountWithLoad) []accountWithLoad {
 	if len(accounts) == 0 {
 		return accounts
 	}
-	minPriority := accounts[0].account.Priority
+	best := accounts[0].account
 	for _, acc := range accounts[1:] {
-		if acc.account.Priority < minPriority {
-			minPriority = acc.account.Priority
+		if compareAccountPriority(acc.account, best) < 0 {
+			best = acc.account
 		}
 	}
 	result := make([]accountWithLoad, 0, len(accounts))
 	for _, acc := range accounts {
-		if acc.account.Priority == minPriority {
+		if compareAccountPriority(acc.account, best) == 0 {
 			result = append(result, acc)
 		}
 	}
@@ -1951,8 +2022,8 @@ func selectByLRU(accounts []accountWithLoad, preferOAuth bool) *accountWithLoad
 func sortAccountsByPriorityAndLastUsed(accounts []*Account, preferOAuth bool) {
 	sort.SliceStable(accounts, func(i, j int) bool {
 		a, b := accounts[i], accounts[j]
-		if a.Priority != b.Priority {
-			return a.Priority < b.Priority
+		if c := compareAccountPriority(a, b); c != 0 {
+			return c < 0
 		}
 		switch {
 		case a.LastUsedAt == nil && b.LastUsedAt != nil:
@@ -1994,7 +2065,7 @@ func shuffleWithinSortGroups(accounts []accountWithLoad) {
 
 // sameAccountWithLoadGroup 判断两个 accountWithLoad 是否属于同一排序组
 func sameAccountWithLoadGroup(a, b accountWithLoad) bool {
-	if a.account.Priority != b.account.Priority {
+	if compareAccountPriority(a.account, b.account) != 0 {
 		return false
 	}
 	if a.loadInfo.LoadRate != b.loadInfo.LoadRate {
@@ -2023,9 +2094,9 @@ func shuffleWithinPriorityAndLastUsed(accounts []*Account) {
 	}
 }
 
-// sameAccountGroup 判断两个 Account 是否属于同一排序组（Priority + LastUsedAt）
+// sameAccountGroup 判断两个 Account 是否属于同一排序组（Tier + Priority + LastUsedAt）
 func sameAccountGroup(a, b *Account) bool {
-	if a.Priority != b.Priority {
+	if compareAccountPriority(a, b) != 0 {
 		return false
 	}
 	return sameLastUsedAt(a.LastUsedAt, b.LastUsedAt)
@@ -2060,8 +2131,8 @@ func (s *GatewayService) sortCandidatesForFallback(accounts []*Account, preferOA
 func sortAccountsByPriorityOnly(accounts []*Account, preferOAuth bool) {
 	sort.SliceStable(accounts, func(i, j int) bool {
 		a, b := accounts[i], accounts[j]
-		if a.Priority != b.Priority {
-			return a.Priority < b.Priority
+		if c := compareAccountPriority(a, b); c != 0 {
+			return c < 0
 		}
 		if preferOAuth && a.Type != b.Type {
 			return a.Type == AccountTypeOAuth
@@ -2078,9 +2149,8 @@ func shuffleWithinPriority(accounts []*Account) {
 	r := mathrand.New(mathrand.NewSource(time.Now().UnixNano()))
 	start := 0
 	for start < len(accounts) {
-		priority := accounts[start].Priority
 		end := start + 1
-		for end < len(accounts) && accounts[end].Priority == priority {
+		for end < len(accounts) && compareAccountPriority(accounts[end], accounts[start]) == 0 {
 			end++
 		}
 		// 对 [start, end) 范围内的账户随机打乱
@@ -2175,9 +2245,9 @@ func (s *GatewayService) selectAccountForModelWithPlatform(ctx context.Context,
 				selected = acc
 				continue
 			}
-			if acc.Priority < selected.Priority {
+			if c := compareAccountPriority(acc, selected); c < 0 {
 				selected = acc
-			} else if acc.Priority == selected.Priority {
+			} else if c == 0 {
 				switch {
 				case acc.LastUsedAt == nil && selected.LastUsedAt != nil:
 					selected = acc
@@ -2264,9 +2334,9 @@ func (s *GatewayService) selectAccountForModelWithPlatform(ctx context.Context,
 			selected = acc
 			continue
 		}
-		if acc.Priority < selected.Priority {
+		if c := compareAccountPriority(acc, selected); c < 0 {
 			selected = acc
-		} else if acc.Priority == selected.Priority {
+		} else if c == 0 {
 			switch {
 			case acc.LastUsedAt == nil && selected.LastUsedAt != nil:
 				selected = acc
@@ -2384,9 +2454,9 @@ func (s *GatewayService) selectAccountWithMixedScheduling(ctx context.Context, g
 				selected = acc
 				continue
 			}
-			if acc.Priority < selected.Priority {
+			if c := compareAccountPriority(acc, selected); c < 0 {
 				selected = acc
-			} else if acc.Priority == selected.Priority {
+			} else if c == 0 {
 				switch {
 				case acc.LastUsedAt == nil && selected.LastUsedAt != nil:
 					selected = acc
@@ -2475,9 +2545,9 @@ func (s *GatewayService) selectAccountWithMixedScheduling(ctx context.Context, g
 			selected = acc
 			continue
 		}
-		if acc.Priority < selected.Priority {
+		if c := compareAccountPriority(acc, selected); c < 0 {
 			selected = acc
-		} else if acc.Priority == selected.Priority {
+		} else if c == 0 {
 			switch {
 			case acc.LastUsedAt == nil && selected.LastUsedAt != nil:
 				selected = acc
@@ -2513,8 +2583,12 @@ func (s *GatewayService) selectAccountWithMixedScheduling(ctx context.Context, g
 }
 
 // isModelSupportedByAccountWithContext 根据账户平台检查模型支持（带 context）
-// 对于 Antigravity 平台，会先获取映射后的最终模型名（包括 thinking 后缀）再检查支持
+// 对于 Antigravity 平台，会先获取映射后的最终模型名（包括 thinking 后缀）再检查支持；
+// 账号显式声明不支持请求所需能力（图片/工具/长上下文/流式）时同样视为不支持，避免上游 400 后再故障转移
 func (s *GatewayService) isModelSupportedByAccountWithContext(ctx context.Context, account *Account, requestedModel string) bool {
+	if !account.SupportsRequestFeatures(RequestFeaturesFromContext(ctx)) {
+		return false
+	}
 	if account.Platform == PlatformAntigravity {
 		if strings.TrimSpace(requestedModel) == "" {
 			return true
@@ -2549,9 +2623,9 @@ func (s *GatewayService) isModelSupportedByAccount(account *Account, requestedMo
 	if account.Platform == PlatformAnthropic && account.Type != AccountTypeAPIKey {
 		requestedModel = claude.NormalizeModelID(requestedModel)
 	}
-	// Gemini API Key 账户直接透传，由上游判断模型是否支持
+	// Gemini API Key 账户直接透传，由上游判断模型是否支持（已自动发现模型列表时按列表过滤）
 	if account.Platform == PlatformGemini && account.Type == AccountTypeAPIKey {
-		return true
+		return account.IsModelDiscovered(requestedModel)
 	}
 	// 其他平台使用账户的模型支持检查
 	return account.IsModelSupported(requestedModel)
@@ -3006,6 +3080,10 @@ func removeCacheControlFromThinkingBlocks(data map[string]any) {
 
 // Forward 转发请求到Claude API
 func (s *GatewayService) Forward(ctx context.Context, c *gin.Context, account *Account, parsed *ParsedRequest) (*ForwardResult, error) {
+	// 账号配置了 mTLS 客户端证书 / 自定义 CA 时，由 HTTP 上游据此建立连接
+	ctx = WithAccountUpstreamTLS(ctx, account)
+	// 分阶段超时（连接/首 token/分片间隔/总时长）由 HTTP 上游执行
+	ctx = WithUpstreamTimeoutBudget(ctx, NewUpstreamTimeoutBudget(s.cfg))
 	startTime := time.Now()
 	if parsed == nil {
 		return nil, fmt.Errorf("parse request: empty request")
@@ -3109,18 +3187,27 @@ func (s *GatewayService) Forward(ctx context.Context, c *gin.Context, account *A
 			if resp != nil && resp.Body != nil {
 				_ = resp.Body.Close()
 			}
+			// 连接/首 token/单次尝试超时（尚未向客户端输出）：交由 handler 切换账号
+			if failoverErr := upstreamTimeoutFailover(err); failoverErr != nil {
+				return nil, failoverErr
+			}
+			// 账号 worker 池饱和：该账号上游调用已占满，切换到其他账号
+			if errors.Is(err, ErrAccountWorkerPoolSaturated) {
+				return nil, &UpstreamFailoverError{StatusCode: http.StatusServiceUnavailable}
+			}
 			// Ensure the client receives an error response (handlers assume Forward writes on non-failover errors).
 			safeErr := sanitizeUpstreamErrorMessage(err.Error())
+			errKind, errStatus := upstreamRequestErrorKind(err)
 			setOpsUpstreamError(c, 0, safeErr, "")
 			appendOpsUpstreamError(c, OpsUpstreamErrorEvent{
 				Platform:           account.Platform,
 				AccountID:          account.ID,
 				AccountName:        account.Name,
 				UpstreamStatusCode: 0,
-				Kind:               "request_error",
+				Kind:               errKind,
 				Message:            safeErr,
 			})
-			c.JSON(http.StatusBadGateway, gin.H{
+			c.JSON(errStatus, gin.H{
 				"type": "error",
 				"error": gin.H{
 					"type":    "upstream_error",
@@ -3146,8 +3233,8 @@ func (s *GatewayService) Forward(ctx context.Context, c *gin.Context, account *A
 						Kind:               "signature_error",
 						Message:            extractUpstreamErrorMessage(respBody),
 						Detail: func() string {
-							if s.cfg != nil && s.cfg.Gateway.LogUpstreamErrorBody {
-								return truncateString(string(respBody), s.cfg.Gateway.LogUpstreamErrorBodyMaxBytes)
+							if s.cfg != nil && s.cfg.Live().Gateway.LogUpstreamErrorBody {
+								return truncateString(string(respBody), s.cfg.Live().Gateway.LogUpstreamErrorBodyMaxBytes)
 							}
 							return ""
 						}(),
@@ -3198,8 +3285,8 @@ func (s *GatewayService) Forward(ctx context.Context, c *gin.Context, account *A
 									Kind:               "signature_retry_thinking",
 									Message:            extractUpstreamErrorMessage(retryRespBody),
 									Detail: func() string {
-										if s.cfg != nil && s.cfg.Gateway.LogUpstreamErrorBody {
-											return truncateString(string(retryRespBody), s.cfg.Gateway.LogUpstreamErrorBodyMaxBytes)
+										if s.cfg != nil && s.cfg.Live().Gateway.LogUpstreamErrorBody {
+											return truncateString(string(retryRespBody), s.cfg.Live().Gateway.LogUpstreamErrorBodyMaxBytes)
 										}
 										return ""
 									}(),
@@ -3286,8 +3373,8 @@ func (s *GatewayService) Forward(ctx context.Context, c *gin.Context, account *A
 					Kind:               "retry",
 					Message:            extractUpstreamErrorMessage(respBody),
 					Detail: func() string {
-						if s.cfg != nil && s.cfg.Gateway.LogUpstreamErrorBody {
-							return truncateString(string(respBody), s.cfg.Gateway.LogUpstreamErrorBodyMaxBytes)
+						if s.cfg != nil && s.cfg.Live().Gateway.LogUpstreamErrorBody {
+							return truncateString(string(respBody), s.cfg.Live().Gateway.LogUpstreamErrorBodyMaxBytes)
 						}
 						return ""
 					}(),
@@ -3339,13 +3426,13 @@ func (s *GatewayService) Forward(ctx context.Context, c *gin.Context, account *A
 				Kind:               "retry_exhausted_failover",
 				Message:            extractUpstreamErrorMessage(respBody),
 				Detail: func() string {
-					if s.cfg != nil && s.cfg.Gateway.LogUpstreamErrorBody {
-						return truncateString(string(respBody), s.cfg.Gateway.LogUpstreamErrorBodyMaxBytes)
+					if s.cfg != nil && s.cfg.Live().Gateway.LogUpstreamErrorBody {
+						return truncateString(string(respBody), s.cfg.Live().Gateway.LogUpstreamErrorBodyMaxBytes)
 					}
 					return ""
 				}(),
 			})
-			return nil, &UpstreamFailoverError{StatusCode: resp.StatusCode, ResponseBody: respBody}
+			return nil, &UpstreamFailoverError{StatusCode: resp.StatusCode, ResponseBody: respBody, ResponseHeaders: resp.Header}
 		}
 		return s.handleRetryExhaustedError(ctx, resp, c, account)
 	}
@@ -3369,17 +3456,17 @@ func (s *GatewayService) Forward(ctx context.Context, c *gin.Context, account *A
 			Kind:               "failover",
 			Message:            extractUpstreamErrorMessage(respBody),
 			Detail: func() string {
-				if s.cfg != nil && s.cfg.Gateway.LogUpstreamErrorBody {
-					return truncateString(string(respBody), s.cfg.Gateway.LogUpstreamErrorBodyMaxBytes)
+				if s.cfg != nil && s.cfg.Live().Gateway.LogUpstreamErrorBody {
+					return truncateString(string(respBody), s.cfg.Live().Gateway.LogUpstreamErrorBodyMaxBytes)
 				}
 				return ""
 			}(),
 		})
-		return nil, &UpstreamFailoverError{StatusCode: resp.StatusCode, ResponseBody: respBody}
+		return nil, &UpstreamFailoverError{StatusCode: resp.StatusCode, ResponseBody: respBody, ResponseHeaders: resp.Header}
 	}
 	if resp.StatusCode >= 400 {
 		// 可选：对部分 400 触发 failover（默认关闭以保持语义）
-		if resp.StatusCode == 400 && s.cfg != nil && s.cfg.Gateway.FailoverOn400 {
+		if resp.StatusCode == 400 && s.cfg != nil && s.cfg.Live().Gateway.FailoverOn400 {
 			respBody, readErr := io.ReadAll(io.LimitReader(resp.Body, 2<<20))
 			if readErr != nil {
 				// ReadAll failed, fall back to normal error handling without consuming the stream
@@ -3392,8 +3479,8 @@ func (s *GatewayService) Forward(ctx context.Context, c *gin.Context, account *A
 				upstreamMsg := strings.TrimSpace(extractUpstreamErrorMessage(respBody))
 				upstreamMsg = sanitizeUpstreamErrorMessage(upstreamMsg)
 				upstreamDetail := ""
-				if s.cfg != nil && s.cfg.Gateway.LogUpstreamErrorBody {
-					maxBytes := s.cfg.Gateway.LogUpstreamErrorBodyMaxBytes
+				if s.cfg != nil && s.cfg.Live().Gateway.LogUpstreamErrorBody {
+					maxBytes := s.cfg.Live().Gateway.LogUpstreamErrorBodyMaxBytes
 					if maxBytes <= 0 {
 						maxBytes = 2048
 					}
@@ -3410,17 +3497,17 @@ func (s *GatewayService) Forward(ctx context.Context, c *gin.Context, account *A
 					Detail:             upstreamDetail,
 				})
 
-				if s.cfg.Gateway.LogUpstreamErrorBody {
+				if s.cfg.Live().Gateway.LogUpstreamErrorBody {
 					log.Printf(
 						"Account %d: 400 error, attempting failover: %s",
 						account.ID,
-						truncateForLog(respBody, s.cfg.Gateway.LogUpstreamErrorBodyMaxBytes),
+						truncateForLog(respBody, s.cfg.Live().Gateway.LogUpstreamErrorBodyMaxBytes),
 					)
 				} else {
 					log.Printf("Account %d: 400 error, attempting failover", account.ID)
 				}
 				s.handleFailoverSideEffects(ctx, resp, account)
-				return nil, &UpstreamFailoverError{StatusCode: resp.StatusCode, ResponseBody: respBody}
+				return nil, &UpstreamFailoverError{StatusCode: resp.StatusCode, ResponseBody: respBody, ResponseHeaders: resp.Header}
 			}
 		}
 		return s.handleErrorResponse(ctx, resp, c, account)
@@ -3560,7 +3647,7 @@ func (s *GatewayService) buildUpstreamRequest(ctx context.Context, c *gin.Contex
 			clientBetaHeader := req.Header.Get("anthropic-beta")
 			req.Header.Set("anthropic-beta", s.getBetaHeader(modelID, clientBetaHeader))
 		}
-	} else if s.cfg != nil && s.cfg.Gateway.InjectBetaForAPIKey && req.Header.Get("anthropic-beta") == "" {
+	} else if s.cfg != nil && s.cfg.Live().Gateway.InjectBetaForAPIKey && req.Header.Get("anthropic-beta") == "" {
 		// API-key：仅在请求显式使用 beta 特性且客户端未提供时，按需补齐（默认关闭）
 		if requestNeedsBetaFeatures(body) {
 			if beta := defaultAPIKeyBetaHeader(body); beta != "" {
@@ -3867,8 +3954,8 @@ func (s *GatewayService) handleErrorResponse(ctx context.Context, resp *http.Res
 
 	// Enrich Ops error logs with upstream status + message, and optionally a truncated body snippet.
 	upstreamDetail := ""
-	if s.cfg != nil && s.cfg.Gateway.LogUpstreamErrorBody {
-		maxBytes := s.cfg.Gateway.LogUpstreamErrorBodyMaxBytes
+	if s.cfg != nil && s.cfg.Live().Gateway.LogUpstreamErrorBody {
+		maxBytes := s.cfg.Live().Gateway.LogUpstreamErrorBodyMaxBytes
 		if maxBytes <= 0 {
 			maxBytes = 2048
 		}
@@ -3891,18 +3978,18 @@ func (s *GatewayService) handleErrorResponse(ctx context.Context, resp *http.Res
 		shouldDisable = s.rateLimitService.HandleUpstreamError(ctx, account, resp.StatusCode, resp.Header, body)
 	}
 	if shouldDisable {
-		return nil, &UpstreamFailoverError{StatusCode: resp.StatusCode, ResponseBody: body}
+		return nil, &UpstreamFailoverError{StatusCode: resp.StatusCode, ResponseBody: body, ResponseHeaders: resp.Header}
 	}
 
 	// 记录上游错误响应体摘要便于排障（可选：由配置控制；不回显到客户端）
-	if s.cfg != nil && s.cfg.Gateway.LogUpstreamErrorBody {
+	if s.cfg != nil && s.cfg.Live().Gateway.LogUpstreamErrorBody {
 		log.Printf(
 			"Upstream error %d (account=%d platform=%s type=%s): %s",
 			resp.StatusCode,
 			account.ID,
 			account.Platform,
 			account.Type,
-			truncateForLog(body, s.cfg.Gateway.LogUpstreamErrorBodyMaxBytes),
+			truncateForLog(body, s.cfg.Live().Gateway.LogUpstreamErrorBodyMaxBytes),
 		)
 	}
 
@@ -3911,6 +3998,7 @@ func (s *GatewayService) handleErrorResponse(ctx context.Context, resp *http.Res
 		c,
 		account.Platform,
 		resp.StatusCode,
+		resp.Header,
 		body,
 		http.StatusBadGateway,
 		"upstream_error",
@@ -3975,13 +4063,17 @@ func (s *GatewayService) handleErrorResponse(ctx context.Context, resp *http.Res
 		errMsg = "Upstream request failed"
 	}
 
-	// 返回自定义错误响应
+	// 返回自定义错误响应（API Key 开启调试模式时附带脱敏后的上游错误详情）
+	errorBody := gin.H{
+		"type":    errType,
+		"message": errMsg,
+	}
+	if debug := BuildUpstreamErrorDebug(c, resp.StatusCode, body); debug != nil {
+		errorBody["upstream"] = debug
+	}
 	c.JSON(statusCode, gin.H{
-		"type": "error",
-		"error": gin.H{
-			"type":    errType,
-			"message": errMsg,
-		},
+		"type":  "error",
+		"error": errorBody,
 	})
 
 	if upstreamMsg == "" {
@@ -4036,8 +4128,8 @@ func (s *GatewayService) handleRetryExhaustedError(ctx context.Context, resp *ht
 	}
 
 	upstreamDetail := ""
-	if s.cfg != nil && s.cfg.Gateway.LogUpstreamErrorBody {
-		maxBytes := s.cfg.Gateway.LogUpstreamErrorBodyMaxBytes
+	if s.cfg != nil && s.cfg.Live().Gateway.LogUpstreamErrorBody {
+		maxBytes := s.cfg.Live().Gateway.LogUpstreamErrorBodyMaxBytes
 		if maxBytes <= 0 {
 			maxBytes = 2048
 		}
@@ -4054,14 +4146,14 @@ func (s *GatewayService) handleRetryExhaustedError(ctx context.Context, resp *ht
 		Detail:             upstreamDetail,
 	})
 
-	if s.cfg != nil && s.cfg.Gateway.LogUpstreamErrorBody {
+	if s.cfg != nil && s.cfg.Live().Gateway.LogUpstreamErrorBody {
 		log.Printf(
 			"Upstream error %d retries_exhausted (account=%d platform=%s type=%s): %s",
 			resp.StatusCode,
 			account.ID,
 			account.Platform,
 			account.Type,
-			truncateForLog(respBody, s.cfg.Gateway.LogUpstreamErrorBodyMaxBytes),
+			truncateForLog(respBody, s.cfg.Live().Gateway.LogUpstreamErrorBodyMaxBytes),
 		)
 	}
 
@@ -4069,6 +4161,7 @@ func (s *GatewayService) handleRetryExhaustedError(ctx context.Context, resp *ht
 		c,
 		account.Platform,
 		resp.StatusCode,
+		resp.Header,
 		respBody,
 		http.StatusBadGateway,
 		"upstream_error",
@@ -4148,6 +4241,9 @@ func (s *GatewayService) handleStreamingResponse(ctx context.Context, resp *http
 		maxLineSize = s.cfg.Gateway.MaxLineSize
 	}
 	scanner.Buffer(make([]byte, 64*1024), maxLineSize)
+	streamBudget := s.memoryGuard.NewStreamBudget()
+	defer streamBudget.Close()
+	scanner.Split(streamBudget.SplitFunc(bufio.ScanLines))
 
 	type scanEvent struct {
 		line string
@@ -4181,8 +4277,8 @@ func (s *GatewayService) handleStreamingResponse(ctx context.Context, resp *http
 	defer close(done)
 
 	streamInterval := time.Duration(0)
-	if s.cfg != nil && s.cfg.Gateway.StreamDataIntervalTimeout > 0 {
-		streamInterval = time.Duration(s.cfg.Gateway.StreamDataIntervalTimeout) * time.Second
+	if s.cfg != nil && s.cfg.Live().Gateway.StreamDataIntervalTimeout > 0 {
+		streamInterval = time.Duration(s.cfg.Live().Gateway.StreamDataIntervalTimeout) * time.Second
 	}
 	// 仅监控上游数据间隔超时，避免下游写入阻塞导致误判
 	var intervalTicker *time.Ticker
@@ -4206,7 +4302,8 @@ func (s *GatewayService) handleStreamingResponse(ctx context.Context, resp *http
 		flusher.Flush()
 	}
 
-	needModelReplace := originalModel != mappedModel
+	echoModel := modelAliasEchoModel(ctx, originalModel)
+	needModelReplace := echoModel != mappedModel
 	clientDisconnected := false // 客户端断开标志，断开后继续读取上游以获取完整usage
 
 	pendingEventLines := make([]string, 0, 4)
@@ -4224,8 +4321,10 @@ func (s *GatewayService) handleStreamingResponse(ctx context.Context, resp *http
 				eventName = strings.TrimSpace(strings.TrimPrefix(trimmed, "event:"))
 				continue
 			}
-			if dataLine == "" && sseDataRe.MatchString(trimmed) {
-				dataLine = sseDataRe.ReplaceAllString(trimmed, "")
+			if dataLine == "" {
+				if payload, ok := sseDataPayload(trimmed); ok {
+					dataLine = payload
+				}
 			}
 		}
 
@@ -4246,61 +4345,29 @@ func (s *GatewayService) handleStreamingResponse(ctx context.Context, resp *http
 			return []string{block}, dataLine, nil
 		}
 
-		var event map[string]any
-		if err := json.Unmarshal([]byte(dataLine), &event); err != nil {
-			// JSON 解析失败，直接透传原始数据
-			block := ""
-			if eventName != "" {
-				block = "event: " + eventName + "\n"
-			}
-			block += "data: " + dataLine + "\n\n"
-			return []string{block}, dataLine, nil
-		}
-
-		eventType, _ := event["type"].(string)
+		// 只按路径读取事件类型，数据原样透传；仅在需要兼容/改写时就地修改对应字段
+		eventType := gjson.Get(dataLine, "type").String()
 		if eventName == "" {
 			eventName = eventType
 		}
 
-		// 兼容 Kimi cached_tokens → cache_read_input_tokens
-		if eventType == "message_start" {
-			if msg, ok := event["message"].(map[string]any); ok {
-				if u, ok := msg["usage"].(map[string]any); ok {
-					reconcileCachedTokens(u)
-				}
-			}
-		}
-		if eventType == "message_delta" {
-			if u, ok := event["usage"].(map[string]any); ok {
-				reconcileCachedTokens(u)
-			}
-		}
-
-		if needModelReplace {
-			if msg, ok := event["message"].(map[string]any); ok {
-				if model, ok := msg["model"].(string); ok && model == mappedModel {
-					msg["model"] = originalModel
-				}
+		switch eventType {
+		case "message_start":
+			// 兼容 Kimi cached_tokens → cache_read_input_tokens
+			dataLine, _ = reconcileCachedTokensJSON(dataLine, "message.usage")
+			if needModelReplace {
+				dataLine, _ = replaceSSEJSONString(dataLine, "message.model", mappedModel, echoModel)
 			}
-		}
-
-		newData, err := json.Marshal(event)
-		if err != nil {
-			// 序列化失败，直接透传原始数据
-			block := ""
-			if eventName != "" {
-				block = "event: " + eventName + "\n"
-			}
-			block += "data: " + dataLine + "\n\n"
-			return []string{block}, dataLine, nil
+		case "message_delta":
+			dataLine, _ = reconcileCachedTokensJSON(dataLine, "usage")
 		}
 
 		block := ""
 		if eventName != "" {
 			block = "event: " + eventName + "\n"
 		}
-		block += "data: " + string(newData) + "\n\n"
-		return []string{block}, string(newData), nil
+		block += "data: " + dataLine + "\n\n"
+		return []string{block}, dataLine, nil
 	}
 
 	for {
@@ -4327,6 +4394,12 @@ func (s *GatewayService) handleStreamingResponse(ctx context.Context, resp *http
 					sendErrorEvent("response_too_large")
 					return &streamingResult{usage: usage, firstTokenMs: firstTokenMs}, ev.err
 				}
+				// 分片间隔/总时长超时：流已开始输出，结束响应且不切换账号
+				if isUpstreamStreamTimeout(ev.err) {
+					log.Printf("Upstream stream timeout: account=%d error=%v", account.ID, ev.err)
+					sendErrorEvent("stream_timeout")
+					return &streamingResult{usage: usage, firstTokenMs: firstTokenMs}, ev.err
+				}
 				sendErrorEvent("stream_read_error")
 				return &streamingResult{usage: usage, firstTokenMs: firstTokenMs}, fmt.Errorf("stream read error: %w", ev.err)
 			}
@@ -4392,61 +4465,57 @@ func (s *GatewayService) handleStreamingResponse(ctx context.Context, resp *http
 }
 
 func (s *GatewayService) parseSSEUsage(data string, usage *ClaudeUsage) {
-	// 解析message_start获取input tokens（标准Claude API格式）
-	var msgStart struct {
-		Type    string `json:"type"`
-		Message struct {
-			Usage ClaudeUsage `json:"usage"`
-		} `json:"message"`
-	}
-	if json.Unmarshal([]byte(data), &msgStart) == nil && msgStart.Type == "message_start" {
-		usage.InputTokens = msgStart.Message.Usage.InputTokens
-		usage.CacheCreationInputTokens = msgStart.Message.Usage.CacheCreationInputTokens
-		usage.CacheReadInputTokens = msgStart.Message.Usage.CacheReadInputTokens
+	// 只解析携带 usage 的事件，其余事件（content_block_delta 等）不做 JSON 解码
+	switch gjson.Get(data, "type").String() {
+	case "message_start":
+		// 解析message_start获取input tokens（标准Claude API格式）
+		u := gjson.Get(data, "message.usage")
+		usage.InputTokens = int(u.Get("input_tokens").Int())
+		usage.CacheCreationInputTokens = int(u.Get("cache_creation_input_tokens").Int())
+		usage.CacheReadInputTokens = int(u.Get("cache_read_input_tokens").Int())
 
 		// 解析嵌套的 cache_creation 对象中的 5m/1h 明细
-		cc5m := gjson.Get(data, "message.usage.cache_creation.ephemeral_5m_input_tokens")
-		cc1h := gjson.Get(data, "message.usage.cache_creation.ephemeral_1h_input_tokens")
+		cc5m := u.Get("cache_creation.ephemeral_5m_input_tokens")
+		cc1h := u.Get("cache_creation.ephemeral_1h_input_tokens")
 		if cc5m.Exists() || cc1h.Exists() {
 			usage.CacheCreation5mTokens = int(cc5m.Int())
 			usage.CacheCreation1hTokens = int(cc1h.Int())
 		}
-	}
 
-	// 解析message_delta获取tokens（兼容GLM等把所有usage放在delta中的API）
-	var msgDelta struct {
-		Type  string `json:"type"`
-		Usage struct {
-			InputTokens              int `json:"input_tokens"`
-			OutputTokens             int `json:"output_tokens"`
-			CacheCreationInputTokens int `json:"cache_creation_input_tokens"`
-			CacheReadInputTokens     int `json:"cache_read_input_tokens"`
-		} `json:"usage"`
-	}
-	if json.Unmarshal([]byte(data), &msgDelta) == nil && msgDelta.Type == "message_delta" {
+	case "message_delta":
+		// 解析message_delta获取tokens（兼容GLM等把所有usage放在delta中的API）
 		// message_delta 仅覆盖存在且非0的字段
 		// 避免覆盖 message_start 中已有的值（如 input_tokens）
 		// Claude API 的 message_delta 通常只包含 output_tokens
-		if msgDelta.Usage.InputTokens > 0 {
-			usage.InputTokens = msgDelta.Usage.InputTokens
+		u := gjson.Get(data, "usage")
+		if v := int(u.Get("input_tokens").Int()); v > 0 {
+			usage.InputTokens = v
 		}
-		if msgDelta.Usage.OutputTokens > 0 {
-			usage.OutputTokens = msgDelta.Usage.OutputTokens
+		if v := int(u.Get("output_tokens").Int()); v > 0 {
+			usage.OutputTokens = v
 		}
-		if msgDelta.Usage.CacheCreationInputTokens > 0 {
-			usage.CacheCreationInputTokens = msgDelta.Usage.CacheCreationInputTokens
+		if v := int(u.Get("cache_creation_input_tokens").Int()); v > 0 {
+			usage.CacheCreationInputTokens = v
 		}
-		if msgDelta.Usage.CacheReadInputTokens > 0 {
-			usage.CacheReadInputTokens = msgDelta.Usage.CacheReadInputTokens
+		if v := int(u.Get("cache_read_input_tokens").Int()); v > 0 {
+			usage.CacheReadInputTokens = v
 		}
 
 		// 解析嵌套的 cache_creation 对象中的 5m/1h 明细
-		cc5m := gjson.Get(data, "usage.cache_creation.ephemeral_5m_input_tokens")
-		cc1h := gjson.Get(data, "usage.cache_creation.ephemeral_1h_input_tokens")
+		cc5m := u.Get("cache_creation.ephemeral_5m_input_tokens")
+		cc1h := u.Get("cache_creation.ephemeral_1h_input_tokens")
 		if cc5m.Exists() || cc1h.Exists() {
 			usage.CacheCreation5mTokens = int(cc5m.Int())
 			usage.CacheCreation1hTokens = int(cc1h.Int())
 		}
+
+		// 解析嵌套的 server_tool_use 对象中的 web 搜索次数（累计值）
+		if webSearch := ParseClaudeToolUsage(u).WebSearchCalls; webSearch > 0 {
+			usage.WebSearchRequests = webSearch
+		}
+		if stopReason := gjson.Get(data, "delta.stop_reason").String(); stopReason != "" {
+			usage.StopReason = stopReason
+		}
 	}
 }
 
@@ -4474,6 +4543,8 @@ func (s *GatewayService) handleNonStreamingResponse(ctx context.Context, resp *h
 		response.Usage.CacheCreation5mTokens = int(cc5m.Int())
 		response.Usage.CacheCreation1hTokens = int(cc1h.Int())
 	}
+	response.Usage.WebSearchRequests = ParseClaudeToolUsage(gjson.GetBytes(body, "usage")).WebSearchCalls
+	response.Usage.StopReason = gjson.GetBytes(body, "stop_reason").String()
 
 	// 兼容 Kimi cached_tokens → cache_read_input_tokens
 	if response.Usage.CacheReadInputTokens == 0 {
@@ -4486,10 +4557,11 @@ func (s *GatewayService) handleNonStreamingResponse(ctx context.Context, resp *h
 		}
 	}
 
-	// 如果有模型映射，替换响应中的model字段
-	if originalModel != mappedModel {
-		body = s.replaceModelInResponseBody(body, mappedModel, originalModel)
+	// 如果有模型映射（含别名改写），替换响应中的model字段
+	if echoModel := modelAliasEchoModel(ctx, originalModel); echoModel != mappedModel {
+		body = s.replaceModelInResponseBody(body, mappedModel, echoModel)
 	}
+	body = withModelDeprecationWarning(ctx, body)
 
 	responseheaders.WriteFilteredHeaders(c.Writer.Header(), resp.Header, s.cfg.Security.ResponseHeaders)
 
@@ -4600,13 +4672,17 @@ func (s *GatewayService) RecordUsage(ctx context.Context, input *RecordUsageInpu
 			CacheCreation1hTokens: result.Usage.CacheCreation1hTokens,
 		}
 		var err error
-		cost, err = s.billingService.CalculateCost(result.Model, tokens, multiplier)
+		cost, err = s.billingService.CalculateCostForPlatform(account.Platform, result.Model, tokens, multiplier)
 		if err != nil {
 			log.Printf("Calculate cost failed: %v", err)
 			cost = &CostBreakdown{ActualCost: 0}
 		}
 	}
 
+	// 内置工具（web_search 等）按次独立计费
+	toolUsage := result.Usage.ToolUsage()
+	s.billingService.ApplyToolCost(cost, toolUsage, multiplier)
+
 	// 判断计费方式：订阅模式 vs 余额模式
 	isSubscriptionBilling := subscription != nil && apiKey.Group != nil && apiKey.Group.IsSubscriptionType()
 	billingType := BillingTypeBalance
@@ -4637,17 +4713,22 @@ func (s *GatewayService) RecordUsage(ctx context.Context, input *RecordUsageInpu
 		OutputCost:            cost.OutputCost,
 		CacheCreationCost:     cost.CacheCreationCost,
 		CacheReadCost:         cost.CacheReadCost,
+		ToolCost:              cost.ToolCost,
 		TotalCost:             cost.TotalCost,
 		ActualCost:            cost.ActualCost,
 		RateMultiplier:        multiplier,
 		AccountRateMultiplier: &accountRateMultiplier,
 		BillingType:           billingType,
+		MeteringOnly:          apiKey.Group.IsMeteringOnly(),
 		Stream:                result.Stream,
 		DurationMs:            &durationMs,
 		FirstTokenMs:          result.FirstTokenMs,
 		ImageCount:            result.ImageCount,
 		ImageSize:             imageSize,
+		ToolUsage:             toolUsage.Ptr(),
+		FinishReason:          result.Usage.StopReason,
 		CreatedAt:             time.Now(),
+		APIKey:                apiKey,
 	}
 
 	// 添加 UserAgent
@@ -4668,34 +4749,45 @@ func (s *GatewayService) RecordUsage(ctx context.Context, input *RecordUsageInpu
 		usageLog.SubscriptionID = &subscription.ID
 	}
 
+	redactUsageLogPII(usageLog)
+	pending := &PendingUsageRecord{}
 	inserted, err := s.usageLogRepo.Create(ctx, usageLog)
 	if err != nil {
 		log.Printf("Create usage log failed: %v", err)
+		pending.SetUsageLog(usageLog)
+	}
+	if inserted || err != nil {
+		observeUsageTokens(account.Platform, usageLog)
 	}
 
 	if s.cfg != nil && s.cfg.RunMode == config.RunModeSimple {
 		log.Printf("[SIMPLE MODE] Usage recorded (not billed): user=%d, tokens=%d", usageLog.UserID, usageLog.TotalTokens())
+		s.usageRetryService.Enqueue(pending)
 		s.deferredService.ScheduleLastUsedUpdate(account.ID)
 		return nil
 	}
 
 	shouldBill := inserted || err != nil
+	// 仅计量分组：照常记录用量，但不扣余额/订阅额度/API Key 额度，也不累计用户消费
+	shouldCharge := shouldBill && !usageLog.MeteringOnly
 
 	// 根据计费类型执行扣费
 	if isSubscriptionBilling {
 		// 订阅模式：更新订阅用量（使用 TotalCost 原始费用，不考虑倍率）
-		if shouldBill && cost.TotalCost > 0 {
+		if shouldCharge && cost.TotalCost > 0 {
 			if err := s.userSubRepo.IncrementUsage(ctx, subscription.ID, cost.TotalCost); err != nil {
 				log.Printf("Increment subscription usage failed: %v", err)
+				pending.SubscriptionID, pending.SubscriptionUsage = subscription.ID, cost.TotalCost
 			}
 			// 异步更新订阅缓存
 			s.billingCacheService.QueueUpdateSubscriptionUsage(user.ID, *apiKey.GroupID, cost.TotalCost)
 		}
 	} else {
 		// 余额模式：扣除用户余额（使用 ActualCost 考虑倍率后的费用）
-		if shouldBill && cost.ActualCost > 0 {
+		if shouldCharge && cost.ActualCost > 0 {
 			if err := s.userRepo.DeductBalance(ctx, user.ID, cost.ActualCost); err != nil {
 				log.Printf("Deduct balance failed: %v", err)
+				pending.UserID, pending.BalanceDeduction = user.ID, cost.ActualCost
 			}
 			// 异步更新余额缓存
 			s.billingCacheService.QueueDeductBalance(user.ID, cost.ActualCost)
@@ -4703,18 +4795,52 @@ func (s *GatewayService) RecordUsage(ctx context.Context, input *RecordUsageInpu
 	}
 
 	// 更新 API Key 配额（如果设置了配额限制）
-	if shouldBill && cost.ActualCost > 0 && apiKey.Quota > 0 && input.APIKeyService != nil {
+	if shouldCharge && cost.ActualCost > 0 && apiKey.Quota > 0 && input.APIKeyService != nil {
 		if err := input.APIKeyService.UpdateQuotaUsed(ctx, apiKey.ID, cost.ActualCost); err != nil {
 			log.Printf("Update API key quota failed: %v", err)
+			pending.APIKeyID, pending.APIKeyQuota = apiKey.ID, cost.ActualCost
 		}
 	}
 
+	// 累加 API Key token/请求数配额计数（如果设置了自然日/月配额）
+	if shouldBill && !apiKey.TokenQuota.IsEmpty() {
+		s.billingCacheService.QueueTokenQuotaUsage(apiKey, int64(usageLog.TotalTokens()))
+	}
+
+	// 累加用户当期消费（用户消费软/硬上限）
+	if shouldCharge && cost.ActualCost > 0 {
+		s.billingCacheService.RecordUserSpend(ctx, user, cost.ActualCost)
+	}
+
+	// 预算阈值告警（API Key 配额、订阅上限、账号窗口费用）
+	if shouldCharge {
+		s.observeBudgetUsage(ctx, apiKey, account, subscription, cost)
+	}
+
+	// 写库失败的用量日志与扣费操作进入持久化重试队列，避免丢失计费数据
+	s.usageRetryService.Enqueue(pending)
+
 	// Schedule batch update for account last_used_at
 	s.deferredService.ScheduleLastUsedUpdate(account.ID)
 
 	return nil
 }
 
+// observeBudgetUsage 将计费后的用量提交给预算告警；账号窗口费用仅在缓存命中时检查，避免额外查询数据库
+func (s *GatewayService) observeBudgetUsage(ctx context.Context, apiKey *APIKey, account *Account, subscription *UserSubscription, cost *CostBreakdown) {
+	if s.budgetAlertService == nil || cost == nil {
+		return
+	}
+	usages := budgetUsagesForRecordedUsage(apiKey, subscription, cost.TotalCost, cost.ActualCost)
+	if account.IsAnthropicOAuthOrSetupToken() && account.GetWindowCostLimit() > 0 && s.sessionLimitCache != nil {
+		if windowCost, hit, err := s.sessionLimitCache.GetWindowCost(ctx, account.ID); err == nil && hit {
+			// 窗口费用使用标准费用（不含账号倍率）
+			usages = append(usages, accountWindowCostBudgetUsage(account, windowCost+cost.TotalCost))
+		}
+	}
+	s.budgetAlertService.Observe(ctx, usages...)
+}
+
 // RecordUsageLongContextInput 记录使用量的输入参数（支持长上下文双倍计费）
 type RecordUsageLongContextInput struct {
 	Result                *ForwardResult
@@ -4785,13 +4911,17 @@ func (s *GatewayService) RecordUsageWithLongContext(ctx context.Context, input *
 			CacheCreation1hTokens: result.Usage.CacheCreation1hTokens,
 		}
 		var err error
-		cost, err = s.billingService.CalculateCostWithLongContext(result.Model, tokens, multiplier, input.LongContextThreshold, input.LongContextMultiplier)
+		cost, err = s.billingService.CalculateCostWithLongContextForPlatform(account.Platform, result.Model, tokens, multiplier, input.LongContextThreshold, input.LongContextMultiplier)
 		if err != nil {
 			log.Printf("Calculate cost failed: %v", err)
 			cost = &CostBreakdown{ActualCost: 0}
 		}
 	}
 
+	// 内置工具（web_search 等）按次独立计费
+	toolUsage := result.Usage.ToolUsage()
+	s.billingService.ApplyToolCost(cost, toolUsage, multiplier)
+
 	// 判断计费方式：订阅模式 vs 余额模式
 	isSubscriptionBilling := subscription != nil && apiKey.Group != nil && apiKey.Group.IsSubscriptionType()
 	billingType := BillingTypeBalance
@@ -4822,17 +4952,22 @@ func (s *GatewayService) RecordUsageWithLongContext(ctx context.Context, input *
 		OutputCost:            cost.OutputCost,
 		CacheCreationCost:     cost.CacheCreationCost,
 		CacheReadCost:         cost.CacheReadCost,
+		ToolCost:              cost.ToolCost,
 		TotalCost:             cost.TotalCost,
 		ActualCost:            cost.ActualCost,
 		RateMultiplier:        multiplier,
 		AccountRateMultiplier: &accountRateMultiplier,
 		BillingType:           billingType,
+		MeteringOnly:          apiKey.Group.IsMeteringOnly(),
 		Stream:                result.Stream,
 		DurationMs:            &durationMs,
 		FirstTokenMs:          result.FirstTokenMs,
 		ImageCount:            result.ImageCount,
 		ImageSize:             imageSize,
+		ToolUsage:             toolUsage.Ptr(),
+		FinishReason:          result.Usage.StopReason,
 		CreatedAt:             time.Now(),
+		APIKey:                apiKey,
 	}
 
 	// 添加 UserAgent
@@ -4853,34 +4988,45 @@ func (s *GatewayService) RecordUsageWithLongContext(ctx context.Context, input *
 		usageLog.SubscriptionID = &subscription.ID
 	}
 
+	redactUsageLogPII(usageLog)
+	pending := &PendingUsageRecord{}
 	inserted, err := s.usageLogRepo.Create(ctx, usageLog)
 	if err != nil {
 		log.Printf("Create usage log failed: %v", err)
+		pending.SetUsageLog(usageLog)
+	}
+	if inserted || err != nil {
+		observeUsageTokens(account.Platform, usageLog)
 	}
 
 	if s.cfg != nil && s.cfg.RunMode == config.RunModeSimple {
 		log.Printf("[SIMPLE MODE] Usage recorded (not billed): user=%d, tokens=%d", usageLog.UserID, usageLog.TotalTokens())
+		s.usageRetryService.Enqueue(pending)
 		s.deferredService.ScheduleLastUsedUpdate(account.ID)
 		return nil
 	}
 
 	shouldBill := inserted || err != nil
+	// 仅计量分组：照常记录用量，但不扣余额/订阅额度/API Key 额度，也不累计用户消费
+	shouldCharge := shouldBill && !usageLog.MeteringOnly
 
 	// 根据计费类型执行扣费
 	if isSubscriptionBilling {
 		// 订阅模式：更新订阅用量（使用 TotalCost 原始费用，不考虑倍率）
-		if shouldBill && cost.TotalCost > 0 {
+		if shouldCharge && cost.TotalCost > 0 {
 			if err := s.userSubRepo.IncrementUsage(ctx, subscription.ID, cost.TotalCost); err != nil {
 				log.Printf("Increment subscription usage failed: %v", err)
+				pending.SubscriptionID, pending.SubscriptionUsage = subscription.ID, cost.TotalCost
 			}
 			// 异步更新订阅缓存
 			s.billingCacheService.QueueUpdateSubscriptionUsage(user.ID, *apiKey.GroupID, cost.TotalCost)
 		}
 	} else {
 		// 余额模式：扣除用户余额（使用 ActualCost 考虑倍率后的费用）
-		if shouldBill && cost.ActualCost > 0 {
+		if shouldCharge && cost.ActualCost > 0 {
 			if err := s.userRepo.DeductBalance(ctx, user.ID, cost.ActualCost); err != nil {
 				log.Printf("Deduct balance failed: %v", err)
+				pending.UserID, pending.BalanceDeduction = user.ID, cost.ActualCost
 			}
 			// 异步更新余额缓存
 			s.billingCacheService.QueueDeductBalance(user.ID, cost.ActualCost)
@@ -4888,11 +5034,30 @@ func (s *GatewayService) RecordUsageWithLongContext(ctx context.Context, input *
 			if input.APIKeyService != nil && apiKey.Quota > 0 {
 				if err := input.APIKeyService.UpdateQuotaUsed(ctx, apiKey.ID, cost.ActualCost); err != nil {
 					log.Printf("Add API key quota used failed: %v", err)
+					pending.APIKeyID, pending.APIKeyQuota = apiKey.ID, cost.ActualCost
 				}
 			}
 		}
 	}
 
+	// 累加 API Key token/请求数配额计数（如果设置了自然日/月配额）
+	if shouldBill && !apiKey.TokenQuota.IsEmpty() {
+		s.billingCacheService.QueueTokenQuotaUsage(apiKey, int64(usageLog.TotalTokens()))
+	}
+
+	// 累加用户当期消费（用户消费软/硬上限）
+	if shouldCharge && cost.ActualCost > 0 {
+		s.billingCacheService.RecordUserSpend(ctx, user, cost.ActualCost)
+	}
+
+	// 预算阈值告警（API Key 配额、订阅上限、账号窗口费用）
+	if shouldCharge {
+		s.observeBudgetUsage(ctx, apiKey, account, subscription, cost)
+	}
+
+	// 写库失败的用量日志与扣费操作进入持久化重试队列，避免丢失计费数据
+	s.usageRetryService.Enqueue(pending)
+
 	// Schedule batch update for account last_used_at
 	s.deferredService.ScheduleLastUsedUpdate(account.ID)
 
@@ -4902,6 +5067,8 @@ func (s *GatewayService) RecordUsageWithLongContext(ctx context.Context, input *
 // ForwardCountTokens 转发 count_tokens 请求到上游 API
 // 特点：不记录使用量、仅支持非流式响应
 func (s *GatewayService) ForwardCountTokens(ctx context.Context, c *gin.Context, account *Account, parsed *ParsedRequest) error {
+	// 账号配置了 mTLS 客户端证书 / 自定义 CA 时，由 HTTP 上游据此建立连接
+	ctx = WithAccountUpstreamTLS(ctx, account)
 	if parsed == nil {
 		s.countTokensError(c, http.StatusBadRequest, "invalid_request_error", "Request body is empty")
 		return fmt.Errorf("parse request: empty request")
@@ -5014,8 +5181,8 @@ func (s *GatewayService) ForwardCountTokens(ctx context.Context, c *gin.Context,
 		upstreamMsg := strings.TrimSpace(extractUpstreamErrorMessage(respBody))
 		upstreamMsg = sanitizeUpstreamErrorMessage(upstreamMsg)
 		upstreamDetail := ""
-		if s.cfg != nil && s.cfg.Gateway.LogUpstreamErrorBody {
-			maxBytes := s.cfg.Gateway.LogUpstreamErrorBodyMaxBytes
+		if s.cfg != nil && s.cfg.Live().Gateway.LogUpstreamErrorBody {
+			maxBytes := s.cfg.Live().Gateway.LogUpstreamErrorBodyMaxBytes
 			if maxBytes <= 0 {
 				maxBytes = 2048
 			}
@@ -5024,14 +5191,14 @@ func (s *GatewayService) ForwardCountTokens(ctx context.Context, c *gin.Context,
 		setOpsUpstreamError(c, resp.StatusCode, upstreamMsg, upstreamDetail)
 
 		// 记录上游错误摘要便于排障（不回显请求内容）
-		if s.cfg != nil && s.cfg.Gateway.LogUpstreamErrorBody {
+		if s.cfg != nil && s.cfg.Live().Gateway.LogUpstreamErrorBody {
 			log.Printf(
 				"count_tokens upstream error %d (account=%d platform=%s type=%s): %s",
 				resp.StatusCode,
 				account.ID,
 				account.Platform,
 				account.Type,
-				truncateForLog(respBody, s.cfg.Gateway.LogUpstreamErrorBodyMaxBytes),
+				truncateForLog(respBody, s.cfg.Live().Gateway.LogUpstreamErrorBodyMaxBytes),
 			)
 		}
 
@@ -5150,7 +5317,7 @@ func (s *GatewayService) buildCountTokensRequest(ctx context.Context, c *gin.Con
 				req.Header.Set("anthropic-beta", beta)
 			}
 		}
-	} else if s.cfg != nil && s.cfg.Gateway.InjectBetaForAPIKey && req.Header.Get("anthropic-beta") == "" {
+	} else if s.cfg != nil && s.cfg.Live().Gateway.InjectBetaForAPIKey && req.Header.Get("anthropic-beta") == "" {
 		// API-key：与 messages 同步的按需 beta 注入（默认关闭）
 		if requestNeedsBetaFeatures(body) {
 			if beta := defaultAPIKeyBetaHeader(body); beta != "" {
@@ -0,0 +1,36 @@
+package service
+
+import (
+	"context"
+
+	"github.com/Wei-Shaw/sub2api/internal/pkg/tracing"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// startAccountSelectionSpan 为一次调度选号创建 span（未启用链路追踪时为 noop）；platform 为空表示由分组决定
+func startAccountSelectionSpan(ctx context.Context, platform string, groupID *int64, requestedModel string, excludedIDs map[int64]struct{}) (context.Context, trace.Span) {
+	attrs := []attribute.KeyValue{
+		attribute.String("gateway.model", requestedModel),
+		attribute.Int("scheduler.excluded_accounts", len(excludedIDs)),
+	}
+	if platform != "" {
+		attrs = append(attrs, attribute.String("gateway.platform", platform))
+	}
+	if groupID != nil {
+		attrs = append(attrs, attribute.Int64("gateway.group_id", *groupID))
+	}
+	return tracing.Start(ctx, "scheduler.select_account", attrs...)
+}
+
+// endAccountSelectionSpan 记录选号结果（选中账号、是否直接获取槽位、是否需要排队）并结束 span
+func endAccountSelectionSpan(span trace.Span, result *AccountSelectionResult, err error) {
+	if result != nil && result.Account != nil {
+		span.SetAttributes(
+			attribute.Int64("scheduler.account_id", result.Account.ID),
+			attribute.Bool("scheduler.slot_acquired", result.Acquired),
+			attribute.Bool("scheduler.wait_planned", result.WaitPlan != nil),
+		)
+	}
+	tracing.End(span, err)
+}
@@ -404,8 +404,8 @@ func (s *GeminiOAuthService) RefreshAccountGoogleOneTier(
 	}
 
 	// 获取 access_token
-	accessToken, ok := account.Credentials["access_token"].(string)
-	if !ok || accessToken == "" {
+	accessToken := account.GetCredential("access_token")
+	if accessToken == "" {
 		return "", nil, nil, fmt.Errorf("missing access_token")
 	}
 
@@ -38,6 +38,26 @@ type Group struct {
 	ModelRouting        map[string][]int64
 	ModelRoutingEnabled bool
 
+	// 模型参数策略
+	// key: 模型匹配模式（支持 * 通配符）
+	// value: temperature/top_p 取值范围与互斥约束
+	ModelParamPolicies map[string]ModelParamPolicy
+
+	// 区域策略：要求/优先使用指定区域的账号（API Key 上的配置优先）
+	RegionPolicy RegionPolicy
+
+	// 系统提示词注入策略（API Key 上的配置优先）
+	SystemPromptPolicy SystemPromptPolicy
+
+	// 提示词前缀/后缀模板
+	PromptTemplate PromptTemplate
+
+	// 模型访问策略：限制分组（及其 API Key）可请求的模型
+	ModelAccessPolicy ModelAccessPolicy
+
+	// 重试策略：可重试状态码、指数退避与总时间预算（网关故障转移时使用）
+	RetryPolicy RetryPolicy
+
 	// MCP XML 协议注入开关（仅 antigravity 平台使用）
 	MCPXMLInject bool
 
@@ -48,6 +68,13 @@ type Group struct {
 	// 分组排序
 	SortOrder int
 
+	// 仅计量模式：照常记录用量与费用，但不扣余额/订阅额度，也不做计费资格拦截（试点、计费系统迁移期间使用）
+	MeteringOnly bool
+
+	// 严格字段模式：请求包含协议未定义的顶层字段（如拼写错误的 max_token）时以 400 拒绝；
+	// 关闭（宽松模式）时未知字段原样透传给上游
+	StrictRequestFields bool
+
 	CreatedAt time.Time
 	UpdatedAt time.Time
 
@@ -59,10 +86,25 @@ func (g *Group) IsActive() bool {
 	return g.Status == StatusActive
 }
 
+// IsInMaintenance 分组是否处于维护状态（新请求直接返回维护提示）
+func (g *Group) IsInMaintenance() bool {
+	return g.Status == StatusMaintenance
+}
+
 func (g *Group) IsSubscriptionType() bool {
 	return g.SubscriptionType == SubscriptionTypeSubscription
 }
 
+// IsMeteringOnly 分组是否处于仅计量模式（nil 分组返回 false）
+func (g *Group) IsMeteringOnly() bool {
+	return g != nil && g.MeteringOnly
+}
+
+// IsStrictRequestFields 分组是否拒绝未知顶层请求字段（nil 分组返回 false）
+func (g *Group) IsStrictRequestFields() bool {
+	return g != nil && g.StrictRequestFields
+}
+
 func (g *Group) IsFreeSubscription() bool {
 	return g.IsSubscriptionType() && g.RateMultiplier == 0
 }
@@ -134,6 +176,34 @@ func (g *Group) GetRoutingAccountIDs(requestedModel string) []int64 {
 	return nil
 }
 
+// GetModelParamPolicy 根据请求模型获取参数策略
+// 精确匹配优先，其次选择最长前缀的通配符规则；无匹配返回 nil
+func (g *Group) GetModelParamPolicy(requestedModel string) *ModelParamPolicy {
+	if g == nil || len(g.ModelParamPolicies) == 0 || requestedModel == "" {
+		return nil
+	}
+
+	if policy, ok := g.ModelParamPolicies[requestedModel]; ok {
+		return &policy
+	}
+
+	var (
+		matched    *ModelParamPolicy
+		matchedLen = -1
+	)
+	for pattern, policy := range g.ModelParamPolicies {
+		if !matchModelPattern(pattern, requestedModel) {
+			continue
+		}
+		if len(pattern) > matchedLen {
+			p := policy
+			matched = &p
+			matchedLen = len(pattern)
+		}
+	}
+	return matched
+}
+
 // matchModelPattern 检查模型是否匹配模式
 // 支持 * 通配符，如 "claude-opus-*" 匹配 "claude-opus-4-20250514"
 func matchModelPattern(pattern, model string) bool {
@@ -1,6 +1,12 @@
 package service
 
-import "net/http"
+import (
+	"errors"
+	"net/http"
+)
+
+// ErrAccountWorkerPoolSaturated 账号上游 worker 池在排队超时内没有空闲 worker（由 HTTP 上游返回，网关据此切换账号）
+var ErrAccountWorkerPoolSaturated = errors.New("account upstream worker pool saturated")
 
 // HTTPUpstream 上游 HTTP 请求接口
 // 用于向上游 API（Claude、OpenAI、Gemini 等）发送请求
@@ -53,3 +59,24 @@ type HTTPUpstream interface {
 	//   - TLS 指纹客户端与普通客户端使用不同的缓存键，互不影响
 	DoWithTLS(req *http.Request, proxyURL string, accountID int64, accountConcurrency int, enableTLSFingerprint bool) (*http.Response, error)
 }
+
+// AccountWorkerPoolStats 账号上游 worker 池运行指标
+type AccountWorkerPoolStats struct {
+	AccountID int64 `json:"account_id"`
+	// Capacity 池容量（worker 数）
+	Capacity int `json:"capacity"`
+	// InFlight 当前占用的 worker 数（请求发出到响应体关闭）
+	InFlight int64 `json:"in_flight"`
+	// Waiting 当前排队等待 worker 的调用数
+	Waiting int64 `json:"waiting"`
+	// Acquired/Saturated 累计获得 worker 的调用数 / 因排队超时被拒绝的调用数
+	Acquired  int64 `json:"acquired"`
+	Saturated int64 `json:"saturated"`
+	// Utilization InFlight / Capacity
+	Utilization float64 `json:"utilization"`
+}
+
+// AccountWorkerPoolStatsProvider 可选接口：HTTPUpstream 实现启用账号 worker 池时暴露饱和度指标
+type AccountWorkerPoolStatsProvider interface {
+	AccountWorkerPoolStats() []AccountWorkerPoolStats
+}
@@ -0,0 +1,158 @@
+package service
+
+import (
+	"context"
+	"log"
+	"net"
+	"sort"
+	"time"
+
+	"github.com/Wei-Shaw/sub2api/internal/config"
+	infraerrors "github.com/Wei-Shaw/sub2api/internal/pkg/errors"
+	"github.com/Wei-Shaw/sub2api/internal/pkg/ip"
+)
+
+// IP 封禁原因
+const (
+	IPBanReasonAuthFailures = "auth_failures"
+)
+
+var (
+	ErrIPBanned        = infraerrors.Forbidden("IP_BANNED", "too many invalid API key attempts from this IP, please try again later")
+	ErrIPRateLimited   = infraerrors.TooManyRequests("IP_RATE_LIMITED", "too many requests from this IP, please try again later")
+	ErrIPBanInvalidIP  = infraerrors.BadRequest("IP_BAN_INVALID_IP", "invalid IP address")
+	ErrIPBanNotEnabled = infraerrors.BadRequest("IP_BAN_NOT_ENABLED", "IP ban is not enabled")
+)
+
+// IPBan 被临时封禁的 IP
+type IPBan struct {
+	IP        string    `json:"ip"`
+	Reason    string    `json:"reason"`
+	Failures  int64     `json:"failures"`
+	BannedAt  time.Time `json:"banned_at"`
+	ExpiresAt time.Time `json:"expires_at"`
+}
+
+// IPBanCache 认证前 IP 计数与封禁记录存储（多实例共享）
+type IPBanCache interface {
+	// IncrIPRequests 累加 IP 在当前窗口内的请求数，返回累加后的值
+	IncrIPRequests(ctx context.Context, ip string, window time.Duration) (int64, error)
+	// IncrIPAuthFailures 累加 IP 在当前窗口内的认证失败数，返回累加后的值
+	IncrIPAuthFailures(ctx context.Context, ip string, window time.Duration) (int64, error)
+	// SetIPBan 写入封禁记录，ttl 到期后自动解封
+	SetIPBan(ctx context.Context, ban *IPBan, ttl time.Duration) error
+	// GetIPBan 获取封禁记录；未封禁时返回 nil
+	GetIPBan(ctx context.Context, ip string) (*IPBan, error)
+	// ListIPBans 列出 now 时仍有效的封禁记录
+	ListIPBans(ctx context.Context, now time.Time) ([]IPBan, error)
+	// DeleteIPBan 解除封禁并清零认证失败计数
+	DeleteIPBan(ctx context.Context, ip string) error
+}
+
+// IPBanService 在 API Key 认证之前按 IP 限流，并临时封禁频繁认证失败的 IP，
+// 避免无效 Key 的请求洪水逐个触发数据库查询。Redis 不可用时放行。
+type IPBanService struct {
+	cfg   config.GatewayIPBanConfig
+	cache IPBanCache
+	now   func() time.Time
+}
+
+// NewIPBanService 创建 IP 封禁服务
+func NewIPBanService(cfg *config.Config, cache IPBanCache) *IPBanService {
+	s := &IPBanService{cache: cache, now: time.Now}
+	if cfg != nil {
+		s.cfg = cfg.Gateway.IPBan
+	}
+	return s
+}
+
+// Enabled 是否启用
+func (s *IPBanService) Enabled() bool {
+	return s != nil && s.cfg.Enabled && s.cache != nil
+}
+
+func (s *IPBanService) exempt(clientIP string) bool {
+	return clientIP == "" || ip.MatchesAnyPattern(clientIP, s.cfg.Whitelist)
+}
+
+// Admit 认证前检查 IP：已封禁时返回封禁记录与 ErrIPBanned，超过每分钟请求上限时返回 ErrIPRateLimited
+func (s *IPBanService) Admit(ctx context.Context, clientIP string) (*IPBan, error) {
+	if !s.Enabled() || s.exempt(clientIP) {
+		return nil, nil
+	}
+	ban, err := s.cache.GetIPBan(ctx, clientIP)
+	if err != nil {
+		log.Printf("[IPBan] get ban failed: ip=%s err=%v", clientIP, err)
+		return nil, nil
+	}
+	if ban != nil {
+		return ban, ErrIPBanned
+	}
+	if s.cfg.RequestsPerMinute <= 0 {
+		return nil, nil
+	}
+	count, err := s.cache.IncrIPRequests(ctx, clientIP, time.Minute)
+	if err != nil {
+		log.Printf("[IPBan] incr requests failed: ip=%s err=%v", clientIP, err)
+		return nil, nil
+	}
+	if count > int64(s.cfg.RequestsPerMinute) {
+		return nil, ErrIPRateLimited
+	}
+	return nil, nil
+}
+
+// RecordAuthFailure 记录一次认证失败；失败次数达到阈值时封禁该 IP 并返回新的封禁记录
+func (s *IPBanService) RecordAuthFailure(ctx context.Context, clientIP string) (*IPBan, error) {
+	if !s.Enabled() || s.exempt(clientIP) {
+		return nil, nil
+	}
+	window := time.Duration(s.cfg.FailureWindowSeconds) * time.Second
+	failures, err := s.cache.IncrIPAuthFailures(ctx, clientIP, window)
+	if err != nil {
+		return nil, err
+	}
+	if failures < int64(s.cfg.MaxAuthFailures) {
+		return nil, nil
+	}
+
+	now := s.now()
+	ttl := time.Duration(s.cfg.BanMinutes) * time.Minute
+	ban := &IPBan{
+		IP:        clientIP,
+		Reason:    IPBanReasonAuthFailures,
+		Failures:  failures,
+		BannedAt:  now,
+		ExpiresAt: now.Add(ttl),
+	}
+	if err := s.cache.SetIPBan(ctx, ban, ttl); err != nil {
+		return nil, err
+	}
+	log.Printf("[IPBan] banned: ip=%s failures=%d window=%s ttl=%s", clientIP, failures, window, ttl)
+	return ban, nil
+}
+
+// ListBans 列出当前有效的封禁记录（按封禁时间倒序）
+func (s *IPBanService) ListBans(ctx context.Context) ([]IPBan, error) {
+	if !s.Enabled() {
+		return []IPBan{}, nil
+	}
+	bans, err := s.cache.ListIPBans(ctx, s.now())
+	if err != nil {
+		return nil, err
+	}
+	sort.Slice(bans, func(i, j int) bool { return bans[i].BannedAt.After(bans[j].BannedAt) })
+	return bans, nil
+}
+
+// Unban 解除 IP 封禁
+func (s *IPBanService) Unban(ctx context.Context, clientIP string) error {
+	if !s.Enabled() {
+		return ErrIPBanNotEnabled
+	}
+	parsed := net.ParseIP(clientIP)
+	if parsed == nil {
+		return ErrIPBanInvalidIP
+	}
+	return s.cache.DeleteIPBan(ctx, parsed.String())
+}
@@ -0,0 +1,157 @@
+//go:build unit
+
+package service
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/Wei-Shaw/sub2api/internal/config"
+	"github.com/stretchr/testify/require"
+)
+
+type ipBanCacheStub struct {
+	requests map[string]int64
+	failures map[string]int64
+	bans     map[string]IPBan
+	err      error
+}
+
+func newIPBanCacheStub() *ipBanCacheStub {
+	return &ipBanCacheStub{requests: map[string]int64{}, failures: map[string]int64{}, bans: map[string]IPBan{}}
+}
+
+func (s *ipBanCacheStub) IncrIPRequests(ctx context.Context, ip string, window time.Duration) (int64, error) {
+	if s.err != nil {
+		return 0, s.err
+	}
+	s.requests[ip]++
+	return s.requests[ip], nil
+}
+
+func (s *ipBanCacheStub) IncrIPAuthFailures(ctx context.Context, ip string, window time.Duration) (int64, error) {
+	s.failures[ip]++
+	return s.failures[ip], nil
+}
+
+func (s *ipBanCacheStub) SetIPBan(ctx context.Context, ban *IPBan, ttl time.Duration) error {
+	s.bans[ban.IP] = *ban
+	delete(s.failures, ban.IP)
+	return nil
+}
+
+func (s *ipBanCacheStub) GetIPBan(ctx context.Context, ip string) (*IPBan, error) {
+	if s.err != nil {
+		return nil, s.err
+	}
+	if ban, ok := s.bans[ip]; ok {
+		return &ban, nil
+	}
+	return nil, nil
+}
+
+func (s *ipBanCacheStub) ListIPBans(ctx context.Context, now time.Time) ([]IPBan, error) {
+	out := make([]IPBan, 0, len(s.bans))
+	for _, ban := range s.bans {
+		if ban.ExpiresAt.After(now) {
+			out = append(out, ban)
+		}
+	}
+	return out, nil
+}
+
+func (s *ipBanCacheStub) DeleteIPBan(ctx context.Context, ip string) error {
+	delete(s.bans, ip)
+	delete(s.failures, ip)
+	return nil
+}
+
+func newIPBanTestService(cache IPBanCache) *IPBanService {
+	cfg := &config.Config{}
+	cfg.Gateway.IPBan = config.GatewayIPBanConfig{
+		Enabled:              true,
+		RequestsPerMinute:    5,
+		MaxAuthFailures:      3,
+		FailureWindowSeconds: 60,
+		BanMinutes:           10,
+		Whitelist:            []string{"10.0.0.0/8"},
+	}
+	return NewIPBanService(cfg, cache)
+}
+
+func TestIPBanService_BanAfterAuthFailures(t *testing.T) {
+	cache := newIPBanCacheStub()
+	svc := newIPBanTestService(cache)
+	now := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+	svc.now = func() time.Time { return now }
+	ctx := context.Background()
+
+	for i := 0; i < 2; i++ {
+		ban, err := svc.RecordAuthFailure(ctx, "203.0.113.7")
+		require.NoError(t, err)
+		require.Nil(t, ban)
+	}
+	ban, err := svc.RecordAuthFailure(ctx, "203.0.113.7")
+	require.NoError(t, err)
+	require.NotNil(t, ban)
+	require.Equal(t, IPBanReasonAuthFailures, ban.Reason)
+	require.Equal(t, int64(3), ban.Failures)
+	require.Equal(t, now.Add(10*time.Minute), ban.ExpiresAt)
+
+	got, err := svc.Admit(ctx, "203.0.113.7")
+	require.ErrorIs(t, err, ErrIPBanned)
+	require.NotNil(t, got)
+	// 其他 IP 不受影响
+	_, err = svc.Admit(ctx, "203.0.113.8")
+	require.NoError(t, err)
+
+	bans, err := svc.ListBans(ctx)
+	require.NoError(t, err)
+	require.Len(t, bans, 1)
+
+	require.ErrorIs(t, svc.Unban(ctx, "not-an-ip"), ErrIPBanInvalidIP)
+	require.NoError(t, svc.Unban(ctx, "203.0.113.7"))
+	_, err = svc.Admit(ctx, "203.0.113.7")
+	require.NoError(t, err)
+}
+
+func TestIPBanService_RateLimit(t *testing.T) {
+	cache := newIPBanCacheStub()
+	svc := newIPBanTestService(cache)
+	ctx := context.Background()
+
+	for i := 0; i < 5; i++ {
+		_, err := svc.Admit(ctx, "198.51.100.1")
+		require.NoError(t, err)
+	}
+	_, err := svc.Admit(ctx, "198.51.100.1")
+	require.ErrorIs(t, err, ErrIPRateLimited)
+
+	// 白名单 IP 不计数、不封禁
+	for i := 0; i < 10; i++ {
+		_, err := svc.Admit(ctx, "10.1.2.3")
+		require.NoError(t, err)
+		ban, err := svc.RecordAuthFailure(ctx, "10.1.2.3")
+		require.NoError(t, err)
+		require.Nil(t, ban)
+	}
+	require.Empty(t, cache.requests["10.1.2.3"])
+}
+
+func TestIPBanService_FailOpenAndDisabled(t *testing.T) {
+	cache := newIPBanCacheStub()
+	cache.err = errors.New("redis down")
+	svc := newIPBanTestService(cache)
+	ban, err := svc.Admit(context.Background(), "203.0.113.7")
+	require.NoError(t, err)
+	require.Nil(t, ban)
+
+	disabled := NewIPBanService(&config.Config{}, newIPBanCacheStub())
+	require.False(t, disabled.Enabled())
+	ban, err = disabled.RecordAuthFailure(context.Background(), "203.0.113.7")
+	require.NoError(t, err)
+	require.Nil(t, ban)
+	require.ErrorIs(t, disabled.Unban(context.Background(), "203.0.113.7"), ErrIPBanNotEnabled)
+}
@@ -0,0 +1,194 @@
+package service
+
+import (
+	"bufio"
+	"fmt"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/Wei-Shaw/sub2api/internal/config"
+)
+
+// ErrStreamMemoryBudget 流式响应缓冲超出单流预算或全局预算耗尽。
+// 包装 bufio.ErrTooLong，使各流式转发路径沿用"响应过大"的既有处理（发送 response_too_large 错误事件并中止）。
+var ErrStreamMemoryBudget = fmt.Errorf("stream memory budget exceeded: %w", bufio.ErrTooLong)
+
+// MemoryGuard 网关内存护栏：统计请求体、上游 SSE 行缓冲与 Ops 错误捕获登记的内存占用，
+// 接近全局预算时拒绝新的流式请求，避免图片密集的高并发流量导致进程被 OOM kill。
+// 未启用时所有方法均为 no-op（nil 接收者同样安全）。
+type MemoryGuard struct {
+	globalLimit     int64
+	perStreamLimit  int64
+	rejectThreshold int64
+	retryAfter      time.Duration
+
+	used          atomic.Int64
+	activeStreams atomic.Int64
+	rejected      atomic.Int64
+	aborted       atomic.Int64
+}
+
+// MemoryGuardStats 内存护栏运行状态
+type MemoryGuardStats struct {
+	Enabled              bool  `json:"enabled"`
+	UsedBytes            int64 `json:"used_bytes"`
+	GlobalBudgetBytes    int64 `json:"global_budget_bytes"`
+	RejectThresholdBytes int64 `json:"reject_threshold_bytes"`
+	PerStreamBudgetBytes int64 `json:"per_stream_budget_bytes"`
+	ActiveStreams        int64 `json:"active_streams"`
+	RejectedRequests     int64 `json:"rejected_requests"`
+	AbortedStreams       int64 `json:"aborted_streams"`
+}
+
+// NewMemoryGuard 按 gateway.memory_guard 配置创建内存护栏，未启用时返回 nil
+func NewMemoryGuard(cfg *config.Config) *MemoryGuard {
+	if cfg == nil || !cfg.Gateway.MemoryGuard.Enabled {
+		return nil
+	}
+	mg := cfg.Gateway.MemoryGuard
+	global := int64(mg.GlobalBudgetMB) << 20
+	return &MemoryGuard{
+		globalLimit:     global,
+		perStreamLimit:  int64(mg.PerStreamBudgetMB) << 20,
+		rejectThreshold: int64(float64(global) * mg.RejectThreshold),
+		retryAfter:      time.Duration(mg.RetryAfterSeconds) * time.Second,
+	}
+}
+
+// TryReserve 登记 n 字节占用，超出全局预算时不登记并返回 false
+func (g *MemoryGuard) TryReserve(n int64) bool {
+	if g == nil || n <= 0 {
+		return true
+	}
+	for {
+		cur := g.used.Load()
+		if cur+n > g.globalLimit {
+			return false
+		}
+		if g.used.CompareAndSwap(cur, cur+n) {
+			return true
+		}
+	}
+}
+
+// Release 释放 TryReserve 登记的占用
+func (g *MemoryGuard) Release(n int64) {
+	if g == nil || n <= 0 {
+		return
+	}
+	g.used.Add(-n)
+}
+
+// AdmitRequest 为已读取的请求体登记内存并做准入判断：
+// 流式请求在占用达到拒绝阈值后不再准入；任何请求在全局预算不足以容纳请求体时不准入。
+// 准入时返回的 release 需在请求结束时调用。
+func (g *MemoryGuard) AdmitRequest(bodySize int, stream bool) (release func(), ok bool) {
+	if g == nil {
+		return func() {}, true
+	}
+	if stream && g.used.Load()+int64(bodySize) >= g.rejectThreshold {
+		g.rejected.Add(1)
+		return nil, false
+	}
+	n := int64(bodySize)
+	if !g.TryReserve(n) {
+		g.rejected.Add(1)
+		return nil, false
+	}
+	var once sync.Once
+	return func() { once.Do(func() { g.Release(n) }) }, true
+}
+
+// RetryAfter 拒绝请求时建议客户端等待的时间
+func (g *MemoryGuard) RetryAfter() time.Duration {
+	if g == nil {
+		return 0
+	}
+	return g.retryAfter
+}
+
+// Stats 返回当前占用与拒绝/中止计数
+func (g *MemoryGuard) Stats() MemoryGuardStats {
+	if g == nil {
+		return MemoryGuardStats{}
+	}
+	return MemoryGuardStats{
+		Enabled:              true,
+		UsedBytes:            g.used.Load(),
+		GlobalBudgetBytes:    g.globalLimit,
+		RejectThresholdBytes: g.rejectThreshold,
+		PerStreamBudgetBytes: g.perStreamLimit,
+		ActiveStreams:        g.activeStreams.Load(),
+		RejectedRequests:     g.rejected.Load(),
+		AbortedStreams:       g.aborted.Load(),
+	}
+}
+
+// StreamBudget 单个流式响应的缓冲预算
+type StreamBudget struct {
+	guard    *MemoryGuard
+	mu       sync.Mutex
+	reserved int64
+	closed   bool
+}
+
+// NewStreamBudget 为一个流式响应创建缓冲预算，结束时需调用 Close
+func (g *MemoryGuard) NewStreamBudget() *StreamBudget {
+	if g == nil {
+		return nil
+	}
+	g.activeStreams.Add(1)
+	return &StreamBudget{guard: g}
+}
+
+// grow 将本流登记的占用提升到 n 字节
+func (b *StreamBudget) grow(n int64) error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if b.closed {
+		return ErrStreamMemoryBudget
+	}
+	if n <= b.reserved {
+		return nil
+	}
+	if n > b.guard.perStreamLimit || !b.guard.TryReserve(n-b.reserved) {
+		b.guard.aborted.Add(1)
+		return ErrStreamMemoryBudget
+	}
+	b.reserved = n
+	return nil
+}
+
+// SplitFunc 包装 scanner 的分割函数：当前行未结束、scanner 需要读取更多数据（可能按 2 倍扩容缓冲）时，
+// 先按扩容后的大小登记占用，超出预算则返回 ErrStreamMemoryBudget 终止扫描。
+func (b *StreamBudget) SplitFunc(inner bufio.SplitFunc) bufio.SplitFunc {
+	if b == nil {
+		return inner
+	}
+	return func(data []byte, atEOF bool) (int, []byte, error) {
+		advance, token, err := inner(data, atEOF)
+		if err == nil && advance == 0 && token == nil && !atEOF {
+			if growErr := b.grow(int64(len(data)) * 2); growErr != nil {
+				return 0, nil, growErr
+			}
+		}
+		return advance, token, err
+	}
+}
+
+// Close 释放本流登记的全部占用（可重复调用）
+func (b *StreamBudget) Close() {
+	if b == nil {
+		return
+	}
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if b.closed {
+		return
+	}
+	b.closed = true
+	b.guard.Release(b.reserved)
+	b.reserved = 0
+	b.guard.activeStreams.Add(-1)
+}
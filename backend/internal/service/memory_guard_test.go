@@ -0,0 +1,87 @@
+package service
+
+import (
+	"bufio"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/Wei-Shaw/sub2api/internal/config"
+	"github.com/stretchr/testify/require"
+)
+
+func newTestMemoryGuard(globalMB, perStreamMB int, threshold float64) *MemoryGuard {
+	cfg := &config.Config{}
+	cfg.Gateway.MemoryGuard = config.GatewayMemoryGuardConfig{
+		Enabled:           true,
+		GlobalBudgetMB:    globalMB,
+		PerStreamBudgetMB: perStreamMB,
+		RejectThreshold:   threshold,
+		RetryAfterSeconds: 3,
+	}
+	return NewMemoryGuard(cfg)
+}
+
+func TestMemoryGuard_DisabledIsNoop(t *testing.T) {
+	var g *MemoryGuard = NewMemoryGuard(&config.Config{})
+	require.Nil(t, g)
+
+	release, ok := g.AdmitRequest(1<<30, true)
+	require.True(t, ok)
+	release()
+	require.True(t, g.TryReserve(1<<40))
+	budget := g.NewStreamBudget()
+	require.Nil(t, budget)
+	budget.Close()
+	require.False(t, g.Stats().Enabled)
+}
+
+func TestMemoryGuard_RejectsStreamsNearBudget(t *testing.T) {
+	g := newTestMemoryGuard(10, 4, 0.5)
+
+	release, ok := g.AdmitRequest(4<<20, false)
+	require.True(t, ok)
+
+	// 已占用 4MB，新流式请求（1MB）将达到 5MB 阈值 -> 拒绝；非流式请求仍可准入
+	_, ok = g.AdmitRequest(1<<20, true)
+	require.False(t, ok)
+	releaseNonStream, ok := g.AdmitRequest(1<<20, false)
+	require.True(t, ok)
+
+	// 超出全局预算的请求体任何情况下都拒绝
+	_, ok = g.AdmitRequest(6<<20, false)
+	require.False(t, ok)
+	require.EqualValues(t, 2, g.Stats().RejectedRequests)
+
+	release()
+	release() // 重复释放不会重复扣减
+	releaseNonStream()
+	require.Zero(t, g.Stats().UsedBytes)
+
+	_, ok = g.AdmitRequest(1<<20, true)
+	require.True(t, ok)
+}
+
+func TestStreamBudget_AbortsOversizedLines(t *testing.T) {
+	g := newTestMemoryGuard(8, 1, 0.9)
+	budget := g.NewStreamBudget()
+	require.EqualValues(t, 1, g.Stats().ActiveStreams)
+
+	long := strings.Repeat("x", 2<<20)
+	scanner := bufio.NewScanner(strings.NewReader("data: short\n" + long + "\n"))
+	scanner.Buffer(make([]byte, 1024), 8<<20)
+	scanner.Split(budget.SplitFunc(bufio.ScanLines))
+
+	require.True(t, scanner.Scan())
+	require.Equal(t, "data: short", scanner.Text())
+	require.False(t, scanner.Scan())
+	require.True(t, errors.Is(scanner.Err(), ErrStreamMemoryBudget))
+	require.True(t, errors.Is(scanner.Err(), bufio.ErrTooLong))
+	require.EqualValues(t, 1, g.Stats().AbortedStreams)
+	require.Positive(t, g.Stats().UsedBytes)
+
+	budget.Close()
+	budget.Close()
+	require.Zero(t, g.Stats().UsedBytes)
+	require.Zero(t, g.Stats().ActiveStreams)
+}
@@ -0,0 +1,104 @@
+package service
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/Wei-Shaw/sub2api/internal/domain"
+	infraerrors "github.com/Wei-Shaw/sub2api/internal/pkg/errors"
+)
+
+type ModelAccessPolicy = domain.ModelAccessPolicy
+
+var ErrInvalidAllowedModels = infraerrors.BadRequest("INVALID_ALLOWED_MODELS", "allowed_models only supports model names with an optional trailing * wildcard")
+
+// ModelAccessDeniedError 请求的模型不在分组（或 API Key）允许范围内
+type ModelAccessDeniedError struct {
+	Model string
+	// ByAPIKey 是否由 API Key 自身的模型限制拒绝
+	ByAPIKey bool
+}
+
+func (e *ModelAccessDeniedError) Error() string {
+	if e.ByAPIKey {
+		return fmt.Sprintf("Model %s is not available for this API key", e.Model)
+	}
+	return fmt.Sprintf("Model %s is not available for this API key's group", e.Model)
+}
+
+// CheckGroupModelAccess 校验分组是否允许请求该模型；未绑定分组或未配置策略时放行
+func CheckGroupModelAccess(group *Group, model string) error {
+	if group == nil || group.ModelAccessPolicy.IsEmpty() || strings.TrimSpace(model) == "" {
+		return nil
+	}
+	if !group.ModelAccessPolicy.Permits(model) {
+		return &ModelAccessDeniedError{Model: model}
+	}
+	return nil
+}
+
+// CheckAPIKeyModelAccess 校验 API Key 是否允许请求该模型：先校验分组策略，再校验 Key 自身的 allowed_models
+func CheckAPIKeyModelAccess(apiKey *APIKey, model string) error {
+	if apiKey == nil {
+		return nil
+	}
+	if err := CheckGroupModelAccess(apiKey.Group, model); err != nil {
+		return err
+	}
+	if len(apiKey.AllowedModels) == 0 || strings.TrimSpace(model) == "" {
+		return nil
+	}
+	if !(ModelAccessPolicy{Allowed: apiKey.AllowedModels}).Permits(model) {
+		return &ModelAccessDeniedError{Model: model, ByAPIKey: true}
+	}
+	return nil
+}
+
+// NormalizeAPIKeyAllowedModels 清理并校验 API Key 的模型限制，空列表返回 nil（不限制）
+func NormalizeAPIKeyAllowedModels(models []string) ([]string, error) {
+	normalized, err := normalizeModelPatterns("allowed", models)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidAllowedModels, err)
+	}
+	return normalized, nil
+}
+
+// NormalizeModelAccessPolicy 清理并校验模型访问策略：去除空白与重复项，通配符仅支持末尾 *
+func NormalizeModelAccessPolicy(policy ModelAccessPolicy) (ModelAccessPolicy, error) {
+	allowed, err := normalizeModelPatterns("allowed", policy.Allowed)
+	if err != nil {
+		return policy, err
+	}
+	denied, err := normalizeModelPatterns("denied", policy.Denied)
+	if err != nil {
+		return policy, err
+	}
+	return ModelAccessPolicy{Allowed: allowed, Denied: denied}, nil
+}
+
+func normalizeModelPatterns(field string, patterns []string) ([]string, error) {
+	if len(patterns) == 0 {
+		return nil, nil
+	}
+	seen := make(map[string]struct{}, len(patterns))
+	result := make([]string, 0, len(patterns))
+	for _, pattern := range patterns {
+		pattern = strings.TrimSpace(pattern)
+		if pattern == "" {
+			continue
+		}
+		if idx := strings.Index(pattern, "*"); idx >= 0 && idx != len(pattern)-1 {
+			return nil, fmt.Errorf("model access policy %s: wildcard is only supported at the end of %q", field, pattern)
+		}
+		key := strings.ToLower(pattern)
+		if _, ok := seen[key]; ok {
+			continue
+		}
+		seen[key] = struct{}{}
+		result = append(result, pattern)
+	}
+	if len(result) == 0 {
+		return nil, nil
+	}
+	return result, nil
+}
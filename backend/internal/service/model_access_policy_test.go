@@ -0,0 +1,83 @@
+//go:build unit
+
+package service
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestCheckGroupModelAccess(t *testing.T) {
+	group := &Group{ModelAccessPolicy: ModelAccessPolicy{
+		Allowed: []string{"claude-sonnet-*", "claude-haiku-4-5"},
+		Denied:  []string{"claude-sonnet-4-5-thinking"},
+	}}
+
+	require.NoError(t, CheckGroupModelAccess(group, "claude-sonnet-4-5-20250929"))
+	require.NoError(t, CheckGroupModelAccess(group, "Claude-Haiku-4-5"))
+
+	err := CheckGroupModelAccess(group, "claude-opus-4-5")
+	var denied *ModelAccessDeniedError
+	require.True(t, errors.As(err, &denied))
+	require.Equal(t, "claude-opus-4-5", denied.Model)
+
+	// 禁止列表优先于允许列表
+	require.Error(t, CheckGroupModelAccess(group, "claude-sonnet-4-5-thinking"))
+
+	// 仅配置禁止列表时其余模型放行
+	denyOnly := &Group{ModelAccessPolicy: ModelAccessPolicy{Denied: []string{"gpt-5*"}}}
+	require.Error(t, CheckGroupModelAccess(denyOnly, "gpt-5.2"))
+	require.NoError(t, CheckGroupModelAccess(denyOnly, "gpt-4.1"))
+
+	require.NoError(t, CheckGroupModelAccess(nil, "any"))
+	require.NoError(t, CheckGroupModelAccess(&Group{}, "any"))
+}
+
+func TestNormalizeModelAccessPolicy(t *testing.T) {
+	policy, err := NormalizeModelAccessPolicy(ModelAccessPolicy{
+		Allowed: []string{" claude-* ", "", "Claude-*"},
+		Denied:  []string{"  "},
+	})
+	require.NoError(t, err)
+	require.Equal(t, ModelAccessPolicy{Allowed: []string{"claude-*"}}, policy)
+
+	_, err = NormalizeModelAccessPolicy(ModelAccessPolicy{Denied: []string{"gpt-*-mini"}})
+	require.Error(t, err)
+}
+
+func TestCheckAPIKeyModelAccess(t *testing.T) {
+	apiKey := &APIKey{
+		AllowedModels: []string{"claude-sonnet-*"},
+		Group:         &Group{ModelAccessPolicy: ModelAccessPolicy{Denied: []string{"claude-sonnet-4-5-thinking"}}},
+	}
+	require.NoError(t, CheckAPIKeyModelAccess(apiKey, "claude-sonnet-4-5"))
+
+	// 分组策略优先校验
+	err := CheckAPIKeyModelAccess(apiKey, "claude-sonnet-4-5-thinking")
+	var denied *ModelAccessDeniedError
+	require.True(t, errors.As(err, &denied))
+	require.False(t, denied.ByAPIKey)
+
+	err = CheckAPIKeyModelAccess(apiKey, "claude-opus-4-5")
+	require.True(t, errors.As(err, &denied))
+	require.True(t, denied.ByAPIKey)
+	require.Contains(t, err.Error(), "not available for this API key")
+
+	require.NoError(t, CheckAPIKeyModelAccess(&APIKey{}, "any"))
+	require.NoError(t, CheckAPIKeyModelAccess(nil, "any"))
+}
+
+func TestNormalizeAPIKeyAllowedModels(t *testing.T) {
+	models, err := NormalizeAPIKeyAllowedModels([]string{" gpt-5 ", "GPT-5", "claude-*", ""})
+	require.NoError(t, err)
+	require.Equal(t, []string{"gpt-5", "claude-*"}, models)
+
+	models, err = NormalizeAPIKeyAllowedModels([]string{})
+	require.NoError(t, err)
+	require.Nil(t, models)
+
+	_, err = NormalizeAPIKeyAllowedModels([]string{"gpt-*-mini"})
+	require.ErrorIs(t, err, ErrInvalidAllowedModels)
+}
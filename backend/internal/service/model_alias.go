@@ -0,0 +1,283 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/Wei-Shaw/sub2api/internal/pkg/ctxkey"
+	"github.com/tidwall/sjson"
+)
+
+// maxModelAliasRules 别名规则数量上限，避免误配置导致每次请求遍历过多规则
+const maxModelAliasRules = 200
+
+// maxModelDeprecationMessageLen 自定义弃用告警文案的最大长度（同时作为响应头返回）
+const maxModelDeprecationMessageLen = 500
+
+// modelAliasCacheTTL 别名规则本地缓存有效期（管理端修改后最多延迟该时长生效）
+const modelAliasCacheTTL = 15 * time.Second
+
+// ModelAliasRule 模型别名/改写规则
+type ModelAliasRule struct {
+	// From 客户端请求的模型名，支持末尾 * 通配（如 claude-3-5-sonnet*）
+	From string `json:"from"`
+	// To 实际用于账号选择与上游转发的模型名
+	To string `json:"to"`
+	// Platform 限定生效平台（anthropic/openai/gemini/antigravity），为空表示所有平台
+	Platform string `json:"platform,omitempty"`
+	// Deprecated 标记 From 为已弃用模型：请求仍按 To 执行，但响应中返回弃用告警
+	Deprecated bool `json:"deprecated,omitempty"`
+	// DeprecationMessage 自定义弃用告警文案，为空时使用默认文案
+	DeprecationMessage string `json:"deprecation_message,omitempty"`
+}
+
+// ModelAliasSettings 模型别名配置
+type ModelAliasSettings struct {
+	// Enabled 是否启用模型别名改写
+	Enabled bool `json:"enabled"`
+	// Rules 别名规则列表
+	Rules []ModelAliasRule `json:"rules"`
+}
+
+// DefaultModelAliasSettings 返回默认模型别名配置（关闭、无规则）
+func DefaultModelAliasSettings() *ModelAliasSettings {
+	return &ModelAliasSettings{Rules: []ModelAliasRule{}}
+}
+
+// normalizeModelAliasSettings 清理并校验规则：去除空白、统一平台小写、拒绝自映射与重复规则
+func normalizeModelAliasSettings(settings *ModelAliasSettings) error {
+	if len(settings.Rules) > maxModelAliasRules {
+		return fmt.Errorf("too many model alias rules (max %d)", maxModelAliasRules)
+	}
+	seen := make(map[string]struct{}, len(settings.Rules))
+	rules := make([]ModelAliasRule, 0, len(settings.Rules))
+	for i, rule := range settings.Rules {
+		rule.From = strings.TrimSpace(rule.From)
+		rule.To = strings.TrimSpace(rule.To)
+		rule.Platform = strings.ToLower(strings.TrimSpace(rule.Platform))
+		rule.DeprecationMessage = strings.TrimSpace(rule.DeprecationMessage)
+		if rule.From == "" || rule.To == "" {
+			return fmt.Errorf("rule %d: from and to are required", i+1)
+		}
+		if strings.Contains(rule.To, "*") {
+			return fmt.Errorf("rule %d: to must not contain wildcard", i+1)
+		}
+		if idx := strings.Index(rule.From, "*"); idx >= 0 && idx != len(rule.From)-1 {
+			return fmt.Errorf("rule %d: wildcard is only supported at the end of from", i+1)
+		}
+		if rule.From == rule.To {
+			return fmt.Errorf("rule %d: from and to must differ", i+1)
+		}
+		if !rule.Deprecated {
+			rule.DeprecationMessage = ""
+		}
+		if len(rule.DeprecationMessage) > maxModelDeprecationMessageLen {
+			return fmt.Errorf("rule %d: deprecation message too long (max %d)", i+1, maxModelDeprecationMessageLen)
+		}
+		if strings.ContainsAny(rule.DeprecationMessage, "\r\n") {
+			return fmt.Errorf("rule %d: deprecation message must be a single line", i+1)
+		}
+		switch rule.Platform {
+		case "", PlatformAnthropic, PlatformOpenAI, PlatformGemini, PlatformAntigravity:
+		default:
+			return fmt.Errorf("rule %d: unsupported platform %q", i+1, rule.Platform)
+		}
+		key := rule.Platform + "|" + rule.From
+		if _, ok := seen[key]; ok {
+			return fmt.Errorf("rule %d: duplicate rule for %q", i+1, rule.From)
+		}
+		seen[key] = struct{}{}
+		rules = append(rules, rule)
+	}
+	settings.Rules = rules
+	return nil
+}
+
+// GetModelAliasSettings 获取模型别名配置
+func (s *SettingService) GetModelAliasSettings(ctx context.Context) (*ModelAliasSettings, error) {
+	value, err := s.settingRepo.GetValue(ctx, SettingKeyModelAliasSettings)
+	if err != nil {
+		if errors.Is(err, ErrSettingNotFound) {
+			return DefaultModelAliasSettings(), nil
+		}
+		return nil, fmt.Errorf("get model alias settings: %w", err)
+	}
+	if value == "" {
+		return DefaultModelAliasSettings(), nil
+	}
+
+	var settings ModelAliasSettings
+	if err := json.Unmarshal([]byte(value), &settings); err != nil {
+		return DefaultModelAliasSettings(), nil
+	}
+	if settings.Rules == nil {
+		settings.Rules = []ModelAliasRule{}
+	}
+	return &settings, nil
+}
+
+// SetModelAliasSettings 设置模型别名配置
+func (s *SettingService) SetModelAliasSettings(ctx context.Context, settings *ModelAliasSettings) error {
+	if settings == nil {
+		return fmt.Errorf("settings cannot be nil")
+	}
+	if err := normalizeModelAliasSettings(settings); err != nil {
+		return err
+	}
+
+	data, err := json.Marshal(settings)
+	if err != nil {
+		return fmt.Errorf("marshal model alias settings: %w", err)
+	}
+	return s.settingRepo.Set(ctx, SettingKeyModelAliasSettings, string(data))
+}
+
+// ModelAliasService 在账号选择前将客户端请求的模型按管理员配置改写为目标模型
+type ModelAliasService struct {
+	settingService *SettingService
+
+	mu        sync.RWMutex
+	cached    *ModelAliasSettings
+	expiresAt time.Time
+}
+
+// NewModelAliasService 创建模型别名服务
+func NewModelAliasService(settingService *SettingService) *ModelAliasService {
+	return &ModelAliasService{settingService: settingService}
+}
+
+// Resolve 按平台查找别名规则，返回改写后的模型；未命中时 ok=false。
+// 精确匹配优先，其次按通配前缀长度最长优先。
+func (s *ModelAliasService) Resolve(ctx context.Context, platform, model string) (string, bool) {
+	rule := s.resolveRule(ctx, platform, model)
+	if rule == nil {
+		return model, false
+	}
+	return rule.To, true
+}
+
+// Apply 解析别名并同步改写请求体中的 model 字段（body 为 nil 时仅解析，如 Gemini 路径中的模型名）。
+// 返回改写后的模型、请求体与命中的规则；未命中或改写失败时原样返回且规则为 nil。
+func (s *ModelAliasService) Apply(ctx context.Context, platform, model string, body []byte) (string, []byte, *ModelAliasRule) {
+	rule := s.resolveRule(ctx, platform, model)
+	if rule == nil {
+		return model, body, nil
+	}
+	if body == nil {
+		return rule.To, body, rule
+	}
+	newBody, err := sjson.SetBytes(body, "model", rule.To)
+	if err != nil {
+		return model, body, nil
+	}
+	return rule.To, newBody, rule
+}
+
+func (s *ModelAliasService) resolveRule(ctx context.Context, platform, model string) *ModelAliasRule {
+	if s == nil || model == "" {
+		return nil
+	}
+	settings := s.load(ctx)
+	if settings == nil || !settings.Enabled {
+		return nil
+	}
+	return resolveModelAliasRule(settings.Rules, platform, model)
+}
+
+// Invalidate 清除本地缓存，下次 Resolve 时重新加载
+func (s *ModelAliasService) Invalidate() {
+	if s == nil {
+		return
+	}
+	s.mu.Lock()
+	s.cached = nil
+	s.expiresAt = time.Time{}
+	s.mu.Unlock()
+}
+
+func (s *ModelAliasService) load(ctx context.Context) *ModelAliasSettings {
+	now := time.Now()
+	s.mu.RLock()
+	if s.cached != nil && now.Before(s.expiresAt) {
+		cached := s.cached
+		s.mu.RUnlock()
+		return cached
+	}
+	stale := s.cached
+	s.mu.RUnlock()
+
+	if s.settingService == nil {
+		return nil
+	}
+	settings, err := s.settingService.GetModelAliasSettings(ctx)
+	if err != nil {
+		log.Printf("[ModelAlias] Failed to load settings: %v", err)
+		// 读取失败时沿用旧缓存，避免数据库抖动导致别名短暂失效
+		return stale
+	}
+
+	s.mu.Lock()
+	s.cached = settings
+	s.expiresAt = now.Add(modelAliasCacheTTL)
+	s.mu.Unlock()
+	return settings
+}
+
+func resolveModelAlias(rules []ModelAliasRule, platform, model string) (string, bool) {
+	rule := resolveModelAliasRule(rules, platform, model)
+	if rule == nil {
+		return model, false
+	}
+	return rule.To, true
+}
+
+func resolveModelAliasRule(rules []ModelAliasRule, platform, model string) *ModelAliasRule {
+	platform = strings.ToLower(platform)
+	var candidates []*ModelAliasRule
+	for i := range rules {
+		rule := &rules[i]
+		if rule.Platform != "" && rule.Platform != platform {
+			continue
+		}
+		if rule.From == model {
+			return rule
+		}
+		if strings.HasSuffix(rule.From, "*") && matchWildcard(rule.From, model) {
+			candidates = append(candidates, rule)
+		}
+	}
+	if len(candidates) == 0 {
+		return nil
+	}
+	sort.SliceStable(candidates, func(i, j int) bool {
+		return len(candidates[i].From) > len(candidates[j].From)
+	})
+	return candidates[0]
+}
+
+// WithModelAliasOrigin 在 context 中记录客户端原始请求的模型名，用于响应中回显。
+// 模型名可能依次经过后缀解析、别名与虚拟模型改写，已记录时保留最早的客户端模型名。
+func WithModelAliasOrigin(ctx context.Context, model string) context.Context {
+	if origin, ok := ctx.Value(ctxkey.ModelAliasOrigin).(string); ok && origin != "" {
+		return ctx
+	}
+	return context.WithValue(ctx, ctxkey.ModelAliasOrigin, model)
+}
+
+// modelAliasEchoModel 返回响应中应回显的模型名：命中别名时为客户端原始模型，否则为 fallback
+func modelAliasEchoModel(ctx context.Context, fallback string) string {
+	if ctx == nil {
+		return fallback
+	}
+	if origin, ok := ctx.Value(ctxkey.ModelAliasOrigin).(string); ok && origin != "" {
+		return origin
+	}
+	return fallback
+}
@@ -0,0 +1,128 @@
+//go:build unit
+
+package service
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestResolveModelAlias(t *testing.T) {
+	rules := []ModelAliasRule{
+		{From: "gpt-4o", To: "gpt-5.2"},
+		{From: "claude-3-5-sonnet*", To: "claude-sonnet-4-5"},
+		{From: "claude-3-5-sonnet-2024*", To: "claude-sonnet-4-5-20250929"},
+		{From: "gemini-pro", To: "gemini-2.5-pro", Platform: PlatformGemini},
+	}
+
+	tests := []struct {
+		name     string
+		platform string
+		model    string
+		want     string
+		wantOK   bool
+	}{
+		{"exact", PlatformOpenAI, "gpt-4o", "gpt-5.2", true},
+		{"exact does not match prefix", PlatformOpenAI, "gpt-4o-mini", "gpt-4o-mini", false},
+		{"wildcard", PlatformAnthropic, "claude-3-5-sonnet-latest", "claude-sonnet-4-5", true},
+		{"longest wildcard wins", PlatformAnthropic, "claude-3-5-sonnet-20241022", "claude-sonnet-4-5-20250929", true},
+		{"platform scoped hit", PlatformGemini, "gemini-pro", "gemini-2.5-pro", true},
+		{"platform scoped miss", PlatformAntigravity, "gemini-pro", "gemini-pro", false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, ok := resolveModelAlias(rules, tt.platform, tt.model)
+			require.Equal(t, tt.wantOK, ok)
+			require.Equal(t, tt.want, got)
+		})
+	}
+}
+
+func TestNormalizeModelAliasSettings(t *testing.T) {
+	settings := &ModelAliasSettings{Rules: []ModelAliasRule{{From: " gpt-4o ", To: " gpt-5.2 ", Platform: " OpenAI "}}}
+	require.NoError(t, normalizeModelAliasSettings(settings))
+	require.Equal(t, ModelAliasRule{From: "gpt-4o", To: "gpt-5.2", Platform: PlatformOpenAI}, settings.Rules[0])
+
+	// 未标记弃用时丢弃弃用文案
+	settings = &ModelAliasSettings{Rules: []ModelAliasRule{{From: "gpt-4o", To: "gpt-5.2", DeprecationMessage: "migrate"}}}
+	require.NoError(t, normalizeModelAliasSettings(settings))
+	require.Empty(t, settings.Rules[0].DeprecationMessage)
+
+	invalid := [][]ModelAliasRule{
+		{{From: "", To: "gpt-5.2"}},
+		{{From: "gpt-4o", To: "gpt-4o"}},
+		{{From: "gpt-*-mini", To: "gpt-5.2"}},
+		{{From: "gpt-4o", To: "gpt-5*"}},
+		{{From: "gpt-4o", To: "gpt-5.2", Platform: "unknown"}},
+		{{From: "gpt-4o", To: "gpt-5.2"}, {From: "gpt-4o", To: "gpt-5.1"}},
+		{{From: "gpt-4o", To: "gpt-5.2", Deprecated: true, DeprecationMessage: "line1\nline2"}},
+	}
+	for _, rules := range invalid {
+		require.Error(t, normalizeModelAliasSettings(&ModelAliasSettings{Rules: rules}), "%+v", rules)
+	}
+}
+
+func TestModelAliasService_Apply(t *testing.T) {
+	repo := &settingRepoStub{values: map[string]string{
+		SettingKeyModelAliasSettings: `{"enabled":true,"rules":[{"from":"gpt-4o","to":"gpt-5.2"}]}`,
+	}}
+	svc := NewModelAliasService(NewSettingService(repo, nil))
+
+	model, body, rule := svc.Apply(context.Background(), PlatformOpenAI, "gpt-4o", []byte(`{"model":"gpt-4o","stream":true}`))
+	require.NotNil(t, rule)
+	require.Equal(t, "gpt-5.2", model)
+	require.JSONEq(t, `{"model":"gpt-5.2","stream":true}`, string(body))
+	require.Nil(t, NewModelDeprecation("gpt-4o", rule))
+
+	model, _, rule = svc.Apply(context.Background(), PlatformOpenAI, "gpt-4.1", nil)
+	require.Nil(t, rule)
+	require.Equal(t, "gpt-4.1", model)
+}
+
+func TestModelAliasService_ApplyDeprecatedRule(t *testing.T) {
+	repo := &settingRepoStub{values: map[string]string{
+		SettingKeyModelAliasSettings: `{"enabled":true,"rules":[{"from":"claude-3-opus*","to":"claude-opus-4-1","deprecated":true}]}`,
+	}}
+	svc := NewModelAliasService(NewSettingService(repo, nil))
+
+	model, _, rule := svc.Apply(context.Background(), PlatformAnthropic, "claude-3-opus-20240229", nil)
+	require.Equal(t, "claude-opus-4-1", model)
+	deprecation := NewModelDeprecation("claude-3-opus-20240229", rule)
+	require.NotNil(t, deprecation)
+	require.Equal(t, "claude-3-opus-20240229; replacement=claude-opus-4-1", deprecation.HeaderValue())
+	require.Contains(t, deprecation.Message, "claude-opus-4-1")
+
+	ctx := WithModelDeprecation(context.Background(), deprecation)
+	body := withModelDeprecationWarning(ctx, []byte(`{"id":"msg_1","model":"claude-3-opus-20240229"}`))
+	require.JSONEq(t, `{"id":"msg_1","model":"claude-3-opus-20240229","warning":{"type":"model_deprecated","model":"claude-3-opus-20240229","replacement":"claude-opus-4-1","message":"`+deprecation.Message+`"}}`, string(body))
+
+	// 非 JSON 对象或上游已返回 warning 时不改写
+	require.Equal(t, `[1]`, string(withModelDeprecationWarning(ctx, []byte(`[1]`))))
+	require.Equal(t, `{"warning":"x"}`, string(withModelDeprecationWarning(ctx, []byte(`{"warning":"x"}`))))
+	require.Equal(t, `{"id":"1"}`, string(withModelDeprecationWarning(context.Background(), []byte(`{"id":"1"}`))))
+}
+
+func TestModelAliasService_DisabledOrMissing(t *testing.T) {
+	repo := &settingRepoStub{values: map[string]string{
+		SettingKeyModelAliasSettings: `{"enabled":false,"rules":[{"from":"gpt-4o","to":"gpt-5.2"}]}`,
+	}}
+	svc := NewModelAliasService(NewSettingService(repo, nil))
+	_, ok := svc.Resolve(context.Background(), PlatformOpenAI, "gpt-4o")
+	require.False(t, ok)
+
+	empty := NewModelAliasService(NewSettingService(&settingRepoStub{}, nil))
+	_, ok = empty.Resolve(context.Background(), PlatformOpenAI, "gpt-4o")
+	require.False(t, ok)
+
+	var nilSvc *ModelAliasService
+	_, ok = nilSvc.Resolve(context.Background(), PlatformOpenAI, "gpt-4o")
+	require.False(t, ok)
+}
+
+func TestModelAliasEchoModel(t *testing.T) {
+	ctx := context.Background()
+	require.Equal(t, "gpt-5.2", modelAliasEchoModel(ctx, "gpt-5.2"))
+	require.Equal(t, "gpt-4o", modelAliasEchoModel(WithModelAliasOrigin(ctx, "gpt-4o"), "gpt-5.2"))
+}
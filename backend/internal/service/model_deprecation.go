@@ -0,0 +1,85 @@
+package service
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/Wei-Shaw/sub2api/internal/pkg/ctxkey"
+	"github.com/tidwall/gjson"
+	"github.com/tidwall/sjson"
+)
+
+// ModelDeprecatedHeader 请求的模型已弃用并被重定向时返回的响应头
+const ModelDeprecatedHeader = "X-Model-Deprecated"
+
+// ModelDeprecation 已弃用模型的重定向信息
+type ModelDeprecation struct {
+	// Model 客户端请求的已弃用模型
+	Model string `json:"model"`
+	// Replacement 实际执行的替代模型
+	Replacement string `json:"replacement"`
+	// Message 告警文案
+	Message string `json:"message"`
+}
+
+// NewModelDeprecation 由命中的别名规则构建弃用信息；规则未标记弃用时返回 nil
+func NewModelDeprecation(model string, rule *ModelAliasRule) *ModelDeprecation {
+	if rule == nil || !rule.Deprecated {
+		return nil
+	}
+	message := rule.DeprecationMessage
+	if message == "" {
+		message = fmt.Sprintf("Model %s is deprecated and this request was served by %s. Please migrate to %s.", model, rule.To, rule.To)
+	}
+	return &ModelDeprecation{Model: model, Replacement: rule.To, Message: message}
+}
+
+// HeaderValue 返回 X-Model-Deprecated 响应头的值，如 "claude-3-opus; replacement=claude-opus-4-1"
+func (d *ModelDeprecation) HeaderValue() string {
+	return fmt.Sprintf("%s; replacement=%s", d.Model, d.Replacement)
+}
+
+// warning 返回写入非流式响应的 warning 字段内容
+func (d *ModelDeprecation) warning() map[string]string {
+	return map[string]string{
+		"type":        "model_deprecated",
+		"model":       d.Model,
+		"replacement": d.Replacement,
+		"message":     d.Message,
+	}
+}
+
+// WithModelDeprecation 在 context 中记录弃用信息，供非流式响应写入告警字段
+func WithModelDeprecation(ctx context.Context, deprecation *ModelDeprecation) context.Context {
+	if deprecation == nil {
+		return ctx
+	}
+	return context.WithValue(ctx, ctxkey.ModelDeprecation, deprecation)
+}
+
+// ModelDeprecationFromContext 读取 context 中的弃用信息，未命中时返回 nil
+func ModelDeprecationFromContext(ctx context.Context) *ModelDeprecation {
+	if ctx == nil {
+		return nil
+	}
+	deprecation, _ := ctx.Value(ctxkey.ModelDeprecation).(*ModelDeprecation)
+	return deprecation
+}
+
+// withModelDeprecationWarning 在非流式 JSON 响应的顶层写入 warning 字段；
+// 未命中弃用规则、响应不是 JSON 对象或已有 warning 字段时原样返回。
+func withModelDeprecationWarning(ctx context.Context, body []byte) []byte {
+	deprecation := ModelDeprecationFromContext(ctx)
+	if deprecation == nil {
+		return body
+	}
+	parsed := gjson.ParseBytes(body)
+	if !parsed.IsObject() || parsed.Get("warning").Exists() {
+		return body
+	}
+	newBody, err := sjson.SetBytes(body, "warning", deprecation.warning())
+	if err != nil {
+		return body
+	}
+	return newBody
+}
@@ -0,0 +1,225 @@
+package service
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/Wei-Shaw/sub2api/internal/domain"
+	"github.com/tidwall/gjson"
+	"github.com/tidwall/sjson"
+)
+
+const (
+	ModelParamPolicyActionClamp  = domain.ModelParamPolicyActionClamp
+	ModelParamPolicyActionReject = domain.ModelParamPolicyActionReject
+)
+
+type ModelParamPolicy = domain.ModelParamPolicy
+
+type ParamRange = domain.ParamRange
+
+// ModelParamPolicyError 请求参数违反分组模型参数策略（reject 模式），应以 400 返回给客户端。
+type ModelParamPolicyError struct {
+	Message string
+}
+
+func (e *ModelParamPolicyError) Error() string {
+	return e.Message
+}
+
+// modelParamPolicyReasoningEfforts 推理强度默认值允许的取值
+var modelParamPolicyReasoningEfforts = map[string]struct{}{
+	"none": {}, "minimal": {}, "low": {}, "medium": {}, "high": {}, "xhigh": {},
+}
+
+// modelParamPaths 不同协议下各参数在请求体中的路径
+type modelParamPaths struct {
+	temperature     string
+	topP            string
+	maxOutputTokens string
+}
+
+func modelParamPathsFor(protocol string) modelParamPaths {
+	switch protocol {
+	case domain.PlatformGemini:
+		return modelParamPaths{temperature: "generationConfig.temperature", topP: "generationConfig.topP", maxOutputTokens: "generationConfig.maxOutputTokens"}
+	case domain.PlatformOpenAI:
+		// Chat Completions 已在 normalizeChatCompletionsRequest 中转换为 Responses 的 max_output_tokens
+		return modelParamPaths{temperature: "temperature", topP: "top_p", maxOutputTokens: "max_output_tokens"}
+	}
+	return modelParamPaths{temperature: "temperature", topP: "top_p", maxOutputTokens: "max_tokens"}
+}
+
+// ApplyModelParamPolicy 按分组的模型参数策略校验/修正请求体中的 temperature、top_p 与输出 token 上限，
+// 并在 OpenAI 请求未指定推理强度时注入默认值。
+// clamp 模式下返回修正后的请求体；reject 模式下参数不合规时返回 *ModelParamPolicyError。
+// 未配置策略或请求未携带相关参数时原样返回 body。
+func ApplyModelParamPolicy(group *Group, requestedModel string, body []byte, protocol string) ([]byte, error) {
+	policy := group.GetModelParamPolicy(requestedModel)
+	if policy == nil {
+		return body, nil
+	}
+	reject := policy.Action == ModelParamPolicyActionReject
+	paths := modelParamPathsFor(protocol)
+
+	temperature := gjson.GetBytes(body, paths.temperature)
+	topP := gjson.GetBytes(body, paths.topP)
+
+	if policy.TemperatureTopPExclusive && isSetParam(temperature) && isSetParam(topP) {
+		if reject {
+			return nil, &ModelParamPolicyError{
+				Message: fmt.Sprintf("temperature and top_p cannot both be specified for model %s", requestedModel),
+			}
+		}
+		next, err := sjson.DeleteBytes(body, paths.topP)
+		if err != nil {
+			return nil, fmt.Errorf("remove top_p: %w", err)
+		}
+		body = next
+		topP = gjson.Result{}
+	}
+
+	var err error
+	if body, err = applyParamRange(body, paths.temperature, "temperature", temperature, policy.Temperature, reject, requestedModel); err != nil {
+		return nil, err
+	}
+	if body, err = applyParamRange(body, paths.topP, "top_p", topP, policy.TopP, reject, requestedModel); err != nil {
+		return nil, err
+	}
+	if body, err = applyMaxOutputTokens(body, paths.maxOutputTokens, policy.MaxOutputTokens, reject, requestedModel, protocol); err != nil {
+		return nil, err
+	}
+	if protocol == domain.PlatformOpenAI {
+		if body, err = applyReasoningEffortDefault(body, policy.ReasoningEffortDefault); err != nil {
+			return nil, err
+		}
+	}
+	return body, nil
+}
+
+// applyMaxOutputTokens 将输出 token 数收敛到上限；请求未携带时注入上限，避免上游按模型最大输出计费。
+// Anthropic 请求开启 thinking 时同步收敛 budget_tokens，保证其小于 max_tokens。
+func applyMaxOutputTokens(body []byte, path string, limit *int, reject bool, requestedModel, protocol string) ([]byte, error) {
+	if limit == nil || *limit <= 0 {
+		return body, nil
+	}
+	value := gjson.GetBytes(body, path)
+	if isSetParam(value) {
+		// 非数值类型交由上游校验
+		if value.Type != gjson.Number || value.Int() <= int64(*limit) {
+			return body, nil
+		}
+		if reject {
+			return nil, &ModelParamPolicyError{
+				Message: fmt.Sprintf("%s must be <= %d for model %s", path, *limit, requestedModel),
+			}
+		}
+	}
+	next, err := sjson.SetBytes(body, path, *limit)
+	if err != nil {
+		return nil, fmt.Errorf("set %s: %w", path, err)
+	}
+	if protocol == domain.PlatformAnthropic {
+		budget := gjson.GetBytes(next, "thinking.budget_tokens")
+		if budget.Type == gjson.Number && budget.Int() >= int64(*limit) {
+			if next, err = sjson.SetBytes(next, "thinking.budget_tokens", *limit-1); err != nil {
+				return nil, fmt.Errorf("clamp thinking.budget_tokens: %w", err)
+			}
+		}
+	}
+	return next, nil
+}
+
+// applyReasoningEffortDefault 请求未指定推理强度（reasoning.effort / reasoning_effort）时注入默认值
+func applyReasoningEffortDefault(body []byte, effort string) ([]byte, error) {
+	if effort == "" {
+		return body, nil
+	}
+	if isSetParam(gjson.GetBytes(body, "reasoning.effort")) || isSetParam(gjson.GetBytes(body, "reasoning_effort")) {
+		return body, nil
+	}
+	next, err := sjson.SetBytes(body, "reasoning.effort", effort)
+	if err != nil {
+		return nil, fmt.Errorf("set reasoning.effort: %w", err)
+	}
+	return next, nil
+}
+
+func applyParamRange(body []byte, path, name string, value gjson.Result, rng *ParamRange, reject bool, requestedModel string) ([]byte, error) {
+	// 非数值类型交由上游校验，网关不做猜测
+	if rng == nil || value.Type != gjson.Number {
+		return body, nil
+	}
+	v := value.Float()
+	target := v
+	if rng.Min != nil && v < *rng.Min {
+		target = *rng.Min
+	}
+	if rng.Max != nil && v > *rng.Max {
+		target = *rng.Max
+	}
+	if target == v {
+		return body, nil
+	}
+	if reject {
+		return nil, &ModelParamPolicyError{
+			Message: fmt.Sprintf("%s must be %s for model %s", name, describeParamRange(rng), requestedModel),
+		}
+	}
+	next, err := sjson.SetBytes(body, path, target)
+	if err != nil {
+		return nil, fmt.Errorf("clamp %s: %w", name, err)
+	}
+	return next, nil
+}
+
+func isSetParam(value gjson.Result) bool {
+	return value.Exists() && value.Type != gjson.Null
+}
+
+func describeParamRange(rng *ParamRange) string {
+	format := func(v float64) string { return strconv.FormatFloat(v, 'f', -1, 64) }
+	switch {
+	case rng.Min != nil && rng.Max != nil:
+		return fmt.Sprintf("between %s and %s", format(*rng.Min), format(*rng.Max))
+	case rng.Min != nil:
+		return ">= " + format(*rng.Min)
+	default:
+		return "<= " + format(*rng.Max)
+	}
+}
+
+// ValidateModelParamPolicies 校验管理员提交的模型参数策略配置
+func ValidateModelParamPolicies(policies map[string]ModelParamPolicy) error {
+	for pattern, policy := range policies {
+		if strings.TrimSpace(pattern) == "" {
+			return fmt.Errorf("model param policy pattern cannot be empty")
+		}
+		switch policy.Action {
+		case "", ModelParamPolicyActionClamp, ModelParamPolicyActionReject:
+		default:
+			return fmt.Errorf("model param policy %q: invalid action %q", pattern, policy.Action)
+		}
+		if policy.MaxOutputTokens != nil && *policy.MaxOutputTokens <= 0 {
+			return fmt.Errorf("model param policy %q: max_output_tokens must be positive", pattern)
+		}
+		if policy.ReasoningEffortDefault != "" {
+			if _, ok := modelParamPolicyReasoningEfforts[policy.ReasoningEffortDefault]; !ok {
+				return fmt.Errorf("model param policy %q: invalid reasoning_effort_default %q", pattern, policy.ReasoningEffortDefault)
+			}
+		}
+		for name, rng := range map[string]*ParamRange{"temperature": policy.Temperature, "top_p": policy.TopP} {
+			if rng == nil {
+				continue
+			}
+			if (rng.Min != nil && *rng.Min < 0) || (rng.Max != nil && *rng.Max < 0) {
+				return fmt.Errorf("model param policy %q: %s range cannot be negative", pattern, name)
+			}
+			if rng.Min != nil && rng.Max != nil && *rng.Min > *rng.Max {
+				return fmt.Errorf("model param policy %q: %s min cannot exceed max", pattern, name)
+			}
+		}
+	}
+	return nil
+}
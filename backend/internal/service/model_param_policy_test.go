@@ -0,0 +1,175 @@
+//go:build unit
+
+package service
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/Wei-Shaw/sub2api/internal/domain"
+	"github.com/stretchr/testify/require"
+	"github.com/tidwall/gjson"
+)
+
+func TestGroup_GetModelParamPolicy_ExactBeforeWildcard(t *testing.T) {
+	group := &Group{
+		ModelParamPolicies: map[string]ModelParamPolicy{
+			"claude-*":          {Action: ModelParamPolicyActionClamp},
+			"claude-opus-*":     {Action: ModelParamPolicyActionReject},
+			"claude-opus-4-1-x": {TemperatureTopPExclusive: true},
+		},
+	}
+
+	exact := group.GetModelParamPolicy("claude-opus-4-1-x")
+	require.NotNil(t, exact)
+	require.True(t, exact.TemperatureTopPExclusive)
+
+	longest := group.GetModelParamPolicy("claude-opus-4-20250514")
+	require.NotNil(t, longest)
+	require.Equal(t, ModelParamPolicyActionReject, longest.Action)
+
+	require.Nil(t, group.GetModelParamPolicy("gpt-4o"))
+	require.Nil(t, (*Group)(nil).GetModelParamPolicy("claude-opus-4"))
+}
+
+func TestApplyModelParamPolicy_ClampTemperature(t *testing.T) {
+	group := &Group{
+		ModelParamPolicies: map[string]ModelParamPolicy{
+			"claude-*": {Temperature: &ParamRange{Min: float64Ptr(0), Max: float64Ptr(1)}},
+		},
+	}
+	body := []byte(`{"model":"claude-sonnet-4","temperature":1.5,"messages":[]}`)
+
+	out, err := ApplyModelParamPolicy(group, "claude-sonnet-4", body, domain.PlatformAnthropic)
+	require.NoError(t, err)
+	require.Equal(t, 1.0, gjson.GetBytes(out, "temperature").Float())
+}
+
+func TestApplyModelParamPolicy_ExclusiveDropsTopP(t *testing.T) {
+	group := &Group{
+		ModelParamPolicies: map[string]ModelParamPolicy{
+			"claude-*": {TemperatureTopPExclusive: true},
+		},
+	}
+	body := []byte(`{"model":"claude-sonnet-4","temperature":0.7,"top_p":0.9}`)
+
+	out, err := ApplyModelParamPolicy(group, "claude-sonnet-4", body, domain.PlatformAnthropic)
+	require.NoError(t, err)
+	require.Equal(t, 0.7, gjson.GetBytes(out, "temperature").Float())
+	require.False(t, gjson.GetBytes(out, "top_p").Exists())
+}
+
+func TestApplyModelParamPolicy_RejectReturnsPolicyError(t *testing.T) {
+	group := &Group{
+		ModelParamPolicies: map[string]ModelParamPolicy{
+			"gpt-*": {
+				TopP:   &ParamRange{Max: float64Ptr(0.95)},
+				Action: ModelParamPolicyActionReject,
+			},
+		},
+	}
+	body := []byte(`{"model":"gpt-5","top_p":1}`)
+
+	_, err := ApplyModelParamPolicy(group, "gpt-5", body, domain.PlatformOpenAI)
+	var policyErr *ModelParamPolicyError
+	require.True(t, errors.As(err, &policyErr))
+	require.Contains(t, policyErr.Message, "top_p must be <= 0.95")
+}
+
+func TestApplyModelParamPolicy_GeminiGenerationConfig(t *testing.T) {
+	group := &Group{
+		ModelParamPolicies: map[string]ModelParamPolicy{
+			"gemini-*": {TopP: &ParamRange{Min: float64Ptr(0.1)}},
+		},
+	}
+	body := []byte(`{"contents":[],"generationConfig":{"topP":0}}`)
+
+	out, err := ApplyModelParamPolicy(group, "gemini-2.5-pro", body, domain.PlatformGemini)
+	require.NoError(t, err)
+	require.Equal(t, 0.1, gjson.GetBytes(out, "generationConfig.topP").Float())
+}
+
+func TestApplyModelParamPolicy_NoPolicyKeepsBody(t *testing.T) {
+	body := []byte(`{"model":"claude-sonnet-4","temperature":2}`)
+
+	out, err := ApplyModelParamPolicy(&Group{}, "claude-sonnet-4", body, domain.PlatformAnthropic)
+	require.NoError(t, err)
+	require.Equal(t, body, out)
+}
+
+func TestApplyModelParamPolicy_MaxOutputTokensCeiling(t *testing.T) {
+	group := &Group{
+		ModelParamPolicies: map[string]ModelParamPolicy{
+			"claude-*": {MaxOutputTokens: intPtr(4096)},
+			"gpt-*":    {MaxOutputTokens: intPtr(2048), Action: ModelParamPolicyActionReject},
+		},
+	}
+
+	// 超过上限时收敛，并同步收敛 thinking.budget_tokens
+	out, err := ApplyModelParamPolicy(group, "claude-sonnet-4", []byte(`{"max_tokens":32000,"thinking":{"type":"enabled","budget_tokens":16000}}`), domain.PlatformAnthropic)
+	require.NoError(t, err)
+	require.Equal(t, int64(4096), gjson.GetBytes(out, "max_tokens").Int())
+	require.Equal(t, int64(4095), gjson.GetBytes(out, "thinking.budget_tokens").Int())
+
+	// 未携带时注入上限
+	out, err = ApplyModelParamPolicy(group, "claude-sonnet-4", []byte(`{"messages":[]}`), domain.PlatformAnthropic)
+	require.NoError(t, err)
+	require.Equal(t, int64(4096), gjson.GetBytes(out, "max_tokens").Int())
+
+	// 未超过上限时保持不变
+	body := []byte(`{"max_output_tokens":1024}`)
+	out, err = ApplyModelParamPolicy(group, "gpt-5", body, domain.PlatformOpenAI)
+	require.NoError(t, err)
+	require.Equal(t, body, out)
+
+	_, err = ApplyModelParamPolicy(group, "gpt-5", []byte(`{"max_output_tokens":4096}`), domain.PlatformOpenAI)
+	var policyErr *ModelParamPolicyError
+	require.True(t, errors.As(err, &policyErr))
+	require.Contains(t, policyErr.Message, "max_output_tokens must be <= 2048")
+}
+
+func TestApplyModelParamPolicy_ReasoningEffortDefault(t *testing.T) {
+	group := &Group{
+		ModelParamPolicies: map[string]ModelParamPolicy{
+			"gpt-*":    {ReasoningEffortDefault: "low"},
+			"claude-*": {ReasoningEffortDefault: "low"},
+		},
+	}
+
+	out, err := ApplyModelParamPolicy(group, "gpt-5", []byte(`{"input":"hi"}`), domain.PlatformOpenAI)
+	require.NoError(t, err)
+	require.Equal(t, "low", gjson.GetBytes(out, "reasoning.effort").String())
+
+	// 客户端已指定时不覆盖
+	out, err = ApplyModelParamPolicy(group, "gpt-5", []byte(`{"reasoning":{"effort":"high"}}`), domain.PlatformOpenAI)
+	require.NoError(t, err)
+	require.Equal(t, "high", gjson.GetBytes(out, "reasoning.effort").String())
+
+	body := []byte(`{"reasoning_effort":"medium"}`)
+	out, err = ApplyModelParamPolicy(group, "gpt-5", body, domain.PlatformOpenAI)
+	require.NoError(t, err)
+	require.Equal(t, body, out)
+
+	// 非 OpenAI 协议不注入
+	out, err = ApplyModelParamPolicy(group, "claude-sonnet-4", []byte(`{"messages":[]}`), domain.PlatformAnthropic)
+	require.NoError(t, err)
+	require.False(t, gjson.GetBytes(out, "reasoning").Exists())
+}
+
+func TestValidateModelParamPolicies(t *testing.T) {
+	require.NoError(t, ValidateModelParamPolicies(map[string]ModelParamPolicy{
+		"claude-*": {Temperature: &ParamRange{Min: float64Ptr(0), Max: float64Ptr(1)}},
+	}))
+	require.Error(t, ValidateModelParamPolicies(map[string]ModelParamPolicy{
+		"claude-*": {Temperature: &ParamRange{Min: float64Ptr(1), Max: float64Ptr(0.5)}},
+	}))
+	require.Error(t, ValidateModelParamPolicies(map[string]ModelParamPolicy{
+		"claude-*": {Action: "drop"},
+	}))
+	require.Error(t, ValidateModelParamPolicies(map[string]ModelParamPolicy{
+		"gpt-*": {MaxOutputTokens: intPtr(0)},
+	}))
+	require.Error(t, ValidateModelParamPolicies(map[string]ModelParamPolicy{
+		"gpt-*": {ReasoningEffortDefault: "extreme"},
+	}))
+}
@@ -0,0 +1,255 @@
+package service
+
+import (
+	"context"
+	"log"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	infraerrors "github.com/Wei-Shaw/sub2api/internal/pkg/errors"
+)
+
+var (
+	ErrModelPriceNotFound = infraerrors.NotFound("MODEL_PRICE_NOT_FOUND", "model price not found")
+	ErrModelPriceExists   = infraerrors.Conflict("MODEL_PRICE_EXISTS", "a price version for this platform/model/effective_from already exists")
+	ErrModelPriceInEffect = infraerrors.Conflict("MODEL_PRICE_IN_EFFECT", "price version is already in effect; add a new version instead of modifying it")
+	ErrInvalidModelPrice  = infraerrors.BadRequest("INVALID_MODEL_PRICE", "model is required, platform must be valid and prices must be non-negative")
+)
+
+const (
+	// modelPriceCacheTTL 价格表内存快照刷新间隔
+	modelPriceCacheTTL = 60 * time.Second
+	// modelPriceLoadTimeout 刷新快照时的数据库超时
+	modelPriceLoadTimeout = 3 * time.Second
+	// modelPriceUnit 价格表以 USD / 1M tokens 保存
+	modelPriceUnit = 1_000_000
+)
+
+// ModelPrice 管理员维护的模型价格版本（价格单位：USD / 1M tokens）
+type ModelPrice struct {
+	ID int64 `json:"id"`
+	// Platform 为空表示适用于所有平台；同一模型平台专属价格优先
+	Platform             string    `json:"platform"`
+	Model                string    `json:"model"`
+	InputPrice           float64   `json:"input_price"`
+	OutputPrice          float64   `json:"output_price"`
+	CacheCreationPrice   float64   `json:"cache_creation_price"`
+	CacheCreation1hPrice float64   `json:"cache_creation_1h_price"`
+	CacheReadPrice       float64   `json:"cache_read_price"`
+	EffectiveFrom        time.Time `json:"effective_from"`
+	Notes                string    `json:"notes"`
+	CreatedAt            time.Time `json:"created_at"`
+	UpdatedAt            time.Time `json:"updated_at"`
+}
+
+// toModelPricing 转换为计费使用的 per-token 价格；未配置缓存价格时按输入价格计费
+func (p *ModelPrice) toModelPricing() *ModelPricing {
+	input := p.InputPrice / modelPriceUnit
+	cacheCreation := p.CacheCreationPrice / modelPriceUnit
+	if cacheCreation <= 0 {
+		cacheCreation = input
+	}
+	cacheRead := p.CacheReadPrice / modelPriceUnit
+	if cacheRead <= 0 {
+		cacheRead = input
+	}
+	cache1h := p.CacheCreation1hPrice / modelPriceUnit
+	return &ModelPricing{
+		InputPricePerToken:         input,
+		OutputPricePerToken:        p.OutputPrice / modelPriceUnit,
+		CacheCreationPricePerToken: cacheCreation,
+		CacheReadPricePerToken:     cacheRead,
+		CacheCreation5mPrice:       cacheCreation,
+		CacheCreation1hPrice:       cache1h,
+		SupportsCacheBreakdown:     cache1h > 0 && cache1h > cacheCreation,
+	}
+}
+
+// ModelPriceRepository 模型价格表数据访问接口
+type ModelPriceRepository interface {
+	// List 按可选的平台/模型过滤，按 platform, model, effective_from DESC 排序
+	List(ctx context.Context, platform, model string) ([]ModelPrice, error)
+	GetByID(ctx context.Context, id int64) (*ModelPrice, error)
+	// Create 同一 platform/model/effective_from 已存在时返回 ErrModelPriceExists
+	Create(ctx context.Context, price *ModelPrice) error
+	Update(ctx context.Context, price *ModelPrice) error
+	Delete(ctx context.Context, id int64) error
+}
+
+// ModelPriceService 模型价格表管理与计费查询
+// 计费路径只读内存快照（定期刷新，写入后立即失效），不在热路径上逐请求查库。
+type ModelPriceService struct {
+	repo ModelPriceRepository
+
+	mu       sync.RWMutex
+	versions map[string][]ModelPrice // key: platform|model，按 effective_from 降序
+	loadedAt time.Time
+}
+
+// NewModelPriceService 创建模型价格服务
+func NewModelPriceService(repo ModelPriceRepository) *ModelPriceService {
+	return &ModelPriceService{repo: repo}
+}
+
+func modelPriceKey(platform, model string) string {
+	return platform + "|" + model
+}
+
+// normalizeModelPrice 校验并规范化价格版本
+func normalizeModelPrice(price *ModelPrice) error {
+	price.Platform = strings.ToLower(strings.TrimSpace(price.Platform))
+	price.Model = strings.ToLower(strings.TrimSpace(price.Model))
+	price.Notes = strings.TrimSpace(price.Notes)
+	if price.Model == "" {
+		return ErrInvalidModelPrice
+	}
+	switch price.Platform {
+	case "", PlatformAnthropic, PlatformOpenAI, PlatformGemini, PlatformAntigravity:
+	default:
+		return ErrInvalidModelPrice
+	}
+	if price.InputPrice < 0 || price.OutputPrice < 0 || price.CacheCreationPrice < 0 ||
+		price.CacheCreation1hPrice < 0 || price.CacheReadPrice < 0 {
+		return ErrInvalidModelPrice
+	}
+	return nil
+}
+
+// List 列出价格版本
+func (s *ModelPriceService) List(ctx context.Context, platform, model string) ([]ModelPrice, error) {
+	return s.repo.List(ctx, strings.ToLower(strings.TrimSpace(platform)), strings.ToLower(strings.TrimSpace(model)))
+}
+
+// GetByID 获取价格版本
+func (s *ModelPriceService) GetByID(ctx context.Context, id int64) (*ModelPrice, error) {
+	return s.repo.GetByID(ctx, id)
+}
+
+// Create 新增价格版本；未指定生效时间时立即生效
+func (s *ModelPriceService) Create(ctx context.Context, price *ModelPrice) (*ModelPrice, error) {
+	if err := normalizeModelPrice(price); err != nil {
+		return nil, err
+	}
+	if price.EffectiveFrom.IsZero() {
+		price.EffectiveFrom = time.Now()
+	}
+	if err := s.repo.Create(ctx, price); err != nil {
+		return nil, err
+	}
+	s.Invalidate()
+	return price, nil
+}
+
+// Update 修改尚未生效的价格版本（已生效版本不可修改，避免改变已计费用量的定价依据）
+func (s *ModelPriceService) Update(ctx context.Context, id int64, apply func(*ModelPrice)) (*ModelPrice, error) {
+	price, err := s.repo.GetByID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if !price.EffectiveFrom.After(time.Now()) {
+		return nil, ErrModelPriceInEffect
+	}
+	apply(price)
+	if err := normalizeModelPrice(price); err != nil {
+		return nil, err
+	}
+	if price.EffectiveFrom.IsZero() {
+		return nil, ErrInvalidModelPrice
+	}
+	if err := s.repo.Update(ctx, price); err != nil {
+		return nil, err
+	}
+	s.Invalidate()
+	return price, nil
+}
+
+// Delete 删除尚未生效的价格版本
+func (s *ModelPriceService) Delete(ctx context.Context, id int64) error {
+	price, err := s.repo.GetByID(ctx, id)
+	if err != nil {
+		return err
+	}
+	if !price.EffectiveFrom.After(time.Now()) {
+		return ErrModelPriceInEffect
+	}
+	if err := s.repo.Delete(ctx, id); err != nil {
+		return err
+	}
+	s.Invalidate()
+	return nil
+}
+
+// Invalidate 使内存快照失效，下次计费查询时重新加载
+func (s *ModelPriceService) Invalidate() {
+	if s == nil {
+		return
+	}
+	s.mu.Lock()
+	s.loadedAt = time.Time{}
+	s.mu.Unlock()
+}
+
+// Resolve 返回 at 时刻对指定平台/模型生效的价格（平台专属价格优先于通用价格），未配置时返回 nil
+func (s *ModelPriceService) Resolve(platform, model string, at time.Time) *ModelPricing {
+	if s == nil || s.repo == nil {
+		return nil
+	}
+	versions := s.snapshot()
+	if len(versions) == 0 {
+		return nil
+	}
+	platform = strings.ToLower(strings.TrimSpace(platform))
+	model = strings.ToLower(strings.TrimSpace(model))
+
+	keys := []string{modelPriceKey(platform, model)}
+	if platform != "" {
+		keys = append(keys, modelPriceKey("", model))
+	}
+	for _, key := range keys {
+		for i := range versions[key] {
+			if !versions[key][i].EffectiveFrom.After(at) {
+				return versions[key][i].toModelPricing()
+			}
+		}
+	}
+	return nil
+}
+
+// snapshot 返回价格版本快照，过期时同步刷新；刷新失败时沿用旧快照
+func (s *ModelPriceService) snapshot() map[string][]ModelPrice {
+	s.mu.RLock()
+	versions, loadedAt := s.versions, s.loadedAt
+	s.mu.RUnlock()
+	if !loadedAt.IsZero() && time.Since(loadedAt) < modelPriceCacheTTL {
+		return versions
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if !s.loadedAt.IsZero() && time.Since(s.loadedAt) < modelPriceCacheTTL {
+		return s.versions
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), modelPriceLoadTimeout)
+	defer cancel()
+	prices, err := s.repo.List(ctx, "", "")
+	// 失败时也更新加载时间，避免数据库异常时每个请求都重试
+	s.loadedAt = time.Now()
+	if err != nil {
+		log.Printf("[Billing] Load model price table failed: %v", err)
+		return s.versions
+	}
+
+	next := make(map[string][]ModelPrice, len(prices))
+	for _, p := range prices {
+		key := modelPriceKey(p.Platform, p.Model)
+		next[key] = append(next[key], p)
+	}
+	for key := range next {
+		list := next[key]
+		sort.Slice(list, func(i, j int) bool { return list[i].EffectiveFrom.After(list[j].EffectiveFrom) })
+	}
+	s.versions = next
+	return next
+}
@@ -0,0 +1,131 @@
+//go:build unit
+
+package service
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/Wei-Shaw/sub2api/internal/config"
+	"github.com/stretchr/testify/require"
+)
+
+type modelPriceRepoStub struct {
+	prices    []ModelPrice
+	listCalls int
+}
+
+func (r *modelPriceRepoStub) List(ctx context.Context, platform, model string) ([]ModelPrice, error) {
+	r.listCalls++
+	return append([]ModelPrice(nil), r.prices...), nil
+}
+
+func (r *modelPriceRepoStub) GetByID(ctx context.Context, id int64) (*ModelPrice, error) {
+	for i := range r.prices {
+		if r.prices[i].ID == id {
+			p := r.prices[i]
+			return &p, nil
+		}
+	}
+	return nil, ErrModelPriceNotFound
+}
+
+func (r *modelPriceRepoStub) Create(ctx context.Context, price *ModelPrice) error {
+	price.ID = int64(len(r.prices) + 1)
+	r.prices = append(r.prices, *price)
+	return nil
+}
+
+func (r *modelPriceRepoStub) Update(ctx context.Context, price *ModelPrice) error {
+	for i := range r.prices {
+		if r.prices[i].ID == price.ID {
+			r.prices[i] = *price
+			return nil
+		}
+	}
+	return ErrModelPriceNotFound
+}
+
+func (r *modelPriceRepoStub) Delete(ctx context.Context, id int64) error {
+	for i := range r.prices {
+		if r.prices[i].ID == id {
+			r.prices = append(r.prices[:i], r.prices[i+1:]...)
+			return nil
+		}
+	}
+	return ErrModelPriceNotFound
+}
+
+func TestModelPriceService_ResolveByEffectiveDate(t *testing.T) {
+	now := time.Now()
+	repo := &modelPriceRepoStub{prices: []ModelPrice{
+		{ID: 1, Model: "gpt-x", InputPrice: 1, OutputPrice: 2, EffectiveFrom: now.Add(-48 * time.Hour)},
+		{ID: 2, Model: "gpt-x", InputPrice: 3, OutputPrice: 6, EffectiveFrom: now.Add(-time.Hour)},
+		{ID: 3, Model: "gpt-x", InputPrice: 9, OutputPrice: 9, EffectiveFrom: now.Add(24 * time.Hour)},
+		{ID: 4, Platform: PlatformOpenAI, Model: "gpt-x", InputPrice: 5, OutputPrice: 10, EffectiveFrom: now.Add(-2 * time.Hour)},
+	}}
+	svc := NewModelPriceService(repo)
+
+	// 平台专属价格优先
+	pricing := svc.Resolve(PlatformOpenAI, "GPT-X", now)
+	require.NotNil(t, pricing)
+	require.InDelta(t, 5.0/1e6, pricing.InputPricePerToken, 1e-15)
+	// 未配置缓存价格时按输入价格计费
+	require.Equal(t, pricing.InputPricePerToken, pricing.CacheReadPricePerToken)
+
+	// 通用价格：按时间选择已生效的最新版本，未来版本不生效
+	require.InDelta(t, 3.0/1e6, svc.Resolve(PlatformAnthropic, "gpt-x", now).InputPricePerToken, 1e-15)
+	require.InDelta(t, 1.0/1e6, svc.Resolve("", "gpt-x", now.Add(-24*time.Hour)).InputPricePerToken, 1e-15)
+	require.Nil(t, svc.Resolve("", "gpt-x", now.Add(-72*time.Hour)))
+	require.Nil(t, svc.Resolve("", "unknown", now))
+
+	// 快照缓存：多次查询只加载一次
+	require.Equal(t, 1, repo.listCalls)
+}
+
+func TestModelPriceService_InEffectVersionsAreImmutable(t *testing.T) {
+	now := time.Now()
+	repo := &modelPriceRepoStub{prices: []ModelPrice{
+		{ID: 1, Model: "m", InputPrice: 1, EffectiveFrom: now.Add(-time.Hour)},
+		{ID: 2, Model: "m", InputPrice: 2, EffectiveFrom: now.Add(time.Hour)},
+	}}
+	svc := NewModelPriceService(repo)
+	ctx := context.Background()
+
+	_, err := svc.Update(ctx, 1, func(p *ModelPrice) { p.InputPrice = 10 })
+	require.ErrorIs(t, err, ErrModelPriceInEffect)
+	require.ErrorIs(t, svc.Delete(ctx, 1), ErrModelPriceInEffect)
+
+	updated, err := svc.Update(ctx, 2, func(p *ModelPrice) { p.InputPrice = 4 })
+	require.NoError(t, err)
+	require.Equal(t, 4.0, updated.InputPrice)
+	require.NoError(t, svc.Delete(ctx, 2))
+
+	_, err = svc.Create(ctx, &ModelPrice{Model: " ", InputPrice: 1})
+	require.ErrorIs(t, err, ErrInvalidModelPrice)
+	_, err = svc.Create(ctx, &ModelPrice{Platform: "azure", Model: "m"})
+	require.ErrorIs(t, err, ErrInvalidModelPrice)
+
+	created, err := svc.Create(ctx, &ModelPrice{Platform: " OpenAI ", Model: " M-2 ", InputPrice: 1})
+	require.NoError(t, err)
+	require.Equal(t, PlatformOpenAI, created.Platform)
+	require.Equal(t, "m-2", created.Model)
+	require.False(t, created.EffectiveFrom.IsZero())
+}
+
+func TestBillingService_PriceTableOverridesFallback(t *testing.T) {
+	repo := &modelPriceRepoStub{prices: []ModelPrice{
+		{ID: 1, Platform: PlatformAnthropic, Model: "claude-sonnet-4", InputPrice: 1, OutputPrice: 2, EffectiveFrom: time.Now().Add(-time.Hour)},
+	}}
+	svc := NewBillingService(&config.Config{}, nil, NewModelPriceService(repo))
+
+	cost, err := svc.CalculateCostForPlatform(PlatformAnthropic, "claude-sonnet-4", UsageTokens{InputTokens: 1_000_000, OutputTokens: 1_000_000}, 1)
+	require.NoError(t, err)
+	require.InDelta(t, 3.0, cost.TotalCost, 1e-9)
+
+	// 其他平台没有专属价格也没有通用价格时回退到内置价格
+	fallback, err := svc.CalculateCostForPlatform(PlatformAntigravity, "claude-sonnet-4", UsageTokens{InputTokens: 1_000_000}, 1)
+	require.NoError(t, err)
+	require.NotEqual(t, 1.0, fallback.TotalCost)
+}
@@ -0,0 +1,107 @@
+package service
+
+import (
+	"strings"
+
+	"github.com/tidwall/gjson"
+	"github.com/tidwall/sjson"
+)
+
+// claudeThinkingModelSuffix Claude 模型名后缀：开启 thinking（使用默认预算）
+const claudeThinkingModelSuffix = "-thinking"
+
+// claudeThinkingSuffixDefaultEffort -thinking 后缀对应的推理强度
+const claudeThinkingSuffixDefaultEffort = "medium"
+
+// claudeThinkingMinBudget Anthropic thinking.budget_tokens 的最小值
+const claudeThinkingMinBudget = 1024
+
+// claudeThinkingBudgets 推理强度后缀 -> Claude thinking.budget_tokens（none 表示关闭 thinking）
+var claudeThinkingBudgets = map[string]int{
+	"minimal": 1024,
+	"low":     4096,
+	"medium":  16384,
+	"high":    32768,
+	"xhigh":   65536,
+}
+
+// isReasoningEffortSuffix 是否为可识别的推理强度后缀
+func isReasoningEffortSuffix(effort string) bool {
+	if effort == "none" {
+		return true
+	}
+	_, ok := claudeThinkingBudgets[effort]
+	return ok
+}
+
+// ParseModelSuffix 解析模型名中的推理强度后缀，返回去除后缀的模型名与推理强度：
+//   - model:effort（如 gpt-5.2:high），effort 取 none/minimal/low/medium/high/xhigh
+//   - claude-*-thinking（如 claude-sonnet-4-5-thinking），等价于 :medium
+//
+// 无法识别的后缀（如 llama3:8b）原样保留，ok 返回 false。
+func ParseModelSuffix(model string) (base string, effort string, ok bool) {
+	if idx := strings.LastIndex(model, ":"); idx > 0 {
+		effort = strings.ToLower(strings.TrimSpace(model[idx+1:]))
+		if isReasoningEffortSuffix(effort) {
+			return model[:idx], effort, true
+		}
+	}
+	if strings.HasPrefix(model, "claude-") && strings.HasSuffix(model, claudeThinkingModelSuffix) {
+		return strings.TrimSuffix(model, claudeThinkingModelSuffix), claudeThinkingSuffixDefaultEffort, true
+	}
+	return model, "", false
+}
+
+// ApplyModelSuffix 去除请求模型名中的推理强度后缀，并按协议写入对应的推理参数（后缀优先于请求体中的同类参数）：
+//   - OpenAI：reasoning.effort（同时移除 Chat Completions 风格的 reasoning_effort）
+//   - Anthropic：thinking.type/budget_tokens，预算不超过 max_tokens-1；max_tokens 不足最小预算时不开启 thinking
+//
+// 未命中后缀或协议不支持时返回 ok=false 与原始 body。
+func ApplyModelSuffix(body []byte, protocol string) (model string, newBody []byte, ok bool) {
+	model = gjson.GetBytes(body, "model").String()
+	base, effort, ok := ParseModelSuffix(model)
+	if !ok {
+		return model, body, false
+	}
+
+	var err error
+	switch protocol {
+	case PlatformOpenAI:
+		newBody, err = applyOpenAIReasoningSuffix(body, effort)
+	case PlatformAnthropic:
+		newBody, err = applyClaudeThinkingSuffix(body, effort)
+	default:
+		return model, body, false
+	}
+	if err != nil {
+		return model, body, false
+	}
+	if newBody, err = sjson.SetBytes(newBody, "model", base); err != nil {
+		return model, body, false
+	}
+	return base, newBody, true
+}
+
+func applyOpenAIReasoningSuffix(body []byte, effort string) ([]byte, error) {
+	next, err := sjson.DeleteBytes(body, "reasoning_effort")
+	if err != nil {
+		return nil, err
+	}
+	return sjson.SetBytes(next, "reasoning.effort", effort)
+}
+
+func applyClaudeThinkingSuffix(body []byte, effort string) ([]byte, error) {
+	if effort == "none" {
+		return sjson.DeleteBytes(body, "thinking")
+	}
+	budget := claudeThinkingBudgets[effort]
+	if maxTokens := gjson.GetBytes(body, "max_tokens"); maxTokens.Type == gjson.Number {
+		if limit := int(maxTokens.Int()) - 1; budget > limit {
+			budget = limit
+		}
+	}
+	if budget < claudeThinkingMinBudget {
+		return body, nil
+	}
+	return sjson.SetBytes(body, "thinking", map[string]any{"type": "enabled", "budget_tokens": budget})
+}
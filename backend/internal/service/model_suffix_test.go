@@ -0,0 +1,80 @@
+//go:build unit
+
+package service
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"github.com/tidwall/gjson"
+)
+
+func TestParseModelSuffix(t *testing.T) {
+	base, effort, ok := ParseModelSuffix("gpt-5.2:High")
+	require.True(t, ok)
+	require.Equal(t, "gpt-5.2", base)
+	require.Equal(t, "high", effort)
+
+	base, effort, ok = ParseModelSuffix("claude-sonnet-4-5-thinking")
+	require.True(t, ok)
+	require.Equal(t, "claude-sonnet-4-5", base)
+	require.Equal(t, "medium", effort)
+
+	// 无法识别的后缀原样保留
+	base, _, ok = ParseModelSuffix("llama3:8b")
+	require.False(t, ok)
+	require.Equal(t, "llama3:8b", base)
+	_, _, ok = ParseModelSuffix("gemini-2.5-flash-thinking")
+	require.False(t, ok)
+}
+
+func TestApplyModelSuffix_OpenAIReasoningEffort(t *testing.T) {
+	model, body, ok := ApplyModelSuffix([]byte(`{"model":"gpt-5.2:high","reasoning_effort":"low","reasoning":{"summary":"auto"}}`), PlatformOpenAI)
+	require.True(t, ok)
+	require.Equal(t, "gpt-5.2", model)
+	require.Equal(t, "gpt-5.2", gjson.GetBytes(body, "model").String())
+	require.Equal(t, "high", gjson.GetBytes(body, "reasoning.effort").String())
+	require.Equal(t, "auto", gjson.GetBytes(body, "reasoning.summary").String())
+	require.False(t, gjson.GetBytes(body, "reasoning_effort").Exists())
+}
+
+func TestApplyModelSuffix_ClaudeThinking(t *testing.T) {
+	model, body, ok := ApplyModelSuffix([]byte(`{"model":"claude-sonnet-4-5-thinking","max_tokens":64000}`), PlatformAnthropic)
+	require.True(t, ok)
+	require.Equal(t, "claude-sonnet-4-5", model)
+	require.Equal(t, "enabled", gjson.GetBytes(body, "thinking.type").String())
+	require.Equal(t, int64(16384), gjson.GetBytes(body, "thinking.budget_tokens").Int())
+
+	// 预算不超过 max_tokens-1
+	_, body, ok = ApplyModelSuffix([]byte(`{"model":"claude-opus-4-1:xhigh","max_tokens":8192}`), PlatformAnthropic)
+	require.True(t, ok)
+	require.Equal(t, int64(8191), gjson.GetBytes(body, "thinking.budget_tokens").Int())
+
+	// max_tokens 不足最小预算时不开启 thinking
+	_, body, ok = ApplyModelSuffix([]byte(`{"model":"claude-opus-4-1:high","max_tokens":512}`), PlatformAnthropic)
+	require.True(t, ok)
+	require.False(t, gjson.GetBytes(body, "thinking").Exists())
+
+	_, body, ok = ApplyModelSuffix([]byte(`{"model":"claude-opus-4-1:none","thinking":{"type":"enabled","budget_tokens":2048}}`), PlatformAnthropic)
+	require.True(t, ok)
+	require.False(t, gjson.GetBytes(body, "thinking").Exists())
+}
+
+func TestApplyModelSuffix_NoSuffixKeepsBody(t *testing.T) {
+	body := []byte(`{"model":"gpt-5.2"}`)
+	model, out, ok := ApplyModelSuffix(body, PlatformOpenAI)
+	require.False(t, ok)
+	require.Equal(t, "gpt-5.2", model)
+	require.Equal(t, body, out)
+
+	_, out, ok = ApplyModelSuffix([]byte(`{"model":"gemini-2.5-pro:high"}`), PlatformGemini)
+	require.False(t, ok)
+	require.Equal(t, "gemini-2.5-pro:high", gjson.GetBytes(out, "model").String())
+}
+
+func TestWithModelAliasOrigin_KeepsFirstOrigin(t *testing.T) {
+	ctx := WithModelAliasOrigin(context.Background(), "gpt-5.2:high")
+	ctx = WithModelAliasOrigin(ctx, "gpt-5.2")
+	require.Equal(t, "gpt-5.2:high", modelAliasEchoModel(ctx, "gpt-5.2-codex"))
+}
@@ -12,7 +12,6 @@ import (
 	"io"
 	"log"
 	"net/http"
-	"regexp"
 	"sort"
 	"strconv"
 	"strings"
@@ -24,6 +23,7 @@ import (
 	"github.com/Wei-Shaw/sub2api/internal/util/responseheaders"
 	"github.com/Wei-Shaw/sub2api/internal/util/urlvalidator"
 	"github.com/gin-gonic/gin"
+	"github.com/tidwall/gjson"
 )
 
 const (
@@ -36,10 +36,6 @@ const (
 	CtxKeyOpenAIChatCompletionsCompat = "openai_chat_completions_compat"
 )
 
-// openaiSSEDataRe matches SSE data lines with optional whitespace after colon.
-// Some upstream APIs return non-standard "data:" without space (should be "data: ").
-var openaiSSEDataRe = regexp.MustCompile(`^data:\s*`)
-
 // OpenAI allowed headers whitelist (for non-OAuth accounts)
 var openaiAllowedHeaders = map[string]bool{
 	"accept-language": true,
@@ -154,6 +150,14 @@ type OpenAIUsage struct {
 	OutputTokens             int `json:"output_tokens"`
 	CacheCreationInputTokens int `json:"cache_creation_input_tokens,omitempty"`
 	CacheReadInputTokens     int `json:"cache_read_input_tokens,omitempty"`
+	// ToolUsage 内置工具调用（从 Responses output 中解析，不参与 usage JSON 序列化）
+	ToolUsage ToolUsage `json:"-"`
+	// FinishReason 结束原因（Responses 的 incomplete_details.reason 或 status，不参与 usage JSON 序列化）
+	FinishReason string `json:"-"`
+	// ResponseID 上游 Responses 响应 ID，用于记录 previous_response_id 续链所属账号（不参与 usage JSON 序列化）
+	ResponseID string `json:"-"`
+	// ResponseOutput 上游响应的 output 数组原文，仅在保存续链历史时解析（不参与 usage JSON 序列化）
+	ResponseOutput json.RawMessage `json:"-"`
 }
 
 // OpenAIForwardResult represents the result of forwarding
@@ -167,6 +171,8 @@ type OpenAIForwardResult struct {
 	Stream          bool
 	Duration        time.Duration
 	FirstTokenMs    *int
+	// GatewayFingerprint is echoed back to clients that sent seed; empty otherwise.
+	GatewayFingerprint string
 }
 
 // OpenAIGatewayService handles OpenAI API gateway operations
@@ -186,6 +192,11 @@ type OpenAIGatewayService struct {
 	deferredService     *DeferredService
 	openAITokenProvider *OpenAITokenProvider
 	toolCorrector       *CodexToolCorrector
+	budgetAlertService  *BudgetAlertService
+	usageRetryService   *UsageRetryService
+	memoryGuard         *MemoryGuard
+	responseContexts    OpenAIResponseContextCache
+	gatewayVersion      string
 }
 
 // NewOpenAIGatewayService creates a new OpenAIGatewayService
@@ -204,6 +215,11 @@ func NewOpenAIGatewayService(
 	httpUpstream HTTPUpstream,
 	deferredService *DeferredService,
 	openAITokenProvider *OpenAITokenProvider,
+	budgetAlertService *BudgetAlertService,
+	usageRetryService *UsageRetryService,
+	memoryGuard *MemoryGuard,
+	responseContexts OpenAIResponseContextCache,
+	buildInfo BuildInfo,
 ) *OpenAIGatewayService {
 	return &OpenAIGatewayService{
 		accountRepo:         accountRepo,
@@ -221,9 +237,19 @@ func NewOpenAIGatewayService(
 		deferredService:     deferredService,
 		openAITokenProvider: openAITokenProvider,
 		toolCorrector:       NewCodexToolCorrector(),
+		budgetAlertService:  budgetAlertService,
+		usageRetryService:   usageRetryService,
+		memoryGuard:         memoryGuard,
+		responseContexts:    responseContexts,
+		gatewayVersion:      buildInfo.Version,
 	}
 }
 
+// MemoryGuard 返回网关内存护栏（未启用时为 nil）
+func (s *OpenAIGatewayService) MemoryGuard() *MemoryGuard {
+	return s.memoryGuard
+}
+
 // GenerateSessionHash generates a sticky-session hash for OpenAI requests.
 //
 // Priority:
@@ -290,7 +316,7 @@ func (s *OpenAIGatewayService) SelectAccountForModelWithExclusions(ctx context.C
 
 	// 3. 按优先级 + LRU 选择最佳账号
 	// Select by priority + LRU
-	selected := s.selectBestAccount(accounts, requestedModel, excludedIDs)
+	selected := s.selectBestAccount(ctx, accounts, requestedModel, excludedIDs)
 
 	if selected == nil {
 		if requestedModel != "" {
@@ -344,7 +370,7 @@ func (s *OpenAIGatewayService) tryStickySessionHit(ctx context.Context, groupID
 	if !account.IsSchedulable() || !account.IsOpenAI() {
 		return nil
 	}
-	if requestedModel != "" && !account.IsModelSupported(requestedModel) {
+	if !isOpenAIAccountUsableForRequest(ctx, account, requestedModel) {
 		return nil
 	}
 
@@ -354,12 +380,21 @@ func (s *OpenAIGatewayService) tryStickySessionHit(ctx context.Context, groupID
 	return account
 }
 
+// isOpenAIAccountUsableForRequest 检查账号是否支持请求模型，且未显式声明不支持请求所需的能力（图片/工具/长上下文/流式），
+// 避免调度到必然返回 400 的账号后再故障转移
+func isOpenAIAccountUsableForRequest(ctx context.Context, account *Account, requestedModel string) bool {
+	if requestedModel != "" && !account.IsModelSupported(requestedModel) {
+		return false
+	}
+	return account.SupportsRequestFeatures(RequestFeaturesFromContext(ctx))
+}
+
 // selectBestAccount 从候选账号中选择最佳账号（优先级 + LRU）。
 // 返回 nil 表示无可用账号。
 //
 // selectBestAccount selects the best account from candidates (priority + LRU).
 // Returns nil if no available account.
-func (s *OpenAIGatewayService) selectBestAccount(accounts []Account, requestedModel string, excludedIDs map[int64]struct{}) *Account {
+func (s *OpenAIGatewayService) selectBestAccount(ctx context.Context, accounts []Account, requestedModel string, excludedIDs map[int64]struct{}) *Account {
 	var selected *Account
 
 	for i := range accounts {
@@ -377,9 +412,9 @@ func (s *OpenAIGatewayService) selectBestAccount(accounts []Account, requestedMo
 			continue
 		}
 
-		// 检查模型支持
-		// Check model support
-		if requestedModel != "" && !acc.IsModelSupported(requestedModel) {
+		// 检查模型支持与请求所需能力
+		// Check model support and required request features
+		if !isOpenAIAccountUsableForRequest(ctx, acc, requestedModel) {
 			continue
 		}
 
@@ -399,18 +434,15 @@ func (s *OpenAIGatewayService) selectBestAccount(accounts []Account, requestedMo
 }
 
 // isBetterAccount 判断 candidate 是否比 current 更优。
-// 规则：优先级更高（数值更小）优先；同优先级时，未使用过的优先，其次是最久未使用的。
+// 规则：层级更高优先，其次优先级更高（数值更小）优先；同层同优先级时，未使用过的优先，其次是最久未使用的。
 //
 // isBetterAccount checks if candidate is better than current.
-// Rules: higher priority (lower value) wins; same priority: never used > least recently used.
+// Rules: higher tier, then higher priority (lower value) wins; same tier and priority: never used > least recently used.
 func (s *OpenAIGatewayService) isBetterAccount(candidate, current *Account) bool {
-	// 优先级更高（数值更小）
-	// Higher priority (lower value)
-	if candidate.Priority < current.Priority {
-		return true
-	}
-	if candidate.Priority > current.Priority {
-		return false
+	// 层级更高、优先级更高（数值更小）
+	// Higher tier, then higher priority (lower value)
+	if c := compareAccountPriority(candidate, current); c != 0 {
+		return c < 0
 	}
 
 	// 同优先级，比较最后使用时间
@@ -433,6 +465,13 @@ func (s *OpenAIGatewayService) isBetterAccount(candidate, current *Account) bool
 
 // SelectAccountWithLoadAwareness selects an account with load-awareness and wait plan.
 func (s *OpenAIGatewayService) SelectAccountWithLoadAwareness(ctx context.Context, groupID *int64, sessionHash string, requestedModel string, excludedIDs map[int64]struct{}) (*AccountSelectionResult, error) {
+	ctx, span := startAccountSelectionSpan(ctx, PlatformOpenAI, groupID, requestedModel, excludedIDs)
+	result, err := s.selectAccountWithLoadAwareness(ctx, groupID, sessionHash, requestedModel, excludedIDs)
+	endAccountSelectionSpan(span, result, err)
+	return result, err
+}
+
+func (s *OpenAIGatewayService) selectAccountWithLoadAwareness(ctx context.Context, groupID *int64, sessionHash string, requestedModel string, excludedIDs map[int64]struct{}) (*AccountSelectionResult, error) {
 	cfg := s.schedulingConfig()
 	var stickyAccountID int64
 	if sessionHash != "" && s.cache != nil {
@@ -497,7 +536,7 @@ func (s *OpenAIGatewayService) SelectAccountWithLoadAwareness(ctx context.Contex
 	// ============ Layer 1: Sticky session ============
 	if sessionHash != "" {
 		accountID, err := s.cache.GetSessionAccountID(ctx, derefGroupID(groupID), "openai:"+sessionHash)
-		if err == nil && accountID > 0 && !isExcluded(accountID) {
+		if err == nil && accountID > 0 && !isExcluded(accountID) && !s.rateLimitService.IsAccountCoolingDown(accountID) {
 			account, err := s.getSchedulableAccount(ctx, accountID)
 			if err == nil {
 				clearSticky := shouldClearStickySession(account, requestedModel)
@@ -505,7 +544,7 @@ func (s *OpenAIGatewayService) SelectAccountWithLoadAwareness(ctx context.Contex
 					_ = s.cache.DeleteSessionAccountID(ctx, derefGroupID(groupID), "openai:"+sessionHash)
 				}
 				if !clearSticky && account.IsSchedulable() && account.IsOpenAI() &&
-					(requestedModel == "" || account.IsModelSupported(requestedModel)) {
+					isOpenAIAccountUsableForRequest(ctx, account, requestedModel) {
 					result, err := s.tryAcquireAccountSlot(ctx, accountID, account.Concurrency)
 					if err == nil && result.Acquired {
 						_ = s.cache.RefreshSessionTTL(ctx, derefGroupID(groupID), "openai:"+sessionHash, openaiStickySessionTTL)
@@ -542,11 +581,12 @@ func (s *OpenAIGatewayService) SelectAccountWithLoadAwareness(ctx context.Contex
 		}
 		// Scheduler snapshots can be temporarily stale (bucket rebuild is throttled);
 		// re-check schedulability here so recently rate-limited/overloaded accounts
-		// are not selected again before the bucket is rebuilt.
-		if !acc.IsSchedulable() {
+		// are not selected again before the bucket is rebuilt. The in-process cooldown
+		// covers the window before the rate-limit state has even reached the database.
+		if !acc.IsSchedulable() || s.rateLimitService.IsAccountCoolingDown(acc.ID) {
 			continue
 		}
-		if requestedModel != "" && !acc.IsModelSupported(requestedModel) {
+		if !isOpenAIAccountUsableForRequest(ctx, acc, requestedModel) {
 			continue
 		}
 		candidates = append(candidates, acc)
@@ -599,8 +639,8 @@ func (s *OpenAIGatewayService) SelectAccountWithLoadAwareness(ctx context.Contex
 		if len(available) > 0 {
 			sort.SliceStable(available, func(i, j int) bool {
 				a, b := available[i], available[j]
-				if a.account.Priority != b.account.Priority {
-					return a.account.Priority < b.account.Priority
+				if c := compareAccountPriority(a.account, b.account); c != 0 {
+					return c < 0
 				}
 				if a.loadInfo.LoadRate != b.loadInfo.LoadRate {
 					return a.loadInfo.LoadRate < b.loadInfo.LoadRate
@@ -617,8 +657,36 @@ func (s *OpenAIGatewayService) SelectAccountWithLoadAwareness(ctx context.Contex
 				}
 			})
 			shuffleWithinSortGroups(available)
+			// 新会话启用一致性哈希时，同一优先级内按哈希环顺序放置
+			if sessionHash != "" && cfg.SessionConsistentHash {
+				orderBySessionRing(available, sessionHash)
+			}
+			// 持有会话预热缓存的账号在负载差距可接受时优先尝试
+			if sessionHash != "" && cfg.PromptCacheAffinity {
+				warmAccountID := lookupWarmCacheAccount(ctx, s.cache, cfg, groupID, "openai:"+sessionHash)
+				warm, decision := pickWarmCacheAccount(available, warmAccountID, cfg.PromptCacheMaxLoadGap)
+				observePromptCacheRouting(PlatformOpenAI, groupID, decision)
+				if warm != nil {
+					available = moveAccountWithLoadToFront(available, warm.account.ID)
+				}
+			}
 
+			// 两阶段选择：按快照预占槽位；快照已过期的账号先让给排序靠后的账号，最后再直接尝试获取
+			var staleItems []accountWithLoad
 			for _, item := range available {
+				result, stale := reserveAccountWithLoad(ctx, s.concurrencyService, cfg.ReservationTTL, item)
+				if stale {
+					staleItems = append(staleItems, item)
+					continue
+				}
+				if result != nil {
+					if sessionHash != "" {
+						_ = s.cache.SetSessionAccountID(ctx, derefGroupID(groupID), "openai:"+sessionHash, item.account.ID, openaiStickySessionTTL)
+					}
+					return result, nil
+				}
+			}
+			for _, item := range staleItems {
 				result, err := s.tryAcquireAccountSlot(ctx, item.account.ID, item.account.Concurrency)
 				if err == nil && result.Acquired {
 					if sessionHash != "" {
@@ -654,7 +722,7 @@ func (s *OpenAIGatewayService) SelectAccountWithLoadAwareness(ctx context.Contex
 func (s *OpenAIGatewayService) listSchedulableAccounts(ctx context.Context, groupID *int64) ([]Account, error) {
 	if s.schedulerSnapshot != nil {
 		accounts, _, err := s.schedulerSnapshot.ListSchedulableAccounts(ctx, groupID, PlatformOpenAI, false)
-		return accounts, err
+		return filterCanaryAccounts(filterAccountsByVirtualModel(ctx, filterAccountsByRegionPolicy(ctx, accounts))), err
 	}
 	var accounts []Account
 	var err error
@@ -668,7 +736,7 @@ func (s *OpenAIGatewayService) listSchedulableAccounts(ctx context.Context, grou
 	if err != nil {
 		return nil, fmt.Errorf("query accounts failed: %w", err)
 	}
-	return accounts, nil
+	return filterCanaryAccounts(filterAccountsByVirtualModel(ctx, filterAccountsByRegionPolicy(ctx, accounts))), nil
 }
 
 func (s *OpenAIGatewayService) tryAcquireAccountSlot(ctx context.Context, accountID int64, maxConcurrency int) (*AcquireResult, error) {
@@ -679,10 +747,25 @@ func (s *OpenAIGatewayService) tryAcquireAccountSlot(ctx context.Context, accoun
 }
 
 func (s *OpenAIGatewayService) getSchedulableAccount(ctx context.Context, accountID int64) (*Account, error) {
+	var (
+		account *Account
+		err     error
+	)
 	if s.schedulerSnapshot != nil {
-		return s.schedulerSnapshot.GetAccount(ctx, accountID)
+		account, err = s.schedulerSnapshot.GetAccount(ctx, accountID)
+	} else {
+		account, err = s.accountRepo.GetByID(ctx, accountID)
+	}
+	if err != nil {
+		return nil, err
+	}
+	if !accountAllowedByRegionPolicy(ctx, account) {
+		return nil, ErrAccountRegionNotAllowed
+	}
+	if !accountAllowedByVirtualModel(ctx, account) {
+		return nil, ErrAccountNotInVirtualModelTarget
 	}
-	return s.accountRepo.GetByID(ctx, accountID)
+	return account, nil
 }
 
 func (s *OpenAIGatewayService) schedulingConfig() config.GatewaySchedulingConfig {
@@ -744,6 +827,10 @@ func (s *OpenAIGatewayService) handleFailoverSideEffects(ctx context.Context, re
 
 // Forward forwards request to OpenAI API
 func (s *OpenAIGatewayService) Forward(ctx context.Context, c *gin.Context, account *Account, body []byte) (*OpenAIForwardResult, error) {
+	// 账号配置了 mTLS 客户端证书 / 自定义 CA 时，由 HTTP 上游据此建立连接
+	ctx = WithAccountUpstreamTLS(ctx, account)
+	// 分阶段超时（连接/首 token/分片间隔/总时长）由 HTTP 上游执行
+	ctx = WithUpstreamTimeoutBudget(ctx, NewUpstreamTimeoutBudget(s.cfg))
 	startTime := time.Now()
 
 	// Parse request body once (avoid multiple parse/serialize cycles)
@@ -760,6 +847,16 @@ func (s *OpenAIGatewayService) Forward(ctx context.Context, c *gin.Context, acco
 		promptCacheKey = strings.TrimSpace(v)
 	}
 
+	// Echo a gateway fingerprint when the client pins sampling with seed.
+	// Reset on every attempt so a failover reports the account that actually served the response.
+	var fingerprint *gatewayFingerprint
+	if requestHasSeed(reqBody) {
+		fingerprint = newGatewayFingerprint(account, s.gatewayVersion)
+	}
+	if c != nil {
+		c.Set(ctxKeyOpenAIGatewayFingerprint, fingerprint)
+	}
+
 	// Track if body needs re-serialization
 	bodyModified := false
 	originalModel := reqModel
@@ -916,18 +1013,27 @@ func (s *OpenAIGatewayService) Forward(ctx context.Context, c *gin.Context, acco
 	// Send request
 	resp, err := s.httpUpstream.Do(upstreamReq, proxyURL, account.ID, account.Concurrency)
 	if err != nil {
+		// 连接/首 token/单次尝试超时（尚未向客户端输出）：交由 handler 切换账号
+		if failoverErr := upstreamTimeoutFailover(err); failoverErr != nil {
+			return nil, failoverErr
+		}
+		// 账号 worker 池饱和：该账号上游调用已占满，切换到其他账号
+		if errors.Is(err, ErrAccountWorkerPoolSaturated) {
+			return nil, &UpstreamFailoverError{StatusCode: http.StatusServiceUnavailable}
+		}
 		// Ensure the client receives an error response (handlers assume Forward writes on non-failover errors).
 		safeErr := sanitizeUpstreamErrorMessage(err.Error())
+		errKind, errStatus := upstreamRequestErrorKind(err)
 		setOpsUpstreamError(c, 0, safeErr, "")
 		appendOpsUpstreamError(c, OpsUpstreamErrorEvent{
 			Platform:           account.Platform,
 			AccountID:          account.ID,
 			AccountName:        account.Name,
 			UpstreamStatusCode: 0,
-			Kind:               "request_error",
+			Kind:               errKind,
 			Message:            safeErr,
 		})
-		c.JSON(http.StatusBadGateway, gin.H{
+		c.JSON(errStatus, gin.H{
 			"error": gin.H{
 				"type":    "upstream_error",
 				"message": "Upstream request failed",
@@ -947,8 +1053,8 @@ func (s *OpenAIGatewayService) Forward(ctx context.Context, c *gin.Context, acco
 			upstreamMsg := strings.TrimSpace(extractUpstreamErrorMessage(respBody))
 			upstreamMsg = sanitizeUpstreamErrorMessage(upstreamMsg)
 			upstreamDetail := ""
-			if s.cfg != nil && s.cfg.Gateway.LogUpstreamErrorBody {
-				maxBytes := s.cfg.Gateway.LogUpstreamErrorBodyMaxBytes
+			if s.cfg != nil && s.cfg.Live().Gateway.LogUpstreamErrorBody {
+				maxBytes := s.cfg.Live().Gateway.LogUpstreamErrorBodyMaxBytes
 				if maxBytes <= 0 {
 					maxBytes = 2048
 				}
@@ -966,7 +1072,7 @@ func (s *OpenAIGatewayService) Forward(ctx context.Context, c *gin.Context, acco
 			})
 
 			s.handleFailoverSideEffects(ctx, resp, account)
-			return nil, &UpstreamFailoverError{StatusCode: resp.StatusCode, ResponseBody: respBody}
+			return nil, &UpstreamFailoverError{StatusCode: resp.StatusCode, ResponseBody: respBody, ResponseHeaders: resp.Header}
 		}
 		return s.handleErrorResponse(ctx, resp, c, account)
 	}
@@ -992,19 +1098,21 @@ func (s *OpenAIGatewayService) Forward(ctx context.Context, c *gin.Context, acco
 	if account.Type == AccountTypeOAuth {
 		if snapshot := ParseCodexRateLimitHeaders(resp.Header); snapshot != nil {
 			s.updateCodexUsageSnapshot(ctx, account.ID, snapshot)
+			s.budgetAlertService.Observe(ctx, codexBudgetUsages(account, snapshot.Normalize(), time.Now())...)
 		}
 	}
 
 	reasoningEffort := extractOpenAIReasoningEffort(reqBody, originalModel)
 
 	return &OpenAIForwardResult{
-		RequestID:       resp.Header.Get("x-request-id"),
-		Usage:           *usage,
-		Model:           originalModel,
-		ReasoningEffort: reasoningEffort,
-		Stream:          reqStream,
-		Duration:        time.Since(startTime),
-		FirstTokenMs:    firstTokenMs,
+		RequestID:          resp.Header.Get("x-request-id"),
+		Usage:              *usage,
+		Model:              originalModel,
+		ReasoningEffort:    reasoningEffort,
+		Stream:             reqStream,
+		Duration:           time.Since(startTime),
+		FirstTokenMs:       firstTokenMs,
+		GatewayFingerprint: fingerprint.String(),
 	}, nil
 }
 
@@ -1093,8 +1201,8 @@ func (s *OpenAIGatewayService) handleErrorResponse(ctx context.Context, resp *ht
 	upstreamMsg := strings.TrimSpace(extractUpstreamErrorMessage(body))
 	upstreamMsg = sanitizeUpstreamErrorMessage(upstreamMsg)
 	upstreamDetail := ""
-	if s.cfg != nil && s.cfg.Gateway.LogUpstreamErrorBody {
-		maxBytes := s.cfg.Gateway.LogUpstreamErrorBodyMaxBytes
+	if s.cfg != nil && s.cfg.Live().Gateway.LogUpstreamErrorBody {
+		maxBytes := s.cfg.Live().Gateway.LogUpstreamErrorBodyMaxBytes
 		if maxBytes <= 0 {
 			maxBytes = 2048
 		}
@@ -1102,14 +1210,14 @@ func (s *OpenAIGatewayService) handleErrorResponse(ctx context.Context, resp *ht
 	}
 	setOpsUpstreamError(c, resp.StatusCode, upstreamMsg, upstreamDetail)
 
-	if s.cfg != nil && s.cfg.Gateway.LogUpstreamErrorBody {
+	if s.cfg != nil && s.cfg.Live().Gateway.LogUpstreamErrorBody {
 		log.Printf(
 			"OpenAI upstream error %d (account=%d platform=%s type=%s): %s",
 			resp.StatusCode,
 			account.ID,
 			account.Platform,
 			account.Type,
-			truncateForLog(body, s.cfg.Gateway.LogUpstreamErrorBodyMaxBytes),
+			truncateForLog(body, s.cfg.Live().Gateway.LogUpstreamErrorBodyMaxBytes),
 		)
 	}
 
@@ -1117,6 +1225,7 @@ func (s *OpenAIGatewayService) handleErrorResponse(ctx context.Context, resp *ht
 		c,
 		PlatformOpenAI,
 		resp.StatusCode,
+		resp.Header,
 		body,
 		http.StatusBadGateway,
 		"upstream_error",
@@ -1181,7 +1290,7 @@ func (s *OpenAIGatewayService) handleErrorResponse(ctx context.Context, resp *ht
 		Detail:             upstreamDetail,
 	})
 	if shouldDisable {
-		return nil, &UpstreamFailoverError{StatusCode: resp.StatusCode, ResponseBody: body}
+		return nil, &UpstreamFailoverError{StatusCode: resp.StatusCode, ResponseBody: body, ResponseHeaders: resp.Header}
 	}
 
 	// Return appropriate error response
@@ -1211,11 +1320,16 @@ func (s *OpenAIGatewayService) handleErrorResponse(ctx context.Context, resp *ht
 		errMsg = "Upstream request failed"
 	}
 
+	// API Key 开启调试模式时附带脱敏后的上游错误详情
+	errorBody := gin.H{
+		"type":    errType,
+		"message": errMsg,
+	}
+	if debug := BuildUpstreamErrorDebug(c, resp.StatusCode, body); debug != nil {
+		errorBody["upstream"] = debug
+	}
 	c.JSON(statusCode, gin.H{
-		"error": gin.H{
-			"type":    errType,
-			"message": errMsg,
-		},
+		"error": errorBody,
 	})
 
 	if upstreamMsg == "" {
@@ -1260,6 +1374,9 @@ func (s *OpenAIGatewayService) handleStreamingResponse(ctx context.Context, resp
 		maxLineSize = s.cfg.Gateway.MaxLineSize
 	}
 	scanner.Buffer(make([]byte, 64*1024), maxLineSize)
+	streamBudget := s.memoryGuard.NewStreamBudget()
+	defer streamBudget.Close()
+	scanner.Split(streamBudget.SplitFunc(bufio.ScanLines))
 
 	type scanEvent struct {
 		line string
@@ -1294,10 +1411,11 @@ func (s *OpenAIGatewayService) handleStreamingResponse(ctx context.Context, resp
 
 	chatCompat, _ := c.Get(CtxKeyOpenAIChatCompletionsCompat)
 	isChatCompat, _ := chatCompat.(bool)
+	fingerprint := gatewayFingerprintFromContext(c)
 
 	streamInterval := time.Duration(0)
-	if s.cfg != nil && s.cfg.Gateway.StreamDataIntervalTimeout > 0 {
-		streamInterval = time.Duration(s.cfg.Gateway.StreamDataIntervalTimeout) * time.Second
+	if s.cfg != nil && s.cfg.Live().Gateway.StreamDataIntervalTimeout > 0 {
+		streamInterval = time.Duration(s.cfg.Live().Gateway.StreamDataIntervalTimeout) * time.Second
 	}
 	// Chat Completions compatibility mode may have long silent gaps during tool execution.
 	// Avoid prematurely terminating these streams on upstream data interval timeout.
@@ -1316,8 +1434,8 @@ func (s *OpenAIGatewayService) handleStreamingResponse(ctx context.Context, resp
 	}
 
 	keepaliveInterval := time.Duration(0)
-	if s.cfg != nil && s.cfg.Gateway.StreamKeepaliveInterval > 0 {
-		keepaliveInterval = time.Duration(s.cfg.Gateway.StreamKeepaliveInterval) * time.Second
+	if s.cfg != nil && s.cfg.Live().Gateway.StreamKeepaliveInterval > 0 {
+		keepaliveInterval = time.Duration(s.cfg.Live().Gateway.StreamKeepaliveInterval) * time.Second
 	}
 	// 下游 keepalive 仅用于防止代理空闲断开
 	var keepaliveTicker *time.Ticker
@@ -1357,7 +1475,8 @@ func (s *OpenAIGatewayService) handleStreamingResponse(ctx context.Context, resp
 		}
 	}
 
-	needModelReplace := originalModel != mappedModel
+	echoModel := modelAliasEchoModel(ctx, originalModel)
+	needModelReplace := echoModel != mappedModel
 	chatChunkID := buildChatCompletionID(resp.Header.Get("x-request-id"))
 	chatCreated := time.Now().Unix()
 	chatRoleSent := false
@@ -1374,8 +1493,8 @@ func (s *OpenAIGatewayService) handleStreamingResponse(ctx context.Context, resp
 					if chatToolState != nil && chatToolState.nextIndex > 0 {
 						reason = "tool_calls"
 					}
-					if chunk := buildChatChunk(originalModel, chatChunkID, chatCreated, map[string]any{}, &reason); chunk != "" {
-						if _, err := fmt.Fprintf(w, "data: %s\n\n", chunk); err == nil {
+					if chunk := buildChatChunk(echoModel, chatChunkID, chatCreated, map[string]any{}, &reason); chunk != "" {
+						if _, err := fmt.Fprintf(w, "data: %s\n\n", fingerprint.decorateChat(chunk)); err == nil {
 							flusher.Flush()
 						}
 					}
@@ -1402,6 +1521,12 @@ func (s *OpenAIGatewayService) handleStreamingResponse(ctx context.Context, resp
 					sendErrorEvent("response_too_large")
 					return &openaiStreamingResult{usage: usage, firstTokenMs: firstTokenMs}, ev.err
 				}
+				// 分片间隔/总时长超时：流已开始输出，结束响应且不切换账号
+				if isUpstreamStreamTimeout(ev.err) {
+					log.Printf("Upstream stream timeout: account=%d error=%v", account.ID, ev.err)
+					sendErrorEvent("stream_timeout")
+					return &openaiStreamingResult{usage: usage, firstTokenMs: firstTokenMs}, ev.err
+				}
 				sendErrorEvent("stream_read_error")
 				return &openaiStreamingResult{usage: usage, firstTokenMs: firstTokenMs}, fmt.Errorf("stream read error: %w", ev.err)
 			}
@@ -1410,12 +1535,11 @@ func (s *OpenAIGatewayService) handleStreamingResponse(ctx context.Context, resp
 			lastDataAt = time.Now()
 
 			// Extract data from SSE line (supports both "data: " and "data:" formats)
-			if openaiSSEDataRe.MatchString(line) {
-				data := openaiSSEDataRe.ReplaceAllString(line, "")
-
+			// 事件原样透传，仅按路径读取/改写需要的字段
+			if data, ok := sseDataPayload(line); ok {
 				// Replace model in response if needed
 				if needModelReplace {
-					line = s.replaceModelInSSELine(line, mappedModel, originalModel)
+					line = s.replaceModelInSSELine(line, mappedModel, echoModel)
 				}
 
 				// Correct Codex tool calls if needed (apply_patch -> edit, etc.)
@@ -1424,12 +1548,23 @@ func (s *OpenAIGatewayService) handleStreamingResponse(ctx context.Context, resp
 					line = "data: " + correctedData
 				}
 
+				// Seeded requests: capture the upstream fingerprint and echo the gateway fingerprint
+				if fingerprint != nil {
+					fingerprint.observe(data)
+					if !isChatCompat {
+						payload, _ := sseDataPayload(line)
+						if decorated := fingerprint.decorateResponses(payload); decorated != payload {
+							line = "data: " + decorated
+						}
+					}
+				}
+
 				// 写入客户端（客户端断开后继续 drain 上游）
 				if !clientDisconnected {
 					if isChatCompat {
-						chunks, done := convertResponsesSSEToChatChunks(data, originalModel, chatChunkID, chatCreated, &chatRoleSent, chatToolState)
+						chunks, done := convertResponsesSSEToChatChunks(data, echoModel, chatChunkID, chatCreated, &chatRoleSent, chatToolState)
 						for _, chunk := range chunks {
-							if _, err := fmt.Fprintf(w, "data: %s\n\n", chunk); err != nil {
+							if _, err := fmt.Fprintf(w, "data: %s\n\n", fingerprint.decorateChat(chunk)); err != nil {
 								clientDisconnected = true
 								log.Printf("Client disconnected during streaming, continuing to drain upstream for billing")
 								break
@@ -1509,38 +1644,15 @@ func (s *OpenAIGatewayService) handleStreamingResponse(ctx context.Context, resp
 }
 
 func (s *OpenAIGatewayService) replaceModelInSSELine(line, fromModel, toModel string) string {
-	if !openaiSSEDataRe.MatchString(line) {
-		return line
-	}
-	data := openaiSSEDataRe.ReplaceAllString(line, "")
-	if data == "" || data == "[DONE]" {
-		return line
-	}
-
-	var event map[string]any
-	if err := json.Unmarshal([]byte(data), &event); err != nil {
+	data, ok := sseDataPayload(line)
+	if !ok || data == "" || data == "[DONE]" {
 		return line
 	}
 
-	// Replace model in response
-	if m, ok := event["model"].(string); ok && m == fromModel {
-		event["model"] = toModel
-		newData, err := json.Marshal(event)
-		if err != nil {
-			return line
-		}
-		return "data: " + string(newData)
-	}
-
-	// Check nested response
-	if response, ok := event["response"].(map[string]any); ok {
-		if m, ok := response["model"].(string); ok && m == fromModel {
-			response["model"] = toModel
-			newData, err := json.Marshal(event)
-			if err != nil {
-				return line
-			}
-			return "data: " + string(newData)
+	// Replace model in response, or in the nested response object
+	for _, path := range []string{"model", "response.model"} {
+		if replaced, ok := replaceSSEJSONString(data, path, fromModel, toModel); ok {
+			return "data: " + replaced
 		}
 	}
 
@@ -1562,25 +1674,26 @@ func (s *OpenAIGatewayService) correctToolCallsInResponseBody(body []byte) []byt
 }
 
 func (s *OpenAIGatewayService) parseSSEUsage(data string, usage *OpenAIUsage) {
-	// Parse response.completed event for usage (OpenAI Responses format)
-	var event struct {
-		Type     string `json:"type"`
-		Response struct {
-			Usage struct {
-				InputTokens       int `json:"input_tokens"`
-				OutputTokens      int `json:"output_tokens"`
-				InputTokenDetails struct {
-					CachedTokens int `json:"cached_tokens"`
-				} `json:"input_tokens_details"`
-			} `json:"usage"`
-		} `json:"response"`
+	// Parse response.completed event for usage (OpenAI Responses format);
+	// other events are only probed for their type, without decoding the payload
+	if gjson.Get(data, "type").String() != "response.completed" {
+		return
 	}
+	response := gjson.Get(data, "response")
+	usage.InputTokens = int(response.Get("usage.input_tokens").Int())
+	usage.OutputTokens = int(response.Get("usage.output_tokens").Int())
+	usage.CacheReadInputTokens = int(response.Get("usage.input_tokens_details.cached_tokens").Int())
+	usage.ToolUsage = ParseResponsesToolUsage(response)
+	usage.FinishReason = responsesFinishReason(response)
+	s.captureResponseContinuity(response, usage)
+}
 
-	if json.Unmarshal([]byte(data), &event) == nil && event.Type == "response.completed" {
-		usage.InputTokens = event.Response.Usage.InputTokens
-		usage.OutputTokens = event.Response.Usage.OutputTokens
-		usage.CacheReadInputTokens = event.Response.Usage.InputTokenDetails.CachedTokens
+// responsesFinishReason 返回 Responses 响应的结束原因：未完成时取 incomplete_details.reason，否则取 status
+func responsesFinishReason(response gjson.Result) string {
+	if reason := response.Get("incomplete_details.reason").String(); reason != "" {
+		return reason
 	}
+	return response.Get("status").String()
 }
 
 func (s *OpenAIGatewayService) handleNonStreamingResponse(ctx context.Context, resp *http.Response, c *gin.Context, account *Account, originalModel, mappedModel string) (*OpenAIUsage, error) {
@@ -1588,11 +1701,12 @@ func (s *OpenAIGatewayService) handleNonStreamingResponse(ctx context.Context, r
 	if err != nil {
 		return nil, err
 	}
+	echoModel := modelAliasEchoModel(ctx, originalModel)
 
 	if account.Type == AccountTypeOAuth {
 		bodyLooksLikeSSE := bytes.Contains(body, []byte("data:")) || bytes.Contains(body, []byte("event:"))
 		if isEventStreamResponse(resp.Header) || bodyLooksLikeSSE {
-			return s.handleOAuthSSEToJSON(resp, c, body, originalModel, mappedModel)
+			return s.handleOAuthSSEToJSON(resp, c, body, echoModel, mappedModel)
 		}
 	}
 
@@ -1614,18 +1728,25 @@ func (s *OpenAIGatewayService) handleNonStreamingResponse(ctx context.Context, r
 		InputTokens:          response.Usage.InputTokens,
 		OutputTokens:         response.Usage.OutputTokens,
 		CacheReadInputTokens: response.Usage.InputTokenDetails.CachedTokens,
+		ToolUsage:            ParseResponsesToolUsage(gjson.ParseBytes(body)),
+		FinishReason:         responsesFinishReason(gjson.ParseBytes(body)),
 	}
+	s.captureResponseContinuity(gjson.ParseBytes(body), usage)
+	fingerprint := gatewayFingerprintFromContext(c)
+	fingerprint.observe(string(body))
 
 	// Replace model in response if needed
-	if originalModel != mappedModel {
-		body = s.replaceModelInResponseBody(body, mappedModel, originalModel)
+	if echoModel != mappedModel {
+		body = s.replaceModelInResponseBody(body, mappedModel, echoModel)
 	}
 
 	if chatCompatRaw, ok := c.Get(CtxKeyOpenAIChatCompletionsCompat); ok {
 		if chatCompat, _ := chatCompatRaw.(bool); chatCompat {
-			body = convertResponsesJSONToChatCompletion(body, originalModel, usage)
+			body = convertResponsesJSONToChatCompletion(body, echoModel, usage)
 		}
 	}
+	body = decorateFingerprintBody(fingerprint, body)
+	body = withModelDeprecationWarning(ctx, body)
 
 	responseheaders.WriteFilteredHeaders(c.Writer.Header(), resp.Header, s.cfg.Security.ResponseHeaders)
 
@@ -2492,6 +2613,9 @@ func (s *OpenAIGatewayService) handleOAuthSSEToJSON(resp *http.Response, c *gin.
 			usage.OutputTokens = response.Usage.OutputTokens
 			usage.CacheReadInputTokens = response.Usage.InputTokenDetails.CachedTokens
 		}
+		usage.ToolUsage = ParseResponsesToolUsage(gjson.ParseBytes(finalResponse))
+		s.captureResponseContinuity(gjson.ParseBytes(finalResponse), usage)
+		gatewayFingerprintFromContext(c).observe(string(finalResponse))
 		body = finalResponse
 		if originalModel != mappedModel {
 			body = s.replaceModelInResponseBody(body, mappedModel, originalModel)
@@ -2511,6 +2635,10 @@ func (s *OpenAIGatewayService) handleOAuthSSEToJSON(resp *http.Response, c *gin.
 			body = convertResponsesJSONToChatCompletion(body, originalModel, usage)
 		}
 	}
+	if ok {
+		body = decorateFingerprintBody(gatewayFingerprintFromContext(c), body)
+		body = withModelDeprecationWarning(c.Request.Context(), body)
+	}
 
 	responseheaders.WriteFilteredHeaders(c.Writer.Header(), resp.Header, s.cfg.Security.ResponseHeaders)
 
@@ -2529,11 +2657,8 @@ func (s *OpenAIGatewayService) handleOAuthSSEToJSON(resp *http.Response, c *gin.
 func extractCodexFinalResponse(body string) ([]byte, bool) {
 	lines := strings.Split(body, "\n")
 	for _, line := range lines {
-		if !openaiSSEDataRe.MatchString(line) {
-			continue
-		}
-		data := openaiSSEDataRe.ReplaceAllString(line, "")
-		if data == "" || data == "[DONE]" {
+		data, ok := sseDataPayload(line)
+		if !ok || data == "" || data == "[DONE]" {
 			continue
 		}
 		var event struct {
@@ -2556,11 +2681,8 @@ func (s *OpenAIGatewayService) parseSSEUsageFromBody(body string) *OpenAIUsage {
 	usage := &OpenAIUsage{}
 	lines := strings.Split(body, "\n")
 	for _, line := range lines {
-		if !openaiSSEDataRe.MatchString(line) {
-			continue
-		}
-		data := openaiSSEDataRe.ReplaceAllString(line, "")
-		if data == "" || data == "[DONE]" {
+		data, ok := sseDataPayload(line)
+		if !ok || data == "" || data == "[DONE]" {
 			continue
 		}
 		s.parseSSEUsage(data, usage)
@@ -2571,9 +2693,6 @@ func (s *OpenAIGatewayService) parseSSEUsageFromBody(body string) *OpenAIUsage {
 func (s *OpenAIGatewayService) replaceModelInSSEBody(body, fromModel, toModel string) string {
 	lines := strings.Split(body, "\n")
 	for i, line := range lines {
-		if !openaiSSEDataRe.MatchString(line) {
-			continue
-		}
 		lines[i] = s.replaceModelInSSELine(line, fromModel, toModel)
 	}
 	return strings.Join(lines, "\n")
@@ -2659,11 +2778,15 @@ func (s *OpenAIGatewayService) RecordUsage(ctx context.Context, input *OpenAIRec
 		multiplier = apiKey.Group.RateMultiplier
 	}
 
-	cost, err := s.billingService.CalculateCost(result.Model, tokens, multiplier)
+	cost, err := s.billingService.CalculateCostForPlatform(account.Platform, result.Model, tokens, multiplier)
 	if err != nil {
 		cost = &CostBreakdown{ActualCost: 0}
 	}
 
+	// 内置工具（web_search / code_interpreter / image_generation）按次独立计费
+	toolUsage := result.Usage.ToolUsage
+	s.billingService.ApplyToolCost(cost, toolUsage, multiplier)
+
 	// Determine billing type
 	isSubscriptionBilling := subscription != nil && apiKey.Group != nil && apiKey.Group.IsSubscriptionType()
 	billingType := BillingTypeBalance
@@ -2689,15 +2812,21 @@ func (s *OpenAIGatewayService) RecordUsage(ctx context.Context, input *OpenAIRec
 		OutputCost:            cost.OutputCost,
 		CacheCreationCost:     cost.CacheCreationCost,
 		CacheReadCost:         cost.CacheReadCost,
+		ToolCost:              cost.ToolCost,
 		TotalCost:             cost.TotalCost,
 		ActualCost:            cost.ActualCost,
 		RateMultiplier:        multiplier,
 		AccountRateMultiplier: &accountRateMultiplier,
 		BillingType:           billingType,
+		MeteringOnly:          apiKey.Group.IsMeteringOnly(),
+		GatewayFingerprint:    gatewayFingerprintPtr(result.GatewayFingerprint),
 		Stream:                result.Stream,
 		DurationMs:            &durationMs,
 		FirstTokenMs:          result.FirstTokenMs,
+		ToolUsage:             toolUsage.Ptr(),
+		FinishReason:          result.Usage.FinishReason,
 		CreatedAt:             time.Now(),
+		APIKey:                apiKey,
 	}
 
 	// 添加 UserAgent
@@ -2717,41 +2846,87 @@ func (s *OpenAIGatewayService) RecordUsage(ctx context.Context, input *OpenAIRec
 		usageLog.SubscriptionID = &subscription.ID
 	}
 
+	redactUsageLogPII(usageLog)
+	pending := &PendingUsageRecord{}
 	inserted, err := s.usageLogRepo.Create(ctx, usageLog)
+	if err != nil {
+		log.Printf("Create usage log failed: %v", err)
+		pending.SetUsageLog(usageLog)
+	}
+	if inserted || err != nil {
+		observeUsageTokens(account.Platform, usageLog)
+	}
 	if s.cfg != nil && s.cfg.RunMode == config.RunModeSimple {
 		log.Printf("[SIMPLE MODE] Usage recorded (not billed): user=%d, tokens=%d", usageLog.UserID, usageLog.TotalTokens())
+		s.usageRetryService.Enqueue(pending)
 		s.deferredService.ScheduleLastUsedUpdate(account.ID)
 		return nil
 	}
 
 	shouldBill := inserted || err != nil
+	// Metering-only groups record usage but skip balance/subscription/API key quota deduction and spend tracking
+	shouldCharge := shouldBill && !usageLog.MeteringOnly
 
 	// Deduct based on billing type
 	if isSubscriptionBilling {
-		if shouldBill && cost.TotalCost > 0 {
-			_ = s.userSubRepo.IncrementUsage(ctx, subscription.ID, cost.TotalCost)
+		if shouldCharge && cost.TotalCost > 0 {
+			if err := s.userSubRepo.IncrementUsage(ctx, subscription.ID, cost.TotalCost); err != nil {
+				log.Printf("Increment subscription usage failed: %v", err)
+				pending.SubscriptionID, pending.SubscriptionUsage = subscription.ID, cost.TotalCost
+			}
 			s.billingCacheService.QueueUpdateSubscriptionUsage(user.ID, *apiKey.GroupID, cost.TotalCost)
 		}
 	} else {
-		if shouldBill && cost.ActualCost > 0 {
-			_ = s.userRepo.DeductBalance(ctx, user.ID, cost.ActualCost)
+		if shouldCharge && cost.ActualCost > 0 {
+			if err := s.userRepo.DeductBalance(ctx, user.ID, cost.ActualCost); err != nil {
+				log.Printf("Deduct balance failed: %v", err)
+				pending.UserID, pending.BalanceDeduction = user.ID, cost.ActualCost
+			}
 			s.billingCacheService.QueueDeductBalance(user.ID, cost.ActualCost)
 		}
 	}
 
 	// Update API key quota if applicable (only for balance mode with quota set)
-	if shouldBill && cost.ActualCost > 0 && apiKey.Quota > 0 && input.APIKeyService != nil {
+	if shouldCharge && cost.ActualCost > 0 && apiKey.Quota > 0 && input.APIKeyService != nil {
 		if err := input.APIKeyService.UpdateQuotaUsed(ctx, apiKey.ID, cost.ActualCost); err != nil {
 			log.Printf("Update API key quota failed: %v", err)
+			pending.APIKeyID, pending.APIKeyQuota = apiKey.ID, cost.ActualCost
 		}
 	}
 
+	// Count tokens/requests against API key token quota if configured
+	if shouldBill && !apiKey.TokenQuota.IsEmpty() {
+		s.billingCacheService.QueueTokenQuotaUsage(apiKey, int64(usageLog.TotalTokens()))
+	}
+
+	// Accumulate user spend for per-user soft/hard spend caps
+	if shouldCharge && cost.ActualCost > 0 {
+		s.billingCacheService.RecordUserSpend(ctx, user, cost.ActualCost)
+	}
+
+	// Budget threshold alerts (API key quota, subscription limits)
+	if shouldCharge {
+		s.observeBudgetUsage(ctx, apiKey, subscription, cost)
+	}
+
+	// Failed usage log writes and charges go to the durable retry queue instead of being lost
+	s.usageRetryService.Enqueue(pending)
+
 	// Schedule batch update for account last_used_at
 	s.deferredService.ScheduleLastUsedUpdate(account.ID)
 
 	return nil
 }
 
+// observeBudgetUsage submits billed usage to budget alerts. Upstream account usage is reported
+// from Codex rate limit headers in Forward.
+func (s *OpenAIGatewayService) observeBudgetUsage(ctx context.Context, apiKey *APIKey, subscription *UserSubscription, cost *CostBreakdown) {
+	if s.budgetAlertService == nil || cost == nil {
+		return
+	}
+	s.budgetAlertService.Observe(ctx, budgetUsagesForRecordedUsage(apiKey, subscription, cost.TotalCost, cost.ActualCost)...)
+}
+
 // ParseCodexRateLimitHeaders extracts Codex usage limits from response headers.
 // Exported for use in ratelimit_service when handling OpenAI 429 responses.
 func ParseCodexRateLimitHeaders(headers http.Header) *OpenAICodexUsageSnapshot {
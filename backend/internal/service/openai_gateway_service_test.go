@@ -88,6 +88,15 @@ func (c stubConcurrencyCache) AcquireAccountSlot(ctx context.Context, accountID
 	return true, nil
 }
 
+func (c stubConcurrencyCache) ReserveAccountSlot(ctx context.Context, accountID int64, maxConcurrency, expectedConcurrency int, requestID string, ttl time.Duration) (bool, int, error) {
+	ok, err := c.AcquireAccountSlot(ctx, accountID, maxConcurrency, requestID)
+	return ok, expectedConcurrency + 1, err
+}
+
+func (c stubConcurrencyCache) CommitAccountSlot(ctx context.Context, accountID int64, requestID string) (bool, error) {
+	return true, nil
+}
+
 func (c stubConcurrencyCache) ReleaseAccountSlot(ctx context.Context, accountID int64, requestID string) error {
 	return nil
 }
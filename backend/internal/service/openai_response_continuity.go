@@ -0,0 +1,190 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"strings"
+	"time"
+
+	"github.com/tidwall/gjson"
+	"github.com/tidwall/sjson"
+)
+
+// OpenAIResponseContext 网关记录的 Responses 响应续链信息
+type OpenAIResponseContext struct {
+	// AccountID 创建该响应的上游账号，上游只在该账号上保存 previous_response_id 对应的上下文
+	AccountID int64 `json:"account_id"`
+	// History 截至该响应（含其输出）的完整对话项，未开启历史保存或超出上限时为空
+	History json.RawMessage `json:"history,omitempty"`
+}
+
+// OpenAIResponseContextCache 响应续链信息存储
+type OpenAIResponseContextCache interface {
+	// GetResponseContext 查询响应续链信息，不存在时返回 nil, nil
+	GetResponseContext(ctx context.Context, responseID string) (*OpenAIResponseContext, error)
+	SetResponseContext(ctx context.Context, responseID string, rc *OpenAIResponseContext, ttl time.Duration) error
+}
+
+// PreviousResponseUnavailableError 续链请求被分配到其他账号，且网关没有可用于重建上下文的历史
+type PreviousResponseUnavailableError struct {
+	ResponseID string
+}
+
+func (e *PreviousResponseUnavailableError) Error() string {
+	return fmt.Sprintf("previous_response_id %q belongs to an upstream account that is currently unavailable, and its conversation history is not stored by the gateway; resend the full conversation in input without previous_response_id", e.ResponseID)
+}
+
+func (s *OpenAIGatewayService) responseContinuityEnabled() bool {
+	return s.responseContexts != nil && s.cfg != nil && s.cfg.Gateway.ResponseContinuity.Enabled
+}
+
+func (s *OpenAIGatewayService) responseHistoryEnabled() bool {
+	return s.responseContinuityEnabled() && s.cfg.Gateway.ResponseContinuity.StoreHistory
+}
+
+// PrepareResponseContinuity 转发前检查 previous_response_id 续链：
+// 响应由当前账号创建、或不是经网关创建（无记录）时原样转发；
+// 由其他账号创建时，用保存的对话历史替换 previous_response_id 重建上下文，
+// 没有历史时返回 PreviousResponseUnavailableError，避免转发必然失败的请求。
+func (s *OpenAIGatewayService) PrepareResponseContinuity(ctx context.Context, account *Account, body []byte) ([]byte, error) {
+	if !s.responseContinuityEnabled() || account == nil {
+		return body, nil
+	}
+	prevID := strings.TrimSpace(gjson.GetBytes(body, "previous_response_id").String())
+	if prevID == "" {
+		return body, nil
+	}
+	rc, err := s.responseContexts.GetResponseContext(ctx, prevID)
+	if err != nil {
+		log.Printf("[OpenAI] response continuity lookup failed: response_id=%s err=%v", prevID, err)
+		return body, nil
+	}
+	if rc == nil || rc.AccountID == account.ID {
+		return body, nil
+	}
+	if len(rc.History) == 0 {
+		return nil, &PreviousResponseUnavailableError{ResponseID: prevID}
+	}
+	rebuilt, err := materializeResponseHistory(body, rc.History)
+	if err != nil {
+		log.Printf("[OpenAI] response continuity rebuild failed: response_id=%s err=%v", prevID, err)
+		return nil, &PreviousResponseUnavailableError{ResponseID: prevID}
+	}
+	log.Printf("[OpenAI] previous_response_id %s owned by account %d, rebuilt context from stored history for account %d", prevID, rc.AccountID, account.ID)
+	return rebuilt, nil
+}
+
+// RecordResponseContext 记录成功响应的所属账号（开启历史保存时一并保存对话历史），供后续续链请求使用。
+// body 为实际转发给该账号的请求体（可能已由 PrepareResponseContinuity 重建）。
+func (s *OpenAIGatewayService) RecordResponseContext(ctx context.Context, account *Account, body []byte, result *OpenAIForwardResult) {
+	if !s.responseContinuityEnabled() || account == nil || result == nil || result.Usage.ResponseID == "" {
+		return
+	}
+	cfg := s.cfg.Gateway.ResponseContinuity
+	rc := &OpenAIResponseContext{AccountID: account.ID}
+	if cfg.StoreHistory {
+		rc.History = s.buildResponseHistory(ctx, body, result.Usage.ResponseOutput, cfg.MaxHistoryBytes)
+	}
+	ttl := time.Duration(cfg.TTLSeconds) * time.Second
+	if err := s.responseContexts.SetResponseContext(ctx, result.Usage.ResponseID, rc, ttl); err != nil {
+		log.Printf("[OpenAI] response continuity record failed: response_id=%s account=%d err=%v", result.Usage.ResponseID, account.ID, err)
+	}
+}
+
+// captureResponseContinuity 从上游最终响应中提取响应 ID 与 output（仅开启历史保存时）
+func (s *OpenAIGatewayService) captureResponseContinuity(response gjson.Result, usage *OpenAIUsage) {
+	if !s.responseContinuityEnabled() || usage == nil {
+		return
+	}
+	usage.ResponseID = response.Get("id").String()
+	if s.responseHistoryEnabled() {
+		if output := response.Get("output"); output.IsArray() {
+			usage.ResponseOutput = json.RawMessage(output.Raw)
+		}
+	}
+}
+
+// buildResponseHistory 拼接截至本次响应的完整对话：上一响应的历史 + 本次输入 + 本次输出。
+// 本次请求引用了无历史记录的 previous_response_id，或结果超过 maxBytes 时返回 nil（只记录所属账号）。
+func (s *OpenAIGatewayService) buildResponseHistory(ctx context.Context, body []byte, output json.RawMessage, maxBytes int) json.RawMessage {
+	var items []json.RawMessage
+	if prevID := strings.TrimSpace(gjson.GetBytes(body, "previous_response_id").String()); prevID != "" {
+		prev, err := s.responseContexts.GetResponseContext(ctx, prevID)
+		if err != nil || prev == nil || len(prev.History) == 0 {
+			return nil
+		}
+		if err := json.Unmarshal(prev.History, &items); err != nil {
+			return nil
+		}
+	}
+	items = append(items, responseInputItems(body)...)
+	items = append(items, portableResponseOutputItems(output)...)
+	raw, err := json.Marshal(items)
+	if err != nil || (maxBytes > 0 && len(raw) > maxBytes) {
+		return nil
+	}
+	return raw
+}
+
+// materializeResponseHistory 用保存的历史替换 previous_response_id：input = 历史 + 本次输入
+func materializeResponseHistory(body []byte, history json.RawMessage) ([]byte, error) {
+	var items []json.RawMessage
+	if err := json.Unmarshal(history, &items); err != nil {
+		return nil, err
+	}
+	items = append(items, responseInputItems(body)...)
+	input, err := json.Marshal(items)
+	if err != nil {
+		return nil, err
+	}
+	out, err := sjson.SetRawBytes(body, "input", input)
+	if err != nil {
+		return nil, err
+	}
+	return sjson.DeleteBytes(out, "previous_response_id")
+}
+
+// responseInputItems 将请求 input 规范化为对话项列表（字符串 input 视为一条 user 消息）
+func responseInputItems(body []byte) []json.RawMessage {
+	input := gjson.GetBytes(body, "input")
+	switch {
+	case input.Type == gjson.String:
+		item, _ := json.Marshal(map[string]any{"type": "message", "role": "user", "content": input.String()})
+		return []json.RawMessage{item}
+	case input.IsArray():
+		items := make([]json.RawMessage, 0, len(input.Array()))
+		for _, item := range input.Array() {
+			items = append(items, json.RawMessage(item.Raw))
+		}
+		return items
+	}
+	return nil
+}
+
+// portableResponseOutputItems 将上游输出项转换为可在其他账号上作为输入重放的对话项：
+// 去掉只在原账号有效的项 ID，丢弃没有 encrypted_content 的 reasoning 项（其内容只保存在原账号）
+func portableResponseOutputItems(output json.RawMessage) []json.RawMessage {
+	if len(output) == 0 {
+		return nil
+	}
+	result := gjson.ParseBytes(output)
+	if !result.IsArray() {
+		return nil
+	}
+	items := make([]json.RawMessage, 0, len(result.Array()))
+	for _, item := range result.Array() {
+		if item.Get("type").String() == "reasoning" && item.Get("encrypted_content").String() == "" {
+			continue
+		}
+		raw := []byte(item.Raw)
+		if item.Get("id").Exists() {
+			if stripped, err := sjson.DeleteBytes(raw, "id"); err == nil {
+				raw = stripped
+			}
+		}
+		items = append(items, raw)
+	}
+	return items
+}
@@ -0,0 +1,121 @@
+//go:build unit
+
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/Wei-Shaw/sub2api/internal/config"
+	"github.com/stretchr/testify/require"
+	"github.com/tidwall/gjson"
+)
+
+type responseContextCacheStub struct {
+	items map[string]*OpenAIResponseContext
+	ttl   time.Duration
+}
+
+func (c *responseContextCacheStub) GetResponseContext(ctx context.Context, responseID string) (*OpenAIResponseContext, error) {
+	return c.items[responseID], nil
+}
+
+func (c *responseContextCacheStub) SetResponseContext(ctx context.Context, responseID string, rc *OpenAIResponseContext, ttl time.Duration) error {
+	c.items[responseID] = rc
+	c.ttl = ttl
+	return nil
+}
+
+func newResponseContinuityTestService(storeHistory bool) (*OpenAIGatewayService, *responseContextCacheStub) {
+	cfg := &config.Config{}
+	cfg.Gateway.ResponseContinuity = config.GatewayResponseContinuityConfig{
+		Enabled:         true,
+		TTLSeconds:      600,
+		StoreHistory:    storeHistory,
+		MaxHistoryBytes: 1 << 20,
+	}
+	cache := &responseContextCacheStub{items: map[string]*OpenAIResponseContext{}}
+	return &OpenAIGatewayService{cfg: cfg, responseContexts: cache}, cache
+}
+
+func recordTestResponse(svc *OpenAIGatewayService, account *Account, body, response string) {
+	result := &OpenAIForwardResult{}
+	svc.captureResponseContinuity(gjson.Parse(response), &result.Usage)
+	svc.RecordResponseContext(context.Background(), account, []byte(body), result)
+}
+
+func TestPrepareResponseContinuity_SameAccountPassesThrough(t *testing.T) {
+	svc, cache := newResponseContinuityTestService(true)
+	account := &Account{ID: 1}
+	recordTestResponse(svc, account, `{"input":"hi"}`, `{"id":"resp_1","output":[]}`)
+	require.Equal(t, int64(1), cache.items["resp_1"].AccountID)
+	require.Equal(t, 600*time.Second, cache.ttl)
+
+	body := []byte(`{"input":"next","previous_response_id":"resp_1"}`)
+	out, err := svc.PrepareResponseContinuity(context.Background(), account, body)
+	require.NoError(t, err)
+	require.Equal(t, body, out)
+
+	// 未经网关创建的响应原样转发，由上游自行判断
+	unknown := []byte(`{"input":"next","previous_response_id":"resp_unknown"}`)
+	out, err = svc.PrepareResponseContinuity(context.Background(), &Account{ID: 2}, unknown)
+	require.NoError(t, err)
+	require.Equal(t, unknown, out)
+}
+
+func TestPrepareResponseContinuity_RebuildsFromHistoryOnOtherAccount(t *testing.T) {
+	svc, _ := newResponseContinuityTestService(true)
+	first := &Account{ID: 1}
+	recordTestResponse(svc, first, `{"model":"gpt-5","input":"hi"}`,
+		`{"id":"resp_1","output":[{"id":"rs_1","type":"reasoning","summary":[]},{"id":"msg_1","type":"message","role":"assistant","content":[{"type":"output_text","text":"hello"}]}]}`)
+	recordTestResponse(svc, first, `{"model":"gpt-5","input":[{"type":"message","role":"user","content":"how are you"}],"previous_response_id":"resp_1"}`,
+		`{"id":"resp_2","output":[{"id":"msg_2","type":"message","role":"assistant","content":[{"type":"output_text","text":"fine"}]}]}`)
+
+	body := []byte(`{"model":"gpt-5","input":"bye","previous_response_id":"resp_2"}`)
+	out, err := svc.PrepareResponseContinuity(context.Background(), &Account{ID: 2}, body)
+	require.NoError(t, err)
+	require.False(t, gjson.GetBytes(out, "previous_response_id").Exists())
+	require.Equal(t, "gpt-5", gjson.GetBytes(out, "model").String())
+
+	var items []map[string]any
+	require.NoError(t, json.Unmarshal([]byte(gjson.GetBytes(out, "input").Raw), &items))
+	require.Len(t, items, 5)
+	require.Equal(t, "hi", items[0]["content"])
+	require.Equal(t, "assistant", items[1]["role"])
+	require.NotContains(t, items[1], "id")
+	require.Equal(t, "how are you", items[2]["content"])
+	require.Equal(t, "assistant", items[3]["role"])
+	require.Equal(t, "bye", items[4]["content"])
+}
+
+func TestPrepareResponseContinuity_NoHistoryReturnsClearError(t *testing.T) {
+	svc, _ := newResponseContinuityTestService(false)
+	recordTestResponse(svc, &Account{ID: 1}, `{"input":"hi"}`, `{"id":"resp_1","output":[]}`)
+
+	_, err := svc.PrepareResponseContinuity(context.Background(), &Account{ID: 2}, []byte(`{"input":"next","previous_response_id":"resp_1"}`))
+	var unavailableErr *PreviousResponseUnavailableError
+	require.True(t, errors.As(err, &unavailableErr))
+	require.Equal(t, "resp_1", unavailableErr.ResponseID)
+	require.Contains(t, err.Error(), "without previous_response_id")
+}
+
+func TestRecordResponseContext_HistoryOverLimitKeepsOwnerOnly(t *testing.T) {
+	svc, cache := newResponseContinuityTestService(true)
+	svc.cfg.Gateway.ResponseContinuity.MaxHistoryBytes = 16
+	recordTestResponse(svc, &Account{ID: 1}, `{"input":"a fairly long prompt"}`, `{"id":"resp_1","output":[]}`)
+
+	require.Equal(t, int64(1), cache.items["resp_1"].AccountID)
+	require.Empty(t, cache.items["resp_1"].History)
+}
+
+func TestResponseContinuity_DisabledIsNoop(t *testing.T) {
+	svc := &OpenAIGatewayService{cfg: &config.Config{}}
+	body := []byte(`{"input":"next","previous_response_id":"resp_1"}`)
+	out, err := svc.PrepareResponseContinuity(context.Background(), &Account{ID: 2}, body)
+	require.NoError(t, err)
+	require.Equal(t, body, out)
+	svc.RecordResponseContext(context.Background(), &Account{ID: 2}, body, &OpenAIForwardResult{})
+}
@@ -0,0 +1,140 @@
+package service
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"github.com/tidwall/gjson"
+	"github.com/tidwall/sjson"
+)
+
+// 携带 seed 的 OpenAI 兼容请求回显网关指纹
+//
+// 上游的 system_fingerprint 只反映模型后端配置，体现不出网关在不同账号之间的故障转移。
+// 网关指纹由（上游指纹、账号别名哈希、网关版本）组成：Chat Completions 响应写入标准的
+// system_fingerprint 字段，Responses 响应写入 gateway_fingerprint 字段，同时记录到使用记录中，
+// 用户据此可以判断结果不可复现是否因为切换了账号。
+const (
+	// ctxKeyOpenAIGatewayFingerprint 当前转发尝试的网关指纹（仅请求携带 seed 时设置）
+	ctxKeyOpenAIGatewayFingerprint = "openai_gateway_fingerprint"
+	// GatewayFingerprintField Responses 响应中回显网关指纹的字段名
+	GatewayFingerprintField = "gateway_fingerprint"
+)
+
+// gatewayFingerprint 网关指纹
+type gatewayFingerprint struct {
+	upstream string
+	account  string
+	version  string
+}
+
+func newGatewayFingerprint(account *Account, version string) *gatewayFingerprint {
+	if version == "" {
+		version = "dev"
+	}
+	return &gatewayFingerprint{account: accountAliasHash(account), version: version}
+}
+
+// accountAliasHash 账号别名哈希：同一账号保持稳定，但不暴露账号 ID 或名称
+func accountAliasHash(account *Account) string {
+	if account == nil {
+		return "none"
+	}
+	sum := sha256.Sum256([]byte(fmt.Sprintf("%d:%d", account.ID, account.CreatedAt.UnixNano())))
+	return hex.EncodeToString(sum[:4])
+}
+
+// requestHasSeed 请求是否携带 seed
+func requestHasSeed(reqBody map[string]any) bool {
+	v, ok := reqBody["seed"]
+	return ok && v != nil
+}
+
+// gatewayFingerprintFromContext 取当前转发尝试的网关指纹；未携带 seed 时返回 nil
+func gatewayFingerprintFromContext(c *gin.Context) *gatewayFingerprint {
+	if c == nil {
+		return nil
+	}
+	v, ok := c.Get(ctxKeyOpenAIGatewayFingerprint)
+	if !ok {
+		return nil
+	}
+	fp, _ := v.(*gatewayFingerprint)
+	return fp
+}
+
+// observe 从上游 JSON 响应或 SSE data 中提取上游指纹（只取第一次出现的值）：
+// 优先使用 system_fingerprint，上游未提供时退化为上游实际解析到的模型版本
+func (f *gatewayFingerprint) observe(data string) {
+	if f == nil || f.upstream != "" || data == "" || !gjson.Valid(data) {
+		return
+	}
+	for _, path := range []string{"system_fingerprint", "response.system_fingerprint", "response.model", "model"} {
+		if v := strings.TrimSpace(gjson.Get(data, path).String()); v != "" {
+			f.upstream = v
+			return
+		}
+	}
+}
+
+// String 返回回显给客户端的指纹，例如 gpt-5.1-codex-2025-11-13+acct_1a2b3c4d+0.1.60
+func (f *gatewayFingerprint) String() string {
+	if f == nil {
+		return ""
+	}
+	upstream := f.upstream
+	if upstream == "" {
+		upstream = "unknown"
+	}
+	return fmt.Sprintf("%s+acct_%s+%s", upstream, f.account, f.version)
+}
+
+// decorateResponses 在 Responses 响应体或带 response 对象的流式事件中写入 gateway_fingerprint
+func (f *gatewayFingerprint) decorateResponses(payload string) string {
+	if f == nil || !gjson.Valid(payload) {
+		return payload
+	}
+	path := GatewayFingerprintField
+	if gjson.Get(payload, "response").IsObject() {
+		path = "response." + GatewayFingerprintField
+	} else if gjson.Get(payload, "object").String() != "response" {
+		return payload
+	}
+	if out, err := sjson.Set(payload, path, f.String()); err == nil {
+		return out
+	}
+	return payload
+}
+
+// decorateChat 在 Chat Completions 响应体或 chunk 中写入 system_fingerprint
+func (f *gatewayFingerprint) decorateChat(payload string) string {
+	if f == nil || !gjson.Valid(payload) {
+		return payload
+	}
+	if out, err := sjson.Set(payload, "system_fingerprint", f.String()); err == nil {
+		return out
+	}
+	return payload
+}
+
+// decorateFingerprintBody 为非流式响应体写入指纹（按响应格式选择字段）
+func decorateFingerprintBody(f *gatewayFingerprint, body []byte) []byte {
+	if f == nil {
+		return body
+	}
+	if gjson.GetBytes(body, "object").String() == "chat.completion" {
+		return []byte(f.decorateChat(string(body)))
+	}
+	return []byte(f.decorateResponses(string(body)))
+}
+
+// gatewayFingerprintPtr 使用记录中的网关指纹，未携带 seed 时为 nil
+func gatewayFingerprintPtr(fingerprint string) *string {
+	if fingerprint == "" {
+		return nil
+	}
+	return &fingerprint
+}
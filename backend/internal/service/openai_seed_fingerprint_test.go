@@ -0,0 +1,65 @@
+//go:build unit
+
+package service
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	"github.com/tidwall/gjson"
+)
+
+func TestRequestHasSeed(t *testing.T) {
+	require.True(t, requestHasSeed(map[string]any{"seed": float64(42)}))
+	require.False(t, requestHasSeed(map[string]any{"seed": nil}))
+	require.False(t, requestHasSeed(map[string]any{"model": "gpt-5"}))
+}
+
+func TestGatewayFingerprint_ComposesUpstreamAccountAndVersion(t *testing.T) {
+	created := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+	a1 := &Account{ID: 1, CreatedAt: created}
+	a2 := &Account{ID: 2, CreatedAt: created}
+	require.Equal(t, accountAliasHash(a1), accountAliasHash(&Account{ID: 1, CreatedAt: created}))
+	require.NotEqual(t, accountAliasHash(a1), accountAliasHash(a2))
+
+	fp := newGatewayFingerprint(a1, "v0.1.60")
+	require.Equal(t, "unknown+acct_"+accountAliasHash(a1)+"+v0.1.60", fp.String())
+
+	// 优先使用上游 system_fingerprint，且只取第一次出现的值
+	fp.observe(`{"type":"response.created","response":{"model":"gpt-5.1-codex","system_fingerprint":"fp_abc"}}`)
+	fp.observe(`{"system_fingerprint":"fp_other"}`)
+	require.Equal(t, "fp_abc+acct_"+accountAliasHash(a1)+"+v0.1.60", fp.String())
+
+	// 上游未提供指纹时退化为上游模型版本
+	fallback := newGatewayFingerprint(a2, "")
+	fallback.observe(`{"object":"response","model":"gpt-5.1-codex-2025-11-13"}`)
+	require.Equal(t, "gpt-5.1-codex-2025-11-13+acct_"+accountAliasHash(a2)+"+dev", fallback.String())
+
+	var nilFP *gatewayFingerprint
+	nilFP.observe(`{"model":"x"}`)
+	require.Empty(t, nilFP.String())
+	require.Nil(t, gatewayFingerprintPtr(nilFP.String()))
+}
+
+func TestGatewayFingerprint_Decorate(t *testing.T) {
+	fp := newGatewayFingerprint(&Account{ID: 1}, "1.0.0")
+	want := fp.String()
+
+	event := fp.decorateResponses(`{"type":"response.completed","response":{"id":"resp_1"}}`)
+	require.Equal(t, want, gjson.Get(event, "response."+GatewayFingerprintField).String())
+
+	// 不含 response 对象的增量事件保持原样
+	delta := `{"type":"response.output_text.delta","delta":"hi"}`
+	require.Equal(t, delta, fp.decorateResponses(delta))
+
+	body := decorateFingerprintBody(fp, []byte(`{"object":"response","id":"resp_1"}`))
+	require.Equal(t, want, gjson.GetBytes(body, GatewayFingerprintField).String())
+
+	chat := decorateFingerprintBody(fp, []byte(`{"object":"chat.completion","id":"chatcmpl_1"}`))
+	require.Equal(t, want, gjson.GetBytes(chat, "system_fingerprint").String())
+	require.Equal(t, want, gjson.Get(fp.decorateChat(`{"object":"chat.completion.chunk"}`), "system_fingerprint").String())
+
+	var nilFP *gatewayFingerprint
+	require.Equal(t, delta, nilFP.decorateChat(delta))
+}
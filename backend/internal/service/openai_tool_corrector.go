@@ -4,6 +4,7 @@ import (
 	"encoding/json"
 	"fmt"
 	"log"
+	"strings"
 	"sync"
 )
 
@@ -61,6 +62,10 @@ func (c *CodexToolCorrector) CorrectToolCallsInSSEData(data string) (string, boo
 	if data == "" || data == "\n" {
 		return data, false
 	}
+	// 快速路径：不含工具调用字段的事件（绝大多数文本增量）不做 JSON 解码，原样透传
+	if !strings.Contains(data, `"tool_calls"`) && !strings.Contains(data, `"function_call"`) {
+		return data, false
+	}
 
 	// 尝试解析 JSON
 	var payload map[string]any
@@ -0,0 +1,166 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/Wei-Shaw/sub2api/internal/pkg/claude"
+	infraerrors "github.com/Wei-Shaw/sub2api/internal/pkg/errors"
+	"github.com/Wei-Shaw/sub2api/internal/pkg/geminicli"
+	"github.com/Wei-Shaw/sub2api/internal/pkg/openai"
+)
+
+const (
+	// opsDryRunDefaultPrompt 未指定 prompt 时使用的固定测试内容
+	opsDryRunDefaultPrompt = "Reply with the single word: pong"
+	// opsDryRunMaxPromptLen prompt 最大长度（字符）
+	opsDryRunMaxPromptLen = 2000
+	// opsDryRunMaxTokens 限制输出长度，避免试运行消耗过多额度
+	opsDryRunMaxTokens = 32
+)
+
+// OpsAccountDryRunRequest 账号试运行请求（均为可选）
+type OpsAccountDryRunRequest struct {
+	Model  string `json:"model"`
+	Prompt string `json:"prompt"`
+}
+
+// OpsAccountDryRunResult 账号试运行结果
+type OpsAccountDryRunResult struct {
+	AccountID   int64  `json:"account_id"`
+	AccountName string `json:"account_name"`
+	Platform    string `json:"platform"`
+	Model       string `json:"model"`
+
+	Success    bool  `json:"success"`
+	StatusCode int   `json:"status_code"`
+	LatencyMs  int64 `json:"latency_ms"`
+
+	UpstreamRequestID  string `json:"upstream_request_id,omitempty"`
+	UpstreamStatusCode int    `json:"upstream_status_code,omitempty"`
+	// UpstreamError 上游返回的原始错误内容（成功时为空）
+	UpstreamError string `json:"upstream_error,omitempty"`
+	ErrorMessage  string `json:"error_message,omitempty"`
+
+	ResponsePreview   string `json:"response_preview,omitempty"`
+	ResponseTruncated bool   `json:"response_truncated,omitempty"`
+}
+
+// DryRunAccount 使用固定 prompt 经网关 Forward 直接向指定账号发送一次非流式请求，
+// 跳过调度与计费（不做账号选择、不绑定粘性会话、不记录用量），用于验证新接入的账号。
+// 账号不要求处于可调度状态，但仍受账号并发上限约束。
+func (s *OpsService) DryRunAccount(ctx context.Context, accountID int64, req *OpsAccountDryRunRequest) (*OpsAccountDryRunResult, error) {
+	if s.accountRepo == nil {
+		return nil, infraerrors.ServiceUnavailable("ACCOUNT_REPO_UNAVAILABLE", "Account repository not available")
+	}
+	if req == nil {
+		req = &OpsAccountDryRunRequest{}
+	}
+	prompt := strings.TrimSpace(req.Prompt)
+	if prompt == "" {
+		prompt = opsDryRunDefaultPrompt
+	}
+	if len([]rune(prompt)) > opsDryRunMaxPromptLen {
+		return nil, infraerrors.BadRequest("OPS_DRY_RUN_PROMPT_TOO_LONG", fmt.Sprintf("prompt must be at most %d characters", opsDryRunMaxPromptLen))
+	}
+
+	account, err := s.accountRepo.GetByID(ctx, accountID)
+	if err != nil {
+		return nil, err
+	}
+	if account == nil {
+		return nil, ErrAccountNotFound
+	}
+
+	model := strings.TrimSpace(req.Model)
+	if model == "" {
+		model = defaultDryRunModel(account)
+	}
+	reqType, path, body, err := buildDryRunRequest(account, model, prompt)
+	if err != nil {
+		return nil, err
+	}
+
+	if s.concurrencyService != nil {
+		acq, err := s.concurrencyService.AcquireAccountSlot(ctx, account.ID, account.Concurrency)
+		if err != nil {
+			return nil, infraerrors.ServiceUnavailable("OPS_DRY_RUN_SLOT_FAILED", fmt.Sprintf("acquire account slot failed: %v", err))
+		}
+		if acq == nil || !acq.Acquired {
+			return nil, infraerrors.Conflict("OPS_DRY_RUN_ACCOUNT_BUSY", "account concurrency limit reached")
+		}
+		if acq.ReleaseFunc != nil {
+			defer acq.ReleaseFunc()
+		}
+	}
+
+	execCtx, cancel := context.WithTimeout(ctx, opsRetryTimeout)
+	defer cancel()
+
+	errorLog := &OpsErrorLogDetail{}
+	errorLog.RequestPath = path
+	errorLog.Model = model
+
+	start := time.Now()
+	exec := s.executeWithAccountLimit(execCtx, reqType, errorLog, body, account, opsRetryCaptureBytesLimit)
+	latency := time.Since(start)
+
+	result := &OpsAccountDryRunResult{
+		AccountID:          account.ID,
+		AccountName:        account.Name,
+		Platform:           account.Platform,
+		Model:              model,
+		Success:            exec.status == opsRetryStatusSucceeded,
+		StatusCode:         exec.httpStatusCode,
+		LatencyMs:          latency.Milliseconds(),
+		UpstreamRequestID:  exec.upstreamRequestID,
+		UpstreamStatusCode: exec.upstreamStatusCode,
+		UpstreamError:      exec.upstreamError,
+		ErrorMessage:       exec.errorMessage,
+		ResponsePreview:    exec.responsePreview,
+		ResponseTruncated:  exec.responseTruncated,
+	}
+	// 失败切换类错误不会写响应，以上游状态码为准
+	if !result.Success && result.StatusCode < 400 && result.UpstreamStatusCode >= 400 {
+		result.StatusCode = result.UpstreamStatusCode
+	}
+	return result, nil
+}
+
+func defaultDryRunModel(account *Account) string {
+	switch account.Platform {
+	case PlatformOpenAI:
+		return openai.DefaultTestModel
+	case PlatformGemini:
+		return geminicli.DefaultTestModel
+	default:
+		return claude.DefaultTestModel
+	}
+}
+
+// buildDryRunRequest 按平台构造试运行请求体：OpenAI 走 Responses 格式，其余走 Claude Messages 格式
+func buildDryRunRequest(account *Account, model, prompt string) (opsRetryRequestType, string, []byte, error) {
+	if account.Platform == PlatformOpenAI {
+		body, err := json.Marshal(map[string]any{
+			"model": model,
+			"input": []map[string]any{
+				{"role": "user", "content": prompt},
+			},
+			"stream": false,
+		})
+		return opsRetryTypeOpenAI, "/v1/responses", body, err
+	}
+
+	body, err := json.Marshal(map[string]any{
+		"model":      model,
+		"max_tokens": opsDryRunMaxTokens,
+		"messages": []map[string]any{
+			{"role": "user", "content": prompt},
+		},
+		"stream": false,
+	})
+	return opsRetryTypeMessages, "/v1/messages", body, err
+}
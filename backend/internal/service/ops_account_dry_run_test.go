@@ -0,0 +1,59 @@
+package service
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/require"
+)
+
+func TestBuildDryRunRequest(t *testing.T) {
+	reqType, path, body, err := buildDryRunRequest(&Account{Platform: PlatformOpenAI}, "gpt-x", "hello")
+	require.NoError(t, err)
+	require.Equal(t, opsRetryTypeOpenAI, reqType)
+	require.Equal(t, "/v1/responses", path)
+	var openaiBody map[string]any
+	require.NoError(t, json.Unmarshal(body, &openaiBody))
+	require.Equal(t, "gpt-x", openaiBody["model"])
+	require.Equal(t, false, openaiBody["stream"])
+	require.Contains(t, string(body), `"content":"hello"`)
+
+	for _, platform := range []string{PlatformAnthropic, PlatformGemini, PlatformAntigravity} {
+		reqType, path, body, err = buildDryRunRequest(&Account{Platform: platform}, "m", "hi")
+		require.NoError(t, err)
+		require.Equal(t, opsRetryTypeMessages, reqType)
+		require.Equal(t, "/v1/messages", path)
+		var msgBody map[string]any
+		require.NoError(t, json.Unmarshal(body, &msgBody))
+		require.Equal(t, float64(opsDryRunMaxTokens), msgBody["max_tokens"])
+		require.Equal(t, false, msgBody["stream"])
+	}
+}
+
+func TestExtractOpsUpstreamError(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	// 失败切换错误：以其携带的上游响应体为准
+	c, _ := gin.CreateTestContext(httptest.NewRecorder())
+	c.Set(OpsUpstreamErrorMessageKey, "ignored")
+	code, detail := extractOpsUpstreamError(c, &UpstreamFailoverError{StatusCode: 429, ResponseBody: []byte(` {"error":"rate limited"} `)})
+	require.Equal(t, 429, code)
+	require.Equal(t, `{"error":"rate limited"}`, detail)
+
+	// 其余错误：详情优先于消息
+	c, _ = gin.CreateTestContext(httptest.NewRecorder())
+	c.Set(OpsUpstreamStatusCodeKey, 400)
+	c.Set(OpsUpstreamErrorMessageKey, "bad request")
+	c.Set(OpsUpstreamErrorDetailKey, `{"error":{"message":"invalid model"}}`)
+	code, detail = extractOpsUpstreamError(c, errors.New("upstream error"))
+	require.Equal(t, 400, code)
+	require.Equal(t, `{"error":{"message":"invalid model"}}`, detail)
+
+	c, _ = gin.CreateTestContext(httptest.NewRecorder())
+	code, detail = extractOpsUpstreamError(c, nil)
+	require.Zero(t, code)
+	require.Empty(t, detail)
+}
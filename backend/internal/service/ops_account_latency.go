@@ -0,0 +1,100 @@
+package service
+
+import (
+	"context"
+	"strconv"
+	"strings"
+	"time"
+
+	infraerrors "github.com/Wei-Shaw/sub2api/internal/pkg/errors"
+)
+
+const (
+	opsAccountLatencyDefaultLimit = 50
+	opsAccountLatencyMaxLimit     = 500
+)
+
+// OpsAccountLatencyBucketBoundsMs 按账号/模型统计的延迟直方图桶上界（毫秒，左闭右开，最后追加 +Inf 桶）。
+// 比仪表盘全局直方图更细，覆盖 LLM 长请求常见的 10s~2min 区间。
+var OpsAccountLatencyBucketBoundsMs = []int{250, 500, 1000, 2000, 5000, 10000, 20000, 30000, 60000, 120000}
+
+// OpsAccountLatencyBucketLabels 返回与 OpsAccountLatencyBucketBoundsMs 对应的区间标签
+func OpsAccountLatencyBucketLabels() []string {
+	labels := make([]string, 0, len(OpsAccountLatencyBucketBoundsMs)+1)
+	lower := 0
+	for _, upper := range OpsAccountLatencyBucketBoundsMs {
+		labels = append(labels, strconv.Itoa(lower)+"-"+strconv.Itoa(upper)+"ms")
+		lower = upper
+	}
+	return append(labels, strconv.Itoa(lower)+"ms+")
+}
+
+// OpsAccountLatencyFilter 按账号延迟直方图查询条件
+type OpsAccountLatencyFilter struct {
+	StartTime time.Time
+	EndTime   time.Time
+
+	Platform  string
+	GroupID   *int64
+	AccountID *int64
+	Model     string
+
+	// Limit 返回的账号/模型组合数上限（按请求数降序）
+	Limit int
+}
+
+// OpsLatencyDistribution 单个指标（总耗时或首字时间）的分布
+type OpsLatencyDistribution struct {
+	Count       int64                        `json:"count"`
+	Percentiles OpsPercentiles               `json:"percentiles"`
+	Buckets     []*OpsLatencyHistogramBucket `json:"buckets"`
+}
+
+// OpsAccountLatencyHistogram 单个账号 + 模型的延迟直方图（仅成功请求）
+type OpsAccountLatencyHistogram struct {
+	AccountID   int64  `json:"account_id"`
+	AccountName string `json:"account_name"`
+	Platform    string `json:"platform"`
+	Model       string `json:"model"`
+
+	Duration OpsLatencyDistribution `json:"duration"`
+	TTFT     OpsLatencyDistribution `json:"ttft"`
+}
+
+// OpsAccountLatencyHistogramResponse 按账号/模型的延迟直方图列表
+type OpsAccountLatencyHistogramResponse struct {
+	StartTime time.Time `json:"start_time"`
+	EndTime   time.Time `json:"end_time"`
+	Platform  string    `json:"platform"`
+	GroupID   *int64    `json:"group_id"`
+
+	Items []*OpsAccountLatencyHistogram `json:"items"`
+}
+
+// GetAccountLatencyHistograms 返回每个账号/模型的总耗时与首字时间直方图，用于比较订阅账号质量
+func (s *OpsService) GetAccountLatencyHistograms(ctx context.Context, filter *OpsAccountLatencyFilter) (*OpsAccountLatencyHistogramResponse, error) {
+	if err := s.RequireMonitoringEnabled(ctx); err != nil {
+		return nil, err
+	}
+	if s.opsRepo == nil {
+		return nil, infraerrors.ServiceUnavailable("OPS_REPO_UNAVAILABLE", "Ops repository not available")
+	}
+	if filter == nil {
+		return nil, infraerrors.BadRequest("OPS_FILTER_REQUIRED", "filter is required")
+	}
+	if filter.StartTime.IsZero() || filter.EndTime.IsZero() {
+		return nil, infraerrors.BadRequest("OPS_TIME_RANGE_REQUIRED", "start_time/end_time are required")
+	}
+	if filter.StartTime.After(filter.EndTime) {
+		return nil, infraerrors.BadRequest("OPS_TIME_RANGE_INVALID", "start_time must be <= end_time")
+	}
+	filter.Platform = strings.TrimSpace(filter.Platform)
+	filter.Model = strings.TrimSpace(filter.Model)
+	if filter.Limit <= 0 {
+		filter.Limit = opsAccountLatencyDefaultLimit
+	}
+	if filter.Limit > opsAccountLatencyMaxLimit {
+		filter.Limit = opsAccountLatencyMaxLimit
+	}
+	return s.opsRepo.GetAccountLatencyHistograms(ctx, filter)
+}
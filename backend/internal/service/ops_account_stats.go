@@ -0,0 +1,237 @@
+package service
+
+import (
+	"context"
+	"math"
+	"sync"
+	"time"
+)
+
+const (
+	// opsAccountStatsSlotSeconds 滚动统计的时间槽粒度（秒）
+	opsAccountStatsSlotSeconds = 10
+	// opsAccountStatsSlots 保留的时间槽数量（覆盖最长的 1h 窗口）
+	opsAccountStatsSlots = 3600 / opsAccountStatsSlotSeconds
+	// opsAccountStatsIdleTTL 账号超过该时间无流量时回收其统计（与最长窗口一致）
+	opsAccountStatsIdleTTL = time.Hour
+)
+
+// opsAccountStatsWindows 对外返回的滚动窗口（label 与 1m/5m/1h 趋势接口一致）
+var opsAccountStatsWindows = []struct {
+	label   string
+	seconds int
+}{
+	{"1m", 60},
+	{"5m", 300},
+	{"1h", 3600},
+}
+
+// OpsAccountRequestSample 单次网关请求对最终账号的统计贡献
+type OpsAccountRequestSample struct {
+	AccountID  int64
+	StatusCode int
+	Tokens     int64
+	// FailoverAccountIDs 本次请求中因失败被切换掉的账号（可重复，按次数计）
+	FailoverAccountIDs []int64
+}
+
+// opsAccountStatsSlot 单个 10s 时间槽的计数
+type opsAccountStatsSlot struct {
+	start     int64 // 槽起始 Unix 秒；与当前轮次不符时视为空槽
+	requests  int64
+	tokens    int64
+	errors4xx int64
+	errors5xx int64
+	failovers int64
+}
+
+type opsAccountStatsRing struct {
+	slots    [opsAccountStatsSlots]opsAccountStatsSlot
+	lastSeen int64
+}
+
+// slot 返回 ts 所在的时间槽（过期槽就地清零复用）
+func (r *opsAccountStatsRing) slot(ts int64) *opsAccountStatsSlot {
+	start := ts - ts%opsAccountStatsSlotSeconds
+	s := &r.slots[(start/opsAccountStatsSlotSeconds)%opsAccountStatsSlots]
+	if s.start != start {
+		*s = opsAccountStatsSlot{start: start}
+	}
+	if ts > r.lastSeen {
+		r.lastSeen = ts
+	}
+	return s
+}
+
+// OpsAccountWindowStats 单个滚动窗口内的账号吞吐与错误率
+type OpsAccountWindowStats struct {
+	Window string `json:"window"`
+	// CoveredSeconds 实际覆盖的秒数（进程启动不足一个窗口时小于窗口长度，速率按此计算）
+	CoveredSeconds int `json:"covered_seconds"`
+
+	RequestCount  int64   `json:"request_count"`
+	TokenCount    int64   `json:"token_count"`
+	Error4xxCount int64   `json:"error_4xx_count"`
+	Error5xxCount int64   `json:"error_5xx_count"`
+	FailoverCount int64   `json:"failover_count"`
+	RPS           float64 `json:"rps"`
+	TokensPerSec  float64 `json:"tokens_per_sec"`
+	Error4xxRate  float64 `json:"error_4xx_rate"`
+	Error5xxRate  float64 `json:"error_5xx_rate"`
+}
+
+// OpsAccountRollingStats 账号滚动窗口统计（本实例内存计数，不查询 usage_logs）
+type OpsAccountRollingStats struct {
+	AccountID   int64                    `json:"account_id"`
+	AccountName string                   `json:"account_name"`
+	Platform    string                   `json:"platform"`
+	GeneratedAt time.Time                `json:"generated_at"`
+	Windows     []*OpsAccountWindowStats `json:"windows"`
+}
+
+// OpsAccountStatsTracker 进程内按账号的滚动计数器（10s 槽 × 360，覆盖 1h）。
+// 只统计本实例处理的请求；多实例部署时各实例分别返回自己的视图。
+type OpsAccountStatsTracker struct {
+	mu        sync.Mutex
+	accounts  map[int64]*opsAccountStatsRing
+	startedAt time.Time
+	lastSweep int64
+
+	now func() time.Time
+}
+
+// NewOpsAccountStatsTracker 创建账号滚动计数器
+func NewOpsAccountStatsTracker() *OpsAccountStatsTracker {
+	return &OpsAccountStatsTracker{
+		accounts:  make(map[int64]*opsAccountStatsRing),
+		startedAt: time.Now(),
+		now:       time.Now,
+	}
+}
+
+// Record 计入一次请求：最终账号计请求/Token/状态码，被切换掉的账号各计一次 failover
+func (t *OpsAccountStatsTracker) Record(sample *OpsAccountRequestSample) {
+	if t == nil || sample == nil {
+		return
+	}
+	ts := t.now().Unix()
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	if sample.AccountID > 0 {
+		s := t.ringLocked(sample.AccountID).slot(ts)
+		s.requests++
+		if sample.Tokens > 0 {
+			s.tokens += sample.Tokens
+		}
+		switch {
+		case sample.StatusCode >= 500:
+			s.errors5xx++
+		case sample.StatusCode >= 400:
+			s.errors4xx++
+		}
+	}
+	for _, id := range sample.FailoverAccountIDs {
+		if id > 0 {
+			t.ringLocked(id).slot(ts).failovers++
+		}
+	}
+	t.sweepLocked(ts)
+}
+
+func (t *OpsAccountStatsTracker) ringLocked(accountID int64) *opsAccountStatsRing {
+	ring := t.accounts[accountID]
+	if ring == nil {
+		ring = &opsAccountStatsRing{}
+		t.accounts[accountID] = ring
+	}
+	return ring
+}
+
+// sweepLocked 每分钟最多一次，回收长时间无流量的账号（已删除账号不会永久占用内存）
+func (t *OpsAccountStatsTracker) sweepLocked(ts int64) {
+	if ts-t.lastSweep < 60 {
+		return
+	}
+	t.lastSweep = ts
+	idle := int64(opsAccountStatsIdleTTL / time.Second)
+	for id, ring := range t.accounts {
+		if ts-ring.lastSeen > idle {
+			delete(t.accounts, id)
+		}
+	}
+}
+
+// Snapshot 返回账号在 1m/5m/1h 窗口内的统计（无流量时各项为 0）
+func (t *OpsAccountStatsTracker) Snapshot(accountID int64) []*OpsAccountWindowStats {
+	out := make([]*OpsAccountWindowStats, 0, len(opsAccountStatsWindows))
+	if t == nil {
+		return out
+	}
+	now := t.now()
+	ts := now.Unix()
+	uptime := int(now.Sub(t.startedAt) / time.Second)
+
+	t.mu.Lock()
+	var slots [opsAccountStatsSlots]opsAccountStatsSlot
+	if ring := t.accounts[accountID]; ring != nil {
+		slots = ring.slots
+	}
+	t.mu.Unlock()
+
+	for _, w := range opsAccountStatsWindows {
+		stats := &OpsAccountWindowStats{Window: w.label, CoveredSeconds: max(min(w.seconds, uptime), 1)}
+		cutoff := ts - int64(w.seconds)
+		for i := range slots {
+			s := &slots[i]
+			if s.start <= cutoff || s.start > ts {
+				continue
+			}
+			stats.RequestCount += s.requests
+			stats.TokenCount += s.tokens
+			stats.Error4xxCount += s.errors4xx
+			stats.Error5xxCount += s.errors5xx
+			stats.FailoverCount += s.failovers
+		}
+		covered := float64(stats.CoveredSeconds)
+		stats.RPS = roundTo4DP(float64(stats.RequestCount) / covered)
+		stats.TokensPerSec = roundTo4DP(float64(stats.TokenCount) / covered)
+		if stats.RequestCount > 0 {
+			stats.Error4xxRate = roundTo4DP(float64(stats.Error4xxCount) / float64(stats.RequestCount))
+			stats.Error5xxRate = roundTo4DP(float64(stats.Error5xxCount) / float64(stats.RequestCount))
+		}
+		out = append(out, stats)
+	}
+	return out
+}
+
+// RecordAccountRequest 计入账号滚动统计（由网关 ops 中间件在请求结束时调用）
+func (s *OpsService) RecordAccountRequest(sample *OpsAccountRequestSample) {
+	if s == nil {
+		return
+	}
+	s.accountStats.Record(sample)
+}
+
+// GetAccountRollingStats 返回账号 1m/5m/1h 滚动窗口的 RPS、tokens/s、4xx/5xx 比例与 failover 次数
+func (s *OpsService) GetAccountRollingStats(ctx context.Context, accountID int64) (*OpsAccountRollingStats, error) {
+	if err := s.RequireMonitoringEnabled(ctx); err != nil {
+		return nil, err
+	}
+	out := &OpsAccountRollingStats{AccountID: accountID, GeneratedAt: time.Now().UTC()}
+	if s.accountRepo != nil {
+		account, err := s.accountRepo.GetByID(ctx, accountID)
+		if err != nil {
+			return nil, err
+		}
+		out.AccountName = account.Name
+		out.Platform = account.Platform
+	}
+	out.Windows = s.accountStats.Snapshot(accountID)
+	return out, nil
+}
+
+func roundTo4DP(v float64) float64 {
+	return math.Round(v*10000) / 10000
+}
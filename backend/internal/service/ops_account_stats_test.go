@@ -0,0 +1,74 @@
+package service
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestOpsAccountStatsTracker_RollingWindows(t *testing.T) {
+	base := time.Unix(1_700_000_000, 0)
+	now := base
+	tracker := NewOpsAccountStatsTracker()
+	tracker.startedAt = base.Add(-2 * time.Hour)
+	tracker.now = func() time.Time { return now }
+
+	// 50 分钟前：只会进入 1h 窗口
+	now = base.Add(-50 * time.Minute)
+	tracker.Record(&OpsAccountRequestSample{AccountID: 1, StatusCode: 500, Tokens: 600})
+	// 3 分钟前：进入 5m / 1h
+	now = base.Add(-3 * time.Minute)
+	tracker.Record(&OpsAccountRequestSample{AccountID: 1, StatusCode: 429, Tokens: 0, FailoverAccountIDs: []int64{2}})
+	// 最近 30 秒：全部窗口
+	now = base.Add(-30 * time.Second)
+	tracker.Record(&OpsAccountRequestSample{AccountID: 1, StatusCode: 200, Tokens: 120})
+	tracker.Record(&OpsAccountRequestSample{AccountID: 2, StatusCode: 200, FailoverAccountIDs: []int64{1, 1}})
+
+	now = base
+	windows := tracker.Snapshot(1)
+	require.Len(t, windows, 3)
+
+	m1, m5, h1 := windows[0], windows[1], windows[2]
+	require.Equal(t, "1m", m1.Window)
+	require.Equal(t, int64(1), m1.RequestCount)
+	require.Equal(t, int64(2), m1.FailoverCount)
+	require.InDelta(t, 2.0, m1.TokensPerSec, 0.0001)
+
+	require.Equal(t, "5m", m5.Window)
+	require.Equal(t, int64(2), m5.RequestCount)
+	require.Equal(t, int64(1), m5.Error4xxCount)
+	require.InDelta(t, 0.5, m5.Error4xxRate, 0.0001)
+	require.Zero(t, m5.Error5xxCount)
+
+	require.Equal(t, "1h", h1.Window)
+	require.Equal(t, int64(3), h1.RequestCount)
+	require.Equal(t, int64(720), h1.TokenCount)
+	require.Equal(t, int64(1), h1.Error5xxCount)
+	require.InDelta(t, 0.3333, h1.Error5xxRate, 0.0001)
+	require.InDelta(t, 3.0/3600, h1.RPS, 0.0001)
+
+	other := tracker.Snapshot(2)
+	require.Equal(t, int64(1), other[1].FailoverCount)
+	require.Equal(t, int64(1), other[1].RequestCount)
+}
+
+func TestOpsAccountStatsTracker_CoveredSecondsAndEviction(t *testing.T) {
+	base := time.Unix(1_700_000_000, 0)
+	now := base
+	tracker := NewOpsAccountStatsTracker()
+	tracker.startedAt = base.Add(-20 * time.Second)
+	tracker.now = func() time.Time { return now }
+
+	tracker.Record(&OpsAccountRequestSample{AccountID: 7, StatusCode: 200})
+	windows := tracker.Snapshot(7)
+	require.Equal(t, 20, windows[2].CoveredSeconds)
+	require.InDelta(t, 0.05, windows[2].RPS, 0.0001)
+
+	// 超过 1h 无流量的账号在下一次写入时被回收
+	now = base.Add(2 * time.Hour)
+	tracker.Record(&OpsAccountRequestSample{AccountID: 8, StatusCode: 200})
+	_, ok := tracker.accounts[7]
+	require.False(t, ok)
+	require.Zero(t, tracker.Snapshot(7)[2].RequestCount)
+}
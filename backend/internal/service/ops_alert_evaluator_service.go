@@ -280,6 +280,12 @@ func (s *OpsAlertEvaluatorService) evaluateOnce(interval time.Duration) {
 				FiredAt:        now,
 				CreatedAt:      now,
 			}
+			if sloID := parseOpsAlertRuleIDFilter(rule.Filters, "slo_id"); sloID > 0 {
+				if firedEvent.Dimensions == nil {
+					firedEvent.Dimensions = map[string]any{}
+				}
+				firedEvent.Dimensions["slo_id"] = sloID
+			}
 
 			created, err := s.opsRepo.CreateAlertEvent(ctx, firedEvent)
 			if err != nil {
@@ -506,6 +512,44 @@ func (s *OpsAlertEvaluatorService) computeRuleMetric(
 		return float64(countAccountsByCondition(availability.Accounts, func(acc *AccountAvailability) bool {
 			return acc.HasError && acc.TempUnschedulableUntil == nil
 		})), true
+	case "slo_burn_rate", "slo_error_budget_remaining":
+		return s.computeSLORuleMetric(ctx, rule, start, end)
+	case "slow_request_count":
+		if s == nil || s.opsRepo == nil {
+			return 0, false
+		}
+		filter := &OpsSlowRequestFilter{
+			StartTime: &start,
+			EndTime:   &end,
+			Platform:  platform,
+			GroupID:   groupID,
+		}
+		if accountID := parseOpsAlertRuleIDFilter(rule.Filters, "account_id"); accountID > 0 {
+			filter.AccountID = &accountID
+		}
+		count, err := s.opsRepo.CountSlowRequests(ctx, filter)
+		if err != nil {
+			return 0, false
+		}
+		return float64(count), true
+	case "stream_abuse_flagged_keys":
+		if s == nil || s.opsService == nil || !s.opsService.streamAbuseService.Enabled() {
+			return 0, false
+		}
+		count, err := s.opsService.streamAbuseService.CountFlaggedKeys(ctx)
+		if err != nil {
+			return 0, false
+		}
+		return float64(count), true
+	case "usage_anomaly_flagged_keys":
+		if s == nil || s.opsService == nil || !s.opsService.usageAnomalyService.Enabled() {
+			return 0, false
+		}
+		count, err := s.opsService.usageAnomalyService.CountFlaggedKeys(ctx)
+		if err != nil {
+			return 0, false
+		}
+		return float64(count), true
 	}
 
 	overview, err := s.opsRepo.GetDashboardOverview(ctx, &OpsDashboardFilter{
@@ -543,6 +587,62 @@ func (s *OpsAlertEvaluatorService) computeRuleMetric(
 	}
 }
 
+// computeSLORuleMetric SLO 告警指标（filters.slo_id 指定 SLO）：
+// slo_burn_rate 为规则窗口内的错误预算燃烧速率，slo_error_budget_remaining 为整个 SLO 窗口的剩余预算百分比。
+func (s *OpsAlertEvaluatorService) computeSLORuleMetric(ctx context.Context, rule *OpsAlertRule, start, end time.Time) (float64, bool) {
+	if s == nil || s.opsService == nil {
+		return 0, false
+	}
+	sloID := parseOpsAlertRuleIDFilter(rule.Filters, "slo_id")
+	if sloID <= 0 {
+		return 0, false
+	}
+	def, err := s.opsService.GetSLO(ctx, sloID)
+	if err != nil || def == nil || !def.Enabled {
+		return 0, false
+	}
+
+	if strings.TrimSpace(rule.MetricType) == "slo_burn_rate" {
+		burnRate, err := s.opsService.computeSLOBurnRate(ctx, def, start, end)
+		if err != nil || burnRate == nil {
+			return 0, false
+		}
+		return *burnRate, true
+	}
+
+	windowStart := end.Add(-time.Duration(def.WindowDays) * 24 * time.Hour)
+	counts, err := s.opsService.querySLOCounts(ctx, def, windowStart, end)
+	if err != nil {
+		return 0, false
+	}
+	status := buildOpsSLOStatus(def, counts, windowStart, end)
+	if status.ErrorBudgetRemainingPercent == nil {
+		return 0, false
+	}
+	return *status.ErrorBudgetRemainingPercent, true
+}
+
+// parseOpsAlertRuleIDFilter 读取规则 filters 中的数值 ID（如 slo_id / account_id），缺失或非法时返回 0
+func parseOpsAlertRuleIDFilter(filters map[string]any, key string) int64 {
+	if filters == nil {
+		return 0
+	}
+	switch t := filters[key].(type) {
+	case float64:
+		return int64(t)
+	case int64:
+		return t
+	case int:
+		return int64(t)
+	case string:
+		n, err := strconv.ParseInt(strings.TrimSpace(t), 10, 64)
+		if err == nil {
+			return n
+		}
+	}
+	return 0
+}
+
 func compareMetric(value float64, operator string, threshold float64) bool {
 	switch strings.TrimSpace(operator) {
 	case ">":
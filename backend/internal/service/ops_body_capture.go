@@ -0,0 +1,119 @@
+package service
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"sort"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/Wei-Shaw/sub2api/internal/config"
+)
+
+// opsInlineMediaMarkers 请求体中内联媒体（base64 图片/文件）的特征片段
+var opsInlineMediaMarkers = [][]byte{
+	[]byte(`data:image/`),
+	[]byte(`"base64"`),
+	[]byte(`"inlineData"`),
+	[]byte(`"inline_data"`),
+	[]byte(`"input_image"`),
+}
+
+// OpsBodyCapture 决定网关为运维错误日志在请求上下文中保留的请求体：
+// 未超过上限时原样保留；超过上限时仅保留头部并附带截断标记；含内联媒体的大请求只保留哈希。
+// 截断/哈希后的请求体仍是合法 JSON，原始大小记录在 request_body_bytes 中。
+type OpsBodyCapture struct {
+	maxBytes           int
+	hashOnlyMultimodal int
+	// routes 按前缀长度降序排列，最长前缀优先
+	routes []config.OpsBodyCaptureRouteConfig
+}
+
+// NewOpsBodyCapture 根据配置创建请求体捕获策略；未配置时返回 nil（nil 策略原样保留请求体）
+func NewOpsBodyCapture(cfg *config.OpsBodyCaptureConfig) *OpsBodyCapture {
+	if cfg == nil {
+		return nil
+	}
+	routes := append([]config.OpsBodyCaptureRouteConfig(nil), cfg.Routes...)
+	sort.SliceStable(routes, func(i, j int) bool {
+		return len(routes[i].PathPrefix) > len(routes[j].PathPrefix)
+	})
+	return &OpsBodyCapture{
+		maxBytes:           cfg.MaxBytes,
+		hashOnlyMultimodal: cfg.HashOnlyMultimodalBytes,
+		routes:             routes,
+	}
+}
+
+// BodyCapture 返回错误日志请求体捕获策略
+func (s *OpsService) BodyCapture() *OpsBodyCapture {
+	if s == nil {
+		return nil
+	}
+	return s.bodyCapture
+}
+
+// Capture 返回 path 路由下应保留的请求体
+func (p *OpsBodyCapture) Capture(path string, body []byte) []byte {
+	if p == nil || len(body) == 0 {
+		return body
+	}
+	limit, hashOnly := p.maxBytes, false
+	for _, route := range p.routes {
+		if strings.HasPrefix(path, route.PathPrefix) {
+			limit, hashOnly = route.MaxBytes, route.HashOnly
+			break
+		}
+	}
+
+	if p.hashOnlyMultimodal > 0 && len(body) > p.hashOnlyMultimodal && hasInlineMedia(body) {
+		return opsBodyHashOnly(body)
+	}
+	if limit <= 0 || len(body) <= limit {
+		return body
+	}
+	if hashOnly {
+		return opsBodyHashOnly(body)
+	}
+	return opsBodyHead(body, limit)
+}
+
+func hasInlineMedia(body []byte) bool {
+	for _, marker := range opsInlineMediaMarkers {
+		if bytes.Contains(body, marker) {
+			return true
+		}
+	}
+	return false
+}
+
+// opsBodyHashOnly 只保留请求体的 SHA-256 与原始大小
+func opsBodyHashOnly(body []byte) []byte {
+	sum := sha256.Sum256(body)
+	return opsBodyMarker(body, map[string]any{
+		"request_body_sha256": hex.EncodeToString(sum[:]),
+	})
+}
+
+// opsBodyHead 保留请求体前 limit 字节（按 UTF-8 边界截断）作为文本摘录
+func opsBodyHead(body []byte, limit int) []byte {
+	head := body[:limit]
+	for i := 0; i < utf8.UTFMax && len(head) > 0 && !utf8.Valid(head); i++ {
+		head = head[:len(head)-1]
+	}
+	return opsBodyMarker(body, map[string]any{
+		"request_body_head": string(head),
+	})
+}
+
+func opsBodyMarker(body []byte, fields map[string]any) []byte {
+	fields["request_body_truncated"] = true
+	fields["request_body_bytes"] = len(body)
+	encoded, err := json.Marshal(fields)
+	if err != nil {
+		return nil
+	}
+	return encoded
+}
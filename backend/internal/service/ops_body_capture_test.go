@@ -0,0 +1,75 @@
+//go:build unit
+
+package service
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"github.com/Wei-Shaw/sub2api/internal/config"
+	"github.com/stretchr/testify/require"
+)
+
+func decodeCapturedBody(t *testing.T, captured []byte) map[string]any {
+	t.Helper()
+	var out map[string]any
+	require.NoError(t, json.Unmarshal(captured, &out))
+	return out
+}
+
+func TestOpsBodyCapture_WithinLimit(t *testing.T) {
+	capture := NewOpsBodyCapture(&config.OpsBodyCaptureConfig{MaxBytes: 1024})
+	body := []byte(`{"model":"claude","messages":[]}`)
+	require.Equal(t, string(body), string(capture.Capture("/v1/messages", body)))
+
+	// nil 策略与 0 上限均不截断
+	var nilCapture *OpsBodyCapture
+	require.Equal(t, string(body), string(nilCapture.Capture("/v1/messages", body)))
+	unlimited := NewOpsBodyCapture(&config.OpsBodyCaptureConfig{})
+	large := []byte(`{"prompt":"` + strings.Repeat("a", 4096) + `"}`)
+	require.Equal(t, string(large), string(unlimited.Capture("/v1/messages", large)))
+}
+
+func TestOpsBodyCapture_HeadTruncation(t *testing.T) {
+	capture := NewOpsBodyCapture(&config.OpsBodyCaptureConfig{MaxBytes: 16})
+	body := []byte(`{"prompt":"你好世界你好世界"}`)
+
+	out := decodeCapturedBody(t, capture.Capture("/v1/messages", body))
+	require.Equal(t, true, out["request_body_truncated"])
+	require.Equal(t, float64(len(body)), out["request_body_bytes"])
+	head, _ := out["request_body_head"].(string)
+	require.True(t, strings.HasPrefix(string(body), head))
+	require.LessOrEqual(t, len(head), 16)
+}
+
+func TestOpsBodyCapture_RouteOverrides(t *testing.T) {
+	capture := NewOpsBodyCapture(&config.OpsBodyCaptureConfig{
+		MaxBytes: 1024,
+		Routes: []config.OpsBodyCaptureRouteConfig{
+			{PathPrefix: "/v1/messages", MaxBytes: 0},
+			{PathPrefix: "/v1/messages/count_tokens", MaxBytes: 8, HashOnly: true},
+		},
+	})
+	body := []byte(`{"model":"claude","messages":[]}`)
+
+	// 最长前缀优先：count_tokens 超限只保留哈希
+	out := decodeCapturedBody(t, capture.Capture("/v1/messages/count_tokens", body))
+	require.Len(t, out["request_body_sha256"], 64)
+	require.NotContains(t, out, "request_body_head")
+
+	// /v1/messages 路由不限制
+	require.Equal(t, string(body), string(capture.Capture("/v1/messages", body)))
+}
+
+func TestOpsBodyCapture_HashOnlyMultimodal(t *testing.T) {
+	capture := NewOpsBodyCapture(&config.OpsBodyCaptureConfig{MaxBytes: 1 << 20, HashOnlyMultimodalBytes: 64})
+	image := []byte(`{"messages":[{"role":"user","content":[{"type":"image","source":{"type":"base64","data":"` + strings.Repeat("A", 128) + `"}}]}]}`)
+	out := decodeCapturedBody(t, capture.Capture("/v1/messages", image))
+	require.Equal(t, true, out["request_body_truncated"])
+	require.Len(t, out["request_body_sha256"], 64)
+
+	// 不含内联媒体的同等大小请求体原样保留
+	text := []byte(`{"messages":[{"role":"user","content":"` + strings.Repeat("A", 128) + `"}]}`)
+	require.Equal(t, string(text), string(capture.Capture("/v1/messages", text)))
+}
@@ -157,18 +157,22 @@ type opsCleanupDeletedCounts struct {
 	errorLogs     int64
 	retryAttempts int64
 	alertEvents   int64
+	slowRequests  int64
 	systemMetrics int64
+	groupPhases   int64
 	hourlyPreagg  int64
 	dailyPreagg   int64
 }
 
 func (c opsCleanupDeletedCounts) String() string {
 	return fmt.Sprintf(
-		"error_logs=%d retry_attempts=%d alert_events=%d system_metrics=%d hourly_preagg=%d daily_preagg=%d",
+		"error_logs=%d retry_attempts=%d alert_events=%d slow_requests=%d system_metrics=%d group_phases=%d hourly_preagg=%d daily_preagg=%d",
 		c.errorLogs,
 		c.retryAttempts,
 		c.alertEvents,
+		c.slowRequests,
 		c.systemMetrics,
+		c.groupPhases,
 		c.hourlyPreagg,
 		c.dailyPreagg,
 	)
@@ -184,7 +188,7 @@ func (s *OpsCleanupService) runCleanupOnce(ctx context.Context) (opsCleanupDelet
 
 	now := time.Now().UTC()
 
-	// Error-like tables: error logs / retry attempts / alert events.
+	// Error-like tables: error logs / retry attempts / alert events / slow requests.
 	if days := s.cfg.Ops.Cleanup.ErrorLogRetentionDays; days > 0 {
 		cutoff := now.AddDate(0, 0, -days)
 		n, err := deleteOldRowsByID(ctx, s.db, "ops_error_logs", "created_at", cutoff, batchSize, false)
@@ -204,9 +208,15 @@ func (s *OpsCleanupService) runCleanupOnce(ctx context.Context) (opsCleanupDelet
 			return out, err
 		}
 		out.alertEvents = n
+
+		n, err = deleteOldRowsByID(ctx, s.db, "ops_slow_requests", "created_at", cutoff, batchSize, false)
+		if err != nil {
+			return out, err
+		}
+		out.slowRequests = n
 	}
 
-	// Minute-level metrics snapshots.
+	// Minute-level metrics snapshots / per-group phase timings.
 	if days := s.cfg.Ops.Cleanup.MinuteMetricsRetentionDays; days > 0 {
 		cutoff := now.AddDate(0, 0, -days)
 		n, err := deleteOldRowsByID(ctx, s.db, "ops_system_metrics", "created_at", cutoff, batchSize, false)
@@ -214,6 +224,12 @@ func (s *OpsCleanupService) runCleanupOnce(ctx context.Context) (opsCleanupDelet
 			return out, err
 		}
 		out.systemMetrics = n
+
+		n, err = deleteOldRowsByID(ctx, s.db, "ops_group_phase_metrics", "bucket_start", cutoff, batchSize, false)
+		if err != nil {
+			return out, err
+		}
+		out.groupPhases = n
 	}
 
 	// Pre-aggregation tables (hourly/daily).
@@ -24,7 +24,7 @@ func (s *OpsService) listAllAccountsForOps(ctx context.Context, platformFilter s
 		accounts, pageInfo, err := s.accountRepo.ListWithFilters(ctx, pagination.PaginationParams{
 			Page:     page,
 			PageSize: opsAccountsPageSize,
-		}, platformFilter, "", "", "", 0)
+		}, platformFilter, "", "", "", 0, "")
 		if err != nil {
 			return nil, err
 		}
@@ -394,3 +394,74 @@ func (s *OpsService) GetUserConcurrencyStats(ctx context.Context) (map[int64]*Us
 
 	return result, &collectedAt, nil
 }
+
+// GetAccountWorkerPoolStats returns per-account upstream worker pool saturation
+// (only populated when gateway.account_worker_pool is enabled).
+func (s *OpsService) GetAccountWorkerPoolStats(ctx context.Context) (bool, []AccountWorkerPoolStats, error) {
+	if err := s.RequireMonitoringEnabled(ctx); err != nil {
+		return false, nil, err
+	}
+	enabled := s.cfg != nil && s.cfg.Gateway.AccountWorkerPool.Enabled
+	if !enabled || s.gatewayService == nil {
+		return enabled, []AccountWorkerPoolStats{}, nil
+	}
+	provider, ok := s.gatewayService.httpUpstream.(AccountWorkerPoolStatsProvider)
+	if !ok {
+		return enabled, []AccountWorkerPoolStats{}, nil
+	}
+	return enabled, provider.AccountWorkerPoolStats(), nil
+}
+
+// GetConcurrencyReconcileResult returns this instance's startup reconciliation result
+// (Enabled=false when concurrency.reconcile_on_startup is disabled; Result is nil until it finishes).
+func (s *OpsService) GetConcurrencyReconcileResult(ctx context.Context) (bool, *ConcurrencyReconcileResult, error) {
+	if err := s.RequireMonitoringEnabled(ctx); err != nil {
+		return false, nil, err
+	}
+	enabled := s.cfg != nil && s.cfg.Concurrency.ReconcileOnStartup
+	if s.concurrencyService == nil {
+		return enabled, nil, nil
+	}
+	return enabled, s.concurrencyService.LastReconcileResult(), nil
+}
+
+// GetMemoryGuardStats returns gateway memory guard usage and rejection counters
+// (Enabled=false when gateway.memory_guard is disabled).
+func (s *OpsService) GetMemoryGuardStats(ctx context.Context) (MemoryGuardStats, error) {
+	if err := s.RequireMonitoringEnabled(ctx); err != nil {
+		return MemoryGuardStats{}, err
+	}
+	return s.MemoryGuard().Stats(), nil
+}
+
+// GetStreamAbuseFlags returns API keys currently flagged by streaming abuse detection
+// (only populated when gateway.stream_abuse is enabled).
+func (s *OpsService) GetStreamAbuseFlags(ctx context.Context) (bool, []StreamAbuseFlag, error) {
+	if err := s.RequireMonitoringEnabled(ctx); err != nil {
+		return false, nil, err
+	}
+	if !s.streamAbuseService.Enabled() {
+		return false, []StreamAbuseFlag{}, nil
+	}
+	flags, err := s.streamAbuseService.ListFlags(ctx)
+	if err != nil {
+		return true, nil, err
+	}
+	return true, flags, nil
+}
+
+// GetUsageAnomalyFlags returns API keys currently flagged by usage anomaly detection
+// (only populated when gateway.usage_anomaly is enabled).
+func (s *OpsService) GetUsageAnomalyFlags(ctx context.Context) (bool, []UsageAnomalyFlag, error) {
+	if err := s.RequireMonitoringEnabled(ctx); err != nil {
+		return false, nil, err
+	}
+	if !s.usageAnomalyService.Enabled() {
+		return false, []UsageAnomalyFlag{}, nil
+	}
+	flags, err := s.usageAnomalyService.ListFlags(ctx)
+	if err != nil {
+		return true, nil, err
+	}
+	return true, flags, nil
+}
@@ -0,0 +1,375 @@
+package service
+
+import (
+	"context"
+	"log"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/Wei-Shaw/sub2api/internal/config"
+)
+
+// opsEventErrorMessageMaxLen 事件中错误信息的最大长度（完整内容仍保存在 ops_error_logs）
+const opsEventErrorMessageMaxLen = 1024
+
+// opsEventDropLogInterval 丢弃事件时的日志输出间隔，避免队列满时刷屏
+const opsEventDropLogInterval = time.Minute
+
+// OpsRequestEvent 请求级运维事件：每个请求一行，成功请求来自用量日志，失败请求来自错误日志。
+// JSON 字段名即分析库中的列名。
+type OpsRequestEvent struct {
+	CreatedAt       time.Time      `json:"created_at"`
+	Kind            OpsRequestKind `json:"kind"`
+	RequestID       string         `json:"request_id"`
+	ClientRequestID string         `json:"client_request_id"`
+
+	Platform    string `json:"platform"`
+	Model       string `json:"model"`
+	RequestPath string `json:"request_path"`
+	Stream      bool   `json:"stream"`
+
+	UserID    *int64 `json:"user_id"`
+	APIKeyID  *int64 `json:"api_key_id"`
+	AccountID *int64 `json:"account_id"`
+	GroupID   *int64 `json:"group_id"`
+
+	StatusCode         int    `json:"status_code"`
+	UpstreamStatusCode *int   `json:"upstream_status_code"`
+	ErrorPhase         string `json:"error_phase"`
+	ErrorType          string `json:"error_type"`
+	Severity           string `json:"severity"`
+	ErrorMessage       string `json:"error_message"`
+	// UpstreamErrors 已脱敏的上游尝试记录（JSON 字符串）
+	UpstreamErrors string `json:"upstream_errors"`
+	RetryCount     int    `json:"retry_count"`
+
+	DurationMs   *int   `json:"duration_ms"`
+	FirstTokenMs *int64 `json:"first_token_ms"`
+
+	InputTokens         int     `json:"input_tokens"`
+	OutputTokens        int     `json:"output_tokens"`
+	CacheCreationTokens int     `json:"cache_creation_tokens"`
+	CacheReadTokens     int     `json:"cache_read_tokens"`
+	ReasoningEffort     string  `json:"reasoning_effort"`
+	TotalCost           float64 `json:"total_cost"`
+	ActualCost          float64 `json:"actual_cost"`
+}
+
+// OpsRequestEventFromUsageLog 由成功请求的用量日志构建事件
+func OpsRequestEventFromUsageLog(usageLog *UsageLog) OpsRequestEvent {
+	userID, apiKeyID, accountID := usageLog.UserID, usageLog.APIKeyID, usageLog.AccountID
+	event := OpsRequestEvent{
+		CreatedAt:           usageLog.CreatedAt,
+		Kind:                OpsRequestKindSuccess,
+		RequestID:           usageLog.RequestID,
+		Model:               usageLog.Model,
+		Stream:              usageLog.Stream,
+		UserID:              &userID,
+		APIKeyID:            &apiKeyID,
+		AccountID:           &accountID,
+		GroupID:             usageLog.GroupID,
+		StatusCode:          200,
+		DurationMs:          usageLog.DurationMs,
+		InputTokens:         usageLog.InputTokens,
+		OutputTokens:        usageLog.OutputTokens,
+		CacheCreationTokens: usageLog.CacheCreationTokens,
+		CacheReadTokens:     usageLog.CacheReadTokens,
+		TotalCost:           usageLog.TotalCost,
+		ActualCost:          usageLog.ActualCost,
+	}
+	if usageLog.FirstTokenMs != nil {
+		firstTokenMs := int64(*usageLog.FirstTokenMs)
+		event.FirstTokenMs = &firstTokenMs
+	}
+	if usageLog.ReasoningEffort != nil {
+		event.ReasoningEffort = *usageLog.ReasoningEffort
+	}
+	if usageLog.Account != nil {
+		event.Platform = usageLog.Account.Platform
+	}
+	return event
+}
+
+// OpsRequestEventFromErrorLog 由错误日志构建事件（在 OpsService.RecordError 脱敏之后调用）
+func OpsRequestEventFromErrorLog(input *OpsInsertErrorLogInput) OpsRequestEvent {
+	event := OpsRequestEvent{
+		CreatedAt:          input.CreatedAt,
+		Kind:               OpsRequestKindError,
+		RequestID:          input.RequestID,
+		ClientRequestID:    input.ClientRequestID,
+		Platform:           input.Platform,
+		Model:              input.Model,
+		RequestPath:        input.RequestPath,
+		Stream:             input.Stream,
+		UserID:             input.UserID,
+		APIKeyID:           input.APIKeyID,
+		AccountID:          input.AccountID,
+		GroupID:            input.GroupID,
+		StatusCode:         input.StatusCode,
+		UpstreamStatusCode: input.UpstreamStatusCode,
+		ErrorPhase:         input.ErrorPhase,
+		ErrorType:          input.ErrorType,
+		Severity:           input.Severity,
+		ErrorMessage:       truncateString(input.ErrorMessage, opsEventErrorMessageMaxLen),
+		RetryCount:         input.RetryCount,
+		FirstTokenMs:       input.TimeToFirstTokenMs,
+	}
+	if input.UpstreamErrorsJSON != nil {
+		event.UpstreamErrors = *input.UpstreamErrorsJSON
+	}
+	if event.CreatedAt.IsZero() {
+		event.CreatedAt = time.Now()
+	}
+	return event
+}
+
+// OpsEventWriter 请求级运维事件的外部存储（ClickHouse / Loki / Elasticsearch）
+type OpsEventWriter interface {
+	// EnsureOpsEventSchema 创建库表（幂等）
+	EnsureOpsEventSchema(ctx context.Context) error
+	// WriteOpsEvents 批量写入事件
+	WriteOpsEvents(ctx context.Context, events []OpsRequestEvent) error
+}
+
+// OpsLogShipper 一个已启用的日志投递目标
+type OpsLogShipper struct {
+	Name   string
+	Writer OpsEventWriter
+}
+
+// OpsLogShippers 已启用的日志投递目标（Loki / Elasticsearch）
+type OpsLogShippers []OpsLogShipper
+
+// OpsEventExporter 将请求级运维事件异步批量写入外部分析存储。
+// 请求路径只做非阻塞入队，队列满时丢弃事件；写入失败按配置重试后仅记录日志（主数据仍在 PostgreSQL 中）。
+type OpsEventExporter struct {
+	name          string
+	writer        OpsEventWriter
+	queue         chan OpsRequestEvent
+	batchSize     int
+	flushInterval time.Duration
+	timeout       time.Duration
+	// maxRetries/retryBackoff 写入失败后的重试次数与首次退避（重试期间队列继续积压，满后丢弃）
+	maxRetries   int
+	retryBackoff time.Duration
+
+	schemaReady bool
+	dropped     atomic.Int64
+	lastDropLog atomic.Int64
+
+	stopCh   chan struct{}
+	stopOnce sync.Once
+	wg       sync.WaitGroup
+}
+
+// NewOpsEventExporter 创建导出器；未启用或 writer 为空时返回 nil（nil 导出器的方法均为空操作）
+func NewOpsEventExporter(writer OpsEventWriter, cfg *config.Config) *OpsEventExporter {
+	if writer == nil || cfg == nil || !cfg.Ops.ClickHouse.Enabled {
+		return nil
+	}
+	chCfg := cfg.Ops.ClickHouse
+	return &OpsEventExporter{
+		name:          "clickhouse",
+		writer:        writer,
+		queue:         make(chan OpsRequestEvent, chCfg.QueueSize),
+		batchSize:     chCfg.BatchSize,
+		flushInterval: chCfg.FlushInterval,
+		timeout:       chCfg.Timeout,
+		stopCh:        make(chan struct{}),
+	}
+}
+
+// NewOpsLogShippingExporter 创建日志投递导出器（Loki / Elasticsearch），使用 ops.log_shipping 的批量与重试参数；
+// writer 为空时返回 nil
+func NewOpsLogShippingExporter(name string, writer OpsEventWriter, cfg config.OpsLogShippingConfig) *OpsEventExporter {
+	if writer == nil {
+		return nil
+	}
+	return &OpsEventExporter{
+		name:          name,
+		writer:        writer,
+		queue:         make(chan OpsRequestEvent, cfg.QueueSize),
+		batchSize:     cfg.BatchSize,
+		flushInterval: cfg.FlushInterval,
+		timeout:       cfg.Timeout,
+		maxRetries:    cfg.MaxRetries,
+		retryBackoff:  cfg.RetryBackoff,
+		stopCh:        make(chan struct{}),
+	}
+}
+
+// Enqueue 非阻塞地提交事件，队列已满时丢弃
+func (e *OpsEventExporter) Enqueue(event OpsRequestEvent) {
+	if e == nil {
+		return
+	}
+	select {
+	case e.queue <- event:
+	default:
+		dropped := e.dropped.Add(1)
+		now := time.Now().UnixNano()
+		last := e.lastDropLog.Load()
+		if now-last >= int64(opsEventDropLogInterval) && e.lastDropLog.CompareAndSwap(last, now) {
+			log.Printf("[OpsEventExporter] %s queue full, dropped %d events so far", e.name, dropped)
+		}
+	}
+}
+
+// Start 启动后台批量写入
+func (e *OpsEventExporter) Start() {
+	if e == nil {
+		return
+	}
+	e.wg.Add(1)
+	go func() {
+		defer e.wg.Done()
+		e.run()
+	}()
+}
+
+// Stop 停止后台写入，并写出队列中剩余的事件
+func (e *OpsEventExporter) Stop() {
+	if e == nil {
+		return
+	}
+	e.stopOnce.Do(func() {
+		close(e.stopCh)
+	})
+	e.wg.Wait()
+}
+
+func (e *OpsEventExporter) run() {
+	ticker := time.NewTicker(e.flushInterval)
+	defer ticker.Stop()
+
+	batch := make([]OpsRequestEvent, 0, e.batchSize)
+	for {
+		select {
+		case event := <-e.queue:
+			batch = append(batch, event)
+			if len(batch) >= e.batchSize {
+				batch = e.flush(batch)
+			}
+		case <-ticker.C:
+			batch = e.flush(batch)
+		case <-e.stopCh:
+			for {
+				select {
+				case event := <-e.queue:
+					batch = append(batch, event)
+					if len(batch) >= e.batchSize {
+						batch = e.flush(batch)
+					}
+				default:
+					e.flush(batch)
+					return
+				}
+			}
+		}
+	}
+}
+
+// flush 写出一批事件并返回可复用的空切片；库表尚未创建成功时先建表，失败则丢弃本批。
+// 配置了重试时按指数退避重试写入，停止过程中不再等待重试。
+func (e *OpsEventExporter) flush(batch []OpsRequestEvent) []OpsRequestEvent {
+	if len(batch) == 0 {
+		return batch
+	}
+	if !e.schemaReady {
+		ctx, cancel := context.WithTimeout(context.Background(), e.timeout)
+		err := e.writer.EnsureOpsEventSchema(ctx)
+		cancel()
+		if err != nil {
+			log.Printf("[OpsEventExporter] %s ensure schema failed, dropped %d events: %v", e.name, len(batch), err)
+			return batch[:0]
+		}
+		e.schemaReady = true
+	}
+
+	backoff := e.retryBackoff
+	for attempt := 0; ; attempt++ {
+		ctx, cancel := context.WithTimeout(context.Background(), e.timeout)
+		err := e.writer.WriteOpsEvents(ctx, batch)
+		cancel()
+		if err == nil {
+			return batch[:0]
+		}
+		if attempt >= e.maxRetries || !e.waitRetry(backoff) {
+			log.Printf("[OpsEventExporter] %s write %d events failed after %d attempts: %v", e.name, len(batch), attempt+1, err)
+			return batch[:0]
+		}
+		backoff *= 2
+	}
+}
+
+// waitRetry 等待重试退避；导出器停止时返回 false
+func (e *OpsEventExporter) waitRetry(backoff time.Duration) bool {
+	if backoff <= 0 {
+		return true
+	}
+	timer := time.NewTimer(backoff)
+	defer timer.Stop()
+	select {
+	case <-timer.C:
+		return true
+	case <-e.stopCh:
+		return false
+	}
+}
+
+// OpsEventExporterGroup 多个事件导出目标（ClickHouse / Loki / Elasticsearch）。
+// 每个目标有独立的队列与写入协程，某个目标变慢或不可用时只丢弃它自己的事件。
+type OpsEventExporterGroup struct {
+	exporters []*OpsEventExporter
+}
+
+// NewOpsEventExporterGroup 组合导出器（忽略 nil）；没有可用导出器时返回 nil（nil 组的方法均为空操作）
+func NewOpsEventExporterGroup(exporters ...*OpsEventExporter) *OpsEventExporterGroup {
+	g := &OpsEventExporterGroup{}
+	for _, e := range exporters {
+		if e != nil {
+			g.exporters = append(g.exporters, e)
+		}
+	}
+	if len(g.exporters) == 0 {
+		return nil
+	}
+	return g
+}
+
+// Enqueue 向所有导出目标非阻塞地提交事件
+func (g *OpsEventExporterGroup) Enqueue(event OpsRequestEvent) {
+	if g == nil {
+		return
+	}
+	for _, e := range g.exporters {
+		e.Enqueue(event)
+	}
+}
+
+// Start 启动所有导出目标
+func (g *OpsEventExporterGroup) Start() {
+	if g == nil {
+		return
+	}
+	for _, e := range g.exporters {
+		e.Start()
+	}
+}
+
+// Stop 并行停止所有导出目标，各自写出剩余事件
+func (g *OpsEventExporterGroup) Stop() {
+	if g == nil {
+		return
+	}
+	var wg sync.WaitGroup
+	for _, e := range g.exporters {
+		wg.Add(1)
+		go func(e *OpsEventExporter) {
+			defer wg.Done()
+			e.Stop()
+		}(e)
+	}
+	wg.Wait()
+}
@@ -0,0 +1,192 @@
+//go:build unit
+
+package service
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/Wei-Shaw/sub2api/internal/config"
+	"github.com/stretchr/testify/require"
+)
+
+type opsEventWriterStub struct {
+	mu          sync.Mutex
+	schemaCalls int
+	schemaErr   error
+	batches     [][]OpsRequestEvent
+}
+
+func (s *opsEventWriterStub) EnsureOpsEventSchema(ctx context.Context) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.schemaCalls++
+	return s.schemaErr
+}
+
+func (s *opsEventWriterStub) WriteOpsEvents(ctx context.Context, events []OpsRequestEvent) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.batches = append(s.batches, append([]OpsRequestEvent(nil), events...))
+	return nil
+}
+
+func (s *opsEventWriterStub) snapshot() (int, [][]OpsRequestEvent) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.schemaCalls, s.batches
+}
+
+func newOpsEventExporterTestConfig(batchSize, queueSize int) *config.Config {
+	cfg := &config.Config{}
+	cfg.Ops.ClickHouse = config.OpsClickHouseConfig{
+		Enabled:       true,
+		BatchSize:     batchSize,
+		FlushInterval: time.Hour,
+		QueueSize:     queueSize,
+		Timeout:       time.Second,
+	}
+	return cfg
+}
+
+func TestNewOpsEventExporter_DisabledReturnsNil(t *testing.T) {
+	exporter := NewOpsEventExporter(&opsEventWriterStub{}, &config.Config{})
+	require.Nil(t, exporter)
+
+	// nil 导出器的方法均为空操作
+	exporter.Start()
+	exporter.Enqueue(OpsRequestEvent{RequestID: "r1"})
+	exporter.Stop()
+}
+
+func TestOpsEventExporter_BatchesAndFlushesOnStop(t *testing.T) {
+	writer := &opsEventWriterStub{}
+	exporter := NewOpsEventExporter(writer, newOpsEventExporterTestConfig(2, 10))
+	exporter.Start()
+
+	for _, id := range []string{"r1", "r2", "r3"} {
+		exporter.Enqueue(OpsRequestEvent{RequestID: id})
+	}
+	require.Eventually(t, func() bool {
+		_, batches := writer.snapshot()
+		return len(batches) == 1
+	}, time.Second, 10*time.Millisecond)
+
+	// 停止时写出未攒满的剩余事件
+	exporter.Stop()
+	schemaCalls, batches := writer.snapshot()
+	require.Equal(t, 1, schemaCalls)
+	require.Len(t, batches, 2)
+	require.Equal(t, "r1", batches[0][0].RequestID)
+	require.Equal(t, "r3", batches[1][0].RequestID)
+}
+
+func TestOpsEventExporter_SchemaFailureRetriesNextBatch(t *testing.T) {
+	writer := &opsEventWriterStub{schemaErr: errors.New("connection refused")}
+	exporter := NewOpsEventExporter(writer, newOpsEventExporterTestConfig(10, 10))
+
+	exporter.flush([]OpsRequestEvent{{RequestID: "r1"}})
+	writer.schemaErr = nil
+	exporter.flush([]OpsRequestEvent{{RequestID: "r2"}})
+
+	schemaCalls, batches := writer.snapshot()
+	require.Equal(t, 2, schemaCalls)
+	require.Len(t, batches, 1)
+	require.Equal(t, "r2", batches[0][0].RequestID)
+}
+
+func TestOpsEventExporter_DropsWhenQueueFull(t *testing.T) {
+	exporter := NewOpsEventExporter(&opsEventWriterStub{}, newOpsEventExporterTestConfig(10, 1))
+
+	// 未启动时队列不会被消费
+	exporter.Enqueue(OpsRequestEvent{RequestID: "r1"})
+	exporter.Enqueue(OpsRequestEvent{RequestID: "r2"})
+	require.Equal(t, int64(1), exporter.dropped.Load())
+}
+
+func TestOpsRequestEventFromErrorLog(t *testing.T) {
+	upstreamErrors := `[{"status":502}]`
+	accountID := int64(7)
+	event := OpsRequestEventFromErrorLog(&OpsInsertErrorLogInput{
+		RequestID:          "req-1",
+		AccountID:          &accountID,
+		Platform:           PlatformOpenAI,
+		StatusCode:         502,
+		ErrorPhase:         "upstream",
+		ErrorMessage:       string(make([]byte, opsEventErrorMessageMaxLen+10)),
+		UpstreamErrorsJSON: &upstreamErrors,
+	})
+	require.Equal(t, OpsRequestKindError, event.Kind)
+	require.Equal(t, &accountID, event.AccountID)
+	require.Equal(t, upstreamErrors, event.UpstreamErrors)
+	require.False(t, event.CreatedAt.IsZero())
+	require.Len(t, event.ErrorMessage, opsEventErrorMessageMaxLen)
+}
+
+type opsEventFlakyWriter struct {
+	opsEventWriterStub
+	failures int
+	attempts int
+}
+
+func (w *opsEventFlakyWriter) WriteOpsEvents(ctx context.Context, events []OpsRequestEvent) error {
+	w.attempts++
+	if w.attempts <= w.failures {
+		return errors.New("503 service unavailable")
+	}
+	return w.opsEventWriterStub.WriteOpsEvents(ctx, events)
+}
+
+func newOpsLogShippingTestConfig(maxRetries int) config.OpsLogShippingConfig {
+	return config.OpsLogShippingConfig{
+		BatchSize:     10,
+		FlushInterval: time.Hour,
+		QueueSize:     10,
+		Timeout:       time.Second,
+		MaxRetries:    maxRetries,
+		RetryBackoff:  time.Millisecond,
+	}
+}
+
+func TestOpsLogShippingExporter_RetriesFailedWrites(t *testing.T) {
+	writer := &opsEventFlakyWriter{failures: 2}
+	exporter := NewOpsLogShippingExporter("loki", writer, newOpsLogShippingTestConfig(2))
+
+	exporter.flush([]OpsRequestEvent{{RequestID: "r1"}})
+	_, batches := writer.snapshot()
+	require.Equal(t, 3, writer.attempts)
+	require.Len(t, batches, 1)
+
+	// 超过重试次数后丢弃本批
+	writer.attempts, writer.failures = 0, 5
+	exporter.flush([]OpsRequestEvent{{RequestID: "r2"}})
+	_, batches = writer.snapshot()
+	require.Equal(t, 3, writer.attempts)
+	require.Len(t, batches, 1)
+}
+
+func TestOpsEventExporterGroup_FansOutAndSkipsNil(t *testing.T) {
+	require.Nil(t, NewOpsEventExporterGroup(nil, nil))
+
+	loki := &opsEventWriterStub{}
+	es := &opsEventWriterStub{}
+	group := NewOpsEventExporterGroup(
+		NewOpsEventExporter(&opsEventWriterStub{}, &config.Config{}),
+		NewOpsLogShippingExporter("loki", loki, newOpsLogShippingTestConfig(0)),
+		NewOpsLogShippingExporter("elasticsearch", es, newOpsLogShippingTestConfig(0)),
+	)
+	require.Len(t, group.exporters, 2)
+
+	group.Start()
+	group.Enqueue(OpsRequestEvent{RequestID: "r1"})
+	group.Stop()
+
+	for _, writer := range []*opsEventWriterStub{loki, es} {
+		_, batches := writer.snapshot()
+		require.Len(t, batches, 1)
+		require.Equal(t, "r1", batches[0][0].RequestID)
+	}
+}
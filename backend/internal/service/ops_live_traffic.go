@@ -0,0 +1,164 @@
+package service
+
+import (
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// opsLiveTrafficDefaultBuffer 每个订阅者的默认事件缓冲（满时丢弃，不阻塞网关请求）
+const opsLiveTrafficDefaultBuffer = 256
+
+// OpsLiveTrafficEvent 实时流量事件（一次网关请求结束时发布）
+type OpsLiveTrafficEvent struct {
+	Time         time.Time `json:"time"`
+	RequestID    string    `json:"request_id,omitempty"`
+	Platform     string    `json:"platform"`
+	Model        string    `json:"model,omitempty"`
+	AccountID    int64     `json:"account_id,omitempty"`
+	AccountName  string    `json:"account_name,omitempty"`
+	GroupID      *int64    `json:"group_id,omitempty"`
+	UserID       int64     `json:"user_id"`
+	APIKeyID     int64     `json:"api_key_id"`
+	Path         string    `json:"path"`
+	StatusCode   int       `json:"status_code"`
+	Stream       bool      `json:"stream"`
+	DurationMs   int64     `json:"duration_ms"`
+	FirstTokenMs *int      `json:"first_token_ms,omitempty"`
+	InputTokens  int       `json:"input_tokens"`
+	OutputTokens int       `json:"output_tokens"`
+}
+
+// OpsLiveTrafficFilter 订阅过滤条件（零值表示不过滤）
+type OpsLiveTrafficFilter struct {
+	Platform  string
+	GroupID   *int64
+	AccountID int64
+	ErrorOnly bool
+}
+
+func (f OpsLiveTrafficFilter) match(ev *OpsLiveTrafficEvent) bool {
+	if f.Platform != "" && f.Platform != ev.Platform {
+		return false
+	}
+	if f.GroupID != nil && (ev.GroupID == nil || *ev.GroupID != *f.GroupID) {
+		return false
+	}
+	if f.AccountID > 0 && f.AccountID != ev.AccountID {
+		return false
+	}
+	if f.ErrorOnly && ev.StatusCode < 400 {
+		return false
+	}
+	return true
+}
+
+// OpsLiveTrafficSubscription 单个实时流量订阅
+type OpsLiveTrafficSubscription struct {
+	hub     *OpsLiveTrafficHub
+	filter  OpsLiveTrafficFilter
+	ch      chan OpsLiveTrafficEvent
+	dropped atomic.Int64
+	once    sync.Once
+}
+
+// Events 返回事件通道；订阅关闭后通道被关闭
+func (s *OpsLiveTrafficSubscription) Events() <-chan OpsLiveTrafficEvent {
+	return s.ch
+}
+
+// TakeDropped 返回自上次调用以来因缓冲已满而丢弃的事件数，并清零
+func (s *OpsLiveTrafficSubscription) TakeDropped() int64 {
+	return s.dropped.Swap(0)
+}
+
+// Close 取消订阅（可重复调用）
+func (s *OpsLiveTrafficSubscription) Close() {
+	s.once.Do(func() {
+		s.hub.unsubscribe(s)
+	})
+}
+
+// OpsLiveTrafficHub 进程内实时流量事件广播器。
+// 没有订阅者时 Publish 只做一次原子读，不产生额外开销；
+// 订阅者消费过慢时丢弃事件而不是阻塞网关请求。
+type OpsLiveTrafficHub struct {
+	mu    sync.RWMutex
+	subs  map[*OpsLiveTrafficSubscription]struct{}
+	count atomic.Int32
+}
+
+// NewOpsLiveTrafficHub 创建实时流量广播器
+func NewOpsLiveTrafficHub() *OpsLiveTrafficHub {
+	return &OpsLiveTrafficHub{subs: make(map[*OpsLiveTrafficSubscription]struct{})}
+}
+
+// HasSubscribers 是否存在订阅者（发布方可据此跳过事件构造）
+func (h *OpsLiveTrafficHub) HasSubscribers() bool {
+	return h != nil && h.count.Load() > 0
+}
+
+// Subscribers 当前订阅者数量
+func (h *OpsLiveTrafficHub) Subscribers() int {
+	if h == nil {
+		return 0
+	}
+	return int(h.count.Load())
+}
+
+// Subscribe 注册订阅者；buffer<=0 时使用默认缓冲
+func (h *OpsLiveTrafficHub) Subscribe(filter OpsLiveTrafficFilter, buffer int) *OpsLiveTrafficSubscription {
+	if buffer <= 0 {
+		buffer = opsLiveTrafficDefaultBuffer
+	}
+	sub := &OpsLiveTrafficSubscription{
+		hub:    h,
+		filter: filter,
+		ch:     make(chan OpsLiveTrafficEvent, buffer),
+	}
+	h.mu.Lock()
+	h.subs[sub] = struct{}{}
+	h.count.Store(int32(len(h.subs)))
+	h.mu.Unlock()
+	return sub
+}
+
+func (h *OpsLiveTrafficHub) unsubscribe(sub *OpsLiveTrafficSubscription) {
+	h.mu.Lock()
+	if _, ok := h.subs[sub]; ok {
+		delete(h.subs, sub)
+		close(sub.ch)
+	}
+	h.count.Store(int32(len(h.subs)))
+	h.mu.Unlock()
+}
+
+// Publish 向所有匹配的订阅者非阻塞投递事件
+func (h *OpsLiveTrafficHub) Publish(ev OpsLiveTrafficEvent) {
+	if !h.HasSubscribers() {
+		return
+	}
+	if ev.Time.IsZero() {
+		ev.Time = time.Now()
+	}
+	h.mu.RLock()
+	defer h.mu.RUnlock()
+	for sub := range h.subs {
+		if !sub.filter.match(&ev) {
+			continue
+		}
+		select {
+		case sub.ch <- ev:
+		default:
+			sub.dropped.Add(1)
+		}
+	}
+}
+
+// defaultOpsLiveTrafficHub 进程级广播器（与 Prometheus 指标一样由网关中间件直接写入）
+var defaultOpsLiveTrafficHub = NewOpsLiveTrafficHub()
+
+// OpsLiveTraffic 返回进程级实时流量广播器
+func OpsLiveTraffic() *OpsLiveTrafficHub {
+	return defaultOpsLiveTrafficHub
+}
@@ -0,0 +1,45 @@
+package service
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestOpsLiveTrafficHub_PublishWithoutSubscribers(t *testing.T) {
+	hub := NewOpsLiveTrafficHub()
+	require.False(t, hub.HasSubscribers())
+	hub.Publish(OpsLiveTrafficEvent{Platform: PlatformAnthropic})
+}
+
+func TestOpsLiveTrafficHub_FilterAndDrop(t *testing.T) {
+	hub := NewOpsLiveTrafficHub()
+	groupID := int64(7)
+	all := hub.Subscribe(OpsLiveTrafficFilter{}, 1)
+	filtered := hub.Subscribe(OpsLiveTrafficFilter{Platform: PlatformOpenAI, GroupID: &groupID, ErrorOnly: true}, 4)
+	require.Equal(t, 2, hub.Subscribers())
+
+	hub.Publish(OpsLiveTrafficEvent{Platform: PlatformOpenAI, GroupID: &groupID, StatusCode: 200})
+	hub.Publish(OpsLiveTrafficEvent{Platform: PlatformOpenAI, GroupID: &groupID, StatusCode: 529, AccountID: 3})
+	hub.Publish(OpsLiveTrafficEvent{Platform: PlatformAnthropic, StatusCode: 500})
+
+	ev := <-all.Events()
+	require.Equal(t, 200, ev.StatusCode)
+	require.False(t, ev.Time.IsZero())
+	require.Equal(t, int64(2), all.TakeDropped())
+	require.Equal(t, int64(0), all.TakeDropped())
+
+	ev = <-filtered.Events()
+	require.Equal(t, 529, ev.StatusCode)
+	require.Equal(t, int64(3), ev.AccountID)
+	require.Len(t, filtered.Events(), 0)
+
+	all.Close()
+	all.Close()
+	_, ok := <-all.Events()
+	require.False(t, ok)
+	require.Equal(t, 1, hub.Subscribers())
+
+	filtered.Close()
+	require.False(t, hub.HasSubscribers())
+}
@@ -25,8 +25,11 @@ type OpsRepository interface {
 	GetDashboardOverview(ctx context.Context, filter *OpsDashboardFilter) (*OpsDashboardOverview, error)
 	GetThroughputTrend(ctx context.Context, filter *OpsDashboardFilter, bucketSeconds int) (*OpsThroughputTrendResponse, error)
 	GetLatencyHistogram(ctx context.Context, filter *OpsDashboardFilter) (*OpsLatencyHistogramResponse, error)
+	GetAccountLatencyHistograms(ctx context.Context, filter *OpsAccountLatencyFilter) (*OpsAccountLatencyHistogramResponse, error)
 	GetErrorTrend(ctx context.Context, filter *OpsDashboardFilter, bucketSeconds int) (*OpsErrorTrendResponse, error)
 	GetErrorDistribution(ctx context.Context, filter *OpsDashboardFilter) (*OpsErrorDistributionResponse, error)
+	// SLO counts (success/error and TTFT-within-threshold) for compliance and burn-rate evaluation.
+	GetSLOCounts(ctx context.Context, filter *OpsDashboardFilter, ttftThresholdMs int) (*OpsSLOCounts, error)
 
 	InsertSystemMetrics(ctx context.Context, input *OpsInsertSystemMetricsInput) error
 	GetLatestSystemMetrics(ctx context.Context, windowMinutes int) (*OpsSystemMetricsSnapshot, error)
@@ -34,6 +37,17 @@ type OpsRepository interface {
 	UpsertJobHeartbeat(ctx context.Context, input *OpsUpsertJobHeartbeatInput) error
 	ListJobHeartbeats(ctx context.Context) ([]*OpsJobHeartbeat, error)
 
+	// Slow requests (duration/TTFT over ops.slow_request thresholds)
+	InsertSlowRequest(ctx context.Context, input *OpsInsertSlowRequestInput) error
+	ListSlowRequests(ctx context.Context, filter *OpsSlowRequestFilter) ([]*OpsSlowRequest, int64, error)
+	GetSlowRequestSummary(ctx context.Context, filter *OpsSlowRequestFilter, limit int) ([]*OpsSlowRequestSummary, error)
+	CountSlowRequests(ctx context.Context, filter *OpsSlowRequestFilter) (int64, error)
+
+	// Per-group request phase timings (minute buckets, additive across instances)
+	UpsertGroupPhaseBuckets(ctx context.Context, buckets []*OpsGroupPhaseBucket) error
+	GetGroupPhaseTrend(ctx context.Context, filter *OpsDashboardFilter, bucketSeconds int) ([]*OpsGroupPhaseBucket, error)
+	GetGroupPhaseSummary(ctx context.Context, filter *OpsDashboardFilter) ([]*OpsGroupPhaseBucket, error)
+
 	// Alerts (rules + events)
 	ListAlertRules(ctx context.Context) ([]*OpsAlertRule, error)
 	CreateAlertRule(ctx context.Context, input *OpsAlertRule) (*OpsAlertRule, error)
@@ -0,0 +1,405 @@
+package service
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	infraerrors "github.com/Wei-Shaw/sub2api/internal/pkg/errors"
+	"github.com/tidwall/sjson"
+)
+
+// opsReferenceDiffMaxEntries 单次对比返回的差异条目上限
+const opsReferenceDiffMaxEntries = 200
+
+const (
+	OpsReferenceDiffMissingInPooled    = "missing_in_pooled"
+	OpsReferenceDiffMissingInReference = "missing_in_reference"
+	OpsReferenceDiffTypeMismatch       = "type_mismatch"
+	OpsReferenceDiffLengthMismatch     = "length_mismatch"
+	OpsReferenceDiffValueMismatch      = "value_mismatch"
+)
+
+// opsReferenceDiffProtocolKeys 协议层字段：值不同即视为差异（文本内容、ID、token 数等易变字段只比较结构）
+var opsReferenceDiffProtocolKeys = map[string]bool{
+	"type":          true,
+	"object":        true,
+	"role":          true,
+	"status":        true,
+	"stop_reason":   true,
+	"finish_reason": true,
+	"finishReason":  true,
+	"events":        true,
+}
+
+// OpsReferenceDiffInput 参考对比请求。请求体可直接提供，也可引用一条已捕获请求体的错误日志（ErrorID）。
+type OpsReferenceDiffInput struct {
+	RequestPath        string
+	Body               []byte
+	ErrorID            int64
+	Model              string // 仅 Gemini v1beta 需要（模型在路径中）
+	PooledAccountID    int64  // 0 表示按 GroupID 由调度器选择池内账号
+	GroupID            *int64
+	ReferenceAccountID int64
+}
+
+// OpsReferenceDiffSide 单侧执行结果
+type OpsReferenceDiffSide struct {
+	AccountID         int64  `json:"account_id"`
+	AccountName       string `json:"account_name"`
+	Platform          string `json:"platform"`
+	StatusCode        int    `json:"status_code"`
+	DurationMs        int64  `json:"duration_ms"`
+	UpstreamRequestID string `json:"upstream_request_id,omitempty"`
+	ResponsePreview   string `json:"response_preview"`
+	ResponseTruncated bool   `json:"response_truncated"`
+	Error             string `json:"error,omitempty"`
+}
+
+// OpsReferenceDiffEntry 单条结构差异
+type OpsReferenceDiffEntry struct {
+	Path      string `json:"path"`
+	Kind      string `json:"kind"`
+	Pooled    any    `json:"pooled,omitempty"`
+	Reference any    `json:"reference,omitempty"`
+}
+
+// OpsReferenceDiffResult 参考对比结果
+type OpsReferenceDiffResult struct {
+	RequestPath          string                  `json:"request_path"`
+	Model                string                  `json:"model"`
+	Pooled               OpsReferenceDiffSide    `json:"pooled"`
+	Reference            OpsReferenceDiffSide    `json:"reference"`
+	Identical            bool                    `json:"identical"`
+	Differences          []OpsReferenceDiffEntry `json:"differences"`
+	DifferencesTruncated bool                    `json:"differences_truncated"`
+}
+
+// CompareWithReference 将同一请求分别发往池内账号与参考账号（非流式），返回两端响应的结构差异。
+// 仅在 ops.reference_diff.enabled 时可用，参考账号必须列入 ops.reference_diff.reference_account_ids。
+func (s *OpsService) CompareWithReference(ctx context.Context, input *OpsReferenceDiffInput) (*OpsReferenceDiffResult, error) {
+	if err := s.RequireMonitoringEnabled(ctx); err != nil {
+		return nil, err
+	}
+	if s.cfg == nil || !s.cfg.Ops.ReferenceDiff.Enabled {
+		return nil, infraerrors.NotFound("OPS_REFERENCE_DIFF_DISABLED", "reference diff is disabled")
+	}
+	if input == nil {
+		return nil, infraerrors.BadRequest("OPS_REFERENCE_DIFF_INVALID", "request is required")
+	}
+	diffCfg := s.cfg.Ops.ReferenceDiff
+	if !containsInt64(diffCfg.ReferenceAccountIDs, input.ReferenceAccountID) {
+		return nil, infraerrors.BadRequest("OPS_REFERENCE_ACCOUNT_NOT_ALLOWED", "reference_account_id is not listed in ops.reference_diff.reference_account_ids")
+	}
+	if input.PooledAccountID == input.ReferenceAccountID {
+		return nil, infraerrors.BadRequest("OPS_REFERENCE_DIFF_SAME_ACCOUNT", "pooled and reference accounts must differ")
+	}
+	if s.accountRepo == nil {
+		return nil, infraerrors.ServiceUnavailable("OPS_ACCOUNT_REPO_UNAVAILABLE", "account repository not available")
+	}
+
+	errorLog, body, err := s.prepareReferenceDiffRequest(ctx, input)
+	if err != nil {
+		return nil, err
+	}
+	reqType := detectOpsRetryType(errorLog.RequestPath)
+	model, _, err := extractRetryModelAndStream(reqType, errorLog, body)
+	if err != nil {
+		return nil, infraerrors.BadRequest("OPS_REFERENCE_DIFF_INVALID", err.Error())
+	}
+
+	now := time.Now()
+	last := s.referenceDiffLastRun.Load()
+	if last > 0 && now.Sub(time.Unix(0, last)) < diffCfg.MinInterval {
+		return nil, infraerrors.TooManyRequests("OPS_REFERENCE_DIFF_TOO_FREQUENT", "reference diff was run too recently, please retry later")
+	}
+	if !s.referenceDiffLastRun.CompareAndSwap(last, now.UnixNano()) {
+		return nil, infraerrors.TooManyRequests("OPS_REFERENCE_DIFF_TOO_FREQUENT", "another reference diff is starting, please retry later")
+	}
+
+	reference, err := s.accountRepo.GetByID(ctx, input.ReferenceAccountID)
+	if err != nil || reference == nil {
+		return nil, infraerrors.NotFound("OPS_REFERENCE_ACCOUNT_NOT_FOUND", "reference account not found")
+	}
+
+	pooled, releasePooled, err := s.resolveReferenceDiffPooledAccount(ctx, reqType, input, errorLog.GroupID, model)
+	if err != nil {
+		return nil, err
+	}
+	if releasePooled != nil {
+		defer releasePooled()
+	}
+	if pooled.Platform != reference.Platform {
+		return nil, infraerrors.BadRequest("OPS_REFERENCE_DIFF_PLATFORM_MISMATCH", "pooled and reference accounts must be on the same platform")
+	}
+
+	execCtx, cancel := context.WithTimeout(ctx, opsRetryTimeout)
+	defer cancel()
+
+	// 两端并发执行，尽量让上游看到同一时刻的请求
+	var (
+		wg                  sync.WaitGroup
+		pooledExec, refExec *opsRetryExecution
+		pooledTook, refTook time.Duration
+	)
+	run := func(account *Account, reqBody []byte, exec **opsRetryExecution, took *time.Duration) {
+		defer wg.Done()
+		logCopy := *errorLog
+		start := time.Now()
+		*exec = s.executeWithAccountLimit(execCtx, reqType, &logCopy, reqBody, account, diffCfg.MaxResponseBytes)
+		*took = time.Since(start)
+	}
+	wg.Add(2)
+	go run(pooled, body, &pooledExec, &pooledTook)
+	go run(reference, append([]byte(nil), body...), &refExec, &refTook)
+	wg.Wait()
+
+	result := &OpsReferenceDiffResult{
+		RequestPath: errorLog.RequestPath,
+		Model:       model,
+		Pooled:      newOpsReferenceDiffSide(pooled, pooledExec, pooledTook),
+		Reference:   newOpsReferenceDiffSide(reference, refExec, refTook),
+	}
+	pooledDoc := parseReferenceDiffDocument(pooledExec.responseBody)
+	refDoc := parseReferenceDiffDocument(refExec.responseBody)
+	if pooledExec.httpStatusCode != refExec.httpStatusCode {
+		result.Differences = append(result.Differences, OpsReferenceDiffEntry{
+			Path:      "$status",
+			Kind:      OpsReferenceDiffValueMismatch,
+			Pooled:    pooledExec.httpStatusCode,
+			Reference: refExec.httpStatusCode,
+		})
+	}
+	result.Differences = append(result.Differences, DiffJSONStructure(pooledDoc, refDoc)...)
+	if len(result.Differences) > opsReferenceDiffMaxEntries {
+		result.Differences = result.Differences[:opsReferenceDiffMaxEntries]
+		result.DifferencesTruncated = true
+	}
+	result.Identical = len(result.Differences) == 0
+	if result.Differences == nil {
+		result.Differences = []OpsReferenceDiffEntry{}
+	}
+	return result, nil
+}
+
+// prepareReferenceDiffRequest 组装重放所需的请求上下文，并将请求强制改为非流式
+func (s *OpsService) prepareReferenceDiffRequest(ctx context.Context, input *OpsReferenceDiffInput) (*OpsErrorLogDetail, []byte, error) {
+	errorLog := &OpsErrorLogDetail{}
+	body := input.Body
+	if input.ErrorID > 0 {
+		stored, err := s.GetErrorLogByID(ctx, input.ErrorID)
+		if err != nil {
+			return nil, nil, err
+		}
+		if strings.TrimSpace(stored.RequestBody) == "" {
+			return nil, nil, infraerrors.BadRequest("OPS_RETRY_NO_REQUEST_BODY", "No request body found to replay")
+		}
+		if stored.RequestBodyTruncated {
+			return nil, nil, infraerrors.BadRequest("OPS_RETRY_REQUEST_BODY_TRUNCATED", "Stored request body is truncated; cannot replay")
+		}
+		errorLog = stored
+		body = []byte(stored.RequestBody)
+	} else {
+		errorLog.RequestPath = strings.TrimSpace(input.RequestPath)
+		errorLog.Model = strings.TrimSpace(input.Model)
+		errorLog.GroupID = input.GroupID
+	}
+	if len(bytes.TrimSpace(body)) == 0 || !json.Valid(body) {
+		return nil, nil, infraerrors.BadRequest("OPS_REFERENCE_DIFF_INVALID", "body must be a valid JSON request")
+	}
+	if errorLog.RequestPath == "" {
+		errorLog.RequestPath = "/v1/messages"
+	}
+	if input.GroupID != nil {
+		errorLog.GroupID = input.GroupID
+	}
+
+	// 流式响应的分片边界与时序天然不同，统一改为非流式后再比较结构
+	errorLog.Stream = false
+	if detectOpsRetryType(errorLog.RequestPath) != opsRetryTypeGeminiV1B {
+		patched, err := sjson.SetBytes(body, "stream", false)
+		if err != nil {
+			return nil, nil, infraerrors.BadRequest("OPS_REFERENCE_DIFF_INVALID", "failed to disable streaming: "+err.Error())
+		}
+		body = patched
+	}
+	return errorLog, body, nil
+}
+
+// resolveReferenceDiffPooledAccount 返回池内账号：指定 ID 时直接使用，否则由调度器在分组内选择（排除参考账号）
+func (s *OpsService) resolveReferenceDiffPooledAccount(ctx context.Context, reqType opsRetryRequestType, input *OpsReferenceDiffInput, groupID *int64, model string) (*Account, func(), error) {
+	if input.PooledAccountID > 0 {
+		account, err := s.accountRepo.GetByID(ctx, input.PooledAccountID)
+		if err != nil || account == nil {
+			return nil, nil, infraerrors.NotFound("OPS_POOLED_ACCOUNT_NOT_FOUND", "pooled account not found")
+		}
+		return account, nil, nil
+	}
+	if groupID == nil || *groupID <= 0 {
+		return nil, nil, infraerrors.BadRequest("OPS_REFERENCE_DIFF_INVALID", "pooled_account_id or group_id is required")
+	}
+	excluded := map[int64]struct{}{input.ReferenceAccountID: {}}
+	selection, err := s.selectAccountForRetry(ctx, reqType, groupID, model, excluded)
+	if err != nil {
+		return nil, nil, infraerrors.ServiceUnavailable("OPS_POOLED_ACCOUNT_UNAVAILABLE", err.Error())
+	}
+	if selection == nil || selection.Account == nil {
+		return nil, nil, infraerrors.ServiceUnavailable("OPS_POOLED_ACCOUNT_UNAVAILABLE", "no available accounts")
+	}
+	if !selection.Acquired || selection.ReleaseFunc == nil {
+		return nil, nil, infraerrors.ServiceUnavailable("OPS_POOLED_ACCOUNT_UNAVAILABLE", "account concurrency limit reached")
+	}
+	if err := selection.Reservation.Commit(ctx); err != nil {
+		selection.ReleaseFunc()
+		return nil, nil, infraerrors.ServiceUnavailable("OPS_POOLED_ACCOUNT_UNAVAILABLE", err.Error())
+	}
+	return selection.Account, selection.ReleaseFunc, nil
+}
+
+func newOpsReferenceDiffSide(account *Account, exec *opsRetryExecution, took time.Duration) OpsReferenceDiffSide {
+	side := OpsReferenceDiffSide{
+		AccountID:         account.ID,
+		AccountName:       account.Name,
+		Platform:          account.Platform,
+		StatusCode:        exec.httpStatusCode,
+		DurationMs:        took.Milliseconds(),
+		UpstreamRequestID: exec.upstreamRequestID,
+		ResponsePreview:   exec.responsePreview,
+		ResponseTruncated: exec.responseTruncated,
+	}
+	if exec.status != opsRetryStatusSucceeded {
+		side.Error = exec.errorMessage
+	}
+	return side
+}
+
+// parseReferenceDiffDocument 将响应体解析为可比较的 JSON 文档。
+// SSE 响应（部分账号强制流式）转换为 {"events": [事件类型...], "final": 最后一个 JSON 数据块}。
+func parseReferenceDiffDocument(body []byte) any {
+	body = bytes.TrimSpace(body)
+	if len(body) == 0 {
+		return nil
+	}
+	var doc any
+	if json.Unmarshal(body, &doc) == nil {
+		return doc
+	}
+
+	events := make([]any, 0)
+	var final any
+	pendingEvent := ""
+	for _, line := range strings.Split(string(body), "\n") {
+		line = strings.TrimSpace(line)
+		switch {
+		case strings.HasPrefix(line, "event:"):
+			pendingEvent = strings.TrimSpace(strings.TrimPrefix(line, "event:"))
+		case strings.HasPrefix(line, "data:"):
+			data := strings.TrimSpace(strings.TrimPrefix(line, "data:"))
+			var chunk any
+			if data == "" || data == "[DONE]" || json.Unmarshal([]byte(data), &chunk) != nil {
+				continue
+			}
+			name := pendingEvent
+			if obj, ok := chunk.(map[string]any); ok && name == "" {
+				name, _ = obj["type"].(string)
+				if name == "" {
+					name, _ = obj["object"].(string)
+				}
+			}
+			events = append(events, name)
+			final = chunk
+			pendingEvent = ""
+		}
+	}
+	if len(events) == 0 {
+		return map[string]any{"raw": string(body)}
+	}
+	return map[string]any{"events": events, "final": final}
+}
+
+// DiffJSONStructure 比较两个 JSON 文档的结构：键缺失、类型不一致、数组长度不一致，
+// 以及协议字段（type/role/stop_reason 等）的取值差异。文本内容、ID、token 数等只比较类型。
+func DiffJSONStructure(pooled, reference any) []OpsReferenceDiffEntry {
+	var out []OpsReferenceDiffEntry
+	diffJSONValue(pooled, reference, "$", "", &out)
+	return out
+}
+
+func diffJSONValue(pooled, reference any, path, key string, out *[]OpsReferenceDiffEntry) {
+	if len(*out) > opsReferenceDiffMaxEntries {
+		return
+	}
+	pk, rk := jsonKindOf(pooled), jsonKindOf(reference)
+	if pk != rk {
+		*out = append(*out, OpsReferenceDiffEntry{Path: path, Kind: OpsReferenceDiffTypeMismatch, Pooled: pk, Reference: rk})
+		return
+	}
+	switch p := pooled.(type) {
+	case map[string]any:
+		r := reference.(map[string]any)
+		keys := make([]string, 0, len(p)+len(r))
+		for k := range p {
+			keys = append(keys, k)
+		}
+		for k := range r {
+			if _, ok := p[k]; !ok {
+				keys = append(keys, k)
+			}
+		}
+		sort.Strings(keys)
+		for _, k := range keys {
+			pv, inPooled := p[k]
+			rv, inRef := r[k]
+			child := path + "." + k
+			switch {
+			case !inPooled:
+				*out = append(*out, OpsReferenceDiffEntry{Path: child, Kind: OpsReferenceDiffMissingInPooled, Reference: jsonKindOf(rv)})
+			case !inRef:
+				*out = append(*out, OpsReferenceDiffEntry{Path: child, Kind: OpsReferenceDiffMissingInReference, Pooled: jsonKindOf(pv)})
+			default:
+				diffJSONValue(pv, rv, child, k, out)
+			}
+		}
+	case []any:
+		r := reference.([]any)
+		if len(p) != len(r) {
+			*out = append(*out, OpsReferenceDiffEntry{Path: path, Kind: OpsReferenceDiffLengthMismatch, Pooled: len(p), Reference: len(r)})
+		}
+		n := len(p)
+		if len(r) < n {
+			n = len(r)
+		}
+		for i := 0; i < n; i++ {
+			diffJSONValue(p[i], r[i], path+"["+strconv.Itoa(i)+"]", key, out)
+		}
+	default:
+		if opsReferenceDiffProtocolKeys[key] && fmt.Sprint(pooled) != fmt.Sprint(reference) {
+			*out = append(*out, OpsReferenceDiffEntry{Path: path, Kind: OpsReferenceDiffValueMismatch, Pooled: pooled, Reference: reference})
+		}
+	}
+}
+
+func jsonKindOf(v any) string {
+	switch v.(type) {
+	case nil:
+		return "null"
+	case map[string]any:
+		return "object"
+	case []any:
+		return "array"
+	case string:
+		return "string"
+	case bool:
+		return "boolean"
+	case float64, json.Number:
+		return "number"
+	default:
+		return fmt.Sprintf("%T", v)
+	}
+}
@@ -0,0 +1,50 @@
+package service
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func mustJSON(t *testing.T, raw string) any {
+	t.Helper()
+	var v any
+	require.NoError(t, json.Unmarshal([]byte(raw), &v))
+	return v
+}
+
+func TestDiffJSONStructure_IgnoresVolatileValues(t *testing.T) {
+	pooled := mustJSON(t, `{"id":"msg_1","type":"message","content":[{"type":"text","text":"hi"}],"usage":{"input_tokens":3}}`)
+	reference := mustJSON(t, `{"id":"msg_2","type":"message","content":[{"type":"text","text":"hello"}],"usage":{"input_tokens":5}}`)
+	require.Empty(t, DiffJSONStructure(pooled, reference))
+}
+
+func TestDiffJSONStructure_ReportsStructuralDifferences(t *testing.T) {
+	pooled := mustJSON(t, `{"type":"message","stop_reason":"end_turn","content":[{"type":"text"}],"extra":1,"usage":{"input_tokens":"3"}}`)
+	reference := mustJSON(t, `{"type":"message","stop_reason":"tool_use","content":[{"type":"text"},{"type":"tool_use"}],"usage":{"input_tokens":3,"cache_read_input_tokens":0}}`)
+
+	diffs := DiffJSONStructure(pooled, reference)
+	byPath := make(map[string]OpsReferenceDiffEntry, len(diffs))
+	for _, d := range diffs {
+		byPath[d.Path] = d
+	}
+	require.Len(t, diffs, 5)
+	require.Equal(t, OpsReferenceDiffLengthMismatch, byPath["$.content"].Kind)
+	require.Equal(t, OpsReferenceDiffMissingInReference, byPath["$.extra"].Kind)
+	require.Equal(t, OpsReferenceDiffValueMismatch, byPath["$.stop_reason"].Kind)
+	require.Equal(t, OpsReferenceDiffTypeMismatch, byPath["$.usage.input_tokens"].Kind)
+	require.Equal(t, OpsReferenceDiffMissingInPooled, byPath["$.usage.cache_read_input_tokens"].Kind)
+}
+
+func TestParseReferenceDiffDocument_SSE(t *testing.T) {
+	body := []byte("event: message_start\ndata: {\"type\":\"message_start\"}\n\ndata: {\"type\":\"message_stop\",\"x\":1}\n\ndata: [DONE]\n")
+	doc := parseReferenceDiffDocument(body)
+	m, ok := doc.(map[string]any)
+	require.True(t, ok)
+	require.Equal(t, []any{"message_start", "message_stop"}, m["events"])
+	require.Equal(t, map[string]any{"type": "message_stop", "x": float64(1)}, m["final"])
+
+	require.Nil(t, parseReferenceDiffDocument(nil))
+	require.Equal(t, map[string]any{"raw": "oops"}, parseReferenceDiffDocument([]byte("oops")))
+}
@@ -0,0 +1,412 @@
+package service
+
+import (
+	"context"
+	"log"
+	"math"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	infraerrors "github.com/Wei-Shaw/sub2api/internal/pkg/errors"
+)
+
+const (
+	opsRequestPhaseFlushInterval = 30 * time.Second
+	opsRequestPhaseFlushTimeout  = 10 * time.Second
+	// opsRequestPhaseMaxPending 写入失败时保留的待写分钟桶上限，超出后丢弃
+	opsRequestPhaseMaxPending = 10000
+)
+
+// OpsRequestPhaseSample 单个成功请求的阶段耗时（毫秒）
+type OpsRequestPhaseSample struct {
+	GroupID  int64
+	Platform string
+
+	// QueueWaitMs 用户级并发排队等待
+	QueueWaitMs int64
+	// SlotWaitMs 账号并发槽位等待
+	SlotWaitMs int64
+	// SelectionMs 账号选择（含重试/切换账号时的多次选择）
+	SelectionMs int64
+	// TTFBMs 上游首字节耗时（流式为首 token，非流式为整个上游调用）
+	TTFBMs *int64
+	// StreamMs 流式请求首字节之后的传输时长
+	StreamMs *int64
+}
+
+// OpsGroupPhaseBucket 分组 + 平台的阶段耗时聚合；sum/count 可跨实例累加，max 取最大值
+type OpsGroupPhaseBucket struct {
+	BucketStart time.Time
+	GroupID     int64
+	GroupName   string
+	Platform    string
+
+	RequestCount int64
+
+	QueuedCount    int64
+	QueueWaitSumMs int64
+	QueueWaitMaxMs int64
+
+	SlotWaitedCount int64
+	SlotWaitSumMs   int64
+	SlotWaitMaxMs   int64
+
+	SelectionSumMs int64
+	SelectionMaxMs int64
+
+	TTFBCount int64
+	TTFBSumMs int64
+	TTFBMaxMs int64
+
+	StreamCount int64
+	StreamSumMs int64
+	StreamMaxMs int64
+}
+
+func (b *OpsGroupPhaseBucket) addSample(sample *OpsRequestPhaseSample) {
+	b.RequestCount++
+	if sample.QueueWaitMs > 0 {
+		b.QueuedCount++
+		b.QueueWaitSumMs += sample.QueueWaitMs
+		b.QueueWaitMaxMs = max(b.QueueWaitMaxMs, sample.QueueWaitMs)
+	}
+	if sample.SlotWaitMs > 0 {
+		b.SlotWaitedCount++
+		b.SlotWaitSumMs += sample.SlotWaitMs
+		b.SlotWaitMaxMs = max(b.SlotWaitMaxMs, sample.SlotWaitMs)
+	}
+	if sample.SelectionMs > 0 {
+		b.SelectionSumMs += sample.SelectionMs
+		b.SelectionMaxMs = max(b.SelectionMaxMs, sample.SelectionMs)
+	}
+	if sample.TTFBMs != nil && *sample.TTFBMs >= 0 {
+		b.TTFBCount++
+		b.TTFBSumMs += *sample.TTFBMs
+		b.TTFBMaxMs = max(b.TTFBMaxMs, *sample.TTFBMs)
+	}
+	if sample.StreamMs != nil && *sample.StreamMs >= 0 {
+		b.StreamCount++
+		b.StreamSumMs += *sample.StreamMs
+		b.StreamMaxMs = max(b.StreamMaxMs, *sample.StreamMs)
+	}
+}
+
+func (b *OpsGroupPhaseBucket) merge(other *OpsGroupPhaseBucket) {
+	b.RequestCount += other.RequestCount
+	b.QueuedCount += other.QueuedCount
+	b.QueueWaitSumMs += other.QueueWaitSumMs
+	b.QueueWaitMaxMs = max(b.QueueWaitMaxMs, other.QueueWaitMaxMs)
+	b.SlotWaitedCount += other.SlotWaitedCount
+	b.SlotWaitSumMs += other.SlotWaitSumMs
+	b.SlotWaitMaxMs = max(b.SlotWaitMaxMs, other.SlotWaitMaxMs)
+	b.SelectionSumMs += other.SelectionSumMs
+	b.SelectionMaxMs = max(b.SelectionMaxMs, other.SelectionMaxMs)
+	b.TTFBCount += other.TTFBCount
+	b.TTFBSumMs += other.TTFBSumMs
+	b.TTFBMaxMs = max(b.TTFBMaxMs, other.TTFBMaxMs)
+	b.StreamCount += other.StreamCount
+	b.StreamSumMs += other.StreamSumMs
+	b.StreamMaxMs = max(b.StreamMaxMs, other.StreamMaxMs)
+}
+
+// OpsPhaseStat 单个阶段的耗时统计
+type OpsPhaseStat struct {
+	// Count 经历该阶段的请求数（排队/槽位等待为实际发生等待的请求数）
+	Count int64 `json:"count"`
+	// AvgMs 每请求平均耗时：排队/槽位等待/选号按全部请求平均，首字节/流式按 Count 平均
+	AvgMs float64 `json:"avg_ms"`
+	MaxMs int64   `json:"max_ms"`
+}
+
+// OpsGroupPhaseStats 一组请求的阶段耗时拆分
+type OpsGroupPhaseStats struct {
+	RequestCount int64        `json:"request_count"`
+	QueueWait    OpsPhaseStat `json:"queue_wait"`
+	SlotWait     OpsPhaseStat `json:"slot_wait"`
+	Selection    OpsPhaseStat `json:"selection"`
+	TTFB         OpsPhaseStat `json:"ttfb"`
+	Stream       OpsPhaseStat `json:"stream"`
+	// QueueingShare 网关侧耗时（排队 + 槽位等待 + 选号）占每请求平均总耗时的比例（0-1），
+	// 偏高说明延迟主要来自排队策略/并发配置，偏低说明主要来自上游账号
+	QueueingShare float64 `json:"queueing_share"`
+}
+
+// OpsGroupPhasePoint 趋势中的一个时间桶
+type OpsGroupPhasePoint struct {
+	BucketStart time.Time `json:"bucket_start"`
+	OpsGroupPhaseStats
+}
+
+// OpsGroupPhaseTrendResponse 分组阶段耗时趋势
+type OpsGroupPhaseTrendResponse struct {
+	Bucket string                `json:"bucket"`
+	Points []*OpsGroupPhasePoint `json:"points"`
+}
+
+// OpsGroupPhaseSummary 分组在时间窗口内的阶段耗时汇总
+type OpsGroupPhaseSummary struct {
+	GroupID   int64  `json:"group_id"`
+	GroupName string `json:"group_name"`
+	OpsGroupPhaseStats
+}
+
+func roundOpsPhaseMs(v float64) float64 {
+	return math.Round(v*10) / 10
+}
+
+// buildOpsGroupPhaseStats 由聚合桶计算平均耗时与网关侧耗时占比
+func buildOpsGroupPhaseStats(b *OpsGroupPhaseBucket) OpsGroupPhaseStats {
+	out := OpsGroupPhaseStats{RequestCount: b.RequestCount}
+	perRequest := func(sum int64) float64 {
+		if b.RequestCount <= 0 {
+			return 0
+		}
+		return float64(sum) / float64(b.RequestCount)
+	}
+	perCount := func(sum, count int64) float64 {
+		if count <= 0 {
+			return 0
+		}
+		return float64(sum) / float64(count)
+	}
+
+	queueAvg := perRequest(b.QueueWaitSumMs)
+	slotAvg := perRequest(b.SlotWaitSumMs)
+	selectionAvg := perRequest(b.SelectionSumMs)
+	ttfbAvg := perCount(b.TTFBSumMs, b.TTFBCount)
+	// 流式传输时长只出现在流式请求上，按全部请求摊薄后参与占比计算
+	streamAvg := perCount(b.StreamSumMs, b.StreamCount)
+
+	out.QueueWait = OpsPhaseStat{Count: b.QueuedCount, AvgMs: roundOpsPhaseMs(queueAvg), MaxMs: b.QueueWaitMaxMs}
+	out.SlotWait = OpsPhaseStat{Count: b.SlotWaitedCount, AvgMs: roundOpsPhaseMs(slotAvg), MaxMs: b.SlotWaitMaxMs}
+	out.Selection = OpsPhaseStat{Count: b.RequestCount, AvgMs: roundOpsPhaseMs(selectionAvg), MaxMs: b.SelectionMaxMs}
+	out.TTFB = OpsPhaseStat{Count: b.TTFBCount, AvgMs: roundOpsPhaseMs(ttfbAvg), MaxMs: b.TTFBMaxMs}
+	out.Stream = OpsPhaseStat{Count: b.StreamCount, AvgMs: roundOpsPhaseMs(streamAvg), MaxMs: b.StreamMaxMs}
+
+	gateway := queueAvg + slotAvg + selectionAvg
+	upstream := perRequest(b.TTFBSumMs) + perRequest(b.StreamSumMs)
+	if total := gateway + upstream; total > 0 {
+		out.QueueingShare = math.Round(gateway/total*1000) / 1000
+	}
+	return out
+}
+
+type opsGroupPhaseKey struct {
+	bucketStart time.Time
+	groupID     int64
+	platform    string
+}
+
+// OpsRequestPhaseService 在内存中按分钟聚合请求阶段耗时，并定期累加写入 ops_group_phase_metrics
+type OpsRequestPhaseService struct {
+	opsRepo OpsRepository
+
+	mu      sync.Mutex
+	pending map[opsGroupPhaseKey]*OpsGroupPhaseBucket
+
+	stopCh    chan struct{}
+	startOnce sync.Once
+	stopOnce  sync.Once
+	wg        sync.WaitGroup
+}
+
+// NewOpsRequestPhaseService 创建请求阶段耗时聚合服务
+func NewOpsRequestPhaseService(opsRepo OpsRepository) *OpsRequestPhaseService {
+	return &OpsRequestPhaseService{
+		opsRepo: opsRepo,
+		pending: make(map[opsGroupPhaseKey]*OpsGroupPhaseBucket),
+		stopCh:  make(chan struct{}),
+	}
+}
+
+// Start 启动定期写入
+func (s *OpsRequestPhaseService) Start() {
+	if s == nil || s.opsRepo == nil {
+		return
+	}
+	s.startOnce.Do(func() {
+		s.wg.Add(1)
+		go func() {
+			defer s.wg.Done()
+			ticker := time.NewTicker(opsRequestPhaseFlushInterval)
+			defer ticker.Stop()
+			for {
+				select {
+				case <-ticker.C:
+					s.flush()
+				case <-s.stopCh:
+					return
+				}
+			}
+		}()
+	})
+}
+
+// Stop 停止定期写入并写出剩余数据
+func (s *OpsRequestPhaseService) Stop() {
+	if s == nil {
+		return
+	}
+	s.stopOnce.Do(func() {
+		close(s.stopCh)
+		s.wg.Wait()
+		s.flush()
+	})
+}
+
+// Record 累加一个请求的阶段耗时（只在内存中操作，不阻塞请求）
+func (s *OpsRequestPhaseService) Record(sample *OpsRequestPhaseSample) {
+	if s == nil || sample == nil {
+		return
+	}
+	key := opsGroupPhaseKey{
+		bucketStart: time.Now().UTC().Truncate(time.Minute),
+		groupID:     max(sample.GroupID, 0),
+		platform:    truncateString(strings.ToLower(strings.TrimSpace(sample.Platform)), 32),
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	bucket := s.pending[key]
+	if bucket == nil {
+		if len(s.pending) >= opsRequestPhaseMaxPending {
+			return
+		}
+		bucket = &OpsGroupPhaseBucket{BucketStart: key.bucketStart, GroupID: key.groupID, Platform: key.platform}
+		s.pending[key] = bucket
+	}
+	bucket.addSample(sample)
+}
+
+func (s *OpsRequestPhaseService) flush() {
+	s.mu.Lock()
+	if len(s.pending) == 0 {
+		s.mu.Unlock()
+		return
+	}
+	batch := s.pending
+	s.pending = make(map[opsGroupPhaseKey]*OpsGroupPhaseBucket, len(batch))
+	s.mu.Unlock()
+
+	buckets := make([]*OpsGroupPhaseBucket, 0, len(batch))
+	for _, b := range batch {
+		buckets = append(buckets, b)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), opsRequestPhaseFlushTimeout)
+	defer cancel()
+	if err := s.opsRepo.UpsertGroupPhaseBuckets(ctx, buckets); err != nil {
+		log.Printf("[OpsRequestPhase] flush failed: buckets=%d err=%v", len(buckets), err)
+		// 写回待写队列，下一轮重试（超出上限的部分丢弃）
+		s.mu.Lock()
+		for key, b := range batch {
+			if existing := s.pending[key]; existing != nil {
+				existing.merge(b)
+				continue
+			}
+			if len(s.pending) < opsRequestPhaseMaxPending {
+				s.pending[key] = b
+			}
+		}
+		s.mu.Unlock()
+	}
+}
+
+// SetRequestPhaseService 注入请求阶段耗时聚合服务（由网关中间件经 RecordRequestPhases 写入）
+func (s *OpsService) SetRequestPhaseService(svc *OpsRequestPhaseService) {
+	if s == nil {
+		return
+	}
+	s.requestPhases = svc
+}
+
+// RecordRequestPhases 记录一个成功请求的阶段耗时
+func (s *OpsService) RecordRequestPhases(sample *OpsRequestPhaseSample) {
+	if s == nil {
+		return
+	}
+	s.requestPhases.Record(sample)
+}
+
+func validateOpsGroupPhaseFilter(filter *OpsDashboardFilter) error {
+	if filter == nil {
+		return infraerrors.BadRequest("OPS_FILTER_REQUIRED", "filter is required")
+	}
+	if filter.StartTime.IsZero() || filter.EndTime.IsZero() {
+		return infraerrors.BadRequest("OPS_TIME_RANGE_REQUIRED", "start_time/end_time are required")
+	}
+	if filter.StartTime.After(filter.EndTime) {
+		return infraerrors.BadRequest("OPS_TIME_RANGE_INVALID", "start_time must be <= end_time")
+	}
+	return nil
+}
+
+// GetGroupPhaseTrend 返回分组阶段耗时趋势（filter.GroupID 为空时为全部分组合计）
+func (s *OpsService) GetGroupPhaseTrend(ctx context.Context, filter *OpsDashboardFilter, bucketSeconds int) (*OpsGroupPhaseTrendResponse, error) {
+	if err := s.RequireMonitoringEnabled(ctx); err != nil {
+		return nil, err
+	}
+	if err := validateOpsGroupPhaseFilter(filter); err != nil {
+		return nil, err
+	}
+	bucketLabel := "1m"
+	switch bucketSeconds {
+	case 300:
+		bucketLabel = "5m"
+	case 3600:
+		bucketLabel = "1h"
+	default:
+		bucketSeconds = 60
+	}
+	out := &OpsGroupPhaseTrendResponse{
+		Bucket: bucketLabel,
+		Points: []*OpsGroupPhasePoint{},
+	}
+	if s.opsRepo == nil {
+		return out, nil
+	}
+
+	buckets, err := s.opsRepo.GetGroupPhaseTrend(ctx, filter, bucketSeconds)
+	if err != nil {
+		return nil, err
+	}
+	for _, b := range buckets {
+		out.Points = append(out.Points, &OpsGroupPhasePoint{
+			BucketStart:        b.BucketStart,
+			OpsGroupPhaseStats: buildOpsGroupPhaseStats(b),
+		})
+	}
+	return out, nil
+}
+
+// GetGroupPhaseSummary 返回时间窗口内各分组的阶段耗时汇总，按网关侧耗时占比倒序
+func (s *OpsService) GetGroupPhaseSummary(ctx context.Context, filter *OpsDashboardFilter) ([]*OpsGroupPhaseSummary, error) {
+	if err := s.RequireMonitoringEnabled(ctx); err != nil {
+		return nil, err
+	}
+	if err := validateOpsGroupPhaseFilter(filter); err != nil {
+		return nil, err
+	}
+	out := []*OpsGroupPhaseSummary{}
+	if s.opsRepo == nil {
+		return out, nil
+	}
+
+	buckets, err := s.opsRepo.GetGroupPhaseSummary(ctx, filter)
+	if err != nil {
+		return nil, err
+	}
+	for _, b := range buckets {
+		out = append(out, &OpsGroupPhaseSummary{
+			GroupID:            b.GroupID,
+			GroupName:          b.GroupName,
+			OpsGroupPhaseStats: buildOpsGroupPhaseStats(b),
+		})
+	}
+	sort.SliceStable(out, func(i, j int) bool {
+		return out[i].QueueingShare > out[j].QueueingShare
+	})
+	return out, nil
+}
@@ -0,0 +1,66 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+type phaseStubOpsRepo struct {
+	OpsRepository
+	err     error
+	upserts [][]*OpsGroupPhaseBucket
+}
+
+func (r *phaseStubOpsRepo) UpsertGroupPhaseBuckets(_ context.Context, buckets []*OpsGroupPhaseBucket) error {
+	if r.err != nil {
+		return r.err
+	}
+	r.upserts = append(r.upserts, buckets)
+	return nil
+}
+
+func TestBuildOpsGroupPhaseStats(t *testing.T) {
+	int64Ptr := func(v int64) *int64 { return &v }
+	bucket := &OpsGroupPhaseBucket{}
+	bucket.addSample(&OpsRequestPhaseSample{QueueWaitMs: 400, SelectionMs: 10, TTFBMs: int64Ptr(500), StreamMs: int64Ptr(1000)})
+	bucket.addSample(&OpsRequestPhaseSample{SlotWaitMs: 200, SelectionMs: 30, TTFBMs: int64Ptr(300)})
+
+	stats := buildOpsGroupPhaseStats(bucket)
+	require.Equal(t, int64(2), stats.RequestCount)
+	require.Equal(t, OpsPhaseStat{Count: 1, AvgMs: 200, MaxMs: 400}, stats.QueueWait)
+	require.Equal(t, OpsPhaseStat{Count: 1, AvgMs: 100, MaxMs: 200}, stats.SlotWait)
+	require.Equal(t, OpsPhaseStat{Count: 2, AvgMs: 20, MaxMs: 30}, stats.Selection)
+	require.Equal(t, OpsPhaseStat{Count: 2, AvgMs: 400, MaxMs: 500}, stats.TTFB)
+	require.Equal(t, OpsPhaseStat{Count: 1, AvgMs: 1000, MaxMs: 1000}, stats.Stream)
+	// 网关侧每请求 320ms（200+100+20），上游每请求 900ms（400+500）
+	require.InDelta(t, 0.262, stats.QueueingShare, 0.001)
+
+	empty := buildOpsGroupPhaseStats(&OpsGroupPhaseBucket{})
+	require.Zero(t, empty.QueueingShare)
+}
+
+func TestOpsRequestPhaseService_FlushMergesOnFailure(t *testing.T) {
+	repo := &phaseStubOpsRepo{err: errors.New("db down")}
+	svc := NewOpsRequestPhaseService(repo)
+
+	svc.Record(&OpsRequestPhaseSample{GroupID: 1, Platform: "Anthropic", QueueWaitMs: 100})
+	svc.flush()
+	require.Len(t, svc.pending, 1, "failed flush should keep buckets for the next round")
+
+	svc.Record(&OpsRequestPhaseSample{GroupID: 1, Platform: "anthropic", QueueWaitMs: 300})
+	repo.err = nil
+	svc.flush()
+	require.Empty(t, svc.pending)
+	require.Len(t, repo.upserts, 1)
+	require.Len(t, repo.upserts[0], 1)
+
+	b := repo.upserts[0][0]
+	require.Equal(t, int64(1), b.GroupID)
+	require.Equal(t, "anthropic", b.Platform)
+	require.Equal(t, int64(2), b.RequestCount)
+	require.Equal(t, int64(400), b.QueueWaitSumMs)
+	require.Equal(t, int64(300), b.QueueWaitMaxMs)
+}
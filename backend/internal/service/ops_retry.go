@@ -345,6 +345,12 @@ type opsRetryExecution struct {
 
 	responsePreview   string
 	responseTruncated bool
+	// responseBody 捕获到的完整响应体（受捕获上限约束，仅供参考对比使用）
+	responseBody []byte
+
+	// upstreamStatusCode/upstreamError 上游原始错误（失败切换错误或 ops 上下文中记录的错误详情）
+	upstreamStatusCode int
+	upstreamError      string
 
 	errorMessage string
 }
@@ -477,6 +483,11 @@ func (s *OpsService) executeClientRetry(ctx context.Context, reqType opsRetryReq
 			switches++
 			continue
 		}
+		// 预占在确认前已过期：槽位已被回收，重新选择
+		if err := selection.Reservation.Commit(ctx); errors.Is(err, ErrAccountReservationExpired) {
+			switches++
+			continue
+		}
 
 		attemptCtx := ctx
 		if switches > 0 {
@@ -554,11 +565,16 @@ func extractRetryModelAndStream(reqType opsRetryRequestType, errorLog *OpsErrorL
 }
 
 func (s *OpsService) executeWithAccount(ctx context.Context, reqType opsRetryRequestType, errorLog *OpsErrorLogDetail, body []byte, account *Account) *opsRetryExecution {
+	return s.executeWithAccountLimit(ctx, reqType, errorLog, body, account, opsRetryCaptureBytesLimit)
+}
+
+// executeWithAccountLimit 在指定账号上重放请求，响应体最多捕获 captureLimit 字节
+func (s *OpsService) executeWithAccountLimit(ctx context.Context, reqType opsRetryRequestType, errorLog *OpsErrorLogDetail, body []byte, account *Account, captureLimit int) *opsRetryExecution {
 	if account == nil {
 		return &opsRetryExecution{status: opsRetryStatusFailed, errorMessage: "missing account"}
 	}
 
-	c, w := newOpsRetryContext(ctx, errorLog)
+	c, w := newOpsRetryContext(ctx, errorLog, captureLimit)
 
 	var err error
 	switch reqType {
@@ -621,8 +637,10 @@ func (s *OpsService) executeWithAccount(ctx context.Context, reqType opsRetryReq
 		upstreamRequestID: upstreamReqID,
 		responsePreview:   preview,
 		responseTruncated: truncated,
+		responseBody:      w.bodyBytes(),
 		errorMessage:      "",
 	}
+	exec.upstreamStatusCode, exec.upstreamError = extractOpsUpstreamError(c, err)
 
 	if err == nil && statusCode < 400 {
 		exec.status = opsRetryStatusSucceeded
@@ -638,8 +656,8 @@ func (s *OpsService) executeWithAccount(ctx context.Context, reqType opsRetryReq
 	return exec
 }
 
-func newOpsRetryContext(ctx context.Context, errorLog *OpsErrorLogDetail) (*gin.Context, *limitedResponseWriter) {
-	w := newLimitedResponseWriter(opsRetryCaptureBytesLimit)
+func newOpsRetryContext(ctx context.Context, errorLog *OpsErrorLogDetail, captureLimit int) (*gin.Context, *limitedResponseWriter) {
+	w := newLimitedResponseWriter(captureLimit)
 	c, _ := gin.CreateTestContext(w)
 
 	path := "/"
@@ -694,6 +712,31 @@ func extractUpstreamRequestID(c *gin.Context) string {
 	return ""
 }
 
+// extractOpsUpstreamError 提取本次转发的上游原始错误：优先取失败切换错误携带的响应体，
+// 其次取网关服务写入 gin 上下文的错误详情/消息
+func extractOpsUpstreamError(c *gin.Context, err error) (statusCode int, detail string) {
+	var failoverErr *UpstreamFailoverError
+	if errors.As(err, &failoverErr) {
+		return failoverErr.StatusCode, strings.TrimSpace(string(failoverErr.ResponseBody))
+	}
+	if c == nil {
+		return 0, ""
+	}
+	if v, ok := c.Get(OpsUpstreamStatusCodeKey); ok {
+		if code, ok := v.(int); ok {
+			statusCode = code
+		}
+	}
+	for _, key := range []string{OpsUpstreamErrorDetailKey, OpsUpstreamErrorMessageKey} {
+		if v, ok := c.Get(key); ok {
+			if msg, ok := v.(string); ok && strings.TrimSpace(msg) != "" {
+				return statusCode, strings.TrimSpace(msg)
+			}
+		}
+	}
+	return statusCode, ""
+}
+
 func extractResponsePreview(w *limitedResponseWriter) (preview string, truncated bool) {
 	if w == nil {
 		return "", false
@@ -7,6 +7,8 @@ import (
 	"errors"
 	"log"
 	"strings"
+	"sync"
+	"sync/atomic"
 	"time"
 
 	"github.com/Wei-Shaw/sub2api/internal/config"
@@ -25,6 +27,7 @@ type OpsService struct {
 	opsRepo     OpsRepository
 	settingRepo SettingRepository
 	cfg         *config.Config
+	bodyCapture *OpsBodyCapture
 
 	accountRepo AccountRepository
 	userRepo    UserRepository
@@ -37,6 +40,23 @@ type OpsService struct {
 	openAIGatewayService      *OpenAIGatewayService
 	geminiCompatService       *GeminiMessagesCompatService
 	antigravityGatewayService *AntigravityGatewayService
+	streamAbuseService        *StreamAbuseService
+	usageAnomalyService       *UsageAnomalyService
+
+	// referenceDiffLastRun 上次参考对比的 Unix 纳秒时间（用于 min_interval 限制）
+	referenceDiffLastRun atomic.Int64
+
+	// sloMu 串行化 SLO 定义的读改写
+	sloMu sync.Mutex
+
+	// slowRequestInflight 进行中的慢请求异步写入数
+	slowRequestInflight atomic.Int64
+
+	// requestPhases 分组请求阶段耗时聚合（可为 nil）
+	requestPhases *OpsRequestPhaseService
+
+	// accountStats 按账号的滚动吞吐/错误计数（进程内）
+	accountStats *OpsAccountStatsTracker
 }
 
 func NewOpsService(
@@ -50,11 +70,18 @@ func NewOpsService(
 	openAIGatewayService *OpenAIGatewayService,
 	geminiCompatService *GeminiMessagesCompatService,
 	antigravityGatewayService *AntigravityGatewayService,
+	streamAbuseService *StreamAbuseService,
+	usageAnomalyService *UsageAnomalyService,
 ) *OpsService {
+	var bodyCapture *OpsBodyCapture
+	if cfg != nil {
+		bodyCapture = NewOpsBodyCapture(&cfg.Ops.BodyCapture)
+	}
 	return &OpsService{
 		opsRepo:     opsRepo,
 		settingRepo: settingRepo,
 		cfg:         cfg,
+		bodyCapture: bodyCapture,
 
 		accountRepo: accountRepo,
 		userRepo:    userRepo,
@@ -64,7 +91,27 @@ func NewOpsService(
 		openAIGatewayService:      openAIGatewayService,
 		geminiCompatService:       geminiCompatService,
 		antigravityGatewayService: antigravityGatewayService,
+		streamAbuseService:        streamAbuseService,
+		usageAnomalyService:       usageAnomalyService,
+
+		accountStats: NewOpsAccountStatsTracker(),
+	}
+}
+
+// MemoryGuard 返回网关内存护栏，用于登记待写入错误日志中捕获的请求体（未启用时为 nil）
+func (s *OpsService) MemoryGuard() *MemoryGuard {
+	if s == nil || s.gatewayService == nil {
+		return nil
 	}
+	return s.gatewayService.MemoryGuard()
+}
+
+// UsageAnomaly 返回用量异常检测服务，供网关中间件记录请求（未注入时为 nil）
+func (s *OpsService) UsageAnomaly() *UsageAnomalyService {
+	if s == nil {
+		return nil
+	}
+	return s.usageAnomalyService
 }
 
 func (s *OpsService) RequireMonitoringEnabled(ctx context.Context) error {
@@ -131,10 +178,19 @@ func (s *OpsService) RecordError(ctx context.Context, entry *OpsInsertErrorLogIn
 		if sanitized != "" {
 			entry.RequestBodyJSON = &sanitized
 		}
+		// 请求体在网关捕获时已被截断/哈希：保留调用方记录的原始大小
+		if entry.RequestBodyBytes != nil && *entry.RequestBodyBytes > bytesLen {
+			truncated = true
+		} else {
+			entry.RequestBodyBytes = &bytesLen
+		}
 		entry.RequestBodyTruncated = truncated
-		entry.RequestBodyBytes = &bytesLen
 	}
 
+	// 错误消息可能回显提示词内容
+	entry.ErrorMessage = redactPII(entry.ErrorMessage)
+	entry.UserAgent = redactPII(entry.UserAgent)
+
 	// Sanitize + truncate error_body to avoid storing sensitive data.
 	if strings.TrimSpace(entry.ErrorBody) != "" {
 		sanitized, _ := sanitizeErrorBodyForStorage(entry.ErrorBody, opsMaxStoredErrorBodyBytes)
@@ -147,7 +203,7 @@ func (s *OpsService) RecordError(ctx context.Context, entry *OpsInsertErrorLogIn
 	}
 	if entry.UpstreamErrorMessage != nil {
 		msg := strings.TrimSpace(*entry.UpstreamErrorMessage)
-		msg = sanitizeUpstreamErrorMessage(msg)
+		msg = redactPII(sanitizeUpstreamErrorMessage(msg))
 		msg = truncateString(msg, 2048)
 		if strings.TrimSpace(msg) == "" {
 			entry.UpstreamErrorMessage = nil
@@ -198,7 +254,7 @@ func (s *OpsService) RecordError(ctx context.Context, entry *OpsInsertErrorLogIn
 				out.AtUnixMs = 0
 			}
 
-			msg := sanitizeUpstreamErrorMessage(strings.TrimSpace(out.Message))
+			msg := redactPII(sanitizeUpstreamErrorMessage(strings.TrimSpace(out.Message)))
 			msg = truncateString(msg, 2048)
 			out.Message = msg
 
@@ -336,7 +392,7 @@ func sanitizeAndTrimRequestBody(raw []byte, maxBytes int) (jsonString string, tr
 		return "", false, bytesLen
 	}
 
-	decoded = redactSensitiveJSON(decoded)
+	decoded = redactPIIValue(redactSensitiveJSON(decoded))
 
 	encoded, err := json.Marshal(decoded)
 	if err != nil {
@@ -639,7 +695,8 @@ func sanitizeErrorBodyForStorage(raw string, maxBytes int) (sanitized string, tr
 		return out, trunc
 	}
 
-	// Non-JSON: best-effort truncate.
+	// Non-JSON: best-effort redact + truncate.
+	raw = redactPII(raw)
 	if maxBytes > 0 && len(raw) > maxBytes {
 		return truncateString(raw, maxBytes), true
 	}
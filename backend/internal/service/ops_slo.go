@@ -0,0 +1,434 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"math"
+	"strings"
+	"time"
+
+	infraerrors "github.com/Wei-Shaw/sub2api/internal/pkg/errors"
+)
+
+// SLO 类型
+const (
+	// OpsSLOKindAvailability 成功率目标：成功请求 / (成功请求 + 计入 SLA 的错误请求)
+	OpsSLOKindAvailability = "availability"
+	// OpsSLOKindTTFT 首字延迟目标：首 token 耗时不超过阈值的请求占比
+	OpsSLOKindTTFT = "ttft"
+)
+
+const (
+	opsSLODefaultWindowDays = 30
+	opsSLOMaxWindowDays     = 90
+	opsSLOMaxDefinitions    = 100
+)
+
+// opsSLOBurnRateWindowsMinutes 状态接口附带的燃烧速率窗口（1h 快速 / 6h 慢速）
+var opsSLOBurnRateWindowsMinutes = []int{60, 360}
+
+// OpsSLODefinition 单条 SLO 定义（以 JSON 形式存储于 settings 表）
+type OpsSLODefinition struct {
+	ID      int64  `json:"id"`
+	Name    string `json:"name"`
+	Enabled bool   `json:"enabled"`
+
+	// GroupID 为空表示全局；Platform 可选，进一步限定平台
+	GroupID  *int64 `json:"group_id,omitempty"`
+	Platform string `json:"platform,omitempty"`
+
+	Kind          string  `json:"kind"`
+	TargetPercent float64 `json:"target_percent"`
+	// TTFTThresholdMs 仅 kind=ttft 时使用
+	TTFTThresholdMs int `json:"ttft_threshold_ms,omitempty"`
+	// WindowDays 滚动合规窗口（天）
+	WindowDays int `json:"window_days"`
+
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+// OpsSLOSettings SLO 定义集合
+type OpsSLOSettings struct {
+	NextID int64              `json:"next_id"`
+	SLOs   []OpsSLODefinition `json:"slos"`
+}
+
+// OpsSLOCounts SLO 计算所需的原始计数
+type OpsSLOCounts struct {
+	SuccessCount        int64
+	ErrorCountSLA       int64
+	TTFTCount           int64
+	TTFTWithinThreshold int64
+}
+
+// OpsSLOBurnRate 短窗口内的错误预算燃烧速率（1 表示按此速度恰好在 SLO 窗口结束时耗尽预算）
+type OpsSLOBurnRate struct {
+	WindowMinutes int      `json:"window_minutes"`
+	TotalRequests int64    `json:"total_requests"`
+	BadRequests   int64    `json:"bad_requests"`
+	BurnRate      *float64 `json:"burn_rate"`
+}
+
+// OpsSLOStatus SLO 滚动合规情况与剩余错误预算
+type OpsSLOStatus struct {
+	SLO         *OpsSLODefinition `json:"slo"`
+	WindowStart time.Time         `json:"window_start"`
+	WindowEnd   time.Time         `json:"window_end"`
+
+	TotalRequests int64 `json:"total_requests"`
+	GoodRequests  int64 `json:"good_requests"`
+	BadRequests   int64 `json:"bad_requests"`
+
+	CompliancePercent *float64 `json:"compliance_percent"`
+	Met               bool     `json:"met"`
+
+	// ErrorBudgetAllowed 窗口内允许的失败请求数
+	ErrorBudgetAllowed          float64  `json:"error_budget_allowed"`
+	ErrorBudgetConsumedPercent  *float64 `json:"error_budget_consumed_percent"`
+	ErrorBudgetRemainingPercent *float64 `json:"error_budget_remaining_percent"`
+
+	BurnRates []OpsSLOBurnRate `json:"burn_rates"`
+}
+
+func normalizeOpsSLODefinition(def *OpsSLODefinition) {
+	def.Name = strings.TrimSpace(def.Name)
+	def.Platform = strings.ToLower(strings.TrimSpace(def.Platform))
+	def.Kind = strings.ToLower(strings.TrimSpace(def.Kind))
+	if def.GroupID != nil && *def.GroupID <= 0 {
+		def.GroupID = nil
+	}
+	if def.WindowDays <= 0 {
+		def.WindowDays = opsSLODefaultWindowDays
+	}
+	if def.Kind != OpsSLOKindTTFT {
+		def.TTFTThresholdMs = 0
+	}
+}
+
+func validateOpsSLODefinition(def *OpsSLODefinition) error {
+	if def.Name == "" {
+		return infraerrors.BadRequest("INVALID_SLO", "name is required")
+	}
+	switch def.Kind {
+	case OpsSLOKindAvailability:
+	case OpsSLOKindTTFT:
+		if def.TTFTThresholdMs <= 0 {
+			return infraerrors.BadRequest("INVALID_SLO", "ttft_threshold_ms must be > 0 for kind ttft")
+		}
+	default:
+		return infraerrors.BadRequest("INVALID_SLO", "kind must be one of: availability, ttft")
+	}
+	if math.IsNaN(def.TargetPercent) || def.TargetPercent <= 0 || def.TargetPercent >= 100 {
+		return infraerrors.BadRequest("INVALID_SLO", "target_percent must be between 0 and 100 (exclusive)")
+	}
+	if def.WindowDays > opsSLOMaxWindowDays {
+		return infraerrors.BadRequest("INVALID_SLO", "window_days must be between 1 and 90")
+	}
+	return nil
+}
+
+func (s *OpsService) loadSLOSettings(ctx context.Context) (*OpsSLOSettings, error) {
+	cfg := &OpsSLOSettings{SLOs: []OpsSLODefinition{}}
+	if s == nil || s.settingRepo == nil {
+		return cfg, nil
+	}
+	raw, err := s.settingRepo.GetValue(ctx, SettingKeyOpsSLOSettings)
+	if err != nil {
+		if errors.Is(err, ErrSettingNotFound) {
+			return cfg, nil
+		}
+		return nil, err
+	}
+	if err := json.Unmarshal([]byte(raw), cfg); err != nil {
+		return nil, err
+	}
+	if cfg.SLOs == nil {
+		cfg.SLOs = []OpsSLODefinition{}
+	}
+	for _, def := range cfg.SLOs {
+		if def.ID >= cfg.NextID {
+			cfg.NextID = def.ID + 1
+		}
+	}
+	if cfg.NextID <= 0 {
+		cfg.NextID = 1
+	}
+	return cfg, nil
+}
+
+func (s *OpsService) saveSLOSettings(ctx context.Context, cfg *OpsSLOSettings) error {
+	raw, err := json.Marshal(cfg)
+	if err != nil {
+		return err
+	}
+	return s.settingRepo.Set(ctx, SettingKeyOpsSLOSettings, string(raw))
+}
+
+// ListSLOs 返回所有 SLO 定义
+func (s *OpsService) ListSLOs(ctx context.Context) ([]OpsSLODefinition, error) {
+	if err := s.RequireMonitoringEnabled(ctx); err != nil {
+		return nil, err
+	}
+	cfg, err := s.loadSLOSettings(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return cfg.SLOs, nil
+}
+
+// GetSLO 按 ID 获取 SLO 定义
+func (s *OpsService) GetSLO(ctx context.Context, id int64) (*OpsSLODefinition, error) {
+	if err := s.RequireMonitoringEnabled(ctx); err != nil {
+		return nil, err
+	}
+	cfg, err := s.loadSLOSettings(ctx)
+	if err != nil {
+		return nil, err
+	}
+	for i := range cfg.SLOs {
+		if cfg.SLOs[i].ID == id {
+			def := cfg.SLOs[i]
+			return &def, nil
+		}
+	}
+	return nil, infraerrors.NotFound("OPS_SLO_NOT_FOUND", "slo not found")
+}
+
+// CreateSLO 新建 SLO 定义
+func (s *OpsService) CreateSLO(ctx context.Context, def *OpsSLODefinition) (*OpsSLODefinition, error) {
+	if err := s.RequireMonitoringEnabled(ctx); err != nil {
+		return nil, err
+	}
+	if s.settingRepo == nil {
+		return nil, errors.New("setting repository not initialized")
+	}
+	if def == nil {
+		return nil, infraerrors.BadRequest("INVALID_SLO", "invalid slo")
+	}
+	normalizeOpsSLODefinition(def)
+	if err := validateOpsSLODefinition(def); err != nil {
+		return nil, err
+	}
+
+	s.sloMu.Lock()
+	defer s.sloMu.Unlock()
+
+	cfg, err := s.loadSLOSettings(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if len(cfg.SLOs) >= opsSLOMaxDefinitions {
+		return nil, infraerrors.BadRequest("OPS_SLO_LIMIT", "too many slo definitions")
+	}
+	now := time.Now().UTC()
+	def.ID = cfg.NextID
+	def.CreatedAt = now
+	def.UpdatedAt = now
+	cfg.NextID++
+	cfg.SLOs = append(cfg.SLOs, *def)
+	if err := s.saveSLOSettings(ctx, cfg); err != nil {
+		return nil, err
+	}
+	return def, nil
+}
+
+// UpdateSLO 更新 SLO 定义（整体替换，保留 ID 与创建时间）
+func (s *OpsService) UpdateSLO(ctx context.Context, id int64, def *OpsSLODefinition) (*OpsSLODefinition, error) {
+	if err := s.RequireMonitoringEnabled(ctx); err != nil {
+		return nil, err
+	}
+	if s.settingRepo == nil {
+		return nil, errors.New("setting repository not initialized")
+	}
+	if def == nil {
+		return nil, infraerrors.BadRequest("INVALID_SLO", "invalid slo")
+	}
+	normalizeOpsSLODefinition(def)
+	if err := validateOpsSLODefinition(def); err != nil {
+		return nil, err
+	}
+
+	s.sloMu.Lock()
+	defer s.sloMu.Unlock()
+
+	cfg, err := s.loadSLOSettings(ctx)
+	if err != nil {
+		return nil, err
+	}
+	for i := range cfg.SLOs {
+		if cfg.SLOs[i].ID != id {
+			continue
+		}
+		def.ID = id
+		def.CreatedAt = cfg.SLOs[i].CreatedAt
+		def.UpdatedAt = time.Now().UTC()
+		cfg.SLOs[i] = *def
+		if err := s.saveSLOSettings(ctx, cfg); err != nil {
+			return nil, err
+		}
+		return def, nil
+	}
+	return nil, infraerrors.NotFound("OPS_SLO_NOT_FOUND", "slo not found")
+}
+
+// DeleteSLO 删除 SLO 定义
+func (s *OpsService) DeleteSLO(ctx context.Context, id int64) error {
+	if err := s.RequireMonitoringEnabled(ctx); err != nil {
+		return err
+	}
+	if s.settingRepo == nil {
+		return errors.New("setting repository not initialized")
+	}
+
+	s.sloMu.Lock()
+	defer s.sloMu.Unlock()
+
+	cfg, err := s.loadSLOSettings(ctx)
+	if err != nil {
+		return err
+	}
+	for i := range cfg.SLOs {
+		if cfg.SLOs[i].ID == id {
+			cfg.SLOs = append(cfg.SLOs[:i], cfg.SLOs[i+1:]...)
+			return s.saveSLOSettings(ctx, cfg)
+		}
+	}
+	return infraerrors.NotFound("OPS_SLO_NOT_FOUND", "slo not found")
+}
+
+// GetSLOStatuses 计算所有 SLO 的滚动合规情况
+func (s *OpsService) GetSLOStatuses(ctx context.Context) ([]*OpsSLOStatus, error) {
+	defs, err := s.ListSLOs(ctx)
+	if err != nil {
+		return nil, err
+	}
+	now := time.Now().UTC()
+	out := make([]*OpsSLOStatus, 0, len(defs))
+	for i := range defs {
+		status, err := s.computeSLOStatus(ctx, &defs[i], now)
+		if err != nil {
+			return nil, err
+		}
+		out = append(out, status)
+	}
+	return out, nil
+}
+
+// GetSLOStatus 计算单个 SLO 的滚动合规情况
+func (s *OpsService) GetSLOStatus(ctx context.Context, id int64) (*OpsSLOStatus, error) {
+	def, err := s.GetSLO(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	return s.computeSLOStatus(ctx, def, time.Now().UTC())
+}
+
+func (s *OpsService) querySLOCounts(ctx context.Context, def *OpsSLODefinition, start, end time.Time) (*OpsSLOCounts, error) {
+	if s.opsRepo == nil {
+		return nil, infraerrors.ServiceUnavailable("OPS_REPO_UNAVAILABLE", "Ops repository not available")
+	}
+	return s.opsRepo.GetSLOCounts(ctx, &OpsDashboardFilter{
+		StartTime: start,
+		EndTime:   end,
+		Platform:  def.Platform,
+		GroupID:   def.GroupID,
+		QueryMode: OpsQueryModeRaw,
+	}, def.TTFTThresholdMs)
+}
+
+func (s *OpsService) computeSLOStatus(ctx context.Context, def *OpsSLODefinition, now time.Time) (*OpsSLOStatus, error) {
+	end := now.Truncate(time.Minute)
+	start := end.Add(-time.Duration(def.WindowDays) * 24 * time.Hour)
+	counts, err := s.querySLOCounts(ctx, def, start, end)
+	if err != nil {
+		return nil, err
+	}
+	status := buildOpsSLOStatus(def, counts, start, end)
+
+	for _, minutes := range opsSLOBurnRateWindowsMinutes {
+		burnCounts, err := s.querySLOCounts(ctx, def, end.Add(-time.Duration(minutes)*time.Minute), end)
+		if err != nil {
+			return nil, err
+		}
+		good, total := opsSLOGoodAndTotal(def.Kind, burnCounts)
+		status.BurnRates = append(status.BurnRates, OpsSLOBurnRate{
+			WindowMinutes: minutes,
+			TotalRequests: total,
+			BadRequests:   total - good,
+			BurnRate:      opsSLOBurnRate(def.TargetPercent, good, total),
+		})
+	}
+	return status, nil
+}
+
+// computeSLOBurnRate 计算 [start, end) 内的燃烧速率；窗口内无请求时返回 nil
+func (s *OpsService) computeSLOBurnRate(ctx context.Context, def *OpsSLODefinition, start, end time.Time) (*float64, error) {
+	counts, err := s.querySLOCounts(ctx, def, start, end)
+	if err != nil {
+		return nil, err
+	}
+	good, total := opsSLOGoodAndTotal(def.Kind, counts)
+	return opsSLOBurnRate(def.TargetPercent, good, total), nil
+}
+
+func opsSLOGoodAndTotal(kind string, counts *OpsSLOCounts) (good int64, total int64) {
+	if counts == nil {
+		return 0, 0
+	}
+	if kind == OpsSLOKindTTFT {
+		return counts.TTFTWithinThreshold, counts.TTFTCount
+	}
+	return counts.SuccessCount, counts.SuccessCount + counts.ErrorCountSLA
+}
+
+// opsSLOBurnRate 燃烧速率 = 实际失败率 / 允许失败率
+func opsSLOBurnRate(targetPercent float64, good, total int64) *float64 {
+	budget := 1 - targetPercent/100
+	if total <= 0 || budget <= 0 {
+		return nil
+	}
+	v := roundOpsSLOValue(float64(total-good) / float64(total) / budget)
+	return &v
+}
+
+func buildOpsSLOStatus(def *OpsSLODefinition, counts *OpsSLOCounts, start, end time.Time) *OpsSLOStatus {
+	good, total := opsSLOGoodAndTotal(def.Kind, counts)
+	status := &OpsSLOStatus{
+		SLO:           def,
+		WindowStart:   start,
+		WindowEnd:     end,
+		TotalRequests: total,
+		GoodRequests:  good,
+		BadRequests:   total - good,
+		Met:           true,
+		BurnRates:     []OpsSLOBurnRate{},
+	}
+	if total <= 0 {
+		remaining := 100.0
+		consumed := 0.0
+		status.ErrorBudgetRemainingPercent = &remaining
+		status.ErrorBudgetConsumedPercent = &consumed
+		return status
+	}
+
+	compliance := roundOpsSLOValue(float64(good) / float64(total) * 100)
+	status.CompliancePercent = &compliance
+	status.Met = float64(good)/float64(total)*100 >= def.TargetPercent
+
+	allowed := float64(total) * (1 - def.TargetPercent/100)
+	status.ErrorBudgetAllowed = roundOpsSLOValue(allowed)
+	if allowed > 0 {
+		consumed := roundOpsSLOValue(float64(status.BadRequests) / allowed * 100)
+		// 剩余预算可为负，表示已透支
+		remaining := roundOpsSLOValue(100 - float64(status.BadRequests)/allowed*100)
+		status.ErrorBudgetConsumedPercent = &consumed
+		status.ErrorBudgetRemainingPercent = &remaining
+	}
+	return status
+}
+
+func roundOpsSLOValue(v float64) float64 {
+	return math.Round(v*10000) / 10000
+}
@@ -0,0 +1,73 @@
+package service
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestBuildOpsSLOStatus_Availability(t *testing.T) {
+	def := &OpsSLODefinition{Kind: OpsSLOKindAvailability, TargetPercent: 99, WindowDays: 30}
+	end := time.Date(2026, 1, 31, 0, 0, 0, 0, time.UTC)
+	start := end.Add(-30 * 24 * time.Hour)
+
+	status := buildOpsSLOStatus(def, &OpsSLOCounts{SuccessCount: 995, ErrorCountSLA: 5}, start, end)
+	require.Equal(t, int64(1000), status.TotalRequests)
+	require.Equal(t, int64(5), status.BadRequests)
+	require.NotNil(t, status.CompliancePercent)
+	require.InDelta(t, 99.5, *status.CompliancePercent, 1e-9)
+	require.True(t, status.Met)
+	require.InDelta(t, 10, status.ErrorBudgetAllowed, 1e-9)
+	require.InDelta(t, 50, *status.ErrorBudgetConsumedPercent, 1e-9)
+	require.InDelta(t, 50, *status.ErrorBudgetRemainingPercent, 1e-9)
+
+	// 超出预算时剩余预算为负
+	status = buildOpsSLOStatus(def, &OpsSLOCounts{SuccessCount: 970, ErrorCountSLA: 30}, start, end)
+	require.False(t, status.Met)
+	require.InDelta(t, -200, *status.ErrorBudgetRemainingPercent, 1e-9)
+}
+
+func TestBuildOpsSLOStatus_TTFTAndEmptyWindow(t *testing.T) {
+	def := &OpsSLODefinition{Kind: OpsSLOKindTTFT, TargetPercent: 90, TTFTThresholdMs: 3000, WindowDays: 7}
+
+	status := buildOpsSLOStatus(def, &OpsSLOCounts{SuccessCount: 500, ErrorCountSLA: 100, TTFTCount: 200, TTFTWithinThreshold: 190}, time.Time{}, time.Time{})
+	require.Equal(t, int64(200), status.TotalRequests)
+	require.InDelta(t, 95, *status.CompliancePercent, 1e-9)
+	require.InDelta(t, 50, *status.ErrorBudgetRemainingPercent, 1e-9)
+
+	status = buildOpsSLOStatus(def, &OpsSLOCounts{}, time.Time{}, time.Time{})
+	require.True(t, status.Met)
+	require.Nil(t, status.CompliancePercent)
+	require.InDelta(t, 100, *status.ErrorBudgetRemainingPercent, 1e-9)
+}
+
+func TestOpsSLOBurnRate(t *testing.T) {
+	// 1% 失败率对 99.9% 目标 => 燃烧速率 10
+	burn := opsSLOBurnRate(99.9, 990, 1000)
+	require.NotNil(t, burn)
+	require.InDelta(t, 10, *burn, 1e-9)
+
+	require.Nil(t, opsSLOBurnRate(99.9, 0, 0))
+	require.InDelta(t, 0, *opsSLOBurnRate(99, 10, 10), 1e-9)
+}
+
+func TestValidateOpsSLODefinition(t *testing.T) {
+	valid := &OpsSLODefinition{Name: " api ", Kind: "Availability", TargetPercent: 99.5}
+	normalizeOpsSLODefinition(valid)
+	require.NoError(t, validateOpsSLODefinition(valid))
+	require.Equal(t, "api", valid.Name)
+	require.Equal(t, opsSLODefaultWindowDays, valid.WindowDays)
+
+	cases := []*OpsSLODefinition{
+		{Name: "", Kind: OpsSLOKindAvailability, TargetPercent: 99},
+		{Name: "x", Kind: "latency", TargetPercent: 99},
+		{Name: "x", Kind: OpsSLOKindAvailability, TargetPercent: 100},
+		{Name: "x", Kind: OpsSLOKindTTFT, TargetPercent: 99},
+		{Name: "x", Kind: OpsSLOKindAvailability, TargetPercent: 99, WindowDays: 365},
+	}
+	for _, def := range cases {
+		normalizeOpsSLODefinition(def)
+		require.Error(t, validateOpsSLODefinition(def))
+	}
+}
@@ -0,0 +1,243 @@
+package service
+
+import (
+	"context"
+	"log"
+	"time"
+
+	"github.com/Wei-Shaw/sub2api/internal/config"
+)
+
+// 慢请求命中原因
+const (
+	OpsSlowRequestReasonDuration = "duration"
+	OpsSlowRequestReasonTTFT     = "ttft"
+)
+
+const (
+	// opsSlowRequestMaxInflight 同时进行中的慢请求写入上限，超出时丢弃（避免上游整体变慢时压垮数据库）
+	opsSlowRequestMaxInflight  = 64
+	opsSlowRequestWriteTimeout = 5 * time.Second
+	// opsSlowRequestSummaryLimit 汇总接口返回的账号/模型组合上限
+	opsSlowRequestSummaryLimit = 50
+)
+
+// OpsSlowRequest 慢请求记录
+type OpsSlowRequest struct {
+	ID        int64     `json:"id"`
+	CreatedAt time.Time `json:"created_at"`
+	RequestID string    `json:"request_id"`
+
+	UserID      *int64 `json:"user_id,omitempty"`
+	APIKeyID    *int64 `json:"api_key_id,omitempty"`
+	AccountID   *int64 `json:"account_id,omitempty"`
+	AccountName string `json:"account_name,omitempty"`
+	GroupID     *int64 `json:"group_id,omitempty"`
+
+	Platform    string `json:"platform"`
+	Model       string `json:"model"`
+	RequestPath string `json:"request_path"`
+	Stream      bool   `json:"stream"`
+	StatusCode  int    `json:"status_code"`
+
+	DurationMs   int  `json:"duration_ms"`
+	FirstTokenMs *int `json:"first_token_ms,omitempty"`
+	// RetryCount 本次请求内的上游重试/切换账号次数
+	RetryCount int      `json:"retry_count"`
+	Reasons    []string `json:"reasons"`
+}
+
+// OpsInsertSlowRequestInput 慢请求写入参数
+type OpsInsertSlowRequestInput struct {
+	RequestID string
+
+	UserID    *int64
+	APIKeyID  *int64
+	AccountID *int64
+	GroupID   *int64
+
+	Platform    string
+	Model       string
+	RequestPath string
+	Stream      bool
+	StatusCode  int
+
+	DurationMs   int
+	FirstTokenMs *int
+	RetryCount   int
+	Reasons      []string
+
+	CreatedAt time.Time
+}
+
+// OpsSlowRequestFilter 慢请求查询条件
+type OpsSlowRequestFilter struct {
+	StartTime *time.Time
+	EndTime   *time.Time
+
+	Platform  string
+	GroupID   *int64
+	AccountID *int64
+	Model     string
+	// Reason duration / ttft，为空表示不限
+	Reason string
+
+	Page     int
+	PageSize int
+}
+
+// Normalize 填充默认分页（50 条，最多 100 条）与时间窗口（最近 1 小时）
+func (f *OpsSlowRequestFilter) Normalize() (page, pageSize int, startTime, endTime time.Time) {
+	detail := &OpsRequestDetailFilter{}
+	if f != nil {
+		detail.StartTime = f.StartTime
+		detail.EndTime = f.EndTime
+		detail.Page = f.Page
+		detail.PageSize = f.PageSize
+	}
+	return detail.Normalize()
+}
+
+// OpsSlowRequestList 慢请求分页结果
+type OpsSlowRequestList struct {
+	Items    []*OpsSlowRequest `json:"items"`
+	Total    int64             `json:"total"`
+	Page     int               `json:"page"`
+	PageSize int               `json:"page_size"`
+}
+
+// OpsSlowRequestSummary 按账号 + 模型聚合的慢请求统计，用于快速定位退化的上游
+type OpsSlowRequestSummary struct {
+	AccountID   *int64 `json:"account_id,omitempty"`
+	AccountName string `json:"account_name,omitempty"`
+	Platform    string `json:"platform"`
+	Model       string `json:"model"`
+
+	Count           int64     `json:"count"`
+	TTFTCount       int64     `json:"ttft_count"`
+	AvgDurationMs   int       `json:"avg_duration_ms"`
+	MaxDurationMs   int       `json:"max_duration_ms"`
+	AvgFirstTokenMs *int      `json:"avg_first_token_ms,omitempty"`
+	AvgRetryCount   float64   `json:"avg_retry_count"`
+	LastSeenAt      time.Time `json:"last_seen_at"`
+}
+
+// detectOpsSlowRequest 按阈值判定请求是否过慢，返回命中原因（未启用或未命中时为空）
+func detectOpsSlowRequest(cfg config.OpsSlowRequestConfig, stream bool, durationMs int64, firstTokenMs *int) []string {
+	if !cfg.Enabled {
+		return nil
+	}
+	var reasons []string
+	durationThreshold := cfg.DurationThresholdMs
+	if stream {
+		durationThreshold = cfg.StreamDurationThresholdMs
+	}
+	if durationThreshold > 0 && durationMs >= int64(durationThreshold) {
+		reasons = append(reasons, OpsSlowRequestReasonDuration)
+	}
+	if cfg.TTFTThresholdMs > 0 && firstTokenMs != nil && *firstTokenMs >= cfg.TTFTThresholdMs {
+		reasons = append(reasons, OpsSlowRequestReasonTTFT)
+	}
+	return reasons
+}
+
+// DetectSlowRequest 按 ops.slow_request 阈值判定请求是否过慢
+func (s *OpsService) DetectSlowRequest(stream bool, durationMs int64, firstTokenMs *int) []string {
+	if s == nil || s.cfg == nil {
+		return nil
+	}
+	return detectOpsSlowRequest(s.cfg.Ops.SlowRequest, stream, durationMs, firstTokenMs)
+}
+
+// RecordSlowRequest 异步写入慢请求记录（请求路径不等待数据库）；写入积压过多时丢弃
+func (s *OpsService) RecordSlowRequest(input *OpsInsertSlowRequestInput) {
+	if s == nil || s.opsRepo == nil || input == nil || len(input.Reasons) == 0 {
+		return
+	}
+	accountID := int64(0)
+	if input.AccountID != nil {
+		accountID = *input.AccountID
+	}
+	log.Printf("[OpsSlowRequest] request_id=%s platform=%s model=%s account=%d duration_ms=%d retries=%d reasons=%v",
+		input.RequestID, input.Platform, input.Model, accountID, input.DurationMs, input.RetryCount, input.Reasons)
+
+	if s.slowRequestInflight.Add(1) > opsSlowRequestMaxInflight {
+		s.slowRequestInflight.Add(-1)
+		return
+	}
+	if input.CreatedAt.IsZero() {
+		input.CreatedAt = time.Now().UTC()
+	}
+	input.RequestID = truncateString(input.RequestID, 64)
+	input.Platform = truncateString(input.Platform, 32)
+	input.Model = truncateString(input.Model, 128)
+	input.RequestPath = truncateString(input.RequestPath, 255)
+	go func() {
+		defer s.slowRequestInflight.Add(-1)
+		ctx, cancel := context.WithTimeout(context.Background(), opsSlowRequestWriteTimeout)
+		defer cancel()
+		if err := s.opsRepo.InsertSlowRequest(ctx, input); err != nil {
+			log.Printf("[OpsSlowRequest] insert failed: request_id=%s err=%v", input.RequestID, err)
+		}
+	}()
+}
+
+// ListSlowRequests 分页查询慢请求记录
+func (s *OpsService) ListSlowRequests(ctx context.Context, filter *OpsSlowRequestFilter) (*OpsSlowRequestList, error) {
+	if err := s.RequireMonitoringEnabled(ctx); err != nil {
+		return nil, err
+	}
+	page, pageSize, startTime, endTime := filter.Normalize()
+	if s.opsRepo == nil {
+		return &OpsSlowRequestList{Items: []*OpsSlowRequest{}, Page: page, PageSize: pageSize}, nil
+	}
+
+	filterCopy := &OpsSlowRequestFilter{}
+	if filter != nil {
+		*filterCopy = *filter
+	}
+	filterCopy.Page = page
+	filterCopy.PageSize = pageSize
+	filterCopy.StartTime = &startTime
+	filterCopy.EndTime = &endTime
+
+	items, total, err := s.opsRepo.ListSlowRequests(ctx, filterCopy)
+	if err != nil {
+		return nil, err
+	}
+	if items == nil {
+		items = []*OpsSlowRequest{}
+	}
+	return &OpsSlowRequestList{
+		Items:    items,
+		Total:    total,
+		Page:     page,
+		PageSize: pageSize,
+	}, nil
+}
+
+// GetSlowRequestSummary 按账号 + 模型聚合慢请求，按数量倒序
+func (s *OpsService) GetSlowRequestSummary(ctx context.Context, filter *OpsSlowRequestFilter) ([]*OpsSlowRequestSummary, error) {
+	if err := s.RequireMonitoringEnabled(ctx); err != nil {
+		return nil, err
+	}
+	if s.opsRepo == nil {
+		return []*OpsSlowRequestSummary{}, nil
+	}
+	_, _, startTime, endTime := filter.Normalize()
+	filterCopy := &OpsSlowRequestFilter{}
+	if filter != nil {
+		*filterCopy = *filter
+	}
+	filterCopy.StartTime = &startTime
+	filterCopy.EndTime = &endTime
+
+	items, err := s.opsRepo.GetSlowRequestSummary(ctx, filterCopy, opsSlowRequestSummaryLimit)
+	if err != nil {
+		return nil, err
+	}
+	if items == nil {
+		items = []*OpsSlowRequestSummary{}
+	}
+	return items, nil
+}
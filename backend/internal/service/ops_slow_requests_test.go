@@ -0,0 +1,44 @@
+package service
+
+import (
+	"testing"
+
+	"github.com/Wei-Shaw/sub2api/internal/config"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDetectOpsSlowRequest(t *testing.T) {
+	intPtr := func(v int) *int { return &v }
+	cfg := config.OpsSlowRequestConfig{
+		Enabled:             true,
+		DurationThresholdMs: 60000,
+		TTFTThresholdMs:     15000,
+	}
+
+	tests := []struct {
+		name         string
+		cfg          config.OpsSlowRequestConfig
+		stream       bool
+		durationMs   int64
+		firstTokenMs *int
+		want         []string
+	}{
+		{name: "disabled", cfg: config.OpsSlowRequestConfig{DurationThresholdMs: 1}, durationMs: 100},
+		{name: "fast", cfg: cfg, durationMs: 1000, firstTokenMs: intPtr(200)},
+		{name: "slow non-stream", cfg: cfg, durationMs: 60000, want: []string{OpsSlowRequestReasonDuration}},
+		{name: "stream duration ignored without stream threshold", cfg: cfg, stream: true, durationMs: 120000, firstTokenMs: intPtr(500)},
+		{name: "slow ttft", cfg: cfg, stream: true, durationMs: 20000, firstTokenMs: intPtr(15000), want: []string{OpsSlowRequestReasonTTFT}},
+		{
+			name:       "stream threshold",
+			cfg:        config.OpsSlowRequestConfig{Enabled: true, DurationThresholdMs: 60000, StreamDurationThresholdMs: 300000, TTFTThresholdMs: 15000},
+			stream:     true,
+			durationMs: 300000, firstTokenMs: intPtr(20000),
+			want: []string{OpsSlowRequestReasonDuration, OpsSlowRequestReasonTTFT},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			require.Equal(t, tt.want, detectOpsSlowRequest(tt.cfg, tt.stream, tt.durationMs, tt.firstTokenMs))
+		})
+	}
+}
@@ -0,0 +1,61 @@
+package service
+
+import (
+	"fmt"
+	"sync/atomic"
+
+	"github.com/Wei-Shaw/sub2api/internal/config"
+	"github.com/Wei-Shaw/sub2api/internal/pkg/piiredact"
+)
+
+// piiRedactor 进程级个人信息脱敏器，未启用时为 nil（所有 redactPII* 辅助函数原样返回）
+var piiRedactor atomic.Pointer[piiredact.Redactor]
+
+// InitPIIRedaction 根据配置初始化个人信息脱敏，需在写入任何日志/记录之前调用。
+// 返回的 Redactor 供调用方包装日志输出；未启用时返回 nil。
+func InitPIIRedaction(cfg *config.Config) (*piiredact.Redactor, error) {
+	if cfg == nil || !cfg.Security.PIIRedaction.Enabled {
+		piiRedactor.Store(nil)
+		return nil, nil
+	}
+	pii := cfg.Security.PIIRedaction
+	redactor, err := piiredact.New(piiredact.Options{
+		BuiltinPatterns: pii.BuiltinPatterns,
+		Patterns:        pii.Patterns,
+		Fields:          pii.Fields,
+		Replacement:     pii.Replacement,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("security.pii_redaction: %w", err)
+	}
+	piiRedactor.Store(redactor)
+	return redactor, nil
+}
+
+// redactPII 屏蔽文本中的个人信息
+func redactPII(s string) string {
+	return piiRedactor.Load().String(s)
+}
+
+// redactPIIPtr 屏蔽可选文本
+func redactPIIPtr(s *string) *string {
+	if s == nil {
+		return nil
+	}
+	out := redactPII(*s)
+	return &out
+}
+
+// redactPIIValue 屏蔽已解码 JSON 值中的个人信息与配置字段
+func redactPIIValue(v any) any {
+	return piiRedactor.Load().Value(v)
+}
+
+// redactUsageLogPII 使用记录落库前屏蔽客户端提供的文本字段
+func redactUsageLogPII(usageLog *UsageLog) {
+	if usageLog == nil || piiRedactor.Load() == nil {
+		return
+	}
+	usageLog.UserAgent = redactPIIPtr(usageLog.UserAgent)
+	usageLog.IPAddress = redactPIIPtr(usageLog.IPAddress)
+}
@@ -0,0 +1,126 @@
+package service
+
+import (
+	"context"
+
+	"github.com/Wei-Shaw/sub2api/internal/config"
+)
+
+// promptCacheWarmPrefix 预热缓存记录的会话 key 前缀（与粘性会话共用 GatewayCache 存储，按分组隔离）。
+// 与粘性绑定分开记录：粘性绑定在账号满载溢出时会被改写，预热记录只在账号成功响应后更新。
+const promptCacheWarmPrefix = "warm:"
+
+// 提示词缓存路由决策（sub2api_prompt_cache_routing_total 的 decision 标签）
+const (
+	// PromptCacheRouteWarm 选择了持有预热缓存的账号
+	PromptCacheRouteWarm = "warm"
+	// PromptCacheRouteOverloaded 预热账号满载或负载差距过大，改选其他账号
+	PromptCacheRouteOverloaded = "overloaded"
+	// PromptCacheRouteCold 会话没有预热账号
+	PromptCacheRouteCold = "cold"
+)
+
+func promptCacheWarmKey(sessionKey string) string {
+	return promptCacheWarmPrefix + sessionKey
+}
+
+// lookupWarmCacheAccount 查询会话当前持有预热缓存的账号，未启用或无记录时返回 0
+func lookupWarmCacheAccount(ctx context.Context, cache GatewayCache, cfg config.GatewaySchedulingConfig, groupID *int64, sessionKey string) int64 {
+	if !cfg.PromptCacheAffinity || cache == nil || sessionKey == "" {
+		return 0
+	}
+	accountID, err := cache.GetSessionAccountID(ctx, derefGroupID(groupID), promptCacheWarmKey(sessionKey))
+	if err != nil {
+		return 0
+	}
+	return accountID
+}
+
+// markPromptCacheWarm 记录账号已为会话建立预热缓存，返回此前的预热账号（无记录时为 0）
+func markPromptCacheWarm(ctx context.Context, cache GatewayCache, cfg config.GatewaySchedulingConfig, groupID *int64, sessionKey string, accountID int64) int64 {
+	if !cfg.PromptCacheAffinity || cache == nil || sessionKey == "" || accountID <= 0 {
+		return 0
+	}
+	previous := lookupWarmCacheAccount(ctx, cache, cfg, groupID, sessionKey)
+	_ = cache.SetSessionAccountID(ctx, derefGroupID(groupID), promptCacheWarmKey(sessionKey), accountID, cfg.PromptCacheWarmTTL)
+	return previous
+}
+
+// pickWarmCacheAccount 在负载感知候选中查找持有预热缓存的账号。
+// 只在同一层级/优先级内比较：预热账号负载率与同优先级最低负载率的差距不超过 maxLoadGap 时返回该账号，
+// 换到冷账号需要重新写入整段提示词缓存，适度的负载不均衡比缓存未命中更划算。
+func pickWarmCacheAccount(available []accountWithLoad, warmAccountID int64, maxLoadGap int) (*accountWithLoad, string) {
+	if warmAccountID <= 0 {
+		return nil, PromptCacheRouteCold
+	}
+	var warm *accountWithLoad
+	for i := range available {
+		if available[i].account.ID == warmAccountID {
+			warm = &available[i]
+			break
+		}
+	}
+	if warm == nil {
+		return nil, PromptCacheRouteOverloaded
+	}
+	minLoadRate := warm.loadInfo.LoadRate
+	for _, item := range available {
+		if compareAccountPriority(item.account, warm.account) < 0 {
+			// 存在更高优先级的可用账号，遵循管理员配置的优先级
+			return nil, PromptCacheRouteOverloaded
+		}
+		if compareAccountPriority(item.account, warm.account) == 0 && item.loadInfo.LoadRate < minLoadRate {
+			minLoadRate = item.loadInfo.LoadRate
+		}
+	}
+	if warm.loadInfo.LoadRate-minLoadRate > maxLoadGap {
+		return nil, PromptCacheRouteOverloaded
+	}
+	return warm, PromptCacheRouteWarm
+}
+
+// observePromptCacheUsage 记录会话请求的缓存读取 token，按是否落在此前的预热账号上区分
+func observePromptCacheUsage(platform string, groupID *int64, previousWarmAccountID, accountID int64, cacheReadTokens int) {
+	if cacheReadTokens <= 0 {
+		return
+	}
+	routing := PromptCacheRouteCold
+	if previousWarmAccountID > 0 && previousWarmAccountID == accountID {
+		routing = PromptCacheRouteWarm
+	}
+	gatewayPromptCacheReadTokensTotal.Add(float64(cacheReadTokens), platform, metricsGroupLabel(groupID), routing)
+}
+
+// MarkPromptCacheWarm 在转发成功后记录账号持有会话的预热缓存，并统计缓存命中节省的 token
+func (s *GatewayService) MarkPromptCacheWarm(ctx context.Context, groupID *int64, sessionKey string, account *Account, cacheReadTokens int) {
+	if account == nil {
+		return
+	}
+	previous := markPromptCacheWarm(ctx, s.cache, s.schedulingConfig(), groupID, sessionKey, account.ID)
+	if sessionKey != "" {
+		observePromptCacheUsage(account.Platform, groupID, previous, account.ID, cacheReadTokens)
+	}
+}
+
+// MarkPromptCacheWarm 在转发成功后记录账号持有会话的预热缓存，并统计缓存命中节省的 token
+func (s *OpenAIGatewayService) MarkPromptCacheWarm(ctx context.Context, groupID *int64, sessionHash string, account *Account, cacheReadTokens int) {
+	if account == nil || sessionHash == "" {
+		return
+	}
+	previous := markPromptCacheWarm(ctx, s.cache, s.schedulingConfig(), groupID, "openai:"+sessionHash, account.ID)
+	observePromptCacheUsage(account.Platform, groupID, previous, account.ID, cacheReadTokens)
+}
+
+// moveAccountWithLoadToFront 将指定账号移到候选列表首位，其余保持原有顺序
+func moveAccountWithLoadToFront(available []accountWithLoad, accountID int64) []accountWithLoad {
+	for i := range available {
+		if available[i].account.ID != accountID {
+			continue
+		}
+		item := available[i]
+		copy(available[1:i+1], available[:i])
+		available[0] = item
+		break
+	}
+	return available
+}
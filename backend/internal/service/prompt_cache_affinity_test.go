@@ -0,0 +1,73 @@
+//go:build unit
+
+package service
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/Wei-Shaw/sub2api/internal/config"
+	"github.com/stretchr/testify/require"
+)
+
+func warmTestCandidates() []accountWithLoad {
+	return []accountWithLoad{
+		{account: &Account{ID: 1, Priority: 1}, loadInfo: &AccountLoadInfo{AccountID: 1, LoadRate: 10}},
+		{account: &Account{ID: 2, Priority: 1}, loadInfo: &AccountLoadInfo{AccountID: 2, LoadRate: 45}},
+		{account: &Account{ID: 3, Priority: 2}, loadInfo: &AccountLoadInfo{AccountID: 3, LoadRate: 0}},
+	}
+}
+
+func TestPickWarmCacheAccount(t *testing.T) {
+	available := warmTestCandidates()
+
+	warm, decision := pickWarmCacheAccount(available, 2, 40)
+	require.Equal(t, PromptCacheRouteWarm, decision)
+	require.Equal(t, int64(2), warm.account.ID)
+
+	// 负载差距超过阈值时放弃预热账号
+	warm, decision = pickWarmCacheAccount(available, 2, 30)
+	require.Nil(t, warm)
+	require.Equal(t, PromptCacheRouteOverloaded, decision)
+
+	// 预热账号满载（不在候选中）
+	warm, decision = pickWarmCacheAccount(available, 9, 40)
+	require.Nil(t, warm)
+	require.Equal(t, PromptCacheRouteOverloaded, decision)
+
+	// 存在更高优先级的可用账号时不越级
+	warm, decision = pickWarmCacheAccount(available, 3, 100)
+	require.Nil(t, warm)
+	require.Equal(t, PromptCacheRouteOverloaded, decision)
+
+	warm, decision = pickWarmCacheAccount(available, 0, 40)
+	require.Nil(t, warm)
+	require.Equal(t, PromptCacheRouteCold, decision)
+}
+
+func TestMoveAccountWithLoadToFront(t *testing.T) {
+	available := moveAccountWithLoadToFront(warmTestCandidates(), 3)
+	ids := make([]int64, 0, len(available))
+	for _, item := range available {
+		ids = append(ids, item.account.ID)
+	}
+	require.Equal(t, []int64{3, 1, 2}, ids)
+}
+
+func TestMarkPromptCacheWarm(t *testing.T) {
+	cache := &stubGatewayCache{}
+	cfg := config.GatewaySchedulingConfig{PromptCacheAffinity: true, PromptCacheWarmTTL: time.Minute}
+	groupID := int64(5)
+	ctx := context.Background()
+
+	require.Zero(t, markPromptCacheWarm(ctx, cache, cfg, &groupID, "sess", 7))
+	require.Equal(t, int64(7), lookupWarmCacheAccount(ctx, cache, cfg, &groupID, "sess"))
+	require.Equal(t, int64(7), markPromptCacheWarm(ctx, cache, cfg, &groupID, "sess", 8))
+	// 预热记录与粘性绑定分开存储
+	_, stickyBound := cache.sessionBindings["sess"]
+	require.False(t, stickyBound)
+
+	cfg.PromptCacheAffinity = false
+	require.Zero(t, lookupWarmCacheAccount(ctx, cache, cfg, &groupID, "sess"))
+}
@@ -0,0 +1,219 @@
+package service
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/Wei-Shaw/sub2api/internal/domain"
+	infraerrors "github.com/Wei-Shaw/sub2api/internal/pkg/errors"
+	"github.com/Wei-Shaw/sub2api/internal/pkg/timezone"
+	"github.com/tidwall/gjson"
+	"github.com/tidwall/sjson"
+)
+
+type PromptTemplate = domain.PromptTemplate
+
+// maxPromptTemplatePartLen 前缀/后缀各自的最大字节数
+const maxPromptTemplatePartLen = 8 * 1024
+
+var ErrInvalidPromptTemplate = infraerrors.BadRequest("INVALID_PROMPT_TEMPLATE", "invalid prompt template")
+
+// promptTemplateVarPattern 匹配模板变量 {{name}}
+var promptTemplateVarPattern = regexp.MustCompile(`\{\{\s*([A-Za-z_]+)\s*\}\}`)
+
+// 支持的模板变量
+const (
+	promptTemplateVarDate     = "date"     // 当前日期（系统时区），如 2026-01-02
+	promptTemplateVarDatetime = "datetime" // 当前时间（系统时区，RFC3339）
+	promptTemplateVarUserID   = "user_id"
+	promptTemplateVarAPIKeyID = "api_key_id"
+	promptTemplateVarGroup    = "group" // 分组名称
+	promptTemplateVarModel    = "model" // 请求模型（别名/虚拟模型改写后）
+)
+
+var promptTemplateVars = map[string]struct{}{
+	promptTemplateVarDate:     {},
+	promptTemplateVarDatetime: {},
+	promptTemplateVarUserID:   {},
+	promptTemplateVarAPIKeyID: {},
+	promptTemplateVarGroup:    {},
+	promptTemplateVarModel:    {},
+}
+
+// NormalizePromptTemplate 清理并校验提示词模板：去除首尾空白、限制长度、拒绝未知模板变量
+func NormalizePromptTemplate(tpl PromptTemplate) (PromptTemplate, error) {
+	tpl.Prefix = strings.TrimSpace(tpl.Prefix)
+	tpl.Suffix = strings.TrimSpace(tpl.Suffix)
+	for name, part := range map[string]string{"prefix": tpl.Prefix, "suffix": tpl.Suffix} {
+		if len(part) > maxPromptTemplatePartLen {
+			return tpl, fmt.Errorf("%w: %s too long (max %d bytes)", ErrInvalidPromptTemplate, name, maxPromptTemplatePartLen)
+		}
+		for _, m := range promptTemplateVarPattern.FindAllStringSubmatch(part, -1) {
+			if _, ok := promptTemplateVars[strings.ToLower(m[1])]; !ok {
+				return tpl, fmt.Errorf("%w: unknown variable %q in %s", ErrInvalidPromptTemplate, m[1], name)
+			}
+		}
+	}
+	return tpl, nil
+}
+
+// renderPromptTemplate 渲染模板变量；未知变量原样保留
+func renderPromptTemplate(text string, apiKey *APIKey, model string, now time.Time) string {
+	if text == "" || !strings.Contains(text, "{{") {
+		return text
+	}
+	return promptTemplateVarPattern.ReplaceAllStringFunc(text, func(match string) string {
+		name := strings.ToLower(promptTemplateVarPattern.FindStringSubmatch(match)[1])
+		switch name {
+		case promptTemplateVarDate:
+			return now.Format("2006-01-02")
+		case promptTemplateVarDatetime:
+			return now.Format(time.RFC3339)
+		case promptTemplateVarUserID:
+			return strconv.FormatInt(apiKey.UserID, 10)
+		case promptTemplateVarAPIKeyID:
+			return strconv.FormatInt(apiKey.ID, 10)
+		case promptTemplateVarGroup:
+			if apiKey.Group != nil {
+				return apiKey.Group.Name
+			}
+			return ""
+		case promptTemplateVarModel:
+			return model
+		default:
+			return match
+		}
+	})
+}
+
+// ApplyPromptTemplate 按分组提示词模板包裹用户最新一条消息，返回改写后的请求体。
+// 仅改写最新的用户消息，历史消息保持不变（不影响上游前缀缓存）；无用户消息时原样返回。
+func ApplyPromptTemplate(apiKey *APIKey, body []byte, protocol, model string) ([]byte, error) {
+	if apiKey == nil || apiKey.Group == nil || apiKey.Group.PromptTemplate.IsEmpty() {
+		return body, nil
+	}
+	now := timezone.Now()
+	prefix := renderPromptTemplate(apiKey.Group.PromptTemplate.Prefix, apiKey, model, now)
+	suffix := renderPromptTemplate(apiKey.Group.PromptTemplate.Suffix, apiKey, model, now)
+
+	switch protocol {
+	case PlatformOpenAI:
+		return wrapOpenAIUserInput(body, prefix, suffix)
+	case PlatformAnthropic:
+		return wrapAnthropicUserMessage(body, prefix, suffix)
+	case PlatformGemini:
+		return wrapGeminiUserContent(body, prefix, suffix)
+	default:
+		return body, nil
+	}
+}
+
+// joinPromptText 以空行拼接非空片段
+func joinPromptText(parts ...string) string {
+	nonEmpty := make([]string, 0, len(parts))
+	for _, part := range parts {
+		if part != "" {
+			nonEmpty = append(nonEmpty, part)
+		}
+	}
+	return strings.Join(nonEmpty, "\n\n")
+}
+
+// lastUserIndex 返回数组中最后一个 role=user 的元素下标，不存在时返回 -1
+func lastUserIndex(items []gjson.Result) int {
+	for i := len(items) - 1; i >= 0; i-- {
+		if items[i].Get("role").String() == "user" {
+			return i
+		}
+	}
+	return -1
+}
+
+// wrapContentBlocks 在内容块数组首尾插入文本块；开头满足 leading 的块（如 tool_result）保持在最前
+func wrapContentBlocks(blocks []gjson.Result, newBlock func(text string) any, leading func(block gjson.Result) bool, prefix, suffix string) []any {
+	out := make([]any, 0, len(blocks)+2)
+	i := 0
+	for ; i < len(blocks) && leading != nil && leading(blocks[i]); i++ {
+		out = append(out, blocks[i].Value())
+	}
+	if prefix != "" {
+		out = append(out, newBlock(prefix))
+	}
+	for ; i < len(blocks); i++ {
+		out = append(out, blocks[i].Value())
+	}
+	if suffix != "" {
+		out = append(out, newBlock(suffix))
+	}
+	return out
+}
+
+// wrapAnthropicUserMessage 包裹 Messages 请求中最后一条 user 消息的 content（字符串或内容块数组）
+func wrapAnthropicUserMessage(body []byte, prefix, suffix string) ([]byte, error) {
+	idx := lastUserIndex(gjson.GetBytes(body, "messages").Array())
+	if idx < 0 {
+		return body, nil
+	}
+	path := fmt.Sprintf("messages.%d.content", idx)
+	content := gjson.GetBytes(body, path)
+	if !content.IsArray() {
+		return sjson.SetBytes(body, path, joinPromptText(prefix, content.String(), suffix))
+	}
+	blocks := wrapContentBlocks(content.Array(),
+		func(text string) any { return map[string]any{"type": "text", "text": text} },
+		func(block gjson.Result) bool { return block.Get("type").String() == "tool_result" },
+		prefix, suffix)
+	return sjson.SetBytes(body, path, blocks)
+}
+
+// wrapOpenAIUserInput 包裹 Responses 请求的 input（字符串或消息数组中最后一条 user 消息）
+func wrapOpenAIUserInput(body []byte, prefix, suffix string) ([]byte, error) {
+	input := gjson.GetBytes(body, "input")
+	if !input.Exists() {
+		return body, nil
+	}
+	if !input.IsArray() {
+		return sjson.SetBytes(body, "input", joinPromptText(prefix, input.String(), suffix))
+	}
+	idx := lastUserIndex(input.Array())
+	if idx < 0 {
+		return body, nil
+	}
+	path := fmt.Sprintf("input.%d.content", idx)
+	content := gjson.GetBytes(body, path)
+	if !content.IsArray() {
+		return sjson.SetBytes(body, path, joinPromptText(prefix, content.String(), suffix))
+	}
+	parts := wrapContentBlocks(content.Array(),
+		func(text string) any { return map[string]any{"type": "input_text", "text": text} },
+		nil, prefix, suffix)
+	return sjson.SetBytes(body, path, parts)
+}
+
+// wrapGeminiUserContent 包裹 generateContent 请求中最后一条 user content 的 parts；
+// 开头的 functionResponse 部分保持在最前
+func wrapGeminiUserContent(body []byte, prefix, suffix string) ([]byte, error) {
+	// Gemini 的 role 可省略（默认为 user）
+	contents := gjson.GetBytes(body, "contents").Array()
+	idx := -1
+	for i := len(contents) - 1; i >= 0; i-- {
+		if role := contents[i].Get("role").String(); role == "" || role == "user" {
+			idx = i
+			break
+		}
+	}
+	if idx < 0 {
+		return body, nil
+	}
+	path := fmt.Sprintf("contents.%d.parts", idx)
+	parts := wrapContentBlocks(gjson.GetBytes(body, path).Array(),
+		func(text string) any { return map[string]any{"text": text} },
+		func(part gjson.Result) bool {
+			return part.Get("functionResponse").Exists() || part.Get("function_response").Exists()
+		},
+		prefix, suffix)
+	return sjson.SetBytes(body, path, parts)
+}
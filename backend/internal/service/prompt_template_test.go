@@ -0,0 +1,84 @@
+//go:build unit
+
+package service
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	"github.com/tidwall/gjson"
+)
+
+func TestNormalizePromptTemplate(t *testing.T) {
+	tpl, err := NormalizePromptTemplate(PromptTemplate{Prefix: "  [{{date}}] ", Suffix: "\n{{ user_id }}\n"})
+	require.NoError(t, err)
+	require.Equal(t, PromptTemplate{Prefix: "[{{date}}]", Suffix: "{{ user_id }}"}, tpl)
+
+	_, err = NormalizePromptTemplate(PromptTemplate{Prefix: "{{password}}"})
+	require.ErrorIs(t, err, ErrInvalidPromptTemplate)
+
+	_, err = NormalizePromptTemplate(PromptTemplate{Suffix: strings.Repeat("a", maxPromptTemplatePartLen+1)})
+	require.ErrorIs(t, err, ErrInvalidPromptTemplate)
+}
+
+func TestRenderPromptTemplate(t *testing.T) {
+	apiKey := &APIKey{ID: 7, UserID: 42, Group: &Group{Name: "team-a"}}
+	now := time.Date(2026, 1, 2, 3, 4, 5, 0, time.UTC)
+
+	got := renderPromptTemplate("{{date}} {{datetime}} {{USER_ID}} {{api_key_id}} {{group}} {{model}} {{other}}", apiKey, "claude-sonnet-4-5", now)
+	require.Equal(t, "2026-01-02 2026-01-02T03:04:05Z 42 7 team-a claude-sonnet-4-5 {{other}}", got)
+}
+
+func TestApplyPromptTemplate_Anthropic(t *testing.T) {
+	apiKey := &APIKey{UserID: 42, Group: &Group{PromptTemplate: PromptTemplate{Prefix: "BANNER user={{user_id}}", Suffix: "END"}}}
+
+	// 字符串 content：仅包裹最后一条 user 消息
+	body := []byte(`{"messages":[{"role":"user","content":"first"},{"role":"assistant","content":"ok"},{"role":"user","content":"hello"}]}`)
+	out, err := ApplyPromptTemplate(apiKey, body, PlatformAnthropic, "claude")
+	require.NoError(t, err)
+	require.Equal(t, "first", gjson.GetBytes(out, "messages.0.content").String())
+	require.Equal(t, "BANNER user=42\n\nhello\n\nEND", gjson.GetBytes(out, "messages.2.content").String())
+
+	// 内容块数组：tool_result 保持在最前
+	body = []byte(`{"messages":[{"role":"user","content":[{"type":"tool_result","tool_use_id":"t1","content":"42"},{"type":"text","text":"next"}]}]}`)
+	out, err = ApplyPromptTemplate(apiKey, body, PlatformAnthropic, "claude")
+	require.NoError(t, err)
+	blocks := gjson.GetBytes(out, "messages.0.content").Array()
+	require.Len(t, blocks, 4)
+	require.Equal(t, "tool_result", blocks[0].Get("type").String())
+	require.Equal(t, "BANNER user=42", blocks[1].Get("text").String())
+	require.Equal(t, "next", blocks[2].Get("text").String())
+	require.Equal(t, "END", blocks[3].Get("text").String())
+}
+
+func TestApplyPromptTemplate_OpenAIAndGemini(t *testing.T) {
+	apiKey := &APIKey{Group: &Group{PromptTemplate: PromptTemplate{Prefix: "PRE"}}}
+
+	out, err := ApplyPromptTemplate(apiKey, []byte(`{"input":"hi"}`), PlatformOpenAI, "gpt-5.2")
+	require.NoError(t, err)
+	require.Equal(t, "PRE\n\nhi", gjson.GetBytes(out, "input").String())
+
+	out, err = ApplyPromptTemplate(apiKey, []byte(`{"input":[{"role":"user","content":[{"type":"input_text","text":"hi"}]}]}`), PlatformOpenAI, "gpt-5.2")
+	require.NoError(t, err)
+	parts := gjson.GetBytes(out, "input.0.content").Array()
+	require.Len(t, parts, 2)
+	require.Equal(t, "input_text", parts[0].Get("type").String())
+	require.Equal(t, "PRE", parts[0].Get("text").String())
+
+	// Gemini 省略 role 的 content 视为 user
+	out, err = ApplyPromptTemplate(apiKey, []byte(`{"contents":[{"parts":[{"text":"hi"}]}]}`), PlatformGemini, "gemini-2.5-pro")
+	require.NoError(t, err)
+	require.Equal(t, "PRE", gjson.GetBytes(out, "contents.0.parts.0.text").String())
+	require.Equal(t, "hi", gjson.GetBytes(out, "contents.0.parts.1.text").String())
+}
+
+func TestApplyPromptTemplate_NoTemplate(t *testing.T) {
+	body := []byte(`{"messages":[{"role":"user","content":"hello"}]}`)
+	for _, apiKey := range []*APIKey{nil, {}, {Group: &Group{}}} {
+		out, err := ApplyPromptTemplate(apiKey, body, PlatformAnthropic, "claude")
+		require.NoError(t, err)
+		require.Equal(t, string(body), string(out))
+	}
+}
@@ -0,0 +1,115 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"math"
+	"time"
+
+	"github.com/Wei-Shaw/sub2api/internal/config"
+	infraerrors "github.com/Wei-Shaw/sub2api/internal/pkg/errors"
+)
+
+var ErrQuotaRolloverNotFound = infraerrors.NotFound("QUOTA_ROLLOVER_NOT_FOUND", "quota rollover not found")
+
+// QuotaRollover 订阅月额度结转记录
+// 月窗口重置时把上期未用完的额度结转到新窗口，每个订阅只保留最近一次结转
+type QuotaRollover struct {
+	SubscriptionID int64
+	AmountUSD      float64
+	WindowStart    time.Time // 结转进入的月窗口起点
+	ExpiresAt      time.Time
+	UpdatedAt      time.Time
+}
+
+// ActiveAmount 返回 now 时刻仍有效的结转额度（过期后为 0）
+func (r *QuotaRollover) ActiveAmount(now time.Time) float64 {
+	if r == nil || r.AmountUSD <= 0 || !now.Before(r.ExpiresAt) {
+		return 0
+	}
+	return r.AmountUSD
+}
+
+// QuotaRolloverRepository 月额度结转持久化
+type QuotaRolloverRepository interface {
+	GetBySubscriptionID(ctx context.Context, subscriptionID int64) (*QuotaRollover, error)
+	Upsert(ctx context.Context, rollover *QuotaRollover) error
+}
+
+// QuotaRolloverService 订阅月额度结转服务
+type QuotaRolloverService struct {
+	repo QuotaRolloverRepository
+	cfg  config.QuotaRolloverConfig
+}
+
+// NewQuotaRolloverService 创建月额度结转服务
+func NewQuotaRolloverService(repo QuotaRolloverRepository, cfg *config.Config) *QuotaRolloverService {
+	svc := &QuotaRolloverService{repo: repo}
+	if cfg != nil {
+		svc.cfg = cfg.Billing.QuotaRollover
+	}
+	return svc
+}
+
+// Enabled 是否启用月额度结转
+func (s *QuotaRolloverService) Enabled() bool {
+	return s != nil && s.repo != nil && s.cfg.Enabled
+}
+
+// Get 获取订阅最近一次结转记录；未启用或没有记录时返回 nil
+func (s *QuotaRolloverService) Get(ctx context.Context, subscriptionID int64) (*QuotaRollover, error) {
+	if !s.Enabled() {
+		return nil, nil
+	}
+	rollover, err := s.repo.GetBySubscriptionID(ctx, subscriptionID)
+	if errors.Is(err, ErrQuotaRolloverNotFound) {
+		return nil, nil
+	}
+	return rollover, err
+}
+
+// CarryOver 在月窗口重置前调用，把 sub 当前窗口未用完的额度结转到 newWindowStart 开始的新窗口。
+// 同一新窗口重复调用（并发重置）时直接返回已有记录，不会重复结转。
+func (s *QuotaRolloverService) CarryOver(ctx context.Context, sub *UserSubscription, group *Group, newWindowStart time.Time) (*QuotaRollover, error) {
+	if !s.Enabled() || sub == nil || group == nil || !group.HasMonthlyLimit() || sub.MonthlyWindowStart == nil {
+		return nil, nil
+	}
+
+	previous, err := s.Get(ctx, sub.ID)
+	if err != nil {
+		return nil, err
+	}
+	if previous != nil && previous.WindowStart.Equal(newWindowStart) {
+		return previous, nil
+	}
+
+	// 只有结转进当前（即将结束的）窗口的额度才参与计算；
+	// 结转额度在窗口内可能已过期，这里按窗口内全部可用处理，结果仍受 max_percent 约束
+	carried := 0.0
+	if previous != nil && previous.WindowStart.Equal(*sub.MonthlyWindowStart) {
+		carried = previous.AmountUSD
+	}
+
+	rollover := &QuotaRollover{
+		SubscriptionID: sub.ID,
+		AmountUSD:      ComputeQuotaRollover(*group.MonthlyLimitUSD, sub.MonthlyUsageUSD, carried, s.cfg.MaxPercent),
+		WindowStart:    newWindowStart,
+		ExpiresAt:      newWindowStart.AddDate(0, 0, s.cfg.ExpiryDays),
+	}
+	if err := s.repo.Upsert(ctx, rollover); err != nil {
+		return nil, err
+	}
+	return rollover, nil
+}
+
+// ComputeQuotaRollover 计算结转到下个窗口的额度
+// 本期用量先消耗上期结转额度，再消耗本期月额度；结转额度不超过月额度的 maxPercent%，
+// 因此结转额度不会跨多个窗口累积。
+func ComputeQuotaRollover(monthlyLimit, used, carried, maxPercent float64) float64 {
+	if monthlyLimit <= 0 || maxPercent <= 0 {
+		return 0
+	}
+	usedFromBase := math.Max(used-carried, 0)
+	unused := math.Max(monthlyLimit-usedFromBase, 0)
+	return math.Min(unused, monthlyLimit*maxPercent/100)
+}
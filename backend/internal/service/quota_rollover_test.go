@@ -0,0 +1,114 @@
+//go:build unit
+
+package service
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/Wei-Shaw/sub2api/internal/config"
+	"github.com/stretchr/testify/require"
+)
+
+type quotaRolloverRepoStub struct {
+	rollovers map[int64]QuotaRollover
+	upserts   int
+}
+
+func (r *quotaRolloverRepoStub) GetBySubscriptionID(ctx context.Context, subscriptionID int64) (*QuotaRollover, error) {
+	rollover, ok := r.rollovers[subscriptionID]
+	if !ok {
+		return nil, ErrQuotaRolloverNotFound
+	}
+	return &rollover, nil
+}
+
+func (r *quotaRolloverRepoStub) Upsert(ctx context.Context, rollover *QuotaRollover) error {
+	r.upserts++
+	r.rollovers[rollover.SubscriptionID] = *rollover
+	return nil
+}
+
+func newQuotaRolloverTestService(repo QuotaRolloverRepository) *QuotaRolloverService {
+	cfg := &config.Config{}
+	cfg.Billing.QuotaRollover = config.QuotaRolloverConfig{Enabled: true, MaxPercent: 50, ExpiryDays: 30}
+	return NewQuotaRolloverService(repo, cfg)
+}
+
+func TestComputeQuotaRollover(t *testing.T) {
+	// 未用完部分受 maxPercent 上限约束
+	require.InDelta(t, 50, ComputeQuotaRollover(100, 10, 0, 50), 1e-9)
+	require.InDelta(t, 30, ComputeQuotaRollover(100, 70, 0, 50), 1e-9)
+	// 用量先消耗上期结转：用了 60，其中 40 来自结转，本期额度只用了 20
+	require.InDelta(t, 80, ComputeQuotaRollover(100, 60, 40, 100), 1e-9)
+	// 超额使用或未配置上限时不结转
+	require.Zero(t, ComputeQuotaRollover(100, 150, 0, 100))
+	require.Zero(t, ComputeQuotaRollover(100, 0, 0, 0))
+	require.Zero(t, ComputeQuotaRollover(0, 0, 0, 100))
+}
+
+func TestQuotaRolloverService_CarryOver(t *testing.T) {
+	ctx := context.Background()
+	repo := &quotaRolloverRepoStub{rollovers: map[int64]QuotaRollover{}}
+	svc := newQuotaRolloverTestService(repo)
+
+	limit := 100.0
+	group := &Group{ID: 2, MonthlyLimitUSD: &limit}
+	oldWindow := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+	newWindow := oldWindow.AddDate(0, 0, 30)
+	sub := &UserSubscription{ID: 9, GroupID: 2, MonthlyWindowStart: &oldWindow, MonthlyUsageUSD: 80}
+
+	rollover, err := svc.CarryOver(ctx, sub, group, newWindow)
+	require.NoError(t, err)
+	require.InDelta(t, 20, rollover.AmountUSD, 1e-9)
+	require.True(t, rollover.ExpiresAt.Equal(newWindow.AddDate(0, 0, 30)))
+
+	// 同一新窗口重复结转（并发重置）直接返回已有记录
+	_, err = svc.CarryOver(ctx, sub, group, newWindow)
+	require.NoError(t, err)
+	require.Equal(t, 1, repo.upserts)
+
+	// 下一窗口：用量 80 中 20 来自结转额度，本期额度剩余 40
+	nextWindow := newWindow.AddDate(0, 0, 30)
+	sub.MonthlyWindowStart = &newWindow
+	sub.MonthlyUsageUSD = 80
+	rollover, err = svc.CarryOver(ctx, sub, group, nextWindow)
+	require.NoError(t, err)
+	require.InDelta(t, 40, rollover.AmountUSD, 1e-9)
+
+	require.InDelta(t, 40, rollover.ActiveAmount(nextWindow), 1e-9)
+	require.Zero(t, rollover.ActiveAmount(rollover.ExpiresAt))
+}
+
+func TestQuotaRolloverService_Disabled(t *testing.T) {
+	repo := &quotaRolloverRepoStub{rollovers: map[int64]QuotaRollover{}}
+	svc := NewQuotaRolloverService(repo, &config.Config{})
+	require.False(t, svc.Enabled())
+
+	var nilSvc *QuotaRolloverService
+	require.False(t, nilSvc.Enabled())
+	got, err := nilSvc.Get(context.Background(), 1)
+	require.NoError(t, err)
+	require.Nil(t, got)
+
+	limit := 100.0
+	window := time.Now()
+	rollover, err := svc.CarryOver(context.Background(), &UserSubscription{ID: 1, MonthlyWindowStart: &window}, &Group{MonthlyLimitUSD: &limit}, window)
+	require.NoError(t, err)
+	require.Nil(t, rollover)
+	require.Zero(t, repo.upserts)
+}
+
+func TestMonthlyLimitWithRollover(t *testing.T) {
+	limit := 100.0
+	group := &Group{MonthlyLimitUSD: &limit}
+	sub := &UserSubscription{MonthlyUsageUSD: 110}
+	require.False(t, sub.CheckMonthlyLimit(group, 0))
+	require.True(t, sub.CheckMonthlyLimitWithRollover(group, 0, 20))
+
+	now := time.Now()
+	data := &subscriptionCacheData{RolloverUSD: 20, RolloverExpiresAt: now.Add(time.Hour)}
+	require.InDelta(t, 20, data.monthlyRollover(now), 1e-9)
+	require.Zero(t, data.monthlyRollover(now.Add(2*time.Hour)))
+}
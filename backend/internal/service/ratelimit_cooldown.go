@@ -0,0 +1,132 @@
+package service
+
+import (
+	"math"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/Wei-Shaw/sub2api/internal/config"
+)
+
+// parseRetryAfter 从 429/529 响应头解析上游建议的重试等待时间：
+// 优先 retry-after-ms，其次 Retry-After（秒数或 HTTP 日期），
+// 都没有时取 x-ratelimit-reset-requests / x-ratelimit-reset-tokens（如 "6m0s"、"20ms"）中较长者。
+// 无法解析时返回 false
+func parseRetryAfter(headers http.Header, now time.Time) (time.Duration, bool) {
+	if headers == nil {
+		return 0, false
+	}
+	if v := strings.TrimSpace(headers.Get("retry-after-ms")); v != "" {
+		if ms, err := strconv.ParseFloat(v, 64); err == nil && ms > 0 {
+			return time.Duration(ms * float64(time.Millisecond)), true
+		}
+	}
+	if v := strings.TrimSpace(headers.Get("Retry-After")); v != "" {
+		if sec, err := strconv.ParseFloat(v, 64); err == nil {
+			if sec > 0 {
+				return time.Duration(sec * float64(time.Second)), true
+			}
+		} else if at, err := http.ParseTime(v); err == nil && at.After(now) {
+			return at.Sub(now), true
+		}
+	}
+	var longest time.Duration
+	for _, key := range []string{"x-ratelimit-reset-requests", "x-ratelimit-reset-tokens"} {
+		if v := strings.TrimSpace(headers.Get(key)); v != "" {
+			if d, err := time.ParseDuration(v); err == nil && d > longest {
+				longest = d
+			}
+		}
+	}
+	return longest, longest > 0
+}
+
+// accountCooldownTracker 进程内的账号冷却记录：
+// 调度快照重建存在延迟，限流/过载状态写库后短时间内快照中的账号仍可能被选中，
+// 选择账号时据此直接跳过冷却中的账号；同时记录连续限流次数，用于冷却时间的指数递增
+type accountCooldownTracker struct {
+	mu      sync.Mutex
+	entries map[int64]*accountCooldownEntry
+}
+
+type accountCooldownEntry struct {
+	strikes    int
+	lastStrike time.Time
+	until      time.Time
+}
+
+func newAccountCooldownTracker() *accountCooldownTracker {
+	return &accountCooldownTracker{entries: make(map[int64]*accountCooldownEntry)}
+}
+
+// strike 记录一次无明确重置时间的限流/过载，返回按连续次数递增后的冷却截止时间：
+// 冷却时长 = base × multiplier^(连续次数-1)，不超过 max（base 本身超过 max 时以 base 为准）；
+// 距上次记录超过 resetWindow 时连续次数重新计数。未启用自适应冷却时固定为 base
+func (t *accountCooldownTracker) strike(accountID int64, base time.Duration, cfg config.AdaptiveCooldownConfig, now time.Time) time.Time {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	entry := t.entries[accountID]
+	if entry == nil {
+		entry = &accountCooldownEntry{}
+		t.entries[accountID] = entry
+	}
+	resetWindow := time.Duration(cfg.ResetWindowSeconds) * time.Second
+	if entry.strikes > 0 && resetWindow > 0 && now.Sub(entry.lastStrike) > resetWindow {
+		entry.strikes = 0
+	}
+	entry.strikes++
+	entry.lastStrike = now
+
+	cooldown := base
+	if cfg.Enabled && cfg.Multiplier > 1 && entry.strikes > 1 {
+		scaled := float64(base) * math.Pow(cfg.Multiplier, float64(entry.strikes-1))
+		limit := time.Duration(cfg.MaxSeconds) * time.Second
+		if limit <= 0 || limit < base {
+			limit = base
+		}
+		if scaled > float64(limit) {
+			cooldown = limit
+		} else {
+			cooldown = time.Duration(scaled)
+		}
+	}
+
+	until := now.Add(cooldown)
+	if until.After(entry.until) {
+		entry.until = until
+	}
+	return until
+}
+
+// hold 记录上游给出明确重置时间的冷却（不参与指数递增）
+func (t *accountCooldownTracker) hold(accountID int64, until time.Time) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	entry := t.entries[accountID]
+	if entry == nil {
+		entry = &accountCooldownEntry{}
+		t.entries[accountID] = entry
+	}
+	if until.After(entry.until) {
+		entry.until = until
+	}
+}
+
+// coolingDown 账号是否仍在冷却中
+func (t *accountCooldownTracker) coolingDown(accountID int64, now time.Time) bool {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	entry := t.entries[accountID]
+	return entry != nil && now.Before(entry.until)
+}
+
+// clear 清除账号的冷却记录与连续次数（管理员手动清除限流时调用）
+func (t *accountCooldownTracker) clear(accountID int64) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	delete(t.entries, accountID)
+}
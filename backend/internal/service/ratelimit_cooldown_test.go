@@ -0,0 +1,124 @@
+//go:build unit
+
+package service
+
+import (
+	"context"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/Wei-Shaw/sub2api/internal/config"
+	"github.com/stretchr/testify/require"
+)
+
+type cooldownAccountRepoStub struct {
+	mockAccountRepoForGemini
+	rateLimitedUntil []time.Time
+	overloadedUntil  []time.Time
+}
+
+func (r *cooldownAccountRepoStub) SetRateLimited(ctx context.Context, id int64, resetAt time.Time) error {
+	r.rateLimitedUntil = append(r.rateLimitedUntil, resetAt)
+	return nil
+}
+
+func (r *cooldownAccountRepoStub) SetOverloaded(ctx context.Context, id int64, until time.Time) error {
+	r.overloadedUntil = append(r.overloadedUntil, until)
+	return nil
+}
+
+func TestParseRetryAfter(t *testing.T) {
+	now := time.Date(2026, 1, 2, 3, 4, 5, 0, time.UTC)
+	tests := []struct {
+		name    string
+		headers http.Header
+		want    time.Duration
+		ok      bool
+	}{
+		{name: "seconds", headers: http.Header{"Retry-After": {"30"}}, want: 30 * time.Second, ok: true},
+		{name: "http date", headers: http.Header{"Retry-After": {now.Add(90 * time.Second).Format(http.TimeFormat)}}, want: 90 * time.Second, ok: true},
+		{name: "milliseconds preferred", headers: http.Header{"Retry-After": {"30"}, "Retry-After-Ms": {"1500"}}, want: 1500 * time.Millisecond, ok: true},
+		{name: "openai reset headers", headers: http.Header{"X-Ratelimit-Reset-Requests": {"1s"}, "X-Ratelimit-Reset-Tokens": {"6m0s"}}, want: 6 * time.Minute, ok: true},
+		{name: "past date", headers: http.Header{"Retry-After": {now.Add(-time.Minute).Format(http.TimeFormat)}}},
+		{name: "invalid", headers: http.Header{"Retry-After": {"soon"}}},
+		{name: "missing", headers: http.Header{}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, ok := parseRetryAfter(tt.headers, now)
+			require.Equal(t, tt.ok, ok)
+			require.Equal(t, tt.want, got)
+		})
+	}
+}
+
+func TestAccountCooldownTracker_ExponentialStrikes(t *testing.T) {
+	cfg := config.AdaptiveCooldownConfig{Enabled: true, Multiplier: 2, MaxSeconds: 100, ResetWindowSeconds: 600}
+	tracker := newAccountCooldownTracker()
+	now := time.Now()
+
+	require.Equal(t, now.Add(30*time.Second), tracker.strike(1, 30*time.Second, cfg, now))
+	require.Equal(t, now.Add(60*time.Second), tracker.strike(1, 30*time.Second, cfg, now))
+	// 超过上限后封顶
+	require.Equal(t, now.Add(100*time.Second), tracker.strike(1, 30*time.Second, cfg, now))
+	// 上游 Retry-After 超过上限时以其为准
+	require.Equal(t, now.Add(200*time.Second), tracker.strike(1, 200*time.Second, cfg, now))
+	require.True(t, tracker.coolingDown(1, now.Add(150*time.Second)))
+	require.False(t, tracker.coolingDown(2, now))
+
+	// 超过计数窗口后重新计数
+	later := now.Add(20 * time.Minute)
+	require.Equal(t, later.Add(30*time.Second), tracker.strike(1, 30*time.Second, cfg, later))
+
+	tracker.clear(1)
+	require.False(t, tracker.coolingDown(1, later))
+}
+
+func TestAccountCooldownTracker_DisabledKeepsBase(t *testing.T) {
+	tracker := newAccountCooldownTracker()
+	now := time.Now()
+	cfg := config.AdaptiveCooldownConfig{Multiplier: 2, MaxSeconds: 100}
+
+	tracker.strike(1, 30*time.Second, cfg, now)
+	require.Equal(t, now.Add(30*time.Second), tracker.strike(1, 30*time.Second, cfg, now))
+}
+
+func TestRateLimitService_Handle429RetryAfterEscalates(t *testing.T) {
+	repo := &cooldownAccountRepoStub{}
+	cfg := &config.Config{}
+	cfg.RateLimit.AdaptiveCooldown = config.AdaptiveCooldownConfig{Enabled: true, Multiplier: 2, MaxSeconds: 3600, ResetWindowSeconds: 1800}
+	svc := NewRateLimitService(repo, nil, cfg, nil, nil)
+	account := &Account{ID: 7, Platform: PlatformAnthropic, Type: AccountTypeAPIKey}
+	headers := http.Header{"Retry-After": {"30"}}
+
+	require.False(t, svc.IsAccountCoolingDown(account.ID))
+	start := time.Now()
+	svc.HandleUpstreamError(context.Background(), account, http.StatusTooManyRequests, headers, []byte(`{}`))
+	svc.HandleUpstreamError(context.Background(), account, http.StatusTooManyRequests, headers, []byte(`{}`))
+
+	require.Len(t, repo.rateLimitedUntil, 2)
+	require.WithinDuration(t, start.Add(30*time.Second), repo.rateLimitedUntil[0], 2*time.Second)
+	require.WithinDuration(t, start.Add(60*time.Second), repo.rateLimitedUntil[1], 2*time.Second)
+	require.True(t, svc.IsAccountCoolingDown(account.ID))
+
+	require.NoError(t, svc.ClearRateLimit(context.Background(), account.ID))
+	require.False(t, svc.IsAccountCoolingDown(account.ID))
+}
+
+func TestRateLimitService_Handle529UsesRetryAfter(t *testing.T) {
+	repo := &cooldownAccountRepoStub{}
+	cfg := &config.Config{}
+	cfg.RateLimit.OverloadCooldownMinutes = 10
+	svc := NewRateLimitService(repo, nil, cfg, nil, nil)
+	account := &Account{ID: 8, Platform: PlatformAnthropic, Type: AccountTypeAPIKey}
+
+	start := time.Now()
+	svc.HandleUpstreamError(context.Background(), account, 529, http.Header{"Retry-After": {"20"}}, []byte(`{}`))
+	svc.HandleUpstreamError(context.Background(), account, 529, http.Header{}, []byte(`{}`))
+
+	require.Len(t, repo.overloadedUntil, 2)
+	require.WithinDuration(t, start.Add(20*time.Second), repo.overloadedUntil[0], 2*time.Second)
+	// 自适应冷却未启用：无 Retry-After 时使用配置的过载冷却时间
+	require.WithinDuration(t, start.Add(10*time.Minute), repo.overloadedUntil[1], 2*time.Second)
+}
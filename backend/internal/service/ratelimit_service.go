@@ -25,6 +25,7 @@ type RateLimitService struct {
 	tokenCacheInvalidator TokenCacheInvalidator
 	usageCacheMu          sync.RWMutex
 	usageCache            map[int64]*geminiUsageCacheEntry
+	cooldowns             *accountCooldownTracker
 }
 
 type geminiUsageCacheEntry struct {
@@ -44,6 +45,7 @@ func NewRateLimitService(accountRepo AccountRepository, usageRepo UsageLogReposi
 		geminiQuotaService: geminiQuotaService,
 		tempUnschedCache:   tempUnschedCache,
 		usageCache:         make(map[int64]*geminiUsageCacheEntry),
+		cooldowns:          newAccountCooldownTracker(),
 	}
 }
 
@@ -168,7 +170,7 @@ func (s *RateLimitService) HandleUpstreamError(ctx context.Context, account *Acc
 		s.handle429(ctx, account, headers, responseBody)
 		shouldDisable = false
 	case 529:
-		s.handle529(ctx, account)
+		s.handle529(ctx, account, headers)
 		shouldDisable = false
 	default:
 		// 自定义错误码启用时：在列表中的错误码都应该停止调度
@@ -372,7 +374,7 @@ func (s *RateLimitService) handle429(ctx context.Context, account *Account, head
 	// 1. OpenAI 平台：优先尝试解析 x-codex-* 响应头（用于 rate_limit_exceeded）
 	if account.Platform == PlatformOpenAI {
 		if resetAt := s.calculateOpenAI429ResetTime(headers); resetAt != nil {
-			if err := s.accountRepo.SetRateLimited(ctx, account.ID, *resetAt); err != nil {
+			if err := s.setRateLimited(ctx, account.ID, *resetAt); err != nil {
 				slog.Warn("rate_limit_set_failed", "account_id", account.ID, "error", err)
 				return
 			}
@@ -383,7 +385,7 @@ func (s *RateLimitService) handle429(ctx context.Context, account *Account, head
 
 	// 2. Anthropic 平台：尝试解析 per-window 头（5h / 7d），选择实际触发的窗口
 	if result := calculateAnthropic429ResetTime(headers); result != nil {
-		if err := s.accountRepo.SetRateLimited(ctx, account.ID, result.resetAt); err != nil {
+		if err := s.setRateLimited(ctx, account.ID, result.resetAt); err != nil {
 			slog.Warn("rate_limit_set_failed", "account_id", account.ID, "error", err)
 			return
 		}
@@ -412,7 +414,7 @@ func (s *RateLimitService) handle429(ctx context.Context, account *Account, head
 			// 尝试解析 OpenAI 的 usage_limit_reached 错误
 			if resetAt := parseOpenAIRateLimitResetTime(responseBody); resetAt != nil {
 				resetTime := time.Unix(*resetAt, 0)
-				if err := s.accountRepo.SetRateLimited(ctx, account.ID, resetTime); err != nil {
+				if err := s.setRateLimited(ctx, account.ID, resetTime); err != nil {
 					slog.Warn("rate_limit_set_failed", "account_id", account.ID, "error", err)
 					return
 				}
@@ -423,7 +425,7 @@ func (s *RateLimitService) handle429(ctx context.Context, account *Account, head
 			// 尝试解析 Gemini 格式（用于其他平台）
 			if resetAt := ParseGeminiRateLimitResetTime(responseBody); resetAt != nil {
 				resetTime := time.Unix(*resetAt, 0)
-				if err := s.accountRepo.SetRateLimited(ctx, account.ID, resetTime); err != nil {
+				if err := s.setRateLimited(ctx, account.ID, resetTime); err != nil {
 					slog.Warn("rate_limit_set_failed", "account_id", account.ID, "error", err)
 					return
 				}
@@ -432,9 +434,9 @@ func (s *RateLimitService) handle429(ctx context.Context, account *Account, head
 			}
 		}
 
-		// 没有重置时间，使用默认5分钟
-		resetAt := time.Now().Add(5 * time.Minute)
-		slog.Warn("rate_limit_no_reset_time", "account_id", account.ID, "platform", account.Platform, "using_default", "5m")
+		// 没有重置时间：按 Retry-After（缺省5分钟）冷却，连续限流时指数递增
+		resetAt := s.adaptiveCooldownUntil(account.ID, headers, defaultRateLimitCooldown)
+		slog.Warn("rate_limit_no_reset_time", "account_id", account.ID, "platform", account.Platform, "reset_at", resetAt, "reset_in", time.Until(resetAt).Truncate(time.Second))
 		if err := s.accountRepo.SetRateLimited(ctx, account.ID, resetAt); err != nil {
 			slog.Warn("rate_limit_set_failed", "account_id", account.ID, "error", err)
 		}
@@ -445,7 +447,7 @@ func (s *RateLimitService) handle429(ctx context.Context, account *Account, head
 	ts, err := strconv.ParseInt(resetTimestamp, 10, 64)
 	if err != nil {
 		slog.Warn("rate_limit_reset_parse_failed", "reset_timestamp", resetTimestamp, "error", err)
-		resetAt := time.Now().Add(5 * time.Minute)
+		resetAt := s.adaptiveCooldownUntil(account.ID, headers, defaultRateLimitCooldown)
 		if err := s.accountRepo.SetRateLimited(ctx, account.ID, resetAt); err != nil {
 			slog.Warn("rate_limit_set_failed", "account_id", account.ID, "error", err)
 		}
@@ -455,7 +457,7 @@ func (s *RateLimitService) handle429(ctx context.Context, account *Account, head
 	resetAt := time.Unix(ts, 0)
 
 	// 标记限流状态
-	if err := s.accountRepo.SetRateLimited(ctx, account.ID, resetAt); err != nil {
+	if err := s.setRateLimited(ctx, account.ID, resetAt); err != nil {
 		slog.Warn("rate_limit_set_failed", "account_id", account.ID, "error", err)
 		return
 	}
@@ -678,15 +680,53 @@ func parseOpenAIRateLimitResetTime(body []byte) *int64 {
 	return nil
 }
 
+// defaultRateLimitCooldown 429 未给出任何重置时间时的基础冷却时间
+const defaultRateLimitCooldown = 5 * time.Minute
+
+// setRateLimited 按上游给出的重置时间标记限流，并记录进程内冷却供账号选择立即生效
+func (s *RateLimitService) setRateLimited(ctx context.Context, accountID int64, resetAt time.Time) error {
+	if s.cooldowns != nil {
+		s.cooldowns.hold(accountID, resetAt)
+	}
+	return s.accountRepo.SetRateLimited(ctx, accountID, resetAt)
+}
+
+// adaptiveCooldownUntil 计算无明确重置时间的限流/过载冷却截止时间：
+// 以 Retry-After 为基础时长（缺省为 fallback），连续发生时按 rate_limit.adaptive_cooldown 指数递增
+func (s *RateLimitService) adaptiveCooldownUntil(accountID int64, headers http.Header, fallback time.Duration) time.Time {
+	now := time.Now()
+	base := fallback
+	if retryAfter, ok := parseRetryAfter(headers, now); ok {
+		base = retryAfter
+	}
+	if s.cooldowns == nil {
+		return now.Add(base)
+	}
+	var cfg config.AdaptiveCooldownConfig
+	if s.cfg != nil {
+		cfg = s.cfg.RateLimit.AdaptiveCooldown
+	}
+	return s.cooldowns.strike(accountID, base, cfg, now)
+}
+
+// IsAccountCoolingDown 账号是否处于进程内记录的限流/过载冷却中。
+// 调度快照可能尚未反映刚写入的限流状态，选择账号时据此跳过，避免下一个请求立即重试该账号
+func (s *RateLimitService) IsAccountCoolingDown(accountID int64) bool {
+	if s == nil || s.cooldowns == nil {
+		return false
+	}
+	return s.cooldowns.coolingDown(accountID, time.Now())
+}
+
 // handle529 处理529过载错误
-// 根据配置设置过载冷却时间
-func (s *RateLimitService) handle529(ctx context.Context, account *Account) {
+// 按 Retry-After（缺省为配置的过载冷却时间）设置过载冷却，连续过载时指数递增
+func (s *RateLimitService) handle529(ctx context.Context, account *Account, headers http.Header) {
 	cooldownMinutes := s.cfg.RateLimit.OverloadCooldownMinutes
 	if cooldownMinutes <= 0 {
 		cooldownMinutes = 10 // 默认10分钟
 	}
 
-	until := time.Now().Add(time.Duration(cooldownMinutes) * time.Minute)
+	until := s.adaptiveCooldownUntil(account.ID, headers, time.Duration(cooldownMinutes)*time.Minute)
 	if err := s.accountRepo.SetOverloaded(ctx, account.ID, until); err != nil {
 		slog.Warn("overload_set_failed", "account_id", account.ID, "error", err)
 		return
@@ -735,6 +775,9 @@ func (s *RateLimitService) ClearRateLimit(ctx context.Context, accountID int64)
 	if err := s.accountRepo.ClearRateLimit(ctx, accountID); err != nil {
 		return err
 	}
+	if s.cooldowns != nil {
+		s.cooldowns.clear(accountID)
+	}
 	if err := s.accountRepo.ClearAntigravityQuotaScopes(ctx, accountID); err != nil {
 		return err
 	}
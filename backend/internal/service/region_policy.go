@@ -0,0 +1,87 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"strings"
+
+	"github.com/Wei-Shaw/sub2api/internal/domain"
+	"github.com/Wei-Shaw/sub2api/internal/pkg/ctxkey"
+)
+
+type RegionPolicy = domain.RegionPolicy
+
+// ErrAccountRegionNotAllowed 账号区域不满足请求的强制区域约束
+var ErrAccountRegionNotAllowed = errors.New("account region not allowed by region policy")
+
+// EffectiveRegionPolicy 计算请求生效的区域策略：API Key 配置覆盖分组配置
+func EffectiveRegionPolicy(apiKey *APIKey) RegionPolicy {
+	if apiKey == nil {
+		return RegionPolicy{}
+	}
+	var policy RegionPolicy
+	if apiKey.Group != nil {
+		policy = apiKey.Group.RegionPolicy
+	}
+	return policy.Merge(apiKey.RegionPolicy)
+}
+
+// WithRegionPolicy 将区域策略写入 context，供账号调度使用
+func WithRegionPolicy(ctx context.Context, policy RegionPolicy) context.Context {
+	if policy.IsEmpty() {
+		return ctx
+	}
+	return context.WithValue(ctx, ctxkey.RegionPolicy, policy)
+}
+
+func regionPolicyFromContext(ctx context.Context) (RegionPolicy, bool) {
+	if ctx == nil {
+		return RegionPolicy{}, false
+	}
+	policy, ok := ctx.Value(ctxkey.RegionPolicy).(RegionPolicy)
+	if !ok || policy.IsEmpty() {
+		return RegionPolicy{}, false
+	}
+	return policy, true
+}
+
+// accountAllowedByRegionPolicy 检查账号是否满足强制区域约束（preferred 不影响可用性）
+func accountAllowedByRegionPolicy(ctx context.Context, account *Account) bool {
+	if account == nil {
+		return false
+	}
+	policy, ok := regionPolicyFromContext(ctx)
+	if !ok || strings.TrimSpace(policy.Required) == "" {
+		return true
+	}
+	return domain.MatchRegion(account.GetRegion(), policy.Required)
+}
+
+// filterAccountsByRegionPolicy 按区域策略过滤候选账号：
+//   - required：仅保留该区域的账号
+//   - preferred：该区域存在账号时仅保留该区域账号，否则保持原列表（回退）
+func filterAccountsByRegionPolicy(ctx context.Context, accounts []Account) []Account {
+	policy, ok := regionPolicyFromContext(ctx)
+	if !ok || len(accounts) == 0 {
+		return accounts
+	}
+	if strings.TrimSpace(policy.Required) != "" {
+		accounts = filterAccountsByRegion(accounts, policy.Required)
+	}
+	if strings.TrimSpace(policy.Preferred) != "" {
+		if preferred := filterAccountsByRegion(accounts, policy.Preferred); len(preferred) > 0 {
+			accounts = preferred
+		}
+	}
+	return accounts
+}
+
+func filterAccountsByRegion(accounts []Account, region string) []Account {
+	filtered := make([]Account, 0, len(accounts))
+	for i := range accounts {
+		if domain.MatchRegion(accounts[i].GetRegion(), region) {
+			filtered = append(filtered, accounts[i])
+		}
+	}
+	return filtered
+}
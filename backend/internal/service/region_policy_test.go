@@ -0,0 +1,70 @@
+//go:build unit
+
+package service
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func regionAccount(id int64, region string) Account {
+	acc := Account{ID: id}
+	if region != "" {
+		acc.Extra = map[string]any{"region": region}
+	}
+	return acc
+}
+
+func accountIDs(accounts []Account) []int64 {
+	ids := make([]int64, 0, len(accounts))
+	for _, acc := range accounts {
+		ids = append(ids, acc.ID)
+	}
+	return ids
+}
+
+func TestFilterAccountsByRegionPolicy_NoPolicyKeepsAll(t *testing.T) {
+	accounts := []Account{regionAccount(1, "us"), regionAccount(2, "")}
+	require.Equal(t, []int64{1, 2}, accountIDs(filterAccountsByRegionPolicy(context.Background(), accounts)))
+}
+
+func TestFilterAccountsByRegionPolicy_Required(t *testing.T) {
+	ctx := WithRegionPolicy(context.Background(), RegionPolicy{Required: "EU"})
+	accounts := []Account{regionAccount(1, "us"), regionAccount(2, "eu"), regionAccount(3, "")}
+
+	require.Equal(t, []int64{2}, accountIDs(filterAccountsByRegionPolicy(ctx, accounts)))
+
+	ctx = WithRegionPolicy(context.Background(), RegionPolicy{Required: "apac"})
+	require.Empty(t, filterAccountsByRegionPolicy(ctx, accounts))
+}
+
+func TestFilterAccountsByRegionPolicy_PreferredFallsBack(t *testing.T) {
+	accounts := []Account{regionAccount(1, "us"), regionAccount(2, "eu")}
+
+	ctx := WithRegionPolicy(context.Background(), RegionPolicy{Preferred: "eu"})
+	require.Equal(t, []int64{2}, accountIDs(filterAccountsByRegionPolicy(ctx, accounts)))
+
+	ctx = WithRegionPolicy(context.Background(), RegionPolicy{Preferred: "apac"})
+	require.Equal(t, []int64{1, 2}, accountIDs(filterAccountsByRegionPolicy(ctx, accounts)))
+}
+
+func TestAccountAllowedByRegionPolicy_PreferredDoesNotBlock(t *testing.T) {
+	acc := regionAccount(1, "us")
+
+	ctx := WithRegionPolicy(context.Background(), RegionPolicy{Preferred: "eu"})
+	require.True(t, accountAllowedByRegionPolicy(ctx, &acc))
+
+	ctx = WithRegionPolicy(context.Background(), RegionPolicy{Required: "eu"})
+	require.False(t, accountAllowedByRegionPolicy(ctx, &acc))
+}
+
+func TestEffectiveRegionPolicy_APIKeyOverridesGroup(t *testing.T) {
+	apiKey := &APIKey{
+		RegionPolicy: RegionPolicy{Required: "eu"},
+		Group:        &Group{RegionPolicy: RegionPolicy{Required: "us", Preferred: "us-east"}},
+	}
+	require.Equal(t, RegionPolicy{Required: "eu", Preferred: "us-east"}, EffectiveRegionPolicy(apiKey))
+	require.True(t, EffectiveRegionPolicy(nil).IsEmpty())
+}
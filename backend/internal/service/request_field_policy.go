@@ -0,0 +1,153 @@
+package service
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/tidwall/gjson"
+)
+
+// 请求体顶层字段校验所用的协议 schema
+const (
+	RequestSchemaAnthropicMessages    = "anthropic_messages"
+	RequestSchemaOpenAIResponses      = "openai_responses"
+	RequestSchemaOpenAIChatCompletion = "openai_chat_completions"
+	RequestSchemaGeminiGenerate       = "gemini_generate_content"
+)
+
+// knownRequestFields 各协议官方文档中的顶层请求字段；严格模式下不在列表中的字段视为未知字段
+var knownRequestFields = map[string]map[string]struct{}{
+	RequestSchemaAnthropicMessages: newFieldSet(
+		"model", "messages", "max_tokens", "system", "metadata", "stop_sequences", "stream",
+		"temperature", "top_p", "top_k", "tools", "tool_choice", "thinking", "service_tier",
+		"container", "mcp_servers", "context_management", "output_format", "output_config",
+		"anthropic_version", "anthropic_beta",
+	),
+	RequestSchemaOpenAIResponses: newFieldSet(
+		"model", "input", "instructions", "max_output_tokens", "max_tool_calls", "metadata",
+		"parallel_tool_calls", "previous_response_id", "reasoning", "store", "stream", "stream_options",
+		"temperature", "text", "tool_choice", "tools", "top_p", "top_logprobs", "truncation", "user",
+		"include", "background", "conversation", "prompt", "prompt_cache_key", "prompt_cache_retention",
+		"safety_identifier", "service_tier", "seed",
+	),
+	RequestSchemaOpenAIChatCompletion: newFieldSet(
+		"model", "messages", "max_tokens", "max_completion_tokens", "temperature", "top_p", "n",
+		"stream", "stream_options", "stop", "presence_penalty", "frequency_penalty", "logit_bias",
+		"logprobs", "top_logprobs", "user", "tools", "tool_choice", "parallel_tool_calls",
+		"response_format", "seed", "service_tier", "store", "metadata", "reasoning_effort",
+		"modalities", "audio", "prediction", "web_search_options", "functions", "function_call",
+		"verbosity", "prompt_cache_key", "safety_identifier",
+	),
+	RequestSchemaGeminiGenerate: newFieldSet(
+		"contents", "systemInstruction", "system_instruction", "tools", "toolConfig", "tool_config",
+		"safetySettings", "safety_settings", "generationConfig", "generation_config",
+		"cachedContent", "cached_content", "labels",
+	),
+}
+
+func newFieldSet(fields ...string) map[string]struct{} {
+	set := make(map[string]struct{}, len(fields))
+	for _, f := range fields {
+		set[f] = struct{}{}
+	}
+	return set
+}
+
+// UnknownRequestFieldsError 严格模式下请求包含未知顶层字段
+type UnknownRequestFieldsError struct {
+	// Fields 未知字段（按出现顺序）
+	Fields []string
+	// Suggestions 未知字段 -> 最接近的已知字段（疑似拼写错误时）
+	Suggestions map[string]string
+}
+
+func (e *UnknownRequestFieldsError) Error() string {
+	parts := make([]string, 0, len(e.Fields))
+	for _, f := range e.Fields {
+		if s, ok := e.Suggestions[f]; ok {
+			parts = append(parts, fmt.Sprintf("%q (did you mean %q?)", f, s))
+		} else {
+			parts = append(parts, fmt.Sprintf("%q", f))
+		}
+	}
+	if len(parts) == 1 {
+		return "Unknown top-level request field " + parts[0]
+	}
+	return "Unknown top-level request fields " + strings.Join(parts, ", ")
+}
+
+// CheckUnknownRequestFields 分组处于严格字段模式时校验请求体顶层字段，存在未知字段时返回
+// *UnknownRequestFieldsError；宽松模式（默认）、未知 schema 或非对象请求体直接放行，字段原样透传。
+func CheckUnknownRequestFields(group *Group, schema string, body []byte) error {
+	if !group.IsStrictRequestFields() {
+		return nil
+	}
+	known, ok := knownRequestFields[schema]
+	if !ok {
+		return nil
+	}
+	root := gjson.ParseBytes(body)
+	if !root.IsObject() {
+		return nil
+	}
+
+	var unknownErr *UnknownRequestFieldsError
+	root.ForEach(func(key, _ gjson.Result) bool {
+		name := key.String()
+		if _, ok := known[name]; ok {
+			return true
+		}
+		if unknownErr == nil {
+			unknownErr = &UnknownRequestFieldsError{Suggestions: map[string]string{}}
+		}
+		unknownErr.Fields = append(unknownErr.Fields, name)
+		if suggestion := closestRequestField(name, known); suggestion != "" {
+			unknownErr.Suggestions[name] = suggestion
+		}
+		return true
+	})
+	if unknownErr == nil {
+		return nil
+	}
+	return unknownErr
+}
+
+// closestRequestField 返回编辑距离不超过 2 的最接近已知字段（同距离按字典序），用于提示拼写错误
+func closestRequestField(name string, known map[string]struct{}) string {
+	candidates := make([]string, 0, len(known))
+	for k := range known {
+		candidates = append(candidates, k)
+	}
+	sort.Strings(candidates)
+
+	best, bestDist := "", 3
+	lower := strings.ToLower(name)
+	for _, k := range candidates {
+		if d := editDistance(lower, strings.ToLower(k)); d < bestDist {
+			best, bestDist = k, d
+		}
+	}
+	return best
+}
+
+// editDistance Levenshtein 编辑距离
+func editDistance(a, b string) int {
+	prev := make([]int, len(b)+1)
+	cur := make([]int, len(b)+1)
+	for j := range prev {
+		prev[j] = j
+	}
+	for i := 1; i <= len(a); i++ {
+		cur[0] = i
+		for j := 1; j <= len(b); j++ {
+			cost := 1
+			if a[i-1] == b[j-1] {
+				cost = 0
+			}
+			cur[j] = min(prev[j]+1, cur[j-1]+1, prev[j-1]+cost)
+		}
+		prev, cur = cur, prev
+	}
+	return prev[len(b)]
+}
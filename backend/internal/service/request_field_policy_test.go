@@ -0,0 +1,42 @@
+package service
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestCheckUnknownRequestFields_LenientPassesThrough(t *testing.T) {
+	body := []byte(`{"model":"claude-sonnet-4-5","max_token":100,"messages":[]}`)
+
+	require.NoError(t, CheckUnknownRequestFields(nil, RequestSchemaAnthropicMessages, body))
+	require.NoError(t, CheckUnknownRequestFields(&Group{}, RequestSchemaAnthropicMessages, body))
+}
+
+func TestCheckUnknownRequestFields_StrictRejectsWithSuggestion(t *testing.T) {
+	group := &Group{StrictRequestFields: true}
+	body := []byte(`{"model":"claude-sonnet-4-5","max_token":100,"messages":[],"x_custom":1}`)
+
+	err := CheckUnknownRequestFields(group, RequestSchemaAnthropicMessages, body)
+	var unknownErr *UnknownRequestFieldsError
+	require.True(t, errors.As(err, &unknownErr))
+	require.Equal(t, []string{"max_token", "x_custom"}, unknownErr.Fields)
+	require.Equal(t, "max_tokens", unknownErr.Suggestions["max_token"])
+	require.NotContains(t, unknownErr.Suggestions, "x_custom")
+	require.Equal(t, `Unknown top-level request fields "max_token" (did you mean "max_tokens"?), "x_custom"`, err.Error())
+}
+
+func TestCheckUnknownRequestFields_StrictAcceptsKnownFields(t *testing.T) {
+	group := &Group{StrictRequestFields: true}
+
+	require.NoError(t, CheckUnknownRequestFields(group, RequestSchemaOpenAIResponses,
+		[]byte(`{"model":"gpt-5","input":"hi","reasoning":{"effort":"high"},"store":false}`)))
+	require.NoError(t, CheckUnknownRequestFields(group, RequestSchemaOpenAIChatCompletion,
+		[]byte(`{"model":"gpt-5","messages":[],"max_completion_tokens":10}`)))
+	require.NoError(t, CheckUnknownRequestFields(group, RequestSchemaGeminiGenerate,
+		[]byte(`{"contents":[],"generationConfig":{}}`)))
+	// 未知 schema 与非对象请求体放行
+	require.NoError(t, CheckUnknownRequestFields(group, "unknown", []byte(`{"foo":1}`)))
+	require.NoError(t, CheckUnknownRequestFields(group, RequestSchemaOpenAIResponses, []byte(`[]`)))
+}
@@ -0,0 +1,374 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/tidwall/gjson"
+	"github.com/tidwall/sjson"
+)
+
+// maxRequestSanitizeRules 净化规则数量上限
+const maxRequestSanitizeRules = 100
+
+// maxRequestSanitizeMessageLen 拒绝说明的最大长度
+const maxRequestSanitizeMessageLen = 500
+
+// requestSanitizeCacheTTL 净化规则本地缓存有效期（管理端修改后最多延迟该时长生效）
+const requestSanitizeCacheTTL = 15 * time.Second
+
+// 净化规则命中后的动作
+const (
+	// RequestSanitizeActionStrip 删除命中的字段/工具后继续转发
+	RequestSanitizeActionStrip = "strip"
+	// RequestSanitizeActionReject 以 400 拒绝请求
+	RequestSanitizeActionReject = "reject"
+)
+
+// anthropicCustomToolType Anthropic 自定义工具未携带 type 字段时使用的类型名
+const anthropicCustomToolType = "custom"
+
+// RequestSanitizeRule 请求净化规则：转发前剔除或拒绝危险/不支持的字段。
+// 字段规则（Path）匹配指定字段（可限定取值，如 store=true）；工具规则（AllowedToolTypes）匹配类型不在白名单中的工具。
+type RequestSanitizeRule struct {
+	// Protocol 限定生效的请求协议（anthropic/openai/gemini），为空表示所有协议
+	Protocol string `json:"protocol,omitempty"`
+	// GroupIDs 限定生效的分组，为空表示所有分组
+	GroupIDs []int64 `json:"group_ids,omitempty"`
+	// Path 字段规则：字段路径，使用 . 分隔嵌套字段（如 store、metadata.user_id）
+	Path string `json:"path,omitempty"`
+	// Values 字段规则：仅当字段值（字符串形式，如 true）在列表中时命中；为空表示字段存在即命中
+	Values []string `json:"values,omitempty"`
+	// AllowedToolTypes 工具规则：允许的工具类型（支持末尾 * 通配），tools 中其他类型的工具命中。
+	// Anthropic/OpenAI 取工具的 type（Anthropic 自定义工具为 custom），Gemini 取工具对象的键名（如 googleSearch）
+	AllowedToolTypes []string `json:"allowed_tool_types,omitempty"`
+	// Action 命中后的动作：strip 删除后继续转发，reject 拒绝请求
+	Action string `json:"action"`
+	// Message 拒绝时返回给客户端的说明，为空时使用默认说明
+	Message string `json:"message,omitempty"`
+}
+
+// RequestSanitizeSettings 请求净化配置
+type RequestSanitizeSettings struct {
+	// Enabled 是否启用请求净化
+	Enabled bool `json:"enabled"`
+	// Rules 净化规则列表，按顺序执行
+	Rules []RequestSanitizeRule `json:"rules"`
+}
+
+// RequestSanitizeRejectError 请求命中 reject 规则
+type RequestSanitizeRejectError struct {
+	Message string
+}
+
+func (e *RequestSanitizeRejectError) Error() string {
+	return e.Message
+}
+
+// DefaultRequestSanitizeSettings 返回默认请求净化配置（关闭、无规则）
+func DefaultRequestSanitizeSettings() *RequestSanitizeSettings {
+	return &RequestSanitizeSettings{Rules: []RequestSanitizeRule{}}
+}
+
+// normalizeRequestSanitizeSettings 清理并校验规则：去除空白、统一协议/动作小写，字段规则与工具规则二选一
+func normalizeRequestSanitizeSettings(settings *RequestSanitizeSettings) error {
+	if len(settings.Rules) > maxRequestSanitizeRules {
+		return fmt.Errorf("too many request sanitize rules (max %d)", maxRequestSanitizeRules)
+	}
+	rules := make([]RequestSanitizeRule, 0, len(settings.Rules))
+	for i, rule := range settings.Rules {
+		rule.Protocol = strings.ToLower(strings.TrimSpace(rule.Protocol))
+		rule.Path = strings.TrimSpace(rule.Path)
+		rule.Action = strings.ToLower(strings.TrimSpace(rule.Action))
+		rule.Message = strings.TrimSpace(rule.Message)
+		rule.Values = normalizeSanitizeList(rule.Values)
+		rule.AllowedToolTypes = normalizeSanitizeList(rule.AllowedToolTypes)
+
+		if err := validateRequestProtocol(rule.Protocol); err != nil {
+			return fmt.Errorf("rule %d: %w", i+1, err)
+		}
+		if rule.Path == "" && rule.AllowedToolTypes == nil {
+			return fmt.Errorf("rule %d: either path or allowed_tool_types is required", i+1)
+		}
+		if rule.Path != "" && rule.AllowedToolTypes != nil {
+			return fmt.Errorf("rule %d: path and allowed_tool_types are mutually exclusive", i+1)
+		}
+		if rule.Path != "" {
+			if err := validateRequestFieldPath(rule.Path); err != nil {
+				return fmt.Errorf("rule %d: %w", i+1, err)
+			}
+		} else if rule.Values != nil {
+			return fmt.Errorf("rule %d: values only apply to path rules", i+1)
+		}
+		switch rule.Action {
+		case RequestSanitizeActionStrip, RequestSanitizeActionReject:
+		default:
+			return fmt.Errorf("rule %d: unsupported action %q", i+1, rule.Action)
+		}
+		if len(rule.Message) > maxRequestSanitizeMessageLen {
+			return fmt.Errorf("rule %d: message too long (max %d)", i+1, maxRequestSanitizeMessageLen)
+		}
+		for _, groupID := range rule.GroupIDs {
+			if groupID <= 0 {
+				return fmt.Errorf("rule %d: invalid group id %d", i+1, groupID)
+			}
+		}
+		rules = append(rules, rule)
+	}
+	settings.Rules = rules
+	return nil
+}
+
+// normalizeSanitizeList 去除空白与空项，结果为空时返回 nil
+func normalizeSanitizeList(values []string) []string {
+	var out []string
+	for _, v := range values {
+		if v = strings.TrimSpace(v); v != "" {
+			out = append(out, v)
+		}
+	}
+	return out
+}
+
+// GetRequestSanitizeSettings 获取请求净化配置
+func (s *SettingService) GetRequestSanitizeSettings(ctx context.Context) (*RequestSanitizeSettings, error) {
+	value, err := s.settingRepo.GetValue(ctx, SettingKeyRequestSanitizeSettings)
+	if err != nil {
+		if errors.Is(err, ErrSettingNotFound) {
+			return DefaultRequestSanitizeSettings(), nil
+		}
+		return nil, fmt.Errorf("get request sanitize settings: %w", err)
+	}
+	if value == "" {
+		return DefaultRequestSanitizeSettings(), nil
+	}
+
+	var settings RequestSanitizeSettings
+	if err := json.Unmarshal([]byte(value), &settings); err != nil {
+		return DefaultRequestSanitizeSettings(), nil
+	}
+	if settings.Rules == nil {
+		settings.Rules = []RequestSanitizeRule{}
+	}
+	return &settings, nil
+}
+
+// SetRequestSanitizeSettings 设置请求净化配置
+func (s *SettingService) SetRequestSanitizeSettings(ctx context.Context, settings *RequestSanitizeSettings) error {
+	if settings == nil {
+		return fmt.Errorf("settings cannot be nil")
+	}
+	if err := normalizeRequestSanitizeSettings(settings); err != nil {
+		return err
+	}
+
+	data, err := json.Marshal(settings)
+	if err != nil {
+		return fmt.Errorf("marshal request sanitize settings: %w", err)
+	}
+	return s.settingRepo.Set(ctx, SettingKeyRequestSanitizeSettings, string(data))
+}
+
+// RequestSanitizeService 在转发前按管理员配置剔除或拒绝请求中的危险/不支持字段
+type RequestSanitizeService struct {
+	settingService *SettingService
+
+	mu        sync.RWMutex
+	cached    *RequestSanitizeSettings
+	expiresAt time.Time
+}
+
+// NewRequestSanitizeService 创建请求净化服务
+func NewRequestSanitizeService(settingService *SettingService) *RequestSanitizeService {
+	return &RequestSanitizeService{settingService: settingService}
+}
+
+// Apply 按协议与 API Key 所属分组匹配规则，返回净化后的请求体与被删除的字段/工具；
+// 命中 reject 规则时返回 *RequestSanitizeRejectError。未启用或未命中时原样返回。
+func (s *RequestSanitizeService) Apply(ctx context.Context, apiKey *APIKey, protocol string, body []byte) ([]byte, []string, error) {
+	if s == nil || len(body) == 0 {
+		return body, nil, nil
+	}
+	settings := s.load(ctx)
+	if settings == nil || !settings.Enabled {
+		return body, nil, nil
+	}
+	var groupID int64
+	if apiKey != nil && apiKey.GroupID != nil {
+		groupID = *apiKey.GroupID
+	}
+	return sanitizeRequest(settings.Rules, protocol, groupID, body)
+}
+
+// Invalidate 清除本地缓存，下次 Apply 时重新加载
+func (s *RequestSanitizeService) Invalidate() {
+	if s == nil {
+		return
+	}
+	s.mu.Lock()
+	s.cached = nil
+	s.expiresAt = time.Time{}
+	s.mu.Unlock()
+}
+
+func (s *RequestSanitizeService) load(ctx context.Context) *RequestSanitizeSettings {
+	now := time.Now()
+	s.mu.RLock()
+	if s.cached != nil && now.Before(s.expiresAt) {
+		cached := s.cached
+		s.mu.RUnlock()
+		return cached
+	}
+	stale := s.cached
+	s.mu.RUnlock()
+
+	if s.settingService == nil {
+		return nil
+	}
+	settings, err := s.settingService.GetRequestSanitizeSettings(ctx)
+	if err != nil {
+		log.Printf("[RequestSanitize] Failed to load settings: %v", err)
+		// 读取失败时沿用旧缓存，避免数据库抖动导致规则短暂失效
+		return stale
+	}
+
+	s.mu.Lock()
+	s.cached = settings
+	s.expiresAt = now.Add(requestSanitizeCacheTTL)
+	s.mu.Unlock()
+	return settings
+}
+
+func sanitizeRequest(rules []RequestSanitizeRule, protocol string, groupID int64, body []byte) ([]byte, []string, error) {
+	protocol = strings.ToLower(protocol)
+	var stripped []string
+	for _, rule := range rules {
+		if rule.Protocol != "" && rule.Protocol != protocol {
+			continue
+		}
+		if len(rule.GroupIDs) > 0 && !containsInt64(rule.GroupIDs, groupID) {
+			continue
+		}
+		if rule.Path != "" {
+			value := gjson.GetBytes(body, rule.Path)
+			if !value.Exists() || !sanitizeValueMatches(rule.Values, value.String()) {
+				continue
+			}
+			if rule.Action == RequestSanitizeActionReject {
+				return body, stripped, &RequestSanitizeRejectError{Message: rejectMessage(rule, fmt.Sprintf("field %s is not allowed", rule.Path))}
+			}
+			newBody, err := sjson.DeleteBytes(body, rule.Path)
+			if err != nil {
+				continue
+			}
+			body = newBody
+			stripped = append(stripped, rule.Path)
+			continue
+		}
+
+		kept, removed := filterRequestTools(body, protocol, rule.AllowedToolTypes)
+		if len(removed) == 0 {
+			continue
+		}
+		if rule.Action == RequestSanitizeActionReject {
+			return body, stripped, &RequestSanitizeRejectError{Message: rejectMessage(rule, fmt.Sprintf("tool type %s is not allowed", removed[0]))}
+		}
+		body = replaceRequestTools(body, protocol, kept)
+		for _, toolType := range removed {
+			stripped = append(stripped, "tools:"+toolType)
+		}
+	}
+	return body, stripped, nil
+}
+
+// sanitizeValueMatches 字段值是否命中取值列表，列表为空时总是命中
+func sanitizeValueMatches(values []string, value string) bool {
+	if len(values) == 0 {
+		return true
+	}
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
+
+func rejectMessage(rule RequestSanitizeRule, fallback string) string {
+	if rule.Message != "" {
+		return rule.Message
+	}
+	return fallback
+}
+
+func toolTypeAllowed(allowed []string, toolType string) bool {
+	for _, pattern := range allowed {
+		if matchWildcard(pattern, toolType) {
+			return true
+		}
+	}
+	return false
+}
+
+// filterRequestTools 按工具类型白名单过滤 tools，返回保留的工具（原始 JSON）与被移除的工具类型。
+// Gemini 的单个工具对象可同时声明多种能力，按键名逐项过滤，全部移除时丢弃该对象。
+func filterRequestTools(body []byte, protocol string, allowed []string) ([]string, []string) {
+	tools := gjson.GetBytes(body, "tools")
+	if !tools.IsArray() {
+		return nil, nil
+	}
+	var kept, removed []string
+	for _, tool := range tools.Array() {
+		if protocol == PlatformGemini {
+			var fields []string
+			tool.ForEach(func(key, value gjson.Result) bool {
+				if toolTypeAllowed(allowed, key.String()) {
+					fields = append(fields, key.Raw+":"+value.Raw)
+				} else {
+					removed = append(removed, key.String())
+				}
+				return true
+			})
+			if len(fields) > 0 {
+				kept = append(kept, "{"+strings.Join(fields, ",")+"}")
+			}
+			continue
+		}
+		toolType := tool.Get("type").String()
+		if toolType == "" && protocol == PlatformAnthropic {
+			toolType = anthropicCustomToolType
+		}
+		if toolTypeAllowed(allowed, toolType) {
+			kept = append(kept, tool.Raw)
+		} else {
+			removed = append(removed, toolType)
+		}
+	}
+	return kept, removed
+}
+
+// replaceRequestTools 写回过滤后的 tools；全部移除时同时删除工具选择字段，避免上游因引用不存在的工具报错
+func replaceRequestTools(body []byte, protocol string, kept []string) []byte {
+	if len(kept) > 0 {
+		if newBody, err := sjson.SetRawBytes(body, "tools", []byte("["+strings.Join(kept, ",")+"]")); err == nil {
+			return newBody
+		}
+		return body
+	}
+	toolChoicePath := "tool_choice"
+	if protocol == PlatformGemini {
+		toolChoicePath = "toolConfig"
+	}
+	for _, path := range []string{"tools", toolChoicePath} {
+		if newBody, err := sjson.DeleteBytes(body, path); err == nil {
+			body = newBody
+		}
+	}
+	return body
+}
@@ -0,0 +1,102 @@
+//go:build unit
+
+package service
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestNormalizeRequestSanitizeSettings(t *testing.T) {
+	settings := &RequestSanitizeSettings{Rules: []RequestSanitizeRule{
+		{Path: " store ", Values: []string{" true ", ""}, Protocol: " OpenAI ", Action: " Reject "},
+		{AllowedToolTypes: []string{"function", " web_search* "}, Action: "strip"},
+	}}
+	require.NoError(t, normalizeRequestSanitizeSettings(settings))
+	require.Equal(t, RequestSanitizeRule{Path: "store", Values: []string{"true"}, Protocol: PlatformOpenAI, Action: RequestSanitizeActionReject}, settings.Rules[0])
+	require.Equal(t, []string{"function", "web_search*"}, settings.Rules[1].AllowedToolTypes)
+
+	invalid := [][]RequestSanitizeRule{
+		{{Action: "strip"}},
+		{{Path: "store", AllowedToolTypes: []string{"function"}, Action: "strip"}},
+		{{Path: "store", Action: "drop"}},
+		{{Path: "model", Action: "strip"}},
+		{{Path: "tools.#.type", Action: "strip"}},
+		{{Path: "store", Protocol: "antigravity", Action: "strip"}},
+		{{AllowedToolTypes: []string{"function"}, Values: []string{"x"}, Action: "strip"}},
+		{{Path: "store", GroupIDs: []int64{0}, Action: "strip"}},
+	}
+	for _, rules := range invalid {
+		require.Error(t, normalizeRequestSanitizeSettings(&RequestSanitizeSettings{Rules: rules}), "%+v", rules)
+	}
+}
+
+func TestSanitizeRequest_FieldRules(t *testing.T) {
+	rules := []RequestSanitizeRule{
+		{Path: "store", Values: []string{"true"}, Protocol: PlatformOpenAI, GroupIDs: []int64{2}, Action: RequestSanitizeActionReject, Message: "store is not allowed in this group"},
+		{Path: "metadata", Action: RequestSanitizeActionStrip},
+	}
+
+	// store=true 仅在限定分组中被拒绝
+	_, _, err := sanitizeRequest(rules, PlatformOpenAI, 2, []byte(`{"model":"gpt-5.2","store":true}`))
+	var rejectErr *RequestSanitizeRejectError
+	require.True(t, errors.As(err, &rejectErr))
+	require.Equal(t, "store is not allowed in this group", rejectErr.Message)
+
+	body, stripped, err := sanitizeRequest(rules, PlatformOpenAI, 2, []byte(`{"model":"gpt-5.2","store":false,"metadata":{"a":1}}`))
+	require.NoError(t, err)
+	require.Equal(t, []string{"metadata"}, stripped)
+	require.JSONEq(t, `{"model":"gpt-5.2","store":false}`, string(body))
+
+	body, stripped, err = sanitizeRequest(rules, PlatformOpenAI, 3, []byte(`{"model":"gpt-5.2","store":true}`))
+	require.NoError(t, err)
+	require.Empty(t, stripped)
+	require.JSONEq(t, `{"model":"gpt-5.2","store":true}`, string(body))
+}
+
+func TestSanitizeRequest_ToolAllowlist(t *testing.T) {
+	strip := []RequestSanitizeRule{{AllowedToolTypes: []string{"custom", "web_search_*"}, Action: RequestSanitizeActionStrip}}
+
+	body, stripped, err := sanitizeRequest(strip, PlatformAnthropic, 0, []byte(`{"tools":[{"name":"get_weather","input_schema":{}},{"type":"bash_20250124","name":"bash"},{"type":"web_search_20250305","name":"web_search"}],"tool_choice":{"type":"auto"}}`))
+	require.NoError(t, err)
+	require.Equal(t, []string{"tools:bash_20250124"}, stripped)
+	require.JSONEq(t, `{"tools":[{"name":"get_weather","input_schema":{}},{"type":"web_search_20250305","name":"web_search"}],"tool_choice":{"type":"auto"}}`, string(body))
+
+	// 全部工具被移除时同时删除工具选择字段
+	body, _, err = sanitizeRequest(strip, PlatformOpenAI, 0, []byte(`{"tools":[{"type":"code_interpreter"}],"tool_choice":"required"}`))
+	require.NoError(t, err)
+	require.JSONEq(t, `{}`, string(body))
+
+	// Gemini 按工具对象的键名过滤
+	gemini := []RequestSanitizeRule{{AllowedToolTypes: []string{"functionDeclarations"}, Action: RequestSanitizeActionStrip}}
+	body, stripped, err = sanitizeRequest(gemini, PlatformGemini, 0, []byte(`{"tools":[{"functionDeclarations":[{"name":"f"}],"googleSearch":{}},{"codeExecution":{}}]}`))
+	require.NoError(t, err)
+	require.Equal(t, []string{"tools:googleSearch", "tools:codeExecution"}, stripped)
+	require.JSONEq(t, `{"tools":[{"functionDeclarations":[{"name":"f"}]}]}`, string(body))
+
+	reject := []RequestSanitizeRule{{AllowedToolTypes: []string{"function"}, Action: RequestSanitizeActionReject}}
+	_, _, err = sanitizeRequest(reject, PlatformOpenAI, 0, []byte(`{"tools":[{"type":"function"},{"type":"mcp"}]}`))
+	require.EqualError(t, err, "tool type mcp is not allowed")
+}
+
+func TestRequestSanitizeService_DisabledOrMissing(t *testing.T) {
+	original := []byte(`{"store":true}`)
+	apiKey := &APIKey{ID: 1}
+
+	repo := &settingRepoStub{values: map[string]string{
+		SettingKeyRequestSanitizeSettings: `{"enabled":false,"rules":[{"path":"store","action":"reject"}]}`,
+	}}
+	body, stripped, err := NewRequestSanitizeService(NewSettingService(repo, nil)).Apply(context.Background(), apiKey, PlatformOpenAI, original)
+	require.NoError(t, err)
+	require.Empty(t, stripped)
+	require.Equal(t, string(original), string(body))
+
+	var nilSvc *RequestSanitizeService
+	body, stripped, err = nilSvc.Apply(context.Background(), apiKey, PlatformOpenAI, original)
+	require.NoError(t, err)
+	require.Empty(t, stripped)
+	require.Equal(t, string(original), string(body))
+}
@@ -0,0 +1,225 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/tidwall/gjson"
+	"github.com/tidwall/sjson"
+)
+
+// maxRequestStripRules 字段剔除规则数量上限
+const maxRequestStripRules = 100
+
+// requestStripCacheTTL 剔除规则本地缓存有效期（管理端修改后最多延迟该时长生效）
+const requestStripCacheTTL = 15 * time.Second
+
+// RequestStripRule 请求预处理规则：在会话 hash 与请求规范化前从请求体中删除指定字段。
+// 用于剔除客户端每次请求注入的随机字段（nonce、metadata 中的时间戳等），避免其破坏粘性会话与幂等。
+type RequestStripRule struct {
+	// Path 要删除的字段路径，使用 . 分隔嵌套字段（如 metadata.nonce）
+	Path string `json:"path"`
+	// Protocol 限定生效的请求协议（anthropic/openai/gemini），为空表示所有协议
+	Protocol string `json:"protocol,omitempty"`
+}
+
+// RequestStripSettings 请求字段剔除配置
+type RequestStripSettings struct {
+	// Enabled 是否启用字段剔除
+	Enabled bool `json:"enabled"`
+	// Rules 剔除规则列表
+	Rules []RequestStripRule `json:"rules"`
+}
+
+// DefaultRequestStripSettings 返回默认字段剔除配置（关闭、无规则）
+func DefaultRequestStripSettings() *RequestStripSettings {
+	return &RequestStripSettings{Rules: []RequestStripRule{}}
+}
+
+// normalizeRequestStripSettings 清理并校验规则：去除空白、统一协议小写、拒绝通配/查询语法与重复规则
+func normalizeRequestStripSettings(settings *RequestStripSettings) error {
+	if len(settings.Rules) > maxRequestStripRules {
+		return fmt.Errorf("too many request strip rules (max %d)", maxRequestStripRules)
+	}
+	seen := make(map[string]struct{}, len(settings.Rules))
+	rules := make([]RequestStripRule, 0, len(settings.Rules))
+	for i, rule := range settings.Rules {
+		rule.Path = strings.TrimSpace(rule.Path)
+		rule.Protocol = strings.ToLower(strings.TrimSpace(rule.Protocol))
+		if err := validateRequestFieldPath(rule.Path); err != nil {
+			return fmt.Errorf("rule %d: %w", i+1, err)
+		}
+		if err := validateRequestProtocol(rule.Protocol); err != nil {
+			return fmt.Errorf("rule %d: %w", i+1, err)
+		}
+		key := rule.Protocol + "|" + rule.Path
+		if _, ok := seen[key]; ok {
+			return fmt.Errorf("rule %d: duplicate rule for %q", i+1, rule.Path)
+		}
+		seen[key] = struct{}{}
+		rules = append(rules, rule)
+	}
+	settings.Rules = rules
+	return nil
+}
+
+// validateRequestFieldPath 校验请求字段路径：仅允许 . 分隔的普通字段名（不支持通配/查询语法），且不能是 model
+func validateRequestFieldPath(path string) error {
+	if path == "" {
+		return errors.New("path is required")
+	}
+	if strings.ContainsAny(path, "*?#|@\\") {
+		return errors.New("path must be a plain dotted field path")
+	}
+	for _, segment := range strings.Split(path, ".") {
+		if segment == "" {
+			return errors.New("path contains empty segment")
+		}
+	}
+	// 模型字段参与路由与计费，不允许剔除
+	if path == "model" {
+		return errors.New("model field cannot be stripped")
+	}
+	return nil
+}
+
+// validateRequestProtocol 校验规则限定的请求协议，为空表示所有协议
+func validateRequestProtocol(protocol string) error {
+	switch protocol {
+	case "", PlatformAnthropic, PlatformOpenAI, PlatformGemini:
+		return nil
+	default:
+		return fmt.Errorf("unsupported protocol %q", protocol)
+	}
+}
+
+// GetRequestStripSettings 获取请求字段剔除配置
+func (s *SettingService) GetRequestStripSettings(ctx context.Context) (*RequestStripSettings, error) {
+	value, err := s.settingRepo.GetValue(ctx, SettingKeyRequestStripSettings)
+	if err != nil {
+		if errors.Is(err, ErrSettingNotFound) {
+			return DefaultRequestStripSettings(), nil
+		}
+		return nil, fmt.Errorf("get request strip settings: %w", err)
+	}
+	if value == "" {
+		return DefaultRequestStripSettings(), nil
+	}
+
+	var settings RequestStripSettings
+	if err := json.Unmarshal([]byte(value), &settings); err != nil {
+		return DefaultRequestStripSettings(), nil
+	}
+	if settings.Rules == nil {
+		settings.Rules = []RequestStripRule{}
+	}
+	return &settings, nil
+}
+
+// SetRequestStripSettings 设置请求字段剔除配置
+func (s *SettingService) SetRequestStripSettings(ctx context.Context, settings *RequestStripSettings) error {
+	if settings == nil {
+		return fmt.Errorf("settings cannot be nil")
+	}
+	if err := normalizeRequestStripSettings(settings); err != nil {
+		return err
+	}
+
+	data, err := json.Marshal(settings)
+	if err != nil {
+		return fmt.Errorf("marshal request strip settings: %w", err)
+	}
+	return s.settingRepo.Set(ctx, SettingKeyRequestStripSettings, string(data))
+}
+
+// RequestStripService 在会话 hash 与请求规范化前按管理员配置剔除请求体中的字段
+type RequestStripService struct {
+	settingService *SettingService
+
+	mu        sync.RWMutex
+	cached    *RequestStripSettings
+	expiresAt time.Time
+}
+
+// NewRequestStripService 创建请求字段剔除服务
+func NewRequestStripService(settingService *SettingService) *RequestStripService {
+	return &RequestStripService{settingService: settingService}
+}
+
+// Apply 按协议匹配规则并删除请求体中存在的字段，返回改写后的请求体与被删除的字段路径；
+// 未启用或未命中时原样返回。
+func (s *RequestStripService) Apply(ctx context.Context, protocol string, body []byte) ([]byte, []string) {
+	if s == nil || len(body) == 0 {
+		return body, nil
+	}
+	settings := s.load(ctx)
+	if settings == nil || !settings.Enabled {
+		return body, nil
+	}
+	return stripRequestFields(settings.Rules, protocol, body)
+}
+
+// Invalidate 清除本地缓存，下次 Apply 时重新加载
+func (s *RequestStripService) Invalidate() {
+	if s == nil {
+		return
+	}
+	s.mu.Lock()
+	s.cached = nil
+	s.expiresAt = time.Time{}
+	s.mu.Unlock()
+}
+
+func (s *RequestStripService) load(ctx context.Context) *RequestStripSettings {
+	now := time.Now()
+	s.mu.RLock()
+	if s.cached != nil && now.Before(s.expiresAt) {
+		cached := s.cached
+		s.mu.RUnlock()
+		return cached
+	}
+	stale := s.cached
+	s.mu.RUnlock()
+
+	if s.settingService == nil {
+		return nil
+	}
+	settings, err := s.settingService.GetRequestStripSettings(ctx)
+	if err != nil {
+		log.Printf("[RequestStrip] Failed to load settings: %v", err)
+		// 读取失败时沿用旧缓存，避免数据库抖动导致规则短暂失效
+		return stale
+	}
+
+	s.mu.Lock()
+	s.cached = settings
+	s.expiresAt = now.Add(requestStripCacheTTL)
+	s.mu.Unlock()
+	return settings
+}
+
+func stripRequestFields(rules []RequestStripRule, protocol string, body []byte) ([]byte, []string) {
+	protocol = strings.ToLower(protocol)
+	var stripped []string
+	for _, rule := range rules {
+		if rule.Protocol != "" && rule.Protocol != protocol {
+			continue
+		}
+		if !gjson.GetBytes(body, rule.Path).Exists() {
+			continue
+		}
+		newBody, err := sjson.DeleteBytes(body, rule.Path)
+		if err != nil {
+			continue
+		}
+		body = newBody
+		stripped = append(stripped, rule.Path)
+	}
+	return body, stripped
+}
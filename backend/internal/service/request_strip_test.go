@@ -0,0 +1,71 @@
+//go:build unit
+
+package service
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestNormalizeRequestStripSettings(t *testing.T) {
+	settings := &RequestStripSettings{Rules: []RequestStripRule{{Path: " metadata.nonce ", Protocol: " Anthropic "}}}
+	require.NoError(t, normalizeRequestStripSettings(settings))
+	require.Equal(t, RequestStripRule{Path: "metadata.nonce", Protocol: PlatformAnthropic}, settings.Rules[0])
+
+	invalid := [][]RequestStripRule{
+		{{Path: ""}},
+		{{Path: "model"}},
+		{{Path: "messages.#.nonce"}},
+		{{Path: "metadata.*"}},
+		{{Path: "metadata..nonce"}},
+		{{Path: "nonce", Protocol: "antigravity"}},
+		{{Path: "nonce"}, {Path: "nonce"}},
+	}
+	for _, rules := range invalid {
+		require.Error(t, normalizeRequestStripSettings(&RequestStripSettings{Rules: rules}), "%+v", rules)
+	}
+}
+
+func TestRequestStripService_Apply(t *testing.T) {
+	repo := &settingRepoStub{values: map[string]string{
+		SettingKeyRequestStripSettings: `{"enabled":true,"rules":[{"path":"metadata.nonce"},{"path":"metadata.ts"},{"path":"request_id","protocol":"openai"}]}`,
+	}}
+	svc := NewRequestStripService(NewSettingService(repo, nil))
+
+	body, stripped := svc.Apply(context.Background(), PlatformAnthropic, []byte(`{"model":"claude","metadata":{"user_id":"u1","nonce":"abc","ts":123},"request_id":"r1"}`))
+	require.Equal(t, []string{"metadata.nonce", "metadata.ts"}, stripped)
+	require.JSONEq(t, `{"model":"claude","metadata":{"user_id":"u1"},"request_id":"r1"}`, string(body))
+
+	// 限定协议的规则仅对对应协议生效
+	body, stripped = svc.Apply(context.Background(), PlatformOpenAI, []byte(`{"model":"gpt-5.2","request_id":"r1"}`))
+	require.Equal(t, []string{"request_id"}, stripped)
+	require.JSONEq(t, `{"model":"gpt-5.2"}`, string(body))
+
+	// 未命中时原样返回
+	original := []byte(`{"model":"gpt-5.2"}`)
+	body, stripped = svc.Apply(context.Background(), PlatformGemini, original)
+	require.Empty(t, stripped)
+	require.Equal(t, string(original), string(body))
+}
+
+func TestRequestStripService_DisabledOrMissing(t *testing.T) {
+	original := []byte(`{"metadata":{"nonce":"abc"}}`)
+
+	repo := &settingRepoStub{values: map[string]string{
+		SettingKeyRequestStripSettings: `{"enabled":false,"rules":[{"path":"metadata.nonce"}]}`,
+	}}
+	body, stripped := NewRequestStripService(NewSettingService(repo, nil)).Apply(context.Background(), PlatformAnthropic, original)
+	require.Empty(t, stripped)
+	require.Equal(t, string(original), string(body))
+
+	body, stripped = NewRequestStripService(NewSettingService(&settingRepoStub{}, nil)).Apply(context.Background(), PlatformAnthropic, original)
+	require.Empty(t, stripped)
+	require.Equal(t, string(original), string(body))
+
+	var nilSvc *RequestStripService
+	body, stripped = nilSvc.Apply(context.Background(), PlatformAnthropic, original)
+	require.Empty(t, stripped)
+	require.Equal(t, string(original), string(body))
+}
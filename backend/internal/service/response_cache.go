@@ -0,0 +1,159 @@
+package service
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"log"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/Wei-Shaw/sub2api/internal/config"
+	"github.com/google/uuid"
+	"github.com/tidwall/gjson"
+)
+
+const (
+	// ResponseCacheHeader 客户端缓存指令请求头：on 表示即使 temperature 不为 0 也缓存，off 表示跳过缓存
+	ResponseCacheHeader = "X-Sub2API-Cache"
+	// ResponseCacheStatusHeader 缓存结果响应头（HIT/MISS）
+	ResponseCacheStatusHeader = "X-Cache"
+	// responseCacheRequestIDPrefix 缓存命中用量记录的 request_id 前缀，便于在用量明细中区分
+	responseCacheRequestIDPrefix = "cache-hit-"
+)
+
+// CachedResponse 缓存的上游响应
+type CachedResponse struct {
+	StatusCode  int    `json:"status_code"`
+	ContentType string `json:"content_type"`
+	Body        []byte `json:"body"`
+	// AccountID/Model 生成该响应的上游账号与模型，命中时用于记录用量
+	AccountID int64  `json:"account_id,omitempty"`
+	Model     string `json:"model,omitempty"`
+}
+
+// ResponseCache 响应缓存存储
+type ResponseCache interface {
+	// GetCachedResponse 查询缓存，不存在时返回 nil, nil
+	GetCachedResponse(ctx context.Context, key string) (*CachedResponse, error)
+	SetCachedResponse(ctx context.Context, key string, resp *CachedResponse, ttl time.Duration) error
+}
+
+// ResponseCacheService 相同非流式请求的响应缓存，命中时不请求上游
+type ResponseCacheService struct {
+	cfg          config.GatewayResponseCacheConfig
+	cache        ResponseCache
+	usageLogRepo UsageLogRepository
+}
+
+// NewResponseCacheService 创建响应缓存服务
+func NewResponseCacheService(cfg *config.Config, cache ResponseCache, usageLogRepo UsageLogRepository) *ResponseCacheService {
+	s := &ResponseCacheService{cache: cache, usageLogRepo: usageLogRepo}
+	if cfg != nil {
+		s.cfg = cfg.Gateway.ResponseCache
+	}
+	return s
+}
+
+// Enabled 是否启用响应缓存
+func (s *ResponseCacheService) Enabled() bool {
+	return s != nil && s.cfg.Enabled && s.cache != nil
+}
+
+// MaxResponseBytes 可缓存的响应体上限
+func (s *ResponseCacheService) MaxResponseBytes() int {
+	if s == nil {
+		return 0
+	}
+	return s.cfg.MaxResponseBytes
+}
+
+// CacheKey 计算请求的缓存 key，请求不可缓存时返回空字符串。
+// 只缓存非流式请求，且 temperature 为 0 或客户端通过 X-Sub2API-Cache: on 显式要求缓存；
+// key 按 API Key 与路由隔离，body 应为最终转发前的请求体（模型别名、提示词模板等改写之后）。
+func (s *ResponseCacheService) CacheKey(apiKeyID int64, route string, body []byte, stream bool, directive string) string {
+	if !s.Enabled() || stream || len(body) == 0 {
+		return ""
+	}
+	switch strings.ToLower(strings.TrimSpace(directive)) {
+	case "on", "true", "force":
+	case "off", "false", "bypass", "no-cache":
+		return ""
+	default:
+		temp := gjson.GetBytes(body, "temperature")
+		if temp.Type != gjson.Number || temp.Float() != 0 {
+			return ""
+		}
+	}
+	h := sha256.New()
+	h.Write([]byte("response-cache:" + strconv.FormatInt(apiKeyID, 10) + ":" + route + ":"))
+	h.Write(body)
+	return hex.EncodeToString(h.Sum(nil))
+}
+
+// Lookup 查询缓存，未命中或查询失败时返回 nil
+func (s *ResponseCacheService) Lookup(ctx context.Context, key string) *CachedResponse {
+	if !s.Enabled() || key == "" {
+		return nil
+	}
+	resp, err := s.cache.GetCachedResponse(ctx, key)
+	if err != nil {
+		log.Printf("[ResponseCache] lookup failed: err=%v", err)
+		return nil
+	}
+	return resp
+}
+
+// Store 缓存成功的响应，非 200 或超过大小上限的响应不缓存
+func (s *ResponseCacheService) Store(ctx context.Context, key string, resp *CachedResponse) {
+	if !s.Enabled() || key == "" || resp == nil || resp.StatusCode != http.StatusOK {
+		return
+	}
+	if len(resp.Body) == 0 || len(resp.Body) > s.cfg.MaxResponseBytes {
+		return
+	}
+	if err := s.cache.SetCachedResponse(ctx, key, resp, time.Duration(s.cfg.TTLSeconds)*time.Second); err != nil {
+		log.Printf("[ResponseCache] store failed: err=%v", err)
+	}
+}
+
+// RecordHit 为缓存命中记录一条零费用的用量日志：命中不请求上游、不扣费，但计入请求数与用量明细。
+// 缓存条目未记录来源账号（旧条目）时跳过
+func (s *ResponseCacheService) RecordHit(ctx context.Context, apiKey *APIKey, subscription *UserSubscription, cached *CachedResponse, userAgent, ipAddress string) {
+	if s == nil || s.usageLogRepo == nil || apiKey == nil || cached == nil || cached.AccountID <= 0 {
+		return
+	}
+	billingType := BillingTypeBalance
+	if subscription != nil && apiKey.Group != nil && apiKey.Group.IsSubscriptionType() {
+		billingType = BillingTypeSubscription
+	}
+	durationMs := 0
+	usageLog := &UsageLog{
+		UserID:         apiKey.UserID,
+		APIKeyID:       apiKey.ID,
+		AccountID:      cached.AccountID,
+		RequestID:      responseCacheRequestIDPrefix + uuid.NewString(),
+		Model:          cached.Model,
+		GroupID:        apiKey.GroupID,
+		RateMultiplier: 1,
+		BillingType:    billingType,
+		MeteringOnly:   apiKey.Group.IsMeteringOnly(),
+		DurationMs:     &durationMs,
+		CreatedAt:      time.Now(),
+	}
+	if subscription != nil {
+		usageLog.SubscriptionID = &subscription.ID
+	}
+	if userAgent != "" {
+		usageLog.UserAgent = &userAgent
+	}
+	if ipAddress != "" {
+		usageLog.IPAddress = &ipAddress
+	}
+	redactUsageLogPII(usageLog)
+	if _, err := s.usageLogRepo.Create(ctx, usageLog); err != nil {
+		log.Printf("[ResponseCache] record hit usage failed: api_key_id=%d err=%v", apiKey.ID, err)
+	}
+}
@@ -0,0 +1,71 @@
+//go:build unit
+
+package service
+
+import (
+	"context"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/Wei-Shaw/sub2api/internal/config"
+	"github.com/stretchr/testify/require"
+)
+
+type responseCacheStub struct {
+	items map[string]*CachedResponse
+	ttl   time.Duration
+}
+
+func (c *responseCacheStub) GetCachedResponse(ctx context.Context, key string) (*CachedResponse, error) {
+	return c.items[key], nil
+}
+
+func (c *responseCacheStub) SetCachedResponse(ctx context.Context, key string, resp *CachedResponse, ttl time.Duration) error {
+	c.items[key] = resp
+	c.ttl = ttl
+	return nil
+}
+
+func newResponseCacheTestService() (*ResponseCacheService, *responseCacheStub) {
+	cfg := &config.Config{}
+	cfg.Gateway.ResponseCache = config.GatewayResponseCacheConfig{Enabled: true, TTLSeconds: 60, MaxResponseBytes: 16}
+	cache := &responseCacheStub{items: map[string]*CachedResponse{}}
+	return NewResponseCacheService(cfg, cache, nil), cache
+}
+
+func TestResponseCacheService_CacheKeyEligibility(t *testing.T) {
+	svc, _ := newResponseCacheTestService()
+	zeroTemp := []byte(`{"model":"m","temperature":0,"input":"hi"}`)
+	warmTemp := []byte(`{"model":"m","temperature":0.7,"input":"hi"}`)
+
+	key := svc.CacheKey(1, "/v1/responses", zeroTemp, false, "")
+	require.NotEmpty(t, key)
+	require.Equal(t, key, svc.CacheKey(1, "/v1/responses", zeroTemp, false, ""))
+	// 按 API Key 与路由隔离
+	require.NotEqual(t, key, svc.CacheKey(2, "/v1/responses", zeroTemp, false, ""))
+	require.NotEqual(t, key, svc.CacheKey(1, "/v1/chat/completions", zeroTemp, false, ""))
+
+	require.Empty(t, svc.CacheKey(1, "/v1/responses", zeroTemp, true, ""))
+	require.Empty(t, svc.CacheKey(1, "/v1/responses", zeroTemp, false, "off"))
+	require.Empty(t, svc.CacheKey(1, "/v1/responses", warmTemp, false, ""))
+	require.Empty(t, svc.CacheKey(1, "/v1/responses", []byte(`{"model":"m"}`), false, ""))
+	require.NotEmpty(t, svc.CacheKey(1, "/v1/responses", warmTemp, false, "on"))
+
+	disabled := NewResponseCacheService(&config.Config{}, &responseCacheStub{}, nil)
+	require.Empty(t, disabled.CacheKey(1, "/v1/responses", zeroTemp, false, "on"))
+}
+
+func TestResponseCacheService_StoreOnlySuccessWithinLimit(t *testing.T) {
+	svc, cache := newResponseCacheTestService()
+	ctx := context.Background()
+
+	svc.Store(ctx, "ok", &CachedResponse{StatusCode: http.StatusOK, ContentType: "application/json", Body: []byte(`{"a":1}`)})
+	svc.Store(ctx, "error", &CachedResponse{StatusCode: http.StatusBadRequest, Body: []byte(`{}`)})
+	svc.Store(ctx, "large", &CachedResponse{StatusCode: http.StatusOK, Body: []byte(`{"a":"0123456789abcdef"}`)})
+
+	require.Len(t, cache.items, 1)
+	require.Equal(t, 60*time.Second, cache.ttl)
+	require.Equal(t, []byte(`{"a":1}`), svc.Lookup(ctx, "ok").Body)
+	require.Nil(t, svc.Lookup(ctx, "large"))
+}
@@ -0,0 +1,178 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"math"
+	mathrand "math/rand"
+	"sort"
+	"time"
+
+	"github.com/Wei-Shaw/sub2api/internal/config"
+	"github.com/Wei-Shaw/sub2api/internal/domain"
+	infraerrors "github.com/Wei-Shaw/sub2api/internal/pkg/errors"
+)
+
+type RetryPolicy = domain.RetryPolicy
+
+const (
+	// defaultRetryBackoffMultiplier 未配置倍数时的指数退避倍数
+	defaultRetryBackoffMultiplier = 2.0
+	// maxRetryPolicyAttempts 分组可配置的最大账号切换次数上限
+	maxRetryPolicyAttempts = 20
+)
+
+// NormalizeRetryPolicy 校验并清理分组重试策略：状态码去重排序，数值字段不能为负
+func NormalizeRetryPolicy(policy RetryPolicy) (RetryPolicy, error) {
+	invalid := func(msg string) (RetryPolicy, error) {
+		return policy, infraerrors.BadRequest("INVALID_RETRY_POLICY", "retry_policy: "+msg)
+	}
+	if policy.MaxAttempts < 0 || policy.MaxAttempts > maxRetryPolicyAttempts {
+		return invalid(fmt.Sprintf("max_attempts must be between 0 and %d", maxRetryPolicyAttempts))
+	}
+	if policy.InitialBackoffMs < 0 || policy.MaxBackoffMs < 0 || policy.TotalBudgetMs < 0 {
+		return invalid("backoff and budget must not be negative")
+	}
+	if policy.MaxBackoffMs > 0 && policy.MaxBackoffMs < policy.InitialBackoffMs {
+		return invalid("max_backoff_ms must not be less than initial_backoff_ms")
+	}
+	if policy.BackoffMultiplier != 0 && policy.BackoffMultiplier < 1 {
+		return invalid("backoff_multiplier must be >= 1")
+	}
+	if policy.Jitter < 0 || policy.Jitter > 1 {
+		return invalid("jitter must be between 0 and 1")
+	}
+
+	if len(policy.RetryStatusCodes) > 0 {
+		seen := make(map[int]struct{}, len(policy.RetryStatusCodes))
+		codes := make([]int, 0, len(policy.RetryStatusCodes))
+		for _, code := range policy.RetryStatusCodes {
+			if code < 100 || code > 599 {
+				return invalid(fmt.Sprintf("invalid status code %d", code))
+			}
+			if _, ok := seen[code]; ok {
+				continue
+			}
+			seen[code] = struct{}{}
+			codes = append(codes, code)
+		}
+		sort.Ints(codes)
+		policy.RetryStatusCodes = codes
+	}
+	return policy, nil
+}
+
+// RetryController 单次请求的重试控制器：按分组重试策略决定上游失败后是否切换账号重试及退避时长
+type RetryController struct {
+	policy      RetryPolicy
+	maxSwitches int
+	deadline    time.Time // 零值表示不限制总时间
+}
+
+// ResolveRetryController 计算本次请求的重试控制器与故障转移预算。
+// 切换次数优先级：API Key 优先级类别 > 分组 max_attempts > 全局 max_account_switches；
+// 分组关闭故障转移时切换次数与对冲均为 0。
+func ResolveRetryController(group *Group, classes map[string]config.GatewayFailoverClassConfig, priorityClass string, defaultSwitches int) (*RetryController, FailoverBudget) {
+	var policy RetryPolicy
+	if group != nil {
+		policy = group.RetryPolicy
+	}
+	if policy.MaxAttempts > 0 {
+		defaultSwitches = policy.MaxAttempts
+	}
+	budget := ResolveFailoverBudget(classes, priorityClass, defaultSwitches)
+	if policy.DisableFailover {
+		budget.MaxAccountSwitches = 0
+		budget.HedgeAfter = 0
+	}
+	return NewRetryController(policy, budget.MaxAccountSwitches), budget
+}
+
+// NewRetryController 创建重试控制器，总时间预算从此刻开始计算
+func NewRetryController(policy RetryPolicy, maxSwitches int) *RetryController {
+	r := &RetryController{policy: policy, maxSwitches: maxSwitches}
+	if policy.TotalBudgetMs > 0 {
+		r.deadline = time.Now().Add(time.Duration(policy.TotalBudgetMs) * time.Millisecond)
+	}
+	return r
+}
+
+// MaxAccountSwitches 最大账号切换次数
+func (r *RetryController) MaxAccountSwitches() int {
+	return r.maxSwitches
+}
+
+// ShouldRetry 判断上游失败后是否继续切换账号重试：
+// 切换次数未用尽、状态码在可重试列表中、且退避后仍在总时间预算内。
+func (r *RetryController) ShouldRetry(statusCode int, switchCount int) bool {
+	if switchCount >= r.maxSwitches {
+		return false
+	}
+	if !r.policy.RetriesStatus(statusCode) {
+		return false
+	}
+	if !r.deadline.IsZero() && time.Now().Add(r.baseBackoff(switchCount+1)).After(r.deadline) {
+		return false
+	}
+	return true
+}
+
+// FailoverEnabled 分组是否允许故障转移（含同账号重试）
+func (r *RetryController) FailoverEnabled() bool {
+	return r == nil || !r.policy.DisableFailover
+}
+
+// PassthroughUpstreamError 最终错误是否原样返回上游状态码与响应体
+func (r *RetryController) PassthroughUpstreamError() bool {
+	return r != nil && r.policy.PassthroughUpstreamError
+}
+
+// HasBackoff 分组是否配置了重试退避
+func (r *RetryController) HasBackoff() bool {
+	return r.policy.InitialBackoffMs > 0
+}
+
+// Backoff 计算第 attempt 次重试（从 1 开始）前的退避时长（含抖动）
+func (r *RetryController) Backoff(attempt int) time.Duration {
+	d := r.baseBackoff(attempt)
+	if d <= 0 || r.policy.Jitter <= 0 {
+		return d
+	}
+	return d - time.Duration(float64(d)*r.policy.Jitter*mathrand.Float64())
+}
+
+// Wait 按退避时长等待，返回 false 表示 context 已取消
+func (r *RetryController) Wait(ctx context.Context, attempt int) bool {
+	d := r.Backoff(attempt)
+	if d <= 0 {
+		return true
+	}
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+	select {
+	case <-ctx.Done():
+		return false
+	case <-timer.C:
+		return true
+	}
+}
+
+// baseBackoff 不含抖动的指数退避：initial * multiplier^(attempt-1)，受 max_backoff_ms 限制
+func (r *RetryController) baseBackoff(attempt int) time.Duration {
+	if r.policy.InitialBackoffMs <= 0 || attempt <= 0 {
+		return 0
+	}
+	multiplier := r.policy.BackoffMultiplier
+	if multiplier <= 0 {
+		multiplier = defaultRetryBackoffMultiplier
+	}
+	ms := float64(r.policy.InitialBackoffMs) * math.Pow(multiplier, float64(attempt-1))
+	if r.policy.MaxBackoffMs > 0 && ms > float64(r.policy.MaxBackoffMs) {
+		ms = float64(r.policy.MaxBackoffMs)
+	}
+	// 防止倍数过大导致溢出
+	if ms > float64(time.Hour/time.Millisecond) {
+		ms = float64(time.Hour / time.Millisecond)
+	}
+	return time.Duration(ms) * time.Millisecond
+}
@@ -0,0 +1,102 @@
+//go:build unit
+
+package service
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/Wei-Shaw/sub2api/internal/config"
+	"github.com/stretchr/testify/require"
+)
+
+func TestResolveRetryController_MaxSwitchesPrecedence(t *testing.T) {
+	classes := map[string]config.GatewayFailoverClassConfig{
+		PriorityClassBatch: {MaxAccountSwitches: 8},
+	}
+
+	retry, _ := ResolveRetryController(nil, classes, "", 10)
+	require.Equal(t, 10, retry.MaxAccountSwitches())
+
+	group := &Group{RetryPolicy: RetryPolicy{MaxAttempts: 3}}
+	retry, _ = ResolveRetryController(group, classes, "", 10)
+	require.Equal(t, 3, retry.MaxAccountSwitches())
+
+	// API Key 优先级类别覆盖分组配置
+	retry, _ = ResolveRetryController(group, classes, PriorityClassBatch, 10)
+	require.Equal(t, 8, retry.MaxAccountSwitches())
+}
+
+func TestRetryController_ShouldRetry(t *testing.T) {
+	retry := NewRetryController(RetryPolicy{RetryStatusCodes: []int{429, 529}}, 2)
+	require.True(t, retry.ShouldRetry(429, 0))
+	require.True(t, retry.ShouldRetry(529, 1))
+	require.False(t, retry.ShouldRetry(529, 2), "切换次数用尽")
+	require.False(t, retry.ShouldRetry(400, 0), "状态码不在可重试列表中")
+
+	// 未配置状态码时所有可故障转移的错误都重试（与原有行为一致）
+	require.True(t, NewRetryController(RetryPolicy{}, 1).ShouldRetry(500, 0))
+
+	// 退避后会超出总时间预算时不再重试
+	budgeted := NewRetryController(RetryPolicy{InitialBackoffMs: 1000, TotalBudgetMs: 500}, 5)
+	require.False(t, budgeted.ShouldRetry(503, 0))
+}
+
+func TestRetryController_Backoff(t *testing.T) {
+	retry := NewRetryController(RetryPolicy{InitialBackoffMs: 100, MaxBackoffMs: 500, BackoffMultiplier: 3}, 5)
+	require.Equal(t, 100*time.Millisecond, retry.Backoff(1))
+	require.Equal(t, 300*time.Millisecond, retry.Backoff(2))
+	require.Equal(t, 500*time.Millisecond, retry.Backoff(3), "受 max_backoff_ms 限制")
+
+	jittered := NewRetryController(RetryPolicy{InitialBackoffMs: 1000, Jitter: 0.5}, 5)
+	for i := 0; i < 20; i++ {
+		d := jittered.Backoff(1)
+		require.GreaterOrEqual(t, d, 500*time.Millisecond)
+		require.LessOrEqual(t, d, time.Second)
+	}
+
+	require.Equal(t, time.Duration(0), NewRetryController(RetryPolicy{}, 1).Backoff(1))
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	require.False(t, NewRetryController(RetryPolicy{InitialBackoffMs: 1000}, 1).Wait(ctx, 1))
+}
+
+func TestNormalizeRetryPolicy(t *testing.T) {
+	policy, err := NormalizeRetryPolicy(RetryPolicy{RetryStatusCodes: []int{529, 429, 529}})
+	require.NoError(t, err)
+	require.Equal(t, []int{429, 529}, policy.RetryStatusCodes)
+
+	invalid := []RetryPolicy{
+		{RetryStatusCodes: []int{99}},
+		{MaxAttempts: -1},
+		{InitialBackoffMs: 500, MaxBackoffMs: 100},
+		{BackoffMultiplier: 0.5},
+		{Jitter: 1.5},
+		{TotalBudgetMs: -1},
+	}
+	for _, p := range invalid {
+		_, err := NormalizeRetryPolicy(p)
+		require.Error(t, err, "%+v", p)
+	}
+}
+
+func TestResolveRetryController_DisableFailover(t *testing.T) {
+	classes := map[string]config.GatewayFailoverClassConfig{
+		PriorityClassInteractive: {MaxAccountSwitches: 2, HedgeAfterMs: 500},
+	}
+	group := &Group{RetryPolicy: RetryPolicy{DisableFailover: true, MaxAttempts: 5}}
+
+	retry, budget := ResolveRetryController(group, classes, PriorityClassInteractive, 10)
+	require.Equal(t, 0, retry.MaxAccountSwitches())
+	require.Equal(t, time.Duration(0), budget.HedgeAfter, "关闭故障转移时不发起对冲")
+	require.False(t, retry.FailoverEnabled())
+	require.False(t, retry.ShouldRetry(529, 0))
+
+	retry, _ = ResolveRetryController(&Group{}, classes, "", 10)
+	require.True(t, retry.FailoverEnabled())
+	require.False(t, retry.PassthroughUpstreamError())
+	require.True(t, NewRetryController(RetryPolicy{PassthroughUpstreamError: true}, 1).PassthroughUpstreamError())
+	require.False(t, RetryPolicy{PassthroughUpstreamError: true}.IsEmpty())
+}
@@ -0,0 +1,137 @@
+package service
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+const scalingSignalCacheTTL = 5 * time.Second
+
+// ScalingSignal 机器可读的扩缩容信号，供 HPA / KEDA 外部指标采集。
+// queue_depth / slot_saturation 为集群维度（基于 Redis 中的槽位与等待计数），
+// local_* 与 shed_* 为当前实例维度。
+type ScalingSignal struct {
+	QueueDepth      int64   `json:"queue_depth"`
+	SlotsInUse      int64   `json:"slots_in_use"`
+	SlotCapacity    int64   `json:"slot_capacity"`
+	SlotSaturation  float64 `json:"slot_saturation"`
+	LocalQueueDepth int64   `json:"local_queue_depth"`
+	ShedTotal       int64   `json:"shed_total"`
+	ShedLastMinute  int64   `json:"shed_last_minute"`
+	ShedRatePerSec  float64 `json:"shed_rate_per_sec"`
+	CollectedAt     int64   `json:"collected_at"`
+}
+
+// ScalingSignalService 聚合等待队列深度、槽位饱和度与排队拒绝率
+type ScalingSignalService struct {
+	accountRepo        AccountRepository
+	concurrencyService *ConcurrencyService
+
+	mu       sync.Mutex
+	cached   *ScalingSignal
+	cachedAt time.Time
+}
+
+func NewScalingSignalService(accountRepo AccountRepository, concurrencyService *ConcurrencyService) *ScalingSignalService {
+	return &ScalingSignalService{
+		accountRepo:        accountRepo,
+		concurrencyService: concurrencyService,
+	}
+}
+
+// GetSignal 返回当前扩缩容信号；集群维度数据缓存 scalingSignalCacheTTL，避免高频采集压垮 Redis/DB
+func (s *ScalingSignalService) GetSignal(ctx context.Context) (*ScalingSignal, error) {
+	now := time.Now()
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.cached == nil || now.Sub(s.cachedAt) >= scalingSignalCacheTTL {
+		signal, err := s.collectClusterSignal(ctx)
+		if err != nil {
+			return nil, err
+		}
+		s.cached = signal
+		s.cachedAt = now
+	}
+
+	signal := *s.cached
+	signal.LocalQueueDepth = s.concurrencyService.LocalWaitingCount()
+	signal.ShedTotal, signal.ShedLastMinute = s.concurrencyService.ShedCounts()
+	signal.ShedRatePerSec = float64(signal.ShedLastMinute) / time.Minute.Seconds()
+	signal.CollectedAt = now.Unix()
+	return &signal, nil
+}
+
+func (s *ScalingSignalService) collectClusterSignal(ctx context.Context) (*ScalingSignal, error) {
+	accounts, err := s.accountRepo.ListSchedulable(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	batch := make([]AccountWithConcurrency, 0, len(accounts))
+	var capacity int64
+	for i := range accounts {
+		batch = append(batch, AccountWithConcurrency{
+			ID:             accounts[i].ID,
+			MaxConcurrency: accounts[i].Concurrency,
+		})
+		if accounts[i].Concurrency > 0 {
+			capacity += int64(accounts[i].Concurrency)
+		}
+	}
+
+	loadMap, err := s.concurrencyService.GetAccountsLoadBatch(ctx, batch)
+	if err != nil {
+		return nil, err
+	}
+
+	signal := &ScalingSignal{SlotCapacity: capacity}
+	for _, info := range loadMap {
+		if info == nil {
+			continue
+		}
+		signal.SlotsInUse += int64(info.CurrentConcurrency)
+		signal.QueueDepth += int64(info.WaitingCount)
+	}
+	if capacity > 0 {
+		signal.SlotSaturation = float64(signal.SlotsInUse) / float64(capacity)
+	}
+	return signal, nil
+}
+
+// shedCounter 以秒级分桶统计最近一分钟的排队拒绝次数
+type shedCounter struct {
+	mu      sync.Mutex
+	total   int64
+	buckets [60]int64
+	stamps  [60]int64
+}
+
+func (c *shedCounter) Add(now time.Time) {
+	sec := now.Unix()
+	idx := sec % int64(len(c.buckets))
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.total++
+	if c.stamps[idx] != sec {
+		c.stamps[idx] = sec
+		c.buckets[idx] = 0
+	}
+	c.buckets[idx]++
+}
+
+func (c *shedCounter) Counts(now time.Time) (total int64, lastMinute int64) {
+	sec := now.Unix()
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	for i := range c.buckets {
+		if sec-c.stamps[i] < int64(len(c.buckets)) {
+			lastMinute += c.buckets[i]
+		}
+	}
+	return c.total, lastMinute
+}
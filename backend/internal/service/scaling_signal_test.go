@@ -0,0 +1,46 @@
+//go:build unit
+
+package service
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestShedCounter_SlidingMinute(t *testing.T) {
+	var c shedCounter
+	base := time.Unix(1_700_000_000, 0)
+
+	c.Add(base)
+	c.Add(base)
+	c.Add(base.Add(30 * time.Second))
+
+	total, lastMinute := c.Counts(base.Add(30 * time.Second))
+	require.Equal(t, int64(3), total)
+	require.Equal(t, int64(3), lastMinute)
+
+	total, lastMinute = c.Counts(base.Add(75 * time.Second))
+	require.Equal(t, int64(3), total)
+	require.Equal(t, int64(1), lastMinute)
+}
+
+func TestConcurrencyService_LocalWaitingAndShed(t *testing.T) {
+	svc := NewConcurrencyService(nil)
+	ctx := context.Background()
+
+	ok, err := svc.IncrementWaitCount(ctx, 1, 1)
+	require.NoError(t, err)
+	require.True(t, ok)
+	require.Equal(t, int64(1), svc.LocalWaitingCount())
+
+	svc.DecrementWaitCount(ctx, 1)
+	require.Equal(t, int64(0), svc.LocalWaitingCount())
+
+	svc.recordWaitResult(false)
+	total, lastMinute := svc.ShedCounts()
+	require.Equal(t, int64(1), total)
+	require.Equal(t, int64(1), lastMinute)
+}
@@ -46,6 +46,27 @@ func TestFilterByMinPriority(t *testing.T) {
 		require.Equal(t, int64(2), result[0].account.ID)
 		require.Equal(t, int64(4), result[1].account.ID)
 	})
+
+	t.Run("tier takes precedence over priority", func(t *testing.T) {
+		accounts := []accountWithLoad{
+			{account: &Account{ID: 1, Priority: 1, Extra: map[string]any{"tier": "backup"}}, loadInfo: &AccountLoadInfo{}},
+			{account: &Account{ID: 2, Priority: 9}, loadInfo: &AccountLoadInfo{}},
+			{account: &Account{ID: 3, Priority: 5, Extra: map[string]any{"tier": 2}}, loadInfo: &AccountLoadInfo{}},
+		}
+		result := filterByMinPriority(accounts)
+		require.Len(t, result, 1)
+		require.Equal(t, int64(2), result[0].account.ID)
+	})
+
+	t.Run("overflows to next tier when primary is absent", func(t *testing.T) {
+		accounts := []accountWithLoad{
+			{account: &Account{ID: 1, Priority: 1, Extra: map[string]any{"tier": "backup"}}, loadInfo: &AccountLoadInfo{}},
+			{account: &Account{ID: 2, Priority: 5, Extra: map[string]any{"tier": "secondary"}}, loadInfo: &AccountLoadInfo{}},
+		}
+		result := filterByMinPriority(accounts)
+		require.Len(t, result, 1)
+		require.Equal(t, int64(2), result[0].account.ID)
+	})
 }
 
 func TestFilterByMinLoadRate(t *testing.T) {
@@ -31,6 +31,8 @@ type SchedulerSnapshotService struct {
 	fallbackLimit *fallbackLimiter
 	lagMu         sync.Mutex
 	lagFailures   int
+
+	sessionCleanup *AccountSessionCleanupService
 }
 
 func NewSchedulerSnapshotService(
@@ -55,6 +57,13 @@ func NewSchedulerSnapshotService(
 	}
 }
 
+// SetAccountSessionCleanup 注入账号会话清理服务：账号删除/禁用事件到达时清理其粘性会话与槽位
+func (s *SchedulerSnapshotService) SetAccountSessionCleanup(cleanup *AccountSessionCleanupService) {
+	if s != nil {
+		s.sessionCleanup = cleanup
+	}
+}
+
 func (s *SchedulerSnapshotService) Start() {
 	if s == nil || s.cache == nil {
 		return
@@ -329,6 +338,7 @@ func (s *SchedulerSnapshotService) handleAccountEvent(ctx context.Context, accou
 	account, err := s.accountRepo.GetByID(ctx, *accountID)
 	if err != nil {
 		if errors.Is(err, ErrAccountNotFound) {
+			s.cleanupAccountSessions(ctx, *accountID, nil)
 			if s.cache != nil {
 				if err := s.cache.DeleteAccount(ctx, *accountID); err != nil {
 					return err
@@ -338,6 +348,9 @@ func (s *SchedulerSnapshotService) handleAccountEvent(ctx context.Context, accou
 		}
 		return err
 	}
+	if needsSessionCleanup(account) {
+		s.cleanupAccountSessions(ctx, account.ID, account)
+	}
 	if s.cache != nil {
 		if err := s.cache.SetAccount(ctx, account); err != nil {
 			return err
@@ -349,6 +362,23 @@ func (s *SchedulerSnapshotService) handleAccountEvent(ctx context.Context, accou
 	return s.rebuildByAccount(ctx, account, groupIDs, "account_change")
 }
 
+// cleanupAccountSessions 清理被删除/禁用账号的会话绑定；账号已删除时从快照缓存中取平台信息用于重绑
+func (s *SchedulerSnapshotService) cleanupAccountSessions(ctx context.Context, accountID int64, account *Account) {
+	if s.sessionCleanup == nil {
+		return
+	}
+	if account == nil && s.cache != nil {
+		account, _ = s.cache.GetAccount(ctx, accountID)
+	}
+	platform := ""
+	if account != nil {
+		platform = account.Platform
+	}
+	if _, err := s.sessionCleanup.CleanupAccount(ctx, accountID, platform); err != nil {
+		log.Printf("[Scheduler] account session cleanup failed: account=%d err=%v", accountID, err)
+	}
+}
+
 func (s *SchedulerSnapshotService) handleGroupEvent(ctx context.Context, groupID *int64) error {
 	if groupID == nil || *groupID <= 0 {
 		return nil
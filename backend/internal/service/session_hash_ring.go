@@ -0,0 +1,173 @@
+package service
+
+import (
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/cespare/xxhash/v2"
+)
+
+const (
+	// sessionRingReplicasPerSlot 每个并发槽位对应的虚拟节点数
+	sessionRingReplicasPerSlot = 4
+	// sessionRingMaxWeight 参与加权的最大并发数，避免超大并发账号生成过多虚拟节点
+	sessionRingMaxWeight = 64
+	// sessionRingCacheSize 哈希环缓存条目上限，超过后整体清空重建
+	sessionRingCacheSize = 256
+)
+
+// sessionHashRing 新会话初始放置使用的一致性哈希环：账号为节点，虚拟节点数按并发容量加权。
+// 账号增减时只有落在该账号区间内的会话会改变首选账号，其余会话的放置保持不变。
+type sessionHashRing struct {
+	hashes   []uint64
+	accounts []int64
+}
+
+type sessionRingPoint struct {
+	hash      uint64
+	accountID int64
+}
+
+func sessionRingWeight(account *Account) int {
+	weight := account.Concurrency
+	if weight < 1 {
+		weight = 1
+	}
+	if weight > sessionRingMaxWeight {
+		weight = sessionRingMaxWeight
+	}
+	return weight
+}
+
+func newSessionHashRing(accounts []*Account) *sessionHashRing {
+	total := 0
+	for _, acc := range accounts {
+		total += sessionRingWeight(acc) * sessionRingReplicasPerSlot
+	}
+	points := make([]sessionRingPoint, 0, total)
+	for _, acc := range accounts {
+		// 虚拟节点只由账号自身 ID 与容量决定，与其他账号无关，保证增减账号时其余节点位置不变
+		prefix := strconv.FormatInt(acc.ID, 10) + "#"
+		replicas := sessionRingWeight(acc) * sessionRingReplicasPerSlot
+		for i := 0; i < replicas; i++ {
+			points = append(points, sessionRingPoint{
+				hash:      xxhash.Sum64String(prefix + strconv.Itoa(i)),
+				accountID: acc.ID,
+			})
+		}
+	}
+	sort.Slice(points, func(i, j int) bool {
+		if points[i].hash != points[j].hash {
+			return points[i].hash < points[j].hash
+		}
+		return points[i].accountID < points[j].accountID
+	})
+
+	ring := &sessionHashRing{
+		hashes:   make([]uint64, len(points)),
+		accounts: make([]int64, len(points)),
+	}
+	for i, p := range points {
+		ring.hashes[i] = p.hash
+		ring.accounts[i] = p.accountID
+	}
+	return ring
+}
+
+// walk 从会话哈希位置顺时针遍历环上的账号（同一账号只访问一次），visit 返回 false 时停止
+func (r *sessionHashRing) walk(sessionHash string, visit func(accountID int64) bool) {
+	if r == nil || len(r.hashes) == 0 {
+		return
+	}
+	h := xxhash.Sum64String(sessionHash)
+	start := sort.Search(len(r.hashes), func(i int) bool { return r.hashes[i] >= h })
+	seen := make(map[int64]struct{})
+	for i := 0; i < len(r.hashes); i++ {
+		accountID := r.accounts[(start+i)%len(r.hashes)]
+		if _, ok := seen[accountID]; ok {
+			continue
+		}
+		seen[accountID] = struct{}{}
+		if !visit(accountID) {
+			return
+		}
+	}
+}
+
+// pick 返回 candidates 中沿环最先遇到的账号；环中没有任何候选时返回 nil
+func (r *sessionHashRing) pick(sessionHash string, candidates []accountWithLoad) *accountWithLoad {
+	if len(candidates) == 0 {
+		return nil
+	}
+	index := make(map[int64]int, len(candidates))
+	for i := range candidates {
+		index[candidates[i].account.ID] = i
+	}
+	var selected *accountWithLoad
+	r.walk(sessionHash, func(accountID int64) bool {
+		if i, ok := index[accountID]; ok {
+			selected = &candidates[i]
+			return false
+		}
+		return true
+	})
+	return selected
+}
+
+// rank 返回各账号沿环的先后顺序（0 为首选）
+func (r *sessionHashRing) rank(sessionHash string) map[int64]int {
+	ranks := make(map[int64]int)
+	r.walk(sessionHash, func(accountID int64) bool {
+		ranks[accountID] = len(ranks)
+		return true
+	})
+	return ranks
+}
+
+// sessionRingCache 按候选账号集合（ID 与容量）缓存哈希环，避免每个新会话都重建
+var sessionRingCache = struct {
+	mu    sync.Mutex
+	rings map[string]*sessionHashRing
+}{rings: make(map[string]*sessionHashRing)}
+
+// getSessionHashRing 获取候选账号集合对应的哈希环
+func getSessionHashRing(accounts []*Account) *sessionHashRing {
+	if len(accounts) == 0 {
+		return nil
+	}
+	ids := make([]string, 0, len(accounts))
+	for _, acc := range accounts {
+		ids = append(ids, strconv.FormatInt(acc.ID, 10)+":"+strconv.Itoa(sessionRingWeight(acc)))
+	}
+	sort.Strings(ids)
+	key := strings.Join(ids, ",")
+
+	sessionRingCache.mu.Lock()
+	defer sessionRingCache.mu.Unlock()
+	if ring, ok := sessionRingCache.rings[key]; ok {
+		return ring
+	}
+	if len(sessionRingCache.rings) >= sessionRingCacheSize {
+		sessionRingCache.rings = make(map[string]*sessionHashRing)
+	}
+	ring := newSessionHashRing(accounts)
+	sessionRingCache.rings[key] = ring
+	return ring
+}
+
+// orderBySessionRing 保持层级/优先级顺序不变，同一优先级内按会话在哈希环上的顺序重排
+func orderBySessionRing(items []accountWithLoad, sessionHash string) {
+	accounts := make([]*Account, 0, len(items))
+	for _, item := range items {
+		accounts = append(accounts, item.account)
+	}
+	ranks := getSessionHashRing(accounts).rank(sessionHash)
+	sort.SliceStable(items, func(i, j int) bool {
+		if c := compareAccountPriority(items[i].account, items[j].account); c != 0 {
+			return c < 0
+		}
+		return ranks[items[i].account.ID] < ranks[items[j].account.ID]
+	})
+}
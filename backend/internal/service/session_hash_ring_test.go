@@ -0,0 +1,91 @@
+//go:build unit
+
+package service
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func newSessionRingTestAccounts(concurrency ...int) []*Account {
+	accounts := make([]*Account, 0, len(concurrency))
+	for i, c := range concurrency {
+		accounts = append(accounts, &Account{ID: int64(i + 1), Priority: 1, Concurrency: c})
+	}
+	return accounts
+}
+
+func sessionRingPlacement(ring *sessionHashRing, accounts []*Account, sessions int) map[string]int64 {
+	candidates := make([]accountWithLoad, 0, len(accounts))
+	for _, acc := range accounts {
+		candidates = append(candidates, accountWithLoad{account: acc, loadInfo: &AccountLoadInfo{AccountID: acc.ID}})
+	}
+	placement := make(map[string]int64, sessions)
+	for i := 0; i < sessions; i++ {
+		session := fmt.Sprintf("session-%d", i)
+		placement[session] = ring.pick(session, candidates).account.ID
+	}
+	return placement
+}
+
+func TestSessionHashRing_RemovingAccountOnlyRemapsItsSessions(t *testing.T) {
+	accounts := newSessionRingTestAccounts(5, 5, 5, 5, 5, 5, 5, 5, 5, 5)
+	before := sessionRingPlacement(newSessionHashRing(accounts), accounts, 5000)
+
+	remaining := accounts[1:]
+	after := sessionRingPlacement(newSessionHashRing(remaining), remaining, 5000)
+
+	moved := 0
+	for session, accountID := range before {
+		if accountID == 1 {
+			require.NotEqual(t, int64(1), after[session])
+			continue
+		}
+		if after[session] != accountID {
+			moved++
+		}
+	}
+	require.Zero(t, moved, "未被移除账号上的会话不应重新映射")
+}
+
+func TestSessionHashRing_WeightedByConcurrency(t *testing.T) {
+	accounts := newSessionRingTestAccounts(2, 8)
+	placement := sessionRingPlacement(newSessionHashRing(accounts), accounts, 10000)
+
+	counts := map[int64]int{}
+	for _, accountID := range placement {
+		counts[accountID]++
+	}
+	// 容量 1:4，放置比例应明显偏向大容量账号
+	require.Greater(t, counts[2], counts[1]*2)
+}
+
+func TestSessionHashRing_PickSkipsUnavailableCandidates(t *testing.T) {
+	accounts := newSessionRingTestAccounts(5, 5, 5)
+	ring := getSessionHashRing(accounts)
+	require.Same(t, ring, getSessionHashRing(accounts), "相同账号集合应复用缓存的哈希环")
+
+	ranks := ring.rank("session-x")
+	require.Len(t, ranks, 3)
+
+	var first, second *Account
+	for _, acc := range accounts {
+		switch ranks[acc.ID] {
+		case 0:
+			first = acc
+		case 1:
+			second = acc
+		}
+	}
+	// 首选账号不在候选中（已满或被排除）时沿环选择下一个账号
+	candidates := []accountWithLoad{}
+	for _, acc := range accounts {
+		if acc != first {
+			candidates = append(candidates, accountWithLoad{account: acc, loadInfo: &AccountLoadInfo{AccountID: acc.ID}})
+		}
+	}
+	require.Equal(t, second.ID, ring.pick("session-x", candidates).account.ID)
+	require.Nil(t, ring.pick("session-x", nil))
+}
@@ -0,0 +1,98 @@
+package service
+
+import (
+	"context"
+	"net"
+	"sync/atomic"
+	"time"
+)
+
+// shutdownTaskPollInterval 等待后台任务结束时的轮询间隔
+const shutdownTaskPollInterval = 50 * time.Millisecond
+
+// ShutdownCoordinator 协调优雅关闭：
+//   - 作为所有 HTTP 请求的基础 context，排空超时后统一取消仍在进行的请求（流式请求随之结束并执行槽位释放等清理）；
+//   - 跟踪请求结束后异步执行的任务（如用量记录），关闭前等待其完成；
+//   - 暴露排空状态，供健康检查返回 503，让负载均衡器停止转发新请求。
+type ShutdownCoordinator struct {
+	baseCtx  context.Context
+	abort    context.CancelFunc
+	draining atomic.Bool
+	tasks    atomic.Int64
+}
+
+// NewShutdownCoordinator 创建关闭协调器
+func NewShutdownCoordinator() *ShutdownCoordinator {
+	ctx, cancel := context.WithCancel(context.Background())
+	return &ShutdownCoordinator{baseCtx: ctx, abort: cancel}
+}
+
+// BaseContext 用作 http.Server.BaseContext，AbortInFlight 时所有请求 context 随之取消
+func (s *ShutdownCoordinator) BaseContext(net.Listener) context.Context {
+	if s == nil {
+		return context.Background()
+	}
+	return s.baseCtx
+}
+
+// BeginDrain 进入排空状态（不再接收新请求，进行中的请求继续完成）
+func (s *ShutdownCoordinator) BeginDrain() {
+	if s == nil {
+		return
+	}
+	s.draining.Store(true)
+}
+
+// Draining 是否处于排空状态
+func (s *ShutdownCoordinator) Draining() bool {
+	return s != nil && s.draining.Load()
+}
+
+// AbortInFlight 取消所有进行中的请求（排空超时后调用）
+func (s *ShutdownCoordinator) AbortInFlight() {
+	if s == nil {
+		return
+	}
+	s.abort()
+}
+
+// BeginTask 登记一个需要在关闭前完成的后台任务，返回的函数须在任务结束时调用
+func (s *ShutdownCoordinator) BeginTask() func() {
+	if s == nil {
+		return func() {}
+	}
+	s.tasks.Add(1)
+	var done atomic.Bool
+	return func() {
+		if done.CompareAndSwap(false, true) {
+			s.tasks.Add(-1)
+		}
+	}
+}
+
+// PendingTasks 未完成的后台任务数
+func (s *ShutdownCoordinator) PendingTasks() int64 {
+	if s == nil {
+		return 0
+	}
+	return s.tasks.Load()
+}
+
+// WaitTasks 等待所有后台任务完成；ctx 结束时返回 false
+func (s *ShutdownCoordinator) WaitTasks(ctx context.Context) bool {
+	if s.PendingTasks() == 0 {
+		return true
+	}
+	ticker := time.NewTicker(shutdownTaskPollInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return s.PendingTasks() == 0
+		case <-ticker.C:
+			if s.PendingTasks() == 0 {
+				return true
+			}
+		}
+	}
+}
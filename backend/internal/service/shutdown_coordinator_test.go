@@ -0,0 +1,55 @@
+//go:build unit
+
+package service
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestShutdownCoordinator_WaitTasks(t *testing.T) {
+	s := NewShutdownCoordinator()
+	require.True(t, s.WaitTasks(context.Background()))
+
+	done := s.BeginTask()
+	require.Equal(t, int64(1), s.PendingTasks())
+
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+	require.False(t, s.WaitTasks(ctx))
+
+	go func() {
+		time.Sleep(10 * time.Millisecond)
+		done()
+		done() // 重复调用不应使计数变为负数
+	}()
+	require.True(t, s.WaitTasks(context.Background()))
+	require.Zero(t, s.PendingTasks())
+}
+
+func TestShutdownCoordinator_DrainAndAbort(t *testing.T) {
+	s := NewShutdownCoordinator()
+	require.False(t, s.Draining())
+
+	ctx := s.BaseContext(nil)
+	s.BeginDrain()
+	require.True(t, s.Draining())
+	require.NoError(t, ctx.Err(), "draining must not cancel in-flight requests")
+
+	s.AbortInFlight()
+	require.ErrorIs(t, ctx.Err(), context.Canceled)
+}
+
+func TestShutdownCoordinator_NilSafe(t *testing.T) {
+	var s *ShutdownCoordinator
+	require.NotNil(t, s.BaseContext(nil))
+	s.BeginDrain()
+	s.AbortInFlight()
+	require.False(t, s.Draining())
+	s.BeginTask()()
+	require.Zero(t, s.PendingTasks())
+	require.True(t, s.WaitTasks(context.Background()))
+}
@@ -0,0 +1,422 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/Wei-Shaw/sub2api/internal/config"
+	"github.com/Wei-Shaw/sub2api/internal/pkg/ctxkey"
+	infraerrors "github.com/Wei-Shaw/sub2api/internal/pkg/errors"
+	"github.com/Wei-Shaw/sub2api/internal/pkg/timezone"
+)
+
+// SpendCapWarningHeader 用户当期消费超过软上限时返回的响应头
+const SpendCapWarningHeader = "X-Spend-Cap-Warning"
+
+// 消费上限周期
+const (
+	SpendCapPeriodDaily   = "daily"
+	SpendCapPeriodMonthly = "monthly"
+)
+
+const (
+	// spendCapOverrideCacheTTL 用户覆盖配置本地缓存有效期（多实例下修改最多延迟该时长生效）
+	spendCapOverrideCacheTTL = 30 * time.Second
+	// spendCapOverrideCacheMax 本地缓存条目上限，超过后清理过期条目
+	spendCapOverrideCacheMax = 10000
+)
+
+var (
+	ErrInvalidSpendCap          = infraerrors.BadRequest("INVALID_SPEND_CAP", "spend caps must be non-negative and soft caps must not exceed hard caps")
+	ErrSpendCapOverrideNotFound = infraerrors.NotFound("SPEND_CAP_OVERRIDE_NOT_FOUND", "spend cap override not found")
+
+	ErrDailySpendCapExceeded   = infraerrors.TooManyRequests("DAILY_SPEND_CAP_EXCEEDED", "daily spend cap exceeded")
+	ErrMonthlySpendCapExceeded = infraerrors.TooManyRequests("MONTHLY_SPEND_CAP_EXCEEDED", "monthly spend cap exceeded")
+)
+
+// SpendCap 用户每日/每月消费软/硬上限（美元，0 表示不限制）
+type SpendCap struct {
+	DailySoftUSD   float64 `json:"daily_soft_usd"`
+	DailyHardUSD   float64 `json:"daily_hard_usd"`
+	MonthlySoftUSD float64 `json:"monthly_soft_usd"`
+	MonthlyHardUSD float64 `json:"monthly_hard_usd"`
+}
+
+// IsEmpty 是否未设置任何上限
+func (c SpendCap) IsEmpty() bool {
+	return c.DailySoftUSD <= 0 && c.DailyHardUSD <= 0 && c.MonthlySoftUSD <= 0 && c.MonthlyHardUSD <= 0
+}
+
+// Validate 上限不能为负；同一周期软硬上限均设置时软上限不能高于硬上限
+func (c SpendCap) Validate() error {
+	if c.DailySoftUSD < 0 || c.DailyHardUSD < 0 || c.MonthlySoftUSD < 0 || c.MonthlyHardUSD < 0 {
+		return ErrInvalidSpendCap
+	}
+	if c.DailyHardUSD > 0 && c.DailySoftUSD > c.DailyHardUSD {
+		return ErrInvalidSpendCap
+	}
+	if c.MonthlyHardUSD > 0 && c.MonthlySoftUSD > c.MonthlyHardUSD {
+		return ErrInvalidSpendCap
+	}
+	return nil
+}
+
+// Evaluate 根据当期消费判定：超过硬上限返回错误；否则超过软上限时返回告警（每日优先）
+func (c SpendCap) Evaluate(usage *SpendCapUsage) (*SpendCapWarning, error) {
+	if usage == nil {
+		return nil, nil
+	}
+	if c.DailyHardUSD > 0 && usage.DailyUSD >= c.DailyHardUSD {
+		return nil, ErrDailySpendCapExceeded
+	}
+	if c.MonthlyHardUSD > 0 && usage.MonthlyUSD >= c.MonthlyHardUSD {
+		return nil, ErrMonthlySpendCapExceeded
+	}
+	if c.DailySoftUSD > 0 && usage.DailyUSD >= c.DailySoftUSD {
+		return &SpendCapWarning{Period: SpendCapPeriodDaily, SpendUSD: usage.DailyUSD, SoftCapUSD: c.DailySoftUSD, HardCapUSD: c.DailyHardUSD}, nil
+	}
+	if c.MonthlySoftUSD > 0 && usage.MonthlyUSD >= c.MonthlySoftUSD {
+		return &SpendCapWarning{Period: SpendCapPeriodMonthly, SpendUSD: usage.MonthlyUSD, SoftCapUSD: c.MonthlySoftUSD, HardCapUSD: c.MonthlyHardUSD}, nil
+	}
+	return nil, nil
+}
+
+// SpendCapOverride 用户级上限覆盖，nil 字段沿用全局默认值
+type SpendCapOverride struct {
+	UserID         int64     `json:"user_id"`
+	DailySoftUSD   *float64  `json:"daily_soft_usd"`
+	DailyHardUSD   *float64  `json:"daily_hard_usd"`
+	MonthlySoftUSD *float64  `json:"monthly_soft_usd"`
+	MonthlyHardUSD *float64  `json:"monthly_hard_usd"`
+	Notes          string    `json:"notes"`
+	CreatedAt      time.Time `json:"created_at"`
+	UpdatedAt      time.Time `json:"updated_at"`
+}
+
+// apply 将覆盖应用到默认上限
+func (o *SpendCapOverride) apply(base SpendCap) SpendCap {
+	if o == nil {
+		return base
+	}
+	pick := func(v *float64, fallback float64) float64 {
+		if v != nil {
+			return *v
+		}
+		return fallback
+	}
+	return SpendCap{
+		DailySoftUSD:   pick(o.DailySoftUSD, base.DailySoftUSD),
+		DailyHardUSD:   pick(o.DailyHardUSD, base.DailyHardUSD),
+		MonthlySoftUSD: pick(o.MonthlySoftUSD, base.MonthlySoftUSD),
+		MonthlyHardUSD: pick(o.MonthlyHardUSD, base.MonthlyHardUSD),
+	}
+}
+
+// SpendCapUsage 用户当前自然日/月的消费金额
+type SpendCapUsage struct {
+	// Day/Month 当前周期标识（服务器时区下的 20060102 / 200601）
+	Day        string  `json:"day"`
+	Month      string  `json:"month"`
+	DailyUSD   float64 `json:"daily_usd"`
+	MonthlyUSD float64 `json:"monthly_usd"`
+}
+
+// SpendCapWarning 当期消费超过软上限的告警
+type SpendCapWarning struct {
+	Period     string  `json:"period"`
+	SpendUSD   float64 `json:"spend_usd"`
+	SoftCapUSD float64 `json:"soft_cap_usd"`
+	// HardCapUSD 同周期硬上限，0 表示未设置
+	HardCapUSD float64 `json:"hard_cap_usd"`
+}
+
+// HeaderValue 返回 X-Spend-Cap-Warning 响应头的值，如 "daily; spend=12.3400; soft_cap=10.0000; hard_cap=20.0000"
+func (w *SpendCapWarning) HeaderValue() string {
+	value := fmt.Sprintf("%s; spend=%.4f; soft_cap=%.4f", w.Period, w.SpendUSD, w.SoftCapUSD)
+	if w.HardCapUSD > 0 {
+		value += fmt.Sprintf("; hard_cap=%.4f", w.HardCapUSD)
+	}
+	return value
+}
+
+// spendCapWarningRecorder 请求内记录软上限告警，由 handler 在资格检查前放入 context
+type spendCapWarningRecorder struct {
+	warning *SpendCapWarning
+}
+
+// WithSpendCapWarningRecorder 在 context 中放入软上限告警记录器，资格检查命中软上限时写入
+func WithSpendCapWarningRecorder(ctx context.Context) context.Context {
+	return context.WithValue(ctx, ctxkey.SpendCapWarning, &spendCapWarningRecorder{})
+}
+
+// SpendCapWarningFromContext 读取资格检查记录的软上限告警，未命中时返回 nil
+func SpendCapWarningFromContext(ctx context.Context) *SpendCapWarning {
+	if ctx == nil {
+		return nil
+	}
+	recorder, _ := ctx.Value(ctxkey.SpendCapWarning).(*spendCapWarningRecorder)
+	if recorder == nil {
+		return nil
+	}
+	return recorder.warning
+}
+
+func recordSpendCapWarning(ctx context.Context, warning *SpendCapWarning) {
+	if warning == nil {
+		return
+	}
+	if recorder, _ := ctx.Value(ctxkey.SpendCapWarning).(*spendCapWarningRecorder); recorder != nil {
+		recorder.warning = warning
+	}
+}
+
+// spendCapPeriod 返回服务器时区下 now 所在的自然日与自然月标识
+func spendCapPeriod(now time.Time) (day, month string) {
+	t := now.In(timezone.Location())
+	return t.Format("20060102"), t.Format("200601")
+}
+
+// SpendCapRepository 用户级上限覆盖存储
+type SpendCapRepository interface {
+	// GetOverride 获取用户覆盖，不存在时返回 ErrSpendCapOverrideNotFound
+	GetOverride(ctx context.Context, userID int64) (*SpendCapOverride, error)
+	UpsertOverride(ctx context.Context, override *SpendCapOverride) error
+	DeleteOverride(ctx context.Context, userID int64) error
+}
+
+// SpendCapCache 用户当期消费计数（多实例共享）
+type SpendCapCache interface {
+	GetUserSpend(ctx context.Context, userID int64, day, month string) (*SpendCapUsage, error)
+	// IncrUserSpend 累加当日/当月消费并返回累加后的金额
+	IncrUserSpend(ctx context.Context, userID int64, day, month string, amount float64) (*SpendCapUsage, error)
+}
+
+// UserSpendCap 用户消费上限详情（管理端展示）
+type UserSpendCap struct {
+	UserID    int64             `json:"user_id"`
+	Enabled   bool              `json:"enabled"`
+	Default   SpendCap          `json:"default"`
+	Override  *SpendCapOverride `json:"override"`
+	Effective SpendCap          `json:"effective"`
+	Usage     *SpendCapUsage    `json:"usage"`
+}
+
+type spendCapOverrideEntry struct {
+	override  *SpendCapOverride
+	expiresAt time.Time
+}
+
+// SpendCapService 用户消费软/硬上限：全局默认值来自 billing.spend_cap，管理员可按用户覆盖。
+// 计数仅在启用后累加（按实际扣费金额），硬上限在计费资格检查中拒绝请求，软上限只告警。
+type SpendCapService struct {
+	repo        SpendCapRepository
+	cache       SpendCapCache
+	budgetAlert *BudgetAlertService
+	cfg         config.SpendCapConfig
+	now         func() time.Time
+
+	mu        sync.Mutex
+	overrides map[int64]spendCapOverrideEntry
+}
+
+// NewSpendCapService 创建消费上限服务
+func NewSpendCapService(repo SpendCapRepository, cache SpendCapCache, budgetAlert *BudgetAlertService, cfg *config.Config) *SpendCapService {
+	s := &SpendCapService{
+		repo:        repo,
+		cache:       cache,
+		budgetAlert: budgetAlert,
+		now:         time.Now,
+		overrides:   make(map[int64]spendCapOverrideEntry),
+	}
+	if cfg != nil {
+		s.cfg = cfg.Billing.SpendCap
+	}
+	return s
+}
+
+// Enabled 是否启用消费上限
+func (s *SpendCapService) Enabled() bool {
+	return s != nil && s.cfg.Enabled && s.cache != nil
+}
+
+// DefaultCap 全局默认上限
+func (s *SpendCapService) DefaultCap() SpendCap {
+	return SpendCap{
+		DailySoftUSD:   s.cfg.DailySoftUSD,
+		DailyHardUSD:   s.cfg.DailyHardUSD,
+		MonthlySoftUSD: s.cfg.MonthlySoftUSD,
+		MonthlyHardUSD: s.cfg.MonthlyHardUSD,
+	}
+}
+
+// EffectiveCap 用户生效的上限（默认值叠加用户覆盖）
+func (s *SpendCapService) EffectiveCap(ctx context.Context, userID int64) (SpendCap, error) {
+	override, err := s.loadOverride(ctx, userID)
+	if err != nil {
+		return SpendCap{}, err
+	}
+	return override.apply(s.DefaultCap()), nil
+}
+
+// Current 返回用户生效的上限与当期消费；未设置任何上限时不读取计数
+func (s *SpendCapService) Current(ctx context.Context, userID int64) (SpendCap, *SpendCapUsage, error) {
+	caps, err := s.EffectiveCap(ctx, userID)
+	if err != nil || caps.IsEmpty() {
+		return caps, nil, err
+	}
+	day, month := spendCapPeriod(s.now())
+	usage, err := s.cache.GetUserSpend(ctx, userID, day, month)
+	if err != nil {
+		return caps, nil, err
+	}
+	usage.Day, usage.Month = day, month
+	return caps, usage, nil
+}
+
+// RecordSpend 累加用户当期消费，并将软/硬上限用量提交给预算告警（软上限的通知由此发出）
+func (s *SpendCapService) RecordSpend(ctx context.Context, user *User, amount float64) {
+	if !s.Enabled() || user == nil || amount <= 0 {
+		return
+	}
+	day, month := spendCapPeriod(s.now())
+	usage, err := s.cache.IncrUserSpend(ctx, user.ID, day, month, amount)
+	if err != nil {
+		log.Printf("[SpendCap] Increment spend failed for user %d: %v", user.ID, err)
+		return
+	}
+	if s.budgetAlert == nil {
+		return
+	}
+	caps, err := s.EffectiveCap(ctx, user.ID)
+	if err != nil {
+		log.Printf("[SpendCap] Load caps failed for user %d: %v", user.ID, err)
+		return
+	}
+	usage.Day, usage.Month = day, month
+	s.budgetAlert.Observe(ctx, spendCapBudgetUsages(user, caps, usage)...)
+}
+
+// GetUserSpendCap 获取用户的默认/覆盖/生效上限与当期消费
+func (s *SpendCapService) GetUserSpendCap(ctx context.Context, userID int64) (*UserSpendCap, error) {
+	override, err := s.repo.GetOverride(ctx, userID)
+	if err != nil {
+		if !errors.Is(err, ErrSpendCapOverrideNotFound) {
+			return nil, err
+		}
+		override = nil
+	}
+	result := &UserSpendCap{
+		UserID:    userID,
+		Enabled:   s.cfg.Enabled,
+		Default:   s.DefaultCap(),
+		Override:  override,
+		Effective: override.apply(s.DefaultCap()),
+	}
+	if s.cache != nil {
+		day, month := spendCapPeriod(s.now())
+		usage, err := s.cache.GetUserSpend(ctx, userID, day, month)
+		if err != nil {
+			return nil, err
+		}
+		usage.Day, usage.Month = day, month
+		result.Usage = usage
+	}
+	return result, nil
+}
+
+// SetOverride 设置用户覆盖；生效上限需通过校验
+func (s *SpendCapService) SetOverride(ctx context.Context, override *SpendCapOverride) (*UserSpendCap, error) {
+	if override == nil || override.UserID <= 0 {
+		return nil, ErrInvalidSpendCap
+	}
+	if err := override.apply(s.DefaultCap()).Validate(); err != nil {
+		return nil, err
+	}
+	if err := s.repo.UpsertOverride(ctx, override); err != nil {
+		return nil, err
+	}
+	s.invalidate(override.UserID)
+	return s.GetUserSpendCap(ctx, override.UserID)
+}
+
+// DeleteOverride 删除用户覆盖，恢复全局默认值
+func (s *SpendCapService) DeleteOverride(ctx context.Context, userID int64) error {
+	if err := s.repo.DeleteOverride(ctx, userID); err != nil {
+		return err
+	}
+	s.invalidate(userID)
+	return nil
+}
+
+// loadOverride 读取用户覆盖（带本地缓存），不存在时返回 nil
+func (s *SpendCapService) loadOverride(ctx context.Context, userID int64) (*SpendCapOverride, error) {
+	now := s.now()
+	s.mu.Lock()
+	if entry, ok := s.overrides[userID]; ok && now.Before(entry.expiresAt) {
+		s.mu.Unlock()
+		return entry.override, nil
+	}
+	s.mu.Unlock()
+
+	if s.repo == nil {
+		return nil, nil
+	}
+	override, err := s.repo.GetOverride(ctx, userID)
+	if err != nil {
+		if !errors.Is(err, ErrSpendCapOverrideNotFound) {
+			return nil, err
+		}
+		override = nil
+	}
+
+	s.mu.Lock()
+	if len(s.overrides) >= spendCapOverrideCacheMax {
+		for id, entry := range s.overrides {
+			if !now.Before(entry.expiresAt) {
+				delete(s.overrides, id)
+			}
+		}
+		if len(s.overrides) >= spendCapOverrideCacheMax {
+			s.overrides = make(map[int64]spendCapOverrideEntry)
+		}
+	}
+	s.overrides[userID] = spendCapOverrideEntry{override: override, expiresAt: now.Add(spendCapOverrideCacheTTL)}
+	s.mu.Unlock()
+	return override, nil
+}
+
+func (s *SpendCapService) invalidate(userID int64) {
+	s.mu.Lock()
+	delete(s.overrides, userID)
+	s.mu.Unlock()
+}
+
+// spendCapBudgetUsages 构造用户消费相对各软/硬上限的用量；以上限值作为周期标识的一部分，调整上限后重新告警
+func spendCapBudgetUsages(user *User, caps SpendCap, usage *SpendCapUsage) []BudgetUsage {
+	var usages []BudgetUsage
+	add := func(metric, period string, used, limit float64, ttl time.Duration) {
+		if limit <= 0 {
+			return
+		}
+		usages = append(usages, BudgetUsage{
+			Scope:   BudgetAlertScopeUser,
+			ScopeID: user.ID,
+			Name:    user.Email,
+			Metric:  metric,
+			Period:  period + ":" + strconv.FormatFloat(limit, 'f', -1, 64),
+			Used:    used,
+			Limit:   limit,
+			TTL:     ttl,
+		})
+	}
+	add(BudgetAlertMetricSpendDailySoft, usage.Day, usage.DailyUSD, caps.DailySoftUSD, 25*time.Hour)
+	add(BudgetAlertMetricSpendDailyHard, usage.Day, usage.DailyUSD, caps.DailyHardUSD, 25*time.Hour)
+	add(BudgetAlertMetricSpendMonthlySoft, usage.Month, usage.MonthlyUSD, caps.MonthlySoftUSD, 32*24*time.Hour)
+	add(BudgetAlertMetricSpendMonthlyHard, usage.Month, usage.MonthlyUSD, caps.MonthlyHardUSD, 32*24*time.Hour)
+	return usages
+}
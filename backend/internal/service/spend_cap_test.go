@@ -0,0 +1,176 @@
+//go:build unit
+
+package service
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/Wei-Shaw/sub2api/internal/config"
+	"github.com/stretchr/testify/require"
+)
+
+type spendCapRepoStub struct {
+	overrides map[int64]*SpendCapOverride
+	gets      int
+}
+
+func (s *spendCapRepoStub) GetOverride(ctx context.Context, userID int64) (*SpendCapOverride, error) {
+	s.gets++
+	if o, ok := s.overrides[userID]; ok {
+		return o, nil
+	}
+	return nil, ErrSpendCapOverrideNotFound
+}
+
+func (s *spendCapRepoStub) UpsertOverride(ctx context.Context, override *SpendCapOverride) error {
+	s.overrides[override.UserID] = override
+	return nil
+}
+
+func (s *spendCapRepoStub) DeleteOverride(ctx context.Context, userID int64) error {
+	if _, ok := s.overrides[userID]; !ok {
+		return ErrSpendCapOverrideNotFound
+	}
+	delete(s.overrides, userID)
+	return nil
+}
+
+type spendCapCacheStub struct {
+	usage map[int64]*SpendCapUsage
+	err   error
+}
+
+func (s *spendCapCacheStub) GetUserSpend(ctx context.Context, userID int64, day, month string) (*SpendCapUsage, error) {
+	if s.err != nil {
+		return nil, s.err
+	}
+	if u, ok := s.usage[userID]; ok {
+		copied := *u
+		return &copied, nil
+	}
+	return &SpendCapUsage{}, nil
+}
+
+func (s *spendCapCacheStub) IncrUserSpend(ctx context.Context, userID int64, day, month string, amount float64) (*SpendCapUsage, error) {
+	u, ok := s.usage[userID]
+	if !ok {
+		u = &SpendCapUsage{}
+		s.usage[userID] = u
+	}
+	u.DailyUSD += amount
+	u.MonthlyUSD += amount
+	copied := *u
+	return &copied, nil
+}
+
+func newSpendCapTestService(repo SpendCapRepository, cache SpendCapCache) *SpendCapService {
+	return NewSpendCapService(repo, cache, nil, &config.Config{Billing: config.BillingConfig{SpendCap: config.SpendCapConfig{
+		Enabled:        true,
+		DailySoftUSD:   10,
+		DailyHardUSD:   20,
+		MonthlySoftUSD: 100,
+	}}})
+}
+
+func TestSpendCap_Evaluate(t *testing.T) {
+	caps := SpendCap{DailySoftUSD: 10, DailyHardUSD: 20, MonthlySoftUSD: 100, MonthlyHardUSD: 200}
+
+	warning, err := caps.Evaluate(&SpendCapUsage{DailyUSD: 5, MonthlyUSD: 50})
+	require.NoError(t, err)
+	require.Nil(t, warning)
+
+	warning, err = caps.Evaluate(&SpendCapUsage{DailyUSD: 12, MonthlyUSD: 150})
+	require.NoError(t, err)
+	require.Equal(t, SpendCapPeriodDaily, warning.Period)
+	require.Equal(t, "daily; spend=12.0000; soft_cap=10.0000; hard_cap=20.0000", warning.HeaderValue())
+
+	warning, err = caps.Evaluate(&SpendCapUsage{DailyUSD: 1, MonthlyUSD: 150})
+	require.NoError(t, err)
+	require.Equal(t, SpendCapPeriodMonthly, warning.Period)
+
+	_, err = caps.Evaluate(&SpendCapUsage{DailyUSD: 20})
+	require.ErrorIs(t, err, ErrDailySpendCapExceeded)
+	_, err = caps.Evaluate(&SpendCapUsage{DailyUSD: 1, MonthlyUSD: 200})
+	require.ErrorIs(t, err, ErrMonthlySpendCapExceeded)
+}
+
+func TestSpendCap_ValidateAndOverride(t *testing.T) {
+	require.NoError(t, SpendCap{DailySoftUSD: 10}.Validate())
+	require.ErrorIs(t, SpendCap{DailySoftUSD: 30, DailyHardUSD: 20}.Validate(), ErrInvalidSpendCap)
+	require.ErrorIs(t, SpendCap{MonthlyHardUSD: -1}.Validate(), ErrInvalidSpendCap)
+
+	base := SpendCap{DailySoftUSD: 10, DailyHardUSD: 20, MonthlySoftUSD: 100}
+	// nil 字段沿用默认值，0 表示不限制
+	override := &SpendCapOverride{DailyHardUSD: float64Ptr(0), MonthlyHardUSD: float64Ptr(500)}
+	require.Equal(t, SpendCap{DailySoftUSD: 10, MonthlySoftUSD: 100, MonthlyHardUSD: 500}, override.apply(base))
+	var none *SpendCapOverride
+	require.Equal(t, base, none.apply(base))
+}
+
+func TestBillingCacheService_CheckSpendCap(t *testing.T) {
+	repo := &spendCapRepoStub{overrides: map[int64]*SpendCapOverride{
+		2: {UserID: 2, DailyHardUSD: float64Ptr(50)},
+	}}
+	cache := &spendCapCacheStub{usage: map[int64]*SpendCapUsage{
+		1: {DailyUSD: 12},
+		2: {DailyUSD: 25},
+		3: {DailyUSD: 5},
+	}}
+	svc := NewBillingCacheService(&billingCacheWorkerStub{}, nil, nil, &config.Config{}, newSpendCapTestService(repo, cache), nil)
+	t.Cleanup(svc.Stop)
+
+	// 超过软上限：放行并记录告警
+	ctx := WithSpendCapWarningRecorder(context.Background())
+	require.NoError(t, svc.checkSpendCap(ctx, &User{ID: 1}))
+	warning := SpendCapWarningFromContext(ctx)
+	require.NotNil(t, warning)
+	require.Equal(t, SpendCapPeriodDaily, warning.Period)
+
+	// 用户 2 覆盖了每日硬上限
+	ctx = WithSpendCapWarningRecorder(context.Background())
+	require.NoError(t, svc.checkSpendCap(ctx, &User{ID: 2}))
+	require.NotNil(t, SpendCapWarningFromContext(ctx))
+
+	// 用户 3 未超过任何上限；未放入记录器的 context 也不影响检查
+	require.NoError(t, svc.checkSpendCap(context.Background(), &User{ID: 3}))
+
+	cache.usage[1].DailyUSD = 20
+	require.ErrorIs(t, svc.checkSpendCap(context.Background(), &User{ID: 1}), ErrDailySpendCapExceeded)
+
+	cache.err = errors.New("redis down")
+	require.ErrorIs(t, svc.checkSpendCap(context.Background(), &User{ID: 1}), ErrBillingServiceUnavailable)
+}
+
+func TestSpendCapService_OverrideCacheAndRecordSpend(t *testing.T) {
+	repo := &spendCapRepoStub{overrides: map[int64]*SpendCapOverride{}}
+	cache := &spendCapCacheStub{usage: map[int64]*SpendCapUsage{}}
+	svc := newSpendCapTestService(repo, cache)
+	ctx := context.Background()
+
+	caps, err := svc.EffectiveCap(ctx, 7)
+	require.NoError(t, err)
+	require.Equal(t, 20.0, caps.DailyHardUSD)
+	_, err = svc.EffectiveCap(ctx, 7)
+	require.NoError(t, err)
+	require.Equal(t, 1, repo.gets, "override lookup should be cached")
+
+	// 软上限高于硬上限的覆盖被拒绝
+	_, err = svc.SetOverride(ctx, &SpendCapOverride{UserID: 7, DailySoftUSD: float64Ptr(30)})
+	require.ErrorIs(t, err, ErrInvalidSpendCap)
+
+	result, err := svc.SetOverride(ctx, &SpendCapOverride{UserID: 7, DailyHardUSD: float64Ptr(40)})
+	require.NoError(t, err)
+	require.Equal(t, 40.0, result.Effective.DailyHardUSD)
+	caps, err = svc.EffectiveCap(ctx, 7)
+	require.NoError(t, err)
+	require.Equal(t, 40.0, caps.DailyHardUSD)
+
+	svc.RecordSpend(ctx, &User{ID: 7}, 1.5)
+	svc.RecordSpend(ctx, &User{ID: 7}, 0)
+	require.InDelta(t, 1.5, cache.usage[7].DailyUSD, 1e-9)
+
+	require.NoError(t, svc.DeleteOverride(ctx, 7))
+	require.ErrorIs(t, svc.DeleteOverride(ctx, 7), ErrSpendCapOverrideNotFound)
+}
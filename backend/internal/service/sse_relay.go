@@ -0,0 +1,50 @@
+package service
+
+import (
+	"strings"
+
+	"github.com/tidwall/gjson"
+	"github.com/tidwall/sjson"
+)
+
+// 流式转发的轻量 SSE 处理：上游事件原样透传，只用 gjson 按路径读取计费/错误检测需要的字段，
+// 仅在确需改写（模型回显、缓存字段兼容）时用 sjson 就地修改，避免逐事件 JSON 解码再编码
+
+// sseDataPayload 提取 SSE data 行的内容（兼容 "data: " 与 "data:"），非 data 行返回 false
+func sseDataPayload(line string) (string, bool) {
+	payload, ok := strings.CutPrefix(line, "data:")
+	if !ok {
+		return "", false
+	}
+	return strings.TrimLeft(payload, " \t"), true
+}
+
+// replaceSSEJSONString 将 data 中 path 处等于 from 的字符串改写为 to，未命中时原样返回
+func replaceSSEJSONString(data, path, from, to string) (string, bool) {
+	if value := gjson.Get(data, path); value.Type != gjson.String || value.Str != from {
+		return data, false
+	}
+	out, err := sjson.Set(data, path, to)
+	if err != nil {
+		return data, false
+	}
+	return out, true
+}
+
+// reconcileCachedTokensJSON 与 reconcileCachedTokens 相同的兼容处理（Kimi cached_tokens → cache_read_input_tokens），
+// 直接作用于 data 中 usagePath 处的 usage 对象
+func reconcileCachedTokensJSON(data, usagePath string) (string, bool) {
+	usage := gjson.Get(data, usagePath)
+	if !usage.IsObject() || usage.Get("cache_read_input_tokens").Float() > 0 {
+		return data, false
+	}
+	cached := usage.Get("cached_tokens")
+	if cached.Float() <= 0 {
+		return data, false
+	}
+	out, err := sjson.SetRaw(data, usagePath+".cache_read_input_tokens", cached.Raw)
+	if err != nil {
+		return data, false
+	}
+	return out, true
+}
@@ -0,0 +1,65 @@
+//go:build unit
+
+package service
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestSSEDataPayload(t *testing.T) {
+	payload, ok := sseDataPayload(`data: {"a":1}`)
+	require.True(t, ok)
+	require.Equal(t, `{"a":1}`, payload)
+
+	payload, ok = sseDataPayload(`data:[DONE]`)
+	require.True(t, ok)
+	require.Equal(t, "[DONE]", payload)
+
+	_, ok = sseDataPayload(`event: message_start`)
+	require.False(t, ok)
+}
+
+func TestReplaceSSEJSONString_PreservesUntouchedBytes(t *testing.T) {
+	data := `{"type":"message_start","message":{"model":"claude-mapped","content":"<b>&</b>"}}`
+	out, ok := replaceSSEJSONString(data, "message.model", "claude-mapped", "my-alias")
+	require.True(t, ok)
+	require.Equal(t, `{"type":"message_start","message":{"model":"my-alias","content":"<b>&</b>"}}`, out)
+
+	out, ok = replaceSSEJSONString(data, "message.model", "other", "my-alias")
+	require.False(t, ok)
+	require.Equal(t, data, out)
+}
+
+func TestReconcileCachedTokensJSON(t *testing.T) {
+	out, ok := reconcileCachedTokensJSON(`{"type":"message_delta","usage":{"output_tokens":5,"cached_tokens":12}}`, "usage")
+	require.True(t, ok)
+	require.Equal(t, `{"type":"message_delta","usage":{"output_tokens":5,"cached_tokens":12,"cache_read_input_tokens":12}}`, out)
+
+	data := `{"usage":{"cached_tokens":12,"cache_read_input_tokens":3}}`
+	out, ok = reconcileCachedTokensJSON(data, "usage")
+	require.False(t, ok)
+	require.Equal(t, data, out)
+}
+
+func TestGatewayParseSSEUsage_OnlyUsageEvents(t *testing.T) {
+	svc := &GatewayService{}
+	usage := &ClaudeUsage{}
+	svc.parseSSEUsage(`{"type":"message_start","message":{"usage":{"input_tokens":10,"cache_read_input_tokens":4,"cache_creation":{"ephemeral_5m_input_tokens":2}}}}`, usage)
+	svc.parseSSEUsage(`{"type":"content_block_delta","delta":{"type":"text_delta","text":"hi"},"usage":{"output_tokens":99}}`, usage)
+	svc.parseSSEUsage(`{"type":"message_delta","delta":{"stop_reason":"end_turn"},"usage":{"output_tokens":7}}`, usage)
+
+	require.Equal(t, 10, usage.InputTokens)
+	require.Equal(t, 4, usage.CacheReadInputTokens)
+	require.Equal(t, 2, usage.CacheCreation5mTokens)
+	require.Equal(t, 7, usage.OutputTokens)
+	require.Equal(t, "end_turn", usage.StopReason)
+}
+
+func TestOpenAIReplaceModelInSSELine_NestedResponse(t *testing.T) {
+	svc := &OpenAIGatewayService{}
+	line := `data: {"type":"response.created","response":{"id":"r1","model":"gpt-5-mapped"}}`
+	require.Equal(t, `data: {"type":"response.created","response":{"id":"r1","model":"gpt-5"}}`, svc.replaceModelInSSELine(line, "gpt-5-mapped", "gpt-5"))
+	require.Equal(t, "data: [DONE]", svc.replaceModelInSSELine("data: [DONE]", "gpt-5-mapped", "gpt-5"))
+}
@@ -0,0 +1,173 @@
+package service
+
+import (
+	"context"
+	"log"
+	"time"
+
+	"github.com/Wei-Shaw/sub2api/internal/config"
+)
+
+// 流式滥用标记原因
+const (
+	StreamAbuseReasonEarlyAbandon = "early_abandon"
+	StreamAbuseReasonHighCancel   = "high_cancel_rate"
+)
+
+// StreamObservation 单次流式请求的客户端行为
+type StreamObservation struct {
+	APIKeyID int64
+	UserID   int64
+	// FirstTokenAt 首 token 写出时间（零值表示未产生首 token）
+	FirstTokenAt time.Time
+	// DisconnectedAt 客户端断开时间（零值表示正常读取完毕）
+	DisconnectedAt time.Time
+}
+
+// Canceled 客户端是否在流结束前断开
+func (o StreamObservation) Canceled() bool {
+	return !o.DisconnectedAt.IsZero()
+}
+
+// abandonedWithin 是否在首 token 之后 d 内断开
+func (o StreamObservation) abandonedWithin(d time.Duration) bool {
+	if !o.Canceled() || o.FirstTokenAt.IsZero() {
+		return false
+	}
+	return o.DisconnectedAt.Sub(o.FirstTokenAt) <= d
+}
+
+// StreamAbuseCounters 窗口内的流式请求计数
+type StreamAbuseCounters struct {
+	Streams   int64 `json:"streams"`
+	Canceled  int64 `json:"canceled"`
+	Abandoned int64 `json:"abandoned"`
+}
+
+// StreamAbuseFlag 被标记为疑似抓取的 API Key
+type StreamAbuseFlag struct {
+	APIKeyID      int64     `json:"api_key_id"`
+	UserID        int64     `json:"user_id"`
+	Reason        string    `json:"reason"`
+	Streams       int64     `json:"streams"`
+	Canceled      int64     `json:"canceled"`
+	Abandoned     int64     `json:"abandoned"`
+	WindowMinutes int       `json:"window_minutes"`
+	FlaggedAt     time.Time `json:"flagged_at"`
+}
+
+// StreamAbuseCache 流式行为计数与标记存储（按分钟分桶，多实例共享）
+type StreamAbuseCache interface {
+	// IncrStreamCounters 累加 at 所在分钟桶的计数
+	IncrStreamCounters(ctx context.Context, apiKeyID int64, at time.Time, canceled, abandoned bool, ttl time.Duration) error
+	// GetStreamCounters 汇总截止 at 的 window 内各分钟桶
+	GetStreamCounters(ctx context.Context, apiKeyID int64, at time.Time, window time.Duration) (StreamAbuseCounters, error)
+	// SetStreamAbuseFlag 写入标记；Key 已处于标记期内时返回 false
+	SetStreamAbuseFlag(ctx context.Context, flag *StreamAbuseFlag, ttl time.Duration) (bool, error)
+	// ListStreamAbuseFlags 列出 since 之后仍有效的标记
+	ListStreamAbuseFlags(ctx context.Context, since time.Time) ([]StreamAbuseFlag, error)
+}
+
+// StreamAbuseService 按 API Key 统计流式请求的取消/立即放弃比例，标记疑似抓取的 Key
+type StreamAbuseService struct {
+	cfg   config.GatewayStreamAbuseConfig
+	cache StreamAbuseCache
+	now   func() time.Time
+}
+
+// NewStreamAbuseService 创建流式滥用检测服务
+func NewStreamAbuseService(cfg *config.Config, cache StreamAbuseCache) *StreamAbuseService {
+	s := &StreamAbuseService{cache: cache, now: time.Now}
+	if cfg != nil {
+		s.cfg = cfg.Gateway.StreamAbuse
+	}
+	return s
+}
+
+// Enabled 是否启用检测
+func (s *StreamAbuseService) Enabled() bool {
+	return s != nil && s.cfg.Enabled && s.cache != nil
+}
+
+func (s *StreamAbuseService) window() time.Duration {
+	return time.Duration(s.cfg.WindowMinutes) * time.Minute
+}
+
+func (s *StreamAbuseService) flagTTL() time.Duration {
+	return time.Duration(s.cfg.FlagTTLMinutes) * time.Minute
+}
+
+// Observe 记录一次流式请求；只有取消的请求才会触发判定，返回本次新产生的标记
+func (s *StreamAbuseService) Observe(ctx context.Context, obs StreamObservation) (*StreamAbuseFlag, error) {
+	if !s.Enabled() || obs.APIKeyID <= 0 {
+		return nil, nil
+	}
+	now := s.now()
+	abandoned := obs.abandonedWithin(time.Duration(s.cfg.EarlyAbandonSeconds) * time.Second)
+	// 桶保留时间比窗口多一分钟，保证窗口边界的桶仍可读取
+	if err := s.cache.IncrStreamCounters(ctx, obs.APIKeyID, now, obs.Canceled(), abandoned, s.window()+time.Minute); err != nil {
+		return nil, err
+	}
+	if !obs.Canceled() {
+		return nil, nil
+	}
+
+	counters, err := s.cache.GetStreamCounters(ctx, obs.APIKeyID, now, s.window())
+	if err != nil {
+		return nil, err
+	}
+	reason := s.evaluate(counters)
+	if reason == "" {
+		return nil, nil
+	}
+
+	flag := &StreamAbuseFlag{
+		APIKeyID:      obs.APIKeyID,
+		UserID:        obs.UserID,
+		Reason:        reason,
+		Streams:       counters.Streams,
+		Canceled:      counters.Canceled,
+		Abandoned:     counters.Abandoned,
+		WindowMinutes: s.cfg.WindowMinutes,
+		FlaggedAt:     now,
+	}
+	created, err := s.cache.SetStreamAbuseFlag(ctx, flag, s.flagTTL())
+	if err != nil || !created {
+		return nil, err
+	}
+	log.Printf("[StreamAbuse] API key %d (user %d) flagged: reason=%s streams=%d canceled=%d abandoned=%d window=%dm",
+		flag.APIKeyID, flag.UserID, flag.Reason, flag.Streams, flag.Canceled, flag.Abandoned, flag.WindowMinutes)
+	return flag, nil
+}
+
+// evaluate 样本数达到下限后，立即放弃占比优先于取消占比判定
+func (s *StreamAbuseService) evaluate(c StreamAbuseCounters) string {
+	if c.Streams <= 0 || c.Streams < int64(s.cfg.MinRequests) {
+		return ""
+	}
+	total := float64(c.Streams)
+	if float64(c.Abandoned)/total >= s.cfg.EarlyAbandonRate {
+		return StreamAbuseReasonEarlyAbandon
+	}
+	if float64(c.Canceled)/total >= s.cfg.CancelRate {
+		return StreamAbuseReasonHighCancel
+	}
+	return ""
+}
+
+// ListFlags 列出当前仍在标记期内的 Key
+func (s *StreamAbuseService) ListFlags(ctx context.Context) ([]StreamAbuseFlag, error) {
+	if !s.Enabled() {
+		return []StreamAbuseFlag{}, nil
+	}
+	return s.cache.ListStreamAbuseFlags(ctx, s.now().Add(-s.flagTTL()))
+}
+
+// CountFlaggedKeys 当前被标记的 Key 数量（运维告警指标 stream_abuse_flagged_keys）
+func (s *StreamAbuseService) CountFlaggedKeys(ctx context.Context) (int, error) {
+	flags, err := s.ListFlags(ctx)
+	if err != nil {
+		return 0, err
+	}
+	return len(flags), nil
+}
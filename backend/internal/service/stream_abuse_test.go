@@ -0,0 +1,153 @@
+//go:build unit
+
+package service
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/Wei-Shaw/sub2api/internal/config"
+	"github.com/stretchr/testify/require"
+)
+
+type streamAbuseCacheStub struct {
+	counters map[int64]*StreamAbuseCounters
+	flags    map[int64]StreamAbuseFlag
+}
+
+func newStreamAbuseCacheStub() *streamAbuseCacheStub {
+	return &streamAbuseCacheStub{counters: map[int64]*StreamAbuseCounters{}, flags: map[int64]StreamAbuseFlag{}}
+}
+
+func (s *streamAbuseCacheStub) IncrStreamCounters(ctx context.Context, apiKeyID int64, at time.Time, canceled, abandoned bool, ttl time.Duration) error {
+	c, ok := s.counters[apiKeyID]
+	if !ok {
+		c = &StreamAbuseCounters{}
+		s.counters[apiKeyID] = c
+	}
+	c.Streams++
+	if canceled {
+		c.Canceled++
+	}
+	if abandoned {
+		c.Abandoned++
+	}
+	return nil
+}
+
+func (s *streamAbuseCacheStub) GetStreamCounters(ctx context.Context, apiKeyID int64, at time.Time, window time.Duration) (StreamAbuseCounters, error) {
+	if c, ok := s.counters[apiKeyID]; ok {
+		return *c, nil
+	}
+	return StreamAbuseCounters{}, nil
+}
+
+func (s *streamAbuseCacheStub) SetStreamAbuseFlag(ctx context.Context, flag *StreamAbuseFlag, ttl time.Duration) (bool, error) {
+	if _, ok := s.flags[flag.APIKeyID]; ok {
+		return false, nil
+	}
+	s.flags[flag.APIKeyID] = *flag
+	return true, nil
+}
+
+func (s *streamAbuseCacheStub) ListStreamAbuseFlags(ctx context.Context, since time.Time) ([]StreamAbuseFlag, error) {
+	out := make([]StreamAbuseFlag, 0, len(s.flags))
+	for _, f := range s.flags {
+		if !f.FlaggedAt.Before(since) {
+			out = append(out, f)
+		}
+	}
+	return out, nil
+}
+
+func newStreamAbuseTestService(cache StreamAbuseCache) *StreamAbuseService {
+	return NewStreamAbuseService(&config.Config{Gateway: config.GatewayConfig{StreamAbuse: config.GatewayStreamAbuseConfig{
+		Enabled:             true,
+		WindowMinutes:       10,
+		MinRequests:         5,
+		EarlyAbandonSeconds: 2,
+		EarlyAbandonRate:    0.6,
+		CancelRate:          0.9,
+		FlagTTLMinutes:      60,
+	}}}, cache)
+}
+
+func TestStreamObservation_Abandoned(t *testing.T) {
+	first := time.Now()
+	require.False(t, StreamObservation{FirstTokenAt: first}.Canceled())
+	require.True(t, StreamObservation{FirstTokenAt: first, DisconnectedAt: first.Add(time.Second)}.abandonedWithin(2*time.Second))
+	require.False(t, StreamObservation{FirstTokenAt: first, DisconnectedAt: first.Add(5 * time.Second)}.abandonedWithin(2*time.Second))
+	// 首 token 之前断开只算取消，不算立即放弃
+	require.False(t, StreamObservation{DisconnectedAt: first}.abandonedWithin(2*time.Second))
+}
+
+func TestStreamAbuseService_FlagsEarlyAbandon(t *testing.T) {
+	cache := newStreamAbuseCacheStub()
+	svc := newStreamAbuseTestService(cache)
+	ctx := context.Background()
+	first := time.Now()
+
+	// 样本不足时不判定
+	for i := 0; i < 4; i++ {
+		flag, err := svc.Observe(ctx, StreamObservation{APIKeyID: 1, UserID: 9, FirstTokenAt: first, DisconnectedAt: first.Add(500 * time.Millisecond)})
+		require.NoError(t, err)
+		require.Nil(t, flag)
+	}
+
+	flag, err := svc.Observe(ctx, StreamObservation{APIKeyID: 1, UserID: 9, FirstTokenAt: first, DisconnectedAt: first.Add(500 * time.Millisecond)})
+	require.NoError(t, err)
+	require.NotNil(t, flag)
+	require.Equal(t, StreamAbuseReasonEarlyAbandon, flag.Reason)
+	require.Equal(t, int64(5), flag.Abandoned)
+	require.Equal(t, int64(9), flag.UserID)
+
+	// 标记期内不重复产生标记
+	flag, err = svc.Observe(ctx, StreamObservation{APIKeyID: 1, FirstTokenAt: first, DisconnectedAt: first})
+	require.NoError(t, err)
+	require.Nil(t, flag)
+
+	count, err := svc.CountFlaggedKeys(ctx)
+	require.NoError(t, err)
+	require.Equal(t, 1, count)
+}
+
+func TestStreamAbuseService_HighCancelRateAndNormalTraffic(t *testing.T) {
+	cache := newStreamAbuseCacheStub()
+	svc := newStreamAbuseTestService(cache)
+	ctx := context.Background()
+	first := time.Now()
+
+	// Key 2：首 token 前就取消（未进入立即放弃统计），取消率 100%
+	var flag *StreamAbuseFlag
+	for i := 0; i < 5; i++ {
+		var err error
+		flag, err = svc.Observe(ctx, StreamObservation{APIKeyID: 2, DisconnectedAt: first})
+		require.NoError(t, err)
+	}
+	require.NotNil(t, flag)
+	require.Equal(t, StreamAbuseReasonHighCancel, flag.Reason)
+
+	// Key 3：大部分正常读完，偶尔取消
+	for i := 0; i < 10; i++ {
+		obs := StreamObservation{APIKeyID: 3, FirstTokenAt: first}
+		if i%5 == 0 {
+			obs.DisconnectedAt = first.Add(time.Second)
+		}
+		flag, err := svc.Observe(ctx, obs)
+		require.NoError(t, err)
+		require.Nil(t, flag)
+	}
+	require.NotContains(t, cache.flags, int64(3))
+}
+
+func TestStreamAbuseService_Disabled(t *testing.T) {
+	svc := NewStreamAbuseService(&config.Config{}, newStreamAbuseCacheStub())
+	require.False(t, svc.Enabled())
+	flag, err := svc.Observe(context.Background(), StreamObservation{APIKeyID: 1, DisconnectedAt: time.Now()})
+	require.NoError(t, err)
+	require.Nil(t, flag)
+
+	var nilSvc *StreamAbuseService
+	require.False(t, nilSvc.Enabled())
+}
@@ -0,0 +1,590 @@
+package service
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"math"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/Wei-Shaw/sub2api/internal/config"
+	infraerrors "github.com/Wei-Shaw/sub2api/internal/pkg/errors"
+)
+
+// Stripe 订阅状态
+const (
+	StripeStatusActive            = "active"
+	StripeStatusTrialing          = "trialing"
+	StripeStatusPastDue           = "past_due"
+	StripeStatusUnpaid            = "unpaid"
+	StripeStatusCanceled          = "canceled"
+	StripeStatusIncompleteExpired = "incomplete_expired"
+	StripeStatusPaused            = "paused"
+)
+
+// stripeUserIDMetadataKey Checkout Session / Subscription metadata 中携带的用户 ID
+const stripeUserIDMetadataKey = "sub2api_user_id"
+
+// stripeUsageReportLag 用量上报截止到 now-lag，给异步写入的用量日志留出落库时间
+const stripeUsageReportLag = 2 * time.Minute
+
+var (
+	ErrStripeDisabled            = infraerrors.NotFound("STRIPE_DISABLED", "stripe integration is disabled")
+	ErrStripeInvalidSignature    = infraerrors.BadRequest("STRIPE_INVALID_SIGNATURE", "invalid stripe webhook signature")
+	ErrStripeInvalidEvent        = infraerrors.BadRequest("STRIPE_INVALID_EVENT", "invalid stripe webhook event")
+	ErrStripeCustomerNotFound    = infraerrors.NotFound("STRIPE_CUSTOMER_NOT_FOUND", "stripe customer not found")
+	ErrStripeCustomerLinked      = infraerrors.Conflict("STRIPE_CUSTOMER_LINKED", "stripe customer is linked to another user")
+	errStripeSignatureTimestamp  = errors.New("stripe signature timestamp outside tolerance")
+	errStripeSignatureMissingSig = errors.New("stripe signature header missing v1 signature")
+)
+
+// StripeCustomer 用户与 Stripe Customer / Subscription 的关联
+type StripeCustomer struct {
+	UserID             int64
+	CustomerID         string
+	SubscriptionID     string
+	SubscriptionStatus string
+	// Metered 订阅是否包含按量计费 Price
+	Metered bool
+	// UserSuspended 用户是否因 Stripe 欠费/取消被自动禁用
+	UserSuspended      bool
+	LastEventAt        *time.Time
+	UsageReportedUntil *time.Time
+	// UsageCarry 取整后未上报的零头（已乘 value_scale）
+	UsageCarry float64
+	CreatedAt  time.Time
+	UpdatedAt  time.Time
+}
+
+// StripeSubscriptionState Webhook 同步后的订阅状态
+type StripeSubscriptionState struct {
+	SubscriptionID string
+	Status         string
+	Metered        bool
+	UserSuspended  bool
+	EventAt        time.Time
+	// ReportFrom 首次成为按量计费客户时的用量上报起点（已有水位时不覆盖）
+	ReportFrom time.Time
+}
+
+// StripeRepository Stripe 关联与 Webhook 幂等记录的持久化
+type StripeRepository interface {
+	GetCustomerByCustomerID(ctx context.Context, customerID string) (*StripeCustomer, error)
+	// LinkCustomer 关联用户与 Stripe Customer；subscriptionID 为空时保留原值
+	LinkCustomer(ctx context.Context, userID int64, customerID, subscriptionID string) error
+	UpdateSubscriptionState(ctx context.Context, userID int64, state StripeSubscriptionState) error
+	ListMeteredCustomers(ctx context.Context) ([]StripeCustomer, error)
+	UpdateUsageReport(ctx context.Context, userID int64, reportedUntil time.Time, carry float64) error
+
+	IsEventProcessed(ctx context.Context, eventID string) (bool, error)
+	MarkEventProcessed(ctx context.Context, eventID, eventType string) error
+}
+
+// StripeMeterEvent 上报到 Stripe Meter Events API 的用量事件
+type StripeMeterEvent struct {
+	EventName  string
+	CustomerID string
+	Value      int64
+	// Identifier Stripe 按此去重（24 小时内），每个上报窗口唯一
+	Identifier string
+	Timestamp  time.Time
+}
+
+// StripeClient Stripe API 调用端口
+type StripeClient interface {
+	ReportMeterEvent(ctx context.Context, event StripeMeterEvent) error
+}
+
+// StripeBillingService 处理 Stripe Webhook（开通/暂停订阅、禁用欠费用户）并定期上报按量用量
+type StripeBillingService struct {
+	cfg                  config.StripeConfig
+	repo                 StripeRepository
+	client               StripeClient
+	subscriptionService  *SubscriptionService
+	userRepo             UserRepository
+	usageRepo            UsageLogRepository
+	authCacheInvalidator APIKeyAuthCacheInvalidator
+
+	planGroups    map[string]int64
+	meteredPrices map[string]struct{}
+	now           func() time.Time
+
+	stopCh   chan struct{}
+	stopOnce sync.Once
+	wg       sync.WaitGroup
+}
+
+// NewStripeBillingService 创建 Stripe 计费集成服务
+func NewStripeBillingService(
+	cfg *config.Config,
+	repo StripeRepository,
+	client StripeClient,
+	subscriptionService *SubscriptionService,
+	userRepo UserRepository,
+	usageRepo UsageLogRepository,
+	authCacheInvalidator APIKeyAuthCacheInvalidator,
+) *StripeBillingService {
+	s := &StripeBillingService{
+		repo:                 repo,
+		client:               client,
+		subscriptionService:  subscriptionService,
+		userRepo:             userRepo,
+		usageRepo:            usageRepo,
+		authCacheInvalidator: authCacheInvalidator,
+		planGroups:           make(map[string]int64),
+		meteredPrices:        make(map[string]struct{}),
+		now:                  time.Now,
+		stopCh:               make(chan struct{}),
+	}
+	if cfg != nil {
+		s.cfg = cfg.Stripe
+	}
+	for _, plan := range s.cfg.Plans {
+		s.planGroups[strings.TrimSpace(plan.PriceID)] = plan.GroupID
+	}
+	for _, priceID := range s.cfg.Metered.PriceIDs {
+		if priceID = strings.TrimSpace(priceID); priceID != "" {
+			s.meteredPrices[priceID] = struct{}{}
+		}
+	}
+	return s
+}
+
+// Enabled 是否启用 Stripe 集成
+func (s *StripeBillingService) Enabled() bool {
+	return s != nil && s.cfg.Enabled
+}
+
+// VerifyStripeSignature 校验 Stripe-Signature 头：t=时间戳,v1=HMAC-SHA256(secret, "t.payload")，
+// 任一 v1 签名匹配且时间戳在容忍范围内即通过
+func VerifyStripeSignature(payload []byte, header, secret string, tolerance time.Duration, now time.Time) error {
+	var (
+		timestamp  int64
+		signatures []string
+	)
+	for _, part := range strings.Split(header, ",") {
+		key, value, ok := strings.Cut(strings.TrimSpace(part), "=")
+		if !ok {
+			continue
+		}
+		switch key {
+		case "t":
+			ts, err := strconv.ParseInt(value, 10, 64)
+			if err != nil {
+				return fmt.Errorf("parse stripe signature timestamp: %w", err)
+			}
+			timestamp = ts
+		case "v1":
+			signatures = append(signatures, value)
+		}
+	}
+	if timestamp == 0 || len(signatures) == 0 {
+		return errStripeSignatureMissingSig
+	}
+
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(strconv.FormatInt(timestamp, 10)))
+	mac.Write([]byte("."))
+	mac.Write(payload)
+	expected := mac.Sum(nil)
+
+	matched := false
+	for _, sig := range signatures {
+		decoded, err := hex.DecodeString(sig)
+		if err == nil && hmac.Equal(decoded, expected) {
+			matched = true
+			break
+		}
+	}
+	if !matched {
+		return ErrStripeInvalidSignature
+	}
+	if tolerance > 0 {
+		skew := now.Sub(time.Unix(timestamp, 0))
+		if skew < 0 {
+			skew = -skew
+		}
+		if skew > tolerance {
+			return errStripeSignatureTimestamp
+		}
+	}
+	return nil
+}
+
+type stripeEvent struct {
+	ID      string `json:"id"`
+	Type    string `json:"type"`
+	Created int64  `json:"created"`
+	Data    struct {
+		Object json.RawMessage `json:"object"`
+	} `json:"data"`
+}
+
+type stripeCheckoutSession struct {
+	Customer          string            `json:"customer"`
+	ClientReferenceID string            `json:"client_reference_id"`
+	Subscription      string            `json:"subscription"`
+	Metadata          map[string]string `json:"metadata"`
+}
+
+type stripeSubscription struct {
+	ID               string            `json:"id"`
+	Customer         string            `json:"customer"`
+	Status           string            `json:"status"`
+	CurrentPeriodEnd int64             `json:"current_period_end"`
+	Metadata         map[string]string `json:"metadata"`
+	Items            struct {
+		Data []struct {
+			// 新版 API 将计费周期移到了订阅项上
+			CurrentPeriodEnd int64 `json:"current_period_end"`
+			Price            struct {
+				ID string `json:"id"`
+			} `json:"price"`
+		} `json:"data"`
+	} `json:"items"`
+}
+
+// periodEnd 当前计费周期结束时间，优先订阅级字段，否则取订阅项中最晚的一个
+func (sub *stripeSubscription) periodEnd() int64 {
+	end := sub.CurrentPeriodEnd
+	for _, item := range sub.Items.Data {
+		if item.CurrentPeriodEnd > end {
+			end = item.CurrentPeriodEnd
+		}
+	}
+	return end
+}
+
+// HandleWebhook 校验签名并处理 Stripe 事件。返回错误时 Stripe 会重试投递，
+// 因此无法关联用户等不可恢复的情况只记录日志并视为成功
+func (s *StripeBillingService) HandleWebhook(ctx context.Context, payload []byte, signatureHeader string) error {
+	if !s.Enabled() {
+		return ErrStripeDisabled
+	}
+	if err := VerifyStripeSignature(payload, signatureHeader, s.cfg.WebhookSecret, s.cfg.WebhookTolerance, s.now()); err != nil {
+		if errors.Is(err, ErrStripeInvalidSignature) {
+			return err
+		}
+		return ErrStripeInvalidSignature.WithCause(err)
+	}
+
+	var event stripeEvent
+	if err := json.Unmarshal(payload, &event); err != nil || event.ID == "" {
+		return ErrStripeInvalidEvent
+	}
+
+	processed, err := s.repo.IsEventProcessed(ctx, event.ID)
+	if err != nil {
+		return fmt.Errorf("check stripe event: %w", err)
+	}
+	if processed {
+		return nil
+	}
+
+	switch event.Type {
+	case "checkout.session.completed":
+		err = s.handleCheckoutCompleted(ctx, event.Data.Object)
+	case "customer.subscription.created",
+		"customer.subscription.updated",
+		"customer.subscription.deleted",
+		"customer.subscription.paused",
+		"customer.subscription.resumed":
+		err = s.handleSubscriptionEvent(ctx, event.Type, time.Unix(event.Created, 0), event.Data.Object)
+	default:
+		// 付款成功/失败会伴随订阅状态变化（active ↔ past_due/unpaid），由订阅事件统一处理
+	}
+	if err != nil {
+		return err
+	}
+	return s.repo.MarkEventProcessed(ctx, event.ID, event.Type)
+}
+
+func (s *StripeBillingService) handleCheckoutCompleted(ctx context.Context, raw json.RawMessage) error {
+	var session stripeCheckoutSession
+	if err := json.Unmarshal(raw, &session); err != nil {
+		return ErrStripeInvalidEvent
+	}
+	if session.Customer == "" {
+		return nil
+	}
+	userID := parseStripeUserID(session.ClientReferenceID)
+	if userID == 0 {
+		userID = parseStripeUserID(session.Metadata[stripeUserIDMetadataKey])
+	}
+	if userID == 0 {
+		log.Printf("[Stripe] Checkout session for customer %s has no user reference, ignored", session.Customer)
+		return nil
+	}
+	if _, err := s.userRepo.GetByID(ctx, userID); err != nil {
+		if errors.Is(err, ErrUserNotFound) {
+			log.Printf("[Stripe] Checkout session references unknown user %d, ignored", userID)
+			return nil
+		}
+		return err
+	}
+	if err := s.repo.LinkCustomer(ctx, userID, session.Customer, session.Subscription); err != nil {
+		if errors.Is(err, ErrStripeCustomerLinked) {
+			log.Printf("[Stripe] Customer %s is already linked to another user, checkout for user %d ignored", session.Customer, userID)
+			return nil
+		}
+		return err
+	}
+	return nil
+}
+
+func (s *StripeBillingService) handleSubscriptionEvent(ctx context.Context, eventType string, eventAt time.Time, raw json.RawMessage) error {
+	var sub stripeSubscription
+	if err := json.Unmarshal(raw, &sub); err != nil || sub.Customer == "" {
+		return ErrStripeInvalidEvent
+	}
+
+	customer, err := s.resolveCustomer(ctx, &sub)
+	if err != nil || customer == nil {
+		return err
+	}
+	// Stripe 不保证投递顺序，丢弃比已处理事件更早的订阅快照
+	if customer.LastEventAt != nil && eventAt.Before(*customer.LastEventAt) {
+		return nil
+	}
+
+	status := sub.Status
+	if eventType == "customer.subscription.deleted" {
+		status = StripeStatusCanceled
+	}
+	active := status == StripeStatusActive || status == StripeStatusTrialing
+	suspended := isStripeSuspendedStatus(status)
+
+	metered := false
+	periodEnd := time.Unix(sub.periodEnd(), 0)
+	for _, item := range sub.Items.Data {
+		priceID := item.Price.ID
+		if _, ok := s.meteredPrices[priceID]; ok {
+			metered = true
+		}
+		groupID, ok := s.planGroups[priceID]
+		if !ok {
+			continue
+		}
+		switch {
+		case active && periodEnd.After(s.now()):
+			notes := fmt.Sprintf("Stripe subscription %s", sub.ID)
+			if _, err := s.subscriptionService.SyncExternalSubscription(ctx, customer.UserID, groupID, periodEnd, notes); err != nil {
+				return fmt.Errorf("activate subscription for user %d group %d: %w", customer.UserID, groupID, err)
+			}
+		case suspended:
+			if err := s.subscriptionService.SuspendUserGroupSubscription(ctx, customer.UserID, groupID); err != nil {
+				return fmt.Errorf("suspend subscription for user %d group %d: %w", customer.UserID, groupID, err)
+			}
+		}
+	}
+
+	// 按量计费用户没有预付额度可暂停，欠费/取消时直接禁用用户；恢复时只解禁由 Stripe 禁用的用户
+	userSuspended := customer.UserSuspended
+	if metered || customer.Metered {
+		switch {
+		case suspended && !customer.UserSuspended:
+			changed, err := s.setUserStatus(ctx, customer.UserID, StatusActive, StatusDisabled)
+			if err != nil {
+				return err
+			}
+			userSuspended = changed
+		case active && customer.UserSuspended:
+			if _, err := s.setUserStatus(ctx, customer.UserID, StatusDisabled, StatusActive); err != nil {
+				return err
+			}
+			userSuspended = false
+		}
+	}
+
+	return s.repo.UpdateSubscriptionState(ctx, customer.UserID, StripeSubscriptionState{
+		SubscriptionID: sub.ID,
+		Status:         status,
+		Metered:        metered,
+		UserSuspended:  userSuspended,
+		EventAt:        eventAt,
+		ReportFrom:     s.now(),
+	})
+}
+
+// resolveCustomer 按 Customer ID 查找关联用户，未关联时尝试订阅 metadata 中的用户 ID
+func (s *StripeBillingService) resolveCustomer(ctx context.Context, sub *stripeSubscription) (*StripeCustomer, error) {
+	customer, err := s.repo.GetCustomerByCustomerID(ctx, sub.Customer)
+	if err == nil {
+		return customer, nil
+	}
+	if !errors.Is(err, ErrStripeCustomerNotFound) {
+		return nil, err
+	}
+
+	userID := parseStripeUserID(sub.Metadata[stripeUserIDMetadataKey])
+	if userID == 0 {
+		log.Printf("[Stripe] Subscription %s belongs to unlinked customer %s, ignored", sub.ID, sub.Customer)
+		return nil, nil
+	}
+	if _, err := s.userRepo.GetByID(ctx, userID); err != nil {
+		if errors.Is(err, ErrUserNotFound) {
+			log.Printf("[Stripe] Subscription %s references unknown user %d, ignored", sub.ID, userID)
+			return nil, nil
+		}
+		return nil, err
+	}
+	if err := s.repo.LinkCustomer(ctx, userID, sub.Customer, sub.ID); err != nil {
+		if errors.Is(err, ErrStripeCustomerLinked) {
+			log.Printf("[Stripe] Customer %s is already linked to another user, subscription %s ignored", sub.Customer, sub.ID)
+			return nil, nil
+		}
+		return nil, err
+	}
+	return s.repo.GetCustomerByCustomerID(ctx, sub.Customer)
+}
+
+// setUserStatus 仅当用户当前状态为 from 时切换到 to，返回是否发生变更
+func (s *StripeBillingService) setUserStatus(ctx context.Context, userID int64, from, to string) (bool, error) {
+	user, err := s.userRepo.GetByID(ctx, userID)
+	if err != nil {
+		return false, err
+	}
+	if user.Status != from {
+		return false, nil
+	}
+	user.Status = to
+	if err := s.userRepo.Update(ctx, user); err != nil {
+		return false, fmt.Errorf("update user status: %w", err)
+	}
+	if s.authCacheInvalidator != nil {
+		s.authCacheInvalidator.InvalidateAuthCacheByUserID(ctx, userID)
+	}
+	log.Printf("[Stripe] User %d status changed %s -> %s", userID, from, to)
+	return true, nil
+}
+
+func isStripeSuspendedStatus(status string) bool {
+	switch status {
+	case StripeStatusPastDue, StripeStatusUnpaid, StripeStatusCanceled, StripeStatusIncompleteExpired, StripeStatusPaused:
+		return true
+	default:
+		return false
+	}
+}
+
+func parseStripeUserID(value string) int64 {
+	id, err := strconv.ParseInt(strings.TrimSpace(value), 10, 64)
+	if err != nil || id <= 0 {
+		return 0
+	}
+	return id
+}
+
+// Start 启动按量用量上报（未配置按量计费时不启动）
+func (s *StripeBillingService) Start() {
+	if !s.Enabled() || s.client == nil || len(s.meteredPrices) == 0 || s.cfg.Metered.ReportInterval <= 0 {
+		return
+	}
+	s.wg.Add(1)
+	go func() {
+		defer s.wg.Done()
+		ticker := time.NewTicker(s.cfg.Metered.ReportInterval)
+		defer ticker.Stop()
+
+		s.runOnce()
+		for {
+			select {
+			case <-ticker.C:
+				s.runOnce()
+			case <-s.stopCh:
+				return
+			}
+		}
+	}()
+}
+
+func (s *StripeBillingService) Stop() {
+	if s == nil {
+		return
+	}
+	s.stopOnce.Do(func() {
+		close(s.stopCh)
+	})
+	s.wg.Wait()
+}
+
+func (s *StripeBillingService) runOnce() {
+	ctx, cancel := context.WithTimeout(context.Background(), 2*time.Minute)
+	defer cancel()
+
+	reported, err := s.ReportUsage(ctx)
+	if err != nil {
+		log.Printf("[Stripe] Report metered usage failed: %v", err)
+		return
+	}
+	if reported > 0 {
+		log.Printf("[Stripe] Reported metered usage for %d customers", reported)
+	}
+}
+
+// ReportUsage 将每个按量计费客户自上次水位以来的实际消费上报到 Stripe，返回上报的客户数
+func (s *StripeBillingService) ReportUsage(ctx context.Context) (int, error) {
+	customers, err := s.repo.ListMeteredCustomers(ctx)
+	if err != nil {
+		return 0, err
+	}
+	end := s.now().Add(-stripeUsageReportLag).Truncate(time.Second)
+	reported := 0
+	for i := range customers {
+		c := &customers[i]
+		if c.UsageReportedUntil == nil || !end.After(*c.UsageReportedUntil) {
+			continue
+		}
+		if c.SubscriptionStatus == StripeStatusCanceled || c.SubscriptionStatus == StripeStatusIncompleteExpired {
+			continue
+		}
+		ok, err := s.reportCustomerUsage(ctx, c, end)
+		if err != nil {
+			log.Printf("[Stripe] Report usage for user %d failed: %v", c.UserID, err)
+			continue
+		}
+		if ok {
+			reported++
+		}
+	}
+	return reported, nil
+}
+
+func (s *StripeBillingService) reportCustomerUsage(ctx context.Context, c *StripeCustomer, end time.Time) (bool, error) {
+	from := *c.UsageReportedUntil
+	// 仅计量分组的用量未扣费，同样不上报给 Stripe
+	cost, err := s.usageRepo.GetUserBillableCost(ctx, c.UserID, from, end)
+	if err != nil {
+		return false, fmt.Errorf("aggregate usage: %w", err)
+	}
+
+	// Meter 只接受整数：向下取整，零头累计到下次上报，避免长期少计
+	amount := cost*s.cfg.Metered.ValueScale + c.UsageCarry
+	value := int64(math.Floor(amount))
+	carry := amount - float64(value)
+
+	if value > 0 {
+		err := s.client.ReportMeterEvent(ctx, StripeMeterEvent{
+			EventName:  s.cfg.Metered.MeterEventName,
+			CustomerID: c.CustomerID,
+			Value:      value,
+			Identifier: fmt.Sprintf("sub2api-usage-%d-%d-%d", c.UserID, from.Unix(), end.Unix()),
+			Timestamp:  end,
+		})
+		if err != nil {
+			return false, err
+		}
+	}
+	if err := s.repo.UpdateUsageReport(ctx, c.UserID, end, carry); err != nil {
+		return false, fmt.Errorf("update usage watermark: %w", err)
+	}
+	return value > 0, nil
+}
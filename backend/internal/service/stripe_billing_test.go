@@ -0,0 +1,314 @@
+//go:build unit
+
+package service
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/Wei-Shaw/sub2api/internal/config"
+	"github.com/Wei-Shaw/sub2api/internal/pkg/usagestats"
+	"github.com/stretchr/testify/require"
+)
+
+const stripeTestSecret = "whsec_test"
+
+type stripeRepoStub struct {
+	customers map[string]*StripeCustomer
+	processed map[string]bool
+	states    []StripeSubscriptionState
+	reported  map[int64]time.Time
+	carry     map[int64]float64
+}
+
+func newStripeRepoStub(customers ...*StripeCustomer) *stripeRepoStub {
+	s := &stripeRepoStub{
+		customers: make(map[string]*StripeCustomer),
+		processed: make(map[string]bool),
+		reported:  make(map[int64]time.Time),
+		carry:     make(map[int64]float64),
+	}
+	for _, c := range customers {
+		s.customers[c.CustomerID] = c
+	}
+	return s
+}
+
+func (s *stripeRepoStub) GetCustomerByCustomerID(ctx context.Context, customerID string) (*StripeCustomer, error) {
+	c, ok := s.customers[customerID]
+	if !ok {
+		return nil, ErrStripeCustomerNotFound
+	}
+	cp := *c
+	return &cp, nil
+}
+
+func (s *stripeRepoStub) LinkCustomer(ctx context.Context, userID int64, customerID, subscriptionID string) error {
+	s.customers[customerID] = &StripeCustomer{UserID: userID, CustomerID: customerID, SubscriptionID: subscriptionID}
+	return nil
+}
+
+func (s *stripeRepoStub) UpdateSubscriptionState(ctx context.Context, userID int64, state StripeSubscriptionState) error {
+	s.states = append(s.states, state)
+	for _, c := range s.customers {
+		if c.UserID == userID {
+			c.SubscriptionStatus = state.Status
+			c.Metered = state.Metered
+			c.UserSuspended = state.UserSuspended
+			eventAt := state.EventAt
+			c.LastEventAt = &eventAt
+		}
+	}
+	return nil
+}
+
+func (s *stripeRepoStub) ListMeteredCustomers(ctx context.Context) ([]StripeCustomer, error) {
+	out := make([]StripeCustomer, 0)
+	for _, c := range s.customers {
+		if c.Metered {
+			out = append(out, *c)
+		}
+	}
+	return out, nil
+}
+
+func (s *stripeRepoStub) UpdateUsageReport(ctx context.Context, userID int64, reportedUntil time.Time, carry float64) error {
+	s.reported[userID] = reportedUntil
+	s.carry[userID] = carry
+	return nil
+}
+
+func (s *stripeRepoStub) IsEventProcessed(ctx context.Context, eventID string) (bool, error) {
+	return s.processed[eventID], nil
+}
+
+func (s *stripeRepoStub) MarkEventProcessed(ctx context.Context, eventID, eventType string) error {
+	s.processed[eventID] = true
+	return nil
+}
+
+type stripeClientStub struct {
+	events []StripeMeterEvent
+}
+
+func (s *stripeClientStub) ReportMeterEvent(ctx context.Context, event StripeMeterEvent) error {
+	s.events = append(s.events, event)
+	return nil
+}
+
+type stripeUserRepoStub struct {
+	UserRepository
+	users map[int64]*User
+}
+
+func (s *stripeUserRepoStub) GetByID(ctx context.Context, id int64) (*User, error) {
+	u, ok := s.users[id]
+	if !ok {
+		return nil, ErrUserNotFound
+	}
+	cp := *u
+	return &cp, nil
+}
+
+func (s *stripeUserRepoStub) Update(ctx context.Context, user *User) error {
+	s.users[user.ID] = user
+	return nil
+}
+
+type stripeGroupRepoStub struct {
+	GroupRepository
+}
+
+func (s *stripeGroupRepoStub) GetByID(ctx context.Context, id int64) (*Group, error) {
+	return &Group{ID: id, SubscriptionType: SubscriptionTypeSubscription}, nil
+}
+
+type stripeUserSubRepoStub struct {
+	UserSubscriptionRepository
+	subs map[int64]*UserSubscription
+}
+
+func (s *stripeUserSubRepoStub) GetByUserIDAndGroupID(ctx context.Context, userID, groupID int64) (*UserSubscription, error) {
+	for _, sub := range s.subs {
+		if sub.UserID == userID && sub.GroupID == groupID {
+			cp := *sub
+			return &cp, nil
+		}
+	}
+	return nil, ErrSubscriptionNotFound
+}
+
+func (s *stripeUserSubRepoStub) Create(ctx context.Context, sub *UserSubscription) error {
+	sub.ID = int64(len(s.subs) + 1)
+	cp := *sub
+	s.subs[sub.ID] = &cp
+	return nil
+}
+
+func (s *stripeUserSubRepoStub) GetByID(ctx context.Context, id int64) (*UserSubscription, error) {
+	sub, ok := s.subs[id]
+	if !ok {
+		return nil, ErrSubscriptionNotFound
+	}
+	cp := *sub
+	return &cp, nil
+}
+
+func (s *stripeUserSubRepoStub) UpdateStatus(ctx context.Context, id int64, status string) error {
+	s.subs[id].Status = status
+	return nil
+}
+
+func (s *stripeUserSubRepoStub) ExtendExpiry(ctx context.Context, id int64, expiresAt time.Time) error {
+	s.subs[id].ExpiresAt = expiresAt
+	return nil
+}
+
+type stripeUsageRepoStub struct {
+	UsageLogRepository
+	cost             float64
+	meteringOnlyCost float64
+}
+
+// GetUserStatsAggregated 汇总全部记录（含仅计量分组），Stripe 上报不应使用
+func (s *stripeUsageRepoStub) GetUserStatsAggregated(ctx context.Context, userID int64, startTime, endTime time.Time) (*usagestats.UsageStats, error) {
+	return &usagestats.UsageStats{TotalActualCost: s.cost + s.meteringOnlyCost}, nil
+}
+
+func (s *stripeUsageRepoStub) GetUserBillableCost(ctx context.Context, userID int64, startTime, endTime time.Time) (float64, error) {
+	return s.cost, nil
+}
+
+func signStripePayload(payload []byte, ts int64) string {
+	mac := hmac.New(sha256.New, []byte(stripeTestSecret))
+	mac.Write([]byte(fmt.Sprintf("%d.", ts)))
+	mac.Write(payload)
+	return fmt.Sprintf("t=%d,v1=%s", ts, hex.EncodeToString(mac.Sum(nil)))
+}
+
+func newStripeTestService(repo *stripeRepoStub, users *stripeUserRepoStub, subs *stripeUserSubRepoStub, usage UsageLogRepository, client StripeClient) *StripeBillingService {
+	cfg := &config.Config{Stripe: config.StripeConfig{
+		Enabled:          true,
+		WebhookSecret:    stripeTestSecret,
+		WebhookTolerance: 5 * time.Minute,
+		Plans:            []config.StripePlanConfig{{PriceID: "price_pro", GroupID: 10}},
+		Metered: config.StripeMeteredConfig{
+			PriceIDs:       []string{"price_usage"},
+			MeterEventName: "api_cost",
+			ReportInterval: time.Hour,
+			ValueScale:     100,
+		},
+	}}
+	subscriptionService := NewSubscriptionService(&stripeGroupRepoStub{}, subs, nil)
+	return NewStripeBillingService(cfg, repo, client, subscriptionService, users, usage, nil)
+}
+
+func TestVerifyStripeSignature(t *testing.T) {
+	payload := []byte(`{"id":"evt_1"}`)
+	now := time.Unix(1700000000, 0)
+	header := signStripePayload(payload, now.Unix())
+
+	require.NoError(t, VerifyStripeSignature(payload, header, stripeTestSecret, 5*time.Minute, now))
+	// 多个 v1 签名（密钥轮换期间）任一匹配即可
+	require.NoError(t, VerifyStripeSignature(payload, "v1=deadbeef,"+header, stripeTestSecret, 5*time.Minute, now))
+
+	require.ErrorIs(t, VerifyStripeSignature(payload, header, "whsec_other", 5*time.Minute, now), ErrStripeInvalidSignature)
+	require.ErrorIs(t, VerifyStripeSignature([]byte(`{"id":"evt_2"}`), header, stripeTestSecret, 5*time.Minute, now), ErrStripeInvalidSignature)
+	require.Error(t, VerifyStripeSignature(payload, header, stripeTestSecret, 5*time.Minute, now.Add(10*time.Minute)))
+	require.Error(t, VerifyStripeSignature(payload, "", stripeTestSecret, 5*time.Minute, now))
+}
+
+func TestStripeBillingService_SubscriptionActivatesPlan(t *testing.T) {
+	repo := newStripeRepoStub()
+	users := &stripeUserRepoStub{users: map[int64]*User{7: {ID: 7, Status: StatusActive}}}
+	subs := &stripeUserSubRepoStub{subs: map[int64]*UserSubscription{}}
+	svc := newStripeTestService(repo, users, subs, nil, nil)
+	now := time.Now()
+	svc.now = func() time.Time { return now }
+
+	periodEnd := now.Add(30 * 24 * time.Hour).Unix()
+	payload := []byte(fmt.Sprintf(`{"id":"evt_sub","type":"customer.subscription.created","created":%d,"data":{"object":{
+		"id":"sub_1","customer":"cus_1","status":"active","metadata":{"sub2api_user_id":"7"},
+		"items":{"data":[{"current_period_end":%d,"price":{"id":"price_pro"}}]}}}}`, now.Unix(), periodEnd))
+
+	require.NoError(t, svc.HandleWebhook(context.Background(), payload, signStripePayload(payload, now.Unix())))
+	require.Len(t, subs.subs, 1)
+	require.Equal(t, int64(7), subs.subs[1].UserID)
+	require.Equal(t, int64(10), subs.subs[1].GroupID)
+	require.Equal(t, periodEnd, subs.subs[1].ExpiresAt.Unix())
+	require.Equal(t, SubscriptionStatusActive, subs.subs[1].Status)
+	require.True(t, repo.processed["evt_sub"])
+
+	// 取消后暂停分组订阅
+	payload = []byte(fmt.Sprintf(`{"id":"evt_del","type":"customer.subscription.deleted","created":%d,"data":{"object":{
+		"id":"sub_1","customer":"cus_1","status":"canceled","items":{"data":[{"price":{"id":"price_pro"}}]}}}}`, now.Unix()+1))
+	require.NoError(t, svc.HandleWebhook(context.Background(), payload, signStripePayload(payload, now.Unix())))
+	require.Equal(t, SubscriptionStatusSuspended, subs.subs[1].Status)
+}
+
+func TestStripeBillingService_MeteredPastDueDisablesUser(t *testing.T) {
+	repo := newStripeRepoStub(&StripeCustomer{UserID: 7, CustomerID: "cus_1"})
+	users := &stripeUserRepoStub{users: map[int64]*User{7: {ID: 7, Status: StatusActive}}}
+	svc := newStripeTestService(repo, users, &stripeUserSubRepoStub{subs: map[int64]*UserSubscription{}}, nil, nil)
+	now := time.Now()
+	svc.now = func() time.Time { return now }
+
+	event := func(id, status string, created int64) []byte {
+		return []byte(fmt.Sprintf(`{"id":%q,"type":"customer.subscription.updated","created":%d,"data":{"object":{
+			"id":"sub_1","customer":"cus_1","status":%q,"items":{"data":[{"price":{"id":"price_usage"}}]}}}}`, id, created, status))
+	}
+
+	pastDue := event("evt_1", StripeStatusPastDue, now.Unix())
+	require.NoError(t, svc.HandleWebhook(context.Background(), pastDue, signStripePayload(pastDue, now.Unix())))
+	require.Equal(t, StatusDisabled, users.users[7].Status)
+	require.True(t, repo.customers["cus_1"].UserSuspended)
+	require.True(t, repo.customers["cus_1"].Metered)
+
+	// 重复投递不再处理
+	require.NoError(t, svc.HandleWebhook(context.Background(), pastDue, signStripePayload(pastDue, now.Unix())))
+	require.Len(t, repo.states, 1)
+
+	// 乱序到达的旧事件被忽略
+	stale := event("evt_0", StripeStatusActive, now.Unix()-60)
+	require.NoError(t, svc.HandleWebhook(context.Background(), stale, signStripePayload(stale, now.Unix())))
+	require.Equal(t, StatusDisabled, users.users[7].Status)
+
+	active := event("evt_2", StripeStatusActive, now.Unix()+60)
+	require.NoError(t, svc.HandleWebhook(context.Background(), active, signStripePayload(active, now.Unix())))
+	require.Equal(t, StatusActive, users.users[7].Status)
+	require.False(t, repo.customers["cus_1"].UserSuspended)
+}
+
+func TestStripeBillingService_ManuallyDisabledUserStaysDisabled(t *testing.T) {
+	repo := newStripeRepoStub(&StripeCustomer{UserID: 7, CustomerID: "cus_1", Metered: true})
+	users := &stripeUserRepoStub{users: map[int64]*User{7: {ID: 7, Status: StatusDisabled}}}
+	svc := newStripeTestService(repo, users, &stripeUserSubRepoStub{subs: map[int64]*UserSubscription{}}, nil, nil)
+
+	payload := []byte(`{"id":"evt_1","type":"customer.subscription.updated","created":1,"data":{"object":{
+		"id":"sub_1","customer":"cus_1","status":"active","items":{"data":[{"price":{"id":"price_usage"}}]}}}}`)
+	require.NoError(t, svc.HandleWebhook(context.Background(), payload, signStripePayload(payload, time.Now().Unix())))
+	require.Equal(t, StatusDisabled, users.users[7].Status)
+}
+
+func TestStripeBillingService_ReportUsageCarriesRemainder(t *testing.T) {
+	from := time.Now().Add(-time.Hour)
+	repo := newStripeRepoStub(&StripeCustomer{UserID: 7, CustomerID: "cus_1", Metered: true, SubscriptionStatus: StripeStatusActive, UsageReportedUntil: &from, UsageCarry: 0.5})
+	client := &stripeClientStub{}
+	svc := newStripeTestService(repo, &stripeUserRepoStub{}, &stripeUserSubRepoStub{}, &stripeUsageRepoStub{cost: 1.238, meteringOnlyCost: 5}, client)
+
+	reported, err := svc.ReportUsage(context.Background())
+	require.NoError(t, err)
+	require.Equal(t, 1, reported)
+	require.Len(t, client.events, 1)
+	// 仅计量记录不上报：1.238 USD × 100 + 0.5 = 124.3 → 上报 124，零头 0.3 留到下次
+	require.Equal(t, int64(124), client.events[0].Value)
+	require.Equal(t, "cus_1", client.events[0].CustomerID)
+	require.Equal(t, "api_cost", client.events[0].EventName)
+	require.InDelta(t, 0.3, repo.carry[7], 1e-9)
+	require.True(t, repo.reported[7].After(from))
+}
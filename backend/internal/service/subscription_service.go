@@ -2,6 +2,7 @@ package service
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"log"
 	"time"
@@ -210,8 +211,12 @@ func (s *SubscriptionService) createSubscription(ctx context.Context, input *Ass
 		validityDays = MaxValidityDays
 	}
 
+	return s.createSubscriptionUntil(ctx, input, time.Now().AddDate(0, 0, validityDays))
+}
+
+// createSubscriptionUntil 创建指定过期时间的新订阅（内部方法）
+func (s *SubscriptionService) createSubscriptionUntil(ctx context.Context, input *AssignSubscriptionInput, expiresAt time.Time) (*UserSubscription, error) {
 	now := time.Now()
-	expiresAt := now.AddDate(0, 0, validityDays)
 	if expiresAt.After(MaxExpiresAt) {
 		expiresAt = MaxExpiresAt
 	}
@@ -240,6 +245,83 @@ func (s *SubscriptionService) createSubscription(ctx context.Context, input *Ass
 	return s.userSubRepo.GetByID(ctx, sub.ID)
 }
 
+// SyncExternalSubscription 按外部计费系统（如 Stripe）的计费周期同步订阅：
+// 已有订阅则将过期时间设置为 expiresAt 并恢复为 active，否则创建新订阅
+func (s *SubscriptionService) SyncExternalSubscription(ctx context.Context, userID, groupID int64, expiresAt time.Time, notes string) (*UserSubscription, error) {
+	group, err := s.groupRepo.GetByID(ctx, groupID)
+	if err != nil {
+		return nil, fmt.Errorf("group not found: %w", err)
+	}
+	if !group.IsSubscriptionType() {
+		return nil, ErrGroupNotSubscriptionType
+	}
+	if expiresAt.After(MaxExpiresAt) {
+		expiresAt = MaxExpiresAt
+	}
+
+	existingSub, err := s.userSubRepo.GetByUserIDAndGroupID(ctx, userID, groupID)
+	if err != nil {
+		if !errors.Is(err, ErrSubscriptionNotFound) {
+			return nil, err
+		}
+		existingSub = nil
+	}
+
+	var sub *UserSubscription
+	if existingSub != nil {
+		if !existingSub.ExpiresAt.Equal(expiresAt) {
+			if err := s.userSubRepo.ExtendExpiry(ctx, existingSub.ID, expiresAt); err != nil {
+				return nil, fmt.Errorf("extend subscription: %w", err)
+			}
+		}
+		if existingSub.Status != SubscriptionStatusActive {
+			if err := s.userSubRepo.UpdateStatus(ctx, existingSub.ID, SubscriptionStatusActive); err != nil {
+				return nil, fmt.Errorf("update subscription status: %w", err)
+			}
+		}
+		if sub, err = s.userSubRepo.GetByID(ctx, existingSub.ID); err != nil {
+			return nil, err
+		}
+	} else {
+		if sub, err = s.createSubscriptionUntil(ctx, &AssignSubscriptionInput{UserID: userID, GroupID: groupID, Notes: notes}, expiresAt); err != nil {
+			return nil, err
+		}
+	}
+
+	s.invalidateSubscriptionCache(userID, groupID)
+	return sub, nil
+}
+
+// SuspendUserGroupSubscription 暂停用户在指定分组的订阅（订阅不存在时忽略）
+func (s *SubscriptionService) SuspendUserGroupSubscription(ctx context.Context, userID, groupID int64) error {
+	existingSub, err := s.userSubRepo.GetByUserIDAndGroupID(ctx, userID, groupID)
+	if err != nil {
+		if errors.Is(err, ErrSubscriptionNotFound) {
+			return nil
+		}
+		return err
+	}
+	if existingSub.Status == SubscriptionStatusSuspended {
+		return nil
+	}
+	if err := s.userSubRepo.UpdateStatus(ctx, existingSub.ID, SubscriptionStatusSuspended); err != nil {
+		return fmt.Errorf("update subscription status: %w", err)
+	}
+	s.invalidateSubscriptionCache(userID, groupID)
+	return nil
+}
+
+func (s *SubscriptionService) invalidateSubscriptionCache(userID, groupID int64) {
+	if s.billingCacheService == nil {
+		return
+	}
+	go func() {
+		cacheCtx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+		_ = s.billingCacheService.InvalidateSubscription(cacheCtx, userID, groupID)
+	}()
+}
+
 // BulkAssignSubscriptionInput 批量分配订阅输入
 type BulkAssignSubscriptionInput struct {
 	UserIDs      []int64
@@ -513,6 +595,12 @@ func (s *SubscriptionService) CheckAndResetWindows(ctx context.Context, sub *Use
 
 	// 月窗口重置（30天）
 	if sub.NeedsMonthlyReset() {
+		// 重置前结转上期未用完的额度；结转失败不阻塞重置
+		if s.billingCacheService != nil {
+			if err := s.carryOverMonthlyQuota(ctx, sub, windowStart); err != nil {
+				log.Printf("Warning: carry over monthly quota failed for subscription %d: %v", sub.ID, err)
+			}
+		}
 		if err := s.userSubRepo.ResetMonthlyUsage(ctx, sub.ID, windowStart); err != nil {
 			return err
 		}
@@ -529,6 +617,29 @@ func (s *SubscriptionService) CheckAndResetWindows(ctx context.Context, sub *Use
 	return nil
 }
 
+func (s *SubscriptionService) carryOverMonthlyQuota(ctx context.Context, sub *UserSubscription, windowStart time.Time) error {
+	group := sub.Group
+	if group == nil {
+		if s.groupRepo == nil {
+			return nil
+		}
+		var err error
+		group, err = s.groupRepo.GetByID(ctx, sub.GroupID)
+		if err != nil {
+			return err
+		}
+	}
+	return s.billingCacheService.CarryOverMonthlyQuota(ctx, sub, group, windowStart)
+}
+
+// monthlyRollover 返回订阅当前有效的月额度结转（未启用结转时为 0）
+func (s *SubscriptionService) monthlyRollover(ctx context.Context, sub *UserSubscription, group *Group) (float64, time.Time) {
+	if s.billingCacheService == nil || !group.HasMonthlyLimit() {
+		return 0, time.Time{}
+	}
+	return s.billingCacheService.MonthlyRollover(ctx, sub.UserID, sub.GroupID)
+}
+
 // CheckUsageLimits 检查使用限额（返回错误如果超限）
 // 用于中间件的快速预检查，additionalCost 通常为 0
 func (s *SubscriptionService) CheckUsageLimits(ctx context.Context, sub *UserSubscription, group *Group, additionalCost float64) error {
@@ -539,7 +650,11 @@ func (s *SubscriptionService) CheckUsageLimits(ctx context.Context, sub *UserSub
 		return ErrWeeklyLimitExceeded
 	}
 	if !sub.CheckMonthlyLimit(group, additionalCost) {
-		return ErrMonthlyLimitExceeded
+		// 超出月额度时再看结转额度，避免正常请求额外读取缓存
+		rollover, _ := s.monthlyRollover(ctx, sub, group)
+		if !sub.CheckMonthlyLimitWithRollover(group, additionalCost, rollover) {
+			return ErrMonthlyLimitExceeded
+		}
 	}
 	return nil
 }
@@ -569,6 +684,9 @@ type UsageWindowProgress struct {
 	WindowStart     time.Time `json:"window_start"`
 	ResetsAt        time.Time `json:"resets_at"`
 	ResetsInSeconds int64     `json:"resets_in_seconds"`
+	// 月额度结转（仅月窗口；剩余额度与百分比已计入结转）
+	RolloverUSD       float64    `json:"rollover_usd,omitempty"`
+	RolloverExpiresAt *time.Time `json:"rollover_expires_at,omitempty"`
 }
 
 // GetSubscriptionProgress 获取订阅使用进度
@@ -644,16 +762,21 @@ func (s *SubscriptionService) GetSubscriptionProgress(ctx context.Context, subsc
 	// 月进度
 	if group.HasMonthlyLimit() && sub.MonthlyWindowStart != nil {
 		limit := *group.MonthlyLimitUSD
+		rollover, rolloverExpiresAt := s.monthlyRollover(ctx, sub, group)
 		resetsAt := sub.MonthlyWindowStart.Add(30 * 24 * time.Hour)
 		progress.Monthly = &UsageWindowProgress{
 			LimitUSD:        limit,
 			UsedUSD:         sub.MonthlyUsageUSD,
-			RemainingUSD:    limit - sub.MonthlyUsageUSD,
-			Percentage:      (sub.MonthlyUsageUSD / limit) * 100,
+			RemainingUSD:    limit + rollover - sub.MonthlyUsageUSD,
+			Percentage:      (sub.MonthlyUsageUSD / (limit + rollover)) * 100,
 			WindowStart:     *sub.MonthlyWindowStart,
 			ResetsAt:        resetsAt,
 			ResetsInSeconds: int64(time.Until(resetsAt).Seconds()),
 		}
+		if rollover > 0 {
+			progress.Monthly.RolloverUSD = rollover
+			progress.Monthly.RolloverExpiresAt = &rolloverExpiresAt
+		}
 		if progress.Monthly.RemainingUSD < 0 {
 			progress.Monthly.RemainingUSD = 0
 		}
@@ -0,0 +1,158 @@
+package service
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/Wei-Shaw/sub2api/internal/domain"
+	infraerrors "github.com/Wei-Shaw/sub2api/internal/pkg/errors"
+	"github.com/Wei-Shaw/sub2api/internal/pkg/openai"
+	"github.com/tidwall/gjson"
+	"github.com/tidwall/sjson"
+)
+
+type SystemPromptPolicy = domain.SystemPromptPolicy
+
+// maxSystemPromptLen 注入提示词的最大字节数
+const maxSystemPromptLen = 32 * 1024
+
+var ErrInvalidSystemPromptPolicy = infraerrors.BadRequest("INVALID_SYSTEM_PROMPT_POLICY", "invalid system prompt policy")
+
+// NormalizeSystemPromptPolicy 清理并校验系统提示词策略；未配置模式时清空提示词
+func NormalizeSystemPromptPolicy(policy SystemPromptPolicy) (SystemPromptPolicy, error) {
+	policy.Mode = strings.ToLower(strings.TrimSpace(policy.Mode))
+	policy.Prompt = strings.TrimSpace(policy.Prompt)
+	switch policy.Mode {
+	case "":
+		return SystemPromptPolicy{}, nil
+	case domain.SystemPromptModeOff:
+		policy.Prompt = ""
+	case domain.SystemPromptModeInjectIfEmpty, domain.SystemPromptModeAlwaysPrepend, domain.SystemPromptModeAlwaysReplace:
+	default:
+		return policy, fmt.Errorf("%w: unsupported mode %q", ErrInvalidSystemPromptPolicy, policy.Mode)
+	}
+	if len(policy.Prompt) > maxSystemPromptLen {
+		return policy, fmt.Errorf("%w: prompt too long (max %d bytes)", ErrInvalidSystemPromptPolicy, maxSystemPromptLen)
+	}
+	return policy, nil
+}
+
+// EffectiveSystemPromptPolicy 计算请求生效的系统提示词策略：API Key 配置覆盖分组配置
+func EffectiveSystemPromptPolicy(apiKey *APIKey) SystemPromptPolicy {
+	if apiKey == nil {
+		return SystemPromptPolicy{}
+	}
+	var policy SystemPromptPolicy
+	if apiKey.Group != nil {
+		policy = apiKey.Group.SystemPromptPolicy
+	}
+	return policy.Merge(apiKey.SystemPromptPolicy)
+}
+
+// ApplySystemPromptPolicy 按分组/API Key 的系统提示词策略改写请求体，返回改写后的请求体。
+// 未配置策略时保持原有行为：OpenAI 协议下非 Codex CLI 客户端且未携带 instructions 时注入内置 OpenCode 指令。
+func ApplySystemPromptPolicy(apiKey *APIKey, body []byte, protocol, userAgent string) ([]byte, error) {
+	policy := EffectiveSystemPromptPolicy(apiKey)
+	if policy.IsEmpty() {
+		if protocol != PlatformOpenAI || openai.IsCodexCLIRequest(userAgent) {
+			return body, nil
+		}
+		policy = SystemPromptPolicy{Mode: domain.SystemPromptModeInjectIfEmpty}
+	}
+	if policy.Mode == domain.SystemPromptModeOff {
+		return body, nil
+	}
+	prompt := policy.Prompt
+	if prompt == "" && protocol == PlatformOpenAI {
+		prompt = strings.TrimSpace(GetOpenCodeInstructions())
+	}
+	if prompt == "" {
+		return body, nil
+	}
+
+	switch protocol {
+	case PlatformOpenAI:
+		return applyOpenAISystemPrompt(body, policy.Mode, prompt)
+	case PlatformAnthropic:
+		return applyAnthropicSystemPrompt(body, policy.Mode, prompt)
+	case PlatformGemini:
+		return applyGeminiSystemPrompt(body, policy.Mode, prompt)
+	default:
+		return body, nil
+	}
+}
+
+// applyOpenAISystemPrompt 改写 Responses 请求的 instructions（字符串）
+func applyOpenAISystemPrompt(body []byte, mode, prompt string) ([]byte, error) {
+	existing := strings.TrimSpace(gjson.GetBytes(body, "instructions").String())
+	switch {
+	case existing == "" || mode == domain.SystemPromptModeAlwaysReplace:
+		return sjson.SetBytes(body, "instructions", prompt)
+	case mode == domain.SystemPromptModeAlwaysPrepend:
+		return sjson.SetBytes(body, "instructions", prompt+"\n\n"+existing)
+	default:
+		return body, nil
+	}
+}
+
+// applyAnthropicSystemPrompt 改写 Messages 请求的 system（字符串或 text 块数组）
+func applyAnthropicSystemPrompt(body []byte, mode, prompt string) ([]byte, error) {
+	system := gjson.GetBytes(body, "system")
+	empty := true
+	if system.IsArray() {
+		for _, block := range system.Array() {
+			if strings.TrimSpace(block.Get("text").String()) != "" {
+				empty = false
+				break
+			}
+		}
+	} else if strings.TrimSpace(system.String()) != "" {
+		empty = false
+	}
+
+	switch {
+	case empty || mode == domain.SystemPromptModeAlwaysReplace:
+		return sjson.SetBytes(body, "system", prompt)
+	case mode != domain.SystemPromptModeAlwaysPrepend:
+		return body, nil
+	case system.IsArray():
+		blocks := make([]any, 0, len(system.Array())+1)
+		blocks = append(blocks, map[string]any{"type": "text", "text": prompt})
+		for _, block := range system.Array() {
+			blocks = append(blocks, block.Value())
+		}
+		return sjson.SetBytes(body, "system", blocks)
+	default:
+		return sjson.SetBytes(body, "system", prompt+"\n\n"+system.String())
+	}
+}
+
+// applyGeminiSystemPrompt 改写 generateContent 请求的 systemInstruction（兼容 system_instruction 写法）
+func applyGeminiSystemPrompt(body []byte, mode, prompt string) ([]byte, error) {
+	key := "systemInstruction"
+	if !gjson.GetBytes(body, key).Exists() && gjson.GetBytes(body, "system_instruction").Exists() {
+		key = "system_instruction"
+	}
+	parts := gjson.GetBytes(body, key+".parts")
+	empty := true
+	for _, part := range parts.Array() {
+		if strings.TrimSpace(part.Get("text").String()) != "" {
+			empty = false
+			break
+		}
+	}
+
+	switch {
+	case empty || mode == domain.SystemPromptModeAlwaysReplace:
+		return sjson.SetBytes(body, key, map[string]any{"parts": []any{map[string]any{"text": prompt}}})
+	case mode == domain.SystemPromptModeAlwaysPrepend:
+		newParts := make([]any, 0, len(parts.Array())+1)
+		newParts = append(newParts, map[string]any{"text": prompt})
+		for _, part := range parts.Array() {
+			newParts = append(newParts, part.Value())
+		}
+		return sjson.SetBytes(body, key+".parts", newParts)
+	default:
+		return body, nil
+	}
+}
@@ -0,0 +1,134 @@
+//go:build unit
+
+package service
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/Wei-Shaw/sub2api/internal/domain"
+	"github.com/stretchr/testify/require"
+	"github.com/tidwall/gjson"
+)
+
+func TestNormalizeSystemPromptPolicy(t *testing.T) {
+	policy, err := NormalizeSystemPromptPolicy(SystemPromptPolicy{Mode: " Always_Prepend ", Prompt: "  be nice  "})
+	require.NoError(t, err)
+	require.Equal(t, SystemPromptPolicy{Mode: domain.SystemPromptModeAlwaysPrepend, Prompt: "be nice"}, policy)
+
+	// 未配置模式时整体清空
+	policy, err = NormalizeSystemPromptPolicy(SystemPromptPolicy{Prompt: "ignored"})
+	require.NoError(t, err)
+	require.True(t, policy.IsEmpty())
+	require.Empty(t, policy.Prompt)
+
+	// off 模式不保留提示词
+	policy, err = NormalizeSystemPromptPolicy(SystemPromptPolicy{Mode: "off", Prompt: "ignored"})
+	require.NoError(t, err)
+	require.Equal(t, SystemPromptPolicy{Mode: domain.SystemPromptModeOff}, policy)
+
+	_, err = NormalizeSystemPromptPolicy(SystemPromptPolicy{Mode: "append"})
+	require.ErrorIs(t, err, ErrInvalidSystemPromptPolicy)
+
+	_, err = NormalizeSystemPromptPolicy(SystemPromptPolicy{Mode: "always_replace", Prompt: strings.Repeat("a", maxSystemPromptLen+1)})
+	require.ErrorIs(t, err, ErrInvalidSystemPromptPolicy)
+}
+
+func TestEffectiveSystemPromptPolicy_KeyOverridesGroup(t *testing.T) {
+	groupPolicy := SystemPromptPolicy{Mode: domain.SystemPromptModeAlwaysPrepend, Prompt: "group"}
+	apiKey := &APIKey{Group: &Group{SystemPromptPolicy: groupPolicy}}
+	require.Equal(t, groupPolicy, EffectiveSystemPromptPolicy(apiKey))
+
+	apiKey.SystemPromptPolicy = SystemPromptPolicy{Mode: domain.SystemPromptModeOff}
+	require.Equal(t, domain.SystemPromptModeOff, EffectiveSystemPromptPolicy(apiKey).Mode)
+
+	require.True(t, EffectiveSystemPromptPolicy(nil).IsEmpty())
+}
+
+func TestApplySystemPromptPolicy_DefaultOpenAIBehavior(t *testing.T) {
+	body := []byte(`{"model":"gpt-5.2","input":[]}`)
+	builtin := strings.TrimSpace(GetOpenCodeInstructions())
+
+	// 未配置策略：非 Codex CLI 客户端缺省注入内置指令
+	out, err := ApplySystemPromptPolicy(&APIKey{}, body, PlatformOpenAI, "opencode/1.0")
+	require.NoError(t, err)
+	require.Equal(t, builtin, gjson.GetBytes(out, "instructions").String())
+
+	// 已携带 instructions 时保持不变
+	withInstructions := []byte(`{"model":"gpt-5.2","instructions":"custom"}`)
+	out, err = ApplySystemPromptPolicy(&APIKey{}, withInstructions, PlatformOpenAI, "opencode/1.0")
+	require.NoError(t, err)
+	require.Equal(t, "custom", gjson.GetBytes(out, "instructions").String())
+
+	// 其他协议未配置策略时不注入
+	out, err = ApplySystemPromptPolicy(&APIKey{}, []byte(`{"model":"claude"}`), PlatformAnthropic, "")
+	require.NoError(t, err)
+	require.False(t, gjson.GetBytes(out, "system").Exists())
+
+	// off 模式关闭缺省注入
+	off := &APIKey{SystemPromptPolicy: SystemPromptPolicy{Mode: domain.SystemPromptModeOff}}
+	out, err = ApplySystemPromptPolicy(off, body, PlatformOpenAI, "opencode/1.0")
+	require.NoError(t, err)
+	require.False(t, gjson.GetBytes(out, "instructions").Exists())
+}
+
+func TestApplySystemPromptPolicy_OpenAIModes(t *testing.T) {
+	body := []byte(`{"model":"gpt-5.2","instructions":"client"}`)
+
+	prepend := &APIKey{SystemPromptPolicy: SystemPromptPolicy{Mode: domain.SystemPromptModeAlwaysPrepend, Prompt: "policy"}}
+	out, err := ApplySystemPromptPolicy(prepend, body, PlatformOpenAI, "")
+	require.NoError(t, err)
+	require.Equal(t, "policy\n\nclient", gjson.GetBytes(out, "instructions").String())
+
+	replace := &APIKey{SystemPromptPolicy: SystemPromptPolicy{Mode: domain.SystemPromptModeAlwaysReplace, Prompt: "policy"}}
+	out, err = ApplySystemPromptPolicy(replace, body, PlatformOpenAI, "")
+	require.NoError(t, err)
+	require.Equal(t, "policy", gjson.GetBytes(out, "instructions").String())
+
+	ifEmpty := &APIKey{SystemPromptPolicy: SystemPromptPolicy{Mode: domain.SystemPromptModeInjectIfEmpty, Prompt: "policy"}}
+	out, err = ApplySystemPromptPolicy(ifEmpty, body, PlatformOpenAI, "")
+	require.NoError(t, err)
+	require.Equal(t, "client", gjson.GetBytes(out, "instructions").String())
+}
+
+func TestApplySystemPromptPolicy_AnthropicModes(t *testing.T) {
+	prepend := &APIKey{SystemPromptPolicy: SystemPromptPolicy{Mode: domain.SystemPromptModeAlwaysPrepend, Prompt: "policy"}}
+
+	out, err := ApplySystemPromptPolicy(prepend, []byte(`{"system":"client"}`), PlatformAnthropic, "")
+	require.NoError(t, err)
+	require.Equal(t, "policy\n\nclient", gjson.GetBytes(out, "system").String())
+
+	// 数组形式的 system 在首位插入 text 块，保留原有块（含 cache_control）
+	out, err = ApplySystemPromptPolicy(prepend, []byte(`{"system":[{"type":"text","text":"client","cache_control":{"type":"ephemeral"}}]}`), PlatformAnthropic, "")
+	require.NoError(t, err)
+	blocks := gjson.GetBytes(out, "system").Array()
+	require.Len(t, blocks, 2)
+	require.Equal(t, "policy", blocks[0].Get("text").String())
+	require.Equal(t, "ephemeral", blocks[1].Get("cache_control.type").String())
+
+	ifEmpty := &APIKey{Group: &Group{SystemPromptPolicy: SystemPromptPolicy{Mode: domain.SystemPromptModeInjectIfEmpty, Prompt: "policy"}}}
+	out, err = ApplySystemPromptPolicy(ifEmpty, []byte(`{"messages":[]}`), PlatformAnthropic, "")
+	require.NoError(t, err)
+	require.Equal(t, "policy", gjson.GetBytes(out, "system").String())
+
+	// 未配置提示词时 Anthropic 协议不注入
+	noPrompt := &APIKey{SystemPromptPolicy: SystemPromptPolicy{Mode: domain.SystemPromptModeAlwaysReplace}}
+	out, err = ApplySystemPromptPolicy(noPrompt, []byte(`{"system":"client"}`), PlatformAnthropic, "")
+	require.NoError(t, err)
+	require.Equal(t, "client", gjson.GetBytes(out, "system").String())
+}
+
+func TestApplySystemPromptPolicy_GeminiModes(t *testing.T) {
+	prepend := &APIKey{SystemPromptPolicy: SystemPromptPolicy{Mode: domain.SystemPromptModeAlwaysPrepend, Prompt: "policy"}}
+	out, err := ApplySystemPromptPolicy(prepend, []byte(`{"system_instruction":{"parts":[{"text":"client"}]}}`), PlatformGemini, "")
+	require.NoError(t, err)
+	parts := gjson.GetBytes(out, "system_instruction.parts").Array()
+	require.Len(t, parts, 2)
+	require.Equal(t, "policy", parts[0].Get("text").String())
+	require.Equal(t, "client", parts[1].Get("text").String())
+
+	replace := &APIKey{SystemPromptPolicy: SystemPromptPolicy{Mode: domain.SystemPromptModeAlwaysReplace, Prompt: "policy"}}
+	out, err = ApplySystemPromptPolicy(replace, []byte(`{"contents":[]}`), PlatformGemini, "")
+	require.NoError(t, err)
+	require.Equal(t, "policy", gjson.GetBytes(out, "systemInstruction.parts.0.text").String())
+}
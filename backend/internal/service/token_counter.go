@@ -0,0 +1,142 @@
+package service
+
+import (
+	"encoding/json"
+
+	"github.com/Wei-Shaw/sub2api/internal/config"
+	"github.com/Wei-Shaw/sub2api/internal/pkg/tokenizer"
+	"github.com/tidwall/gjson"
+	"github.com/tidwall/sjson"
+)
+
+// TokenCount 本地 token 估算结果
+type TokenCount struct {
+	Model       string `json:"model"`
+	InputTokens int    `json:"input_tokens"`
+	// Tokenizer 使用的分词器家族（openai/claude/gemini）
+	Tokenizer string `json:"tokenizer"`
+}
+
+// TokenCounterService 本地 token 计数服务：用于 count_tokens 本地应答、上下文裁剪与 /v1/token-count 接口，
+// 额度预检（estimateRequestMaxCost）直接使用同一分词器
+type TokenCounterService struct {
+	cfg config.GatewayTokenCountingConfig
+}
+
+// NewTokenCounterService 创建本地 token 计数服务
+func NewTokenCounterService(cfg *config.Config) *TokenCounterService {
+	s := &TokenCounterService{}
+	if cfg != nil {
+		s.cfg = cfg.Gateway.TokenCounting
+	}
+	return s
+}
+
+// LocalCountTokens 是否由本地估算应答 count_tokens
+func (s *TokenCounterService) LocalCountTokens() bool {
+	return s != nil && s.cfg.LocalCountTokens
+}
+
+// CountRequestTokens 估算请求体的提示 token 数
+func (s *TokenCounterService) CountRequestTokens(platform, model string, body []byte) *TokenCount {
+	family := tokenizer.ForModel(platform, model)
+	return &TokenCount{
+		Model:       model,
+		InputTokens: tokenizer.CountJSON(family, body),
+		Tokenizer:   string(family),
+	}
+}
+
+// TrimContext 估算提示 token 超过 context_trim_max_tokens 时，从最早的对话轮次开始删除，
+// 直到不超过上限或只剩最新一轮；删除后对话总是从一条普通 user 消息开始，不会留下孤立的工具结果。
+// 支持 Anthropic/Chat Completions 的 messages 与 Responses 的 input；返回裁剪后的请求体与删除的条目数。
+func (s *TokenCounterService) TrimContext(platform, model string, body []byte) ([]byte, int) {
+	if s == nil || s.cfg.ContextTrimMaxTokens <= 0 {
+		return body, 0
+	}
+	family := tokenizer.ForModel(platform, model)
+	total := tokenizer.CountJSON(family, body)
+	if total <= s.cfg.ContextTrimMaxTokens {
+		return body, 0
+	}
+
+	path := "messages"
+	items := gjson.GetBytes(body, path)
+	if !items.IsArray() {
+		path = "input"
+		items = gjson.GetBytes(body, path)
+	}
+	if !items.IsArray() {
+		return body, 0
+	}
+	list := items.Array()
+	// 候选切分点：从某条普通 user 消息开始保留，删除其之前除 system/developer 以外的条目；
+	// 取满足上限的最早切分点，都不满足时保留最新一轮
+	cut, droppedTokens := -1, 0
+	for j := 1; j < len(list); j++ {
+		item := list[j-1]
+		if !isPinnedContextItem(item) {
+			droppedTokens += tokenizer.CountJSON(family, []byte(item.Raw))
+		}
+		if !isConversationStart(list[j]) {
+			continue
+		}
+		cut = j
+		if total-droppedTokens <= s.cfg.ContextTrimMaxTokens {
+			break
+		}
+	}
+	if cut <= 0 {
+		return body, 0
+	}
+
+	kept := make([]json.RawMessage, 0, len(list))
+	removed := 0
+	for i, item := range list {
+		if i < cut && !isPinnedContextItem(item) {
+			removed++
+			continue
+		}
+		kept = append(kept, json.RawMessage(item.Raw))
+	}
+	if removed == 0 {
+		return body, 0
+	}
+	raw, err := json.Marshal(kept)
+	if err != nil {
+		return body, 0
+	}
+	trimmed, err := sjson.SetRawBytes(body, path, raw)
+	if err != nil {
+		return body, 0
+	}
+	return trimmed, removed
+}
+
+// isPinnedContextItem 裁剪时始终保留的条目（消息列表中的 system/developer 指令）
+func isPinnedContextItem(item gjson.Result) bool {
+	switch item.Get("role").String() {
+	case "system", "developer":
+		return true
+	}
+	return false
+}
+
+// isConversationStart 条目是否为不含工具结果的 user 消息
+func isConversationStart(item gjson.Result) bool {
+	if item.Get("role").String() != "user" {
+		return false
+	}
+	if t := item.Get("type").String(); t != "" && t != "message" {
+		return false
+	}
+	content := item.Get("content")
+	if content.IsArray() {
+		for _, block := range content.Array() {
+			if block.Get("type").String() == "tool_result" {
+				return false
+			}
+		}
+	}
+	return true
+}
@@ -0,0 +1,78 @@
+//go:build unit
+
+package service
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/Wei-Shaw/sub2api/internal/config"
+	"github.com/stretchr/testify/require"
+	"github.com/tidwall/gjson"
+)
+
+func newTokenCounterTestService(maxTokens int) *TokenCounterService {
+	cfg := &config.Config{}
+	cfg.Gateway.TokenCounting = config.GatewayTokenCountingConfig{LocalCountTokens: true, ContextTrimMaxTokens: maxTokens}
+	return NewTokenCounterService(cfg)
+}
+
+func TestTokenCounterService_CountRequestTokens(t *testing.T) {
+	svc := newTokenCounterTestService(0)
+	require.True(t, svc.LocalCountTokens())
+
+	count := svc.CountRequestTokens(PlatformAnthropic, "claude-sonnet-4-5", []byte(`{"model":"claude-sonnet-4-5","messages":[{"role":"user","content":"hello world"}]}`))
+	require.Equal(t, "claude-sonnet-4-5", count.Model)
+	require.Equal(t, "claude", count.Tokenizer)
+	require.Greater(t, count.InputTokens, 0)
+
+	var nilSvc *TokenCounterService
+	require.False(t, nilSvc.LocalCountTokens())
+}
+
+func TestTokenCounterService_TrimContextDisabled(t *testing.T) {
+	svc := newTokenCounterTestService(0)
+	body := []byte(`{"model":"gpt-5","input":[{"role":"user","content":"` + strings.Repeat("word ", 200) + `"}]}`)
+	trimmed, removed := svc.TrimContext(PlatformOpenAI, "gpt-5", body)
+	require.Zero(t, removed)
+	require.Equal(t, body, trimmed)
+}
+
+func TestTokenCounterService_TrimContextDropsOldestTurns(t *testing.T) {
+	svc := newTokenCounterTestService(400)
+	long := strings.Repeat("lorem ipsum dolor sit amet ", 40)
+	body := []byte(`{"model":"claude-sonnet-4-5","messages":[` +
+		`{"role":"user","content":"` + long + `"},` +
+		`{"role":"assistant","content":[{"type":"tool_use","id":"t1","name":"f","input":{}}]},` +
+		`{"role":"user","content":[{"type":"tool_result","tool_use_id":"t1","content":"` + long + `"}]},` +
+		`{"role":"assistant","content":"done"},` +
+		`{"role":"user","content":"latest question"}]}`)
+
+	trimmed, removed := svc.TrimContext(PlatformAnthropic, "claude-sonnet-4-5", body)
+	require.Equal(t, 4, removed)
+	messages := gjson.GetBytes(trimmed, "messages").Array()
+	require.Len(t, messages, 1)
+	require.Equal(t, "latest question", messages[0].Get("content").String())
+	require.Equal(t, "claude-sonnet-4-5", gjson.GetBytes(trimmed, "model").String())
+}
+
+func TestTokenCounterService_TrimContextKeepsPinnedAndToolPairs(t *testing.T) {
+	svc := newTokenCounterTestService(400)
+	long := strings.Repeat("lorem ipsum dolor sit amet ", 40)
+	body := []byte(`{"model":"gpt-5","input":[` +
+		`{"role":"developer","content":"be concise"},` +
+		`{"role":"user","content":"` + long + `"},` +
+		`{"role":"assistant","content":"` + long + `"},` +
+		`{"role":"user","content":"next"},` +
+		`{"type":"function_call","call_id":"c1","name":"f","arguments":"{}"},` +
+		`{"type":"function_call_output","call_id":"c1","output":"ok"}]}`)
+
+	trimmed, removed := svc.TrimContext(PlatformOpenAI, "gpt-5", body)
+	require.Equal(t, 2, removed)
+	items := gjson.GetBytes(trimmed, "input").Array()
+	require.Len(t, items, 4)
+	require.Equal(t, "developer", items[0].Get("role").String())
+	require.Equal(t, "next", items[1].Get("content").String())
+	require.Equal(t, "function_call", items[2].Get("type").String())
+	require.Equal(t, "function_call_output", items[3].Get("type").String())
+}
@@ -0,0 +1,157 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"strings"
+	"time"
+
+	"github.com/Wei-Shaw/sub2api/internal/config"
+	"github.com/Wei-Shaw/sub2api/internal/domain"
+	infraerrors "github.com/Wei-Shaw/sub2api/internal/pkg/errors"
+	"github.com/Wei-Shaw/sub2api/internal/pkg/timezone"
+)
+
+type TokenQuota = domain.TokenQuota
+
+var (
+	ErrInvalidTokenQuota = infraerrors.BadRequest("INVALID_TOKEN_QUOTA", "token_quota limits must be non-negative and timezone must be a valid IANA time zone")
+
+	ErrAPIKeyDailyTokenQuotaExceeded     = infraerrors.TooManyRequests("API_KEY_DAILY_TOKEN_QUOTA_EXCEEDED", "daily token quota exceeded for this API key")
+	ErrAPIKeyMonthlyTokenQuotaExceeded   = infraerrors.TooManyRequests("API_KEY_MONTHLY_TOKEN_QUOTA_EXCEEDED", "monthly token quota exceeded for this API key")
+	ErrAPIKeyDailyRequestQuotaExceeded   = infraerrors.TooManyRequests("API_KEY_DAILY_REQUEST_QUOTA_EXCEEDED", "daily request quota exceeded for this API key")
+	ErrAPIKeyMonthlyRequestQuotaExceeded = infraerrors.TooManyRequests("API_KEY_MONTHLY_REQUEST_QUOTA_EXCEEDED", "monthly request quota exceeded for this API key")
+)
+
+// TokenQuotaUsage API Key 当前自然日/月的 token 与请求数用量
+type TokenQuotaUsage struct {
+	// Day/Month 当前周期标识（配额时区下的 20060102 / 200601）
+	Day             string `json:"day"`
+	Month           string `json:"month"`
+	DailyTokens     int64  `json:"daily_tokens"`
+	MonthlyTokens   int64  `json:"monthly_tokens"`
+	DailyRequests   int64  `json:"daily_requests"`
+	MonthlyRequests int64  `json:"monthly_requests"`
+}
+
+// NormalizeTokenQuota 校验并清理 token 配额；未配置任何限制时返回零值（同时丢弃时区）
+func NormalizeTokenQuota(quota TokenQuota) (TokenQuota, error) {
+	if quota.DailyTokens < 0 || quota.MonthlyTokens < 0 || quota.DailyRequests < 0 || quota.MonthlyRequests < 0 {
+		return TokenQuota{}, ErrInvalidTokenQuota
+	}
+	if quota.IsEmpty() {
+		return TokenQuota{}, nil
+	}
+	quota.Timezone = strings.TrimSpace(quota.Timezone)
+	if quota.Timezone != "" {
+		if _, err := time.LoadLocation(quota.Timezone); err != nil {
+			return TokenQuota{}, fmt.Errorf("%w: %v", ErrInvalidTokenQuota, err)
+		}
+	}
+	return quota, nil
+}
+
+// tokenQuotaPeriod 返回配额时区下 now 所在的自然日与自然月标识；时区为空或无效时使用服务器时区
+func tokenQuotaPeriod(quota TokenQuota, now time.Time) (day, month string) {
+	loc := timezone.Location()
+	if quota.Timezone != "" {
+		if userLoc, err := time.LoadLocation(quota.Timezone); err == nil {
+			loc = userLoc
+		}
+	}
+	t := now.In(loc)
+	return t.Format("20060102"), t.Format("200601")
+}
+
+// checkTokenQuota 检查 API Key 当前自然日/月的 token 与请求数是否已达上限。
+// 计数在请求完成后累加，因此单个请求可能使用量略微超过上限，之后的请求会被拒绝。
+func (s *BillingCacheService) checkTokenQuota(ctx context.Context, apiKey *APIKey) error {
+	if apiKey == nil || apiKey.TokenQuota.IsEmpty() || s.cache == nil {
+		return nil
+	}
+	usage, err := s.GetTokenQuotaUsage(ctx, apiKey)
+	if err != nil {
+		if s.circuitBreaker != nil {
+			s.circuitBreaker.OnFailure(err)
+		}
+		log.Printf("ALERT: token quota check failed for api key %d: %v", apiKey.ID, err)
+		return ErrBillingServiceUnavailable.WithCause(err)
+	}
+
+	quota := apiKey.TokenQuota
+	switch {
+	case quota.DailyRequests > 0 && usage.DailyRequests >= quota.DailyRequests:
+		return ErrAPIKeyDailyRequestQuotaExceeded
+	case quota.MonthlyRequests > 0 && usage.MonthlyRequests >= quota.MonthlyRequests:
+		return ErrAPIKeyMonthlyRequestQuotaExceeded
+	case quota.DailyTokens > 0 && usage.DailyTokens >= quota.DailyTokens:
+		return ErrAPIKeyDailyTokenQuotaExceeded
+	case quota.MonthlyTokens > 0 && usage.MonthlyTokens >= quota.MonthlyTokens:
+		return ErrAPIKeyMonthlyTokenQuotaExceeded
+	}
+	return nil
+}
+
+// GetTokenQuotaUsage 获取 API Key 当前自然日/月的 token 与请求数用量
+func (s *BillingCacheService) GetTokenQuotaUsage(ctx context.Context, apiKey *APIKey) (*TokenQuotaUsage, error) {
+	day, month := tokenQuotaPeriod(apiKey.TokenQuota, time.Now())
+	if s.cache == nil {
+		return &TokenQuotaUsage{Day: day, Month: month}, nil
+	}
+	usage, err := s.cache.GetTokenQuotaUsage(ctx, apiKey.ID, day, month)
+	if err != nil {
+		return nil, err
+	}
+	usage.Day, usage.Month = day, month
+	return usage, nil
+}
+
+// QueueTokenQuotaUsage 异步累加 API Key 的 token 与请求数（仅配置了配额的 Key）
+func (s *BillingCacheService) QueueTokenQuotaUsage(apiKey *APIKey, tokens int64) {
+	if s == nil || s.cache == nil || apiKey == nil || apiKey.TokenQuota.IsEmpty() {
+		return
+	}
+	if s.cfg != nil && s.cfg.RunMode == config.RunModeSimple {
+		return
+	}
+	day, month := tokenQuotaPeriod(apiKey.TokenQuota, time.Now())
+	task := cacheWriteTask{
+		kind:     cacheWriteIncrementTokenQuota,
+		apiKeyID: apiKey.ID,
+		day:      day,
+		month:    month,
+		tokens:   tokens,
+	}
+	// 队列满时同步回退：配额计数丢失会导致硬限额失效
+	if s.enqueueCacheWrite(task) {
+		return
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), cacheWriteTimeout)
+	defer cancel()
+	s.incrementTokenQuotaReliable(ctx, apiKey.ID, day, month, tokens)
+}
+
+// incrementTokenQuotaReliable 累加配额计数，重试仍失败时登记到本地缓冲。
+// 配额计数只存在于 Redis，Redis 恢复后按合并后的 token 数与请求数重放。
+func (s *BillingCacheService) incrementTokenQuotaReliable(ctx context.Context, apiKeyID int64, day, month string, tokens int64) {
+	err := retryCacheOp(ctx, func(ctx context.Context) error {
+		return s.cache.IncrementTokenQuotaUsage(ctx, apiKeyID, day, month, tokens)
+	})
+	if err == nil {
+		return
+	}
+	log.Printf("Warning: increment token quota cache failed for api key %d, buffered until redis recovers: %v", apiKeyID, err)
+	key := fmt.Sprintf("token_quota:%d:%s:%s", apiKeyID, day, month)
+	s.fallback.Add(key, float64(tokens), func(ctx context.Context, e *cacheDeltaEntry) error {
+		// 每次调用计一次请求：首次写入全部 token，其余只补请求数
+		for e.count > 0 {
+			if err := s.cache.IncrementTokenQuotaUsage(ctx, apiKeyID, day, month, int64(e.delta)); err != nil {
+				return err
+			}
+			e.delta = 0
+			e.count--
+		}
+		return nil
+	})
+}
@@ -0,0 +1,76 @@
+//go:build unit
+
+package service
+
+import (
+	"context"
+	"errors"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/Wei-Shaw/sub2api/internal/config"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNormalizeTokenQuota(t *testing.T) {
+	quota, err := NormalizeTokenQuota(TokenQuota{DailyTokens: 1000, Timezone: " Asia/Shanghai "})
+	require.NoError(t, err)
+	require.Equal(t, TokenQuota{DailyTokens: 1000, Timezone: "Asia/Shanghai"}, quota)
+
+	// 未配置任何限制时丢弃时区
+	quota, err = NormalizeTokenQuota(TokenQuota{Timezone: "Asia/Shanghai"})
+	require.NoError(t, err)
+	require.True(t, quota.IsEmpty())
+	require.Empty(t, quota.Timezone)
+
+	_, err = NormalizeTokenQuota(TokenQuota{MonthlyRequests: -1})
+	require.ErrorIs(t, err, ErrInvalidTokenQuota)
+
+	_, err = NormalizeTokenQuota(TokenQuota{DailyRequests: 10, Timezone: "Mars/Olympus"})
+	require.ErrorIs(t, err, ErrInvalidTokenQuota)
+}
+
+func TestTokenQuotaPeriod_UsesQuotaTimezone(t *testing.T) {
+	now := time.Date(2026, 1, 31, 17, 30, 0, 0, time.UTC)
+
+	day, month := tokenQuotaPeriod(TokenQuota{DailyTokens: 1, Timezone: "Asia/Shanghai"}, now)
+	require.Equal(t, "20260201", day)
+	require.Equal(t, "202602", month)
+
+	day, month = tokenQuotaPeriod(TokenQuota{DailyTokens: 1, Timezone: "America/New_York"}, now)
+	require.Equal(t, "20260131", day)
+	require.Equal(t, "202601", month)
+}
+
+func TestBillingCacheService_CheckTokenQuota(t *testing.T) {
+	cache := &billingCacheWorkerStub{quotaUsage: &TokenQuotaUsage{DailyTokens: 500, MonthlyTokens: 900, DailyRequests: 3, MonthlyRequests: 10}}
+	svc := NewBillingCacheService(cache, nil, nil, &config.Config{}, nil, nil)
+	t.Cleanup(svc.Stop)
+	ctx := context.Background()
+
+	require.NoError(t, svc.checkTokenQuota(ctx, &APIKey{ID: 1}))
+	require.NoError(t, svc.checkTokenQuota(ctx, &APIKey{ID: 1, TokenQuota: TokenQuota{DailyTokens: 501, MonthlyRequests: 11}}))
+	require.ErrorIs(t, svc.checkTokenQuota(ctx, &APIKey{ID: 1, TokenQuota: TokenQuota{DailyTokens: 500}}), ErrAPIKeyDailyTokenQuotaExceeded)
+	require.ErrorIs(t, svc.checkTokenQuota(ctx, &APIKey{ID: 1, TokenQuota: TokenQuota{MonthlyTokens: 900}}), ErrAPIKeyMonthlyTokenQuotaExceeded)
+	require.ErrorIs(t, svc.checkTokenQuota(ctx, &APIKey{ID: 1, TokenQuota: TokenQuota{DailyRequests: 3}}), ErrAPIKeyDailyRequestQuotaExceeded)
+	require.ErrorIs(t, svc.checkTokenQuota(ctx, &APIKey{ID: 1, TokenQuota: TokenQuota{MonthlyRequests: 10}}), ErrAPIKeyMonthlyRequestQuotaExceeded)
+
+	cache.quotaErr = errors.New("redis down")
+	err := svc.checkTokenQuota(ctx, &APIKey{ID: 1, TokenQuota: TokenQuota{DailyTokens: 1000}})
+	require.ErrorIs(t, err, ErrBillingServiceUnavailable)
+}
+
+func TestBillingCacheService_QueueTokenQuotaUsage(t *testing.T) {
+	cache := &billingCacheWorkerStub{}
+	svc := NewBillingCacheService(cache, nil, nil, &config.Config{}, nil, nil)
+	t.Cleanup(svc.Stop)
+
+	// 未配置配额的 Key 不计数
+	svc.QueueTokenQuotaUsage(&APIKey{ID: 1}, 100)
+	svc.QueueTokenQuotaUsage(&APIKey{ID: 2, TokenQuota: TokenQuota{DailyTokens: 1000}}, 100)
+
+	require.Eventually(t, func() bool {
+		return atomic.LoadInt64(&cache.quotaIncrements) == 1
+	}, 2*time.Second, 10*time.Millisecond)
+}
@@ -0,0 +1,238 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	infraerrors "github.com/Wei-Shaw/sub2api/internal/pkg/errors"
+	"github.com/tidwall/gjson"
+)
+
+// 内置工具名称（用于 API Key 的 tool_limits 配置）
+const (
+	ToolWebSearch       = "web_search"
+	ToolCodeInterpreter = "code_interpreter"
+	ToolImageGeneration = "image_generation"
+)
+
+var ErrInvalidToolLimits = infraerrors.BadRequest("INVALID_TOOL_LIMITS", "tool_limits only supports web_search, code_interpreter, image_generation with non-negative values")
+
+// ToolLimitExceededError API Key 当日内置工具调用已达上限
+type ToolLimitExceededError struct {
+	Tool  string
+	Used  int
+	Limit int
+}
+
+func (e *ToolLimitExceededError) Error() string {
+	if e.Limit == 0 {
+		return fmt.Sprintf("Tool %s is not allowed for this API key", e.Tool)
+	}
+	return fmt.Sprintf("Daily %s limit reached for this API key (%d/%d)", e.Tool, e.Used, e.Limit)
+}
+
+// ToolUsage 一次请求中上游内置工具的调用次数，独立于 token 计费
+type ToolUsage struct {
+	// WebSearchCalls web 搜索调用次数（Claude server_tool_use.web_search_requests / OpenAI web_search_call）
+	WebSearchCalls int `json:"web_search_calls,omitempty"`
+	// CodeInterpreterSessions code interpreter 会话数（OpenAI code_interpreter_call 按 container 去重）
+	CodeInterpreterSessions int `json:"code_interpreter_sessions,omitempty"`
+	// ImageGenerations Responses 内 image_generation 工具生成的图片数
+	ImageGenerations int `json:"image_generations,omitempty"`
+}
+
+// IsZero 是否没有任何工具调用
+func (u ToolUsage) IsZero() bool {
+	return u.WebSearchCalls == 0 && u.CodeInterpreterSessions == 0 && u.ImageGenerations == 0
+}
+
+// Count 返回指定工具的调用次数
+func (u ToolUsage) Count(tool string) int {
+	switch tool {
+	case ToolWebSearch:
+		return u.WebSearchCalls
+	case ToolCodeInterpreter:
+		return u.CodeInterpreterSessions
+	case ToolImageGeneration:
+		return u.ImageGenerations
+	}
+	return 0
+}
+
+// Ptr 返回用于 UsageLog 的指针（无工具调用时为 nil，不写入数据库）
+func (u ToolUsage) Ptr() *ToolUsage {
+	if u.IsZero() {
+		return nil
+	}
+	return &u
+}
+
+// NormalizeToolLimits 校验并清理 API Key 的工具上限配置。
+// 值为 0 表示禁止使用该工具；未配置的工具不限制；空配置返回 nil。
+func NormalizeToolLimits(limits map[string]int) (map[string]int, error) {
+	if len(limits) == 0 {
+		return nil, nil
+	}
+	normalized := make(map[string]int, len(limits))
+	for tool, limit := range limits {
+		tool = strings.ToLower(strings.TrimSpace(tool))
+		switch tool {
+		case ToolWebSearch, ToolCodeInterpreter, ToolImageGeneration:
+		default:
+			return nil, fmt.Errorf("%w: unknown tool %q", ErrInvalidToolLimits, tool)
+		}
+		if limit < 0 {
+			return nil, fmt.Errorf("%w: %s must be >= 0", ErrInvalidToolLimits, tool)
+		}
+		normalized[tool] = limit
+	}
+	return normalized, nil
+}
+
+// ParseClaudeToolUsage 从 Claude usage 对象中解析服务端工具调用次数
+func ParseClaudeToolUsage(usage gjson.Result) ToolUsage {
+	return ToolUsage{
+		WebSearchCalls: int(usage.Get("server_tool_use.web_search_requests").Int()),
+	}
+}
+
+// ParseResponsesToolUsage 从 OpenAI Responses 的 response 对象（output 数组）中解析内置工具调用。
+// code interpreter 按 container_id 去重计为会话数，缺失 container_id 时每次调用计一次。
+func ParseResponsesToolUsage(response gjson.Result) ToolUsage {
+	var usage ToolUsage
+	containers := make(map[string]struct{})
+	response.Get("output").ForEach(func(_, item gjson.Result) bool {
+		switch item.Get("type").String() {
+		case "web_search_call":
+			usage.WebSearchCalls++
+		case "code_interpreter_call":
+			containerID := item.Get("container_id").String()
+			if containerID == "" {
+				usage.CodeInterpreterSessions++
+			} else if _, ok := containers[containerID]; !ok {
+				containers[containerID] = struct{}{}
+				usage.CodeInterpreterSessions++
+			}
+		case "image_generation_call":
+			if item.Get("status").String() != "failed" {
+				usage.ImageGenerations++
+			}
+		}
+		return true
+	})
+	return usage
+}
+
+// RequestedTools 返回请求体中声明的受限内置工具（去重）。
+// 兼容 Claude Messages（web_search_YYYYMMDD / code_execution_YYYYMMDD）与 OpenAI Responses 工具类型。
+func RequestedTools(body []byte) []string {
+	tools := gjson.GetBytes(body, "tools")
+	if !tools.IsArray() {
+		return nil
+	}
+	seen := make(map[string]struct{}, 3)
+	var result []string
+	tools.ForEach(func(_, tool gjson.Result) bool {
+		name := builtinToolName(tool.Get("type").String())
+		if name == "" {
+			return true
+		}
+		if _, ok := seen[name]; !ok {
+			seen[name] = struct{}{}
+			result = append(result, name)
+		}
+		return true
+	})
+	return result
+}
+
+func builtinToolName(toolType string) string {
+	switch {
+	case toolType == "":
+		return ""
+	case strings.HasPrefix(toolType, "web_search"):
+		return ToolWebSearch
+	case toolType == "code_interpreter" || strings.HasPrefix(toolType, "code_execution"):
+		return ToolCodeInterpreter
+	case toolType == "image_generation":
+		return ToolImageGeneration
+	}
+	return ""
+}
+
+// CheckToolLimits 检查 API Key 当日内置工具调用是否已达上限（Claude Messages）
+func (s *GatewayService) CheckToolLimits(ctx context.Context, apiKey *APIKey, body []byte) error {
+	return checkAPIKeyToolLimits(ctx, s.usageLogRepo, apiKey, body)
+}
+
+// CheckToolLimits 检查 API Key 当日内置工具调用是否已达上限（OpenAI Responses）
+func (s *OpenAIGatewayService) CheckToolLimits(ctx context.Context, apiKey *APIKey, body []byte) error {
+	return checkAPIKeyToolLimits(ctx, s.usageLogRepo, apiKey, body)
+}
+
+// checkAPIKeyToolLimits 检查 API Key 当日（UTC）内置工具调用是否已达上限。
+// 仅在 Key 配置了 tool_limits 且请求声明了对应工具时查询用量。
+func checkAPIKeyToolLimits(ctx context.Context, repo UsageLogRepository, apiKey *APIKey, body []byte) error {
+	if repo == nil || apiKey == nil || len(apiKey.ToolLimits) == 0 {
+		return nil
+	}
+	var limited []string
+	for _, tool := range RequestedTools(body) {
+		limit, ok := apiKey.ToolLimits[tool]
+		if !ok {
+			continue
+		}
+		if limit == 0 {
+			return &ToolLimitExceededError{Tool: tool}
+		}
+		limited = append(limited, tool)
+	}
+	if len(limited) == 0 {
+		return nil
+	}
+
+	now := time.Now().UTC()
+	dayStart := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, time.UTC)
+	used, err := repo.GetAPIKeyToolUsage(ctx, apiKey.ID, dayStart, now)
+	if err != nil || used == nil {
+		// 统计失败时放行，避免数据库抖动影响正常请求
+		return nil
+	}
+	for _, tool := range limited {
+		limit := apiKey.ToolLimits[tool]
+		if used.Count(tool) >= limit {
+			return &ToolLimitExceededError{Tool: tool, Used: used.Count(tool), Limit: limit}
+		}
+	}
+	return nil
+}
+
+// CalculateToolCost 计算内置工具费用（USD，未乘倍率）
+func (s *BillingService) CalculateToolCost(usage ToolUsage) float64 {
+	if usage.IsZero() || s.cfg == nil {
+		return 0
+	}
+	prices := s.cfg.Pricing.ToolPrices
+	return float64(usage.WebSearchCalls)*prices.WebSearchPerCall +
+		float64(usage.CodeInterpreterSessions)*prices.CodeInterpreterPerSession +
+		float64(usage.ImageGenerations)*prices.ImageGenerationPerImage
+}
+
+// ApplyToolCost 将内置工具费用作为独立明细叠加到费用中（TotalCost 为原始费用，ActualCost 乘以倍率）
+func (s *BillingService) ApplyToolCost(cost *CostBreakdown, usage ToolUsage, rateMultiplier float64) {
+	if cost == nil {
+		return
+	}
+	toolCost := s.CalculateToolCost(usage)
+	if toolCost <= 0 {
+		return
+	}
+	if rateMultiplier <= 0 {
+		rateMultiplier = 1.0
+	}
+	cost.ToolCost = toolCost
+	cost.TotalCost += toolCost
+	cost.ActualCost += toolCost * rateMultiplier
+}
@@ -0,0 +1,122 @@
+//go:build unit
+
+package service
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/Wei-Shaw/sub2api/internal/config"
+	"github.com/stretchr/testify/require"
+	"github.com/tidwall/gjson"
+)
+
+type toolUsageRepoStub struct {
+	UsageLogRepository
+	usage *ToolUsage
+	err   error
+	calls int
+}
+
+func (s *toolUsageRepoStub) GetAPIKeyToolUsage(ctx context.Context, apiKeyID int64, startTime, endTime time.Time) (*ToolUsage, error) {
+	s.calls++
+	return s.usage, s.err
+}
+
+func TestParseClaudeToolUsage(t *testing.T) {
+	usage := gjson.Parse(`{"input_tokens":10,"server_tool_use":{"web_search_requests":3}}`)
+	require.Equal(t, ToolUsage{WebSearchCalls: 3}, ParseClaudeToolUsage(usage))
+	require.True(t, ParseClaudeToolUsage(gjson.Parse(`{"input_tokens":10}`)).IsZero())
+}
+
+func TestParseResponsesToolUsage(t *testing.T) {
+	response := gjson.Parse(`{"output":[
+		{"type":"web_search_call","status":"completed"},
+		{"type":"web_search_call","status":"completed"},
+		{"type":"code_interpreter_call","container_id":"cntr_1"},
+		{"type":"code_interpreter_call","container_id":"cntr_1"},
+		{"type":"code_interpreter_call","container_id":"cntr_2"},
+		{"type":"image_generation_call","status":"completed"},
+		{"type":"image_generation_call","status":"failed"},
+		{"type":"message"}
+	]}`)
+	require.Equal(t, ToolUsage{WebSearchCalls: 2, CodeInterpreterSessions: 2, ImageGenerations: 1}, ParseResponsesToolUsage(response))
+}
+
+func TestRequestedTools(t *testing.T) {
+	claude := []byte(`{"tools":[{"type":"web_search_20250305","name":"web_search"},{"name":"get_weather","input_schema":{}},{"type":"code_execution_20250522"}]}`)
+	require.Equal(t, []string{ToolWebSearch, ToolCodeInterpreter}, RequestedTools(claude))
+
+	openai := []byte(`{"tools":[{"type":"web_search_preview"},{"type":"function","name":"f"},{"type":"image_generation"},{"type":"web_search"}]}`)
+	require.Equal(t, []string{ToolWebSearch, ToolImageGeneration}, RequestedTools(openai))
+
+	require.Nil(t, RequestedTools([]byte(`{"model":"gpt-5"}`)))
+}
+
+func TestNormalizeToolLimits(t *testing.T) {
+	limits, err := NormalizeToolLimits(map[string]int{" Web_Search ": 10, "image_generation": 0})
+	require.NoError(t, err)
+	require.Equal(t, map[string]int{ToolWebSearch: 10, ToolImageGeneration: 0}, limits)
+
+	limits, err = NormalizeToolLimits(map[string]int{})
+	require.NoError(t, err)
+	require.Nil(t, limits)
+
+	_, err = NormalizeToolLimits(map[string]int{"file_search": 1})
+	require.ErrorIs(t, err, ErrInvalidToolLimits)
+	_, err = NormalizeToolLimits(map[string]int{ToolWebSearch: -1})
+	require.ErrorIs(t, err, ErrInvalidToolLimits)
+}
+
+func TestCheckAPIKeyToolLimits(t *testing.T) {
+	ctx := context.Background()
+	body := []byte(`{"tools":[{"type":"web_search"}]}`)
+
+	repo := &toolUsageRepoStub{usage: &ToolUsage{WebSearchCalls: 5}}
+	apiKey := &APIKey{ID: 1, ToolLimits: map[string]int{ToolWebSearch: 5}}
+	err := checkAPIKeyToolLimits(ctx, repo, apiKey, body)
+	var limitErr *ToolLimitExceededError
+	require.True(t, errors.As(err, &limitErr))
+	require.Equal(t, ToolWebSearch, limitErr.Tool)
+	require.Equal(t, 5, limitErr.Used)
+
+	apiKey.ToolLimits[ToolWebSearch] = 6
+	require.NoError(t, checkAPIKeyToolLimits(ctx, repo, apiKey, body))
+
+	// 未声明受限工具时不查询用量
+	repo.calls = 0
+	require.NoError(t, checkAPIKeyToolLimits(ctx, repo, apiKey, []byte(`{"tools":[{"type":"image_generation"}]}`)))
+	require.Zero(t, repo.calls)
+
+	// 上限为 0 表示禁止使用，无需查询用量
+	apiKey.ToolLimits[ToolWebSearch] = 0
+	err = checkAPIKeyToolLimits(ctx, repo, apiKey, body)
+	require.True(t, errors.As(err, &limitErr))
+	require.Zero(t, repo.calls)
+
+	// 统计失败时放行
+	apiKey.ToolLimits[ToolWebSearch] = 1
+	require.NoError(t, checkAPIKeyToolLimits(ctx, &toolUsageRepoStub{err: errors.New("db down")}, apiKey, body))
+}
+
+func TestBillingService_ApplyToolCost(t *testing.T) {
+	cfg := &config.Config{}
+	cfg.Pricing.ToolPrices = config.ToolPricingConfig{
+		WebSearchPerCall:          0.01,
+		CodeInterpreterPerSession: 0.03,
+		ImageGenerationPerImage:   0.04,
+	}
+	svc := &BillingService{cfg: cfg}
+
+	cost := &CostBreakdown{TotalCost: 1, ActualCost: 2}
+	svc.ApplyToolCost(cost, ToolUsage{WebSearchCalls: 2, CodeInterpreterSessions: 1, ImageGenerations: 1}, 2)
+	require.InDelta(t, 0.09, cost.ToolCost, 1e-12)
+	require.InDelta(t, 1.09, cost.TotalCost, 1e-12)
+	require.InDelta(t, 2.18, cost.ActualCost, 1e-12)
+
+	unchanged := &CostBreakdown{TotalCost: 1, ActualCost: 1}
+	svc.ApplyToolCost(unchanged, ToolUsage{}, 1)
+	require.Equal(t, &CostBreakdown{TotalCost: 1, ActualCost: 1}, unchanged)
+}
@@ -0,0 +1,177 @@
+package service
+
+import (
+	"context"
+	"log"
+	"sync"
+	"time"
+
+	"github.com/Wei-Shaw/sub2api/internal/config"
+	infraerrors "github.com/Wei-Shaw/sub2api/internal/pkg/errors"
+	"github.com/Wei-Shaw/sub2api/internal/pkg/pagination"
+)
+
+// trashPurgeInterval 回收站过期清理间隔
+const trashPurgeInterval = time.Hour
+
+var ErrTrashItemNotFound = infraerrors.NotFound("TRASH_ITEM_NOT_FOUND", "item not found in trash or no longer restorable")
+
+// TrashedAccount 回收站中的账号
+type TrashedAccount struct {
+	ID        int64     `json:"id"`
+	Name      string    `json:"name"`
+	Platform  string    `json:"platform"`
+	Type      string    `json:"type"`
+	GroupIDs  []int64   `json:"group_ids"` // 删除前的分组绑定，恢复时重新绑定
+	DeletedAt time.Time `json:"deleted_at"`
+	// PurgeAt 超过该时间后不可恢复（永久保留时为 nil）
+	PurgeAt *time.Time `json:"purge_at"`
+}
+
+// TrashedAPIKey 回收站中的 API Key
+type TrashedAPIKey struct {
+	ID        int64      `json:"id"`
+	Name      string     `json:"name"`
+	UserID    int64      `json:"user_id"`
+	UserEmail string     `json:"user_email"`
+	GroupID   *int64     `json:"group_id"`
+	DeletedAt time.Time  `json:"deleted_at"`
+	PurgeAt   *time.Time `json:"purge_at"`
+}
+
+// TrashRepository 回收站数据访问（软删除记录的查询、恢复与过期清理）
+type TrashRepository interface {
+	ListTrashedAccounts(ctx context.Context, params pagination.PaginationParams) ([]TrashedAccount, *pagination.PaginationResult, error)
+	ListTrashedAPIKeys(ctx context.Context, params pagination.PaginationParams) ([]TrashedAPIKey, *pagination.PaginationResult, error)
+	// RestoreAccount 清除 deleted_at 并重新绑定删除前仍存在的分组；不在回收站中返回 ErrTrashItemNotFound
+	RestoreAccount(ctx context.Context, id int64) error
+	// RestoreAPIKey 清除 deleted_at 并返回 Key 明文（用于清理认证缓存）；所属用户已删除时不可恢复
+	RestoreAPIKey(ctx context.Context, id int64) (string, error)
+	// PurgeDeletedBefore 清除 deleted_at 早于 before 的账号凭证与 Key 明文并标记 purged_at（记录保留）
+	PurgeDeletedBefore(ctx context.Context, before time.Time) (accounts int64, apiKeys int64, err error)
+}
+
+// TrashService 账号与 API Key 的回收站：删除后在保留期内可恢复，过期后清除敏感数据。
+// 用量历史始终保留（记录不做物理删除）。
+type TrashService struct {
+	repo                 TrashRepository
+	authCacheInvalidator APIKeyAuthCacheInvalidator
+	retention            time.Duration
+
+	stopCh   chan struct{}
+	stopOnce sync.Once
+	wg       sync.WaitGroup
+	nowFunc  func() time.Time
+}
+
+// NewTrashService 创建回收站服务
+func NewTrashService(repo TrashRepository, authCacheInvalidator APIKeyAuthCacheInvalidator, cfg *config.Config) *TrashService {
+	s := &TrashService{
+		repo:                 repo,
+		authCacheInvalidator: authCacheInvalidator,
+		stopCh:               make(chan struct{}),
+		nowFunc:              time.Now,
+	}
+	if cfg != nil && cfg.Trash.RetentionDays > 0 {
+		s.retention = time.Duration(cfg.Trash.RetentionDays) * 24 * time.Hour
+	}
+	return s
+}
+
+// Start 启动过期清理协程（永久保留时不启动）
+func (s *TrashService) Start() {
+	if s == nil || s.repo == nil || s.retention <= 0 {
+		return
+	}
+	s.wg.Add(1)
+	go func() {
+		defer s.wg.Done()
+		s.purgeExpired()
+		ticker := time.NewTicker(trashPurgeInterval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ticker.C:
+				s.purgeExpired()
+			case <-s.stopCh:
+				return
+			}
+		}
+	}()
+}
+
+// Stop 停止过期清理协程
+func (s *TrashService) Stop() {
+	if s == nil {
+		return
+	}
+	s.stopOnce.Do(func() { close(s.stopCh) })
+	s.wg.Wait()
+}
+
+func (s *TrashService) purgeExpired() {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Minute)
+	defer cancel()
+	accounts, apiKeys, err := s.repo.PurgeDeletedBefore(ctx, s.nowFunc().Add(-s.retention))
+	if err != nil {
+		log.Printf("[Trash] Purge failed: %v", err)
+		return
+	}
+	if accounts > 0 || apiKeys > 0 {
+		log.Printf("[Trash] Purged %d accounts and %d api keys past retention", accounts, apiKeys)
+	}
+}
+
+// RetentionDays 返回可恢复天数（0 表示永久可恢复）
+func (s *TrashService) RetentionDays() int {
+	return int(s.retention / (24 * time.Hour))
+}
+
+func (s *TrashService) purgeAt(deletedAt time.Time) *time.Time {
+	if s.retention <= 0 {
+		return nil
+	}
+	t := deletedAt.Add(s.retention)
+	return &t
+}
+
+// ListAccounts 分页列出回收站中的账号
+func (s *TrashService) ListAccounts(ctx context.Context, params pagination.PaginationParams) ([]TrashedAccount, *pagination.PaginationResult, error) {
+	items, result, err := s.repo.ListTrashedAccounts(ctx, params)
+	if err != nil {
+		return nil, nil, err
+	}
+	for i := range items {
+		items[i].PurgeAt = s.purgeAt(items[i].DeletedAt)
+	}
+	return items, result, nil
+}
+
+// ListAPIKeys 分页列出回收站中的 API Key
+func (s *TrashService) ListAPIKeys(ctx context.Context, params pagination.PaginationParams) ([]TrashedAPIKey, *pagination.PaginationResult, error) {
+	items, result, err := s.repo.ListTrashedAPIKeys(ctx, params)
+	if err != nil {
+		return nil, nil, err
+	}
+	for i := range items {
+		items[i].PurgeAt = s.purgeAt(items[i].DeletedAt)
+	}
+	return items, result, nil
+}
+
+// RestoreAccount 从回收站恢复账号（保持删除前的状态与调度设置）
+func (s *TrashService) RestoreAccount(ctx context.Context, id int64) error {
+	return s.repo.RestoreAccount(ctx, id)
+}
+
+// RestoreAPIKey 从回收站恢复 API Key，并清除可能缓存的"不存在"认证结果
+func (s *TrashService) RestoreAPIKey(ctx context.Context, id int64) error {
+	key, err := s.repo.RestoreAPIKey(ctx, id)
+	if err != nil {
+		return err
+	}
+	if s.authCacheInvalidator != nil {
+		s.authCacheInvalidator.InvalidateAuthCacheByKey(ctx, key)
+	}
+	return nil
+}
@@ -0,0 +1,112 @@
+package service
+
+import (
+	"context"
+	"crypto/sha256"
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/hex"
+	"fmt"
+	"strings"
+
+	"github.com/Wei-Shaw/sub2api/internal/pkg/ctxkey"
+	infraerrors "github.com/Wei-Shaw/sub2api/internal/pkg/errors"
+)
+
+// 账号凭证中的上游 TLS 字段（PEM 文本），用于要求双向 TLS 的企业代理
+const (
+	// CredentialTLSClientCert 客户端证书（可包含中间证书链）
+	CredentialTLSClientCert = "tls_client_cert"
+	// CredentialTLSClientKey 客户端证书私钥
+	CredentialTLSClientKey = "tls_client_key"
+	// CredentialTLSCACert 校验上游服务端证书的自定义 CA（替代系统根证书）
+	CredentialTLSCACert = "tls_ca_cert"
+)
+
+var ErrInvalidUpstreamClientTLS = infraerrors.BadRequest("INVALID_UPSTREAM_CLIENT_TLS", "invalid upstream TLS credentials")
+
+// UpstreamClientTLS 账号级上游 TLS 设置：mTLS 客户端证书与自定义 CA
+type UpstreamClientTLS struct {
+	ClientCert string
+	ClientKey  string
+	CACert     string
+}
+
+// UpstreamClientTLS 从账号凭证读取上游 TLS 设置，未配置时返回 nil
+func (a *Account) UpstreamClientTLS() *UpstreamClientTLS {
+	cfg := &UpstreamClientTLS{
+		ClientCert: strings.TrimSpace(a.GetCredential(CredentialTLSClientCert)),
+		ClientKey:  strings.TrimSpace(a.GetCredential(CredentialTLSClientKey)),
+		CACert:     strings.TrimSpace(a.GetCredential(CredentialTLSCACert)),
+	}
+	if cfg.ClientCert == "" && cfg.ClientKey == "" && cfg.CACert == "" {
+		return nil
+	}
+	return cfg
+}
+
+// CacheKey 返回 TLS 材料的摘要，用于区分上游客户端缓存（材料变化时重建连接池）
+func (t *UpstreamClientTLS) CacheKey() string {
+	h := sha256.New()
+	for _, part := range []string{t.ClientCert, t.ClientKey, t.CACert} {
+		h.Write([]byte(part))
+		h.Write([]byte{0})
+	}
+	return hex.EncodeToString(h.Sum(nil))[:16]
+}
+
+// TLSConfig 构建上游连接使用的 tls.Config
+func (t *UpstreamClientTLS) TLSConfig() (*tls.Config, error) {
+	cfg := &tls.Config{MinVersion: tls.VersionTLS12}
+	if t.ClientCert != "" || t.ClientKey != "" {
+		if t.ClientCert == "" || t.ClientKey == "" {
+			return nil, fmt.Errorf("%s and %s must be set together", CredentialTLSClientCert, CredentialTLSClientKey)
+		}
+		cert, err := tls.X509KeyPair([]byte(t.ClientCert), []byte(t.ClientKey))
+		if err != nil {
+			return nil, fmt.Errorf("parse client certificate: %w", err)
+		}
+		cfg.Certificates = []tls.Certificate{cert}
+	}
+	if t.CACert != "" {
+		pool := x509.NewCertPool()
+		if !pool.AppendCertsFromPEM([]byte(t.CACert)) {
+			return nil, fmt.Errorf("%s contains no valid PEM certificates", CredentialTLSCACert)
+		}
+		cfg.RootCAs = pool
+	}
+	return cfg, nil
+}
+
+// ValidateUpstreamClientTLSCredentials 校验账号凭证中的上游 TLS 字段，在创建/更新账号时调用
+func ValidateUpstreamClientTLSCredentials(credentials map[string]any) error {
+	tlsCfg := (&Account{Credentials: credentials}).UpstreamClientTLS()
+	if tlsCfg == nil {
+		return nil
+	}
+	if _, err := tlsCfg.TLSConfig(); err != nil {
+		return fmt.Errorf("%w: %v", ErrInvalidUpstreamClientTLS, err)
+	}
+	return nil
+}
+
+// WithAccountUpstreamTLS 将账号的上游 TLS 设置写入 context，由 HTTP 上游在建立连接时使用
+func WithAccountUpstreamTLS(ctx context.Context, account *Account) context.Context {
+	if account == nil {
+		return ctx
+	}
+	tlsCfg := account.UpstreamClientTLS()
+	if tlsCfg == nil {
+		return ctx
+	}
+	return context.WithValue(ctx, ctxkey.UpstreamClientTLS, tlsCfg)
+}
+
+// UpstreamClientTLSFromContext 返回 context 中的上游 TLS 设置，未设置时返回 nil
+func UpstreamClientTLSFromContext(ctx context.Context) *UpstreamClientTLS {
+	if ctx == nil {
+		return nil
+	}
+	tlsCfg, _ := ctx.Value(ctxkey.UpstreamClientTLS).(*UpstreamClientTLS)
+	return tlsCfg
+}
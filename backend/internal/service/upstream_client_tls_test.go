@@ -0,0 +1,82 @@
+//go:build unit
+
+package service
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"math/big"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func newUpstreamTLSTestCert(t *testing.T) (certPEM, keyPEM string) {
+	t.Helper()
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+	tmpl := &x509.Certificate{
+		SerialNumber: big.NewInt(1),
+		Subject:      pkix.Name{CommonName: "upstream-client"},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(time.Hour),
+		ExtKeyUsage:  []x509.ExtKeyUsage{x509.ExtKeyUsageClientAuth},
+	}
+	der, err := x509.CreateCertificate(rand.Reader, tmpl, tmpl, &key.PublicKey, key)
+	require.NoError(t, err)
+	keyDER, err := x509.MarshalECPrivateKey(key)
+	require.NoError(t, err)
+	certPEM = string(pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der}))
+	keyPEM = string(pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: keyDER}))
+	return certPEM, keyPEM
+}
+
+func TestAccount_UpstreamClientTLS(t *testing.T) {
+	require.Nil(t, (&Account{Credentials: map[string]any{"api_key": "sk-x"}}).UpstreamClientTLS())
+
+	certPEM, keyPEM := newUpstreamTLSTestCert(t)
+	account := &Account{Credentials: map[string]any{
+		CredentialTLSClientCert: certPEM,
+		CredentialTLSClientKey:  keyPEM,
+		CredentialTLSCACert:     certPEM,
+	}}
+	tlsCfg := account.UpstreamClientTLS()
+	require.NotNil(t, tlsCfg)
+	config, err := tlsCfg.TLSConfig()
+	require.NoError(t, err)
+	require.Len(t, config.Certificates, 1)
+	require.NotNil(t, config.RootCAs)
+
+	// 摘要随 TLS 材料变化
+	other := &UpstreamClientTLS{CACert: certPEM}
+	require.NotEqual(t, tlsCfg.CacheKey(), other.CacheKey())
+	require.Equal(t, tlsCfg.CacheKey(), account.UpstreamClientTLS().CacheKey())
+
+	ctx := WithAccountUpstreamTLS(context.Background(), account)
+	require.Equal(t, tlsCfg, UpstreamClientTLSFromContext(ctx))
+	require.Nil(t, UpstreamClientTLSFromContext(WithAccountUpstreamTLS(context.Background(), &Account{})))
+}
+
+func TestValidateUpstreamClientTLSCredentials(t *testing.T) {
+	certPEM, keyPEM := newUpstreamTLSTestCert(t)
+	require.NoError(t, ValidateUpstreamClientTLSCredentials(nil))
+	require.NoError(t, ValidateUpstreamClientTLSCredentials(map[string]any{CredentialTLSCACert: certPEM}))
+	require.NoError(t, ValidateUpstreamClientTLSCredentials(map[string]any{
+		CredentialTLSClientCert: certPEM,
+		CredentialTLSClientKey:  keyPEM,
+	}))
+
+	require.ErrorIs(t, ValidateUpstreamClientTLSCredentials(map[string]any{CredentialTLSClientCert: certPEM}), ErrInvalidUpstreamClientTLS)
+	require.ErrorIs(t, ValidateUpstreamClientTLSCredentials(map[string]any{CredentialTLSCACert: "not a pem"}), ErrInvalidUpstreamClientTLS)
+	_, otherKey := newUpstreamTLSTestCert(t)
+	require.ErrorIs(t, ValidateUpstreamClientTLSCredentials(map[string]any{
+		CredentialTLSClientCert: certPEM,
+		CredentialTLSClientKey:  otherKey,
+	}), ErrInvalidUpstreamClientTLS)
+}
@@ -0,0 +1,171 @@
+package service
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"github.com/tidwall/gjson"
+)
+
+// upstreamErrorDebugKey 标记当前请求的 API Key 开启了调试模式（由 API Key 认证中间件设置）
+const upstreamErrorDebugKey = "upstream_error_debug"
+
+// upstreamErrorDebugSourceKey 暂存最近一次上游错误的状态码与响应体，供统一的错误响应出口构建调试详情
+const upstreamErrorDebugSourceKey = "upstream_error_debug_source"
+
+// upstreamErrorDebugMaxMessageLen 回显给客户端的上游错误信息最大长度
+const upstreamErrorDebugMaxMessageLen = 300
+
+var (
+	// 上游错误信息中可能出现的账号身份信息：邮箱、组织/项目 ID
+	debugEmailRegex     = regexp.MustCompile(`[A-Za-z0-9._%+\-]+@[A-Za-z0-9.\-]+\.[A-Za-z]{2,}`)
+	debugOrgIDRegex     = regexp.MustCompile(`\b(org|proj|user|acct)[-_][A-Za-z0-9]{6,}\b`)
+	debugSecretKeyRegex = regexp.MustCompile(`\b(sk|ak|AIza)[-_A-Za-z0-9]{12,}\b`)
+)
+
+// UpstreamErrorDebug 调试模式下附带在错误响应中的上游错误详情（已脱敏，不含真实账号身份）
+type UpstreamErrorDebug struct {
+	// UpstreamStatus 上游返回的 HTTP 状态码
+	UpstreamStatus int `json:"upstream_status,omitempty"`
+	// ProviderErrorCode 上游错误码/错误类型（如 rate_limit_error、insufficient_quota、RESOURCE_EXHAUSTED）
+	ProviderErrorCode string `json:"provider_error_code,omitempty"`
+	// ProviderMessage 上游错误信息（脱敏并截断）
+	ProviderMessage string `json:"provider_message,omitempty"`
+	// AccountAlias 账号别名：由账号 ID 派生的稳定标识，便于反馈问题时定位，但不暴露账号名称/ID
+	AccountAlias string `json:"account_alias,omitempty"`
+	// Attempts 本次请求累计的上游失败次数（含故障转移）
+	Attempts int `json:"attempts,omitempty"`
+}
+
+// EnableUpstreamErrorDebug 为当前请求开启上游错误详情回显
+func EnableUpstreamErrorDebug(c *gin.Context) {
+	if c == nil {
+		return
+	}
+	c.Set(upstreamErrorDebugKey, true)
+}
+
+func upstreamErrorDebugEnabled(c *gin.Context) bool {
+	if c == nil {
+		return false
+	}
+	v, ok := c.Get(upstreamErrorDebugKey)
+	if !ok {
+		return false
+	}
+	enabled, _ := v.(bool)
+	return enabled
+}
+
+type upstreamErrorDebugSource struct {
+	statusCode int
+	body       []byte
+}
+
+// SetUpstreamErrorDebugSource 记录上游错误的状态码与响应体（仅在调试模式下保存），
+// 供后续 BuildUpstreamErrorDebug 提取上游错误码。
+func SetUpstreamErrorDebugSource(c *gin.Context, statusCode int, body []byte) {
+	if !upstreamErrorDebugEnabled(c) {
+		return
+	}
+	c.Set(upstreamErrorDebugSourceKey, &upstreamErrorDebugSource{statusCode: statusCode, body: body})
+}
+
+// AccountAlias 返回账号的对外别名（不可逆推账号名称，同一账号始终一致）
+func AccountAlias(accountID int64) string {
+	if accountID <= 0 {
+		return ""
+	}
+	sum := sha256.Sum256([]byte("sub2api-account:" + strconv.FormatInt(accountID, 10)))
+	return "acct-" + hex.EncodeToString(sum[:4])
+}
+
+// BuildUpstreamErrorDebug 基于本次请求记录的上游错误事件构建调试详情。
+// 未开启调试模式或没有任何上游错误信息时返回 nil。
+// statusCode/body 为调用方已知的上游状态与响应体（可为空，空时从 ops 上游错误事件中取）。
+func BuildUpstreamErrorDebug(c *gin.Context, statusCode int, body []byte) *UpstreamErrorDebug {
+	if !upstreamErrorDebugEnabled(c) {
+		return nil
+	}
+
+	if statusCode <= 0 && len(body) == 0 {
+		if v, ok := c.Get(upstreamErrorDebugSourceKey); ok {
+			if src, ok := v.(*upstreamErrorDebugSource); ok && src != nil {
+				statusCode, body = src.statusCode, src.body
+			}
+		}
+	}
+
+	var events []*OpsUpstreamErrorEvent
+	if v, ok := c.Get(OpsUpstreamErrorsKey); ok {
+		events, _ = v.([]*OpsUpstreamErrorEvent)
+	}
+	var last *OpsUpstreamErrorEvent
+	if len(events) > 0 {
+		last = events[len(events)-1]
+	}
+	if last == nil && statusCode <= 0 && len(body) == 0 {
+		return nil
+	}
+
+	debug := &UpstreamErrorDebug{
+		UpstreamStatus: statusCode,
+		Attempts:       len(events),
+	}
+	message := ""
+	if len(body) > 0 {
+		debug.ProviderErrorCode = extractUpstreamErrorCode(body)
+		message = extractUpstreamErrorMessage(body)
+	}
+	if last != nil {
+		if debug.UpstreamStatus <= 0 {
+			debug.UpstreamStatus = last.UpstreamStatusCode
+		}
+		if debug.ProviderErrorCode == "" && last.Detail != "" {
+			debug.ProviderErrorCode = extractUpstreamErrorCode([]byte(last.Detail))
+		}
+		if message == "" {
+			message = last.Message
+		}
+		debug.AccountAlias = AccountAlias(last.AccountID)
+	}
+	debug.ProviderMessage = sanitizeDebugErrorMessage(message)
+	return debug
+}
+
+// extractUpstreamErrorCode 提取上游错误码，兼容 Claude / OpenAI / Gemini 错误格式
+func extractUpstreamErrorCode(body []byte) string {
+	if !gjson.ValidBytes(body) {
+		return ""
+	}
+	for _, path := range []string{"error.code", "error.status", "error.type", "code", "type"} {
+		v := gjson.GetBytes(body, path)
+		// Gemini 的 error.code 为数字状态码，跳过以便取 error.status（如 RESOURCE_EXHAUSTED）
+		if !v.Exists() || v.Type != gjson.String {
+			continue
+		}
+		code := strings.TrimSpace(v.String())
+		// 顶层 type 为 "error" 是 Claude 的信封字段，不是错误码
+		if code == "" || (path == "type" && code == "error") {
+			continue
+		}
+		return code
+	}
+	return ""
+}
+
+func sanitizeDebugErrorMessage(msg string) string {
+	msg = strings.TrimSpace(msg)
+	if msg == "" {
+		return ""
+	}
+	msg = sanitizeUpstreamErrorMessage(msg)
+	msg = debugEmailRegex.ReplaceAllString(msg, "***@***")
+	msg = debugOrgIDRegex.ReplaceAllString(msg, "$1-***")
+	msg = debugSecretKeyRegex.ReplaceAllString(msg, "$1-***")
+	return truncateString(msg, upstreamErrorDebugMaxMessageLen)
+}
@@ -0,0 +1,62 @@
+//go:build unit
+
+package service
+
+import (
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/require"
+)
+
+func newUpstreamErrorDebugContext(enabled bool) *gin.Context {
+	gin.SetMode(gin.TestMode)
+	c, _ := gin.CreateTestContext(httptest.NewRecorder())
+	c.Request = httptest.NewRequest("POST", "/v1/messages", nil)
+	if enabled {
+		EnableUpstreamErrorDebug(c)
+	}
+	return c
+}
+
+func TestBuildUpstreamErrorDebug_DisabledReturnsNil(t *testing.T) {
+	c := newUpstreamErrorDebugContext(false)
+	appendOpsUpstreamError(c, OpsUpstreamErrorEvent{AccountID: 7, UpstreamStatusCode: 429})
+	require.Nil(t, BuildUpstreamErrorDebug(c, 429, []byte(`{"error":{"type":"rate_limit_error"}}`)))
+}
+
+func TestBuildUpstreamErrorDebug_NoUpstreamErrorReturnsNil(t *testing.T) {
+	c := newUpstreamErrorDebugContext(true)
+	require.Nil(t, BuildUpstreamErrorDebug(c, 0, nil))
+}
+
+func TestBuildUpstreamErrorDebug_FromFailoverSource(t *testing.T) {
+	c := newUpstreamErrorDebugContext(true)
+	appendOpsUpstreamError(c, OpsUpstreamErrorEvent{AccountID: 3, UpstreamStatusCode: 500, Kind: "failover"})
+	appendOpsUpstreamError(c, OpsUpstreamErrorEvent{AccountID: 9, UpstreamStatusCode: 429, Kind: "failover"})
+	SetUpstreamErrorDebugSource(c, 429, []byte(`{"error":{"code":"insufficient_quota","message":"You exceeded your quota for org-AbCdEf123456, contact ops@example.com"}}`))
+
+	debug := BuildUpstreamErrorDebug(c, 0, nil)
+	require.NotNil(t, debug)
+	require.Equal(t, 429, debug.UpstreamStatus)
+	require.Equal(t, "insufficient_quota", debug.ProviderErrorCode)
+	require.Equal(t, 2, debug.Attempts)
+	require.Equal(t, AccountAlias(9), debug.AccountAlias)
+	require.NotContains(t, debug.ProviderMessage, "AbCdEf123456")
+	require.NotContains(t, debug.ProviderMessage, "ops@example.com")
+}
+
+func TestExtractUpstreamErrorCode(t *testing.T) {
+	require.Equal(t, "rate_limit_error", extractUpstreamErrorCode([]byte(`{"type":"error","error":{"type":"rate_limit_error","message":"x"}}`)))
+	require.Equal(t, "RESOURCE_EXHAUSTED", extractUpstreamErrorCode([]byte(`{"error":{"code":429,"status":"RESOURCE_EXHAUSTED"}}`)))
+	require.Equal(t, "", extractUpstreamErrorCode([]byte(`not json`)))
+}
+
+func TestAccountAlias_StableAndOpaque(t *testing.T) {
+	require.Equal(t, AccountAlias(42), AccountAlias(42))
+	require.NotEqual(t, AccountAlias(42), AccountAlias(43))
+	require.True(t, strings.HasPrefix(AccountAlias(42), "acct-"))
+	require.Empty(t, AccountAlias(0))
+}
@@ -0,0 +1,281 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/tidwall/gjson"
+)
+
+// maxUpstreamErrorMappingRules 上游错误映射规则数量上限
+const maxUpstreamErrorMappingRules = 200
+
+// upstreamErrorMappingCacheTTL 映射规则本地缓存有效期（管理端修改后最多延迟该时长生效）
+const upstreamErrorMappingCacheTTL = 15 * time.Second
+
+// UpstreamErrorMappingRule 上游错误映射规则：故障转移耗尽后，将上游状态码/错误码映射为返回给客户端的状态码、错误类型与消息。
+// ClientStatus/ErrorType/Message 为空时沿用内置映射的对应值，可只覆盖其中一部分。
+type UpstreamErrorMappingRule struct {
+	// Platform 限定生效的平台（anthropic/openai/gemini/antigravity），为空表示所有平台
+	Platform string `json:"platform,omitempty"`
+	// UpstreamStatus 匹配的上游 HTTP 状态码，0 表示任意状态码
+	UpstreamStatus int `json:"upstream_status,omitempty"`
+	// UpstreamErrorCode 匹配的上游错误码（响应体 error.code / error.type / error.status，忽略大小写），为空表示任意
+	UpstreamErrorCode string `json:"upstream_error_code,omitempty"`
+	// ClientStatus 返回给客户端的状态码
+	ClientStatus int `json:"client_status,omitempty"`
+	// ErrorType 返回给客户端的错误类型（如 rate_limit_error、overloaded_error）
+	ErrorType string `json:"error_type,omitempty"`
+	// Message 返回给客户端的错误消息
+	Message string `json:"message,omitempty"`
+}
+
+// UpstreamErrorMappingSettings 上游错误映射配置，规则按顺序匹配，第一条命中的规则生效
+type UpstreamErrorMappingSettings struct {
+	// Enabled 是否启用自定义映射（关闭时仅使用内置映射）
+	Enabled bool `json:"enabled"`
+	// Rules 自定义映射规则，优先于内置映射
+	Rules []UpstreamErrorMappingRule `json:"rules"`
+}
+
+// UpstreamErrorMapping 映射结果
+type UpstreamErrorMapping struct {
+	Status  int
+	Type    string
+	Message string
+}
+
+// builtinUpstreamErrorMappingRules 内置映射（原硬编码的错误映射），自定义规则未命中时使用
+var builtinUpstreamErrorMappingRules = []UpstreamErrorMappingRule{
+	{UpstreamStatus: 401, ClientStatus: http.StatusBadGateway, ErrorType: "upstream_error", Message: "Upstream authentication failed, please contact administrator"},
+	{UpstreamStatus: 403, ClientStatus: http.StatusBadGateway, ErrorType: "upstream_error", Message: "Upstream access forbidden, please contact administrator"},
+	{UpstreamStatus: 429, ClientStatus: http.StatusTooManyRequests, ErrorType: "rate_limit_error", Message: "Upstream rate limit exceeded, please retry later"},
+	{Platform: PlatformOpenAI, UpstreamStatus: 529, ClientStatus: http.StatusServiceUnavailable, ErrorType: "upstream_error", Message: "Upstream service overloaded, please retry later"},
+	{UpstreamStatus: 529, ClientStatus: http.StatusServiceUnavailable, ErrorType: "overloaded_error", Message: "Upstream service overloaded, please retry later"},
+	{UpstreamStatus: 500, ClientStatus: http.StatusBadGateway, ErrorType: "upstream_error", Message: "Upstream service temporarily unavailable"},
+	{UpstreamStatus: 502, ClientStatus: http.StatusBadGateway, ErrorType: "upstream_error", Message: "Upstream service temporarily unavailable"},
+	{UpstreamStatus: 503, ClientStatus: http.StatusBadGateway, ErrorType: "upstream_error", Message: "Upstream service temporarily unavailable"},
+	{UpstreamStatus: 504, ClientStatus: http.StatusBadGateway, ErrorType: "upstream_error", Message: "Upstream service temporarily unavailable"},
+}
+
+// defaultUpstreamErrorMapping 所有规则都未命中时的兜底映射
+var defaultUpstreamErrorMapping = UpstreamErrorMapping{Status: http.StatusBadGateway, Type: "upstream_error", Message: "Upstream request failed"}
+
+// BuiltinUpstreamErrorMappingRules 返回内置映射规则（供管理端展示）
+func BuiltinUpstreamErrorMappingRules() []UpstreamErrorMappingRule {
+	out := make([]UpstreamErrorMappingRule, len(builtinUpstreamErrorMappingRules))
+	copy(out, builtinUpstreamErrorMappingRules)
+	return out
+}
+
+// DefaultUpstreamErrorMappingSettings 返回默认映射配置（关闭、无自定义规则）
+func DefaultUpstreamErrorMappingSettings() *UpstreamErrorMappingSettings {
+	return &UpstreamErrorMappingSettings{Rules: []UpstreamErrorMappingRule{}}
+}
+
+// normalizeUpstreamErrorMappingSettings 清理并校验规则：平台小写、至少指定状态码或错误码、状态码合法
+func normalizeUpstreamErrorMappingSettings(settings *UpstreamErrorMappingSettings) error {
+	if len(settings.Rules) > maxUpstreamErrorMappingRules {
+		return fmt.Errorf("too many upstream error mapping rules (max %d)", maxUpstreamErrorMappingRules)
+	}
+	rules := make([]UpstreamErrorMappingRule, 0, len(settings.Rules))
+	for i, rule := range settings.Rules {
+		rule.Platform = strings.ToLower(strings.TrimSpace(rule.Platform))
+		rule.UpstreamErrorCode = strings.TrimSpace(rule.UpstreamErrorCode)
+		rule.ErrorType = strings.TrimSpace(rule.ErrorType)
+		rule.Message = strings.TrimSpace(rule.Message)
+		switch rule.Platform {
+		case "", PlatformAnthropic, PlatformOpenAI, PlatformGemini, PlatformAntigravity:
+		default:
+			return fmt.Errorf("rule %d: unsupported platform %q", i+1, rule.Platform)
+		}
+		if rule.UpstreamStatus == 0 && rule.UpstreamErrorCode == "" {
+			return fmt.Errorf("rule %d: upstream_status or upstream_error_code is required", i+1)
+		}
+		if rule.UpstreamStatus != 0 && (rule.UpstreamStatus < 100 || rule.UpstreamStatus > 599) {
+			return fmt.Errorf("rule %d: invalid upstream_status %d", i+1, rule.UpstreamStatus)
+		}
+		if rule.ClientStatus != 0 && (rule.ClientStatus < 400 || rule.ClientStatus > 599) {
+			return fmt.Errorf("rule %d: client_status must be a 4xx or 5xx status", i+1)
+		}
+		if rule.ClientStatus == 0 && rule.ErrorType == "" && rule.Message == "" {
+			return fmt.Errorf("rule %d: at least one of client_status, error_type or message is required", i+1)
+		}
+		rules = append(rules, rule)
+	}
+	settings.Rules = rules
+	return nil
+}
+
+// GetUpstreamErrorMappingSettings 获取上游错误映射配置
+func (s *SettingService) GetUpstreamErrorMappingSettings(ctx context.Context) (*UpstreamErrorMappingSettings, error) {
+	value, err := s.settingRepo.GetValue(ctx, SettingKeyUpstreamErrorMappingSettings)
+	if err != nil {
+		if errors.Is(err, ErrSettingNotFound) {
+			return DefaultUpstreamErrorMappingSettings(), nil
+		}
+		return nil, fmt.Errorf("get upstream error mapping settings: %w", err)
+	}
+	if value == "" {
+		return DefaultUpstreamErrorMappingSettings(), nil
+	}
+
+	var settings UpstreamErrorMappingSettings
+	if err := json.Unmarshal([]byte(value), &settings); err != nil {
+		return DefaultUpstreamErrorMappingSettings(), nil
+	}
+	if settings.Rules == nil {
+		settings.Rules = []UpstreamErrorMappingRule{}
+	}
+	return &settings, nil
+}
+
+// SetUpstreamErrorMappingSettings 设置上游错误映射配置
+func (s *SettingService) SetUpstreamErrorMappingSettings(ctx context.Context, settings *UpstreamErrorMappingSettings) error {
+	if settings == nil {
+		return fmt.Errorf("settings cannot be nil")
+	}
+	if err := normalizeUpstreamErrorMappingSettings(settings); err != nil {
+		return err
+	}
+
+	data, err := json.Marshal(settings)
+	if err != nil {
+		return fmt.Errorf("marshal upstream error mapping settings: %w", err)
+	}
+	return s.settingRepo.Set(ctx, SettingKeyUpstreamErrorMappingSettings, string(data))
+}
+
+// UpstreamErrorMappingService 故障转移耗尽后按管理员配置的映射规则生成返回给客户端的错误
+type UpstreamErrorMappingService struct {
+	settingService *SettingService
+
+	mu        sync.RWMutex
+	cached    *UpstreamErrorMappingSettings
+	expiresAt time.Time
+}
+
+// NewUpstreamErrorMappingService 创建上游错误映射服务
+func NewUpstreamErrorMappingService(settingService *SettingService) *UpstreamErrorMappingService {
+	return &UpstreamErrorMappingService{settingService: settingService}
+}
+
+// Map 将上游状态码与响应体映射为客户端错误：自定义规则优先，其次内置映射，最后兜底为 502。
+// 服务为 nil 或未启用自定义映射时仅使用内置映射。
+func (s *UpstreamErrorMappingService) Map(ctx context.Context, platform string, statusCode int, body []byte) UpstreamErrorMapping {
+	var rules []UpstreamErrorMappingRule
+	if s != nil {
+		if settings := s.load(ctx); settings != nil && settings.Enabled {
+			rules = settings.Rules
+		}
+	}
+	return mapUpstreamError(rules, platform, statusCode, body)
+}
+
+// Invalidate 清除本地缓存，下次 Map 时重新加载
+func (s *UpstreamErrorMappingService) Invalidate() {
+	if s == nil {
+		return
+	}
+	s.mu.Lock()
+	s.cached = nil
+	s.expiresAt = time.Time{}
+	s.mu.Unlock()
+}
+
+func (s *UpstreamErrorMappingService) load(ctx context.Context) *UpstreamErrorMappingSettings {
+	now := time.Now()
+	s.mu.RLock()
+	if s.cached != nil && now.Before(s.expiresAt) {
+		cached := s.cached
+		s.mu.RUnlock()
+		return cached
+	}
+	stale := s.cached
+	s.mu.RUnlock()
+
+	if s.settingService == nil {
+		return nil
+	}
+	settings, err := s.settingService.GetUpstreamErrorMappingSettings(ctx)
+	if err != nil {
+		log.Printf("[UpstreamErrorMapping] Failed to load settings: %v", err)
+		// 读取失败时沿用旧缓存，避免数据库抖动导致映射短暂失效
+		return stale
+	}
+
+	s.mu.Lock()
+	s.cached = settings
+	s.expiresAt = now.Add(upstreamErrorMappingCacheTTL)
+	s.mu.Unlock()
+	return settings
+}
+
+func mapUpstreamError(rules []UpstreamErrorMappingRule, platform string, statusCode int, body []byte) UpstreamErrorMapping {
+	platform = strings.ToLower(platform)
+	codes := extractUpstreamErrorCodes(body)
+
+	result := defaultUpstreamErrorMapping
+	if builtin, ok := matchUpstreamErrorMappingRule(builtinUpstreamErrorMappingRules, platform, statusCode, codes); ok {
+		result = UpstreamErrorMapping{Status: builtin.ClientStatus, Type: builtin.ErrorType, Message: builtin.Message}
+	}
+	if custom, ok := matchUpstreamErrorMappingRule(rules, platform, statusCode, codes); ok {
+		if custom.ClientStatus != 0 {
+			result.Status = custom.ClientStatus
+		}
+		if custom.ErrorType != "" {
+			result.Type = custom.ErrorType
+		}
+		if custom.Message != "" {
+			result.Message = custom.Message
+		}
+	}
+	return result
+}
+
+func matchUpstreamErrorMappingRule(rules []UpstreamErrorMappingRule, platform string, statusCode int, codes []string) (UpstreamErrorMappingRule, bool) {
+	for _, rule := range rules {
+		if rule.Platform != "" && rule.Platform != platform {
+			continue
+		}
+		if rule.UpstreamStatus != 0 && rule.UpstreamStatus != statusCode {
+			continue
+		}
+		if rule.UpstreamErrorCode != "" && !containsFoldString(codes, rule.UpstreamErrorCode) {
+			continue
+		}
+		return rule, true
+	}
+	return UpstreamErrorMappingRule{}, false
+}
+
+// extractUpstreamErrorCodes 提取上游响应体中的字符串错误码：
+// Anthropic error.type、OpenAI error.code/error.type、Gemini error.status
+func extractUpstreamErrorCodes(body []byte) []string {
+	if len(body) == 0 || !gjson.ValidBytes(body) {
+		return nil
+	}
+	var codes []string
+	for _, path := range []string{"error.code", "error.type", "error.status"} {
+		if v := gjson.GetBytes(body, path); v.Type == gjson.String && v.String() != "" {
+			codes = append(codes, v.String())
+		}
+	}
+	return codes
+}
+
+func containsFoldString(values []string, target string) bool {
+	for _, v := range values {
+		if strings.EqualFold(v, target) {
+			return true
+		}
+	}
+	return false
+}
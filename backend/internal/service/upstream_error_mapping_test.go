@@ -0,0 +1,87 @@
+//go:build unit
+
+package service
+
+import (
+	"context"
+	"net/http"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestNormalizeUpstreamErrorMappingSettings(t *testing.T) {
+	settings := &UpstreamErrorMappingSettings{Rules: []UpstreamErrorMappingRule{
+		{Platform: " OpenAI ", UpstreamStatus: 429, UpstreamErrorCode: " insufficient_quota ", ClientStatus: 402, Message: " Quota exhausted "},
+	}}
+	require.NoError(t, normalizeUpstreamErrorMappingSettings(settings))
+	require.Equal(t, UpstreamErrorMappingRule{
+		Platform: PlatformOpenAI, UpstreamStatus: 429, UpstreamErrorCode: "insufficient_quota", ClientStatus: 402, Message: "Quota exhausted",
+	}, settings.Rules[0])
+
+	invalid := []UpstreamErrorMappingRule{
+		{ClientStatus: 502},
+		{UpstreamStatus: 429},
+		{UpstreamStatus: 42, ClientStatus: 502},
+		{UpstreamStatus: 429, ClientStatus: 200},
+		{Platform: "azure", UpstreamStatus: 429, ClientStatus: 503},
+	}
+	for _, rule := range invalid {
+		require.Error(t, normalizeUpstreamErrorMappingSettings(&UpstreamErrorMappingSettings{Rules: []UpstreamErrorMappingRule{rule}}), "%+v", rule)
+	}
+}
+
+func TestUpstreamErrorMappingService_Builtin(t *testing.T) {
+	var nilSvc *UpstreamErrorMappingService
+	ctx := context.Background()
+
+	m := nilSvc.Map(ctx, PlatformAnthropic, 529, nil)
+	require.Equal(t, UpstreamErrorMapping{Status: http.StatusServiceUnavailable, Type: "overloaded_error", Message: "Upstream service overloaded, please retry later"}, m)
+
+	// OpenAI 协议没有 overloaded_error 类型
+	m = nilSvc.Map(ctx, PlatformOpenAI, 529, nil)
+	require.Equal(t, "upstream_error", m.Type)
+	require.Equal(t, http.StatusServiceUnavailable, m.Status)
+
+	m = nilSvc.Map(ctx, PlatformGemini, 429, nil)
+	require.Equal(t, http.StatusTooManyRequests, m.Status)
+	require.Equal(t, "rate_limit_error", m.Type)
+
+	m = nilSvc.Map(ctx, PlatformAnthropic, 418, nil)
+	require.Equal(t, UpstreamErrorMapping{Status: http.StatusBadGateway, Type: "upstream_error", Message: "Upstream request failed"}, m)
+}
+
+func TestUpstreamErrorMappingService_CustomRules(t *testing.T) {
+	repo := &settingRepoStub{values: map[string]string{
+		SettingKeyUpstreamErrorMappingSettings: `{"enabled":true,"rules":[` +
+			`{"platform":"openai","upstream_status":429,"upstream_error_code":"insufficient_quota","client_status":402,"error_type":"billing_error","message":"Quota exhausted"},` +
+			`{"upstream_status":401,"message":"Service is being maintained"}]}`,
+	}}
+	svc := NewUpstreamErrorMappingService(NewSettingService(repo, nil))
+	ctx := context.Background()
+
+	// 错误码匹配（忽略大小写）
+	m := svc.Map(ctx, PlatformOpenAI, 429, []byte(`{"error":{"code":"INSUFFICIENT_QUOTA","message":"x"}}`))
+	require.Equal(t, UpstreamErrorMapping{Status: 402, Type: "billing_error", Message: "Quota exhausted"}, m)
+
+	// 错误码不匹配时回落到内置映射
+	m = svc.Map(ctx, PlatformOpenAI, 429, []byte(`{"error":{"code":"rate_limit_exceeded"}}`))
+	require.Equal(t, http.StatusTooManyRequests, m.Status)
+	require.Equal(t, "rate_limit_error", m.Type)
+
+	// 平台不匹配
+	m = svc.Map(ctx, PlatformAnthropic, 429, []byte(`{"error":{"type":"insufficient_quota"}}`))
+	require.Equal(t, http.StatusTooManyRequests, m.Status)
+
+	// 部分覆盖：仅替换消息，状态码与类型沿用内置映射
+	m = svc.Map(ctx, PlatformGemini, 401, nil)
+	require.Equal(t, UpstreamErrorMapping{Status: http.StatusBadGateway, Type: "upstream_error", Message: "Service is being maintained"}, m)
+}
+
+func TestUpstreamErrorMappingService_Disabled(t *testing.T) {
+	repo := &settingRepoStub{values: map[string]string{
+		SettingKeyUpstreamErrorMappingSettings: `{"enabled":false,"rules":[{"upstream_status":401,"client_status":503}]}`,
+	}}
+	m := NewUpstreamErrorMappingService(NewSettingService(repo, nil)).Map(context.Background(), PlatformAnthropic, 401, nil)
+	require.Equal(t, http.StatusBadGateway, m.Status)
+}
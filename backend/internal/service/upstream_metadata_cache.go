@@ -0,0 +1,100 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"log"
+	"net/http"
+	"time"
+
+	"github.com/Wei-Shaw/sub2api/internal/config"
+)
+
+// 上游元数据缓存键（按账号隔离）
+const (
+	// upstreamMetadataDiscoveredModelsKey 模型自动发现结果（JSON 字符串数组）
+	upstreamMetadataDiscoveredModelsKey = "discovered_models"
+	// upstreamMetadataAIStudioPrefix AI Studio 模型列表/模型信息探测结果，后接请求路径
+	upstreamMetadataAIStudioPrefix = "aistudio:"
+)
+
+// UpstreamMetadataCache 缓存账号上游的模型列表与模型能力探测结果，
+// 避免 /v1/models、/v1beta/models 等请求在高负载下频繁访问上游元数据接口。
+type UpstreamMetadataCache interface {
+	// GetUpstreamMetadata 读取缓存，未命中或已过期时返回 nil, nil
+	GetUpstreamMetadata(ctx context.Context, accountID int64, key string) ([]byte, error)
+	// SetUpstreamMetadata 写入缓存
+	SetUpstreamMetadata(ctx context.Context, accountID int64, key string, value []byte, ttl time.Duration) error
+	// DeleteUpstreamMetadata 清除账号的全部上游元数据缓存（管理端强制刷新）
+	DeleteUpstreamMetadata(ctx context.Context, accountID int64) error
+}
+
+// upstreamMetadataCacheTTL 返回上游元数据缓存有效期，<= 0 表示不缓存
+func upstreamMetadataCacheTTL(cfg *config.Config) time.Duration {
+	if cfg == nil {
+		return 0
+	}
+	return cfg.Gateway.ModelDiscovery.CacheTTL
+}
+
+// getCachedDiscoveredModels 读取缓存的模型发现结果，缓存不可用时返回 nil
+func getCachedDiscoveredModels(ctx context.Context, cache UpstreamMetadataCache, accountID int64) []string {
+	if cache == nil {
+		return nil
+	}
+	data, err := cache.GetUpstreamMetadata(ctx, accountID, upstreamMetadataDiscoveredModelsKey)
+	if err != nil {
+		log.Printf("[UpstreamMetadataCache] Get discovered models for account %d failed: %v", accountID, err)
+		return nil
+	}
+	if len(data) == 0 {
+		return nil
+	}
+	var models []string
+	if err := json.Unmarshal(data, &models); err != nil {
+		return nil
+	}
+	return models
+}
+
+// setCachedDiscoveredModels 缓存模型发现结果（空列表不缓存）
+func setCachedDiscoveredModels(ctx context.Context, cache UpstreamMetadataCache, accountID int64, models []string, ttl time.Duration) {
+	if cache == nil || ttl <= 0 || len(models) == 0 {
+		return
+	}
+	data, err := json.Marshal(models)
+	if err != nil {
+		return
+	}
+	if err := cache.SetUpstreamMetadata(ctx, accountID, upstreamMetadataDiscoveredModelsKey, data, ttl); err != nil {
+		log.Printf("[UpstreamMetadataCache] Set discovered models for account %d failed: %v", accountID, err)
+	}
+}
+
+// getCachedAIStudioResult 读取缓存的 AI Studio GET 结果（仅缓存 200 响应，回放时仅保留 Content-Type）
+func getCachedAIStudioResult(ctx context.Context, cache UpstreamMetadataCache, accountID int64, path string) *UpstreamHTTPResult {
+	if cache == nil {
+		return nil
+	}
+	body, err := cache.GetUpstreamMetadata(ctx, accountID, upstreamMetadataAIStudioPrefix+path)
+	if err != nil {
+		log.Printf("[UpstreamMetadataCache] Get %s for account %d failed: %v", path, accountID, err)
+		return nil
+	}
+	if len(body) == 0 {
+		return nil
+	}
+	headers := make(http.Header)
+	headers.Set("Content-Type", "application/json; charset=utf-8")
+	return &UpstreamHTTPResult{StatusCode: http.StatusOK, Headers: headers, Body: body}
+}
+
+// setCachedAIStudioResult 缓存 AI Studio GET 的成功响应
+func setCachedAIStudioResult(ctx context.Context, cache UpstreamMetadataCache, accountID int64, path string, result *UpstreamHTTPResult, ttl time.Duration) {
+	if cache == nil || ttl <= 0 || result == nil || result.StatusCode != http.StatusOK || len(result.Body) == 0 {
+		return
+	}
+	if err := cache.SetUpstreamMetadata(ctx, accountID, upstreamMetadataAIStudioPrefix+path, result.Body, ttl); err != nil {
+		log.Printf("[UpstreamMetadataCache] Set %s for account %d failed: %v", path, accountID, err)
+	}
+}
@@ -0,0 +1,125 @@
+//go:build unit
+
+package service
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/Wei-Shaw/sub2api/internal/config"
+	"github.com/stretchr/testify/require"
+)
+
+type upstreamMetadataCacheStub struct {
+	values  map[string][]byte
+	deleted []int64
+}
+
+func newUpstreamMetadataCacheStub() *upstreamMetadataCacheStub {
+	return &upstreamMetadataCacheStub{values: make(map[string][]byte)}
+}
+
+func (s *upstreamMetadataCacheStub) key(accountID int64, key string) string {
+	return fmt.Sprintf("%d|%s", accountID, key)
+}
+
+func (s *upstreamMetadataCacheStub) GetUpstreamMetadata(ctx context.Context, accountID int64, key string) ([]byte, error) {
+	return s.values[s.key(accountID, key)], nil
+}
+
+func (s *upstreamMetadataCacheStub) SetUpstreamMetadata(ctx context.Context, accountID int64, key string, value []byte, ttl time.Duration) error {
+	s.values[s.key(accountID, key)] = value
+	return nil
+}
+
+func (s *upstreamMetadataCacheStub) DeleteUpstreamMetadata(ctx context.Context, accountID int64) error {
+	s.deleted = append(s.deleted, accountID)
+	s.values = make(map[string][]byte)
+	return nil
+}
+
+type discoveryAccountRepoStub struct {
+	AccountRepository
+	extras []map[string]any
+}
+
+func (s *discoveryAccountRepoStub) UpdateExtra(ctx context.Context, id int64, updates map[string]any) error {
+	s.extras = append(s.extras, updates)
+	return nil
+}
+
+func newModelDiscoveryTestConfig() *config.Config {
+	cfg := &config.Config{}
+	cfg.Gateway.ModelDiscovery.CacheTTL = time.Minute
+	return cfg
+}
+
+func TestAccountModelDiscoveryService_DiscoverAndSave_UsesCache(t *testing.T) {
+	ctx := context.Background()
+	cache := newUpstreamMetadataCacheStub()
+	account := &Account{ID: 1, Platform: PlatformOpenAI, Type: AccountTypeAPIKey, Credentials: map[string]any{"api_key": "sk-test"}}
+	setCachedDiscoveredModels(ctx, cache, account.ID, []string{"gpt-4o"}, time.Minute)
+
+	repo := &discoveryAccountRepoStub{}
+	upstream := &httpUpstreamStub{resp: &http.Response{
+		StatusCode: http.StatusOK,
+		Header:     http.Header{},
+		Body:       io.NopCloser(strings.NewReader(`{"data":[{"id":"gpt-5"},{"id":"gpt-4o"}]}`)),
+	}}
+	svc := NewAccountModelDiscoveryService(repo, upstream, cache, newModelDiscoveryTestConfig())
+
+	// 缓存命中：不请求上游，也不重复写入 extra
+	models, err := svc.DiscoverAndSave(ctx, account, false)
+	require.NoError(t, err)
+	require.Equal(t, []string{"gpt-4o"}, models)
+	require.Empty(t, repo.extras)
+
+	// 强制刷新：清除缓存后重新查询并回填缓存
+	models, err = svc.DiscoverAndSave(ctx, account, true)
+	require.NoError(t, err)
+	require.Equal(t, []string{"gpt-4o", "gpt-5"}, models)
+	require.Equal(t, []int64{1}, cache.deleted)
+	require.Len(t, repo.extras, 1)
+	require.Equal(t, []string{"gpt-4o", "gpt-5"}, getCachedDiscoveredModels(ctx, cache, account.ID))
+}
+
+func TestGeminiMessagesCompatService_ForwardAIStudioGET_CachesSuccess(t *testing.T) {
+	ctx := context.Background()
+	cache := newUpstreamMetadataCacheStub()
+	account := &Account{ID: 2, Platform: PlatformGemini, Type: AccountTypeAPIKey, Credentials: map[string]any{"api_key": "g-test"}}
+	upstream := &httpUpstreamStub{resp: &http.Response{
+		StatusCode: http.StatusOK,
+		Header:     http.Header{"Content-Type": []string{"application/json"}},
+		Body:       io.NopCloser(strings.NewReader(`{"models":[{"name":"models/gemini-2.5-pro"}]}`)),
+	}}
+	svc := &GeminiMessagesCompatService{httpUpstream: upstream, metadataCache: cache, cfg: newModelDiscoveryTestConfig()}
+
+	res, err := svc.ForwardAIStudioGET(ctx, account, "/v1beta/models")
+	require.NoError(t, err)
+	require.Equal(t, http.StatusOK, res.StatusCode)
+
+	// 上游改为返回错误：第二次请求命中缓存，不再访问上游
+	upstream.resp = nil
+	upstream.err = io.ErrUnexpectedEOF
+	cached, err := svc.ForwardAIStudioGET(ctx, account, "/v1beta/models")
+	require.NoError(t, err)
+	require.Equal(t, http.StatusOK, cached.StatusCode)
+	require.JSONEq(t, string(res.Body), string(cached.Body))
+	require.Contains(t, cached.Headers.Get("Content-Type"), "application/json")
+}
+
+func TestSetCachedAIStudioResult_SkipsErrors(t *testing.T) {
+	ctx := context.Background()
+	cache := newUpstreamMetadataCacheStub()
+	setCachedAIStudioResult(ctx, cache, 3, "/v1beta/models", &UpstreamHTTPResult{StatusCode: http.StatusTooManyRequests, Body: []byte(`{}`)}, time.Minute)
+	require.Nil(t, getCachedAIStudioResult(ctx, cache, 3, "/v1beta/models"))
+
+	// TTL 为 0 时不缓存
+	setCachedAIStudioResult(ctx, cache, 3, "/v1beta/models", &UpstreamHTTPResult{StatusCode: http.StatusOK, Body: []byte(`{}`)}, 0)
+	require.Nil(t, getCachedAIStudioResult(ctx, cache, 3, "/v1beta/models"))
+}
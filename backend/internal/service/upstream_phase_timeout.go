@@ -0,0 +1,97 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"time"
+
+	"github.com/Wei-Shaw/sub2api/internal/config"
+	"github.com/Wei-Shaw/sub2api/internal/pkg/ctxkey"
+)
+
+// 分阶段超时错误：由 HTTP 上游在对应阶段超时后返回，网关据此区分“启动慢”与“流停滞”并决定是否切换账号
+var (
+	// ErrUpstreamConnectTimeout 建立连接（含代理与 TLS 握手）超时：账号/代理链路不可达，切换账号
+	ErrUpstreamConnectTimeout = errors.New("upstream connect timed out")
+	// ErrUpstreamFirstTokenTimeout 首 token 超时：上游启动慢，尚未向客户端输出，切换账号
+	ErrUpstreamFirstTokenTimeout = errors.New("upstream timed out waiting for first token")
+	// ErrUpstreamChunkGapTimeout 分片间隔超时：流已开始输出后停滞，结束响应，不切换账号
+	ErrUpstreamChunkGapTimeout = errors.New("upstream stream stalled between chunks")
+	// ErrUpstreamTotalTimeout 总时长超时：整个请求超出预算，不再切换账号
+	ErrUpstreamTotalTimeout = errors.New("upstream request exceeded total duration budget")
+)
+
+// UpstreamTimeoutBudget 单次上游请求的分阶段超时预算，0 表示该阶段不限制
+type UpstreamTimeoutBudget struct {
+	// Connect 获取连接（新建连接含 DNS、代理隧道与 TLS 握手）的超时
+	Connect time.Duration
+	// FirstToken 从发出请求到收到首个响应数据（流式为首个 SSE 分片，非流式为响应头）的超时
+	FirstToken time.Duration
+	// ChunkGap 首个分片之后，相邻两次读取到数据的最大间隔
+	ChunkGap time.Duration
+	// Total 从发出请求到响应体读取完毕的总时长
+	Total time.Duration
+}
+
+// IsZero 是否未设置任何阶段超时
+func (b UpstreamTimeoutBudget) IsZero() bool {
+	return b == UpstreamTimeoutBudget{}
+}
+
+// NewUpstreamTimeoutBudget 按 gateway.phase_timeouts 配置构建分阶段超时预算
+func NewUpstreamTimeoutBudget(cfg *config.Config) UpstreamTimeoutBudget {
+	if cfg == nil {
+		return UpstreamTimeoutBudget{}
+	}
+	pt := cfg.Live().Gateway.PhaseTimeouts
+	return UpstreamTimeoutBudget{
+		Connect:    time.Duration(pt.ConnectSeconds) * time.Second,
+		FirstToken: time.Duration(pt.FirstTokenSeconds) * time.Second,
+		ChunkGap:   time.Duration(pt.ChunkGapSeconds) * time.Second,
+		Total:      time.Duration(pt.TotalSeconds) * time.Second,
+	}
+}
+
+// WithUpstreamTimeoutBudget 将分阶段超时预算写入 context，由 HTTP 上游执行
+func WithUpstreamTimeoutBudget(ctx context.Context, budget UpstreamTimeoutBudget) context.Context {
+	if budget.IsZero() {
+		return ctx
+	}
+	return context.WithValue(ctx, ctxkey.UpstreamTimeoutBudget, budget)
+}
+
+// UpstreamTimeoutBudgetFromContext 返回 context 中的分阶段超时预算，未设置时返回零值
+func UpstreamTimeoutBudgetFromContext(ctx context.Context) UpstreamTimeoutBudget {
+	if ctx == nil {
+		return UpstreamTimeoutBudget{}
+	}
+	budget, _ := ctx.Value(ctxkey.UpstreamTimeoutBudget).(UpstreamTimeoutBudget)
+	return budget
+}
+
+// upstreamTimeoutFailover 上游请求超时的故障转移决策：
+// 连接超时、首 token 超时与单次尝试超时发生在向客户端输出之前，交由 handler 切换账号；
+// 总时长超时与其他错误返回 nil，由调用方直接向客户端返回错误
+func upstreamTimeoutFailover(err error) *UpstreamFailoverError {
+	switch {
+	case errors.Is(err, ErrUpstreamConnectTimeout),
+		errors.Is(err, ErrUpstreamFirstTokenTimeout),
+		errors.Is(err, ErrUpstreamAttemptTimeout):
+		return &UpstreamFailoverError{StatusCode: http.StatusGatewayTimeout}
+	}
+	return nil
+}
+
+// upstreamRequestErrorKind 上游请求失败（未收到响应）时的运维错误类型与返回给客户端的状态码
+func upstreamRequestErrorKind(err error) (kind string, status int) {
+	if errors.Is(err, ErrUpstreamTotalTimeout) {
+		return "total_timeout", http.StatusGatewayTimeout
+	}
+	return "request_error", http.StatusBadGateway
+}
+
+// isUpstreamStreamTimeout 流式读取过程中的分片间隔/总时长超时
+func isUpstreamStreamTimeout(err error) bool {
+	return errors.Is(err, ErrUpstreamChunkGapTimeout) || errors.Is(err, ErrUpstreamTotalTimeout)
+}
@@ -0,0 +1,54 @@
+//go:build unit
+
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/Wei-Shaw/sub2api/internal/config"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNewUpstreamTimeoutBudget(t *testing.T) {
+	require.True(t, NewUpstreamTimeoutBudget(nil).IsZero())
+
+	cfg := &config.Config{}
+	cfg.Gateway.PhaseTimeouts = config.GatewayPhaseTimeoutsConfig{ConnectSeconds: 10, FirstTokenSeconds: 30, TotalSeconds: 600}
+	budget := NewUpstreamTimeoutBudget(cfg)
+	require.Equal(t, UpstreamTimeoutBudget{Connect: 10 * time.Second, FirstToken: 30 * time.Second, Total: 10 * time.Minute}, budget)
+
+	ctx := context.Background()
+	require.Equal(t, ctx, WithUpstreamTimeoutBudget(ctx, UpstreamTimeoutBudget{}))
+	require.Equal(t, budget, UpstreamTimeoutBudgetFromContext(WithUpstreamTimeoutBudget(ctx, budget)))
+}
+
+func TestUpstreamTimeoutFailover(t *testing.T) {
+	wrap := func(err error) error { return fmt.Errorf("%w (5s)", err) }
+
+	// 启动阶段超时：切换账号
+	for _, err := range []error{ErrUpstreamConnectTimeout, ErrUpstreamFirstTokenTimeout, ErrUpstreamAttemptTimeout} {
+		failoverErr := upstreamTimeoutFailover(wrap(err))
+		require.NotNil(t, failoverErr, err.Error())
+		require.Equal(t, http.StatusGatewayTimeout, failoverErr.StatusCode)
+	}
+
+	// 总时长超时与普通错误：不切换账号
+	require.Nil(t, upstreamTimeoutFailover(wrap(ErrUpstreamTotalTimeout)))
+	require.Nil(t, upstreamTimeoutFailover(errors.New("connection reset")))
+
+	kind, status := upstreamRequestErrorKind(wrap(ErrUpstreamTotalTimeout))
+	require.Equal(t, "total_timeout", kind)
+	require.Equal(t, http.StatusGatewayTimeout, status)
+	kind, status = upstreamRequestErrorKind(errors.New("connection reset"))
+	require.Equal(t, "request_error", kind)
+	require.Equal(t, http.StatusBadGateway, status)
+
+	require.True(t, isUpstreamStreamTimeout(wrap(ErrUpstreamChunkGapTimeout)))
+	require.True(t, isUpstreamStreamTimeout(wrap(ErrUpstreamTotalTimeout)))
+	require.False(t, isUpstreamStreamTimeout(wrap(ErrUpstreamFirstTokenTimeout)))
+}
@@ -0,0 +1,241 @@
+package service
+
+import (
+	"context"
+	"log"
+	"strings"
+	"time"
+
+	"github.com/Wei-Shaw/sub2api/internal/config"
+)
+
+// 用量异常标记原因
+const (
+	UsageAnomalyReasonRequestSpike = "request_spike"
+	UsageAnomalyReasonNewCountry   = "new_country"
+	UsageAnomalyReasonNewModel     = "new_model"
+)
+
+// 用量异常检测记录的属性类别
+const (
+	usageAnomalyAttrCountry = "country"
+	usageAnomalyAttrModel   = "model"
+)
+
+// UsageObservation 一次已认证网关请求的用量特征
+type UsageObservation struct {
+	APIKeyID int64
+	UserID   int64
+	Model    string
+	// Country 客户端国家代码（来自 CDN/反向代理写入的请求头，可为空）
+	Country string
+}
+
+// UsageAnomalyFlag 被标记为用量异常的 API Key
+type UsageAnomalyFlag struct {
+	APIKeyID int64    `json:"api_key_id"`
+	UserID   int64    `json:"user_id"`
+	Reasons  []string `json:"reasons"`
+	// RequestsThisHour 当前小时请求数
+	RequestsThisHour int64 `json:"requests_this_hour"`
+	// BaselinePerHour 基线每小时平均请求数
+	BaselinePerHour float64   `json:"baseline_per_hour"`
+	Country         string    `json:"country,omitempty"`
+	Model           string    `json:"model,omitempty"`
+	Suspended       bool      `json:"suspended"`
+	FlaggedAt       time.Time `json:"flagged_at"`
+}
+
+// UsageAnomalyCache 用量基线与异常标记存储（按小时分桶，多实例共享）
+type UsageAnomalyCache interface {
+	// TrackingSince 返回开始跟踪该 Key 的时间，首次调用时记录为 now
+	TrackingSince(ctx context.Context, apiKeyID int64, now time.Time, ttl time.Duration) (time.Time, error)
+	// IncrHourlyRequests 累加 hour（Unix 小时）桶的请求数，返回累加后的值
+	IncrHourlyRequests(ctx context.Context, apiKeyID int64, hour int64, ttl time.Duration) (int64, error)
+	// SumHourlyRequests 汇总 [fromHour, toHour] 各小时桶的请求数
+	SumHourlyRequests(ctx context.Context, apiKeyID int64, fromHour, toHour int64) (int64, error)
+	// AddSeenAttribute 记录 Key 出现过的属性值（国家、模型），首次出现时返回 true
+	AddSeenAttribute(ctx context.Context, apiKeyID int64, attr, value string, ttl time.Duration) (bool, error)
+	// SetUsageAnomalyFlag 写入标记；Key 已处于标记期内时返回 false
+	SetUsageAnomalyFlag(ctx context.Context, flag *UsageAnomalyFlag, ttl time.Duration) (bool, error)
+	// ListUsageAnomalyFlags 列出 since 之后仍有效的标记
+	ListUsageAnomalyFlags(ctx context.Context, since time.Time) ([]UsageAnomalyFlag, error)
+}
+
+// APIKeySuspender 停用 API Key（用量异常自动处置）
+type APIKeySuspender interface {
+	Suspend(ctx context.Context, id int64) error
+}
+
+// UsageAnomalyService 按 API Key 学习用量基线，检测请求量突增、新国家与新模型，
+// 在订阅额度被耗尽之前发现疑似泄露的 Key，并按配置告警或自动停用。
+type UsageAnomalyService struct {
+	cfg       config.GatewayUsageAnomalyConfig
+	cache     UsageAnomalyCache
+	suspender APIKeySuspender
+	now       func() time.Time
+}
+
+// NewUsageAnomalyService 创建用量异常检测服务
+func NewUsageAnomalyService(cfg *config.Config, cache UsageAnomalyCache, apiKeyService *APIKeyService) *UsageAnomalyService {
+	s := &UsageAnomalyService{cache: cache, now: time.Now}
+	if apiKeyService != nil {
+		s.suspender = apiKeyService
+	}
+	if cfg != nil {
+		s.cfg = cfg.Gateway.UsageAnomaly
+	}
+	return s
+}
+
+// Enabled 是否启用检测
+func (s *UsageAnomalyService) Enabled() bool {
+	return s != nil && s.cfg.Enabled && s.cache != nil
+}
+
+// CountryHeader 客户端国家代码请求头，为空表示不检测新国家
+func (s *UsageAnomalyService) CountryHeader() string {
+	if !s.Enabled() {
+		return ""
+	}
+	return s.cfg.CountryHeader
+}
+
+func (s *UsageAnomalyService) baseline() time.Duration {
+	return time.Duration(s.cfg.BaselineHours) * time.Hour
+}
+
+func (s *UsageAnomalyService) flagTTL() time.Duration {
+	return time.Duration(s.cfg.FlagTTLMinutes) * time.Minute
+}
+
+// spikeCheckInterval 超过下限后每隔多少个请求判定一次突增，避免每个请求都汇总基线
+func (s *UsageAnomalyService) spikeCheckInterval() int64 {
+	if interval := int64(s.cfg.MinRequestsPerHour / 10); interval > 1 {
+		return interval
+	}
+	return 1
+}
+
+// Observe 记录一次请求并判定是否异常，返回本次新产生的标记
+func (s *UsageAnomalyService) Observe(ctx context.Context, obs UsageObservation) (*UsageAnomalyFlag, error) {
+	if !s.Enabled() || obs.APIKeyID <= 0 {
+		return nil, nil
+	}
+	now := s.now()
+	// 属性与跟踪起点在 Key 持续无流量超过基线窗口后过期，重新学习
+	retention := s.baseline() + time.Duration(s.cfg.LearningHours)*time.Hour
+
+	since, err := s.cache.TrackingSince(ctx, obs.APIKeyID, now, retention)
+	if err != nil {
+		return nil, err
+	}
+	hour := now.Unix() / 3600
+	count, err := s.cache.IncrHourlyRequests(ctx, obs.APIKeyID, hour, s.baseline()+2*time.Hour)
+	if err != nil {
+		return nil, err
+	}
+	country := strings.ToUpper(strings.TrimSpace(obs.Country))
+	newCountry, err := s.addSeen(ctx, obs.APIKeyID, usageAnomalyAttrCountry, country, retention)
+	if err != nil {
+		return nil, err
+	}
+	newModel := false
+	if s.cfg.DetectNewModels {
+		if newModel, err = s.addSeen(ctx, obs.APIKeyID, usageAnomalyAttrModel, obs.Model, retention); err != nil {
+			return nil, err
+		}
+	}
+
+	// 学习期内只积累基线
+	if now.Sub(since) < time.Duration(s.cfg.LearningHours)*time.Hour {
+		return nil, nil
+	}
+
+	flag := &UsageAnomalyFlag{
+		APIKeyID:         obs.APIKeyID,
+		UserID:           obs.UserID,
+		RequestsThisHour: count,
+		FlaggedAt:        now,
+	}
+	if newCountry {
+		flag.Reasons = append(flag.Reasons, UsageAnomalyReasonNewCountry)
+		flag.Country = country
+	}
+	if newModel {
+		flag.Reasons = append(flag.Reasons, UsageAnomalyReasonNewModel)
+		flag.Model = obs.Model
+	}
+	if count >= int64(s.cfg.MinRequestsPerHour) && count%s.spikeCheckInterval() == 0 {
+		avg, err := s.baselinePerHour(ctx, obs.APIKeyID, since, hour)
+		if err != nil {
+			return nil, err
+		}
+		flag.BaselinePerHour = avg
+		if float64(count) > avg*s.cfg.SpikeFactor {
+			flag.Reasons = append(flag.Reasons, UsageAnomalyReasonRequestSpike)
+		}
+	}
+	if len(flag.Reasons) == 0 {
+		return nil, nil
+	}
+	return s.raise(ctx, flag)
+}
+
+func (s *UsageAnomalyService) addSeen(ctx context.Context, apiKeyID int64, attr, value string, ttl time.Duration) (bool, error) {
+	if value == "" {
+		return false, nil
+	}
+	return s.cache.AddSeenAttribute(ctx, apiKeyID, attr, value, ttl)
+}
+
+// baselinePerHour 当前小时之前、跟踪开始之后（最多 baseline_hours）的每小时平均请求数
+func (s *UsageAnomalyService) baselinePerHour(ctx context.Context, apiKeyID int64, since time.Time, hour int64) (float64, error) {
+	fromHour := hour - int64(s.cfg.BaselineHours)
+	if sinceHour := since.Unix() / 3600; sinceHour > fromHour {
+		fromHour = sinceHour
+	}
+	hours := hour - fromHour
+	if hours <= 0 {
+		return 0, nil
+	}
+	total, err := s.cache.SumHourlyRequests(ctx, apiKeyID, fromHour, hour-1)
+	if err != nil {
+		return 0, err
+	}
+	return float64(total) / float64(hours), nil
+}
+
+// raise 写入标记（标记期内不重复处置），按配置停用 Key
+func (s *UsageAnomalyService) raise(ctx context.Context, flag *UsageAnomalyFlag) (*UsageAnomalyFlag, error) {
+	flag.Suspended = s.cfg.Action == config.UsageAnomalyActionSuspend && s.suspender != nil
+	created, err := s.cache.SetUsageAnomalyFlag(ctx, flag, s.flagTTL())
+	if err != nil || !created {
+		return nil, err
+	}
+	log.Printf("[UsageAnomaly] API key %d (user %d) flagged: reasons=%v requests_this_hour=%d baseline_per_hour=%.1f country=%s model=%s suspend=%v",
+		flag.APIKeyID, flag.UserID, flag.Reasons, flag.RequestsThisHour, flag.BaselinePerHour, flag.Country, flag.Model, flag.Suspended)
+	if flag.Suspended {
+		if err := s.suspender.Suspend(ctx, flag.APIKeyID); err != nil {
+			return flag, err
+		}
+	}
+	return flag, nil
+}
+
+// ListFlags 列出当前仍在标记期内的 Key
+func (s *UsageAnomalyService) ListFlags(ctx context.Context) ([]UsageAnomalyFlag, error) {
+	if !s.Enabled() {
+		return []UsageAnomalyFlag{}, nil
+	}
+	return s.cache.ListUsageAnomalyFlags(ctx, s.now().Add(-s.flagTTL()))
+}
+
+// CountFlaggedKeys 当前被标记的 Key 数量（运维告警指标 usage_anomaly_flagged_keys）
+func (s *UsageAnomalyService) CountFlaggedKeys(ctx context.Context) (int, error) {
+	flags, err := s.ListFlags(ctx)
+	if err != nil {
+		return 0, err
+	}
+	return len(flags), nil
+}
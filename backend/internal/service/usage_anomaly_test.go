@@ -0,0 +1,179 @@
+//go:build unit
+
+package service
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/Wei-Shaw/sub2api/internal/config"
+	"github.com/stretchr/testify/require"
+)
+
+type usageAnomalyCacheStub struct {
+	since map[int64]time.Time
+	hours map[int64]map[int64]int64
+	seen  map[string]bool
+	flags map[int64]UsageAnomalyFlag
+}
+
+func newUsageAnomalyCacheStub() *usageAnomalyCacheStub {
+	return &usageAnomalyCacheStub{
+		since: map[int64]time.Time{},
+		hours: map[int64]map[int64]int64{},
+		seen:  map[string]bool{},
+		flags: map[int64]UsageAnomalyFlag{},
+	}
+}
+
+func (s *usageAnomalyCacheStub) TrackingSince(ctx context.Context, apiKeyID int64, now time.Time, ttl time.Duration) (time.Time, error) {
+	if t, ok := s.since[apiKeyID]; ok {
+		return t, nil
+	}
+	s.since[apiKeyID] = now
+	return now, nil
+}
+
+func (s *usageAnomalyCacheStub) IncrHourlyRequests(ctx context.Context, apiKeyID int64, hour int64, ttl time.Duration) (int64, error) {
+	if s.hours[apiKeyID] == nil {
+		s.hours[apiKeyID] = map[int64]int64{}
+	}
+	s.hours[apiKeyID][hour]++
+	return s.hours[apiKeyID][hour], nil
+}
+
+func (s *usageAnomalyCacheStub) SumHourlyRequests(ctx context.Context, apiKeyID int64, fromHour, toHour int64) (int64, error) {
+	var total int64
+	for h := fromHour; h <= toHour; h++ {
+		total += s.hours[apiKeyID][h]
+	}
+	return total, nil
+}
+
+func (s *usageAnomalyCacheStub) AddSeenAttribute(ctx context.Context, apiKeyID int64, attr, value string, ttl time.Duration) (bool, error) {
+	key := attr + ":" + value
+	if s.seen[key] {
+		return false, nil
+	}
+	s.seen[key] = true
+	return true, nil
+}
+
+func (s *usageAnomalyCacheStub) SetUsageAnomalyFlag(ctx context.Context, flag *UsageAnomalyFlag, ttl time.Duration) (bool, error) {
+	if _, ok := s.flags[flag.APIKeyID]; ok {
+		return false, nil
+	}
+	s.flags[flag.APIKeyID] = *flag
+	return true, nil
+}
+
+func (s *usageAnomalyCacheStub) ListUsageAnomalyFlags(ctx context.Context, since time.Time) ([]UsageAnomalyFlag, error) {
+	out := make([]UsageAnomalyFlag, 0, len(s.flags))
+	for _, f := range s.flags {
+		out = append(out, f)
+	}
+	return out, nil
+}
+
+type apiKeySuspenderStub struct {
+	suspended []int64
+}
+
+func (s *apiKeySuspenderStub) Suspend(ctx context.Context, id int64) error {
+	s.suspended = append(s.suspended, id)
+	return nil
+}
+
+func newUsageAnomalyTestService(action string) (*UsageAnomalyService, *usageAnomalyCacheStub, *apiKeySuspenderStub, *time.Time) {
+	cache := newUsageAnomalyCacheStub()
+	svc := NewUsageAnomalyService(&config.Config{Gateway: config.GatewayConfig{UsageAnomaly: config.GatewayUsageAnomalyConfig{
+		Enabled:            true,
+		BaselineHours:      24,
+		LearningHours:      2,
+		SpikeFactor:        5,
+		MinRequestsPerHour: 20,
+		CountryHeader:      "CF-IPCountry",
+		DetectNewModels:    true,
+		Action:             action,
+		FlagTTLMinutes:     60,
+	}}}, cache, nil)
+	suspender := &apiKeySuspenderStub{}
+	svc.suspender = suspender
+	now := time.Date(2026, 1, 1, 0, 30, 0, 0, time.UTC)
+	svc.now = func() time.Time { return now }
+	return svc, cache, suspender, &now
+}
+
+func observeN(t *testing.T, svc *UsageAnomalyService, n int, obs UsageObservation) *UsageAnomalyFlag {
+	t.Helper()
+	var last *UsageAnomalyFlag
+	for i := 0; i < n; i++ {
+		flag, err := svc.Observe(context.Background(), obs)
+		require.NoError(t, err)
+		if flag != nil {
+			last = flag
+		}
+	}
+	return last
+}
+
+func TestUsageAnomalyService_LearningThenSpike(t *testing.T) {
+	svc, _, suspender, now := newUsageAnomalyTestService(config.UsageAnomalyActionAlert)
+	obs := UsageObservation{APIKeyID: 1, UserID: 9, Model: "claude-sonnet", Country: "us"}
+
+	// 学习期内的新国家/模型与高请求量均不告警
+	require.Nil(t, observeN(t, svc, 30, obs))
+	*now = now.Add(time.Hour)
+	require.Nil(t, observeN(t, svc, 4, obs))
+
+	// 学习期结束后正常用量不告警
+	*now = now.Add(2 * time.Hour)
+	require.Nil(t, observeN(t, svc, 10, obs))
+
+	// 请求量远超基线
+	*now = now.Add(time.Hour)
+	flag := observeN(t, svc, 200, obs)
+	require.NotNil(t, flag)
+	require.Equal(t, []string{UsageAnomalyReasonRequestSpike}, flag.Reasons)
+	require.Greater(t, flag.RequestsThisHour, int64(float64(5)*flag.BaselinePerHour))
+	require.False(t, flag.Suspended)
+	require.Empty(t, suspender.suspended)
+
+	count, err := svc.CountFlaggedKeys(context.Background())
+	require.NoError(t, err)
+	require.Equal(t, 1, count)
+}
+
+func TestUsageAnomalyService_NewCountryAndModelSuspend(t *testing.T) {
+	svc, _, suspender, now := newUsageAnomalyTestService(config.UsageAnomalyActionSuspend)
+	obs := UsageObservation{APIKeyID: 2, UserID: 9, Model: "claude-sonnet", Country: "US"}
+	require.Nil(t, observeN(t, svc, 1, obs))
+
+	*now = now.Add(3 * time.Hour)
+	require.Nil(t, observeN(t, svc, 1, obs))
+
+	flag := observeN(t, svc, 1, UsageObservation{APIKeyID: 2, UserID: 9, Model: "claude-opus", Country: "kp"})
+	require.NotNil(t, flag)
+	require.Equal(t, []string{UsageAnomalyReasonNewCountry, UsageAnomalyReasonNewModel}, flag.Reasons)
+	require.Equal(t, "KP", flag.Country)
+	require.Equal(t, "claude-opus", flag.Model)
+	require.True(t, flag.Suspended)
+	require.Equal(t, []int64{2}, suspender.suspended)
+
+	// 标记期内不重复处置
+	require.Nil(t, observeN(t, svc, 1, UsageObservation{APIKeyID: 2, UserID: 9, Country: "RU"}))
+	require.Equal(t, []int64{2}, suspender.suspended)
+}
+
+func TestUsageAnomalyService_Disabled(t *testing.T) {
+	svc := NewUsageAnomalyService(&config.Config{}, newUsageAnomalyCacheStub(), nil)
+	require.False(t, svc.Enabled())
+	flag, err := svc.Observe(context.Background(), UsageObservation{APIKeyID: 1})
+	require.NoError(t, err)
+	require.Nil(t, flag)
+
+	var nilSvc *UsageAnomalyService
+	require.False(t, nilSvc.Enabled())
+	require.Equal(t, "", nilSvc.CountryHeader())
+}
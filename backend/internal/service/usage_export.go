@@ -0,0 +1,250 @@
+package service
+
+import (
+	"context"
+	"encoding/csv"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"time"
+
+	infraerrors "github.com/Wei-Shaw/sub2api/internal/pkg/errors"
+	"github.com/Wei-Shaw/sub2api/internal/pkg/parquet"
+	"github.com/Wei-Shaw/sub2api/internal/pkg/usagestats"
+)
+
+const (
+	UsageExportFormatCSV     = "csv"
+	UsageExportFormatParquet = "parquet"
+
+	// usageExportBatchSize 每批读取的记录数，同时也是 Parquet 行组大小
+	usageExportBatchSize = 5000
+	// usageExportMaxRange 单次导出的最大时间跨度
+	usageExportMaxRange = 366 * 24 * time.Hour
+)
+
+var (
+	ErrUsageExportInvalidFormat = infraerrors.BadRequest("USAGE_EXPORT_INVALID_FORMAT", "format must be csv or parquet")
+	ErrUsageExportRangeRequired = infraerrors.BadRequest("USAGE_EXPORT_RANGE_REQUIRED", "start_date and end_date are required for export")
+	ErrUsageExportRangeTooLarge = infraerrors.BadRequest("USAGE_EXPORT_RANGE_TOO_LARGE", "export range must not exceed 366 days")
+)
+
+// UsageExportOptions 导出选项
+type UsageExportOptions struct {
+	Format string
+	// IncludeAccount 是否导出上游账号相关列（仅管理员）
+	IncludeAccount bool
+}
+
+// usageExportColumn 导出列：value 返回 int64 / float64 / string / bool / time.Time，与 parquet 列类型一致
+type usageExportColumn struct {
+	name      string
+	typ       parquet.ColumnType
+	adminOnly bool
+	value     func(l *UsageLog) any
+}
+
+var usageExportColumns = []usageExportColumn{
+	{name: "id", typ: parquet.Int64, value: func(l *UsageLog) any { return l.ID }},
+	{name: "created_at", typ: parquet.TimestampMillis, value: func(l *UsageLog) any { return l.CreatedAt }},
+	{name: "request_id", typ: parquet.String, value: func(l *UsageLog) any { return l.RequestID }},
+	{name: "user_id", typ: parquet.Int64, value: func(l *UsageLog) any { return l.UserID }},
+	{name: "user_email", typ: parquet.String, value: func(l *UsageLog) any {
+		if l.User != nil {
+			return l.User.Email
+		}
+		return ""
+	}},
+	{name: "api_key_id", typ: parquet.Int64, value: func(l *UsageLog) any { return l.APIKeyID }},
+	{name: "api_key_name", typ: parquet.String, value: func(l *UsageLog) any {
+		if l.APIKey != nil {
+			return l.APIKey.Name
+		}
+		return ""
+	}},
+	{name: "account_id", typ: parquet.Int64, adminOnly: true, value: func(l *UsageLog) any { return l.AccountID }},
+	{name: "account_name", typ: parquet.String, adminOnly: true, value: func(l *UsageLog) any {
+		if l.Account != nil {
+			return l.Account.Name
+		}
+		return ""
+	}},
+	{name: "group_id", typ: parquet.Int64, value: func(l *UsageLog) any { return derefInt64(l.GroupID) }},
+	{name: "group_name", typ: parquet.String, value: func(l *UsageLog) any {
+		if l.Group != nil {
+			return l.Group.Name
+		}
+		return ""
+	}},
+	{name: "model", typ: parquet.String, value: func(l *UsageLog) any { return l.Model }},
+	{name: "billing_type", typ: parquet.Int64, value: func(l *UsageLog) any { return int64(l.BillingType) }},
+	{name: "stream", typ: parquet.Boolean, value: func(l *UsageLog) any { return l.Stream }},
+	{name: "metering_only", typ: parquet.Boolean, value: func(l *UsageLog) any { return l.MeteringOnly }},
+	{name: "input_tokens", typ: parquet.Int64, value: func(l *UsageLog) any { return l.InputTokens }},
+	{name: "output_tokens", typ: parquet.Int64, value: func(l *UsageLog) any { return l.OutputTokens }},
+	{name: "cache_creation_tokens", typ: parquet.Int64, value: func(l *UsageLog) any { return l.CacheCreationTokens }},
+	{name: "cache_read_tokens", typ: parquet.Int64, value: func(l *UsageLog) any { return l.CacheReadTokens }},
+	{name: "image_count", typ: parquet.Int64, value: func(l *UsageLog) any { return l.ImageCount }},
+	{name: "input_cost", typ: parquet.Double, value: func(l *UsageLog) any { return l.InputCost }},
+	{name: "output_cost", typ: parquet.Double, value: func(l *UsageLog) any { return l.OutputCost }},
+	{name: "cache_creation_cost", typ: parquet.Double, value: func(l *UsageLog) any { return l.CacheCreationCost }},
+	{name: "cache_read_cost", typ: parquet.Double, value: func(l *UsageLog) any { return l.CacheReadCost }},
+	{name: "tool_cost", typ: parquet.Double, value: func(l *UsageLog) any { return l.ToolCost }},
+	{name: "total_cost", typ: parquet.Double, value: func(l *UsageLog) any { return l.TotalCost }},
+	{name: "rate_multiplier", typ: parquet.Double, value: func(l *UsageLog) any { return l.RateMultiplier }},
+	{name: "account_rate_multiplier", typ: parquet.Double, adminOnly: true, value: func(l *UsageLog) any {
+		if l.AccountRateMultiplier != nil {
+			return *l.AccountRateMultiplier
+		}
+		return 1.0
+	}},
+	{name: "actual_cost", typ: parquet.Double, value: func(l *UsageLog) any { return l.ActualCost }},
+	{name: "duration_ms", typ: parquet.Int64, value: func(l *UsageLog) any { return derefInt(l.DurationMs) }},
+	{name: "first_token_ms", typ: parquet.Int64, value: func(l *UsageLog) any { return derefInt(l.FirstTokenMs) }},
+}
+
+func derefInt64(v *int64) int64 {
+	if v == nil {
+		return 0
+	}
+	return *v
+}
+
+func derefInt(v *int) int {
+	if v == nil {
+		return 0
+	}
+	return *v
+}
+
+// NormalizeUsageExportFormat 校验导出格式，空值默认为 csv
+func NormalizeUsageExportFormat(format string) (string, error) {
+	switch f := strings.ToLower(strings.TrimSpace(format)); f {
+	case "", UsageExportFormatCSV:
+		return UsageExportFormatCSV, nil
+	case UsageExportFormatParquet:
+		return UsageExportFormatParquet, nil
+	default:
+		return "", ErrUsageExportInvalidFormat
+	}
+}
+
+// ValidateUsageExportRange 导出必须指定起止时间，且跨度不超过 366 天，避免一次性扫描全表
+func ValidateUsageExportRange(filters usagestats.UsageLogFilters) error {
+	if filters.StartTime == nil || filters.EndTime == nil {
+		return ErrUsageExportRangeRequired
+	}
+	if filters.EndTime.Sub(*filters.StartTime) > usageExportMaxRange {
+		return ErrUsageExportRangeTooLarge
+	}
+	return nil
+}
+
+// UsageExportContentType 返回导出格式对应的 Content-Type
+func UsageExportContentType(format string) string {
+	if format == UsageExportFormatParquet {
+		return "application/vnd.apache.parquet"
+	}
+	return "text/csv; charset=utf-8"
+}
+
+// ExportUsage 流式导出筛选范围内的用量记录：按批读取数据库并立即写出，内存占用与总记录数无关。
+// 调用方应先通过 NormalizeUsageExportFormat / ValidateUsageExportRange 校验参数。
+func (s *UsageService) ExportUsage(ctx context.Context, w io.Writer, filters usagestats.UsageLogFilters, opts UsageExportOptions) error {
+	columns := make([]usageExportColumn, 0, len(usageExportColumns))
+	for _, col := range usageExportColumns {
+		if col.adminOnly && !opts.IncludeAccount {
+			continue
+		}
+		columns = append(columns, col)
+	}
+	flusher, _ := w.(interface{ Flush() })
+	flush := func() {
+		if flusher != nil {
+			flusher.Flush()
+		}
+	}
+
+	switch opts.Format {
+	case UsageExportFormatParquet:
+		pqColumns := make([]parquet.Column, len(columns))
+		for i, col := range columns {
+			pqColumns[i] = parquet.Column{Name: col.name, Type: col.typ}
+		}
+		pw := parquet.NewWriter(w, pqColumns, "sub2api")
+		err := s.usageRepo.ExportWithFilters(ctx, filters, usageExportBatchSize, func(logs []UsageLog) error {
+			rows := make([][]any, len(logs))
+			for i := range logs {
+				row := make([]any, len(columns))
+				for ci, col := range columns {
+					row[ci] = col.value(&logs[i])
+				}
+				rows[i] = row
+			}
+			if err := pw.WriteRowGroup(rows); err != nil {
+				return err
+			}
+			flush()
+			return nil
+		})
+		if err != nil {
+			return fmt.Errorf("export usage logs: %w", err)
+		}
+		return pw.Close()
+
+	case UsageExportFormatCSV:
+		cw := csv.NewWriter(w)
+		header := make([]string, len(columns))
+		for i, col := range columns {
+			header[i] = col.name
+		}
+		if err := cw.Write(header); err != nil {
+			return err
+		}
+		record := make([]string, len(columns))
+		err := s.usageRepo.ExportWithFilters(ctx, filters, usageExportBatchSize, func(logs []UsageLog) error {
+			for i := range logs {
+				for ci, col := range columns {
+					record[ci] = formatUsageExportValue(col.value(&logs[i]))
+				}
+				if err := cw.Write(record); err != nil {
+					return err
+				}
+			}
+			cw.Flush()
+			if err := cw.Error(); err != nil {
+				return err
+			}
+			flush()
+			return nil
+		})
+		if err != nil {
+			return fmt.Errorf("export usage logs: %w", err)
+		}
+		cw.Flush()
+		return cw.Error()
+
+	default:
+		return ErrUsageExportInvalidFormat
+	}
+}
+
+func formatUsageExportValue(v any) string {
+	switch val := v.(type) {
+	case int64:
+		return strconv.FormatInt(val, 10)
+	case int:
+		return strconv.Itoa(val)
+	case float64:
+		return strconv.FormatFloat(val, 'f', -1, 64)
+	case bool:
+		return strconv.FormatBool(val)
+	case time.Time:
+		return val.Format(time.RFC3339)
+	case string:
+		return val
+	default:
+		return fmt.Sprint(val)
+	}
+}
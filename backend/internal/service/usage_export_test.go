@@ -0,0 +1,112 @@
+//go:build unit
+
+package service
+
+import (
+	"bytes"
+	"context"
+	"encoding/csv"
+	"testing"
+	"time"
+
+	"github.com/Wei-Shaw/sub2api/internal/pkg/usagestats"
+	"github.com/stretchr/testify/require"
+)
+
+type usageExportRepoStub struct {
+	UsageLogRepository
+	batches [][]UsageLog
+}
+
+func (s *usageExportRepoStub) ExportWithFilters(ctx context.Context, filters usagestats.UsageLogFilters, batchSize int, fn func([]UsageLog) error) error {
+	for _, batch := range s.batches {
+		if err := fn(batch); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func TestNormalizeUsageExportFormat(t *testing.T) {
+	format, err := NormalizeUsageExportFormat("")
+	require.NoError(t, err)
+	require.Equal(t, UsageExportFormatCSV, format)
+
+	format, err = NormalizeUsageExportFormat(" Parquet ")
+	require.NoError(t, err)
+	require.Equal(t, UsageExportFormatParquet, format)
+
+	_, err = NormalizeUsageExportFormat("xlsx")
+	require.ErrorIs(t, err, ErrUsageExportInvalidFormat)
+}
+
+func TestValidateUsageExportRange(t *testing.T) {
+	start := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+	end := start.AddDate(0, 1, 0)
+	require.ErrorIs(t, ValidateUsageExportRange(usagestats.UsageLogFilters{StartTime: &start}), ErrUsageExportRangeRequired)
+	require.NoError(t, ValidateUsageExportRange(usagestats.UsageLogFilters{StartTime: &start, EndTime: &end}))
+
+	tooFar := start.AddDate(2, 0, 0)
+	require.ErrorIs(t, ValidateUsageExportRange(usagestats.UsageLogFilters{StartTime: &start, EndTime: &tooFar}), ErrUsageExportRangeTooLarge)
+}
+
+func TestUsageService_ExportUsageCSV(t *testing.T) {
+	groupID := int64(3)
+	duration := 1200
+	createdAt := time.Date(2026, 2, 1, 8, 0, 0, 0, time.UTC)
+	repo := &usageExportRepoStub{batches: [][]UsageLog{
+		{{ID: 1, UserID: 7, User: &User{Email: "a@example.com"}, APIKeyID: 9, AccountID: 5, Account: &Account{Name: "upstream-1"},
+			GroupID: &groupID, Model: "claude-sonnet-4", InputTokens: 100, OutputTokens: 20, ActualCost: 0.0125, RateMultiplier: 1, DurationMs: &duration, CreatedAt: createdAt}},
+		{{ID: 2, UserID: 7, Model: "gpt-5", Stream: true, CreatedAt: createdAt}},
+	}}
+	svc := &UsageService{usageRepo: repo}
+
+	var buf bytes.Buffer
+	require.NoError(t, svc.ExportUsage(context.Background(), &buf, usagestats.UsageLogFilters{}, UsageExportOptions{Format: UsageExportFormatCSV}))
+
+	records, err := csv.NewReader(&buf).ReadAll()
+	require.NoError(t, err)
+	require.Len(t, records, 3)
+
+	header := records[0]
+	col := func(name string) int {
+		for i, h := range header {
+			if h == name {
+				return i
+			}
+		}
+		return -1
+	}
+	// 普通用户导出不包含上游账号信息
+	require.Equal(t, -1, col("account_id"))
+	require.Equal(t, -1, col("account_name"))
+
+	require.Equal(t, "2026-02-01T08:00:00Z", records[1][col("created_at")])
+	require.Equal(t, "a@example.com", records[1][col("user_email")])
+	require.Equal(t, "3", records[1][col("group_id")])
+	require.Equal(t, "0.0125", records[1][col("actual_cost")])
+	require.Equal(t, "1200", records[1][col("duration_ms")])
+	require.Equal(t, "true", records[2][col("stream")])
+	require.Equal(t, "0", records[2][col("group_id")])
+}
+
+func TestUsageService_ExportUsageAdminColumnsAndParquet(t *testing.T) {
+	repo := &usageExportRepoStub{batches: [][]UsageLog{
+		{{ID: 1, AccountID: 5, Account: &Account{Name: "upstream-1"}, CreatedAt: time.Now()}},
+	}}
+	svc := &UsageService{usageRepo: repo}
+
+	var csvBuf bytes.Buffer
+	require.NoError(t, svc.ExportUsage(context.Background(), &csvBuf, usagestats.UsageLogFilters{}, UsageExportOptions{Format: UsageExportFormatCSV, IncludeAccount: true}))
+	records, err := csv.NewReader(&csvBuf).ReadAll()
+	require.NoError(t, err)
+	require.Contains(t, records[0], "account_name")
+	require.Contains(t, records[1], "upstream-1")
+
+	var pqBuf bytes.Buffer
+	require.NoError(t, svc.ExportUsage(context.Background(), &pqBuf, usagestats.UsageLogFilters{}, UsageExportOptions{Format: UsageExportFormatParquet, IncludeAccount: true}))
+	out := pqBuf.Bytes()
+	require.Equal(t, []byte("PAR1"), out[:4])
+	require.Equal(t, []byte("PAR1"), out[len(out)-4:])
+	require.True(t, bytes.Contains(out, []byte("upstream-1")))
+}
@@ -33,23 +33,36 @@ type UsageLog struct {
 	OutputCost        float64
 	CacheCreationCost float64
 	CacheReadCost     float64
-	TotalCost         float64
-	ActualCost        float64
-	RateMultiplier    float64
+	// ToolCost 内置工具费用（已计入 TotalCost）
+	ToolCost       float64
+	TotalCost      float64
+	ActualCost     float64
+	RateMultiplier float64
 	// AccountRateMultiplier 账号计费倍率快照（nil 表示历史数据，按 1.0 处理）
 	AccountRateMultiplier *float64
 
-	BillingType  int8
+	BillingType int8
+	// MeteringOnly 分组处于仅计量模式：费用已计算但未扣除余额/订阅额度
+	MeteringOnly bool
 	Stream       bool
 	DurationMs   *int
 	FirstTokenMs *int
 	UserAgent    *string
 	IPAddress    *string
 
+	// GatewayFingerprint 请求携带 seed 时回显的网关指纹（上游指纹+账号别名哈希+网关版本）
+	GatewayFingerprint *string
+
 	// 图片生成字段
 	ImageCount int
 	ImageSize  *string
 
+	// 内置工具调用次数（nil 表示无工具调用）
+	ToolUsage *ToolUsage
+
+	// FinishReason 上游返回的结束原因（不落库，仅用于用量回调）
+	FinishReason string
+
 	CreatedAt time.Time
 
 	User         *User
@@ -0,0 +1,288 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"os"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/Wei-Shaw/sub2api/internal/config"
+)
+
+const (
+	// usageRetryEnqueueTimeout 入队超时（与请求上下文无关，写库超时后请求上下文可能已耗尽）
+	usageRetryEnqueueTimeout = 3 * time.Second
+	// usageRetryOpTimeout 重放单条记录的数据库操作超时
+	usageRetryOpTimeout = 10 * time.Second
+	// usageRetryClaimIdle 其他实例领取后超过该时长未确认的记录会被重新领取（实例崩溃恢复）
+	usageRetryClaimIdle = 5 * time.Minute
+)
+
+// UsageRetryMessage 重试队列中的一条消息
+type UsageRetryMessage struct {
+	ID      string
+	Payload []byte
+}
+
+// UsageRetryQueue 用量记录持久化重试队列（Redis Stream，多实例共享，消费者组保证每条记录只被一个实例重放）
+type UsageRetryQueue interface {
+	// Enqueue 追加一条待重放记录
+	Enqueue(ctx context.Context, payload []byte) error
+	// Claim 领取最多 count 条记录：先取本消费者未确认的记录，再接管其他消费者超过 minIdle 未确认的记录，最后读取新记录
+	Claim(ctx context.Context, consumer string, count int, minIdle time.Duration) ([]UsageRetryMessage, error)
+	// Ack 确认并删除已处理的记录
+	Ack(ctx context.Context, ids ...string) error
+	// DeadLetter 将多次重放仍失败的记录移入死信队列，等待人工处理
+	DeadLetter(ctx context.Context, payload []byte) error
+}
+
+// PendingUsageRecord 写库失败、待重放的用量记录：只包含失败的操作，已成功的操作不会重复执行。
+// 重放为至少一次语义：用量日志按 (request_id, api_key_id) 幂等，扣费类操作在"写库超时但实际已提交"时可能重复。
+type PendingUsageRecord struct {
+	// UsageLog 非空表示用量日志尚未写入（不含关联对象）
+	UsageLog *UsageLog `json:"usage_log,omitempty"`
+
+	UserID           int64   `json:"user_id,omitempty"`
+	BalanceDeduction float64 `json:"balance_deduction,omitempty"`
+
+	SubscriptionID    int64   `json:"subscription_id,omitempty"`
+	SubscriptionUsage float64 `json:"subscription_usage,omitempty"`
+
+	APIKeyID    int64   `json:"api_key_id,omitempty"`
+	APIKeyQuota float64 `json:"api_key_quota,omitempty"`
+
+	Attempts  int       `json:"attempts"`
+	LastError string    `json:"last_error,omitempty"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// IsEmpty 是否没有待重放的操作
+func (r *PendingUsageRecord) IsEmpty() bool {
+	return r.UsageLog == nil && r.BalanceDeduction <= 0 && r.SubscriptionUsage <= 0 && r.APIKeyQuota <= 0
+}
+
+// SetUsageLog 记录写入失败的用量日志；关联对象（含 API Key 明文）不入队
+func (r *PendingUsageRecord) SetUsageLog(usageLog *UsageLog) {
+	cloned := *usageLog
+	cloned.User = nil
+	cloned.APIKey = nil
+	cloned.Account = nil
+	cloned.Group = nil
+	cloned.Subscription = nil
+	r.UsageLog = &cloned
+}
+
+// UsageRetryService 用量记录重试服务：RecordUsage 写库失败时入队，后台定期重放，避免丢失计费数据
+type UsageRetryService struct {
+	queue        UsageRetryQueue
+	usageLogRepo UsageLogRepository
+	userRepo     UserRepository
+	userSubRepo  UserSubscriptionRepository
+	quotaUpdater APIKeyQuotaUpdater
+
+	consumer    string
+	interval    time.Duration
+	batchSize   int
+	maxAttempts int
+
+	stopCh   chan struct{}
+	stopOnce sync.Once
+	wg       sync.WaitGroup
+}
+
+// NewUsageRetryService 创建用量重试服务；未启用或队列为空时返回 nil（nil 服务入队时仅记录日志）
+func NewUsageRetryService(
+	queue UsageRetryQueue,
+	usageLogRepo UsageLogRepository,
+	userRepo UserRepository,
+	userSubRepo UserSubscriptionRepository,
+	quotaUpdater APIKeyQuotaUpdater,
+	cfg *config.Config,
+) *UsageRetryService {
+	if queue == nil || cfg == nil || !cfg.UsageRetry.Enabled {
+		return nil
+	}
+	hostname, _ := os.Hostname()
+	return &UsageRetryService{
+		queue:        queue,
+		usageLogRepo: usageLogRepo,
+		userRepo:     userRepo,
+		userSubRepo:  userSubRepo,
+		quotaUpdater: quotaUpdater,
+		consumer:     hostname + "-" + strconv.Itoa(os.Getpid()),
+		interval:     cfg.UsageRetry.ReplayInterval,
+		batchSize:    cfg.UsageRetry.BatchSize,
+		maxAttempts:  cfg.UsageRetry.MaxAttempts,
+		stopCh:       make(chan struct{}),
+	}
+}
+
+// Enqueue 持久化待重放的用量记录；入队失败时输出完整记录到日志，便于人工补录
+func (s *UsageRetryService) Enqueue(record *PendingUsageRecord) {
+	if record == nil || record.IsEmpty() {
+		return
+	}
+	if record.CreatedAt.IsZero() {
+		record.CreatedAt = time.Now()
+	}
+	payload, err := json.Marshal(record)
+	if err != nil {
+		log.Printf("[UsageRetry] Marshal record failed: %v", err)
+		return
+	}
+	if s == nil {
+		log.Printf("[UsageRetry] Retry queue disabled, usage record lost: %s", payload)
+		return
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), usageRetryEnqueueTimeout)
+	defer cancel()
+	if err := s.queue.Enqueue(ctx, payload); err != nil {
+		log.Printf("[UsageRetry] Enqueue failed, usage record lost: err=%v record=%s", err, payload)
+	}
+}
+
+// Start 启动后台重放协程
+func (s *UsageRetryService) Start() {
+	if s == nil {
+		return
+	}
+	s.wg.Add(1)
+	go func() {
+		defer s.wg.Done()
+		ticker := time.NewTicker(s.interval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ticker.C:
+				s.ReplayOnce()
+			case <-s.stopCh:
+				return
+			}
+		}
+	}()
+}
+
+// Stop 停止后台重放（未确认的记录会在下次启动或被其他实例接管后继续重放）
+func (s *UsageRetryService) Stop() {
+	if s == nil {
+		return
+	}
+	s.stopOnce.Do(func() {
+		close(s.stopCh)
+	})
+	s.wg.Wait()
+}
+
+// ReplayOnce 领取并重放一批记录，返回成功重放的条数。
+// 遇到失败时结束本轮：数据库多半仍不可用，剩余记录留待下一轮。
+func (s *UsageRetryService) ReplayOnce() int {
+	if s == nil {
+		return 0
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), usageRetryOpTimeout)
+	messages, err := s.queue.Claim(ctx, s.consumer, s.batchSize, usageRetryClaimIdle)
+	cancel()
+	if err != nil {
+		log.Printf("[UsageRetry] Claim records failed: %v", err)
+		return 0
+	}
+
+	replayed := 0
+	for _, msg := range messages {
+		var record PendingUsageRecord
+		if err := json.Unmarshal(msg.Payload, &record); err != nil {
+			log.Printf("[UsageRetry] Invalid record %s moved to dead letter: %v", msg.ID, err)
+			s.finish(msg, msg.Payload, true)
+			continue
+		}
+
+		replayErr := s.replay(&record)
+		if replayErr == nil {
+			s.ack(msg.ID)
+			replayed++
+			continue
+		}
+
+		// 保留剩余未完成的操作，重新入队（或移入死信队列）后确认原记录
+		record.Attempts++
+		record.LastError = replayErr.Error()
+		payload, err := json.Marshal(&record)
+		if err != nil {
+			log.Printf("[UsageRetry] Marshal record %s failed: %v", msg.ID, err)
+			break
+		}
+		deadLetter := record.Attempts >= s.maxAttempts
+		if deadLetter {
+			log.Printf("[UsageRetry] Record %s failed %d times, moved to dead letter: %v", msg.ID, record.Attempts, replayErr)
+		}
+		s.finish(msg, payload, deadLetter)
+		if !deadLetter {
+			break
+		}
+	}
+	if replayed > 0 {
+		log.Printf("[UsageRetry] Replayed %d usage records", replayed)
+	}
+	return replayed
+}
+
+// replay 依次执行记录中的待完成操作，成功的操作从记录中清除
+func (s *UsageRetryService) replay(record *PendingUsageRecord) error {
+	ctx, cancel := context.WithTimeout(context.Background(), usageRetryOpTimeout)
+	defer cancel()
+
+	if record.UsageLog != nil {
+		if _, err := s.usageLogRepo.Create(ctx, record.UsageLog); err != nil {
+			return fmt.Errorf("create usage log: %w", err)
+		}
+		record.UsageLog = nil
+	}
+	if record.SubscriptionUsage > 0 {
+		if err := s.userSubRepo.IncrementUsage(ctx, record.SubscriptionID, record.SubscriptionUsage); err != nil {
+			return fmt.Errorf("increment subscription usage: %w", err)
+		}
+		record.SubscriptionUsage = 0
+	}
+	if record.BalanceDeduction > 0 {
+		if err := s.userRepo.DeductBalance(ctx, record.UserID, record.BalanceDeduction); err != nil {
+			return fmt.Errorf("deduct balance: %w", err)
+		}
+		record.BalanceDeduction = 0
+	}
+	if record.APIKeyQuota > 0 && s.quotaUpdater != nil {
+		if err := s.quotaUpdater.UpdateQuotaUsed(ctx, record.APIKeyID, record.APIKeyQuota); err != nil {
+			return fmt.Errorf("update api key quota: %w", err)
+		}
+		record.APIKeyQuota = 0
+	}
+	return nil
+}
+
+// finish 将记录重新入队或移入死信队列，成功后确认原消息；失败时不确认，由后续 Claim 重新领取
+func (s *UsageRetryService) finish(msg UsageRetryMessage, payload []byte, deadLetter bool) {
+	ctx, cancel := context.WithTimeout(context.Background(), usageRetryEnqueueTimeout)
+	defer cancel()
+	var err error
+	if deadLetter {
+		err = s.queue.DeadLetter(ctx, payload)
+	} else {
+		err = s.queue.Enqueue(ctx, payload)
+	}
+	if err != nil {
+		log.Printf("[UsageRetry] Requeue record %s failed: %v", msg.ID, err)
+		return
+	}
+	s.ack(msg.ID)
+}
+
+func (s *UsageRetryService) ack(id string) {
+	ctx, cancel := context.WithTimeout(context.Background(), usageRetryEnqueueTimeout)
+	defer cancel()
+	if err := s.queue.Ack(ctx, id); err != nil {
+		log.Printf("[UsageRetry] Ack record %s failed: %v", id, err)
+	}
+}
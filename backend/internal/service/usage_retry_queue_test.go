@@ -0,0 +1,181 @@
+//go:build unit
+
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/Wei-Shaw/sub2api/internal/config"
+	"github.com/stretchr/testify/require"
+)
+
+type usageRetryQueueStub struct {
+	nextID   int
+	messages []UsageRetryMessage
+	dead     [][]byte
+}
+
+func (q *usageRetryQueueStub) Enqueue(_ context.Context, payload []byte) error {
+	q.nextID++
+	q.messages = append(q.messages, UsageRetryMessage{ID: strconv.Itoa(q.nextID), Payload: payload})
+	return nil
+}
+
+func (q *usageRetryQueueStub) Claim(_ context.Context, _ string, count int, _ time.Duration) ([]UsageRetryMessage, error) {
+	if count > len(q.messages) {
+		count = len(q.messages)
+	}
+	return append([]UsageRetryMessage(nil), q.messages[:count]...), nil
+}
+
+func (q *usageRetryQueueStub) Ack(_ context.Context, ids ...string) error {
+	for _, id := range ids {
+		for i, msg := range q.messages {
+			if msg.ID == id {
+				q.messages = append(q.messages[:i], q.messages[i+1:]...)
+				break
+			}
+		}
+	}
+	return nil
+}
+
+func (q *usageRetryQueueStub) DeadLetter(_ context.Context, payload []byte) error {
+	q.dead = append(q.dead, payload)
+	return nil
+}
+
+type usageRetryLogRepoStub struct {
+	UsageLogRepository
+	created []*UsageLog
+	err     error
+}
+
+func (r *usageRetryLogRepoStub) Create(_ context.Context, usageLog *UsageLog) (bool, error) {
+	if r.err != nil {
+		return false, r.err
+	}
+	r.created = append(r.created, usageLog)
+	return true, nil
+}
+
+type usageRetryUserRepoStub struct {
+	UserRepository
+	deducted map[int64]float64
+	err      error
+}
+
+func (r *usageRetryUserRepoStub) DeductBalance(_ context.Context, id int64, amount float64) error {
+	if r.err != nil {
+		return r.err
+	}
+	r.deducted[id] += amount
+	return nil
+}
+
+func newUsageRetryServiceForTest(queue UsageRetryQueue, logRepo UsageLogRepository, userRepo UserRepository, maxAttempts int) *UsageRetryService {
+	cfg := &config.Config{UsageRetry: config.UsageRetryConfig{
+		Enabled:        true,
+		ReplayInterval: time.Minute,
+		BatchSize:      10,
+		MaxAttempts:    maxAttempts,
+	}}
+	return NewUsageRetryService(queue, logRepo, userRepo, nil, nil, cfg)
+}
+
+func TestPendingUsageRecord_SetUsageLogDropsRelations(t *testing.T) {
+	record := &PendingUsageRecord{}
+	require.True(t, record.IsEmpty())
+
+	usageLog := &UsageLog{RequestID: "req-1", APIKeyID: 7, APIKey: &APIKey{Key: "sk-secret"}, User: &User{ID: 1}}
+	record.SetUsageLog(usageLog)
+	require.False(t, record.IsEmpty())
+	require.Nil(t, record.UsageLog.APIKey)
+	require.Nil(t, record.UsageLog.User)
+	require.NotNil(t, usageLog.APIKey, "original usage log must not be modified")
+
+	payload, err := json.Marshal(record)
+	require.NoError(t, err)
+	require.NotContains(t, string(payload), "sk-secret")
+}
+
+func TestUsageRetryService_ReplaySuccess(t *testing.T) {
+	queue := &usageRetryQueueStub{}
+	logRepo := &usageRetryLogRepoStub{}
+	userRepo := &usageRetryUserRepoStub{deducted: map[int64]float64{}}
+	svc := newUsageRetryServiceForTest(queue, logRepo, userRepo, 3)
+
+	record := &PendingUsageRecord{UserID: 1, BalanceDeduction: 0.5}
+	record.SetUsageLog(&UsageLog{RequestID: "req-1", APIKeyID: 7, TotalCost: 0.5})
+	svc.Enqueue(record)
+	require.Len(t, queue.messages, 1)
+
+	require.Equal(t, 1, svc.ReplayOnce())
+	require.Empty(t, queue.messages)
+	require.Len(t, logRepo.created, 1)
+	require.Equal(t, "req-1", logRepo.created[0].RequestID)
+	require.Equal(t, 0.5, userRepo.deducted[1])
+}
+
+func TestUsageRetryService_PartialFailureKeepsRemainingOps(t *testing.T) {
+	queue := &usageRetryQueueStub{}
+	logRepo := &usageRetryLogRepoStub{}
+	userRepo := &usageRetryUserRepoStub{deducted: map[int64]float64{}, err: errors.New("db down")}
+	svc := newUsageRetryServiceForTest(queue, logRepo, userRepo, 3)
+
+	record := &PendingUsageRecord{UserID: 1, BalanceDeduction: 0.5}
+	record.SetUsageLog(&UsageLog{RequestID: "req-1", APIKeyID: 7})
+	svc.Enqueue(record)
+	svc.Enqueue(&PendingUsageRecord{UserID: 2, BalanceDeduction: 1})
+
+	require.Equal(t, 0, svc.ReplayOnce())
+	require.Len(t, logRepo.created, 1)
+	// 失败后结束本轮：第二条记录未处理，第一条以剩余操作重新入队
+	require.Len(t, queue.messages, 2)
+	var requeued PendingUsageRecord
+	require.NoError(t, json.Unmarshal(queue.messages[1].Payload, &requeued))
+	require.Nil(t, requeued.UsageLog)
+	require.Equal(t, 0.5, requeued.BalanceDeduction)
+	require.Equal(t, 1, requeued.Attempts)
+	require.Contains(t, requeued.LastError, "db down")
+
+	// 恢复后全部重放，用量日志不重复写入
+	userRepo.err = nil
+	require.Equal(t, 2, svc.ReplayOnce())
+	require.Empty(t, queue.messages)
+	require.Len(t, logRepo.created, 1)
+	require.Equal(t, 0.5, userRepo.deducted[1])
+	require.Equal(t, 1.0, userRepo.deducted[2])
+}
+
+func TestUsageRetryService_DeadLetterAfterMaxAttempts(t *testing.T) {
+	queue := &usageRetryQueueStub{}
+	logRepo := &usageRetryLogRepoStub{err: errors.New("constraint violation")}
+	svc := newUsageRetryServiceForTest(queue, logRepo, &usageRetryUserRepoStub{}, 2)
+
+	record := &PendingUsageRecord{}
+	record.SetUsageLog(&UsageLog{RequestID: "req-1"})
+	svc.Enqueue(record)
+
+	svc.ReplayOnce()
+	require.Len(t, queue.messages, 1)
+	require.Empty(t, queue.dead)
+
+	svc.ReplayOnce()
+	require.Empty(t, queue.messages)
+	require.Len(t, queue.dead, 1)
+}
+
+func TestUsageRetryService_DisabledIsNil(t *testing.T) {
+	svc := NewUsageRetryService(&usageRetryQueueStub{}, nil, nil, nil, nil, &config.Config{})
+	require.Nil(t, svc)
+	require.Equal(t, 0, svc.ReplayOnce())
+	svc.Enqueue(&PendingUsageRecord{UserID: 1, BalanceDeduction: 1})
+	svc.Start()
+	svc.Stop()
+}
@@ -50,6 +50,8 @@ type UsageStats struct {
 	TotalCost         float64 `json:"total_cost"`
 	TotalActualCost   float64 `json:"total_actual_cost"`
 	AverageDurationMs float64 `json:"average_duration_ms"`
+	// CacheSavings 缓存计费节省明细（仅按 API Key 统计时返回）
+	CacheSavings *CacheSavings `json:"cache_savings,omitempty"`
 }
 
 // UsageService 使用统计服务
@@ -58,15 +60,17 @@ type UsageService struct {
 	userRepo             UserRepository
 	entClient            *dbent.Client
 	authCacheInvalidator APIKeyAuthCacheInvalidator
+	billingService       *BillingService
 }
 
 // NewUsageService 创建使用统计服务实例
-func NewUsageService(usageRepo UsageLogRepository, userRepo UserRepository, entClient *dbent.Client, authCacheInvalidator APIKeyAuthCacheInvalidator) *UsageService {
+func NewUsageService(usageRepo UsageLogRepository, userRepo UserRepository, entClient *dbent.Client, authCacheInvalidator APIKeyAuthCacheInvalidator, billingService *BillingService) *UsageService {
 	return &UsageService{
 		usageRepo:            usageRepo,
 		userRepo:             userRepo,
 		entClient:            entClient,
 		authCacheInvalidator: authCacheInvalidator,
+		billingService:       billingService,
 	}
 }
 
@@ -208,7 +212,7 @@ func (s *UsageService) GetStatsByAPIKey(ctx context.Context, apiKeyID int64, sta
 		return nil, fmt.Errorf("get api key stats: %w", err)
 	}
 
-	return &UsageStats{
+	result := &UsageStats{
 		TotalRequests:     stats.TotalRequests,
 		TotalInputTokens:  stats.TotalInputTokens,
 		TotalOutputTokens: stats.TotalOutputTokens,
@@ -217,7 +221,16 @@ func (s *UsageService) GetStatsByAPIKey(ctx context.Context, apiKeyID int64, sta
 		TotalCost:         stats.TotalCost,
 		TotalActualCost:   stats.TotalActualCost,
 		AverageDurationMs: stats.AverageDurationMs,
-	}, nil
+	}
+
+	if s.billingService != nil {
+		cacheUsage, err := s.usageRepo.GetAPIKeyCacheUsageByModel(ctx, apiKeyID, startTime, endTime)
+		if err != nil {
+			return nil, fmt.Errorf("get api key cache usage: %w", err)
+		}
+		result.CacheSavings = s.billingService.CalculateCacheSavings(cacheUsage)
+	}
+	return result, nil
 }
 
 // GetStatsByAccount 获取账号的使用统计
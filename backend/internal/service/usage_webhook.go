@@ -0,0 +1,309 @@
+package service
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"net/url"
+	"strconv"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/Wei-Shaw/sub2api/internal/config"
+	"github.com/Wei-Shaw/sub2api/internal/domain"
+	infraerrors "github.com/Wei-Shaw/sub2api/internal/pkg/errors"
+	"github.com/Wei-Shaw/sub2api/internal/util/urlvalidator"
+)
+
+type UsageWebhook = domain.UsageWebhook
+
+var (
+	ErrUsageWebhookDisabled   = infraerrors.BadRequest("USAGE_WEBHOOK_DISABLED", "usage webhook is disabled")
+	ErrInvalidUsageWebhookURL = infraerrors.BadRequest("INVALID_USAGE_WEBHOOK_URL", "invalid usage webhook url")
+)
+
+// UsageWebhookEventCompleted 请求完成事件类型
+const UsageWebhookEventCompleted = "request.completed"
+
+// 回调请求头
+const (
+	UsageWebhookHeaderEvent     = "X-Sub2API-Event"
+	UsageWebhookHeaderTimestamp = "X-Sub2API-Timestamp"
+	// UsageWebhookHeaderSignature 值为 sha256=<hex>，对 "<timestamp>.<body>" 以密钥做 HMAC-SHA256
+	UsageWebhookHeaderSignature = "X-Sub2API-Signature"
+)
+
+// usageWebhookSecretPrefix 自动生成的签名密钥前缀
+const usageWebhookSecretPrefix = "whsec_"
+
+// usageWebhookDropLogInterval 丢弃回调时的日志输出间隔，避免队列满时刷屏
+const usageWebhookDropLogInterval = time.Minute
+
+// usageWebhookRetryBackoff 重试基础退避时长（按尝试次数线性递增）
+const usageWebhookRetryBackoff = time.Second
+
+// UsageWebhookEvent 用量回调负载：单个请求完成后的摘要
+type UsageWebhookEvent struct {
+	Event     string `json:"event"`
+	RequestID string `json:"request_id"`
+	APIKeyID  int64  `json:"api_key_id"`
+	Model     string `json:"model"`
+	Stream    bool   `json:"stream"`
+
+	InputTokens         int `json:"input_tokens"`
+	OutputTokens        int `json:"output_tokens"`
+	CacheCreationTokens int `json:"cache_creation_tokens"`
+	CacheReadTokens     int `json:"cache_read_tokens"`
+	TotalTokens         int `json:"total_tokens"`
+
+	// TotalCost 按标准价格计算的费用（USD），ActualCost 为倍率折算后的实际扣费
+	TotalCost  float64 `json:"total_cost"`
+	ActualCost float64 `json:"actual_cost"`
+
+	FinishReason string    `json:"finish_reason"`
+	DurationMs   *int      `json:"duration_ms"`
+	FirstTokenMs *int      `json:"first_token_ms"`
+	CreatedAt    time.Time `json:"created_at"`
+}
+
+// UsageWebhookEventFromUsageLog 由用量日志构建回调负载
+func UsageWebhookEventFromUsageLog(usageLog *UsageLog) UsageWebhookEvent {
+	return UsageWebhookEvent{
+		Event:               UsageWebhookEventCompleted,
+		RequestID:           usageLog.RequestID,
+		APIKeyID:            usageLog.APIKeyID,
+		Model:               usageLog.Model,
+		Stream:              usageLog.Stream,
+		InputTokens:         usageLog.InputTokens,
+		OutputTokens:        usageLog.OutputTokens,
+		CacheCreationTokens: usageLog.CacheCreationTokens,
+		CacheReadTokens:     usageLog.CacheReadTokens,
+		TotalTokens:         usageLog.TotalTokens(),
+		TotalCost:           usageLog.TotalCost,
+		ActualCost:          usageLog.ActualCost,
+		FinishReason:        usageLog.FinishReason,
+		DurationMs:          usageLog.DurationMs,
+		FirstTokenMs:        usageLog.FirstTokenMs,
+		CreatedAt:           usageLog.CreatedAt,
+	}
+}
+
+// SignUsageWebhook 计算回调签名：sha256=hex(HMAC-SHA256(secret, "<timestamp>.<body>"))
+func SignUsageWebhook(secret, timestamp string, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(timestamp))
+	mac.Write([]byte("."))
+	mac.Write(body)
+	return "sha256=" + hex.EncodeToString(mac.Sum(nil))
+}
+
+// generateUsageWebhookSecret 生成随机签名密钥
+func generateUsageWebhookSecret() (string, error) {
+	buf := make([]byte, 24)
+	if _, err := rand.Read(buf); err != nil {
+		return "", fmt.Errorf("generate webhook secret: %w", err)
+	}
+	return usageWebhookSecretPrefix + hex.EncodeToString(buf), nil
+}
+
+// buildUsageWebhook 校验回调地址并返回新的回调配置：地址为空表示关闭；
+// 首次启用或 rotate 时生成新的签名密钥，否则沿用现有密钥。
+func (s *APIKeyService) buildUsageWebhook(current UsageWebhook, rawURL string, rotate bool) (UsageWebhook, error) {
+	if rawURL == "" {
+		return UsageWebhook{}, nil
+	}
+	if s.cfg == nil || !s.cfg.UsageWebhook.Enabled {
+		return current, ErrUsageWebhookDisabled
+	}
+	normalized, err := validateUsageWebhookURL(rawURL, &s.cfg.UsageWebhook)
+	if err != nil {
+		return current, fmt.Errorf("%w: %v", ErrInvalidUsageWebhookURL, err)
+	}
+	webhook := UsageWebhook{URL: normalized, Secret: current.Secret}
+	if webhook.Secret == "" || rotate {
+		if webhook.Secret, err = generateUsageWebhookSecret(); err != nil {
+			return current, err
+		}
+	}
+	return webhook, nil
+}
+
+// validateUsageWebhookURL 校验回调地址；允许 http 时仅做格式校验，私网地址由投递时的解析 IP 校验拦截
+func validateUsageWebhookURL(raw string, cfg *config.UsageWebhookConfig) (string, error) {
+	if cfg.AllowInsecureHTTP {
+		return urlvalidator.ValidateURLFormat(raw, true)
+	}
+	return urlvalidator.ValidateHTTPSURL(raw, urlvalidator.ValidationOptions{
+		AllowPrivate: cfg.AllowPrivateHosts,
+	})
+}
+
+// UsageWebhookSender 投递单次回调请求，返回 HTTP 状态码
+type UsageWebhookSender interface {
+	Send(ctx context.Context, url string, header http.Header, body []byte) (int, error)
+}
+
+type usageWebhookJob struct {
+	url     string
+	secret  string
+	payload []byte
+}
+
+// UsageWebhookDispatcher 异步投递 API Key 用量回调。
+// 请求路径只做非阻塞入队，队列满时丢弃；投递失败按配置重试，最终失败仅记录日志（用量数据仍可从用量接口查询）。
+type UsageWebhookDispatcher struct {
+	sender     UsageWebhookSender
+	queue      chan usageWebhookJob
+	workers    int
+	timeout    time.Duration
+	maxRetries int
+
+	dropped     atomic.Int64
+	lastDropLog atomic.Int64
+
+	stopCh   chan struct{}
+	stopOnce sync.Once
+	wg       sync.WaitGroup
+}
+
+// NewUsageWebhookDispatcher 创建回调投递器；未启用或 sender 为空时返回 nil（nil 投递器的方法均为空操作）
+func NewUsageWebhookDispatcher(sender UsageWebhookSender, cfg *config.Config) *UsageWebhookDispatcher {
+	if sender == nil || cfg == nil || !cfg.UsageWebhook.Enabled {
+		return nil
+	}
+	whCfg := cfg.UsageWebhook
+	return &UsageWebhookDispatcher{
+		sender:     sender,
+		queue:      make(chan usageWebhookJob, whCfg.QueueSize),
+		workers:    whCfg.Workers,
+		timeout:    whCfg.Timeout,
+		maxRetries: whCfg.MaxRetries,
+		stopCh:     make(chan struct{}),
+	}
+}
+
+// Enqueue 非阻塞地提交用量日志对应的回调；Key 未配置回调时忽略，队列已满时丢弃
+func (d *UsageWebhookDispatcher) Enqueue(usageLog *UsageLog) {
+	if d == nil || usageLog == nil || usageLog.APIKey == nil || usageLog.APIKey.UsageWebhook.IsEmpty() {
+		return
+	}
+	payload, err := json.Marshal(UsageWebhookEventFromUsageLog(usageLog))
+	if err != nil {
+		log.Printf("[UsageWebhook] Marshal event failed: request_id=%s err=%v", usageLog.RequestID, err)
+		return
+	}
+	job := usageWebhookJob{
+		url:     usageLog.APIKey.UsageWebhook.URL,
+		secret:  usageLog.APIKey.UsageWebhook.Secret,
+		payload: payload,
+	}
+	select {
+	case d.queue <- job:
+	default:
+		dropped := d.dropped.Add(1)
+		now := time.Now().UnixNano()
+		last := d.lastDropLog.Load()
+		if now-last >= int64(usageWebhookDropLogInterval) && d.lastDropLog.CompareAndSwap(last, now) {
+			log.Printf("[UsageWebhook] Queue full, dropped %d callbacks so far", dropped)
+		}
+	}
+}
+
+// Start 启动投递协程
+func (d *UsageWebhookDispatcher) Start() {
+	if d == nil {
+		return
+	}
+	for i := 0; i < d.workers; i++ {
+		d.wg.Add(1)
+		go func() {
+			defer d.wg.Done()
+			d.run()
+		}()
+	}
+}
+
+// Stop 停止投递；队列中剩余的回调各尝试投递一次（不再重试）
+func (d *UsageWebhookDispatcher) Stop() {
+	if d == nil {
+		return
+	}
+	d.stopOnce.Do(func() {
+		close(d.stopCh)
+	})
+	d.wg.Wait()
+}
+
+func (d *UsageWebhookDispatcher) run() {
+	for {
+		select {
+		case job := <-d.queue:
+			d.deliver(job, d.maxRetries)
+		case <-d.stopCh:
+			for {
+				select {
+				case job := <-d.queue:
+					d.deliver(job, 0)
+				default:
+					return
+				}
+			}
+		}
+	}
+}
+
+// deliver 投递回调；网络错误或 5xx/429 时按线性退避重试，停止时中断等待
+func (d *UsageWebhookDispatcher) deliver(job usageWebhookJob, maxRetries int) {
+	var lastErr error
+	for attempt := 0; attempt <= maxRetries; attempt++ {
+		if attempt > 0 {
+			select {
+			case <-time.After(time.Duration(attempt) * usageWebhookRetryBackoff):
+			case <-d.stopCh:
+				maxRetries = attempt
+			}
+		}
+		retryable, err := d.send(job)
+		if err == nil {
+			return
+		}
+		lastErr = err
+		if !retryable {
+			break
+		}
+	}
+	host := job.url
+	if parsed, err := url.Parse(job.url); err == nil {
+		host = parsed.Host
+	}
+	log.Printf("[UsageWebhook] Deliver to %s failed: %v", host, lastErr)
+}
+
+func (d *UsageWebhookDispatcher) send(job usageWebhookJob) (bool, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), d.timeout)
+	defer cancel()
+
+	timestamp := strconv.FormatInt(time.Now().Unix(), 10)
+	header := http.Header{}
+	header.Set("Content-Type", "application/json")
+	header.Set(UsageWebhookHeaderEvent, UsageWebhookEventCompleted)
+	header.Set(UsageWebhookHeaderTimestamp, timestamp)
+	header.Set(UsageWebhookHeaderSignature, SignUsageWebhook(job.secret, timestamp, job.payload))
+
+	status, err := d.sender.Send(ctx, job.url, header, job.payload)
+	if err != nil {
+		return true, err
+	}
+	if status >= 200 && status < 300 {
+		return false, nil
+	}
+	retryable := status == http.StatusTooManyRequests || status >= 500
+	return retryable, fmt.Errorf("unexpected status %d", status)
+}
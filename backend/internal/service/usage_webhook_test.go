@@ -0,0 +1,183 @@
+//go:build unit
+
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/Wei-Shaw/sub2api/internal/config"
+	"github.com/stretchr/testify/require"
+)
+
+type usageWebhookDelivery struct {
+	url    string
+	header http.Header
+	body   []byte
+}
+
+type usageWebhookSenderStub struct {
+	mu         sync.Mutex
+	statuses   []int
+	deliveries []usageWebhookDelivery
+}
+
+func (s *usageWebhookSenderStub) Send(ctx context.Context, url string, header http.Header, body []byte) (int, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.deliveries = append(s.deliveries, usageWebhookDelivery{url: url, header: header, body: body})
+	if len(s.statuses) == 0 {
+		return http.StatusOK, nil
+	}
+	status := s.statuses[0]
+	s.statuses = s.statuses[1:]
+	return status, nil
+}
+
+func (s *usageWebhookSenderStub) snapshot() []usageWebhookDelivery {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return append([]usageWebhookDelivery(nil), s.deliveries...)
+}
+
+func newUsageWebhookTestConfig(queueSize, maxRetries int) *config.Config {
+	cfg := &config.Config{}
+	cfg.UsageWebhook = config.UsageWebhookConfig{
+		Enabled:    true,
+		Workers:    1,
+		QueueSize:  queueSize,
+		Timeout:    time.Second,
+		MaxRetries: maxRetries,
+	}
+	return cfg
+}
+
+func newUsageWebhookTestLog() *UsageLog {
+	durationMs := 1200
+	return &UsageLog{
+		APIKeyID:     7,
+		RequestID:    "req_1",
+		Model:        "claude-sonnet-4-5",
+		InputTokens:  100,
+		OutputTokens: 20,
+		TotalCost:    0.5,
+		ActualCost:   0.25,
+		FinishReason: "end_turn",
+		DurationMs:   &durationMs,
+		APIKey: &APIKey{
+			ID:           7,
+			UsageWebhook: UsageWebhook{URL: "https://hooks.example.com/usage", Secret: "whsec_test"},
+		},
+	}
+}
+
+func TestNewUsageWebhookDispatcher_DisabledReturnsNil(t *testing.T) {
+	dispatcher := NewUsageWebhookDispatcher(&usageWebhookSenderStub{}, &config.Config{})
+	require.Nil(t, dispatcher)
+
+	// nil 投递器的方法均为空操作
+	dispatcher.Start()
+	dispatcher.Enqueue(newUsageWebhookTestLog())
+	dispatcher.Stop()
+}
+
+func TestUsageWebhookDispatcher_DeliversSignedSummary(t *testing.T) {
+	sender := &usageWebhookSenderStub{}
+	dispatcher := NewUsageWebhookDispatcher(sender, newUsageWebhookTestConfig(10, 0))
+	dispatcher.Enqueue(newUsageWebhookTestLog())
+	dispatcher.Start()
+	dispatcher.Stop()
+
+	deliveries := sender.snapshot()
+	require.Len(t, deliveries, 1)
+	delivery := deliveries[0]
+	require.Equal(t, "https://hooks.example.com/usage", delivery.url)
+	require.Equal(t, UsageWebhookEventCompleted, delivery.header.Get(UsageWebhookHeaderEvent))
+
+	timestamp := delivery.header.Get(UsageWebhookHeaderTimestamp)
+	require.NotEmpty(t, timestamp)
+	require.Equal(t, SignUsageWebhook("whsec_test", timestamp, delivery.body), delivery.header.Get(UsageWebhookHeaderSignature))
+
+	var event UsageWebhookEvent
+	require.NoError(t, json.Unmarshal(delivery.body, &event))
+	require.Equal(t, "req_1", event.RequestID)
+	require.Equal(t, int64(7), event.APIKeyID)
+	require.Equal(t, 120, event.TotalTokens)
+	require.Equal(t, 0.25, event.ActualCost)
+	require.Equal(t, "end_turn", event.FinishReason)
+	require.NotNil(t, event.DurationMs)
+	require.Equal(t, 1200, *event.DurationMs)
+}
+
+func TestUsageWebhookDispatcher_SkipsKeysWithoutWebhook(t *testing.T) {
+	sender := &usageWebhookSenderStub{}
+	dispatcher := NewUsageWebhookDispatcher(sender, newUsageWebhookTestConfig(10, 0))
+
+	usageLog := newUsageWebhookTestLog()
+	usageLog.APIKey.UsageWebhook = UsageWebhook{}
+	dispatcher.Enqueue(usageLog)
+	dispatcher.Enqueue(&UsageLog{RequestID: "no_key"})
+	dispatcher.Start()
+	dispatcher.Stop()
+
+	require.Empty(t, sender.snapshot())
+}
+
+func TestUsageWebhookDispatcher_DropsWhenQueueFull(t *testing.T) {
+	sender := &usageWebhookSenderStub{}
+	dispatcher := NewUsageWebhookDispatcher(sender, newUsageWebhookTestConfig(1, 0))
+	dispatcher.Enqueue(newUsageWebhookTestLog())
+	dispatcher.Enqueue(newUsageWebhookTestLog())
+	require.Equal(t, int64(1), dispatcher.dropped.Load())
+
+	dispatcher.Start()
+	dispatcher.Stop()
+	require.Len(t, sender.snapshot(), 1)
+}
+
+func TestUsageWebhookDispatcher_RetriesOnlyRetryableStatus(t *testing.T) {
+	sender := &usageWebhookSenderStub{statuses: []int{http.StatusServiceUnavailable, http.StatusOK}}
+	dispatcher := NewUsageWebhookDispatcher(sender, newUsageWebhookTestConfig(10, 2))
+	dispatcher.deliver(usageWebhookJob{url: "https://hooks.example.com/usage", secret: "s", payload: []byte(`{}`)}, 2)
+	require.Len(t, sender.snapshot(), 2)
+
+	sender = &usageWebhookSenderStub{statuses: []int{http.StatusBadRequest}}
+	dispatcher = NewUsageWebhookDispatcher(sender, newUsageWebhookTestConfig(10, 2))
+	dispatcher.deliver(usageWebhookJob{url: "https://hooks.example.com/usage", secret: "s", payload: []byte(`{}`)}, 2)
+	require.Len(t, sender.snapshot(), 1)
+}
+
+func TestAPIKeyService_BuildUsageWebhook(t *testing.T) {
+	svc := &APIKeyService{cfg: newUsageWebhookTestConfig(10, 0)}
+
+	webhook, err := svc.buildUsageWebhook(UsageWebhook{}, "https://hooks.example.com/usage/", false)
+	require.NoError(t, err)
+	require.Equal(t, "https://hooks.example.com/usage", webhook.URL)
+	require.True(t, strings.HasPrefix(webhook.Secret, usageWebhookSecretPrefix))
+
+	// 修改地址时沿用原密钥，rotate 时重新生成
+	updated, err := svc.buildUsageWebhook(webhook, "https://hooks.example.com/v2", false)
+	require.NoError(t, err)
+	require.Equal(t, webhook.Secret, updated.Secret)
+	rotated, err := svc.buildUsageWebhook(webhook, webhook.URL, true)
+	require.NoError(t, err)
+	require.NotEqual(t, webhook.Secret, rotated.Secret)
+
+	cleared, err := svc.buildUsageWebhook(webhook, "", false)
+	require.NoError(t, err)
+	require.True(t, cleared.IsEmpty())
+
+	_, err = svc.buildUsageWebhook(UsageWebhook{}, "http://hooks.example.com/usage", false)
+	require.ErrorIs(t, err, ErrInvalidUsageWebhookURL)
+	_, err = svc.buildUsageWebhook(UsageWebhook{}, "https://127.0.0.1/usage", false)
+	require.ErrorIs(t, err, ErrInvalidUsageWebhookURL)
+
+	disabled := &APIKeyService{cfg: &config.Config{}}
+	_, err = disabled.buildUsageWebhook(UsageWebhook{}, "https://hooks.example.com/usage", false)
+	require.ErrorIs(t, err, ErrUsageWebhookDisabled)
+}
@@ -38,6 +38,11 @@ func (u *User) IsAdmin() bool {
 	return u.Role == RoleAdmin
 }
 
+// HasAdminAccess 是否可登录管理后台（超级管理员或受限管理角色）
+func (u *User) HasAdminAccess() bool {
+	return IsAdminRole(u.Role)
+}
+
 func (u *User) IsActive() bool {
 	return u.Status == StatusActive
 }
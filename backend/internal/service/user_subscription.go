@@ -110,10 +110,15 @@ func (s *UserSubscription) CheckWeeklyLimit(group *Group, additionalCost float64
 }
 
 func (s *UserSubscription) CheckMonthlyLimit(group *Group, additionalCost float64) bool {
+	return s.CheckMonthlyLimitWithRollover(group, additionalCost, 0)
+}
+
+// CheckMonthlyLimitWithRollover 检查月限额，rollover 为当前有效的上期结转额度
+func (s *UserSubscription) CheckMonthlyLimitWithRollover(group *Group, additionalCost, rollover float64) bool {
 	if !group.HasMonthlyLimit() {
 		return true
 	}
-	return s.MonthlyUsageUSD+additionalCost <= *group.MonthlyLimitUSD
+	return s.MonthlyUsageUSD+additionalCost <= *group.MonthlyLimitUSD+rollover
 }
 
 func (s *UserSubscription) CheckAllLimits(group *Group, additionalCost float64) (daily, weekly, monthly bool) {
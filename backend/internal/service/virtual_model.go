@@ -0,0 +1,343 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/Wei-Shaw/sub2api/internal/pkg/ctxkey"
+)
+
+// ErrAccountNotInVirtualModelTarget 账号不在虚拟模型当前目标限定的账号范围内
+var ErrAccountNotInVirtualModelTarget = errors.New("account not in virtual model target")
+
+// maxVirtualModels 虚拟模型数量上限
+const maxVirtualModels = 100
+
+// maxVirtualModelTargets 单个虚拟模型的回退链长度上限
+const maxVirtualModelTargets = 10
+
+// virtualModelCacheTTL 虚拟模型配置本地缓存有效期（管理端修改后最多延迟该时长生效）
+const virtualModelCacheTTL = 15 * time.Second
+
+// VirtualModelTarget 虚拟模型回退链中的一个候选：真实模型 + 可选的账号范围
+type VirtualModelTarget struct {
+	// Model 实际用于账号选择与上游转发的模型名
+	Model string `json:"model"`
+	// AccountIDs 限定使用的账号，为空表示分组内所有支持该模型的账号
+	AccountIDs []int64 `json:"account_ids,omitempty"`
+}
+
+// VirtualModel 虚拟模型：客户端请求的模型名展开为按顺序尝试的真实模型/账号组合
+type VirtualModel struct {
+	// Name 客户端请求的虚拟模型名（如 smart）
+	Name string `json:"name"`
+	// Platform 限定生效平台（anthropic/openai/gemini/antigravity），为空表示所有平台
+	Platform string `json:"platform,omitempty"`
+	// Targets 回退链，按顺序尝试
+	Targets []VirtualModelTarget `json:"targets"`
+}
+
+// VirtualModelSettings 虚拟模型配置
+type VirtualModelSettings struct {
+	// Enabled 是否启用虚拟模型
+	Enabled bool `json:"enabled"`
+	// Models 虚拟模型列表
+	Models []VirtualModel `json:"models"`
+}
+
+// DefaultVirtualModelSettings 返回默认虚拟模型配置（关闭、无虚拟模型）
+func DefaultVirtualModelSettings() *VirtualModelSettings {
+	return &VirtualModelSettings{Models: []VirtualModel{}}
+}
+
+// normalizeVirtualModelSettings 清理并校验虚拟模型：去除空白、统一平台小写、去除重复账号，拒绝空链与重名
+func normalizeVirtualModelSettings(settings *VirtualModelSettings) error {
+	if len(settings.Models) > maxVirtualModels {
+		return fmt.Errorf("too many virtual models (max %d)", maxVirtualModels)
+	}
+	seen := make(map[string]struct{}, len(settings.Models))
+	models := make([]VirtualModel, 0, len(settings.Models))
+	for i, vm := range settings.Models {
+		vm.Name = strings.TrimSpace(vm.Name)
+		vm.Platform = strings.ToLower(strings.TrimSpace(vm.Platform))
+		if vm.Name == "" {
+			return fmt.Errorf("virtual model %d: name is required", i+1)
+		}
+		if strings.Contains(vm.Name, "*") {
+			return fmt.Errorf("virtual model %d: name must not contain wildcard", i+1)
+		}
+		switch vm.Platform {
+		case "", PlatformAnthropic, PlatformOpenAI, PlatformGemini, PlatformAntigravity:
+		default:
+			return fmt.Errorf("virtual model %d: unsupported platform %q", i+1, vm.Platform)
+		}
+		if len(vm.Targets) == 0 {
+			return fmt.Errorf("virtual model %d: at least one target is required", i+1)
+		}
+		if len(vm.Targets) > maxVirtualModelTargets {
+			return fmt.Errorf("virtual model %d: too many targets (max %d)", i+1, maxVirtualModelTargets)
+		}
+		targets := make([]VirtualModelTarget, 0, len(vm.Targets))
+		for j, target := range vm.Targets {
+			target.Model = strings.TrimSpace(target.Model)
+			if target.Model == "" {
+				return fmt.Errorf("virtual model %d target %d: model is required", i+1, j+1)
+			}
+			if target.Model == vm.Name {
+				return fmt.Errorf("virtual model %d target %d: model must differ from virtual model name", i+1, j+1)
+			}
+			target.AccountIDs = normalizeVirtualModelAccountIDs(target.AccountIDs)
+			targets = append(targets, target)
+		}
+		vm.Targets = targets
+
+		key := vm.Platform + "|" + vm.Name
+		if _, ok := seen[key]; ok {
+			return fmt.Errorf("virtual model %d: duplicate name %q", i+1, vm.Name)
+		}
+		seen[key] = struct{}{}
+		models = append(models, vm)
+	}
+	settings.Models = models
+	return nil
+}
+
+func normalizeVirtualModelAccountIDs(ids []int64) []int64 {
+	if len(ids) == 0 {
+		return nil
+	}
+	seen := make(map[int64]struct{}, len(ids))
+	result := make([]int64, 0, len(ids))
+	for _, id := range ids {
+		if id <= 0 {
+			continue
+		}
+		if _, ok := seen[id]; ok {
+			continue
+		}
+		seen[id] = struct{}{}
+		result = append(result, id)
+	}
+	if len(result) == 0 {
+		return nil
+	}
+	return result
+}
+
+// GetVirtualModelSettings 获取虚拟模型配置
+func (s *SettingService) GetVirtualModelSettings(ctx context.Context) (*VirtualModelSettings, error) {
+	value, err := s.settingRepo.GetValue(ctx, SettingKeyVirtualModelSettings)
+	if err != nil {
+		if errors.Is(err, ErrSettingNotFound) {
+			return DefaultVirtualModelSettings(), nil
+		}
+		return nil, fmt.Errorf("get virtual model settings: %w", err)
+	}
+	if value == "" {
+		return DefaultVirtualModelSettings(), nil
+	}
+
+	var settings VirtualModelSettings
+	if err := json.Unmarshal([]byte(value), &settings); err != nil {
+		return DefaultVirtualModelSettings(), nil
+	}
+	if settings.Models == nil {
+		settings.Models = []VirtualModel{}
+	}
+	return &settings, nil
+}
+
+// SetVirtualModelSettings 设置虚拟模型配置
+func (s *SettingService) SetVirtualModelSettings(ctx context.Context, settings *VirtualModelSettings) error {
+	if settings == nil {
+		return fmt.Errorf("settings cannot be nil")
+	}
+	if err := normalizeVirtualModelSettings(settings); err != nil {
+		return err
+	}
+
+	data, err := json.Marshal(settings)
+	if err != nil {
+		return fmt.Errorf("marshal virtual model settings: %w", err)
+	}
+	return s.settingRepo.Set(ctx, SettingKeyVirtualModelSettings, string(data))
+}
+
+// VirtualModelService 将客户端请求的虚拟模型名展开为按顺序尝试的真实模型/账号回退链
+type VirtualModelService struct {
+	settingService *SettingService
+
+	mu        sync.RWMutex
+	cached    *VirtualModelSettings
+	expiresAt time.Time
+}
+
+// NewVirtualModelService 创建虚拟模型服务
+func NewVirtualModelService(settingService *SettingService) *VirtualModelService {
+	return &VirtualModelService{settingService: settingService}
+}
+
+// Resolve 按平台查找虚拟模型（精确匹配，平台限定优先于通用配置），未命中时返回 nil
+func (s *VirtualModelService) Resolve(ctx context.Context, platform, model string) *VirtualModel {
+	if s == nil || model == "" {
+		return nil
+	}
+	settings := s.load(ctx)
+	if settings == nil || !settings.Enabled {
+		return nil
+	}
+	return resolveVirtualModel(settings.Models, platform, model)
+}
+
+// Invalidate 清除本地缓存，下次 Resolve 时重新加载
+func (s *VirtualModelService) Invalidate() {
+	if s == nil {
+		return
+	}
+	s.mu.Lock()
+	s.cached = nil
+	s.expiresAt = time.Time{}
+	s.mu.Unlock()
+}
+
+func (s *VirtualModelService) load(ctx context.Context) *VirtualModelSettings {
+	now := time.Now()
+	s.mu.RLock()
+	if s.cached != nil && now.Before(s.expiresAt) {
+		cached := s.cached
+		s.mu.RUnlock()
+		return cached
+	}
+	stale := s.cached
+	s.mu.RUnlock()
+
+	if s.settingService == nil {
+		return nil
+	}
+	settings, err := s.settingService.GetVirtualModelSettings(ctx)
+	if err != nil {
+		log.Printf("[VirtualModel] Failed to load settings: %v", err)
+		// 读取失败时沿用旧缓存，避免数据库抖动导致虚拟模型短暂失效
+		return stale
+	}
+	s.mu.Lock()
+	s.cached = settings
+	s.expiresAt = now.Add(virtualModelCacheTTL)
+	s.mu.Unlock()
+	return settings
+}
+
+func resolveVirtualModel(models []VirtualModel, platform, model string) *VirtualModel {
+	platform = strings.ToLower(platform)
+	var generic *VirtualModel
+	for i := range models {
+		vm := &models[i]
+		if vm.Name != model {
+			continue
+		}
+		if vm.Platform == platform {
+			return vm
+		}
+		if vm.Platform == "" && generic == nil {
+			generic = vm
+		}
+	}
+	return generic
+}
+
+// VirtualModelChain 单次请求内虚拟模型回退链的迭代状态
+type VirtualModelChain struct {
+	name    string
+	targets []VirtualModelTarget
+	index   int
+}
+
+// NewVirtualModelChain 创建回退链，跳过分组模型访问策略或 API Key 模型限制不允许的目标；无可用目标时返回 ModelAccessDeniedError
+func NewVirtualModelChain(vm *VirtualModel, apiKey *APIKey) (*VirtualModelChain, error) {
+	if vm == nil {
+		return nil, nil
+	}
+	targets := make([]VirtualModelTarget, 0, len(vm.Targets))
+	for _, target := range vm.Targets {
+		if CheckAPIKeyModelAccess(apiKey, target.Model) != nil {
+			continue
+		}
+		targets = append(targets, target)
+	}
+	if len(targets) == 0 {
+		return nil, &ModelAccessDeniedError{Model: vm.Name}
+	}
+	return &VirtualModelChain{name: vm.Name, targets: targets}, nil
+}
+
+// Name 返回客户端请求的虚拟模型名
+func (c *VirtualModelChain) Name() string {
+	return c.name
+}
+
+// Current 返回当前尝试的目标
+func (c *VirtualModelChain) Current() VirtualModelTarget {
+	return c.targets[c.index]
+}
+
+// Next 切换到下一个目标，链已耗尽时返回 false
+func (c *VirtualModelChain) Next() bool {
+	if c == nil || c.index+1 >= len(c.targets) {
+		return false
+	}
+	c.index++
+	return true
+}
+
+// WithVirtualModelTarget 将当前目标的账号范围写入 context，供账号调度使用（包括粘性会话校验）
+func WithVirtualModelTarget(ctx context.Context, target VirtualModelTarget) context.Context {
+	return context.WithValue(ctx, ctxkey.VirtualModelAccountIDs, target.AccountIDs)
+}
+
+func virtualModelAccountIDsFromContext(ctx context.Context) ([]int64, bool) {
+	if ctx == nil {
+		return nil, false
+	}
+	ids, ok := ctx.Value(ctxkey.VirtualModelAccountIDs).([]int64)
+	if !ok || len(ids) == 0 {
+		return nil, false
+	}
+	return ids, true
+}
+
+// accountAllowedByVirtualModel 检查账号是否在当前虚拟模型目标的账号范围内
+func accountAllowedByVirtualModel(ctx context.Context, account *Account) bool {
+	if account == nil {
+		return false
+	}
+	ids, ok := virtualModelAccountIDsFromContext(ctx)
+	if !ok {
+		return true
+	}
+	for _, id := range ids {
+		if id == account.ID {
+			return true
+		}
+	}
+	return false
+}
+
+// filterAccountsByVirtualModel 按当前虚拟模型目标的账号范围过滤候选账号
+func filterAccountsByVirtualModel(ctx context.Context, accounts []Account) []Account {
+	if _, ok := virtualModelAccountIDsFromContext(ctx); !ok || len(accounts) == 0 {
+		return accounts
+	}
+	filtered := make([]Account, 0, len(accounts))
+	for i := range accounts {
+		if accountAllowedByVirtualModel(ctx, &accounts[i]) {
+			filtered = append(filtered, accounts[i])
+		}
+	}
+	return filtered
+}
@@ -0,0 +1,94 @@
+//go:build unit
+
+package service
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestNormalizeVirtualModelSettings(t *testing.T) {
+	settings := &VirtualModelSettings{Models: []VirtualModel{{
+		Name:     " smart ",
+		Platform: " Anthropic ",
+		Targets: []VirtualModelTarget{
+			{Model: " claude-opus-4 ", AccountIDs: []int64{3, 3, 0, 5}},
+			{Model: "claude-sonnet-4"},
+		},
+	}}}
+	require.NoError(t, normalizeVirtualModelSettings(settings))
+	vm := settings.Models[0]
+	require.Equal(t, "smart", vm.Name)
+	require.Equal(t, PlatformAnthropic, vm.Platform)
+	require.Equal(t, "claude-opus-4", vm.Targets[0].Model)
+	require.Equal(t, []int64{3, 5}, vm.Targets[0].AccountIDs)
+	require.Nil(t, vm.Targets[1].AccountIDs)
+
+	require.Error(t, normalizeVirtualModelSettings(&VirtualModelSettings{Models: []VirtualModel{{Name: "smart"}}}))
+	require.Error(t, normalizeVirtualModelSettings(&VirtualModelSettings{Models: []VirtualModel{{Name: "smart", Targets: []VirtualModelTarget{{Model: "smart"}}}}}))
+	require.Error(t, normalizeVirtualModelSettings(&VirtualModelSettings{Models: []VirtualModel{
+		{Name: "smart", Targets: []VirtualModelTarget{{Model: "a"}}},
+		{Name: "smart", Targets: []VirtualModelTarget{{Model: "b"}}},
+	}}))
+}
+
+func TestResolveVirtualModel(t *testing.T) {
+	models := []VirtualModel{
+		{Name: "smart", Targets: []VirtualModelTarget{{Model: "generic"}}},
+		{Name: "smart", Platform: PlatformOpenAI, Targets: []VirtualModelTarget{{Model: "gpt-5"}}},
+	}
+	require.Equal(t, "gpt-5", resolveVirtualModel(models, "OpenAI", "smart").Targets[0].Model)
+	require.Equal(t, "generic", resolveVirtualModel(models, PlatformAnthropic, "smart").Targets[0].Model)
+	require.Nil(t, resolveVirtualModel(models, PlatformAnthropic, "fast"))
+}
+
+func TestVirtualModelChain(t *testing.T) {
+	vm := &VirtualModel{Name: "smart", Targets: []VirtualModelTarget{
+		{Model: "claude-opus-4", AccountIDs: []int64{1}},
+		{Model: "claude-haiku-4"},
+		{Model: "claude-sonnet-4"},
+	}}
+	apiKey := &APIKey{Group: &Group{ModelAccessPolicy: ModelAccessPolicy{Denied: []string{"claude-haiku*"}}}}
+
+	chain, err := NewVirtualModelChain(vm, apiKey)
+	require.NoError(t, err)
+	require.Equal(t, "smart", chain.Name())
+	require.Equal(t, "claude-opus-4", chain.Current().Model)
+	require.True(t, chain.Next())
+	// 分组禁止的目标被跳过
+	require.Equal(t, "claude-sonnet-4", chain.Current().Model)
+	require.False(t, chain.Next())
+
+	// API Key 自身的模型限制同样生效
+	apiKey.AllowedModels = []string{"claude-sonnet-*"}
+	chain, err = NewVirtualModelChain(vm, apiKey)
+	require.NoError(t, err)
+	require.Equal(t, "claude-sonnet-4", chain.Current().Model)
+	require.False(t, chain.Next())
+
+	_, err = NewVirtualModelChain(vm, &APIKey{Group: &Group{ModelAccessPolicy: ModelAccessPolicy{Allowed: []string{"gpt-*"}}}})
+	var deniedErr *ModelAccessDeniedError
+	require.True(t, errors.As(err, &deniedErr))
+	require.Equal(t, "smart", deniedErr.Model)
+}
+
+func TestFilterAccountsByVirtualModel(t *testing.T) {
+	accounts := []Account{{ID: 1}, {ID: 2}, {ID: 3}}
+	ctx := context.Background()
+	require.Len(t, filterAccountsByVirtualModel(ctx, accounts), 3)
+	require.True(t, accountAllowedByVirtualModel(ctx, &accounts[1]))
+
+	ctx = WithVirtualModelTarget(ctx, VirtualModelTarget{Model: "m", AccountIDs: []int64{3, 1}})
+	filtered := filterAccountsByVirtualModel(ctx, accounts)
+	require.Len(t, filtered, 2)
+	require.Equal(t, int64(1), filtered[0].ID)
+	require.Equal(t, int64(3), filtered[1].ID)
+	require.False(t, accountAllowedByVirtualModel(ctx, &accounts[1]))
+
+	// 切换到不限账号的目标后解除限制
+	ctx = WithVirtualModelTarget(ctx, VirtualModelTarget{Model: "n"})
+	require.Len(t, filterAccountsByVirtualModel(ctx, accounts), 3)
+}
@@ -73,6 +73,20 @@ func ProvideAccountExpiryService(accountRepo AccountRepository) *AccountExpirySe
 	return svc
 }
 
+// ProvideAccountCanaryService creates and starts AccountCanaryService.
+func ProvideAccountCanaryService(accountRepo AccountRepository, usageLogRepo UsageLogRepository, opsRepo OpsRepository) *AccountCanaryService {
+	svc := NewAccountCanaryService(accountRepo, usageLogRepo, opsRepo, time.Minute)
+	svc.Start()
+	return svc
+}
+
+// ProvideAccountModelDiscoveryService creates AccountModelDiscoveryService and starts it when enabled.
+func ProvideAccountModelDiscoveryService(accountRepo AccountRepository, httpUpstream HTTPUpstream, metadataCache UpstreamMetadataCache, cfg *config.Config) *AccountModelDiscoveryService {
+	svc := NewAccountModelDiscoveryService(accountRepo, httpUpstream, metadataCache, cfg)
+	svc.Start()
+	return svc
+}
+
 // ProvideSubscriptionExpiryService creates and starts SubscriptionExpiryService.
 func ProvideSubscriptionExpiryService(userSubRepo UserSubscriptionRepository) *SubscriptionExpiryService {
 	svc := NewSubscriptionExpiryService(userSubRepo, time.Minute)
@@ -97,10 +111,14 @@ func ProvideDeferredService(accountRepo AccountRepository, timingWheel *TimingWh
 	return svc
 }
 
-// ProvideConcurrencyService creates ConcurrencyService and starts slot cleanup worker.
+// ProvideConcurrencyService creates ConcurrencyService, reconciles leftover Redis state and starts slot cleanup worker.
 func ProvideConcurrencyService(cache ConcurrencyCache, accountRepo AccountRepository, cfg *config.Config) *ConcurrencyService {
 	svc := NewConcurrencyService(cache)
 	if cfg != nil {
+		if cfg.Concurrency.ReconcileOnStartup {
+			svc.EnableSlotOwnership(cfg.Concurrency.InstanceID)
+			go svc.ReconcileStartupState(context.Background())
+		}
 		svc.StartSlotCleanupWorker(accountRepo, cfg.Gateway.Scheduling.SlotCleanupInterval)
 	}
 	return svc
@@ -112,9 +130,11 @@ func ProvideSchedulerSnapshotService(
 	outboxRepo SchedulerOutboxRepository,
 	accountRepo AccountRepository,
 	groupRepo GroupRepository,
+	sessionCleanup *AccountSessionCleanupService,
 	cfg *config.Config,
 ) *SchedulerSnapshotService {
 	svc := NewSchedulerSnapshotService(cache, outboxRepo, accountRepo, groupRepo, cfg)
+	svc.SetAccountSessionCleanup(sessionCleanup)
 	svc.Start()
 	return svc
 }
@@ -203,6 +223,88 @@ func ProvideOpsScheduledReportService(
 	return svc
 }
 
+// ProvideOpsRequestPhaseService creates and starts OpsRequestPhaseService and attaches it to OpsService.
+func ProvideOpsRequestPhaseService(opsRepo OpsRepository, opsService *OpsService) *OpsRequestPhaseService {
+	svc := NewOpsRequestPhaseService(opsRepo)
+	opsService.SetRequestPhaseService(svc)
+	svc.Start()
+	return svc
+}
+
+// ProvideOpsEventExporter creates and starts the ops event exporters: the ClickHouse sink plus any
+// enabled log shipping targets (nil when all of them are disabled).
+func ProvideOpsEventExporter(writer OpsEventWriter, shippers OpsLogShippers, cfg *config.Config) *OpsEventExporterGroup {
+	exporters := []*OpsEventExporter{NewOpsEventExporter(writer, cfg)}
+	if cfg != nil {
+		for _, shipper := range shippers {
+			exporters = append(exporters, NewOpsLogShippingExporter(shipper.Name, shipper.Writer, cfg.Ops.LogShipping))
+		}
+	}
+	group := NewOpsEventExporterGroup(exporters...)
+	group.Start()
+	return group
+}
+
+// ProvideUsageWebhookDispatcher creates and starts UsageWebhookDispatcher (nil when usage webhooks are disabled).
+func ProvideUsageWebhookDispatcher(sender UsageWebhookSender, cfg *config.Config) *UsageWebhookDispatcher {
+	dispatcher := NewUsageWebhookDispatcher(sender, cfg)
+	dispatcher.Start()
+	return dispatcher
+}
+
+// ProvideAuditLogService creates and starts AuditLogService (nil when audit logging is disabled).
+func ProvideAuditLogService(repo AuditLogRepository, cfg *config.Config) (*AuditLogService, error) {
+	svc, err := NewAuditLogService(repo, cfg)
+	if err != nil {
+		return nil, err
+	}
+	svc.Start()
+	return svc, nil
+}
+
+// ProvideTrashService creates TrashService and starts the retention purge loop.
+func ProvideTrashService(repo TrashRepository, authCacheInvalidator APIKeyAuthCacheInvalidator, cfg *config.Config) *TrashService {
+	svc := NewTrashService(repo, authCacheInvalidator, cfg)
+	svc.Start()
+	return svc
+}
+
+// ProvideStripeBillingService creates StripeBillingService and starts metered usage reporting when configured.
+func ProvideStripeBillingService(
+	cfg *config.Config,
+	repo StripeRepository,
+	client StripeClient,
+	subscriptionService *SubscriptionService,
+	userRepo UserRepository,
+	usageRepo UsageLogRepository,
+	authCacheInvalidator APIKeyAuthCacheInvalidator,
+) *StripeBillingService {
+	svc := NewStripeBillingService(cfg, repo, client, subscriptionService, userRepo, usageRepo, authCacheInvalidator)
+	svc.Start()
+	return svc
+}
+
+// ProvideBudgetAlertService creates and starts BudgetAlertService.
+func ProvideBudgetAlertService(settingService *SettingService, cache BudgetAlertCache, sender UsageWebhookSender, emailService *EmailService) *BudgetAlertService {
+	svc := NewBudgetAlertService(settingService, cache, sender, emailService)
+	svc.Start()
+	return svc
+}
+
+// ProvideUsageRetryService creates UsageRetryService and starts background replay (nil when the retry queue is disabled).
+func ProvideUsageRetryService(
+	queue UsageRetryQueue,
+	usageLogRepo UsageLogRepository,
+	userRepo UserRepository,
+	userSubRepo UserSubscriptionRepository,
+	apiKeyService *APIKeyService,
+	cfg *config.Config,
+) *UsageRetryService {
+	svc := NewUsageRetryService(queue, usageLogRepo, userRepo, userSubRepo, apiKeyService, cfg)
+	svc.Start()
+	return svc
+}
+
 // ProvideAPIKeyAuthCacheInvalidator 提供 API Key 认证缓存失效能力
 func ProvideAPIKeyAuthCacheInvalidator(apiKeyService *APIKeyService) APIKeyAuthCacheInvalidator {
 	// Start Pub/Sub subscriber for L1 cache invalidation across instances
@@ -225,10 +327,14 @@ var ProviderSet = wire.NewSet(
 	NewUsageService,
 	NewDashboardService,
 	ProvidePricingService,
+	NewModelPriceService,
 	NewBillingService,
 	NewBillingCacheService,
+	NewSpendCapService,
+	NewQuotaRolloverService,
 	NewAnnouncementService,
 	NewAdminService,
+	NewMemoryGuard,
 	NewGatewayService,
 	NewOpenAIGatewayService,
 	NewOAuthService,
@@ -247,6 +353,7 @@ var ProviderSet = wire.NewSet(
 	ProvideRateLimitService,
 	NewAccountUsageService,
 	NewAccountTestService,
+	NewAccountCredentialRotationService,
 	NewSettingService,
 	NewOpsService,
 	ProvideOpsMetricsCollector,
@@ -254,17 +361,37 @@ var ProviderSet = wire.NewSet(
 	ProvideOpsAlertEvaluatorService,
 	ProvideOpsCleanupService,
 	ProvideOpsScheduledReportService,
+	ProvideOpsEventExporter,
+	ProvideOpsRequestPhaseService,
+	ProvideUsageWebhookDispatcher,
+	ProvideAuditLogService,
+	ProvideTrashService,
+	NewAdminActionLogService,
+	NewConfigReloadService,
+	ProvideBudgetAlertService,
+	ProvideUsageRetryService,
 	NewEmailService,
 	ProvideEmailQueueService,
 	NewTurnstileService,
 	NewSubscriptionService,
 	ProvideConcurrencyService,
+	NewScalingSignalService,
+	NewGatewayMetricsService,
 	ProvideSchedulerSnapshotService,
+	NewAccountSessionCleanupService,
 	NewIdentityService,
 	NewCRSSyncService,
 	ProvideUpdateService,
 	ProvideTokenRefreshService,
 	ProvideAccountExpiryService,
+	ProvideStripeBillingService,
+	NewStreamAbuseService,
+	NewResponseCacheService,
+	NewTokenCounterService,
+	NewIPBanService,
+	NewUsageAnomalyService,
+	ProvideAccountCanaryService,
+	ProvideAccountModelDiscoveryService,
 	ProvideSubscriptionExpiryService,
 	ProvideTimingWheelService,
 	ProvideDashboardAggregationService,
@@ -275,5 +402,11 @@ var ProviderSet = wire.NewSet(
 	NewUsageCache,
 	NewTotpService,
 	NewErrorPassthroughService,
+	NewModelAliasService,
+	NewVirtualModelService,
+	NewRequestStripService,
+	NewRequestSanitizeService,
+	NewUpstreamErrorMappingService,
+	NewShutdownCoordinator,
 	NewDigestSessionStore,
 )
@@ -37,10 +37,11 @@ type FrontendServer struct {
 	baseHTML   []byte
 	cache      *HTMLCache
 	settings   PublicSettingsProvider
+	opts       FrontendOptions
 }
 
 // NewFrontendServer creates a new frontend server with settings injection
-func NewFrontendServer(settingsProvider PublicSettingsProvider) (*FrontendServer, error) {
+func NewFrontendServer(settingsProvider PublicSettingsProvider, opts FrontendOptions) (*FrontendServer, error) {
 	distFS, err := fs.Sub(frontendFS, "dist")
 	if err != nil {
 		return nil, err
@@ -67,6 +68,7 @@ func NewFrontendServer(settingsProvider PublicSettingsProvider) (*FrontendServer
 		baseHTML:   baseHTML,
 		cache:      cache,
 		settings:   settingsProvider,
+		opts:       opts,
 	}, nil
 }
 
@@ -106,6 +108,7 @@ func (s *FrontendServer) Middleware() gin.HandlerFunc {
 		}
 
 		// Serve static files normally
+		c.Header("Cache-Control", s.opts.staticCacheControl(cleanPath))
 		s.fileServer.ServeHTTP(c.Writer, c.Request)
 		c.Abort()
 	}
@@ -182,7 +185,14 @@ func (s *FrontendServer) serveIndexHTML(c *gin.Context) {
 func (s *FrontendServer) injectSettings(settingsJSON []byte) []byte {
 	// Create the script tag to inject with nonce placeholder
 	// The placeholder will be replaced with actual nonce at request time
-	script := []byte(`<script nonce="` + NonceHTMLPlaceholder + `">window.__APP_CONFIG__=` + string(settingsJSON) + `;</script>`)
+	// The API base path is injected alongside so the dashboard works when mounted under a reverse-proxy sub-path
+	apiBase := ""
+	if s.opts.APIBasePath != "" {
+		if encoded, err := json.Marshal(s.opts.APIBasePath); err == nil {
+			apiBase = `window.__API_BASE__=` + string(encoded) + `;`
+		}
+	}
+	script := []byte(`<script nonce="` + NonceHTMLPlaceholder + `">` + apiBase + `window.__APP_CONFIG__=` + string(settingsJSON) + `;</script>`)
 
 	// Inject before </head>
 	headClose := []byte("</head>")
@@ -196,7 +206,7 @@ func replaceNoncePlaceholder(html []byte, nonce string) []byte {
 
 // ServeEmbeddedFrontend returns a middleware for serving embedded frontend
 // This is the legacy function for backward compatibility when no settings provider is available
-func ServeEmbeddedFrontend() gin.HandlerFunc {
+func ServeEmbeddedFrontend(opts FrontendOptions) gin.HandlerFunc {
 	distFS, err := fs.Sub(frontendFS, "dist")
 	if err != nil {
 		panic("failed to get dist subdirectory: " + err.Error())
@@ -224,6 +234,7 @@ func ServeEmbeddedFrontend() gin.HandlerFunc {
 
 		if file, err := distFS.Open(cleanPath); err == nil {
 			_ = file.Close()
+			c.Header("Cache-Control", opts.staticCacheControl(cleanPath))
 			fileServer.ServeHTTP(c.Writer, c.Request)
 			c.Abort()
 			return
@@ -93,7 +93,7 @@ func TestFrontendServer_InjectSettings(t *testing.T) {
 			settings: map[string]string{"key": "value"},
 		}
 
-		server, err := NewFrontendServer(provider)
+		server, err := NewFrontendServer(provider, FrontendOptions{})
 		require.NoError(t, err)
 
 		settingsJSON := []byte(`{"test":"data"}`)
@@ -110,7 +110,7 @@ func TestFrontendServer_InjectSettings(t *testing.T) {
 			settings: map[string]string{"key": "value"},
 		}
 
-		server, err := NewFrontendServer(provider)
+		server, err := NewFrontendServer(provider, FrontendOptions{})
 		require.NoError(t, err)
 
 		settingsJSON := []byte(`{}`)
@@ -132,7 +132,7 @@ func TestFrontendServer_InjectSettings(t *testing.T) {
 			},
 		}
 
-		server, err := NewFrontendServer(provider)
+		server, err := NewFrontendServer(provider, FrontendOptions{})
 		require.NoError(t, err)
 
 		settingsJSON := []byte(`{"nested":{"array":[1,2,3]},"special":"<>&"}`)
@@ -140,6 +140,39 @@ func TestFrontendServer_InjectSettings(t *testing.T) {
 
 		assert.Contains(t, string(result), `window.__APP_CONFIG__={"nested":{"array":[1,2,3]},"special":"<>&"};`)
 	})
+
+	t.Run("injects_api_base_path", func(t *testing.T) {
+		provider := &mockSettingsProvider{
+			settings: map[string]string{"key": "value"},
+		}
+
+		server, err := NewFrontendServer(provider, FrontendOptions{APIBasePath: "/sub2api/api/v1"})
+		require.NoError(t, err)
+
+		result := server.injectSettings([]byte(`{}`))
+
+		assert.Contains(t, string(result), `window.__API_BASE__="/sub2api/api/v1";window.__APP_CONFIG__={};`)
+	})
+
+	t.Run("omits_api_base_when_unset", func(t *testing.T) {
+		provider := &mockSettingsProvider{
+			settings: map[string]string{"key": "value"},
+		}
+
+		server, err := NewFrontendServer(provider, FrontendOptions{})
+		require.NoError(t, err)
+
+		result := server.injectSettings([]byte(`{}`))
+
+		assert.NotContains(t, string(result), "__API_BASE__")
+	})
+}
+
+func TestFrontendOptions_StaticCacheControl(t *testing.T) {
+	opts := FrontendOptions{AssetCacheMaxAge: 86400}
+	assert.Equal(t, "public, max-age=86400, immutable", opts.staticCacheControl("assets/index-3f2a1b.js"))
+	assert.Equal(t, "no-cache", opts.staticCacheControl("logo.png"))
+	assert.Equal(t, "no-cache", FrontendOptions{}.staticCacheControl("assets/index-3f2a1b.js"))
 }
 
 func TestFrontendServer_ServeIndexHTML(t *testing.T) {
@@ -148,7 +181,7 @@ func TestFrontendServer_ServeIndexHTML(t *testing.T) {
 			settings: map[string]string{"test": "value"},
 		}
 
-		server, err := NewFrontendServer(provider)
+		server, err := NewFrontendServer(provider, FrontendOptions{})
 		require.NoError(t, err)
 
 		// Create a gin context with nonce
@@ -176,7 +209,7 @@ func TestFrontendServer_ServeIndexHTML(t *testing.T) {
 			settings: map[string]string{"test": "value"},
 		}
 
-		server, err := NewFrontendServer(provider)
+		server, err := NewFrontendServer(provider, FrontendOptions{})
 		require.NoError(t, err)
 
 		// First request
@@ -207,7 +240,7 @@ func TestFrontendServer_ServeIndexHTML(t *testing.T) {
 			settings: map[string]string{"test": "value"},
 		}
 
-		server, err := NewFrontendServer(provider)
+		server, err := NewFrontendServer(provider, FrontendOptions{})
 		require.NoError(t, err)
 
 		w := httptest.NewRecorder()
@@ -228,7 +261,7 @@ func TestFrontendServer_ServeIndexHTML(t *testing.T) {
 			settings: map[string]string{"test": "value"},
 		}
 
-		server, err := NewFrontendServer(provider)
+		server, err := NewFrontendServer(provider, FrontendOptions{})
 		require.NoError(t, err)
 
 		// Use a real router for proper 304 handling
@@ -261,7 +294,7 @@ func TestFrontendServer_ServeIndexHTML(t *testing.T) {
 			settings: map[string]string{"test": "value"},
 		}
 
-		server, err := NewFrontendServer(provider)
+		server, err := NewFrontendServer(provider, FrontendOptions{})
 		require.NoError(t, err)
 
 		w := httptest.NewRecorder()
@@ -279,7 +312,7 @@ func TestFrontendServer_ServeIndexHTML(t *testing.T) {
 			err: context.DeadlineExceeded,
 		}
 
-		server, err := NewFrontendServer(provider)
+		server, err := NewFrontendServer(provider, FrontendOptions{})
 		require.NoError(t, err)
 
 		// Invalidate cache to force settings fetch
@@ -304,7 +337,7 @@ func TestFrontendServer_InvalidateCache(t *testing.T) {
 			settings: map[string]string{"test": "value"},
 		}
 
-		server, err := NewFrontendServer(provider)
+		server, err := NewFrontendServer(provider, FrontendOptions{})
 		require.NoError(t, err)
 
 		// First request to populate cache
@@ -355,7 +388,7 @@ func TestFrontendServer_Middleware(t *testing.T) {
 			settings: map[string]string{"test": "value"},
 		}
 
-		server, err := NewFrontendServer(provider)
+		server, err := NewFrontendServer(provider, FrontendOptions{})
 		require.NoError(t, err)
 
 		apiPaths := []string{
@@ -392,7 +425,7 @@ func TestFrontendServer_Middleware(t *testing.T) {
 			settings: map[string]string{"test": "value"},
 		}
 
-		server, err := NewFrontendServer(provider)
+		server, err := NewFrontendServer(provider, FrontendOptions{})
 		require.NoError(t, err)
 
 		router := gin.New()
@@ -426,7 +459,7 @@ func TestFrontendServer_Middleware(t *testing.T) {
 			settings: map[string]string{"test": "value"},
 		}
 
-		server, err := NewFrontendServer(provider)
+		server, err := NewFrontendServer(provider, FrontendOptions{})
 		require.NoError(t, err)
 
 		router := gin.New()
@@ -439,6 +472,7 @@ func TestFrontendServer_Middleware(t *testing.T) {
 
 		assert.Equal(t, http.StatusOK, w.Code)
 		assert.Contains(t, w.Header().Get("Content-Type"), "image/png")
+		assert.Equal(t, "no-cache", w.Header().Get("Cache-Control"))
 	})
 }
 
@@ -448,7 +482,7 @@ func TestNewFrontendServer(t *testing.T) {
 			settings: map[string]string{"test": "value"},
 		}
 
-		server, err := NewFrontendServer(provider)
+		server, err := NewFrontendServer(provider, FrontendOptions{})
 
 		require.NoError(t, err)
 		assert.NotNil(t, server)
@@ -464,7 +498,7 @@ func TestNewFrontendServer(t *testing.T) {
 			settings: map[string]string{"test": "value"},
 		}
 
-		server, err := NewFrontendServer(provider)
+		server, err := NewFrontendServer(provider, FrontendOptions{})
 		require.NoError(t, err)
 
 		assert.NotEmpty(t, server.baseHTML)
@@ -482,7 +516,7 @@ func TestHasEmbeddedFrontend(t *testing.T) {
 // Tests for legacy ServeEmbeddedFrontend function
 func TestServeEmbeddedFrontend(t *testing.T) {
 	t.Run("serves_static_files", func(t *testing.T) {
-		middleware := ServeEmbeddedFrontend()
+		middleware := ServeEmbeddedFrontend(FrontendOptions{})
 
 		router := gin.New()
 		router.Use(middleware)
@@ -496,7 +530,7 @@ func TestServeEmbeddedFrontend(t *testing.T) {
 	})
 
 	t.Run("serves_index_html_for_root", func(t *testing.T) {
-		middleware := ServeEmbeddedFrontend()
+		middleware := ServeEmbeddedFrontend(FrontendOptions{})
 
 		router := gin.New()
 		router.Use(middleware)
@@ -511,7 +545,7 @@ func TestServeEmbeddedFrontend(t *testing.T) {
 	})
 
 	t.Run("serves_index_html_for_spa_routes", func(t *testing.T) {
-		middleware := ServeEmbeddedFrontend()
+		middleware := ServeEmbeddedFrontend(FrontendOptions{})
 
 		router := gin.New()
 		router.Use(middleware)
@@ -531,7 +565,7 @@ func TestServeEmbeddedFrontend(t *testing.T) {
 	})
 
 	t.Run("skips_api_routes", func(t *testing.T) {
-		middleware := ServeEmbeddedFrontend()
+		middleware := ServeEmbeddedFrontend(FrontendOptions{})
 
 		apiPaths := []string{
 			"/api/users",
@@ -646,7 +680,7 @@ func BenchmarkFrontendServerServeIndexHTML(b *testing.B) {
 		settings: map[string]string{"test": "value"},
 	}
 
-	server, _ := NewFrontendServer(provider)
+	server, _ := NewFrontendServer(provider, FrontendOptions{})
 
 	b.ResetTimer()
 	for i := 0; i < b.N; i++ {
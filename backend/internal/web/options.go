@@ -0,0 +1,23 @@
+package web
+
+import (
+	"strconv"
+	"strings"
+)
+
+// FrontendOptions 内嵌前端服务选项（来自 config.FrontendConfig）
+type FrontendOptions struct {
+	// APIBasePath 注入到 index.html 的 API 基础路径（window.__API_BASE__），为空时不注入，前端使用构建时默认值
+	APIBasePath string
+	// AssetCacheMaxAge 带内容哈希的静态资源（assets/ 目录）缓存时间（秒），0 表示不缓存
+	AssetCacheMaxAge int
+}
+
+// staticCacheControl 返回静态文件的 Cache-Control：
+// Vite 构建产物 assets/ 下的文件名带内容哈希，可长期缓存；其余文件（favicon、logo 等）每次重新校验
+func (o FrontendOptions) staticCacheControl(path string) string {
+	if o.AssetCacheMaxAge > 0 && strings.HasPrefix(path, "assets/") {
+		return "public, max-age=" + strconv.Itoa(o.AssetCacheMaxAge) + ", immutable"
+	}
+	return "no-cache"
+}
@@ -0,0 +1,11 @@
+-- 055_add_group_model_param_policies.sql
+-- 添加分组级别的模型参数策略（temperature/top_p 范围约束）
+
+-- 添加 model_param_policies 字段：模型参数策略（JSONB 格式）
+-- 格式: {"model_pattern": {"temperature": {"min": 0, "max": 1}, "top_p": {"max": 1}, "temperature_top_p_exclusive": true, "action": "clamp"}, ...}
+-- action: clamp（收敛到边界值，默认）| reject（返回 400）
+ALTER TABLE groups
+ADD COLUMN IF NOT EXISTS model_param_policies JSONB DEFAULT '{}';
+
+-- 添加字段注释
+COMMENT ON COLUMN groups.model_param_policies IS '模型参数策略：{"model_pattern": {...}}，支持通配符匹配';
@@ -0,0 +1,13 @@
+-- 056_add_region_policy.sql
+-- 添加区域路由策略：分组与 API Key 可要求/优先使用指定区域的账号（账号区域通过 extra.region 声明）
+
+-- 格式: {"required": "eu", "preferred": "eu-west"}
+-- required: 仅调度到该区域的账号（数据驻留）；preferred: 优先调度，无可用账号时回退
+ALTER TABLE groups
+ADD COLUMN IF NOT EXISTS region_policy JSONB DEFAULT '{}';
+
+ALTER TABLE api_keys
+ADD COLUMN IF NOT EXISTS region_policy JSONB DEFAULT '{}';
+
+COMMENT ON COLUMN groups.region_policy IS '区域策略：{"required": "...", "preferred": "..."}';
+COMMENT ON COLUMN api_keys.region_policy IS '区域策略（覆盖分组配置）：{"required": "...", "preferred": "..."}';
@@ -0,0 +1,7 @@
+-- 057_add_api_key_debug_errors.sql
+-- API Key 调试模式：开启后在错误响应中附带脱敏后的上游错误详情（状态码、上游错误码、账号别名）
+
+ALTER TABLE api_keys
+ADD COLUMN IF NOT EXISTS debug_errors BOOLEAN NOT NULL DEFAULT FALSE;
+
+COMMENT ON COLUMN api_keys.debug_errors IS '调试模式：错误响应中附带脱敏后的上游错误详情';
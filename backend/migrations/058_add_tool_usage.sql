@@ -0,0 +1,14 @@
+-- 058_add_tool_usage.sql
+-- 内置工具（web_search / code_interpreter / image_generation）用量计费与 API Key 每日调用上限
+
+ALTER TABLE usage_logs
+ADD COLUMN IF NOT EXISTS tool_usage JSONB,
+ADD COLUMN IF NOT EXISTS tool_cost DECIMAL(20, 10) NOT NULL DEFAULT 0;
+
+COMMENT ON COLUMN usage_logs.tool_usage IS '内置工具调用次数：{"web_search_calls":N,"code_interpreter_sessions":N,"image_generations":N}';
+COMMENT ON COLUMN usage_logs.tool_cost IS '内置工具费用（USD，未乘倍率，已计入 total_cost）';
+
+ALTER TABLE api_keys
+ADD COLUMN IF NOT EXISTS tool_limits JSONB;
+
+COMMENT ON COLUMN api_keys.tool_limits IS '内置工具每日调用上限（UTC 自然日），如 {"web_search":100}';
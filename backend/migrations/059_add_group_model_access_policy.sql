@@ -0,0 +1,7 @@
+-- 059_add_group_model_access_policy.sql
+-- 分组模型访问策略：限制分组（及其 API Key）可请求的模型，支持允许列表与禁止列表（末尾 * 通配）
+
+ALTER TABLE groups
+ADD COLUMN IF NOT EXISTS model_access_policy JSONB;
+
+COMMENT ON COLUMN groups.model_access_policy IS '模型访问策略：{"allowed":["claude-sonnet-*"],"denied":["claude-opus-*"]}';
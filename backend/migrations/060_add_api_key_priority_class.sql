@@ -0,0 +1,7 @@
+-- 060_add_api_key_priority_class.sql
+-- API Key 优先级类别：按类别（interactive/batch）选择故障转移预算（账号切换次数与单次尝试超时）
+
+ALTER TABLE api_keys
+ADD COLUMN IF NOT EXISTS priority_class VARCHAR(20) NOT NULL DEFAULT '';
+
+COMMENT ON COLUMN api_keys.priority_class IS '优先级类别：interactive/batch，空字符串使用全局 max_account_switches';
@@ -0,0 +1,7 @@
+-- 061_add_api_key_allowed_models.sql
+-- API Key 级别的模型限制：在分组模型访问策略之上进一步限定单个 Key 可请求的模型
+
+ALTER TABLE api_keys
+ADD COLUMN IF NOT EXISTS allowed_models JSONB;
+
+COMMENT ON COLUMN api_keys.allowed_models IS '允许请求的模型（支持末尾 * 通配），如 ["claude-sonnet-*","gpt-5"]；为空表示仅受分组策略限制';
@@ -0,0 +1,13 @@
+-- 062_add_system_prompt_policy.sql
+-- 添加系统提示词注入策略：分组与 API Key 可配置是否及如何注入系统提示词（替代硬编码的 OpenCode 指令注入）
+
+-- 格式: {"mode": "inject_if_empty", "prompt": "..."}
+-- mode: off / inject_if_empty / always_prepend / always_replace；prompt 为空时 OpenAI 协议使用内置指令
+ALTER TABLE groups
+ADD COLUMN IF NOT EXISTS system_prompt_policy JSONB DEFAULT '{}';
+
+ALTER TABLE api_keys
+ADD COLUMN IF NOT EXISTS system_prompt_policy JSONB DEFAULT '{}';
+
+COMMENT ON COLUMN groups.system_prompt_policy IS '系统提示词注入策略：{"mode": "...", "prompt": "..."}';
+COMMENT ON COLUMN api_keys.system_prompt_policy IS '系统提示词注入策略（覆盖分组配置）：{"mode": "...", "prompt": "..."}';
@@ -0,0 +1,9 @@
+-- 063_add_group_prompt_template.sql
+-- 添加分组提示词模板：转发前以前缀/后缀包裹用户最新一条消息（合规声明、角色设定等）
+
+-- 格式: {"prefix": "...", "suffix": "..."}
+-- 支持模板变量：{{date}}、{{datetime}}、{{user_id}}、{{api_key_id}}、{{group}}、{{model}}
+ALTER TABLE groups
+ADD COLUMN IF NOT EXISTS prompt_template JSONB DEFAULT '{}';
+
+COMMENT ON COLUMN groups.prompt_template IS '提示词模板：{"prefix": "...", "suffix": "..."}';
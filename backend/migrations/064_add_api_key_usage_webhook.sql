@@ -0,0 +1,8 @@
+-- 064_add_api_key_usage_webhook.sql
+-- 添加 API Key 用量回调：Key 持有者配置回调地址后，每个请求完成时网关推送请求摘要（带 HMAC 签名）
+
+-- 格式: {"url": "https://...", "secret": "whsec_..."}
+ALTER TABLE api_keys
+ADD COLUMN IF NOT EXISTS usage_webhook JSONB DEFAULT '{}';
+
+COMMENT ON COLUMN api_keys.usage_webhook IS '用量回调：{"url": "...", "secret": "..."}';
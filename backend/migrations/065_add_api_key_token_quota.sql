@@ -0,0 +1,8 @@
+-- 065_add_api_key_token_quota.sql
+-- 添加 API Key 每日/每月 token 与请求数硬配额（计数保存在 Redis，按配置时区的自然日/月重置）
+
+-- 格式: {"daily_tokens": 0, "monthly_tokens": 0, "daily_requests": 0, "monthly_requests": 0, "timezone": "Asia/Shanghai"}
+ALTER TABLE api_keys
+ADD COLUMN IF NOT EXISTS token_quota JSONB DEFAULT '{}';
+
+COMMENT ON COLUMN api_keys.token_quota IS 'Token/请求数配额：{"daily_tokens", "monthly_tokens", "daily_requests", "monthly_requests", "timezone"}，0 表示不限制';
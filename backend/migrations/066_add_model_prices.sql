@@ -0,0 +1,32 @@
+-- 066_add_model_prices.sql
+-- 管理员维护的模型价格表（按平台 + 模型，带生效时间的版本）
+-- 计费时选择 effective_from <= 请求时间的最新版本，优先于 LiteLLM 动态价格与内置回退价格；
+-- 已生效的版本不可修改，调价通过新增版本完成，历史用量不会被重新定价。
+
+CREATE TABLE IF NOT EXISTS model_prices (
+    id                       BIGSERIAL PRIMARY KEY,
+    platform                 VARCHAR(50)    NOT NULL DEFAULT '',
+    model                    VARCHAR(200)   NOT NULL,
+    input_price              DECIMAL(20,10) NOT NULL DEFAULT 0,
+    output_price             DECIMAL(20,10) NOT NULL DEFAULT 0,
+    cache_creation_price     DECIMAL(20,10) NOT NULL DEFAULT 0,
+    cache_creation_1h_price  DECIMAL(20,10) NOT NULL DEFAULT 0,
+    cache_read_price         DECIMAL(20,10) NOT NULL DEFAULT 0,
+    effective_from           TIMESTAMPTZ    NOT NULL DEFAULT NOW(),
+    notes                    TEXT           NOT NULL DEFAULT '',
+    created_at               TIMESTAMPTZ    NOT NULL DEFAULT NOW(),
+    updated_at               TIMESTAMPTZ    NOT NULL DEFAULT NOW()
+);
+
+CREATE UNIQUE INDEX IF NOT EXISTS idx_model_prices_version
+    ON model_prices(platform, model, effective_from);
+
+COMMENT ON TABLE model_prices IS '管理员维护的模型价格（带生效时间版本）';
+COMMENT ON COLUMN model_prices.platform IS '平台（anthropic/openai/gemini/antigravity），空字符串表示所有平台';
+COMMENT ON COLUMN model_prices.model IS '模型名（小写，精确匹配）';
+COMMENT ON COLUMN model_prices.input_price IS '输入价格（USD / 1M tokens）';
+COMMENT ON COLUMN model_prices.output_price IS '输出价格（USD / 1M tokens）';
+COMMENT ON COLUMN model_prices.cache_creation_price IS '缓存创建价格（5 分钟，USD / 1M tokens），0 表示按输入价格计费';
+COMMENT ON COLUMN model_prices.cache_creation_1h_price IS '1 小时缓存创建价格（USD / 1M tokens），0 表示不区分缓存时长';
+COMMENT ON COLUMN model_prices.cache_read_price IS '缓存读取价格（USD / 1M tokens），0 表示按输入价格计费';
+COMMENT ON COLUMN model_prices.effective_from IS '生效时间，计费按请求时间选择最新的已生效版本';
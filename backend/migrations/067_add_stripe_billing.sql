@@ -0,0 +1,39 @@
+-- 067_add_stripe_billing.sql
+-- Stripe 订阅与按量计费集成：用户与 Stripe Customer 的关联、订阅状态、用量上报水位，
+-- 以及已处理 Webhook 事件（Stripe 会重复投递，按 event_id 幂等）。
+
+CREATE TABLE IF NOT EXISTS stripe_customers (
+    user_id               BIGINT         PRIMARY KEY REFERENCES users(id) ON DELETE CASCADE,
+    customer_id           VARCHAR(255)   NOT NULL,
+    subscription_id       VARCHAR(255)   NOT NULL DEFAULT '',
+    subscription_status   VARCHAR(32)    NOT NULL DEFAULT '',
+    metered               BOOLEAN        NOT NULL DEFAULT FALSE,
+    user_suspended        BOOLEAN        NOT NULL DEFAULT FALSE,
+    last_event_at         TIMESTAMPTZ,
+    usage_reported_until  TIMESTAMPTZ,
+    usage_carry           DECIMAL(20,10) NOT NULL DEFAULT 0,
+    created_at            TIMESTAMPTZ    NOT NULL DEFAULT NOW(),
+    updated_at            TIMESTAMPTZ    NOT NULL DEFAULT NOW()
+);
+
+CREATE UNIQUE INDEX IF NOT EXISTS idx_stripe_customers_customer_id
+    ON stripe_customers(customer_id);
+
+COMMENT ON TABLE stripe_customers IS '用户与 Stripe Customer / Subscription 的关联';
+COMMENT ON COLUMN stripe_customers.subscription_status IS 'Stripe 订阅状态（active/trialing/past_due/unpaid/canceled 等）';
+COMMENT ON COLUMN stripe_customers.metered IS '订阅是否包含按量计费 Price，是则定期上报用量';
+COMMENT ON COLUMN stripe_customers.user_suspended IS '用户是否因 Stripe 欠费/取消被自动禁用（恢复时只解禁由此禁用的用户）';
+COMMENT ON COLUMN stripe_customers.last_event_at IS '最近处理的订阅事件创建时间，用于丢弃乱序到达的旧事件';
+COMMENT ON COLUMN stripe_customers.usage_reported_until IS '用量已上报到的时间点（不含）';
+COMMENT ON COLUMN stripe_customers.usage_carry IS '取整后未上报的零头（已乘 value_scale），计入下次上报';
+
+CREATE TABLE IF NOT EXISTS stripe_webhook_events (
+    event_id      VARCHAR(255) PRIMARY KEY,
+    event_type    VARCHAR(100) NOT NULL DEFAULT '',
+    processed_at  TIMESTAMPTZ  NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX IF NOT EXISTS idx_stripe_webhook_events_processed_at
+    ON stripe_webhook_events(processed_at);
+
+COMMENT ON TABLE stripe_webhook_events IS '已处理的 Stripe Webhook 事件，用于幂等';
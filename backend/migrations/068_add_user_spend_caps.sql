@@ -0,0 +1,20 @@
+-- 068_add_user_spend_caps.sql
+-- 用户级消费上限覆盖：未覆盖的字段（NULL）沿用 billing.spend_cap 全局默认值，0 表示不限制。
+-- 当期消费计数保存在 Redis（按服务器时区自然日/月重置）。
+
+CREATE TABLE IF NOT EXISTS user_spend_caps (
+    user_id           BIGINT         PRIMARY KEY REFERENCES users(id) ON DELETE CASCADE,
+    daily_soft_usd    DECIMAL(20,8),
+    daily_hard_usd    DECIMAL(20,8),
+    monthly_soft_usd  DECIMAL(20,8),
+    monthly_hard_usd  DECIMAL(20,8),
+    notes             TEXT           NOT NULL DEFAULT '',
+    created_at        TIMESTAMPTZ    NOT NULL DEFAULT NOW(),
+    updated_at        TIMESTAMPTZ    NOT NULL DEFAULT NOW()
+);
+
+COMMENT ON TABLE user_spend_caps IS '用户级消费软/硬上限覆盖（NULL 沿用全局默认，0 表示不限制）';
+COMMENT ON COLUMN user_spend_caps.daily_soft_usd IS '每日软上限：超过后仅告警';
+COMMENT ON COLUMN user_spend_caps.daily_hard_usd IS '每日硬上限：超过后拒绝请求';
+COMMENT ON COLUMN user_spend_caps.monthly_soft_usd IS '每月软上限：超过后仅告警';
+COMMENT ON COLUMN user_spend_caps.monthly_hard_usd IS '每月硬上限：超过后拒绝请求';
@@ -0,0 +1,13 @@
+-- 069_add_group_metering_only.sql
+-- 分组“仅计量”模式：照常记录 token 与费用，但不扣余额/订阅额度，也不做计费资格拦截（试点、计费系统迁移期间使用）
+
+ALTER TABLE groups
+ADD COLUMN IF NOT EXISTS metering_only BOOLEAN NOT NULL DEFAULT false;
+
+COMMENT ON COLUMN groups.metering_only IS '仅计量：记录用量与费用但不扣费、不做计费资格拦截';
+
+-- 用量记录标记：true 表示该记录产生时所在分组处于仅计量模式，费用未实际扣除
+ALTER TABLE usage_logs
+ADD COLUMN IF NOT EXISTS metering_only BOOLEAN NOT NULL DEFAULT false;
+
+COMMENT ON COLUMN usage_logs.metering_only IS '仅计量记录：费用已计算但未扣除';
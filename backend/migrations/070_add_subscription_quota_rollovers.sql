@@ -0,0 +1,16 @@
+-- 070_add_subscription_quota_rollovers.sql
+-- 订阅月额度结转：月窗口重置时将上期未用完的额度（受 billing.quota_rollover 上限约束）
+-- 结转到新窗口，过期后不再计入。每个订阅只保留最近一次结转记录。
+
+CREATE TABLE IF NOT EXISTS subscription_quota_rollovers (
+    subscription_id   BIGINT         PRIMARY KEY REFERENCES user_subscriptions(id) ON DELETE CASCADE,
+    amount_usd        DECIMAL(20,10) NOT NULL DEFAULT 0,
+    window_start      TIMESTAMPTZ    NOT NULL,
+    expires_at        TIMESTAMPTZ    NOT NULL,
+    updated_at        TIMESTAMPTZ    NOT NULL DEFAULT NOW()
+);
+
+COMMENT ON TABLE subscription_quota_rollovers IS '订阅月额度结转记录（每个订阅一条，覆盖写入）';
+COMMENT ON COLUMN subscription_quota_rollovers.amount_usd IS '结转到新月窗口的额度（USD）';
+COMMENT ON COLUMN subscription_quota_rollovers.window_start IS '结转进入的月窗口起点';
+COMMENT ON COLUMN subscription_quota_rollovers.expires_at IS '结转额度过期时间';
@@ -0,0 +1,8 @@
+-- 071_add_usage_log_gateway_fingerprint.sql
+-- OpenAI 兼容请求携带 seed 时回显并记录网关指纹（上游指纹 + 账号别名哈希 + 网关版本），
+-- 用于排查可复现性是否因账号故障转移而失效。
+
+ALTER TABLE usage_logs
+ADD COLUMN IF NOT EXISTS gateway_fingerprint VARCHAR(255);
+
+COMMENT ON COLUMN usage_logs.gateway_fingerprint IS '网关指纹：仅请求携带 seed 时记录';
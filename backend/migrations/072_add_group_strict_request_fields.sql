@@ -0,0 +1,7 @@
+-- 072_add_group_strict_request_fields.sql
+-- 分组未知顶层请求字段处理模式：false（宽松，默认）原样透传给上游；true（严格）以 400 拒绝并指出具体字段，便于发现 max_token 之类的拼写错误
+
+ALTER TABLE groups
+ADD COLUMN IF NOT EXISTS strict_request_fields BOOLEAN NOT NULL DEFAULT false;
+
+COMMENT ON COLUMN groups.strict_request_fields IS '严格字段模式：拒绝包含未知顶层字段的请求（宽松模式原样透传）';
@@ -0,0 +1,37 @@
+-- 073_add_audit_logs.sql
+-- 网关请求审计日志：记录请求元数据与（可选）脱敏后的请求/响应体，供合规审查与事故复盘。
+-- 由 audit_log.enabled 开启，过期记录按 audit_log.retention_days 定时清理。
+
+CREATE TABLE IF NOT EXISTS audit_logs (
+    id                  BIGSERIAL    PRIMARY KEY,
+    request_id          VARCHAR(64)  NOT NULL DEFAULT '',
+    user_id             BIGINT,
+    api_key_id          BIGINT,
+    account_id          BIGINT,
+    group_id            BIGINT,
+    platform            VARCHAR(32)  NOT NULL DEFAULT '',
+    model               VARCHAR(128) NOT NULL DEFAULT '',
+    method              VARCHAR(16)  NOT NULL DEFAULT '',
+    path                VARCHAR(255) NOT NULL DEFAULT '',
+    status_code         INT          NOT NULL DEFAULT 0,
+    stream              BOOLEAN      NOT NULL DEFAULT FALSE,
+    duration_ms         INT          NOT NULL DEFAULT 0,
+    client_ip           VARCHAR(64)  NOT NULL DEFAULT '',
+    user_agent          VARCHAR(512) NOT NULL DEFAULT '',
+    request_bytes       INT          NOT NULL DEFAULT 0,
+    response_bytes      INT          NOT NULL DEFAULT 0,
+    request_body        TEXT,
+    response_body       TEXT,
+    body_truncated      BOOLEAN      NOT NULL DEFAULT FALSE,
+    created_at          TIMESTAMPTZ  NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX IF NOT EXISTS idx_audit_logs_created_at ON audit_logs (created_at);
+CREATE INDEX IF NOT EXISTS idx_audit_logs_user_created ON audit_logs (user_id, created_at);
+CREATE INDEX IF NOT EXISTS idx_audit_logs_api_key_created ON audit_logs (api_key_id, created_at);
+CREATE INDEX IF NOT EXISTS idx_audit_logs_request_id ON audit_logs (request_id);
+
+COMMENT ON TABLE audit_logs IS '网关请求审计日志（可选脱敏请求/响应体）';
+COMMENT ON COLUMN audit_logs.request_body IS '脱敏后的请求体（未开启 capture_bodies 时为空）';
+COMMENT ON COLUMN audit_logs.response_body IS '脱敏后的响应体（流式响应保存 SSE 文本，超出上限截断）';
+COMMENT ON COLUMN audit_logs.body_truncated IS '请求体或响应体是否因超出 max_body_bytes 被截断';
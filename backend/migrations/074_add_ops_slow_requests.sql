@@ -0,0 +1,30 @@
+-- 074_add_ops_slow_requests.sql
+-- 慢请求记录：总耗时或首 token 耗时超过 ops.slow_request 阈值的网关请求，
+-- 保留账号、模型、重试次数等上下文，便于定位某个上游的性能退化。
+
+CREATE TABLE IF NOT EXISTS ops_slow_requests (
+    id              BIGSERIAL    PRIMARY KEY,
+    request_id      VARCHAR(64)  NOT NULL DEFAULT '',
+    user_id         BIGINT,
+    api_key_id      BIGINT,
+    account_id      BIGINT,
+    group_id        BIGINT,
+    platform        VARCHAR(32)  NOT NULL DEFAULT '',
+    model           VARCHAR(128) NOT NULL DEFAULT '',
+    request_path    VARCHAR(255) NOT NULL DEFAULT '',
+    stream          BOOLEAN      NOT NULL DEFAULT FALSE,
+    status_code     INT          NOT NULL DEFAULT 0,
+    duration_ms     INT          NOT NULL DEFAULT 0,
+    first_token_ms  INT,
+    retry_count     INT          NOT NULL DEFAULT 0,
+    reasons         VARCHAR(64)  NOT NULL DEFAULT '',
+    created_at      TIMESTAMPTZ  NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX IF NOT EXISTS idx_ops_slow_requests_created_at ON ops_slow_requests (created_at);
+CREATE INDEX IF NOT EXISTS idx_ops_slow_requests_account_created ON ops_slow_requests (account_id, created_at);
+CREATE INDEX IF NOT EXISTS idx_ops_slow_requests_group_created ON ops_slow_requests (group_id, created_at);
+
+COMMENT ON TABLE ops_slow_requests IS '慢请求记录（超过 ops.slow_request 耗时/首字阈值）';
+COMMENT ON COLUMN ops_slow_requests.retry_count IS '本次请求内的上游重试/切换账号次数';
+COMMENT ON COLUMN ops_slow_requests.reasons IS '命中的阈值，逗号分隔：duration / ttft';
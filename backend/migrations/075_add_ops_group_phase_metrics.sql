@@ -0,0 +1,44 @@
+-- 075_add_ops_group_phase_metrics.sql
+-- 分组维度的请求阶段耗时（分钟桶）：排队等待、账号槽位等待、账号选择、上游首字节、流式传输。
+-- 各实例在内存中按分钟聚合后累加写入（sum/count 可跨实例相加），用于判断分组延迟来自排队策略还是上游账号。
+
+CREATE TABLE IF NOT EXISTS ops_group_phase_metrics (
+    id                  BIGSERIAL    PRIMARY KEY,
+    bucket_start        TIMESTAMPTZ  NOT NULL,
+    group_id            BIGINT       NOT NULL DEFAULT 0,
+    platform            VARCHAR(32)  NOT NULL DEFAULT '',
+
+    request_count       BIGINT       NOT NULL DEFAULT 0,
+
+    queued_count        BIGINT       NOT NULL DEFAULT 0,
+    queue_wait_sum_ms   BIGINT       NOT NULL DEFAULT 0,
+    queue_wait_max_ms   BIGINT       NOT NULL DEFAULT 0,
+
+    slot_waited_count   BIGINT       NOT NULL DEFAULT 0,
+    slot_wait_sum_ms    BIGINT       NOT NULL DEFAULT 0,
+    slot_wait_max_ms    BIGINT       NOT NULL DEFAULT 0,
+
+    selection_sum_ms    BIGINT       NOT NULL DEFAULT 0,
+    selection_max_ms    BIGINT       NOT NULL DEFAULT 0,
+
+    ttfb_count          BIGINT       NOT NULL DEFAULT 0,
+    ttfb_sum_ms         BIGINT       NOT NULL DEFAULT 0,
+    ttfb_max_ms         BIGINT       NOT NULL DEFAULT 0,
+
+    stream_count        BIGINT       NOT NULL DEFAULT 0,
+    stream_sum_ms       BIGINT       NOT NULL DEFAULT 0,
+    stream_max_ms       BIGINT       NOT NULL DEFAULT 0,
+
+    updated_at          TIMESTAMPTZ  NOT NULL DEFAULT NOW()
+);
+
+CREATE UNIQUE INDEX IF NOT EXISTS idx_ops_group_phase_metrics_bucket_group_platform
+    ON ops_group_phase_metrics (bucket_start, group_id, platform);
+CREATE INDEX IF NOT EXISTS idx_ops_group_phase_metrics_group_bucket
+    ON ops_group_phase_metrics (group_id, bucket_start);
+
+COMMENT ON TABLE ops_group_phase_metrics IS '分组请求阶段耗时分钟聚合（排队/槽位等待/选号/首字节/流式传输）';
+COMMENT ON COLUMN ops_group_phase_metrics.group_id IS '分组 ID，0 表示未绑定分组的 API Key';
+COMMENT ON COLUMN ops_group_phase_metrics.queued_count IS '发生用户级排队等待的请求数';
+COMMENT ON COLUMN ops_group_phase_metrics.slot_waited_count IS '发生账号槽位等待的请求数';
+COMMENT ON COLUMN ops_group_phase_metrics.stream_sum_ms IS '流式请求首字节之后的传输时长合计';
@@ -0,0 +1,13 @@
+-- 076_add_trash_lifecycle.sql
+-- 账号与 API Key 回收站：删除仍为软删除（deleted_at），在 trash.retention_days 内可恢复。
+-- 账号删除时记录原分组绑定，恢复时重新绑定；超过保留期后清除凭证/密钥并标记 purged_at，
+-- 记录本身保留，usage_logs 等历史数据不受影响（外键为 ON DELETE CASCADE，因此不做物理删除）。
+
+ALTER TABLE accounts ADD COLUMN IF NOT EXISTS trashed_group_ids JSONB;
+ALTER TABLE accounts ADD COLUMN IF NOT EXISTS purged_at TIMESTAMPTZ;
+ALTER TABLE api_keys ADD COLUMN IF NOT EXISTS purged_at TIMESTAMPTZ;
+
+CREATE INDEX IF NOT EXISTS idx_accounts_trash ON accounts (deleted_at)
+    WHERE deleted_at IS NOT NULL AND purged_at IS NULL;
+CREATE INDEX IF NOT EXISTS idx_api_keys_trash ON api_keys (deleted_at)
+    WHERE deleted_at IS NOT NULL AND purged_at IS NULL;
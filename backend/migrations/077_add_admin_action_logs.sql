@@ -0,0 +1,30 @@
+-- 077_add_admin_action_logs.sql
+-- 管理后台操作审计：记录每次管理端变更操作（账号/分组/用户/规则等）的操作人、时间与变更前后快照，
+-- 多管理员部署下用于追溯责任。快照与请求体在写入前已脱敏（凭证/密钥类字段替换为 [REDACTED]）。
+
+CREATE TABLE IF NOT EXISTS admin_action_logs (
+    id              BIGSERIAL    PRIMARY KEY,
+    actor_user_id   BIGINT,
+    auth_method     VARCHAR(16)  NOT NULL DEFAULT '',
+    method          VARCHAR(8)   NOT NULL DEFAULT '',
+    path            VARCHAR(255) NOT NULL DEFAULT '',
+    route           VARCHAR(255) NOT NULL DEFAULT '',
+    resource_type   VARCHAR(64)  NOT NULL DEFAULT '',
+    resource_id     BIGINT,
+    status_code     INT          NOT NULL DEFAULT 0,
+    client_ip       VARCHAR(64)  NOT NULL DEFAULT '',
+    user_agent      VARCHAR(512) NOT NULL DEFAULT '',
+    request_body    TEXT,
+    before_state    JSONB,
+    after_state     JSONB,
+    changes         JSONB,
+    created_at      TIMESTAMPTZ  NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX IF NOT EXISTS idx_admin_action_logs_created_at ON admin_action_logs (created_at);
+CREATE INDEX IF NOT EXISTS idx_admin_action_logs_actor_created ON admin_action_logs (actor_user_id, created_at);
+CREATE INDEX IF NOT EXISTS idx_admin_action_logs_resource ON admin_action_logs (resource_type, resource_id, created_at);
+
+COMMENT ON TABLE admin_action_logs IS '管理后台变更操作审计（操作人、变更前后快照与字段差异）';
+COMMENT ON COLUMN admin_action_logs.route IS '匹配的路由模板，如 PUT /api/v1/admin/accounts/:id';
+COMMENT ON COLUMN admin_action_logs.changes IS '顶层字段差异 [{field, before, after}]';
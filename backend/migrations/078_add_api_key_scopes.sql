@@ -0,0 +1,7 @@
+-- 078_add_api_key_scopes.sql
+-- API Key 访问范围：限制单个 Key 可调用的端点（responses/chat/claude/gemini/embeddings）与功能（streaming/tools/images）
+
+ALTER TABLE api_keys
+ADD COLUMN IF NOT EXISTS scopes JSONB;
+
+COMMENT ON COLUMN api_keys.scopes IS '访问范围，如 ["chat","streaming"]；端点与功能分别限制，某类未列出任何项时不限制，为空表示不限制';
@@ -0,0 +1,7 @@
+-- 079_add_api_key_request_signing.sql
+-- API Key 请求签名：启用后请求须携带时间戳与 HMAC-SHA256 签名，防止截获的请求被重放
+
+ALTER TABLE api_keys
+ADD COLUMN IF NOT EXISTS request_signing JSONB;
+
+COMMENT ON COLUMN api_keys.request_signing IS '请求签名配置，如 {"secret":"sigsec_..."}；为空表示未启用';
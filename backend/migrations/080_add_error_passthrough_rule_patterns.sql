@@ -0,0 +1,9 @@
+-- 080_add_error_passthrough_rule_patterns.sql
+-- 错误透传规则：支持响应体正则匹配与响应头匹配
+
+ALTER TABLE error_passthrough_rules
+ADD COLUMN IF NOT EXISTS body_patterns JSONB,
+ADD COLUMN IF NOT EXISTS header_matches JSONB;
+
+COMMENT ON COLUMN error_passthrough_rules.body_patterns IS '匹配上游响应体的正则表达式列表（OR关系），与 keywords 一起构成响应体条件';
+COMMENT ON COLUMN error_passthrough_rules.header_matches IS '匹配上游响应头，{"头名": "正则"}，所有头都必须匹配';
@@ -0,0 +1,7 @@
+-- 081_add_group_retry_policy.sql
+-- 分组重试策略：上游失败时按状态码决定是否切换账号重试，重试间指数退避+抖动，并限制总时间预算
+
+ALTER TABLE groups
+ADD COLUMN IF NOT EXISTS retry_policy JSONB;
+
+COMMENT ON COLUMN groups.retry_policy IS '重试策略：{"retry_status_codes":[429,529],"max_attempts":3,"initial_backoff_ms":200,"max_backoff_ms":2000,"backoff_multiplier":2,"jitter":0.2,"total_budget_ms":30000}';
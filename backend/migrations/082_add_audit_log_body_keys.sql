@@ -0,0 +1,9 @@
+-- 082_add_audit_log_body_keys.sql
+-- 审计日志请求/响应体静态加密：每个用户使用独立的数据密钥，包装后的数据密钥与主密钥版本随记录保存。
+-- 轮换主密钥时只需重新包装 body_wrapped_key，request_body/response_body 密文保持不变。
+
+ALTER TABLE audit_logs ADD COLUMN IF NOT EXISTS body_key_id VARCHAR(64) NOT NULL DEFAULT '';
+ALTER TABLE audit_logs ADD COLUMN IF NOT EXISTS body_wrapped_key TEXT;
+
+COMMENT ON COLUMN audit_logs.body_key_id IS '包装数据密钥的主密钥版本（空表示请求/响应体为明文）';
+COMMENT ON COLUMN audit_logs.body_wrapped_key IS '主密钥包装后的数据密钥（base64）';
@@ -9,7 +9,8 @@ import { getLocale } from '@/i18n'
 
 // ==================== Axios Instance Configuration ====================
 
-const API_BASE_URL = import.meta.env.VITE_API_BASE_URL || '/api/v1'
+// Prefer the base path injected by the backend (frontend.api_base_path), then the build-time value
+export const API_BASE_URL = window.__API_BASE__ || import.meta.env.VITE_API_BASE_URL || '/api/v1'
 
 export const apiClient: AxiosInstance = axios.create({
   baseURL: API_BASE_URL,
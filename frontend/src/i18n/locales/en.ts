@@ -2845,7 +2845,8 @@ export default {
           accountRateLimitedCount: 'Rate-limited Accounts',
           accountErrorCount: 'Error Accounts (excluding temporarily unschedulable)',
           accountErrorRatio: 'Error Account Ratio (%)',
-          overloadAccountCount: 'Overloaded Accounts'
+          overloadAccountCount: 'Overloaded Accounts',
+          streamAbuseFlaggedKeys: 'Stream Abuse Flagged Keys'
         },
         metricDescriptions: {
           successRate: 'Percentage of successful requests in the window (0-100).',
@@ -2862,7 +2863,8 @@ export default {
           accountRateLimitedCount: 'Number of rate-limited accounts within the window.',
           accountErrorCount: 'Number of error accounts within the window (excluding temporarily unschedulable).',
           accountErrorRatio: 'Error account ratio within the window (0-100).',
-          overloadAccountCount: 'Number of overloaded accounts within the window.'
+          overloadAccountCount: 'Number of overloaded accounts within the window.',
+          streamAbuseFlaggedKeys: 'Number of API keys currently flagged for scraping-like streaming behavior (abandoning right after the first token or cancelling most streams). Requires gateway.stream_abuse.enabled.'
         },
         hints: {
           recommended: 'Recommended: operator {operator}, threshold {threshold}{unit}',
@@ -3018,7 +3018,8 @@ export default {
           accountRateLimitedCount: '限流账号数',
           accountErrorCount: '错误账号数（不含临时不可调度）',
           accountErrorRatio: '错误账号比例 (%)',
-          overloadAccountCount: '过载账号数'
+          overloadAccountCount: '过载账号数',
+          streamAbuseFlaggedKeys: '流式滥用标记 Key 数'
         },
         metricDescriptions: {
           successRate: '统计窗口内成功请求占比（0~100）。',
@@ -3035,7 +3036,8 @@ export default {
           accountRateLimitedCount: '统计窗口内被限流的账号数量。',
           accountErrorCount: '统计窗口内产生错误的账号数量（不含临时不可调度）。',
           accountErrorRatio: '统计窗口内错误账号占比（0~100）。',
-          overloadAccountCount: '统计窗口内过载账号数量。'
+          overloadAccountCount: '统计窗口内过载账号数量。',
+          streamAbuseFlaggedKeys: '当前被标记为疑似抓取的 API Key 数量（首 token 后立即断开或大部分流式请求被取消）。需启用 gateway.stream_abuse。'
         },
         hints: {
           recommended: '推荐：运算符 {operator}，阈值 {threshold}{unit}',
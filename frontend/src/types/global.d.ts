@@ -3,6 +3,7 @@ import type { PublicSettings } from '@/types'
 declare global {
   interface Window {
     __APP_CONFIG__?: PublicSettings
+    __API_BASE__?: string
   }
 }
 